@@ -0,0 +1,197 @@
+// Package authn authenticates API callers through pluggable providers.
+//
+// A provider turns the credentials on a request into an Identity. The backend then talks to
+// the Kubernetes API either with the caller's own token (Identity.Token) or as its service
+// account impersonating the caller, so RBAC is always evaluated for the caller. Providers:
+//
+//   - openshift: forwards the bearer token (or the OAuth proxy's X-Forwarded-Access-Token) to
+//     the API server, which validates it; identity headers come from the OAuth proxy. This is
+//     the default and also covers ServiceAccount access keys on any cluster.
+//   - oidc: validates ID tokens from an OpenID Connect issuer and impersonates the user, for
+//     vanilla Kubernetes whose API server does not trust the issuer.
+//   - static: a file of fixed tokens mapped to users and groups, for development and CI.
+//
+// AUTH_PROVIDERS lists the providers to try, in order.
+package authn
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrNoCredentials means the request carries no credentials a provider recognises; the next
+// provider in the chain is tried
+var ErrNoCredentials = errors.New("no credentials")
+
+// Identity is an authenticated caller
+type Identity struct {
+	// Provider is the name of the provider that authenticated the caller
+	Provider string
+	UserID   string
+	UserName string
+	Email    string
+	Groups   []string
+	// Token, when set, is the caller's own credential for the Kubernetes API. Without it the
+	// backend impersonates UserName and Groups.
+	Token string
+	// Verified is set by providers that checked the credentials themselves (a signature, a
+	// known token); forwarded tokens are only checked by the API server
+	Verified bool
+}
+
+// Provider authenticates requests. Authenticate returns ErrNoCredentials when the request
+// has nothing for this provider and any other error when its credentials are invalid.
+type Provider interface {
+	Name() string
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Chain tries providers in order; the first one that recognises the credentials decides
+type Chain struct {
+	providers []Provider
+}
+
+// NewChain returns a chain of the given providers
+func NewChain(providers ...Provider) *Chain {
+	return &Chain{providers: providers}
+}
+
+// Names lists the providers in order, for the startup log
+func (ch *Chain) Names() []string {
+	names := make([]string, 0, len(ch.providers))
+	for _, p := range ch.providers {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// Authenticate returns the caller's identity, ErrNoCredentials if no provider recognised the
+// request, or the error of the provider that rejected it
+func (ch *Chain) Authenticate(r *http.Request) (*Identity, error) {
+	for _, p := range ch.providers {
+		id, err := p.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return nil, &rejectedError{provider: p.Name(), err: err}
+		}
+		id.Provider = p.Name()
+		return id, nil
+	}
+	return nil, ErrNoCredentials
+}
+
+// rejectedError is the error of the provider that rejected the credentials
+type rejectedError struct {
+	provider string
+	err      error
+}
+
+func (e *rejectedError) Error() string { return fmt.Sprintf("%s: %v", e.provider, e.err) }
+func (e *rejectedError) Unwrap() error { return e.err }
+
+// active is the chain used by the middleware and Identify; set once at startup
+var active = NewChain(&OpenShift{})
+
+// SetChain replaces the chain used to authenticate requests
+func SetChain(ch *Chain) {
+	active = ch
+}
+
+// NewChainFromEnv builds the chain named by AUTH_PROVIDERS (comma-separated, default
+// "openshift"), configuring each provider from its environment variables
+func NewChainFromEnv() (*Chain, error) {
+	names := strings.TrimSpace(os.Getenv("AUTH_PROVIDERS"))
+	if names == "" {
+		names = "openshift"
+	}
+	var providers []Provider
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+			continue
+		case "openshift":
+			providers = append(providers, &OpenShift{})
+		case "oidc":
+			p, err := NewOIDCFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, p)
+		case "static":
+			p, err := NewStaticFromFile(os.Getenv("AUTH_STATIC_TOKENS_FILE"))
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, p)
+		default:
+			return nil, fmt.Errorf("unknown auth provider %q in AUTH_PROVIDERS (use openshift, oidc or static)", name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("AUTH_PROVIDERS names no provider")
+	}
+	return NewChain(providers...), nil
+}
+
+const identityKey = "authn.identity"
+
+// Identify returns the caller of the request, authenticating it on first use. Only a
+// successful result is remembered, so credentials added later in the request (such as the
+// websocket ?token= parameter) are still seen.
+func Identify(c *gin.Context) (*Identity, error) {
+	if v, ok := c.Get(identityKey); ok {
+		return v.(*Identity), nil
+	}
+	id, err := active.Authenticate(c.Request)
+	if err != nil {
+		var rejected *rejectedError
+		if errors.As(err, &rejected) {
+			RecordFailure(c, rejected.provider)
+		}
+		return nil, err
+	}
+	c.Set(identityKey, id)
+	if id.UserID != "" {
+		c.Set("userID", id.UserID)
+	}
+	if id.UserName != "" {
+		c.Set("userName", id.UserName)
+	}
+	if id.Email != "" {
+		c.Set("userEmail", id.Email)
+	}
+	if len(id.Groups) > 0 {
+		c.Set("userGroups", id.Groups)
+	}
+	return id, nil
+}
+
+// Middleware identifies the caller of every request and exposes userID, userName,
+// userEmail and userGroups in the Gin context. It only rejects credentials from a locked out
+// IP or identity (see Lockout): public routes have no caller, and project routes require one
+// in their own middleware.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectLockedOut(c) {
+			return
+		}
+		_, _ = Identify(c)
+		c.Next()
+	}
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
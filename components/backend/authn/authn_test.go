@@ -0,0 +1,177 @@
+package authn
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testIssuer serves an OIDC discovery document and key set for one RSA key
+func testIssuer(t *testing.T) (*httptest.Server, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": srv.URL, "jwks_uri": srv.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	return srv, key
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/projects/p/agentic-sessions", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+// TestOIDC verifies signature, issuer, audience and expiry checks and the claim mapping
+func TestOIDC(t *testing.T) {
+	srv, key := testIssuer(t)
+	p := &OIDC{IssuerURL: srv.URL, ClientID: "ambient", GroupsPrefix: "oidc:"}
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": srv.URL, "aud": "ambient", "sub": "123", "email": "ada@example.com", "email_verified": true,
+			"groups": []string{"eng"}, "exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	id, err := p.Authenticate(bearerRequest(signToken(t, key, "k1", valid())))
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if id.UserName != "ada@example.com" || id.Token != "" || len(id.Groups) != 1 || id.Groups[0] != "oidc:eng" {
+		t.Errorf("unexpected identity %+v", id)
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	cases := []struct {
+		name    string
+		token   string
+		noCreds bool
+	}{
+		{"no token", "", true},
+		{"not a JWT", "sha256~opaque-openshift-token", true},
+		{"other issuer", signToken(t, key, "k1", jwt.MapClaims{"iss": "https://kubernetes.default.svc", "aud": "ambient"}), true},
+		{"wrong audience", signToken(t, key, "k1", func() jwt.MapClaims { c := valid(); c["aud"] = "other"; return c }()), false},
+		{"expired", signToken(t, key, "k1", func() jwt.MapClaims { c := valid(); c["exp"] = time.Now().Add(-time.Hour).Unix(); return c }()), false},
+		{"unverified email", signToken(t, key, "k1", func() jwt.MapClaims { c := valid(); c["email_verified"] = false; return c }()), false},
+		{"wrong key", signToken(t, otherKey, "k1", valid()), false},
+		{"unknown key id", signToken(t, key, "k2", valid()), false},
+	}
+	for _, tc := range cases {
+		_, err := p.Authenticate(bearerRequest(tc.token))
+		if tc.noCreds && err != ErrNoCredentials {
+			t.Errorf("%s: expected ErrNoCredentials, got %v", tc.name, err)
+		}
+		if !tc.noCreds && (err == nil || err == ErrNoCredentials) {
+			t.Errorf("%s: expected the token to be rejected, got %v", tc.name, err)
+		}
+	}
+
+	p.ForwardToken = true
+	raw := signToken(t, key, "k1", valid())
+	if id, err := p.Authenticate(bearerRequest(raw)); err != nil || id.Token != raw {
+		t.Errorf("expected the token to be forwarded, got %+v, %v", id, err)
+	}
+}
+
+// TestStatic verifies token lines map to users and groups and unknown tokens fall through
+func TestStatic(t *testing.T) {
+	p, err := NewStatic(strings.NewReader("# dev users\nt0ken,alice,u-1,\"devs,admins\"\nother,bob\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := p.Authenticate(bearerRequest("t0ken"))
+	if err != nil || id.UserName != "alice" || id.UserID != "u-1" || strings.Join(id.Groups, ",") != "devs,admins" {
+		t.Errorf("unexpected identity %+v, %v", id, err)
+	}
+	if _, err := p.Authenticate(bearerRequest("nope")); err != ErrNoCredentials {
+		t.Errorf("expected an unknown token to fall through, got %v", err)
+	}
+	if _, err := NewStatic(strings.NewReader("lonely-token\n")); err == nil {
+		t.Error("expected a line without a user to be rejected")
+	}
+}
+
+// TestMiddleware verifies the chain order and the identity exposed to handlers
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, key := testIssuer(t)
+	static, _ := NewStatic(strings.NewReader("t0ken,alice,,devs\n"))
+	SetChain(NewChain(static, &OIDC{IssuerURL: srv.URL, ClientID: "ambient"}, &OpenShift{}))
+	t.Cleanup(func() { SetChain(NewChain(&OpenShift{})) })
+
+	oidcToken := signToken(t, key, "k1", jwt.MapClaims{"iss": srv.URL, "aud": "ambient", "email": "ada@example.com", "exp": time.Now().Add(time.Hour).Unix()})
+	badOIDCToken := signToken(t, key, "k1", jwt.MapClaims{"iss": srv.URL, "aud": "other", "email": "ada@example.com", "exp": time.Now().Add(time.Hour).Unix()})
+
+	cases := []struct {
+		name     string
+		headers  map[string]string
+		provider string
+		user     string
+	}{
+		{"static token", map[string]string{"Authorization": "Bearer t0ken"}, "static", "alice"},
+		{"oidc token", map[string]string{"Authorization": "Bearer " + oidcToken}, "oidc", "ada@example.com"},
+		{"oauth proxy", map[string]string{"X-Forwarded-Access-Token": "sha256~x", "X-Forwarded-User": "grace", "X-Forwarded-Preferred-Username": "grace.h"}, "openshift", "grace.h"},
+		{"rejected oidc token", map[string]string{"Authorization": "Bearer " + badOIDCToken}, "", ""},
+		{"anonymous", nil, "", ""},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Use(Middleware())
+		var provider, user string
+		r.GET("/", func(c *gin.Context) {
+			if id, err := Identify(c); err == nil {
+				provider = id.Provider
+			}
+			user = c.GetString("userName")
+			c.Status(http.StatusNoContent)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s: middleware must not reject requests, got %d", tc.name, w.Code)
+		}
+		if provider != tc.provider || user != tc.user {
+			t.Errorf("%s: got provider %q user %q, want %q %q", tc.name, provider, user, tc.provider, tc.user)
+		}
+	}
+}
@@ -0,0 +1,502 @@
+package authn
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-backend/messages"
+	"ambient-code-pkg/runtimeconfig"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Lockout scopes, the scope label of the auth metrics
+const (
+	ScopeIdentity = "identity"
+	ScopeIP       = "ip"
+)
+
+// maxTrackedKeys bounds the memory spent on callers; keys without recent failures are
+// dropped first
+const maxTrackedKeys = 10000
+
+// Failed authentications by provider (or "kubernetes" when the API server rejected a
+// forwarded token), lockouts started and requests refused while locked out, by scope
+var (
+	authFailures      = expvar.NewMap("auth_failures")
+	authLockouts      = expvar.NewMap("auth_lockouts")
+	authLockedRejects = expvar.NewMap("auth_locked_requests")
+)
+
+// LockoutEvent is passed to the alert hooks when a caller is locked out
+type LockoutEvent struct {
+	// Scope is ScopeIdentity or ScopeIP; Key is the identity or the IP address
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+	// IP is the address an identity lockout applies to
+	IP       string    `json:"ip,omitempty"`
+	Failures int       `json:"failures"`
+	Until    time.Time `json:"until"`
+}
+
+// Lockout tracks failed authentications per client IP and per claimed identity from that IP,
+// and locks out those with too many failures within Window for Duration. The identity is the
+// one the credentials claim before they are verified (the OAuth proxy's user or a JWT's
+// subject). Since anyone can claim it, identity failures only count against the IP they came
+// from: guessing tokens for one account from one address hits the lower identity limit, and
+// nobody can lock an account out of other addresses. A locked identity is refused without
+// checking its credentials; on a locked IP, credentials that verify still pass, but each
+// claimed identity gets only MaxIdentityFailures checks per Window (see rejectLockedOut).
+// A zero limit disables its scope. The runtime config's backend.authLockout overrides the
+// limits without a restart.
+type Lockout struct {
+	MaxIdentityFailures int
+	MaxIPFailures       int
+	Window              time.Duration
+	Duration            time.Duration
+
+	now   func() time.Time
+	mu    sync.Mutex
+	keys  map[string]*failures
+	hooks []func(LockoutEvent)
+	// verified holds digests of credentials that verified on a locked IP, until when they
+	// pass without another check
+	verified map[string]time.Time
+}
+
+type failures struct {
+	times       []time.Time
+	lockedUntil time.Time
+	// checks are the credential checks made for the key while its IP was locked out
+	checks []time.Time
+}
+
+// NewLockout returns a tracker with the given limits
+func NewLockout(maxIdentityFailures, maxIPFailures int, window, duration time.Duration) *Lockout {
+	return &Lockout{
+		MaxIdentityFailures: maxIdentityFailures,
+		MaxIPFailures:       maxIPFailures,
+		Window:              window,
+		Duration:            duration,
+		now:                 time.Now,
+		keys:                map[string]*failures{},
+		verified:            map[string]time.Time{},
+	}
+}
+
+// NewLockoutFromEnv configures the tracker from AUTH_LOCKOUT_IDENTITY_FAILURES (default 10),
+// AUTH_LOCKOUT_IP_FAILURES (default 50), AUTH_LOCKOUT_WINDOW and AUTH_LOCKOUT_DURATION (Go
+// durations, default 15m). Lockouts are logged, and posted as JSON to
+// AUTH_LOCKOUT_WEBHOOK_URL when it is set.
+func NewLockoutFromEnv() *Lockout {
+	l := NewLockout(
+		envInt("AUTH_LOCKOUT_IDENTITY_FAILURES", 10),
+		envInt("AUTH_LOCKOUT_IP_FAILURES", 50),
+		envDuration("AUTH_LOCKOUT_WINDOW", 15*time.Minute),
+		envDuration("AUTH_LOCKOUT_DURATION", 15*time.Minute),
+	)
+	l.OnLockout(func(e LockoutEvent) {
+		log.Printf("Auth lockout: %s %s after %d failed attempts, until %s", e.Scope, e.Key, e.Failures, e.Until.Format(time.RFC3339))
+	})
+	if url := strings.TrimSpace(os.Getenv("AUTH_LOCKOUT_WEBHOOK_URL")); url != "" {
+		l.OnLockout(WebhookAlert(url))
+	}
+	return l
+}
+
+// OnLockout adds a hook called, outside the tracker's lock, whenever a caller is locked out
+func (l *Lockout) OnLockout(hook func(LockoutEvent)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// Locked reports whether the IP or the identity is locked out, and until when
+func (l *Lockout) Locked(ip, identity string) (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var until time.Time
+	for _, key := range lockoutKeys(ip, identity) {
+		if f := l.keys[key]; f != nil && now.Before(f.lockedUntil) && f.lockedUntil.After(until) {
+			until = f.lockedUntil
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// identityLocked reports whether the identity is locked out on the IP
+func (l *Lockout) identityLocked(ip, identity string) bool {
+	if identity == "" {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f := l.keys[ScopeIdentity+":"+identity+"@"+ip]
+	return f != nil && l.now().Before(f.lockedUntil)
+}
+
+// allowCheck reports whether credentials claiming identity on a locked IP may be checked,
+// and counts the check: each identity (or the IP, for credentials claiming none) gets as
+// many per window as the identity failure limit allows
+func (l *Lockout) allowCheck(ip, identity string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	maxIdentity, _, window, _ := l.limits()
+	if maxIdentity <= 0 {
+		maxIdentity = 1
+	}
+	if len(l.keys) >= maxTrackedKeys {
+		l.prune(now, window)
+	}
+	key := lockoutKeys(ip, identity)[0]
+	f := l.keys[key]
+	if f == nil {
+		f = &failures{}
+		l.keys[key] = f
+	}
+	f.checks = recent(f.checks, now.Add(-window))
+	if len(f.checks) >= maxIdentity {
+		return false
+	}
+	f.checks = append(f.checks, now)
+	return true
+}
+
+// recentlyVerified reports whether credentials with the digest verified on a locked IP
+// within the lockout duration
+func (l *Lockout) recentlyVerified(digest string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.now().Before(l.verified[digest])
+}
+
+// rememberVerified lets credentials with the digest pass a locked IP without another check
+// for the lockout duration
+func (l *Lockout) rememberVerified(digest string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	_, _, _, duration := l.limits()
+	if len(l.verified) >= maxTrackedKeys {
+		for d, until := range l.verified {
+			if !now.Before(until) {
+				delete(l.verified, d)
+			}
+		}
+		if len(l.verified) >= maxTrackedKeys {
+			l.verified = map[string]time.Time{}
+		}
+	}
+	l.verified[digest] = now.Add(duration)
+}
+
+// Failure records a failed authentication and starts the lockouts it brings over a limit
+func (l *Lockout) Failure(ip, identity string) {
+	l.mu.Lock()
+	now := l.now()
+	maxIdentity, maxIP, window, duration := l.limits()
+	if len(l.keys) >= maxTrackedKeys {
+		l.prune(now, window)
+	}
+	var events []LockoutEvent
+	for _, key := range lockoutKeys(ip, identity) {
+		scope, value, _ := strings.Cut(key, ":")
+		var onIP string
+		if scope == ScopeIdentity && ip != "" {
+			value, onIP = identity, ip
+		}
+		limit := maxIdentity
+		if scope == ScopeIP {
+			limit = maxIP
+		}
+		if limit <= 0 {
+			continue
+		}
+		f := l.keys[key]
+		if f == nil {
+			f = &failures{}
+			l.keys[key] = f
+		}
+		f.times = append(recent(f.times, now.Add(-window)), now)
+		if len(f.times) >= limit && !now.Before(f.lockedUntil) {
+			f.lockedUntil = now.Add(duration)
+			events = append(events, LockoutEvent{Scope: scope, Key: value, IP: onIP, Failures: len(f.times), Until: f.lockedUntil})
+			f.times = nil
+		}
+	}
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	for _, e := range events {
+		authLockouts.Add(e.Scope, 1)
+		for _, hook := range hooks {
+			hook(e)
+		}
+	}
+}
+
+// limits are the tracker's, with the runtime config's overrides applied
+func (l *Lockout) limits() (maxIdentity, maxIP int, window, duration time.Duration) {
+	maxIdentity, maxIP, window, duration = l.MaxIdentityFailures, l.MaxIPFailures, l.Window, l.Duration
+	b := runtimeconfig.Current().Backend
+	if b == nil || b.AuthLockout == nil {
+		return
+	}
+	o := b.AuthLockout
+	if o.IdentityFailures != nil {
+		maxIdentity = *o.IdentityFailures
+	}
+	if o.IPFailures != nil {
+		maxIP = *o.IPFailures
+	}
+	if o.Window != nil {
+		window = o.Window.Duration
+	}
+	if o.Duration != nil {
+		duration = o.Duration.Duration
+	}
+	return
+}
+
+// prune drops the keys that are neither locked nor failed or checked within the window
+func (l *Lockout) prune(now time.Time, window time.Duration) {
+	for key, f := range l.keys {
+		f.times = recent(f.times, now.Add(-window))
+		f.checks = recent(f.checks, now.Add(-window))
+		if len(f.times) == 0 && len(f.checks) == 0 && !now.Before(f.lockedUntil) {
+			delete(l.keys, key)
+		}
+	}
+}
+
+func recent(times []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(since) {
+		i++
+	}
+	return times[i:]
+}
+
+// lockoutKeys are the tracked keys of a request: the claimed identity on the IP, and the IP
+func lockoutKeys(ip, identity string) []string {
+	var keys []string
+	if identity != "" {
+		keys = append(keys, ScopeIdentity+":"+identity+"@"+ip)
+	}
+	if ip != "" {
+		keys = append(keys, ScopeIP+":"+ip)
+	}
+	return keys
+}
+
+// WebhookAlert returns a hook that posts each lockout as JSON to url, in the background
+func WebhookAlert(url string) func(LockoutEvent) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	return func(e LockoutEvent) {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		go func() {
+			resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Printf("Auth lockout alert to %s failed: %v", url, err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				log.Printf("Auth lockout alert to %s failed: %s", url, resp.Status)
+			}
+		}()
+	}
+}
+
+// lockout is the tracker used by the middleware; nil disables lockouts
+var lockout *Lockout
+
+// SetLockout replaces the tracker of failed authentications; nil disables it
+func SetLockout(l *Lockout) {
+	lockout = l
+}
+
+const failureRecordedKey = "authn.failureRecorded"
+
+// RecordFailure counts a rejected credential once per request, against the caller's IP and
+// claimed identity. source is the provider, or "kubernetes" when the API server rejected a
+// forwarded token.
+func RecordFailure(c *gin.Context, source string) {
+	FailureRecorder(c, source)()
+}
+
+// FailureRecorder returns a func that does RecordFailure later, without the request's
+// context: for calls that may finish after the handler returned, such as the API server
+// rejecting the token of a user-scoped client. Failures of one request still count once.
+func FailureRecorder(c *gin.Context, source string) func() {
+	var once *sync.Once
+	if v, ok := c.Get(failureRecordedKey); ok {
+		once = v.(*sync.Once)
+	} else {
+		once = &sync.Once{}
+		c.Set(failureRecordedKey, once)
+	}
+	ip, identity := c.ClientIP(), claimedIdentity(c.Request)
+	return func() {
+		once.Do(func() {
+			authFailures.Add(source, 1)
+			if lockout != nil {
+				lockout.Failure(ip, identity)
+			}
+		})
+	}
+}
+
+// rejectLockedOut refuses requests with credentials from a locked out identity or IP. A
+// locked identity is refused without checking its credentials, which would tell a guesser
+// when they are right. On a locked IP, credentials that verify pass, so everyone behind a
+// shared address is not kept out; the checks are limited per claimed identity (allowCheck).
+func rejectLockedOut(c *gin.Context) bool {
+	if lockout == nil || !hasCredentials(c.Request) {
+		return false
+	}
+	ip, identity := c.ClientIP(), claimedIdentity(c.Request)
+	until, locked := lockout.Locked(ip, identity)
+	if !locked {
+		return false
+	}
+	scope := ScopeIdentity
+	if !lockout.identityLocked(ip, identity) {
+		scope = ScopeIP
+		if verifiedCredentials(c, ip, identity) {
+			return false
+		}
+	}
+	authLockedRejects.Add(scope, 1)
+
+	locale := messages.Negotiate(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", locale)
+	c.Header("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error": messages.Render(locale, "auth.lockedOut", map[string]interface{}{"Until": until.UTC().Format(time.RFC3339)}),
+		"code":  "auth.lockedOut",
+	})
+	return true
+}
+
+// TokenVerifier reports whether the API server accepts a forwarded token (a TokenReview);
+// nil treats forwarded tokens as unverified
+type TokenVerifier func(ctx context.Context, token string) (bool, error)
+
+var tokenVerifier TokenVerifier
+
+// SetTokenVerifier sets how forwarded tokens of locked out callers are checked
+func SetTokenVerifier(v TokenVerifier) {
+	tokenVerifier = v
+}
+
+// verifiedCredentials reports whether the credentials of a request from a locked IP check
+// out, counting the check against the claimed identity: a provider that verifies them itself
+// (oidc, static) accepted them, or the API server accepts the token the provider forwards.
+// Credentials that verified pass again without a check until the lockout duration is over.
+func verifiedCredentials(c *gin.Context, ip, identity string) bool {
+	digest := credentialDigest(c.Request)
+	if lockout.recentlyVerified(digest) {
+		return true
+	}
+	if !lockout.allowCheck(ip, identity) {
+		return false
+	}
+	if !checkCredentials(c) {
+		return false
+	}
+	lockout.rememberVerified(digest)
+	return true
+}
+
+// checkCredentials verifies the request's credentials
+func checkCredentials(c *gin.Context) bool {
+	id, err := Identify(c)
+	if err != nil {
+		return false
+	}
+	if id.Verified {
+		return true
+	}
+	if id.Token == "" || tokenVerifier == nil {
+		return false
+	}
+	ok, err := tokenVerifier(c.Request.Context(), id.Token)
+	if err != nil {
+		log.Printf("Failed to verify the token of a locked out caller: %v", err)
+		return false
+	}
+	if !ok {
+		RecordFailure(c, "kubernetes")
+	}
+	return ok
+}
+
+// credentialDigest identifies the request's credentials without keeping them
+func credentialDigest(r *http.Request) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		strings.TrimSpace(r.Header.Get("Authorization")),
+		strings.TrimSpace(r.Header.Get("X-Forwarded-Access-Token")),
+		strings.TrimSpace(r.URL.Query().Get("token")),
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// hasCredentials reports whether the request carries a token of any provider
+func hasCredentials(r *http.Request) bool {
+	return strings.TrimSpace(r.Header.Get("Authorization")) != "" ||
+		strings.TrimSpace(r.Header.Get("X-Forwarded-Access-Token")) != "" ||
+		strings.TrimSpace(r.URL.Query().Get("token")) != ""
+}
+
+// claimedIdentity is who the request claims to be before its credentials are verified: the
+// OAuth proxy's user, or the subject of a JWT bearer token
+func claimedIdentity(r *http.Request) string {
+	if user := strings.TrimSpace(r.Header.Get("X-Forwarded-User")); user != "" {
+		return user
+	}
+	token := bearerToken(r)
+	if token == "" {
+		token = strings.TrimSpace(r.URL.Query().Get("token"))
+	}
+	if strings.Count(token, ".") != 2 {
+		return ""
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(name))); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(strings.TrimSpace(os.Getenv(name))); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
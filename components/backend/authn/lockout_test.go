@@ -0,0 +1,202 @@
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ambient-code-pkg/runtimeconfig"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestLockout verifies identities and IPs are locked out at their own limits, an identity
+// only on the address its failures came from, only failures within the window count, and
+// the lockout ends after its duration
+func TestLockout(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLockout(3, 5, 10*time.Minute, 15*time.Minute)
+	l.now = func() time.Time { return now }
+	var events []LockoutEvent
+	l.OnLockout(func(e LockoutEvent) { events = append(events, e) })
+
+	// Guesses claiming one account from many addresses lock nobody out
+	for i := 0; i < 3; i++ {
+		l.Failure(fmt.Sprintf("10.0.0.%d", i), "ada")
+	}
+	if _, locked := l.Locked("10.9.9.9", "ada"); locked {
+		t.Error("ada locked out of an address that never failed")
+	}
+
+	// Guesses for one account from one address
+	for i := 0; i < 3; i++ {
+		if _, locked := l.Locked("10.1.1.1", "ada"); locked {
+			t.Fatalf("ada locked after %d failures", i)
+		}
+		l.Failure("10.1.1.1", "ada")
+	}
+	if until, locked := l.Locked("10.1.1.1", "ada"); !locked || !until.Equal(now.Add(15*time.Minute)) {
+		t.Errorf("ada not locked out: %v %v", until, locked)
+	}
+	if _, locked := l.Locked("10.9.9.9", "ada"); locked {
+		t.Error("ada locked out of other addresses")
+	}
+	if len(events) != 1 || events[0].Scope != ScopeIdentity || events[0].Key != "ada" || events[0].IP != "10.1.1.1" || events[0].Failures != 3 {
+		t.Errorf("events = %+v", events)
+	}
+	if _, locked := l.Locked("10.1.1.1", "grace"); locked {
+		t.Error("another identity on a failing IP locked before the IP limit")
+	}
+
+	// Anonymous guesses from one address, spread past the window
+	for i := 0; i < 4; i++ {
+		l.Failure("192.0.2.1", "")
+	}
+	now = now.Add(11 * time.Minute)
+	l.Failure("192.0.2.1", "")
+	if _, locked := l.Locked("192.0.2.1", ""); locked {
+		t.Error("failures outside the window counted")
+	}
+	for i := 0; i < 4; i++ {
+		l.Failure("192.0.2.1", "")
+	}
+	if _, locked := l.Locked("192.0.2.1", ""); !locked {
+		t.Error("IP not locked out at its limit")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if _, locked := l.Locked("10.1.1.1", "ada"); locked {
+		t.Error("lockout did not expire")
+	}
+}
+
+// TestLockoutRuntimeConfig verifies the runtime config's limits replace the tracker's
+func TestLockoutRuntimeConfig(t *testing.T) {
+	one := 1
+	runtimeconfig.SetCurrent(&runtimeconfig.Settings{Backend: &runtimeconfig.BackendSettings{
+		AuthLockout: &runtimeconfig.AuthLockoutSettings{IdentityFailures: &one},
+	}})
+	t.Cleanup(func() { runtimeconfig.SetCurrent(nil) })
+	l := NewLockout(10, 0, time.Minute, time.Minute)
+
+	l.Failure("10.0.0.1", "ada")
+	if _, locked := l.Locked("10.0.0.1", "ada"); !locked {
+		t.Error("runtime identity limit not applied")
+	}
+}
+
+// TestMiddlewareLockout verifies rejected tokens lock the claimed identity out with a 429,
+// that even its valid credentials are refused on that IP without being checked, and that
+// requests without credentials and the identity on other addresses still pass
+func TestMiddlewareLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, key := testIssuer(t)
+	SetChain(NewChain(&OIDC{IssuerURL: srv.URL, ClientID: "ambient"}))
+	SetLockout(NewLockout(2, 0, time.Minute, time.Minute))
+	t.Cleanup(func() {
+		SetChain(NewChain(&OpenShift{}))
+		SetLockout(nil)
+	})
+	bad := signToken(t, key, "k1", jwt.MapClaims{"iss": srv.URL, "aud": "other", "sub": "ada", "exp": time.Now().Add(time.Hour).Unix()})
+
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/projects/p/agentic-sessions", func(c *gin.Context) {
+		// A handler asking again must not count the request twice
+		_, _ = Identify(c)
+		c.Status(http.StatusNoContent)
+	})
+	serve := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, bearerRequest(token))
+		return w
+	}
+	serveFrom := func(token, remoteAddr string) int {
+		req := bearerRequest(token)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if w := serve(bad); w.Code != http.StatusNoContent {
+		t.Fatalf("first failure: %d", w.Code)
+	}
+	if w := serve(bad); w.Code != http.StatusNoContent {
+		t.Fatalf("second failure: %d", w.Code)
+	}
+	w := serve(bad)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("locked out request: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["code"] != "auth.lockedOut" {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	if w := serve(""); w.Code != http.StatusNoContent {
+		t.Errorf("request without credentials: %d", w.Code)
+	}
+	good := signToken(t, key, "k1", jwt.MapClaims{"iss": srv.URL, "aud": "ambient", "sub": "ada", "email": "ada@example.com", "exp": time.Now().Add(time.Hour).Unix()})
+	if w := serve(good); w.Code != http.StatusTooManyRequests {
+		t.Errorf("valid token of the locked out identity checked on its locked IP: %d", w.Code)
+	}
+	if code := serveFrom(good, "198.51.100.9:1234"); code != http.StatusNoContent {
+		t.Errorf("valid token of the locked out identity refused on another IP: %d", code)
+	}
+}
+
+// TestMiddlewareLockoutForwardedToken verifies a forwarded token from a locked out IP passes
+// only when the API server accepts it, and that the TokenReviews are limited per claimed
+// identity while accepted tokens are not reviewed again
+func TestMiddlewareLockoutForwardedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := NewLockout(2, 1, time.Minute, time.Minute)
+	SetLockout(l)
+	reviews := 0
+	SetTokenVerifier(func(_ context.Context, token string) (bool, error) {
+		reviews++
+		return token == "good", nil
+	})
+	t.Cleanup(func() {
+		SetLockout(nil)
+		SetTokenVerifier(nil)
+	})
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/projects/p/agentic-sessions", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	serve := func(token string) int {
+		req := bearerRequest(token)
+		req.RemoteAddr = "192.0.2.7:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	l.Failure("192.0.2.7", "")
+	if code := serve("good"); code != http.StatusNoContent {
+		t.Errorf("token the API server accepts refused: %d", code)
+	}
+	if code := serve("guess"); code != http.StatusTooManyRequests {
+		t.Errorf("unverified token from a locked out IP: %d", code)
+	}
+	// Tokens claiming no identity share the IP's two reviews per window
+	if reviews != 2 {
+		t.Fatalf("reviews = %d, want 2", reviews)
+	}
+	for i := 0; i < 3; i++ {
+		if code := serve(fmt.Sprintf("guess-%d", i)); code != http.StatusTooManyRequests {
+			t.Errorf("guess %d: %d", i, code)
+		}
+	}
+	if code := serve("good"); code != http.StatusNoContent {
+		t.Errorf("accepted token refused once the reviews ran out: %d", code)
+	}
+	if reviews != 2 {
+		t.Errorf("reviews = %d after the limit, want 2", reviews)
+	}
+}
@@ -0,0 +1,278 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval limits how often an unknown key id triggers a refetch of the issuer's keys
+const jwksRefreshInterval = time.Minute
+
+// OIDC authenticates ID tokens from an OpenID Connect issuer. The token's signature is
+// checked against the issuer's published keys, along with its issuer, audience and expiry.
+// The backend then impersonates the user unless ForwardToken is set, for API servers that
+// are configured with the same issuer (--oidc-issuer-url) and accept the token themselves.
+type OIDC struct {
+	IssuerURL string
+	ClientID  string
+	// UsernameClaim names the user (default "email"); GroupsClaim holds the groups (default "groups")
+	UsernameClaim  string
+	GroupsClaim    string
+	UsernamePrefix string
+	GroupsPrefix   string
+	ForwardToken   bool
+	HTTPClient     *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewOIDCFromEnv configures the provider from OIDC_ISSUER_URL, OIDC_CLIENT_ID,
+// OIDC_USERNAME_CLAIM, OIDC_GROUPS_CLAIM, OIDC_USERNAME_PREFIX, OIDC_GROUPS_PREFIX and
+// OIDC_FORWARD_TOKEN. The issuer's keys are fetched on first use.
+func NewOIDCFromEnv() (*OIDC, error) {
+	p := &OIDC{
+		IssuerURL:      strings.TrimSpace(os.Getenv("OIDC_ISSUER_URL")),
+		ClientID:       strings.TrimSpace(os.Getenv("OIDC_CLIENT_ID")),
+		UsernameClaim:  strings.TrimSpace(os.Getenv("OIDC_USERNAME_CLAIM")),
+		GroupsClaim:    strings.TrimSpace(os.Getenv("OIDC_GROUPS_CLAIM")),
+		UsernamePrefix: os.Getenv("OIDC_USERNAME_PREFIX"),
+		GroupsPrefix:   os.Getenv("OIDC_GROUPS_PREFIX"),
+		ForwardToken:   strings.EqualFold(strings.TrimSpace(os.Getenv("OIDC_FORWARD_TOKEN")), "true"),
+	}
+	if p.IssuerURL == "" || p.ClientID == "" {
+		return nil, errors.New("oidc auth provider needs OIDC_ISSUER_URL and OIDC_CLIENT_ID")
+	}
+	if !strings.HasPrefix(p.IssuerURL, "https://") {
+		return nil, fmt.Errorf("OIDC_ISSUER_URL must be an https URL, got %q", p.IssuerURL)
+	}
+	return p, nil
+}
+
+// Name implements Provider
+func (p *OIDC) Name() string {
+	return "oidc"
+}
+
+// Authenticate implements Provider. Tokens that are not JWTs from this issuer (API keys,
+// ServiceAccount tokens) are left to the next provider.
+func (p *OIDC) Authenticate(r *http.Request) (*Identity, error) {
+	raw := bearerToken(r)
+	if raw == "" {
+		return nil, ErrNoCredentials
+	}
+	unverified := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, unverified); err != nil {
+		return nil, ErrNoCredentials
+	}
+	if iss, _ := unverified["iss"].(string); iss != p.IssuerURL {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, p.keyFor,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.IssuerURL),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	claim := p.UsernameClaim
+	if claim == "" {
+		claim = "email"
+	}
+	name, _ := claims[claim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("ID token has no %q claim", claim)
+	}
+	// Like the API server, only trust an email the issuer has verified
+	if claim == "email" {
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return nil, errors.New("ID token email is not verified")
+		}
+	}
+	id := &Identity{UserName: p.UsernamePrefix + name, Verified: true}
+	id.UserID = id.UserName
+	if email, _ := claims["email"].(string); email != "" {
+		id.Email = email
+	}
+	groupsClaim := p.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+	switch groups := claims[groupsClaim].(type) {
+	case string:
+		id.Groups = []string{p.GroupsPrefix + groups}
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok && s != "" {
+				id.Groups = append(id.Groups, p.GroupsPrefix+s)
+			}
+		}
+	}
+	if p.ForwardToken {
+		id.Token = raw
+	}
+	return id, nil
+}
+
+// keyFor returns the issuer key that signed the token, refetching the key set when the
+// key id is unknown (the issuer rotated its keys)
+func (p *OIDC) keyFor(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.fetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	keys, err := p.fetchKeys()
+	p.fetchedAt = time.Now()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the issuer's keys: %w", err)
+	}
+	p.keys = keys
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by id; a token without a kid matches the only key of a one-key set
+func (p *OIDC) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *OIDC) httpClient() *http.Client {
+	if p.HTTPClient != nil {
+		return p.HTTPClient
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func (p *OIDC) getJSON(url string, out interface{}) error {
+	resp, err := p.httpClient().Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys reads the issuer's discovery document and its JSON Web Key Set
+func (p *OIDC) fetchKeys() (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := p.getJSON(strings.TrimSuffix(p.IssuerURL, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != p.IssuerURL {
+		return nil, fmt.Errorf("discovery document is for issuer %q, not %q", discovery.Issuer, p.IssuerURL)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Skip key types we do not verify with; the token names the key it needs
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
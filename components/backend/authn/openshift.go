@@ -0,0 +1,46 @@
+package authn
+
+import (
+	"net/http"
+	"strings"
+)
+
+// OpenShift forwards the caller's token to the Kubernetes API, which validates it. Behind the
+// OpenShift OAuth proxy the token arrives in X-Forwarded-Access-Token and the user in the
+// X-Forwarded-* headers; API clients send their token as a bearer token. Only use this
+// provider where the proxy strips those headers from client requests.
+type OpenShift struct{}
+
+// Name implements Provider
+func (p *OpenShift) Name() string {
+	return "openshift"
+}
+
+// Authenticate implements Provider. The Authorization header is preferred over the proxy's
+// forwarded token; a header without the Bearer scheme is used as the token itself.
+func (p *OpenShift) Authenticate(r *http.Request) (*Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		token = strings.TrimSpace(r.Header.Get("Authorization"))
+	}
+	if token == "" {
+		token = strings.TrimSpace(r.Header.Get("X-Forwarded-Access-Token"))
+	}
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	id := &Identity{
+		UserID: r.Header.Get("X-Forwarded-User"),
+		Email:  r.Header.Get("X-Forwarded-Email"),
+		Token:  token,
+	}
+	// Prefer the preferred username; fall back to the user id
+	id.UserName = r.Header.Get("X-Forwarded-Preferred-Username")
+	if id.UserName == "" {
+		id.UserName = id.UserID
+	}
+	if v := r.Header.Get("X-Forwarded-Groups"); v != "" {
+		id.Groups = strings.Split(v, ",")
+	}
+	return id, nil
+}
@@ -0,0 +1,96 @@
+package authn
+
+import (
+	"crypto/subtle"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Static authenticates fixed bearer tokens listed in a file, in the Kubernetes static token
+// file format: one "token,user,uid,\"group1,group2\"" line per token (uid and groups are
+// optional). The backend impersonates the user. Meant for development clusters and CI.
+type Static struct {
+	tokens []staticToken
+}
+
+type staticToken struct {
+	token string
+	id    Identity
+}
+
+// NewStaticFromFile loads a static token file
+func NewStaticFromFile(path string) (*Static, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("static auth provider needs AUTH_STATIC_TOKENS_FILE")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("static auth provider: %w", err)
+	}
+	defer f.Close()
+	return NewStatic(f)
+}
+
+// NewStatic parses static token lines
+func NewStatic(r io.Reader) (*Static, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	p := &Static{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("static auth provider: %w", err)
+		}
+		if len(record) < 2 || strings.TrimSpace(record[0]) == "" || strings.TrimSpace(record[1]) == "" {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("static auth provider: line %d needs at least a token and a user", line)
+		}
+		t := staticToken{token: strings.TrimSpace(record[0])}
+		t.id.UserName = strings.TrimSpace(record[1])
+		t.id.UserID = t.id.UserName
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			t.id.UserID = strings.TrimSpace(record[2])
+		}
+		if len(record) > 3 {
+			for _, g := range strings.Split(record[3], ",") {
+				if g = strings.TrimSpace(g); g != "" {
+					t.id.Groups = append(t.id.Groups, g)
+				}
+			}
+		}
+		p.tokens = append(p.tokens, t)
+	}
+	return p, nil
+}
+
+// Name implements Provider
+func (p *Static) Name() string {
+	return "static"
+}
+
+// Authenticate implements Provider. Tokens not in the file are left to the next provider.
+func (p *Static) Authenticate(r *http.Request) (*Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	for _, t := range p.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.token)) == 1 {
+			id := t.id
+			id.Groups = append([]string(nil), t.id.Groups...)
+			id.Verified = true
+			return &id, nil
+		}
+	}
+	return nil, ErrNoCredentials
+}
@@ -0,0 +1,200 @@
+// Package breaker guards outbound calls to external services (the GitHub API, token mints)
+// with circuit breakers, so an outage fails requests fast instead of making each one wait for
+// its timeout.
+//
+// A circuit opens after a run of consecutive failures and rejects calls with ErrOpen for a
+// cooldown. The first call after the cooldown is let through as a probe: success closes the
+// circuit, failure opens it again. Circuits are process-wide and kept in memory; they start
+// closed when the backend restarts.
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State of a circuit
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half-open"
+)
+
+const (
+	// DefaultFailureThreshold consecutive failures open a circuit
+	DefaultFailureThreshold = 5
+	// DefaultCooldown is how long an open circuit rejects calls before probing
+	DefaultCooldown = 30 * time.Second
+)
+
+// ErrOpen is returned (wrapped) for calls rejected by an open circuit
+var ErrOpen = errors.New("circuit breaker open")
+
+// IsOpen reports whether err comes from a call rejected by an open circuit
+func IsOpen(err error) bool {
+	return errors.Is(err, ErrOpen)
+}
+
+// GitHub guards calls to the GitHub API, including installation token mints
+var GitHub = For("github")
+
+// Breaker is one circuit
+type Breaker struct {
+	name             string
+	failureThreshold int
+	cooldown         time.Duration
+	now              func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	lastError string
+}
+
+// Status is a snapshot of a circuit, as reported by the health endpoint
+type Status struct {
+	Name      string     `json:"name"`
+	State     State      `json:"state"`
+	Failures  int        `json:"failures"`
+	RetryAt   *time.Time `json:"retryAt,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Breaker{}
+)
+
+// For returns the process-wide circuit with the given name, creating it with the defaults
+func For(name string) *Breaker {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if b, ok := registry[name]; ok {
+		return b
+	}
+	b := New(name, DefaultFailureThreshold, DefaultCooldown)
+	registry[name] = b
+	return b
+}
+
+// Snapshot returns the state of every registered circuit, sorted by name
+func Snapshot() []Status {
+	registryMu.Lock()
+	breakers := make([]*Breaker, 0, len(registry))
+	for _, b := range registry {
+		breakers = append(breakers, b)
+	}
+	registryMu.Unlock()
+	out := make([]Status, 0, len(breakers))
+	for _, b := range breakers {
+		out = append(out, b.Status())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// New creates an unregistered circuit
+func New(name string, failureThreshold int, cooldown time.Duration) *Breaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &Breaker{name: name, failureThreshold: failureThreshold, cooldown: cooldown, now: time.Now, state: StateClosed}
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be followed by Record.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateOpen:
+		retryAt := b.openedAt.Add(b.cooldown)
+		if b.now().Before(retryAt) {
+			return fmt.Errorf("%s: %w (retry after %s)", b.name, ErrOpen, retryAt.Format(time.RFC3339))
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		// One probe at a time
+		if b.probing {
+			return fmt.Errorf("%s: %w (probe in progress)", b.name, ErrOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record reports the outcome of an allowed call; nil is a success
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.state = StateClosed
+		b.failures = 0
+		b.lastError = ""
+		return
+	}
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == StateHalfOpen || b.failures >= b.failureThreshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Do sends an HTTP request through the circuit. Transport errors, 5xx and 429 responses count
+// as failures; other responses are returned as is.
+func (b *Breaker) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if err := b.Allow(); err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	switch {
+	case err != nil:
+		// The caller giving up is not a sign the service is down
+		if req.Context().Err() != nil {
+			b.Record(nil)
+		} else {
+			b.Record(err)
+		}
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		b.Record(fmt.Errorf("%s %s: %s", req.Method, req.URL.Host, resp.Status))
+	default:
+		b.Record(nil)
+	}
+	return resp, err
+}
+
+// Status returns a snapshot of the circuit
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := Status{Name: b.name, State: b.state, Failures: b.failures, LastError: b.lastError}
+	if b.state == StateOpen {
+		retryAt := b.openedAt.Add(b.cooldown)
+		st.RetryAt = &retryAt
+	}
+	return st
+}
+
+// RetryAfter is how long until an open circuit lets a probe through; zero when it is not open
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != StateOpen {
+		return 0
+	}
+	if d := b.openedAt.Add(b.cooldown).Sub(b.now()); d > 0 {
+		return d
+	}
+	return 0
+}
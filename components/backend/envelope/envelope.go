@@ -0,0 +1,201 @@
+// Package envelope encrypts files at rest with envelope encryption: every file gets a fresh
+// AES-256 data key, the data is sealed with AES-GCM in fixed-size segments so files of any
+// size stream through, and the data key is stored wrapped by a key-encryption key (KEK) the
+// platform never writes to disk.
+//
+// Segment i is sealed with the nonce noncePrefix || uint64(i) and the additional data
+// {final}, where final is 1 for the last segment only, so segments cannot be reordered,
+// dropped or truncated without Open failing.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// Algorithm names the format in Header.Algorithm
+	Algorithm = "AES-256-GCM-SEGMENTED"
+	// KeySize is the size of KEKs and data keys
+	KeySize = 32
+	// DefaultSegmentSize is the plaintext size of every segment but the last
+	DefaultSegmentSize = 64 << 10
+
+	noncePrefixSize = 4
+)
+
+// Header describes a sealed file. It holds nothing secret and is stored next to the file.
+type Header struct {
+	Version     int    `json:"version"`
+	Algorithm   string `json:"algorithm"`
+	KeyRef      string `json:"keyRef"`
+	WrappedKey  string `json:"wrappedKey"`
+	NoncePrefix string `json:"noncePrefix"`
+	SegmentSize int    `json:"segmentSize"`
+}
+
+// ParseKEK accepts a 32-byte key given raw or base64-encoded, as stored in a Secret
+func ParseKEK(raw []byte) ([]byte, error) {
+	if len(raw) == KeySize {
+		return raw, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err == nil && len(decoded) == KeySize {
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("key-encryption key must be %d bytes, raw or base64-encoded", KeySize)
+}
+
+// Fingerprint identifies a KEK without revealing it, so key references survive rotation audits
+func Fingerprint(kek []byte) string {
+	sum := sha256.Sum256(kek)
+	return hex.EncodeToString(sum[:8])
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts src into dst under a new data key wrapped by kek
+func Seal(dst io.Writer, src io.Reader, kek []byte, keyRef string) (*Header, error) {
+	wrapper, err := newGCM(kek)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key-encryption key: %w", err)
+	}
+	dataKey := make([]byte, KeySize)
+	prefix := make([]byte, noncePrefixSize)
+	wrapNonce := make([]byte, wrapper.NonceSize())
+	for _, b := range [][]byte{dataKey, prefix, wrapNonce} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	header := &Header{
+		Version:     1,
+		Algorithm:   Algorithm,
+		KeyRef:      keyRef,
+		WrappedKey:  base64.StdEncoding.EncodeToString(wrapper.Seal(wrapNonce, wrapNonce, dataKey, nil)),
+		NoncePrefix: base64.StdEncoding.EncodeToString(prefix),
+		SegmentSize: DefaultSegmentSize,
+	}
+
+	// One segment of lookahead tells whether the current segment is the last
+	cur := make([]byte, DefaultSegmentSize)
+	next := make([]byte, DefaultSegmentSize)
+	n, err := readSegment(src, cur)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); ; i++ {
+		m := 0
+		if n == len(cur) {
+			if m, err = readSegment(src, next); err != nil {
+				return nil, err
+			}
+		}
+		final := m == 0
+		if _, err := dst.Write(aead.Seal(nil, segmentNonce(prefix, i), cur[:n], finalFlag(final))); err != nil {
+			return nil, err
+		}
+		if final {
+			return header, nil
+		}
+		cur, next, n = next, cur, m
+	}
+}
+
+// Open decrypts a file sealed by Seal into dst. It fails on any tampering or truncation;
+// dst may then have received a prefix of the plaintext.
+func Open(dst io.Writer, src io.Reader, kek []byte, header *Header) error {
+	if header.Version != 1 || header.Algorithm != Algorithm || header.SegmentSize <= 0 {
+		return fmt.Errorf("unsupported envelope %s version %d", header.Algorithm, header.Version)
+	}
+	wrapper, err := newGCM(kek)
+	if err != nil {
+		return fmt.Errorf("invalid key-encryption key: %w", err)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(header.WrappedKey)
+	if err != nil || len(wrapped) < wrapper.NonceSize() {
+		return errors.New("invalid wrapped data key")
+	}
+	dataKey, err := wrapper.Open(nil, wrapped[:wrapper.NonceSize()], wrapped[wrapper.NonceSize():], nil)
+	if err != nil {
+		return errors.New("data key cannot be unwrapped with this key-encryption key")
+	}
+	prefix, err := base64.StdEncoding.DecodeString(header.NoncePrefix)
+	if err != nil || len(prefix) != noncePrefixSize {
+		return errors.New("invalid nonce prefix")
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+
+	sealedSize := header.SegmentSize + aead.Overhead()
+	cur := make([]byte, sealedSize)
+	next := make([]byte, sealedSize)
+	n, err := readSegment(src, cur)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); ; i++ {
+		m := 0
+		if n == len(cur) {
+			if m, err = readSegment(src, next); err != nil {
+				return err
+			}
+		}
+		final := m == 0
+		plain, err := aead.Open(nil, segmentNonce(prefix, i), cur[:n], finalFlag(final))
+		if err != nil {
+			return fmt.Errorf("segment %d: %w", i, err)
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		cur, next, n = next, cur, m
+	}
+}
+
+// readSegment fills buf unless the input ends first
+func readSegment(r io.Reader, buf []byte) (int, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, nil
+	}
+	return n, err
+}
+
+func segmentNonce(prefix []byte, i uint64) []byte {
+	nonce := make([]byte, noncePrefixSize+8)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint64(nonce[noncePrefixSize:], i)
+	return nonce
+}
+
+func finalFlag(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
@@ -0,0 +1,81 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+func testKEK(t *testing.T) []byte {
+	t.Helper()
+	kek := make([]byte, KeySize)
+	if _, err := rand.Read(kek); err != nil {
+		t.Fatal(err)
+	}
+	return kek
+}
+
+// TestSealOpenRoundTrip covers empty input, a partial segment and exact segment multiples
+func TestSealOpenRoundTrip(t *testing.T) {
+	kek := testKEK(t)
+	for _, size := range []int{0, 1, DefaultSegmentSize - 1, DefaultSegmentSize, 3*DefaultSegmentSize + 17} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+		var sealed bytes.Buffer
+		header, err := Seal(&sealed, bytes.NewReader(plain), kek, "secret/kek/kek@"+Fingerprint(kek))
+		if err != nil {
+			t.Fatalf("size %d: Seal: %v", size, err)
+		}
+		if size > 0 && bytes.Contains(sealed.Bytes(), plain) {
+			t.Fatalf("size %d: plaintext found in sealed output", size)
+		}
+		var opened bytes.Buffer
+		if err := Open(&opened, bytes.NewReader(sealed.Bytes()), kek, header); err != nil {
+			t.Fatalf("size %d: Open: %v", size, err)
+		}
+		if !bytes.Equal(opened.Bytes(), plain) {
+			t.Fatalf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+// TestOpenRejectsTampering verifies a wrong key, flipped bits and truncation at a segment
+// boundary are all detected
+func TestOpenRejectsTampering(t *testing.T) {
+	kek := testKEK(t)
+	plain := make([]byte, 2*DefaultSegmentSize+5)
+	var sealed bytes.Buffer
+	header, err := Seal(&sealed, bytes.NewReader(plain), kek, "ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := sealed.Bytes()
+
+	if err := Open(&bytes.Buffer{}, bytes.NewReader(data), testKEK(t), header); err == nil {
+		t.Error("expected Open with another KEK to fail")
+	}
+	flipped := append([]byte(nil), data...)
+	flipped[10] ^= 1
+	if err := Open(&bytes.Buffer{}, bytes.NewReader(flipped), kek, header); err == nil {
+		t.Error("expected Open of modified data to fail")
+	}
+	// Dropping the last segment leaves a valid-looking prefix whose final flag is wrong
+	segment := DefaultSegmentSize + 16
+	if err := Open(&bytes.Buffer{}, bytes.NewReader(data[:2*segment]), kek, header); err == nil {
+		t.Error("expected Open of truncated data to fail")
+	}
+}
+
+func TestParseKEK(t *testing.T) {
+	kek := testKEK(t)
+	for _, raw := range [][]byte{kek, []byte(base64.StdEncoding.EncodeToString(kek) + "\n")} {
+		got, err := ParseKEK(raw)
+		if err != nil || !bytes.Equal(got, kek) {
+			t.Errorf("ParseKEK(%q) = %v, %v", raw, got, err)
+		}
+	}
+	if _, err := ParseKEK([]byte("too-short")); err == nil {
+		t.Error("expected a short key to be rejected")
+	}
+}
@@ -0,0 +1,374 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+)
+
+const (
+	// maxFindingsPerCheck keeps check results small enough for session status
+	maxFindingsPerCheck = 50
+	// maxScannedFileSize skips large untracked files (build output, datasets)
+	maxScannedFileSize = 1 << 20
+	// licenseHeaderLines is how far into a file the license header may start
+	licenseHeaderLines = 20
+	// emptyTree is git's well-known empty tree, the diff base for a repo without commits
+	emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+)
+
+// ChangedFile is one file of a workspace diff
+type ChangedFile struct {
+	Path    string
+	New     bool
+	Deleted bool
+	Binary  bool
+	// Added are the lines added by the diff; for a new file, the whole file
+	Added []AddedLine
+}
+
+// AddedLine is a line added by the diff, numbered as in the new file
+type AddedLine struct {
+	Number int
+	Text   string
+}
+
+// PublishCheck inspects a workspace diff before it is published. Checks only report
+// findings; whether failing checks block the publish is decided by the caller.
+type PublishCheck struct {
+	Name    string
+	Enabled func(policy apiv1alpha1.PublishChecks) bool
+	Run     func(policy apiv1alpha1.PublishChecks, files []ChangedFile) []apiv1alpha1.PublishCheckFinding
+}
+
+var publishChecks = []PublishCheck{
+	{
+		Name:    "secret-scan",
+		Enabled: func(p apiv1alpha1.PublishChecks) bool { return !p.DisableSecretScan },
+		Run:     scanSecrets,
+	},
+	{
+		Name:    "license-header",
+		Enabled: func(p apiv1alpha1.PublishChecks) bool { return strings.TrimSpace(p.LicenseHeader) != "" },
+		Run:     checkLicenseHeaders,
+	},
+	{
+		Name:    "forbidden-files",
+		Enabled: func(p apiv1alpha1.PublishChecks) bool { return len(p.ForbiddenPaths) > 0 },
+		Run:     checkForbiddenPaths,
+	},
+}
+
+// RegisterPublishCheck adds a check to the pipeline run by RunPublishChecks
+func RegisterPublishCheck(check PublishCheck) {
+	publishChecks = append(publishChecks, check)
+}
+
+// RunPublishChecks runs every enabled check on the diff
+func RunPublishChecks(policy apiv1alpha1.PublishChecks, files []ChangedFile) []apiv1alpha1.PublishCheckResult {
+	results := []apiv1alpha1.PublishCheckResult{}
+	for _, check := range publishChecks {
+		if check.Enabled != nil && !check.Enabled(policy) {
+			continue
+		}
+		findings := check.Run(policy, files)
+		if len(findings) > maxFindingsPerCheck {
+			more := len(findings) - maxFindingsPerCheck
+			findings = append(findings[:maxFindingsPerCheck], apiv1alpha1.PublishCheckFinding{
+				Message: fmt.Sprintf("%d more findings not shown", more),
+			})
+		}
+		results = append(results, apiv1alpha1.PublishCheckResult{
+			Name:     check.Name,
+			Passed:   len(findings) == 0,
+			Findings: findings,
+		})
+	}
+	return results
+}
+
+// PublishChecksPassed reports whether every result passed
+func PublishChecksPassed(results []apiv1alpha1.PublishCheckResult) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+var secretPatterns = []struct {
+	re   *regexp.Regexp
+	desc string
+}{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`), "private key"},
+	{regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), "AWS access key ID"},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), "GitHub token"},
+	{regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}`), "GitHub fine-grained token"},
+	{regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`), "Anthropic API key"},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), "Slack token"},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), "Google API key"},
+	{regexp.MustCompile(`(?i)\b(password|passwd|secret|api[_-]?key|access[_-]?token)\b["']?\s*[:=]\s*["'][^"'\s]{12,}["']`), "hard-coded credential"},
+}
+
+// scanSecrets reports added lines that look like credentials. Findings name the kind of
+// secret only, so the value never reaches session status.
+func scanSecrets(_ apiv1alpha1.PublishChecks, files []ChangedFile) []apiv1alpha1.PublishCheckFinding {
+	var findings []apiv1alpha1.PublishCheckFinding
+	for _, f := range files {
+		for _, line := range f.Added {
+			for _, p := range secretPatterns {
+				if p.re.MatchString(line.Text) {
+					findings = append(findings, apiv1alpha1.PublishCheckFinding{
+						Path:    f.Path,
+						Line:    line.Number,
+						Message: "Possible " + p.desc,
+					})
+					break
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// SecretMatch is a credential found by FindSecrets; Start and End are byte offsets
+type SecretMatch struct {
+	Start, End int
+	Kind       string
+}
+
+// FindSecrets returns the credentials in text that the secret scan would report, in order
+// of appearance. Overlapping matches are reported once.
+func FindSecrets(text string) []SecretMatch {
+	var matches []SecretMatch
+	for _, p := range secretPatterns {
+		for _, loc := range p.re.FindAllStringIndex(text, -1) {
+			matches = append(matches, SecretMatch{Start: loc[0], End: loc[1], Kind: p.desc})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	out := matches[:0]
+	for _, m := range matches {
+		if len(out) > 0 && m.Start < out[len(out)-1].End {
+			if m.End > out[len(out)-1].End {
+				out[len(out)-1].End = m.End
+			}
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// checkLicenseHeaders reports new files whose first lines lack the first line of the header
+func checkLicenseHeaders(policy apiv1alpha1.PublishChecks, files []ChangedFile) []apiv1alpha1.PublishCheckFinding {
+	header := ""
+	for _, l := range strings.Split(policy.LicenseHeader, "\n") {
+		if header = strings.TrimSpace(l); header != "" {
+			break
+		}
+	}
+	var findings []apiv1alpha1.PublishCheckFinding
+	for _, f := range files {
+		if !f.New || f.Deleted || f.Binary || !hasExtension(f.Path, policy.LicenseHeaderExtensions) {
+			continue
+		}
+		found := false
+		for _, line := range f.Added {
+			if line.Number > licenseHeaderLines {
+				break
+			}
+			if strings.Contains(line.Text, header) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			findings = append(findings, apiv1alpha1.PublishCheckFinding{Path: f.Path, Message: "Missing license header"})
+		}
+	}
+	return findings
+}
+
+func hasExtension(p string, extensions []string) bool {
+	if len(extensions) == 0 {
+		return true
+	}
+	ext := path.Ext(p)
+	for _, e := range extensions {
+		e = strings.TrimSpace(e)
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkForbiddenPaths reports added or modified files matching a forbidden pattern. Patterns
+// without a slash match the file name in any directory.
+func checkForbiddenPaths(policy apiv1alpha1.PublishChecks, files []ChangedFile) []apiv1alpha1.PublishCheckFinding {
+	var findings []apiv1alpha1.PublishCheckFinding
+	for _, f := range files {
+		if f.Deleted {
+			continue
+		}
+		for _, pattern := range policy.ForbiddenPaths {
+			pattern = strings.TrimSpace(pattern)
+			target := f.Path
+			if !strings.Contains(pattern, "/") {
+				target = path.Base(f.Path)
+			}
+			if ok, err := path.Match(pattern, target); err == nil && ok {
+				findings = append(findings, apiv1alpha1.PublishCheckFinding{
+					Path:    f.Path,
+					Message: fmt.Sprintf("File matches forbidden pattern %q", pattern),
+				})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// WorkspaceChanges returns what publishing repoDir would add to its upstream: commits not yet
+// on the upstream branch plus uncommitted and untracked (non-ignored) files.
+func WorkspaceChanges(ctx context.Context, repoDir string) ([]ChangedFile, error) {
+	if fi, err := os.Stat(repoDir); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("repo directory not found: %s", repoDir)
+	}
+	run := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "core.quotePath=false"}, args...)...)
+		cmd.Dir = repoDir
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.String(), nil
+	}
+
+	base := emptyTree
+	if _, err := run("rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		base = "HEAD"
+		if mb, err := run("merge-base", "HEAD", "@{upstream}"); err == nil && strings.TrimSpace(mb) != "" {
+			base = strings.TrimSpace(mb)
+		}
+	}
+
+	diff, err := run("diff", "--no-color", "--no-ext-diff", "--unified=0", base)
+	if err != nil {
+		return nil, err
+	}
+	files := parseUnifiedDiff(diff)
+
+	untracked, err := run("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(strings.TrimSpace(untracked), "\n") {
+		if p == "" {
+			continue
+		}
+		files = append(files, readUntracked(repoDir, p))
+	}
+	return files, nil
+}
+
+// parseUnifiedDiff extracts changed files and added lines from `git diff --unified=0`
+func parseUnifiedDiff(diff string) []ChangedFile {
+	var files []ChangedFile
+	var cur *ChangedFile
+	inHunk := false
+	next := 0
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, ChangedFile{})
+			cur = &files[len(files)-1]
+			inHunk = false
+		case cur == nil:
+			continue
+		case !inHunk && strings.HasPrefix(line, "new file mode"):
+			cur.New = true
+		case !inHunk && strings.HasPrefix(line, "deleted file mode"):
+			cur.Deleted = true
+		case !inHunk && strings.HasPrefix(line, "Binary files "):
+			cur.Binary = true
+			if cur.Path == "" {
+				cur.Path = binaryDiffPath(line)
+			}
+		case !inHunk && strings.HasPrefix(line, "--- a/"):
+			cur.Path = strings.TrimPrefix(line, "--- a/")
+		case !inHunk && strings.HasPrefix(line, "+++ b/"):
+			cur.Path = strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+			next = hunkStart(line)
+		case inHunk && strings.HasPrefix(line, "+"):
+			cur.Added = append(cur.Added, AddedLine{Number: next, Text: line[1:]})
+			next++
+		}
+	}
+	return files
+}
+
+// hunkStart returns the first new-file line number of a "@@ -a,b +c,d @@" header
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.SplitN(strings.TrimPrefix(fields[2], "+"), ",", 2)[0])
+	return n
+}
+
+// binaryDiffPath extracts the path from "Binary files a/x and b/x differ"
+func binaryDiffPath(line string) string {
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "Binary files "), " differ")
+	if i := strings.LastIndex(line, " and "); i >= 0 {
+		if p := line[i+len(" and "):]; p != "/dev/null" {
+			return strings.TrimPrefix(p, "b/")
+		}
+		return strings.TrimPrefix(line[:i], "a/")
+	}
+	return line
+}
+
+// readUntracked loads an untracked file as a new file; large and binary files are only named
+func readUntracked(repoDir, p string) ChangedFile {
+	f := ChangedFile{Path: p, New: true}
+	full := filepath.Join(repoDir, filepath.FromSlash(p))
+	fi, err := os.Stat(full)
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() > maxScannedFileSize {
+		f.Binary = true
+		return f
+	}
+	data, err := os.ReadFile(full)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		f.Binary = true
+		return f
+	}
+	for i, text := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		f.Added = append(f.Added, AddedLine{Number: i + 1, Text: text})
+	}
+	return f
+}
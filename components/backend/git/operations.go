@@ -18,6 +18,9 @@ import (
 	"strings"
 	"time"
 
+	"ambient-code-backend/breaker"
+	"ambient-code-pkg/gitutil"
+
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -167,22 +170,14 @@ func CheckRepoSeeding(ctx context.Context, repoURL string, branch *string, githu
 
 // ParseGitHubURL extracts owner and repo from a GitHub URL
 func ParseGitHubURL(gitURL string) (owner, repo string, err error) {
-	gitURL = strings.TrimSuffix(gitURL, ".git")
-
-	if strings.Contains(gitURL, "github.com") {
-		parts := strings.Split(gitURL, "github.com")
-		if len(parts) != 2 {
-			return "", "", fmt.Errorf("invalid GitHub URL")
-		}
-		path := strings.Trim(parts[1], "/:")
-		pathParts := strings.Split(path, "/")
-		if len(pathParts) < 2 {
-			return "", "", fmt.Errorf("invalid GitHub URL path")
-		}
-		return pathParts[0], pathParts[1], nil
+	r, err := gitutil.Parse(gitURL)
+	if err != nil {
+		return "", "", err
 	}
-
-	return "", "", fmt.Errorf("not a GitHub URL")
+	if r.Provider != gitutil.ProviderGitHub {
+		return "", "", fmt.Errorf("not a GitHub URL")
+	}
+	return r.Owner, r.Name, nil
 }
 
 // IsProtectedBranch checks if a branch name is a protected branch
@@ -224,7 +219,7 @@ func checkGitHubPathExists(ctx context.Context, owner, repo, branch, path, token
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := breaker.GitHub.Do(http.DefaultClient, req)
 	if err != nil {
 		return false, err
 	}
@@ -280,7 +275,7 @@ func PerformRepoSeeding(ctx context.Context, wf Workflow, branchName, githubToke
 
 	// Clone umbrella repo with authentication
 	log.Printf("Cloning umbrella repo: %s", umbrellaRepo.GetURL())
-	authenticatedURL, err := InjectGitHubToken(umbrellaRepo.GetURL(), githubToken)
+	authenticatedURL, err := InjectToken(umbrellaRepo.GetURL(), githubToken)
 	if err != nil {
 		return false, fmt.Errorf("failed to prepare spec repo URL: %w", err)
 	}
@@ -592,8 +587,9 @@ func PerformRepoSeeding(ctx context.Context, wf Workflow, branchName, githubToke
 	return branchExistsRemotely, nil
 }
 
-// InjectGitHubToken injects a GitHub token into a git URL for authentication
-func InjectGitHubToken(gitURL, token string) (string, error) {
+// InjectToken adds a token to an https git URL in the format the URL's provider expects
+// (see gitutil.TokenUserinfo); other URLs are returned unchanged
+func InjectToken(gitURL, token string) (string, error) {
 	u, err := url.Parse(gitURL)
 	if err != nil {
 		return "", fmt.Errorf("invalid git URL: %w", err)
@@ -603,39 +599,25 @@ func InjectGitHubToken(gitURL, token string) (string, error) {
 		return gitURL, nil
 	}
 
-	u.User = url.UserPassword("x-access-token", token)
+	provider := gitutil.ProviderUnknown
+	if r, err := gitutil.Parse(gitURL); err == nil {
+		provider = r.Provider
+	}
+	u.User = gitutil.TokenUserinfo(provider, token)
 	return u.String(), nil
 }
 
 // DeriveRepoFolderFromURL extracts the repo folder from a Git URL
 func DeriveRepoFolderFromURL(u string) string {
-	s := strings.TrimSpace(u)
-	if s == "" {
-		return ""
+	if r, err := gitutil.Parse(u); err == nil {
+		return r.Name
 	}
-
-	if strings.HasPrefix(s, "git@") && strings.Contains(s, ":") {
-		parts := strings.SplitN(s, ":", 2)
-		host := strings.TrimPrefix(parts[0], "git@")
-		s = "https://" + host + "/" + parts[1]
-	}
-
-	if i := strings.Index(s, "://"); i >= 0 {
-		s = s[i+3:]
-	}
-
-	if i := strings.Index(s, "/"); i >= 0 {
+	// Not a recognisable repository URL: fall back to the last path segment
+	s := strings.TrimRight(strings.TrimSpace(u), "/")
+	if i := strings.LastIndexAny(s, "/:"); i >= 0 {
 		s = s[i+1:]
 	}
-
-	segs := strings.Split(s, "/")
-	if len(segs) == 0 {
-		return ""
-	}
-
-	last := segs[len(segs)-1]
-	last = strings.TrimSuffix(last, ".git")
-	return strings.TrimSpace(last)
+	return strings.TrimSuffix(s, ".git")
 }
 
 // PushRepo performs git add/commit/push operations on a repository directory
@@ -670,7 +652,7 @@ func PushRepo(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch
 		req, _ := http.NewRequest("GET", "https://api.github.com/user", nil)
 		req.Header.Set("Authorization", "token "+githubToken)
 		req.Header.Set("Accept", "application/vnd.github+json")
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := breaker.GitHub.Do(http.DefaultClient, req)
 		if err == nil {
 			defer resp.Body.Close()
 			switch resp.StatusCode {
@@ -890,7 +872,7 @@ func ReadGitHubFile(ctx context.Context, owner, repo, branch, path, token string
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/vnd.github.v3.raw")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := breaker.GitHub.Do(http.DefaultClient, req)
 	if err != nil {
 		return nil, err
 	}
@@ -922,7 +904,7 @@ func CheckBranchExists(ctx context.Context, repoURL, branchName, githubToken str
 	req.Header.Set("Authorization", "Bearer "+githubToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := breaker.GitHub.Do(http.DefaultClient, req)
 	if err != nil {
 		return false, err
 	}
@@ -954,7 +936,7 @@ func createBranchInRepo(ctx context.Context, repo GitRepo, branchName, githubTok
 	}
 	defer os.RemoveAll(repoDir)
 
-	authenticatedURL, err := InjectGitHubToken(repoURL, githubToken)
+	authenticatedURL, err := InjectToken(repoURL, githubToken)
 	if err != nil {
 		return fmt.Errorf("failed to prepare repo URL: %w", err)
 	}
@@ -0,0 +1,96 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+// BranchPolicy mirrors ProjectSettings spec.branchProtection
+type BranchPolicy struct {
+	// AllowedTargetBranches are glob patterns (e.g. "sessions/*"); empty allows any branch
+	AllowedTargetBranches []string `json:"allowedTargetBranches,omitempty"`
+	// RequirePR forbids pushing directly to protected branches (main, master, develop)
+	RequirePR bool `json:"requirePR,omitempty"`
+	// ForbidForcePush is enforced by the runner's pre-push hook
+	ForbidForcePush bool `json:"forbidForcePush,omitempty"`
+}
+
+// GetBranchPolicy reads the branch protection policy from the project's ProjectSettings.
+// A missing ProjectSettings object yields an empty (permissive) policy.
+func GetBranchPolicy(ctx context.Context, dynClient dynamic.Interface, project string) (BranchPolicy, error) {
+	policy := BranchPolicy{}
+	if dynClient == nil || GetProjectSettingsResource == nil {
+		return policy, fmt.Errorf("project settings client not initialized")
+	}
+	obj, err := dynClient.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return policy, nil
+		}
+		return policy, fmt.Errorf("failed to read project settings: %w", err)
+	}
+	if allowed, found, _ := unstructured.NestedStringSlice(obj.Object, "spec", "branchProtection", "allowedTargetBranches"); found {
+		policy.AllowedTargetBranches = allowed
+	}
+	if v, found, _ := unstructured.NestedBool(obj.Object, "spec", "branchProtection", "requirePR"); found {
+		policy.RequirePR = v
+	}
+	if v, found, _ := unstructured.NestedBool(obj.Object, "spec", "branchProtection", "forbidForcePush"); found {
+		policy.ForbidForcePush = v
+	}
+	return policy, nil
+}
+
+// CheckTargetBranch returns an error if pushing to branch violates the policy
+func (p BranchPolicy) CheckTargetBranch(branch string) error {
+	normalized := strings.TrimSpace(branch)
+	if normalized == "" {
+		return fmt.Errorf("target branch cannot be empty")
+	}
+	if p.RequirePR && IsProtectedBranch(normalized) {
+		return fmt.Errorf("project policy requires a pull request; direct pushes to '%s' are not allowed", normalized)
+	}
+	if len(p.AllowedTargetBranches) == 0 {
+		return nil
+	}
+	for _, pattern := range p.AllowedTargetBranches {
+		if ok, err := path.Match(strings.TrimSpace(pattern), normalized); err == nil && ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("branch '%s' is not in the project's allowed target branches", normalized)
+}
+
+// GetPublishCheckPolicy reads spec.publishChecks from the project's ProjectSettings. A missing
+// ProjectSettings object or field yields the defaults (secret scanning only).
+func GetPublishCheckPolicy(ctx context.Context, dynClient dynamic.Interface, project string) (apiv1alpha1.PublishChecks, error) {
+	policy := apiv1alpha1.PublishChecks{}
+	if dynClient == nil || GetProjectSettingsResource == nil {
+		return policy, fmt.Errorf("project settings client not initialized")
+	}
+	obj, err := dynClient.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return policy, nil
+		}
+		return policy, fmt.Errorf("failed to read project settings: %w", err)
+	}
+	raw, found, _ := unstructured.NestedMap(obj.Object, "spec", "publishChecks")
+	if !found {
+		return policy, nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &policy); err != nil {
+		return policy, fmt.Errorf("invalid spec.publishChecks: %w", err)
+	}
+	return policy, nil
+}
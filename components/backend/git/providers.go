@@ -0,0 +1,97 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ambient-code-backend/breaker"
+	"ambient-code-pkg/gitutil"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// integrationSecretName holds the project's provider tokens (GITHUB_TOKEN, GITEA_TOKEN, ...)
+const integrationSecretName = "ambient-non-vertex-integrations"
+
+// GetRepoToken returns the token for repo: the GitHub App or GITHUB_TOKEN for GitHub, and
+// the provider's key of the project integration secret (see gitutil.TokenEnv) otherwise
+func GetRepoToken(ctx context.Context, k8sClient *kubernetes.Clientset, dynClient dynamic.Interface, project, userID string, repo *gitutil.Repo) (string, error) {
+	if repo.Provider == gitutil.ProviderGitHub {
+		return GetGitHubToken(ctx, k8sClient, dynClient, project, userID)
+	}
+	key := gitutil.TokenEnv(repo.Provider)
+	if k8sClient == nil {
+		return "", fmt.Errorf("no credentials for %s: k8s client is nil", repo.Host)
+	}
+	secret, err := k8sClient.CoreV1().Secrets(project).Get(ctx, integrationSecretName, v1.GetOptions{})
+	if err != nil || len(secret.Data[key]) == 0 {
+		return "", fmt.Errorf("no credentials for %s: configure %s in integration secrets", repo.Host, key)
+	}
+	return strings.TrimSpace(string(secret.Data[key])), nil
+}
+
+// ReadRepoFile reads the content of a file at ref through the repository provider's API
+func ReadRepoFile(ctx context.Context, repo *gitutil.Repo, ref, path, token string) ([]byte, error) {
+	if repo.Provider == gitutil.ProviderGitHub && repo.Host == "github.com" {
+		return ReadGitHubFile(ctx, repo.Owner, repo.Name, ref, path, token)
+	}
+	apiURL, err := rawFileURL(repo, ref, path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", gitutil.AuthorizationHeader(repo.Provider, token))
+	}
+	if repo.Provider == gitutil.ProviderGitHub {
+		req.Header.Set("Accept", "application/vnd.github.v3.raw")
+	}
+
+	resp, err := breaker.For(string(repo.Provider)).Do(http.DefaultClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s API error: %s (body: %s)", repo.Provider, resp.Status, string(body))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// rawFileURL is the API URL that returns the raw content of path at ref
+func rawFileURL(repo *gitutil.Repo, ref, path string) (string, error) {
+	api := repo.APIBase()
+	file := escapePath(path)
+	switch repo.Provider {
+	case gitutil.ProviderGitHub:
+		return fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", api, repo.Owner, repo.Name, file, url.QueryEscape(ref)), nil
+	case gitutil.ProviderGitLab:
+		return fmt.Sprintf("%s/projects/%s/repository/files/%s/raw?ref=%s", api, url.PathEscape(repo.FullName()), url.PathEscape(path), url.QueryEscape(ref)), nil
+	case gitutil.ProviderGitea:
+		return fmt.Sprintf("%s/repos/%s/%s/raw/%s?ref=%s", api, repo.Owner, repo.Name, file, url.QueryEscape(ref)), nil
+	case gitutil.ProviderBitbucket:
+		return fmt.Sprintf("%s/repositories/%s/%s/src/%s/%s", api, repo.Owner, repo.Name, url.PathEscape(ref), file), nil
+	case gitutil.ProviderBitbucketServer:
+		return fmt.Sprintf("%s/projects/%s/repos/%s/raw/%s?at=%s", api, repo.Owner, repo.Name, file, url.QueryEscape(ref)), nil
+	}
+	return "", fmt.Errorf("cannot read files from %s: unknown Git provider", repo.Host)
+}
+
+// escapePath escapes each segment of a slash-separated path
+func escapePath(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
@@ -30,8 +30,9 @@ func GetInstallation(ctx context.Context, userID string) (*handlers.GitHubAppIns
 }
 
 // MintSessionToken creates a GitHub access token for a session
-// Returns the token and expiry time to be injected as a Kubernetes Secret
-func MintSessionToken(ctx context.Context, userID string) (string, time.Time, error) {
+// Returns the token and expiry time to be injected as a Kubernetes Secret. With repos the
+// token only works for those repositories (see MintRepositoryToken).
+func MintSessionToken(ctx context.Context, userID string, repos []string) (string, time.Time, error) {
 	if Manager == nil {
 		return "", time.Time{}, fmt.Errorf("GitHub App not configured")
 	}
@@ -43,6 +44,13 @@ func MintSessionToken(ctx context.Context, userID string) (string, time.Time, er
 	}
 
 	// Mint short-lived token for the installation's host
+	if len(repos) > 0 {
+		token, expiresAt, err := Manager.MintRepositoryToken(ctx, installation.InstallationID, installation.Host, repos)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to mint repository token: %w", err)
+		}
+		return token, expiresAt, nil
+	}
 	token, expiresAt, err := Manager.MintInstallationTokenForHost(ctx, installation.InstallationID, installation.Host)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to mint installation token: %w", err)
@@ -12,10 +12,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"ambient-code-backend/breaker"
+	"ambient-code-pkg/gitutil"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -24,7 +28,8 @@ type TokenManager struct {
 	AppID      string
 	PrivateKey *rsa.PrivateKey
 	cacheMu    *sync.Mutex
-	cache      map[int64]cachedInstallationToken
+	// cache is keyed by installation, host and repository scope
+	cache map[string]cachedInstallationToken
 }
 
 type cachedInstallationToken struct {
@@ -63,7 +68,7 @@ func NewTokenManager() (*TokenManager, error) {
 		AppID:      appID,
 		PrivateKey: privateKey,
 		cacheMu:    &sync.Mutex{},
-		cache:      map[int64]cachedInstallationToken{},
+		cache:      map[string]cachedInstallationToken{},
 	}, nil
 }
 
@@ -114,12 +119,49 @@ func (m *TokenManager) MintInstallationToken(ctx context.Context, installationID
 
 // MintInstallationTokenForHost mints an installation token against the specified GitHub API host
 func (m *TokenManager) MintInstallationTokenForHost(ctx context.Context, installationID int64, host string) (string, time.Time, error) {
+	return m.mintToken(ctx, installationID, host, nil)
+}
+
+// RepositoryTokenPermissions are the permissions of repository-scoped tokens: enough to clone,
+// push branches and open pull requests, nothing on the organization
+var RepositoryTokenPermissions = map[string]string{
+	"contents":      "write",
+	"pull_requests": "write",
+	"metadata":      "read",
+}
+
+// MintRepositoryToken mints an installation token that only works for the named repositories
+// ("owner/name" or bare names of repositories the installation can access) and carries
+// RepositoryTokenPermissions. GitHub rejects the request when a repository is not part of
+// the installation.
+func (m *TokenManager) MintRepositoryToken(ctx context.Context, installationID int64, host string, repos []string) (string, time.Time, error) {
+	if len(repos) == 0 {
+		return "", time.Time{}, fmt.Errorf("no repositories to scope the token to")
+	}
+	return m.mintToken(ctx, installationID, host, repos)
+}
+
+// mintToken requests an installation access token, scoped to repos when given
+func (m *TokenManager) mintToken(ctx context.Context, installationID int64, host string, repos []string) (string, time.Time, error) {
 	if m == nil {
 		return "", time.Time{}, fmt.Errorf("GitHub App not configured")
 	}
+	names := make([]string, 0, len(repos))
+	for _, r := range repos {
+		name := r
+		if i := strings.LastIndex(r, "/"); i >= 0 {
+			name = r[i+1:]
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	cacheKey := fmt.Sprintf("%d|%s|%s", installationID, host, strings.Join(names, ","))
+
 	// Serve from cache if still valid (>3 minutes left)
 	m.cacheMu.Lock()
-	if entry, ok := m.cache[installationID]; ok {
+	if entry, ok := m.cache[cacheKey]; ok {
 		if time.Until(entry.expiresAt) > 3*time.Minute {
 			token := entry.token
 			exp := entry.expiresAt
@@ -134,10 +176,15 @@ func (m *TokenManager) MintInstallationTokenForHost(ctx context.Context, install
 		return "", time.Time{}, fmt.Errorf("failed to generate JWT: %w", err)
 	}
 
+	payload := map[string]interface{}{}
+	if len(names) > 0 {
+		payload["repositories"] = names
+		payload["permissions"] = RepositoryTokenPermissions
+	}
+	b, _ := json.Marshal(payload)
 	apiBase := APIBaseURL(host)
 	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", apiBase, installationID)
-	reqBody := bytes.NewBuffer([]byte("{}"))
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, reqBody)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -147,7 +194,7 @@ func (m *TokenManager) MintInstallationTokenForHost(ctx context.Context, install
 	req.Header.Set("User-Agent", "vTeam-Backend")
 
 	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := breaker.GitHub.Do(client, req)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to call GitHub: %w", err)
 	}
@@ -164,7 +211,7 @@ func (m *TokenManager) MintInstallationTokenForHost(ctx context.Context, install
 		return "", time.Time{}, fmt.Errorf("failed to parse token response: %w", err)
 	}
 	m.cacheMu.Lock()
-	m.cache[installationID] = cachedInstallationToken{token: parsed.Token, expiresAt: parsed.ExpiresAt}
+	m.cache[cacheKey] = cachedInstallationToken{token: parsed.Token, expiresAt: parsed.ExpiresAt}
 	m.cacheMu.Unlock()
 	return parsed.Token, parsed.ExpiresAt, nil
 }
@@ -182,14 +229,8 @@ func (m *TokenManager) ValidateInstallationAccess(ctx context.Context, installat
 
 	// repo should be in form "owner/repo"; tolerate full URL and trim
 	ownerRepo := repo
-	if strings.HasPrefix(ownerRepo, "http://") || strings.HasPrefix(ownerRepo, "https://") {
-		// Trim protocol and host
-		// Examples: https://github.com/owner/repo(.git)?
-		// Split by "/" and take last two segments
-		parts := strings.Split(strings.TrimSuffix(ownerRepo, ".git"), "/")
-		if len(parts) >= 2 {
-			ownerRepo = parts[len(parts)-2] + "/" + parts[len(parts)-1]
-		}
+	if r, err := gitutil.Parse(ownerRepo); err == nil {
+		ownerRepo = r.FullName()
 	}
 	parts := strings.Split(ownerRepo, "/")
 	if len(parts) != 2 {
@@ -210,7 +251,7 @@ func (m *TokenManager) ValidateInstallationAccess(ctx context.Context, installat
 	req.Header.Set("User-Agent", "vTeam-Backend")
 
 	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := breaker.GitHub.Do(client, req)
 	if err != nil {
 		return fmt.Errorf("GitHub request failed: %w", err)
 	}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"ambient-code-backend/messages"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// lastViewedAnnotation is the RFC3339 time a user last had the session open
+	lastViewedAnnotation = "ambient-code.io/last-viewed"
+	// abandonedNoticeAnnotation is the RFC3339 time the abandonment notice was posted for the
+	// current unwatched period
+	abandonedNoticeAnnotation = "ambient-code.io/abandoned-notice-sent"
+
+	// viewRecordInterval throttles last-viewed writes; the UI heartbeats every minute per tab
+	viewRecordInterval = 5 * time.Minute
+)
+
+var (
+	viewMu       sync.Mutex
+	viewRecorded = map[string]time.Time{}
+)
+
+// SessionHeartbeat handles POST /api/projects/:projectName/agentic-sessions/:sessionName/heartbeat.
+// The UI posts it while a session page is visible; API clients watching a session may post it too.
+// Anyone who can read the session may heartbeat it.
+func SessionHeartbeat(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	if _, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
+		return
+	}
+
+	RecordSessionViewed(project, sessionName)
+	c.Status(http.StatusNoContent)
+}
+
+// RecordSessionViewed marks a session as watched; writes to the CR at most once per
+// viewRecordInterval per session. Written with the backend SA since viewers cannot patch.
+func RecordSessionViewed(project, sessionName string) {
+	if project == "" || sessionName == "" || DynamicClient == nil {
+		return
+	}
+	key := project + "/" + sessionName
+	now := time.Now()
+	viewMu.Lock()
+	if now.Sub(viewRecorded[key]) < viewRecordInterval {
+		viewMu.Unlock()
+		return
+	}
+	viewRecorded[key] = now
+	viewMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), K8sCallTimeout)
+	defer cancel()
+	patchSession(ctx, project, sessionName, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{lastViewedAnnotation: now.UTC().Format(time.RFC3339)},
+		},
+	}, "")
+}
+
+// pruneViewRecords drops throttle entries that no longer suppress a write
+func pruneViewRecords(now time.Time) {
+	viewMu.Lock()
+	defer viewMu.Unlock()
+	for key, t := range viewRecorded {
+		if now.Sub(t) >= viewRecordInterval {
+			delete(viewRecorded, key)
+		}
+	}
+}
+
+// sessionLastViewed is the latest of the recorded view, the start time and creation
+func sessionLastViewed(session *apiv1alpha1.AgenticSession) time.Time {
+	last := session.CreationTimestamp.Time
+	if session.Status.StartTime != nil && session.Status.StartTime.After(last) {
+		last = session.Status.StartTime.Time
+	}
+	if t, err := time.Parse(time.RFC3339, session.Annotations[lastViewedAnnotation]); err == nil && t.After(last) {
+		last = t
+	}
+	return last
+}
+
+// abandonedSessionAction returns the action due for the session under policy, "" for none.
+// A notice is posted once per unwatched period; viewing the session again starts a new one.
+func abandonedSessionAction(policy *apiv1alpha1.AbandonedSessionPolicy, session *apiv1alpha1.AgenticSession, now time.Time) string {
+	if policy == nil || policy.MaxUnwatchedHours <= 0 {
+		return ""
+	}
+	lastViewed := sessionLastViewed(session)
+	if now.Sub(lastViewed) < time.Duration(policy.MaxUnwatchedHours)*time.Hour {
+		return ""
+	}
+	if policy.Action == apiv1alpha1.AbandonedSessionNotify {
+		if t, err := time.Parse(time.RFC3339, session.Annotations[abandonedNoticeAnnotation]); err == nil && !t.Before(lastViewed) {
+			return ""
+		}
+		return apiv1alpha1.AbandonedSessionNotify
+	}
+	return apiv1alpha1.AbandonedSessionStop
+}
+
+// checkAbandonedSession notifies or stops a running interactive session nobody has viewed for
+// the project's spec.abandonedSessions.maxUnwatchedHours. Unlike the idle check, a session the
+// agent is still busy in counts as abandoned when no one is watching it.
+func checkAbandonedSession(ctx context.Context, session *apiv1alpha1.AgenticSession, policy *apiv1alpha1.AbandonedSessionPolicy, now time.Time) {
+	switch abandonedSessionAction(policy, session, now) {
+	case apiv1alpha1.AbandonedSessionStop:
+		stopSession(ctx, session, fmt.Sprintf("Session stopped after %d hours without anyone viewing it", policy.MaxUnwatchedHours))
+	case apiv1alpha1.AbandonedSessionNotify:
+		data := map[string]interface{}{"Hours": policy.MaxUnwatchedHours}
+		log.Printf("Idle session reaper: %s/%s unwatched for %d hours, notifying", session.Namespace, session.Name, policy.MaxUnwatchedHours)
+		if !patchSession(ctx, session.Namespace, session.Name, map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{abandonedNoticeAnnotation: now.UTC().Format(time.RFC3339)},
+			},
+		}, "") {
+			return
+		}
+		if SendMessageToSession != nil {
+			// Viewers' languages are unknown here: send every translation and let each client pick
+			SendMessageToSession(session.Name, "system.message", map[string]interface{}{
+				"message":  messages.Render(messages.DefaultLocale, "session.abandonedNotice", data),
+				"code":     "session.abandonedNotice",
+				"messages": messages.RenderAll("session.abandonedNotice", data),
+			})
+		}
+	}
+}
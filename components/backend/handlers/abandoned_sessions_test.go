@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAbandonedSessionAction(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	session := func(annotations map[string]string) *apiv1alpha1.AgenticSession {
+		return &apiv1alpha1.AgenticSession{
+			ObjectMeta: v1.ObjectMeta{CreationTimestamp: v1.NewTime(start), Annotations: annotations},
+		}
+	}
+	stamp := func(t time.Time) string { return t.Format(time.RFC3339) }
+	stop := &apiv1alpha1.AbandonedSessionPolicy{MaxUnwatchedHours: 4}
+	notify := &apiv1alpha1.AbandonedSessionPolicy{MaxUnwatchedHours: 4, Action: apiv1alpha1.AbandonedSessionNotify}
+
+	cases := []struct {
+		name   string
+		policy *apiv1alpha1.AbandonedSessionPolicy
+		s      *apiv1alpha1.AgenticSession
+		now    time.Time
+		want   string
+	}{
+		{"no policy", nil, session(nil), start.Add(24 * time.Hour), ""},
+		{"disabled", &apiv1alpha1.AbandonedSessionPolicy{}, session(nil), start.Add(24 * time.Hour), ""},
+		{"never viewed, not yet due", stop, session(nil), start.Add(3 * time.Hour), ""},
+		{"never viewed, due", stop, session(nil), start.Add(4 * time.Hour), apiv1alpha1.AbandonedSessionStop},
+		{"viewed recently", stop, session(map[string]string{lastViewedAnnotation: stamp(start.Add(2 * time.Hour))}), start.Add(5 * time.Hour), ""},
+		{"notify due", notify, session(nil), start.Add(5 * time.Hour), apiv1alpha1.AbandonedSessionNotify},
+		{"notice already sent", notify, session(map[string]string{abandonedNoticeAnnotation: stamp(start.Add(4 * time.Hour))}), start.Add(6 * time.Hour), ""},
+		{"viewed since the notice", notify, session(map[string]string{
+			abandonedNoticeAnnotation: stamp(start.Add(4 * time.Hour)),
+			lastViewedAnnotation:      stamp(start.Add(5 * time.Hour)),
+		}), start.Add(9 * time.Hour), apiv1alpha1.AbandonedSessionNotify},
+	}
+	for _, tc := range cases {
+		if got := abandonedSessionAction(tc.policy, tc.s, tc.now); got != tc.want {
+			t.Errorf("%s: abandonedSessionAction() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
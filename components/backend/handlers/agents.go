@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+// Agents are the personas sessions of a project can hand work to, such as the RFE council.
+// A session names the ones it wants in AGENT_PERSONAS; the runner config carries their
+// definitions to the runner. All calls use the caller's credentials, so project RBAC on
+// agents applies: admins manage them, everyone else reads them.
+
+// ListAgents handles GET /api/projects/:projectName/agents
+func ListAgents(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	list, err := reqDyn.Resource(GetAgentResource()).Namespace(project).List(c.Request.Context(), v1.ListOptions{})
+	if err != nil {
+		respondAgentError(c, project, "list", err)
+		return
+	}
+	items := make([]types.Agent, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, agentFromObject(&list.Items[i]))
+	}
+	respondCatalog(c, agentCatalogCacheControl, gin.H{"items": items})
+}
+
+// GetAgent handles GET /api/projects/:projectName/agents/:name
+func GetAgent(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	obj, err := reqDyn.Resource(GetAgentResource()).Namespace(project).Get(c.Request.Context(), c.Param("name"), v1.GetOptions{})
+	if err != nil {
+		respondAgentError(c, project, "get", err)
+		return
+	}
+	respondCatalog(c, agentCatalogCacheControl, agentFromObject(obj))
+}
+
+// CreateAgent handles POST /api/projects/:projectName/agents
+func CreateAgent(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	var req types.CreateAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The name is the persona in AGENT_PERSONAS and the subagent's name
+	if errs := validation.IsDNS1123Label(req.Name); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid name: %s", errs[0])})
+		return
+	}
+	if err := req.AgentSpec.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	specMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&req.AgentSpec)
+	if err != nil {
+		respondAgentError(c, project, "create", err)
+		return
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiv1alpha1.SchemeGroupVersion.String(),
+		"kind":       "Agent",
+		"metadata": map[string]interface{}{
+			"name":        req.Name,
+			"namespace":   project,
+			"annotations": map[string]interface{}{createdByAnnotation: c.GetString("userID")},
+		},
+		"spec": specMap,
+	}}
+	created, err := reqDyn.Resource(GetAgentResource()).Namespace(project).Create(c.Request.Context(), obj, v1.CreateOptions{})
+	if err != nil {
+		respondAgentError(c, project, "create", err)
+		return
+	}
+	c.JSON(http.StatusCreated, agentFromObject(created))
+}
+
+// UpdateAgent handles PUT /api/projects/:projectName/agents/:name, replacing the spec.
+// Running sessions keep the definition they started with.
+func UpdateAgent(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	var spec apiv1alpha1.AgentSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := spec.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	specMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		respondAgentError(c, project, "update", err)
+		return
+	}
+	agents := reqDyn.Resource(GetAgentResource()).Namespace(project)
+	obj, err := agents.Get(c.Request.Context(), c.Param("name"), v1.GetOptions{})
+	if err != nil {
+		respondAgentError(c, project, "update", err)
+		return
+	}
+	obj.Object["spec"] = specMap
+	updated, err := agents.Update(c.Request.Context(), obj, v1.UpdateOptions{})
+	if err != nil {
+		respondAgentError(c, project, "update", err)
+		return
+	}
+	c.JSON(http.StatusOK, agentFromObject(updated))
+}
+
+// DeleteAgent handles DELETE /api/projects/:projectName/agents/:name
+func DeleteAgent(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	if err := reqDyn.Resource(GetAgentResource()).Namespace(project).Delete(c.Request.Context(), c.Param("name"), v1.DeleteOptions{}); err != nil {
+		respondAgentError(c, project, "delete", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// sessionAgents resolves the personas the session names in AGENT_PERSONAS (or AGENT_PERSONA)
+// to the project's Agents. Names without an Agent are skipped: they may be agents of the
+// session's workflow, which the runner finds in its .claude/agents.
+func sessionAgents(ctx context.Context, client dynamic.Interface, session *unstructured.Unstructured) ([]types.Agent, error) {
+	env, _, _ := unstructured.NestedStringMap(session.Object, "spec", "environmentVariables")
+	personas := env["AGENT_PERSONAS"]
+	if personas == "" {
+		personas = env["AGENT_PERSONA"]
+	}
+	var agents []types.Agent
+	for _, name := range apiv1alpha1.ParseAgentPersonas(personas) {
+		obj, err := client.Resource(GetAgentResource()).Namespace(session.GetNamespace()).Get(ctx, name, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		agent := agentFromObject(obj)
+		if err := agent.Validate(); err != nil {
+			log.Printf("Skipping invalid agent %s/%s for session %s: %v", session.GetNamespace(), name, session.GetName(), err)
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	return agents, nil
+}
+
+func agentFromObject(obj *unstructured.Unstructured) types.Agent {
+	agent := types.Agent{Name: obj.GetName(), CreationTimestamp: obj.GetCreationTimestamp().UTC().Format(time.RFC3339)}
+	if spec, ok := obj.Object["spec"].(map[string]interface{}); ok {
+		_ = runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &agent.AgentSpec)
+	}
+	return agent
+}
+
+func respondAgentError(c *gin.Context, project, verb string, err error) {
+	switch {
+	case errors.IsNotFound(err):
+		respondMessage(c, http.StatusNotFound, "agent.notFound", nil)
+	case errors.IsAlreadyExists(err):
+		respondMessage(c, http.StatusConflict, "agent.exists", nil)
+	case errors.IsForbidden(err):
+		respondMessage(c, http.StatusForbidden, "agent.forbidden", gin.H{"Verb": "verb." + verb})
+	case errors.IsInvalid(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to %s agents in %s: %v", verb, project, err)
+		respondMessage(c, http.StatusInternalServerError, "agent.failed", gin.H{"Verb": "verb." + verb})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestSessionAgents verifies the personas a session names resolve to the project's valid
+// Agents in order, and names without an Agent are left to the workflow
+func TestSessionAgents(t *testing.T) {
+	agent := func(name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "Agent",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "proj"},
+			"spec":       spec,
+		}}
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		GetAgentResource(): "AgentList",
+	},
+		agent("stella", map[string]interface{}{
+			"displayName": "Stella Staff Engineer",
+			"description": "Use for technical design and code review",
+			"prompt":      "You are a staff engineer.",
+			"tools":       []interface{}{"Read", "Grep"},
+			"model":       "opus",
+		}),
+		agent("parker", map[string]interface{}{"description": "Use for product questions", "prompt": "You are a product manager."}),
+		agent("broken", map[string]interface{}{"description": "Missing its prompt"}),
+	)
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "s1", "namespace": "proj"},
+		"spec": map[string]interface{}{
+			"environmentVariables": map[string]interface{}{"AGENT_PERSONAS": "parker, stella, workflow-only, broken"},
+		},
+	}}
+
+	agents, err := sessionAgents(context.Background(), client, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agents) != 2 || agents[0].Name != "parker" || agents[1].Name != "stella" {
+		t.Fatalf("agents = %+v", agents)
+	}
+	if a := agents[1]; a.Model != "opus" || len(a.Tools) != 2 || a.Prompt != "You are a staff engineer." {
+		t.Errorf("stella = %+v", a)
+	}
+
+	unstructured.RemoveNestedField(session.Object, "spec", "environmentVariables")
+	if agents, err := sessionAgents(context.Background(), client, session); err != nil || agents != nil {
+		t.Errorf("session without personas: %v, %v", agents, err)
+	}
+}
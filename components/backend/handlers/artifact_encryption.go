@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ambient-code-backend/envelope"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// artifactEnvelopeSuffix names the file next to an encrypted artifact that holds its
+// envelope header (the wrapped data key)
+const artifactEnvelopeSuffix = ".envelope.json"
+
+// artifactEncryptionKey loads the key-encryption key of ProjectSettings
+// spec.workspaceEncryption.artifactKey. It returns a nil key when the project does not
+// encrypt artifacts. The key reference names the Secret and the key's fingerprint.
+func artifactEncryptionKey(ctx context.Context, project string) ([]byte, string, error) {
+	if VteamClient == nil {
+		return nil, "", nil
+	}
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to read project settings: %w", err)
+	}
+	enc := ps.Spec.WorkspaceEncryption
+	if enc == nil || enc.ArtifactKey == nil || strings.TrimSpace(enc.ArtifactKey.SecretName) == "" {
+		return nil, "", nil
+	}
+	ref := enc.ArtifactKey
+	key := ref.Key
+	if key == "" {
+		key = "kek"
+	}
+	secret, err := K8sClient.CoreV1().Secrets(project).Get(ctx, ref.SecretName, v1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read artifact key secret %s: %w", ref.SecretName, err)
+	}
+	kek, err := envelope.ParseKEK(secret.Data[key])
+	if err != nil {
+		return nil, "", fmt.Errorf("secret %s key %s: %w", ref.SecretName, key, err)
+	}
+	return kek, fmt.Sprintf("secret/%s/%s@%s", ref.SecretName, key, envelope.Fingerprint(kek)), nil
+}
+
+// sealArtifact encrypts the verified upload at src into dest and writes the envelope header
+// next to it. Both are written under temporary names first so a crash never leaves a
+// ciphertext without its header.
+func sealArtifact(src, dest string, kek []byte, keyRef string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".sealing"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	header, err := envelope.Seal(out, in, kek, keyRef)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	b, _ := json.Marshal(header)
+	if err := os.WriteFile(dest+artifactEnvelopeSuffix+".tmp", b, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(dest+artifactEnvelopeSuffix+".tmp", dest+artifactEnvelopeSuffix); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
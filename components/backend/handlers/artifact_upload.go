@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Resumable artifact uploads from runners, modelled on the tus protocol
+// (https://tus.io/protocols/resumable-upload):
+//
+//	POST  /internal/artifacts/:session/uploads      {"path","size","sha256"} -> Location, Upload-Offset
+//	HEAD  /internal/artifacts/:session/uploads/:id  -> Upload-Offset, Upload-Length
+//	PATCH /internal/artifacts/:session/uploads/:id  Upload-Offset + chunk -> Upload-Offset
+//
+// The upload ID is derived from the session, path, size and checksum, so a runner that lost
+// its connection (or restarted) re-creates the same upload and continues from the offset the
+// backend reports. A chunk may carry "Upload-Checksum: sha256 <base64>"; the whole file is
+// verified against the declared sha256 before it is moved into the session's artifact store.
+// Progress is mirrored into status.artifacts of the AgenticSession.
+
+const (
+	uploadOffsetHeader   = "Upload-Offset"
+	uploadLengthHeader   = "Upload-Length"
+	uploadChecksumHeader = "Upload-Checksum"
+	uploadContentType    = "application/offset+octet-stream"
+
+	// maxArtifactChunkBytes bounds a single PATCH body
+	maxArtifactChunkBytes = 32 << 20
+	// defaultArtifactMaxBytes caps one artifact; override with ARTIFACT_UPLOAD_MAX_BYTES
+	defaultArtifactMaxBytes int64 = 2 << 30
+	// artifactProgressStep is the fraction of the file between status.artifacts updates
+	artifactProgressStep = 10
+)
+
+// artifactUpload is the metadata stored next to a partial upload
+type artifactUpload struct {
+	ID      string `json:"id"`
+	Project string `json:"project"`
+	Session string `json:"session"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	// KeyRef is set once the artifact was stored encrypted
+	KeyRef string `json:"keyRef,omitempty"`
+}
+
+// artifactUploadLocks serializes PATCHes per upload so concurrent retries cannot interleave
+var artifactUploadLocks sync.Map
+
+func artifactMaxBytes() int64 {
+	if v := strings.TrimSpace(os.Getenv("ARTIFACT_UPLOAD_MAX_BYTES")); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultArtifactMaxBytes
+}
+
+func artifactUploadDir(project, session string) string {
+	return filepath.Join(StateBaseDir, "artifact-uploads", project, session)
+}
+
+// ArtifactStoreDir is where completed artifacts of a session are kept on the backend volume
+func ArtifactStoreDir(project, session string) string {
+	return filepath.Join(StateBaseDir, "artifacts", project, session)
+}
+
+// cleanArtifactPath validates a runner-supplied relative path
+func cleanArtifactPath(p string) (string, error) {
+	p = strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+	if p == "" || strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("path must be relative")
+	}
+	clean := filepath.ToSlash(filepath.Clean(p))
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("path must stay inside the artifact directory")
+	}
+	return clean, nil
+}
+
+// runnerArtifactSession authenticates the runner token and returns the session namespace
+func runnerArtifactSession(c *gin.Context) (string, string, bool) {
+	sessionName := c.Param("session")
+	namespace, serviceAccount, ok := authenticateRunnerToken(c)
+	if !ok {
+		return "", "", false
+	}
+	if getRunnerSession(c, namespace, sessionName, serviceAccount) == nil {
+		return "", "", false
+	}
+	return namespace, sessionName, true
+}
+
+// CreateArtifactUpload starts (or resumes) an artifact upload.
+// POST /internal/artifacts/:session/uploads
+// Auth: Authorization: Bearer <BOT_TOKEN>
+func CreateArtifactUpload(c *gin.Context) {
+	project, session, ok := runnerArtifactSession(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Path   string `json:"path" binding:"required"`
+		Size   int64  `json:"size"`
+		SHA256 string `json:"sha256" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	relPath, err := cleanArtifactPath(req.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sum := strings.ToLower(strings.TrimSpace(req.SHA256))
+	if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sha256 must be a hex SHA-256 digest"})
+		return
+	}
+	if req.Size < 0 || req.Size > artifactMaxBytes() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("artifact size must be between 0 and %d bytes", artifactMaxBytes())})
+		return
+	}
+
+	idSum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s\x00%s\x00%d\x00%s", project, session, relPath, req.Size, sum)))
+	up := artifactUpload{
+		ID:      hex.EncodeToString(idSum[:16]),
+		Project: project,
+		Session: session,
+		Path:    relPath,
+		Size:    req.Size,
+		SHA256:  sum,
+	}
+
+	dir := artifactUploadDir(project, session)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("CreateArtifactUpload: mkdir %s: %v", dir, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload"})
+		return
+	}
+	metaPath := filepath.Join(dir, up.ID+".json")
+	partPath := filepath.Join(dir, up.ID+".part")
+	if _, err := os.Stat(metaPath); os.IsNotExist(err) {
+		b, _ := json.Marshal(up)
+		if err := os.WriteFile(metaPath, b, 0o644); err != nil {
+			log.Printf("CreateArtifactUpload: write %s: %v", metaPath, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload"})
+			return
+		}
+		recordArtifactProgress(c.Request.Context(), up, 0, apiv1alpha1.ArtifactUploading, "")
+	}
+	// O_CREATE without O_TRUNC keeps the bytes of an upload being resumed
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("CreateArtifactUpload: create %s: %v", partPath, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload"})
+		return
+	}
+	f.Close()
+
+	offset, err := artifactUploadOffset(up)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read upload"})
+		return
+	}
+	if offset >= up.Size {
+		// Empty artifact, or every byte arrived but the completing request was lost
+		finishArtifactUpload(c, up)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/internal/artifacts/%s/uploads/%s", session, up.ID))
+	c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	c.Header(uploadLengthHeader, strconv.FormatInt(up.Size, 10))
+	c.JSON(http.StatusCreated, gin.H{"id": up.ID, "offset": offset, "size": up.Size})
+}
+
+// HeadArtifactUpload reports how many bytes of an upload the backend has.
+// HEAD /internal/artifacts/:session/uploads/:id
+func HeadArtifactUpload(c *gin.Context) {
+	project, session, ok := runnerArtifactSession(c)
+	if !ok {
+		return
+	}
+	up, err := loadArtifactUpload(project, session, c.Param("id"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	offset, err := artifactUploadOffset(up)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	c.Header(uploadLengthHeader, strconv.FormatInt(up.Size, 10))
+	c.Status(http.StatusOK)
+}
+
+// PatchArtifactUpload appends one chunk at Upload-Offset.
+// PATCH /internal/artifacts/:session/uploads/:id
+func PatchArtifactUpload(c *gin.Context) {
+	project, session, ok := runnerArtifactSession(c)
+	if !ok {
+		return
+	}
+	if ct := c.ContentType(); ct != uploadContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + uploadContentType})
+		return
+	}
+	up, err := loadArtifactUpload(project, session, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return
+	}
+	clientOffset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || clientOffset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing or invalid " + uploadOffsetHeader})
+		return
+	}
+
+	lock, _ := artifactUploadLocks.LoadOrStore(up.ID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	offset, err := artifactUploadOffset(up)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return
+	}
+	if clientOffset != offset {
+		// The runner resumes from the offset we report
+		c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+		c.JSON(http.StatusConflict, gin.H{"error": "offset mismatch", "offset": offset})
+		return
+	}
+
+	// Read the chunk fully before appending so a dropped connection never leaves a torn write
+	limit := up.Size - offset
+	if limit > maxArtifactChunkBytes {
+		limit = maxArtifactChunkBytes
+	}
+	chunk, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+	if err != nil {
+		c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read chunk"})
+		return
+	}
+	if int64(len(chunk)) > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("chunk exceeds %d bytes or the declared size", limit)})
+		return
+	}
+	if hdr := strings.TrimSpace(c.GetHeader(uploadChecksumHeader)); hdr != "" {
+		algo, value, _ := strings.Cut(hdr, " ")
+		want, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if !strings.EqualFold(algo, "sha256") || err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": uploadChecksumHeader + " must be \"sha256 <base64>\""})
+			return
+		}
+		got := sha256.Sum256(chunk)
+		if string(want) != string(got[:]) {
+			// tus uses 460 Checksum Mismatch; the runner resends the chunk
+			c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+			c.JSON(460, gin.H{"error": "chunk checksum mismatch"})
+			return
+		}
+	}
+
+	partPath := filepath.Join(artifactUploadDir(project, session), up.ID+".part")
+	f, err := os.OpenFile(partPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open upload"})
+		return
+	}
+	_, werr := f.Write(chunk)
+	cerr := f.Close()
+	if werr != nil || cerr != nil {
+		log.Printf("PatchArtifactUpload: write %s: %v %v", partPath, werr, cerr)
+		// Drop a partially written chunk so the reported offset stays on a chunk boundary
+		_ = os.Truncate(partPath, offset)
+		c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to write chunk"})
+		return
+	}
+	newOffset := offset + int64(len(chunk))
+
+	if newOffset == up.Size {
+		finishArtifactUpload(c, up)
+		return
+	}
+	if crossedProgressStep(offset, newOffset, up.Size) {
+		recordArtifactProgress(c.Request.Context(), up, newOffset, apiv1alpha1.ArtifactUploading, "")
+	}
+	c.Header(uploadOffsetHeader, strconv.FormatInt(newOffset, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// finishArtifactUpload verifies the whole file and moves it into the artifact store
+func finishArtifactUpload(c *gin.Context, up artifactUpload) {
+	dir := artifactUploadDir(up.Project, up.Session)
+	partPath := filepath.Join(dir, up.ID+".part")
+	metaPath := filepath.Join(dir, up.ID+".json")
+
+	sum, err := fileSHA256(partPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify upload"})
+		return
+	}
+	if sum != up.SHA256 {
+		// Start from scratch: the runner re-creates the upload and sends the file again
+		_ = os.Remove(partPath)
+		_ = os.Remove(metaPath)
+		msg := "checksum mismatch after upload"
+		recordArtifactProgress(c.Request.Context(), up, 0, apiv1alpha1.ArtifactFailed, msg)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": msg})
+		return
+	}
+
+	// Projects with spec.workspaceEncryption.artifactKey keep artifacts envelope-encrypted
+	kek, keyRef, err := artifactEncryptionKey(c.Request.Context(), up.Project)
+	if err != nil {
+		// The upload stays complete; the runner's retry finishes it once the key is readable
+		log.Printf("finishArtifactUpload: %s/%s: %v", up.Project, up.Session, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load the artifact encryption key"})
+		return
+	}
+	dest := filepath.Join(ArtifactStoreDir(up.Project, up.Session), filepath.FromSlash(up.Path))
+	if kek != nil {
+		if err = sealArtifact(partPath, dest, kek, keyRef); err == nil {
+			_ = os.Remove(partPath)
+			up.KeyRef = keyRef
+		}
+	} else if err = os.MkdirAll(filepath.Dir(dest), 0o755); err == nil {
+		err = os.Rename(partPath, dest)
+	}
+	if err != nil {
+		log.Printf("finishArtifactUpload: store %s: %v", dest, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store artifact"})
+		return
+	}
+	_ = os.Remove(metaPath)
+	artifactUploadLocks.Delete(up.ID)
+	recordArtifactProgress(c.Request.Context(), up, up.Size, apiv1alpha1.ArtifactComplete, "")
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(up.Size, 10))
+	c.JSON(http.StatusOK, gin.H{"id": up.ID, "path": up.Path, "size": up.Size, "sha256": up.SHA256})
+}
+
+func loadArtifactUpload(project, session, id string) (artifactUpload, error) {
+	var up artifactUpload
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 32 {
+		return up, fmt.Errorf("invalid upload id")
+	}
+	b, err := os.ReadFile(filepath.Join(artifactUploadDir(project, session), id+".json"))
+	if err != nil {
+		return up, err
+	}
+	err = json.Unmarshal(b, &up)
+	return up, err
+}
+
+func artifactUploadOffset(up artifactUpload) (int64, error) {
+	info, err := os.Stat(filepath.Join(artifactUploadDir(up.Project, up.Session), up.ID+".part"))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// crossedProgressStep reports whether an append moved the upload into a new 10% bucket
+func crossedProgressStep(before, after, size int64) bool {
+	if size <= 0 {
+		return false
+	}
+	return before*artifactProgressStep/size != after*artifactProgressStep/size
+}
+
+// recordArtifactProgress upserts the upload into status.artifacts. Failures are logged only:
+// progress reporting must never fail the upload itself.
+func recordArtifactProgress(ctx context.Context, up artifactUpload, uploaded int64, state apiv1alpha1.ArtifactUploadState, message string) {
+	if VteamClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), K8sCallTimeout)
+	defer cancel()
+	client := VteamClient.VteamV1alpha1().AgenticSessions(up.Project)
+	err := retryOnConflict("record_artifact_progress", func() error {
+		session, err := client.Get(ctx, up.Session, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		now := v1.NewTime(time.Now())
+		entry := apiv1alpha1.ArtifactStatus{
+			Path:          up.Path,
+			Size:          up.Size,
+			SHA256:        up.SHA256,
+			UploadedBytes: uploaded,
+			State:         state,
+			Message:       message,
+			LastUpdated:   &now,
+			KeyRef:        up.KeyRef,
+		}
+		replaced := false
+		for i := range session.Status.Artifacts {
+			if session.Status.Artifacts[i].Path == up.Path {
+				session.Status.Artifacts[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			session.Status.Artifacts = append(session.Status.Artifacts, entry)
+		}
+		_, err = client.UpdateStatus(ctx, session, v1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to record artifact progress for %s/%s %s: %v", up.Project, up.Session, up.Path, err)
+	}
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/hardware"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Causes the capacity preflight reports besides quota and maintenance
+const (
+	// capacityCauseResourceQuota: a namespace ResourceQuota has no room for the runner pod
+	capacityCauseResourceQuota = "resourceQuota"
+	// capacityCauseQueue: sessions are already waiting for a cluster-wide runner slot
+	capacityCauseQueue = "queue"
+	// capacityCauseNodes: matching nodes exist but none has the requests free right now
+	capacityCauseNodes = "capacity"
+	// capacityCauseHardware: no node could ever run the session
+	capacityCauseHardware = "hardware"
+)
+
+// capacityRequest is what the session to be created needs
+type capacityRequest struct {
+	hardware *apiv1alpha1.RunnerHardware
+	cpu      resource.Quantity
+	memory   resource.Quantity
+}
+
+// GetProjectCapacity handles GET /projects/:projectName/capacity
+// Preflight for session creation: estimates whether a session with the requirements in the
+// query (cpu, memory, gpus, architecture, runtimeClassName) would start now, so the UI can warn
+// about a long queue before the user submits. Hardware not in the query comes from the
+// project's runnerHardware defaults, as for a created session. The answer is an estimate:
+// waits are derived from the timeouts of running sessions.
+func GetProjectCapacity(c *gin.Context) {
+	project := c.GetString("project")
+	req, err := parseCapacityRequest(c, project)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The cluster-wide view needs the backend service account; the caller may only see the project
+	var client kubernetes.Interface
+	if K8sClient != nil {
+		client = K8sClient
+	}
+	est, err := estimateCapacity(c.Request.Context(), client, project, req, time.Now())
+	if err != nil {
+		log.Printf("Failed to estimate capacity for project %s: %v", project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate capacity"})
+		return
+	}
+	c.JSON(http.StatusOK, est)
+}
+
+func parseCapacityRequest(c *gin.Context, project string) (*capacityRequest, error) {
+	req := &capacityRequest{}
+	for _, q := range []struct {
+		name string
+		into *resource.Quantity
+	}{{"cpu", &req.cpu}, {"memory", &req.memory}} {
+		raw := strings.TrimSpace(c.Query(q.name))
+		if raw == "" {
+			continue
+		}
+		qty, err := resource.ParseQuantity(raw)
+		if err != nil || qty.Sign() < 0 {
+			return nil, fmt.Errorf("%s must be a Kubernetes quantity, e.g. 500m or 2Gi", q.name)
+		}
+		*q.into = qty
+	}
+	hw := &apiv1alpha1.RunnerHardware{
+		Architecture:     strings.TrimSpace(c.Query("architecture")),
+		RuntimeClassName: strings.TrimSpace(c.Query("runtimeClassName")),
+	}
+	if raw := strings.TrimSpace(c.Query("gpus")); raw != "" {
+		gpus, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("gpus must be a number")
+		}
+		hw.GPUs = gpus
+	}
+	if err := hw.Validate(); err != nil {
+		return nil, err
+	}
+	var defaults *apiv1alpha1.RunnerHardware
+	if VteamClient != nil {
+		if spec := projectSettingsSpec(c.Request.Context(), project); spec != nil {
+			defaults = spec.RunnerHardware
+		}
+	}
+	req.hardware = defaults.Merge(hw)
+	return req, nil
+}
+
+// estimateCapacity collects what would hold a new session back: maintenance, the project's
+// session quota, its ResourceQuotas, the cluster-wide runner queue and free node capacity
+func estimateCapacity(ctx context.Context, client kubernetes.Interface, project string, req *capacityRequest, now time.Time) (*types.CapacityEstimate, error) {
+	est := &types.CapacityEstimate{Feasible: true}
+
+	if err := checkMaintenance(ctx, project); err != nil {
+		hint := err.(*maintenanceError).hint()
+		hint.Message = err.Error()
+		est.Blockers = append(est.Blockers, *hint)
+	}
+	if err := checkSessionQuota(ctx, project, ""); err != nil {
+		quotaErr, ok := err.(*sessionQuotaError)
+		if !ok {
+			return nil, err
+		}
+		hint := quotaErr.hint()
+		hint.Message = quotaErr.Error()
+		est.Blockers = append(est.Blockers, *hint)
+	}
+
+	var sessions []apiv1alpha1.AgenticSession
+	if VteamClient != nil {
+		list, err := VteamClient.VteamV1alpha1().AgenticSessions("").List(ctx, v1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		sessions = list.Items
+	}
+	var projectSessions []apiv1alpha1.AgenticSession
+	for _, s := range sessions {
+		if s.Namespace == project {
+			projectSessions = append(projectSessions, s)
+		}
+		if isActiveSessionPhase(s.Status.Phase) && meta.IsStatusConditionTrue(s.Status.Conditions, "Queued") {
+			est.QueuedSessions++
+		}
+	}
+	// A new session gets a runner once the sessions queued ahead of it got theirs
+	clusterWait := nthWait(sessionTimeouts(sessions, now), est.QueuedSessions)
+
+	if client != nil {
+		hint, err := resourceQuotaBlocker(ctx, client, project, req)
+		if err != nil {
+			return nil, err
+		}
+		if hint != nil {
+			hint.EstimatedWaitSeconds = waitSeconds(earliestTimeout(projectSessions, now))
+			est.Blockers = append(est.Blockers, *hint)
+		}
+
+		// The same check rejects the create call; a session that fails it would never run
+		if err := hardware.Check(ctx, client, req.hardware); err != nil {
+			est.Feasible = false
+			est.Blockers = append(est.Blockers, types.SchedulingHint{Cause: capacityCauseHardware, Message: err.Error()})
+		} else {
+			if err := countAvailableNodes(ctx, client, req, est); err != nil {
+				return nil, err
+			}
+			if est.MatchingNodes == 0 {
+				est.Feasible = false
+				est.Blockers = append(est.Blockers, types.SchedulingHint{Cause: capacityCauseHardware, Message: "no schedulable node can run the session"})
+			} else if est.AvailableNodes == 0 {
+				est.Blockers = append(est.Blockers, types.SchedulingHint{
+					Cause:                capacityCauseNodes,
+					Message:              fmt.Sprintf("none of the %d matching nodes has the requested resources free", est.MatchingNodes),
+					EstimatedWaitSeconds: waitSeconds(clusterWait),
+				})
+			}
+		}
+	}
+	if est.QueuedSessions > 0 {
+		est.Blockers = append(est.Blockers, types.SchedulingHint{
+			Cause:                capacityCauseQueue,
+			Message:              fmt.Sprintf("%d sessions are waiting for a runner slot", est.QueuedSessions),
+			QueuePosition:        est.QueuedSessions + 1,
+			EstimatedWaitSeconds: waitSeconds(clusterWait),
+		})
+	}
+
+	est.Schedulable = len(est.Blockers) == 0
+	if est.Feasible && !est.Schedulable {
+		est.EstimatedWaitSeconds = longestWait(est.Blockers)
+	}
+	return est, nil
+}
+
+// countAvailableNodes counts the nodes that can run the session, and those of them with the
+// requests free right now
+func countAvailableNodes(ctx context.Context, client kubernetes.Interface, req *capacityRequest, est *types.CapacityEstimate) error {
+	nodes, err := hardware.Nodes(ctx, client, req.hardware)
+	if err != nil {
+		return err
+	}
+	pods, err := client.CoreV1().Pods("").List(ctx, v1.ListOptions{FieldSelector: "status.phase!=Succeeded,status.phase!=Failed"})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	requested := map[string]corev1.ResourceList{}
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		if p.Spec.NodeName == "" || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		sum := requested[p.Spec.NodeName]
+		if sum == nil {
+			sum = corev1.ResourceList{}
+			requested[p.Spec.NodeName] = sum
+		}
+		for _, ctr := range p.Spec.Containers {
+			for name, qty := range ctr.Resources.Requests {
+				total := sum[name]
+				total.Add(qty)
+				sum[name] = total
+			}
+		}
+	}
+
+	want := corev1.ResourceList{corev1.ResourceCPU: req.cpu, corev1.ResourceMemory: req.memory}
+	if req.hardware != nil && req.hardware.GPUs > 0 {
+		want[apiv1alpha1.GPUResourceName] = *resource.NewQuantity(req.hardware.GPUs, resource.DecimalSI)
+	}
+	for i := range nodes {
+		n := &nodes[i]
+		if hasBlockingTaint(n) {
+			continue
+		}
+		est.MatchingNodes++
+		if nodeHasFree(n, requested[n.Name], want) {
+			est.AvailableNodes++
+		}
+	}
+	return nil
+}
+
+// hasBlockingTaint reports taints runner pods do not tolerate, such as control plane nodes.
+// GPU nodes are tainted for GPU workloads, which runners asking for GPUs tolerate.
+func hasBlockingTaint(n *corev1.Node) bool {
+	for _, t := range n.Spec.Taints {
+		if t.Effect == corev1.TaintEffectPreferNoSchedule || t.Key == apiv1alpha1.GPUResourceName {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// nodeHasFree reports whether the node's allocatable minus what its pods request covers want
+func nodeHasFree(n *corev1.Node, requested, want corev1.ResourceList) bool {
+	for name, qty := range want {
+		if qty.IsZero() {
+			continue
+		}
+		free := n.Status.Allocatable[name].DeepCopy()
+		free.Sub(requested[name])
+		if free.Cmp(qty) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceQuotaBlocker returns a hint when a ResourceQuota in the project has no room for the
+// runner pod: one more pod with the requested CPU, memory and GPUs
+func resourceQuotaBlocker(ctx context.Context, client kubernetes.Interface, project string, req *capacityRequest) (*types.SchedulingHint, error) {
+	quotas, err := client.CoreV1().ResourceQuotas(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource quotas: %w", err)
+	}
+	want := corev1.ResourceList{
+		corev1.ResourcePods:           resource.MustParse("1"),
+		corev1.ResourceRequestsCPU:    req.cpu,
+		corev1.ResourceRequestsMemory: req.memory,
+	}
+	if req.hardware != nil && req.hardware.GPUs > 0 {
+		want[corev1.ResourceName("requests."+apiv1alpha1.GPUResourceName)] = *resource.NewQuantity(req.hardware.GPUs, resource.DecimalSI)
+	}
+	for _, q := range quotas.Items {
+		for name, qty := range want {
+			hard, ok := q.Status.Hard[name]
+			if !ok || qty.IsZero() {
+				continue
+			}
+			total := q.Status.Used[name].DeepCopy()
+			total.Add(qty)
+			if total.Cmp(hard) > 0 {
+				used := q.Status.Used[name]
+				return &types.SchedulingHint{
+					Cause:   capacityCauseResourceQuota,
+					Message: fmt.Sprintf("ResourceQuota %s allows %s %s and %s is in use", q.Name, hard.String(), name, used.String()),
+				}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// nthWait returns the n-th soonest wait (0-based), or nil when fewer sessions will time out
+func nthWait(waits []time.Duration, n int64) *time.Duration {
+	if n < 0 || n >= int64(len(waits)) {
+		return nil
+	}
+	return &waits[n]
+}
+
+func waitSeconds(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+	secs := int64(d.Round(time.Second).Seconds())
+	return &secs
+}
+
+// longestWait is the wait until every blocker lifted; nil when one of them has no estimate
+func longestWait(blockers []types.SchedulingHint) *int64 {
+	var longest *int64
+	for _, b := range blockers {
+		if b.EstimatedWaitSeconds == nil {
+			return nil
+		}
+		if longest == nil || *b.EstimatedWaitSeconds > *longest {
+			longest = b.EstimatedWaitSeconds
+		}
+	}
+	return longest
+}
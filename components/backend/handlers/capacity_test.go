@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func capacityNode(name, cpu, memory string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: v1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelArchStable: "amd64"}},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}},
+	}
+}
+
+func capacityPod(name, node, cpu string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "other"},
+		Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{
+			Name:      "main",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+// TestEstimateCapacity_BusyNodes verifies a session that fits no node's free resources waits
+// behind the queued sessions for running ones to time out
+func TestEstimateCapacity_BusyNodes(t *testing.T) {
+	now := time.Now()
+	tainted := capacityNode("control-plane", "8", "16Gi")
+	tainted.Spec.Taints = []corev1.Taint{{Key: "node-role.kubernetes.io/control-plane", Effect: corev1.TaintEffectNoSchedule}}
+	client := fake.NewSimpleClientset(
+		capacityNode("worker-1", "4", "16Gi"),
+		capacityNode("worker-2", "4", "16Gi"),
+		tainted,
+		capacityPod("busy-1", "worker-1", "3700m"),
+		capacityPod("busy-2", "worker-2", "3500m"),
+	)
+	VteamClient = vteamfake.NewSimpleClientset(
+		quotaTestSession("ends-soon", apiv1alpha1.SessionPhaseRunning, now.Add(-50*time.Minute), 3600, false),
+		quotaTestSession("ends-later", apiv1alpha1.SessionPhaseRunning, now.Add(-20*time.Minute), 3600, false),
+		quotaTestSession("waiting", apiv1alpha1.SessionPhasePending, time.Time{}, 3600, true),
+	)
+	defer func() { VteamClient = nil }()
+
+	ctx := context.Background()
+	est, err := estimateCapacity(ctx, client, "team-b", &capacityRequest{cpu: resource.MustParse("2")}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Schedulable || !est.Feasible || est.MatchingNodes != 2 || est.AvailableNodes != 0 || est.QueuedSessions != 1 {
+		t.Fatalf("estimate = %+v", est)
+	}
+	// One session is queued ahead, so the second running session to time out frees the slot
+	if est.EstimatedWaitSeconds == nil || *est.EstimatedWaitSeconds != 40*60 {
+		t.Errorf("estimated wait = %v, want 2400s", est.EstimatedWaitSeconds)
+	}
+
+	// Half a core is free on worker-2
+	est, err = estimateCapacity(ctx, client, "team-b", &capacityRequest{cpu: resource.MustParse("500m")}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.AvailableNodes != 1 || len(est.Blockers) != 1 || est.Blockers[0].Cause != capacityCauseQueue {
+		t.Errorf("estimate = %+v, want only the queue blocker", est)
+	}
+}
+
+func TestEstimateCapacity_ResourceQuotaAndHardware(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		capacityNode("worker", "4", "16Gi"),
+		&corev1.ResourceQuota{
+			ObjectMeta: v1.ObjectMeta{Name: "compute", Namespace: "team-a"},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("8Gi")},
+				Used: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("7Gi")},
+			},
+		},
+	)
+	ctx := context.Background()
+	est, err := estimateCapacity(ctx, client, "team-a", &capacityRequest{memory: resource.MustParse("2Gi")}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Schedulable || len(est.Blockers) != 1 || est.Blockers[0].Cause != capacityCauseResourceQuota {
+		t.Errorf("estimate = %+v, want a resourceQuota blocker", est)
+	}
+
+	est, err = estimateCapacity(ctx, client, "team-b", &capacityRequest{hardware: &apiv1alpha1.RunnerHardware{GPUs: 1}}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Feasible || est.EstimatedWaitSeconds != nil || len(est.Blockers) != 1 || est.Blockers[0].Cause != capacityCauseHardware {
+		t.Errorf("estimate = %+v, want infeasible", est)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Catalogs (the OOTB workflow templates and a project's agent personas) are read on every
+// page view but change rarely. They are served with a strong ETag of the response body, so
+// browsers revalidate with If-None-Match and get a bodyless 304 while nothing changed.
+
+// Cache-Control of the OOTB workflow catalog: the same for every user and only changed by
+// the workflows repo, so browsers and shared caches may reuse it for a while
+const ootbWorkflowsCacheControl = "public, max-age=300, stale-while-revalidate=3600"
+
+// Cache-Control of agent personas: per user (RBAC) and edited through this API, so every
+// use is revalidated and an edit shows up on the next page view
+const agentCatalogCacheControl = "private, no-cache"
+
+// respondCatalog writes body as JSON with an ETag and cacheControl, or 304 when the
+// client's copy is current
+func respondCatalog(c *gin.Context, cacheControl string, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Failed to encode catalog %s: %v", c.Request.URL.Path, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cacheControl)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// etagMatches implements the If-None-Match comparison, which is weak: W/ prefixes added
+// by compressing proxies still match
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRespondCatalog verifies a client holding the current ETag gets a bodyless 304, and a
+// changed catalog gets a new ETag
+func TestRespondCatalog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(body interface{}, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/workflows/ootb", nil)
+		if ifNoneMatch != "" {
+			c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		respondCatalog(c, ootbWorkflowsCacheControl, body)
+		return w
+	}
+	catalog := gin.H{"workflows": []OOTBWorkflow{{ID: "bugfix", Name: "Bugfix"}}}
+
+	first := serve(catalog, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Cache-Control") != ootbWorkflowsCacheControl {
+		t.Fatalf("first response %d, headers %v", first.Code, first.Header())
+	}
+	if w := serve(catalog, `"other", W/`+etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("revalidation with the current ETag = %d %q", w.Code, w.Body.String())
+	}
+	changed := serve(gin.H{"workflows": []OOTBWorkflow{{ID: "triage"}}}, etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Errorf("changed catalog = %d with ETag %s", changed.Code, changed.Header().Get("ETag"))
+	}
+}
+
+// TestOOTBWorkflowCache verifies the list is served while fresh, and after an invalidation
+// only as the stale fallback
+func TestOOTBWorkflowCache(t *testing.T) {
+	cache := &ootbWorkflowCache{}
+	workflows := []OOTBWorkflow{{ID: "bugfix"}}
+	cache.set("https://github.com/org/wf.git", "main", "workflows", workflows)
+
+	if got := cache.fresh("https://github.com/org/wf.git", "main", "workflows", time.Minute); len(got) != 1 {
+		t.Errorf("fresh = %v", got)
+	}
+	if got := cache.fresh("https://github.com/org/wf.git", "dev", "workflows", time.Minute); got != nil {
+		t.Errorf("another branch served %v", got)
+	}
+	if got := cache.fresh("https://github.com/org/wf.git", "main", "workflows", 0); got != nil {
+		t.Errorf("TTL 0 served %v", got)
+	}
+	cache.invalidate()
+	if got := cache.fresh("https://github.com/org/wf.git", "main", "workflows", time.Minute); got != nil {
+		t.Errorf("invalidated list served as fresh: %v", got)
+	}
+	if got := cache.get("https://github.com/org/wf.git", "main", "workflows"); len(got) != 1 {
+		t.Errorf("stale fallback = %v", got)
+	}
+}
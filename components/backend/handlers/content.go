@@ -14,6 +14,9 @@ import (
 
 	"ambient-code-backend/git"
 
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/runtimeconfig"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -32,6 +35,7 @@ var (
 	GitPushToRepo         func(ctx context.Context, repoDir, branch, commitMessage string) error
 	GitCreateBranch       func(ctx context.Context, repoDir, branchName string) error
 	GitListRemoteBranches func(ctx context.Context, repoDir string) ([]string, error)
+	GitWorkspaceChanges   func(ctx context.Context, repoDir string) ([]git.ChangedFile, error)
 )
 
 // ContentGitPush handles POST /content/github/push in CONTENT_SERVICE_MODE
@@ -43,7 +47,7 @@ func ContentGitPush(c *gin.Context) {
 		Branch        string `json:"branch"`
 	}
 	_ = c.BindJSON(&body)
-	log.Printf("contentGitPush: request received repoPath=%q outputRepoUrl=%q branch=%q commitLen=%d", body.RepoPath, body.OutputRepoURL, body.Branch, len(strings.TrimSpace(body.CommitMessage)))
+	runtimeconfig.Debugf("contentGitPush: request received repoPath=%q outputRepoUrl=%q branch=%q commitLen=%d", body.RepoPath, body.OutputRepoURL, body.Branch, len(strings.TrimSpace(body.CommitMessage)))
 
 	// Require explicit output repo URL and branch from caller
 	if strings.TrimSpace(body.OutputRepoURL) == "" {
@@ -88,6 +92,36 @@ func ContentGitPush(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"ok": true, "stdout": out})
 }
 
+// ContentGitChecks handles POST /content/github/checks in CONTENT_SERVICE_MODE
+// Runs the publish checks on what pushing repoPath would publish.
+func ContentGitChecks(c *gin.Context) {
+	var body struct {
+		RepoPath string                    `json:"repoPath"`
+		Policy   apiv1alpha1.PublishChecks `json:"policy"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+		return
+	}
+
+	repoDir := filepath.Clean(filepath.Join(StateBaseDir, body.RepoPath))
+	if !strings.HasPrefix(repoDir+string(os.PathSeparator), StateBaseDir+string(os.PathSeparator)) && repoDir != StateBaseDir {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repoPath"})
+		return
+	}
+
+	files, err := GitWorkspaceChanges(c.Request.Context(), repoDir)
+	if err != nil {
+		log.Printf("contentGitChecks: failed to read changes in %q: %v", repoDir, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read workspace changes"})
+		return
+	}
+	results := git.RunPublishChecks(body.Policy, files)
+	passed := git.PublishChecksPassed(results)
+	log.Printf("contentGitChecks: repoDir=%q files=%d passed=%t", repoDir, len(files), passed)
+	c.JSON(http.StatusOK, gin.H{"passed": passed, "results": results})
+}
+
 // ContentGitAbandon handles POST /content/github/abandon
 func ContentGitAbandon(c *gin.Context) {
 	var body struct {
@@ -219,7 +253,7 @@ func ContentGitConfigureRemote(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -251,7 +285,7 @@ func ContentGitConfigureRemote(c *gin.Context) {
 	remoteURL := body.RemoteURL
 	gitHubToken := strings.TrimSpace(c.GetHeader("X-GitHub-Token"))
 	if gitHubToken != "" {
-		if authenticatedURL, err := git.InjectGitHubToken(remoteURL, gitHubToken); err == nil {
+		if authenticatedURL, err := git.InjectToken(remoteURL, gitHubToken); err == nil {
 			remoteURL = authenticatedURL
 			log.Printf("Injected GitHub token into remote URL")
 		}
@@ -296,7 +330,7 @@ func ContentGitSync(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -713,7 +747,7 @@ func ContentGitPull(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -748,7 +782,7 @@ func ContentGitPushToBranch(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -786,7 +820,7 @@ func ContentGitCreateBranch(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
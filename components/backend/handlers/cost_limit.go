@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"log"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// costLimitReachedCondition is set by the operator when a session reaches spec.costLimit
+const costLimitReachedCondition = "CostLimitReached"
+
+// notifyCostLimitReached tells the runner to summarize and wind down when the operator
+// flips CostLimitReached to True. If the message is lost the operator stops the session
+// once its grace period is over.
+func notifyCostLimitReached(oldObj, newObj *unstructured.Unstructured) {
+	if SendMessageToSession == nil || !costLimitReached(newObj) || costLimitReached(oldObj) {
+		return
+	}
+	cond := meta.FindStatusCondition(sessionConditions(newObj), costLimitReachedCondition)
+	log.Printf("Session %s/%s reached its cost limit, asking the runner to wind down", newObj.GetNamespace(), newObj.GetName())
+	SendMessageToSession(newObj.GetName(), "cost_limit_reached", map[string]interface{}{"message": cond.Message})
+}
+
+func costLimitReached(obj *unstructured.Unstructured) bool {
+	return meta.IsStatusConditionTrue(sessionConditions(obj), costLimitReachedCondition)
+}
+
+func sessionConditions(obj *unstructured.Unstructured) []v1.Condition {
+	raw, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	conditions := make([]v1.Condition, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var cond v1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &cond); err == nil {
+			conditions = append(conditions, cond)
+		}
+	}
+	return conditions
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+
+	"ambient-code-pkg/redact"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// credentialsVerifiedCondition records the runner's pre-flight check of the model and Git
+// credentials (components/runners/claude-code-runner/credential_check.py)
+const credentialsVerifiedCondition = "CredentialsVerified"
+
+// credentialCheckReasons are the failure reasons the runner reports
+var credentialCheckReasons = map[string]bool{
+	"AnthropicCredentialsInvalid": true,
+	"VertexCredentialsInvalid":    true,
+	"GitTokenInvalid":             true,
+	"GitTokenScopesInsufficient":  true,
+}
+
+// credentialCheck is the credentialCheck field of a runner status update
+type credentialCheck struct {
+	Passed  bool   `json:"passed"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// setCredentialsVerified upserts the CredentialsVerified condition in a session status map
+func setCredentialsVerified(status map[string]interface{}, raw interface{}) error {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("credentialCheck must be an object")
+	}
+	var check credentialCheck
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &check); err != nil {
+		return fmt.Errorf("invalid credentialCheck: %v", err)
+	}
+
+	cond := v1.Condition{Type: credentialsVerifiedCondition, Status: v1.ConditionTrue, Reason: "Verified", Message: "Model and Git credentials accepted"}
+	if !check.Passed {
+		if !credentialCheckReasons[check.Reason] {
+			return fmt.Errorf("unknown credentialCheck reason %q", check.Reason)
+		}
+		cond = v1.Condition{Type: credentialsVerifiedCondition, Status: v1.ConditionFalse, Reason: check.Reason, Message: redact.String(check.Message)}
+	}
+
+	conditions := sessionConditions(&unstructured.Unstructured{Object: map[string]interface{}{"status": status}})
+	if !meta.SetStatusCondition(&conditions, cond) {
+		return nil
+	}
+	out := make([]interface{}, 0, len(conditions))
+	for i := range conditions {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&conditions[i])
+		if err != nil {
+			return err
+		}
+		out = append(out, u)
+	}
+	status["conditions"] = out
+	return nil
+}
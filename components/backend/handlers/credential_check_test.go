@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSetCredentialsVerified(t *testing.T) {
+	status := map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Scheduled", "status": "True", "reason": "Scheduled", "message": "", "lastTransitionTime": "2026-01-02T03:04:05Z"},
+		},
+	}
+	if err := setCredentialsVerified(status, map[string]interface{}{"passed": true}); err != nil {
+		t.Fatal(err)
+	}
+	err := setCredentialsVerified(status, map[string]interface{}{
+		"passed":  false,
+		"reason":  "GitTokenInvalid",
+		"message": "github.com returned 401 for Authorization: token deadbeefcafe",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conditions := sessionConditions(&unstructured.Unstructured{Object: map[string]interface{}{"status": status}})
+	if len(conditions) != 2 || meta.FindStatusCondition(conditions, "Scheduled") == nil {
+		t.Fatalf("conditions = %+v", conditions)
+	}
+	cond := meta.FindStatusCondition(conditions, credentialsVerifiedCondition)
+	if cond == nil || cond.Status != "False" || cond.Reason != "GitTokenInvalid" {
+		t.Fatalf("CredentialsVerified = %+v", cond)
+	}
+	if cond.Message != "github.com returned 401 for Authorization: token [REDACTED]" {
+		t.Errorf("message not redacted: %q", cond.Message)
+	}
+}
+
+func TestSetCredentialsVerified_Invalid(t *testing.T) {
+	for _, raw := range []interface{}{
+		"passed",
+		map[string]interface{}{"passed": false, "reason": "Whatever"},
+		map[string]interface{}{"passed": "yes"},
+	} {
+		if err := setCredentialsVerified(map[string]interface{}{}, raw); err == nil {
+			t.Errorf("expected an error for %v", raw)
+		}
+	}
+}
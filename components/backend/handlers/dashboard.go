@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteaminformers "ambient-code-pkg/client/informers/externalversions"
+	vteamlisters "ambient-code-pkg/client/listers/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	// dashboardRecentSessions is how many of the newest sessions are listed per project
+	dashboardRecentSessions = 5
+	// dashboardAccessTTL is how long a user's per-namespace access decision is reused
+	dashboardAccessTTL = 30 * time.Second
+	// dashboardAccessWorkers bounds concurrent SelfSubjectAccessReviews per request
+	dashboardAccessWorkers = 8
+)
+
+var (
+	dashboardSynced    atomic.Bool
+	dashboardSessions  vteamlisters.AgenticSessionLister
+	dashboardNamespace corelisters.NamespaceLister
+
+	dashboardAccessMu    sync.Mutex
+	dashboardAccessCache = map[string]dashboardAccess{}
+)
+
+type dashboardAccess struct {
+	allowed bool
+	expires time.Time
+}
+
+// StartDashboardInformers caches managed namespaces and all AgenticSessions with the backend
+// SA so GET /dashboard is served from memory instead of one LIST per project. Blocks until
+// ctx is cancelled.
+func StartDashboardInformers(ctx context.Context) {
+	if VteamClient == nil || K8sClientProjects == nil {
+		log.Printf("Dashboard informers disabled: backend SA clients not initialized")
+		return
+	}
+	vteamFactory := vteaminformers.NewSharedInformerFactory(VteamClient, 10*time.Minute)
+	dashboardSessions = vteamFactory.Vteam().V1alpha1().AgenticSessions().Lister()
+
+	coreFactory := informers.NewSharedInformerFactoryWithOptions(K8sClientProjects, 10*time.Minute,
+		informers.WithTweakListOptions(func(opts *v1.ListOptions) {
+			opts.LabelSelector = "ambient-code.io/managed=true"
+		}))
+	dashboardNamespace = coreFactory.Core().V1().Namespaces().Lister()
+
+	log.Printf("Starting dashboard informers for namespaces and AgenticSessions")
+	vteamFactory.Start(ctx.Done())
+	coreFactory.Start(ctx.Done())
+	vteamFactory.WaitForCacheSync(ctx.Done())
+	coreFactory.WaitForCacheSync(ctx.Done())
+	if ctx.Err() != nil {
+		return
+	}
+	dashboardSynced.Store(true)
+	<-ctx.Done()
+}
+
+// GetDashboard handles GET /api/dashboard
+// Returns session summaries for every managed project in which the caller may list
+// AgenticSessions, built from the informer caches in a single pass.
+func GetDashboard(c *gin.Context) {
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	if !dashboardSynced.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Dashboard cache is warming up, retry shortly"})
+		return
+	}
+
+	namespaces, err := dashboardNamespace.List(labels.Everything())
+	if err != nil {
+		log.Printf("GetDashboard: failed to list cached namespaces: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load dashboard"})
+		return
+	}
+	names := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		names = append(names, ns.Name)
+	}
+	allowed := dashboardAllowedNamespaces(c.Request.Context(), reqK8s, dashboardAccessKey(c), names)
+
+	isOpenShift := isOpenShiftCluster()
+	byProject := map[string]*types.DashboardProject{}
+	for _, ns := range namespaces {
+		if !allowed[ns.Name] {
+			continue
+		}
+		p := projectFromNamespace(ns, isOpenShift)
+		byProject[ns.Name] = &types.DashboardProject{
+			Name:           p.Name,
+			DisplayName:    p.DisplayName,
+			PhaseCounts:    map[string]int{},
+			RecentSessions: []types.DashboardSession{},
+		}
+	}
+
+	sessions, err := dashboardSessions.List(labels.Everything())
+	if err != nil {
+		log.Printf("GetDashboard: failed to list cached sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load dashboard"})
+		return
+	}
+	recent := map[string][]*apiv1alpha1.AgenticSession{}
+	lastActivity := map[string]time.Time{}
+	for _, s := range sessions {
+		p, ok := byProject[s.Namespace]
+		if !ok {
+			continue
+		}
+		phase := s.Status.Phase
+		if phase == "" {
+			phase = apiv1alpha1.SessionPhasePending
+		}
+		p.TotalSessions++
+		p.PhaseCounts[string(phase)]++
+		if !phase.IsTerminal() {
+			p.ActiveSessions++
+		}
+		if t := sessionLastActivity(s); t.After(lastActivity[s.Namespace]) {
+			lastActivity[s.Namespace] = t
+		}
+		recent[s.Namespace] = append(recent[s.Namespace], s)
+	}
+
+	resp := types.DashboardResponse{Projects: make([]types.DashboardProject, 0, len(byProject))}
+	for ns, p := range byProject {
+		items := recent[ns]
+		sort.Slice(items, func(i, j int) bool {
+			return items[j].CreationTimestamp.Before(&items[i].CreationTimestamp)
+		})
+		if len(items) > dashboardRecentSessions {
+			items = items[:dashboardRecentSessions]
+		}
+		for _, s := range items {
+			phase := string(s.Status.Phase)
+			if phase == "" {
+				phase = string(apiv1alpha1.SessionPhasePending)
+			}
+			p.RecentSessions = append(p.RecentSessions, types.DashboardSession{
+				Name:              s.Name,
+				DisplayName:       s.Spec.DisplayName,
+				Phase:             phase,
+				Interactive:       s.Spec.Interactive,
+				CreationTimestamp: s.CreationTimestamp.Format(time.RFC3339),
+			})
+		}
+		if t, ok := lastActivity[ns]; ok {
+			p.LastActivity = t.UTC().Format(time.RFC3339)
+		}
+		resp.Projects = append(resp.Projects, *p)
+	}
+	sort.Slice(resp.Projects, func(i, j int) bool { return resp.Projects[i].Name < resp.Projects[j].Name })
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// dashboardAccessKey identifies the caller's token without keeping it in memory
+func dashboardAccessKey(c *gin.Context) string {
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		token = c.GetHeader("X-Forwarded-Access-Token")
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// dashboardAllowedNamespaces reports, per namespace, whether the caller can list
+// AgenticSessions there. Decisions are cached per token for dashboardAccessTTL and uncached
+// namespaces are reviewed concurrently.
+func dashboardAllowedNamespaces(ctx context.Context, userClient *kubernetes.Clientset, key string, namespaces []string) map[string]bool {
+	now := time.Now()
+	allowed := map[string]bool{}
+	var pending []string
+
+	dashboardAccessMu.Lock()
+	for k, a := range dashboardAccessCache {
+		if now.After(a.expires) {
+			delete(dashboardAccessCache, k)
+		}
+	}
+	for _, ns := range namespaces {
+		if a, ok := dashboardAccessCache[key+"/"+ns]; ok {
+			allowed[ns] = a.allowed
+			continue
+		}
+		pending = append(pending, ns)
+	}
+	dashboardAccessMu.Unlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, dashboardAccessWorkers)
+	for _, ns := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ns string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ok, err := checkUserCanListSessions(ctx, userClient, ns)
+			if err != nil {
+				// Not cached, so a transient failure only hides the project for this request
+				log.Printf("GetDashboard: access review failed for namespace %s: %v", ns, err)
+				return
+			}
+			mu.Lock()
+			allowed[ns] = ok
+			mu.Unlock()
+			dashboardAccessMu.Lock()
+			dashboardAccessCache[key+"/"+ns] = dashboardAccess{allowed: ok, expires: now.Add(dashboardAccessTTL)}
+			dashboardAccessMu.Unlock()
+		}(ns)
+	}
+	wg.Wait()
+	return allowed
+}
+
+// checkUserCanListSessions checks if user can LIST agenticsessions in the namespace
+func checkUserCanListSessions(ctx context.Context, userClient *kubernetes.Clientset, namespace string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Group:     "vteam.ambient-code",
+				Resource:  "agenticsessions",
+			},
+		},
+	}
+
+	result, err := userClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, v1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return result.Status.Allowed, nil
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ambient-code-backend/prompttemplate"
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// A fan-out creates one session per parameter set from a single create-request template
+// ("apply this fix across all our microservices"). Every session goes through createSession,
+// so prompt policy, system prompt, quota and maintenance apply to each; the sessions share a
+// batch label so their progress can be read back in one call.
+
+const (
+	// fanoutLabel groups a batch's sessions; fanoutIndexAnnotation and fanoutParamsAnnotation
+	// record which parameter set each was created from
+	fanoutLabel            = apiv1alpha1.SessionBatchLabel
+	fanoutIndexAnnotation  = "ambient-code.io/fanout-index"
+	fanoutParamsAnnotation = "ambient-code.io/fanout-parameters"
+
+	// maxFanoutSessions bounds the parameter sets of one request
+	maxFanoutSessions = 50
+)
+
+var fanoutBatchPattern = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}-[0-9a-f]{8}$`)
+
+// fanoutTemplateData holds the variables available to a fan-out template's string values
+type fanoutTemplateData struct {
+	Project string
+	User    string
+	Index   int
+	Params  map[string]string
+}
+
+// FanoutSessions handles POST /api/projects/:projectName/agentic-sessions/fanout. Every
+// parameter set is rendered and validated before any session is created; creation stops at
+// the first quota or maintenance rejection and the remaining sets are reported as not created.
+func FanoutSessions(c *gin.Context) {
+	project := c.GetString("project")
+	var req types.FanoutSessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Parameters) == 0 || len(req.Parameters) > maxFanoutSessions {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("parameters must list between 1 and %d parameter sets", maxFanoutSessions)})
+		return
+	}
+	var tmpl map[string]interface{}
+	if err := json.Unmarshal(req.Template, &tmpl); err != nil || tmpl == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "template must be a session create request object"})
+		return
+	}
+
+	if len(req.Variables) > 0 {
+		if err := prompttemplate.ValidateDeclarations(req.Variables); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("variables: %v", err)})
+			return
+		}
+		if err := checkFanoutTemplate(tmpl, "template", req.Variables); err != nil {
+			respondInvalidVariables(c, "", err)
+			return
+		}
+	}
+
+	batch := newRecordID()
+	bodies := make([][]byte, len(req.Parameters))
+	for i, params := range req.Parameters {
+		if len(req.Variables) > 0 {
+			resolved, err := resolveFanoutParameters(req.Variables, params)
+			if err != nil {
+				respondInvalidVariables(c, fmt.Sprintf("parameter set %d: ", i), err)
+				return
+			}
+			params = resolved
+			req.Parameters[i] = resolved
+		}
+		body, err := renderFanoutRequest(tmpl, fanoutTemplateData{Project: project, User: c.GetString("userID"), Index: i, Params: params}, batch)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("parameter set %d: %v", i, err)})
+			return
+		}
+		bodies[i] = body
+	}
+
+	log.Printf("Fanning out %d sessions in project %s for %s (batch %s)", len(bodies), project, c.GetString("userID"), batch)
+	results := make([]types.FanoutSessionResult, len(bodies))
+	created, firstFailure := 0, 0
+	stopped := false
+	for i, body := range bodies {
+		results[i] = types.FanoutSessionResult{Index: i, Parameters: req.Parameters[i]}
+		if stopped {
+			results[i].Error = "not created: an earlier session was rejected"
+			continue
+		}
+		status, resp := createFanoutSession(c, project, body)
+		results[i].Status = status
+		if status == http.StatusCreated {
+			results[i].Name, _ = resp["name"].(string)
+			created++
+			continue
+		}
+		results[i].Error, _ = resp["error"].(string)
+		if firstFailure == 0 {
+			firstFailure = status
+		}
+		// Quota and maintenance reject every later session too
+		stopped = status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+	}
+
+	status := http.StatusCreated
+	switch {
+	case created == 0:
+		status = firstFailure
+	case created < len(bodies):
+		status = http.StatusMultiStatus
+	}
+	resp := types.FanoutBatch{ID: batch, Total: len(bodies), Created: created, Sessions: results}
+	if created > 0 {
+		resp.SessionBatch = createFanoutSessionBatch(c, project, batch, created)
+	}
+	c.JSON(status, resp)
+}
+
+// GetFanoutBatch handles GET /api/projects/:projectName/agentic-sessions/fanout/:batchId and
+// reports the phase of each session in the batch, using the caller's credentials
+func GetFanoutBatch(c *gin.Context) {
+	project := c.GetString("project")
+	batch := c.Param("batchId")
+	if !fanoutBatchPattern.MatchString(batch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid batch ID"})
+		return
+	}
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	list, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).List(c.Request.Context(), v1.ListOptions{
+		LabelSelector: fanoutLabel + "=" + batch,
+	})
+	if err != nil {
+		log.Printf("Failed to list fan-out batch %s in project %s: %v", batch, project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+	if len(list.Items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fan-out batch not found"})
+		return
+	}
+	c.JSON(http.StatusOK, fanoutBatchStatus(batch, list.Items))
+}
+
+// fanoutBatchStatus aggregates the batch's sessions, ordered by parameter set. Sessions deleted
+// since the fan-out are simply absent. The batch is done once every session is terminal.
+func fanoutBatchStatus(batch string, items []unstructured.Unstructured) types.FanoutBatch {
+	out := types.FanoutBatch{ID: batch, Total: len(items), Created: len(items), Phases: map[string]int{}, Done: true}
+	for _, item := range items {
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		if phase == "" {
+			phase = string(apiv1alpha1.SessionPhasePending)
+		}
+		out.Phases[phase]++
+		if !apiv1alpha1.AgenticSessionPhase(phase).IsTerminal() {
+			out.Done = false
+		}
+		result := types.FanoutSessionResult{Name: item.GetName(), Phase: phase}
+		annotations := item.GetAnnotations()
+		result.Index, _ = strconv.Atoi(annotations[fanoutIndexAnnotation])
+		_ = json.Unmarshal([]byte(annotations[fanoutParamsAnnotation]), &result.Parameters)
+		out.Sessions = append(out.Sessions, result)
+	}
+	sort.Slice(out.Sessions, func(i, j int) bool { return out.Sessions[i].Index < out.Sessions[j].Index })
+	return out
+}
+
+// createFanoutSessionBatch creates the SessionBatch the operator aggregates the batch's
+// sessions into and returns its name. The batch is usable without it (GetFanoutBatch reads
+// the labels), so a failure, e.g. a cluster without the CRD, is only logged.
+func createFanoutSessionBatch(c *gin.Context, project, batch string, created int) string {
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		return ""
+	}
+	name := apiv1alpha1.SessionBatchNamePrefix + batch
+	spec := apiv1alpha1.SessionBatchSpec{
+		Selector:         &v1.LabelSelector{MatchLabels: map[string]string{fanoutLabel: batch}},
+		ExpectedSessions: created,
+	}
+	if _, err := createSessionBatch(c, reqDyn, project, name, spec); err != nil {
+		log.Printf("Failed to create SessionBatch %s in project %s: %v", name, project, err)
+		return ""
+	}
+	return name
+}
+
+// renderFanoutRequest renders every string value of the template for one parameter set and
+// returns the create request body, labelled with the batch
+func renderFanoutRequest(tmpl map[string]interface{}, data fanoutTemplateData, batch string) ([]byte, error) {
+	rendered, err := renderFanoutValue(tmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := json.Marshal(rendered)
+	var create types.CreateAgenticSessionRequest
+	if err := json.Unmarshal(raw, &create); err != nil {
+		return nil, fmt.Errorf("invalid session request: %v", err)
+	}
+	if strings.TrimSpace(create.Prompt) == "" {
+		return nil, fmt.Errorf("prompt rendered empty")
+	}
+	if create.Labels == nil {
+		create.Labels = map[string]string{}
+	}
+	create.Labels[fanoutLabel] = batch
+	if create.Annotations == nil {
+		create.Annotations = map[string]string{}
+	}
+	recorded, _ := json.Marshal(data.Params)
+	create.Annotations[fanoutIndexAnnotation] = strconv.Itoa(data.Index)
+	create.Annotations[fanoutParamsAnnotation] = string(recorded)
+	return json.Marshal(create)
+}
+
+// renderFanoutValue renders string values, recursing into objects and arrays; a missing
+// parameter is an error rather than an empty string
+func renderFanoutValue(v interface{}, data fanoutTemplateData) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if !strings.Contains(val, "{{") {
+			return val, nil
+		}
+		t, err := prompttemplate.Parse("fanout", val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %v", err)
+		}
+		out, err := t.Execute(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template: %v", err)
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			r, err := renderFanoutValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			r, err := renderFanoutValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// createFanoutSession creates the session for one rendered body and returns the status and
+// body CreateSession would respond with
+func createFanoutSession(c *gin.Context, project string, body []byte) (int, gin.H) {
+	var req types.CreateAgenticSessionRequest
+	if err := binding.JSON.BindBody(body, &req); err != nil {
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	}
+	return createSession(c, project, req)
+}
+
+// checkFanoutTemplate reports every parameter the template's string values use without
+// declaring it. Each value is parsed under its JSON path, so positions read like
+// template.prompt:1:12.
+func checkFanoutTemplate(v interface{}, path string, vars []prompttemplate.Variable) error {
+	var errs prompttemplate.Errors
+	switch val := v.(type) {
+	case string:
+		if !strings.Contains(val, "{{") {
+			return nil
+		}
+		t, err := prompttemplate.Parse(path, val)
+		if err != nil {
+			return fmt.Errorf("invalid template: %v", err)
+		}
+		return t.Check(vars)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := checkFanoutTemplate(val[k], path+"."+k, vars); err != nil {
+				more, ok := err.(prompttemplate.Errors)
+				if !ok {
+					return err
+				}
+				errs = append(errs, more...)
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			if err := checkFanoutTemplate(item, fmt.Sprintf("%s[%d]", path, i), vars); err != nil {
+				more, ok := err.(prompttemplate.Errors)
+				if !ok {
+					return err
+				}
+				errs = append(errs, more...)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// resolveFanoutParameters checks one parameter set against the declared variables and
+// returns it with defaults filled in and values normalized, e.g. repository URLs
+func resolveFanoutParameters(vars []prompttemplate.Variable, params map[string]string) (map[string]string, error) {
+	supplied := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		supplied[k] = v
+	}
+	values, err := prompttemplate.Resolve(vars, supplied)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		out[k] = fmt.Sprint(v)
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"ambient-code-backend/prompttemplate"
+	"ambient-code-backend/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRenderFanoutRequest(t *testing.T) {
+	var tmpl map[string]interface{}
+	_ = json.Unmarshal([]byte(`{
+		"prompt": "Bump {{.Params.dep}} in {{.Params.repo}} ({{.Index}} of project {{.Project}})",
+		"displayName": "bump-{{.Index}}",
+		"repos": [{"input": {"url": "https://github.com/acme/{{.Params.repo}}"}}],
+		"timeout": 600,
+		"labels": {"team": "platform"}
+	}`), &tmpl)
+	data := fanoutTemplateData{Project: "p1", Index: 3, Params: map[string]string{"repo": "api", "dep": "go"}}
+
+	body, err := renderFanoutRequest(tmpl, data, "20261016-120000-0a1b2c3d")
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	var got types.CreateAgenticSessionRequest
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Prompt != "Bump go in api (3 of project p1)" || got.DisplayName != "bump-3" {
+		t.Errorf("prompt %q, displayName %q", got.Prompt, got.DisplayName)
+	}
+	if len(got.Repos) != 1 || got.Repos[0].Input.URL != "https://github.com/acme/api" {
+		t.Errorf("repos = %+v", got.Repos)
+	}
+	if got.Timeout == nil || *got.Timeout != 600 {
+		t.Errorf("non-string value not kept: timeout = %v", got.Timeout)
+	}
+	if got.Labels["team"] != "platform" || got.Labels[fanoutLabel] != "20261016-120000-0a1b2c3d" {
+		t.Errorf("labels = %v", got.Labels)
+	}
+	if got.Annotations[fanoutIndexAnnotation] != "3" || !strings.Contains(got.Annotations[fanoutParamsAnnotation], `"repo":"api"`) {
+		t.Errorf("annotations = %v", got.Annotations)
+	}
+
+	// Missing parameters and empty prompts are rejected rather than rendered blank
+	data.Params = map[string]string{"repo": "api"}
+	if _, err := renderFanoutRequest(tmpl, data, "b"); err == nil {
+		t.Error("missing parameter accepted")
+	}
+	empty := map[string]interface{}{"prompt": "{{.Params.prompt}}"}
+	if _, err := renderFanoutRequest(empty, fanoutTemplateData{Params: map[string]string{"prompt": " "}}, "b"); err == nil {
+		t.Error("empty prompt accepted")
+	}
+}
+
+// TestFanoutVariables verifies declared variables catch undeclared template uses with their
+// JSON path, and fill in defaults for each parameter set
+func TestFanoutVariables(t *testing.T) {
+	tmpl := map[string]interface{}{
+		"prompt": "Bump {{.Params.dep}} in {{.Params.repo}}",
+		"repos":  []interface{}{map[string]interface{}{"input": map[string]interface{}{"url": "{{.Params.url}}"}}},
+	}
+	vars := []prompttemplate.Variable{{Name: "repo", Required: true}, {Name: "dep", Default: "go"}}
+	errs, ok := checkFanoutTemplate(tmpl, "template", vars).(prompttemplate.Errors)
+	if !ok || len(errs) != 1 || errs[0].Variable != "url" || !strings.HasPrefix(errs[0].Position, "template.repos[0].input.url:1:") {
+		t.Fatalf("unexpected errors %+v", errs)
+	}
+
+	params, err := resolveFanoutParameters(vars, map[string]string{"repo": "api"})
+	if err != nil || params["dep"] != "go" {
+		t.Errorf("defaults not applied: %v, %v", params, err)
+	}
+	if _, err := resolveFanoutParameters(vars, map[string]string{"dep": "npm"}); err == nil || !strings.Contains(err.Error(), `"repo" is required`) {
+		t.Errorf("missing required variable accepted: %v", err)
+	}
+}
+
+func TestFanoutBatchStatus(t *testing.T) {
+	session := func(name, index, phase string) unstructured.Unstructured {
+		u := unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetName(name)
+		u.SetAnnotations(map[string]string{fanoutIndexAnnotation: index, fanoutParamsAnnotation: `{"repo":"r` + index + `"}`})
+		if phase != "" {
+			_ = unstructured.SetNestedField(u.Object, phase, "status", "phase")
+		}
+		return u
+	}
+
+	got := fanoutBatchStatus("b", []unstructured.Unstructured{
+		session("s2", "2", "Running"), session("s0", "0", "Completed"), session("s1", "1", ""),
+	})
+	if got.Total != 3 || got.Done || got.Phases["Running"] != 1 || got.Phases["Pending"] != 1 || got.Phases["Completed"] != 1 {
+		t.Errorf("batch = %+v", got)
+	}
+	for i, s := range got.Sessions {
+		if s.Index != i || s.Parameters["repo"] != "r"+s.Name[1:] {
+			t.Errorf("session %d = %+v", i, s)
+		}
+	}
+
+	done := fanoutBatchStatus("b", []unstructured.Unstructured{session("s0", "0", "Completed"), session("s1", "1", "Failed")})
+	if !done.Done {
+		t.Error("batch of terminal sessions not done")
+	}
+}
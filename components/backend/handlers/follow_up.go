@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// userPromptAnnotation keeps what the user asked, before the project system prompt and
+	// the thread's context were prepended, for the follow-ups that quote the session
+	userPromptAnnotation = "ambient-code.io/user-prompt"
+
+	// defaultFollowUpContextLength is the default of ProjectSettings followUps.maxContextLength
+	defaultFollowUpContextLength = 20000
+
+	// maxThreadDepth bounds how many earlier sessions a follow-up is told about
+	maxThreadDepth = 10
+)
+
+// errParentNotFound is returned when a follow-up names a session the caller cannot find
+var errParentNotFound = fmt.Errorf("parent session not found")
+
+// followUpPolicy returns ProjectSettings spec.followUps with its defaults applied
+func followUpPolicy(ctx context.Context, project string) (apiv1alpha1.FollowUpPolicy, error) {
+	policy := apiv1alpha1.FollowUpPolicy{Context: apiv1alpha1.FollowUpContextSummary, MaxContextLength: defaultFollowUpContextLength}
+	if VteamClient == nil {
+		return policy, nil
+	}
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return policy, nil
+		}
+		return policy, fmt.Errorf("failed to read project settings: %w", err)
+	}
+	if p := ps.Spec.FollowUps; p != nil {
+		if p.Context != "" {
+			policy.Context = p.Context
+		}
+		if p.MaxContextLength > 0 {
+			policy.MaxContextLength = p.MaxContextLength
+		}
+	}
+	return policy, nil
+}
+
+// sessionAncestors returns parent and the sessions it follows up on, oldest first, read
+// with the caller's client. Ancestors that were deleted end the thread.
+func sessionAncestors(ctx context.Context, dyn dynamic.Interface, project, parent string) ([]*apiv1alpha1.AgenticSession, error) {
+	var thread []*apiv1alpha1.AgenticSession
+	seen := map[string]bool{}
+	for name := parent; name != "" && !seen[name] && len(thread) < maxThreadDepth; {
+		seen[name] = true
+		obj, err := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(ctx, name, v1.GetOptions{})
+		if errors.IsNotFound(err) && len(thread) > 0 {
+			break
+		}
+		if errors.IsNotFound(err) {
+			return nil, errParentNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session %s: %w", name, err)
+		}
+		session := &apiv1alpha1.AgenticSession{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, session); err != nil {
+			return nil, fmt.Errorf("failed to read session %s: %w", name, err)
+		}
+		thread = append([]*apiv1alpha1.AgenticSession{session}, thread...)
+		name = session.Spec.ParentSession
+	}
+	return thread, nil
+}
+
+// buildFollowUpContext renders what a follow-up is told of thread (oldest first, its
+// parent last) in at most limit characters. Transcript adds the parent's conversation, as
+// returned by transcript. Earlier sessions are dropped first when the limit is reached.
+func buildFollowUpContext(thread []*apiv1alpha1.AgenticSession, mode apiv1alpha1.FollowUpContext, limit int, transcript func(name string) string) string {
+	if len(thread) == 0 || mode == apiv1alpha1.FollowUpContextNone {
+		return ""
+	}
+	const footer = "\n\nThe follow-up request:"
+	header := "This session follows up on earlier sessions in its thread, oldest first.\n\n"
+	omittedHeader := func(n int) string {
+		return fmt.Sprintf("This session follows up on earlier sessions in its thread, oldest first (%d earlier omitted).\n\n", n)
+	}
+	// Room is kept for the longer header naming omitted sessions
+	budget := limit - runeLen(omittedHeader(len(thread))) - runeLen(footer)
+	if budget <= 0 {
+		return ""
+	}
+
+	parent := thread[len(thread)-1]
+	last := clipRunes(summarizeThreadSession(parent), budget)
+	if mode == apiv1alpha1.FollowUpContextTranscript && transcript != nil {
+		if conv := transcript(parent.Name); conv != "" {
+			const label = "\nConversation:\n"
+			if room := budget - runeLen(last) - runeLen(label); room > 0 {
+				last += label + tailRunes(conv, room)
+			}
+		}
+	}
+
+	entries := []string{last}
+	used := runeLen(last)
+	omitted := 0
+	for i := len(thread) - 2; i >= 0; i-- {
+		entry := summarizeThreadSession(thread[i])
+		if used+runeLen(entry)+2 > budget {
+			omitted = i + 1
+			break
+		}
+		entries = append([]string{entry}, entries...)
+		used += runeLen(entry) + 2
+	}
+	if omitted > 0 {
+		header = omittedHeader(omitted)
+	}
+	return header + strings.Join(entries, "\n\n") + footer
+}
+
+// summarizeThreadSession is a session's entry in a follow-up's context: its request and
+// its result
+func summarizeThreadSession(s *apiv1alpha1.AgenticSession) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Session %s", s.Name)
+	if s.Spec.DisplayName != "" {
+		fmt.Fprintf(&b, " (%s)", s.Spec.DisplayName)
+	}
+	request := s.Annotations[userPromptAnnotation]
+	if request == "" {
+		request = s.Spec.Prompt
+	}
+	fmt.Fprintf(&b, "\nRequest: %s", strings.TrimSpace(request))
+	r := s.Status.Result
+	if r == nil {
+		if s.Status.Phase != "" {
+			fmt.Fprintf(&b, "\nOutcome: none reported (phase %s)", s.Status.Phase)
+		}
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\nOutcome: %s", r.Outcome)
+	if summary := strings.TrimSpace(r.Summary); summary != "" {
+		fmt.Fprintf(&b, "\nSummary: %s", summary)
+	}
+	if len(r.PRURLs) > 0 {
+		fmt.Fprintf(&b, "\nPull requests: %s", strings.Join(r.PRURLs, ", "))
+	}
+	for _, f := range r.FollowUps {
+		fmt.Fprintf(&b, "\nOpen follow-up: %s", f)
+	}
+	return b.String()
+}
+
+// sessionTranscript renders the user and assistant text of a session's persisted messages,
+// one line per message; tool calls are named without their input
+func sessionTranscript(name string) string {
+	f, err := os.Open(filepath.Join(StateBaseDir, "sessions", name, "messages.jsonl"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var m struct {
+			Type    string                 `json:"type"`
+			Payload map[string]interface{} `json:"payload"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &m) != nil {
+			continue
+		}
+		if line := transcriptLine(m.Type, m.Payload); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func transcriptLine(msgType string, payload map[string]interface{}) string {
+	switch msgType {
+	case "user_message", "user.message":
+		if text, _ := payload["content"].(string); strings.TrimSpace(text) != "" {
+			return "User: " + strings.TrimSpace(text)
+		}
+	case "agent.message":
+		if tool, ok := payload["tool"].(string); ok {
+			return "Assistant used " + tool
+		}
+		content, _ := payload["content"].(map[string]interface{})
+		if text, _ := content["text"].(string); strings.TrimSpace(text) != "" {
+			return "Assistant: " + strings.TrimSpace(text)
+		}
+	}
+	return ""
+}
+
+// applyFollowUpContext prepends context to the session's prompt
+func applyFollowUpContext(spec map[string]interface{}, context string) {
+	if context == "" {
+		return
+	}
+	prompt, _ := spec["prompt"].(string)
+	spec["prompt"] = context + "\n\n" + prompt
+}
+
+// sessionThread returns the sessions in the thread of the named session: its root, found
+// by following spec.parentSession, and everything that follows up on it, oldest first.
+// It returns nil when no session has that name.
+func sessionThread(items []unstructured.Unstructured, name string) []unstructured.Unstructured {
+	parents := make(map[string]string, len(items))
+	for _, item := range items {
+		parent, _, _ := unstructured.NestedString(item.Object, "spec", "parentSession")
+		parents[item.GetName()] = parent
+	}
+	if _, ok := parents[name]; !ok {
+		return nil
+	}
+	root := func(n string) string {
+		seen := map[string]bool{}
+		for {
+			parent, ok := parents[n]
+			if !ok || parent == "" || seen[n] {
+				return n
+			}
+			if _, exists := parents[parent]; !exists {
+				return n
+			}
+			seen[n] = true
+			n = parent
+		}
+	}
+	want := root(name)
+	thread := []unstructured.Unstructured{}
+	for _, item := range items {
+		if root(item.GetName()) == want {
+			thread = append(thread, item)
+		}
+	}
+	sort.SliceStable(thread, func(i, j int) bool {
+		return thread[i].GetCreationTimestamp().Time.Before(thread[j].GetCreationTimestamp().Time)
+	})
+	return thread
+}
+
+func runeLen(s string) int {
+	return len([]rune(s))
+}
+
+// clipRunes keeps the first n characters of s
+func clipRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(r[:n])
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// tailRunes keeps the last n characters of s
+func tailRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(r[len(r)-n:])
+	}
+	return "…" + string(r[len(r)-n+1:])
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TestBuildFollowUpContext verifies the thread is quoted oldest first with each session's
+// own request, the oldest sessions are dropped to fit, and Transcript adds the parent's tail
+func TestBuildFollowUpContext(t *testing.T) {
+	thread := []*apiv1alpha1.AgenticSession{
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "s1"},
+			Spec:       apiv1alpha1.AgenticSessionSpec{Prompt: "Add retries to the client"},
+			Status:     apiv1alpha1.AgenticSessionStatus{Result: &apiv1alpha1.SessionResult{Outcome: apiv1alpha1.OutcomePartial, FollowUps: []string{"cover timeouts"}}},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "s2", Annotations: map[string]string{userPromptAnnotation: "Now cover timeouts"}},
+			Spec:       apiv1alpha1.AgenticSessionSpec{Prompt: "SYSTEM PROMPT\n\nNow cover timeouts", DisplayName: "Timeouts"},
+			Status: apiv1alpha1.AgenticSessionStatus{Result: &apiv1alpha1.SessionResult{
+				Outcome: apiv1alpha1.OutcomeSucceeded, Summary: "Added timeout tests", PRURLs: []string{"https://github.com/org/repo/pull/7"},
+			}},
+		},
+	}
+
+	got := buildFollowUpContext(thread, apiv1alpha1.FollowUpContextSummary, defaultFollowUpContextLength, nil)
+	first, second := strings.Index(got, "Session s1\nRequest: Add retries"), strings.Index(got, "Session s2 (Timeouts)\nRequest: Now cover timeouts\n")
+	if first < 0 || second < first || strings.Contains(got, "SYSTEM PROMPT") {
+		t.Fatalf("context = %q", got)
+	}
+	for _, want := range []string{"Open follow-up: cover timeouts", "Pull requests: https://github.com/org/repo/pull/7", "The follow-up request:"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("context lacks %q", want)
+		}
+	}
+
+	short := buildFollowUpContext(thread, apiv1alpha1.FollowUpContextSummary, runeLen(got)-20, nil)
+	if strings.Contains(short, "Session s1") || !strings.Contains(short, "1 earlier omitted") || runeLen(short) > runeLen(got)-20 {
+		t.Errorf("context over the limit = %q", short)
+	}
+
+	transcript := func(name string) string {
+		return strings.Repeat("User: earlier\n", 500) + "Assistant: the last word"
+	}
+	long := buildFollowUpContext(thread, apiv1alpha1.FollowUpContextTranscript, 2000, transcript)
+	if runeLen(long) > 2000 || !strings.Contains(long, "Conversation:\n…") || !strings.Contains(long, "the last word\n\nThe follow-up request:") {
+		t.Errorf("transcript context = %q", long)
+	}
+
+	if none := buildFollowUpContext(thread, apiv1alpha1.FollowUpContextNone, defaultFollowUpContextLength, nil); none != "" {
+		t.Errorf("None context = %q", none)
+	}
+}
+
+func TestSessionThread(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	session := func(name, parent string, minute int) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{}}}
+		obj.SetName(name)
+		obj.SetCreationTimestamp(v1.NewTime(base.Add(time.Duration(minute) * time.Minute)))
+		if parent != "" {
+			obj.Object["spec"].(map[string]interface{})["parentSession"] = parent
+		}
+		return obj
+	}
+	items := []unstructured.Unstructured{
+		session("reply-b", "root", 3),
+		session("root", "", 0),
+		session("other", "", 1),
+		session("reply-a", "root", 2),
+		session("reply-a-1", "reply-a", 4),
+		session("orphan", "deleted", 5),
+	}
+
+	var names []string
+	for _, item := range sessionThread(items, "reply-a-1") {
+		names = append(names, item.GetName())
+	}
+	if strings.Join(names, ",") != "root,reply-a,reply-b,reply-a-1" {
+		t.Errorf("thread = %v", names)
+	}
+	if got := sessionThread(items, "orphan"); len(got) != 1 {
+		t.Errorf("thread of a session whose parent was deleted = %d sessions", len(got))
+	}
+	if got := sessionThread(items, "missing"); got != nil {
+		t.Errorf("thread of a missing session = %v", got)
+	}
+}
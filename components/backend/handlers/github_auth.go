@@ -15,6 +15,8 @@ import (
 	"strings"
 	"time"
 
+	"ambient-code-backend/breaker"
+
 	"github.com/gin-gonic/gin"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -84,7 +86,18 @@ func doGitHubRequest(ctx context.Context, method string, url string, authHeader
 		}
 	}
 	client := &http.Client{Timeout: 15 * time.Second}
-	return client.Do(req)
+	return breaker.GitHub.Do(client, req)
+}
+
+// respondGitHubRequestFailed writes the error for a GitHub API call that got no response.
+// While the GitHub circuit is open the call failed fast; clients get 503 and when to retry.
+func respondGitHubRequestFailed(c *gin.Context, err error) {
+	if breaker.IsOpen(err) {
+		c.Header("Retry-After", strconv.Itoa(int(breaker.GitHub.RetryAfter().Seconds())+1))
+		respondMessage(c, http.StatusServiceUnavailable, "github.unavailable", nil)
+		return
+	}
+	respondMessage(c, http.StatusBadGateway, "github.requestFailed", gin.H{"Error": err.Error()})
 }
 
 // ===== OAuth during installation (user verification) =====
@@ -217,7 +230,7 @@ func exchangeOAuthCodeForUserToken(clientID, clientSecret, code string) (string,
 	req, _ := http.NewRequest(http.MethodPost, "https://github.com/login/oauth/access_token", reqBody)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := breaker.GitHub.Do(http.DefaultClient, req)
 	if err != nil {
 		return "", err
 	}
@@ -239,7 +252,7 @@ func userOwnsInstallation(userToken string, installationID int64) (bool, string,
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("Authorization", "token "+userToken)
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := breaker.GitHub.Do(http.DefaultClient, req)
 	if err != nil {
 		return false, "", err
 	}
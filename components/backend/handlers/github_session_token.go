@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/gitutil"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Runners authenticate to GitHub with short-lived GitHub App installation tokens instead of
+// long-lived PATs. For every active session whose creator linked a GitHub App installation,
+// the backend mints a token scoped to the session's repositories and keeps it in the Secret
+// ambient-github-token-<session>, refreshing it well before it expires. The operator mounts
+// that Secret into the runner Job; the kubelet propagates refreshes to the mounted file.
+
+const (
+	// sessionGitHubTokenKey and sessionGitHubExpiresKey are the keys of the session token Secret
+	sessionGitHubTokenKey   = "GITHUB_TOKEN"
+	sessionGitHubExpiresKey = "expiresAt"
+
+	// sessionGitHubTokenRefresh is how long before expiry a token is replaced. Installation
+	// tokens live one hour; the kubelet can take a minute or two to update mounted Secrets.
+	sessionGitHubTokenRefresh = 20 * time.Minute
+	// sessionGitHubTokenInterval is how often active sessions are checked
+	sessionGitHubTokenInterval = time.Minute
+)
+
+// MintSessionGitHubAppToken mints a GitHub App installation token for the user's linked
+// installation, limited to the given "owner/name" repositories. Set by main; nil when the
+// GitHub App is not configured.
+var MintSessionGitHubAppToken func(ctx context.Context, userID string, repos []string) (string, time.Time, error)
+
+func sessionGitHubTokenSecretName(session string) string {
+	return "ambient-github-token-" + session
+}
+
+// sessionGitHubRepos returns the GitHub repositories a session reads from or pushes to
+func sessionGitHubRepos(spec *apiv1alpha1.AgenticSessionSpec) []string {
+	seen := map[string]bool{}
+	var repos []string
+	add := func(raw string) {
+		r, err := gitutil.Parse(raw)
+		if err != nil || r.Provider != gitutil.ProviderGitHub || seen[r.FullName()] {
+			return
+		}
+		seen[r.FullName()] = true
+		repos = append(repos, r.FullName())
+	}
+	for _, repo := range spec.Repos {
+		add(repo.Input.URL)
+		if repo.Output != nil {
+			add(repo.Output.URL)
+		}
+	}
+	return repos
+}
+
+// mintSessionGitHubToken mints a repository-scoped App token for a session's creator
+func mintSessionGitHubToken(ctx context.Context, session *apiv1alpha1.AgenticSession) (string, time.Time, bool) {
+	if MintSessionGitHubAppToken == nil || session.Spec.UserContext == nil {
+		return "", time.Time{}, false
+	}
+	userID := strings.TrimSpace(session.Spec.UserContext.UserID)
+	repos := sessionGitHubRepos(&session.Spec)
+	if userID == "" || len(repos) == 0 {
+		return "", time.Time{}, false
+	}
+	token, expiresAt, err := MintSessionGitHubAppToken(ctx, userID, repos)
+	if err != nil {
+		// Users without a linked installation fall back to the project's integration secret
+		if !strings.Contains(err.Error(), "installation not found") {
+			log.Printf("GitHub token for session %s/%s: %v", session.Namespace, session.Name, err)
+		}
+		return "", time.Time{}, false
+	}
+	return token, expiresAt, true
+}
+
+// syncSessionGitHubToken creates or refreshes the GitHub token Secret of an active session
+func syncSessionGitHubToken(ctx context.Context, session *apiv1alpha1.AgenticSession) {
+	switch session.Status.Phase {
+	case "", apiv1alpha1.SessionPhasePending, apiv1alpha1.SessionPhaseCreating, apiv1alpha1.SessionPhaseRunning:
+	default:
+		return
+	}
+	if session.DeletionTimestamp != nil {
+		return
+	}
+	name := sessionGitHubTokenSecretName(session.Name)
+	existing, err := K8sClient.CoreV1().Secrets(session.Namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("GitHub token for session %s/%s: failed to read secret: %v", session.Namespace, session.Name, err)
+		return
+	}
+	found := err == nil
+	if found {
+		if exp, perr := time.Parse(time.RFC3339, string(existing.Data[sessionGitHubExpiresKey])); perr == nil && time.Until(exp) > sessionGitHubTokenRefresh {
+			return
+		}
+	}
+
+	token, expiresAt, ok := mintSessionGitHubToken(ctx, session)
+	if !ok {
+		return
+	}
+	data := map[string][]byte{
+		sessionGitHubTokenKey:   []byte(token),
+		sessionGitHubExpiresKey: []byte(expiresAt.UTC().Format(time.RFC3339)),
+	}
+	if found {
+		existing.Data = data
+		if _, err := K8sClient.CoreV1().Secrets(session.Namespace).Update(ctx, existing, v1.UpdateOptions{}); err != nil {
+			log.Printf("GitHub token for session %s/%s: failed to update secret: %v", session.Namespace, session.Name, err)
+		}
+		return
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: session.Namespace,
+			Labels:    map[string]string{"agentic-session": session.Name},
+			OwnerReferences: []v1.OwnerReference{{
+				APIVersion: "vteam.ambient-code/v1alpha1",
+				Kind:       "AgenticSession",
+				Name:       session.Name,
+				UID:        session.UID,
+				Controller: types.BoolPtr(true),
+			}},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	if _, err := K8sClient.CoreV1().Secrets(session.Namespace).Create(ctx, secret, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		log.Printf("GitHub token for session %s/%s: failed to create secret: %v", session.Namespace, session.Name, err)
+	}
+}
+
+// StartSessionGitHubTokenRefresher keeps the GitHub token Secrets of active sessions fresh.
+// Blocks until ctx is cancelled.
+func StartSessionGitHubTokenRefresher(ctx context.Context) {
+	if MintSessionGitHubAppToken == nil || VteamClient == nil || K8sClient == nil {
+		log.Printf("Session GitHub token refresher disabled: GitHub App or backend SA clients not initialized")
+		return
+	}
+	ticker := time.NewTicker(sessionGitHubTokenInterval)
+	defer ticker.Stop()
+	for {
+		refreshSessionGitHubTokens(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sessionFromUnstructured converts an informer object for syncSessionGitHubToken
+func sessionFromUnstructured(obj *unstructured.Unstructured) (*apiv1alpha1.AgenticSession, error) {
+	session := &apiv1alpha1.AgenticSession{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func refreshSessionGitHubTokens(ctx context.Context) {
+	list, err := VteamClient.VteamV1alpha1().AgenticSessions("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("Session GitHub token refresher: failed to list sessions: %v", err)
+		return
+	}
+	for i := range list.Items {
+		syncSessionGitHubToken(ctx, &list.Items[i])
+	}
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/gitutil"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// A project can be kept in Git as a kustomize directory:
+//
+//	kustomization.yaml   lists the files below; namespace is the project
+//	namespace.yaml       the project namespace (for bootstrapping with kubectl or Argo CD)
+//	projectsettings.yaml the ProjectSettings, including the system prompt and review templates
+//	rbac.yaml            the members, as Ambient permission RoleBindings
+//
+// GET /projects/:projectName/gitops-bundle exports it. With spec.gitOps set in the settings,
+// pushes to the repository's branch call the webhook, which imports the directory: the
+// settings and the members are replaced by what the repository declares.
+
+// gitOpsWebhookSecretKey is the key of the spec.gitOps.webhookSecret Secret
+const gitOpsWebhookSecretKey = "secret"
+
+// gitOpsImportMaxBytes bounds webhook payloads and imported files
+const gitOpsImportMaxBytes = 5 << 20
+
+// GetGitOpsBundle handles GET /api/projects/:projectName/gitops-bundle
+// The response is a gzipped tarball with a single <project>/ directory.
+func GetGitOpsBundle(c *gin.Context) {
+	projectName := c.Param("projectName")
+	if !requireSettingsAccess(c, projectName, false) {
+		return
+	}
+	if K8sClientProjects == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Backend client not initialized"})
+		return
+	}
+	_, reqDyn := GetK8sClientsForRequest(c)
+	ctx := c.Request.Context()
+
+	ns, err := K8sClientProjects.CoreV1().Namespaces().Get(ctx, projectName, v1.GetOptions{})
+	if err != nil || ns.Labels["ambient-code.io/managed"] != "true" {
+		if err != nil && !errors.IsNotFound(err) {
+			log.Printf("GetGitOpsBundle: failed to get Namespace %s: %v", projectName, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+			return
+		}
+		respondMessage(c, http.StatusNotFound, "project.notFound", nil)
+		return
+	}
+	settings, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(projectName).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project settings not found"})
+			return
+		}
+		log.Printf("GetGitOpsBundle: failed to get ProjectSettings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"})
+		return
+	}
+	rbs, err := K8sClientProjects.RbacV1().RoleBindings(projectName).List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("GetGitOpsBundle: failed to list RoleBindings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list project members"})
+		return
+	}
+
+	files, err := buildGitOpsBundle(ns, settings, collectPermissionAssignments(rbs.Items))
+	if err != nil {
+		log.Printf("GetGitOpsBundle: failed to render bundle for %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render bundle"})
+		return
+	}
+	archive, err := tarGitOpsBundle(projectName, files)
+	if err != nil {
+		log.Printf("GetGitOpsBundle: failed to archive bundle for %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render bundle"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", projectName+"-gitops.tar.gz"))
+	c.Data(http.StatusOK, "application/gzip", archive)
+}
+
+// buildGitOpsBundle renders the bundle files, keyed by name
+func buildGitOpsBundle(ns *corev1.Namespace, settings *unstructured.Unstructured, members []PermissionAssignment) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	render := func(name string, docs ...interface{}) error {
+		var b bytes.Buffer
+		for i, doc := range docs {
+			out, err := yaml.Marshal(doc)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if i > 0 {
+				b.WriteString("---\n")
+			}
+			b.Write(out)
+		}
+		files[name] = b.Bytes()
+		return nil
+	}
+
+	if err := render("kustomization.yaml", map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"namespace":  ns.Name,
+		"resources":  []string{"namespace.yaml", "projectsettings.yaml", "rbac.yaml"},
+	}); err != nil {
+		return nil, err
+	}
+
+	nsMeta := map[string]interface{}{
+		"name":   ns.Name,
+		"labels": map[string]string{"ambient-code.io/managed": "true"},
+	}
+	annotations := map[string]string{}
+	for _, key := range []string{"openshift.io/display-name", "openshift.io/description"} {
+		if v := ns.Annotations[key]; v != "" {
+			annotations[key] = v
+		}
+	}
+	if len(annotations) > 0 {
+		nsMeta["annotations"] = annotations
+	}
+	if err := render("namespace.yaml", map[string]interface{}{"apiVersion": "v1", "kind": "Namespace", "metadata": nsMeta}); err != nil {
+		return nil, err
+	}
+
+	if err := render("projectsettings.yaml", gitOpsManifest(settings)); err != nil {
+		return nil, err
+	}
+
+	// Rebuilt from the assignments so the files are canonical and diff cleanly
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].SubjectType != members[j].SubjectType {
+			return members[i].SubjectType < members[j].SubjectType
+		}
+		if members[i].SubjectName != members[j].SubjectName {
+			return members[i].SubjectName < members[j].SubjectName
+		}
+		return members[i].Role < members[j].Role
+	})
+	bindings := []interface{}{}
+	for _, m := range members {
+		rb, err := newPermissionRoleBinding(ns.Name, m.SubjectType, m.SubjectName, m.Role)
+		if err != nil {
+			continue
+		}
+		rb.TypeMeta = v1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"}
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(rb)
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, gitOpsManifest(&unstructured.Unstructured{Object: obj}))
+	}
+	if err := render("rbac.yaml", bindings...); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// tarGitOpsBundle packs the files into <project>/ of a gzipped tarball
+func tarGitOpsBundle(projectName string, files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: projectName + "/", Mode: 0o755, ModTime: now}); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		content := files[name]
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: projectName + "/" + name, Mode: 0o644, Size: int64(len(content)), ModTime: now}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GitOpsWebhook handles POST /api/gitops/webhook/:projectName
+// It is called by the repository host (GitHub push webhook) rather than a user: the request is
+// authenticated by its X-Hub-Signature-256 against the project's webhook secret.
+func GitOpsWebhook(c *gin.Context) {
+	projectName := c.Param("projectName")
+	if VteamClient == nil || K8sClient == nil || DynamicClient == nil || K8sClientProjects == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Backend client not initialized"})
+		return
+	}
+	ctx := c.Request.Context()
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, gitOpsImportMaxBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(projectName).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("GitOpsWebhook: failed to read ProjectSettings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"})
+		return
+	}
+	// Unknown projects and projects without GitOps look the same to unauthenticated callers
+	if err != nil || ps.Spec.GitOps == nil || ps.Spec.GitOps.RepoURL == "" || ps.Spec.GitOps.WebhookSecret == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "GitOps is not configured for this project"})
+		return
+	}
+	source := ps.Spec.GitOps
+	secret, err := K8sClient.CoreV1().Secrets(projectName).Get(ctx, source.WebhookSecret, v1.GetOptions{})
+	if err != nil || len(secret.Data[gitOpsWebhookSecretKey]) == 0 {
+		log.Printf("GitOpsWebhook: webhook secret %s/%s unavailable: %v", projectName, source.WebhookSecret, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "GitOps webhook secret is not available"})
+		return
+	}
+	event, signature := webhookEventAndSignature(c.Request.Header)
+	if !validWebhookSignature(secret.Data[gitOpsWebhookSecretKey], body, signature) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
+
+	switch event {
+	case "ping", "diagnostics:ping":
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+		return
+	case "push", "repo:refs_changed":
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"message": fmt.Sprintf("ignored %q event", event)})
+		return
+	}
+	branch := source.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	pushed, err := pushedBranch(body, "refs/heads/"+branch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid push payload"})
+		return
+	}
+	if !pushed {
+		c.JSON(http.StatusAccepted, gin.H{"message": fmt.Sprintf("ignored push to branches other than %s", branch)})
+		return
+	}
+	// The push only triggers the import: the branch tip is read, never the commit named in
+	// the payload, so replaying an old signed delivery cannot roll the project back
+	ref := branch
+
+	docs, err := fetchGitOpsBundle(c, projectName, source, ref)
+	if err != nil {
+		log.Printf("GitOpsWebhook: failed to fetch bundle for %s from %s@%s: %v", projectName, source.RepoURL, ref, err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("failed to read bundle: %v", err)})
+		return
+	}
+	// Attribute the import in the settings history
+	c.Set("userName", fmt.Sprintf("gitops:%s@%s", source.RepoURL, ref))
+	changes, err := importGitOpsBundle(c, projectName, source, docs)
+	if err != nil {
+		log.Printf("GitOpsWebhook: failed to import bundle into %s from %s@%s: %v", projectName, source.RepoURL, ref, err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "changes": changes})
+		return
+	}
+	if len(changes) > 0 {
+		log.Printf("GitOps import into %s from %s@%s: %s", projectName, source.RepoURL, ref, strings.Join(changes, "; "))
+	}
+	c.JSON(http.StatusOK, gin.H{"ref": ref, "changes": changes})
+}
+
+// webhookEventAndSignature returns the event name and the "sha256=<hex>" body signature of
+// a GitHub, Gitea or Bitbucket Server webhook delivery
+func webhookEventAndSignature(h http.Header) (string, string) {
+	if sig := h.Get("X-Gitea-Signature"); sig != "" {
+		return h.Get("X-Gitea-Event"), "sha256=" + sig
+	}
+	if event := h.Get("X-Event-Key"); event != "" {
+		return event, h.Get("X-Hub-Signature")
+	}
+	return h.Get("X-GitHub-Event"), h.Get("X-Hub-Signature-256")
+}
+
+// pushedBranch reports whether a push event moved ref. GitHub and Gitea report one ref per
+// push; Bitbucket Server lists its changes.
+func pushedBranch(body []byte, ref string) (bool, error) {
+	var push struct {
+		Ref     string `json:"ref"`
+		Changes []struct {
+			RefID string `json:"refId"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(body, &push); err != nil {
+		return false, err
+	}
+	if push.Ref == ref {
+		return true, nil
+	}
+	for _, change := range push.Changes {
+		if change.RefID == ref {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// validWebhookSignature checks a "sha256=<hex>" HMAC of the body
+func validWebhookSignature(secret, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(strings.TrimSpace(header), "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// fetchGitOpsBundle reads kustomization.yaml at ref and the resource files it lists, and
+// returns their documents. Only plain files in the bundle directory are supported.
+func fetchGitOpsBundle(c *gin.Context, projectName string, source *apiv1alpha1.GitOpsSource, ref string) ([]map[string]interface{}, error) {
+	repo, err := gitutil.Parse(source.RepoURL)
+	if err != nil {
+		return nil, err
+	}
+	token, err := git.GetRepoToken(c.Request.Context(), K8sClient, DynamicClient, projectName, "", repo)
+	if err != nil {
+		return nil, err
+	}
+	read := func(name string) ([]byte, error) {
+		content, err := git.ReadRepoFile(c.Request.Context(), repo, ref, path.Join(source.Path, name), token)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(content) > gitOpsImportMaxBytes {
+			return nil, fmt.Errorf("%s: file too large", name)
+		}
+		return content, nil
+	}
+
+	raw, err := read("kustomization.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var kustomization struct {
+		Resources []string `json:"resources"`
+	}
+	if err := yaml.Unmarshal(raw, &kustomization); err != nil {
+		return nil, fmt.Errorf("kustomization.yaml: %w", err)
+	}
+	docs := []map[string]interface{}{}
+	for _, name := range kustomization.Resources {
+		if strings.Contains(name, "..") || strings.HasPrefix(name, "/") || strings.Contains(name, "://") ||
+			!(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			return nil, fmt.Errorf("kustomization.yaml: unsupported resource %q (only YAML files in the bundle directory)", name)
+		}
+		content, err := read(name)
+		if err != nil {
+			return nil, err
+		}
+		decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+		for {
+			doc := map[string]interface{}{}
+			if err := decoder.Decode(&doc); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if len(doc) > 0 {
+				docs = append(docs, doc)
+			}
+		}
+	}
+	return docs, nil
+}
+
+// importGitOpsBundle applies the ProjectSettings and permission RoleBindings of a bundle with
+// the backend service account. Other kinds are ignored. Members are only replaced when the
+// bundle declares at least one RoleBinding.
+func importGitOpsBundle(c *gin.Context, projectName string, source *apiv1alpha1.GitOpsSource, docs []map[string]interface{}) ([]string, error) {
+	changes := []string{}
+	var settings *apiv1alpha1.ProjectSettingsSpec
+	var bindings []rbacv1.RoleBinding
+	for _, doc := range docs {
+		obj := &unstructured.Unstructured{Object: doc}
+		if ns := obj.GetNamespace(); ns != "" && ns != projectName {
+			return changes, fmt.Errorf("%s %s belongs to namespace %s, not %s", obj.GetKind(), obj.GetName(), ns, projectName)
+		}
+		switch obj.GetKind() {
+		case "ProjectSettings":
+			if settings != nil {
+				return changes, fmt.Errorf("bundle declares more than one ProjectSettings")
+			}
+			var ps apiv1alpha1.ProjectSettings
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(doc, &ps); err != nil {
+				return changes, fmt.Errorf("ProjectSettings: %w", err)
+			}
+			settings = &ps.Spec
+		case "RoleBinding":
+			var rb rbacv1.RoleBinding
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(doc, &rb); err != nil {
+				return changes, fmt.Errorf("RoleBinding %s: %w", obj.GetName(), err)
+			}
+			bindings = append(bindings, rb)
+		}
+	}
+
+	var members []types.ProjectMember
+	if len(bindings) > 0 {
+		for _, a := range collectPermissionAssignments(bindings) {
+			members = append(members, types.ProjectMember{SubjectType: a.SubjectType, SubjectName: a.SubjectName, Role: a.Role})
+		}
+		var err error
+		if members, err = normalizeProjectMembers(members); err != nil {
+			return changes, err
+		}
+	}
+	if settings != nil {
+		// A bundle without spec.gitOps must not disconnect the project from its repository
+		if settings.GitOps == nil {
+			settings.GitOps = source
+		}
+		if err := validateProjectSettingsSpec(c.Request.Context(), K8sClient, projectName, settings); err != nil {
+			return changes, err
+		}
+		changed, err := applyProjectSettings(c, DynamicClient, projectName, settings)
+		if err != nil {
+			return changes, fmt.Errorf("apply settings: %w", err)
+		}
+		if changed {
+			changes = append(changes, "updated settings")
+		}
+	}
+	if members != nil {
+		memberChanges, err := applyProjectMembers(c.Request.Context(), projectName, members, "gitops:"+source.RepoURL)
+		changes = append(changes, memberChanges...)
+		if err != nil {
+			return changes, fmt.Errorf("apply members: %w", err)
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+// TestGitOpsWebhookProviders verifies GitHub, Gitea and Bitbucket Server deliveries are
+// verified and their pushes to the tracked branch found
+func TestGitOpsWebhookProviders(t *testing.T) {
+	secret := []byte("s3cret")
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	github := `{"ref":"refs/heads/main","after":"abc123"}`
+	bitbucket := `{"eventKey":"repo:refs_changed","changes":[{"refId":"refs/heads/dev","toHash":"fff"},{"refId":"refs/heads/main","toHash":"def456","type":"UPDATE"}]}`
+	cases := []struct {
+		name      string
+		headers   map[string]string
+		body      string
+		wantEvent string
+	}{
+		{"github", map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(github)}, github, "push"},
+		{"gitea", map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign(github)}, github, "push"},
+		{"bitbucket server", map[string]string{"X-Event-Key": "repo:refs_changed", "X-Hub-Signature": "sha256=" + sign(bitbucket)}, bitbucket, "repo:refs_changed"},
+	}
+	for _, tc := range cases {
+		h := http.Header{}
+		for k, v := range tc.headers {
+			h.Set(k, v)
+		}
+		event, signature := webhookEventAndSignature(h)
+		if event != tc.wantEvent || !validWebhookSignature(secret, []byte(tc.body), signature) {
+			t.Errorf("%s: event %q, signature %q rejected", tc.name, event, signature)
+		}
+		if pushed, err := pushedBranch([]byte(tc.body), "refs/heads/main"); err != nil || !pushed {
+			t.Errorf("%s: pushedBranch() = %v, %v", tc.name, pushed, err)
+		}
+	}
+
+	if pushed, _ := pushedBranch([]byte(bitbucket), "refs/heads/release"); pushed {
+		t.Error("push to another branch matched")
+	}
+	h := http.Header{}
+	h.Set("X-Gitea-Signature", sign(github))
+	if _, signature := webhookEventAndSignature(h); validWebhookSignature(secret, []byte(github+" "), signature) {
+		t.Error("signature of a different body accepted")
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/hardware"
+)
+
+// validateRunnerHardware checks the format of hw and, with the backend SA, that some node
+// can run it. The operator repeats the cluster check when it creates the Job.
+func validateRunnerHardware(ctx context.Context, hw *apiv1alpha1.RunnerHardware) error {
+	if err := hw.Validate(); err != nil {
+		return err
+	}
+	if hw == nil || K8sClient == nil {
+		return nil
+	}
+	return hardware.Check(ctx, K8sClient, hw)
+}
+
+// validateSessionHardware validates a session's hardware request as the operator will apply
+// it: on top of the project's runnerHardware defaults
+func validateSessionHardware(ctx context.Context, project string, hw *apiv1alpha1.RunnerHardware) error {
+	if err := hw.Validate(); err != nil {
+		return err
+	}
+	var defaults *apiv1alpha1.RunnerHardware
+	if VteamClient != nil {
+		if spec := projectSettingsSpec(ctx, project); spec != nil {
+			defaults = spec.RunnerHardware
+		}
+	}
+	return validateRunnerHardware(ctx, defaults.Merge(hw))
+}
@@ -1,12 +1,72 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-backend/breaker"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Health returns a simple health check handler
+// crdPollInterval is how often missing CRDs are looked for again while readiness is held
+const crdPollInterval = 30 * time.Second
+
+var (
+	notReadyMu sync.RWMutex
+	notReady   error
+)
+
+// Health returns a simple health check handler. Open circuits to external services are
+// reported but do not make the backend unhealthy.
 func Health(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	c.JSON(http.StatusOK, gin.H{"status": "healthy", "circuits": breaker.Snapshot()})
+}
+
+// Ready is the readiness probe. It fails only while required CRDs are missing and
+// REQUIRE_CRDS=true, so traffic is not sent to a backend whose session APIs would all 404.
+func Ready(c *gin.Context) {
+	notReadyMu.RLock()
+	err := notReady
+	notReadyMu.RUnlock()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
+
+func setNotReady(err error) {
+	notReadyMu.Lock()
+	notReady = err
+	notReadyMu.Unlock()
+}
+
+// CheckRequiredCRDs runs verify at startup and logs which CRDs are missing. With
+// REQUIRE_CRDS=true the backend also stays unready until verify passes.
+func CheckRequiredCRDs(verify func() error) {
+	err := verify()
+	if err == nil {
+		return
+	}
+	log.Printf("WARNING: %v", err)
+	if !strings.EqualFold(strings.TrimSpace(os.Getenv("REQUIRE_CRDS")), "true") {
+		return
+	}
+	setNotReady(err)
+	go func() {
+		for {
+			time.Sleep(crdPollInterval)
+			err := verify()
+			setNotReady(err)
+			if err == nil {
+				log.Printf("Required CRDs are installed; backend is ready")
+				return
+			}
+		}
+	}()
 }
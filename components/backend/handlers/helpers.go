@@ -6,26 +6,44 @@ import (
 	"math"
 	"time"
 
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
 )
 
+// K8sCallTimeout bounds Kubernetes API calls made while serving a request (set from main package).
+// The client transport enforces it per call; handlers that set their own deadline use it too.
+var K8sCallTimeout = 10 * time.Second
+
 // GetProjectSettingsResource returns the GroupVersionResource for ProjectSettings
 func GetProjectSettingsResource() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "vteam.ambient-code",
-		Version:  "v1alpha1",
-		Resource: "projectsettings",
-	}
+	return apiv1alpha1.ProjectSettingsGVR()
+}
+
+// GetSessionBatchResource returns the GroupVersionResource for SessionBatch
+func GetSessionBatchResource() schema.GroupVersionResource {
+	return apiv1alpha1.SessionBatchGVR()
+}
+
+// GetAgentResource returns the GroupVersionResource for Agent
+func GetAgentResource() schema.GroupVersionResource {
+	return apiv1alpha1.AgentGVR()
+}
+
+// GetPreviewResource returns the GroupVersionResource for Preview
+func GetPreviewResource() schema.GroupVersionResource {
+	return apiv1alpha1.PreviewGVR()
 }
 
 // RetryWithBackoff attempts an operation with exponential backoff
 // Used for operations that may temporarily fail due to async resource creation
 // This is a generic utility that can be used by any handler
-// Checks for context cancellation between retries to avoid wasting resources
-func RetryWithBackoff(maxRetries int, initialDelay, maxDelay time.Duration, operation func() error) error {
+// name labels the attempt, failure and duration metrics of the operation (see retryMetrics)
+func RetryWithBackoff(name string, maxRetries int, initialDelay, maxDelay time.Duration, operation func() error) error {
+	done := retryMetrics.begin(name)
 	var lastErr error
 	for i := 0; i < maxRetries; i++ {
-		if err := operation(); err != nil {
+		if err := retryMetrics.attempt(name, operation); err != nil {
 			lastErr = err
 			if i < maxRetries-1 {
 				// Calculate exponential backoff delay
@@ -33,13 +51,26 @@ func RetryWithBackoff(maxRetries int, initialDelay, maxDelay time.Duration, oper
 				if delay > maxDelay {
 					delay = maxDelay
 				}
-				log.Printf("Operation failed (attempt %d/%d), retrying in %v: %v", i+1, maxRetries, delay, err)
+				log.Printf("Operation %s failed (attempt %d/%d), retrying in %v: %v", name, i+1, maxRetries, delay, err)
 				time.Sleep(delay)
 				continue
 			}
 		} else {
+			done(nil)
 			return nil
 		}
 	}
-	return fmt.Errorf("operation failed after %d retries: %w", maxRetries, lastErr)
+	err := fmt.Errorf("%s failed after %d retries: %w", name, maxRetries, lastErr)
+	done(err)
+	return err
+}
+
+// retryOnConflict is retry.RetryOnConflict with the metrics of RetryWithBackoff
+func retryOnConflict(name string, operation func() error) error {
+	done := retryMetrics.begin(name)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return retryMetrics.attempt(name, operation)
+	})
+	done(err)
+	return err
 }
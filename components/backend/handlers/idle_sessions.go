@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// lastActivityAnnotation is the RFC3339 time of the last message sent to or by the session
+	lastActivityAnnotation = "ambient-code.io/last-activity"
+	// idleWarningAnnotation is the RFC3339 time the idle warning was sent for the current idle period
+	idleWarningAnnotation = "ambient-code.io/idle-warning-sent"
+
+	// activityRecordInterval throttles last-activity writes for chatty sessions
+	activityRecordInterval = time.Minute
+	// idleCheckInterval is how often running sessions are checked for idleness
+	idleCheckInterval = time.Minute
+	// maxIdleWarningLead is how long before the stop users are warned (at most half the limit)
+	maxIdleWarningLead = 5 * time.Minute
+)
+
+var (
+	activityMu       sync.Mutex
+	activityRecorded = map[string]time.Time{}
+)
+
+// RecordSessionActivity marks a session as active. Called for every message sent to or by a
+// session; writes to the CR at most once per activityRecordInterval per session.
+func RecordSessionActivity(project, sessionName string) {
+	if project == "" || sessionName == "" || DynamicClient == nil {
+		return
+	}
+	key := project + "/" + sessionName
+	now := time.Now()
+	activityMu.Lock()
+	if now.Sub(activityRecorded[key]) < activityRecordInterval {
+		activityMu.Unlock()
+		return
+	}
+	activityRecorded[key] = now
+	activityMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), K8sCallTimeout)
+	defer cancel()
+	patchSession(ctx, project, sessionName, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{lastActivityAnnotation: now.UTC().Format(time.RFC3339)},
+		},
+	}, "")
+}
+
+// StartIdleSessionReaper stops running interactive sessions that have had no messages for
+// their project's ProjectSettings spec.maxIdleMinutes. A warning is posted to the session
+// shortly before the stop. This targets forgotten interactive sessions and is independent of
+// the wall-clock spec.timeout. The same pass applies spec.abandonedSessions to sessions nobody
+// has viewed (abandoned_sessions.go). Blocks until ctx is cancelled.
+func StartIdleSessionReaper(ctx context.Context) {
+	if VteamClient == nil || DynamicClient == nil {
+		log.Printf("Idle session reaper disabled: backend SA clients not initialized")
+		return
+	}
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reapIdleSessions(ctx, time.Now())
+		}
+	}
+}
+
+func reapIdleSessions(ctx context.Context, now time.Time) {
+	list, err := VteamClient.VteamV1alpha1().AgenticSessions("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("Idle session reaper: failed to list sessions: %v", err)
+		return
+	}
+
+	pruneActivityRecords(now)
+	pruneViewRecords(now)
+
+	// ProjectSettings per project, read once per pass
+	settings := map[string]*apiv1alpha1.ProjectSettingsSpec{}
+	for i := range list.Items {
+		session := &list.Items[i]
+		if session.Status.Phase != apiv1alpha1.SessionPhaseRunning || !session.Spec.Interactive || session.DeletionTimestamp != nil {
+			continue
+		}
+		spec, ok := settings[session.Namespace]
+		if !ok {
+			spec = projectSettingsSpec(ctx, session.Namespace)
+			settings[session.Namespace] = spec
+		}
+		if spec == nil {
+			continue
+		}
+		if checkIdleSession(ctx, session, spec, now) {
+			continue
+		}
+		checkAbandonedSession(ctx, session, spec.AbandonedSessions, now)
+	}
+}
+
+// checkIdleSession warns or stops the session when it has been idle too long; returns true
+// when the session was stopped
+func checkIdleSession(ctx context.Context, session *apiv1alpha1.AgenticSession, spec *apiv1alpha1.ProjectSettingsSpec, now time.Time) bool {
+	maxIdle := time.Duration(spec.MaxIdleMinutes) * time.Minute
+	if maxIdle <= 0 {
+		return false
+	}
+	lastActive := sessionLastActivity(session)
+	idle := now.Sub(lastActive)
+	switch {
+	case idle >= maxIdle:
+		return stopSession(ctx, session, fmt.Sprintf("Session stopped after %d minutes without activity", int(idle.Minutes())))
+	case idle >= maxIdle-idleWarningLead(maxIdle) && !idleWarningSent(session, lastActive):
+		warnIdleSession(ctx, session, lastActive.Add(maxIdle))
+	}
+	return false
+}
+
+// pruneActivityRecords drops throttle entries that no longer suppress a write
+func pruneActivityRecords(now time.Time) {
+	activityMu.Lock()
+	defer activityMu.Unlock()
+	for key, t := range activityRecorded {
+		if now.Sub(t) >= activityRecordInterval {
+			delete(activityRecorded, key)
+		}
+	}
+}
+
+// projectSettingsSpec returns the project's settings; nil when unset or unreadable
+func projectSettingsSpec(ctx context.Context, project string) *apiv1alpha1.ProjectSettingsSpec {
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	return &ps.Spec
+}
+
+func idleWarningLead(maxIdle time.Duration) time.Duration {
+	if maxIdle/2 < maxIdleWarningLead {
+		return maxIdle / 2
+	}
+	return maxIdleWarningLead
+}
+
+// sessionLastActivity is the latest of the recorded activity, the start time and creation
+func sessionLastActivity(session *apiv1alpha1.AgenticSession) time.Time {
+	last := session.CreationTimestamp.Time
+	if session.Status.StartTime != nil && session.Status.StartTime.After(last) {
+		last = session.Status.StartTime.Time
+	}
+	if t, err := time.Parse(time.RFC3339, session.Annotations[lastActivityAnnotation]); err == nil && t.After(last) {
+		last = t
+	}
+	return last
+}
+
+// idleWarningSent reports whether a warning was already sent since the last activity
+func idleWarningSent(session *apiv1alpha1.AgenticSession, lastActive time.Time) bool {
+	t, err := time.Parse(time.RFC3339, session.Annotations[idleWarningAnnotation])
+	return err == nil && !t.Before(lastActive)
+}
+
+func warnIdleSession(ctx context.Context, session *apiv1alpha1.AgenticSession, stopAt time.Time) {
+	msg := fmt.Sprintf("This session has been idle and will be stopped at %s unless there is new activity.", stopAt.UTC().Format(time.RFC3339))
+	log.Printf("Idle session reaper: warning %s/%s (stop at %s)", session.Namespace, session.Name, stopAt.UTC().Format(time.RFC3339))
+	if !patchSession(ctx, session.Namespace, session.Name, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{idleWarningAnnotation: time.Now().UTC().Format(time.RFC3339)},
+		},
+	}, "") {
+		return
+	}
+	if SendMessageToSession != nil {
+		SendMessageToSession(session.Name, "system.message", map[string]interface{}{"message": msg})
+	}
+}
+
+// stopSession moves the session to Stopped; the operator then deletes the runner Job
+func stopSession(ctx context.Context, session *apiv1alpha1.AgenticSession, msg string) bool {
+	log.Printf("Idle session reaper: stopping %s/%s: %s", session.Namespace, session.Name, msg)
+	if !patchSession(ctx, session.Namespace, session.Name, map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":          string(apiv1alpha1.SessionPhaseStopped),
+			"message":        msg,
+			"completionTime": time.Now().Format(time.RFC3339),
+			"result":         map[string]interface{}{"outcome": string(apiv1alpha1.OutcomeInterrupted), "summary": msg},
+		},
+	}, "status") {
+		return false
+	}
+	if SendMessageToSession != nil {
+		SendMessageToSession(session.Name, "system.message", map[string]interface{}{"message": msg + "."})
+	}
+	return true
+}
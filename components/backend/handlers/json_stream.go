@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Large list responses (thousands of sessions) are streamed instead of marshalled whole:
+// items are converted and encoded one at a time into a pooled buffer that is written out
+// in chunks, so the response never exists in memory as one slice or one byte array.
+
+const (
+	// jsonStreamChunkBytes is how much encoded output is buffered before it is written
+	jsonStreamChunkBytes = 32 << 10
+	// maxPooledJSONBuffer keeps buffers grown by one huge item out of the pool
+	maxPooledJSONBuffer = 256 << 10
+)
+
+var jsonBufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// writeJSONList streams {"items":[item(0),...,item(n-1)], <fields>} with the given status.
+// Keys of fields are written in sorted order, matching c.JSON. Once the first chunk is
+// written the status cannot change, so an encoding error ends the response early.
+func writeJSONList(c *gin.Context, status, n int, item func(i int) any, fields gin.H) {
+	buf := jsonBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledJSONBuffer {
+			jsonBufferPool.Put(buf)
+		}
+	}()
+	enc := json.NewEncoder(buf)
+	// encode appends v without the newline json.Encoder adds
+	encode := func(v any) error {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+		return nil
+	}
+	flush := func() bool {
+		if _, err := c.Writer.Write(buf.Bytes()); err != nil {
+			return false
+		}
+		buf.Reset()
+		c.Writer.Flush()
+		return true
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(status)
+
+	buf.WriteString(`{"items":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encode(item(i)); err != nil {
+			log.Printf("Failed to encode list item %d for %s: %v", i, c.FullPath(), err)
+			_ = c.Error(err)
+			flush()
+			return
+		}
+		if buf.Len() >= jsonStreamChunkBytes && !flush() {
+			// Client went away
+			return
+		}
+	}
+	buf.WriteByte(']')
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteByte(',')
+		_ = encode(k)
+		buf.WriteByte(':')
+		if err := encode(fields[k]); err != nil {
+			log.Printf("Failed to encode %q for %s: %v", k, c.FullPath(), err)
+			_ = c.Error(err)
+			flush()
+			return
+		}
+	}
+	buf.WriteByte('}')
+	flush()
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// benchSessionCount matches the largest projects seen in production
+const benchSessionCount = 5000
+
+func testSessionItems(n int) []unstructured.Unstructured {
+	items := make([]unstructured.Unstructured, n)
+	for i := range items {
+		items[i] = unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "AgenticSession",
+			"metadata": map[string]interface{}{
+				"name":              fmt.Sprintf("session-%d", i),
+				"namespace":         "team-a",
+				"uid":               fmt.Sprintf("uid-%d", i),
+				"resourceVersion":   fmt.Sprintf("%d", 1000+i),
+				"creationTimestamp": "2025-01-01T00:00:00Z",
+				"labels":            map[string]interface{}{"ambient-code.io/session-group": "g"},
+			},
+			"spec": map[string]interface{}{
+				"prompt":      "Refactor the payment service <and> add tests & docs",
+				"displayName": fmt.Sprintf("Session %d", i),
+				"interactive": false,
+				"timeout":     int64(3600),
+				"llmSettings": map[string]interface{}{"model": "sonnet", "temperature": 0.7, "maxTokens": int64(4000)},
+				"repos": []interface{}{map[string]interface{}{
+					"input": map[string]interface{}{"url": "https://github.com/acme/payments", "branch": "main"},
+				}},
+			},
+			"status": map[string]interface{}{
+				"phase":     "Completed",
+				"message":   "Session finished",
+				"startTime": "2025-01-01T00:00:05Z",
+			},
+		}}
+	}
+	return items
+}
+
+func sessionFromItem(item *unstructured.Unstructured) types.AgenticSession {
+	session := types.AgenticSession{
+		APIVersion: item.GetAPIVersion(),
+		Kind:       item.GetKind(),
+		Metadata:   item.Object["metadata"].(map[string]interface{}),
+	}
+	if spec, ok := item.Object["spec"].(map[string]interface{}); ok {
+		session.Spec = parseSpec(spec)
+	}
+	if status, ok := item.Object["status"].(map[string]interface{}); ok {
+		session.Status = parseStatus(status)
+	}
+	return session
+}
+
+// discardWriter stands in for the network connection so benchmarks count only the
+// handler's allocations, not a recorder's copy of the body
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+func (w *discardWriter) Flush()                      {}
+
+// marshalList is the previous ListSessions response: the whole list converted, then c.JSON
+func marshalList(w http.ResponseWriter, items []unstructured.Unstructured) {
+	c, _ := gin.CreateTestContext(w)
+	sessions := make([]types.AgenticSession, 0, len(items))
+	for i := range items {
+		sessions = append(sessions, sessionFromItem(&items[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": sessions, "resourceVersion": "42"})
+}
+
+func streamList(w http.ResponseWriter, items []unstructured.Unstructured) {
+	c, _ := gin.CreateTestContext(w)
+	var session types.AgenticSession
+	writeJSONList(c, http.StatusOK, len(items), func(i int) any {
+		session = sessionFromItem(&items[i])
+		return &session
+	}, gin.H{"resourceVersion": "42"})
+}
+
+// TestWriteJSONListMatchesMarshal verifies the streamed response decodes to the same
+// document as c.JSON, including across chunk boundaries and for an empty list
+func TestWriteJSONListMatchesMarshal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, n := range []int{0, 1, 500} {
+		items := testSessionItems(n)
+		want, got := httptest.NewRecorder(), httptest.NewRecorder()
+		marshalList(want, items)
+		streamList(got, items)
+		if got.Code != http.StatusOK || got.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+			t.Fatalf("n=%d: status %d, content type %q", n, got.Code, got.Header().Get("Content-Type"))
+		}
+		var wantDoc, gotDoc map[string]interface{}
+		if err := json.Unmarshal(want.Body.Bytes(), &wantDoc); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(got.Body.Bytes(), &gotDoc); err != nil {
+			t.Fatalf("n=%d: streamed body is not valid JSON: %v", n, err)
+		}
+		if n == 0 {
+			// c.JSON of an empty slice and the stream both give []
+			if items, ok := gotDoc["items"].([]interface{}); !ok || len(items) != 0 {
+				t.Fatalf("empty list streamed as %v", gotDoc["items"])
+			}
+		}
+		if !reflect.DeepEqual(wantDoc, gotDoc) {
+			t.Errorf("n=%d: streamed document differs from c.JSON", n)
+		}
+	}
+}
+
+func BenchmarkListSessionsMarshal(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	items := testSessionItems(benchSessionCount)
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		marshalList(w, items)
+	}
+}
+
+func BenchmarkListSessionsStream(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	items := testSessionItems(benchSessionCount)
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		streamList(w, items)
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	awsRegionPattern  = regexp.MustCompile(`^[a-z]{2}(-gov)?-[a-z]+-\d+$`)
+	awsRoleARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/[\w+=,.@/-]+$`)
+)
+
+// bedrockCredentialKeys must be present in a Bedrock credentials Secret
+var bedrockCredentialKeys = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}
+
+// GetLLMProvider handles GET /api/projects/:projectName/llm-provider
+func GetLLMProvider(c *gin.Context) {
+	projectName := c.Param("projectName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		c.Abort()
+		return
+	}
+
+	obj, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(projectName).Get(c.Request.Context(), "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusOK, types.LLMProviderSettings{})
+			return
+		}
+		log.Printf("Failed to get ProjectSettings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"})
+		return
+	}
+	c.JSON(http.StatusOK, parseLLMProviderSettings(obj))
+}
+
+// UpdateLLMProvider handles PUT /api/projects/:projectName/llm-provider
+// Validates the provider configuration before storing it in ProjectSettings spec.llmProvider.
+func UpdateLLMProvider(c *gin.Context) {
+	projectName := c.Param("projectName")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		c.Abort()
+		return
+	}
+
+	var req types.LLMProviderSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateLLMProvider(c.Request.Context(), reqK8s, projectName, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gvr := GetProjectSettingsResource()
+	obj, err := reqDyn.Resource(gvr).Namespace(projectName).Get(c.Request.Context(), "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ProjectSettings not found"})
+			return
+		}
+		log.Printf("Failed to get ProjectSettings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"})
+		return
+	}
+
+	provider := map[string]interface{}{}
+	if req.Provider != "" {
+		provider["provider"] = req.Provider
+	}
+	if req.Bedrock != nil {
+		bedrock := map[string]interface{}{"region": req.Bedrock.Region}
+		if req.Bedrock.RoleARN != "" {
+			bedrock["roleArn"] = req.Bedrock.RoleARN
+		}
+		if req.Bedrock.CredentialsSecretName != "" {
+			bedrock["credentialsSecretName"] = req.Bedrock.CredentialsSecretName
+		}
+		provider["bedrock"] = bedrock
+	}
+	if err := unstructured.SetNestedMap(obj.Object, provider, "spec", "llmProvider"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project settings"})
+		return
+	}
+	updated, err := reqDyn.Resource(gvr).Namespace(projectName).Update(c.Request.Context(), obj, v1.UpdateOptions{})
+	if err != nil {
+		if errors.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to update project settings"})
+			return
+		}
+		log.Printf("Failed to update ProjectSettings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project settings"})
+		return
+	}
+	recordSettingsRevision(c, updated, 0)
+
+	c.JSON(http.StatusOK, req)
+}
+
+// validateLLMProvider checks the provider name and, for Bedrock, the region and that exactly
+// one credential source is configured. A credentials Secret must already exist and carry
+// the AWS key pair, so misconfiguration surfaces here rather than as a failed runner pod.
+func validateLLMProvider(ctx context.Context, reqK8s *kubernetes.Clientset, project string, req *types.LLMProviderSettings) error {
+	req.Provider = strings.ToLower(strings.TrimSpace(req.Provider))
+	switch req.Provider {
+	case "", "anthropic", "vertex":
+		if req.Bedrock != nil {
+			return fmt.Errorf("bedrock settings are only allowed when provider is 'bedrock'")
+		}
+		return nil
+	case "bedrock":
+	default:
+		return fmt.Errorf("unsupported provider '%s' (expected anthropic, vertex or bedrock)", req.Provider)
+	}
+
+	if req.Bedrock == nil {
+		return fmt.Errorf("bedrock settings are required when provider is 'bedrock'")
+	}
+	b := req.Bedrock
+	b.Region = strings.TrimSpace(b.Region)
+	b.RoleARN = strings.TrimSpace(b.RoleARN)
+	b.CredentialsSecretName = strings.TrimSpace(b.CredentialsSecretName)
+	if !awsRegionPattern.MatchString(b.Region) {
+		return fmt.Errorf("invalid AWS region '%s'", b.Region)
+	}
+	if (b.RoleARN == "") == (b.CredentialsSecretName == "") {
+		return fmt.Errorf("exactly one of bedrock.roleArn or bedrock.credentialsSecretName must be set")
+	}
+	if b.RoleARN != "" {
+		if !awsRoleARNPattern.MatchString(b.RoleARN) {
+			return fmt.Errorf("invalid IAM role ARN '%s'", b.RoleARN)
+		}
+		return nil
+	}
+
+	sec, err := reqK8s.CoreV1().Secrets(project).Get(ctx, b.CredentialsSecretName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("credentials secret '%s' not found in project", b.CredentialsSecretName)
+		}
+		return fmt.Errorf("failed to read credentials secret '%s': %v", b.CredentialsSecretName, err)
+	}
+	for _, key := range bedrockCredentialKeys {
+		if len(sec.Data[key]) == 0 {
+			return fmt.Errorf("credentials secret '%s' is missing key %s", b.CredentialsSecretName, key)
+		}
+	}
+	return nil
+}
+
+// parseLLMProviderSettings reads spec.llmProvider from a ProjectSettings object
+func parseLLMProviderSettings(obj *unstructured.Unstructured) types.LLMProviderSettings {
+	ps := &apiv1alpha1.ProjectSettings{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, ps); err != nil {
+		log.Printf("Failed to decode ProjectSettings %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return types.LLMProviderSettings{}
+	}
+	if ps.Spec.LLMProvider == nil {
+		return types.LLMProviderSettings{}
+	}
+	return *ps.Spec.LLMProvider
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-backend/messages"
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Maintenance kill switch: platform admins can stop session creation everywhere, or only for
+// projects using one LLM provider (e.g. during a provider incident). The state lives in the
+// ConfigMap ambient-maintenance in the backend namespace, so it survives restarts and every
+// backend replica sees it. Admins are the users allowed to update that ConfigMap. A block
+// with an end time lifts itself: expired blocks are ignored and dropped on the next write.
+//
+// Running sessions are not affected; to also hold runner Jobs of sessions that already exist,
+// use the operator's job creation switch.
+
+const (
+	maintenanceConfigMapName = "ambient-maintenance"
+	maintenanceStateKey      = "state"
+	// maintenanceCacheTTL bounds how stale a replica's view of the switch can be
+	maintenanceCacheTTL = 5 * time.Second
+	// maxMaintenanceMinutes caps durationMinutes at one week
+	maxMaintenanceMinutes = 7 * 24 * 60
+)
+
+// maintenanceProviders are the LLM providers a block can target
+var maintenanceProviders = []string{"anthropic", "vertex", "bedrock"}
+
+var maintenanceCache struct {
+	sync.Mutex
+	state    types.MaintenanceState
+	loadedAt time.Time
+}
+
+// maintenanceError is returned by checkMaintenance while session creation is disabled
+type maintenanceError struct {
+	provider string
+	block    *types.MaintenanceBlock
+}
+
+func (e *maintenanceError) Error() string {
+	id, data := e.message()
+	return messages.Render(messages.DefaultLocale, id, data)
+}
+
+// message is the user-facing message of the block; the admin's message is shown as written
+func (e *maintenanceError) message() (string, map[string]interface{}) {
+	data := map[string]interface{}{"Message": strings.TrimSpace(e.block.Message), "Until": ""}
+	if e.block.Until != nil {
+		data["Until"] = e.block.Until.UTC().Format(time.RFC3339)
+	}
+	if e.provider != "" {
+		data["Provider"] = e.provider
+		return "maintenance.providerDisabled", data
+	}
+	return "maintenance.disabled", data
+}
+
+// activeMaintenance drops blocks that have expired
+func activeMaintenance(state types.MaintenanceState, now time.Time) types.MaintenanceState {
+	active := func(b *types.MaintenanceBlock) bool {
+		return b != nil && (b.Until == nil || now.Before(*b.Until))
+	}
+	out := types.MaintenanceState{}
+	if active(state.Global) {
+		out.Global = state.Global
+	}
+	for p, b := range state.Providers {
+		if active(b) {
+			if out.Providers == nil {
+				out.Providers = map[string]*types.MaintenanceBlock{}
+			}
+			out.Providers[p] = b
+		}
+	}
+	return out
+}
+
+// readMaintenanceState reads the stored switch, including expired blocks
+func readMaintenanceState(ctx context.Context) (*corev1.ConfigMap, types.MaintenanceState, error) {
+	state := types.MaintenanceState{}
+	cm, err := K8sClient.CoreV1().ConfigMaps(Namespace).Get(ctx, maintenanceConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, state, nil
+		}
+		return nil, state, err
+	}
+	if raw := cm.Data[maintenanceStateKey]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &state); err != nil {
+			return cm, state, fmt.Errorf("invalid %s: %w", maintenanceConfigMapName, err)
+		}
+	}
+	return cm, state, nil
+}
+
+// currentMaintenance returns the active blocks, cached for maintenanceCacheTTL
+func currentMaintenance(ctx context.Context) (types.MaintenanceState, error) {
+	maintenanceCache.Lock()
+	defer maintenanceCache.Unlock()
+	if time.Since(maintenanceCache.loadedAt) > maintenanceCacheTTL {
+		_, state, err := readMaintenanceState(ctx)
+		if err != nil {
+			return types.MaintenanceState{}, err
+		}
+		maintenanceCache.state = state
+		maintenanceCache.loadedAt = time.Now()
+	}
+	return activeMaintenance(maintenanceCache.state, time.Now()), nil
+}
+
+// projectLLMProvider is the provider the project's runners use: ProjectSettings
+// spec.llmProvider, else the cluster default (Vertex when CLAUDE_CODE_USE_VERTEX=1)
+func projectLLMProvider(ctx context.Context, project string) string {
+	if VteamClient != nil {
+		ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+		if err == nil && ps.Spec.LLMProvider != nil && ps.Spec.LLMProvider.Provider != "" {
+			return strings.ToLower(ps.Spec.LLMProvider.Provider)
+		}
+	}
+	if os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1" {
+		return "vertex"
+	}
+	return "anthropic"
+}
+
+// checkMaintenance returns a *maintenanceError when new sessions may not start in the project
+func checkMaintenance(ctx context.Context, project string) error {
+	if K8sClient == nil {
+		return nil
+	}
+	state, err := currentMaintenance(ctx)
+	if err != nil {
+		// Fail open: an unreadable switch must not take the platform down
+		log.Printf("Failed to read maintenance state: %v", err)
+		return nil
+	}
+	if state.Global != nil {
+		return &maintenanceError{block: state.Global}
+	}
+	if len(state.Providers) == 0 {
+		return nil
+	}
+	provider := projectLLMProvider(ctx, project)
+	if b := state.Providers[provider]; b != nil {
+		return &maintenanceError{provider: provider, block: b}
+	}
+	return nil
+}
+
+// hint describes the block for the API response; the wait is known when the block has an end
+func (e *maintenanceError) hint() *types.SchedulingHint {
+	h := &types.SchedulingHint{Cause: rejectionCauseMaintenance}
+	if e.block.Until != nil {
+		if secs := int64(time.Until(*e.block.Until).Seconds()); secs > 0 {
+			h.EstimatedWaitSeconds = &secs
+		}
+	}
+	return h
+}
+
+// rejectForMaintenance writes 503 with Retry-After and a scheduling hint when session
+// creation is disabled
+func rejectForMaintenance(c *gin.Context, project string) bool {
+	body := maintenanceRejection(c, project)
+	if body == nil {
+		return false
+	}
+	c.JSON(http.StatusServiceUnavailable, body)
+	return true
+}
+
+// maintenanceRejection sets Retry-After and returns the 503 body when session creation is
+// disabled, or nil
+func maintenanceRejection(c *gin.Context, project string) gin.H {
+	err := checkMaintenance(c.Request.Context(), project)
+	if err == nil {
+		return nil
+	}
+	mErr := err.(*maintenanceError)
+	recordSessionRejection(rejectionCauseMaintenance)
+	hint := mErr.hint()
+	if hint.EstimatedWaitSeconds != nil {
+		c.Header("Retry-After", strconv.FormatInt(*hint.EstimatedWaitSeconds, 10))
+	}
+	id, data := mErr.message()
+	body := localizedError(c, id, data)
+	body["maintenance"] = true
+	body["scheduling"] = hint
+	return body
+}
+
+// canAdministerMaintenance reports whether the caller may update the maintenance ConfigMap
+func canAdministerMaintenance(c *gin.Context) (bool, error) {
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		return false, nil
+	}
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace: Namespace,
+				Verb:      "update",
+				Resource:  "configmaps",
+				Name:      maintenanceConfigMapName,
+			},
+		},
+	}
+	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return res.Status.Allowed, nil
+}
+
+// GetMaintenance returns the active blocks so clients can warn users before they create a session.
+// GET /api/admin/maintenance
+func GetMaintenance(c *gin.Context) {
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	_, state, err := readMaintenanceState(c.Request.Context())
+	if err != nil {
+		log.Printf("GetMaintenance: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read maintenance state"})
+		return
+	}
+	c.JSON(http.StatusOK, activeMaintenance(state, time.Now()))
+}
+
+// SetMaintenance disables or re-enables session creation globally or for one provider.
+// POST /api/admin/maintenance
+func SetMaintenance(c *gin.Context) {
+	allowed, err := canAdministerMaintenance(c)
+	if err != nil {
+		log.Printf("SetMaintenance: access review failed: %v", err)
+		respondMessage(c, http.StatusInternalServerError, "auth.accessReviewFailed", nil)
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Only platform admins (allowed to update configmap %s/%s) can change maintenance mode", Namespace, maintenanceConfigMapName)})
+		return
+	}
+
+	var req types.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Provider = strings.ToLower(strings.TrimSpace(req.Provider))
+	if req.Provider != "" && !slices.Contains(maintenanceProviders, req.Provider) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("provider must be one of %s, or empty for all sessions", strings.Join(maintenanceProviders, ", "))})
+		return
+	}
+	if req.DurationMinutes < 0 || req.DurationMinutes > maxMaintenanceMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("durationMinutes must be between 0 and %d", maxMaintenanceMinutes)})
+		return
+	}
+
+	var block *types.MaintenanceBlock
+	if req.Disabled {
+		now := time.Now().UTC()
+		block = &types.MaintenanceBlock{Message: strings.TrimSpace(req.Message), SetBy: c.GetString("userID"), SetAt: now}
+		if req.DurationMinutes > 0 {
+			until := now.Add(time.Duration(req.DurationMinutes) * time.Minute)
+			block.Until = &until
+		}
+	}
+
+	state, err := updateMaintenanceState(c.Request.Context(), func(state *types.MaintenanceState) {
+		if req.Provider == "" {
+			state.Global = block
+			return
+		}
+		if block == nil {
+			delete(state.Providers, req.Provider)
+			return
+		}
+		if state.Providers == nil {
+			state.Providers = map[string]*types.MaintenanceBlock{}
+		}
+		state.Providers[req.Provider] = block
+	})
+	if err != nil {
+		log.Printf("SetMaintenance: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update maintenance state"})
+		return
+	}
+	scope := "all sessions"
+	if req.Provider != "" {
+		scope = "provider " + req.Provider
+	}
+	log.Printf("Maintenance: session creation %s for %s by %s", map[bool]string{true: "disabled", false: "re-enabled"}[req.Disabled], scope, c.GetString("userID"))
+	c.JSON(http.StatusOK, state)
+}
+
+// updateMaintenanceState applies mutate to the stored switch, dropping expired blocks, and
+// returns the active state
+func updateMaintenanceState(ctx context.Context, mutate func(*types.MaintenanceState)) (types.MaintenanceState, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		cm, state, err := readMaintenanceState(ctx)
+		if err != nil && cm == nil {
+			return types.MaintenanceState{}, err
+		}
+		state = activeMaintenance(state, time.Now())
+		mutate(&state)
+		b, _ := json.Marshal(state)
+
+		if cm == nil {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: v1.ObjectMeta{Name: maintenanceConfigMapName, Namespace: Namespace},
+				Data:       map[string]string{maintenanceStateKey: string(b)},
+			}
+			_, err = K8sClient.CoreV1().ConfigMaps(Namespace).Create(ctx, cm, v1.CreateOptions{})
+		} else {
+			if cm.Data == nil {
+				cm.Data = map[string]string{}
+			}
+			cm.Data[maintenanceStateKey] = string(b)
+			_, err = K8sClient.CoreV1().ConfigMaps(Namespace).Update(ctx, cm, v1.UpdateOptions{})
+		}
+		if errors.IsConflict(err) || errors.IsAlreadyExists(err) {
+			continue
+		}
+		if err != nil {
+			return types.MaintenanceState{}, err
+		}
+		maintenanceCache.Lock()
+		maintenanceCache.state = state
+		maintenanceCache.loadedAt = time.Now()
+		maintenanceCache.Unlock()
+		return state, nil
+	}
+	return types.MaintenanceState{}, fmt.Errorf("failed to update %s after retries", maintenanceConfigMapName)
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Project members are the same Ambient-managed RoleBindings the permissions API manages.
+// The difference is who performs the write: the caller only has to be a project admin
+// (allowed to update ProjectSettings); the RoleBinding itself is created by the backend
+// service account, which holds "bind" on the ambient-project-* ClusterRoles. This lets
+// project admins manage membership without asking a cluster admin.
+
+// ListProjectMembers handles GET /api/projects/:projectName/members
+func ListProjectMembers(c *gin.Context) {
+	projectName := c.Param("projectName")
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+
+	canView, err := checkUserCanViewProject(reqK8s, projectName)
+	if err != nil {
+		log.Printf("ListProjectMembers: failed to check access for %s: %v", projectName, err)
+		respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
+		return
+	}
+	if !canView {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to view project members"})
+		return
+	}
+
+	rbs, err := K8sClientProjects.RbacV1().RoleBindings(projectName).List(c.Request.Context(), v1.ListOptions{})
+	if err != nil {
+		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list members"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": collectPermissionAssignments(rbs.Items)})
+}
+
+// AddProjectMember handles POST /api/projects/:projectName/members
+// Grants a user or group a role. A member that already holds a different role is moved to
+// the new one, so the endpoint doubles as "change role".
+func AddProjectMember(c *gin.Context) {
+	projectName := c.Param("projectName")
+	if !requireProjectAdmin(c, projectName) {
+		return
+	}
+
+	var req PermissionAssignment
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	subjectType := strings.ToLower(strings.TrimSpace(req.SubjectType))
+	subjectName := strings.TrimSpace(req.SubjectName)
+	if subjectType != "group" && subjectType != "user" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectType must be one of: group, user"})
+		return
+	}
+	if subjectName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectName is required"})
+		return
+	}
+	rb, err := newPermissionRoleBinding(projectName, subjectType, subjectName, req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rb.Annotations["ambient-code.io/granted-by"] = c.GetString("userID")
+
+	all, err := K8sClientProjects.RbacV1().RoleBindings(projectName).List(c.Request.Context(), v1.ListOptions{LabelSelector: permissionBindingSelector})
+	if err != nil {
+		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+	// Changing the role drops the old binding, so demoting the only admin would orphan the project
+	if rb.Annotations["ambient-code.io/role"] != "admin" && isLastAdmin(all.Items, subjectType, subjectName) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last project admin"})
+		return
+	}
+	existing := subjectRoleBindings(all.Items, subjectType, subjectName)
+
+	status := http.StatusCreated
+	if _, err := K8sClientProjects.RbacV1().RoleBindings(projectName).Create(c.Request.Context(), rb, v1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			log.Printf("Failed to create RoleBinding in %s for %s %s: %v", projectName, subjectType, subjectName, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+			return
+		}
+		status = http.StatusOK
+	}
+	// Drop any other role the member held; done after the create so they never lose access in between
+	for _, old := range existing {
+		if old.Name == rb.Name {
+			continue
+		}
+		if err := K8sClientProjects.RbacV1().RoleBindings(projectName).Delete(c.Request.Context(), old.Name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("Failed to delete previous RoleBinding %s/%s: %v", projectName, old.Name, err)
+		}
+		status = http.StatusOK
+	}
+
+	log.Printf("User %s set %s %s to role %s in project %s", c.GetString("userID"), subjectType, subjectName, rb.Annotations["ambient-code.io/role"], projectName)
+	c.JSON(status, PermissionAssignment{SubjectType: subjectType, SubjectName: subjectName, Role: rb.Annotations["ambient-code.io/role"]})
+}
+
+// RemoveProjectMember handles DELETE /api/projects/:projectName/members/:subjectType/:subjectName
+// Refuses to remove the last admin so a project can never be orphaned.
+func RemoveProjectMember(c *gin.Context) {
+	projectName := c.Param("projectName")
+	subjectType := strings.ToLower(c.Param("subjectType"))
+	subjectName := c.Param("subjectName")
+	if !requireProjectAdmin(c, projectName) {
+		return
+	}
+	if subjectType != "group" && subjectType != "user" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectType must be one of: group, user"})
+		return
+	}
+	if strings.TrimSpace(subjectName) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectName is required"})
+		return
+	}
+
+	bindings, err := memberRoleBindings(c, projectName, subjectType, subjectName)
+	if err != nil {
+		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	if len(bindings) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	all, err := K8sClientProjects.RbacV1().RoleBindings(projectName).List(c.Request.Context(), v1.ListOptions{LabelSelector: permissionBindingSelector})
+	if err != nil {
+		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	if isLastAdmin(all.Items, subjectType, subjectName) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last project admin"})
+		return
+	}
+
+	for _, rb := range bindings {
+		if err := K8sClientProjects.RbacV1().RoleBindings(projectName).Delete(c.Request.Context(), rb.Name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("Failed to delete RoleBinding %s/%s: %v", projectName, rb.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+			return
+		}
+	}
+
+	log.Printf("User %s removed %s %s from project %s", c.GetString("userID"), subjectType, subjectName, projectName)
+	c.Status(http.StatusNoContent)
+}
+
+// requireProjectAdmin verifies the caller may administer the project (UPDATE projectsettings).
+// On failure it writes the error response and returns false.
+func requireProjectAdmin(c *gin.Context, projectName string) bool {
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return false
+	}
+	if K8sClientProjects == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Backend client not initialized"})
+		return false
+	}
+	canModify, err := checkUserCanModifyProject(reqK8s, projectName)
+	if err != nil {
+		log.Printf("Failed to check admin access for %s: %v", projectName, err)
+		respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
+		return false
+	}
+	if !canModify {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only project admins can manage members"})
+		return false
+	}
+	return true
+}
+
+// memberRoleBindings returns the Ambient-managed permission RoleBindings for one subject,
+// legacy group access bindings included, so removing a member removes every binding the
+// last-admin check counted
+func memberRoleBindings(c *gin.Context, projectName, subjectType, subjectName string) ([]rbacv1.RoleBinding, error) {
+	rbs, err := K8sClientProjects.RbacV1().RoleBindings(projectName).List(c.Request.Context(), v1.ListOptions{LabelSelector: permissionBindingSelector})
+	if err != nil {
+		return nil, err
+	}
+	return subjectRoleBindings(rbs.Items, subjectType, subjectName), nil
+}
+
+// subjectRoleBindings filters bindings to those naming the subject
+func subjectRoleBindings(bindings []rbacv1.RoleBinding, subjectType, subjectName string) []rbacv1.RoleBinding {
+	out := []rbacv1.RoleBinding{}
+	for _, rb := range bindings {
+		for _, sub := range rb.Subjects {
+			if strings.EqualFold(sub.Kind, subjectType) && sub.Name == subjectName {
+				out = append(out, rb)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// isLastAdmin reports whether the subject is the project's only admin among bindings
+func isLastAdmin(bindings []rbacv1.RoleBinding, subjectType, subjectName string) bool {
+	admins := 0
+	isAdmin := false
+	for _, a := range collectPermissionAssignments(bindings) {
+		if a.Role != "admin" {
+			continue
+		}
+		admins++
+		if a.SubjectType == subjectType && a.SubjectName == subjectName {
+			isAdmin = true
+		}
+	}
+	return isAdmin && admins <= 1
+}
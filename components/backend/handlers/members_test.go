@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// TestPermissionBindingSelector verifies member removal lists exactly the bindings the
+// last-admin check counts, legacy group access bindings included
+func TestPermissionBindingSelector(t *testing.T) {
+	selector, err := labels.Parse(permissionBindingSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, app := range []string{"ambient-permission", "ambient-group-access", "gitops", ""} {
+		rb := rbacv1.RoleBinding{
+			ObjectMeta: v1.ObjectMeta{Name: "b", Labels: map[string]string{"app": app}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: AmbientRoleAdmin},
+			Subjects:   []rbacv1.Subject{{Kind: "Group", Name: "devs"}},
+		}
+		selected := selector.Matches(labels.Set(rb.Labels))
+		counted := len(collectPermissionAssignments([]rbacv1.RoleBinding{rb})) == 1
+		if selected != counted {
+			t.Errorf("app=%q: selected %v but counted as a member %v", app, selected, counted)
+		}
+	}
+}
+
+// TestIsLastAdmin verifies demoting or removing the only admin is refused, while another
+// admin, a group admin included, lets the change through
+func TestIsLastAdmin(t *testing.T) {
+	binding := func(kind, name, role string) rbacv1.RoleBinding {
+		rb, err := newPermissionRoleBinding("proj", kind, name, role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *rb
+	}
+	cases := []struct {
+		name     string
+		bindings []rbacv1.RoleBinding
+		want     bool
+	}{
+		{"only admin", []rbacv1.RoleBinding{binding("user", "alice", "admin"), binding("user", "bob", "edit")}, true},
+		{"second user admin", []rbacv1.RoleBinding{binding("user", "alice", "admin"), binding("user", "bob", "admin")}, false},
+		{"group admin", []rbacv1.RoleBinding{binding("user", "alice", "admin"), binding("group", "admins", "admin")}, false},
+		{"not an admin", []rbacv1.RoleBinding{binding("user", "alice", "view"), binding("user", "bob", "admin")}, false},
+	}
+	for _, tc := range cases {
+		if got := isLastAdmin(tc.bindings, "user", "alice"); got != tc.want {
+			t.Errorf("%s: isLastAdmin = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
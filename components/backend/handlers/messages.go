@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"ambient-code-backend/messages"
+
+	"github.com/gin-gonic/gin"
+)
+
+// User-facing API errors come from the messages catalog, in the language the caller asks for
+// with Accept-Language. The body keeps the "error" text clients already show and adds
+// "code", the stable message ID, for clients that need to tell errors apart.
+
+// requestLocale is the caller's locale, negotiated from Accept-Language
+func requestLocale(c *gin.Context) string {
+	if c.Request == nil {
+		return messages.DefaultLocale
+	}
+	return messages.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// localizedError returns the error body of message id in the caller's language; callers
+// may add fields before writing it
+func localizedError(c *gin.Context, id string, data map[string]interface{}) gin.H {
+	locale := requestLocale(c)
+	c.Header("Content-Language", locale)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	return gin.H{"error": messages.Render(locale, id, data), "code": id}
+}
+
+// respondMessage writes message id as the error of a status response
+func respondMessage(c *gin.Context, status int, id string, data map[string]interface{}) {
+	c.JSON(status, localizedError(c, id, data))
+}
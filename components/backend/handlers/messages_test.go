@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRespondMessage verifies API errors follow Accept-Language and keep a stable code
+func TestRespondMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for header, want := range map[string]string{
+		"":               "Session not found",
+		"de-AT, en;q=.5": "Sitzung nicht gefunden",
+		"es":             "Sesión no encontrada",
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/projects/p/agentic-sessions/s", nil)
+		c.Request.Header.Set("Accept-Language", header)
+		respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusNotFound || body["error"] != want || body["code"] != "session.notFound" {
+			t.Errorf("Accept-Language %q: %d %v", header, w.Code, body)
+		}
+		if w.Header().Get("Vary") != "Accept-Language" || w.Header().Get("Content-Language") == "" {
+			t.Errorf("Accept-Language %q: headers %v", header, w.Header())
+		}
+	}
+}
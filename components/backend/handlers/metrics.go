@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rejection causes of session creation, the cause label of ambient_session_rejections_total
+const (
+	rejectionCauseQuota       = "quota"
+	rejectionCauseMaintenance = "maintenance"
+)
+
+// sessionRejections counts refused session creations by cause. It is an expvar so it also
+// shows up in any expvar dump of the process.
+var sessionRejections = expvar.NewMap("session_rejections")
+
+// recordSessionRejection counts one refused session creation
+func recordSessionRejection(cause string) {
+	sessionRejections.Add(cause, 1)
+}
+
+// retryMetrics counts the work of the retry helpers by operation name, so flaky Kubernetes
+// operations show up in /metrics: every attempt, every failed attempt, the operations that
+// still failed after their last retry, and the time operations took including backoff.
+var retryMetrics = &retryCounters{
+	attempts:        expvar.NewMap("retry_attempts"),
+	attemptErrors:   expvar.NewMap("retry_attempt_failures"),
+	failures:        expvar.NewMap("retry_failures"),
+	operations:      expvar.NewMap("retry_operations"),
+	durationSeconds: expvar.NewMap("retry_duration_seconds"),
+}
+
+type retryCounters struct {
+	attempts, attemptErrors, failures, operations, durationSeconds *expvar.Map
+}
+
+// begin starts timing one operation; done records its outcome
+func (r *retryCounters) begin(name string) (done func(err error)) {
+	start := time.Now()
+	return func(err error) {
+		r.operations.Add(name, 1)
+		r.durationSeconds.AddFloat(name, time.Since(start).Seconds())
+		if err != nil {
+			r.failures.Add(name, 1)
+		}
+	}
+}
+
+// attempt runs one try of the operation and counts it
+func (r *retryCounters) attempt(name string, operation func() error) error {
+	r.attempts.Add(name, 1)
+	err := operation()
+	if err != nil {
+		r.attemptErrors.Add(name, 1)
+	}
+	return err
+}
+
+// Metrics serves the backend's counters in the Prometheus text format.
+// GET /metrics
+func Metrics(c *gin.Context) {
+	counts := map[string]int64{rejectionCauseQuota: 0, rejectionCauseMaintenance: 0}
+	sessionRejections.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			counts[kv.Key] = v.Value()
+		}
+	})
+	causes := make([]string, 0, len(counts))
+	for cause := range counts {
+		causes = append(causes, cause)
+	}
+	sort.Strings(causes)
+
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	fmt.Fprintln(c.Writer, "# HELP ambient_session_rejections_total Session creations refused, by cause.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_session_rejections_total counter")
+	for _, cause := range causes {
+		fmt.Fprintf(c.Writer, "ambient_session_rejections_total{cause=%q} %d\n", cause, counts[cause])
+	}
+
+	// Kubernetes API calls, counted by the server package's client transport
+	fmt.Fprintln(c.Writer, "# HELP ambient_k8s_api_requests_total Kubernetes API calls made by the backend, by verb.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_k8s_api_requests_total counter")
+	for _, kv := range expvarInts("k8s_api_requests") {
+		fmt.Fprintf(c.Writer, "ambient_k8s_api_requests_total{verb=%q} %d\n", kv.key, kv.value)
+	}
+	fmt.Fprintln(c.Writer, "# HELP ambient_k8s_api_errors_total Failed or throttled Kubernetes API calls, by error class and verb.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_k8s_api_errors_total counter")
+	for _, kv := range expvarInts("k8s_api_errors") {
+		class, verb, _ := strings.Cut(kv.key, ":")
+		fmt.Fprintf(c.Writer, "ambient_k8s_api_errors_total{class=%q,verb=%q} %d\n", class, verb, kv.value)
+	}
+	fmt.Fprintln(c.Writer, "# HELP ambient_k8s_client_throttle_seconds_total Time Kubernetes API calls waited for the client-side rate limiter.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_k8s_client_throttle_seconds_total counter")
+	throttled := 0.0
+	if v, ok := expvar.Get("k8s_client_throttle_seconds").(*expvar.Float); ok {
+		throttled = v.Value()
+	}
+	fmt.Fprintf(c.Writer, "ambient_k8s_client_throttle_seconds_total %g\n", throttled)
+
+	// Authentication failures and lockouts, counted by the authn package
+	fmt.Fprintln(c.Writer, "# HELP ambient_auth_failures_total Rejected credentials, by the provider or API server that rejected them.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_auth_failures_total counter")
+	for _, kv := range expvarInts("auth_failures") {
+		fmt.Fprintf(c.Writer, "ambient_auth_failures_total{source=%q} %d\n", kv.key, kv.value)
+	}
+	fmt.Fprintln(c.Writer, "# HELP ambient_auth_lockouts_total Lockouts started after repeated authentication failures, by scope (identity or ip).")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_auth_lockouts_total counter")
+	for _, kv := range expvarInts("auth_lockouts") {
+		fmt.Fprintf(c.Writer, "ambient_auth_lockouts_total{scope=%q} %d\n", kv.key, kv.value)
+	}
+	fmt.Fprintln(c.Writer, "# HELP ambient_auth_locked_requests_total Requests refused because their IP or identity was locked out, by scope.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_auth_locked_requests_total counter")
+	for _, kv := range expvarInts("auth_locked_requests") {
+		fmt.Fprintf(c.Writer, "ambient_auth_locked_requests_total{scope=%q} %d\n", kv.key, kv.value)
+	}
+
+	// Session frames shared between backend replicas, counted by the websocket package's relay
+	fmt.Fprintln(c.Writer, "# HELP ambient_ws_relay_frames_total Session frames relayed between backend replicas, by result (forwarded, pushed, received, dropped or failed).")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_ws_relay_frames_total counter")
+	for _, kv := range expvarInts("ws_relay_frames") {
+		fmt.Fprintf(c.Writer, "ambient_ws_relay_frames_total{result=%q} %d\n", kv.key, kv.value)
+	}
+
+	// Retried operations, counted by RetryWithBackoff and retryOnConflict
+	fmt.Fprintln(c.Writer, "# HELP ambient_retry_attempts_total Tries of retried operations, by operation.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_retry_attempts_total counter")
+	for _, kv := range expvarInts("retry_attempts") {
+		fmt.Fprintf(c.Writer, "ambient_retry_attempts_total{operation=%q} %d\n", kv.key, kv.value)
+	}
+	fmt.Fprintln(c.Writer, "# HELP ambient_retry_attempt_failures_total Tries of retried operations that returned an error, by operation.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_retry_attempt_failures_total counter")
+	for _, kv := range expvarInts("retry_attempt_failures") {
+		fmt.Fprintf(c.Writer, "ambient_retry_attempt_failures_total{operation=%q} %d\n", kv.key, kv.value)
+	}
+	fmt.Fprintln(c.Writer, "# HELP ambient_retry_failures_total Retried operations that failed after their last try, by operation.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_retry_failures_total counter")
+	for _, kv := range expvarInts("retry_failures") {
+		fmt.Fprintf(c.Writer, "ambient_retry_failures_total{operation=%q} %d\n", kv.key, kv.value)
+	}
+	fmt.Fprintln(c.Writer, "# HELP ambient_retry_duration_seconds Time retried operations took, backoff included, by operation.")
+	fmt.Fprintln(c.Writer, "# TYPE ambient_retry_duration_seconds summary")
+	durations := expvarFloats("retry_duration_seconds")
+	for _, kv := range expvarInts("retry_operations") {
+		fmt.Fprintf(c.Writer, "ambient_retry_duration_seconds_sum{operation=%q} %g\n", kv.key, durations[kv.key])
+		fmt.Fprintf(c.Writer, "ambient_retry_duration_seconds_count{operation=%q} %d\n", kv.key, kv.value)
+	}
+}
+
+type expvarInt struct {
+	key   string
+	value int64
+}
+
+// expvarInts returns the integer entries of an expvar map, sorted by key
+func expvarInts(name string) []expvarInt {
+	m, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		return nil
+	}
+	var out []expvarInt
+	// Do visits keys in sorted order
+	m.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			out = append(out, expvarInt{key: kv.Key, value: v.Value()})
+		}
+	})
+	return out
+}
+
+// expvarFloats returns the float entries of an expvar map
+func expvarFloats(name string) map[string]float64 {
+	out := map[string]float64{}
+	if m, ok := expvar.Get(name).(*expvar.Map); ok {
+		m.Do(func(kv expvar.KeyValue) {
+			if v, ok := kv.Value.(*expvar.Float); ok {
+				out[kv.Key] = v.Value()
+			}
+		})
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TestRetryMetrics verifies retried operations are counted per operation name in /metrics
+func TestRetryMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	if err := RetryWithBackoff("test_flaky_get", 3, 0, 0, func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("not yet")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RetryWithBackoff("test_broken_get", 2, 0, 0, func() error { return errors.New("down") }); err == nil {
+		t.Fatal("expected the operation to fail after its retries")
+	}
+	conflicts := 0
+	if err := retryOnConflict("test_status_update", func() error {
+		conflicts++
+		if conflicts == 1 {
+			return k8serrors.NewConflict(schema.GroupResource{Resource: "agenticsessions"}, "s1", errors.New("modified"))
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	Metrics(c)
+	body := w.Body.String()
+	for _, want := range []string{
+		`ambient_retry_attempts_total{operation="test_flaky_get"} 2`,
+		`ambient_retry_attempt_failures_total{operation="test_flaky_get"} 1`,
+		`ambient_retry_attempts_total{operation="test_broken_get"} 2`,
+		`ambient_retry_failures_total{operation="test_broken_get"} 1`,
+		`ambient_retry_attempts_total{operation="test_status_update"} 2`,
+		`ambient_retry_duration_seconds_count{operation="test_flaky_get"} 1`,
+		`ambient_retry_duration_seconds_sum{operation="test_broken_get"} `,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics are missing %s", want)
+		}
+	}
+	if strings.Contains(body, `ambient_retry_failures_total{operation="test_flaky_get"}`) {
+		t.Error("an operation that succeeded on retry was counted as failed")
+	}
+}
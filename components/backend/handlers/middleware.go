@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"log"
@@ -8,6 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"ambient-code-backend/authn"
+	"ambient-code-pkg/runtimeconfig"
+
 	"github.com/gin-gonic/gin"
 	authv1 "k8s.io/api/authorization/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -22,6 +26,8 @@ import (
 var (
 	BaseKubeConfig *rest.Config
 	K8sClientMw    *kubernetes.Clientset
+	// RecordRequestTiming adds to a Server-Timing phase of the current request
+	RecordRequestTiming = func(ctx context.Context, phase string, d time.Duration) {}
 )
 
 // Helper functions and types
@@ -39,64 +45,77 @@ type ContentListItem struct {
 	ModifiedAt string `json:"modifiedAt"`
 }
 
-// GetK8sClientsForRequest returns K8s typed and dynamic clients using the caller's token when provided.
-// It supports both Authorization: Bearer and X-Forwarded-Access-Token and NEVER falls back to the backend service account.
-// Returns nil, nil if no valid user token is provided - all API operations require user authentication.
+// GetK8sClientsForRequest returns K8s typed and dynamic clients acting as the caller, as
+// identified by the configured auth providers (see package authn). A caller with a Kubernetes
+// token (Authorization: Bearer or X-Forwarded-Access-Token) is served with that token; OIDC
+// and static-token callers through the backend service account impersonating them. It NEVER
+// acts as the backend service account itself.
+// Returns nil, nil if the caller is not authenticated - all API operations require user authentication.
 func GetK8sClientsForRequest(c *gin.Context) (*kubernetes.Clientset, dynamic.Interface) {
-	// Prefer Authorization header (Bearer <token>)
-	rawAuth := c.GetHeader("Authorization")
-	rawFwd := c.GetHeader("X-Forwarded-Access-Token")
-	tokenSource := "none"
-	token := rawAuth
-
-	if token != "" {
-		tokenSource = "authorization"
-		parts := strings.SplitN(token, " ", 2)
-		if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-			token = strings.TrimSpace(parts[1])
+	id, err := authn.Identify(c)
+	if err != nil {
+		if err == authn.ErrNoCredentials {
+			runtimeconfig.Debugf("No user token found for %s (hasAuthHeader=%t hasFwdToken=%t)", c.FullPath(),
+				strings.TrimSpace(c.GetHeader("Authorization")) != "", strings.TrimSpace(c.GetHeader("X-Forwarded-Access-Token")) != "")
 		} else {
-			token = strings.TrimSpace(token)
+			log.Printf("Rejected credentials for %s: %v", c.FullPath(), err)
 		}
+		return nil, nil
 	}
-	// Fallback to X-Forwarded-Access-Token
-	if token == "" {
-		if rawFwd != "" {
-			tokenSource = "x-forwarded-access-token"
-		}
-		token = rawFwd
+	if BaseKubeConfig == nil {
+		return nil, nil
 	}
 
-	// Debug: basic auth header state (do not log token)
-	hasAuthHeader := strings.TrimSpace(rawAuth) != ""
-	hasFwdToken := strings.TrimSpace(rawFwd) != ""
-
-	if token != "" && BaseKubeConfig != nil {
-		cfg := *BaseKubeConfig
-		cfg.BearerToken = token
+	cfg := *BaseKubeConfig
+	if id.Token != "" {
+		cfg.BearerToken = id.Token
 		// Ensure we do NOT fall back to the in-cluster SA token or other auth providers
 		cfg.BearerTokenFile = ""
 		cfg.AuthProvider = nil
 		cfg.ExecProvider = nil
 		cfg.Username = ""
 		cfg.Password = ""
-
-		kc, err1 := kubernetes.NewForConfig(&cfg)
-		dc, err2 := dynamic.NewForConfig(&cfg)
-
-		if err1 == nil && err2 == nil {
-
-			// Best-effort update last-used for service account tokens
-			updateAccessKeyLastUsedAnnotation(c)
-			return kc, dc
+		// Tokens the API server rejects count toward the caller's lockout on every route
+		cfg.Wrap(recordRejectedToken(authn.FailureRecorder(c, "kubernetes")))
+	} else {
+		if id.UserName == "" {
+			return nil, nil
 		}
+		cfg.Impersonate = rest.ImpersonationConfig{UserName: id.UserName, Groups: id.Groups}
+	}
+
+	kc, err1 := kubernetes.NewForConfig(&cfg)
+	dc, err2 := dynamic.NewForConfig(&cfg)
+	if err1 != nil || err2 != nil {
 		// Token provided but client build failed – treat as invalid token
-		log.Printf("Failed to build user-scoped k8s clients (source=%s tokenLen=%d) typedErr=%v dynamicErr=%v for %s", tokenSource, len(token), err1, err2, c.FullPath())
-		return nil, nil
-	} else {
-		// No token provided
-		log.Printf("No user token found for %s (hasAuthHeader=%t hasFwdToken=%t)", c.FullPath(), hasAuthHeader, hasFwdToken)
+		log.Printf("Failed to build user-scoped k8s clients (provider=%s) typedErr=%v dynamicErr=%v for %s", id.Provider, err1, err2, c.FullPath())
 		return nil, nil
 	}
+	if id.Token != "" {
+		// Best-effort update last-used for service account tokens
+		updateAccessKeyLastUsedAnnotation(c)
+	}
+	return kc, dc
+}
+
+// recordRejectedToken wraps a user-scoped client's transport to record a failure when the
+// API server answers 401
+func recordRejectedToken(record func()) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := rt.RoundTrip(req)
+			if err == nil && resp.StatusCode == http.StatusUnauthorized {
+				record()
+			}
+			return resp, err
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
 }
 
 // updateAccessKeyLastUsedAnnotation attempts to update the ServiceAccount's last-used annotation
@@ -223,6 +242,7 @@ func ExtractServiceAccountFromAuth(c *gin.Context) (string, string, bool) {
 // ValidateProjectContext is middleware for project context validation
 func ValidateProjectContext() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		authStart := time.Now()
 		// Allow token via query parameter for websocket/agent callers
 		if c.GetHeader("Authorization") == "" && c.GetHeader("X-Forwarded-Access-Token") == "" {
 			if qp := strings.TrimSpace(c.Query("token")); qp != "" {
@@ -231,13 +251,13 @@ func ValidateProjectContext() gin.HandlerFunc {
 		}
 		// Require user/API key token; do not fall back to service account
 		if c.GetHeader("Authorization") == "" && c.GetHeader("X-Forwarded-Access-Token") == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User token required"})
+			respondMessage(c, http.StatusUnauthorized, "auth.userTokenRequired", nil)
 			c.Abort()
 			return
 		}
 		reqK8s, _ := GetK8sClientsForRequest(c)
 		if reqK8s == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 			c.Abort()
 			return
 		}
@@ -247,7 +267,7 @@ func ValidateProjectContext() gin.HandlerFunc {
 			projectHeader = c.GetHeader("X-OpenShift-Project")
 		}
 		if projectHeader == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Project is required in path /api/projects/:projectName or X-OpenShift-Project header"})
+			respondMessage(c, http.StatusBadRequest, "project.required", nil)
 			c.Abort()
 			return
 		}
@@ -264,20 +284,27 @@ func ValidateProjectContext() gin.HandlerFunc {
 			},
 		}
 		res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
+		if errors.IsUnauthorized(err) {
+			// A forwarded token the API server does not accept; the client recorded the failure
+			respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+			c.Abort()
+			return
+		}
 		if err != nil {
 			log.Printf("validateProjectContext: SSAR failed for %s: %v", projectHeader, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to perform access review"})
+			respondMessage(c, http.StatusInternalServerError, "auth.accessReviewFailed", nil)
 			c.Abort()
 			return
 		}
 		if !res.Status.Allowed {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to access project"})
+			respondMessage(c, http.StatusForbidden, "project.accessDenied", nil)
 			c.Abort()
 			return
 		}
 
 		// Store project in context for handlers
 		c.Set("project", projectHeader)
+		RecordRequestTiming(c.Request.Context(), "auth", time.Since(authStart))
 		c.Next()
 	}
 }
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ambient-code-backend/authn"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// TestGetK8sClientsForRequest_RecordsRejectedToken verifies a token the API server rejects
+// counts toward the caller's lockout on routes without project middleware, once per request
+func TestGetK8sClientsForRequest_RecordsRejectedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Unauthorized","code":401}`))
+	}))
+	defer apiServer.Close()
+	prev := BaseKubeConfig
+	BaseKubeConfig = &rest.Config{Host: apiServer.URL}
+	lockout := authn.NewLockout(0, 2, time.Minute, time.Minute)
+	authn.SetLockout(lockout)
+	t.Cleanup(func() {
+		BaseKubeConfig = prev
+		authn.SetLockout(nil)
+	})
+
+	request := func() {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/cluster-info", nil)
+		c.Request.RemoteAddr = "192.0.2.7:1234"
+		c.Request.Header.Set("Authorization", "Bearer guess")
+		kc, _ := GetK8sClientsForRequest(c)
+		if kc == nil {
+			t.Fatal("no clients for a bearer token")
+		}
+		for i := 0; i < 2; i++ {
+			_, _ = kc.CoreV1().Namespaces().Get(context.Background(), "default", v1.GetOptions{})
+		}
+	}
+
+	request()
+	if _, locked := lockout.Locked("192.0.2.7", ""); locked {
+		t.Fatal("two rejected calls of one request counted twice")
+	}
+	request()
+	if _, locked := lockout.Locked("192.0.2.7", ""); !locked {
+		t.Error("rejected tokens outside project routes not counted")
+	}
+}
@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	rbacv1client "k8s.io/client-go/kubernetes/typed/rbac/v1"
+)
+
+// Ownership transfers hand a project over when its owner leaves the team. One call makes the
+// new owner a project admin, moves the previous owner's sessions to them (creator annotation
+// and spec.userContext, which selects whose GitHub credentials a session pushes with), updates
+// the project's requester annotation and finally removes or downgrades the previous owner's
+// roles. Kubernetes has no transactions: every step registers its undo and a failing step
+// undoes the earlier ones, so a transfer either completes or leaves the project as it was.
+// Every transfer, completed or rolled back, is recorded as a ConfigMap in the project namespace.
+
+const (
+	ownershipTransferLabel = "ambient-code.io/ownership-transfer"
+	ownershipTransferKey   = "transfer.json"
+	// transferredFromAnnotation marks sessions and RoleBindings a transfer moved to a new owner
+	transferredFromAnnotation = "ambient-code.io/transferred-from"
+	requesterAnnotation       = "openshift.io/requester"
+	// ownershipTransferTimeout bounds a whole transfer, rollback included
+	ownershipTransferTimeout = 2 * time.Minute
+)
+
+// errNothingToTransfer means the previous owner has no roles, sessions or requester annotation
+var errNothingToTransfer = fmt.Errorf("nothing to transfer")
+
+func ownershipTransferConfigMapName(id string) string {
+	return "ownership-transfer-" + id
+}
+
+// TransferProjectOwnership handles POST /api/projects/:projectName/ownership/transfer
+func TransferProjectOwnership(c *gin.Context) {
+	projectName := c.Param("projectName")
+	if !requireProjectAdmin(c, projectName) {
+		return
+	}
+	if DynamicClient == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Backend client not initialized"})
+		return
+	}
+
+	var req types.OwnershipTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateOwnershipTransfer(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	by, _ := getUserSubjectFromContext(c)
+
+	// Not tied to the request context: a transfer must complete or roll back even if the client went away
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), ownershipTransferTimeout)
+	defer cancel()
+
+	record, err := transferOwnership(ctx, K8sClientProjects, DynamicClient, projectName, req, by)
+	if err == errNothingToTransfer {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s has no roles or sessions in project %s", req.From, projectName)})
+		return
+	}
+	if err != nil {
+		log.Printf("Ownership transfer in %s failed: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer ownership"})
+		return
+	}
+	if err := saveOwnershipTransfer(ctx, K8sClientProjects, record); err != nil {
+		log.Printf("Failed to record ownership transfer %s in %s: %v", record.ID, projectName, err)
+	}
+
+	if record.Status != types.OwnershipTransferCompleted {
+		log.Printf("Ownership transfer %s in %s rolled back: %s", record.ID, projectName, record.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ownership transfer failed and was rolled back", "transfer": record})
+		return
+	}
+	log.Printf("User %s transferred project %s from %s to %s (%d sessions)", by, projectName, req.From, req.To, len(record.Sessions))
+	c.JSON(http.StatusCreated, record)
+}
+
+// ListOwnershipTransfers handles GET /api/projects/:projectName/ownership/transfers
+// Transfers are newest first.
+func ListOwnershipTransfers(c *gin.Context) {
+	projectName := c.Param("projectName")
+	if !requireProjectAdmin(c, projectName) {
+		return
+	}
+	cms, err := K8sClientProjects.CoreV1().ConfigMaps(projectName).List(c.Request.Context(), v1.ListOptions{LabelSelector: ownershipTransferLabel + "=true"})
+	if err != nil {
+		log.Printf("Failed to list ownership transfers in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list ownership transfers"})
+		return
+	}
+	items := []types.OwnershipTransfer{}
+	for _, cm := range cms.Items {
+		var record types.OwnershipTransfer
+		if err := json.Unmarshal([]byte(cm.Data[ownershipTransferKey]), &record); err != nil {
+			log.Printf("Skipping invalid ownership transfer record %s/%s: %v", projectName, cm.Name, err)
+			continue
+		}
+		items = append(items, record)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].TransferredAt.After(items[j].TransferredAt) })
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func validateOwnershipTransfer(req *types.OwnershipTransferRequest) error {
+	req.From = strings.TrimSpace(req.From)
+	req.To = strings.TrimSpace(req.To)
+	req.PreviousOwnerRole = strings.ToLower(strings.TrimSpace(req.PreviousOwnerRole))
+	if req.From == "" || req.To == "" {
+		return fmt.Errorf("from and to are required")
+	}
+	if req.From == req.To {
+		return fmt.Errorf("from and to must differ")
+	}
+	if getUserSubjectKind(req.From) != "User" || getUserSubjectKind(req.To) != "User" {
+		return fmt.Errorf("ownership can only be transferred between users")
+	}
+	if req.PreviousOwnerRole != "" && req.PreviousOwnerRole != "edit" && req.PreviousOwnerRole != "view" {
+		return fmt.Errorf("previousOwnerRole must be edit, view or empty")
+	}
+	return nil
+}
+
+// transferOwnership performs a validated transfer with the backend service account. A failed
+// step is rolled back and reported in the returned record; the error is only set when nothing
+// was changed.
+func transferOwnership(ctx context.Context, k8s kubernetes.Interface, dyn dynamic.Interface, project string, req types.OwnershipTransferRequest, by string) (*types.OwnershipTransfer, error) {
+	rbs := k8s.RbacV1().RoleBindings(project)
+	all, err := rbs.List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list RoleBindings: %w", err)
+	}
+	owned := ownedRoleBindings(all.Items, req.From)
+
+	var sessions []unstructured.Unstructured
+	if !req.KeepSessionCreators {
+		list, err := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).List(ctx, v1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("list sessions: %w", err)
+		}
+		for _, s := range list.Items {
+			if sessionCreatedBy(&s, req.From) {
+				sessions = append(sessions, s)
+			}
+		}
+	}
+
+	ns, err := k8s.CoreV1().Namespaces().Get(ctx, project, v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get namespace: %w", err)
+	}
+	isRequester := ns.Annotations[requesterAnnotation] == req.From
+
+	if len(owned) == 0 && len(sessions) == 0 && !isRequester {
+		return nil, errNothingToTransfer
+	}
+
+	record := &types.OwnershipTransfer{
+		ID:                newRecordID(),
+		Project:           project,
+		From:              req.From,
+		To:                req.To,
+		PreviousOwnerRole: req.PreviousOwnerRole,
+		TransferredBy:     by,
+		TransferredAt:     time.Now().UTC(),
+		Status:            types.OwnershipTransferCompleted,
+	}
+	var undo []func() error
+	fail := func(step string, err error) (*types.OwnershipTransfer, error) {
+		record.Status = types.OwnershipTransferRolledBack
+		record.Error = fmt.Sprintf("%s: %v", step, err)
+		for i := len(undo) - 1; i >= 0; i-- {
+			if uerr := undo[i](); uerr != nil {
+				log.Printf("CRITICAL: Ownership transfer %s in %s could not undo a step: %v", record.ID, project, uerr)
+			}
+		}
+		return record, nil
+	}
+
+	// The new owner becomes admin first, so the project always has one
+	granted, err := grantTransferRole(ctx, rbs, project, req.To, "admin", req.From, by)
+	if err != nil {
+		return fail("grant admin to "+req.To, err)
+	}
+	if granted != "" {
+		record.GrantedRoleBindings = append(record.GrantedRoleBindings, granted)
+		undo = append(undo, func() error { return deleteRoleBinding(ctx, rbs, granted) })
+	}
+
+	sessionClient := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project)
+	for i := range sessions {
+		s := &sessions[i]
+		forward, back, err := sessionCreatorPatches(s, req.From, req.To)
+		if err != nil {
+			return fail("reassign session "+s.GetName(), err)
+		}
+		if _, err := sessionClient.Patch(ctx, s.GetName(), ktypes.MergePatchType, forward, v1.PatchOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fail("reassign session "+s.GetName(), err)
+		}
+		name := s.GetName()
+		record.Sessions = append(record.Sessions, name)
+		undo = append(undo, func() error {
+			_, err := sessionClient.Patch(ctx, name, ktypes.MergePatchType, back, v1.PatchOptions{})
+			return err
+		})
+	}
+
+	if isRequester {
+		if err := patchRequester(ctx, k8s, project, req.To); err != nil {
+			return fail("update requester annotation", err)
+		}
+		record.RequesterUpdated = true
+		undo = append(undo, func() error { return patchRequester(ctx, k8s, project, req.From) })
+	}
+
+	// kept is the binding of the role the previous owner keeps, whether created now or before
+	kept := ""
+	if req.PreviousOwnerRole != "" {
+		created, err := grantTransferRole(ctx, rbs, project, req.From, req.PreviousOwnerRole, "", by)
+		if err != nil {
+			return fail("grant "+req.PreviousOwnerRole+" to "+req.From, err)
+		}
+		if created != "" {
+			record.GrantedRoleBindings = append(record.GrantedRoleBindings, created)
+			undo = append(undo, func() error { return deleteRoleBinding(ctx, rbs, created) })
+		}
+		kept = permissionRoleBindingName(req.From, "user", req.PreviousOwnerRole)
+	}
+
+	// Removing the previous owner's roles comes last: until here they can still fix things up
+	for i := range owned {
+		rb := owned[i]
+		if rb.Name == kept {
+			continue
+		}
+		restore, err := removeRoleBindingSubject(ctx, rbs, &rb, req.From)
+		if err != nil {
+			return fail("remove RoleBinding "+rb.Name, err)
+		}
+		record.RemovedRoleBindings = append(record.RemovedRoleBindings, rb.Name)
+		undo = append(undo, restore)
+	}
+	return record, nil
+}
+
+// ownedRoleBindings returns the Ambient-managed RoleBindings (members, permissions, group
+// access and the creator's admin binding) with user as a subject
+func ownedRoleBindings(all []rbacv1.RoleBinding, user string) []rbacv1.RoleBinding {
+	out := []rbacv1.RoleBinding{}
+	for _, rb := range all {
+		if rb.Labels["app"] != "ambient-permission" && rb.Labels["app"] != "ambient-group-access" && rb.Labels["ambient-code.io/role"] == "" {
+			continue
+		}
+		for _, sub := range rb.Subjects {
+			if sub.Kind == "User" && sub.Name == user {
+				out = append(out, rb)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// sessionCreatedBy reports whether user created the session, by annotation or user context
+func sessionCreatedBy(obj *unstructured.Unstructured, user string) bool {
+	if obj.GetAnnotations()[createdByAnnotation] == user {
+		return true
+	}
+	uid, _, _ := unstructured.NestedString(obj.Object, "spec", "userContext", "userId")
+	return uid == user
+}
+
+// sessionCreatorPatches returns the merge patch moving a session to its new creator and the one
+// restoring its current annotations and user context
+func sessionCreatorPatches(obj *unstructured.Unstructured, from, to string) ([]byte, []byte, error) {
+	forward, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+			createdByAnnotation:       to,
+			transferredFromAnnotation: from,
+		}},
+		// The display name and groups were the previous creator's
+		"spec": map[string]interface{}{"userContext": map[string]interface{}{
+			"userId":      to,
+			"displayName": nil,
+			"groups":      nil,
+		}},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prev := func(key string) interface{} {
+		if v, ok := obj.GetAnnotations()[key]; ok {
+			return v
+		}
+		return nil
+	}
+	var userContext interface{}
+	if uc, found, _ := unstructured.NestedMap(obj.Object, "spec", "userContext"); found {
+		userContext = uc
+	}
+	back, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+			createdByAnnotation:       prev(createdByAnnotation),
+			transferredFromAnnotation: prev(transferredFromAnnotation),
+		}},
+		"spec": map[string]interface{}{"userContext": userContext},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return forward, back, nil
+}
+
+// grantTransferRole creates the permission RoleBinding giving user role. It returns the name of
+// the binding when it was created, or "" when the user already had it.
+func grantTransferRole(ctx context.Context, rbs rbacv1client.RoleBindingInterface, project, user, role, transferredFrom, by string) (string, error) {
+	rb, err := newPermissionRoleBinding(project, "user", user, role)
+	if err != nil {
+		return "", err
+	}
+	rb.Annotations["ambient-code.io/granted-by"] = by
+	if transferredFrom != "" {
+		rb.Annotations[transferredFromAnnotation] = transferredFrom
+	}
+	if _, err := rbs.Create(ctx, rb, v1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return rb.Name, nil
+}
+
+// removeRoleBindingSubject deletes a binding that only names user, or drops user from a shared
+// one. It returns the undo.
+func removeRoleBindingSubject(ctx context.Context, rbs rbacv1client.RoleBindingInterface, rb *rbacv1.RoleBinding, user string) (func() error, error) {
+	if len(rb.Subjects) == 1 {
+		if err := deleteRoleBinding(ctx, rbs, rb.Name); err != nil {
+			return nil, err
+		}
+		restored := rb.DeepCopy()
+		restored.ResourceVersion = ""
+		restored.UID = ""
+		return func() error {
+			_, err := rbs.Create(ctx, restored, v1.CreateOptions{})
+			return err
+		}, nil
+	}
+
+	removed := []rbacv1.Subject{}
+	updated := rb.DeepCopy()
+	updated.Subjects = nil
+	for _, sub := range rb.Subjects {
+		if sub.Kind == "User" && sub.Name == user {
+			removed = append(removed, sub)
+			continue
+		}
+		updated.Subjects = append(updated.Subjects, sub)
+	}
+	if _, err := rbs.Update(ctx, updated, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return func() error {
+		current, err := rbs.Get(ctx, rb.Name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current.Subjects = append(current.Subjects, removed...)
+		_, err = rbs.Update(ctx, current, v1.UpdateOptions{})
+		return err
+	}, nil
+}
+
+func deleteRoleBinding(ctx context.Context, rbs rbacv1client.RoleBindingInterface, name string) error {
+	if err := rbs.Delete(ctx, name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func patchRequester(ctx context.Context, k8s kubernetes.Interface, project, requester string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]string{requesterAnnotation: requester}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = k8s.CoreV1().Namespaces().Patch(ctx, project, ktypes.MergePatchType, patch, v1.PatchOptions{})
+	return err
+}
+
+// saveOwnershipTransfer records a transfer in the project namespace
+func saveOwnershipTransfer(ctx context.Context, k8s kubernetes.Interface, record *types.OwnershipTransfer) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = k8s.CoreV1().ConfigMaps(record.Project).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   ownershipTransferConfigMapName(record.ID),
+			Labels: map[string]string{ownershipTransferLabel: "true"},
+		},
+		Data: map[string]string{ownershipTransferKey: string(b)},
+	}, v1.CreateOptions{})
+	return err
+}
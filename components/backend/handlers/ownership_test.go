@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"ambient-code-backend/types"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func transferTestSession(name, creator string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   "team-a",
+			"annotations": map[string]interface{}{createdByAnnotation: creator},
+		},
+		"spec": map[string]interface{}{
+			"userContext": map[string]interface{}{"userId": creator, "displayName": creator + " (display)"},
+		},
+	}}
+}
+
+func transferTestClients(t *testing.T) (*fake.Clientset, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	gvr := schema.GroupVersionResource{Group: "vteam.ambient-code", Version: "v1alpha1", Resource: "agenticsessions"}
+	GetAgenticSessionV1Alpha1Resource = func() schema.GroupVersionResource { return gvr }
+
+	creatorBinding := &rbacv1.RoleBinding{
+		ObjectMeta: v1.ObjectMeta{Name: "ambient-admin-alice", Namespace: "team-a", Labels: map[string]string{"ambient-code.io/role": "admin"}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: AmbientRoleAdmin},
+		Subjects:   []rbacv1.Subject{{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "alice"}},
+	}
+	shared := &rbacv1.RoleBinding{
+		ObjectMeta: v1.ObjectMeta{Name: "ambient-permission-edit-team", Namespace: "team-a", Labels: map[string]string{"app": "ambient-permission"}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: AmbientRoleEdit},
+		Subjects: []rbacv1.Subject{
+			{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "alice"},
+			{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "carol"},
+		},
+	}
+	// Not Ambient-managed, so never touched
+	manual := &rbacv1.RoleBinding{
+		ObjectMeta: v1.ObjectMeta{Name: "manual", Namespace: "team-a"},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "view"},
+		Subjects:   []rbacv1.Subject{{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "alice"}},
+	}
+	ns := &corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "team-a", Annotations: map[string]string{requesterAnnotation: "alice"}}}
+	k8s := fake.NewSimpleClientset(ns, creatorBinding, shared, manual)
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "AgenticSessionList"},
+		transferTestSession("s1", "alice"), transferTestSession("s2", "carol"))
+	return k8s, dyn
+}
+
+func TestTransferOwnership(t *testing.T) {
+	k8s, dyn := transferTestClients(t)
+	ctx := context.Background()
+	req := types.OwnershipTransferRequest{From: "alice", To: "bob", PreviousOwnerRole: "view"}
+
+	record, err := transferOwnership(ctx, k8s, dyn, "team-a", req, "admin")
+	if err != nil {
+		t.Fatalf("transferOwnership: %v", err)
+	}
+	if record.Status != types.OwnershipTransferCompleted {
+		t.Fatalf("status = %s (%s), want Completed", record.Status, record.Error)
+	}
+	if len(record.Sessions) != 1 || record.Sessions[0] != "s1" || !record.RequesterUpdated {
+		t.Errorf("record = %+v", record)
+	}
+
+	rbs, _ := k8s.RbacV1().RoleBindings("team-a").List(ctx, v1.ListOptions{})
+	got := map[string]string{}
+	for _, a := range collectPermissionAssignments(rbs.Items) {
+		got[a.SubjectName] = a.Role
+	}
+	if got["bob"] != "admin" || got["alice"] != "view" || got["carol"] != "edit" {
+		t.Errorf("assignments = %v, want bob admin, alice view, carol edit", got)
+	}
+	if _, err := k8s.RbacV1().RoleBindings("team-a").Get(ctx, "manual", v1.GetOptions{}); err != nil {
+		t.Errorf("unmanaged binding removed: %v", err)
+	}
+
+	s1, _ := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("team-a").Get(ctx, "s1", v1.GetOptions{})
+	uc, _, _ := unstructured.NestedMap(s1.Object, "spec", "userContext")
+	if s1.GetAnnotations()[createdByAnnotation] != "bob" || s1.GetAnnotations()[transferredFromAnnotation] != "alice" || uc["userId"] != "bob" || uc["displayName"] != nil {
+		t.Errorf("s1 annotations %v, userContext %v", s1.GetAnnotations(), uc)
+	}
+	s2, _ := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("team-a").Get(ctx, "s2", v1.GetOptions{})
+	if s2.GetAnnotations()[createdByAnnotation] != "carol" {
+		t.Errorf("s2 reassigned: %v", s2.GetAnnotations())
+	}
+	ns, _ := k8s.CoreV1().Namespaces().Get(ctx, "team-a", v1.GetOptions{})
+	if ns.Annotations[requesterAnnotation] != "bob" {
+		t.Errorf("requester = %q", ns.Annotations[requesterAnnotation])
+	}
+
+	if _, err := transferOwnership(ctx, k8s, dyn, "team-a", types.OwnershipTransferRequest{From: "dave", To: "bob"}, "admin"); err != errNothingToTransfer {
+		t.Errorf("unknown previous owner: err = %v, want errNothingToTransfer", err)
+	}
+}
+
+func TestTransferOwnershipRollsBack(t *testing.T) {
+	k8s, dyn := transferTestClients(t)
+	ctx := context.Background()
+	k8s.PrependReactor("update", "rolebindings", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("conflict")
+	})
+
+	record, err := transferOwnership(ctx, k8s, dyn, "team-a", types.OwnershipTransferRequest{From: "alice", To: "bob"}, "admin")
+	if err != nil {
+		t.Fatalf("transferOwnership: %v", err)
+	}
+	if record.Status != types.OwnershipTransferRolledBack || record.Error == "" {
+		t.Fatalf("record = %+v, want RolledBack with an error", record)
+	}
+
+	rbs, _ := k8s.RbacV1().RoleBindings("team-a").List(ctx, v1.ListOptions{})
+	got := map[string]string{}
+	for _, a := range collectPermissionAssignments(rbs.Items) {
+		got[a.SubjectName] = a.Role
+	}
+	if _, ok := got["bob"]; ok {
+		t.Errorf("bob kept a role after rollback: %v", got)
+	}
+	if _, err := k8s.RbacV1().RoleBindings("team-a").Get(ctx, "ambient-admin-alice", v1.GetOptions{}); err != nil {
+		t.Errorf("creator binding not restored: %v", err)
+	}
+	s1, _ := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("team-a").Get(ctx, "s1", v1.GetOptions{})
+	uc, _, _ := unstructured.NestedMap(s1.Object, "spec", "userContext")
+	if _, ok := s1.GetAnnotations()[transferredFromAnnotation]; ok || s1.GetAnnotations()[createdByAnnotation] != "alice" || uc["userId"] != "alice" || uc["displayName"] != "alice (display)" {
+		t.Errorf("s1 not restored: annotations %v, userContext %v", s1.GetAnnotations(), uc)
+	}
+	ns, _ := k8s.CoreV1().Namespaces().Get(ctx, "team-a", v1.GetOptions{})
+	if ns.Annotations[requesterAnnotation] != "alice" {
+		t.Errorf("requester = %q, want alice", ns.Annotations[requesterAnnotation])
+	}
+}
+
+func TestValidateOwnershipTransfer(t *testing.T) {
+	cases := []struct {
+		req     types.OwnershipTransferRequest
+		wantErr bool
+	}{
+		{types.OwnershipTransferRequest{From: "alice", To: "bob"}, false},
+		{types.OwnershipTransferRequest{From: " alice ", To: "bob", PreviousOwnerRole: "Edit"}, false},
+		{types.OwnershipTransferRequest{From: "alice"}, true},
+		{types.OwnershipTransferRequest{From: "alice", To: "alice"}, true},
+		{types.OwnershipTransferRequest{From: "alice", To: "system:serviceaccount:team-a:bot"}, true},
+		{types.OwnershipTransferRequest{From: "alice", To: "bob", PreviousOwnerRole: "admin"}, true},
+	}
+	for _, tc := range cases {
+		req := tc.req
+		if err := validateOwnershipTransfer(&req); (err != nil) != tc.wantErr {
+			t.Errorf("validateOwnershipTransfer(%+v) = %v, wantErr %v", tc.req, err, tc.wantErr)
+		}
+	}
+}
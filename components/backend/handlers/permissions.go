@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -57,19 +56,12 @@ type PermissionAssignment struct {
 	Role        string `json:"role"`
 }
 
-// ListProjectPermissions handles GET /api/projects/:projectName/permissions
-func ListProjectPermissions(c *gin.Context) {
-	projectName := c.Param("projectName")
-	reqK8s, _ := GetK8sClientsForRequest(c)
-
-	// Prefer new label, but also include legacy group-access for backward-compat listing
-	rbsAll, err := reqK8s.RbacV1().RoleBindings(projectName).List(context.TODO(), v1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list permissions"})
-		return
-	}
+// permissionBindingSelector selects the RoleBindings collectPermissionAssignments reads: those
+// the API creates and the legacy group access ones
+const permissionBindingSelector = "app in (ambient-permission,ambient-group-access)"
 
+// collectPermissionAssignments extracts user/group role assignments from Ambient-managed RoleBindings
+func collectPermissionAssignments(rbs []rbacv1.RoleBinding) []PermissionAssignment {
 	validRoles := map[string]string{
 		AmbientRoleAdmin: "admin",
 		AmbientRoleEdit:  "edit",
@@ -80,7 +72,7 @@ func ListProjectPermissions(c *gin.Context) {
 	seen := map[key]struct{}{}
 	assignments := []PermissionAssignment{}
 
-	for _, rb := range rbsAll.Items {
+	for _, rb := range rbs {
 		// Filter to Ambient-managed permission rolebindings
 		if rb.Labels["app"] != "ambient-permission" && rb.Labels["app"] != "ambient-group-access" {
 			continue
@@ -123,36 +115,31 @@ func ListProjectPermissions(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"items": assignments})
+	return assignments
 }
 
-// AddProjectPermission handles POST /api/projects/:projectName/permissions
-func AddProjectPermission(c *gin.Context) {
+// ListProjectPermissions handles GET /api/projects/:projectName/permissions
+func ListProjectPermissions(c *gin.Context) {
 	projectName := c.Param("projectName")
 	reqK8s, _ := GetK8sClientsForRequest(c)
 
-	var req struct {
-		SubjectType string `json:"subjectType" binding:"required"`
-		SubjectName string `json:"subjectName" binding:"required"`
-		Role        string `json:"role" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Prefer new label, but also include legacy group-access for backward-compat listing
+	rbsAll, err := reqK8s.RbacV1().RoleBindings(projectName).List(c.Request.Context(), v1.ListOptions{})
+	if err != nil {
+		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list permissions"})
 		return
 	}
 
-	st := strings.ToLower(strings.TrimSpace(req.SubjectType))
-	if st != "group" && st != "user" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectType must be one of: group, user"})
-		return
-	}
-	subjectKind := "Group"
-	if st == "user" {
-		subjectKind = "User"
-	}
+	c.JSON(http.StatusOK, gin.H{"items": collectPermissionAssignments(rbsAll.Items)})
+}
 
+// newPermissionRoleBinding builds the Ambient-managed RoleBinding granting subjectType
+// ("user" or "group") subjectName the given role (admin, edit or view) in projectName
+func newPermissionRoleBinding(projectName, subjectType, subjectName, role string) (*rbacv1.RoleBinding, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
 	roleRefName := ""
-	switch strings.ToLower(req.Role) {
+	switch role {
 	case "admin":
 		roleRefName = AmbientRoleAdmin
 	case "edit":
@@ -160,29 +147,62 @@ func AddProjectPermission(c *gin.Context) {
 	case "view":
 		roleRefName = AmbientRoleView
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of: admin, edit, view"})
-		return
+		return nil, fmt.Errorf("role must be one of: admin, edit, view")
+	}
+	subjectKind := "Group"
+	if subjectType == "user" {
+		subjectKind = "User"
 	}
 
-	rbName := "ambient-permission-" + strings.ToLower(req.Role) + "-" + sanitizeName(req.SubjectName) + "-" + st
-	rb := &rbacv1.RoleBinding{
+	return &rbacv1.RoleBinding{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      rbName,
+			Name:      permissionRoleBindingName(subjectName, subjectType, role),
 			Namespace: projectName,
 			Labels: map[string]string{
 				"app": "ambient-permission",
 			},
 			Annotations: map[string]string{
 				"ambient-code.io/subject-kind": subjectKind,
-				"ambient-code.io/subject-name": req.SubjectName,
-				"ambient-code.io/role":         strings.ToLower(req.Role),
+				"ambient-code.io/subject-name": subjectName,
+				"ambient-code.io/role":         role,
 			},
 		},
 		RoleRef:  rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: roleRefName},
-		Subjects: []rbacv1.Subject{{Kind: subjectKind, APIGroup: "rbac.authorization.k8s.io", Name: req.SubjectName}},
+		Subjects: []rbacv1.Subject{{Kind: subjectKind, APIGroup: "rbac.authorization.k8s.io", Name: subjectName}},
+	}, nil
+}
+
+func permissionRoleBindingName(subjectName, subjectType, role string) string {
+	return "ambient-permission-" + role + "-" + sanitizeName(subjectName) + "-" + subjectType
+}
+
+// AddProjectPermission handles POST /api/projects/:projectName/permissions
+func AddProjectPermission(c *gin.Context) {
+	projectName := c.Param("projectName")
+	reqK8s, _ := GetK8sClientsForRequest(c)
+
+	var req struct {
+		SubjectType string `json:"subjectType" binding:"required"`
+		SubjectName string `json:"subjectName" binding:"required"`
+		Role        string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	st := strings.ToLower(strings.TrimSpace(req.SubjectType))
+	if st != "group" && st != "user" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectType must be one of: group, user"})
+		return
+	}
+	rb, err := newPermissionRoleBinding(projectName, st, req.SubjectName, req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if _, err := reqK8s.RbacV1().RoleBindings(projectName).Create(context.TODO(), rb, v1.CreateOptions{}); err != nil {
+	if _, err := reqK8s.RbacV1().RoleBindings(projectName).Create(c.Request.Context(), rb, v1.CreateOptions{}); err != nil {
 		if errors.IsAlreadyExists(err) {
 			c.JSON(http.StatusConflict, gin.H{"error": "permission already exists for this subject and role"})
 			return
@@ -211,7 +231,7 @@ func RemoveProjectPermission(c *gin.Context) {
 		return
 	}
 
-	rbs, err := reqK8s.RbacV1().RoleBindings(projectName).List(context.TODO(), v1.ListOptions{LabelSelector: "app=ambient-permission"})
+	rbs, err := reqK8s.RbacV1().RoleBindings(projectName).List(c.Request.Context(), v1.ListOptions{LabelSelector: "app=ambient-permission"})
 	if err != nil {
 		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove permission"})
@@ -221,11 +241,11 @@ func RemoveProjectPermission(c *gin.Context) {
 	for _, rb := range rbs.Items {
 		for _, sub := range rb.Subjects {
 			if strings.EqualFold(sub.Kind, "Group") && subjectType == "group" && sub.Name == subjectName {
-				_ = reqK8s.RbacV1().RoleBindings(projectName).Delete(context.TODO(), rb.Name, v1.DeleteOptions{})
+				_ = reqK8s.RbacV1().RoleBindings(projectName).Delete(c.Request.Context(), rb.Name, v1.DeleteOptions{})
 				break
 			}
 			if strings.EqualFold(sub.Kind, "User") && subjectType == "user" && sub.Name == subjectName {
-				_ = reqK8s.RbacV1().RoleBindings(projectName).Delete(context.TODO(), rb.Name, v1.DeleteOptions{})
+				_ = reqK8s.RbacV1().RoleBindings(projectName).Delete(c.Request.Context(), rb.Name, v1.DeleteOptions{})
 				break
 			}
 		}
@@ -241,7 +261,7 @@ func ListProjectKeys(c *gin.Context) {
 	reqK8s, _ := GetK8sClientsForRequest(c)
 
 	// List ServiceAccounts with label app=ambient-access-key
-	sas, err := reqK8s.CoreV1().ServiceAccounts(projectName).List(context.TODO(), v1.ListOptions{LabelSelector: "app=ambient-access-key"})
+	sas, err := reqK8s.CoreV1().ServiceAccounts(projectName).List(c.Request.Context(), v1.ListOptions{LabelSelector: "app=ambient-access-key"})
 	if err != nil {
 		log.Printf("Failed to list access keys in %s: %v", projectName, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list access keys"})
@@ -250,7 +270,7 @@ func ListProjectKeys(c *gin.Context) {
 
 	// Map ServiceAccount -> role by scanning RoleBindings with the same label
 	roleBySA := map[string]string{}
-	if rbs, err := reqK8s.RbacV1().RoleBindings(projectName).List(context.TODO(), v1.ListOptions{LabelSelector: "app=ambient-access-key"}); err == nil {
+	if rbs, err := reqK8s.RbacV1().RoleBindings(projectName).List(c.Request.Context(), v1.ListOptions{LabelSelector: "app=ambient-access-key"}); err == nil {
 		for _, rb := range rbs.Items {
 			role := strings.ToLower(rb.Annotations["ambient-code.io/role"])
 			if role == "" {
@@ -344,7 +364,7 @@ func CreateProjectKey(c *gin.Context) {
 			},
 		},
 	}
-	if _, err := reqK8s.CoreV1().ServiceAccounts(projectName).Create(context.TODO(), sa, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+	if _, err := reqK8s.CoreV1().ServiceAccounts(projectName).Create(c.Request.Context(), sa, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
 		log.Printf("Failed to create ServiceAccount %s in %s: %v", saName, projectName, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service account"})
 		return
@@ -366,7 +386,7 @@ func CreateProjectKey(c *gin.Context) {
 		RoleRef:  rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: roleRefName},
 		Subjects: []rbacv1.Subject{{Kind: "ServiceAccount", Name: saName, Namespace: projectName}},
 	}
-	if _, err := reqK8s.RbacV1().RoleBindings(projectName).Create(context.TODO(), rb, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+	if _, err := reqK8s.RbacV1().RoleBindings(projectName).Create(c.Request.Context(), rb, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
 		log.Printf("Failed to create RoleBinding %s in %s: %v", rbName, projectName, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bind service account"})
 		return
@@ -374,7 +394,7 @@ func CreateProjectKey(c *gin.Context) {
 
 	// Issue a one-time JWT token for this ServiceAccount (no audience; used as API key)
 	tr := &authnv1.TokenRequest{Spec: authnv1.TokenRequestSpec{}}
-	tok, err := reqK8s.CoreV1().ServiceAccounts(projectName).CreateToken(context.TODO(), saName, tr, v1.CreateOptions{})
+	tok, err := reqK8s.CoreV1().ServiceAccounts(projectName).CreateToken(c.Request.Context(), saName, tr, v1.CreateOptions{})
 	if err != nil {
 		log.Printf("Failed to create token for SA %s/%s: %v", projectName, saName, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
@@ -399,15 +419,15 @@ func DeleteProjectKey(c *gin.Context) {
 	reqK8s, _ := GetK8sClientsForRequest(c)
 
 	// Delete associated RoleBindings
-	rbs, _ := reqK8s.RbacV1().RoleBindings(projectName).List(context.TODO(), v1.ListOptions{LabelSelector: "app=ambient-access-key"})
+	rbs, _ := reqK8s.RbacV1().RoleBindings(projectName).List(c.Request.Context(), v1.ListOptions{LabelSelector: "app=ambient-access-key"})
 	for _, rb := range rbs.Items {
 		if rb.Annotations["ambient-code.io/sa-name"] == keyID {
-			_ = reqK8s.RbacV1().RoleBindings(projectName).Delete(context.TODO(), rb.Name, v1.DeleteOptions{})
+			_ = reqK8s.RbacV1().RoleBindings(projectName).Delete(c.Request.Context(), rb.Name, v1.DeleteOptions{})
 		}
 	}
 
 	// Delete the ServiceAccount itself
-	if err := reqK8s.CoreV1().ServiceAccounts(projectName).Delete(context.TODO(), keyID, v1.DeleteOptions{}); err != nil {
+	if err := reqK8s.CoreV1().ServiceAccounts(projectName).Delete(c.Request.Context(), keyID, v1.DeleteOptions{}); err != nil {
 		if !errors.IsNotFound(err) {
 			log.Printf("Failed to delete service account %s in %s: %v", keyID, projectName, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete access key"})
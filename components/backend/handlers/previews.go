@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// A session has at most one Preview, named after it and owned by it, so deleting the session
+// deletes the preview. The operator runs the image and deletes the Preview after its TTL. All
+// calls use the caller's credentials, so project RBAC on previews applies.
+
+// CreateSessionPreview handles POST /api/projects/:projectName/agentic-sessions/:sessionName/preview
+func CreateSessionPreview(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	var req types.CreatePreviewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	session, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+		respondPreviewError(c, project, "get", err)
+		return
+	}
+	spec, err := previewSpecForSession(session, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	specMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		respondPreviewError(c, project, "create", err)
+		return
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiv1alpha1.SchemeGroupVersion.String(),
+		"kind":       "Preview",
+		"metadata": map[string]interface{}{
+			"name":        apiv1alpha1.PreviewName(sessionName),
+			"namespace":   project,
+			"labels":      map[string]interface{}{apiv1alpha1.PreviewSessionLabel: sessionName},
+			"annotations": map[string]interface{}{createdByAnnotation: c.GetString("userID")},
+			"ownerReferences": []interface{}{map[string]interface{}{
+				"apiVersion": session.GetAPIVersion(),
+				"kind":       session.GetKind(),
+				"name":       session.GetName(),
+				"uid":        string(session.GetUID()),
+			}},
+		},
+		"spec": specMap,
+	}}
+	created, err := reqDyn.Resource(GetPreviewResource()).Namespace(project).Create(c.Request.Context(), obj, v1.CreateOptions{})
+	if err != nil {
+		respondPreviewError(c, project, "create", err)
+		return
+	}
+	recordSessionAction(c, project, sessionName, "create_preview", "")
+	c.JSON(http.StatusCreated, previewFromObject(created))
+}
+
+// GetSessionPreview handles GET /api/projects/:projectName/agentic-sessions/:sessionName/preview
+func GetSessionPreview(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	obj, err := reqDyn.Resource(GetPreviewResource()).Namespace(project).Get(c.Request.Context(), apiv1alpha1.PreviewName(c.Param("sessionName")), v1.GetOptions{})
+	if err != nil {
+		respondPreviewError(c, project, "get", err)
+		return
+	}
+	c.JSON(http.StatusOK, previewFromObject(obj))
+}
+
+// DeleteSessionPreview handles DELETE /api/projects/:projectName/agentic-sessions/:sessionName/preview
+// before the preview's TTL runs out
+func DeleteSessionPreview(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	if err := reqDyn.Resource(GetPreviewResource()).Namespace(project).Delete(c.Request.Context(), apiv1alpha1.PreviewName(c.Param("sessionName")), v1.DeleteOptions{}); err != nil {
+		respondPreviewError(c, project, "delete", err)
+		return
+	}
+	recordSessionAction(c, project, c.Param("sessionName"), "delete_preview", "")
+	c.Status(http.StatusNoContent)
+}
+
+// previewSpecForSession builds the Preview spec for a completed session, taking the image and
+// port the request leaves empty from the artifact in status.result.preview
+func previewSpecForSession(session *unstructured.Unstructured, req types.CreatePreviewRequest) (apiv1alpha1.PreviewSpec, error) {
+	spec := apiv1alpha1.PreviewSpec{SessionName: session.GetName(), Image: req.Image, Port: req.Port, TTL: req.TTL}
+	if phase, _, _ := unstructured.NestedString(session.Object, "status", "phase"); phase != "Completed" {
+		return spec, fmt.Errorf("only completed sessions can be previewed (phase is %q)", phase)
+	}
+	status, _, _ := unstructured.NestedMap(session.Object, "status")
+	result, err := apiv1alpha1.ParseSessionResult(status["result"], false)
+	if err != nil {
+		log.Printf("Ignoring malformed result of session %s: %v", session.GetName(), err)
+	}
+	if result != nil && result.Preview != nil {
+		if spec.Image == "" {
+			spec.Image = result.Preview.Image
+		}
+		if spec.Port == 0 {
+			spec.Port = result.Preview.Port
+		}
+	}
+	if spec.Image == "" {
+		return spec, fmt.Errorf("the session reported no preview artifact; pass an image")
+	}
+	return spec, spec.Validate()
+}
+
+func previewFromObject(obj *unstructured.Unstructured) types.Preview {
+	preview := types.Preview{Name: obj.GetName(), CreationTimestamp: obj.GetCreationTimestamp().UTC().Format(time.RFC3339)}
+	if spec, ok := obj.Object["spec"].(map[string]interface{}); ok {
+		_ = runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &preview.Spec)
+	}
+	if status, ok := obj.Object["status"].(map[string]interface{}); ok {
+		preview.Status = &apiv1alpha1.PreviewStatus{}
+		_ = runtime.DefaultUnstructuredConverter.FromUnstructured(status, preview.Status)
+	}
+	return preview
+}
+
+func respondPreviewError(c *gin.Context, project, verb string, err error) {
+	switch {
+	case errors.IsNotFound(err):
+		respondMessage(c, http.StatusNotFound, "preview.notFound", nil)
+	case errors.IsAlreadyExists(err):
+		respondMessage(c, http.StatusConflict, "preview.exists", nil)
+	case errors.IsForbidden(err):
+		respondMessage(c, http.StatusForbidden, "preview.forbidden", gin.H{"Verb": "verb." + verb})
+	case errors.IsInvalid(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to %s previews in %s: %v", verb, project, err)
+		respondMessage(c, http.StatusInternalServerError, "preview.failed", gin.H{"Verb": "verb." + verb})
+	}
+}
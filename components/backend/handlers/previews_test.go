@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"testing"
+
+	"ambient-code-backend/types"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TestPreviewSpecForSession verifies the image and port fall back to the session's reported
+// artifact, and that only completed sessions with an image can be previewed
+func TestPreviewSpecForSession(t *testing.T) {
+	session := func(phase string, result interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "s1", "namespace": "proj"},
+			"status":   map[string]interface{}{"phase": phase, "result": result},
+		}}
+	}
+	withArtifact := map[string]interface{}{
+		"outcome": "succeeded",
+		"summary": "Added the settings page",
+		"preview": map[string]interface{}{"image": "quay.io/team/app:abc", "port": int64(3000)},
+	}
+
+	spec, err := previewSpecForSession(session("Completed", withArtifact), types.CreatePreviewRequest{TTL: "4h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.SessionName != "s1" || spec.Image != "quay.io/team/app:abc" || spec.Port != 3000 || spec.TTL != "4h" {
+		t.Errorf("spec = %+v", spec)
+	}
+	spec, err = previewSpecForSession(session("Completed", withArtifact), types.CreatePreviewRequest{Image: "quay.io/team/app:fix", Port: 8000})
+	if err != nil || spec.Image != "quay.io/team/app:fix" || spec.Port != 8000 {
+		t.Errorf("overridden spec = %+v, %v", spec, err)
+	}
+
+	if _, err := previewSpecForSession(session("Running", withArtifact), types.CreatePreviewRequest{}); err == nil {
+		t.Error("running session accepted")
+	}
+	if _, err := previewSpecForSession(session("Completed", "plain summary"), types.CreatePreviewRequest{}); err == nil {
+		t.Error("session without an artifact accepted without an image")
+	}
+	if _, err := previewSpecForSession(session("Completed", withArtifact), types.CreatePreviewRequest{TTL: "30d"}); err == nil {
+		t.Error("invalid ttl accepted")
+	}
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+
+	"ambient-code-backend/moderation"
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PUT /projects/:projectName is declarative so infrastructure-as-code tools (Terraform,
+// Pulumi) can own projects: the body is the desired state, the project is created when
+// missing, and only what differs is written. A repeated request writes nothing and returns
+// the same body with no changes.
+
+// ApplyProject handles PUT /projects/:projectName
+func ApplyProject(c *gin.Context) {
+	projectName := c.Param("projectName")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	if K8sClientProjects == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+
+	var req types.ApplyProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name != "" && req.Name != projectName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project name in URL does not match request body"})
+		return
+	}
+	if err := validateProjectName(projectName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	members, err := normalizeProjectMembers(req.Members)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Settings != nil {
+		if err := validateProjectSettingsSpec(c.Request.Context(), reqK8s, projectName, req.Settings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), K8sCallTimeout)
+	defer cancel()
+	isOpenShift := isOpenShiftCluster()
+	changes := []string{}
+	created := false
+
+	ns, err := K8sClientProjects.CoreV1().Namespaces().Get(ctx, projectName, v1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		userSubject, err := getUserSubjectFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+		createReq := types.CreateProjectRequest{Name: projectName}
+		if req.DisplayName != nil {
+			createReq.DisplayName = *req.DisplayName
+		}
+		if req.Description != nil {
+			createReq.Description = *req.Description
+		}
+		if ns = createProjectNamespace(c, createReq, userSubject); ns == nil {
+			return
+		}
+		created = true
+		changes = append(changes, "created project")
+	case err != nil:
+		log.Printf("Failed to get Namespace %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		return
+	default:
+		if ns.Labels["ambient-code.io/managed"] != "true" {
+			log.Printf("SECURITY: User attempted to update non-managed namespace: %s", projectName)
+			respondMessage(c, http.StatusNotFound, "project.notFound", nil)
+			return
+		}
+		canModify, err := checkUserCanModifyProject(reqK8s, projectName)
+		if err != nil {
+			log.Printf("ApplyProject: Failed to check access for %s: %v", projectName, err)
+			respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
+			return
+		}
+		if !canModify {
+			log.Printf("User attempted to update project %s without UPDATE projectsettings permission", projectName)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update project"})
+			return
+		}
+		// Display metadata only exists on OpenShift (see CreateProject)
+		if isOpenShift {
+			updated := ns.DeepCopy()
+			if updated.Annotations == nil {
+				updated.Annotations = map[string]string{}
+			}
+			for _, field := range []struct {
+				name, annotation string
+				value            *string
+			}{
+				{"displayName", "openshift.io/display-name", req.DisplayName},
+				{"description", "openshift.io/description", req.Description},
+			} {
+				if field.value == nil || updated.Annotations[field.annotation] == *field.value {
+					continue
+				}
+				if *field.value == "" {
+					delete(updated.Annotations, field.annotation)
+				} else {
+					updated.Annotations[field.annotation] = *field.value
+				}
+				changes = append(changes, "updated "+field.name)
+			}
+			if len(changes) > 0 {
+				// Backend SA: users can't update namespace annotations
+				if ns, err = K8sClientProjects.CoreV1().Namespaces().Update(ctx, updated, v1.UpdateOptions{}); err != nil {
+					log.Printf("Failed to update Namespace annotations for %s: %v", projectName, err)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+					return
+				}
+			}
+		}
+	}
+
+	resp := types.ApplyProjectResponse{}
+	if req.Settings != nil {
+		changed, err := applyProjectSettings(c, reqDyn, projectName, req.Settings)
+		if err != nil {
+			if errors.IsForbidden(err) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to update project settings"})
+				return
+			}
+			log.Printf("Failed to apply ProjectSettings in %s: %v", projectName, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project settings", "changes": changes})
+			return
+		}
+		if changed {
+			changes = append(changes, "updated settings")
+		}
+		resp.Settings = req.Settings
+	}
+	if members != nil {
+		memberChanges, err := applyProjectMembers(ctx, projectName, members, c.GetString("userID"))
+		changes = append(changes, memberChanges...)
+		if err != nil {
+			log.Printf("Failed to apply members of %s: %v", projectName, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project members", "changes": changes})
+			return
+		}
+		resp.Members = members
+	}
+
+	if len(changes) > 0 {
+		log.Printf("User %s applied project %s: %s", c.GetString("userID"), projectName, strings.Join(changes, "; "))
+	}
+	resp.AmbientProject = projectFromNamespace(ns, isOpenShift)
+	resp.Changes = changes
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, resp)
+}
+
+// normalizeProjectMembers validates a desired member list and returns it deduplicated and
+// sorted. A nil list (members not managed) stays nil.
+func normalizeProjectMembers(in []types.ProjectMember) ([]types.ProjectMember, error) {
+	if in == nil {
+		return nil, nil
+	}
+	seen := map[string]string{}
+	out := []types.ProjectMember{}
+	admins := 0
+	for i, m := range in {
+		m.SubjectType = strings.ToLower(strings.TrimSpace(m.SubjectType))
+		m.SubjectName = strings.TrimSpace(m.SubjectName)
+		m.Role = strings.ToLower(strings.TrimSpace(m.Role))
+		if m.SubjectType != "group" && m.SubjectType != "user" {
+			return nil, fmt.Errorf("members[%d]: subjectType must be one of: group, user", i)
+		}
+		if m.SubjectName == "" {
+			return nil, fmt.Errorf("members[%d]: subjectName is required", i)
+		}
+		if _, err := newPermissionRoleBinding("", m.SubjectType, m.SubjectName, m.Role); err != nil {
+			return nil, fmt.Errorf("members[%d]: %v", i, err)
+		}
+		key := m.SubjectType + "/" + m.SubjectName
+		if role, ok := seen[key]; ok {
+			if role != m.Role {
+				return nil, fmt.Errorf("members[%d]: %s %s is listed with more than one role", i, m.SubjectType, m.SubjectName)
+			}
+			continue
+		}
+		seen[key] = m.Role
+		if m.Role == "admin" {
+			admins++
+		}
+		out = append(out, m)
+	}
+	if admins == 0 {
+		return nil, fmt.Errorf("members must include at least one admin")
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SubjectType != out[j].SubjectType {
+			return out[i].SubjectType < out[j].SubjectType
+		}
+		return out[i].SubjectName < out[j].SubjectName
+	})
+	return out, nil
+}
+
+// validateProjectSettingsSpec applies the checks of the per-field settings endpoints
+func validateProjectSettingsSpec(ctx context.Context, reqK8s *kubernetes.Clientset, project string, spec *apiv1alpha1.ProjectSettingsSpec) error {
+	if err := validateSystemPromptTemplate(project, spec.SystemPromptTemplate); err != nil {
+		return fmt.Errorf("settings.systemPromptTemplate: %v", err)
+	}
+	if err := moderation.Validate(spec.PromptPolicy); err != nil {
+		return fmt.Errorf("settings.promptPolicy: %v", err)
+	}
+	if err := validateRunbooks(project, spec.Runbooks); err != nil {
+		return fmt.Errorf("settings.runbooks: %v", err)
+	}
+	if err := spec.NetworkPolicy.Validate(); err != nil {
+		return fmt.Errorf("settings.networkPolicy: %v", err)
+	}
+	if err := validateRunnerHardware(ctx, spec.RunnerHardware); err != nil {
+		return fmt.Errorf("settings.runnerHardware: %v", err)
+	}
+	if err := spec.RunnerSecurity.Validate(); err != nil {
+		return fmt.Errorf("settings.runnerSecurity: %v", err)
+	}
+	if err := spec.RunnerDNS.Validate(); err != nil {
+		return fmt.Errorf("settings.runnerDNS: %v", err)
+	}
+	if err := spec.SessionSchedule.Validate(); err != nil {
+		return fmt.Errorf("settings.sessionSchedule: %v", err)
+	}
+	if err := spec.SessionFairness.Validate(); err != nil {
+		return fmt.Errorf("settings.sessionFairness: %v", err)
+	}
+	if err := spec.ObjectMetadata.Validate(); err != nil {
+		return fmt.Errorf("settings.objectMetadata: %v", err)
+	}
+	if err := spec.FollowUps.Validate(); err != nil {
+		return fmt.Errorf("settings.followUps: %v", err)
+	}
+	if a := spec.AbandonedSessions; a != nil {
+		if a.MaxUnwatchedHours < 0 {
+			return fmt.Errorf("settings.abandonedSessions.maxUnwatchedHours must not be negative")
+		}
+		if a.Action != "" && a.Action != apiv1alpha1.AbandonedSessionNotify && a.Action != apiv1alpha1.AbandonedSessionStop {
+			return fmt.Errorf("settings.abandonedSessions.action must be %s or %s", apiv1alpha1.AbandonedSessionNotify, apiv1alpha1.AbandonedSessionStop)
+		}
+	}
+	if u := spec.IssueUpdates; u != nil && u.CommentTemplate != "" {
+		if _, err := template.New("issue").Parse(u.CommentTemplate); err != nil {
+			return fmt.Errorf("settings.issueUpdates.commentTemplate: %v", err)
+		}
+	}
+	if spec.LLMProvider != nil {
+		if err := validateLLMProvider(ctx, reqK8s, project, spec.LLMProvider); err != nil {
+			return fmt.Errorf("settings.llmProvider: %v", err)
+		}
+	}
+	return nil
+}
+
+// applyProjectSettings makes spec the ProjectSettings spec, creating the resource if the
+// operator has not yet. It writes (and records a revision) only when the spec differs.
+func applyProjectSettings(c *gin.Context, reqDyn dynamic.Interface, project string, spec *apiv1alpha1.ProjectSettingsSpec) (bool, error) {
+	desired, err := runtime.DefaultUnstructuredConverter.ToUnstructured(spec)
+	if err != nil {
+		return false, err
+	}
+	client := reqDyn.Resource(GetProjectSettingsResource()).Namespace(project)
+	ctx := c.Request.Context()
+	// Retried once: the operator creates ProjectSettings for new projects concurrently
+	for attempt := 0; ; attempt++ {
+		obj, err := client.Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			obj = &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": apiv1alpha1.SchemeGroupVersion.String(),
+				"kind":       "ProjectSettings",
+				"metadata":   map[string]interface{}{"name": apiv1alpha1.ProjectSettingsName, "namespace": project},
+				"spec":       desired,
+			}}
+			obj, err = client.Create(ctx, obj, v1.CreateOptions{})
+			if errors.IsAlreadyExists(err) && attempt == 0 {
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			recordSettingsRevision(c, obj, 0)
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		var current apiv1alpha1.ProjectSettings
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &current); err != nil {
+			return false, err
+		}
+		if equality.Semantic.DeepEqual(current.Spec, *spec) {
+			return false, nil
+		}
+		obj.Object["spec"] = desired
+		obj, err = client.Update(ctx, obj, v1.UpdateOptions{})
+		if errors.IsConflict(err) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		recordSettingsRevision(c, obj, 0)
+		return true, nil
+	}
+}
+
+// applyProjectMembers makes the members-API RoleBindings match members. New grants are
+// created before stale ones are removed so nobody loses access in between.
+func applyProjectMembers(ctx context.Context, project string, members []types.ProjectMember, grantedBy string) ([]string, error) {
+	changes := []string{}
+	rbs, err := K8sClientProjects.RbacV1().RoleBindings(project).List(ctx, v1.ListOptions{LabelSelector: "app=ambient-permission"})
+	if err != nil {
+		return changes, err
+	}
+	existing := map[string]bool{}
+	for _, rb := range rbs.Items {
+		existing[rb.Name] = true
+	}
+
+	keep := map[string]bool{}
+	for _, m := range members {
+		rb, err := newPermissionRoleBinding(project, m.SubjectType, m.SubjectName, m.Role)
+		if err != nil {
+			return changes, err
+		}
+		keep[rb.Name] = true
+		if existing[rb.Name] {
+			continue
+		}
+		rb.Annotations["ambient-code.io/granted-by"] = grantedBy
+		if _, err := K8sClientProjects.RbacV1().RoleBindings(project).Create(ctx, rb, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return changes, fmt.Errorf("grant %s %s: %w", m.SubjectType, m.SubjectName, err)
+		}
+		changes = append(changes, fmt.Sprintf("added %s %s as %s", m.SubjectType, m.SubjectName, m.Role))
+	}
+
+	for _, rb := range rbs.Items {
+		if keep[rb.Name] {
+			continue
+		}
+		if err := K8sClientProjects.RbacV1().RoleBindings(project).Delete(ctx, rb.Name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return changes, fmt.Errorf("revoke RoleBinding %s: %w", rb.Name, err)
+		}
+		for _, a := range collectPermissionAssignments([]rbacv1.RoleBinding{rb}) {
+			changes = append(changes, fmt.Sprintf("removed %s %s (%s)", a.SubjectType, a.SubjectName, a.Role))
+		}
+	}
+	return changes, nil
+}
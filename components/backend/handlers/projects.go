@@ -41,9 +41,6 @@ var (
 	isOpenShiftOnce  sync.Once
 )
 
-// Default timeout for Kubernetes API operations
-const defaultK8sTimeout = 10 * time.Second
-
 // Retry configuration constants
 const (
 	projectRetryAttempts     = 5
@@ -129,16 +126,18 @@ func isOpenShiftCluster() bool {
 }
 
 // GetClusterInfo handles GET /cluster-info
-// Returns information about the cluster type (OpenShift vs vanilla Kubernetes)
-// and whether Vertex AI is enabled
+// Returns information about the cluster type (OpenShift vs vanilla Kubernetes),
+// whether Vertex AI is enabled and whether the backend is a read-only demo
 // This endpoint does not require authentication as it's public cluster information
 func GetClusterInfo(c *gin.Context) {
 	isOpenShift := isOpenShiftCluster()
 	vertexEnabled := os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1"
+	demoMode := os.Getenv("DEMO_MODE") == "true"
 
 	c.JSON(http.StatusOK, gin.H{
 		"isOpenShift":   isOpenShift,
 		"vertexEnabled": vertexEnabled,
+		"demoMode":      demoMode,
 	})
 }
 
@@ -149,7 +148,7 @@ func ListProjects(c *gin.Context) {
 	reqK8s, _ := GetK8sClientsForRequest(c)
 
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 		return
 	}
 
@@ -162,7 +161,7 @@ func ListProjects(c *gin.Context) {
 	isOpenShift := isOpenShiftCluster()
 	projects := []types.AmbientProject{}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultK8sTimeout)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), K8sCallTimeout)
 	defer cancel()
 
 	nsList, err := K8sClientProjects.CoreV1().Namespaces().List(ctx, v1.ListOptions{
@@ -188,7 +187,7 @@ func ListProjects(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"items": projects})
+	writeJSONList(c, http.StatusOK, len(projects), func(i int) any { return &projects[i] }, nil)
 }
 
 // projectFromNamespace converts a Kubernetes Namespace to AmbientProject
@@ -233,7 +232,7 @@ func CreateProject(c *gin.Context) {
 	// Validate that user authentication succeeded
 	if reqK8s == nil {
 		log.Printf("CreateProject: Invalid or missing authentication token")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 		return
 	}
 
@@ -257,6 +256,39 @@ func CreateProject(c *gin.Context) {
 		return
 	}
 
+	createdNs := createProjectNamespace(c, req, userSubject)
+	if createdNs == nil {
+		return
+	}
+	isOpenShift := isOpenShiftCluster()
+
+	// Build response
+	responseDisplayName := ""
+	if isOpenShift {
+		responseDisplayName = req.DisplayName
+		if responseDisplayName == "" {
+			responseDisplayName = req.Name
+		}
+	}
+
+	project := types.AmbientProject{
+		Name:              createdNs.Name,
+		DisplayName:       responseDisplayName,
+		Description:       req.Description,
+		Labels:            createdNs.Labels,
+		Annotations:       createdNs.Annotations,
+		CreationTimestamp: createdNs.CreationTimestamp.Format(time.RFC3339),
+		Status:            "Active",
+		IsOpenShift:       isOpenShift,
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+// createProjectNamespace creates the namespace of a new project, makes userSubject its admin
+// and, on OpenShift, sets the display metadata. On failure it writes the error response and
+// returns nil.
+func createProjectNamespace(c *gin.Context, req types.CreateProjectRequest, userSubject string) *corev1.Namespace {
 	isOpenShift := isOpenShiftCluster()
 
 	// Create namespace using backend SA (users don't have cluster-level permissions)
@@ -284,7 +316,7 @@ func CreateProject(c *gin.Context) {
 		ns.Annotations["openshift.io/requester"] = userSubject
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), K8sCallTimeout)
 	defer cancel()
 
 	createdNs, err := K8sClientProjects.CoreV1().Namespaces().Create(ctx, ns, v1.CreateOptions{})
@@ -297,7 +329,7 @@ func CreateProject(c *gin.Context) {
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
 		}
-		return
+		return nil
 	}
 
 	// Assign ambient-project-admin ClusterRole to the creator in the namespace
@@ -332,7 +364,7 @@ func CreateProject(c *gin.Context) {
 		roleBinding.Subjects[0].APIGroup = ""
 	}
 
-	ctx2, cancel2 := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx2, cancel2 := context.WithTimeout(c.Request.Context(), K8sCallTimeout)
 	defer cancel2()
 
 	_, err = K8sClientProjects.RbacV1().RoleBindings(req.Name).Create(ctx2, roleBinding, v1.CreateOptions{})
@@ -341,6 +373,7 @@ func CreateProject(c *gin.Context) {
 
 		// ROLLBACK: Delete the namespace since role binding failed
 		// Without the role binding, the user won't have access to their project
+		// Not tied to the request context: the rollback must finish even if the client went away
 		ctx3, cancel3 := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel3()
 
@@ -364,7 +397,7 @@ func CreateProject(c *gin.Context) {
 		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project permissions"})
-		return
+		return nil
 	}
 
 	// On OpenShift: Update the Project resource with display metadata
@@ -374,8 +407,8 @@ func CreateProject(c *gin.Context) {
 		projGvr := GetOpenShiftProjectResource()
 
 		// Retry getting and updating the Project resource (OpenShift creates it asynchronously)
-		retryErr := RetryWithBackoff(projectRetryAttempts, projectRetryInitialDelay, projectRetryMaxDelay, func() error {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		retryErr := RetryWithBackoff("update_openshift_project", projectRetryAttempts, projectRetryInitialDelay, projectRetryMaxDelay, func() error {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 			defer cancel()
 
 			// Get the Project resource (using backend SA)
@@ -408,7 +441,7 @@ func CreateProject(c *gin.Context) {
 			}
 			anns["openshift.io/requester"] = userSubject
 
-			ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
+			ctx2, cancel2 := context.WithTimeout(c.Request.Context(), 5*time.Second)
 			defer cancel2()
 
 			// Update using backend SA (users don't have Project update permission)
@@ -427,27 +460,7 @@ func CreateProject(c *gin.Context) {
 		}
 	}
 
-	// Build response
-	responseDisplayName := ""
-	if isOpenShift {
-		responseDisplayName = req.DisplayName
-		if responseDisplayName == "" {
-			responseDisplayName = req.Name
-		}
-	}
-
-	project := types.AmbientProject{
-		Name:              createdNs.Name,
-		DisplayName:       responseDisplayName,
-		Description:       req.Description,
-		Labels:            createdNs.Labels,
-		Annotations:       createdNs.Annotations,
-		CreationTimestamp: createdNs.CreationTimestamp.Format(time.RFC3339),
-		Status:            "Active",
-		IsOpenShift:       isOpenShift,
-	}
-
-	c.JSON(http.StatusCreated, project)
+	return createdNs
 }
 
 // GetProject handles GET /projects/:projectName
@@ -457,7 +470,7 @@ func GetProject(c *gin.Context) {
 	reqK8s, _ := GetK8sClientsForRequest(c)
 
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 		return
 	}
 
@@ -469,7 +482,7 @@ func GetProject(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultK8sTimeout)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), K8sCallTimeout)
 	defer cancel()
 
 	ns, err := K8sClientProjects.CoreV1().Namespaces().Get(ctx, projectName, v1.GetOptions{})
@@ -486,7 +499,7 @@ func GetProject(c *gin.Context) {
 	// Validate it's an Ambient-managed namespace
 	if ns.Labels["ambient-code.io/managed"] != "true" {
 		log.Printf("SECURITY: User attempted to access non-managed namespace: %s", projectName)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found or not an Ambient project"})
+		respondMessage(c, http.StatusNotFound, "project.notFound", nil)
 		return
 	}
 
@@ -494,7 +507,7 @@ func GetProject(c *gin.Context) {
 	canView, err := checkUserCanViewProject(reqK8s, projectName)
 	if err != nil {
 		log.Printf("GetProject: Failed to check access for %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+		respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
 		return
 	}
 
@@ -508,113 +521,6 @@ func GetProject(c *gin.Context) {
 	c.JSON(http.StatusOK, project)
 }
 
-// UpdateProject handles PUT /projects/:projectName
-// On OpenShift: Updates namespace annotations for display name/description
-// On Kubernetes: No-op (k8s namespaces don't have display metadata)
-func UpdateProject(c *gin.Context) {
-	projectName := c.Param("projectName")
-	reqK8s, _ := GetK8sClientsForRequest(c)
-
-	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		return
-	}
-
-	var req struct {
-		Name        string `json:"name"`
-		DisplayName string `json:"displayName"`
-		Description string `json:"description"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if req.Name != "" && req.Name != projectName {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "project name in URL does not match request body"})
-		return
-	}
-
-	isOpenShift := isOpenShiftCluster()
-
-	// Get namespace using backend SA
-	if K8sClientProjects == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
-		return
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), defaultK8sTimeout)
-	defer cancel()
-
-	ns, err := K8sClientProjects.CoreV1().Namespaces().Get(ctx, projectName, v1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-			return
-		}
-		log.Printf("Failed to get Namespace %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
-		return
-	}
-
-	// Validate it's an Ambient-managed namespace
-	if ns.Labels["ambient-code.io/managed"] != "true" {
-		log.Printf("SECURITY: User attempted to update non-managed namespace: %s", projectName)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found or not an Ambient project"})
-		return
-	}
-
-	// Verify user can modify the project (UPDATE projectsettings)
-	canModify, err := checkUserCanModifyProject(reqK8s, projectName)
-	if err != nil {
-		log.Printf("UpdateProject: Failed to check access for %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
-		return
-	}
-
-	if !canModify {
-		log.Printf("User attempted to update project %s without UPDATE projectsettings permission", projectName)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update project"})
-		return
-	}
-
-	// On OpenShift: Update namespace annotations (backend SA needed for namespace updates)
-	if isOpenShift && K8sClientProjects != nil {
-		if req.DisplayName != "" {
-			if ns.Annotations == nil {
-				ns.Annotations = make(map[string]string)
-			}
-			ns.Annotations["openshift.io/display-name"] = req.DisplayName
-		}
-		if req.Description != "" {
-			if ns.Annotations == nil {
-				ns.Annotations = make(map[string]string)
-			}
-			ns.Annotations["openshift.io/description"] = req.Description
-		}
-
-		ctx2, cancel2 := context.WithTimeout(context.Background(), defaultK8sTimeout)
-		defer cancel2()
-
-		// Update using backend SA (users can't update namespace annotations)
-		_, err = K8sClientProjects.CoreV1().Namespaces().Update(ctx2, ns, v1.UpdateOptions{})
-		if err != nil {
-			log.Printf("Failed to update Namespace annotations for %s: %v", projectName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
-			return
-		}
-
-		// Read back the updated namespace
-		ctx3, cancel3 := context.WithTimeout(context.Background(), defaultK8sTimeout)
-		defer cancel3()
-
-		ns, _ = K8sClientProjects.CoreV1().Namespaces().Get(ctx3, projectName, v1.GetOptions{})
-	}
-
-	project := projectFromNamespace(ns, isOpenShift)
-	c.JSON(http.StatusOK, project)
-}
-
 // DeleteProject handles DELETE /projects/:projectName
 // Verifies user has access, then uses backend SA to delete namespace (both platforms)
 // Namespace deletion is cluster-scoped, so regular users can't delete directly
@@ -623,11 +529,11 @@ func DeleteProject(c *gin.Context) {
 	reqK8s, _ := GetK8sClientsForRequest(c)
 
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultK8sTimeout)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), K8sCallTimeout)
 	defer cancel()
 
 	// Verify namespace exists and is Ambient-managed (using backend SA)
@@ -650,7 +556,7 @@ func DeleteProject(c *gin.Context) {
 	// Validate it's an Ambient-managed namespace
 	if ns.Labels["ambient-code.io/managed"] != "true" {
 		log.Printf("SECURITY: User attempted to delete non-managed namespace: %s", projectName)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found or not an Ambient project"})
+		respondMessage(c, http.StatusNotFound, "project.notFound", nil)
 		return
 	}
 
@@ -658,7 +564,7 @@ func DeleteProject(c *gin.Context) {
 	canModify, err := checkUserCanModifyProject(reqK8s, projectName)
 	if err != nil {
 		log.Printf("DeleteProject: Failed to check access for %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+		respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
 		return
 	}
 
@@ -669,7 +575,7 @@ func DeleteProject(c *gin.Context) {
 	}
 
 	// Delete the namespace using backend SA (after verifying user has access)
-	ctx2, cancel2 := context.WithTimeout(context.Background(), defaultK8sTimeout)
+	ctx2, cancel2 := context.WithTimeout(c.Request.Context(), K8sCallTimeout)
 	defer cancel2()
 
 	err = K8sClientProjects.CoreV1().Namespaces().Delete(ctx2, projectName, v1.DeleteOptions{})
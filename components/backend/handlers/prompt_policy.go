@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"ambient-code-backend/moderation"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// applyPromptPolicy enforces ProjectSettings spec.promptPolicy on spec.prompt: the size limits,
+// then the moderators, which may rewrite the prompt with redactions. It runs before the
+// project system prompt is prepended, so limits apply to what the user wrote. A rejected
+// prompt returns a *moderation.RejectedError.
+func applyPromptPolicy(ctx context.Context, project string, spec map[string]interface{}) error {
+	if VteamClient == nil {
+		return nil
+	}
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read project settings: %w", err)
+	}
+	policy := ps.Spec.PromptPolicy
+	if policy == nil {
+		return nil
+	}
+	prompt, _ := spec["prompt"].(string)
+	if err := moderation.CheckLimits(policy, prompt); err != nil {
+		return err
+	}
+	moderators, err := moderation.ForPolicy(policy, promptWebhookToken)
+	if err != nil {
+		return fmt.Errorf("invalid prompt policy: %w", err)
+	}
+	result, err := moderation.Run(ctx, moderators, project, prompt)
+	if err != nil {
+		return err
+	}
+	if len(result.Redactions) > 0 {
+		log.Printf("Prompt for a session in project %s was redacted (%s)", project, strings.Join(result.Redactions, "; "))
+		spec["prompt"] = result.Prompt
+	}
+	return nil
+}
+
+// promptWebhookToken reads the "token" key of the webhook's Secret in the project
+func promptWebhookToken(ctx context.Context, project, secretName string) (string, error) {
+	secret, err := K8sClient.CoreV1().Secrets(project).Get(ctx, secretName, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(secret.Data["token"]))
+	if token == "" {
+		return "", fmt.Errorf("secret %s has no token key", secretName)
+	}
+	return token, nil
+}
+
+// respondPromptPolicyError writes the response for an applyPromptPolicy error
+func respondPromptPolicyError(c *gin.Context, project string, err error) {
+	c.JSON(promptPolicyErrorResponse(project, err))
+}
+
+// promptPolicyErrorResponse is the status and body for an applyPromptPolicy error
+func promptPolicyErrorResponse(project string, err error) (int, gin.H) {
+	if moderation.IsRejected(err) {
+		return http.StatusUnprocessableEntity, gin.H{"error": err.Error()}
+	}
+	log.Printf("Prompt policy check failed for project %s: %v", project, err)
+	return http.StatusInternalServerError, gin.H{"error": "Failed to check the prompt against the project's prompt policy"}
+}
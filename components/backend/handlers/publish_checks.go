@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ambient-code-backend/git"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// publishChecksTimeout bounds the content service's scan of a workspace diff
+const publishChecksTimeout = 2 * time.Minute
+
+// enforcePublishChecks runs the project's publish checks on the workspace diff of one repo
+// through the session's content service and records the results in status.publishChecks.
+// Failing checks block the publish unless force is set by a project admin. On failure it
+// writes the error response and returns false.
+func enforcePublishChecks(c *gin.Context, project, session, endpoint, repoName, repoPath string, force bool) bool {
+	policy, err := git.GetPublishCheckPolicy(c.Request.Context(), DynamicClient, project)
+	if err != nil {
+		log.Printf("enforcePublishChecks: failed to load policy for project %s: %v", project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load publish check policy"})
+		return false
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{"repoPath": repoPath, "policy": policy})
+	ctx, cancel := context.WithTimeout(c.Request.Context(), publishChecksTimeout)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/content/github/checks", strings.NewReader(string(payload)))
+	if v := c.GetHeader("Authorization"); v != "" {
+		req.Header.Set("Authorization", v)
+	}
+	if v := c.GetHeader("X-Forwarded-Access-Token"); v != "" {
+		req.Header.Set("X-Forwarded-Access-Token", v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("enforcePublishChecks: content service unreachable for %s/%s: %v", project, session, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "publish checks could not be run"})
+		return false
+	}
+	defer resp.Body.Close()
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	var out struct {
+		Passed  bool                             `json:"passed"`
+		Results []apiv1alpha1.PublishCheckResult `json:"results"`
+	}
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(bodyBytes, &out) != nil {
+		log.Printf("enforcePublishChecks: content service returned status=%d for %s/%s", resp.StatusCode, project, session)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "publish checks could not be run"})
+		return false
+	}
+
+	now := v1.NewTime(time.Now())
+	entry := apiv1alpha1.PublishCheckStatus{
+		Repo:      repoName,
+		Passed:    out.Passed,
+		CheckedAt: &now,
+		Results:   out.Results,
+	}
+	if !out.Passed && force {
+		reqK8s, _ := GetK8sClientsForRequest(c)
+		if reqK8s == nil {
+			respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+			return false
+		}
+		canModify, err := checkUserCanModifyProject(reqK8s, project)
+		if err != nil {
+			log.Printf("enforcePublishChecks: failed to check admin access for %s: %v", project, err)
+			respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
+			return false
+		}
+		if !canModify {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only project admins can override failing publish checks"})
+			return false
+		}
+		entry.OverriddenBy = c.GetString("userID")
+		log.Printf("enforcePublishChecks: %s overrode failing publish checks for %s/%s repo=%s", entry.OverriddenBy, project, session, repoName)
+	}
+	recordPublishChecks(c.Request.Context(), project, session, entry)
+
+	if !out.Passed && !force {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Publish checks failed", "checks": entry})
+		return false
+	}
+	return true
+}
+
+// recordPublishChecks upserts the repo's entry in status.publishChecks. Failures are logged
+// only: the results were already returned to the caller.
+func recordPublishChecks(ctx context.Context, project, session string, entry apiv1alpha1.PublishCheckStatus) {
+	if VteamClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), K8sCallTimeout)
+	defer cancel()
+	client := VteamClient.VteamV1alpha1().AgenticSessions(project)
+	err := retryOnConflict("record_publish_checks", func() error {
+		obj, err := client.Get(ctx, session, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		replaced := false
+		for i := range obj.Status.PublishChecks {
+			if obj.Status.PublishChecks[i].Repo == entry.Repo {
+				obj.Status.PublishChecks[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			obj.Status.PublishChecks = append(obj.Status.PublishChecks, entry)
+		}
+		_, err = client.UpdateStatus(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to record publish checks for %s/%s repo %s: %v", project, session, entry.Repo, err)
+	}
+}
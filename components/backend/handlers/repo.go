@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"strings"
 
+	"ambient-code-pkg/gitutil"
+
 	"github.com/gin-gonic/gin"
 	authv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,30 +27,20 @@ var (
 
 // ===== Helper Functions =====
 
-// parseOwnerRepo parses repo into owner/repo from either owner/repo or full URL/SSH
+// parseOwnerRepo parses repo into owner/repo from either owner/repo or a full URL/SSH address
 func parseOwnerRepo(full string) (string, string, error) {
 	s := strings.TrimSpace(full)
-	s = strings.TrimSuffix(s, ".git")
-	// Handle URLs or SSH forms like git@github.com:owner/repo.git
-	if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "ssh://") || strings.Contains(s, "@") {
-		// Normalize SSH to https-like then split
-		s = strings.NewReplacer(":", "/", "git@", "https://").Replace(s)
-		parts := strings.Split(s, "/")
-		if len(parts) >= 2 {
-			owner := parts[len(parts)-2]
-			repo := parts[len(parts)-1]
-			if owner != "" && repo != "" {
-				return owner, repo, nil
-			}
+	if parts := strings.Split(strings.TrimSuffix(s, ".git"), "/"); len(parts) == 2 && !strings.ContainsAny(s, ":@") {
+		if parts[0] != "" && parts[1] != "" {
+			return parts[0], parts[1], nil
 		}
 		return "", "", fmt.Errorf("invalid repo format, expected owner/repo")
 	}
-	// owner/repo
-	parts := strings.Split(s, "/")
-	if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
-		return parts[0], parts[1], nil
+	r, err := gitutil.Parse(s)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid repo format, expected owner/repo: %w", err)
 	}
-	return "", "", fmt.Errorf("invalid repo format, expected owner/repo")
+	return r.Owner, r.Name, nil
 }
 
 // Note: githubAPIBaseURL and doGitHubRequest are defined in github_auth.go
@@ -145,7 +137,7 @@ func ListUserForks(c *gin.Context) {
 		url := fmt.Sprintf("%s/repos/%s/%s/forks?per_page=%d&page=%d", api, owner, repoName, perPage, page)
 		resp, err := doGitHubRequest(c.Request.Context(), http.MethodGet, url, "Bearer "+token, "", nil)
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+			respondGitHubRequestFailed(c, err)
 			return
 		}
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -219,7 +211,7 @@ func CreateUserFork(c *gin.Context) {
 	url := fmt.Sprintf("%s/repos/%s/%s/forks", api, owner, repoName)
 	resp, err := doGitHubRequest(c.Request.Context(), http.MethodPost, url, "Bearer "+token, "", nil)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+		respondGitHubRequestFailed(c, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -268,7 +260,7 @@ func GetRepoTree(c *gin.Context) {
 	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", api, owner, repoName, strings.TrimPrefix(p, "/"), ref)
 	resp, err := doGitHubRequest(c.Request.Context(), http.MethodGet, url, "Bearer "+token, "", nil)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+		respondGitHubRequestFailed(c, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -348,7 +340,7 @@ func ListRepoBranches(c *gin.Context) {
 	url := fmt.Sprintf("%s/repos/%s/%s/branches", api, owner, repoName)
 	resp, err := doGitHubRequest(c.Request.Context(), http.MethodGet, url, "Bearer "+token, "", nil)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+		respondGitHubRequestFailed(c, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -413,7 +405,7 @@ func GetRepoBlob(c *gin.Context) {
 	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", api, owner, repoName, strings.TrimPrefix(path, "/"), ref)
 	resp, err := doGitHubRequest(c.Request.Context(), http.MethodGet, url, "Bearer "+token, "", nil)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+		respondGitHubRequestFailed(c, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Live resource usage of runner pods. The current sample comes from metrics-server
+// (metrics.k8s.io), read with the backend's service account after the caller's access to the
+// session is checked. The time series comes from Prometheus when PROMETHEUS_URL is set;
+// without it the backend keeps the samples it took while the session was being viewed.
+
+const (
+	usageSourceMetricsServer = "metrics-server"
+	usageSourcePrometheus    = "prometheus"
+
+	// usagePressureRatio of a limit counts as pressure on that resource
+	usagePressureRatio = 0.9
+	// maxUsageSamples bounds the in-memory series of one session
+	maxUsageSamples = 240
+	// usageSampleTTL drops the in-memory series of sessions nobody looked at for this long
+	usageSampleTTL = time.Hour
+	// maxUsageRange bounds the range of a series request
+	maxUsageRange = 24 * time.Hour
+)
+
+// fetchPodMetrics reads a pod's metrics.k8s.io PodMetrics; replaced in tests
+var fetchPodMetrics = func(ctx context.Context, client kubernetes.Interface, namespace, pod string) ([]byte, error) {
+	return client.Discovery().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods", pod).
+		DoRaw(ctx)
+}
+
+// podMetrics is the part of a metrics.k8s.io PodMetrics the backend reads
+type podMetrics struct {
+	Timestamp  string `json:"timestamp"`
+	Containers []struct {
+		Name  string            `json:"name"`
+		Usage map[string]string `json:"usage"`
+	} `json:"containers"`
+}
+
+// usageSamples holds the in-memory series, keyed by namespace/session
+var usageSamples = struct {
+	sync.Mutex
+	series map[string]*usageSeries
+}{series: map[string]*usageSeries{}}
+
+type usageSeries struct {
+	pod      string
+	samples  []types.ResourceUsageSample
+	lastSeen time.Time
+}
+
+// runnerPod returns the session's runner pod, preferring a running one; nil when none exists
+func runnerPod(ctx context.Context, client kubernetes.Interface, project, session string) (*corev1.Pod, error) {
+	pods, err := client.CoreV1().Pods(project).List(ctx, v1.ListOptions{LabelSelector: "agentic-session=" + session})
+	if err != nil {
+		return nil, err
+	}
+	var found *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		isRunner := false
+		for _, c := range pod.Spec.Containers {
+			isRunner = isRunner || c.Name == "ambient-code-runner"
+		}
+		if !isRunner {
+			continue
+		}
+		if found == nil || (pod.Status.Phase == corev1.PodRunning && found.Status.Phase != corev1.PodRunning) ||
+			(pod.Status.Phase == found.Status.Phase && pod.CreationTimestamp.After(found.CreationTimestamp.Time)) {
+			found = pod
+		}
+	}
+	return found, nil
+}
+
+// currentResourceUsage samples the pod from metrics-server and records the sample in the
+// session's in-memory series
+func currentResourceUsage(ctx context.Context, client kubernetes.Interface, project, session string, pod *corev1.Pod) (*types.ResourceUsage, error) {
+	raw, err := fetchPodMetrics(ctx, client, project, pod.Name)
+	if err != nil {
+		return nil, err
+	}
+	var metrics podMetrics
+	if err := json.Unmarshal(raw, &metrics); err != nil {
+		return nil, fmt.Errorf("invalid pod metrics: %v", err)
+	}
+	usage := &types.ResourceUsage{Pod: pod.Name, Source: usageSourceMetricsServer, Timestamp: metrics.Timestamp}
+	for _, c := range metrics.Containers {
+		cu := types.ContainerResourceUsage{Name: c.Name}
+		if q, err := resource.ParseQuantity(c.Usage["cpu"]); err == nil {
+			cu.CPUMillicores = q.MilliValue()
+		}
+		if q, err := resource.ParseQuantity(c.Usage["memory"]); err == nil {
+			cu.MemoryBytes = q.Value()
+		}
+		usage.CPUMillicores += cu.CPUMillicores
+		usage.MemoryBytes += cu.MemoryBytes
+		usage.Containers = append(usage.Containers, cu)
+	}
+	applyPodResources(usage, pod)
+	recordUsageSample(project+"/"+session, usage)
+	return usage, nil
+}
+
+// applyPodResources adds the pod's requests and limits and flags resources near their limit
+func applyPodResources(usage *types.ResourceUsage, pod *corev1.Pod) {
+	for _, c := range pod.Spec.Containers {
+		usage.CPURequestMillicores += c.Resources.Requests.Cpu().MilliValue()
+		usage.CPULimitMillicores += c.Resources.Limits.Cpu().MilliValue()
+		usage.MemoryRequestBytes += c.Resources.Requests.Memory().Value()
+		usage.MemoryLimitBytes += c.Resources.Limits.Memory().Value()
+	}
+	usage.Pressure = nil
+	if usage.CPULimitMillicores > 0 && float64(usage.CPUMillicores) >= usagePressureRatio*float64(usage.CPULimitMillicores) {
+		usage.Pressure = append(usage.Pressure, "cpu")
+	}
+	if usage.MemoryLimitBytes > 0 && float64(usage.MemoryBytes) >= usagePressureRatio*float64(usage.MemoryLimitBytes) {
+		usage.Pressure = append(usage.Pressure, "memory")
+	}
+}
+
+func recordUsageSample(key string, usage *types.ResourceUsage) {
+	now := time.Now()
+	usageSamples.Lock()
+	defer usageSamples.Unlock()
+	for k, s := range usageSamples.series {
+		if now.Sub(s.lastSeen) > usageSampleTTL {
+			delete(usageSamples.series, k)
+		}
+	}
+	s := usageSamples.series[key]
+	if s == nil || s.pod != usage.Pod {
+		// A new runner pod (restart, preemption) starts a new series
+		s = &usageSeries{pod: usage.Pod}
+		usageSamples.series[key] = s
+	}
+	s.lastSeen = now
+	if n := len(s.samples); n > 0 && s.samples[n-1].Timestamp == usage.Timestamp {
+		return
+	}
+	s.samples = append(s.samples, types.ResourceUsageSample{Timestamp: usage.Timestamp, CPUMillicores: usage.CPUMillicores, MemoryBytes: usage.MemoryBytes})
+	if len(s.samples) > maxUsageSamples {
+		s.samples = s.samples[len(s.samples)-maxUsageSamples:]
+	}
+}
+
+func recordedUsageSamples(key string, since time.Time) []types.ResourceUsageSample {
+	usageSamples.Lock()
+	defer usageSamples.Unlock()
+	out := []types.ResourceUsageSample{}
+	if s := usageSamples.series[key]; s != nil {
+		for _, sample := range s.samples {
+			if t, err := time.Parse(time.RFC3339, sample.Timestamp); err == nil && t.Before(since) {
+				continue
+			}
+			out = append(out, sample)
+		}
+	}
+	return out
+}
+
+// sessionResourceUsage is the usage shown on a running session's detail; best effort, nil
+// when the pod or its metrics are not available yet
+func sessionResourceUsage(ctx context.Context, project, session string) *types.ResourceUsage {
+	if K8sClient == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	pod, err := runnerPod(ctx, K8sClient, project, session)
+	if err != nil || pod == nil {
+		return nil
+	}
+	usage, err := currentResourceUsage(ctx, K8sClient, project, session, pod)
+	if err != nil {
+		return nil
+	}
+	return usage
+}
+
+// GetSessionResourceUsage handles GET /api/projects/:projectName/agentic-sessions/:sessionName/resource-usage.
+// ?range= (default 30m, at most 24h) and ?step= (Prometheus only, default range/60) shape the series.
+func GetSessionResourceUsage(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	window, step, err := usageRange(c.Query("range"), c.Query("step"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The caller's access to the session gates the metrics read with the service account
+	if _, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+		if errors.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to read this session"})
+			return
+		}
+		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
+		return
+	}
+	if K8sClient == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Resource metrics are not available"})
+		return
+	}
+	pod, err := runnerPod(c.Request.Context(), K8sClient, project, sessionName)
+	if err != nil {
+		log.Printf("Failed to list runner pods of %s/%s: %v", project, sessionName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find the runner pod"})
+		return
+	}
+	if pod == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session has no runner pod"})
+		return
+	}
+
+	out := types.ResourceUsageSeries{Pod: pod.Name, Source: usageSourceMetricsServer}
+	current, err := currentResourceUsage(c.Request.Context(), K8sClient, project, sessionName, pod)
+	if err != nil {
+		log.Printf("Pod metrics of %s/%s unavailable: %v", project, pod.Name, err)
+	}
+	out.Current = current
+
+	since := time.Now().Add(-window)
+	if base := strings.TrimRight(strings.TrimSpace(os.Getenv("PROMETHEUS_URL")), "/"); base != "" {
+		samples, err := prometheusUsageSeries(c.Request.Context(), base, project, pod.Name, since, time.Now(), step)
+		if err == nil {
+			out.Source = usageSourcePrometheus
+			out.Samples = samples
+			c.JSON(http.StatusOK, out)
+			return
+		}
+		log.Printf("Prometheus usage query for %s/%s failed, using recorded samples: %v", project, pod.Name, err)
+	}
+	if current == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Resource metrics are not available; is metrics-server installed?"})
+		return
+	}
+	out.Samples = recordedUsageSamples(project+"/"+sessionName, since)
+	c.JSON(http.StatusOK, out)
+}
+
+// usageRange parses the series window and Prometheus step
+func usageRange(rawRange, rawStep string) (time.Duration, time.Duration, error) {
+	window := 30 * time.Minute
+	if rawRange != "" {
+		d, err := time.ParseDuration(rawRange)
+		if err != nil || d <= 0 || d > maxUsageRange {
+			return 0, 0, fmt.Errorf("range must be a duration up to %s", maxUsageRange)
+		}
+		window = d
+	}
+	step := max(window/60, 15*time.Second)
+	if rawStep != "" {
+		d, err := time.ParseDuration(rawStep)
+		if err != nil || d < time.Second || window/d > 11000 {
+			return 0, 0, fmt.Errorf("step must be a duration of at least 1s and at most 11000 points")
+		}
+		step = d
+	}
+	return window, step, nil
+}
+
+// prometheusUsageSeries queries the pod's CPU (cores, as a 2m rate) and working set memory
+func prometheusUsageSeries(ctx context.Context, base, namespace, pod string, start, end time.Time, step time.Duration) ([]types.ResourceUsageSample, error) {
+	selector := fmt.Sprintf(`namespace=%q,pod=%q,container!="",container!="POD"`, namespace, pod)
+	cpu, err := prometheusRange(ctx, base, fmt.Sprintf("sum(rate(container_cpu_usage_seconds_total{%s}[2m]))", selector), start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := prometheusRange(ctx, base, fmt.Sprintf("sum(container_memory_working_set_bytes{%s})", selector), start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	points := map[int64]*types.ResourceUsageSample{}
+	sample := func(ts int64) *types.ResourceUsageSample {
+		if points[ts] == nil {
+			points[ts] = &types.ResourceUsageSample{Timestamp: time.Unix(ts, 0).UTC().Format(time.RFC3339)}
+		}
+		return points[ts]
+	}
+	for ts, v := range cpu {
+		sample(ts).CPUMillicores = int64(v * 1000)
+	}
+	for ts, v := range memory {
+		sample(ts).MemoryBytes = int64(v)
+	}
+	keys := make([]int64, 0, len(points))
+	for ts := range points {
+		keys = append(keys, ts)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	out := make([]types.ResourceUsageSample, 0, len(keys))
+	for _, ts := range keys {
+		out = append(out, *points[ts])
+	}
+	return out, nil
+}
+
+// prometheusRange runs a range query returning one series, as unix seconds to value.
+// PROMETHEUS_TOKEN_FILE, when set, is sent as a bearer token (e.g. OpenShift's Thanos querier).
+func prometheusRange(ctx context.Context, base, query string, start, end time.Time, step time.Duration) (map[int64]float64, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if file := strings.TrimSpace(os.Getenv("PROMETHEUS_TOKEN_FILE")); file != "" {
+		token, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read PROMETHEUS_TOKEN_FILE: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prometheus returned %d", resp.StatusCode)
+	}
+	var result struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []struct {
+				Values [][2]interface{} `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Status != "success" {
+		return nil, fmt.Errorf("unexpected prometheus response")
+	}
+	out := map[int64]float64{}
+	for _, series := range result.Data.Result {
+		for _, point := range series.Values {
+			ts, _ := point[0].(float64)
+			raw, _ := point[1].(string)
+			if v, err := strconv.ParseFloat(raw, 64); err == nil {
+				out[int64(ts)] = v
+			}
+		}
+	}
+	return out, nil
+}
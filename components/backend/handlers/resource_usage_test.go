@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestCurrentResourceUsage verifies the runner pod is found among the session's pods, its
+// metrics are summed against its limits, and the sample lands in the in-memory series
+func TestCurrentResourceUsage(t *testing.T) {
+	ctx := context.Background()
+	pod := func(name, container string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "proj", Labels: map[string]string{"agentic-session": "s1"}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: container,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			}}},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		pod("old-runner", "ambient-code-runner", corev1.PodFailed),
+		pod("runner", "ambient-code-runner", corev1.PodRunning),
+		pod("temp-content-s1", "content", corev1.PodRunning),
+	)
+	found, err := runnerPod(ctx, client, "proj", "s1")
+	if err != nil || found == nil || found.Name != "runner" {
+		t.Fatalf("runnerPod = %v, %v", found, err)
+	}
+
+	defer func(f func(context.Context, kubernetes.Interface, string, string) ([]byte, error)) {
+		fetchPodMetrics = f
+	}(fetchPodMetrics)
+	fetchPodMetrics = func(_ context.Context, _ kubernetes.Interface, namespace, name string) ([]byte, error) {
+		return []byte(`{"timestamp":"2026-01-02T03:04:05Z","containers":[{"name":"ambient-code-runner","usage":{"cpu":"950000000n","memory":"512Mi"}}]}`), nil
+	}
+	usage, err := currentResourceUsage(ctx, client, "proj", "s1", found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.CPUMillicores != 950 || usage.MemoryBytes != 512<<20 || usage.CPULimitMillicores != 1000 || usage.CPURequestMillicores != 500 || usage.MemoryLimitBytes != 1<<30 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if !reflect.DeepEqual(usage.Pressure, []string{"cpu"}) {
+		t.Errorf("pressure = %v, want cpu only", usage.Pressure)
+	}
+	samples := recordedUsageSamples("proj/s1", time.Time{})
+	if len(samples) != 1 || samples[0].CPUMillicores != 950 {
+		t.Errorf("recorded samples = %v", samples)
+	}
+	// The same metrics window is not recorded twice
+	_, _ = currentResourceUsage(ctx, client, "proj", "s1", found)
+	if n := len(recordedUsageSamples("proj/s1", time.Time{})); n != 1 {
+		t.Errorf("%d samples after a repeated read, want 1", n)
+	}
+}
+
+func TestPrometheusUsageSeries(t *testing.T) {
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(r.URL.Query().Get("query"), "sum(rate(") {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"result":[{"values":[[1700000000,"0.25"],[1700000060,"0.5"]]}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"result":[{"values":[[1700000000,"1048576"]]}]}}`))
+	}))
+	defer prom.Close()
+
+	samples, err := prometheusUsageSeries(context.Background(), prom.URL, "proj", "runner", time.Unix(1700000000, 0), time.Unix(1700000060, 0), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].CPUMillicores != 250 || samples[0].MemoryBytes != 1<<20 || samples[1].CPUMillicores != 500 {
+		t.Errorf("samples = %+v", samples)
+	}
+
+	if _, _, err := usageRange("48h", ""); err == nil {
+		t.Error("range above 24h accepted")
+	}
+	if window, step, err := usageRange("1h", ""); err != nil || window != time.Hour || step != time.Minute {
+		t.Errorf("usageRange(1h) = %s, %s, %v", window, step, err)
+	}
+}
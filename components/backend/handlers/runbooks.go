@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"ambient-code-backend/prompttemplate"
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+// Runbooks are named, parameterized operations ("upgrade Go version") kept in ProjectSettings
+// spec.runbooks. Executing one validates the caller's parameters against their declared
+// types, renders the prompt template (see package prompttemplate) and creates the session
+// through createSession, so the project's prompt policy, system prompt, quota and
+// maintenance switches all apply.
+
+const (
+	// runbookLabel and runbookParamsAnnotation record which runbook launched a session, and how
+	runbookLabel            = "ambient-code.io/runbook"
+	runbookParamsAnnotation = "ambient-code.io/runbook-parameters"
+)
+
+var runbookNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// runbookPromptData holds the variables available to a runbook's prompt template
+type runbookPromptData struct {
+	Project string
+	User    string
+	Params  map[string]interface{}
+}
+
+// readRunbooks returns the project's runbooks using the caller's credentials
+func readRunbooks(c *gin.Context, project string) ([]apiv1alpha1.Runbook, bool) {
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		c.Abort()
+		return nil, false
+	}
+	obj, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(c.Request.Context(), apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, true
+		}
+		log.Printf("Failed to get ProjectSettings in %s: %v", project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"})
+		return nil, false
+	}
+	var ps apiv1alpha1.ProjectSettings
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ps); err != nil {
+		log.Printf("Failed to decode ProjectSettings in %s: %v", project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"})
+		return nil, false
+	}
+	return ps.Spec.Runbooks, true
+}
+
+// ListRunbooks handles GET /api/projects/:projectName/runbooks
+func ListRunbooks(c *gin.Context) {
+	runbooks, ok := readRunbooks(c, c.Param("projectName"))
+	if !ok {
+		return
+	}
+	if runbooks == nil {
+		runbooks = []apiv1alpha1.Runbook{}
+	}
+	c.JSON(http.StatusOK, types.RunbookList{Items: runbooks})
+}
+
+// UpdateRunbooks handles PUT /api/projects/:projectName/runbooks
+// Every runbook is validated (parameter types, defaults and a test render of the template)
+// before the list replaces spec.runbooks.
+func UpdateRunbooks(c *gin.Context) {
+	projectName := c.Param("projectName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		c.Abort()
+		return
+	}
+
+	var req types.RunbookList
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateRunbooks(projectName, req.Items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A null value removes the field, so an empty list leaves nothing behind
+	var value interface{}
+	if len(req.Items) > 0 {
+		value = req.Items
+	}
+	patch, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"runbooks": value}})
+	updated, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(projectName).Patch(c.Request.Context(), apiv1alpha1.ProjectSettingsName, ktypes.MergePatchType, patch, v1.PatchOptions{})
+	if err != nil {
+		switch {
+		case errors.IsNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "ProjectSettings not found"})
+		case errors.IsForbidden(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to update project settings"})
+		default:
+			log.Printf("Failed to update ProjectSettings in %s: %v", projectName, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project settings"})
+		}
+		return
+	}
+	recordSettingsRevision(c, updated, 0)
+
+	if req.Items == nil {
+		req.Items = []apiv1alpha1.Runbook{}
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// ExecuteRunbook handles POST /api/projects/:projectName/runbooks/:name/execute
+// It responds like CreateSession; invalid parameters are rejected with 400 before any
+// session is created.
+func ExecuteRunbook(c *gin.Context) {
+	project := c.GetString("project")
+	runbooks, ok := readRunbooks(c, project)
+	if !ok {
+		return
+	}
+	var runbook *apiv1alpha1.Runbook
+	for i := range runbooks {
+		if runbooks[i].Name == c.Param("name") {
+			runbook = &runbooks[i]
+			break
+		}
+	}
+	if runbook == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Runbook %q not found", c.Param("name"))})
+		return
+	}
+
+	var req types.ExecuteRunbookRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	params, err := prompttemplate.Resolve(runbook.Parameters, req.Parameters)
+	if err != nil {
+		respondInvalidVariables(c, "", err)
+		return
+	}
+	prompt, err := renderRunbookPrompt(runbook.PromptTemplate, runbookPromptData{Project: project, User: c.GetString("userID"), Params: params})
+	if err == nil && prompt == "" {
+		err = fmt.Errorf("runbook prompt rendered empty with these parameters")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	create := types.CreateAgenticSessionRequest{
+		Prompt:      prompt,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Labels:      map[string]string{runbookLabel: runbook.Name},
+	}
+	if create.DisplayName == "" {
+		create.DisplayName = runbook.Name
+	}
+	if runbook.Model != "" {
+		create.LLMSettings = &types.LLMSettings{Model: runbook.Model, Temperature: 0.7, MaxTokens: 4000}
+	}
+	if runbook.Timeout > 0 {
+		create.Timeout = &runbook.Timeout
+	}
+	interactive := runbook.Interactive
+	if req.Interactive != nil {
+		interactive = *req.Interactive
+	}
+	create.Interactive = &interactive
+	if runbook.AutoPushOnComplete {
+		create.AutoPushOnComplete = types.BoolPtr(true)
+	}
+	for _, p := range runbook.Parameters {
+		if p.Type == apiv1alpha1.RunbookParamRepo {
+			if url, _ := params[p.Name].(string); url != "" {
+				create.Repos = append(create.Repos, types.SessionRepoMapping{Input: types.NamedGitRepo{URL: url}})
+			}
+		}
+	}
+	recorded, _ := json.Marshal(params)
+	create.Annotations = map[string]string{runbookParamsAnnotation: string(recorded)}
+
+	log.Printf("Executing runbook %s in project %s for %s", runbook.Name, project, c.GetString("userID"))
+	c.JSON(createSession(c, project, create))
+}
+
+// respondInvalidVariables writes a 400 for template variables that are missing or invalid,
+// listing each in variableErrors so a client can point at the fields to fix
+func respondInvalidVariables(c *gin.Context, prefix string, err error) {
+	body := gin.H{"error": prefix + err.Error()}
+	if errs, ok := err.(prompttemplate.Errors); ok {
+		body["variableErrors"] = errs
+	}
+	c.JSON(http.StatusBadRequest, body)
+}
+
+// renderRunbookPrompt executes a runbook's prompt template
+func renderRunbookPrompt(tmpl string, data runbookPromptData) (string, error) {
+	t, err := prompttemplate.Parse("runbook", tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid runbook prompt template: %v", err)
+	}
+	out, err := t.Execute(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render runbook prompt: %v", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// validateRunbooks checks runbook definitions: unique names, valid parameter declarations,
+// a template that uses only declared parameters, and a test render with sample values
+func validateRunbooks(project string, runbooks []apiv1alpha1.Runbook) error {
+	names := map[string]bool{}
+	for i := range runbooks {
+		rb := &runbooks[i]
+		if !runbookNamePattern.MatchString(rb.Name) || len(rb.Name) > 63 {
+			return fmt.Errorf("runbooks[%d].name must be a lowercase DNS label", i)
+		}
+		if names[rb.Name] {
+			return fmt.Errorf("runbook %q is defined twice", rb.Name)
+		}
+		names[rb.Name] = true
+		if rb.Timeout < 0 {
+			return fmt.Errorf("runbook %q: timeout must not be negative", rb.Name)
+		}
+		if err := prompttemplate.ValidateDeclarations(rb.Parameters); err != nil {
+			return fmt.Errorf("runbook %q: %v", rb.Name, err)
+		}
+		if strings.TrimSpace(rb.PromptTemplate) == "" {
+			return fmt.Errorf("runbook %q: promptTemplate is required", rb.Name)
+		}
+		t, err := prompttemplate.Parse("runbook", rb.PromptTemplate)
+		if err != nil {
+			return fmt.Errorf("runbook %q: invalid prompt template: %v", rb.Name, err)
+		}
+		if err := t.Check(rb.Parameters); err != nil {
+			return fmt.Errorf("runbook %q: %v", rb.Name, err)
+		}
+		if _, err := renderRunbookPrompt(rb.PromptTemplate, runbookPromptData{Project: project, User: "user", Params: prompttemplate.Sample(rb.Parameters)}); err != nil {
+			return fmt.Errorf("runbook %q: %v", rb.Name, err)
+		}
+	}
+	return nil
+}
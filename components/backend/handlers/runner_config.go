@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/runtimeconfig"
+
+	"github.com/gin-gonic/gin"
+	authnv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// InternalBaseURL is the base URL runners use for /internal endpoints when they are served on
+// the mTLS listener; empty means the plain API port. Set by main.
+var InternalBaseURL string
+
+// authenticateRunnerToken validates the bearer token via TokenReview and returns the
+// namespace and service account name it belongs to. On failure it writes the error
+// response and returns ok=false.
+func authenticateRunnerToken(c *gin.Context) (namespace string, serviceAccount string, ok bool) {
+	rawAuth := strings.TrimSpace(c.GetHeader("Authorization"))
+	if rawAuth == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
+		return "", "", false
+	}
+	parts := strings.SplitN(rawAuth, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header"})
+		return "", "", false
+	}
+	token := strings.TrimSpace(parts[1])
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "empty token"})
+		return "", "", false
+	}
+
+	// TokenReview using default audience (works with standard SA tokens)
+	tr := &authnv1.TokenReview{Spec: authnv1.TokenReviewSpec{Token: token}}
+	rv, err := K8sClient.AuthenticationV1().TokenReviews().Create(c.Request.Context(), tr, v1.CreateOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token review failed"})
+		return "", "", false
+	}
+	if rv.Status.Error != "" || !rv.Status.Authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		return "", "", false
+	}
+	subj := strings.TrimSpace(rv.Status.User.Username)
+	const pfx = "system:serviceaccount:"
+	if !strings.HasPrefix(subj, pfx) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "subject is not a service account"})
+		return "", "", false
+	}
+	segs := strings.SplitN(strings.TrimPrefix(subj, pfx), ":", 2)
+	if len(segs) != 2 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid service account subject"})
+		return "", "", false
+	}
+	// A runner client certificate (mTLS) must belong to the token's namespace
+	if certNamespace := c.GetString("runnerCertNamespace"); certNamespace != "" && certNamespace != segs[0] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "token does not match client certificate"})
+		return "", "", false
+	}
+	return segs[0], segs[1], true
+}
+
+// getRunnerSession loads the session and verifies that serviceAccount matches the
+// runner SA recorded on the CR. On failure it writes the error response and returns nil.
+func getRunnerSession(c *gin.Context, namespace, sessionName, serviceAccount string) *unstructured.Unstructured {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	obj, err := DynamicClient.Resource(gvr).Namespace(namespace).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return nil
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read session"})
+		return nil
+	}
+	expectedSA := strings.TrimSpace(obj.GetAnnotations()["ambient-code.io/runner-sa"])
+	if expectedSA == "" || expectedSA != serviceAccount {
+		c.JSON(http.StatusForbidden, gin.H{"error": "service account not authorized for session"})
+		return nil
+	}
+	return obj
+}
+
+// GetRunnerConfig returns the resolved runner configuration for a session.
+// GET /internal/runner-config/:session
+// Auth: Authorization: Bearer <BOT_TOKEN>. The session namespace is taken from the token.
+func GetRunnerConfig(c *gin.Context) {
+	sessionName := c.Param("session")
+
+	namespace, serviceAccount, ok := authenticateRunnerToken(c)
+	if !ok {
+		return
+	}
+	obj := getRunnerSession(c, namespace, sessionName, serviceAccount)
+	if obj == nil {
+		return
+	}
+
+	cfg := buildRunnerConfig(obj)
+
+	// Tool policy comes from ProjectSettings; a missing singleton just means defaults
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(c.Request.Context(), apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read project settings"})
+		return
+	}
+	if err == nil {
+		if ps.Spec.RunnerToolPolicy != nil {
+			cfg.ToolPolicy = *ps.Spec.RunnerToolPolicy
+		}
+		if ps.Spec.LLMProvider != nil {
+			cfg.Provider = ps.Spec.LLMProvider.Provider
+		}
+		if ps.Spec.NetworkPolicy.Restricted() {
+			cfg.NetworkPolicy = ps.Spec.NetworkPolicy
+		}
+	}
+
+	if cfg.Agents, err = sessionAgents(c.Request.Context(), DynamicClient, obj); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read agents"})
+		return
+	}
+
+	policy, err := git.GetBranchPolicy(c.Request.Context(), DynamicClient, namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load branch policy"})
+		return
+	}
+	cfg.GitPolicy = types.RunnerGitPolicy{
+		AllowedTargetBranches: policy.AllowedTargetBranches,
+		RequirePR:             policy.RequirePR,
+		ForbidForcePush:       policy.ForbidForcePush,
+	}
+
+	c.JSON(http.StatusOK, cfg)
+}
+
+// buildRunnerConfig resolves the session spec into a runner config document.
+// Paths mirror the layout the operator mounts into the runner container.
+func buildRunnerConfig(obj *unstructured.Unstructured) types.RunnerConfig {
+	name := obj.GetName()
+	namespace := obj.GetNamespace()
+
+	spec := types.AgenticSessionSpec{}
+	if s, ok := obj.Object["spec"].(map[string]interface{}); ok {
+		spec = decodeSpec(s)
+	}
+	autoPush, _, _ := unstructured.NestedBool(obj.Object, "spec", "autoPushOnComplete")
+	mainRepoIndex := 0
+	if spec.MainRepoIndex != nil {
+		mainRepoIndex = *spec.MainRepoIndex
+	}
+
+	apiBase := fmt.Sprintf("http://backend-service.%s.svc.cluster.local:8080/api", Namespace)
+	wsBase := strings.Replace(apiBase, "http://", "ws://", 1)
+	internalBase := InternalBaseURL
+	if internalBase == "" {
+		internalBase = strings.TrimSuffix(apiBase, "/api")
+	}
+
+	return types.RunnerConfig{
+		Version: types.RunnerConfigVersion,
+		Session: types.RunnerSessionRef{
+			Name:            name,
+			Namespace:       namespace,
+			ParentSessionID: obj.GetAnnotations()["vteam.ambient-code/parent-session-id"],
+		},
+		Model:   spec.LLMSettings,
+		Timeout: spec.Timeout,
+		Workspace: types.RunnerWorkspaceLayout{
+			Root:          "/workspace",
+			WorkspacePath: fmt.Sprintf("/workspace/sessions/%s/workspace", name),
+			ArtifactsDir:  "_artifacts",
+			StateDir:      fmt.Sprintf("/workspace/sessions/%s/.claude", name),
+			Repos:         spec.Repos,
+			MainRepoIndex: mainRepoIndex,
+			Workflow:      spec.ActiveWorkflow,
+		},
+		Callbacks: types.RunnerCallbacks{
+			APIBaseURL:        apiBase,
+			WebSocketURL:      fmt.Sprintf("%s/projects/%s/sessions/%s/ws", wsBase, namespace, name),
+			GitHubTokenURL:    fmt.Sprintf("%s/projects/%s/agentic-sessions/%s/github/token", apiBase, namespace, name),
+			ArtifactUploadURL: fmt.Sprintf("%s/internal/artifacts/%s/uploads", internalBase, name),
+			InputsURL:         fmt.Sprintf("%s/internal/inputs/%s", internalBase, name),
+		},
+		Features: runnerFeatures(map[string]bool{
+			"interactive":        spec.Interactive,
+			"autoPushOnComplete": autoPush,
+			"preemptible":        spec.Preemptible,
+			"vertex":             os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1",
+		}),
+	}
+}
+
+// runnerFeatures adds the runtime config's feature flags to the session's own, which win
+func runnerFeatures(session map[string]bool) map[string]bool {
+	for name, enabled := range runtimeconfig.Current().Features {
+		if _, set := session[name]; !set {
+			session[name] = enabled
+		}
+	}
+	return session
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"ambient-code-backend/types"
+	"ambient-code-pkg/podsecurity"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetRunnerSecurityReport handles GET /api/projects/:projectName/runner-security
+// The report checks the shape of a runner pod, hardened with the project's runnerSecurity,
+// against each level the namespace labels; the operator repeats the check on the real pod
+// before it creates a Job.
+func GetRunnerSecurityReport(c *gin.Context) {
+	projectName := c.Param("projectName")
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	canView, err := checkUserCanViewProject(reqK8s, projectName)
+	if err != nil {
+		log.Printf("GetRunnerSecurityReport: failed to check access for %s: %v", projectName, err)
+		respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
+		return
+	}
+	if !canView {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to view project"})
+		return
+	}
+	if K8sClient == nil || VteamClient == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Backend client not initialized"})
+		return
+	}
+
+	ns, err := K8sClient.CoreV1().Namespaces().Get(c.Request.Context(), projectName, v1.GetOptions{})
+	if err != nil {
+		log.Printf("Failed to read namespace %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project"})
+		return
+	}
+	report := types.RunnerSecurityReport{Levels: podsecurity.Levels(ns), Compatible: true}
+	if spec := projectSettingsSpec(c.Request.Context(), projectName); spec != nil {
+		report.RunnerSecurity = spec.RunnerSecurity
+	}
+
+	pod := podsecurity.RunnerPod(report.RunnerSecurity)
+	for mode, level := range report.Levels {
+		violations := podsecurity.Check(level, pod)
+		if len(violations) == 0 {
+			continue
+		}
+		if report.Violations == nil {
+			report.Violations = map[string][]string{}
+		}
+		report.Violations[mode] = violations
+		if mode == podsecurity.ModeEnforce {
+			report.Compatible = false
+		}
+	}
+	c.JSON(http.StatusOK, report)
+}
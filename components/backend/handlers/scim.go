@@ -0,0 +1,931 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SCIM 2.0 provisioning (RFC 7643/7644), so an identity provider can grant and revoke project
+// access as people join and leave teams. Only what IdPs use is implemented: Users and Groups
+// with list filters on a single attribute, and PATCH of active and group members.
+//
+// Users and groups are stored as ConfigMaps in the backend namespace. A group named
+// "<SCIM_GROUP_PREFIX><project>:<role>" (prefix "ambient:" by default, e.g.
+// "ambient:payments:edit") makes its active members project members with that role, as long
+// as the namespace is an Ambient project. The backend reconciles these into the same
+// RoleBindings the members API manages, labelled as SCIM-provisioned; it never touches
+// bindings granted by hand.
+
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	scimContentType = "application/scim+json"
+
+	// scimProvisionedLabel marks the RoleBindings SCIM created; only those are ever removed
+	scimProvisionedLabel = "ambient-code.io/provisioned-by"
+	scimMaxResults       = 200
+)
+
+// scimMu serializes SCIM writes and the membership sync that follows each of them
+var scimMu sync.Mutex
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type scimUser struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	UserName    string   `json:"userName"`
+	DisplayName string   `json:"displayName,omitempty"`
+	// Name and emails are kept as the IdP sent them
+	Name   json.RawMessage `json:"name,omitempty"`
+	Emails json.RawMessage `json:"emails,omitempty"`
+	Active *bool           `json:"active,omitempty"`
+	Meta   scimMeta        `json:"meta"`
+}
+
+type scimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type scimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []scimMember `json:"members"`
+	Meta        scimMeta     `json:"meta"`
+}
+
+type scimPatchRequest struct {
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+func (u *scimUser) isActive() bool {
+	return u.Active == nil || *u.Active
+}
+
+func scimGroupPrefix() string {
+	if p := os.Getenv("SCIM_GROUP_PREFIX"); p != "" {
+		return p
+	}
+	return "ambient:"
+}
+
+// scimGroupGrant parses a group name of the form "<prefix><project>:<role>"
+func scimGroupGrant(displayName string) (project, role string, ok bool) {
+	rest, found := strings.CutPrefix(displayName, scimGroupPrefix())
+	if !found {
+		return "", "", false
+	}
+	project, role, found = strings.Cut(rest, ":")
+	role = strings.ToLower(role)
+	if !found || project == "" || (role != "admin" && role != "edit" && role != "view") {
+		return "", "", false
+	}
+	return project, role, true
+}
+
+func scimError(c *gin.Context, status int, detail string) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, gin.H{"schemas": []string{scimErrorSchema}, "status": strconv.Itoa(status), "detail": detail})
+}
+
+func scimRespond(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, body)
+}
+
+// RequireSCIMToken authenticates the identity provider with the bearer token in SCIM_TOKEN.
+// Without SCIM_TOKEN the SCIM endpoints are disabled.
+func RequireSCIMToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimSpace(os.Getenv("SCIM_TOKEN"))
+		if token == "" {
+			scimError(c, http.StatusNotFound, "SCIM provisioning is not enabled")
+			c.Abort()
+			return
+		}
+		got, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || !hmac.Equal([]byte(strings.TrimSpace(got)), []byte(token)) {
+			scimError(c, http.StatusUnauthorized, "invalid SCIM token")
+			c.Abort()
+			return
+		}
+		if K8sClient == nil || K8sClientProjects == nil {
+			scimError(c, http.StatusInternalServerError, "backend client not initialized")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetSCIMServiceProviderConfig handles GET /api/scim/v2/ServiceProviderConfig
+func GetSCIMServiceProviderConfig(c *gin.Context) {
+	scimRespond(c, http.StatusOK, gin.H{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": scimMaxResults},
+		"changePassword": gin.H{"supported": false},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type": "oauthbearertoken", "name": "Bearer token", "description": "Static token configured as SCIM_TOKEN",
+		}},
+	})
+}
+
+// --- storage ---
+
+func scimConfigMapName(kind, id string) string {
+	return fmt.Sprintf("scim-%s-%s", kind, id)
+}
+
+func newSCIMID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func scimLoad(ctx context.Context, kind, id string, out interface{}) error {
+	cm, err := K8sClient.CoreV1().ConfigMaps(Namespace).Get(ctx, scimConfigMapName(kind, id), v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(cm.Data["resource"]), out)
+}
+
+func scimList(ctx context.Context, kind string, each func(data []byte) error) error {
+	list, err := K8sClient.CoreV1().ConfigMaps(Namespace).List(ctx, v1.ListOptions{LabelSelector: "app=ambient-scim,ambient-code.io/scim-type=" + kind})
+	if err != nil {
+		return err
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].CreationTimestamp.Before(&list.Items[j].CreationTimestamp)
+	})
+	for _, cm := range list.Items {
+		if err := each([]byte(cm.Data["resource"])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scimSave(ctx context.Context, kind, id string, resource interface{}, create bool) error {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      scimConfigMapName(kind, id),
+			Namespace: Namespace,
+			Labels:    map[string]string{"app": "ambient-scim", "ambient-code.io/scim-type": kind},
+		},
+		Data: map[string]string{"resource": string(data)},
+	}
+	if create {
+		_, err = K8sClient.CoreV1().ConfigMaps(Namespace).Create(ctx, cm, v1.CreateOptions{})
+	} else {
+		_, err = K8sClient.CoreV1().ConfigMaps(Namespace).Update(ctx, cm, v1.UpdateOptions{})
+	}
+	return err
+}
+
+func scimLoadUsers(ctx context.Context) ([]scimUser, error) {
+	users := []scimUser{}
+	err := scimList(ctx, "user", func(data []byte) error {
+		var u scimUser
+		if err := json.Unmarshal(data, &u); err == nil {
+			users = append(users, u)
+		}
+		return nil
+	})
+	return users, err
+}
+
+func scimLoadGroups(ctx context.Context) ([]scimGroup, error) {
+	groups := []scimGroup{}
+	err := scimList(ctx, "group", func(data []byte) error {
+		var g scimGroup
+		if err := json.Unmarshal(data, &g); err == nil {
+			groups = append(groups, g)
+		}
+		return nil
+	})
+	return groups, err
+}
+
+// --- membership sync ---
+
+type scimGrantKey struct {
+	project string
+	user    string
+}
+
+var scimRoleRank = map[string]int{"view": 1, "edit": 2, "admin": 3}
+
+// syncSCIMMemberships makes the SCIM-provisioned RoleBindings match the groups: every active
+// member of a project group gets the group's role (the highest one if several groups grant
+// roles in the same project), and provisioned bindings nobody is entitled to any more are
+// removed. The last admin of a project is never removed, and only namespaces labelled as
+// Ambient projects are granted access.
+func syncSCIMMemberships(ctx context.Context) error {
+	users, err := scimLoadUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("load users: %w", err)
+	}
+	groups, err := scimLoadGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("load groups: %w", err)
+	}
+	rbs, err := K8sClientProjects.RbacV1().RoleBindings("").List(ctx, v1.ListOptions{LabelSelector: scimProvisionedLabel + "=scim"})
+	if err != nil {
+		return fmt.Errorf("list provisioned RoleBindings: %w", err)
+	}
+
+	plan := planSCIMSync(users, groups, rbs.Items, func(project string) bool {
+		return isManagedProject(ctx, project)
+	}, func(project string) int {
+		return projectAdminCount(ctx, project)
+	})
+
+	for _, rb := range plan.revoke {
+		user, role := rb.Annotations["ambient-code.io/subject-name"], rb.Annotations["ambient-code.io/role"]
+		if err := K8sClientProjects.RbacV1().RoleBindings(rb.Namespace).Delete(ctx, rb.Name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("SCIM: failed to revoke %s in %s: %v", user, rb.Namespace, err)
+			continue
+		}
+		log.Printf("SCIM: revoked %s role of %s in project %s", role, user, rb.Namespace)
+	}
+	for key, role := range plan.grant {
+		rb, err := newPermissionRoleBinding(key.project, "user", key.user, role)
+		if err != nil {
+			continue
+		}
+		rb.Labels[scimProvisionedLabel] = "scim"
+		rb.Annotations["ambient-code.io/granted-by"] = "scim"
+		if _, err := K8sClientProjects.RbacV1().RoleBindings(key.project).Create(ctx, rb, v1.CreateOptions{}); err != nil {
+			// An identical binding granted by hand already gives the access
+			if !errors.IsAlreadyExists(err) {
+				log.Printf("SCIM: failed to grant %s role %s in project %s: %v", key.user, role, key.project, err)
+			}
+			continue
+		}
+		log.Printf("SCIM: granted %s role %s in project %s", key.user, role, key.project)
+	}
+	return nil
+}
+
+// scimSyncPlan is what a membership sync creates and deletes
+type scimSyncPlan struct {
+	grant  map[scimGrantKey]string
+	revoke []rbacv1.RoleBinding
+}
+
+// planSCIMSync compares the roles the groups grant with the provisioned bindings. managed
+// reports whether a namespace is an Ambient project; admins counts a project's admins, so
+// revoking never leaves a project without one.
+func planSCIMSync(users []scimUser, groups []scimGroup, provisioned []rbacv1.RoleBinding, managed func(project string) bool, admins func(project string) int) scimSyncPlan {
+	byID := map[string]*scimUser{}
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	isManaged := map[string]bool{}
+	desired := map[scimGrantKey]string{}
+	for _, g := range groups {
+		project, role, ok := scimGroupGrant(g.DisplayName)
+		if !ok {
+			continue
+		}
+		if _, seen := isManaged[project]; !seen {
+			isManaged[project] = managed(project)
+			if !isManaged[project] {
+				log.Printf("SCIM: group %s names %s, which is not an Ambient project; ignoring it", g.DisplayName, project)
+			}
+		}
+		if !isManaged[project] {
+			continue
+		}
+		for _, m := range g.Members {
+			u := byID[m.Value]
+			if u == nil || !u.isActive() {
+				continue
+			}
+			key := scimGrantKey{project: project, user: u.UserName}
+			if scimRoleRank[role] > scimRoleRank[desired[key]] {
+				desired[key] = role
+			}
+		}
+	}
+
+	plan := scimSyncPlan{grant: map[scimGrantKey]string{}}
+	existing := map[scimGrantKey]string{}
+	remaining := map[string]int{}
+	for _, rb := range provisioned {
+		key := scimGrantKey{project: rb.Namespace, user: rb.Annotations["ambient-code.io/subject-name"]}
+		role := rb.Annotations["ambient-code.io/role"]
+		if desired[key] == role {
+			existing[key] = role
+			continue
+		}
+		if role == "admin" {
+			if _, counted := remaining[rb.Namespace]; !counted {
+				remaining[rb.Namespace] = admins(rb.Namespace)
+			}
+			if remaining[rb.Namespace] <= 1 {
+				log.Printf("SCIM: keeping %s as the last admin of project %s", key.user, key.project)
+				continue
+			}
+			remaining[rb.Namespace]--
+		}
+		plan.revoke = append(plan.revoke, rb)
+	}
+	for key, role := range desired {
+		if existing[key] != role {
+			plan.grant[key] = role
+		}
+	}
+	return plan
+}
+
+// isManagedProject reports whether the namespace is labelled as an Ambient project
+func isManagedProject(ctx context.Context, project string) bool {
+	ns, err := K8sClientProjects.CoreV1().Namespaces().Get(ctx, project, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("SCIM: failed to get Namespace %s: %v", project, err)
+		}
+		return false
+	}
+	return ns.Labels["ambient-code.io/managed"] == "true"
+}
+
+// projectAdminCount counts the project's admins; on error it reports one, so nobody is revoked
+func projectAdminCount(ctx context.Context, project string) int {
+	all, err := K8sClientProjects.RbacV1().RoleBindings(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return 1
+	}
+	admins := 0
+	for _, a := range collectPermissionAssignments(all.Items) {
+		if a.Role == "admin" {
+			admins++
+		}
+	}
+	return admins
+}
+
+// scimWrite runs a store change and the membership sync under scimMu. A failed sync is only
+// logged: the store is the source of truth and the next write syncs again.
+func scimWrite(c *gin.Context, change func() bool) {
+	scimMu.Lock()
+	defer scimMu.Unlock()
+	if !change() {
+		return
+	}
+	if err := syncSCIMMemberships(c.Request.Context()); err != nil {
+		log.Printf("SCIM: membership sync failed: %v", err)
+	}
+}
+
+// --- filters and lists ---
+
+var scimFilterPattern = regexp.MustCompile(`(?i)^\s*(\w+)\s+eq\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// parseSCIMFilter supports the single-attribute equality filters IdPs send
+// (userName eq "x", displayName eq "x", externalId eq "x")
+func parseSCIMFilter(filter string) (attr, value string, ok bool) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", true
+	}
+	m := scimFilterPattern.FindStringSubmatch(filter)
+	if m == nil {
+		return "", "", false
+	}
+	unquoted, err := strconv.Unquote(`"` + m[2] + `"`)
+	if err != nil {
+		return "", "", false
+	}
+	return strings.ToLower(m[1]), unquoted, true
+}
+
+func scimListResponse(c *gin.Context, resources []interface{}) {
+	start, _ := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if start < 1 {
+		start = 1
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(scimMaxResults)))
+	if err != nil || count < 0 || count > scimMaxResults {
+		count = scimMaxResults
+	}
+	total := len(resources)
+	page := []interface{}{}
+	if start <= total {
+		end := start - 1 + count
+		if end > total {
+			end = total
+		}
+		page = resources[start-1 : end]
+	}
+	scimRespond(c, http.StatusOK, gin.H{
+		"schemas":      []string{scimListSchema},
+		"totalResults": total,
+		"startIndex":   start,
+		"itemsPerPage": len(page),
+		"Resources":    page,
+	})
+}
+
+func scimNow() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// --- users ---
+
+func (u *scimUser) normalize(id, created string) {
+	u.Schemas = []string{scimUserSchema}
+	u.ID = id
+	u.UserName = strings.TrimSpace(u.UserName)
+	u.Meta = scimMeta{ResourceType: "User", Created: created, LastModified: scimNow(), Location: "/api/scim/v2/Users/" + id}
+}
+
+// ListSCIMUsers handles GET /api/scim/v2/Users
+func ListSCIMUsers(c *gin.Context) {
+	attr, value, ok := parseSCIMFilter(c.Query("filter"))
+	if !ok {
+		scimError(c, http.StatusBadRequest, "unsupported filter; use <attribute> eq \"<value>\"")
+		return
+	}
+	users, err := scimLoadUsers(c.Request.Context())
+	if err != nil {
+		log.Printf("SCIM: failed to list users: %v", err)
+		scimError(c, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+	out := []interface{}{}
+	for _, u := range users {
+		switch attr {
+		case "username":
+			if !strings.EqualFold(u.UserName, value) {
+				continue
+			}
+		case "externalid":
+			if u.ExternalID != value {
+				continue
+			}
+		case "":
+		default:
+			continue
+		}
+		out = append(out, u)
+	}
+	scimListResponse(c, out)
+}
+
+// GetSCIMUser handles GET /api/scim/v2/Users/:id
+func GetSCIMUser(c *gin.Context) {
+	var u scimUser
+	if err := scimLoad(c.Request.Context(), "user", c.Param("id"), &u); err != nil {
+		scimLoadFailed(c, "user", err)
+		return
+	}
+	scimRespond(c, http.StatusOK, u)
+}
+
+// CreateSCIMUser handles POST /api/scim/v2/Users
+func CreateSCIMUser(c *gin.Context) {
+	var u scimUser
+	if err := c.ShouldBindJSON(&u); err != nil || strings.TrimSpace(u.UserName) == "" {
+		scimError(c, http.StatusBadRequest, "userName is required")
+		return
+	}
+	scimWrite(c, func() bool {
+		users, err := scimLoadUsers(c.Request.Context())
+		if err != nil {
+			log.Printf("SCIM: failed to list users: %v", err)
+			scimError(c, http.StatusInternalServerError, "failed to create user")
+			return false
+		}
+		for _, existing := range users {
+			if strings.EqualFold(existing.UserName, strings.TrimSpace(u.UserName)) {
+				scimError(c, http.StatusConflict, fmt.Sprintf("user %q already exists", u.UserName))
+				return false
+			}
+		}
+		id := newSCIMID()
+		now := scimNow()
+		u.normalize(id, now)
+		if err := scimSave(c.Request.Context(), "user", id, &u, true); err != nil {
+			log.Printf("SCIM: failed to store user %s: %v", u.UserName, err)
+			scimError(c, http.StatusInternalServerError, "failed to create user")
+			return false
+		}
+		log.Printf("SCIM: provisioned user %s", u.UserName)
+		scimRespond(c, http.StatusCreated, u)
+		return true
+	})
+}
+
+// ReplaceSCIMUser handles PUT /api/scim/v2/Users/:id
+func ReplaceSCIMUser(c *gin.Context) {
+	var u scimUser
+	if err := c.ShouldBindJSON(&u); err != nil || strings.TrimSpace(u.UserName) == "" {
+		scimError(c, http.StatusBadRequest, "userName is required")
+		return
+	}
+	scimWrite(c, func() bool {
+		var current scimUser
+		if err := scimLoad(c.Request.Context(), "user", c.Param("id"), &current); err != nil {
+			scimLoadFailed(c, "user", err)
+			return false
+		}
+		u.normalize(current.ID, current.Meta.Created)
+		return scimStoreUser(c, &u)
+	})
+}
+
+// PatchSCIMUser handles PATCH /api/scim/v2/Users/:id
+// Supports replace/add of active, userName, displayName and externalId, with or without a path.
+func PatchSCIMUser(c *gin.Context) {
+	var req scimPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, http.StatusBadRequest, "invalid PatchOp")
+		return
+	}
+	scimWrite(c, func() bool {
+		var u scimUser
+		if err := scimLoad(c.Request.Context(), "user", c.Param("id"), &u); err != nil {
+			scimLoadFailed(c, "user", err)
+			return false
+		}
+		for _, op := range req.Operations {
+			if name := strings.ToLower(op.Op); name != "replace" && name != "add" {
+				scimError(c, http.StatusBadRequest, fmt.Sprintf("unsupported op %q for users", op.Op))
+				return false
+			}
+			values := map[string]json.RawMessage{}
+			if op.Path == "" {
+				if err := json.Unmarshal(op.Value, &values); err != nil {
+					scimError(c, http.StatusBadRequest, "value must be an object when path is empty")
+					return false
+				}
+			} else {
+				values[op.Path] = op.Value
+			}
+			for path, value := range values {
+				if err := applySCIMUserAttribute(&u, path, value); err != nil {
+					scimError(c, http.StatusBadRequest, err.Error())
+					return false
+				}
+			}
+		}
+		u.normalize(u.ID, u.Meta.Created)
+		return scimStoreUser(c, &u)
+	})
+}
+
+func applySCIMUserAttribute(u *scimUser, path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "active":
+		var active bool
+		// Some IdPs send booleans as strings
+		if err := json.Unmarshal(value, &active); err != nil {
+			var s string
+			if json.Unmarshal(value, &s) != nil {
+				return fmt.Errorf("active must be a boolean")
+			}
+			active = strings.EqualFold(s, "true")
+		}
+		u.Active = &active
+	case "username":
+		return json.Unmarshal(value, &u.UserName)
+	case "displayname":
+		return json.Unmarshal(value, &u.DisplayName)
+	case "externalid":
+		return json.Unmarshal(value, &u.ExternalID)
+	case "name":
+		u.Name = value
+	case "emails":
+		u.Emails = value
+	}
+	// Other attributes are not stored
+	return nil
+}
+
+func scimStoreUser(c *gin.Context, u *scimUser) bool {
+	if u.UserName == "" {
+		scimError(c, http.StatusBadRequest, "userName is required")
+		return false
+	}
+	if err := scimSave(c.Request.Context(), "user", u.ID, u, false); err != nil {
+		log.Printf("SCIM: failed to store user %s: %v", u.UserName, err)
+		scimError(c, http.StatusInternalServerError, "failed to update user")
+		return false
+	}
+	if !u.isActive() {
+		log.Printf("SCIM: deactivated user %s", u.UserName)
+	}
+	scimRespond(c, http.StatusOK, u)
+	return true
+}
+
+// DeleteSCIMUser handles DELETE /api/scim/v2/Users/:id; the user's provisioned access is revoked
+func DeleteSCIMUser(c *gin.Context) {
+	scimDelete(c, "user")
+}
+
+// --- groups ---
+
+func (g *scimGroup) normalize(id, created string) {
+	g.Schemas = []string{scimGroupSchema}
+	g.ID = id
+	g.DisplayName = strings.TrimSpace(g.DisplayName)
+	if g.Members == nil {
+		g.Members = []scimMember{}
+	}
+	g.Meta = scimMeta{ResourceType: "Group", Created: created, LastModified: scimNow(), Location: "/api/scim/v2/Groups/" + id}
+}
+
+// ListSCIMGroups handles GET /api/scim/v2/Groups
+func ListSCIMGroups(c *gin.Context) {
+	attr, value, ok := parseSCIMFilter(c.Query("filter"))
+	if !ok {
+		scimError(c, http.StatusBadRequest, "unsupported filter; use <attribute> eq \"<value>\"")
+		return
+	}
+	groups, err := scimLoadGroups(c.Request.Context())
+	if err != nil {
+		log.Printf("SCIM: failed to list groups: %v", err)
+		scimError(c, http.StatusInternalServerError, "failed to list groups")
+		return
+	}
+	out := []interface{}{}
+	for _, g := range groups {
+		switch attr {
+		case "displayname":
+			if g.DisplayName != value {
+				continue
+			}
+		case "externalid":
+			if g.ExternalID != value {
+				continue
+			}
+		case "":
+		default:
+			continue
+		}
+		// Member lists can be large; IdPs ask for them explicitly
+		if strings.Contains(c.Query("excludedAttributes"), "members") {
+			g.Members = nil
+		}
+		out = append(out, g)
+	}
+	scimListResponse(c, out)
+}
+
+// GetSCIMGroup handles GET /api/scim/v2/Groups/:id
+func GetSCIMGroup(c *gin.Context) {
+	var g scimGroup
+	if err := scimLoad(c.Request.Context(), "group", c.Param("id"), &g); err != nil {
+		scimLoadFailed(c, "group", err)
+		return
+	}
+	scimRespond(c, http.StatusOK, g)
+}
+
+// CreateSCIMGroup handles POST /api/scim/v2/Groups
+func CreateSCIMGroup(c *gin.Context) {
+	var g scimGroup
+	if err := c.ShouldBindJSON(&g); err != nil || strings.TrimSpace(g.DisplayName) == "" {
+		scimError(c, http.StatusBadRequest, "displayName is required")
+		return
+	}
+	scimWrite(c, func() bool {
+		groups, err := scimLoadGroups(c.Request.Context())
+		if err != nil {
+			log.Printf("SCIM: failed to list groups: %v", err)
+			scimError(c, http.StatusInternalServerError, "failed to create group")
+			return false
+		}
+		for _, existing := range groups {
+			if existing.DisplayName == strings.TrimSpace(g.DisplayName) {
+				scimError(c, http.StatusConflict, fmt.Sprintf("group %q already exists", g.DisplayName))
+				return false
+			}
+		}
+		id := newSCIMID()
+		g.normalize(id, scimNow())
+		if err := scimSave(c.Request.Context(), "group", id, &g, true); err != nil {
+			log.Printf("SCIM: failed to store group %s: %v", g.DisplayName, err)
+			scimError(c, http.StatusInternalServerError, "failed to create group")
+			return false
+		}
+		log.Printf("SCIM: provisioned group %s with %d members", g.DisplayName, len(g.Members))
+		scimRespond(c, http.StatusCreated, g)
+		return true
+	})
+}
+
+// ReplaceSCIMGroup handles PUT /api/scim/v2/Groups/:id
+func ReplaceSCIMGroup(c *gin.Context) {
+	var g scimGroup
+	if err := c.ShouldBindJSON(&g); err != nil || strings.TrimSpace(g.DisplayName) == "" {
+		scimError(c, http.StatusBadRequest, "displayName is required")
+		return
+	}
+	scimWrite(c, func() bool {
+		var current scimGroup
+		if err := scimLoad(c.Request.Context(), "group", c.Param("id"), &current); err != nil {
+			scimLoadFailed(c, "group", err)
+			return false
+		}
+		g.normalize(current.ID, current.Meta.Created)
+		return scimStoreGroup(c, &g)
+	})
+}
+
+// scimMemberFilter matches the path of a single-member removal: members[value eq "id"]
+var scimMemberFilter = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]+)"\s*\]$`)
+
+// PatchSCIMGroup handles PATCH /api/scim/v2/Groups/:id
+// Supports adding, removing and replacing members, and renaming the group.
+func PatchSCIMGroup(c *gin.Context) {
+	var req scimPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, http.StatusBadRequest, "invalid PatchOp")
+		return
+	}
+	scimWrite(c, func() bool {
+		var g scimGroup
+		if err := scimLoad(c.Request.Context(), "group", c.Param("id"), &g); err != nil {
+			scimLoadFailed(c, "group", err)
+			return false
+		}
+		for _, op := range req.Operations {
+			if err := applySCIMGroupOp(&g, strings.ToLower(op.Op), op.Path, op.Value); err != nil {
+				scimError(c, http.StatusBadRequest, err.Error())
+				return false
+			}
+		}
+		g.normalize(g.ID, g.Meta.Created)
+		return scimStoreGroup(c, &g)
+	})
+}
+
+func applySCIMGroupOp(g *scimGroup, op, path string, value json.RawMessage) error {
+	if m := scimMemberFilter.FindStringSubmatch(path); m != nil && op == "remove" {
+		g.Members = removeSCIMMembers(g.Members, map[string]bool{m[1]: true})
+		return nil
+	}
+	switch strings.ToLower(path) {
+	case "members":
+		var members []scimMember
+		if len(value) > 0 {
+			if err := json.Unmarshal(value, &members); err != nil {
+				return fmt.Errorf("members must be a list of {value}")
+			}
+		}
+		switch op {
+		case "add":
+			g.Members = addSCIMMembers(g.Members, members)
+		case "remove":
+			// Without a value every member is removed
+			if len(members) == 0 {
+				g.Members = nil
+				return nil
+			}
+			ids := map[string]bool{}
+			for _, m := range members {
+				ids[m.Value] = true
+			}
+			g.Members = removeSCIMMembers(g.Members, ids)
+		case "replace":
+			g.Members = addSCIMMembers(nil, members)
+		default:
+			return fmt.Errorf("unsupported op %q", op)
+		}
+	case "displayname":
+		if op != "replace" && op != "add" {
+			return fmt.Errorf("unsupported op %q for displayName", op)
+		}
+		return json.Unmarshal(value, &g.DisplayName)
+	case "externalid":
+		return json.Unmarshal(value, &g.ExternalID)
+	case "":
+		if op != "replace" && op != "add" {
+			return fmt.Errorf("unsupported op %q without a path", op)
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(value, &values); err != nil {
+			return fmt.Errorf("value must be an object when path is empty")
+		}
+		for attr, v := range values {
+			if err := applySCIMGroupOp(g, op, attr, v); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported path %q", path)
+	}
+	return nil
+}
+
+func addSCIMMembers(members, add []scimMember) []scimMember {
+	seen := map[string]bool{}
+	for _, m := range members {
+		seen[m.Value] = true
+	}
+	for _, m := range add {
+		if m.Value != "" && !seen[m.Value] {
+			seen[m.Value] = true
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func removeSCIMMembers(members []scimMember, ids map[string]bool) []scimMember {
+	kept := []scimMember{}
+	for _, m := range members {
+		if !ids[m.Value] {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+func scimStoreGroup(c *gin.Context, g *scimGroup) bool {
+	if g.DisplayName == "" {
+		scimError(c, http.StatusBadRequest, "displayName is required")
+		return false
+	}
+	if err := scimSave(c.Request.Context(), "group", g.ID, g, false); err != nil {
+		log.Printf("SCIM: failed to store group %s: %v", g.DisplayName, err)
+		scimError(c, http.StatusInternalServerError, "failed to update group")
+		return false
+	}
+	scimRespond(c, http.StatusOK, g)
+	return true
+}
+
+// DeleteSCIMGroup handles DELETE /api/scim/v2/Groups/:id; access it granted is revoked
+func DeleteSCIMGroup(c *gin.Context) {
+	scimDelete(c, "group")
+}
+
+func scimDelete(c *gin.Context, kind string) {
+	scimWrite(c, func() bool {
+		err := K8sClient.CoreV1().ConfigMaps(Namespace).Delete(c.Request.Context(), scimConfigMapName(kind, c.Param("id")), v1.DeleteOptions{})
+		if err != nil {
+			scimLoadFailed(c, kind, err)
+			return false
+		}
+		log.Printf("SCIM: deleted %s %s", kind, c.Param("id"))
+		c.Status(http.StatusNoContent)
+		return true
+	})
+}
+
+func scimLoadFailed(c *gin.Context, kind string, err error) {
+	if errors.IsNotFound(err) {
+		scimError(c, http.StatusNotFound, fmt.Sprintf("%s %q not found", kind, c.Param("id")))
+		return
+	}
+	log.Printf("SCIM: failed to access %s %s: %v", kind, c.Param("id"), err)
+	scimError(c, http.StatusInternalServerError, fmt.Sprintf("failed to access %s", kind))
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// TestSCIMGroupGrant verifies only "<prefix><project>:<role>" names with a known role grant access
+func TestSCIMGroupGrant(t *testing.T) {
+	t.Setenv("SCIM_GROUP_PREFIX", "")
+	cases := []struct {
+		name          string
+		project, role string
+		ok            bool
+	}{
+		{"ambient:payments:edit", "payments", "edit", true},
+		{"ambient:payments:ADMIN", "payments", "admin", true},
+		{"ambient:payments:owner", "", "", false},
+		{"ambient::view", "", "", false},
+		{"ambient:payments", "", "", false},
+		{"engineering", "", "", false},
+	}
+	for _, tc := range cases {
+		project, role, ok := scimGroupGrant(tc.name)
+		if project != tc.project || role != tc.role || ok != tc.ok {
+			t.Errorf("%q: got (%q, %q, %v), want (%q, %q, %v)", tc.name, project, role, ok, tc.project, tc.role, tc.ok)
+		}
+	}
+	t.Setenv("SCIM_GROUP_PREFIX", "idp-")
+	if project, _, ok := scimGroupGrant("idp-payments:view"); !ok || project != "payments" {
+		t.Errorf("custom prefix: got (%q, %v)", project, ok)
+	}
+}
+
+// TestParseSCIMFilter verifies single-attribute eq filters parse, escapes included, and
+// anything else is refused
+func TestParseSCIMFilter(t *testing.T) {
+	cases := []struct {
+		filter      string
+		attr, value string
+		ok          bool
+	}{
+		{"", "", "", true},
+		{`userName eq "alice@example.com"`, "username", "alice@example.com", true},
+		{`displayName EQ "ambient:payments:edit"`, "displayname", "ambient:payments:edit", true},
+		{`externalId eq "a\"b"`, "externalid", `a"b`, true},
+		{`userName co "alice"`, "", "", false},
+		{`userName eq "a" and active eq "true"`, "", "", false},
+		{`userName eq alice`, "", "", false},
+	}
+	for _, tc := range cases {
+		attr, value, ok := parseSCIMFilter(tc.filter)
+		if attr != tc.attr || value != tc.value || ok != tc.ok {
+			t.Errorf("%q: got (%q, %q, %v), want (%q, %q, %v)", tc.filter, attr, value, ok, tc.attr, tc.value, tc.ok)
+		}
+	}
+}
+
+// TestApplySCIMGroupOp verifies the member and rename operations IdPs send
+func TestApplySCIMGroupOp(t *testing.T) {
+	members := func(g scimGroup) []string {
+		ids := []string{}
+		for _, m := range g.Members {
+			ids = append(ids, m.Value)
+		}
+		return ids
+	}
+	g := scimGroup{DisplayName: "ambient:payments:view", Members: []scimMember{{Value: "u1"}}}
+	steps := []struct {
+		op, path, value string
+		want            []string
+	}{
+		{"add", "members", `[{"value":"u2"},{"value":"u1"}]`, []string{"u1", "u2"}},
+		{"remove", `members[value eq "u1"]`, ``, []string{"u2"}},
+		{"replace", "members", `[{"value":"u3"}]`, []string{"u3"}},
+		{"add", "", `{"members":[{"value":"u4"}]}`, []string{"u3", "u4"}},
+		{"remove", "members", `[{"value":"u3"}]`, []string{"u4"}},
+		{"remove", "members", ``, []string{}},
+	}
+	for i, s := range steps {
+		if err := applySCIMGroupOp(&g, s.op, s.path, json.RawMessage(s.value)); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if got := members(g); !reflect.DeepEqual(got, s.want) {
+			t.Errorf("step %d: members = %v, want %v", i, got, s.want)
+		}
+	}
+	if err := applySCIMGroupOp(&g, "replace", "displayName", json.RawMessage(`"ambient:payments:edit"`)); err != nil || g.DisplayName != "ambient:payments:edit" {
+		t.Errorf("rename: err = %v, displayName = %q", err, g.DisplayName)
+	}
+	for _, bad := range []struct{ op, path, value string }{
+		{"remove", "displayName", ``},
+		{"move", "members", `[]`},
+		{"add", "members", `"u5"`},
+		{"add", "owner", `"x"`},
+	} {
+		if err := applySCIMGroupOp(&g, bad.op, bad.path, json.RawMessage(bad.value)); err == nil {
+			t.Errorf("%s %s accepted", bad.op, bad.path)
+		}
+	}
+}
+
+func scimTestBinding(project, user, role string) rbacv1.RoleBinding {
+	rb, _ := newPermissionRoleBinding(project, "user", user, role)
+	rb.Labels[scimProvisionedLabel] = "scim"
+	return *rb
+}
+
+// TestPlanSCIMSync verifies grants follow active group members at their highest role, go
+// only to Ambient projects, and revokes never remove a project's last admin
+func TestPlanSCIMSync(t *testing.T) {
+	t.Setenv("SCIM_GROUP_PREFIX", "")
+	inactive := false
+	users := []scimUser{
+		{ID: "1", UserName: "alice"},
+		{ID: "2", UserName: "bob"},
+		{ID: "3", UserName: "carol", Active: &inactive},
+	}
+	groups := []scimGroup{
+		{DisplayName: "ambient:payments:view", Members: []scimMember{{Value: "1"}, {Value: "2"}, {Value: "3"}}},
+		{DisplayName: "ambient:payments:edit", Members: []scimMember{{Value: "1"}}},
+		{DisplayName: "ambient:kube-system:admin", Members: []scimMember{{Value: "1"}}},
+		{DisplayName: "engineering", Members: []scimMember{{Value: "2"}}},
+	}
+	provisioned := []rbacv1.RoleBinding{
+		scimTestBinding("payments", "bob", "view"),
+		scimTestBinding("payments", "carol", "edit"),
+		scimTestBinding("billing", "dave", "admin"),
+		scimTestBinding("search", "erin", "admin"),
+		scimTestBinding("search", "frank", "admin"),
+	}
+	managed := func(project string) bool { return project != "kube-system" }
+	admins := map[string]int{"billing": 1, "search": 2}
+
+	plan := planSCIMSync(users, groups, provisioned, managed, func(project string) int { return admins[project] })
+
+	wantGrant := map[scimGrantKey]string{{project: "payments", user: "alice"}: "edit"}
+	if !reflect.DeepEqual(plan.grant, wantGrant) {
+		t.Errorf("grant = %v, want %v", plan.grant, wantGrant)
+	}
+	var revoked []string
+	for _, rb := range plan.revoke {
+		revoked = append(revoked, rb.Namespace+"/"+rb.Annotations["ambient-code.io/subject-name"])
+	}
+	// dave is billing's only admin; of search's two admins only one may go
+	wantRevoke := []string{"payments/carol", "search/erin"}
+	if !reflect.DeepEqual(revoked, wantRevoke) {
+		t.Errorf("revoke = %v, want %v", revoked, wantRevoke)
+	}
+}
@@ -60,7 +60,7 @@ func ListRunnerSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 		c.Abort()
 		return
 	}
@@ -90,7 +90,7 @@ func UpdateRunnerSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 		c.Abort()
 		return
 	}
@@ -169,7 +169,7 @@ func ListIntegrationSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 		c.Abort()
 		return
 	}
@@ -199,7 +199,7 @@ func UpdateIntegrationSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
 		c.Abort()
 		return
 	}
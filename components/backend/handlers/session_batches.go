@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+// SessionBatches group sessions by label selector; the operator keeps their status (phase
+// counts, cost, Complete condition) current. Fan-out requests create one per batch. All calls
+// use the caller's credentials, so project RBAC on sessionbatches applies.
+
+// ListSessionBatches handles GET /api/projects/:projectName/session-batches
+func ListSessionBatches(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	list, err := reqDyn.Resource(GetSessionBatchResource()).Namespace(project).List(c.Request.Context(), v1.ListOptions{})
+	if err != nil {
+		respondSessionBatchError(c, project, "list", err)
+		return
+	}
+	items := make([]types.SessionBatch, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, sessionBatchFromObject(&list.Items[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetSessionBatch handles GET /api/projects/:projectName/session-batches/:name
+func GetSessionBatch(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	obj, err := reqDyn.Resource(GetSessionBatchResource()).Namespace(project).Get(c.Request.Context(), c.Param("name"), v1.GetOptions{})
+	if err != nil {
+		respondSessionBatchError(c, project, "get", err)
+		return
+	}
+	c.JSON(http.StatusOK, sessionBatchFromObject(obj))
+}
+
+// CreateSessionBatch handles POST /api/projects/:projectName/session-batches
+func CreateSessionBatch(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	var req types.CreateSessionBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validation.IsDNS1123Subdomain(req.Name); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid name: %s", errs[0])})
+		return
+	}
+	selector, err := v1.LabelSelectorAsSelector(req.Selector)
+	if err != nil || selector.Empty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "selector must select sessions by label"})
+		return
+	}
+	if req.ExpectedSessions < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expectedSessions must not be negative"})
+		return
+	}
+	obj, err := createSessionBatch(c, reqDyn, project, req.Name, apiv1alpha1.SessionBatchSpec{Selector: req.Selector, ExpectedSessions: req.ExpectedSessions})
+	if err != nil {
+		respondSessionBatchError(c, project, "create", err)
+		return
+	}
+	c.JSON(http.StatusCreated, sessionBatchFromObject(obj))
+}
+
+// DeleteSessionBatch handles DELETE /api/projects/:projectName/session-batches/:name. The
+// sessions are not deleted with the batch.
+func DeleteSessionBatch(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	if err := reqDyn.Resource(GetSessionBatchResource()).Namespace(project).Delete(c.Request.Context(), c.Param("name"), v1.DeleteOptions{}); err != nil {
+		respondSessionBatchError(c, project, "delete", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// createSessionBatch creates a SessionBatch in the project with the given client
+func createSessionBatch(c *gin.Context, client dynamic.Interface, project, name string, spec apiv1alpha1.SessionBatchSpec) (*unstructured.Unstructured, error) {
+	specMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiv1alpha1.SchemeGroupVersion.String(),
+		"kind":       "SessionBatch",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   project,
+			"annotations": map[string]interface{}{createdByAnnotation: c.GetString("userID")},
+		},
+		"spec": specMap,
+	}}
+	return client.Resource(GetSessionBatchResource()).Namespace(project).Create(c.Request.Context(), obj, v1.CreateOptions{})
+}
+
+func sessionBatchFromObject(obj *unstructured.Unstructured) types.SessionBatch {
+	batch := types.SessionBatch{Name: obj.GetName(), CreationTimestamp: obj.GetCreationTimestamp().UTC().Format(time.RFC3339)}
+	if spec, ok := obj.Object["spec"].(map[string]interface{}); ok {
+		_ = runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &batch.Spec)
+	}
+	if status, ok := obj.Object["status"].(map[string]interface{}); ok {
+		batch.Status = &apiv1alpha1.SessionBatchStatus{}
+		_ = runtime.DefaultUnstructuredConverter.FromUnstructured(status, batch.Status)
+	}
+	return batch
+}
+
+func respondSessionBatchError(c *gin.Context, project, verb string, err error) {
+	switch {
+	case errors.IsNotFound(err):
+		respondMessage(c, http.StatusNotFound, "sessionBatch.notFound", nil)
+	case errors.IsAlreadyExists(err):
+		respondMessage(c, http.StatusConflict, "sessionBatch.exists", nil)
+	case errors.IsForbidden(err):
+		respondMessage(c, http.StatusForbidden, "sessionBatch.forbidden", gin.H{"Verb": "verb." + verb})
+	case errors.IsInvalid(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to %s session batches in %s: %v", verb, project, err)
+		respondMessage(c, http.StatusInternalServerError, "sessionBatch.failed", gin.H{"Verb": "verb." + verb})
+	}
+}
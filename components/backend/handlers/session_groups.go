@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"ambient-code-pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// Sessions with the same spec.sessionGroup work in one shared workspace. Their edits are
+// coordinated with advisory locks on workspace paths, kept as Leases in the project namespace
+// so they hold across backend replicas and expire on their own if a member dies.
+
+// sessionGroupLabel marks the sessions of a group (and, set by the operator, the group's PVC)
+const sessionGroupLabel = "ambient-code.io/session-group"
+
+// sessionGroupLockPathAnnotation records the workspace path a lock Lease covers
+const sessionGroupLockPathAnnotation = "ambient-code.io/lock-path"
+
+const (
+	defaultSessionGroupLockTTL = 5 * time.Minute
+	maxSessionGroupLockTTL     = time.Hour
+	minSessionGroupLockTTL     = 10 * time.Second
+)
+
+// isValidSessionGroupName matches spec.sessionGroup in the CRD; the name ends up in PVC,
+// Lease and label values
+func isValidSessionGroupName(name string) bool {
+	return validation.ValidSessionGroupName(name)
+}
+
+// sessionGroupPVCName is the workspace volume the operator provisions for a group
+func sessionGroupPVCName(group string) string {
+	return fmt.Sprintf("ambient-workspace-group-%s", group)
+}
+
+// sessionWorkspacePVCName is the PVC holding a session's workspace: the group's shared volume
+// for group members, otherwise the session's own
+func sessionWorkspacePVCName(session *unstructured.Unstructured, sessionName string) string {
+	if session != nil {
+		if group, _, _ := unstructured.NestedString(session.Object, "spec", "sessionGroup"); group != "" {
+			return sessionGroupPVCName(group)
+		}
+	}
+	return fmt.Sprintf("ambient-workspace-%s", sessionName)
+}
+
+type sessionGroupLockRequest struct {
+	// Session taking the lock; it must be a member of the group
+	Session string `json:"session" binding:"required"`
+	// Path in the shared workspace, relative to its root; "." locks the whole workspace
+	Path       string `json:"path"`
+	TTLSeconds int    `json:"ttlSeconds,omitempty"`
+}
+
+type sessionGroupLock struct {
+	Path       string    `json:"path"`
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// normalizeLockPath cleans a workspace-relative path; ".." cannot climb above the workspace root
+func normalizeLockPath(p string) (string, bool) {
+	p = path.Clean("/" + strings.TrimSpace(p))
+	if strings.Contains(p, "\x00") {
+		return "", false
+	}
+	if p == "/" {
+		return ".", true
+	}
+	return strings.TrimPrefix(p, "/"), true
+}
+
+// sessionGroupLockName is the Lease name of a lock; paths are hashed to fit object names
+func sessionGroupLockName(group, lockPath string) string {
+	sum := sha256.Sum256([]byte(lockPath))
+	return fmt.Sprintf("session-group-%s-%s", group, hex.EncodeToString(sum[:8]))
+}
+
+func sessionGroupLockView(lease *coordinationv1.Lease) sessionGroupLock {
+	lock := sessionGroupLock{Path: lease.Annotations[sessionGroupLockPathAnnotation]}
+	if lease.Spec.HolderIdentity != nil {
+		lock.Holder = *lease.Spec.HolderIdentity
+	}
+	if lease.Spec.AcquireTime != nil {
+		lock.AcquiredAt = lease.Spec.AcquireTime.UTC()
+	}
+	if lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
+		lock.ExpiresAt = lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).UTC()
+	}
+	return lock
+}
+
+func sessionGroupLockExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	return lease.Spec.HolderIdentity == nil || !sessionGroupLockView(lease).ExpiresAt.After(now)
+}
+
+// requireSessionGroupMember checks, with the caller's credentials, that the session exists
+// and belongs to the group. Runners pass with their own session; users need read access.
+func requireSessionGroupMember(c *gin.Context, reqDyn dynamic.Interface, project, group, session string) bool {
+	obj, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("session %q not found", session)})
+		} else if errors.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to read the session"})
+		} else {
+			log.Printf("Session group %s/%s: failed to read session %s: %v", project, group, session, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read session"})
+		}
+		return false
+	}
+	if member, _, _ := unstructured.NestedString(obj.Object, "spec", "sessionGroup"); member != group {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("session %q is not a member of session group %q", session, group)})
+		return false
+	}
+	return true
+}
+
+// GetSessionGroup handles GET /api/projects/:projectName/session-groups/:group
+// Lists the group's sessions and the locks currently held in its workspace.
+func GetSessionGroup(c *gin.Context) {
+	project := c.Param("projectName")
+	group := c.Param("group")
+	if !isValidSessionGroupName(group) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session group name"})
+		return
+	}
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	ctx := c.Request.Context()
+	selector := fmt.Sprintf("%s=%s", sessionGroupLabel, group)
+
+	list, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).List(ctx, v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		if errors.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to list sessions"})
+			return
+		}
+		log.Printf("GetSessionGroup: failed to list sessions of %s/%s: %v", project, group, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+	sessions := make([]gin.H, 0, len(list.Items))
+	for _, item := range list.Items {
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		sessions = append(sessions, gin.H{"name": item.GetName(), "phase": phase})
+	}
+
+	leases, err := K8sClient.CoordinationV1().Leases(project).List(ctx, v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		log.Printf("GetSessionGroup: failed to list locks of %s/%s: %v", project, group, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list locks"})
+		return
+	}
+	now := time.Now()
+	locks := []sessionGroupLock{}
+	for i := range leases.Items {
+		if !sessionGroupLockExpired(&leases.Items[i], now) {
+			locks = append(locks, sessionGroupLockView(&leases.Items[i]))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group":    group,
+		"pvcName":  sessionGroupPVCName(group),
+		"sessions": sessions,
+		"locks":    locks,
+	})
+}
+
+// AcquireSessionGroupLock handles POST /api/projects/:projectName/session-groups/:group/locks
+// Takes or renews the advisory lock on a workspace path for a member session. A lock held by
+// another session answers 409 with the current holder; locks expire after ttlSeconds unless
+// renewed.
+func AcquireSessionGroupLock(c *gin.Context) {
+	project := c.Param("projectName")
+	group := c.Param("group")
+	var req sessionGroupLockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	lockPath, ok := normalizeLockPath(req.Path)
+	if !isValidSessionGroupName(group) || !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session group or path"})
+		return
+	}
+	ttl := defaultSessionGroupLockTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+		if ttl < minSessionGroupLockTTL || ttl > maxSessionGroupLockTTL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ttlSeconds must be between %d and %d", int(minSessionGroupLockTTL.Seconds()), int(maxSessionGroupLockTTL.Seconds()))})
+			return
+		}
+	}
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	if !requireSessionGroupMember(c, reqDyn, project, group, req.Session) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	leases := K8sClient.CoordinationV1().Leases(project)
+	now := v1.NewMicroTime(time.Now())
+	seconds := int32(ttl.Seconds())
+	name := sessionGroupLockName(group, lockPath)
+
+	lease, err := leases.Get(ctx, name, v1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		lease = &coordinationv1.Lease{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        name,
+				Namespace:   project,
+				Labels:      map[string]string{sessionGroupLabel: group},
+				Annotations: map[string]string{sessionGroupLockPathAnnotation: lockPath},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &req.Session,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		lease, err = leases.Create(ctx, lease, v1.CreateOptions{})
+	case err == nil:
+		if !sessionGroupLockExpired(lease, now.Time) && *lease.Spec.HolderIdentity != req.Session {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is locked by session %s", lockPath, *lease.Spec.HolderIdentity), "lock": sessionGroupLockView(lease)})
+			return
+		}
+		updated := lease.DeepCopy()
+		if sessionGroupLockExpired(lease, now.Time) || *lease.Spec.HolderIdentity != req.Session {
+			updated.Spec.HolderIdentity = &req.Session
+			updated.Spec.AcquireTime = &now
+		}
+		updated.Spec.LeaseDurationSeconds = &seconds
+		updated.Spec.RenewTime = &now
+		// The resourceVersion makes a concurrent acquire by another member fail with a conflict
+		lease, err = leases.Update(ctx, updated, v1.UpdateOptions{})
+	}
+	if err != nil {
+		if errors.IsAlreadyExists(err) || errors.IsConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s was locked concurrently, retry", lockPath)})
+			return
+		}
+		log.Printf("AcquireSessionGroupLock: %s/%s %s for %s: %v", project, group, lockPath, req.Session, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to acquire lock"})
+		return
+	}
+	c.JSON(http.StatusOK, sessionGroupLockView(lease))
+}
+
+// ReleaseSessionGroupLock handles DELETE /api/projects/:projectName/session-groups/:group/locks?session=&path=
+// Releases a lock held by the session. Releasing a lock that is not held succeeds; a lock held
+// by another session answers 409.
+func ReleaseSessionGroupLock(c *gin.Context) {
+	project := c.Param("projectName")
+	group := c.Param("group")
+	session := strings.TrimSpace(c.Query("session"))
+	lockPath, ok := normalizeLockPath(c.Query("path"))
+	if !isValidSessionGroupName(group) || !ok || session == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session group, session and path are required"})
+		return
+	}
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	if !requireSessionGroupMember(c, reqDyn, project, group, session) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	leases := K8sClient.CoordinationV1().Leases(project)
+	lease, err := leases.Get(ctx, sessionGroupLockName(group, lockPath), v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		log.Printf("ReleaseSessionGroupLock: %s/%s %s: %v", project, group, lockPath, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read lock"})
+		return
+	}
+	if !sessionGroupLockExpired(lease, time.Now()) && *lease.Spec.HolderIdentity != session {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is locked by session %s", lockPath, *lease.Spec.HolderIdentity), "lock": sessionGroupLockView(lease)})
+		return
+	}
+	rv := lease.ResourceVersion
+	if err := leases.Delete(ctx, lease.Name, v1.DeleteOptions{Preconditions: &v1.Preconditions{ResourceVersion: &rv}}); err != nil && !errors.IsNotFound(err) {
+		if errors.IsConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s changed hands concurrently, retry", lockPath)})
+			return
+		}
+		log.Printf("ReleaseSessionGroupLock: %s/%s %s: %v", project, group, lockPath, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release lock"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
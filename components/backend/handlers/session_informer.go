@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ambient-code-backend/moderation"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// creationSourceAnnotation records how a session was created: "api" or "external" (kubectl, GitOps, ...)
+	creationSourceAnnotation = "ambient-code.io/creation-source"
+	// createdByAnnotation records the creator: the user ID for API sessions, the field manager otherwise
+	createdByAnnotation = "ambient-code.io/created-by"
+
+	creationSourceAPI      = "api"
+	creationSourceExternal = "external"
+)
+
+// StartSessionInformer watches AgenticSessions in all managed namespaces and adopts the ones
+// created outside the backend (e.g. kubectl apply): defaults are backfilled, the spec is
+// validated, quotas are applied and a runner token is provisioned, so they behave exactly
+// like sessions created through the API. Blocks until ctx is cancelled.
+func StartSessionInformer(ctx context.Context) {
+	if DynamicClient == nil || K8sClient == nil {
+		log.Printf("Session informer disabled: backend SA clients not initialized")
+		return
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(DynamicClient, 10*time.Minute)
+	informer := factory.ForResource(GetAgenticSessionV1Alpha1Resource()).Informer()
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				adoptExternalSession(ctx, u)
+				// Mint the runner's GitHub token before the operator builds the Job
+				if MintSessionGitHubAppToken != nil {
+					if session, err := sessionFromUnstructured(u); err == nil {
+						go syncSessionGitHubToken(ctx, session)
+					}
+				}
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if u, ok := newObj.(*unstructured.Unstructured); ok {
+				adoptExternalSession(ctx, u)
+				if old, ok := oldObj.(*unstructured.Unstructured); ok {
+					notifyCostLimitReached(old, u)
+				}
+			}
+		},
+	})
+	if err != nil {
+		log.Printf("Session informer disabled: failed to register handler: %v", err)
+		return
+	}
+	log.Printf("Starting AgenticSession informer for externally created sessions")
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	<-ctx.Done()
+}
+
+// needsAdoption reports whether a session was created outside the backend and not yet adopted.
+// The operator holds such sessions until they carry the creation-source annotation, so the
+// annotation alone decides; the runner token annotation is written by the operator and says
+// nothing about adoption.
+func needsAdoption(obj *unstructured.Unstructured) bool {
+	if obj.GetDeletionTimestamp() != nil {
+		return false
+	}
+	return obj.GetAnnotations()[creationSourceAnnotation] == ""
+}
+
+func adoptExternalSession(ctx context.Context, obj *unstructured.Unstructured) {
+	if !needsAdoption(obj) {
+		return
+	}
+	project := obj.GetNamespace()
+	name := obj.GetName()
+
+	ns, err := K8sClient.CoreV1().Namespaces().Get(ctx, project, v1.GetOptions{})
+	if err != nil || ns.Labels["ambient-code.io/managed"] != "true" {
+		return
+	}
+
+	creator := externalSessionCreator(obj)
+	log.Printf("Adopting externally created session %s/%s (created by %s)", project, name, creator)
+
+	adopted := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				creationSourceAnnotation: creationSourceExternal,
+				createdByAnnotation:      creator,
+			},
+		},
+	}
+	// Sessions that ran before the operator waited for adoption are only annotated: checking
+	// them now could not stop them
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "" && phase != "Pending" {
+		patchSession(ctx, project, name, adopted, "")
+		return
+	}
+
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	validationErr := validateSessionSpec(spec)
+	if validationErr == nil {
+		validationErr = checkSessionQuota(ctx, project, name)
+	}
+	if validationErr == nil {
+		validationErr = checkMaintenance(ctx, project)
+	}
+	if validationErr == nil {
+		defaults := sessionSpecDefaults(spec, project)
+		prompt, _ := spec["prompt"].(string)
+		validationErr = applyPromptPolicy(ctx, project, spec)
+		if validationErr != nil && !moderation.IsRejected(validationErr) {
+			// The policy could not be checked, e.g. the moderation webhook was unreachable
+			validationErr = &adoptionRetryError{validationErr}
+		}
+		if validationErr == nil {
+			validationErr = applyProjectSystemPrompt(ctx, project, spec, obj.GetAnnotations()[issueAnnotation])
+		}
+		if rendered, _ := spec["prompt"].(string); rendered != prompt {
+			defaults["prompt"] = rendered
+		}
+		if validationErr == nil {
+			adopted["spec"] = defaults
+		}
+	}
+
+	// A check that could not run leaves the session unannotated and held; the informer's
+	// next resync retries it
+	if validationErr != nil && !rejectsSession(validationErr) {
+		log.Printf("Cannot adopt session %s/%s yet: %v", project, name, validationErr)
+		return
+	}
+
+	// The annotation releases the operator's hold, so a rejected session is failed first
+	if validationErr != nil {
+		log.Printf("Externally created session %s/%s rejected: %v", project, name, validationErr)
+		switch validationErr.(type) {
+		case *sessionQuotaError:
+			recordSessionRejection(rejectionCauseQuota)
+		case *maintenanceError:
+			recordSessionRejection(rejectionCauseMaintenance)
+		}
+		if !patchSession(ctx, project, name, map[string]interface{}{
+			"status": map[string]interface{}{
+				"phase":   "Error",
+				"message": fmt.Sprintf("Invalid session: %v", validationErr),
+			},
+		}, "status") {
+			return
+		}
+	}
+	patchSession(ctx, project, name, adopted, "")
+}
+
+// adoptionRetryError marks an adoption check that failed for a reason other than the
+// session itself
+type adoptionRetryError struct{ error }
+
+func (e *adoptionRetryError) Unwrap() error { return e.error }
+
+// rejectsSession reports whether an adoption check failed because of the session, rather than
+// because the cluster could not be read or the prompt policy could not be checked
+func rejectsSession(err error) bool {
+	var retry *adoptionRetryError
+	var status apierrors.APIStatus
+	return !errors.As(err, &retry) && !errors.As(err, &status)
+}
+
+func patchSession(ctx context.Context, project, name string, patch map[string]interface{}, subresource string) bool {
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return false
+	}
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	var subresources []string
+	if subresource != "" {
+		subresources = append(subresources, subresource)
+	}
+	if _, err := DynamicClient.Resource(gvr).Namespace(project).Patch(ctx, name, ktypes.MergePatchType, b, v1.PatchOptions{}, subresources...); err != nil {
+		log.Printf("Failed to patch session %s/%s: %v", project, name, err)
+		return false
+	}
+	return true
+}
+
+// externalSessionCreator prefers a userContext supplied in the manifest, then the field
+// manager that wrote the object (e.g. "kubectl-client-side-apply").
+func externalSessionCreator(obj *unstructured.Unstructured) string {
+	if uid, _, _ := unstructured.NestedString(obj.Object, "spec", "userContext", "userId"); strings.TrimSpace(uid) != "" {
+		return strings.TrimSpace(uid)
+	}
+	// The first managedFields entry belongs to whoever wrote the object initially
+	for _, mf := range obj.GetManagedFields() {
+		if mf.Manager != "" && mf.Subresource == "" {
+			return mf.Manager
+		}
+	}
+	return "unknown"
+}
+
+// sessionSpecDefaults returns the spec fields CreateSession would have filled in but the
+// manifest left out.
+func sessionSpecDefaults(spec map[string]interface{}, project string) map[string]interface{} {
+	defaults := map[string]interface{}{}
+	if p, _ := spec["project"].(string); p == "" {
+		defaults["project"] = project
+	}
+	if _, ok := spec["timeout"]; !ok {
+		defaults["timeout"] = 300
+	}
+	llm, _ := spec["llmSettings"].(map[string]interface{})
+	llmDefaults := map[string]interface{}{}
+	if m, _ := llm["model"].(string); m == "" {
+		llmDefaults["model"] = "sonnet"
+	}
+	if _, ok := llm["temperature"]; !ok {
+		llmDefaults["temperature"] = 0.7
+	}
+	if _, ok := llm["maxTokens"]; !ok {
+		llmDefaults["maxTokens"] = 4000
+	}
+	if len(llmDefaults) > 0 {
+		defaults["llmSettings"] = llmDefaults
+	}
+	return defaults
+}
+
+// validateSessionSpec applies the rules the API enforces on request bodies
+func validateSessionSpec(spec map[string]interface{}) error {
+	return sessionSpecErrors(spec, nil).ToAggregate()
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	}
+	return 0, false
+}
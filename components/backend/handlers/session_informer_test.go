@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// TestRejectsSession verifies only checks that failed on the session fail it; unreadable
+// project settings and an unreachable moderation service leave it for the next resync
+func TestRejectsSession(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"invalid spec", fmt.Errorf("spec.timeout: must not be negative"), true},
+		{"quota", &sessionQuotaError{project: "team-a", limit: 1, active: 1}, true},
+		{"system prompt template", fmt.Errorf("invalid system prompt template: unexpected EOF"), true},
+		{"settings read", fmt.Errorf("failed to read project settings: %w", apierrors.NewServiceUnavailable("etcd timeout")), false},
+		{"moderation webhook", &adoptionRetryError{fmt.Errorf("moderation webhook: connection refused")}, false},
+	}
+	for _, tc := range cases {
+		if got := rejectsSession(tc.err); got != tc.want {
+			t.Errorf("%s: rejectsSession = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
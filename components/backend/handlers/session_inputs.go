@@ -0,0 +1,436 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Session inputs are files a user attaches to a session (design docs, CSVs, ...) for the
+// agent to work on. They are kept in the backend's input store so a runner that has not
+// started yet picks them up, and copied into workspace/inputs of a running session through
+// its content service.
+//
+//	POST /api/projects/:projectName/agentic-sessions/:sessionName/inputs  multipart "file" fields
+//	GET  /api/projects/:projectName/agentic-sessions/:sessionName/inputs
+//	GET  /internal/inputs/:session         (runner) list
+//	GET  /internal/inputs/:session/:name   (runner) download
+
+const (
+	// defaultSessionInputMaxBytes caps one input file; override with SESSION_INPUT_MAX_BYTES
+	defaultSessionInputMaxBytes int64 = 25 << 20
+	// defaultSessionInputsTotalBytes caps all inputs of a session; override with SESSION_INPUTS_MAX_TOTAL_BYTES
+	defaultSessionInputsTotalBytes int64 = 100 << 20
+	// sessionInputsWorkspaceDir is where inputs appear, relative to the session workspace
+	sessionInputsWorkspaceDir = "inputs"
+)
+
+// defaultSessionInputExtensions are the file types accepted unless SESSION_INPUT_EXTENSIONS
+// (comma-separated, e.g. ".md,.csv") overrides them
+var defaultSessionInputExtensions = []string{
+	".md", ".txt", ".csv", ".tsv", ".json", ".yaml", ".yml", ".xml", ".html",
+	".pdf", ".png", ".jpg", ".jpeg", ".gif", ".log", ".diff", ".patch",
+}
+
+// SessionInput describes one stored input file
+type SessionInput struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+func sessionInputEnvBytes(name string, def int64) int64 {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
+
+func sessionInputMaxBytes() int64 {
+	return sessionInputEnvBytes("SESSION_INPUT_MAX_BYTES", defaultSessionInputMaxBytes)
+}
+
+func sessionInputsTotalBytes() int64 {
+	return sessionInputEnvBytes("SESSION_INPUTS_MAX_TOTAL_BYTES", defaultSessionInputsTotalBytes)
+}
+
+func sessionInputExtensions() []string {
+	v := strings.TrimSpace(os.Getenv("SESSION_INPUT_EXTENSIONS"))
+	if v == "" {
+		return defaultSessionInputExtensions
+	}
+	var exts []string
+	for _, e := range strings.Split(v, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		exts = append(exts, e)
+	}
+	return exts
+}
+
+// SessionInputsDir is where the inputs of a session are kept on the backend volume
+func SessionInputsDir(project, session string) string {
+	return filepath.Join(StateBaseDir, "session-inputs", project, session)
+}
+
+// cleanSessionInputName reduces an uploaded file name to a safe base name and checks its type
+func cleanSessionInputName(name string) (string, error) {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if name == "" || name == "." || name == ".." || name == "/" || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	if strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".sha256") {
+		return "", fmt.Errorf("file name %q uses a reserved suffix", name)
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, allowed := range sessionInputExtensions() {
+		if ext == allowed {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("file type %q is not allowed; allowed types: %s", ext, strings.Join(sessionInputExtensions(), ", "))
+}
+
+// listSessionInputs reads the input store of a session, sorted by name
+func listSessionInputs(project, session string) ([]SessionInput, error) {
+	dir := SessionInputsDir(project, session)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []SessionInput{}, nil
+		}
+		return nil, err
+	}
+	inputs := []SessionInput{}
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".sha256") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		sum, _ := os.ReadFile(filepath.Join(dir, name+".sha256"))
+		inputs = append(inputs, SessionInput{
+			Name:       name,
+			Size:       info.Size(),
+			SHA256:     strings.TrimSpace(string(sum)),
+			UploadedAt: info.ModTime().UTC(),
+		})
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Name < inputs[j].Name })
+	return inputs, nil
+}
+
+// storeSessionInput copies an uploaded file into the input store, replacing an input of the
+// same name, and returns its description
+func storeSessionInput(project, session, name string, fh *multipart.FileHeader) (SessionInput, error) {
+	dir := SessionInputsDir(project, session)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return SessionInput{}, err
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return SessionInput{}, err
+	}
+	defer src.Close()
+	dest := filepath.Join(dir, name)
+	out, err := os.OpenFile(dest+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return SessionInput{}, err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), src)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(dest + ".tmp")
+		return SessionInput{}, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err := os.WriteFile(dest+".sha256", []byte(sum), 0o644); err != nil {
+		_ = os.Remove(dest + ".tmp")
+		return SessionInput{}, err
+	}
+	if err := os.Rename(dest+".tmp", dest); err != nil {
+		return SessionInput{}, err
+	}
+	return SessionInput{Name: name, Size: n, SHA256: sum, UploadedAt: time.Now().UTC()}, nil
+}
+
+// sessionForInputs loads the session with the caller's credentials and, for uploads, checks
+// the caller may update it. On failure it writes the error response and returns nil.
+func sessionForInputs(c *gin.Context, project, session string, write bool) *unstructured.Unstructured {
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return nil
+	}
+	obj, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return nil
+		}
+		log.Printf("sessionForInputs: failed to get session %s/%s: %v", project, session, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		return nil
+	}
+	if !write {
+		return obj
+	}
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Group:     "vteam.ambient-code",
+				Resource:  "agenticsessions",
+				Verb:      "update",
+				Namespace: project,
+				Name:      session,
+			},
+		},
+	}
+	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
+	if err != nil {
+		log.Printf("sessionForInputs: SSAR failed for %s/%s: %v", project, session, err)
+		respondMessage(c, http.StatusInternalServerError, "auth.accessReviewFailed", nil)
+		return nil
+	}
+	if !res.Status.Allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update session"})
+		return nil
+	}
+	return obj
+}
+
+// UploadSessionInputs stores the files of a multipart upload as session inputs.
+// POST /api/projects/:projectName/agentic-sessions/:sessionName/inputs
+func UploadSessionInputs(c *gin.Context) {
+	project := c.GetString("project")
+	if project == "" {
+		project = c.Param("projectName")
+	}
+	session := c.Param("sessionName")
+
+	obj := sessionForInputs(c, project, session, true)
+	if obj == nil {
+		return
+	}
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	for _, terminal := range []string{"Completed", "Failed", "Stopped", "Error"} {
+		if phase == terminal {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Session is %s; inputs can only be attached before or while it runs", phase)})
+			return
+		}
+	}
+
+	maxFile, maxTotal := sessionInputMaxBytes(), sessionInputsTotalBytes()
+	// Multipart framing adds a little on top of the files themselves
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxTotal+(1<<20))
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Upload exceeds the %d byte limit for session inputs", maxTotal)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected a multipart/form-data body with one or more \"file\" fields"})
+		return
+	}
+	defer func() { _ = form.RemoveAll() }()
+	files := form.File["file"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files uploaded; use the \"file\" form field"})
+		return
+	}
+
+	existing, err := listSessionInputs(project, session)
+	if err != nil {
+		log.Printf("UploadSessionInputs: failed to list inputs of %s/%s: %v", project, session, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read session inputs"})
+		return
+	}
+	sizes := map[string]int64{}
+	for _, in := range existing {
+		sizes[in.Name] = in.Size
+	}
+
+	// Validate every file before storing any, so a rejected upload changes nothing
+	names := make([]string, len(files))
+	for i, fh := range files {
+		name, err := cleanSessionInputName(fh.Filename)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if fh.Size > maxFile {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("%s is %d bytes; the limit per file is %d", name, fh.Size, maxFile)})
+			return
+		}
+		names[i] = name
+		sizes[name] = fh.Size
+	}
+	var total int64
+	for _, s := range sizes {
+		total += s
+	}
+	if total > maxTotal {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Session inputs would total %d bytes; the limit is %d", total, maxTotal)})
+		return
+	}
+
+	stored := make([]SessionInput, 0, len(files))
+	for i, fh := range files {
+		in, err := storeSessionInput(project, session, names[i], fh)
+		if err != nil {
+			log.Printf("UploadSessionInputs: failed to store %s for %s/%s: %v", names[i], project, session, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store input file"})
+			return
+		}
+		stored = append(stored, in)
+	}
+
+	// A running session gets the files right away; otherwise the runner downloads them at start
+	copied := false
+	if phase == "Running" {
+		copied = true
+		for _, in := range stored {
+			if err := writeSessionInputToWorkspace(c, project, session, in.Name); err != nil {
+				log.Printf("UploadSessionInputs: copying %s into the workspace of %s/%s failed: %v", in.Name, project, session, err)
+				copied = false
+			}
+		}
+	}
+	c.JSON(http.StatusCreated, gin.H{"inputs": stored, "copiedToWorkspace": copied})
+}
+
+// writeSessionInputToWorkspace copies a stored input into workspace/inputs through the
+// session's content service, with the caller's token
+func writeSessionInputToWorkspace(c *gin.Context, project, session, name string) error {
+	data, err := os.ReadFile(filepath.Join(SessionInputsDir(project, session), name))
+	if err != nil {
+		return err
+	}
+	token := c.GetHeader("Authorization")
+	if strings.TrimSpace(token) == "" {
+		token = c.GetHeader("X-Forwarded-Access-Token")
+	}
+	serviceName := fmt.Sprintf("ambient-content-%s", session)
+	wreq := struct {
+		Path     string `json:"path"`
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}{
+		Path:     fmt.Sprintf("/sessions/%s/workspace/%s/%s", session, sessionInputsWorkspaceDir, name),
+		Content:  base64.StdEncoding.EncodeToString(data),
+		Encoding: "base64",
+	}
+	b, _ := json.Marshal(wreq)
+	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/write", serviceName, project)
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint, strings.NewReader(string(b)))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(token) != "" {
+		req.Header.Set("Authorization", token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		rb, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("content service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(rb)))
+	}
+	return nil
+}
+
+// ListSessionInputs returns the inputs attached to a session.
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/inputs
+func ListSessionInputs(c *gin.Context) {
+	project := c.GetString("project")
+	if project == "" {
+		project = c.Param("projectName")
+	}
+	session := c.Param("sessionName")
+	if sessionForInputs(c, project, session, false) == nil {
+		return
+	}
+	inputs, err := listSessionInputs(project, session)
+	if err != nil {
+		log.Printf("ListSessionInputs: failed to list inputs of %s/%s: %v", project, session, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read session inputs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"inputs": inputs})
+}
+
+// ListRunnerSessionInputs lists the inputs the runner should place in the workspace.
+// GET /internal/inputs/:session
+// Auth: Authorization: Bearer <BOT_TOKEN>
+func ListRunnerSessionInputs(c *gin.Context) {
+	project, session, ok := runnerArtifactSession(c)
+	if !ok {
+		return
+	}
+	inputs, err := listSessionInputs(project, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read session inputs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"inputs": inputs})
+}
+
+// GetRunnerSessionInput downloads one input file.
+// GET /internal/inputs/:session/:name
+// Auth: Authorization: Bearer <BOT_TOKEN>
+func GetRunnerSessionInput(c *gin.Context) {
+	project, session, ok := runnerArtifactSession(c)
+	if !ok {
+		return
+	}
+	name := c.Param("name")
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".sha256") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input name"})
+		return
+	}
+	p := filepath.Join(SessionInputsDir(project, session), name)
+	if _, err := os.Stat(p); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "input not found"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.File(p)
+}
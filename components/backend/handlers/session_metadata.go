@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// missingSessionMetadata lists the labels and annotations the project's objectMetadata
+// policy requires on sessions and metadata lacks. The operator would hold such a session,
+// so it is rejected up front.
+func missingSessionMetadata(ctx context.Context, project string, metadata map[string]interface{}) ([]string, error) {
+	if VteamClient == nil {
+		return nil, nil
+	}
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read project settings: %w", err)
+	}
+	return ps.Spec.ObjectMetadata.Missing(stringMap(metadata["labels"]), stringMap(metadata["annotations"])), nil
+}
+
+func stringMap(v interface{}) map[string]string {
+	m, _ := v.(map[string]interface{})
+	out := make(map[string]string, len(m))
+	for k, val := range m {
+		if s, ok := val.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
+
+// missingMetadataBody is the 422 body naming the labels and annotations a session lacks
+func missingMetadataBody(c *gin.Context, project string, missing []string) gin.H {
+	return localizedError(c, "session.missingMetadata", gin.H{
+		"Project": project,
+		"Details": strings.Join(missing, ", "),
+	})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMissingSessionMetadata(t *testing.T) {
+	VteamClient = vteamfake.NewSimpleClientset()
+	defer func() { VteamClient = nil }()
+	if _, err := VteamClient.VteamV1alpha1().ProjectSettings("regulated").Create(context.Background(), &apiv1alpha1.ProjectSettings{
+		ObjectMeta: v1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "regulated"},
+		Spec: apiv1alpha1.ProjectSettingsSpec{ObjectMetadata: &apiv1alpha1.ObjectMetadataPolicy{
+			RequiredLabels:      []string{"owner-team"},
+			RequiredAnnotations: []string{"example.com/cost-center"},
+		}},
+	}, v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := map[string]interface{}{
+		"labels":      map[string]interface{}{"owner-team": "payments"},
+		"annotations": map[string]interface{}{},
+	}
+	missing, err := missingSessionMetadata(context.Background(), "regulated", metadata)
+	if err != nil || !reflect.DeepEqual(missing, []string{"annotation example.com/cost-center"}) {
+		t.Errorf("missingSessionMetadata() = %v, %v", missing, err)
+	}
+	if missing, err := missingSessionMetadata(context.Background(), "open", metadata); err != nil || missing != nil {
+		t.Errorf("project without settings: %v, %v", missing, err)
+	}
+}
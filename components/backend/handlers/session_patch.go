@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	contentTypeJSONPatch  = "application/json-patch+json"
+	contentTypeMergePatch = "application/merge-patch+json"
+
+	maxSessionPatchBytes = 64 << 10
+)
+
+// protectedSessionAnnotations are written by the backend and operator and cannot be patched by clients
+var protectedSessionAnnotations = map[string]bool{
+	creationSourceAnnotation:               true,
+	createdByAnnotation:                    true,
+	"ambient-code.io/runner-token-secret":  true,
+	"ambient-code.io/runner-sa":            true,
+	"vteam.ambient-code/parent-session-id": true,
+	userPromptAnnotation:                   true,
+}
+
+// jsonPatchOp is a single RFC 6902 operation
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+	From  string          `json:"from,omitempty"`
+}
+
+// PatchSession applies a partial update to a session without a read-modify-write cycle.
+// PATCH /api/projects/:projectName/agentic-sessions/:sessionName
+// Accepts a JSON Patch (application/json-patch+json) or a JSON Merge Patch
+// (application/merge-patch+json, or plain application/json). Only metadata.labels,
+// metadata.annotations, spec.displayName, spec.timeout and spec.resourceOverrides.priorityClass
+// may change; every other field is immutable through this endpoint.
+func PatchSession(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		c.Abort()
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSessionPatchBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	if len(body) > maxSessionPatchBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Patch is too large"})
+		return
+	}
+
+	var patchType ktypes.PatchType
+	switch c.ContentType() {
+	case contentTypeJSONPatch:
+		patchType = ktypes.JSONPatchType
+		err = validateSessionJSONPatch(body)
+	case contentTypeMergePatch, "application/json", "":
+		patchType = ktypes.MergePatchType
+		err = validateSessionMergePatch(body)
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("Content-Type must be %s or %s", contentTypeJSONPatch, contentTypeMergePatch)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	updated, err := reqDyn.Resource(gvr).Namespace(project).Patch(c.Request.Context(), sessionName, patchType, body, v1.PatchOptions{})
+	if err != nil {
+		switch {
+		case errors.IsNotFound(err):
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+		case errors.IsForbidden(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to update this session"})
+		case errors.IsConflict(err):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.IsInvalid(err), errors.IsBadRequest(err):
+			// Schema violations and failed JSON Patch "test" operations
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Failed to patch agentic session %s in project %s: %v", sessionName, project, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to patch session"})
+		}
+		return
+	}
+
+	session := types.AgenticSession{
+		APIVersion: updated.GetAPIVersion(),
+		Kind:       updated.GetKind(),
+		Metadata:   updated.Object["metadata"].(map[string]interface{}),
+	}
+	if spec, ok := updated.Object["spec"].(map[string]interface{}); ok {
+		session.Spec = parseSpec(spec)
+	}
+	if status, ok := updated.Object["status"].(map[string]interface{}); ok {
+		session.Status = parseStatus(status)
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// validateSessionJSONPatch checks that every operation targets a mutable field with a valid value.
+// move and copy are rejected because their source could be an immutable field.
+func validateSessionJSONPatch(body []byte) error {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return fmt.Errorf("invalid JSON Patch: %v", err)
+	}
+	if len(ops) == 0 {
+		return fmt.Errorf("patch contains no operations")
+	}
+	for i, op := range ops {
+		switch op.Op {
+		case "add", "replace", "test":
+			var value interface{}
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return fmt.Errorf("operation %d: value is required", i)
+			}
+			if err := validateSessionPatchPath(op.Path, value); err != nil {
+				return fmt.Errorf("operation %d: %v", i, err)
+			}
+		case "remove":
+			if err := validateSessionPatchPath(op.Path, nil); err != nil {
+				return fmt.Errorf("operation %d: %v", i, err)
+			}
+		default:
+			return fmt.Errorf("operation %d: unsupported op %q", i, op.Op)
+		}
+	}
+	return nil
+}
+
+// validateSessionPatchPath checks a JSON Pointer and the value written to it (nil for remove)
+func validateSessionPatchPath(path string, value interface{}) error {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, s := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+	}
+	field := strings.Join(segments, ".")
+
+	switch {
+	case len(segments) == 2 && segments[0] == "metadata" && (segments[1] == "labels" || segments[1] == "annotations"):
+		// Replacing the whole map would drop the platform's annotations, which only merge patch
+		// keeps; JSON Patch clients set keys one at a time
+		return fmt.Errorf("%s cannot be set or removed as a whole; patch %s/<key> instead", field, path)
+	case len(segments) == 3 && segments[0] == "metadata" && (segments[1] == "labels" || segments[1] == "annotations"):
+		return validateSessionMetadataMap(segments[1], map[string]interface{}{segments[2]: value})
+	case path == "/spec/displayName", path == "/spec/timeout", path == "/spec/resourceOverrides/priorityClass":
+		return validateSessionSpecValue(field, value)
+	}
+	return fmt.Errorf("%s is immutable", field)
+}
+
+// validateSessionMergePatch checks a JSON Merge Patch; null values delete a field.
+func validateSessionMergePatch(body []byte) error {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return fmt.Errorf("invalid merge patch: %v", err)
+	}
+	if len(patch) == 0 {
+		return fmt.Errorf("patch contains no changes")
+	}
+	for key, raw := range patch {
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is immutable", key)
+		}
+		for field, value := range obj {
+			path := key + "." + field
+			switch path {
+			case "metadata.labels", "metadata.annotations":
+				m, ok := value.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s must be an object", path)
+				}
+				if err := validateSessionMetadataMap(field, m); err != nil {
+					return err
+				}
+			case "spec.displayName", "spec.timeout":
+				if err := validateSessionSpecValue(path, value); err != nil {
+					return err
+				}
+			case "spec.resourceOverrides":
+				overrides, ok := value.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s must be an object", path)
+				}
+				for k, v := range overrides {
+					if k != "priorityClass" {
+						return fmt.Errorf("%s.%s is immutable", path, k)
+					}
+					if err := validateSessionSpecValue(path+"."+k, v); err != nil {
+						return err
+					}
+				}
+			default:
+				return fmt.Errorf("%s is immutable", path)
+			}
+		}
+	}
+	return nil
+}
+
+// validateSessionMetadataMap checks label or annotation values; nil removes a key
+func validateSessionMetadataMap(kind string, m map[string]interface{}) error {
+	for k, v := range m {
+		if kind == "annotations" && protectedSessionAnnotations[k] {
+			return fmt.Errorf("annotation %s is managed by the platform", k)
+		}
+		if v == nil {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("metadata.%s.%s must be a string", kind, k)
+		}
+		if kind == "labels" && len(s) > 63 {
+			return fmt.Errorf("metadata.labels.%s must be at most 63 characters", k)
+		}
+	}
+	return nil
+}
+
+// validateSessionSpecValue checks a value written to a mutable spec field; nil removes it
+func validateSessionSpecValue(field string, value interface{}) error {
+	if value == nil {
+		if field == "spec.timeout" {
+			return fmt.Errorf("spec.timeout cannot be removed")
+		}
+		return nil
+	}
+	switch field {
+	case "spec.timeout":
+		n, ok := value.(float64)
+		if !ok || n <= 0 || n != float64(int64(n)) {
+			return fmt.Errorf("spec.timeout must be a positive number of seconds")
+		}
+	default:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s must be a string", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import "testing"
+
+// TestValidateSessionJSONPatch verifies label and annotation keys can be patched one at a
+// time, but not the whole map, which would drop the platform's annotations
+func TestValidateSessionJSONPatch(t *testing.T) {
+	cases := []struct {
+		patch string
+		ok    bool
+	}{
+		{`[{"op":"add","path":"/metadata/labels/team","value":"core"}]`, true},
+		{`[{"op":"remove","path":"/metadata/annotations/note"}]`, true},
+		{`[{"op":"replace","path":"/spec/displayName","value":"Renamed"}]`, true},
+		{`[{"op":"replace","path":"/metadata/annotations","value":{}}]`, false},
+		{`[{"op":"add","path":"/metadata/labels","value":{"team":"core"}}]`, false},
+		{`[{"op":"remove","path":"/metadata/labels"}]`, false},
+		{`[{"op":"replace","path":"/metadata/annotations/ambient-code.io~1runner-sa","value":"x"}]`, false},
+	}
+	for _, tc := range cases {
+		if err := validateSessionJSONPatch([]byte(tc.patch)); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v, want ok %v", tc.patch, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/messages"
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sessionQuotaError is returned by checkSessionQuota when the project is at its limit
+type sessionQuotaError struct {
+	project string
+	limit   int64
+	active  int64
+	// queued counts active sessions still waiting for a runner slot
+	queued int64
+	// wait is the time until the first running session times out; nil when none will
+	wait *time.Duration
+}
+
+func (e *sessionQuotaError) Error() string {
+	return messages.Render(messages.DefaultLocale, "session.quotaExceeded", e.messageData())
+}
+
+func (e *sessionQuotaError) messageData() map[string]interface{} {
+	return map[string]interface{}{"Project": e.project, "Limit": e.limit}
+}
+
+// hint describes the rejection for the API response
+func (e *sessionQuotaError) hint() *types.SchedulingHint {
+	h := &types.SchedulingHint{Cause: rejectionCauseQuota, Used: e.active, Limit: e.limit, QueuePosition: e.queued + 1}
+	if e.wait != nil {
+		secs := int64(e.wait.Round(time.Second).Seconds())
+		h.EstimatedWaitSeconds = &secs
+	}
+	return h
+}
+
+// earliestTimeout returns how long until the first running session reaches spec.timeout.
+// Interactive sessions and sessions without a timeout may run indefinitely and are ignored.
+func earliestTimeout(sessions []apiv1alpha1.AgenticSession, now time.Time) *time.Duration {
+	left := sessionTimeouts(sessions, now)
+	if len(left) == 0 {
+		return nil
+	}
+	return &left[0]
+}
+
+// sessionTimeouts returns how long until each running session reaches spec.timeout, soonest
+// first, ignoring the same sessions as earliestTimeout
+func sessionTimeouts(sessions []apiv1alpha1.AgenticSession, now time.Time) []time.Duration {
+	var out []time.Duration
+	for i := range sessions {
+		s := &sessions[i]
+		if s.Status.Phase != apiv1alpha1.SessionPhaseRunning || s.Status.StartTime == nil || s.Spec.Interactive || s.Spec.Timeout <= 0 {
+			continue
+		}
+		left := s.Status.StartTime.Add(time.Duration(s.Spec.Timeout) * time.Second).Sub(now)
+		if left < 0 {
+			left = 0
+		}
+		out = append(out, left)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// isActiveSessionPhase reports whether a session in this phase counts toward the project quota
+func isActiveSessionPhase(phase apiv1alpha1.AgenticSessionPhase) bool {
+	switch phase {
+	case "", apiv1alpha1.SessionPhasePending, apiv1alpha1.SessionPhaseCreating, apiv1alpha1.SessionPhaseRunning:
+		return true
+	}
+	return false
+}
+
+// checkSessionQuota enforces ProjectSettings spec.maxActiveSessions. Every AgenticSession in the
+// namespace counts, whether it was created through the API or applied directly with kubectl.
+// exclude names a session that should not count against itself (used when adopting).
+// A missing ProjectSettings object or an unset limit means no quota.
+func checkSessionQuota(ctx context.Context, project string, exclude string) error {
+	if VteamClient == nil {
+		return nil
+	}
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read project settings: %w", err)
+	}
+	limit := int64(ps.Spec.MaxActiveSessions)
+	if limit <= 0 {
+		return nil
+	}
+
+	list, err := VteamClient.VteamV1alpha1().AgenticSessions(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	var active, queued int64
+	activeItems := make([]apiv1alpha1.AgenticSession, 0, len(list.Items))
+	for _, item := range list.Items {
+		if item.Name == exclude || item.DeletionTimestamp != nil {
+			continue
+		}
+		if isActiveSessionPhase(item.Status.Phase) {
+			active++
+			activeItems = append(activeItems, item)
+			if meta.IsStatusConditionTrue(item.Status.Conditions, "Queued") {
+				queued++
+			}
+		}
+	}
+	if active >= limit {
+		return &sessionQuotaError{project: project, limit: limit, active: active, queued: queued, wait: earliestTimeout(activeItems, time.Now())}
+	}
+	return nil
+}
+
+// quotaRejection returns the 429 body with the quota's scheduling hint, and sets Retry-After
+// when a slot is expected to free up
+func quotaRejection(c *gin.Context, quotaErr *sessionQuotaError) gin.H {
+	recordSessionRejection(rejectionCauseQuota)
+	hint := quotaErr.hint()
+	if hint.EstimatedWaitSeconds != nil && *hint.EstimatedWaitSeconds > 0 {
+		c.Header("Retry-After", strconv.FormatInt(*hint.EstimatedWaitSeconds, 10))
+	}
+	body := localizedError(c, "session.quotaExceeded", quotaErr.messageData())
+	body["scheduling"] = hint
+	return body
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func quotaTestSession(name string, phase apiv1alpha1.AgenticSessionPhase, started time.Time, timeout int, queued bool) *apiv1alpha1.AgenticSession {
+	s := &apiv1alpha1.AgenticSession{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "team-a"},
+		Spec:       apiv1alpha1.AgenticSessionSpec{Timeout: timeout},
+		Status:     apiv1alpha1.AgenticSessionStatus{Phase: phase},
+	}
+	if !started.IsZero() {
+		s.Status.StartTime = &v1.Time{Time: started}
+	}
+	if queued {
+		s.Status.Conditions = []v1.Condition{{Type: "Queued", Status: v1.ConditionTrue, Reason: "MaxConcurrentJobs"}}
+	}
+	return s
+}
+
+// TestCheckSessionQuota_Hint verifies a rejection reports usage, queue position and the earliest timeout
+func TestCheckSessionQuota_Hint(t *testing.T) {
+	now := time.Now()
+	VteamClient = vteamfake.NewSimpleClientset(
+		quotaTestSession("long", apiv1alpha1.SessionPhaseRunning, now.Add(-10*time.Minute), 3600, false),
+		quotaTestSession("short", apiv1alpha1.SessionPhaseRunning, now.Add(-55*time.Minute), 3600, false),
+		quotaTestSession("waiting", apiv1alpha1.SessionPhasePending, time.Time{}, 3600, true),
+		quotaTestSession("done", apiv1alpha1.SessionPhaseCompleted, now.Add(-2*time.Hour), 3600, false),
+	)
+	// ProjectSettings is created through the client: the fake tracker's guessed plural
+	// ("projectsettingses") does not match the one the typed client uses
+	if _, err := VteamClient.VteamV1alpha1().ProjectSettings("team-a").Create(context.Background(), &apiv1alpha1.ProjectSettings{
+		ObjectMeta: v1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "team-a"},
+		Spec:       apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 3},
+	}, v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { VteamClient = nil }()
+
+	err := checkSessionQuota(context.Background(), "team-a", "")
+	quotaErr, ok := err.(*sessionQuotaError)
+	if !ok {
+		t.Fatalf("expected a quota error, got %v", err)
+	}
+	hint := quotaErr.hint()
+	if hint.Cause != rejectionCauseQuota || hint.Used != 3 || hint.Limit != 3 || hint.QueuePosition != 2 {
+		t.Errorf("hint = %+v", hint)
+	}
+	// "short" times out in about 5 minutes
+	if hint.EstimatedWaitSeconds == nil || *hint.EstimatedWaitSeconds < 290 || *hint.EstimatedWaitSeconds > 300 {
+		t.Errorf("estimated wait = %v, want about 300s", hint.EstimatedWaitSeconds)
+	}
+
+	// The session being adopted does not count against itself
+	if err := checkSessionQuota(context.Background(), "team-a", "waiting"); err != nil {
+		t.Errorf("expected no error when excluding a session, got %v", err)
+	}
+}
+
+func TestEarliestTimeout_IgnoresOpenEndedSessions(t *testing.T) {
+	now := time.Now()
+	interactive := quotaTestSession("chat", apiv1alpha1.SessionPhaseRunning, now, 3600, false)
+	interactive.Spec.Interactive = true
+	sessions := []apiv1alpha1.AgenticSession{
+		*interactive,
+		*quotaTestSession("no-timeout", apiv1alpha1.SessionPhaseRunning, now, 0, false),
+		*quotaTestSession("not-started", apiv1alpha1.SessionPhasePending, time.Time{}, 60, false),
+	}
+	if got := earliestTimeout(sessions, now); got != nil {
+		t.Errorf("expected no estimate, got %v", *got)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// redactedSecretValue replaces injected secret values in workspace files served to the UI
+const redactedSecretValue = "[REDACTED]"
+
+// minRedactedSecretLength skips values too short to redact without mangling unrelated text
+const minRedactedSecretLength = 6
+
+// validateSessionSecrets normalizes spec.envFromSecrets and checks every Secret against the
+// project's allowedSessionSecrets. The allowlist is read with the backend service account so
+// callers cannot widen it by lacking read access; on failure the status and body to respond
+// with are returned.
+func validateSessionSecrets(c *gin.Context, project string, sources []apiv1alpha1.SecretEnvSource) ([]apiv1alpha1.SecretEnvSource, int, gin.H) {
+	if len(sources) == 0 {
+		return nil, 0, nil
+	}
+	seen := map[string]bool{}
+	out := make([]apiv1alpha1.SecretEnvSource, 0, len(sources))
+	for i, src := range sources {
+		name := strings.TrimSpace(src.Name)
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, http.StatusBadRequest, gin.H{"error": fmt.Sprintf("envFromSecrets[%d].name: %s", i, strings.Join(errs, "; "))}
+		}
+		if seen[name] {
+			return nil, http.StatusBadRequest, gin.H{"error": fmt.Sprintf("envFromSecrets[%d].name: secret %q listed twice", i, name)}
+		}
+		seen[name] = true
+		for _, key := range src.Keys {
+			if errs := validation.IsEnvVarName(key); len(errs) > 0 {
+				return nil, http.StatusBadRequest, gin.H{"error": fmt.Sprintf("envFromSecrets[%d].keys: %q: %s", i, key, strings.Join(errs, "; "))}
+			}
+		}
+		out = append(out, apiv1alpha1.SecretEnvSource{Name: name, Keys: src.Keys})
+	}
+
+	if VteamClient == nil {
+		return nil, http.StatusInternalServerError, gin.H{"error": "project settings client not initialized"}
+	}
+	spec := &apiv1alpha1.ProjectSettingsSpec{}
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(c.Request.Context(), apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err == nil {
+		spec = &ps.Spec
+	} else if !errors.IsNotFound(err) {
+		log.Printf("validateSessionSecrets: failed to read ProjectSettings for %s: %v", project, err)
+		return nil, http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"}
+	}
+	for i, src := range out {
+		if !spec.SessionSecretAllowed(src.Name) {
+			return nil, http.StatusForbidden, gin.H{"error": fmt.Sprintf("envFromSecrets[%d]: secret %q is not in the project's allowedSessionSecrets", i, src.Name)}
+		}
+	}
+	return out, 0, nil
+}
+
+// sessionSecretValues returns the values a session injects through spec.envFromSecrets,
+// longest first so overlapping values are redacted whole
+func sessionSecretValues(ctx context.Context, project, session string) ([]string, error) {
+	if VteamClient == nil || K8sClient == nil {
+		return nil, fmt.Errorf("backend clients not initialized")
+	}
+	obj, err := VteamClient.VteamV1alpha1().AgenticSessions(project).Get(ctx, session, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	values := []string{}
+	for _, src := range obj.Spec.EnvFromSecrets {
+		secret, err := K8sClient.CoreV1().Secrets(project).Get(ctx, src.Name, v1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		keys := src.Keys
+		if len(keys) == 0 {
+			for k := range secret.Data {
+				keys = append(keys, k)
+			}
+		}
+		for _, k := range keys {
+			if v := strings.TrimSpace(string(secret.Data[k])); len(v) >= minRedactedSecretLength {
+				values = append(values, v)
+			}
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	return values, nil
+}
+
+// sessionInjectsSecrets reports whether the session sets spec.envFromSecrets, for workspace
+// downloads that cannot be redacted and are refused instead
+func sessionInjectsSecrets(ctx context.Context, project, session string) (bool, error) {
+	if VteamClient == nil {
+		return false, fmt.Errorf("backend clients not initialized")
+	}
+	obj, err := VteamClient.VteamV1alpha1().AgenticSessions(project).Get(ctx, session, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(obj.Spec.EnvFromSecrets) > 0, nil
+}
+
+// redactSessionSecrets masks injected secret values in content read from the session's
+// workspace. When the values cannot be read the content is withheld rather than leaked.
+func redactSessionSecrets(ctx context.Context, project, session string, content []byte) ([]byte, error) {
+	values, err := sessionSecretValues(ctx, project, session)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		content = bytes.ReplaceAll(content, []byte(v), []byte(redactedSecretValue))
+	}
+	return content, nil
+}
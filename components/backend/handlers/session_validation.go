@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// The spec rules live in pkg/validation so the operator's admission webhook rejects exactly
+// what the API rejects. Here they are applied to the spec a handler is about to write and to
+// externally created sessions the informer adopts (clusters without the webhook).
+
+// sessionSpecErrors decodes spec into the typed spec and applies the shared rules. old is the
+// stored spec on updates, nil on creates; an unchanged spec passes.
+func sessionSpecErrors(spec, old map[string]interface{}) field.ErrorList {
+	path := field.NewPath("spec")
+	newSpec, err := typedSessionSpec(spec)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, nil, err.Error())}
+	}
+	if old == nil {
+		return validation.ValidateAgenticSessionSpec(newSpec, path)
+	}
+	oldSpec, err := typedSessionSpec(old)
+	if err != nil {
+		return validation.ValidateAgenticSessionSpec(newSpec, path)
+	}
+	return validation.ValidateAgenticSessionSpecUpdate(newSpec, oldSpec, path)
+}
+
+func typedSessionSpec(spec map[string]interface{}) (*apiv1alpha1.AgenticSessionSpec, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	out := &apiv1alpha1.AgenticSessionSpec{}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// respondInvalidSessionSpec writes a 400 listing every failing field
+func respondInvalidSessionSpec(c *gin.Context, errs field.ErrorList) {
+	c.JSON(http.StatusBadRequest, invalidSessionSpecBody(c, errs))
+}
+
+// invalidSessionSpecBody is the 400 body listing every failing field
+func invalidSessionSpecBody(c *gin.Context, errs field.ErrorList) gin.H {
+	fields := make([]types.SpecFieldError, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, types.SpecFieldError{Field: e.Field, Type: string(e.Type), Detail: e.Detail})
+	}
+	body := localizedError(c, "session.invalidSpec", gin.H{"Details": errs.ToAggregate().Error()})
+	body["fieldErrors"] = fields
+	return body
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSessionSpecErrors verifies the shared rules reject an invalid spec with field paths, an
+// unchanged stored spec passes on update, and the 400 lists every failing field
+func TestSessionSpecErrors(t *testing.T) {
+	spec := map[string]interface{}{
+		"prompt":       "hi",
+		"timeout":      300,
+		"sessionGroup": "Bad_Group",
+		"costLimit":    map[string]interface{}{"usd": -1.0},
+	}
+	errs := sessionSpecErrors(spec, nil)
+	if len(errs) != 2 {
+		t.Fatalf("errors = %v, want costLimit and sessionGroup", errs)
+	}
+	if validateSessionSpec(spec) == nil {
+		t.Error("informer validation accepted the spec")
+	}
+	if errs := sessionSpecErrors(spec, spec); len(errs) != 0 {
+		t.Errorf("unchanged spec rejected on update: %v", errs)
+	}
+	if errs := sessionSpecErrors(map[string]interface{}{"timeout": "soon"}, nil); len(errs) != 1 || errs[0].Field != "spec" {
+		t.Errorf("undecodable spec errors = %v", errs)
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	respondInvalidSessionSpec(c, errs)
+	var body struct {
+		FieldErrors []struct{ Field, Type string } `json:"fieldErrors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusBadRequest {
+		t.Fatalf("response %d: %s", w.Code, w.Body.String())
+	}
+	if len(body.FieldErrors) != 2 || body.FieldErrors[0].Field != "spec.costLimit.usd" || body.FieldErrors[1].Type != "FieldValueInvalid" {
+		t.Errorf("fieldErrors = %+v", body.FieldErrors)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// GET /agentic-sessions/:sessionName?waitForPhase=Completed&timeoutSeconds=300 long-polls:
+// the response is held until the session reaches one of the phases, or any terminal phase it
+// cannot leave on its own, or the timeout expires. The body is the session either way;
+// X-Wait-Result says which: "reached", "terminal" or "timeout".
+
+const (
+	defaultSessionWaitSeconds = 300
+	// maxSessionWaitSeconds keeps held requests below common proxy and router timeouts
+	maxSessionWaitSeconds = 600
+)
+
+// sessionWaitRequest is the parsed long-poll query, nil for a plain GET
+type sessionWaitRequest struct {
+	phases  map[string]bool
+	timeout time.Duration
+}
+
+// parseSessionWait reads waitForPhase (comma-separated phases) and timeoutSeconds
+func parseSessionWait(c *gin.Context) (*sessionWaitRequest, error) {
+	raw := strings.TrimSpace(c.Query("waitForPhase"))
+	if raw == "" {
+		if c.Query("timeoutSeconds") != "" {
+			return nil, fmt.Errorf("timeoutSeconds requires waitForPhase")
+		}
+		return nil, nil
+	}
+	req := &sessionWaitRequest{phases: map[string]bool{}, timeout: defaultSessionWaitSeconds * time.Second}
+	for _, p := range strings.Split(raw, ",") {
+		switch phase := apiv1alpha1.AgenticSessionPhase(strings.TrimSpace(p)); phase {
+		case apiv1alpha1.SessionPhasePending, apiv1alpha1.SessionPhaseCreating, apiv1alpha1.SessionPhaseRunning,
+			apiv1alpha1.SessionPhaseCompleted, apiv1alpha1.SessionPhaseFailed, apiv1alpha1.SessionPhaseStopped,
+			apiv1alpha1.SessionPhaseError:
+			req.phases[string(phase)] = true
+		default:
+			return nil, fmt.Errorf("unknown phase %q in waitForPhase", p)
+		}
+	}
+	if s := c.Query("timeoutSeconds"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > maxSessionWaitSeconds {
+			return nil, fmt.Errorf("timeoutSeconds must be between 0 and %d", maxSessionWaitSeconds)
+		}
+		req.timeout = time.Duration(n) * time.Second
+	}
+	return req, nil
+}
+
+// result reports whether the wait is over for the session and why
+func (w *sessionWaitRequest) result(obj *unstructured.Unstructured) (string, bool) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	if w.phases[phase] {
+		return "reached", true
+	}
+	if apiv1alpha1.AgenticSessionPhase(phase).IsTerminal() {
+		return "terminal", true
+	}
+	return "", false
+}
+
+// waitForSession watches the session with the caller's client until the wait is over. It
+// returns the latest version of the session and the wait result; a session deleted while
+// waiting is reported as a NotFound error.
+func waitForSession(ctx context.Context, reqDyn dynamic.Interface, project string, item *unstructured.Unstructured, req *sessionWaitRequest) (*unstructured.Unstructured, string, error) {
+	if result, done := req.result(item); done {
+		return item, result, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, req.timeout)
+	defer cancel()
+
+	res := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project)
+	for {
+		w, err := res.Watch(ctx, v1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", item.GetName()).String(),
+			ResourceVersion: item.GetResourceVersion(),
+		})
+		result, done := "", false
+		if err == nil {
+			result, done, err = drainSessionWait(ctx, w, &item, req)
+			w.Stop()
+		}
+		if ctx.Err() != nil && !done {
+			return item, "timeout", nil
+		}
+		if err != nil {
+			if !errors.IsResourceExpired(err) && !errors.IsGone(err) {
+				return nil, "", err
+			}
+			// Missed too much history: start over from the current version
+			latest, err := res.Get(ctx, item.GetName(), v1.GetOptions{})
+			if err != nil {
+				if ctx.Err() != nil {
+					return item, "timeout", nil
+				}
+				return nil, "", err
+			}
+			item = latest
+			result, done = req.result(item)
+		}
+		if done {
+			return item, result, nil
+		}
+		// The API server closed the watch early; resume from the last version seen
+	}
+}
+
+// drainSessionWait consumes one watch until the wait is over, the watch closes or ctx ends
+func drainSessionWait(ctx context.Context, w watch.Interface, item **unstructured.Unstructured, req *sessionWaitRequest) (string, bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return "", false, nil
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return "", false, nil
+			}
+			switch ev.Type {
+			case watch.Deleted:
+				return "", false, errors.NewNotFound(GetAgenticSessionV1Alpha1Resource().GroupResource(), (*item).GetName())
+			case watch.Added, watch.Modified:
+				obj, ok := ev.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				*item = obj
+				if result, done := req.result(obj); done {
+					return result, true, nil
+				}
+			case watch.Error:
+				// Usually 410 Expired, which the caller recovers from by reading the session again
+				return "", false, errors.FromObject(ev.Object)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func waitTestSession(phase, rv string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": "team-a", "resourceVersion": rv},
+	}}
+	if phase != "" {
+		obj.Object["status"] = map[string]interface{}{"phase": phase}
+	}
+	return obj
+}
+
+// fakeSessionWatch serves watches of AgenticSessions from a FakeWatcher the test drives
+func fakeSessionWatch(t *testing.T) (*dynamicfake.FakeDynamicClient, *watch.FakeWatcher) {
+	t.Helper()
+	gvr := schema.GroupVersionResource{Group: "vteam.ambient-code", Version: "v1alpha1", Resource: "agenticsessions"}
+	GetAgenticSessionV1Alpha1Resource = func() schema.GroupVersionResource { return gvr }
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "AgenticSessionList"})
+	fw := watch.NewFake()
+	client.PrependWatchReactor("agenticsessions", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, fw, nil
+	})
+	return client, fw
+}
+
+func TestParseSessionWait(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		query   string
+		wantNil bool
+		wantErr bool
+	}{
+		{"", true, false},
+		{"?waitForPhase=Completed", false, false},
+		{"?waitForPhase=Completed,Failed&timeoutSeconds=30", false, false},
+		{"?waitForPhase=Done", false, true},
+		{"?waitForPhase=Completed&timeoutSeconds=-1", false, true},
+		{"?waitForPhase=Completed&timeoutSeconds=601", false, true},
+		{"?timeoutSeconds=30", false, true},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/projects/team-a/agentic-sessions/s1"+tc.query, nil)
+		req, err := parseSessionWait(c)
+		if (err != nil) != tc.wantErr || (req == nil) != (tc.wantNil || tc.wantErr) {
+			t.Errorf("%q: req=%v err=%v", tc.query, req, err)
+		}
+	}
+}
+
+func TestWaitForSession_Reached(t *testing.T) {
+	client, fw := fakeSessionWatch(t)
+	req := &sessionWaitRequest{phases: map[string]bool{"Completed": true}, timeout: 5 * time.Second}
+	go func() {
+		fw.Modify(waitTestSession("Running", "2"))
+		fw.Modify(waitTestSession("Completed", "3"))
+	}()
+	item, result, err := waitForSession(context.Background(), client, "team-a", waitTestSession("Pending", "1"), req)
+	if err != nil || result != "reached" || item.GetResourceVersion() != "3" {
+		t.Fatalf("result=%q err=%v rv=%s", result, err, item.GetResourceVersion())
+	}
+}
+
+func TestWaitForSession_StopsOnOtherTerminalPhase(t *testing.T) {
+	client, fw := fakeSessionWatch(t)
+	req := &sessionWaitRequest{phases: map[string]bool{"Completed": true}, timeout: 5 * time.Second}
+	go fw.Modify(waitTestSession("Failed", "2"))
+	if _, result, err := waitForSession(context.Background(), client, "team-a", waitTestSession("Running", "1"), req); err != nil || result != "terminal" {
+		t.Fatalf("result=%q err=%v", result, err)
+	}
+}
+
+func TestWaitForSession_Timeout(t *testing.T) {
+	client, _ := fakeSessionWatch(t)
+	req := &sessionWaitRequest{phases: map[string]bool{"Completed": true}, timeout: 50 * time.Millisecond}
+	item, result, err := waitForSession(context.Background(), client, "team-a", waitTestSession("Running", "1"), req)
+	if err != nil || result != "timeout" || item.GetResourceVersion() != "1" {
+		t.Fatalf("result=%q err=%v", result, err)
+	}
+}
+
+func TestWaitForSession_Deleted(t *testing.T) {
+	client, fw := fakeSessionWatch(t)
+	req := &sessionWaitRequest{phases: map[string]bool{"Completed": true}, timeout: 5 * time.Second}
+	go fw.Delete(waitTestSession("Running", "2"))
+	if _, _, err := waitForSession(context.Background(), client, "team-a", waitTestSession("Running", "1"), req); !errors.IsNotFound(err) {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestWaitForSession_AlreadyReached(t *testing.T) {
+	req := &sessionWaitRequest{phases: map[string]bool{"Running": true}, timeout: time.Second}
+	// No client: a session already in the phase must not start a watch
+	if _, result, err := waitForSession(context.Background(), nil, "team-a", waitTestSession("Running", "1"), req); err != nil || result != "reached" {
+		t.Fatalf("result=%q err=%v", result, err)
+	}
+}
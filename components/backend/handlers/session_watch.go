@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// sessionWatchEvent is one change in a session watch stream, shaped like a Kubernetes watch
+// event: ADDED, MODIFIED and DELETED carry the session, BOOKMARK only a resourceVersion,
+// and ERROR a Status (code 410 means the resourceVersion expired and the client must relist).
+type sessionWatchEvent struct {
+	Type   string      `json:"type"`
+	Object interface{} `json:"object"`
+}
+
+// WatchSessions handles GET /api/projects/:projectName/agentic-sessions?watch=true
+//
+// It follows Kubernetes watch semantics. Clients list first, then watch from the list's
+// resourceVersion so no change between the two is missed; without a resourceVersion the
+// current sessions are sent as ADDED events first. Bookmarks carry the latest
+// resourceVersion to resume from. An expired resourceVersion is answered with 410 Gone (or an
+// ERROR event once streaming), after which the client relists.
+//
+// Events are newline-delimited JSON, or Server-Sent Events with Accept: text/event-stream.
+// An SSE reconnect resumes from the Last-Event-ID header.
+func WatchSessions(c *gin.Context) {
+	project := c.GetString("project")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.userTokenRequired", nil)
+		return
+	}
+
+	sse := strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+	resourceVersion := c.Query("resourceVersion")
+	if sse && c.GetHeader("Last-Event-ID") != "" {
+		resourceVersion = c.GetHeader("Last-Event-ID")
+	}
+
+	w, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Watch(c.Request.Context(), v1.ListOptions{
+		ResourceVersion:     resourceVersion,
+		AllowWatchBookmarks: true,
+	})
+	if err != nil {
+		switch {
+		case errors.IsResourceExpired(err) || errors.IsGone(err):
+			c.JSON(http.StatusGone, gin.H{"error": fmt.Sprintf("resourceVersion %s is too old; list the sessions again and watch from the list's resourceVersion", resourceVersion), "reason": "Expired"})
+		case errors.IsForbidden(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to watch sessions in this project"})
+		case errors.IsBadRequest(err):
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid resourceVersion %q", resourceVersion)})
+		default:
+			log.Printf("Failed to watch agentic sessions in project %s: %v", project, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to watch agentic sessions"})
+		}
+		return
+	}
+	defer w.Stop()
+
+	if sse {
+		c.Header("Content-Type", "text/event-stream")
+	} else {
+		c.Header("Content-Type", "application/json")
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				// The API server ended the watch (its timeout); the client resumes from the last event
+				return
+			}
+			out, rv := sessionWatchEventFor(ev)
+			if out == nil {
+				continue
+			}
+			data, err := json.Marshal(out)
+			if err != nil {
+				log.Printf("Failed to encode session watch event in project %s: %v", project, err)
+				continue
+			}
+			if sse {
+				if rv != "" {
+					fmt.Fprintf(c.Writer, "id: %s\n", rv)
+				}
+				fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", out.Type, data)
+			} else {
+				c.Writer.Write(append(data, '\n'))
+			}
+			c.Writer.Flush()
+			if ev.Type == watch.Error {
+				return
+			}
+		}
+	}
+}
+
+// sessionWatchEventFor converts an API server watch event and returns the resourceVersion
+// it brings the client to
+func sessionWatchEventFor(ev watch.Event) (*sessionWatchEvent, string) {
+	switch ev.Type {
+	case watch.Error:
+		status := errors.FromObject(ev.Object)
+		if se, ok := status.(*errors.StatusError); ok {
+			return &sessionWatchEvent{Type: string(ev.Type), Object: se.ErrStatus}, ""
+		}
+		return &sessionWatchEvent{Type: string(ev.Type), Object: v1.Status{Status: v1.StatusFailure, Message: status.Error()}}, ""
+	case watch.Bookmark:
+		obj, ok := ev.Object.(*unstructured.Unstructured)
+		if !ok {
+			return nil, ""
+		}
+		rv := obj.GetResourceVersion()
+		return &sessionWatchEvent{Type: string(ev.Type), Object: gin.H{"metadata": gin.H{"resourceVersion": rv}}}, rv
+	case watch.Added, watch.Modified, watch.Deleted:
+		obj, ok := ev.Object.(*unstructured.Unstructured)
+		if !ok {
+			return nil, ""
+		}
+		session := types.AgenticSession{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Metadata:   obj.Object["metadata"].(map[string]interface{}),
+		}
+		if spec, ok := obj.Object["spec"].(map[string]interface{}); ok {
+			session.Spec = parseSpec(spec)
+		}
+		if status, ok := obj.Object["status"].(map[string]interface{}); ok {
+			session.Status = parseStatus(status)
+		}
+		return &sessionWatchEvent{Type: string(ev.Type), Object: session}, obj.GetResourceVersion()
+	}
+	return nil, ""
+}
@@ -11,21 +11,26 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"ambient-code-backend/breaker"
 	"ambient-code-backend/git"
 	"ambient-code-backend/types"
 
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/client/clientset/versioned"
+	"ambient-code-pkg/gitutil"
+	"ambient-code-pkg/redact"
+	"ambient-code-pkg/validation"
 	"github.com/gin-gonic/gin"
-	authnv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
-	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	ktypes "k8s.io/apimachinery/pkg/types"
 	intstr "k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -35,13 +40,20 @@ import (
 var (
 	GetAgenticSessionV1Alpha1Resource func() schema.GroupVersionResource
 	DynamicClient                     dynamic.Interface
+	VteamClient                       versioned.Interface
 	GetGitHubToken                    func(context.Context, *kubernetes.Clientset, dynamic.Interface, string, string) (string, error)
 	DeriveRepoFolderFromURL           func(string) string
 	SendMessageToSession              func(string, string, map[string]interface{})
 )
 
-// parseSpec parses AgenticSessionSpec with v1alpha1 fields
+// parseSpec parses AgenticSessionSpec with v1alpha1 fields for API responses, with
+// credentials (tokens in repository URLs, pasted keys) masked
 func parseSpec(spec map[string]interface{}) types.AgenticSessionSpec {
+	return decodeSpec(redact.Map(spec))
+}
+
+// decodeSpec parses AgenticSessionSpec as stored. Only the session's own runner may see it.
+func decodeSpec(spec map[string]interface{}) types.AgenticSessionSpec {
 	result := types.AgenticSessionSpec{}
 
 	if prompt, ok := spec["prompt"].(string); ok {
@@ -52,6 +64,10 @@ func parseSpec(spec map[string]interface{}) types.AgenticSessionSpec {
 		result.Interactive = interactive
 	}
 
+	if preemptible, ok := spec["preemptible"].(bool); ok {
+		result.Preemptible = preemptible
+	}
+
 	if displayName, ok := spec["displayName"].(string); ok {
 		result.DisplayName = displayName
 	}
@@ -64,6 +80,65 @@ func parseSpec(spec map[string]interface{}) types.AgenticSessionSpec {
 		result.Timeout = int(timeout)
 	}
 
+	if costLimit, ok := spec["costLimit"].(map[string]interface{}); ok {
+		result.CostLimit = &apiv1alpha1.CostLimit{}
+		switch usd := costLimit["usd"].(type) {
+		case float64:
+			result.CostLimit.USD = usd
+		case int64:
+			result.CostLimit.USD = float64(usd)
+		}
+		switch tokens := costLimit["tokens"].(type) {
+		case int64:
+			result.CostLimit.Tokens = tokens
+		case float64:
+			result.CostLimit.Tokens = int64(tokens)
+		}
+	}
+
+	if workspaceFrom, ok := spec["workspaceFrom"].(string); ok {
+		result.WorkspaceFrom = workspaceFrom
+	}
+
+	if sessionGroup, ok := spec["sessionGroup"].(string); ok {
+		result.SessionGroup = sessionGroup
+	}
+
+	if parentSession, ok := spec["parentSession"].(string); ok {
+		result.ParentSession = parentSession
+	}
+
+	if hw, ok := spec["hardware"].(map[string]interface{}); ok {
+		result.Hardware = &apiv1alpha1.RunnerHardware{}
+		result.Hardware.RuntimeClassName, _ = hw["runtimeClassName"].(string)
+		result.Hardware.Architecture, _ = hw["architecture"].(string)
+		switch gpus := hw["gpus"].(type) {
+		case int64:
+			result.Hardware.GPUs = gpus
+		case float64:
+			result.Hardware.GPUs = int64(gpus)
+		}
+	}
+
+	if envFromSecrets, ok := spec["envFromSecrets"].([]interface{}); ok {
+		for _, item := range envFromSecrets {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			src := apiv1alpha1.SecretEnvSource{}
+			src.Name, _ = m["name"].(string)
+			if keys, ok := m["keys"].([]interface{}); ok {
+				for _, k := range keys {
+					if ks, ok := k.(string); ok {
+						src.Keys = append(src.Keys, ks)
+					}
+				}
+			}
+			result.EnvFromSecrets = append(result.EnvFromSecrets, src)
+		}
+	}
+
 	if llmSettings, ok := spec["llmSettings"].(map[string]interface{}); ok {
 		if model, ok := llmSettings["model"].(string); ok {
 			result.LLMSettings.Model = model
@@ -74,6 +149,12 @@ func parseSpec(spec map[string]interface{}) types.AgenticSessionSpec {
 		if maxTokens, ok := llmSettings["maxTokens"].(float64); ok {
 			result.LLMSettings.MaxTokens = int(maxTokens)
 		}
+		if strategy, ok := llmSettings["contextStrategy"].(string); ok {
+			result.LLMSettings.ContextStrategy = strategy
+		}
+		if maxContext, ok := llmSettings["maxContextTokens"].(float64); ok {
+			result.LLMSettings.MaxContextTokens = int(maxContext)
+		}
 	}
 
 	// environmentVariables passthrough
@@ -197,6 +278,7 @@ func parseSpec(spec map[string]interface{}) types.AgenticSessionSpec {
 // parseStatus parses AgenticSessionStatus with v1alpha1 fields
 func parseStatus(status map[string]interface{}) *types.AgenticSessionStatus {
 	result := &types.AgenticSessionStatus{}
+	status = redact.Map(status)
 
 	if phase, ok := status["phase"].(string); ok {
 		result.Phase = phase
@@ -238,35 +320,142 @@ func parseStatus(status map[string]interface{}) *types.AgenticSessionStatus {
 	if usage, ok := status["usage"].(map[string]interface{}); ok {
 		result.Usage = usage
 	}
-	if res, ok := status["result"].(string); ok {
-		result.Result = &res
+	if res, err := apiv1alpha1.ParseSessionResult(status["result"], result.IsError); err != nil {
+		log.Printf("Ignoring malformed session result: %v", err)
+	} else {
+		result.Result = res
 	}
 
 	if stateDir, ok := status["stateDir"].(string); ok {
 		result.StateDir = stateDir
 	}
 
+	switch v := status["preemptionRetries"].(type) {
+	case int64:
+		result.PreemptionRetries = int(v)
+	case float64:
+		result.PreemptionRetries = int(v)
+	}
+	switch v := status["runAttempt"].(type) {
+	case int64:
+		result.RunAttempt = int(v)
+	case float64:
+		result.RunAttempt = int(v)
+	}
+
+	if arts, ok := status["artifacts"].([]interface{}); ok && len(arts) > 0 {
+		if b, err := json.Marshal(arts); err == nil {
+			if err := json.Unmarshal(b, &result.Artifacts); err != nil {
+				log.Printf("Ignoring malformed artifact status: %v", err)
+			}
+		}
+	}
+
+	if steps, ok := status["steps"].([]interface{}); ok && len(steps) > 0 {
+		if b, err := json.Marshal(steps); err == nil {
+			if err := json.Unmarshal(b, &result.Steps); err != nil {
+				log.Printf("Ignoring malformed step status: %v", err)
+			}
+		}
+	}
+	switch v := status["progress"].(type) {
+	case int64:
+		result.Progress = int(v)
+	case float64:
+		result.Progress = int(v)
+	}
+
+	if enc, ok := status["encryption"].(map[string]interface{}); ok {
+		result.Encryption = &apiv1alpha1.SessionEncryption{}
+		result.Encryption.StorageClassName, _ = enc["storageClassName"].(string)
+	}
+
+	if rl, ok := status["rateLimit"].(map[string]interface{}); ok {
+		result.RateLimit = &apiv1alpha1.RateLimitStatus{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rl, result.RateLimit); err != nil {
+			log.Printf("Ignoring malformed rate limit status: %v", err)
+			result.RateLimit = nil
+		}
+	}
+
 	return result
 }
 
+// validateRateLimitReport checks a runner's status.rateLimit update against the typed schema
+func validateRateLimitReport(raw interface{}) error {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("invalid rateLimit: %v", err)
+	}
+	var rl apiv1alpha1.RateLimitStatus
+	if err := json.Unmarshal(b, &rl); err != nil {
+		return fmt.Errorf("invalid rateLimit: %v", err)
+	}
+	return rl.Validate()
+}
+
+// validateLLMSettings checks the context window settings of a request; nil is valid
+func validateLLMSettings(s *types.LLMSettings) error {
+	if s == nil {
+		return nil
+	}
+	return (&apiv1alpha1.LLMSettings{ContextStrategy: s.ContextStrategy, MaxContextTokens: s.MaxContextTokens}).Validate()
+}
+
+// llmSettingsSpec is spec.llmSettings of a new session; the context window fields are only
+// set when requested
+func llmSettingsSpec(s types.LLMSettings) map[string]interface{} {
+	spec := map[string]interface{}{
+		"model":       s.Model,
+		"temperature": s.Temperature,
+		"maxTokens":   s.MaxTokens,
+	}
+	if s.ContextStrategy != "" {
+		spec["contextStrategy"] = s.ContextStrategy
+	}
+	if s.MaxContextTokens != 0 {
+		spec["maxContextTokens"] = s.MaxContextTokens
+	}
+	return spec
+}
+
 // V2 API Handlers - Multi-tenant session management
 
 func ListSessions(c *gin.Context) {
+	if c.Query("watch") == "true" || c.Query("watch") == "1" {
+		WatchSessions(c)
+		return
+	}
 	project := c.GetString("project")
 	reqK8s, reqDyn := GetK8sClientsForRequest(c)
 	_ = reqK8s
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
-	list, err := reqDyn.Resource(gvr).Namespace(project).List(context.TODO(), v1.ListOptions{})
+	list, err := reqDyn.Resource(gvr).Namespace(project).List(c.Request.Context(), v1.ListOptions{})
 	if err != nil {
 		log.Printf("Failed to list agentic sessions in project %s: %v", project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list agentic sessions"})
 		return
 	}
+	// ?thread=<session> lists the thread the session belongs to, oldest first
+	if thread := strings.TrimSpace(c.Query("thread")); thread != "" {
+		list.Items = sessionThread(list.Items, thread)
+		if list.Items == nil {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+	}
+	if wantsYAML(c) {
+		respondYAML(c, http.StatusOK, gitOpsList(list.Items))
+		return
+	}
 
-	var sessions []types.AgenticSession
-	for _, item := range list.Items {
-		session := types.AgenticSession{
+	// Items are converted as they are streamed, reusing one session value since each is
+	// encoded before the next is converted. Watch from resourceVersion to follow changes.
+	var session types.AgenticSession
+	writeJSONList(c, http.StatusOK, len(list.Items), func(i int) any {
+		item := &list.Items[i]
+		session = types.AgenticSession{
 			APIVersion: item.GetAPIVersion(),
 			Kind:       item.GetKind(),
 			Metadata:   item.Object["metadata"].(map[string]interface{}),
@@ -279,28 +468,61 @@ func ListSessions(c *gin.Context) {
 		if status, ok := item.Object["status"].(map[string]interface{}); ok {
 			session.Status = parseStatus(status)
 		}
+		return &session
+	}, gin.H{"resourceVersion": list.GetResourceVersion()})
+}
 
-		sessions = append(sessions, session)
+func CreateSession(c *gin.Context) {
+	var req types.CreateAgenticSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"items": sessions})
+	c.JSON(createSession(c, c.GetString("project"), req))
 }
 
-func CreateSession(c *gin.Context) {
-	project := c.GetString("project")
+// createSession creates a session in project for the caller of c and returns the status and
+// body to respond with. Fan-outs and runbooks create their sessions through it, so every
+// session passes the same policy, quota and validation checks.
+func createSession(c *gin.Context, project string, req types.CreateAgenticSessionRequest) (int, gin.H) {
 	// Get user-scoped clients for creating the AgenticSession (enforces user RBAC)
 	_, reqDyn := GetK8sClientsForRequest(c)
 	if reqDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User token required"})
-		return
+		return http.StatusUnauthorized, localizedError(c, "auth.userTokenRequired", nil)
 	}
-	var req types.CreateAgenticSessionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+
+	// Normalize repository URLs (https form, credentials stripped) before they reach the CR
+	for i := range req.Repos {
+		u, err := gitutil.Normalize(req.Repos[i].Input.URL)
+		if err != nil {
+			return http.StatusBadRequest, gin.H{"error": fmt.Sprintf("repos[%d].input.url: %v", i, err)}
+		}
+		req.Repos[i].Input.URL = u
+		if req.Repos[i].Output != nil && strings.TrimSpace(req.Repos[i].Output.URL) != "" {
+			u, err := gitutil.Normalize(req.Repos[i].Output.URL)
+			if err != nil {
+				return http.StatusBadRequest, gin.H{"error": fmt.Sprintf("repos[%d].output.url: %v", i, err)}
+			}
+			req.Repos[i].Output.URL = u
+		}
+	}
+	req.WorkspaceFrom = strings.TrimSpace(req.WorkspaceFrom)
+	req.SessionGroup = strings.TrimSpace(req.SessionGroup)
+	req.ParentSession = strings.TrimSpace(req.ParentSession)
+	if req.ParentSession != "" && req.ParentSessionID != "" && req.ParentSession != req.ParentSessionID {
+		return http.StatusBadRequest, gin.H{"error": "parentSession and parent_session_id must name the same session"}
 	}
 
-	// Validation for multi-repo can be added here if needed
+	envFromSecrets, status, body := validateSessionSecrets(c, project, req.EnvFromSecrets)
+	if body != nil {
+		return status, body
+	}
+
+	if req.Hardware != nil {
+		if err := validateSessionHardware(c.Request.Context(), project, req.Hardware); err != nil {
+			return http.StatusBadRequest, gin.H{"error": fmt.Sprintf("hardware: %v", err)}
+		}
+	}
 
 	// Set defaults for LLM settings if not provided
 	llmSettings := types.LLMSettings{
@@ -318,6 +540,8 @@ func CreateSession(c *gin.Context) {
 		if req.LLMSettings.MaxTokens != 0 {
 			llmSettings.MaxTokens = req.LLMSettings.MaxTokens
 		}
+		llmSettings.ContextStrategy = req.LLMSettings.ContextStrategy
+		llmSettings.MaxContextTokens = req.LLMSettings.MaxContextTokens
 	}
 
 	timeout := 300
@@ -325,8 +549,8 @@ func CreateSession(c *gin.Context) {
 		timeout = *req.Timeout
 	}
 
-	// Generate unique name
-	timestamp := time.Now().Unix()
+	// Generate unique name; milliseconds keep sessions created in quick succession (fan-out) apart
+	timestamp := time.Now().UnixMilli()
 	name := fmt.Sprintf("agentic-session-%d", timestamp)
 
 	// Create the custom resource
@@ -342,6 +566,15 @@ func CreateSession(c *gin.Context) {
 		}
 		metadata["labels"] = labels
 	}
+	if req.SessionGroup != "" {
+		// Lets the group's members be listed by label
+		labels, _ := metadata["labels"].(map[string]interface{})
+		if labels == nil {
+			labels = map[string]interface{}{}
+		}
+		labels[sessionGroupLabel] = req.SessionGroup
+		metadata["labels"] = labels
+	}
 	if len(req.Annotations) > 0 {
 		annotations := map[string]interface{}{}
 		for k, v := range req.Annotations {
@@ -358,12 +591,8 @@ func CreateSession(c *gin.Context) {
 			"prompt":      req.Prompt,
 			"displayName": req.DisplayName,
 			"project":     project,
-			"llmSettings": map[string]interface{}{
-				"model":       llmSettings.Model,
-				"temperature": llmSettings.Temperature,
-				"maxTokens":   llmSettings.MaxTokens,
-			},
-			"timeout": timeout,
+			"llmSettings": llmSettingsSpec(llmSettings),
+			"timeout":     timeout,
 		},
 		"status": map[string]interface{}{
 			"phase": "Pending",
@@ -378,7 +607,7 @@ func CreateSession(c *gin.Context) {
 
 	// Handle session continuation
 	if req.ParentSessionID != "" {
-		envVars["PARENT_SESSION_ID"] = req.ParentSessionID
+		envVars[validation.ParentSessionEnv] = req.ParentSessionID
 		// Add annotation to track continuation lineage
 		if metadata["annotations"] == nil {
 			metadata["annotations"] = make(map[string]interface{})
@@ -386,20 +615,6 @@ func CreateSession(c *gin.Context) {
 		annotations := metadata["annotations"].(map[string]interface{})
 		annotations["vteam.ambient-code/parent-session-id"] = req.ParentSessionID
 		log.Printf("Creating continuation session from parent %s", req.ParentSessionID)
-
-		// Clean up temp-content pod from parent session to free the PVC
-		// This prevents Multi-Attach errors when the new session tries to mount the same workspace
-		reqK8s, _ := GetK8sClientsForRequest(c)
-		if reqK8s != nil {
-			tempPodName := fmt.Sprintf("temp-content-%s", req.ParentSessionID)
-			if err := reqK8s.CoreV1().Pods(project).Delete(c.Request.Context(), tempPodName, v1.DeleteOptions{}); err != nil {
-				if !errors.IsNotFound(err) {
-					log.Printf("CreateSession: failed to delete temp-content pod %s (non-fatal): %v", tempPodName, err)
-				}
-			} else {
-				log.Printf("CreateSession: deleted temp-content pod %s to free PVC for continuation", tempPodName)
-			}
-		}
 	}
 
 	if len(envVars) > 0 {
@@ -417,6 +632,74 @@ func CreateSession(c *gin.Context) {
 		session["spec"].(map[string]interface{})["autoPushOnComplete"] = *req.AutoPushOnComplete
 	}
 
+	// Preemptible flag (schedule onto spot nodes, retried from checkpoint on reclaim)
+	if req.Preemptible != nil {
+		session["spec"].(map[string]interface{})["preemptible"] = *req.Preemptible
+	}
+
+	// Spending cap, enforced by the operator from the usage the runner reports
+	if req.CostLimit != nil && (req.CostLimit.USD != 0 || req.CostLimit.Tokens != 0) {
+		costLimit := map[string]interface{}{}
+		if req.CostLimit.USD != 0 {
+			costLimit["usd"] = req.CostLimit.USD
+		}
+		if req.CostLimit.Tokens != 0 {
+			costLimit["tokens"] = req.CostLimit.Tokens
+		}
+		session["spec"].(map[string]interface{})["costLimit"] = costLimit
+	}
+
+	// Injected by the operator into the runner only while the session's Job exists
+	if len(envFromSecrets) > 0 {
+		sources := make([]interface{}, 0, len(envFromSecrets))
+		for _, src := range envFromSecrets {
+			entry := map[string]interface{}{"name": src.Name}
+			if len(src.Keys) > 0 {
+				keys := make([]interface{}, 0, len(src.Keys))
+				for _, k := range src.Keys {
+					keys = append(keys, k)
+				}
+				entry["keys"] = keys
+			}
+			sources = append(sources, entry)
+		}
+		session["spec"].(map[string]interface{})["envFromSecrets"] = sources
+	}
+
+	if req.SessionGroup != "" {
+		session["spec"].(map[string]interface{})["sessionGroup"] = req.SessionGroup
+	}
+
+	// Merged with the project's runnerHardware by the operator when it builds the Job
+	if req.Hardware != nil {
+		hw := map[string]interface{}{}
+		if req.Hardware.RuntimeClassName != "" {
+			hw["runtimeClassName"] = req.Hardware.RuntimeClassName
+		}
+		if req.Hardware.GPUs > 0 {
+			hw["gpus"] = req.Hardware.GPUs
+		}
+		if req.Hardware.Architecture != "" {
+			hw["architecture"] = req.Hardware.Architecture
+		}
+		if len(hw) > 0 {
+			session["spec"].(map[string]interface{})["hardware"] = hw
+		}
+	}
+
+	// Warm start: the operator clones the source session's workspace into the new PVC
+	if req.WorkspaceFrom != "" {
+		session["spec"].(map[string]interface{})["workspaceFrom"] = req.WorkspaceFrom
+	}
+
+	// Follow-ups and continuations join their parent's thread
+	if parent := req.ParentSession; parent != "" || req.ParentSessionID != "" {
+		if parent == "" {
+			parent = req.ParentSessionID
+		}
+		session["spec"].(map[string]interface{})["parentSession"] = parent
+	}
+
 	// Set multi-repo configuration on spec
 	{
 		spec := session["spec"].(map[string]interface{})
@@ -508,204 +791,142 @@ func CreateSession(c *gin.Context) {
 		}
 	}
 
-	gvr := GetAgenticSessionV1Alpha1Resource()
-	obj := &unstructured.Unstructured{Object: session}
-
-	// Create AgenticSession using user token (enforces user RBAC permissions)
-	created, err := reqDyn.Resource(gvr).Namespace(project).Create(context.TODO(), obj, v1.CreateOptions{})
-	if err != nil {
-		log.Printf("Failed to create agentic session in project %s: %v", project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create agentic session"})
-		return
-	}
-
-	// Best-effort prefill of agent markdown into PVC workspace for immediate UI availability
-	// Uses AGENT_PERSONAS or AGENT_PERSONA if provided in request environment variables
-	func() {
-		defer func() { _ = recover() }()
-		personasCsv := ""
-		if v, ok := req.EnvironmentVariables["AGENT_PERSONAS"]; ok && strings.TrimSpace(v) != "" {
-			personasCsv = v
-		} else if v, ok := req.EnvironmentVariables["AGENT_PERSONA"]; ok && strings.TrimSpace(v) != "" {
-			personasCsv = v
+	// Record who created the session so API- and kubectl-created sessions look the same
+	{
+		if metadata["annotations"] == nil {
+			metadata["annotations"] = make(map[string]interface{})
 		}
-		if strings.TrimSpace(personasCsv) == "" {
-			return
+		annotations := metadata["annotations"].(map[string]interface{})
+		annotations[creationSourceAnnotation] = creationSourceAPI
+		if uid := c.GetString("userID"); uid != "" {
+			annotations[createdByAnnotation] = uid
 		}
-		// content service removed; skip workspace path handling
-		// Write each agent markdown
-		for _, p := range strings.Split(personasCsv, ",") {
-			persona := strings.TrimSpace(p)
-			if persona == "" {
-				continue
-			}
-			// ambient-content removed: skip agent prefill writes
+		if issue := strings.TrimSpace(req.Issue); issue != "" {
+			annotations[issueAnnotation] = issue
 		}
-	}()
-
-	// Provision runner token using backend SA (requires elevated permissions for SA/Role/Secret creation)
-	if DynamicClient == nil || K8sClient == nil {
-		log.Printf("Warning: backend SA clients not available, skipping runner token provisioning for session %s/%s", project, name)
-	} else if err := provisionRunnerTokenForSession(c, K8sClient, DynamicClient, project, name); err != nil {
-		// Non-fatal: log and continue. Operator may retry later if implemented.
-		log.Printf("Warning: failed to provision runner token for session %s/%s: %v", project, name, err)
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Agentic session created successfully",
-		"name":    name,
-		"uid":     created.GetUID(),
-	})
-}
-
-// provisionRunnerTokenForSession creates a per-session ServiceAccount, grants minimal RBAC,
-// mints a short-lived token, stores it in a Secret, and annotates the AgenticSession with the Secret name.
-func provisionRunnerTokenForSession(c *gin.Context, reqK8s *kubernetes.Clientset, reqDyn dynamic.Interface, project string, sessionName string) error {
-	// Load owning AgenticSession to parent all resources
-	gvr := GetAgenticSessionV1Alpha1Resource()
-	obj, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("get AgenticSession: %w", err)
+	// The same rules the admission webhook applies to sessions created with kubectl
+	if errs := sessionSpecErrors(session["spec"].(map[string]interface{}), nil); len(errs) > 0 {
+		return http.StatusBadRequest, invalidSessionSpecBody(c, errs)
 	}
-	ownerRef := v1.OwnerReference{
-		APIVersion: obj.GetAPIVersion(),
-		Kind:       obj.GetKind(),
-		Name:       obj.GetName(),
-		UID:        obj.GetUID(),
-		Controller: types.BoolPtr(true),
+	if req.WorkspaceFrom != "" {
+		// The caller must be able to read the source session
+		if _, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), req.WorkspaceFrom, v1.GetOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				return http.StatusBadRequest, gin.H{"error": fmt.Sprintf("workspaceFrom: session %q not found", req.WorkspaceFrom)}
+			}
+			log.Printf("CreateSession: failed to read workspaceFrom session %s/%s: %v", project, req.WorkspaceFrom, err)
+			return http.StatusInternalServerError, gin.H{"error": "Failed to read workspaceFrom session"}
+		}
 	}
 
-	// Create ServiceAccount
-	saName := fmt.Sprintf("ambient-session-%s", sessionName)
-	sa := &corev1.ServiceAccount{
-		ObjectMeta: v1.ObjectMeta{
-			Name:            saName,
-			Namespace:       project,
-			Labels:          map[string]string{"app": "ambient-runner"},
-			OwnerReferences: []v1.OwnerReference{ownerRef},
-		},
-	}
-	if _, err := reqK8s.CoreV1().ServiceAccounts(project).Create(c.Request.Context(), sa, v1.CreateOptions{}); err != nil {
-		if !errors.IsAlreadyExists(err) {
-			return fmt.Errorf("create SA: %w", err)
+	// A follow-up is told what happened earlier in its thread. A continuation resumes its
+	// parent's conversation, so it needs no context.
+	var followUpContext string
+	if req.ParentSession != "" {
+		thread, err := sessionAncestors(c.Request.Context(), reqDyn, project, req.ParentSession)
+		if err == errParentNotFound {
+			return http.StatusBadRequest, gin.H{"error": fmt.Sprintf("parentSession: session %q not found", req.ParentSession)}
+		}
+		if err != nil {
+			log.Printf("CreateSession: failed to read the thread of %s/%s: %v", project, req.ParentSession, err)
+			return http.StatusInternalServerError, gin.H{"error": "Failed to read parent session"}
+		}
+		if req.ParentSessionID == "" {
+			policy, err := followUpPolicy(c.Request.Context(), project)
+			if err != nil {
+				log.Printf("CreateSession: failed to read follow-up policy for project %s: %v", project, err)
+				return http.StatusInternalServerError, gin.H{"error": "Failed to read the project's follow-up policy"}
+			}
+			followUpContext = buildFollowUpContext(thread, policy.Context, policy.MaxContextLength, sessionTranscript)
 		}
 	}
 
-	// Create Role with least-privilege for updating AgenticSession status and annotations
-	roleName := fmt.Sprintf("ambient-session-%s-role", sessionName)
-	role := &rbacv1.Role{
-		ObjectMeta: v1.ObjectMeta{
-			Name:            roleName,
-			Namespace:       project,
-			OwnerReferences: []v1.OwnerReference{ownerRef},
-		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{"vteam.ambient-code"},
-				Resources: []string{"agenticsessions/status"},
-				Verbs:     []string{"get", "update", "patch"},
-			},
-			{
-				APIGroups: []string{"vteam.ambient-code"},
-				Resources: []string{"agenticsessions"},
-				Verbs:     []string{"get", "list", "watch", "update", "patch"}, // Added update, patch for annotations
-			},
-			{
-				APIGroups: []string{"authorization.k8s.io"},
-				Resources: []string{"selfsubjectaccessreviews"},
-				Verbs:     []string{"create"},
-			},
-		},
+	// Free the workspace a continuation or warm start reads from: a workspace browsing pod
+	// would otherwise hold the PVC and cause Multi-Attach errors
+	source := req.ParentSessionID
+	if source == "" {
+		source = req.WorkspaceFrom
 	}
-	// Try to create or update the Role to ensure it has latest permissions
-	if _, err := reqK8s.RbacV1().Roles(project).Create(c.Request.Context(), role, v1.CreateOptions{}); err != nil {
-		if errors.IsAlreadyExists(err) {
-			// Role exists - update it to ensure it has the latest permissions (including update/patch)
-			log.Printf("Role %s already exists, updating with latest permissions", roleName)
-			if _, err := reqK8s.RbacV1().Roles(project).Update(c.Request.Context(), role, v1.UpdateOptions{}); err != nil {
-				return fmt.Errorf("update Role: %w", err)
+	if source != "" {
+		if reqK8s, _ := GetK8sClientsForRequest(c); reqK8s != nil {
+			tempPodName := fmt.Sprintf("temp-content-%s", source)
+			if err := reqK8s.CoreV1().Pods(project).Delete(c.Request.Context(), tempPodName, v1.DeleteOptions{}); err != nil {
+				if !errors.IsNotFound(err) {
+					log.Printf("CreateSession: failed to delete temp-content pod %s (non-fatal): %v", tempPodName, err)
+				}
+			} else {
+				log.Printf("CreateSession: deleted temp-content pod %s to free its PVC", tempPodName)
 			}
-			log.Printf("Successfully updated Role %s with annotation update permissions", roleName)
-		} else {
-			return fmt.Errorf("create Role: %w", err)
 		}
 	}
 
-	// Bind Role to the ServiceAccount
-	rbName := fmt.Sprintf("ambient-session-%s-rb", sessionName)
-	rb := &rbacv1.RoleBinding{
-		ObjectMeta: v1.ObjectMeta{
-			Name:            rbName,
-			Namespace:       project,
-			OwnerReferences: []v1.OwnerReference{ownerRef},
-		},
-		RoleRef:  rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: roleName},
-		Subjects: []rbacv1.Subject{{Kind: "ServiceAccount", Name: saName, Namespace: project}},
-	}
-	if _, err := reqK8s.RbacV1().RoleBindings(project).Create(context.TODO(), rb, v1.CreateOptions{}); err != nil {
-		if !errors.IsAlreadyExists(err) {
-			return fmt.Errorf("create RoleBinding: %w", err)
-		}
+	// Enforce the project's prompt limits and moderation on what the user wrote
+	if err := applyPromptPolicy(c.Request.Context(), project, session["spec"].(map[string]interface{})); err != nil {
+		return promptPolicyErrorResponse(project, err)
 	}
 
-	// Mint short-lived K8s ServiceAccount token for CR status updates
-	tr := &authnv1.TokenRequest{Spec: authnv1.TokenRequestSpec{}}
-	tok, err := reqK8s.CoreV1().ServiceAccounts(project).CreateToken(c.Request.Context(), saName, tr, v1.CreateOptions{})
+	// Compliance labels and annotations the project requires on every session
+	missing, err := missingSessionMetadata(c.Request.Context(), project, metadata)
 	if err != nil {
-		return fmt.Errorf("mint token: %w", err)
+		log.Printf("CreateSession: failed to check required metadata for project %s: %v", project, err)
+		return http.StatusInternalServerError, gin.H{"error": "Failed to check the project's required session metadata"}
 	}
-	k8sToken := tok.Status.Token
-	if strings.TrimSpace(k8sToken) == "" {
-		return fmt.Errorf("received empty token for SA %s", saName)
+	if len(missing) > 0 {
+		return http.StatusUnprocessableEntity, missingMetadataBody(c, project, missing)
 	}
 
-	// Only store the K8s token; GitHub tokens are minted on-demand by the runner
-	secretData := map[string]string{
-		"k8s-token": k8sToken,
+	// Prepend the thread's context, then the project's system prompt (org-wide guardrails,
+	// style guides)
+	spec := session["spec"].(map[string]interface{})
+	userPrompt, _ := spec["prompt"].(string)
+	applyFollowUpContext(spec, followUpContext)
+	if err := applyProjectSystemPrompt(c.Request.Context(), project, spec, strings.TrimSpace(req.Issue)); err != nil {
+		log.Printf("CreateSession: failed to apply system prompt for project %s: %v", project, err)
+		return http.StatusInternalServerError, gin.H{"error": "Failed to apply project system prompt"}
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if spec["prompt"] != userPrompt {
+		// Quoted by the session's follow-ups instead of the prefixed prompt
+		if annotations == nil {
+			annotations = map[string]interface{}{}
+			metadata["annotations"] = annotations
+		}
+		annotations[userPromptAnnotation] = clipRunes(userPrompt, defaultFollowUpContextLength)
+	} else if annotations != nil {
+		delete(annotations, userPromptAnnotation)
 	}
 
-	// Store token in a Secret (update if exists to refresh token)
-	secretName := fmt.Sprintf("ambient-runner-token-%s", sessionName)
-	sec := &corev1.Secret{
-		ObjectMeta: v1.ObjectMeta{
-			Name:            secretName,
-			Namespace:       project,
-			Labels:          map[string]string{"app": "ambient-runner-token"},
-			OwnerReferences: []v1.OwnerReference{ownerRef},
-		},
-		Type:       corev1.SecretTypeOpaque,
-		StringData: secretData,
+	if body := maintenanceRejection(c, project); body != nil {
+		return http.StatusServiceUnavailable, body
 	}
 
-	// Try to create the secret
-	if _, err := reqK8s.CoreV1().Secrets(project).Create(c.Request.Context(), sec, v1.CreateOptions{}); err != nil {
-		if errors.IsAlreadyExists(err) {
-			// Secret exists - update it with fresh token
-			log.Printf("Updating existing secret %s with fresh token", secretName)
-			if _, err := reqK8s.CoreV1().Secrets(project).Update(c.Request.Context(), sec, v1.UpdateOptions{}); err != nil {
-				return fmt.Errorf("update Secret: %w", err)
-			}
-			log.Printf("Successfully updated secret %s with fresh token", secretName)
-		} else {
-			return fmt.Errorf("create Secret: %w", err)
+	if err := checkSessionQuota(c.Request.Context(), project, ""); err != nil {
+		if quotaErr, ok := err.(*sessionQuotaError); ok {
+			return http.StatusTooManyRequests, quotaRejection(c, quotaErr)
 		}
+		log.Printf("CreateSession: quota check failed for project %s: %v", project, err)
+		return http.StatusInternalServerError, gin.H{"error": "Failed to check session quota"}
 	}
 
-	// Annotate the AgenticSession with the Secret and SA names (conflict-safe patch)
-	patch := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"annotations": map[string]string{
-				"ambient-code.io/runner-token-secret": secretName,
-				"ambient-code.io/runner-sa":           saName,
-			},
-		},
-	}
-	b, _ := json.Marshal(patch)
-	if _, err := reqDyn.Resource(gvr).Namespace(project).Patch(c.Request.Context(), obj.GetName(), ktypes.MergePatchType, b, v1.PatchOptions{}); err != nil {
-		return fmt.Errorf("annotate AgenticSession: %w", err)
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	obj := &unstructured.Unstructured{Object: session}
+
+	// Create AgenticSession using user token (enforces user RBAC permissions)
+	created, err := reqDyn.Resource(gvr).Namespace(project).Create(c.Request.Context(), obj, v1.CreateOptions{})
+	if err != nil {
+		log.Printf("Failed to create agentic session in project %s: %v", project, err)
+		return http.StatusInternalServerError, gin.H{"error": "Failed to create agentic session"}
 	}
 
-	return nil
+	// Agents named in AGENT_PERSONAS reach the runner through its config (see sessionAgents)
+
+	return http.StatusCreated, gin.H{
+		"message": "Agentic session created successfully",
+		"name":    name,
+		"uid":     created.GetUID(),
+	}
 }
 
 func GetSession(c *gin.Context) {
@@ -715,16 +936,40 @@ func GetSession(c *gin.Context) {
 	_ = reqK8s
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	wait, err := parseSessionWait(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	if err == nil && wait != nil {
+		var result string
+		if item, result, err = waitForSession(c.Request.Context(), reqDyn, project, item, wait); err == nil {
+			c.Header("X-Wait-Result", result)
+		}
+	}
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+		if wait != nil && errors.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed to watch sessions in this project"})
+			return
+		}
+		if c.Request.Context().Err() != nil {
+			// Client gave up while waiting
 			return
 		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
 		return
 	}
+	if wantsYAML(c) {
+		respondYAML(c, http.StatusOK, gitOpsManifest(item))
+		return
+	}
 
 	session := types.AgenticSession{
 		APIVersion: item.GetAPIVersion(),
@@ -739,6 +984,9 @@ func GetSession(c *gin.Context) {
 	if status, ok := item.Object["status"].(map[string]interface{}); ok {
 		session.Status = parseStatus(status)
 	}
+	if session.Status != nil && session.Status.Phase == string(apiv1alpha1.SessionPhaseRunning) {
+		session.ResourceUsage = sessionResourceUsage(c.Request.Context(), project, sessionName)
+	}
 
 	c.JSON(http.StatusOK, session)
 }
@@ -750,72 +998,18 @@ func MintSessionGitHubToken(c *gin.Context) {
 	project := c.Param("projectName")
 	sessionName := c.Param("sessionName")
 
-	rawAuth := strings.TrimSpace(c.GetHeader("Authorization"))
-	if rawAuth == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
-		return
-	}
-	parts := strings.SplitN(rawAuth, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header"})
-		return
-	}
-	token := strings.TrimSpace(parts[1])
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "empty token"})
-		return
-	}
-
-	// TokenReview using default audience (works with standard SA tokens)
-	tr := &authnv1.TokenReview{Spec: authnv1.TokenReviewSpec{Token: token}}
-	rv, err := K8sClient.AuthenticationV1().TokenReviews().Create(c.Request.Context(), tr, v1.CreateOptions{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token review failed"})
-		return
-	}
-	if rv.Status.Error != "" || !rv.Status.Authenticated {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
-		return
-	}
-	subj := strings.TrimSpace(rv.Status.User.Username)
-	const pfx = "system:serviceaccount:"
-	if !strings.HasPrefix(subj, pfx) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "subject is not a service account"})
-		return
-	}
-	rest := strings.TrimPrefix(subj, pfx)
-	segs := strings.SplitN(rest, ":", 2)
-	if len(segs) != 2 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "invalid service account subject"})
+	nsFromToken, saFromToken, ok := authenticateRunnerToken(c)
+	if !ok {
 		return
 	}
-	nsFromToken, saFromToken := segs[0], segs[1]
 	if nsFromToken != project {
 		c.JSON(http.StatusForbidden, gin.H{"error": "namespace mismatch"})
 		return
 	}
 
 	// Load session and verify SA matches annotation
-	gvr := GetAgenticSessionV1Alpha1Resource()
-	obj, err := DynamicClient.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read session"})
-		return
-	}
-	meta, _ := obj.Object["metadata"].(map[string]interface{})
-	anns, _ := meta["annotations"].(map[string]interface{})
-	expectedSA := ""
-	if anns != nil {
-		if v, ok := anns["ambient-code.io/runner-sa"].(string); ok {
-			expectedSA = strings.TrimSpace(v)
-		}
-	}
-	if expectedSA == "" || expectedSA != saFromToken {
-		c.JSON(http.StatusForbidden, gin.H{"error": "service account not authorized for session"})
+	obj := getRunnerSession(c, project, sessionName, saFromToken)
+	if obj == nil {
 		return
 	}
 
@@ -834,6 +1028,14 @@ func MintSessionGitHubToken(c *gin.Context) {
 		return
 	}
 
+	// Prefer a GitHub App token limited to the session's repositories
+	if session, err := sessionFromUnstructured(obj); err == nil {
+		if tokenStr, expiresAt, ok := mintSessionGitHubToken(c.Request.Context(), session); ok {
+			c.JSON(http.StatusOK, gin.H{"token": tokenStr, "expiresAt": expiresAt.UTC().Format(time.RFC3339)})
+			return
+		}
+	}
+
 	// Get GitHub token (GitHub App or PAT fallback via project runner secret)
 	tokenStr, err := GetGitHubToken(c.Request.Context(), K8sClient, DynamicClient, project, userID)
 	if err != nil {
@@ -845,55 +1047,6 @@ func MintSessionGitHubToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"token": tokenStr})
 }
 
-func PatchSession(c *gin.Context) {
-	project := c.GetString("project")
-	sessionName := c.Param("sessionName")
-	_, reqDyn := GetK8sClientsForRequest(c)
-
-	var patch map[string]interface{}
-	if err := c.ShouldBindJSON(&patch); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	gvr := GetAgenticSessionV1Alpha1Resource()
-
-	// Get current resource
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
-		return
-	}
-
-	// Apply patch to metadata annotations
-	if metaPatch, ok := patch["metadata"].(map[string]interface{}); ok {
-		if annsPatch, ok := metaPatch["annotations"].(map[string]interface{}); ok {
-			metadata := item.Object["metadata"].(map[string]interface{})
-			if metadata["annotations"] == nil {
-				metadata["annotations"] = make(map[string]interface{})
-			}
-			anns := metadata["annotations"].(map[string]interface{})
-			for k, v := range annsPatch {
-				anns[k] = v
-			}
-		}
-	}
-
-	// Update the resource
-	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
-	if err != nil {
-		log.Printf("Failed to patch agentic session %s: %v", sessionName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to patch session"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Session patched successfully", "annotations": updated.GetAnnotations()})
-}
-
 func UpdateSession(c *gin.Context) {
 	project := c.GetString("project")
 	sessionName := c.Param("sessionName")
@@ -912,7 +1065,7 @@ func UpdateSession(c *gin.Context) {
 	var item *unstructured.Unstructured
 	var err error
 	for attempt := 0; attempt < 5; attempt++ {
-		item, err = reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+		item, err = reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 		if err == nil {
 			break
 		}
@@ -925,14 +1078,19 @@ func UpdateSession(c *gin.Context) {
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 		return
 	}
 
 	// Update spec
 	spec := item.Object["spec"].(map[string]interface{})
+	oldSpec := runtime.DeepCopyJSONValue(spec).(map[string]interface{})
 	spec["prompt"] = req.Prompt
 	spec["displayName"] = req.DisplayName
+	if err := applyPromptPolicy(c.Request.Context(), project, spec); err != nil {
+		respondPromptPolicyError(c, project, err)
+		return
+	}
 
 	if req.LLMSettings != nil {
 		llmSettings := make(map[string]interface{})
@@ -945,15 +1103,25 @@ func UpdateSession(c *gin.Context) {
 		if req.LLMSettings.MaxTokens != 0 {
 			llmSettings["maxTokens"] = req.LLMSettings.MaxTokens
 		}
+		if req.LLMSettings.ContextStrategy != "" {
+			llmSettings["contextStrategy"] = req.LLMSettings.ContextStrategy
+		}
+		if req.LLMSettings.MaxContextTokens != 0 {
+			llmSettings["maxContextTokens"] = req.LLMSettings.MaxContextTokens
+		}
 		spec["llmSettings"] = llmSettings
 	}
 
 	if req.Timeout != nil {
 		spec["timeout"] = *req.Timeout
 	}
+	if errs := sessionSpecErrors(spec, oldSpec); len(errs) > 0 {
+		respondInvalidSessionSpec(c, errs)
+		return
+	}
 
 	// Update the resource
-	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update agentic session %s in project %s: %v", sessionName, project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update agentic session"})
@@ -975,6 +1143,7 @@ func UpdateSession(c *gin.Context) {
 		session.Status = parseStatus(status)
 	}
 
+	recordSessionAction(c, project, sessionName, "update", "")
 	c.JSON(http.StatusOK, session)
 }
 
@@ -996,10 +1165,10 @@ func UpdateSessionDisplayName(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
 	// Retrieve current resource
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 			return
 		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
@@ -1016,7 +1185,7 @@ func UpdateSessionDisplayName(c *gin.Context) {
 	spec["displayName"] = req.DisplayName
 
 	// Persist the change
-	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update display name for agentic session %s in project %s: %v", sessionName, project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update display name"})
@@ -1055,10 +1224,10 @@ func SelectWorkflow(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
 	// Retrieve current resource
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 			return
 		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
@@ -1088,7 +1257,7 @@ func SelectWorkflow(c *gin.Context) {
 	spec["activeWorkflow"] = workflowMap
 
 	// Persist the change
-	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update workflow for agentic session %s in project %s: %v", sessionName, project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workflow"})
@@ -1113,6 +1282,7 @@ func SelectWorkflow(c *gin.Context) {
 		session.Status = parseStatus(st)
 	}
 
+	recordSessionAction(c, project, sessionName, "select_workflow", req.GitURL)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Workflow updated successfully",
 		"session": session,
@@ -1144,11 +1314,24 @@ func AddRepo(c *gin.Context) {
 		req.Branch = "main"
 	}
 
+	normalized, err := gitutil.Normalize(req.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("url: %v", err)})
+		return
+	}
+	req.URL = normalized
+	if req.Output != nil && strings.TrimSpace(req.Output.URL) != "" {
+		if req.Output.URL, err = gitutil.Normalize(req.Output.URL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("output.url: %v", err)})
+			return
+		}
+	}
+
 	gvr := GetAgenticSessionV1Alpha1Resource()
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 			return
 		}
 		log.Printf("Failed to get session %s in project %s: %v", sessionName, project, err)
@@ -1183,7 +1366,7 @@ func AddRepo(c *gin.Context) {
 	spec["repos"] = repos
 
 	// Persist change
-	_, err = reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+	_, err = reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update session %s in project %s: %v", sessionName, project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
@@ -1201,6 +1384,7 @@ func AddRepo(c *gin.Context) {
 	}
 
 	log.Printf("Added repository %s to session %s in project %s", repoName, sessionName, project)
+	recordSessionAction(c, project, sessionName, "add_repo", req.URL)
 	c.JSON(http.StatusOK, gin.H{"message": "Repository added", "name": repoName})
 }
 
@@ -1213,10 +1397,10 @@ func RemoveRepo(c *gin.Context) {
 	_, reqDyn := GetK8sClientsForRequest(c)
 
 	gvr := GetAgenticSessionV1Alpha1Resource()
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 			return
 		}
 		log.Printf("Failed to get session %s in project %s: %v", sessionName, project, err)
@@ -1253,7 +1437,7 @@ func RemoveRepo(c *gin.Context) {
 	spec["repos"] = filteredRepos
 
 	// Persist change
-	_, err = reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+	_, err = reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update session %s in project %s: %v", sessionName, project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
@@ -1268,6 +1452,7 @@ func RemoveRepo(c *gin.Context) {
 	}
 
 	log.Printf("Removed repository %s from session %s in project %s", repoName, sessionName, project)
+	recordSessionAction(c, project, sessionName, "remove_repo", repoName)
 	c.JSON(http.StatusOK, gin.H{"message": "Repository removed"})
 }
 
@@ -1348,7 +1533,7 @@ func fetchGitHubFileContent(ctx context.Context, owner, repo, ref, path, token s
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := breaker.GitHub.Do(client, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1385,7 +1570,7 @@ func fetchGitHubDirectoryListing(ctx context.Context, owner, repo, ref, path, to
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := breaker.GitHub.Do(client, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1419,6 +1604,29 @@ type OOTBWorkflow struct {
 // Attempts to use user's GitHub token for better rate limits, falls back to unauthenticated for public repos
 // GET /api/workflows/ootb?project=<projectName>
 func ListOOTBWorkflows(c *gin.Context) {
+	// Read OOTB repo configuration from environment
+	ootbRepo := strings.TrimSpace(os.Getenv("OOTB_WORKFLOWS_REPO"))
+	if ootbRepo == "" {
+		ootbRepo = "https://github.com/ambient-code/ootb-ambient-workflows.git"
+	}
+
+	ootbBranch := strings.TrimSpace(os.Getenv("OOTB_WORKFLOWS_BRANCH"))
+	if ootbBranch == "" {
+		ootbBranch = "main"
+	}
+
+	ootbWorkflowsPath := strings.TrimSpace(os.Getenv("OOTB_WORKFLOWS_PATH"))
+	if ootbWorkflowsPath == "" {
+		ootbWorkflowsPath = "workflows"
+	}
+
+	// Discovery costs a GitHub call per workflow; between refreshes every page view is
+	// answered from memory
+	if cached := lastOOTBWorkflows.fresh(ootbRepo, ootbBranch, ootbWorkflowsPath, ootbWorkflowsCacheTTL()); cached != nil {
+		respondCatalog(c, ootbWorkflowsCacheControl, gin.H{"workflows": cached})
+		return
+	}
+
 	// Try to get user's GitHub token (best effort - not required)
 	// This gives better rate limits (5000/hr vs 60/hr) and supports private repos
 	// Project is optional - if provided, we'll try to get the user's token
@@ -1441,22 +1649,6 @@ func ListOOTBWorkflows(c *gin.Context) {
 		log.Printf("ListOOTBWorkflows: proceeding without GitHub token (public repo, lower rate limits)")
 	}
 
-	// Read OOTB repo configuration from environment
-	ootbRepo := strings.TrimSpace(os.Getenv("OOTB_WORKFLOWS_REPO"))
-	if ootbRepo == "" {
-		ootbRepo = "https://github.com/ambient-code/ootb-ambient-workflows.git"
-	}
-
-	ootbBranch := strings.TrimSpace(os.Getenv("OOTB_WORKFLOWS_BRANCH"))
-	if ootbBranch == "" {
-		ootbBranch = "main"
-	}
-
-	ootbWorkflowsPath := strings.TrimSpace(os.Getenv("OOTB_WORKFLOWS_PATH"))
-	if ootbWorkflowsPath == "" {
-		ootbWorkflowsPath = "workflows"
-	}
-
 	// Parse GitHub URL
 	owner, repoName, err := git.ParseGitHubURL(ootbRepo)
 	if err != nil {
@@ -1469,6 +1661,11 @@ func ListOOTBWorkflows(c *gin.Context) {
 	entries, err := fetchGitHubDirectoryListing(c.Request.Context(), owner, repoName, ootbBranch, ootbWorkflowsPath, token)
 	if err != nil {
 		log.Printf("ListOOTBWorkflows: failed to list workflows directory: %v", err)
+		// Serve the last good list while GitHub is unreachable
+		if cached := lastOOTBWorkflows.get(ootbRepo, ootbBranch, ootbWorkflowsPath); cached != nil {
+			c.JSON(http.StatusOK, gin.H{"workflows": cached, "stale": true})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discover OOTB workflows"})
 		return
 	}
@@ -1517,7 +1714,79 @@ func ListOOTBWorkflows(c *gin.Context) {
 	}
 
 	log.Printf("ListOOTBWorkflows: discovered %d workflows from %s", len(workflows), ootbRepo)
-	c.JSON(http.StatusOK, gin.H{"workflows": workflows})
+	lastOOTBWorkflows.set(ootbRepo, ootbBranch, ootbWorkflowsPath, workflows)
+	respondCatalog(c, ootbWorkflowsCacheControl, gin.H{"workflows": workflows})
+}
+
+// RefreshOOTBWorkflows handles POST /api/workflows/ootb/refresh. Platform admins (or their
+// CI, after changing the workflows repo) call it so the next page view rediscovers the
+// workflows instead of waiting for OOTB_WORKFLOWS_CACHE_TTL.
+func RefreshOOTBWorkflows(c *gin.Context) {
+	allowed, err := canAdministerMaintenance(c)
+	if err != nil {
+		log.Printf("RefreshOOTBWorkflows: access review failed: %v", err)
+		respondMessage(c, http.StatusInternalServerError, "auth.accessReviewFailed", nil)
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only platform admins can refresh the workflow catalog"})
+		return
+	}
+	lastOOTBWorkflows.invalidate()
+	log.Printf("OOTB workflow catalog invalidated by %s", c.GetString("userID"))
+	c.Status(http.StatusNoContent)
+}
+
+// ootbWorkflowsCacheTTL is how long a discovered list is served before GitHub is asked again
+func ootbWorkflowsCacheTTL() time.Duration {
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("OOTB_WORKFLOWS_CACHE_TTL"))); err == nil && d >= 0 {
+		return d
+	}
+	return 10 * time.Minute
+}
+
+// ootbWorkflowCache keeps the last list discovered from GitHub: served while fresh, and
+// served stale when GitHub fails
+type ootbWorkflowCache struct {
+	mu        sync.Mutex
+	source    string
+	workflows []OOTBWorkflow
+	fetchedAt time.Time
+}
+
+var lastOOTBWorkflows = &ootbWorkflowCache{}
+
+func (w *ootbWorkflowCache) get(repo, branch, path string) []OOTBWorkflow {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.source != repo+"@"+branch+":"+path {
+		return nil
+	}
+	return w.workflows
+}
+
+// fresh returns the list when it was discovered within ttl and not invalidated since
+func (w *ootbWorkflowCache) fresh(repo, branch, path string, ttl time.Duration) []OOTBWorkflow {
+	w.mu.Lock()
+	fetchedAt := w.fetchedAt
+	w.mu.Unlock()
+	if fetchedAt.IsZero() || time.Since(fetchedAt) >= ttl {
+		return nil
+	}
+	return w.get(repo, branch, path)
+}
+
+func (w *ootbWorkflowCache) set(repo, branch, path string, workflows []OOTBWorkflow) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.source, w.workflows, w.fetchedAt = repo+"@"+branch+":"+path, workflows, time.Now()
+}
+
+// invalidate makes the next request rediscover; the list is kept as the stale fallback
+func (w *ootbWorkflowCache) invalidate() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fetchedAt = time.Time{}
 }
 
 func DeleteSession(c *gin.Context) {
@@ -1527,10 +1796,10 @@ func DeleteSession(c *gin.Context) {
 	_ = reqK8s
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
-	err := reqDyn.Resource(gvr).Namespace(project).Delete(context.TODO(), sessionName, v1.DeleteOptions{})
+	err := reqDyn.Resource(gvr).Namespace(project).Delete(c.Request.Context(), sessionName, v1.DeleteOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 			return
 		}
 		log.Printf("Failed to delete agentic session %s in project %s: %v", sessionName, project, err)
@@ -1555,7 +1824,7 @@ func CloneSession(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
 	// Get source session
-	sourceItem, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	sourceItem, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Source session not found"})
@@ -1568,7 +1837,7 @@ func CloneSession(c *gin.Context) {
 
 	// Validate target project exists and is managed by Ambient via OpenShift Project
 	projGvr := GetOpenShiftProjectResource()
-	projObj, err := reqDyn.Resource(projGvr).Get(context.TODO(), req.TargetProject, v1.GetOptions{})
+	projObj, err := reqDyn.Resource(projGvr).Get(c.Request.Context(), req.TargetProject, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Target project not found"})
@@ -1590,6 +1859,18 @@ func CloneSession(c *gin.Context) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Target project is not managed by Ambient"})
 		return
 	}
+	if rejectForMaintenance(c, req.TargetProject) {
+		return
+	}
+	if err := checkSessionQuota(c.Request.Context(), req.TargetProject, ""); err != nil {
+		if quotaErr, ok := err.(*sessionQuotaError); ok {
+			c.JSON(http.StatusTooManyRequests, quotaRejection(c, quotaErr))
+			return
+		}
+		log.Printf("CloneSession: quota check failed for project %s: %v", req.TargetProject, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session quota"})
+		return
+	}
 
 	// Ensure unique target session name in target namespace; if exists, append "-duplicate" (and numeric suffix)
 	newName := strings.TrimSpace(req.NewSessionName)
@@ -1599,7 +1880,7 @@ func CloneSession(c *gin.Context) {
 	finalName := newName
 	conflicted := false
 	for i := 0; i < 50; i++ {
-		_, getErr := reqDyn.Resource(gvr).Namespace(req.TargetProject).Get(context.TODO(), finalName, v1.GetOptions{})
+		_, getErr := reqDyn.Resource(gvr).Namespace(req.TargetProject).Get(c.Request.Context(), finalName, v1.GetOptions{})
 		if errors.IsNotFound(getErr) {
 			break
 		}
@@ -1640,9 +1921,16 @@ func CloneSession(c *gin.Context) {
 		}
 	}
 
+	// Recorded like CreateSession does, so the clone is not adopted as an external session
+	annotations := map[string]interface{}{creationSourceAnnotation: creationSourceAPI}
+	if uid := c.GetString("userID"); uid != "" {
+		annotations[createdByAnnotation] = uid
+	}
+	clonedSession["metadata"].(map[string]interface{})["annotations"] = annotations
+
 	obj := &unstructured.Unstructured{Object: clonedSession}
 
-	created, err := reqDyn.Resource(gvr).Namespace(req.TargetProject).Create(context.TODO(), obj, v1.CreateOptions{})
+	created, err := reqDyn.Resource(gvr).Namespace(req.TargetProject).Create(c.Request.Context(), obj, v1.CreateOptions{})
 	if err != nil {
 		log.Printf("Failed to create cloned agentic session in project %s: %v", req.TargetProject, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cloned agentic session"})
@@ -1667,58 +1955,6 @@ func CloneSession(c *gin.Context) {
 	c.JSON(http.StatusCreated, session)
 }
 
-// ensureRunnerRolePermissions updates the runner role to ensure it has all required permissions
-// This is useful for existing sessions that were created before we added new permissions
-func ensureRunnerRolePermissions(c *gin.Context, reqK8s *kubernetes.Clientset, project string, sessionName string) error {
-	roleName := fmt.Sprintf("ambient-session-%s-role", sessionName)
-
-	// Get existing role
-	existingRole, err := reqK8s.RbacV1().Roles(project).Get(c.Request.Context(), roleName, v1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Printf("Role %s not found for session %s - will be created by operator", roleName, sessionName)
-			return nil
-		}
-		return fmt.Errorf("get role: %w", err)
-	}
-
-	// Check if role has selfsubjectaccessreviews permission
-	hasSelfSubjectAccessReview := false
-	for _, rule := range existingRole.Rules {
-		for _, apiGroup := range rule.APIGroups {
-			if apiGroup == "authorization.k8s.io" {
-				for _, resource := range rule.Resources {
-					if resource == "selfsubjectaccessreviews" {
-						hasSelfSubjectAccessReview = true
-						break
-					}
-				}
-			}
-		}
-	}
-
-	if hasSelfSubjectAccessReview {
-		log.Printf("Role %s already has selfsubjectaccessreviews permission", roleName)
-		return nil
-	}
-
-	// Add missing permission
-	log.Printf("Updating role %s to add selfsubjectaccessreviews permission", roleName)
-	existingRole.Rules = append(existingRole.Rules, rbacv1.PolicyRule{
-		APIGroups: []string{"authorization.k8s.io"},
-		Resources: []string{"selfsubjectaccessreviews"},
-		Verbs:     []string{"create"},
-	})
-
-	_, err = reqK8s.RbacV1().Roles(project).Update(c.Request.Context(), existingRole, v1.UpdateOptions{})
-	if err != nil {
-		return fmt.Errorf("update role: %w", err)
-	}
-
-	log.Printf("Successfully updated role %s with selfsubjectaccessreviews permission", roleName)
-	return nil
-}
-
 func StartSession(c *gin.Context) {
 	project := c.GetString("project")
 	sessionName := c.Param("sessionName")
@@ -1726,10 +1962,10 @@ func StartSession(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
 	// Get current resource
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 			return
 		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
@@ -1737,12 +1973,6 @@ func StartSession(c *gin.Context) {
 		return
 	}
 
-	// Ensure runner role has required permissions (update if needed for existing sessions)
-	if err := ensureRunnerRolePermissions(c, reqK8s, project, sessionName); err != nil {
-		log.Printf("Warning: failed to ensure runner role permissions for %s: %v", sessionName, err)
-		// Non-fatal - continue with restart
-	}
-
 	// Clean up temp-content pod if it exists to free the PVC
 	// This prevents Multi-Attach errors when the session job tries to mount the workspace
 	if reqK8s != nil {
@@ -1778,6 +2008,11 @@ func StartSession(c *gin.Context) {
 		log.Printf("StartSession: Not a continuation - current phase is: %s (not in terminal phases)", currentPhase)
 	}
 
+	// Restarting a finished session starts a new runner, so it honours the maintenance switch
+	if isActualContinuation && rejectForMaintenance(c, project) {
+		return
+	}
+
 	// Only set parent session annotation if this is an actual continuation
 	// Don't set it on first start, even though StartSession can be called for initial creation
 	if isActualContinuation {
@@ -1799,36 +2034,27 @@ func StartSession(c *gin.Context) {
 		}
 
 		// Update the metadata and spec to persist the annotation and interactive flag
-		item, err = reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+		item, err = reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{})
 		if err != nil {
 			log.Printf("Failed to update agentic session metadata %s in project %s: %v", sessionName, project, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session metadata"})
 			return
 		}
 
-		// Regenerate runner token for continuation (old token may have expired)
-		log.Printf("StartSession: Regenerating runner token for session continuation")
-		if err := provisionRunnerTokenForSession(c, reqK8s, reqDyn, project, sessionName); err != nil {
-			log.Printf("Warning: failed to regenerate runner token for session %s/%s: %v", project, sessionName, err)
-			// Non-fatal: continue anyway, operator may retry
-		} else {
-			log.Printf("StartSession: Successfully regenerated runner token for continuation")
-
-			// Delete the old job so operator creates a new one
-			// This ensures fresh token and clean state
-			jobName := fmt.Sprintf("ambient-runner-%s", sessionName)
-			log.Printf("StartSession: Deleting old job %s to allow operator to create fresh one", jobName)
-			if err := reqK8s.BatchV1().Jobs(project).Delete(c.Request.Context(), jobName, v1.DeleteOptions{
-				PropagationPolicy: func() *v1.DeletionPropagation { p := v1.DeletePropagationBackground; return &p }(),
-			}); err != nil {
-				if !errors.IsNotFound(err) {
-					log.Printf("Warning: failed to delete old job %s: %v", jobName, err)
-				} else {
-					log.Printf("StartSession: Job %s already gone", jobName)
-				}
+		// Delete the old job so operator creates a new one
+		// The operator mints a fresh runner token when it sees the session back in Pending
+		jobName := fmt.Sprintf("%s-job", sessionName)
+		log.Printf("StartSession: Deleting old job %s to allow operator to create fresh one", jobName)
+		if err := reqK8s.BatchV1().Jobs(project).Delete(c.Request.Context(), jobName, v1.DeleteOptions{
+			PropagationPolicy: func() *v1.DeletionPropagation { p := v1.DeletePropagationBackground; return &p }(),
+		}); err != nil {
+			if !errors.IsNotFound(err) {
+				log.Printf("Warning: failed to delete old job %s: %v", jobName, err)
 			} else {
-				log.Printf("StartSession: Successfully deleted old job %s", jobName)
+				log.Printf("StartSession: Job %s already gone", jobName)
 			}
+		} else {
+			log.Printf("StartSession: Successfully deleted old job %s", jobName)
 		}
 	} else {
 		log.Printf("StartSession: Not setting parent-session-id (first run, no completion time)")
@@ -1847,13 +2073,18 @@ func StartSession(c *gin.Context) {
 	delete(status, "completionTime")
 	// Update start time for this run
 	status["startTime"] = time.Now().Format(time.RFC3339)
+	// A new run gets a new runner Job idempotency key, so the operator replaces a Job of the
+	// previous run that is still around instead of adopting it
+	attempt, _, _ := unstructured.NestedInt64(status, "runAttempt")
+	status["runAttempt"] = attempt + 1
+	delete(status, "jobName")
 
 	// Update the status subresource using backend SA (status updates require elevated permissions)
 	if DynamicClient == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "backend not initialized"})
 		return
 	}
-	updated, err := DynamicClient.Resource(gvr).Namespace(project).UpdateStatus(context.TODO(), item, v1.UpdateOptions{})
+	updated, err := DynamicClient.Resource(gvr).Namespace(project).UpdateStatus(c.Request.Context(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to start agentic session %s in project %s: %v", sessionName, project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start agentic session"})
@@ -1875,6 +2106,7 @@ func StartSession(c *gin.Context) {
 		session.Status = parseStatus(status)
 	}
 
+	recordSessionAction(c, project, sessionName, "start", "")
 	c.JSON(http.StatusAccepted, session)
 }
 
@@ -1885,10 +2117,10 @@ func StopSession(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
 	// Get current resource
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 			return
 		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
@@ -1924,7 +2156,7 @@ func StopSession(c *gin.Context) {
 
 	// First, delete the job itself with foreground propagation
 	deletePolicy := v1.DeletePropagationForeground
-	err = reqK8s.BatchV1().Jobs(project).Delete(context.TODO(), jobName, v1.DeleteOptions{
+	err = reqK8s.BatchV1().Jobs(project).Delete(c.Request.Context(), jobName, v1.DeleteOptions{
 		PropagationPolicy: &deletePolicy,
 	})
 	if err != nil {
@@ -1942,7 +2174,7 @@ func StopSession(c *gin.Context) {
 	// Then, explicitly delete all pods for this job (by job-name label)
 	podSelector := fmt.Sprintf("job-name=%s", jobName)
 	log.Printf("Deleting pods with job-name selector: %s", podSelector)
-	err = reqK8s.CoreV1().Pods(project).DeleteCollection(context.TODO(), v1.DeleteOptions{}, v1.ListOptions{
+	err = reqK8s.CoreV1().Pods(project).DeleteCollection(c.Request.Context(), v1.DeleteOptions{}, v1.ListOptions{
 		LabelSelector: podSelector,
 	})
 	if err != nil && !errors.IsNotFound(err) {
@@ -1954,7 +2186,7 @@ func StopSession(c *gin.Context) {
 	// Also delete any pods labeled with this session (in case owner refs are lost)
 	sessionPodSelector := fmt.Sprintf("agentic-session=%s", sessionName)
 	log.Printf("Deleting pods with agentic-session selector: %s", sessionPodSelector)
-	err = reqK8s.CoreV1().Pods(project).DeleteCollection(context.TODO(), v1.DeleteOptions{}, v1.ListOptions{
+	err = reqK8s.CoreV1().Pods(project).DeleteCollection(c.Request.Context(), v1.DeleteOptions{}, v1.ListOptions{
 		LabelSelector: sessionPodSelector,
 	})
 	if err != nil && !errors.IsNotFound(err) {
@@ -1974,7 +2206,7 @@ func StopSession(c *gin.Context) {
 			log.Printf("Setting interactive: true for stopped session %s to allow restart", sessionName)
 			spec["interactive"] = true
 			// Update spec first (must use Update, not UpdateStatus)
-			item, err = reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+			item, err = reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{})
 			if err != nil {
 				log.Printf("Failed to update session spec for %s: %v (continuing with status update)", sessionName, err)
 				// Continue anyway - status update is more important
@@ -1987,7 +2219,7 @@ func StopSession(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "backend not initialized"})
 		return
 	}
-	updated, err := DynamicClient.Resource(gvr).Namespace(project).UpdateStatus(context.TODO(), item, v1.UpdateOptions{})
+	updated, err := DynamicClient.Resource(gvr).Namespace(project).UpdateStatus(c.Request.Context(), item, v1.UpdateOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Session was deleted while we were trying to update it
@@ -2016,6 +2248,7 @@ func StopSession(c *gin.Context) {
 	}
 
 	log.Printf("Successfully stopped agentic session %s", sessionName)
+	recordSessionAction(c, project, sessionName, "stop", "")
 	c.JSON(http.StatusAccepted, session)
 }
 
@@ -2035,10 +2268,10 @@ func UpdateSessionStatus(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
 	// Get current resource
-	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
 			return
 		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
@@ -2052,17 +2285,58 @@ func UpdateSessionStatus(c *gin.Context) {
 	}
 	status := item.Object["status"].(map[string]interface{})
 
+	// The runner's credential pre-flight is stored as a condition, not as a raw field
+	if raw, ok := statusUpdate["credentialCheck"]; ok {
+		if err := setCredentialsVerified(status, raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Accept standard fields and result summary fields from runner
 	allowed := map[string]struct{}{
 		"phase": {}, "completionTime": {}, "cost": {}, "message": {},
 		"subtype": {}, "duration_ms": {}, "duration_api_ms": {}, "is_error": {},
 		"num_turns": {}, "session_id": {}, "total_cost_usd": {}, "usage": {}, "result": {},
+		"rateLimit": {},
 	}
 	for k := range statusUpdate {
 		if _, ok := allowed[k]; !ok {
 			delete(statusUpdate, k)
 		}
 	}
+	// Runner messages quote git and tool errors, which can carry tokens; never store them
+	statusUpdate = redact.Map(statusUpdate)
+
+	// The result must match the typed schema; a plain string from an older runner is
+	// converted so the CR never stores a free-form blob
+	if raw, ok := statusUpdate["result"]; ok {
+		isError, _ := statusUpdate["is_error"].(bool)
+		res, err := apiv1alpha1.ParseSessionResult(raw, isError)
+		if err == nil && res != nil {
+			err = res.Validate()
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if res == nil {
+			delete(statusUpdate, "result")
+		} else if statusUpdate["result"], err = res.ToUnstructured(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode session result"})
+			return
+		}
+	}
+
+	// The operator schedules on rate limit reports, so they must match the typed schema too
+	if raw, ok := statusUpdate["rateLimit"]; ok {
+		if err := validateRateLimitReport(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	prevUsage, _ := status["usage"].(map[string]interface{})
 
 	// Merge remaining fields into status
 	for k, v := range statusUpdate {
@@ -2074,11 +2348,15 @@ func UpdateSessionStatus(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "backend not initialized"})
 		return
 	}
-	if _, err := DynamicClient.Resource(gvr).Namespace(project).UpdateStatus(context.TODO(), item, v1.UpdateOptions{}); err != nil {
+	if _, err := DynamicClient.Resource(gvr).Namespace(project).UpdateStatus(c.Request.Context(), item, v1.UpdateOptions{}); err != nil {
 		log.Printf("Failed to update agentic session status %s in project %s: %v", sessionName, project, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update agentic session status"})
 		return
 	}
+	if usage, ok := statusUpdate["usage"].(map[string]interface{}); ok {
+		isError, _ := statusUpdate["is_error"].(bool)
+		recordTurnTelemetry(project, sessionName, item, prevUsage, usage, isError)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "agentic session status updated"})
 }
@@ -2093,7 +2371,7 @@ func SpawnContentPod(c *gin.Context) {
 	}
 	sessionName := c.Param("sessionName")
 
-	reqK8s, _ := GetK8sClientsForRequest(c)
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
@@ -2114,8 +2392,9 @@ func SpawnContentPod(c *gin.Context) {
 		return
 	}
 
-	// Verify PVC exists
-	pvcName := fmt.Sprintf("ambient-workspace-%s", sessionName)
+	// Verify PVC exists; members of a session group browse the group's shared volume
+	session, _ := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	pvcName := sessionWorkspacePVCName(session, sessionName)
 	if _, err := reqK8s.CoreV1().PersistentVolumeClaims(project).Get(c.Request.Context(), pvcName, v1.GetOptions{}); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "workspace PVC not found"})
 		return
@@ -2473,9 +2752,9 @@ func GetSessionK8sResources(c *gin.Context) {
 
 	result["pods"] = podInfos
 
-	// Get PVC info - always use session's own PVC name
+	// Get PVC info - the session's own PVC, or its group's shared one
 	// Note: If session was created with parent_session_id (via API), the operator handles PVC reuse
-	pvcName := fmt.Sprintf("ambient-workspace-%s", sessionName)
+	pvcName := sessionWorkspacePVCName(session, sessionName)
 	pvc, err := reqK8s.CoreV1().PersistentVolumeClaims(project).Get(c.Request.Context(), pvcName, v1.GetOptions{})
 	result["pvcName"] = pvcName
 	if err == nil {
@@ -2491,9 +2770,9 @@ func GetSessionK8sResources(c *gin.Context) {
 }
 
 // setRepoStatus updates status.repos[idx] with status and diff info
-func setRepoStatus(dyn dynamic.Interface, project, sessionName string, repoIndex int, newStatus string) error {
+func setRepoStatus(ctx context.Context, dyn dynamic.Interface, project, sessionName string, repoIndex int, newStatus string) error {
 	gvr := GetAgenticSessionV1Alpha1Resource()
-	item, err := dyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+	item, err := dyn.Resource(gvr).Namespace(project).Get(ctx, sessionName, v1.GetOptions{})
 	if err != nil {
 		return err
 	}
@@ -2554,7 +2833,7 @@ func setRepoStatus(dyn dynamic.Interface, project, sessionName string, repoIndex
 	status["repos"] = statusRepos
 	item.Object["status"] = status
 
-	updated, err := dyn.Resource(gvr).Namespace(project).UpdateStatus(context.TODO(), item, v1.UpdateOptions{})
+	updated, err := dyn.Resource(gvr).Namespace(project).UpdateStatus(ctx, item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("setRepoStatus: update failed project=%s session=%s repoIndex=%d status=%s err=%v", project, sessionName, repoIndex, newStatus, err)
 		return err
@@ -2681,6 +2960,14 @@ func GetSessionWorkspaceFile(c *gin.Context) {
 	}
 	defer resp.Body.Close()
 	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		// Files may echo values injected through spec.envFromSecrets
+		if b, err = redactSessionSecrets(c.Request.Context(), project, session, b); err != nil {
+			log.Printf("GetSessionWorkspaceFile: failed to load secrets to redact for %s/%s: %v", project, session, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redact session secrets"})
+			return
+		}
+	}
 	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), b)
 }
 
@@ -2742,9 +3029,28 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), rb)
 }
 
+// enforceBranchPolicy checks the target branch against the project's branch protection policy
+// and writes a 403 when it is not allowed. The policy is read with the backend service account
+// so enforcement does not depend on whether the caller can read ProjectSettings.
+func enforceBranchPolicy(c *gin.Context, project, branch string) bool {
+	policy, err := git.GetBranchPolicy(c.Request.Context(), DynamicClient, project)
+	if err != nil {
+		log.Printf("enforceBranchPolicy: failed to load policy for project %s: %v", project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load branch policy"})
+		return false
+	}
+	if err := policy.CheckTargetBranch(branch); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
 // PushSessionRepo proxies a push request for a given session repo to the per-job content service.
+// The project's publish checks run on the workspace diff first; failures return 422 unless a
+// project admin sets force.
 // POST /api/projects/:projectName/agentic-sessions/:sessionName/github/push
-// Body: { repoIndex: number, commitMessage?: string, branch?: string }
+// Body: { repoIndex: number, commitMessage?: string, branch?: string, force?: boolean }
 func PushSessionRepo(c *gin.Context) {
 	project := c.Param("projectName")
 	session := c.Param("sessionName")
@@ -2752,6 +3058,7 @@ func PushSessionRepo(c *gin.Context) {
 	var body struct {
 		RepoIndex     int    `json:"repoIndex"`
 		CommitMessage string `json:"commitMessage"`
+		Force         bool   `json:"force"`
 	}
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
@@ -2777,6 +3084,7 @@ func PushSessionRepo(c *gin.Context) {
 	// default branch when not defined on output
 	resolvedBranch := fmt.Sprintf("sessions/%s", session)
 	resolvedOutputURL := ""
+	resolvedRepoName := fmt.Sprintf("repo-%d", body.RepoIndex)
 	if _, reqDyn := GetK8sClientsForRequest(c); reqDyn != nil {
 		gvr := GetAgenticSessionV1Alpha1Resource()
 		obj, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
@@ -2797,9 +3105,14 @@ func PushSessionRepo(c *gin.Context) {
 				folder := DeriveRepoFolderFromURL(strings.TrimSpace(urlv))
 				if folder != "" {
 					resolvedRepoPath = fmt.Sprintf("/sessions/%s/workspace/%s", session, folder)
+					resolvedRepoName = folder
 				}
 			}
 		}
+		// Same naming as status.repos (see setRepoStatus)
+		if name, ok := rm["name"].(string); ok && name != "" {
+			resolvedRepoName = name
+		}
 		if out, ok := rm["output"].(map[string]interface{}); ok {
 			if urlv, ok2 := out["url"].(string); ok2 && strings.TrimSpace(urlv) != "" {
 				resolvedOutputURL = strings.TrimSpace(urlv)
@@ -2827,6 +3140,12 @@ func PushSessionRepo(c *gin.Context) {
 		return
 	}
 	log.Printf("pushSessionRepo: resolved repoPath=%q outputUrl=%q branch=%q", resolvedRepoPath, resolvedOutputURL, resolvedBranch)
+	if !enforceBranchPolicy(c, project, resolvedBranch) {
+		return
+	}
+	if !enforcePublishChecks(c, project, session, endpoint, resolvedRepoName, resolvedRepoPath, body.Force) {
+		return
+	}
 
 	payload := map[string]interface{}{
 		"repoPath":      resolvedRepoPath,
@@ -2895,13 +3214,14 @@ func PushSessionRepo(c *gin.Context) {
 	}
 	if DynamicClient != nil {
 		log.Printf("pushSessionRepo: setting repo status to 'pushed' for repoIndex=%d", body.RepoIndex)
-		if err := setRepoStatus(DynamicClient, project, session, body.RepoIndex, "pushed"); err != nil {
+		if err := setRepoStatus(c.Request.Context(), DynamicClient, project, session, body.RepoIndex, "pushed"); err != nil {
 			log.Printf("pushSessionRepo: setRepoStatus failed project=%s session=%s repoIndex=%d err=%v", project, session, body.RepoIndex, err)
 		}
 	} else {
 		log.Printf("pushSessionRepo: backend SA not available; cannot set repo status project=%s session=%s", project, session)
 	}
 	log.Printf("pushSessionRepo: content push succeeded status=%d body.len=%d", resp.StatusCode, len(bodyBytes))
+	recordSessionAction(c, project, session, "push_repo", resolvedRepoPath)
 	c.Data(http.StatusOK, "application/json", bodyBytes)
 }
 
@@ -2964,12 +3284,13 @@ func AbandonSessionRepo(c *gin.Context) {
 		return
 	}
 	if DynamicClient != nil {
-		if err := setRepoStatus(DynamicClient, project, session, body.RepoIndex, "abandoned"); err != nil {
+		if err := setRepoStatus(c.Request.Context(), DynamicClient, project, session, body.RepoIndex, "abandoned"); err != nil {
 			log.Printf("abandonSessionRepo: setRepoStatus failed project=%s session=%s repoIndex=%d err=%v", project, session, body.RepoIndex, err)
 		}
 	} else {
 		log.Printf("abandonSessionRepo: backend SA not available; cannot set repo status project=%s session=%s", project, session)
 	}
+	recordSessionAction(c, project, session, "abandon_repo", repoPath)
 	c.Data(http.StatusOK, "application/json", bodyBytes)
 }
 
@@ -3022,6 +3343,14 @@ func DiffSessionRepo(c *gin.Context) {
 	}
 	defer resp.Body.Close()
 	bodyBytes, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		// Diffs may echo values injected through spec.envFromSecrets
+		if bodyBytes, err = redactSessionSecrets(c.Request.Context(), project, session, bodyBytes); err != nil {
+			log.Printf("DiffSessionRepo: failed to load secrets to redact for %s/%s: %v", project, session, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redact session secrets"})
+			return
+		}
+	}
 	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
 }
 
@@ -3084,7 +3413,7 @@ func ConfigureGitRemote(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -3179,7 +3508,7 @@ func SynchronizeGit(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -3187,6 +3516,14 @@ func SynchronizeGit(c *gin.Context) {
 	if body.Message == "" {
 		body.Message = fmt.Sprintf("Session %s - %s", session, time.Now().Format(time.RFC3339))
 	}
+	// Content service syncs to main when no branch is given
+	targetBranch := body.Branch
+	if targetBranch == "" {
+		targetBranch = "main"
+	}
+	if !enforceBranchPolicy(c, project, targetBranch) {
+		return
+	}
 
 	// Build absolute path
 	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, body.Path)
@@ -3285,7 +3622,7 @@ func GitPullSession(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -3345,7 +3682,7 @@ func GitPushSession(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -3358,6 +3695,9 @@ func GitPushSession(c *gin.Context) {
 	if body.Message == "" {
 		body.Message = fmt.Sprintf("Session %s artifacts", session)
 	}
+	if !enforceBranchPolicy(c, project, body.Branch) {
+		return
+	}
 
 	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, body.Path)
 
@@ -3408,7 +3748,7 @@ func GitCreateBranchSession(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondMessage(c, http.StatusBadRequest, "request.invalidBody", nil)
 		return
 	}
 
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/redact"
+	"ambient-code-pkg/settingshistory"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// recordSettingsRevision attributes a ProjectSettings write made through the backend to the
+// calling user. The operator snapshots every change as well, so a failure here only loses
+// the author, never the revision.
+func recordSettingsRevision(c *gin.Context, obj *unstructured.Unstructured, rollbackOf int64) {
+	if K8sClient == nil || obj == nil {
+		return
+	}
+	var ps apiv1alpha1.ProjectSettings
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ps); err != nil {
+		log.Printf("Failed to decode ProjectSettings %s for history: %v", obj.GetNamespace(), err)
+		return
+	}
+	author, _ := getUserSubjectFromContext(c)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), K8sCallTimeout)
+	defer cancel()
+	if _, err := settingshistory.Record(ctx, K8sClient, &ps, author, rollbackOf); err != nil {
+		log.Printf("Failed to record ProjectSettings revision in %s: %v", ps.Namespace, err)
+	}
+}
+
+// ListSettingsRevisions handles GET /api/projects/:projectName/settings/revisions
+// Revisions are newest first, each with the fields changed since the previous one.
+func ListSettingsRevisions(c *gin.Context) {
+	projectName := c.Param("projectName")
+	if !requireSettingsAccess(c, projectName, false) {
+		return
+	}
+
+	revs, err := settingshistory.List(c.Request.Context(), K8sClient, projectName)
+	if err != nil {
+		log.Printf("Failed to list ProjectSettings revisions in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list settings revisions"})
+		return
+	}
+	items := make([]types.SettingsRevision, 0, len(revs))
+	for i := range revs {
+		var prev *settingshistory.Revision
+		if i+1 < len(revs) {
+			prev = &revs[i+1]
+		}
+		items = append(items, settingsRevisionResponse(&revs[i], prev, false))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetSettingsRevision handles GET /api/projects/:projectName/settings/revisions/:revision
+func GetSettingsRevision(c *gin.Context) {
+	projectName := c.Param("projectName")
+	revision, ok := parseRevisionParam(c)
+	if !ok || !requireSettingsAccess(c, projectName, false) {
+		return
+	}
+
+	revs, err := settingshistory.List(c.Request.Context(), K8sClient, projectName)
+	if err != nil {
+		log.Printf("Failed to list ProjectSettings revisions in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read settings revision"})
+		return
+	}
+	for i := range revs {
+		if revs[i].Revision != revision {
+			continue
+		}
+		var prev *settingshistory.Revision
+		if i+1 < len(revs) {
+			prev = &revs[i+1]
+		}
+		if wantsYAML(c) {
+			// The revision as a ProjectSettings manifest, ready to commit in place of the current one
+			spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&revs[i].Spec)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read settings revision"})
+				return
+			}
+			respondYAML(c, http.StatusOK, map[string]interface{}{
+				"apiVersion": apiv1alpha1.SchemeGroupVersion.String(),
+				"kind":       "ProjectSettings",
+				"metadata":   map[string]interface{}{"name": apiv1alpha1.ProjectSettingsName, "namespace": projectName},
+				"spec":       spec,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, settingsRevisionResponse(&revs[i], prev, true))
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+}
+
+// GetProjectSettings handles GET /api/projects/:projectName/settings
+// Returns the ProjectSettings custom resource; with Accept: application/yaml it is rendered as
+// a manifest for GitOps repos.
+func GetProjectSettings(c *gin.Context) {
+	projectName := c.Param("projectName")
+	if !requireSettingsAccess(c, projectName, false) {
+		return
+	}
+	_, reqDyn := GetK8sClientsForRequest(c)
+
+	obj, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(projectName).Get(c.Request.Context(), apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project settings not found"})
+			return
+		}
+		log.Printf("Failed to get ProjectSettings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"})
+		return
+	}
+	if wantsYAML(c) {
+		respondYAML(c, http.StatusOK, gitOpsManifest(obj))
+		return
+	}
+	c.JSON(http.StatusOK, obj.Object)
+}
+
+// RollbackSettingsRevision handles POST /api/projects/:projectName/settings/revisions/:revision/rollback
+// The spec of the revision replaces the current spec; the rollback is itself a new revision.
+func RollbackSettingsRevision(c *gin.Context) {
+	projectName := c.Param("projectName")
+	revision, ok := parseRevisionParam(c)
+	if !ok || !requireSettingsAccess(c, projectName, true) {
+		return
+	}
+	_, reqDyn := GetK8sClientsForRequest(c)
+
+	rev, err := settingshistory.Get(c.Request.Context(), K8sClient, projectName, revision)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		log.Printf("Failed to get ProjectSettings revision %d in %s: %v", revision, projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read settings revision"})
+		return
+	}
+	spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rev.Spec)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read settings revision"})
+		return
+	}
+
+	// Write with the caller's token so RBAC decides whether the rollback is allowed
+	gvr := GetProjectSettingsResource()
+	obj, err := reqDyn.Resource(gvr).Namespace(projectName).Get(c.Request.Context(), apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		// Restoring a deleted ProjectSettings is the main use of the history
+		obj = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiv1alpha1.SchemeGroupVersion.String(),
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": apiv1alpha1.ProjectSettingsName, "namespace": projectName},
+			"spec":       spec,
+		}}
+		obj, err = reqDyn.Resource(gvr).Namespace(projectName).Create(c.Request.Context(), obj, v1.CreateOptions{})
+	case err == nil:
+		obj.Object["spec"] = spec
+		obj, err = reqDyn.Resource(gvr).Namespace(projectName).Update(c.Request.Context(), obj, v1.UpdateOptions{})
+	}
+	if err != nil {
+		switch {
+		case errors.IsForbidden(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to update project settings"})
+		case errors.IsConflict(err):
+			c.JSON(http.StatusConflict, gin.H{"error": "Project settings changed concurrently, retry"})
+		default:
+			log.Printf("Failed to roll back ProjectSettings in %s to revision %d: %v", projectName, revision, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project settings"})
+		}
+		return
+	}
+
+	recordSettingsRevision(c, obj, revision)
+	log.Printf("ProjectSettings in %s rolled back to revision %d", projectName, revision)
+	c.JSON(http.StatusOK, gin.H{"message": "Project settings rolled back", "revision": revision, "generation": obj.GetGeneration()})
+}
+
+// requireSettingsAccess checks the caller may view (or, with modify, update) ProjectSettings.
+// Revisions are ConfigMaps read with the backend SA, so access is decided on the settings.
+func requireSettingsAccess(c *gin.Context, projectName string, modify bool) bool {
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		c.Abort()
+		return false
+	}
+	check := checkUserCanViewProject
+	if modify {
+		check = checkUserCanModifyProject
+	}
+	allowed, err := check(reqK8s, projectName)
+	if err != nil {
+		log.Printf("Failed to check settings access in %s: %v", projectName, err)
+		respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
+		return false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to access project settings"})
+		return false
+	}
+	return true
+}
+
+func parseRevisionParam(c *gin.Context) (int64, bool) {
+	revision, err := strconv.ParseInt(c.Param("revision"), 10, 64)
+	if err != nil || revision <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision"})
+		return 0, false
+	}
+	return revision, true
+}
+
+func settingsRevisionResponse(rev, prev *settingshistory.Revision, withSpec bool) types.SettingsRevision {
+	var from apiv1alpha1.ProjectSettingsSpec
+	if prev != nil {
+		from = prev.Spec
+	}
+	out := types.SettingsRevision{
+		Revision:   rev.Revision,
+		Generation: rev.Generation,
+		Author:     rev.Author,
+		Manager:    rev.Manager,
+		ChangedAt:  rev.ChangedAt.Format(time.RFC3339),
+		RollbackOf: rev.RollbackOf,
+		Changes:    settingshistory.Diff(from, rev.Spec),
+	}
+	// The history is an audit trail read by every settings viewer; credentials pasted into
+	// prompts or URLs are masked
+	redactChanges(out.Changes)
+	if withSpec {
+		if spec, err := redact.Object(rev.Spec); err == nil {
+			out.Spec = &spec
+		} else {
+			log.Printf("Failed to redact settings revision %d: %v", rev.Revision, err)
+		}
+	}
+	return out
+}
+
+// redactChanges masks credentials in the old and new values of changes
+func redactChanges(changes []settingshistory.Change) {
+	for i := range changes {
+		// Wrapped under the changed field's name so a credential-named field is masked whole
+		ch := &changes[i]
+		key := ch.Path[strings.LastIndex(ch.Path, ".")+1:]
+		ch.Old = redact.Map(map[string]interface{}{key: ch.Old})[key]
+		ch.New = redact.Map(map[string]interface{}{key: ch.New})[key]
+	}
+}
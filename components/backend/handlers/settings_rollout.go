@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/settingshistory"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Settings rollouts apply one patch to the ProjectSettings of many projects, e.g. a new
+// default runner image or quota, in place of kubectl loops. Every project is written with the
+// caller's token and recorded in the project's settings history like any other change. A
+// rollout is also recorded as a ConfigMap in the backend namespace holding each updated
+// project's spec before and after, so it can be rolled back as a whole.
+
+const (
+	settingsRolloutLabel = "ambient-code.io/settings-rollout"
+	settingsRolloutKey   = "rollout.json"
+	// maxRolloutProjects keeps a rollout record well within a ConfigMap
+	maxRolloutProjects = 500
+)
+
+func settingsRolloutConfigMapName(id string) string {
+	return "settings-rollout-" + id
+}
+
+// requireSettingsAdmin allows callers who may update ProjectSettings in every namespace
+func requireSettingsAdmin(c *gin.Context) (*kubernetes.Clientset, dynamic.Interface, bool) {
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return nil, nil, false
+	}
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Verb:     "update",
+				Group:    "vteam.ambient-code",
+				Resource: "projectsettings",
+			},
+		},
+	}
+	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
+	if err != nil {
+		log.Printf("Settings rollout: access review failed: %v", err)
+		respondMessage(c, http.StatusInternalServerError, "auth.accessReviewFailed", nil)
+		return nil, nil, false
+	}
+	if !res.Status.Allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only platform admins (allowed to update projectsettings in all namespaces) can roll out settings"})
+		return nil, nil, false
+	}
+	return reqK8s, reqDyn, true
+}
+
+// RolloutProjectSettings handles POST /api/admin/settings/rollout
+// Results are per project; a project that fails does not stop the others.
+func RolloutProjectSettings(c *gin.Context) {
+	reqK8s, reqDyn, ok := requireSettingsAdmin(c)
+	if !ok {
+		return
+	}
+	var req types.SettingsRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(req.Patch, &patch); err != nil || len(patch) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "patch must be a non-empty JSON object"})
+		return
+	}
+	projects, err := rolloutProjects(c.Request.Context(), req.Projects, req.LabelSelector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(projects) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No projects match"})
+		return
+	}
+	if len(projects) > maxRolloutProjects {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d projects can be updated at once", maxRolloutProjects)})
+		return
+	}
+
+	author, _ := getUserSubjectFromContext(c)
+	record := types.SettingsRolloutRecord{SettingsRollout: types.SettingsRollout{
+		DryRun:    req.DryRun,
+		CreatedBy: author,
+		CreatedAt: time.Now().UTC(),
+		Patch:     req.Patch,
+		Results:   []types.SettingsRolloutResult{},
+	}}
+	for _, project := range projects {
+		result, changed := rolloutProject(c, reqK8s, reqDyn, project, patch, req.DryRun)
+		record.Results = append(record.Results, result)
+		if changed != nil {
+			record.Projects = append(record.Projects, *changed)
+		}
+	}
+
+	if !req.DryRun && len(record.Projects) > 0 {
+		record.ID = newRecordID()
+		if err := saveSettingsRollout(c.Request.Context(), &record, true); err != nil {
+			// The projects are updated; only the whole-rollout rollback is lost
+			log.Printf("Settings rollout: failed to record rollout %s: %v", record.ID, err)
+			record.ID = ""
+		}
+	}
+	log.Printf("Settings rollout %s by %s: %s", record.ID, author, summarizeRollout(record.Results))
+	c.JSON(http.StatusOK, settingsRolloutResponse(&record.SettingsRollout))
+}
+
+// GetSettingsRollout handles GET /api/admin/settings/rollouts/:rolloutId
+func GetSettingsRollout(c *gin.Context) {
+	if _, _, ok := requireSettingsAdmin(c); !ok {
+		return
+	}
+	record, ok := loadSettingsRollout(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, settingsRolloutResponse(&record.SettingsRollout))
+}
+
+// RollbackSettingsRollout handles POST /api/admin/settings/rollouts/:rolloutId/rollback
+// Each project gets back its spec from before the rollout, unless its settings changed
+// again since; those are skipped rather than overwritten. ?dryRun=true reports what would
+// happen.
+func RollbackSettingsRollout(c *gin.Context) {
+	reqK8s, reqDyn, ok := requireSettingsAdmin(c)
+	if !ok {
+		return
+	}
+	record, ok := loadSettingsRollout(c)
+	if !ok {
+		return
+	}
+	if record.RolledBackAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Rollout already rolled back at %s", record.RolledBackAt.Format(time.RFC3339))})
+		return
+	}
+	dryRun := c.Query("dryRun") == "true"
+
+	author, _ := getUserSubjectFromContext(c)
+	out := types.SettingsRollout{ID: record.ID, DryRun: dryRun, CreatedBy: author, CreatedAt: time.Now().UTC(), Results: []types.SettingsRolloutResult{}}
+	for _, p := range record.Projects {
+		out.Results = append(out.Results, rollbackProject(c, reqK8s, reqDyn, p, dryRun))
+	}
+	if !dryRun {
+		now := time.Now().UTC()
+		record.RolledBackAt = &now
+		if err := saveSettingsRollout(c.Request.Context(), record, false); err != nil {
+			log.Printf("Settings rollout: failed to mark rollout %s rolled back: %v", record.ID, err)
+		}
+		out.RolledBackAt = &now
+	}
+	log.Printf("Settings rollout %s rolled back by %s: %s", record.ID, author, summarizeRollout(out.Results))
+	c.JSON(http.StatusOK, settingsRolloutResponse(&out))
+}
+
+// rolloutProjects resolves the target projects: the named ones, or every managed project
+// whose namespace matches selector
+func rolloutProjects(ctx context.Context, names []string, selector string) ([]string, error) {
+	if len(names) > 0 {
+		if selector != "" {
+			return nil, fmt.Errorf("projects and labelSelector are mutually exclusive")
+		}
+		seen := map[string]bool{}
+		out := []string{}
+		for _, n := range names {
+			n = strings.TrimSpace(n)
+			if n != "" && !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+			}
+		}
+		return out, nil
+	}
+	sel := "ambient-code.io/managed=true"
+	if selector != "" {
+		if _, err := labels.Parse(selector); err != nil {
+			return nil, fmt.Errorf("invalid labelSelector: %v", err)
+		}
+		sel += "," + selector
+	}
+	list, err := K8sClient.CoreV1().Namespaces().List(ctx, v1.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %v", err)
+	}
+	out := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		out = append(out, ns.Name)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// rolloutProject merges patch into one project's spec. It returns the project's before and
+// after specs when it was updated.
+func rolloutProject(c *gin.Context, reqK8s *kubernetes.Clientset, reqDyn dynamic.Interface, project string, patch map[string]interface{}, dryRun bool) (types.SettingsRolloutResult, *types.SettingsRolloutProject) {
+	ctx := c.Request.Context()
+	result := types.SettingsRolloutResult{Project: project}
+	fail := func(format string, args ...interface{}) (types.SettingsRolloutResult, *types.SettingsRolloutProject) {
+		result.Status = types.RolloutFailed
+		result.Error = fmt.Sprintf(format, args...)
+		return result, nil
+	}
+
+	if ns, err := K8sClient.CoreV1().Namespaces().Get(ctx, project, v1.GetOptions{}); err != nil || ns.Labels["ambient-code.io/managed"] != "true" {
+		result.Status = types.RolloutSkipped
+		result.Error = "not an Ambient project"
+		return result, nil
+	}
+	client := reqDyn.Resource(GetProjectSettingsResource()).Namespace(project)
+	obj, err := client.Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		result.Status = types.RolloutSkipped
+		result.Error = "project has no ProjectSettings yet"
+		return result, nil
+	}
+	if err != nil {
+		return fail("failed to read project settings: %v", err)
+	}
+	var current apiv1alpha1.ProjectSettings
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &current); err != nil {
+		return fail("failed to read project settings: %v", err)
+	}
+
+	specMap, _ := obj.Object["spec"].(map[string]interface{})
+	merged := mergePatch(runtime.DeepCopyJSONValue(specMap), patch)
+	desired, err := decodeSettingsSpec(merged)
+	if err != nil {
+		return fail("patch does not produce a valid spec: %v", err)
+	}
+	if err := validateProjectSettingsSpec(ctx, reqK8s, project, desired); err != nil {
+		return fail("%v", err)
+	}
+	result.Changes = settingshistory.Diff(current.Spec, *desired)
+	if equality.Semantic.DeepEqual(current.Spec, *desired) {
+		result.Status = types.RolloutUnchanged
+		return result, nil
+	}
+	if dryRun {
+		result.Status = types.RolloutWouldUpdate
+		return result, nil
+	}
+
+	desiredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return fail("%v", err)
+	}
+	obj.Object["spec"] = desiredMap
+	updated, err := client.Update(ctx, obj, v1.UpdateOptions{})
+	if err != nil {
+		switch {
+		case errors.IsForbidden(err):
+			return fail("not authorized to update project settings")
+		case errors.IsConflict(err):
+			return fail("project settings changed concurrently, retry")
+		}
+		return fail("failed to update project settings: %v", err)
+	}
+	recordSettingsRevision(c, updated, 0)
+	result.Status = types.RolloutUpdated
+	// The stored spec, after API server defaulting, is what a rollback compares against
+	after := *desired
+	var written apiv1alpha1.ProjectSettings
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(updated.Object, &written); err == nil {
+		after = written.Spec
+	}
+	return result, &types.SettingsRolloutProject{Project: project, Before: current.Spec, After: after}
+}
+
+// rollbackProject restores one project's spec from before the rollout
+func rollbackProject(c *gin.Context, reqK8s *kubernetes.Clientset, reqDyn dynamic.Interface, p types.SettingsRolloutProject, dryRun bool) types.SettingsRolloutResult {
+	ctx := c.Request.Context()
+	result := types.SettingsRolloutResult{Project: p.Project}
+	client := reqDyn.Resource(GetProjectSettingsResource()).Namespace(p.Project)
+	obj, err := client.Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		result.Status = types.RolloutFailed
+		result.Error = fmt.Sprintf("failed to read project settings: %v", err)
+		return result
+	}
+	var current apiv1alpha1.ProjectSettings
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &current); err != nil {
+		result.Status = types.RolloutFailed
+		result.Error = fmt.Sprintf("failed to read project settings: %v", err)
+		return result
+	}
+	if equality.Semantic.DeepEqual(current.Spec, p.Before) {
+		result.Status = types.RolloutUnchanged
+		return result
+	}
+	if !equality.Semantic.DeepEqual(current.Spec, p.After) {
+		result.Status = types.RolloutSkipped
+		result.Error = "settings changed since the rollout; roll back this project from its settings history"
+		result.Changes = settingshistory.Diff(p.After, current.Spec)
+		return result
+	}
+	result.Changes = settingshistory.Diff(current.Spec, p.Before)
+	if dryRun {
+		result.Status = types.RolloutWouldUpdate
+		return result
+	}
+
+	before, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&p.Before)
+	if err != nil {
+		result.Status = types.RolloutFailed
+		result.Error = err.Error()
+		return result
+	}
+	obj.Object["spec"] = before
+	updated, err := client.Update(ctx, obj, v1.UpdateOptions{})
+	if err != nil {
+		result.Status = types.RolloutFailed
+		result.Error = fmt.Sprintf("failed to update project settings: %v", err)
+		if errors.IsConflict(err) {
+			result.Error = "project settings changed concurrently, retry"
+		}
+		return result
+	}
+	recordSettingsRevision(c, updated, 0)
+	result.Status = types.RolloutUpdated
+	return result
+}
+
+// mergePatch applies an RFC 7386 JSON Merge Patch to target
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = map[string]interface{}{}
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+			continue
+		}
+		targetMap[k] = mergePatch(targetMap[k], v)
+	}
+	return targetMap
+}
+
+// decodeSettingsSpec decodes a spec, rejecting fields ProjectSettings does not have so a
+// misspelled patch fails instead of being silently dropped
+func decodeSettingsSpec(spec interface{}) (*apiv1alpha1.ProjectSettingsSpec, error) {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	out := &apiv1alpha1.ProjectSettingsSpec{}
+	if err := dec.Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// newRecordID names audit records kept as ConfigMaps: sortable by time, unique per call
+func newRecordID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// saveSettingsRollout creates (or updates) the ConfigMap recording a rollout
+func saveSettingsRollout(ctx context.Context, record *types.SettingsRolloutRecord, create bool) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	cms := K8sClient.CoreV1().ConfigMaps(Namespace)
+	if create {
+		_, err = cms.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:   settingsRolloutConfigMapName(record.ID),
+				Labels: map[string]string{settingsRolloutLabel: "true"},
+			},
+			Data: map[string]string{settingsRolloutKey: string(b)},
+		}, v1.CreateOptions{})
+		return err
+	}
+	cm, err := cms.Get(ctx, settingsRolloutConfigMapName(record.ID), v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	cm.Data = map[string]string{settingsRolloutKey: string(b)}
+	_, err = cms.Update(ctx, cm, v1.UpdateOptions{})
+	return err
+}
+
+func loadSettingsRollout(c *gin.Context) (*types.SettingsRolloutRecord, bool) {
+	id := c.Param("rolloutId")
+	cm, err := K8sClient.CoreV1().ConfigMaps(Namespace).Get(c.Request.Context(), settingsRolloutConfigMapName(id), v1.GetOptions{})
+	if errors.IsNotFound(err) || (err == nil && cm.Labels[settingsRolloutLabel] != "true") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rollout not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Settings rollout: failed to read rollout %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read rollout"})
+		return nil, false
+	}
+	record := &types.SettingsRolloutRecord{}
+	if err := json.Unmarshal([]byte(cm.Data[settingsRolloutKey]), record); err != nil {
+		log.Printf("Settings rollout: invalid record %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read rollout"})
+		return nil, false
+	}
+	return record, true
+}
+
+// settingsRolloutResponse masks credentials in the reported changes, as the settings history does
+func settingsRolloutResponse(r *types.SettingsRollout) *types.SettingsRollout {
+	for i := range r.Results {
+		redactChanges(r.Results[i].Changes)
+	}
+	return r
+}
+
+func summarizeRollout(results []types.SettingsRolloutResult) string {
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	parts := []string{}
+	for _, s := range []string{types.RolloutUpdated, types.RolloutWouldUpdate, types.RolloutUnchanged, types.RolloutSkipped, types.RolloutFailed} {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], strings.ToLower(s)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"ambient-code-backend/types"
+)
+
+func TestMergePatch(t *testing.T) {
+	var target, patch, want map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"maxIdleMinutes": 60, "kueue": {"queueName": "a", "priorityClassName": "low"}, "runnerSecretsName": "s"}`), &target)
+	_ = json.Unmarshal([]byte(`{"kueue": {"priorityClassName": "high"}, "runnerSecretsName": null, "networkPolicy": {"allowedDomains": ["pkg.go.dev"]}}`), &patch)
+	_ = json.Unmarshal([]byte(`{"maxIdleMinutes": 60, "kueue": {"queueName": "a", "priorityClassName": "high"}, "networkPolicy": {"allowedDomains": ["pkg.go.dev"]}}`), &want)
+	if got := mergePatch(target, patch); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergePatch() = %v, want %v", got, want)
+	}
+	// A nil spec (no fields set yet) is patched like an empty one
+	if got := mergePatch(nil, map[string]interface{}{"maxIdleMinutes": 30.0}); !reflect.DeepEqual(got, map[string]interface{}{"maxIdleMinutes": 30.0}) {
+		t.Errorf("mergePatch(nil) = %v", got)
+	}
+}
+
+func TestDecodeSettingsSpec(t *testing.T) {
+	spec, err := decodeSettingsSpec(map[string]interface{}{"maxIdleMinutes": 30})
+	if err != nil || spec.MaxIdleMinutes != 30 {
+		t.Errorf("decodeSettingsSpec() = %+v, %v", spec, err)
+	}
+	if _, err := decodeSettingsSpec(map[string]interface{}{"maxIdleMinute": 30}); err == nil {
+		t.Error("a misspelled field must be rejected")
+	}
+}
+
+func TestSummarizeRollout(t *testing.T) {
+	got := summarizeRollout([]types.SettingsRolloutResult{
+		{Status: types.RolloutUpdated}, {Status: types.RolloutUpdated}, {Status: types.RolloutFailed}, {Status: types.RolloutUnchanged},
+	})
+	if want := "2 updated, 1 unchanged, 1 failed"; got != want {
+		t.Errorf("summarizeRollout() = %q, want %q", got, want)
+	}
+}
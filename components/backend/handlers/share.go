@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	defaultShareLinkTTL = 24 * time.Hour
+	maxShareLinkTTL     = 7 * 24 * time.Hour
+
+	// shareGenerationAnnotation is bumped to revoke every share link of a session
+	shareGenerationAnnotation = "ambient-code.io/share-generation"
+)
+
+// shareClaims is the signed payload of a session share token. UID and Generation tie it to
+// one incarnation of the session and to the links not yet revoked.
+type shareClaims struct {
+	Project    string `json:"p"`
+	Session    string `json:"s"`
+	UID        string `json:"uid"`
+	Generation int64  `json:"gen,omitempty"`
+	ExpiresAt  int64  `json:"exp"`
+	CreatedBy  string `json:"by,omitempty"`
+}
+
+type createShareLinkRequest struct {
+	ExpiresInHours int `json:"expiresInHours,omitempty"`
+}
+
+func shareLinkSecret() string {
+	return strings.TrimSpace(os.Getenv("SHARE_LINK_SECRET"))
+}
+
+// signShareToken encodes claims as "<payload>.<signature>"
+func signShareToken(secret string, claims shareClaims) (string, error) {
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + signState(secret, payload), nil
+}
+
+// shareGeneration is the session's current share generation; links of older ones are revoked
+func shareGeneration(item *unstructured.Unstructured) int64 {
+	gen, _ := strconv.ParseInt(item.GetAnnotations()[shareGenerationAnnotation], 10, 64)
+	return gen
+}
+
+// validateShareToken verifies a share token's signature and expiry and returns its claims
+func validateShareToken(token string) (shareClaims, error) {
+	secret := shareLinkSecret()
+	if secret == "" {
+		return shareClaims{}, fmt.Errorf("session sharing not configured")
+	}
+	payload, sig, found := strings.Cut(token, ".")
+	if !found || payload == "" || sig == "" {
+		return shareClaims{}, fmt.Errorf("malformed share token")
+	}
+	if !hmac.Equal([]byte(signState(secret, payload)), []byte(sig)) {
+		return shareClaims{}, fmt.Errorf("bad share token signature")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return shareClaims{}, fmt.Errorf("malformed share token")
+	}
+	var claims shareClaims
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.UID == "" {
+		return shareClaims{}, fmt.Errorf("malformed share token")
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return shareClaims{}, fmt.Errorf("share link expired")
+	}
+	return claims, nil
+}
+
+// LoadSharedSession verifies a share token and returns the session it grants read-only
+// access to. The live session must be the one the link was made for (same UID) and its
+// links must not have been revoked since. On error, status is the HTTP status to respond
+// with and the error message is safe to return to the caller.
+func LoadSharedSession(ctx context.Context, token string) (*unstructured.Unstructured, int, error) {
+	claims, err := validateShareToken(token)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	item, err := DynamicClient.Resource(gvr).Namespace(claims.Project).Get(ctx, claims.Session, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, http.StatusNotFound, fmt.Errorf("session not found")
+		}
+		log.Printf("LoadSharedSession: failed to get session %s/%s: %v", claims.Project, claims.Session, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to get session")
+	}
+	// A session recreated under the same name is a different session
+	if string(item.GetUID()) != claims.UID {
+		return nil, http.StatusNotFound, fmt.Errorf("session not found")
+	}
+	if shareGeneration(item) != claims.Generation {
+		return nil, http.StatusUnauthorized, fmt.Errorf("share link revoked")
+	}
+	return item, http.StatusOK, nil
+}
+
+// CreateSessionShareLink handles POST /api/projects/:projectName/agentic-sessions/:sessionName/share
+// Mints a signed, expiring URL granting read-only access to the session's status, messages and result.
+func CreateSessionShareLink(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+
+	secret := shareLinkSecret()
+	if secret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Session sharing not configured"})
+		return
+	}
+
+	var req createShareLinkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	ttl := defaultShareLinkTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if ttl > maxShareLinkTTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expiresInHours must not exceed %d", int(maxShareLinkTTL.Hours()))})
+		return
+	}
+
+	// Caller must be able to read the session themselves before sharing it
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+		if errors.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to share this session"})
+			return
+		}
+		log.Printf("CreateSessionShareLink: failed to get session %s/%s: %v", project, sessionName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl).UTC()
+	claims := shareClaims{
+		Project:    project,
+		Session:    sessionName,
+		UID:        string(item.GetUID()),
+		Generation: shareGeneration(item),
+		ExpiresAt:  expiresAt.Unix(),
+		CreatedBy:  c.GetString("userID"),
+	}
+	token, err := signShareToken(secret, claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	log.Printf("Created share link for session %s/%s by %s (expires %s)", project, sessionName, claims.CreatedBy, expiresAt.Format(time.RFC3339))
+	recordSessionAction(c, project, sessionName, "share", "expires "+expiresAt.Format(time.RFC3339))
+	c.JSON(http.StatusCreated, gin.H{
+		"url":       fmt.Sprintf("/api/shared/%s", token),
+		"token":     token,
+		"expiresAt": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// RevokeSessionShareLinks handles DELETE /api/projects/:projectName/agentic-sessions/:sessionName/share
+// Invalidates every share link minted for the session so far.
+func RevokeSessionShareLinks(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+		log.Printf("RevokeSessionShareLinks: failed to get session %s/%s: %v", project, sessionName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		return
+	}
+
+	annotations := item.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[shareGenerationAnnotation] = strconv.FormatInt(shareGeneration(item)+1, 10)
+	item.SetAnnotations(annotations)
+	if _, err := reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{}); err != nil {
+		if errors.IsConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Session was modified, retry the request"})
+			return
+		}
+		if errors.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to revoke share links of this session"})
+			return
+		}
+		log.Printf("RevokeSessionShareLinks: failed to update session %s/%s: %v", project, sessionName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share links"})
+		return
+	}
+
+	log.Printf("Revoked share links of session %s/%s by %s", project, sessionName, c.GetString("userID"))
+	recordSessionAction(c, project, sessionName, "unshare", "share links revoked")
+	c.Status(http.StatusNoContent)
+}
+
+// GetSharedSession handles GET /api/shared/:token
+// Public endpoint: the signed token is the credential. Returns a read-only view of the
+// session status and result; spec details such as environment variables are omitted.
+func GetSharedSession(c *gin.Context) {
+	item, status, err := LoadSharedSession(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		if status == http.StatusNotFound {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := gin.H{
+		"project": item.GetNamespace(),
+		"name":    item.GetName(),
+	}
+	if spec, ok := item.Object["spec"].(map[string]interface{}); ok {
+		parsed := parseSpec(spec)
+		resp["displayName"] = parsed.DisplayName
+	}
+	if status, ok := item.Object["status"].(map[string]interface{}); ok {
+		resp["status"] = parseStatus(status)
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func shareTestToken(t *testing.T, claims shareClaims) string {
+	t.Helper()
+	if claims.ExpiresAt == 0 {
+		claims.ExpiresAt = time.Now().Add(time.Hour).Unix()
+	}
+	token, err := signShareToken("share-secret", claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func shareTestSession(project, name, uid, displayName string, generation string) runtime.Object {
+	metadata := map[string]interface{}{"name": name, "namespace": project, "uid": uid}
+	if generation != "" {
+		metadata["annotations"] = map[string]interface{}{shareGenerationAnnotation: generation}
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   metadata,
+		"spec":       map[string]interface{}{"displayName": displayName},
+	}}
+}
+
+// useShareTestSessions serves the given sessions from a fake DynamicClient
+func useShareTestSessions(t *testing.T, sessions ...runtime.Object) {
+	t.Helper()
+	t.Setenv("SHARE_LINK_SECRET", "share-secret")
+	gvr := schema.GroupVersionResource{Group: "vteam.ambient-code", Version: "v1alpha1", Resource: "agenticsessions"}
+	GetAgenticSessionV1Alpha1Resource = func() schema.GroupVersionResource { return gvr }
+	prev := DynamicClient
+	DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "AgenticSessionList"}, sessions...)
+	t.Cleanup(func() { DynamicClient = prev })
+}
+
+// TestValidateShareToken verifies a token is honored only while unexpired and unmodified
+func TestValidateShareToken(t *testing.T) {
+	t.Setenv("SHARE_LINK_SECRET", "share-secret")
+	valid := shareTestToken(t, shareClaims{Project: "team-a", Session: "session-a", UID: "uid-a"})
+
+	claims, err := validateShareToken(valid)
+	if err != nil || claims.Project != "team-a" || claims.Session != "session-a" || claims.UID != "uid-a" {
+		t.Fatalf("valid token: got (%+v, %v)", claims, err)
+	}
+
+	payload, sig, _ := strings.Cut(valid, ".")
+	tamperedSig := []byte(sig)
+	if tamperedSig[0] == 'a' {
+		tamperedSig[0] = 'b'
+	} else {
+		tamperedSig[0] = 'a'
+	}
+	// Session A's signature on a payload rewritten to name session B
+	raw, _ := json.Marshal(shareClaims{Project: "team-a", Session: "session-b", UID: "uid-b", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	swapped := base64.RawURLEncoding.EncodeToString(raw) + "." + sig
+	forged, _ := signShareToken("other-secret", shareClaims{Project: "team-a", Session: "session-a", UID: "uid-a", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+
+	cases := []struct {
+		name, token, wantErr string
+	}{
+		{"expired", shareTestToken(t, shareClaims{Project: "team-a", Session: "session-a", UID: "uid-a", ExpiresAt: time.Now().Add(-time.Minute).Unix()}), "share link expired"},
+		{"tampered signature", payload + "." + string(tamperedSig), "bad share token signature"},
+		{"session swapped", swapped, "bad share token signature"},
+		{"signed with another secret", forged, "bad share token signature"},
+		{"without session UID", shareTestToken(t, shareClaims{Project: "team-a", Session: "session-a"}), "malformed share token"},
+		{"missing signature", payload, "malformed share token"},
+		{"empty", "", "malformed share token"},
+	}
+	for _, tc := range cases {
+		if _, err := validateShareToken(tc.token); err == nil || err.Error() != tc.wantErr {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+
+	t.Setenv("SHARE_LINK_SECRET", "")
+	if _, err := validateShareToken(valid); err == nil {
+		t.Error("token accepted with sharing unconfigured")
+	}
+}
+
+// TestLoadSharedSession verifies a link resolves only to the session incarnation it was made
+// for, in its own project, and stops working once the session's links are revoked
+func TestLoadSharedSession(t *testing.T) {
+	useShareTestSessions(t,
+		shareTestSession("team-a", "foo", "uid-a", "A", ""),
+		shareTestSession("team-b", "bar", "uid-b", "B", ""),
+		shareTestSession("team-c", "revoked", "uid-c", "C", "2"),
+	)
+	cases := []struct {
+		name   string
+		claims shareClaims
+		status int
+	}{
+		{"valid", shareClaims{Project: "team-a", Session: "foo", UID: "uid-a"}, http.StatusOK},
+		{"other project's session of the same name", shareClaims{Project: "team-b", Session: "foo", UID: "uid-a"}, http.StatusNotFound},
+		{"session recreated under the same name", shareClaims{Project: "team-a", Session: "foo", UID: "uid-old"}, http.StatusNotFound},
+		{"revoked", shareClaims{Project: "team-c", Session: "revoked", UID: "uid-c", Generation: 1}, http.StatusUnauthorized},
+		{"current generation", shareClaims{Project: "team-c", Session: "revoked", UID: "uid-c", Generation: 2}, http.StatusOK},
+	}
+	for _, tc := range cases {
+		item, status, err := LoadSharedSession(context.Background(), shareTestToken(t, tc.claims))
+		if status != tc.status {
+			t.Errorf("%s: status = %d (%v), want %d", tc.name, status, err, tc.status)
+			continue
+		}
+		if status == http.StatusOK && (item.GetNamespace() != tc.claims.Project || item.GetName() != tc.claims.Session) {
+			t.Errorf("%s: got %s/%s", tc.name, item.GetNamespace(), item.GetName())
+		}
+	}
+}
+
+// TestGetSharedSession_ScopedToTokenSession verifies a link for session A serves session A
+// only, and a link for session B cannot be built from it
+func TestGetSharedSession_ScopedToTokenSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useShareTestSessions(t,
+		shareTestSession("team-a", "session-a", "uid-a", "A", ""),
+		shareTestSession("team-a", "session-b", "uid-b", "B", ""),
+	)
+
+	get := func(token string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/shared/"+token, nil)
+		c.Params = gin.Params{{Key: "token", Value: token}}
+		GetSharedSession(c)
+		var body map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	tokenA := shareTestToken(t, shareClaims{Project: "team-a", Session: "session-a", UID: "uid-a"})
+	code, body := get(tokenA)
+	if code != http.StatusOK || body["name"] != "session-a" || body["displayName"] != "A" {
+		t.Fatalf("token for session-a: code=%d body=%v", code, body)
+	}
+
+	_, sig, _ := strings.Cut(tokenA, ".")
+	raw, _ := json.Marshal(shareClaims{Project: "team-a", Session: "session-b", UID: "uid-b", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if code, body := get(base64.RawURLEncoding.EncodeToString(raw) + "." + sig); code != http.StatusUnauthorized {
+		t.Errorf("session-a signature on session-b: code=%d body=%v", code, body)
+	}
+}
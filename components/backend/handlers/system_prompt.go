@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"ambient-code-backend/prompttemplate"
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// issueAnnotation records the issue a session works on, for the project system prompt
+	issueAnnotation = "ambient-code.io/issue"
+)
+
+// systemPromptData holds the variables available to ProjectSettings spec.systemPromptTemplate
+type systemPromptData struct {
+	Project string
+	Repo    string
+	Branch  string
+	User    string
+	Issue   string
+}
+
+// GetSystemPrompt handles GET /api/projects/:projectName/system-prompt
+func GetSystemPrompt(c *gin.Context) {
+	projectName := c.Param("projectName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		c.Abort()
+		return
+	}
+
+	obj, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(projectName).Get(c.Request.Context(), apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusOK, types.SystemPromptSettings{})
+			return
+		}
+		log.Printf("Failed to get ProjectSettings in %s: %v", projectName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read project settings"})
+		return
+	}
+	tmpl, _, _ := unstructured.NestedString(obj.Object, "spec", "systemPromptTemplate")
+	c.JSON(http.StatusOK, types.SystemPromptSettings{Template: tmpl})
+}
+
+// UpdateSystemPrompt handles PUT /api/projects/:projectName/system-prompt
+// The template is parsed and test-rendered before it is stored, so sessions never fail on it.
+func UpdateSystemPrompt(c *gin.Context) {
+	projectName := c.Param("projectName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		c.Abort()
+		return
+	}
+
+	var req types.SystemPromptSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSystemPromptTemplate(projectName, req.Template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A null value removes the field, so clearing the template leaves no empty string behind
+	var value interface{}
+	if strings.TrimSpace(req.Template) != "" {
+		value = req.Template
+	}
+	patch, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"systemPromptTemplate": value}})
+	updated, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(projectName).Patch(c.Request.Context(), apiv1alpha1.ProjectSettingsName, ktypes.MergePatchType, patch, v1.PatchOptions{})
+	if err != nil {
+		switch {
+		case errors.IsNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "ProjectSettings not found"})
+		case errors.IsForbidden(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to update project settings"})
+		default:
+			log.Printf("Failed to update ProjectSettings in %s: %v", projectName, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project settings"})
+		}
+		return
+	}
+	recordSettingsRevision(c, updated, 0)
+
+	c.JSON(http.StatusOK, req)
+}
+
+// validateSystemPromptTemplate test-renders a template with sample values
+func validateSystemPromptTemplate(project, tmpl string) error {
+	sample := systemPromptData{Project: project, Repo: "https://github.com/org/repo", Branch: "main", User: "user", Issue: "https://github.com/org/repo/issues/1"}
+	_, err := renderSystemPrompt(tmpl, sample)
+	return err
+}
+
+// renderSystemPrompt executes a system prompt template. An empty template renders to "".
+func renderSystemPrompt(tmpl string, data systemPromptData) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return "", nil
+	}
+	t, err := prompttemplate.Parse("systemPrompt", tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid system prompt template: %v", err)
+	}
+	out, err := t.Execute(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render system prompt template: %v", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// sessionPromptData collects the template variables from a session spec map, as built by
+// CreateSession or read from an externally created session
+func sessionPromptData(project string, spec map[string]interface{}, issue string) systemPromptData {
+	data := systemPromptData{Project: project, Issue: issue}
+	if uc, ok := spec["userContext"].(map[string]interface{}); ok {
+		data.User, _ = uc["userId"].(string)
+	}
+
+	var repos []map[string]interface{}
+	switch r := spec["repos"].(type) {
+	case []map[string]interface{}:
+		repos = r
+	case []interface{}:
+		for _, item := range r {
+			if m, ok := item.(map[string]interface{}); ok {
+				repos = append(repos, m)
+			}
+		}
+	}
+	idx := 0
+	if n, ok := toInt64(spec["mainRepoIndex"]); ok {
+		idx = int(n)
+	}
+	if idx >= 0 && idx < len(repos) {
+		if input, ok := repos[idx]["input"].(map[string]interface{}); ok {
+			data.Repo, _ = input["url"].(string)
+			data.Branch, _ = input["branch"].(string)
+		}
+	}
+	return data
+}
+
+// applyProjectSystemPrompt renders the project's system prompt template and prepends it to
+// spec.prompt. A missing ProjectSettings object or an empty template leaves the prompt as is.
+func applyProjectSystemPrompt(ctx context.Context, project string, spec map[string]interface{}, issue string) error {
+	if VteamClient == nil {
+		return nil
+	}
+	ps, err := VteamClient.VteamV1alpha1().ProjectSettings(project).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read project settings: %w", err)
+	}
+	prefix, err := renderSystemPrompt(ps.Spec.SystemPromptTemplate, sessionPromptData(project, spec, issue))
+	if err != nil || prefix == "" {
+		return err
+	}
+	prompt, _ := spec["prompt"].(string)
+	if strings.TrimSpace(prompt) == "" {
+		spec["prompt"] = prefix
+	} else {
+		spec["prompt"] = prefix + "\n\n" + prompt
+	}
+	return nil
+}
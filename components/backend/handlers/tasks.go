@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ambient-code-backend/tasks"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TaskManager runs long operations in the background; set by main
+var TaskManager *tasks.Manager
+
+const (
+	taskTypeDeleteSessions = "delete-sessions"
+	// maxBulkDeleteSessions keeps a task's parameters well within a ConfigMap
+	maxBulkDeleteSessions = 1000
+)
+
+// RegisterTaskTypes registers the background operations the API can submit
+func RegisterTaskTypes(m *tasks.Manager) {
+	m.Register(taskTypeDeleteSessions, deleteSessionsTask)
+}
+
+// GetTask handles GET /api/tasks/:taskId
+func GetTask(c *gin.Context) {
+	task, ok := loadTaskForCaller(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// CancelTask handles POST /api/tasks/:taskId/cancel
+func CancelTask(c *gin.Context) {
+	task, ok := loadTaskForCaller(c, true)
+	if !ok {
+		return
+	}
+	if task.State.IsFinal() {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Task already %s", strings.ToLower(string(task.State)))})
+		return
+	}
+	task, err := TaskManager.Cancel(c.Request.Context(), task.ID)
+	if err != nil {
+		log.Printf("Failed to cancel task %s: %v", c.Param("taskId"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel task"})
+		return
+	}
+	c.JSON(http.StatusAccepted, task)
+}
+
+// ListProjectTasks handles GET /api/projects/:projectName/tasks
+func ListProjectTasks(c *gin.Context) {
+	if TaskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Background tasks are not available"})
+		return
+	}
+	items, err := TaskManager.List(c.Request.Context(), c.GetString("project"))
+	if err != nil {
+		log.Printf("Failed to list tasks in %s: %v", c.GetString("project"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tasks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// loadTaskForCaller returns the task if the caller created it or may view (for cancel:
+// modify) its project. Tasks the caller may not see are reported as not found.
+func loadTaskForCaller(c *gin.Context, modify bool) (*tasks.Task, bool) {
+	if TaskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Background tasks are not available"})
+		return nil, false
+	}
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return nil, false
+	}
+	task, err := TaskManager.Get(c.Request.Context(), c.Param("taskId"))
+	if err == tasks.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Failed to get task %s: %v", c.Param("taskId"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get task"})
+		return nil, false
+	}
+
+	if subject, _ := getUserSubjectFromContext(c); subject != "" && subject == task.CreatedBy {
+		return task, true
+	}
+	if task.Project != "" {
+		check := checkUserCanViewProject
+		if modify {
+			check = checkUserCanModifyProject
+		}
+		if allowed, err := check(reqK8s, task.Project); err == nil && allowed {
+			return task, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	return nil, false
+}
+
+// BulkDeleteSessions handles POST /api/projects/:projectName/agentic-sessions/bulk-delete
+// Sessions are selected by name or by phase and deleted by a background task; the response
+// is 202 with the task to poll at GET /api/tasks/:taskId.
+func BulkDeleteSessions(c *gin.Context) {
+	project := c.GetString("project")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	if TaskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Background tasks are not available"})
+		return
+	}
+	var req types.BulkDeleteSessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Names) == 0 && req.Phase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "names or phase is required"})
+		return
+	}
+
+	// The task deletes with the backend SA after the request is gone, so the caller's
+	// permission is checked up front
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace: project,
+				Verb:      "delete",
+				Group:     "vteam.ambient-code",
+				Resource:  "agenticsessions",
+			},
+		},
+	}
+	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
+	if err != nil {
+		log.Printf("BulkDeleteSessions: access review failed in %s: %v", project, err)
+		respondMessage(c, http.StatusInternalServerError, "auth.verifyPermissionsFailed", nil)
+		return
+	}
+	if !res.Status.Allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete sessions"})
+		return
+	}
+
+	names := req.Names
+	if len(names) == 0 {
+		list, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).List(c.Request.Context(), v1.ListOptions{})
+		if err != nil {
+			log.Printf("BulkDeleteSessions: failed to list sessions in %s: %v", project, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+			return
+		}
+		for _, item := range list.Items {
+			if phase, _, _ := unstructured.NestedString(item.Object, "status", "phase"); phase == req.Phase {
+				names = append(names, item.GetName())
+			}
+		}
+	}
+	if len(names) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No sessions match"})
+		return
+	}
+	if len(names) > maxBulkDeleteSessions {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d sessions can be deleted at once", maxBulkDeleteSessions)})
+		return
+	}
+
+	createdBy, _ := getUserSubjectFromContext(c)
+	task, err := TaskManager.Submit(c.Request.Context(), taskTypeDeleteSessions, project, createdBy, map[string]string{
+		"project": project,
+		"names":   strings.Join(names, ","),
+	})
+	if err != nil {
+		log.Printf("BulkDeleteSessions: failed to submit task in %s: %v", project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start bulk delete"})
+		return
+	}
+	c.Header("Location", "/api/tasks/"+task.ID)
+	c.JSON(http.StatusAccepted, task)
+}
+
+// deleteSessionsTask deletes the named sessions one by one; sessions already gone count as deleted
+func deleteSessionsTask(ctx context.Context, params map[string]string, progress tasks.ProgressFunc) (map[string]interface{}, error) {
+	project := params["project"]
+	names := strings.Split(params["names"], ",")
+	gvr := GetAgenticSessionV1Alpha1Resource()
+
+	deleted := 0
+	failed := []string{}
+	for i, name := range names {
+		if err := ctx.Err(); err != nil {
+			return map[string]interface{}{"deleted": deleted, "failed": failed}, err
+		}
+		callCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err := DynamicClient.Resource(gvr).Namespace(project).Delete(callCtx, name, v1.DeleteOptions{})
+		cancel()
+		if err != nil && !errors.IsNotFound(err) {
+			log.Printf("Bulk delete: failed to delete session %s/%s: %v", project, name, err)
+			failed = append(failed, name)
+		} else {
+			deleted++
+		}
+		progress(i+1, len(names), fmt.Sprintf("Deleted %d of %d sessions", deleted, len(names)))
+	}
+
+	result := map[string]interface{}{"deleted": deleted, "failed": failed}
+	if len(failed) > 0 {
+		return result, fmt.Errorf("failed to delete %d of %d sessions", len(failed), len(names))
+	}
+	return result, nil
+}
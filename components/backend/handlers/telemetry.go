@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"ambient-code-backend/telemetry"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// recordTurnTelemetry reports a runner status update that carries token usage as the end of
+// an agent turn. The runner reports usage summed over the session, so the turn used the
+// difference from the previous status; counters that went down mean the runner restarted.
+func recordTurnTelemetry(project, session string, obj *unstructured.Unstructured, prev, next map[string]interface{}, isError bool) {
+	model, _, _ := unstructured.NestedString(obj.Object, "spec", "llmSettings", "model")
+	delta := func(field string) int64 {
+		n, p := usageCount(next[field]), usageCount(prev[field])
+		if n < p {
+			return n
+		}
+		return n - p
+	}
+	telemetry.TurnCompleted(project, session, model, telemetry.Usage{
+		InputTokens:              delta("input_tokens"),
+		OutputTokens:             delta("output_tokens"),
+		CacheCreationInputTokens: delta("cache_creation_input_tokens"),
+		CacheReadInputTokens:     delta("cache_read_input_tokens"),
+	}, isError)
+}
+
+func usageCount(v interface{}) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	}
+	return 0
+}
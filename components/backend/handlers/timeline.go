@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// A session's timeline merges what the cluster already records about it (status conditions,
+// runner steps, Kubernetes events of the session, its Job and pods) with the actions users
+// took through the API. Nothing else records those actions, so they are kept on the session
+// in userActionsAnnotation, newest maxUserActions only.
+
+const (
+	// userActionsAnnotation holds a JSON list of sessionUserAction, oldest first
+	userActionsAnnotation = "ambient-code.io/user-actions"
+	maxUserActions        = 50
+)
+
+// Timeline entry types
+const (
+	timelineLifecycle = "lifecycle"
+	timelineCondition = "condition"
+	timelineEvent     = "event"
+	timelineMilestone = "milestone"
+	timelineAction    = "action"
+)
+
+// sessionUserAction is one action a user took on a session through the API
+type sessionUserAction struct {
+	Action string `json:"action"`
+	User   string `json:"user,omitempty"`
+	At     string `json:"at"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func parseUserActions(raw string) []sessionUserAction {
+	if raw == "" {
+		return nil
+	}
+	var actions []sessionUserAction
+	if err := json.Unmarshal([]byte(raw), &actions); err != nil {
+		return nil
+	}
+	return actions
+}
+
+// recordSessionAction appends the caller's action to the session's timeline. Best effort:
+// the action already succeeded, so a failure to record it is only logged. Written with the
+// backend SA since not every caller who may act on a session may edit its annotations.
+func recordSessionAction(c *gin.Context, project, sessionName, action, detail string) {
+	if VteamClient == nil {
+		return
+	}
+	entry := sessionUserAction{
+		Action: action,
+		User:   c.GetString("userID"),
+		At:     time.Now().UTC().Format(time.RFC3339),
+		Detail: detail,
+	}
+	parent := context.Background()
+	if c.Request != nil {
+		parent = context.WithoutCancel(c.Request.Context())
+	}
+	ctx, cancel := context.WithTimeout(parent, K8sCallTimeout)
+	defer cancel()
+	client := VteamClient.VteamV1alpha1().AgenticSessions(project)
+	err := retryOnConflict("record_session_action", func() error {
+		obj, err := client.Get(ctx, sessionName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		actions := append(parseUserActions(obj.Annotations[userActionsAnnotation]), entry)
+		if len(actions) > maxUserActions {
+			actions = actions[len(actions)-maxUserActions:]
+		}
+		b, err := json.Marshal(actions)
+		if err != nil {
+			return err
+		}
+		if obj.Annotations == nil {
+			obj.Annotations = map[string]string{}
+		}
+		obj.Annotations[userActionsAnnotation] = string(b)
+		_, err = client.Update(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to record action %s on session %s/%s: %v", action, project, sessionName, err)
+	}
+}
+
+// GetSessionTimeline handles GET /api/projects/:projectName/agentic-sessions/:sessionName/timeline.
+// Events are listed with the caller's token; without access to them the timeline is
+// returned without events and a warning.
+func GetSessionTimeline(c *gin.Context) {
+	project := c.GetString("project")
+	if project == "" {
+		project = c.Param("projectName")
+	}
+	sessionName := c.Param("sessionName")
+
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	obj, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			respondMessage(c, http.StatusNotFound, "session.notFound", nil)
+			return
+		}
+		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
+		return
+	}
+	session, err := sessionFromUnstructured(obj)
+	if err != nil {
+		log.Printf("Failed to decode agentic session %s in project %s: %v", sessionName, project, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode agentic session"})
+		return
+	}
+
+	timeline := types.SessionTimeline{Session: sessionName}
+	var events []corev1.Event
+	list, err := reqK8s.CoreV1().Events(project).List(c.Request.Context(), v1.ListOptions{})
+	switch {
+	case err == nil:
+		events = sessionEvents(session, list.Items)
+	case errors.IsForbidden(err):
+		timeline.Warnings = append(timeline.Warnings, "Kubernetes events are omitted: you may not list events in this project")
+	default:
+		log.Printf("Failed to list events for session %s in project %s: %v", sessionName, project, err)
+		timeline.Warnings = append(timeline.Warnings, "Kubernetes events are omitted: they could not be listed")
+	}
+
+	timeline.Entries = buildSessionTimeline(session, events, time.Now())
+	c.JSON(http.StatusOK, timeline)
+}
+
+// sessionEvents keeps the events of the session, its Job and the Job's pods. Pods are
+// matched by name since they may be gone while their events remain.
+func sessionEvents(session *apiv1alpha1.AgenticSession, events []corev1.Event) []corev1.Event {
+	jobName := session.Status.JobName
+	if jobName == "" {
+		jobName = session.Name + "-job"
+	}
+	var kept []corev1.Event
+	for _, ev := range events {
+		obj := ev.InvolvedObject
+		switch {
+		case obj.Kind == "AgenticSession" && obj.Name == session.Name,
+			obj.Kind == "Job" && obj.Name == jobName,
+			obj.Kind == "Pod" && strings.HasPrefix(obj.Name, jobName+"-"):
+			kept = append(kept, ev)
+		}
+	}
+	return kept
+}
+
+type timelineItem struct {
+	at    time.Time
+	entry types.TimelineEntry
+}
+
+// buildSessionTimeline merges the session's history with its events, oldest first. Spans
+// still open (a running step or session) last until now.
+func buildSessionTimeline(session *apiv1alpha1.AgenticSession, events []corev1.Event, now time.Time) []types.TimelineEntry {
+	var items []timelineItem
+	add := func(at time.Time, entry types.TimelineEntry) {
+		if at.IsZero() {
+			return
+		}
+		entry.Time = at.UTC().Format(time.RFC3339)
+		items = append(items, timelineItem{at: at, entry: entry})
+	}
+	span := func(entry *types.TimelineEntry, start time.Time, end *v1.Time) {
+		stop := now
+		if end != nil && !end.IsZero() {
+			stop = end.Time
+			entry.EndTime = end.UTC().Format(time.RFC3339)
+		}
+		if d := stop.Sub(start).Seconds(); d >= 0 {
+			entry.DurationSeconds = &d
+		}
+	}
+
+	add(session.CreationTimestamp.Time, types.TimelineEntry{
+		Type:   timelineLifecycle,
+		Reason: "Created",
+		User:   session.Annotations[createdByAnnotation],
+	})
+	if start := session.Status.StartTime; start != nil {
+		entry := types.TimelineEntry{Type: timelineLifecycle, Reason: "Started", Status: string(session.Status.Phase)}
+		span(&entry, start.Time, session.Status.CompletionTime)
+		add(start.Time, entry)
+	}
+	if end := session.Status.CompletionTime; end != nil {
+		add(end.Time, types.TimelineEntry{
+			Type:    timelineLifecycle,
+			Reason:  "Completed",
+			Status:  string(session.Status.Phase),
+			Message: session.Status.Message,
+		})
+	}
+
+	for _, cond := range session.Status.Conditions {
+		add(cond.LastTransitionTime.Time, types.TimelineEntry{
+			Type:    timelineCondition,
+			Object:  cond.Type,
+			Reason:  cond.Reason,
+			Message: cond.Message,
+			Status:  string(cond.Status),
+		})
+	}
+
+	for _, step := range session.Status.Steps {
+		if step.StartedAt == nil {
+			continue
+		}
+		entry := types.TimelineEntry{
+			Type:    timelineMilestone,
+			Reason:  step.Name,
+			Status:  string(step.State),
+			Message: step.Message,
+		}
+		if step.CompletedAt != nil || step.State == apiv1alpha1.StepRunning {
+			span(&entry, step.StartedAt.Time, step.CompletedAt)
+		}
+		add(step.StartedAt.Time, entry)
+	}
+
+	for _, ev := range events {
+		first := ev.FirstTimestamp.Time
+		if first.IsZero() {
+			first = ev.EventTime.Time
+		}
+		if first.IsZero() {
+			first = ev.CreationTimestamp.Time
+		}
+		entry := types.TimelineEntry{
+			Type:    timelineEvent,
+			Reason:  ev.Reason,
+			Message: ev.Message,
+			Status:  ev.Type,
+			Object:  ev.InvolvedObject.Kind + "/" + ev.InvolvedObject.Name,
+			Count:   ev.Count,
+		}
+		if last := ev.LastTimestamp.Time; ev.Count > 1 && last.After(first) {
+			entry.EndTime = last.UTC().Format(time.RFC3339)
+		}
+		add(first, entry)
+	}
+
+	for _, action := range parseUserActions(session.Annotations[userActionsAnnotation]) {
+		at, err := time.Parse(time.RFC3339, action.At)
+		if err != nil {
+			continue
+		}
+		add(at, types.TimelineEntry{
+			Type:    timelineAction,
+			Reason:  action.Action,
+			Message: action.Detail,
+			User:    action.User,
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].at.Before(items[j].at) })
+	entries := make([]types.TimelineEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, item.entry)
+	}
+	return entries
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestBuildSessionTimeline verifies every source is merged in time order, with spans for
+// the run and its steps and only the events of the session's own objects
+func TestBuildSessionTimeline(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) *v1.Time { return &v1.Time{Time: base.Add(time.Duration(sec) * time.Second)} }
+	session := &apiv1alpha1.AgenticSession{
+		ObjectMeta: v1.ObjectMeta{
+			Name:              "s1",
+			CreationTimestamp: *at(0),
+			Annotations: map[string]string{
+				createdByAnnotation:   "alice",
+				userActionsAnnotation: `[{"action":"stop","user":"bob","at":"2026-01-01T12:01:40Z"}]`,
+			},
+		},
+		Status: apiv1alpha1.AgenticSessionStatus{
+			Phase:     apiv1alpha1.SessionPhaseRunning,
+			StartTime: at(10),
+			Conditions: []v1.Condition{
+				{Type: "Queued", Status: v1.ConditionFalse, Reason: "Admitted", LastTransitionTime: *at(5)},
+			},
+			Steps: []apiv1alpha1.SessionStep{
+				{Name: "clone", State: apiv1alpha1.StepCompleted, StartedAt: at(20), CompletedAt: at(50)},
+				{Name: "analyze", State: apiv1alpha1.StepRunning, StartedAt: at(50)},
+				{Name: "edit", State: apiv1alpha1.StepPending},
+			},
+		},
+	}
+	events := sessionEvents(session, []corev1.Event{
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "s1-job-abcde"}, Reason: "Pulled", FirstTimestamp: *at(15), LastTimestamp: *at(30), Count: 2},
+		{InvolvedObject: corev1.ObjectReference{Kind: "Job", Name: "s1-job"}, Reason: "SuccessfulCreate", FirstTimestamp: *at(12)},
+		{InvolvedObject: corev1.ObjectReference{Kind: "Job", Name: "s10-job"}, Reason: "SuccessfulCreate", FirstTimestamp: *at(12)},
+	})
+
+	entries := buildSessionTimeline(session, events, base.Add(100*time.Second))
+	want := []struct{ typ, reason string }{
+		{timelineLifecycle, "Created"},
+		{timelineCondition, "Admitted"},
+		{timelineLifecycle, "Started"},
+		{timelineEvent, "SuccessfulCreate"},
+		{timelineEvent, "Pulled"},
+		{timelineMilestone, "clone"},
+		{timelineMilestone, "analyze"},
+		{timelineAction, "stop"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %+v", entries)
+	}
+	for i, w := range want {
+		if entries[i].Type != w.typ || entries[i].Reason != w.reason {
+			t.Errorf("entry %d = %s/%s, want %s/%s", i, entries[i].Type, entries[i].Reason, w.typ, w.reason)
+		}
+	}
+	if entries[0].User != "alice" || entries[7].User != "bob" {
+		t.Errorf("users = %q, %q", entries[0].User, entries[7].User)
+	}
+	if d := entries[5].DurationSeconds; d == nil || *d != 30 || entries[5].EndTime != "2026-01-01T12:00:50Z" {
+		t.Errorf("completed step span = %v until %q", d, entries[5].EndTime)
+	}
+	if d := entries[6].DurationSeconds; d == nil || *d != 50 || entries[6].EndTime != "" {
+		t.Errorf("running step span = %v until %q, want 50s and open", d, entries[6].EndTime)
+	}
+	if d := entries[2].DurationSeconds; d == nil || *d != 90 {
+		t.Errorf("run duration = %v, want 90s so far", d)
+	}
+	if entries[4].Object != "Pod/s1-job-abcde" || entries[4].Count != 2 || entries[4].EndTime != "2026-01-01T12:00:30Z" {
+		t.Errorf("pod event = %+v", entries[4])
+	}
+}
+
+// TestRecordSessionAction verifies actions are appended with the caller and capped
+func TestRecordSessionAction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	VteamClient = vteamfake.NewSimpleClientset(&apiv1alpha1.AgenticSession{
+		ObjectMeta: v1.ObjectMeta{Name: "s1", Namespace: "team-a"},
+	})
+	defer func() { VteamClient = nil }()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("userID", "alice")
+	for i := 0; i < maxUserActions+2; i++ {
+		recordSessionAction(c, "team-a", "s1", "stop", "")
+	}
+	recordSessionAction(c, "team-a", "s1", "start", "")
+
+	obj, err := VteamClient.VteamV1alpha1().AgenticSessions("team-a").Get(context.Background(), "s1", v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := parseUserActions(obj.Annotations[userActionsAnnotation])
+	if len(actions) != maxUserActions {
+		t.Fatalf("recorded %d actions, want the newest %d", len(actions), maxUserActions)
+	}
+	if last := actions[len(actions)-1]; last.Action != "start" || last.User != "alice" || last.At == "" {
+		t.Errorf("last action = %+v", last)
+	}
+}
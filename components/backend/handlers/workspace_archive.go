@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultWorkspaceArchiveMaxBytes caps the uncompressed size of a workspace download
+const defaultWorkspaceArchiveMaxBytes int64 = 500 << 20
+
+// workspaceArchiveMaxBytes reads WORKSPACE_ARCHIVE_MAX_BYTES, falling back to the default
+func workspaceArchiveMaxBytes() int64 {
+	if v := strings.TrimSpace(os.Getenv("WORKSPACE_ARCHIVE_MAX_BYTES")); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultWorkspaceArchiveMaxBytes
+}
+
+// GetSessionWorkspaceArchive streams the session workspace as a tar.gz from the content service.
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/workspace.tar.gz
+func GetSessionWorkspaceArchive(c *gin.Context) {
+	project := c.GetString("project")
+	if project == "" {
+		project = c.Param("projectName")
+	}
+	session := c.Param("sessionName")
+
+	token := c.GetHeader("Authorization")
+	if strings.TrimSpace(token) == "" {
+		token = c.GetHeader("X-Forwarded-Access-Token")
+	}
+
+	// Try temp service first (for completed sessions), then regular service
+	serviceName := fmt.Sprintf("temp-content-%s", session)
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondMessage(c, http.StatusUnauthorized, "auth.invalidToken", nil)
+		return
+	}
+	// The archive is streamed as-is, so files echoing injected secrets could not be redacted
+	withSecrets, err := sessionInjectsSecrets(c.Request.Context(), project, session)
+	if err != nil {
+		log.Printf("GetSessionWorkspaceArchive: failed to read session %s/%s: %v", project, session, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read session"})
+		return
+	}
+	if withSecrets {
+		c.JSON(http.StatusConflict, gin.H{"error": "Workspace download is not available for sessions that inject secrets (spec.envFromSecrets); browse the files instead"})
+		return
+	}
+	if _, err := reqK8s.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
+		serviceName = fmt.Sprintf("ambient-content-%s", session)
+	}
+
+	absPath := "/sessions/" + session + "/workspace"
+	u := fmt.Sprintf("http://%s.%s.svc:8080/content/archive?path=%s", serviceName, project, url.QueryEscape(absPath))
+	req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, u, nil)
+	if strings.TrimSpace(token) != "" {
+		req.Header.Set("Authorization", token)
+	}
+	// No client timeout: large archives take a while; the request context bounds the transfer
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("GetSessionWorkspaceArchive: content service request failed for %s/%s: %v", project, session, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Workspace is not available; start the session or its content pod first"})
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), b)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", session+"-workspace.tar.gz"))
+	c.DataFromReader(http.StatusOK, -1, "application/gzip", resp.Body, nil)
+}
+
+// ContentArchive handles GET /content/archive?path= in CONTENT_SERVICE_MODE.
+// Streams a tar.gz of the directory. Inside git repositories only files git would track are
+// included (.gitignore is respected); .git directories are always skipped. The file list is
+// collected up front so an oversized workspace is rejected before any bytes are sent.
+func ContentArchive(c *gin.Context) {
+	path := filepath.Clean("/" + strings.TrimSpace(c.Query("path")))
+	if path == "/" || strings.Contains(path, "..") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+		return
+	}
+	root := filepath.Join(StateBaseDir, path)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	files, total, err := collectArchiveFiles(root)
+	if err != nil {
+		log.Printf("ContentArchive: failed to list %q: %v", root, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list workspace"})
+		return
+	}
+	if limit := workspaceArchiveMaxBytes(); total > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("workspace is %d bytes, over the %d byte download limit", total, limit)})
+		return
+	}
+
+	c.Header("Content-Type", "application/gzip")
+	c.Status(http.StatusOK)
+	gz := gzip.NewWriter(c.Writer)
+	tw := tar.NewWriter(gz)
+	for _, rel := range files {
+		if err := addArchiveEntry(tw, root, rel); err != nil {
+			// Headers are already sent; a truncated archive fails to extract, which is the signal
+			log.Printf("ContentArchive: aborting archive of %q at %q: %v", root, rel, err)
+			return
+		}
+	}
+	if err := tw.Close(); err != nil {
+		log.Printf("ContentArchive: failed to finish tar for %q: %v", root, err)
+		return
+	}
+	if err := gz.Close(); err != nil {
+		log.Printf("ContentArchive: failed to finish gzip for %q: %v", root, err)
+	}
+}
+
+// collectArchiveFiles returns the slash-separated paths (relative to root) to archive and
+// their total size in bytes
+func collectArchiveFiles(root string) ([]string, int64, error) {
+	var files []string
+	var total int64
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Lstat(filepath.Join(p, ".git")); err == nil {
+				repoFiles, size, err := gitArchiveFiles(root, p)
+				if err != nil {
+					return err
+				}
+				files = append(files, repoFiles...)
+				total += size
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		files = append(files, filepath.ToSlash(rel))
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return files, total, err
+}
+
+// gitArchiveFiles lists tracked and untracked-but-not-ignored files of the repository at dir
+func gitArchiveFiles(root, dir string) ([]string, int64, error) {
+	cmd := exec.Command("git", "-C", dir, "ls-files", "--cached", "--others", "--exclude-standard", "-z")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, 0, fmt.Errorf("git ls-files in %s: %w", dir, err)
+	}
+	var files []string
+	var total int64
+	for _, name := range bytes.Split(out, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		abs := filepath.Join(dir, string(name))
+		info, err := os.Lstat(abs)
+		if err != nil {
+			// Tracked but deleted in the working tree
+			continue
+		}
+		if info.IsDir() {
+			// Submodules are listed as directories; their contents are not archived
+			continue
+		}
+		rel, _ := filepath.Rel(root, abs)
+		files = append(files, filepath.ToSlash(rel))
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+	}
+	return files, total, nil
+}
+
+// addArchiveEntry writes one file or symlink (never followed) under workspace/ in the archive
+func addArchiveEntry(tw *tar.Writer, root, rel string) error {
+	abs := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Lstat(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(abs); err != nil {
+			return err
+		}
+	} else if !info.Mode().IsRegular() {
+		return nil
+	}
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = "workspace/" + rel
+	hdr.Uname, hdr.Gname = "", ""
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if link != "" {
+		return nil
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// Copy exactly the header size in case the file grew while archiving
+	_, err = io.CopyN(tw, f, hdr.Size)
+	return err
+}
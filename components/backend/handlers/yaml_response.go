@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"ambient-code-pkg/redact"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// CR endpoints answer "Accept: application/yaml" (or application/x-yaml, text/yaml) with the
+// custom resource as a manifest that can be committed to a GitOps repo and applied as is.
+// JSON stays the default, including for "*/*" and a missing Accept header.
+
+// wantsYAML reports whether the client prefers a YAML response
+func wantsYAML(c *gin.Context) bool {
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEYAML2, binding.MIMEYAML, "text/yaml") {
+	case binding.MIMEYAML2, binding.MIMEYAML, "text/yaml":
+		return true
+	}
+	return false
+}
+
+// respondYAML writes obj as YAML. Field names follow the JSON tags, like kubectl output.
+func respondYAML(c *gin.Context, status int, obj interface{}) {
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		log.Printf("Failed to render YAML response for %s: %v", c.Request.URL.Path, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render YAML"})
+		return
+	}
+	c.Data(status, binding.MIMEYAML2+"; charset=utf-8", out)
+}
+
+// gitOpsManifest returns a copy of a custom resource without status and the metadata the API
+// server manages, so applying it creates or updates the resource instead of conflicting
+func gitOpsManifest(obj *unstructured.Unstructured) map[string]interface{} {
+	// redact.Map returns a deep copy; manifests end up in Git, so credentials are masked
+	out := redact.Map(obj.Object)
+	delete(out, "status")
+	if meta, ok := out["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp", "deletionGracePeriodSeconds", "managedFields", "selfLink", "ownerReferences", "finalizers"} {
+			delete(meta, field)
+		}
+		if annotations, ok := meta["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			if len(annotations) == 0 {
+				delete(meta, "annotations")
+			}
+		}
+	}
+	return out
+}
+
+// gitOpsList wraps manifests in a v1 List, which kubectl apply accepts
+func gitOpsList(items []unstructured.Unstructured) map[string]interface{} {
+	manifests := make([]interface{}, 0, len(items))
+	for i := range items {
+		manifests = append(manifests, gitOpsManifest(&items[i]))
+	}
+	return map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": manifests}
+}
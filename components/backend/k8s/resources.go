@@ -1,24 +1,21 @@
 // Package k8s provides Kubernetes client creation and configuration utilities.
 package k8s
 
-import "k8s.io/apimachinery/pkg/runtime/schema"
+import (
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/crdcheck"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
 
 // GetAgenticSessionV1Alpha1Resource returns the GroupVersionResource for AgenticSession v1alpha1
 func GetAgenticSessionV1Alpha1Resource() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "vteam.ambient-code",
-		Version:  "v1alpha1",
-		Resource: "agenticsessions",
-	}
+	return apiv1alpha1.AgenticSessionGVR()
 }
 
 // GetProjectSettingsResource returns the GroupVersionResource for ProjectSettings
 func GetProjectSettingsResource() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "vteam.ambient-code",
-		Version:  "v1alpha1",
-		Resource: "projectsettings",
-	}
+	return apiv1alpha1.ProjectSettingsGVR()
 }
 
 // GetOpenShiftProjectResource returns the GroupVersionResource for OpenShift Project
@@ -38,3 +35,10 @@ func GetOpenShiftProjectRequestResource() schema.GroupVersionResource {
 		Resource: "projectrequests",
 	}
 }
+
+// VerifyCRDsInstalled checks that the cluster serves the custom resources the backend reads
+// and writes. A missing CRD or version is reported as a *crdcheck.NotInstalledError.
+// OpenShift Projects are optional and not checked.
+func VerifyCRDsInstalled(client discovery.DiscoveryInterface) error {
+	return crdcheck.Verify(client, GetAgenticSessionV1Alpha1Resource(), GetProjectSettingsResource())
+}
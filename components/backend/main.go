@@ -4,18 +4,27 @@ import (
 	"context"
 	"log"
 	"os"
+	"strings"
 
+	"ambient-code-backend/authn"
 	"ambient-code-backend/git"
 	"ambient-code-backend/github"
 	"ambient-code-backend/handlers"
 	"ambient-code-backend/k8s"
 	"ambient-code-backend/server"
+	"ambient-code-backend/tasks"
+	"ambient-code-backend/telemetry"
 	"ambient-code-backend/websocket"
+	"ambient-code-pkg/redact"
+	"ambient-code-pkg/runtimeconfig"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	// Errors quote git URLs and upstream responses; mask any credentials they carry
+	log.SetOutput(redact.NewWriter(os.Stderr))
+
 	// Load environment from .env in development if present
 	_ = godotenv.Overload(".env.local")
 	_ = godotenv.Overload(".env")
@@ -37,6 +46,7 @@ func main() {
 		handlers.GitPushToRepo = git.PushToRepo
 		handlers.GitCreateBranch = git.CreateBranch
 		handlers.GitListRemoteBranches = git.ListRemoteBranches
+		handlers.GitWorkspaceChanges = git.WorkspaceChanges
 
 		log.Printf("Content service using StateBaseDir: %s", server.StateBaseDir)
 
@@ -58,6 +68,35 @@ func main() {
 
 	server.InitConfig()
 
+	// Log level, feature flags and limits that apply without a restart (ambient-runtime-config)
+	runtimeConfig := runtimeconfig.NewWatcherFromEnv()
+	if _, err := runtimeConfig.Reload(); err != nil {
+		log.Printf("Ignoring runtime config: %v", err)
+	}
+	go runtimeConfig.Run(context.Background())
+
+	// Select how API callers are authenticated (AUTH_PROVIDERS)
+	authChain, err := authn.NewChainFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid authentication configuration: %v", err)
+	}
+	authn.SetChain(authChain)
+	log.Printf("Authentication providers: %s", strings.Join(authChain.Names(), ", "))
+	// Lock out IPs and identities that keep failing to authenticate; a locked out caller's
+	// forwarded token still passes when the API server accepts it
+	authn.SetLockout(authn.NewLockoutFromEnv())
+	authn.SetTokenVerifier(server.ReviewToken)
+
+	// Name missing CRDs up front instead of failing every session call with a bare 404
+	handlers.CheckRequiredCRDs(func() error {
+		return k8s.VerifyCRDsInstalled(server.K8sClient.Discovery())
+	})
+
+	// Source CIDR and mTLS restrictions for runner callbacks
+	if err := server.LoadInternalAccess(); err != nil {
+		log.Fatalf("Invalid internal endpoint configuration: %v", err)
+	}
+
 	// Initialize git package
 	git.GetProjectSettingsResource = k8s.GetProjectSettingsResource
 	git.GetGitHubInstallation = func(ctx context.Context, userID string) (interface{}, error) {
@@ -75,11 +114,16 @@ func main() {
 	handlers.GitPushToRepo = git.PushToRepo
 	handlers.GitCreateBranch = git.CreateBranch
 	handlers.GitListRemoteBranches = git.ListRemoteBranches
+	handlers.GitWorkspaceChanges = git.WorkspaceChanges
 
 	// Initialize GitHub auth handlers
 	handlers.K8sClient = server.K8sClient
 	handlers.Namespace = server.Namespace
+	handlers.InternalBaseURL = server.InternalBaseURL()
 	handlers.GithubTokenManager = github.Manager
+	if github.Manager != nil {
+		handlers.MintSessionGitHubAppToken = github.MintSessionToken
+	}
 
 	// Initialize project handlers
 	handlers.GetOpenShiftProjectResource = k8s.GetOpenShiftProjectResource
@@ -89,6 +133,7 @@ func main() {
 	// Initialize session handlers
 	handlers.GetAgenticSessionV1Alpha1Resource = k8s.GetAgenticSessionV1Alpha1Resource
 	handlers.DynamicClient = server.DynamicClient
+	handlers.VteamClient = server.VteamClient
 	handlers.GetGitHubToken = git.GetGitHubToken
 	handlers.DeriveRepoFolderFromURL = git.DeriveRepoFolderFromURL
 	handlers.SendMessageToSession = websocket.SendMessageToSession
@@ -97,12 +142,49 @@ func main() {
 	handlers.GetK8sClientsForRequestRepo = handlers.GetK8sClientsForRequest
 	handlers.GetGitHubTokenRepo = git.GetGitHubToken
 
+	handlers.K8sCallTimeout = server.K8sCallTimeout
+
 	// Initialize middleware
 	handlers.BaseKubeConfig = server.BaseKubeConfig
 	handlers.K8sClientMw = server.K8sClient
+	handlers.RecordRequestTiming = server.RecordTiming
 
 	// Initialize websocket package
 	websocket.StateBaseDir = server.StateBaseDir
+	websocket.MaxMessageBytes = server.WebSocketMaxMessageBytes
+	websocket.IdleTimeout = server.WebSocketIdleTimeout
+
+	// Share session frames with the other backend replicas when WS_RELAY_SERVICE is set
+	relay, err := websocket.NewRelayFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid WebSocket relay configuration: %v", err)
+	}
+	if relay != nil {
+		websocket.PeerRelay = relay
+		go relay.Run(context.Background())
+	}
+
+	// Adopt AgenticSessions created directly against the cluster (kubectl, GitOps)
+	go handlers.StartSessionInformer(context.Background())
+
+	// Serve GET /dashboard from cached namespaces and sessions
+	go handlers.StartDashboardInformers(context.Background())
+
+	// Run long operations as background tasks persisted in the backend namespace
+	handlers.TaskManager = tasks.NewManager(server.K8sClient, server.Namespace)
+	handlers.RegisterTaskTypes(handlers.TaskManager)
+	go handlers.TaskManager.Run(context.Background())
+
+	// Warn about and stop interactive sessions idle past their project's maxIdleMinutes
+	go handlers.StartIdleSessionReaper(context.Background())
+
+	// Keep short-lived GitHub App tokens of active sessions fresh
+	go handlers.StartSessionGitHubTokenRefresher(context.Background())
+
+	// Export agent turns, tool calls and token usage over OTLP when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	if exporter := telemetry.Init(); exporter != nil {
+		go exporter.Run(context.Background())
+	}
 
 	// Normal server mode
 	if err := server.Run(registerRoutes); err != nil {
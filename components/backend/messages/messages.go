@@ -0,0 +1,171 @@
+// Package messages renders the backend's user-facing strings (API error details and session
+// notices) in the caller's language, so clients show them as they are instead of keeping
+// their own translation tables.
+//
+// Each locale is a JSON file under locales/ mapping a message ID to a text/template. IDs
+// are stable and returned to clients alongside the text. Templates may render another
+// message of the same locale with {{t "id"}}, e.g. to translate a verb. A message missing
+// from a locale falls back to English.
+package messages
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used when the caller accepts none of the supported locales
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Catalog holds the templates of every locale
+type Catalog struct {
+	templates map[string]map[string]*template.Template
+	locales   []string
+	matcher   language.Matcher
+}
+
+// Default is the catalog built from the embedded locales
+var Default = mustLoad()
+
+func mustLoad() *Catalog {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	raw := map[string]map[string]string{}
+	for _, f := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		texts := map[string]string{}
+		if err := json.Unmarshal(data, &texts); err != nil {
+			panic(fmt.Sprintf("messages: %s: %v", f.Name(), err))
+		}
+		raw[strings.TrimSuffix(f.Name(), ".json")] = texts
+	}
+	c, err := NewCatalog(raw)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewCatalog parses the templates of each locale; raw must include DefaultLocale
+func NewCatalog(raw map[string]map[string]string) (*Catalog, error) {
+	if _, ok := raw[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("messages: no %s locale", DefaultLocale)
+	}
+	c := &Catalog{templates: map[string]map[string]*template.Template{}}
+	for locale := range raw {
+		c.locales = append(c.locales, locale)
+	}
+	// The default goes first: the matcher falls back to the first tag
+	sort.Slice(c.locales, func(i, j int) bool {
+		if c.locales[i] == DefaultLocale || c.locales[j] == DefaultLocale {
+			return c.locales[i] == DefaultLocale
+		}
+		return c.locales[i] < c.locales[j]
+	})
+	tags := make([]language.Tag, 0, len(c.locales))
+	for _, locale := range c.locales {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("messages: locale %q: %v", locale, err)
+		}
+		tags = append(tags, tag)
+
+		locale := locale
+		c.templates[locale] = map[string]*template.Template{}
+		funcs := template.FuncMap{"t": func(id string) string { return c.Render(locale, id, nil) }}
+		for id, text := range raw[locale] {
+			tmpl, err := template.New(id).Funcs(funcs).Option("missingkey=zero").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("messages: %s %s: %v", locale, id, err)
+			}
+			c.templates[locale][id] = tmpl
+		}
+	}
+	c.matcher = language.NewMatcher(tags)
+	return c, nil
+}
+
+// Locales lists the supported locales, the default first
+func (c *Catalog) Locales() []string {
+	return append([]string(nil), c.locales...)
+}
+
+// IDs lists the message IDs of a locale, sorted
+func (c *Catalog) IDs(locale string) []string {
+	ids := make([]string, 0, len(c.templates[locale]))
+	for id := range c.templates[locale] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Negotiate picks the supported locale that best matches an Accept-Language header
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+	_, index, confidence := c.matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	return c.locales[index]
+}
+
+// Render executes message id of locale with data, falling back to English and then to the
+// ID itself, so a missing translation never hides an error
+func (c *Catalog) Render(locale, id string, data map[string]interface{}) string {
+	tmpl, ok := c.templates[locale][id]
+	if !ok {
+		if tmpl, ok = c.templates[DefaultLocale][id]; !ok {
+			log.Printf("messages: unknown message %q", id)
+			return id
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("messages: rendering %s %s: %v", locale, id, err)
+		return id
+	}
+	return buf.String()
+}
+
+// RenderAll renders message id in every locale, for notices broadcast to clients whose
+// language is unknown
+func (c *Catalog) RenderAll(id string, data map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(c.locales))
+	for _, locale := range c.locales {
+		out[locale] = c.Render(locale, id, data)
+	}
+	return out
+}
+
+// Negotiate picks a locale of the Default catalog
+func Negotiate(acceptLanguage string) string { return Default.Negotiate(acceptLanguage) }
+
+// Render renders a message of the Default catalog
+func Render(locale, id string, data map[string]interface{}) string {
+	return Default.Render(locale, id, data)
+}
+
+// RenderAll renders a message of the Default catalog in every locale
+func RenderAll(id string, data map[string]interface{}) map[string]string {
+	return Default.RenderAll(id, data)
+}
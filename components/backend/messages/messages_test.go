@@ -0,0 +1,69 @@
+package messages
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLocalesAreComplete verifies every locale translates every English message and renders
+// with the data the handlers pass
+func TestLocalesAreComplete(t *testing.T) {
+	data := map[string]interface{}{
+		"Verb": "verb.create", "Details": "spec.prompt: Required value", "Project": "team-a", "Limit": 3,
+		"Hours": 24, "Provider": "vertex", "Message": "upgrade", "Until": "2026-01-01T00:00:00Z", "Error": "timeout",
+	}
+	english := Default.IDs(DefaultLocale)
+	for _, locale := range Default.Locales() {
+		if got := Default.IDs(locale); !reflect.DeepEqual(got, english) {
+			t.Errorf("%s messages differ from %s: %v", locale, DefaultLocale, got)
+		}
+		for _, id := range english {
+			text := Default.Render(locale, id, data)
+			if text == id || strings.Contains(text, "<no value>") {
+				t.Errorf("%s %s rendered %q", locale, id, text)
+			}
+		}
+	}
+	if len(Default.Locales()) < 2 || Default.Locales()[0] != DefaultLocale {
+		t.Errorf("locales = %v", Default.Locales())
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                        "en",
+		"de-DE,de;q=0.9,en;q=0.8": "de",
+		"fr-FR, es;q=0.5":         "es",
+		"es-419":                  "es",
+		"fr, ja":                  "en",
+		"en-GB;q=0.9, de;q=0.1":   "en",
+		"not a header;;":          "en",
+	} {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	if got := Render("de", "agent.forbidden", map[string]interface{}{"Verb": "verb.delete"}); got != "Keine Berechtigung zum Löschen von Agenten" {
+		t.Errorf("de agent.forbidden = %q", got)
+	}
+	c, err := NewCatalog(map[string]map[string]string{
+		"en": {"greeting": "Hello {{.Name}}"},
+		"de": {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Render("de", "greeting", map[string]interface{}{"Name": "Ada"}); got != "Hello Ada" {
+		t.Errorf("missing translation = %q, want the English text", got)
+	}
+	if got := c.Render("en", "unknown.id", nil); got != "unknown.id" {
+		t.Errorf("unknown message = %q", got)
+	}
+	if _, err := NewCatalog(map[string]map[string]string{"de": {}}); err == nil {
+		t.Error("a catalog without English was accepted")
+	}
+}
@@ -0,0 +1,262 @@
+// Package moderation checks session prompts before the backend submits them.
+//
+// A project's ProjectSettings spec.promptPolicy sets size limits and configures a chain of
+// moderators. Each moderator sees the prompt as left by the previous one and may allow it,
+// redact parts of it or block it:
+//
+//   - secrets: credentials matched by the publish secret scan's patterns
+//   - rules: the policy's regular expressions
+//   - webhook: an external endpoint, for classifiers the backend does not ship
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"ambient-code-backend/git"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+)
+
+// DefaultReplacement stands in for redacted text
+const DefaultReplacement = "[REDACTED]"
+
+// Action is a moderator's verdict on a prompt
+type Action string
+
+const (
+	Allow  Action = "allow"
+	Block  Action = "block"
+	Redact Action = "redact"
+)
+
+// Decision is the verdict of one moderator. For Redact, Prompt is the redacted prompt.
+type Decision struct {
+	Action Action
+	Prompt string
+	Reason string
+}
+
+// Moderator inspects a prompt. An error means the prompt could not be checked.
+type Moderator interface {
+	Name() string
+	Moderate(ctx context.Context, project, prompt string) (Decision, error)
+}
+
+// RejectedError is returned when a prompt exceeds a limit or a moderator blocks it
+type RejectedError struct {
+	// Moderator is empty for size limits
+	Moderator string
+	Reason    string
+}
+
+func (e *RejectedError) Error() string {
+	if e.Moderator == "" {
+		return "prompt rejected: " + e.Reason
+	}
+	return fmt.Sprintf("prompt rejected by %s: %s", e.Moderator, e.Reason)
+}
+
+// IsRejected reports whether err rejects the prompt, as opposed to failing to check it
+func IsRejected(err error) bool {
+	var rejected *RejectedError
+	return errors.As(err, &rejected)
+}
+
+// EstimateTokens approximates the token count of a prompt at four characters per token,
+// close enough for English text and code to enforce a budget without a tokenizer
+func EstimateTokens(prompt string) int {
+	return (utf8.RuneCountInString(prompt) + 3) / 4
+}
+
+// CheckLimits enforces the policy's maximum length and token estimate
+func CheckLimits(policy *apiv1alpha1.PromptPolicy, prompt string) error {
+	if policy == nil {
+		return nil
+	}
+	if n := utf8.RuneCountInString(prompt); policy.MaxLength > 0 && n > policy.MaxLength {
+		return &RejectedError{Reason: fmt.Sprintf("prompt is %d characters, the project allows %d", n, policy.MaxLength)}
+	}
+	if n := EstimateTokens(prompt); policy.MaxTokens > 0 && n > policy.MaxTokens {
+		return &RejectedError{Reason: fmt.Sprintf("prompt is about %d tokens, the project allows %d", n, policy.MaxTokens)}
+	}
+	return nil
+}
+
+// Result is a prompt that passed moderation
+type Result struct {
+	Prompt string
+	// Redactions describe what was redacted, one entry per moderator that redacted
+	Redactions []string
+}
+
+// Run passes the prompt through the moderators in order. It stops at the first block or error.
+func Run(ctx context.Context, moderators []Moderator, project, prompt string) (Result, error) {
+	result := Result{Prompt: prompt}
+	for _, m := range moderators {
+		d, err := m.Moderate(ctx, project, result.Prompt)
+		if err != nil {
+			return Result{}, fmt.Errorf("%s: %w", m.Name(), err)
+		}
+		switch d.Action {
+		case Block:
+			return Result{}, &RejectedError{Moderator: m.Name(), Reason: d.Reason}
+		case Redact:
+			result.Prompt = d.Prompt
+			result.Redactions = append(result.Redactions, fmt.Sprintf("%s: %s", m.Name(), d.Reason))
+		}
+	}
+	return result, nil
+}
+
+// ForPolicy builds the moderators configured by a policy. tokens resolves the webhook's
+// bearer token Secret in the project.
+func ForPolicy(policy *apiv1alpha1.PromptPolicy, tokens TokenSource) ([]Moderator, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	var moderators []Moderator
+	if policy.Secrets != "" {
+		moderators = append(moderators, &SecretModerator{Action: policy.Secrets})
+	}
+	if len(policy.Rules) > 0 {
+		rules, err := NewRuleModerator(policy.Rules)
+		if err != nil {
+			return nil, err
+		}
+		moderators = append(moderators, rules)
+	}
+	if policy.Webhook != nil && strings.TrimSpace(policy.Webhook.URL) != "" {
+		moderators = append(moderators, NewWebhookModerator(*policy.Webhook, tokens))
+	}
+	return moderators, nil
+}
+
+// Validate checks a policy the way ForPolicy would use it, for the settings endpoints
+func Validate(policy *apiv1alpha1.PromptPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxLength < 0 || policy.MaxTokens < 0 {
+		return errors.New("maxLength and maxTokens must not be negative")
+	}
+	if !validAction(policy.Secrets, true) {
+		return fmt.Errorf("secrets must be Block or Redact, got %q", policy.Secrets)
+	}
+	if _, err := NewRuleModerator(policy.Rules); err != nil {
+		return err
+	}
+	if policy.Webhook != nil {
+		if err := validateWebhookURL(policy.Webhook.URL); err != nil {
+			return fmt.Errorf("webhook.url: %v", err)
+		}
+		if policy.Webhook.TimeoutSeconds < 0 {
+			return errors.New("webhook.timeoutSeconds must not be negative")
+		}
+	}
+	return nil
+}
+
+func validAction(a apiv1alpha1.PromptAction, allowEmpty bool) bool {
+	switch a {
+	case apiv1alpha1.PromptActionBlock, apiv1alpha1.PromptActionRedact:
+		return true
+	case "":
+		return allowEmpty
+	}
+	return false
+}
+
+// SecretModerator blocks or redacts credentials
+type SecretModerator struct {
+	Action apiv1alpha1.PromptAction
+}
+
+func (m *SecretModerator) Name() string { return "secrets" }
+
+// Moderate never reports the credential itself, only its kind
+func (m *SecretModerator) Moderate(_ context.Context, _ string, prompt string) (Decision, error) {
+	matches := git.FindSecrets(prompt)
+	if len(matches) == 0 {
+		return Decision{Action: Allow}, nil
+	}
+	kinds := make([]string, 0, len(matches))
+	seen := map[string]bool{}
+	for _, match := range matches {
+		if !seen[match.Kind] {
+			seen[match.Kind] = true
+			kinds = append(kinds, match.Kind)
+		}
+	}
+	reason := "contains a possible " + strings.Join(kinds, ", ")
+	if m.Action != apiv1alpha1.PromptActionRedact {
+		return Decision{Action: Block, Reason: reason}, nil
+	}
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		b.WriteString(prompt[last:match.Start])
+		b.WriteString(DefaultReplacement)
+		last = match.End
+	}
+	b.WriteString(prompt[last:])
+	return Decision{Action: Redact, Prompt: b.String(), Reason: reason}, nil
+}
+
+// RuleModerator applies a policy's regular expression rules in order
+type RuleModerator struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	apiv1alpha1.PromptRule
+	re *regexp.Regexp
+}
+
+// NewRuleModerator compiles the rules, naming the first invalid one in the error
+func NewRuleModerator(rules []apiv1alpha1.PromptRule) (*RuleModerator, error) {
+	m := &RuleModerator{}
+	for i, r := range rules {
+		if strings.TrimSpace(r.Name) == "" {
+			return nil, fmt.Errorf("rules[%d]: name is required", i)
+		}
+		if !validAction(r.Action, true) {
+			return nil, fmt.Errorf("rules[%d] (%s): action must be Block or Redact, got %q", i, r.Name, r.Action)
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil || r.Pattern == "" {
+			return nil, fmt.Errorf("rules[%d] (%s): invalid pattern: %v", i, r.Name, err)
+		}
+		m.rules = append(m.rules, compiledRule{PromptRule: r, re: re})
+	}
+	return m, nil
+}
+
+func (m *RuleModerator) Name() string { return "rules" }
+
+// Moderate blocks on the first matching Block rule; Redact rules replace every match
+func (m *RuleModerator) Moderate(_ context.Context, _ string, prompt string) (Decision, error) {
+	var redacted []string
+	for _, r := range m.rules {
+		if !r.re.MatchString(prompt) {
+			continue
+		}
+		if r.Action != apiv1alpha1.PromptActionRedact {
+			return Decision{Action: Block, Reason: fmt.Sprintf("matches rule %q", r.Name)}, nil
+		}
+		replacement := r.Replacement
+		if replacement == "" {
+			replacement = DefaultReplacement
+		}
+		prompt = r.re.ReplaceAllLiteralString(prompt, replacement)
+		redacted = append(redacted, r.Name)
+	}
+	if len(redacted) == 0 {
+		return Decision{Action: Allow}, nil
+	}
+	return Decision{Action: Redact, Prompt: prompt, Reason: "redacted by rules " + strings.Join(redacted, ", ")}, nil
+}
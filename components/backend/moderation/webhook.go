@@ -0,0 +1,129 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+)
+
+const (
+	defaultWebhookTimeout = 5 * time.Second
+	// maxWebhookResponse bounds the response body; it carries at most the redacted prompt
+	maxWebhookResponse = 4 << 20
+)
+
+// TokenSource returns the bearer token stored in a project Secret
+type TokenSource func(ctx context.Context, project, secretName string) (string, error)
+
+// WebhookModerator asks an external endpoint to moderate prompts
+type WebhookModerator struct {
+	config apiv1alpha1.PromptWebhook
+	tokens TokenSource
+	client *http.Client
+}
+
+// NewWebhookModerator returns a moderator for the endpoint; tokens may be nil when the
+// endpoint needs no token
+func NewWebhookModerator(config apiv1alpha1.PromptWebhook, tokens TokenSource) *WebhookModerator {
+	timeout := defaultWebhookTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	return &WebhookModerator{config: config, tokens: tokens, client: &http.Client{Timeout: timeout}}
+}
+
+func (m *WebhookModerator) Name() string { return "webhook" }
+
+type webhookRequest struct {
+	Project string `json:"project"`
+	Prompt  string `json:"prompt"`
+}
+
+type webhookResponse struct {
+	Action Action `json:"action"`
+	Prompt string `json:"prompt,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Moderate calls the endpoint. When it cannot be reached or answers with an error the prompt
+// is rejected unless the webhook fails open.
+func (m *WebhookModerator) Moderate(ctx context.Context, project, prompt string) (Decision, error) {
+	d, err := m.call(ctx, project, prompt)
+	if err != nil && m.config.FailOpen {
+		log.Printf("Prompt moderation webhook failed for project %s, accepting the prompt (failOpen): %v", project, err)
+		return Decision{Action: Allow}, nil
+	}
+	return d, err
+}
+
+func (m *WebhookModerator) call(ctx context.Context, project, prompt string) (Decision, error) {
+	body, _ := json.Marshal(webhookRequest{Project: project, Prompt: prompt})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.config.TokenSecret != "" {
+		if m.tokens == nil {
+			return Decision{}, errors.New("no token source for tokenSecret")
+		}
+		token, err := m.tokens(ctx, project, m.config.TokenSecret)
+		if err != nil {
+			return Decision{}, fmt.Errorf("failed to read token secret %s: %w", m.config.TokenSecret, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return Decision{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
+	if err != nil {
+		return Decision{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	var out webhookResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return Decision{}, fmt.Errorf("invalid response: %v", err)
+	}
+	switch Action(strings.ToLower(string(out.Action))) {
+	case Allow:
+		return Decision{Action: Allow}, nil
+	case Block:
+		if out.Reason == "" {
+			out.Reason = "blocked by moderation endpoint"
+		}
+		return Decision{Action: Block, Reason: out.Reason}, nil
+	case Redact:
+		if out.Reason == "" {
+			out.Reason = "redacted by moderation endpoint"
+		}
+		return Decision{Action: Redact, Prompt: out.Prompt, Reason: out.Reason}, nil
+	}
+	return Decision{}, fmt.Errorf("invalid response: unknown action %q", out.Action)
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("must be an absolute http(s) URL")
+	}
+	return nil
+}
@@ -0,0 +1,451 @@
+// Package prompttemplate renders the prompt templates of runbooks, fan-out session templates
+// and the project system prompt.
+//
+// Templates are Go text/template with a restricted function set: the comparison, logic and
+// formatting builtins plus a few string helpers, and no call, so a template cannot invoke
+// functions reachable from its data. Templates cannot include other templates, and range only
+// iterates .Params and not inside another range, so rendering ends quickly whatever the
+// values. Runbook parameters and fan-out variables are declared
+// with a type and a required flag; Check rejects templates that use undeclared variables,
+// and Resolve rejects a launch with missing or invalid values before any session is created.
+// Both return Errors naming every variable at fault, with where the template uses it.
+package prompttemplate
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/gitutil"
+)
+
+// MaxLength bounds a template and its rendered output, in bytes
+const MaxLength = 20000
+
+// maxValueLength bounds a single string variable
+const maxValueLength = 4096
+
+// Variable declares a template variable, available as .Params.<name>. Runbooks keep theirs
+// in spec.runbooks[].parameters.
+type Variable = apiv1alpha1.RunbookParameter
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// VariableError is one problem with a variable: undeclared, missing or of the wrong type
+type VariableError struct {
+	Variable string `json:"variable"`
+	// Position is where the template uses the variable, as name:line:col, when known
+	Position string `json:"position,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Errors lists every variable problem found, so a caller can fix them all at once
+type Errors []VariableError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, v := range e {
+		msg := fmt.Sprintf("parameter %q %s", v.Variable, v.Message)
+		if v.Position != "" {
+			msg = v.Position + ": " + msg
+		}
+		parts = append(parts, msg)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// funcs replace or add to the builtins. Parse also refuses call, so no template can invoke
+// a function value from its data.
+var funcs = template.FuncMap{
+	"call": func(...interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("call is not allowed in prompt templates")
+	},
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" || v == false || v == int64(0) {
+			return def
+		}
+		return v
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"join": func(sep string, items []string) string {
+		return strings.Join(items, sep)
+	},
+	"quote": strconv.Quote,
+}
+
+// Template is a parsed prompt template
+type Template struct {
+	tmpl *template.Template
+	refs []VariableError
+	// refused is the first construct the template may not use, as "position: reason"
+	refused string
+}
+
+// Parse parses text as the template called name (used in error positions). A missing map
+// key is an error when the template is executed, not an empty string.
+func Parse(name, text string) (*Template, error) {
+	if len(text) > MaxLength {
+		return nil, fmt.Errorf("template exceeds %d characters", MaxLength)
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{tmpl: tmpl}
+	for _, tree := range tmpl.Templates() {
+		if tree.Tree != nil && tree.Tree.Root != nil {
+			t.walk(tree.Tree, tree.Tree.Root)
+		}
+	}
+	if t.refused != "" {
+		return nil, fmt.Errorf("%s", t.refused)
+	}
+	return t, nil
+}
+
+// walk records the .Params.<name> and index .Params "<name>" references under n, and the
+// first use of call, of another template or of a range that could loop without bound
+func (t *Template) walk(tree *parse.Tree, n parse.Node) {
+	switch node := n.(type) {
+	case *parse.IdentifierNode:
+		if node.Ident == "call" {
+			t.refuse(tree, node, "call is not allowed in prompt templates")
+		}
+	case *parse.TemplateNode:
+		// A template including itself runs for as long as it nests
+		t.refuse(tree, node, "template and block are not allowed in prompt templates")
+	case *parse.RangeNode:
+		// Parameters are scalars: range over an integer parameter or literal, or ranges
+		// nested in each other, would run for as long as the numbers allow
+		if !rangesOverParams(node.Pipe) {
+			t.refuse(tree, node, "range is only allowed over .Params in prompt templates")
+		} else if containsRange(node.List) {
+			t.refuse(tree, node, "range is not allowed inside another range in prompt templates")
+		}
+	case *parse.FieldNode:
+		if len(node.Ident) >= 2 && node.Ident[0] == "Params" {
+			t.addRef(tree, node, node.Ident[1])
+		}
+	case *parse.CommandNode:
+		if len(node.Args) == 3 {
+			id, isIdent := node.Args[0].(*parse.IdentifierNode)
+			field, isField := node.Args[1].(*parse.FieldNode)
+			key, isString := node.Args[2].(*parse.StringNode)
+			if isIdent && id.Ident == "index" && isField && len(field.Ident) == 1 && field.Ident[0] == "Params" && isString {
+				t.addRef(tree, key, key.Text)
+			}
+		}
+	}
+	for _, child := range children(n) {
+		t.walk(tree, child)
+	}
+}
+
+func (t *Template) refuse(tree *parse.Tree, n parse.Node, reason string) {
+	if t.refused == "" {
+		pos, _ := tree.ErrorContext(n)
+		t.refused = pos + ": " + reason
+	}
+}
+
+// rangesOverParams reports whether pipe is .Params or $.Params, with or without variables
+func rangesOverParams(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		return len(arg.Ident) == 1 && arg.Ident[0] == "Params"
+	case *parse.VariableNode:
+		return len(arg.Ident) == 2 && arg.Ident[0] == "$" && arg.Ident[1] == "Params"
+	}
+	return false
+}
+
+// containsRange reports whether a range appears anywhere under n
+func containsRange(n parse.Node) bool {
+	if _, ok := n.(*parse.RangeNode); ok {
+		return true
+	}
+	for _, child := range children(n) {
+		if containsRange(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Template) addRef(tree *parse.Tree, n parse.Node, name string) {
+	pos, _ := tree.ErrorContext(n)
+	t.refs = append(t.refs, VariableError{Variable: name, Position: pos})
+}
+
+func children(n parse.Node) []parse.Node {
+	var out []parse.Node
+	add := func(nodes ...parse.Node) {
+		for _, c := range nodes {
+			if c != nil && !isNilNode(c) {
+				out = append(out, c)
+			}
+		}
+	}
+	switch node := n.(type) {
+	case *parse.ListNode:
+		for _, c := range node.Nodes {
+			add(c)
+		}
+	case *parse.ActionNode:
+		add(node.Pipe)
+	case *parse.PipeNode:
+		for _, c := range node.Cmds {
+			add(c)
+		}
+	case *parse.CommandNode:
+		add(node.Args...)
+	case *parse.ChainNode:
+		add(node.Node)
+	case *parse.IfNode:
+		add(node.Pipe, node.List, node.ElseList)
+	case *parse.RangeNode:
+		add(node.Pipe, node.List, node.ElseList)
+	case *parse.WithNode:
+		add(node.Pipe, node.List, node.ElseList)
+	case *parse.TemplateNode:
+		add(node.Pipe)
+	}
+	return out
+}
+
+// isNilNode catches typed nil pointers, e.g. a branch without an else list
+func isNilNode(n parse.Node) bool {
+	switch node := n.(type) {
+	case *parse.ListNode:
+		return node == nil
+	case *parse.PipeNode:
+		return node == nil
+	}
+	return false
+}
+
+// Check reports every use of a variable that vars does not declare
+func (t *Template) Check(vars []Variable) error {
+	declared := map[string]bool{}
+	for _, v := range vars {
+		declared[v.Name] = true
+	}
+	var errs Errors
+	for _, r := range t.refs {
+		if !declared[r.Variable] {
+			errs = append(errs, VariableError{Variable: r.Variable, Position: r.Position, Message: "is used but not declared"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Execute renders the template with data; output past MaxLength is an error
+func (t *Template) Execute(data interface{}) (string, error) {
+	var out bytes.Buffer
+	if err := t.tmpl.Execute(&limitedBuffer{buf: &out, limit: MaxLength}, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// limitedBuffer fails writes past limit so a looping template cannot grow without bound
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if l.buf.Len()+len(p) > l.limit {
+		return 0, fmt.Errorf("rendered prompt exceeds %d characters", l.limit)
+	}
+	return l.buf.Write(p)
+}
+
+// ValidateDeclarations checks variable names are identifiers declared once, types are
+// known, enums list their values and defaults have the declared type. An empty type is set
+// to string.
+func ValidateDeclarations(vars []Variable) error {
+	names := map[string]bool{}
+	for i := range vars {
+		v := &vars[i]
+		if !variableNamePattern.MatchString(v.Name) {
+			return fmt.Errorf("parameter name %q must be a letter or underscore followed by letters, digits or underscores", v.Name)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("parameter %q is defined twice", v.Name)
+		}
+		names[v.Name] = true
+		if v.Type == "" {
+			v.Type = apiv1alpha1.RunbookParamString
+		}
+		switch v.Type {
+		case apiv1alpha1.RunbookParamString, apiv1alpha1.RunbookParamInteger, apiv1alpha1.RunbookParamBoolean, apiv1alpha1.RunbookParamRepo:
+		case apiv1alpha1.RunbookParamEnum:
+			if len(v.Enum) == 0 {
+				return fmt.Errorf("enum parameter %q needs enum values", v.Name)
+			}
+		default:
+			return fmt.Errorf("parameter %q has unknown type %q", v.Name, v.Type)
+		}
+		if v.Default != "" {
+			if _, err := coerce(*v, v.Default); err != nil {
+				return fmt.Errorf("default of parameter %q %v", v.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Sample returns a value of the right type for every variable, its default when it has
+// one, to test-render a template on save
+func Sample(vars []Variable) map[string]interface{} {
+	sample := map[string]interface{}{}
+	for _, v := range vars {
+		if v.Default != "" {
+			if value, err := coerce(v, v.Default); err == nil {
+				sample[v.Name] = value
+				continue
+			}
+		}
+		switch v.Type {
+		case apiv1alpha1.RunbookParamEnum:
+			sample[v.Name] = v.Enum[0]
+		case apiv1alpha1.RunbookParamRepo:
+			sample[v.Name] = "https://github.com/org/repo"
+		default:
+			sample[v.Name] = zero(v.Type)
+		}
+	}
+	return sample
+}
+
+// Resolve checks the supplied values against vars and fills in defaults. Values are typed
+// for the template: int64 for integer, bool for boolean and string otherwise. An optional
+// variable without a value or default is its type's zero value. Every unknown, missing or
+// invalid variable is reported in the returned Errors.
+func Resolve(vars []Variable, supplied map[string]interface{}) (map[string]interface{}, error) {
+	var errs Errors
+	declared := map[string]bool{}
+	for _, v := range vars {
+		declared[v.Name] = true
+	}
+	var unknown []string
+	for name := range supplied {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, VariableError{Variable: name, Message: "is not declared"})
+	}
+
+	values := map[string]interface{}{}
+	for _, v := range vars {
+		raw, ok := supplied[v.Name]
+		if !ok || raw == nil || raw == "" {
+			if v.Default == "" {
+				if v.Required {
+					errs = append(errs, VariableError{Variable: v.Name, Message: "is required"})
+					continue
+				}
+				values[v.Name] = zero(v.Type)
+				continue
+			}
+			raw = v.Default
+		}
+		value, err := coerce(v, raw)
+		if err != nil {
+			errs = append(errs, VariableError{Variable: v.Name, Message: err.Error()})
+			continue
+		}
+		values[v.Name] = value
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return values, nil
+}
+
+func zero(typ string) interface{} {
+	switch typ {
+	case apiv1alpha1.RunbookParamInteger:
+		return int64(0)
+	case apiv1alpha1.RunbookParamBoolean:
+		return false
+	}
+	return ""
+}
+
+// coerce converts a JSON value (or a default, which is always a string) to the variable's
+// type
+func coerce(v Variable, raw interface{}) (interface{}, error) {
+	switch v.Type {
+	case apiv1alpha1.RunbookParamInteger:
+		switch val := raw.(type) {
+		case float64:
+			if val != math.Trunc(val) || math.Abs(val) > 1<<53 {
+				return nil, fmt.Errorf("must be an integer")
+			}
+			return int64(val), nil
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be an integer")
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("must be an integer")
+	case apiv1alpha1.RunbookParamBoolean:
+		switch val := raw.(type) {
+		case bool:
+			return val, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(val))
+			if err != nil {
+				return nil, fmt.Errorf("must be true or false")
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("must be true or false")
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("must be a string")
+	}
+	if len(s) > maxValueLength {
+		return nil, fmt.Errorf("exceeds %d characters", maxValueLength)
+	}
+	switch v.Type {
+	case apiv1alpha1.RunbookParamEnum:
+		for _, allowed := range v.Enum {
+			if s == allowed {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(v.Enum, ", "))
+	case apiv1alpha1.RunbookParamRepo:
+		u, err := gitutil.Normalize(s)
+		if err != nil {
+			return nil, err
+		}
+		return u, nil
+	}
+	return s, nil
+}
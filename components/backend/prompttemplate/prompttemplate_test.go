@@ -0,0 +1,103 @@
+package prompttemplate
+
+import (
+	"strings"
+	"testing"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+)
+
+// TestParseRejectsCall verifies templates cannot call function values from their data
+func TestParseRejectsCall(t *testing.T) {
+	if _, err := Parse("p", `Hi {{call .Fn}}`); err == nil || !strings.Contains(err.Error(), "call is not allowed") {
+		t.Fatalf("call accepted: %v", err)
+	}
+	tmpl, err := Parse("p", `{{.Params.name | upper | quote}} {{default "main" .Params.branch}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tmpl.Execute(map[string]interface{}{"Params": map[string]interface{}{"name": "api", "branch": ""}})
+	if err != nil || out != `"API" main` {
+		t.Fatalf("got %q, %v", out, err)
+	}
+}
+
+// TestParseRejectsUnboundedLoops verifies a template cannot loop for as long as an integer
+// or its own nesting allows, while ranging over the parameters still works
+func TestParseRejectsUnboundedLoops(t *testing.T) {
+	for _, text := range []string{
+		`{{range 20000}}{{range 20000}}{{end}}{{end}}`,
+		`{{range .Params.count}}x{{end}}`,
+		`{{range $i := .Index}}x{{end}}`,
+		`{{range .Params}}{{range $.Params}}{{end}}{{end}}`,
+		`{{define "a"}}{{template "a" .}}{{template "a" .}}{{end}}{{template "a" .}}`,
+		`{{block "a" .}}x{{end}}`,
+	} {
+		if _, err := Parse("p", text); err == nil || !strings.Contains(err.Error(), "p:1:") {
+			t.Errorf("%s accepted or error without position: %v", text, err)
+		}
+	}
+	tmpl, err := Parse("p", `{{range $k, $v := .Params}}{{$k}}={{$v}} {{end}}{{range $.Params}}{{else}}none{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tmpl.Execute(map[string]interface{}{"Params": map[string]interface{}{"a": "1", "b": int64(2)}})
+	if err != nil || out != "a=1 b=2 " {
+		t.Fatalf("got %q, %v", out, err)
+	}
+}
+
+// TestCheckReportsUndeclaredWithPositions verifies every undeclared use is reported with
+// where the template uses it, including index lookups and branches
+func TestCheckReportsUndeclaredWithPositions(t *testing.T) {
+	tmpl, err := Parse("runbook", "Fix {{.Params.repo}}\n{{if .Params.urgent}}now{{end}} {{index .Params \"ticket\"}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tmpl.Check([]Variable{{Name: "repo"}})
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("want 2 errors, got %v", err)
+	}
+	if errs[0].Variable != "urgent" || !strings.HasPrefix(errs[0].Position, "runbook:2:") {
+		t.Errorf("unexpected first error %+v", errs[0])
+	}
+	if errs[1].Variable != "ticket" || errs[1].Message != "is used but not declared" {
+		t.Errorf("unexpected second error %+v", errs[1])
+	}
+	if err := tmpl.Check([]Variable{{Name: "repo"}, {Name: "urgent"}, {Name: "ticket"}}); err != nil {
+		t.Errorf("declared variables rejected: %v", err)
+	}
+}
+
+// TestResolveCollectsErrors verifies one launch reports every unknown, missing and invalid
+// variable, and fills in defaults and zero values otherwise
+func TestResolveCollectsErrors(t *testing.T) {
+	vars := []Variable{
+		{Name: "service", Required: true},
+		{Name: "count", Type: apiv1alpha1.RunbookParamInteger, Default: "3"},
+		{Name: "dryRun", Type: apiv1alpha1.RunbookParamBoolean},
+		{Name: "env", Type: apiv1alpha1.RunbookParamEnum, Enum: []string{"dev", "prod"}},
+	}
+	if err := ValidateDeclarations(vars); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Resolve(vars, map[string]interface{}{"env": "qa", "count": "x", "extra": "1"})
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 4 {
+		t.Fatalf("want 4 errors, got %v", err)
+	}
+	for _, want := range []string{`"extra" is not declared`, `"service" is required`, `"count" must be an integer`, `"env" must be one of dev, prod`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+
+	values, err := Resolve(vars, map[string]interface{}{"service": "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["count"] != int64(3) || values["dryRun"] != false || values["env"] != "" {
+		t.Errorf("unexpected values %v", values)
+	}
+}
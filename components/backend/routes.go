@@ -1,7 +1,11 @@
 package main
 
 import (
+	"net/http"
+	"time"
+
 	"ambient-code-backend/handlers"
+	"ambient-code-backend/server"
 	"ambient-code-backend/websocket"
 
 	"github.com/gin-gonic/gin"
@@ -11,8 +15,10 @@ func registerContentRoutes(r *gin.Engine) {
 	r.POST("/content/write", handlers.ContentWrite)
 	r.GET("/content/file", handlers.ContentRead)
 	r.GET("/content/list", handlers.ContentList)
+	r.GET("/content/archive", handlers.ContentArchive)
 	r.POST("/content/github/push", handlers.ContentGitPush)
 	r.POST("/content/github/abandon", handlers.ContentGitAbandon)
+	r.POST("/content/github/checks", handlers.ContentGitChecks)
 	r.GET("/content/github/diff", handlers.ContentGitDiff)
 	r.GET("/content/git-status", handlers.ContentGitStatus)
 	r.POST("/content/git-configure-remote", handlers.ContentGitConfigureRemote)
@@ -34,6 +40,31 @@ func registerRoutes(r *gin.Engine) {
 
 		api.POST("/projects/:projectName/agentic-sessions/:sessionName/github/token", handlers.MintSessionGitHubToken)
 
+		// Session share links (signed token is the credential)
+		api.GET("/shared/:token", handlers.GetSharedSession)
+		api.GET("/shared/:token/messages", websocket.GetSharedSessionMessages)
+
+		// GitOps import, authenticated by the webhook signature
+		api.POST("/gitops/webhook/:projectName", handlers.GitOpsWebhook)
+
+		// SCIM 2.0 provisioning from the identity provider, authenticated by SCIM_TOKEN
+		scim := api.Group("/scim/v2", handlers.RequireSCIMToken())
+		{
+			scim.GET("/ServiceProviderConfig", handlers.GetSCIMServiceProviderConfig)
+			scim.GET("/Users", handlers.ListSCIMUsers)
+			scim.POST("/Users", handlers.CreateSCIMUser)
+			scim.GET("/Users/:id", handlers.GetSCIMUser)
+			scim.PUT("/Users/:id", handlers.ReplaceSCIMUser)
+			scim.PATCH("/Users/:id", handlers.PatchSCIMUser)
+			scim.DELETE("/Users/:id", handlers.DeleteSCIMUser)
+			scim.GET("/Groups", handlers.ListSCIMGroups)
+			scim.POST("/Groups", handlers.CreateSCIMGroup)
+			scim.GET("/Groups/:id", handlers.GetSCIMGroup)
+			scim.PUT("/Groups/:id", handlers.ReplaceSCIMGroup)
+			scim.PATCH("/Groups/:id", handlers.PatchSCIMGroup)
+			scim.DELETE("/Groups/:id", handlers.DeleteSCIMGroup)
+		}
+
 		projectGroup := api.Group("/projects/:projectName", handlers.ValidateProjectContext())
 		{
 			projectGroup.GET("/access", handlers.AccessCheck)
@@ -44,8 +75,14 @@ func registerRoutes(r *gin.Engine) {
 			projectGroup.GET("/repo/blob", handlers.GetRepoBlob)
 			projectGroup.GET("/repo/branches", handlers.ListRepoBranches)
 
+			projectGroup.GET("/capacity", handlers.GetProjectCapacity)
+			projectGroup.GET("/runner-security", handlers.GetRunnerSecurityReport)
+
 			projectGroup.GET("/agentic-sessions", handlers.ListSessions)
 			projectGroup.POST("/agentic-sessions", handlers.CreateSession)
+			projectGroup.POST("/agentic-sessions/bulk-delete", handlers.BulkDeleteSessions)
+			projectGroup.POST("/agentic-sessions/fanout", handlers.FanoutSessions)
+			projectGroup.GET("/agentic-sessions/fanout/:batchId", handlers.GetFanoutBatch)
 			projectGroup.GET("/agentic-sessions/:sessionName", handlers.GetSession)
 			projectGroup.PUT("/agentic-sessions/:sessionName", handlers.UpdateSession)
 			projectGroup.PATCH("/agentic-sessions/:sessionName", handlers.PatchSession)
@@ -53,10 +90,14 @@ func registerRoutes(r *gin.Engine) {
 			projectGroup.POST("/agentic-sessions/:sessionName/clone", handlers.CloneSession)
 			projectGroup.POST("/agentic-sessions/:sessionName/start", handlers.StartSession)
 			projectGroup.POST("/agentic-sessions/:sessionName/stop", handlers.StopSession)
+			projectGroup.POST("/agentic-sessions/:sessionName/heartbeat", handlers.SessionHeartbeat)
 			projectGroup.PUT("/agentic-sessions/:sessionName/status", handlers.UpdateSessionStatus)
 			projectGroup.GET("/agentic-sessions/:sessionName/workspace", handlers.ListSessionWorkspace)
+			projectGroup.GET("/agentic-sessions/:sessionName/workspace.tar.gz", handlers.GetSessionWorkspaceArchive)
 			projectGroup.GET("/agentic-sessions/:sessionName/workspace/*path", handlers.GetSessionWorkspaceFile)
 			projectGroup.PUT("/agentic-sessions/:sessionName/workspace/*path", handlers.PutSessionWorkspaceFile)
+			projectGroup.GET("/agentic-sessions/:sessionName/inputs", handlers.ListSessionInputs)
+			projectGroup.POST("/agentic-sessions/:sessionName/inputs", handlers.UploadSessionInputs)
 			projectGroup.POST("/agentic-sessions/:sessionName/github/push", handlers.PushSessionRepo)
 			projectGroup.POST("/agentic-sessions/:sessionName/github/abandon", handlers.AbandonSessionRepo)
 			projectGroup.GET("/agentic-sessions/:sessionName/github/diff", handlers.DiffSessionRepo)
@@ -69,14 +110,34 @@ func registerRoutes(r *gin.Engine) {
 			projectGroup.POST("/agentic-sessions/:sessionName/git/create-branch", handlers.GitCreateBranchSession)
 			projectGroup.GET("/agentic-sessions/:sessionName/git/list-branches", handlers.GitListBranchesSession)
 			projectGroup.GET("/agentic-sessions/:sessionName/k8s-resources", handlers.GetSessionK8sResources)
+			projectGroup.GET("/agentic-sessions/:sessionName/timeline", handlers.GetSessionTimeline)
+			projectGroup.GET("/agentic-sessions/:sessionName/resource-usage", handlers.GetSessionResourceUsage)
 			projectGroup.POST("/agentic-sessions/:sessionName/spawn-content-pod", handlers.SpawnContentPod)
 			projectGroup.GET("/agentic-sessions/:sessionName/content-pod-status", handlers.GetContentPodStatus)
 			projectGroup.DELETE("/agentic-sessions/:sessionName/content-pod", handlers.DeleteContentPod)
 			projectGroup.POST("/agentic-sessions/:sessionName/workflow", handlers.SelectWorkflow)
 			projectGroup.GET("/agentic-sessions/:sessionName/workflow/metadata", handlers.GetWorkflowMetadata)
 			projectGroup.POST("/agentic-sessions/:sessionName/repos", handlers.AddRepo)
+			projectGroup.POST("/agentic-sessions/:sessionName/share", handlers.CreateSessionShareLink)
+			projectGroup.DELETE("/agentic-sessions/:sessionName/share", handlers.RevokeSessionShareLinks)
+			projectGroup.POST("/agentic-sessions/:sessionName/preview", handlers.CreateSessionPreview)
+			projectGroup.GET("/agentic-sessions/:sessionName/preview", handlers.GetSessionPreview)
+			projectGroup.DELETE("/agentic-sessions/:sessionName/preview", handlers.DeleteSessionPreview)
 			projectGroup.DELETE("/agentic-sessions/:sessionName/repos/:repoName", handlers.RemoveRepo)
 
+			projectGroup.GET("/session-groups/:group", handlers.GetSessionGroup)
+			projectGroup.POST("/session-groups/:group/locks", handlers.AcquireSessionGroupLock)
+			projectGroup.DELETE("/session-groups/:group/locks", handlers.ReleaseSessionGroupLock)
+			projectGroup.GET("/agents", handlers.ListAgents)
+			projectGroup.POST("/agents", handlers.CreateAgent)
+			projectGroup.GET("/agents/:name", handlers.GetAgent)
+			projectGroup.PUT("/agents/:name", handlers.UpdateAgent)
+			projectGroup.DELETE("/agents/:name", handlers.DeleteAgent)
+			projectGroup.GET("/session-batches", handlers.ListSessionBatches)
+			projectGroup.POST("/session-batches", handlers.CreateSessionBatch)
+			projectGroup.GET("/session-batches/:name", handlers.GetSessionBatch)
+			projectGroup.DELETE("/session-batches/:name", handlers.DeleteSessionBatch)
+
 			projectGroup.GET("/sessions/:sessionId/ws", websocket.HandleSessionWebSocket)
 			projectGroup.GET("/sessions/:sessionId/messages", websocket.GetSessionMessagesWS)
 			// Removed: /messages/claude-format - Using SDK's built-in resume with persisted ~/.claude state
@@ -86,6 +147,12 @@ func registerRoutes(r *gin.Engine) {
 			projectGroup.POST("/permissions", handlers.AddProjectPermission)
 			projectGroup.DELETE("/permissions/:subjectType/:subjectName", handlers.RemoveProjectPermission)
 
+			projectGroup.GET("/members", handlers.ListProjectMembers)
+			projectGroup.POST("/members", handlers.AddProjectMember)
+			projectGroup.DELETE("/members/:subjectType/:subjectName", handlers.RemoveProjectMember)
+			projectGroup.POST("/ownership/transfer", handlers.TransferProjectOwnership)
+			projectGroup.GET("/ownership/transfers", handlers.ListOwnershipTransfers)
+
 			projectGroup.GET("/keys", handlers.ListProjectKeys)
 			projectGroup.POST("/keys", handlers.CreateProjectKey)
 			projectGroup.DELETE("/keys/:keyId", handlers.DeleteProjectKey)
@@ -95,6 +162,22 @@ func registerRoutes(r *gin.Engine) {
 			projectGroup.PUT("/runner-secrets", handlers.UpdateRunnerSecrets)
 			projectGroup.GET("/integration-secrets", handlers.ListIntegrationSecrets)
 			projectGroup.PUT("/integration-secrets", handlers.UpdateIntegrationSecrets)
+
+			projectGroup.GET("/llm-provider", handlers.GetLLMProvider)
+			projectGroup.PUT("/llm-provider", handlers.UpdateLLMProvider)
+			projectGroup.GET("/system-prompt", handlers.GetSystemPrompt)
+			projectGroup.PUT("/system-prompt", handlers.UpdateSystemPrompt)
+			projectGroup.GET("/runbooks", handlers.ListRunbooks)
+			projectGroup.PUT("/runbooks", handlers.UpdateRunbooks)
+			projectGroup.POST("/runbooks/:name/execute", handlers.ExecuteRunbook)
+
+			projectGroup.GET("/settings", handlers.GetProjectSettings)
+			projectGroup.GET("/settings/revisions", handlers.ListSettingsRevisions)
+			projectGroup.GET("/settings/revisions/:revision", handlers.GetSettingsRevision)
+			projectGroup.POST("/settings/revisions/:revision/rollback", handlers.RollbackSettingsRevision)
+			projectGroup.GET("/gitops-bundle", handlers.GetGitOpsBundle)
+
+			projectGroup.GET("/tasks", handlers.ListProjectTasks)
 		}
 
 		api.POST("/auth/github/install", handlers.LinkGitHubInstallationGlobal)
@@ -106,12 +189,69 @@ func registerRoutes(r *gin.Engine) {
 		api.GET("/cluster-info", handlers.GetClusterInfo)
 
 		api.GET("/projects", handlers.ListProjects)
+		api.GET("/dashboard", handlers.GetDashboard)
+
+		// Background tasks started by long operations (poll until final, or cancel)
+		api.GET("/tasks/:taskId", handlers.GetTask)
+		api.POST("/tasks/:taskId/cancel", handlers.CancelTask)
+
+		// Maintenance kill switch for session creation (changes restricted to platform admins)
+		api.GET("/admin/maintenance", handlers.GetMaintenance)
+		api.POST("/admin/maintenance", handlers.SetMaintenance)
+
+		// Apply one settings patch across projects, with dry run and rollback (platform admins)
+		api.POST("/admin/settings/rollout", handlers.RolloutProjectSettings)
+		api.GET("/admin/settings/rollouts/:rolloutId", handlers.GetSettingsRollout)
+		api.POST("/admin/settings/rollouts/:rolloutId/rollback", handlers.RollbackSettingsRollout)
+
+		// Rediscover the OOTB workflow catalog before its cache expires (platform admins)
+		api.POST("/workflows/ootb/refresh", handlers.RefreshOOTBWorkflows)
+
 		api.POST("/projects", handlers.CreateProject)
 		api.GET("/projects/:projectName", handlers.GetProject)
-		api.PUT("/projects/:projectName", handlers.UpdateProject)
+		api.PUT("/projects/:projectName", handlers.ApplyProject)
 		api.DELETE("/projects/:projectName", handlers.DeleteProject)
 	}
 
+	// Internal endpoints called by runner pods (auth via runner SA token, optionally restricted
+	// by source CIDR and runner client certificate)
+	internal := r.Group("/internal", server.InternalAccessMiddleware())
+	{
+		internal.GET("/runner-config/:session", handlers.GetRunnerConfig)
+		internal.POST("/artifacts/:session/uploads", handlers.CreateArtifactUpload)
+		internal.HEAD("/artifacts/:session/uploads/:id", handlers.HeadArtifactUpload)
+		internal.PATCH("/artifacts/:session/uploads/:id", handlers.PatchArtifactUpload)
+		internal.GET("/inputs/:session", handlers.ListRunnerSessionInputs)
+		internal.GET("/inputs/:session/:name", handlers.GetRunnerSessionInput)
+	}
+
+	// Session frames from the other backend replicas (WS_RELAY_SERVICE), authenticated by
+	// WS_RELAY_SECRET
+	r.POST("/relay/frames", websocket.HandleRelayFrames)
+
 	// Health check endpoint
 	r.GET("/health", handlers.Health)
+	r.GET("/ready", handlers.Ready)
+
+	// Prometheus metrics
+	r.GET("/metrics", handlers.Metrics)
+
+	// Routes that move files, run git or touch many objects get more than the default handler
+	// timeout; HANDLER_ROUTE_TIMEOUTS can still override them
+	for _, rt := range []struct {
+		method, path string
+		timeout      time.Duration
+	}{
+		{http.MethodPost, "/api/projects/:projectName/agentic-sessions/fanout", 5 * time.Minute},
+		{http.MethodGet, "/api/projects/:projectName/agentic-sessions/:sessionName/workspace.tar.gz", 30 * time.Minute},
+		{http.MethodPost, "/api/projects/:projectName/agentic-sessions/:sessionName/inputs", 10 * time.Minute},
+		{http.MethodPost, "/api/projects/:projectName/agentic-sessions/:sessionName/github/push", 3 * time.Minute},
+		{http.MethodPost, "/api/projects/:projectName/agentic-sessions/:sessionName/git/push", 3 * time.Minute},
+		{http.MethodPost, "/api/projects/:projectName/ownership/transfer", 3 * time.Minute},
+		{http.MethodPost, "/api/admin/settings/rollout", 5 * time.Minute},
+		{http.MethodPatch, "/internal/artifacts/:session/uploads/:id", 10 * time.Minute},
+		{http.MethodGet, "/internal/inputs/:session/:name", 10 * time.Minute},
+	} {
+		server.SetRouteTimeout(rt.method, rt.path, rt.timeout)
+	}
 }
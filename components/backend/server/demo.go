@@ -0,0 +1,98 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DemoMode makes the backend read-only for public demos: every mutating request is rejected
+// with a structured "demo mode" error. Enabled with DEMO_MODE=true.
+var DemoMode bool
+
+// DemoFixturesDir, when set (DEMO_FIXTURES_DIR), answers API reads in demo mode from JSON
+// fixtures instead of the cluster: GET /api/projects/demo/agentic-sessions is served from
+// <dir>/api/projects/demo/agentic-sessions.json. Reads without a fixture reach the handler.
+var DemoFixturesDir string
+
+// demoModeErrorCode lets clients tell demo rejections apart from permission errors
+const demoModeErrorCode = "DEMO_MODE"
+
+// demoBlockedReads are GET routes with side effects, rejected like writes
+var demoBlockedReads = []string{
+	"/api/auth/github/user/callback",
+}
+
+func loadDemoMode() {
+	DemoMode = os.Getenv("DEMO_MODE") == "true"
+	if !DemoMode {
+		return
+	}
+	if dir := strings.TrimSpace(os.Getenv("DEMO_FIXTURES_DIR")); dir != "" {
+		DemoFixturesDir = filepath.Clean(dir)
+	}
+	log.Printf("Demo mode enabled: mutating requests are rejected (fixtures: %q)", DemoFixturesDir)
+}
+
+// demoModeMiddleware enforces DemoMode; it is a no-op when demo mode is off
+func demoModeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !DemoMode {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			rejectInDemoMode(c)
+			return
+		}
+		for _, p := range demoBlockedReads {
+			if c.Request.URL.Path == p {
+				rejectInDemoMode(c)
+				return
+			}
+		}
+		if c.Request.Method == http.MethodGet && serveDemoFixture(c) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func rejectInDemoMode(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error": "This is a read-only demo; changes are disabled",
+		"code":  demoModeErrorCode,
+	})
+}
+
+// serveDemoFixture writes the fixture for the request path, if there is one
+func serveDemoFixture(c *gin.Context) bool {
+	if DemoFixturesDir == "" {
+		return false
+	}
+	rel := path.Clean("/" + c.Request.URL.Path)
+	if !strings.HasPrefix(rel, "/api/") {
+		return false
+	}
+	file := filepath.Join(DemoFixturesDir, filepath.FromSlash(rel)+".json")
+	if !strings.HasPrefix(file, DemoFixturesDir+string(os.PathSeparator)) {
+		return false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read demo fixture %s: %v", file, err)
+		}
+		return false
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	return true
+}
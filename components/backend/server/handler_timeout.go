@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandlerTimeout bounds how long a handler may work on a request (HANDLER_TIMEOUT, 0 = off).
+// Routes that legitimately take longer register their own limit with SetRouteTimeout;
+// HANDLER_ROUTE_TIMEOUTS overrides any route, e.g.
+// "POST /api/projects/:projectName/agentic-sessions/fanout=10m,GET /api/dashboard=30s".
+var HandlerTimeout = 60 * time.Second
+
+var (
+	// routeTimeouts are the limits routes register in code
+	routeTimeouts = map[string]time.Duration{}
+	// routeTimeoutOverrides are the operator's limits from HANDLER_ROUTE_TIMEOUTS
+	routeTimeoutOverrides = map[string]time.Duration{}
+)
+
+// SetRouteTimeout gives one route (method and path as registered) its own handler timeout.
+// Call it while registering routes; 0 exempts the route.
+func SetRouteTimeout(method, path string, d time.Duration) {
+	routeTimeouts[method+" "+path] = d
+}
+
+func loadHandlerTimeouts() {
+	HandlerTimeout = envDuration("HANDLER_TIMEOUT", HandlerTimeout, true)
+	for _, entry := range strings.Split(os.Getenv("HANDLER_ROUTE_TIMEOUTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		route := strings.Join(strings.Fields(entry[:max(i, 0)]), " ")
+		d, err := time.ParseDuration(strings.TrimSpace(entry[i+1:]))
+		if i < 0 || len(strings.Fields(route)) != 2 || err != nil || d < 0 {
+			log.Printf("Ignoring invalid HANDLER_ROUTE_TIMEOUTS entry %q (want \"METHOD /path=duration\")", entry)
+			continue
+		}
+		routeTimeoutOverrides[route] = d
+	}
+}
+
+// handlerTimeoutFor returns the limit of a route
+func handlerTimeoutFor(method, path string) time.Duration {
+	key := method + " " + path
+	if d, ok := routeTimeoutOverrides[key]; ok {
+		return d
+	}
+	if d, ok := routeTimeouts[key]; ok {
+		return d
+	}
+	return HandlerTimeout
+}
+
+// isLongLivedRequest reports requests that stay open by design and bound themselves: WebSocket
+// upgrades, watch streams, Server-Sent Events and session waits (waitForPhase/timeoutSeconds)
+func isLongLivedRequest(c *gin.Context) bool {
+	if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") || strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		return true
+	}
+	if v := c.Query("watch"); v == "true" || v == "1" {
+		return true
+	}
+	return c.Query("waitForPhase") != ""
+}
+
+// handlerTimeoutMiddleware puts the route's deadline on the request context. Handlers pass
+// that context to the Kubernetes client and outbound calls, so past the deadline their calls
+// fail and they return; the server error they write is replaced with 503 Service Unavailable,
+// and a handler that wrote nothing gets one too. Handlers are not interrupted: one that
+// ignores the context runs to completion.
+func handlerTimeoutMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isLongLivedRequest(c) {
+			c.Next()
+			return
+		}
+		timeout := handlerTimeoutFor(c.Request.Method, c.FullPath())
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		w := &handlerTimeoutWriter{ResponseWriter: c.Writer, ctx: ctx, timeout: timeout}
+		c.Writer = w
+		c.Next()
+		if !w.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if w.replaced {
+			log.Printf("Handler timed out after %s: %s %s", timeout, c.Request.Method, c.FullPath())
+		}
+	}
+}
+
+type handlerTimeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timeout  time.Duration
+	replaced bool
+}
+
+func (w *handlerTimeoutWriter) WriteHeader(code int) {
+	if w.replaced {
+		return
+	}
+	if code >= http.StatusInternalServerError && !w.Written() && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.replaced = true
+		body, _ := json.Marshal(gin.H{
+			"error":  fmt.Sprintf("Request timed out after %s", w.timeout),
+			"detail": "The request was only partially processed: changes made before the timeout may have been applied. Check the resource state before retrying.",
+		})
+		w.ResponseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.ResponseWriter.Write(body)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *handlerTimeoutWriter) Write(data []byte) (int, error) {
+	if w.replaced {
+		// Swallow the handler's original error body
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *handlerTimeoutWriter) WriteString(s string) (int, error) {
+	if w.replaced {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestHandlerTimeoutMiddleware verifies a server error written past the route's deadline
+// becomes a 503, long-lived requests get no deadline, and per-route overrides apply
+func TestHandlerTimeoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer func(d time.Duration) { HandlerTimeout = d }(HandlerTimeout)
+	HandlerTimeout = 20 * time.Millisecond
+	t.Setenv("HANDLER_ROUTE_TIMEOUTS", "GET /slow-ok=1s, bogus, GET /x=nope")
+	loadHandlerTimeouts()
+	defer func() { routeTimeoutOverrides = map[string]time.Duration{} }()
+
+	r := gin.New()
+	r.Use(handlerTimeoutMiddleware())
+	slow := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "context deadline exceeded"})
+		case <-time.After(100 * time.Millisecond):
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		}
+	}
+	r.GET("/slow", slow)
+	r.GET("/slow-ok", slow)
+	r.GET("/silent", func(c *gin.Context) { <-c.Request.Context().Done() })
+
+	for _, tc := range []struct {
+		path   string
+		header string
+		want   int
+	}{
+		{"/slow", "", http.StatusServiceUnavailable},
+		{"/silent", "", http.StatusServiceUnavailable},
+		{"/slow-ok", "", http.StatusOK},
+		{"/slow?watch=true", "", http.StatusOK},
+		{"/slow", "text/event-stream", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set("Accept", tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s (Accept %q): status %d, want %d", tc.path, tc.header, w.Code, tc.want)
+		}
+		if w.Code == http.StatusServiceUnavailable && tc.path == "/slow" && !strings.Contains(w.Body.String(), "timed out after 20ms") {
+			t.Errorf("%s: body %s", tc.path, w.Body.String())
+		}
+	}
+	if len(routeTimeoutOverrides) != 1 {
+		t.Errorf("overrides = %v, want only the valid entry", routeTimeoutOverrides)
+	}
+}
+
+// TestEnvDuration verifies durations, whole seconds, and the zero and invalid fallbacks
+func TestEnvDuration(t *testing.T) {
+	for _, tc := range []struct {
+		raw       string
+		allowZero bool
+		want      time.Duration
+	}{
+		{"", false, time.Minute},
+		{"15s", false, 15 * time.Second},
+		{"30", false, 30 * time.Second},
+		{"0", true, 0},
+		{"0", false, time.Minute},
+		{"-5s", true, time.Minute},
+		{"soon", true, time.Minute},
+	} {
+		t.Setenv("TEST_DURATION", tc.raw)
+		if got := envDuration("TEST_DURATION", time.Minute, tc.allowZero); got != tc.want {
+			t.Errorf("envDuration(%q, allowZero=%v) = %s, want %s", tc.raw, tc.allowZero, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTP server tuning, set from the environment by loadHTTPConfig. Durations accept a Go
+// duration ("15s") or whole seconds. Read and write timeouts are off by default because they
+// cover the whole request: watch streams, WebSockets and large uploads would be cut off.
+// Slow clients are bounded by the header timeout and by the handler timeouts instead.
+var (
+	// ReadHeaderTimeout bounds reading a request's headers (HTTP_READ_HEADER_TIMEOUT); it is
+	// what stops slowloris clients from holding connections open
+	ReadHeaderTimeout = 10 * time.Second
+	// ReadTimeout bounds reading a whole request, body included (HTTP_READ_TIMEOUT, 0 = off)
+	ReadTimeout time.Duration
+	// WriteTimeout bounds writing a response (HTTP_WRITE_TIMEOUT, 0 = off)
+	WriteTimeout time.Duration
+	// IdleTimeout closes keep-alive connections idle this long (HTTP_IDLE_TIMEOUT)
+	IdleTimeout = 120 * time.Second
+	// MaxHeaderBytes caps the request line and headers (HTTP_MAX_HEADER_BYTES); large enough
+	// for OAuth proxy tokens and cookies
+	MaxHeaderBytes = 64 << 10
+
+	// WebSocketMaxMessageBytes caps one message from a session WebSocket client
+	// (WS_MAX_MESSAGE_BYTES); runner messages carry tool output, so it is generous
+	WebSocketMaxMessageBytes int64 = 8 << 20
+	// WebSocketIdleTimeout drops a session WebSocket whose client neither sends anything nor
+	// answers the server's pings for this long (WS_IDLE_TIMEOUT); pings go out every 30s
+	WebSocketIdleTimeout = 90 * time.Second
+
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For and X-Real-IP headers
+	// name the client (TRUSTED_PROXIES, comma-separated). Empty trusts no proxy: the client IP
+	// of auth lockouts and logs is then the connection's peer, which a client cannot spoof.
+	TrustedProxies []string
+)
+
+func loadHTTPConfig() {
+	ReadHeaderTimeout = envDuration("HTTP_READ_HEADER_TIMEOUT", ReadHeaderTimeout, false)
+	ReadTimeout = envDuration("HTTP_READ_TIMEOUT", ReadTimeout, true)
+	WriteTimeout = envDuration("HTTP_WRITE_TIMEOUT", WriteTimeout, true)
+	IdleTimeout = envDuration("HTTP_IDLE_TIMEOUT", IdleTimeout, false)
+	if raw := strings.TrimSpace(os.Getenv("HTTP_MAX_HEADER_BYTES")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n >= 4<<10 {
+			MaxHeaderBytes = n
+		} else {
+			log.Printf("Ignoring invalid HTTP_MAX_HEADER_BYTES %q (minimum 4096), using %d", raw, MaxHeaderBytes)
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("WS_MAX_MESSAGE_BYTES")); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n >= 4<<10 {
+			WebSocketMaxMessageBytes = n
+		} else {
+			log.Printf("Ignoring invalid WS_MAX_MESSAGE_BYTES %q (minimum 4096), using %d", raw, WebSocketMaxMessageBytes)
+		}
+	}
+	WebSocketIdleTimeout = envDuration("WS_IDLE_TIMEOUT", WebSocketIdleTimeout, false)
+	TrustedProxies = nil
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			TrustedProxies = append(TrustedProxies, p)
+		}
+	}
+	loadHandlerTimeouts()
+}
+
+// trustProxies makes r derive the client IP from forwarding headers only on requests from
+// TrustedProxies; Gin otherwise trusts them from any peer
+func trustProxies(r *gin.Engine) error {
+	if err := r.SetTrustedProxies(TrustedProxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	return nil
+}
+
+// newHTTPServer returns a server for handler on addr with the configured limits
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: ReadHeaderTimeout,
+		ReadTimeout:       ReadTimeout,
+		WriteTimeout:      WriteTimeout,
+		IdleTimeout:       IdleTimeout,
+		MaxHeaderBytes:    MaxHeaderBytes,
+	}
+}
+
+// envDuration reads a duration (or whole seconds) from name, keeping def when unset or
+// invalid. Zero is accepted only with allowZero, where it turns the limit off.
+func envDuration(name string, def time.Duration, allowZero bool) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		secs, serr := strconv.Atoi(raw)
+		d, err = time.Duration(secs)*time.Second, serr
+	}
+	if err != nil || d < 0 || (d == 0 && !allowZero) {
+		log.Printf("Ignoring invalid %s %q, using %s", name, raw, def)
+		return def
+	}
+	return d
+}
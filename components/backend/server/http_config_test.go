@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ambient-code-backend/authn"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestTrustedProxies verifies a client cannot dodge the per-IP auth lockout by rotating
+// X-Forwarded-For, while a configured proxy still names the client
+func TestTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authn.SetLockout(authn.NewLockout(0, 2, time.Minute, time.Minute))
+	t.Cleanup(func() {
+		authn.SetLockout(nil)
+		TrustedProxies = nil
+	})
+
+	newRouter := func() *gin.Engine {
+		r := gin.New()
+		if err := trustProxies(r); err != nil {
+			t.Fatal(err)
+		}
+		r.Use(authn.Middleware())
+		r.GET("/api/projects", func(c *gin.Context) {
+			authn.RecordFailure(c, "test")
+			c.Status(http.StatusUnauthorized)
+		})
+		return r
+	}
+	serve := func(r *gin.Engine, peer, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/projects", nil)
+		req.RemoteAddr = peer + ":40000"
+		req.Header.Set("Authorization", "Bearer guess")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "")
+	loadHTTPConfig()
+	direct := newRouter()
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		codes = append(codes, serve(direct, "203.0.113.5", fmt.Sprintf("198.51.100.%d", i)))
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("spoofed X-Forwarded-For dodged the IP lockout: %v", codes)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+	loadHTTPConfig()
+	proxied := newRouter()
+	for i := 0; i < 3; i++ {
+		if code := serve(proxied, "10.1.2.3", fmt.Sprintf("192.0.2.%d", i)); code != http.StatusUnauthorized {
+			t.Errorf("client %d behind a trusted proxy: %d", i, code)
+		}
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "not-an-address")
+	loadHTTPConfig()
+	if err := trustProxies(gin.New()); err == nil {
+		t.Error("invalid TRUSTED_PROXIES accepted")
+	}
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Internal endpoints (/internal/...) are called by runner pods only. They can be restricted by
+// source address (INTERNAL_ALLOWED_CIDRS) and, with INTERNAL_MTLS=true, served on a separate
+// TLS listener that requires a runner client certificate issued by the operator.
+var (
+	// InternalAllowedCIDRs limits the peer addresses allowed on /internal; empty allows any
+	InternalAllowedCIDRs []*net.IPNet
+	// InternalMTLS requires a verified runner client certificate on /internal
+	InternalMTLS bool
+	// InternalTLSDir holds tls.crt, tls.key and ca.crt for the internal listener
+	InternalTLSDir = "/etc/ambient/internal-tls"
+	// InternalTLSPort is the port of the internal TLS listener
+	InternalTLSPort = "8443"
+)
+
+// runnerCommonNamePrefix starts the common name of runner client certificates:
+// "ambient-session:<namespace>:<session>" (see the operator's runnertls package)
+const runnerCommonNamePrefix = "ambient-session:"
+
+// LoadInternalAccess reads the internal endpoint restrictions from the environment. With mTLS
+// enabled the listener certificate must already be mounted.
+func LoadInternalAccess() error {
+	InternalAllowedCIDRs = nil
+	for _, raw := range strings.Split(os.Getenv("INTERNAL_ALLOWED_CIDRS"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(raw)
+		if err != nil {
+			return fmt.Errorf("invalid INTERNAL_ALLOWED_CIDRS entry %q: %w", raw, err)
+		}
+		InternalAllowedCIDRs = append(InternalAllowedCIDRs, cidr)
+	}
+
+	InternalMTLS = os.Getenv("INTERNAL_MTLS") == "true"
+	if v := strings.TrimSpace(os.Getenv("INTERNAL_TLS_DIR")); v != "" {
+		InternalTLSDir = v
+	}
+	if v := strings.TrimSpace(os.Getenv("INTERNAL_TLS_PORT")); v != "" {
+		InternalTLSPort = v
+	}
+	if InternalMTLS {
+		if _, err := loadInternalTLSConfig(); err != nil {
+			return fmt.Errorf("INTERNAL_MTLS is enabled but the listener certificate is unusable: %w", err)
+		}
+	}
+	if len(InternalAllowedCIDRs) > 0 || InternalMTLS {
+		log.Printf("Internal endpoints restricted: cidrs=%d mtls=%t", len(InternalAllowedCIDRs), InternalMTLS)
+	}
+	return nil
+}
+
+// InternalBaseURL is the base URL runners use for /internal endpoints: the TLS listener when
+// mTLS is enabled, otherwise empty (the plain API port)
+func InternalBaseURL() string {
+	if !InternalMTLS {
+		return ""
+	}
+	return fmt.Sprintf("https://backend-service.%s.svc.cluster.local:%s", Namespace, InternalTLSPort)
+}
+
+// InternalAccessMiddleware enforces the source allowlist and, with mTLS, that the client
+// certificate belongs to the session named in the path. The certificate's namespace is stored
+// as "runnerCertNamespace" for the handlers' token checks.
+func InternalAccessMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(InternalAllowedCIDRs) > 0 && !internalSourceAllowed(c.RemoteIP()) {
+			log.Printf("Rejected internal request from %s to %s: source not allowed", c.RemoteIP(), c.Request.URL.Path)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "source address not allowed"})
+			return
+		}
+		if !InternalMTLS {
+			c.Next()
+			return
+		}
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "client certificate required"})
+			return
+		}
+		cn := c.Request.TLS.VerifiedChains[0][0].Subject.CommonName
+		namespace, session, ok := strings.Cut(strings.TrimPrefix(cn, runnerCommonNamePrefix), ":")
+		if !strings.HasPrefix(cn, runnerCommonNamePrefix) || !ok || namespace == "" || session == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "client certificate is not a runner certificate"})
+			return
+		}
+		if p := c.Param("session"); p != "" && p != session {
+			log.Printf("Rejected internal request: certificate for %s/%s used for session %s", namespace, session, p)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "client certificate does not match session"})
+			return
+		}
+		c.Set("runnerCertNamespace", namespace)
+		c.Next()
+	}
+}
+
+func internalSourceAllowed(remoteIP string) bool {
+	ip := net.ParseIP(remoteIP)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range InternalAllowedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// internalOnly passes only /internal requests to handler, so the runner listener does not
+// expose the rest of the API, the public share, SCIM and webhook routes included
+func internalOnly(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := r.URL.Path
+		if !strings.HasPrefix(p, "/internal/") || !strings.HasPrefix(path.Clean(p), "/internal/") {
+			http.NotFound(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// runInternalTLS serves the /internal routes of handler on the internal mTLS listener. The
+// certificate and CA are reloaded when the mounted Secret changes, so operator renewals need
+// no restart.
+func runInternalTLS(handler http.Handler) error {
+	cache := &internalTLSCache{}
+	srv := newHTTPServer(":"+InternalTLSPort, internalOnly(handler))
+	srv.TLSConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) { return cache.get() },
+	}
+	log.Printf("Internal mTLS listener starting on port %s", InternalTLSPort)
+	return srv.ListenAndServeTLS("", "")
+}
+
+// internalTLSCache rebuilds the listener config when the mounted files change
+type internalTLSCache struct {
+	mu      sync.Mutex
+	modTime time.Time
+	config  *tls.Config
+}
+
+func (c *internalTLSCache) get() (*tls.Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, err := os.Stat(filepath.Join(InternalTLSDir, "tls.crt"))
+	if err != nil {
+		return nil, err
+	}
+	if c.config != nil && info.ModTime().Equal(c.modTime) {
+		return c.config, nil
+	}
+	config, err := loadInternalTLSConfig()
+	if err != nil {
+		if c.config != nil {
+			log.Printf("Failed to reload internal TLS certificate, keeping the previous one: %v", err)
+			return c.config, nil
+		}
+		return nil, err
+	}
+	c.config, c.modTime = config, info.ModTime()
+	return config, nil
+}
+
+func loadInternalTLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(InternalTLSDir, "tls.crt"), filepath.Join(InternalTLSDir, "tls.key"))
+	if err != nil {
+		return nil, err
+	}
+	caPEM, err := os.ReadFile(filepath.Join(InternalTLSDir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates in %s", filepath.Join(InternalTLSDir, "ca.crt"))
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestInternalOnly verifies the runner listener serves /internal and nothing else
+func TestInternalOnly(t *testing.T) {
+	h := internalOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	cases := map[string]int{
+		"/internal/runner-config/s1":          http.StatusNoContent,
+		"/internal/inputs/s1/data.csv":        http.StatusNoContent,
+		"/api/projects/p/agentic-sessions":    http.StatusNotFound,
+		"/api/shared/token":                   http.StatusNotFound,
+		"/api/scim/v2/Users":                  http.StatusNotFound,
+		"/health":                             http.StatusNotFound,
+		"/internal":                           http.StatusNotFound,
+		"/internalx/runner-config/s1":         http.StatusNotFound,
+		"/internal/../api/projects/p/secrets": http.StatusNotFound,
+	}
+	for p, want := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.Path = p
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("%s: %d, want %d", p, w.Code, want)
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"os"
 
+	"ambient-code-pkg/client/clientset/versioned"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -13,6 +19,7 @@ import (
 var (
 	K8sClient      *kubernetes.Clientset
 	DynamicClient  dynamic.Interface
+	VteamClient    versioned.Interface
 	Namespace      string
 	StateBaseDir   string
 	PvcBaseDir     string
@@ -37,6 +44,16 @@ func InitK8sClients() error {
 		}
 	}
 
+	// Bound every API call so a slow API server cannot hang request handlers.
+	// Per-request user clients copy BaseKubeConfig and inherit this wrapper.
+	loadK8sCallTimeout()
+	config.Wrap(newDeadlineTransport)
+	// Count calls by error class (conflict, forbidden, throttled, ...) for /metrics
+	config.Wrap(newErrorClassTransport)
+	registerK8sClientMetrics()
+	// Outermost, so the time an API call spends includes reading its body
+	config.Wrap(newTimingTransport)
+
 	// Create standard Kubernetes client
 	K8sClient, err = kubernetes.NewForConfig(config)
 	if err != nil {
@@ -49,12 +66,28 @@ func InitK8sClients() error {
 		return fmt.Errorf("failed to create dynamic client: %v", err)
 	}
 
+	// Create typed client for vteam CRDs
+	VteamClient, err = versioned.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create vteam client: %v", err)
+	}
+
 	// Save base config for per-request impersonation/user-token clients
 	BaseKubeConfig = config
 
 	return nil
 }
 
+// ReviewToken reports whether the API server accepts a token, through a TokenReview as the
+// backend service account
+func ReviewToken(ctx context.Context, token string) (bool, error) {
+	review, err := K8sClient.AuthenticationV1().TokenReviews().Create(ctx, &authnv1.TokenReview{Spec: authnv1.TokenReviewSpec{Token: token}}, v1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return review.Status.Error == "" && review.Status.Authenticated, nil
+}
+
 // InitConfig initializes configuration from environment variables
 func InitConfig() {
 	// Get namespace from environment or use default
@@ -74,4 +107,7 @@ func InitConfig() {
 	if PvcBaseDir == "" {
 		PvcBaseDir = "/workspace"
 	}
+
+	// HTTP server, handler and WebSocket limits
+	loadHTTPConfig()
 }
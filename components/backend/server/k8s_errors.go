@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/client-go/tools/metrics"
+)
+
+// Kubernetes API error classes, the class label of ambient_k8s_api_errors_total
+const (
+	k8sErrorConflict    = "conflict"
+	k8sErrorForbidden   = "forbidden"
+	k8sErrorNotFound    = "not_found"
+	k8sErrorTimeout     = "timeout"
+	k8sErrorThrottled   = "throttled"
+	k8sErrorServerError = "server_error"
+	k8sErrorOther       = "other"
+	// k8sErrorClientThrottled counts calls client-go's own rate limiter held back for longer
+	// than clientThrottleThreshold; they are not failures, but explain slow requests
+	k8sErrorClientThrottled = "client_throttled"
+)
+
+// clientThrottleThreshold is the rate limiter wait that counts as client-side throttling
+const clientThrottleThreshold = 50 * time.Millisecond
+
+// Counters of every Kubernetes API call made by the backend, with the service account or a
+// user's token. They are expvars, read by the /metrics handler: k8s_api_requests is keyed by
+// verb, k8s_api_errors by "<class>:<verb>".
+var (
+	k8sAPIRequests       = expvar.NewMap("k8s_api_requests")
+	k8sAPIErrors         = expvar.NewMap("k8s_api_errors")
+	k8sClientThrottleSec = expvar.NewFloat("k8s_client_throttle_seconds")
+)
+
+// k8sStatusClass classifies an API response status; successful responses have no class
+func k8sStatusClass(status int) string {
+	switch {
+	case status < 400:
+		return ""
+	case status == http.StatusConflict:
+		return k8sErrorConflict
+	case status == http.StatusForbidden:
+		return k8sErrorForbidden
+	case status == http.StatusNotFound:
+		return k8sErrorNotFound
+	case status == http.StatusTooManyRequests:
+		return k8sErrorThrottled
+	case status == http.StatusGatewayTimeout:
+		return k8sErrorTimeout
+	case status >= 500:
+		return k8sErrorServerError
+	}
+	return k8sErrorOther
+}
+
+func recordK8sError(class, verb string) {
+	k8sAPIErrors.Add(class+":"+verb, 1)
+}
+
+// errorClassTransport counts each API call and its error class. It wraps the deadline
+// transport so calls cut off by K8S_CALL_TIMEOUT count as timeouts.
+type errorClassTransport struct {
+	rt http.RoundTripper
+}
+
+func newErrorClassTransport(rt http.RoundTripper) http.RoundTripper {
+	return &errorClassTransport{rt: rt}
+}
+
+func (t *errorClassTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	k8sAPIRequests.Add(req.Method, 1)
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		class := k8sErrorOther
+		if isTimeoutErr(req.Context(), err) {
+			class = k8sErrorTimeout
+		}
+		recordK8sError(class, req.Method)
+		return nil, err
+	}
+	if class := k8sStatusClass(resp.StatusCode); class != "" {
+		recordK8sError(class, req.Method)
+	}
+	return resp, nil
+}
+
+// throttleLatency receives client-go's rate limiter waits
+type throttleLatency struct{}
+
+func (throttleLatency) Observe(_ context.Context, verb string, _ url.URL, latency time.Duration) {
+	k8sClientThrottleSec.Add(latency.Seconds())
+	if latency >= clientThrottleThreshold {
+		recordK8sError(k8sErrorClientThrottled, verb)
+	}
+}
+
+// registerK8sClientMetrics hooks the client-side rate limiter into the counters. client-go
+// accepts one registration per process.
+func registerK8sClientMetrics() {
+	metrics.Register(metrics.RegisterOpts{RateLimiterLatency: throttleLatency{}})
+}
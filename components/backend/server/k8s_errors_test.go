@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestErrorClassTransport verifies API responses are counted by class and verb, and calls cut
+// off by the deadline transport count as timeouts
+func TestErrorClassTransport(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/conflict":
+			w.WriteHeader(http.StatusConflict)
+		case "/throttled":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer api.Close()
+
+	client := &http.Client{Transport: newErrorClassTransport(&deadlineTransport{rt: http.DefaultTransport, timeout: 20 * time.Millisecond})}
+	before := func(key string) int64 {
+		if v, ok := k8sAPIErrors.Get(key).(interface{ Value() int64 }); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	want := map[string]int64{}
+	for _, key := range []string{"conflict:PUT", "throttled:GET", "timeout:GET"} {
+		want[key] = before(key) + 1
+	}
+
+	for _, call := range []struct{ method, path string }{{"PUT", "/conflict"}, {"GET", "/throttled"}, {"GET", "/slow"}, {"GET", "/ok"}} {
+		req, _ := http.NewRequestWithContext(context.Background(), call.method, api.URL+call.path, nil)
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+	for key, n := range want {
+		if got := before(key); got != n {
+			t.Errorf("k8s_api_errors[%s] = %d, want %d", key, got, n)
+		}
+	}
+	if k8sStatusClass(http.StatusOK) != "" || k8sStatusClass(http.StatusServiceUnavailable) != k8sErrorServerError {
+		t.Error("unexpected status classes")
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// K8sCallTimeout bounds every individual Kubernetes API call made by the backend.
+// Configurable via K8S_CALL_TIMEOUT (Go duration such as "15s", or whole seconds).
+var K8sCallTimeout = 10 * time.Second
+
+func loadK8sCallTimeout() {
+	raw := strings.TrimSpace(os.Getenv("K8S_CALL_TIMEOUT"))
+	if raw == "" {
+		return
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+		K8sCallTimeout = d
+		return
+	}
+	if secs, err := strconv.Atoi(raw); err == nil && secs > 0 {
+		K8sCallTimeout = time.Duration(secs) * time.Second
+		return
+	}
+	log.Printf("Ignoring invalid K8S_CALL_TIMEOUT %q, using %s", raw, K8sCallTimeout)
+}
+
+type k8sTimeoutKey struct{}
+
+// k8sTimeoutRecorder collects the API calls that timed out while serving one HTTP request
+type k8sTimeoutRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *k8sTimeoutRecorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *k8sTimeoutRecorder) timedOut() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+// deadlineTransport applies K8sCallTimeout to each API request on top of the caller's
+// context, so a slow API server fails the call instead of hanging the Gin worker.
+// Long-running requests (watches, log follows, exec/port-forward upgrades) are exempt.
+type deadlineTransport struct {
+	rt      http.RoundTripper
+	timeout time.Duration
+}
+
+func newDeadlineTransport(rt http.RoundTripper) http.RoundTripper {
+	return &deadlineTransport{rt: rt, timeout: K8sCallTimeout}
+}
+
+func (t *deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 || isLongRunningK8sRequest(req) {
+		return t.rt.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	call := req.Method + " " + req.URL.Path
+	resp, err := t.rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		if isTimeoutErr(ctx, err) {
+			recordK8sTimeout(req.Context(), call)
+		}
+		cancel()
+		return nil, err
+	}
+	resp.Body = &deadlineBody{ReadCloser: resp.Body, ctx: ctx, parent: req.Context(), cancel: cancel, call: call}
+	return resp, nil
+}
+
+// deadlineBody keeps the per-call context alive until the response body is consumed
+type deadlineBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	parent context.Context
+	cancel context.CancelFunc
+	call   string
+}
+
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && isTimeoutErr(b.ctx, err) {
+		recordK8sTimeout(b.parent, b.call)
+	}
+	return n, err
+}
+
+func (b *deadlineBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func isLongRunningK8sRequest(req *http.Request) bool {
+	q := req.URL.Query()
+	if v := q.Get("watch"); v == "true" || v == "1" {
+		return true
+	}
+	if q.Get("follow") == "true" {
+		return true
+	}
+	return req.Header.Get("Upgrade") != ""
+}
+
+func isTimeoutErr(ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func recordK8sTimeout(ctx context.Context, call string) {
+	log.Printf("Kubernetes API call timed out after %s: %s", K8sCallTimeout, call)
+	if rec, ok := ctx.Value(k8sTimeoutKey{}).(*k8sTimeoutRecorder); ok {
+		rec.record(call)
+	}
+}
+
+// k8sTimeoutMiddleware turns server errors caused by Kubernetes API timeouts into
+// 504 Gateway Timeout. Handlers keep their usual error handling; when one of their API
+// calls timed out, the 5xx they write is replaced with a 504 listing the calls that did
+// not finish, because calls made earlier in the same request may already have applied.
+// Handlers must pass c.Request.Context() (or a context derived from it) to the client.
+func k8sTimeoutMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			c.Next()
+			return
+		}
+		rec := &k8sTimeoutRecorder{}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), k8sTimeoutKey{}, rec))
+		c.Writer = &k8sTimeoutWriter{ResponseWriter: c.Writer, rec: rec}
+		c.Next()
+	}
+}
+
+type k8sTimeoutWriter struct {
+	gin.ResponseWriter
+	rec      *k8sTimeoutRecorder
+	replaced bool
+}
+
+func (w *k8sTimeoutWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && !w.replaced && !w.Written() {
+		if calls := w.rec.timedOut(); len(calls) > 0 {
+			w.replaced = true
+			body, _ := json.Marshal(gin.H{
+				"error":         fmt.Sprintf("Timed out waiting for the Kubernetes API (limit %s)", K8sCallTimeout),
+				"timedOutCalls": calls,
+				"detail":        "The request was only partially processed: changes made before the timeout may have been applied. Check the resource state before retrying.",
+			})
+			w.ResponseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+			_, _ = w.ResponseWriter.Write(body)
+			return
+		}
+	}
+	if w.replaced {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *k8sTimeoutWriter) Write(data []byte) (int, error) {
+	if w.replaced {
+		// Swallow the handler's original error body
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *k8sTimeoutWriter) WriteString(s string) (int, error) {
+	if w.replaced {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
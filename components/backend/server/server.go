@@ -8,6 +8,9 @@ import (
 	"os"
 	"strings"
 
+	"ambient-code-backend/authn"
+	"ambient-code-pkg/redact"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -19,23 +22,33 @@ type RouterFunc func(r *gin.Engine)
 func Run(registerRoutes RouterFunc) error {
 	// Setup Gin router with custom logger that redacts tokens
 	r := gin.New()
+	// The client IP keys auth lockouts; only configured proxies may set it through headers
+	if err := trustProxies(r); err != nil {
+		return err
+	}
 	r.Use(gin.Recovery())
 	r.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		// Redact token from query string
-		path := param.Path
-		if strings.Contains(param.Request.URL.RawQuery, "token=") {
-			path = strings.Split(path, "?")[0] + "?token=[REDACTED]"
-		}
 		return fmt.Sprintf("[GIN] %s | %3d | %s | %s\n",
 			param.Method,
 			param.StatusCode,
 			param.ClientIP,
-			path,
+			redact.String(redactPath(param.Path, param.Request.URL.RawQuery)),
 		)
 	}))
 
-	// Middleware to populate user context from forwarded headers
-	r.Use(forwardedIdentityMiddleware())
+	// Server-Timing headers and slow-request logging
+	loadSlowRequestThreshold()
+	r.Use(requestTimingMiddleware())
+
+	// Bound handler time per route; registered before the Kubernetes timeout mapping so a 504
+	// from a handler past its deadline becomes the 503 timeout response
+	r.Use(handlerTimeoutMiddleware())
+
+	// Identify the caller with the configured auth providers (OAuth proxy, OIDC, static tokens)
+	r.Use(authn.Middleware())
+
+	// Report Kubernetes API timeouts as 504 instead of a generic 500
+	r.Use(k8sTimeoutMiddleware())
 
 	// Configure CORS
 	config := cors.DefaultConfig()
@@ -44,6 +57,11 @@ func Run(registerRoutes RouterFunc) error {
 	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
 	r.Use(cors.New(config))
 
+	// Read-only demo deployments reject writes before any handler runs; registered after
+	// CORS so browsers can read the error
+	loadDemoMode()
+	r.Use(demoModeMiddleware())
+
 	// Register routes
 	registerRoutes(r)
 
@@ -53,51 +71,48 @@ func Run(registerRoutes RouterFunc) error {
 		port = "8080"
 	}
 
+	// Runner callbacks over mutual TLS get their own listener; /internal rejects plain HTTP
+	if InternalMTLS {
+		go func() {
+			if err := runInternalTLS(r); err != nil {
+				log.Fatalf("Internal mTLS listener failed: %v", err)
+			}
+		}()
+	}
+
 	log.Printf("Server starting on port %s", port)
 	log.Printf("Using namespace: %s", Namespace)
 
-	if err := r.Run(":" + port); err != nil {
+	if err := newHTTPServer(":"+port, r).ListenAndServe(); err != nil {
 		return fmt.Errorf("failed to start server: %v", err)
 	}
 
 	return nil
 }
 
-// forwardedIdentityMiddleware populates Gin context from common OAuth proxy headers
-func forwardedIdentityMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if v := c.GetHeader("X-Forwarded-User"); v != "" {
-			c.Set("userID", v)
-		}
-		// Prefer preferred username; fallback to user id
-		name := c.GetHeader("X-Forwarded-Preferred-Username")
-		if name == "" {
-			name = c.GetHeader("X-Forwarded-User")
-		}
-		if name != "" {
-			c.Set("userName", name)
-		}
-		if v := c.GetHeader("X-Forwarded-Email"); v != "" {
-			c.Set("userEmail", v)
-		}
-		if v := c.GetHeader("X-Forwarded-Groups"); v != "" {
-			c.Set("userGroups", strings.Split(v, ","))
-		}
-		// Also expose access token if present
-		auth := c.GetHeader("Authorization")
-		if auth != "" {
-			c.Set("authorizationHeader", auth)
-		}
-		if v := c.GetHeader("X-Forwarded-Access-Token"); v != "" {
-			c.Set("forwardedAccessToken", v)
+// redactPath removes credentials from a request path for logging: query tokens and share
+// link tokens, which travel in the path
+func redactPath(path, rawQuery string) string {
+	if strings.Contains(rawQuery, "token=") {
+		path = strings.Split(path, "?")[0] + "?token=[REDACTED]"
+	}
+	if strings.HasPrefix(path, "/api/shared/") {
+		rest := strings.TrimPrefix(strings.Split(path, "?")[0], "/api/shared/")
+		suffix := ""
+		if i := strings.Index(rest, "/"); i >= 0 {
+			suffix = rest[i:]
 		}
-		c.Next()
+		path = "/api/shared/[REDACTED]" + suffix
 	}
+	return path
 }
 
 // RunContentService starts the server in content service mode
 func RunContentService(registerContentRoutes RouterFunc) error {
 	r := gin.New()
+	if err := trustProxies(r); err != nil {
+		return err
+	}
 	r.Use(gin.Recovery())
 	r.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		path := param.Path
@@ -108,9 +123,11 @@ func RunContentService(registerContentRoutes RouterFunc) error {
 			param.Method,
 			param.StatusCode,
 			param.ClientIP,
-			path,
+			redact.String(path),
 		)
 	}))
+	loadSlowRequestThreshold()
+	r.Use(requestTimingMiddleware())
 
 	// Register content service routes
 	registerContentRoutes(r)
@@ -125,7 +142,7 @@ func RunContentService(registerContentRoutes RouterFunc) error {
 		port = "8080"
 	}
 	log.Printf("Content service starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
+	if err := newHTTPServer(":"+port, r).ListenAndServe(); err != nil {
 		return fmt.Errorf("failed to start content service: %v", err)
 	}
 	return nil
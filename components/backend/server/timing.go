@@ -0,0 +1,270 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-pkg/runtimeconfig"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlowRequestThreshold is the duration above which a request is logged with its timing
+// breakdown. Configurable via SLOW_REQUEST_THRESHOLD (Go duration or whole milliseconds);
+// "0" disables slow-request logging. The runtime config's backend.slowRequestThreshold
+// overrides it without a restart.
+var SlowRequestThreshold = 3 * time.Second
+
+// slowRequestThreshold is the threshold in effect for the request being served
+func slowRequestThreshold() time.Duration {
+	if b := runtimeconfig.Current().Backend; b != nil && b.SlowRequestThreshold != nil {
+		return b.SlowRequestThreshold.Duration
+	}
+	return SlowRequestThreshold
+}
+
+// maxTimedK8sCalls bounds the per-request call log of chatty handlers
+const maxTimedK8sCalls = 100
+
+func loadSlowRequestThreshold() {
+	raw := strings.TrimSpace(os.Getenv("SLOW_REQUEST_THRESHOLD"))
+	if raw == "" {
+		return
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		SlowRequestThreshold = d
+		return
+	}
+	if ms, err := strconv.Atoi(raw); err == nil && ms >= 0 {
+		SlowRequestThreshold = time.Duration(ms) * time.Millisecond
+		return
+	}
+	log.Printf("Ignoring invalid SLOW_REQUEST_THRESHOLD %q, using %s", raw, SlowRequestThreshold)
+}
+
+type requestTimingKey struct{}
+
+// k8sCallTiming is one Kubernetes API call made while serving a request
+type k8sCallTiming struct {
+	call     string
+	status   int
+	duration time.Duration
+}
+
+// requestTiming accumulates the phases of one HTTP request. Phases may overlap: "auth"
+// includes the access review it sends, which is also counted under "k8s".
+type requestTiming struct {
+	start time.Time
+
+	mu          sync.Mutex
+	phases      map[string]time.Duration
+	k8sTotal    time.Duration
+	k8sCalls    []k8sCallTiming
+	k8sCount    int
+	renderStart time.Time
+	render      time.Duration
+}
+
+func (t *requestTiming) addPhase(name string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phases[name] += d
+}
+
+func (t *requestTiming) addK8sCall(call k8sCallTiming) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.k8sTotal += call.duration
+	t.k8sCount++
+	if len(t.k8sCalls) < maxTimedK8sCalls {
+		t.k8sCalls = append(t.k8sCalls, call)
+	}
+}
+
+// serverTimingHeader renders the breakdown in Server-Timing syntax (durations in ms)
+func (t *requestTiming) serverTimingHeader() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ms := func(d time.Duration) string { return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 1, 64) }
+
+	names := make([]string, 0, len(t.phases))
+	for name := range t.phases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names)+3)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s;dur=%s", name, ms(t.phases[name])))
+	}
+	parts = append(parts, fmt.Sprintf("k8s;dur=%s;desc=\"%d calls\"", ms(t.k8sTotal), t.k8sCount))
+	if t.render > 0 {
+		parts = append(parts, fmt.Sprintf("render;dur=%s", ms(t.render)))
+	}
+	parts = append(parts, fmt.Sprintf("total;dur=%s", ms(time.Since(t.start))))
+	return strings.Join(parts, ", ")
+}
+
+// RecordTiming adds d to a named phase of the request served under ctx. Handlers inject
+// it to time work that is not a Kubernetes call (e.g. authentication).
+func RecordTiming(ctx context.Context, phase string, d time.Duration) {
+	if t, ok := ctx.Value(requestTimingKey{}).(*requestTiming); ok {
+		t.addPhase(phase, d)
+	}
+}
+
+// timingTransport times each Kubernetes API call, including reading the response body,
+// and attributes it to the HTTP request whose context the call carries
+type timingTransport struct {
+	rt http.RoundTripper
+}
+
+func newTimingTransport(rt http.RoundTripper) http.RoundTripper {
+	return &timingTransport{rt: rt}
+}
+
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timing, ok := req.Context().Value(requestTimingKey{}).(*requestTiming)
+	if !ok || isLongRunningK8sRequest(req) {
+		return t.rt.RoundTrip(req)
+	}
+	start := time.Now()
+	call := req.Method + " " + req.URL.Path
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		timing.addK8sCall(k8sCallTiming{call: call, duration: time.Since(start)})
+		return nil, err
+	}
+	resp.Body = &timedBody{ReadCloser: resp.Body, done: func() {
+		timing.addK8sCall(k8sCallTiming{call: call, status: resp.StatusCode, duration: time.Since(start)})
+	}}
+	return resp, nil
+}
+
+// timedBody reports the call as finished at EOF or Close, whichever comes first
+type timedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.once.Do(b.done)
+	return b.ReadCloser.Close()
+}
+
+// requestTimingMiddleware emits a Server-Timing header (auth, k8s, render, total) on every
+// response and logs requests slower than the slow-request threshold with their slowest API calls.
+// Handlers must pass c.Request.Context() (or a context derived from it) to the client.
+func requestTimingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Streams stay open for as long as the client listens
+		if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") || c.Query("watch") == "true" || c.Query("watch") == "1" {
+			c.Next()
+			return
+		}
+		t := &requestTiming{start: time.Now(), phases: map[string]time.Duration{}}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestTimingKey{}, t))
+		w := &timingWriter{ResponseWriter: c.Writer, timing: t}
+		c.Writer = w
+		c.Next()
+		// Handlers that only set a status never write through the wrapper
+		if !w.Written() {
+			w.setHeader()
+		}
+
+		elapsed := time.Since(t.start)
+		if threshold := slowRequestThreshold(); threshold > 0 && elapsed >= threshold {
+			logSlowRequest(c, t, elapsed)
+		}
+	}
+}
+
+type timingWriter struct {
+	gin.ResponseWriter
+	timing    *requestTiming
+	headerSet bool
+}
+
+// WriteHeader is called when gin starts rendering (c.Status), before the body is
+// serialized; the header itself is only sent with the first write
+func (w *timingWriter) WriteHeader(code int) {
+	w.timing.mu.Lock()
+	if w.timing.renderStart.IsZero() {
+		w.timing.renderStart = time.Now()
+	}
+	w.timing.mu.Unlock()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timingWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timingWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timingWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timingWriter) setHeader() {
+	if w.headerSet || w.ResponseWriter.Written() {
+		return
+	}
+	w.headerSet = true
+	w.timing.mu.Lock()
+	if !w.timing.renderStart.IsZero() {
+		w.timing.render = time.Since(w.timing.renderStart)
+	}
+	w.timing.mu.Unlock()
+	w.ResponseWriter.Header().Set("Server-Timing", w.timing.serverTimingHeader())
+}
+
+func logSlowRequest(c *gin.Context, t *requestTiming, elapsed time.Duration) {
+	t.mu.Lock()
+	calls := append([]k8sCallTiming(nil), t.k8sCalls...)
+	t.mu.Unlock()
+	sort.Slice(calls, func(i, j int) bool { return calls[i].duration > calls[j].duration })
+	if len(calls) > 5 {
+		calls = calls[:5]
+	}
+	slowest := make([]string, 0, len(calls))
+	for _, call := range calls {
+		slowest = append(slowest, fmt.Sprintf("%s=%d in %s", call.call, call.status, call.duration.Round(time.Millisecond)))
+	}
+
+	log.Printf("Slow request: %s %s route=%s status=%d bytes=%d took=%s user=%q project=%q client=%s timing=[%s] slowestK8sCalls=%v",
+		c.Request.Method,
+		redactPath(c.Request.URL.Path, c.Request.URL.RawQuery),
+		c.FullPath(),
+		c.Writer.Status(),
+		c.Writer.Size(),
+		elapsed.Round(time.Millisecond),
+		c.GetString("userName"),
+		c.GetString("project"),
+		c.ClientIP(),
+		t.serverTimingHeader(),
+		slowest,
+	)
+}
@@ -0,0 +1,451 @@
+// Package tasks runs long backend operations (bulk deletes, exports, onboarding) in the
+// background so HTTP requests return immediately with a task ID to poll.
+//
+// Each task is persisted as a ConfigMap in the backend namespace. Tasks that were queued or
+// running when the backend stopped are picked up again on start, so task handlers must be
+// idempotent. The backend runs as a single replica; the manager does not coordinate between
+// processes.
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// State is the lifecycle state of a task
+type State string
+
+const (
+	StatePending   State = "Pending"
+	StateRunning   State = "Running"
+	StateSucceeded State = "Succeeded"
+	StateFailed    State = "Failed"
+	StateCancelled State = "Cancelled"
+)
+
+// IsFinal reports whether the task will not change any more
+func (s State) IsFinal() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCancelled
+}
+
+const (
+	// taskLabel marks the ConfigMaps that hold tasks
+	taskLabel = "ambient-code.io/task"
+	// projectLabel records the project a task operates on, for listing
+	projectLabel = "ambient-code.io/task-project"
+	dataKey      = "task.json"
+	namePrefix   = "backend-task-"
+
+	// DefaultConcurrency bounds how many tasks run at once
+	DefaultConcurrency = 4
+	// DefaultRetention is how long finished tasks stay queryable
+	DefaultRetention = 24 * time.Hour
+	// progressWriteInterval throttles how often progress updates are persisted
+	progressWriteInterval = 2 * time.Second
+)
+
+// Task is the persisted, client-visible record of one background operation
+type Task struct {
+	ID              string                 `json:"id"`
+	Type            string                 `json:"type"`
+	Project         string                 `json:"project,omitempty"`
+	CreatedBy       string                 `json:"createdBy,omitempty"`
+	Params          map[string]string      `json:"params,omitempty"`
+	State           State                  `json:"state"`
+	Message         string                 `json:"message,omitempty"`
+	Done            int                    `json:"done"`
+	Total           int                    `json:"total"`
+	Result          map[string]interface{} `json:"result,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+	Attempts        int                    `json:"attempts"`
+	CancelRequested bool                   `json:"cancelRequested,omitempty"`
+	CreatedAt       time.Time              `json:"createdAt"`
+	StartedAt       *time.Time             `json:"startedAt,omitempty"`
+	FinishedAt      *time.Time             `json:"finishedAt,omitempty"`
+}
+
+// ProgressFunc lets a handler report how far it got; message is shown to the user
+type ProgressFunc func(done, total int, message string)
+
+// Handler performs one task type with the params given to Submit. It must stop when ctx is
+// cancelled and tolerate being run again after a restart.
+type Handler func(ctx context.Context, params map[string]string, progress ProgressFunc) (map[string]interface{}, error)
+
+// ErrNotFound is returned for unknown task IDs
+var ErrNotFound = fmt.Errorf("task not found")
+
+// Manager queues, runs and persists tasks
+type Manager struct {
+	client    kubernetes.Interface
+	namespace string
+	retention time.Duration
+	slots     chan struct{}
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	cancels  map[string]context.CancelFunc
+	ctx      context.Context
+}
+
+// NewManager creates a manager that stores tasks in namespace
+func NewManager(client kubernetes.Interface, namespace string) *Manager {
+	return &Manager{
+		client:    client,
+		namespace: namespace,
+		retention: DefaultRetention,
+		slots:     make(chan struct{}, DefaultConcurrency),
+		handlers:  map[string]Handler{},
+		cancels:   map[string]context.CancelFunc{},
+		ctx:       context.Background(),
+	}
+}
+
+// Register adds the handler for a task type. Call before Run.
+func (m *Manager) Register(taskType string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[taskType] = h
+}
+
+// Run resumes the tasks interrupted by the last shutdown and then prunes finished tasks
+// past their retention every hour. Blocks until ctx is cancelled; running tasks are
+// cancelled with it and resume on the next start.
+func (m *Manager) Run(ctx context.Context) {
+	m.mu.Lock()
+	m.ctx = ctx
+	m.mu.Unlock()
+
+	m.resume(ctx)
+	m.prune(ctx)
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.prune(ctx)
+		}
+	}
+}
+
+// Submit persists a new task and starts it in the background
+func (m *Manager) Submit(ctx context.Context, taskType, project, createdBy string, params map[string]string) (*Task, error) {
+	m.mu.Lock()
+	_, ok := m.handlers[taskType]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown task type %q", taskType)
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	t := &Task{
+		ID:        id,
+		Type:      taskType,
+		Project:   project,
+		CreatedBy: createdBy,
+		Params:    params,
+		State:     StatePending,
+		Message:   "Queued",
+		CreatedAt: time.Now().UTC(),
+	}
+	cm, err := toConfigMap(t)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.client.CoreV1().ConfigMaps(m.namespace).Create(ctx, cm, v1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to persist task: %w", err)
+	}
+	log.Printf("Task %s (%s) submitted for project %q by %q", t.ID, t.Type, t.Project, t.CreatedBy)
+	go m.execute(t)
+	return t, nil
+}
+
+// Get returns the current state of a task
+func (m *Manager) Get(ctx context.Context, id string) (*Task, error) {
+	cm, err := m.client.CoreV1().ConfigMaps(m.namespace).Get(ctx, namePrefix+id, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fromConfigMap(cm)
+}
+
+// List returns the tasks of a project, newest first
+func (m *Manager) List(ctx context.Context, project string) ([]Task, error) {
+	selector := taskLabel + "=true"
+	if project != "" {
+		selector += "," + projectLabel + "=" + project
+	}
+	list, err := m.client.CoreV1().ConfigMaps(m.namespace).List(ctx, v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Task, 0, len(list.Items))
+	for i := range list.Items {
+		t, err := fromConfigMap(&list.Items[i])
+		if err != nil {
+			log.Printf("Skipping unreadable task %s: %v", list.Items[i].Name, err)
+			continue
+		}
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Cancel stops a task. Running tasks are cancelled through their context and become
+// Cancelled when the handler returns; queued tasks are cancelled immediately.
+func (m *Manager) Cancel(ctx context.Context, id string) (*Task, error) {
+	t, err := m.update(ctx, id, func(t *Task) bool {
+		if t.State.IsFinal() || t.CancelRequested {
+			return false
+		}
+		t.CancelRequested = true
+		if t.State == StatePending {
+			finish(t, StateCancelled, "Cancelled before it started", "")
+		} else {
+			t.Message = "Cancelling"
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	if cancel, ok := m.cancels[id]; ok {
+		cancel()
+	}
+	m.mu.Unlock()
+	return t, nil
+}
+
+// resume restarts the tasks that were queued or running when the backend stopped
+func (m *Manager) resume(ctx context.Context) {
+	tasks, err := m.List(ctx, "")
+	if err != nil {
+		log.Printf("Failed to list tasks to resume: %v", err)
+		return
+	}
+	for i := range tasks {
+		t := tasks[i]
+		if t.State.IsFinal() {
+			continue
+		}
+		if t.CancelRequested {
+			_, _ = m.update(ctx, t.ID, func(t *Task) bool {
+				finish(t, StateCancelled, "Cancelled", "")
+				return true
+			})
+			continue
+		}
+		log.Printf("Resuming task %s (%s) after restart", t.ID, t.Type)
+		resumed, err := m.update(ctx, t.ID, func(t *Task) bool {
+			t.State = StatePending
+			t.Message = "Resumed after backend restart"
+			return true
+		})
+		if err == nil {
+			go m.execute(resumed)
+		}
+	}
+}
+
+// prune deletes finished tasks older than the retention period
+func (m *Manager) prune(ctx context.Context) {
+	tasks, err := m.List(ctx, "")
+	if err != nil {
+		log.Printf("Failed to list tasks to prune: %v", err)
+		return
+	}
+	for _, t := range tasks {
+		if !t.State.IsFinal() || t.FinishedAt == nil || time.Since(*t.FinishedAt) < m.retention {
+			continue
+		}
+		if err := m.client.CoreV1().ConfigMaps(m.namespace).Delete(ctx, namePrefix+t.ID, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("Failed to prune task %s: %v", t.ID, err)
+		}
+	}
+}
+
+// execute waits for a free slot and runs the task's handler
+func (m *Manager) execute(t *Task) {
+	m.mu.Lock()
+	h := m.handlers[t.Type]
+	parent := m.ctx
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	m.mu.Lock()
+	m.cancels[t.ID] = cancel
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.cancels, t.ID)
+		m.mu.Unlock()
+	}()
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		return
+	}
+
+	// The task may have been cancelled while it waited for a slot
+	started, err := m.update(ctx, t.ID, func(t *Task) bool {
+		if t.State != StatePending || t.CancelRequested {
+			return false
+		}
+		now := time.Now().UTC()
+		t.State = StateRunning
+		t.Message = "Running"
+		t.Attempts++
+		t.StartedAt = &now
+		return true
+	})
+	if err != nil || started.State != StateRunning {
+		return
+	}
+
+	var progressMu sync.Mutex
+	var lastWrite time.Time
+	progress := func(done, total int, message string) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		if time.Since(lastWrite) < progressWriteInterval && done < total {
+			return
+		}
+		lastWrite = time.Now()
+		_, _ = m.update(ctx, t.ID, func(t *Task) bool {
+			t.Done, t.Total = done, total
+			if message != "" {
+				t.Message = message
+			}
+			return true
+		})
+	}
+
+	result, runErr := runHandler(ctx, h, started.Params, progress)
+
+	if parent.Err() != nil {
+		// Shutting down: leave the task Running so it resumes on the next start
+		return
+	}
+	// Finish with a fresh context; ctx is cancelled when the user cancels
+	finishCtx, finishCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer finishCancel()
+	_, _ = m.update(finishCtx, t.ID, func(t *Task) bool {
+		t.Result = result
+		switch {
+		case t.CancelRequested:
+			finish(t, StateCancelled, "Cancelled", "")
+		case runErr != nil:
+			finish(t, StateFailed, "Failed", runErr.Error())
+		default:
+			finish(t, StateSucceeded, "Completed", "")
+		}
+		return true
+	})
+	log.Printf("Task %s (%s) finished: err=%v", t.ID, t.Type, runErr)
+}
+
+// runHandler turns a handler panic into a task failure instead of crashing the backend
+func runHandler(ctx context.Context, h Handler, params map[string]string, progress ProgressFunc) (result map[string]interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return h(ctx, params, progress)
+}
+
+// update applies fn to the stored task and writes it back when fn reports a change
+func (m *Manager) update(ctx context.Context, id string, fn func(*Task) bool) (*Task, error) {
+	var out *Task
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := m.client.CoreV1().ConfigMaps(m.namespace).Get(ctx, namePrefix+id, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		t, err := fromConfigMap(cm)
+		if err != nil {
+			return err
+		}
+		out = t
+		if !fn(t) {
+			return nil
+		}
+		data, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		cm.Data[dataKey] = string(data)
+		_, err = m.client.CoreV1().ConfigMaps(m.namespace).Update(ctx, cm, v1.UpdateOptions{})
+		return err
+	})
+	if err != nil && err != ErrNotFound {
+		log.Printf("Failed to update task %s: %v", id, err)
+	}
+	return out, err
+}
+
+func finish(t *Task, state State, message, errMsg string) {
+	now := time.Now().UTC()
+	t.State = state
+	t.Message = message
+	t.Error = errMsg
+	t.FinishedAt = &now
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate task ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toConfigMap(t *Task) (*corev1.ConfigMap, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{taskLabel: "true"}
+	if t.Project != "" {
+		labels[projectLabel] = t.Project
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: namePrefix + t.ID, Labels: labels},
+		Data:       map[string]string{dataKey: string(data)},
+	}, nil
+}
+
+func fromConfigMap(cm *corev1.ConfigMap) (*Task, error) {
+	var t Task
+	if err := json.Unmarshal([]byte(cm.Data[dataKey]), &t); err != nil {
+		return nil, fmt.Errorf("failed to decode task %s: %w", cm.Name, err)
+	}
+	return &t, nil
+}
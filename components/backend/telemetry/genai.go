@@ -0,0 +1,226 @@
+package telemetry
+
+import (
+	"time"
+)
+
+// GenAI semantic convention values for the Claude Code runner
+const (
+	providerName = "anthropic"
+	agentName    = "claude-code-runner"
+
+	operationInvokeAgent = "invoke_agent"
+	operationExecuteTool = "execute_tool"
+
+	metricTokenUsage        = "gen_ai.client.token.usage"
+	metricOperationDuration = "gen_ai.client.operation.duration"
+)
+
+// staleTurn is how long an agent turn may stay open without activity before it is discarded,
+// e.g. when the runner was killed mid-turn
+const staleTurn = time.Hour
+
+// Bucket boundaries recommended by the GenAI semantic conventions
+var (
+	tokenBuckets    = []float64{1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864}
+	durationBuckets = []float64{0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92}
+)
+
+// Usage is the token usage of one agent turn
+type Usage struct {
+	InputTokens              int64
+	OutputTokens             int64
+	CacheCreationInputTokens int64
+	CacheReadInputTokens     int64
+}
+
+// sessionTrace is the open agent turn of a session and its running tool calls
+type sessionTrace struct {
+	turn     *span
+	tools    map[string]*span
+	lastSeen time.Time
+}
+
+func sessionKey(project, session string) string {
+	return project + "/" + session
+}
+
+// ObserveRunnerMessage records the runner messages that mark agent activity: agent.running
+// starts a turn, and agent.message tool calls and tool results start and end tool spans.
+// Other messages are ignored.
+func ObserveRunnerMessage(project, session, msgType string, payload map[string]interface{}) {
+	if active == nil {
+		return
+	}
+	switch msgType {
+	case "agent.running":
+		active.startTurn(project, session, time.Now())
+	case "agent.message":
+		if tool, ok := payload["tool"].(string); ok {
+			id, _ := payload["id"].(string)
+			active.startTool(project, session, id, tool, time.Now())
+		} else if result, ok := payload["tool_result"].(map[string]interface{}); ok {
+			id, _ := result["tool_use_id"].(string)
+			isError, _ := result["is_error"].(bool)
+			active.endTool(project, session, id, isError, time.Now())
+		}
+	}
+}
+
+// TurnCompleted ends the session's open agent turn with its token usage, reported by the
+// runner's status update after every result. Token usage is recorded even without an open
+// turn, e.g. after a backend restart.
+func TurnCompleted(project, session, model string, usage Usage, isError bool) {
+	if active == nil {
+		return
+	}
+	active.endTurn(project, session, model, usage, isError, time.Now())
+}
+
+func (e *Exporter) startTurn(project, session string, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := sessionKey(project, session)
+	st := e.sessions[key]
+	if st != nil && st.turn != nil {
+		// The previous turn's result never arrived; export what is known of it
+		e.finishTurn(st, "", now)
+	}
+	e.sessions[key] = &sessionTrace{
+		turn: &span{
+			traceID: newID(16),
+			spanID:  newID(8),
+			name:    operationInvokeAgent + " " + agentName,
+			kind:    spanKindClient,
+			start:   now,
+			attrs: []attr{
+				{"gen_ai.operation.name", operationInvokeAgent},
+				{"gen_ai.provider.name", providerName},
+				{"gen_ai.system", providerName},
+				{"gen_ai.agent.name", agentName},
+				{"gen_ai.conversation.id", key},
+				{"ambient.project", project},
+				{"ambient.session", session},
+			},
+		},
+		tools:    map[string]*span{},
+		lastSeen: now,
+	}
+}
+
+func (e *Exporter) startTool(project, session, id, tool string, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.sessions[sessionKey(project, session)]
+	if st == nil || st.turn == nil || id == "" {
+		return
+	}
+	st.lastSeen = now
+	st.tools[id] = &span{
+		traceID:  st.turn.traceID,
+		spanID:   newID(8),
+		parentID: st.turn.spanID,
+		name:     operationExecuteTool + " " + tool,
+		kind:     spanKindInternal,
+		start:    now,
+		attrs: []attr{
+			{"gen_ai.operation.name", operationExecuteTool},
+			{"gen_ai.tool.name", tool},
+			{"gen_ai.tool.call.id", id},
+			{"gen_ai.tool.type", "function"},
+		},
+	}
+}
+
+func (e *Exporter) endTool(project, session, id string, isError bool, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.sessions[sessionKey(project, session)]
+	if st == nil {
+		return
+	}
+	s, ok := st.tools[id]
+	if !ok {
+		return
+	}
+	delete(st.tools, id)
+	st.lastSeen = now
+	s.end = now
+	metricAttrs := []attr{{"gen_ai.operation.name", operationExecuteTool}, {"gen_ai.provider.name", providerName}}
+	if isError {
+		s.errType = "tool_error"
+		metricAttrs = append(metricAttrs, attr{"error.type", "tool_error"})
+	}
+	e.enqueue(s)
+	e.record(metricOperationDuration, "s", "GenAI operation duration.", durationBuckets, now.Sub(s.start).Seconds(), metricAttrs)
+}
+
+func (e *Exporter) endTurn(project, session, model string, usage Usage, isError bool, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// Input tokens include cached prompt tokens, as the conventions require; Anthropic reports
+	// them separately
+	input := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	base := []attr{
+		{"gen_ai.operation.name", operationInvokeAgent},
+		{"gen_ai.provider.name", providerName},
+		{"gen_ai.system", providerName},
+	}
+	if model != "" {
+		base = append(base, attr{"gen_ai.request.model", model})
+	}
+	if input > 0 {
+		e.record(metricTokenUsage, "{token}", "Number of input and output tokens used.", tokenBuckets, float64(input), append(append([]attr{}, base...), attr{"gen_ai.token.type", "input"}))
+	}
+	if usage.OutputTokens > 0 {
+		e.record(metricTokenUsage, "{token}", "Number of input and output tokens used.", tokenBuckets, float64(usage.OutputTokens), append(append([]attr{}, base...), attr{"gen_ai.token.type", "output"}))
+	}
+
+	st := e.sessions[sessionKey(project, session)]
+	if st == nil || st.turn == nil {
+		return
+	}
+	t := st.turn
+	if model != "" {
+		t.attrs = append(t.attrs, attr{"gen_ai.request.model", model})
+	}
+	t.attrs = append(t.attrs,
+		attr{"gen_ai.usage.input_tokens", input},
+		attr{"gen_ai.usage.output_tokens", usage.OutputTokens},
+		attr{"gen_ai.usage.cache_creation.input_tokens", usage.CacheCreationInputTokens},
+		attr{"gen_ai.usage.cache_read.input_tokens", usage.CacheReadInputTokens},
+	)
+	errType := ""
+	durationAttrs := append([]attr{}, base...)
+	if isError {
+		errType = "agent_error"
+		durationAttrs = append(durationAttrs, attr{"error.type", errType})
+	}
+	e.record(metricOperationDuration, "s", "GenAI operation duration.", durationBuckets, now.Sub(t.start).Seconds(), durationAttrs)
+	e.finishTurn(st, errType, now)
+}
+
+// finishTurn exports the turn and its unfinished tool calls. Callers hold e.mu.
+func (e *Exporter) finishTurn(st *sessionTrace, errType string, now time.Time) {
+	for id, s := range st.tools {
+		s.end = now
+		s.errType = "incomplete"
+		e.enqueue(s)
+		delete(st.tools, id)
+	}
+	st.turn.end = now
+	st.turn.errType = errType
+	e.enqueue(st.turn)
+	st.turn = nil
+}
+
+// pruneSessions forgets turns with no activity for staleTurn
+func (e *Exporter) pruneSessions(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key, st := range e.sessions {
+		if now.Sub(st.lastSeen) > staleTurn || st.turn == nil {
+			delete(e.sessions, key)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Package telemetry exports the agent activity runners report to the backend as OpenTelemetry
+// traces and metrics following the GenAI semantic conventions, so LLM observability tools
+// (Langfuse, Grafana Tempo and Mimir, any OTLP collector) chart sessions without custom
+// mapping.
+//
+// Export is OTLP/HTTP with JSON encoding, configured with the standard OTEL_EXPORTER_OTLP_*
+// variables. With no endpoint configured every recording function is a no-op. Spans are
+// batched and sent every few seconds; metrics are cumulative histograms pushed every
+// OTEL_METRIC_EXPORT_INTERVAL milliseconds (default one minute). Telemetry is best effort:
+// data that cannot be delivered is logged and dropped.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultServiceName    = "ambient-code-backend"
+	scopeName             = "ambient-code-backend/telemetry"
+	spanFlushInterval     = 5 * time.Second
+	defaultMetricInterval = time.Minute
+	// maxQueuedSpans bounds memory when the collector is down; newer spans are dropped
+	maxQueuedSpans = 2048
+)
+
+// OTLP span kinds and status codes
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusCodeError  = 2
+)
+
+// Exporter buffers spans and aggregates histograms for one OTLP endpoint
+type Exporter struct {
+	tracesURL  string
+	metricsURL string
+	headers    map[string]string
+	resource   []attr
+	client     *http.Client
+	interval   time.Duration
+	startTime  time.Time
+
+	mu         sync.Mutex
+	spans      []*span
+	dropped    int
+	histograms map[string]*histogram
+	sessions   map[string]*sessionTrace
+}
+
+type attr struct {
+	Key   string
+	Value interface{}
+}
+
+type span struct {
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    []attr
+	// errType, when set, marks the span failed and is recorded as error.type
+	errType string
+}
+
+type histogram struct {
+	name        string
+	unit        string
+	description string
+	bounds      []float64
+	attrs       []attr
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+var active *Exporter
+
+// Init configures the process-wide exporter from the environment and returns it, or nil when
+// no OTLP endpoint is set. Start it with Run.
+func Init() *Exporter {
+	active = NewExporterFromEnv()
+	if active != nil {
+		log.Printf("Exporting GenAI telemetry to %s", active.tracesURL)
+	}
+	return active
+}
+
+// NewExporterFromEnv reads OTEL_EXPORTER_OTLP_ENDPOINT (or the per-signal
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and OTEL_EXPORTER_OTLP_METRICS_ENDPOINT),
+// OTEL_EXPORTER_OTLP_HEADERS, OTEL_SERVICE_NAME and OTEL_METRIC_EXPORT_INTERVAL
+func NewExporterFromEnv() *Exporter {
+	base := strings.TrimRight(strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")), "/")
+	tracesURL := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"))
+	metricsURL := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"))
+	if tracesURL == "" && base != "" {
+		tracesURL = base + "/v1/traces"
+	}
+	if metricsURL == "" && base != "" {
+		metricsURL = base + "/v1/metrics"
+	}
+	if tracesURL == "" && metricsURL == "" {
+		return nil
+	}
+	service := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
+	if service == "" {
+		service = defaultServiceName
+	}
+	interval := defaultMetricInterval
+	if ms, err := strconv.Atoi(os.Getenv("OTEL_METRIC_EXPORT_INTERVAL")); err == nil && ms > 0 {
+		interval = time.Duration(ms) * time.Millisecond
+	}
+	return newExporter(tracesURL, metricsURL, parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")), service, interval)
+}
+
+func newExporter(tracesURL, metricsURL string, headers map[string]string, service string, interval time.Duration) *Exporter {
+	return &Exporter{
+		tracesURL:  tracesURL,
+		metricsURL: metricsURL,
+		headers:    headers,
+		resource:   []attr{{"service.name", service}},
+		client:     &http.Client{Timeout: 10 * time.Second},
+		interval:   interval,
+		startTime:  time.Now(),
+		histograms: map[string]*histogram{},
+		sessions:   map[string]*sessionTrace{},
+	}
+}
+
+// parseHeaders parses the OTLP "key1=value1,key2=value2" header list; values are URL-encoded
+func parseHeaders(raw string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = decoded
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+// Run flushes spans and metrics until ctx is done, then flushes once more
+func (e *Exporter) Run(ctx context.Context) {
+	spanTicker := time.NewTicker(spanFlushInterval)
+	defer spanTicker.Stop()
+	metricTicker := time.NewTicker(e.interval)
+	defer metricTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			e.flushSpans(flushCtx)
+			e.flushMetrics(flushCtx)
+			cancel()
+			return
+		case <-spanTicker.C:
+			e.pruneSessions(time.Now())
+			e.flushSpans(ctx)
+		case <-metricTicker.C:
+			e.flushMetrics(ctx)
+		}
+	}
+}
+
+// enqueue adds a finished span to the next batch. Callers hold e.mu.
+func (e *Exporter) enqueue(s *span) {
+	if e.tracesURL == "" {
+		return
+	}
+	if len(e.spans) >= maxQueuedSpans {
+		e.dropped++
+		return
+	}
+	e.spans = append(e.spans, s)
+}
+
+// record adds one value to a histogram. Callers hold e.mu.
+func (e *Exporter) record(name, unit, description string, bounds []float64, value float64, attrs []attr) {
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	var key strings.Builder
+	key.WriteString(name)
+	for _, a := range attrs {
+		fmt.Fprintf(&key, "|%s=%v", a.Key, a.Value)
+	}
+	h, ok := e.histograms[key.String()]
+	if !ok {
+		h = &histogram{name: name, unit: unit, description: description, bounds: bounds, attrs: attrs, counts: make([]uint64, len(bounds)+1)}
+		e.histograms[key.String()] = h
+	}
+	i := sort.SearchFloat64s(bounds, value)
+	h.counts[i]++
+	h.count++
+	h.sum += value
+}
+
+func (e *Exporter) flushSpans(ctx context.Context) {
+	e.mu.Lock()
+	spans, dropped := e.spans, e.dropped
+	e.spans, e.dropped = nil, 0
+	e.mu.Unlock()
+	if dropped > 0 {
+		log.Printf("Telemetry: dropped %d spans, the span queue was full", dropped)
+	}
+	if len(spans) == 0 {
+		return
+	}
+	if err := e.post(ctx, e.tracesURL, e.tracesPayload(spans)); err != nil {
+		log.Printf("Telemetry: failed to export %d spans: %v", len(spans), err)
+	}
+}
+
+func (e *Exporter) flushMetrics(ctx context.Context) {
+	if e.metricsURL == "" {
+		return
+	}
+	e.mu.Lock()
+	payload := e.metricsPayload(time.Now())
+	e.mu.Unlock()
+	if payload == nil {
+		return
+	}
+	if err := e.post(ctx, e.metricsURL, payload); err != nil {
+		log.Printf("Telemetry: failed to export metrics: %v", err)
+	}
+}
+
+func (e *Exporter) post(ctx context.Context, target string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("collector returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// tracesPayload is an OTLP ExportTraceServiceRequest in the JSON encoding
+func (e *Exporter) tracesPayload(spans []*span) map[string]interface{} {
+	out := make([]interface{}, 0, len(spans))
+	for _, s := range spans {
+		attrs := s.attrs
+		status := map[string]interface{}{}
+		if s.errType != "" {
+			attrs = append(attrs, attr{"error.type", s.errType})
+			status = map[string]interface{}{"code": statusCodeError}
+		}
+		o := map[string]interface{}{
+			"traceId":           s.traceID,
+			"spanId":            s.spanID,
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": unixNano(s.start),
+			"endTimeUnixNano":   unixNano(s.end),
+			"attributes":        encodeAttrs(attrs),
+			"status":            status,
+		}
+		if s.parentID != "" {
+			o["parentSpanId"] = s.parentID
+		}
+		out = append(out, o)
+	}
+	return map[string]interface{}{"resourceSpans": []interface{}{map[string]interface{}{
+		"resource":   map[string]interface{}{"attributes": encodeAttrs(e.resource)},
+		"scopeSpans": []interface{}{map[string]interface{}{"scope": map[string]interface{}{"name": scopeName}, "spans": out}},
+	}}}
+}
+
+// metricsPayload is an OTLP ExportMetricsServiceRequest with cumulative histograms, or nil
+// when nothing was recorded yet. Callers hold e.mu.
+func (e *Exporter) metricsPayload(now time.Time) map[string]interface{} {
+	if len(e.histograms) == 0 {
+		return nil
+	}
+	byName := map[string][]*histogram{}
+	names := []string{}
+	for _, h := range e.histograms {
+		if _, ok := byName[h.name]; !ok {
+			names = append(names, h.name)
+		}
+		byName[h.name] = append(byName[h.name], h)
+	}
+	sort.Strings(names)
+	metrics := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		hs := byName[name]
+		points := make([]interface{}, 0, len(hs))
+		for _, h := range hs {
+			counts := make([]string, len(h.counts))
+			for i, c := range h.counts {
+				counts[i] = strconv.FormatUint(c, 10)
+			}
+			points = append(points, map[string]interface{}{
+				"attributes":        encodeAttrs(h.attrs),
+				"startTimeUnixNano": unixNano(e.startTime),
+				"timeUnixNano":      unixNano(now),
+				"count":             strconv.FormatUint(h.count, 10),
+				"sum":               h.sum,
+				"bucketCounts":      counts,
+				"explicitBounds":    h.bounds,
+			})
+		}
+		metrics = append(metrics, map[string]interface{}{
+			"name":        name,
+			"unit":        hs[0].unit,
+			"description": hs[0].description,
+			"histogram":   map[string]interface{}{"aggregationTemporality": 2, "dataPoints": points},
+		})
+	}
+	return map[string]interface{}{"resourceMetrics": []interface{}{map[string]interface{}{
+		"resource":     map[string]interface{}{"attributes": encodeAttrs(e.resource)},
+		"scopeMetrics": []interface{}{map[string]interface{}{"scope": map[string]interface{}{"name": scopeName}, "metrics": metrics}},
+	}}}
+}
+
+// encodeAttrs renders attributes as OTLP KeyValues; 64-bit integers are strings in OTLP JSON
+func encodeAttrs(attrs []attr) []interface{} {
+	out := make([]interface{}, 0, len(attrs))
+	for _, a := range attrs {
+		var v map[string]interface{}
+		switch val := a.Value.(type) {
+		case string:
+			v = map[string]interface{}{"stringValue": val}
+		case bool:
+			v = map[string]interface{}{"boolValue": val}
+		case int:
+			v = map[string]interface{}{"intValue": strconv.Itoa(val)}
+		case int64:
+			v = map[string]interface{}{"intValue": strconv.FormatInt(val, 10)}
+		case float64:
+			v = map[string]interface{}{"doubleValue": val}
+		default:
+			v = map[string]interface{}{"stringValue": fmt.Sprint(val)}
+		}
+		out = append(out, map[string]interface{}{"key": a.Key, "value": v})
+	}
+	return out
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// newID returns n random bytes hex-encoded: 16 for trace IDs, 8 for span IDs
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
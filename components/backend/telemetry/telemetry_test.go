@@ -0,0 +1,124 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// attrValue finds an attribute in an OTLP JSON attribute list
+func attrValue(attrs []interface{}, key string) interface{} {
+	for _, a := range attrs {
+		kv := a.(map[string]interface{})
+		if kv["key"] == key {
+			for _, v := range kv["value"].(map[string]interface{}) {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// TestTurnSpans verifies a turn with one tool call becomes an invoke_agent span with a child
+// execute_tool span, carrying the GenAI usage attributes
+func TestTurnSpans(t *testing.T) {
+	var got map[string]interface{}
+	var gotHeader string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		gotHeader = r.Header.Get("X-Api-Key")
+	}))
+	defer collector.Close()
+
+	e := newExporter(collector.URL+"/v1/traces", "", parseHeaders("X-Api-Key=s%3Dcret"), "test", time.Minute)
+	active = e
+	defer func() { active = nil }()
+	start := time.Now()
+	e.startTurn("proj", "sess", start)
+	ObserveRunnerMessage("proj", "sess", "agent.message", map[string]interface{}{"tool": "Bash", "id": "toolu_1", "input": map[string]interface{}{}})
+	ObserveRunnerMessage("proj", "sess", "agent.message", map[string]interface{}{"tool_result": map[string]interface{}{"tool_use_id": "toolu_1", "is_error": true}})
+	TurnCompleted("proj", "sess", "claude-sonnet-4", Usage{InputTokens: 10, OutputTokens: 20, CacheReadInputTokens: 100}, false)
+	e.flushSpans(context.Background())
+
+	if gotHeader != "s=cret" {
+		t.Errorf("header = %q, want the decoded OTEL_EXPORTER_OTLP_HEADERS value", gotHeader)
+	}
+	rs := got["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	spans := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	tool, turn := spans[0].(map[string]interface{}), spans[1].(map[string]interface{})
+	if turn["name"] != "invoke_agent claude-code-runner" || tool["name"] != "execute_tool Bash" {
+		t.Errorf("span names = %v, %v", turn["name"], tool["name"])
+	}
+	if tool["parentSpanId"] != turn["spanId"] || tool["traceId"] != turn["traceId"] {
+		t.Error("the tool span must be a child of the turn span")
+	}
+	if attrValue(tool["attributes"].([]interface{}), "error.type") != "tool_error" {
+		t.Error("a failed tool call must set error.type")
+	}
+	attrs := turn["attributes"].([]interface{})
+	for key, want := range map[string]interface{}{
+		"gen_ai.operation.name":      "invoke_agent",
+		"gen_ai.request.model":       "claude-sonnet-4",
+		"gen_ai.usage.input_tokens":  "110",
+		"gen_ai.usage.output_tokens": "20",
+		"gen_ai.conversation.id":     "proj/sess",
+	} {
+		if got := attrValue(attrs, key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+}
+
+// TestTokenUsageHistogram verifies token usage is aggregated per token type into the
+// recommended buckets, with or without an open turn
+func TestTokenUsageHistogram(t *testing.T) {
+	e := newExporter("", "http://collector/v1/metrics", nil, "test", time.Minute)
+	e.endTurn("proj", "sess", "claude-sonnet-4", Usage{InputTokens: 3, OutputTokens: 50}, false, time.Now())
+	e.endTurn("proj", "sess", "claude-sonnet-4", Usage{InputTokens: 3}, false, time.Now())
+
+	payload := e.metricsPayload(time.Now())
+	b, _ := json.Marshal(payload)
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(b, &decoded)
+	metrics := decoded["resourceMetrics"].([]interface{})[0].(map[string]interface{})["scopeMetrics"].([]interface{})[0].(map[string]interface{})["metrics"].([]interface{})
+	if len(metrics) != 1 || metrics[0].(map[string]interface{})["name"] != metricTokenUsage {
+		t.Fatalf("metrics = %v", metrics)
+	}
+	points := metrics[0].(map[string]interface{})["histogram"].(map[string]interface{})["dataPoints"].([]interface{})
+	counts := map[interface{}]string{}
+	for _, p := range points {
+		dp := p.(map[string]interface{})
+		counts[attrValue(dp["attributes"].([]interface{}), "gen_ai.token.type")] = dp["count"].(string)
+		if attrValue(dp["attributes"].([]interface{}), "gen_ai.token.type") == "input" {
+			// 3 tokens fall in the (1, 4] bucket
+			if buckets := dp["bucketCounts"].([]interface{}); buckets[1] != "2" {
+				t.Errorf("input bucketCounts = %v", buckets)
+			}
+		}
+	}
+	if counts["input"] != "2" || counts["output"] != "1" {
+		t.Errorf("data point counts = %v", counts)
+	}
+}
+
+func TestNewExporterFromEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+	if NewExporterFromEnv() != nil {
+		t.Error("telemetry must be off without an endpoint")
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318/")
+	e := NewExporterFromEnv()
+	if e == nil || e.tracesURL != "http://otel-collector:4318/v1/traces" || e.metricsURL != "http://otel-collector:4318/v1/metrics" {
+		t.Errorf("exporter = %+v", e)
+	}
+}
@@ -29,6 +29,9 @@ type LLMSettings struct {
 	Model       string  `json:"model"`
 	Temperature float64 `json:"temperature"`
 	MaxTokens   int     `json:"maxTokens"`
+	// Context window management: truncate, summarize or retrieval past MaxContextTokens
+	ContextStrategy  string `json:"contextStrategy,omitempty"`
+	MaxContextTokens int    `json:"maxContextTokens,omitempty"`
 }
 
 type GitConfig struct {
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// MaintenanceBlock disables session creation until an admin lifts it or Until passes
+type MaintenanceBlock struct {
+	Message string     `json:"message"`
+	Until   *time.Time `json:"until,omitempty"`
+	SetBy   string     `json:"setBy,omitempty"`
+	SetAt   time.Time  `json:"setAt"`
+}
+
+// MaintenanceState is the platform's kill switch: Global stops all session creation,
+// Providers stops sessions of projects using that LLM provider (anthropic, vertex, bedrock)
+type MaintenanceState struct {
+	Global    *MaintenanceBlock            `json:"global,omitempty"`
+	Providers map[string]*MaintenanceBlock `json:"providers,omitempty"`
+}
+
+// MaintenanceRequest turns a block on or off.
+// POST /api/admin/maintenance
+type MaintenanceRequest struct {
+	// Provider is empty for the global switch
+	Provider string `json:"provider,omitempty"`
+	Disabled bool   `json:"disabled"`
+	Message  string `json:"message,omitempty"`
+	// DurationMinutes re-enables creation automatically after this long; 0 means until lifted
+	DurationMinutes int `json:"durationMinutes,omitempty"`
+}
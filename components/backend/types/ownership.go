@@ -0,0 +1,40 @@
+package types
+
+import "time"
+
+// Outcomes of a project ownership transfer
+const (
+	OwnershipTransferCompleted  = "Completed"
+	OwnershipTransferRolledBack = "RolledBack"
+)
+
+// OwnershipTransferRequest hands a project over from one person to another.
+// POST /api/projects/:projectName/ownership/transfer
+type OwnershipTransferRequest struct {
+	// From is the previous owner, as in RoleBinding subjects and session creator annotations
+	From string `json:"from"`
+	// To is the user who becomes a project admin and the creator of From's sessions
+	To string `json:"to"`
+	// PreviousOwnerRole is the role From keeps (edit or view); empty removes their access
+	PreviousOwnerRole string `json:"previousOwnerRole,omitempty"`
+	// KeepSessionCreators leaves the creator of From's sessions unchanged
+	KeepSessionCreators bool `json:"keepSessionCreators,omitempty"`
+}
+
+// OwnershipTransfer is the audit record of a transfer. A transfer that fails part way is undone
+// and recorded as RolledBack with the error.
+type OwnershipTransfer struct {
+	ID                  string    `json:"id"`
+	Project             string    `json:"project"`
+	From                string    `json:"from"`
+	To                  string    `json:"to"`
+	PreviousOwnerRole   string    `json:"previousOwnerRole,omitempty"`
+	TransferredBy       string    `json:"transferredBy"`
+	TransferredAt       time.Time `json:"transferredAt"`
+	Status              string    `json:"status"`
+	Error               string    `json:"error,omitempty"`
+	GrantedRoleBindings []string  `json:"grantedRoleBindings,omitempty"`
+	RemovedRoleBindings []string  `json:"removedRoleBindings,omitempty"`
+	Sessions            []string  `json:"sessions,omitempty"`
+	RequesterUpdated    bool      `json:"requesterUpdated,omitempty"`
+}
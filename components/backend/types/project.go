@@ -1,5 +1,10 @@
 package types
 
+import (
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/settingshistory"
+)
+
 // AmbientProject represents project management types.
 type AmbientProject struct {
 	Name              string            `json:"name"`                  // Kubernetes namespace name
@@ -17,3 +22,119 @@ type CreateProjectRequest struct {
 	DisplayName string `json:"displayName,omitempty"` // Optional: only used on OpenShift
 	Description string `json:"description,omitempty"` // Optional: only used on OpenShift
 }
+
+// ApplyProjectRequest is the body of PUT /api/projects/:projectName: the desired state of the
+// project, created if it does not exist. Omitted (null) sections are left unmanaged; a section
+// that is present replaces the current state, so repeating a request changes nothing.
+type ApplyProjectRequest struct {
+	Name        string  `json:"name,omitempty"`
+	DisplayName *string `json:"displayName,omitempty"` // OpenShift only
+	Description *string `json:"description,omitempty"` // OpenShift only
+	// Settings replaces the ProjectSettings spec; secrets are referenced by name
+	// (runnerSecretsName, llmProvider.bedrock.credentialsSecretName, gitMirror.credentialsSecret)
+	Settings *apiv1alpha1.ProjectSettingsSpec `json:"settings,omitempty"`
+	// Members replaces the users and groups granted a role through the members API; it must
+	// name at least one admin
+	Members []ProjectMember `json:"members,omitempty"`
+}
+
+// ProjectMember grants a user or group a project role (admin, edit or view)
+type ProjectMember struct {
+	SubjectType string `json:"subjectType"`
+	SubjectName string `json:"subjectName"`
+	Role        string `json:"role"`
+}
+
+// ApplyProjectResponse is the applied state. Members are sorted and Settings/Members are only
+// returned when the request manages them, so the response of a repeated request is identical.
+type ApplyProjectResponse struct {
+	AmbientProject
+	Settings *apiv1alpha1.ProjectSettingsSpec `json:"settings,omitempty"`
+	Members  []ProjectMember                  `json:"members,omitempty"`
+	// Changes describes what the request changed; empty when the project already matched
+	Changes []string `json:"changes"`
+}
+
+// LLMProviderSettings mirrors ProjectSettings spec.llmProvider. An empty provider uses the
+// cluster default (Vertex when enabled on the operator, otherwise the Anthropic API).
+// Provider is "anthropic", "vertex" or "bedrock".
+type LLMProviderSettings = apiv1alpha1.LLMProvider
+
+// BedrockSettings selects how runners authenticate to AWS Bedrock: an IAM role assumed via
+// web identity, or a Secret in the project namespace holding static AWS credentials.
+type BedrockSettings = apiv1alpha1.BedrockSettings
+
+// SystemPromptSettings is the body of GET/PUT /api/projects/:projectName/system-prompt.
+// An empty template disables the prefix.
+type SystemPromptSettings struct {
+	Template string `json:"template"`
+}
+
+// Runbook mirrors an entry of ProjectSettings spec.runbooks
+type Runbook = apiv1alpha1.Runbook
+
+// RunbookList is the body of GET/PUT /api/projects/:projectName/runbooks
+type RunbookList struct {
+	Items []Runbook `json:"items"`
+}
+
+// ExecuteRunbookRequest is the body of POST /api/projects/:projectName/runbooks/:name/execute.
+// Parameter values are JSON strings, numbers or booleans matching the runbook's parameter types.
+type ExecuteRunbookRequest struct {
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	DisplayName string                 `json:"displayName,omitempty"`
+	Interactive *bool                  `json:"interactive,omitempty"`
+}
+
+// SettingsRevision is one entry of GET /api/projects/:projectName/settings/revisions.
+// Changes are relative to the previous recorded revision; Spec is only set when a single
+// revision is requested.
+type SettingsRevision struct {
+	Revision   int64                            `json:"revision"`
+	Generation int64                            `json:"generation"`
+	Author     string                           `json:"author,omitempty"`
+	Manager    string                           `json:"manager,omitempty"`
+	ChangedAt  string                           `json:"changedAt"`
+	RollbackOf int64                            `json:"rollbackOf,omitempty"`
+	Changes    []settingshistory.Change         `json:"changes"`
+	Spec       *apiv1alpha1.ProjectSettingsSpec `json:"spec,omitempty"`
+}
+
+// DashboardResponse is the body of GET /api/dashboard: one summary per project the caller
+// can list sessions in
+type DashboardResponse struct {
+	Projects []DashboardProject `json:"projects"`
+}
+
+// DashboardProject summarizes the sessions of one project
+type DashboardProject struct {
+	Name           string             `json:"name"`
+	DisplayName    string             `json:"displayName,omitempty"`
+	TotalSessions  int                `json:"totalSessions"`
+	ActiveSessions int                `json:"activeSessions"` // Pending, Creating or Running
+	PhaseCounts    map[string]int     `json:"phaseCounts"`
+	LastActivity   string             `json:"lastActivity,omitempty"`
+	RecentSessions []DashboardSession `json:"recentSessions"`
+}
+
+// DashboardSession is the short form of a session shown on the dashboard
+type DashboardSession struct {
+	Name              string `json:"name"`
+	DisplayName       string `json:"displayName,omitempty"`
+	Phase             string `json:"phase"`
+	Interactive       bool   `json:"interactive,omitempty"`
+	CreationTimestamp string `json:"creationTimestamp"`
+}
+
+// RunnerSecurityReport tells whether a project's runner pods, hardened with its
+// runnerSecurity, pass the Pod Security levels its namespace sets.
+// GET /api/projects/:projectName/runner-security
+type RunnerSecurityReport struct {
+	RunnerSecurity *apiv1alpha1.RunnerSecurity `json:"runnerSecurity,omitempty"`
+	// Levels by Pod Security admission mode (enforce, warn, audit), as labelled on the namespace
+	Levels map[string]string `json:"levels"`
+	// Compatible is false when the enforce level rejects the runner pods
+	Compatible bool `json:"compatible"`
+	// Violations by mode; warn and audit violations only produce warnings and audit events
+	Violations map[string][]string `json:"violations,omitempty"`
+}
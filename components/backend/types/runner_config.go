@@ -0,0 +1,64 @@
+package types
+
+import apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+// RunnerConfigVersion is the schema version of the document served by
+// GET /internal/runner-config/:session. Bump it on breaking changes so runners
+// can refuse documents they do not understand.
+const RunnerConfigVersion = "v1"
+
+// RunnerConfig is the resolved configuration a runner fetches at startup.
+type RunnerConfig struct {
+	Version    string           `json:"version"`
+	Session    RunnerSessionRef `json:"session"`
+	Model      LLMSettings      `json:"model"`
+	Provider   string           `json:"provider,omitempty"`
+	Timeout    int              `json:"timeout"`
+	ToolPolicy RunnerToolPolicy `json:"toolPolicy"`
+	GitPolicy  RunnerGitPolicy  `json:"gitPolicy"`
+	// NetworkPolicy limits the agent's web tools to the project's allowed domains
+	NetworkPolicy *apiv1alpha1.AgentNetworkPolicy `json:"networkPolicy,omitempty"`
+	Workspace     RunnerWorkspaceLayout           `json:"workspace"`
+	Callbacks     RunnerCallbacks                 `json:"callbacks"`
+	Features      map[string]bool                 `json:"features"`
+	// Agents are the project's Agents named in the session's AGENT_PERSONAS, registered by
+	// the runner as subagents
+	Agents []Agent `json:"agents,omitempty"`
+}
+
+type RunnerSessionRef struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ParentSessionID string `json:"parentSessionId,omitempty"`
+}
+
+// RunnerToolPolicy controls which tools the agent may use. Empty lists mean
+// the runner default applies.
+type RunnerToolPolicy = apiv1alpha1.RunnerToolPolicy
+
+// RunnerGitPolicy is the project's branch protection policy, enforced by the runner's git wrapper.
+type RunnerGitPolicy struct {
+	AllowedTargetBranches []string `json:"allowedTargetBranches,omitempty"`
+	RequirePR             bool     `json:"requirePR"`
+	ForbidForcePush       bool     `json:"forbidForcePush"`
+}
+
+type RunnerWorkspaceLayout struct {
+	Root          string               `json:"root"`
+	WorkspacePath string               `json:"workspacePath"`
+	ArtifactsDir  string               `json:"artifactsDir"`
+	StateDir      string               `json:"stateDir"`
+	Repos         []SessionRepoMapping `json:"repos,omitempty"`
+	MainRepoIndex int                  `json:"mainRepoIndex"`
+	Workflow      *WorkflowSelection   `json:"activeWorkflow,omitempty"`
+}
+
+type RunnerCallbacks struct {
+	APIBaseURL     string `json:"apiBaseUrl"`
+	WebSocketURL   string `json:"websocketUrl"`
+	GitHubTokenURL string `json:"githubTokenUrl"`
+	// ArtifactUploadURL creates resumable artifact uploads (tus-style, see handlers/artifact_upload.go)
+	ArtifactUploadURL string `json:"artifactUploadUrl"`
+	// InputsURL lists the files attached to the session (see handlers/session_inputs.go)
+	InputsURL string `json:"inputsUrl"`
+}
@@ -1,5 +1,13 @@
 package types
 
+import (
+	"encoding/json"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // AgenticSession represents the structure of our custom resource
 type AgenticSession struct {
 	APIVersion string                 `json:"apiVersion"`
@@ -7,11 +15,15 @@ type AgenticSession struct {
 	Metadata   map[string]interface{} `json:"metadata"`
 	Spec       AgenticSessionSpec     `json:"spec"`
 	Status     *AgenticSessionStatus  `json:"status,omitempty"`
+	// ResourceUsage is the runner pod's current CPU and memory use, on the detail of a
+	// running session when metrics are available
+	ResourceUsage *ResourceUsage `json:"resourceUsage,omitempty"`
 }
 
 type AgenticSessionSpec struct {
 	Prompt               string             `json:"prompt" binding:"required"`
 	Interactive          bool               `json:"interactive,omitempty"`
+	Preemptible          bool               `json:"preemptible,omitempty"`
 	DisplayName          string             `json:"displayName"`
 	LLMSettings          LLMSettings        `json:"llmSettings"`
 	Timeout              int                `json:"timeout"`
@@ -25,6 +37,18 @@ type AgenticSessionSpec struct {
 	MainRepoIndex *int                 `json:"mainRepoIndex,omitempty"`
 	// Active workflow for dynamic workflow switching
 	ActiveWorkflow *WorkflowSelection `json:"activeWorkflow,omitempty"`
+	// Spending cap enforced by the operator
+	CostLimit *apiv1alpha1.CostLimit `json:"costLimit,omitempty"`
+	// Previous session whose workspace the operator clones into this one (warm start)
+	WorkspaceFrom string `json:"workspaceFrom,omitempty"`
+	// Allowlisted project Secrets injected into the runner for the session's duration
+	EnvFromSecrets []apiv1alpha1.SecretEnvSource `json:"envFromSecrets,omitempty"`
+	// Group whose shared workspace the session works in
+	SessionGroup string `json:"sessionGroup,omitempty"`
+	// RuntimeClass, GPUs and architecture overriding the project's runnerHardware
+	Hardware *apiv1alpha1.RunnerHardware `json:"hardware,omitempty"`
+	// Session this one follows up on, in the same thread
+	ParentSession string `json:"parentSession,omitempty"`
 }
 
 // NamedGitRepo represents named repository types for multi-repo session support.
@@ -59,7 +83,21 @@ type AgenticSessionStatus struct {
 	SessionID    string                 `json:"session_id,omitempty"`
 	TotalCostUSD *float64               `json:"total_cost_usd,omitempty"`
 	Usage        map[string]interface{} `json:"usage,omitempty"`
-	Result       *string                `json:"result,omitempty"`
+	// Typed outcome of the run; see apiv1alpha1.SessionResult
+	Result *apiv1alpha1.SessionResult `json:"result,omitempty"`
+	// Number of times the session was restarted after its spot node was reclaimed
+	PreemptionRetries int `json:"preemptionRetries,omitempty"`
+	// Run of the session, raised by every restart and preemption retry
+	RunAttempt int `json:"runAttempt,omitempty"`
+	// Artifacts uploaded by the runner, with upload progress
+	Artifacts []apiv1alpha1.ArtifactStatus `json:"artifacts,omitempty"`
+	// Steps of the run and overall progress (0-100), for a progress bar
+	Steps    []apiv1alpha1.SessionStep `json:"steps,omitempty"`
+	Progress int                       `json:"progress,omitempty"`
+	// Encryption at rest applied to the workspace volume
+	Encryption *apiv1alpha1.SessionEncryption `json:"encryption,omitempty"`
+	// Provider rate limits the runner last reported
+	RateLimit *apiv1alpha1.RateLimitStatus `json:"rateLimit,omitempty"`
 }
 
 type CreateAgenticSessionRequest struct {
@@ -74,12 +112,119 @@ type CreateAgenticSessionRequest struct {
 	Repos                []SessionRepoMapping `json:"repos,omitempty"`
 	MainRepoIndex        *int                 `json:"mainRepoIndex,omitempty"`
 	AutoPushOnComplete   *bool                `json:"autoPushOnComplete,omitempty"`
+	Preemptible          *bool                `json:"preemptible,omitempty"`
 	UserContext          *UserContext         `json:"userContext,omitempty"`
 	BotAccount           *BotAccountRef       `json:"botAccount,omitempty"`
 	ResourceOverrides    *ResourceOverrides   `json:"resourceOverrides,omitempty"`
 	EnvironmentVariables map[string]string    `json:"environmentVariables,omitempty"`
 	Labels               map[string]string    `json:"labels,omitempty"`
 	Annotations          map[string]string    `json:"annotations,omitempty"`
+	// Issue (URL or reference) the session works on; exposed to the project system prompt as .Issue
+	Issue string `json:"issue,omitempty"`
+	// Spending cap (USD and/or tokens); the session winds down when it is reached
+	CostLimit *apiv1alpha1.CostLimit `json:"costLimit,omitempty"`
+	// Previous session in the same project whose workspace is cloned instead of re-cloning repos
+	WorkspaceFrom string `json:"workspaceFrom,omitempty"`
+	// Project Secrets (listed in ProjectSettings allowedSessionSecrets) injected as env vars
+	EnvFromSecrets []apiv1alpha1.SecretEnvSource `json:"envFromSecrets,omitempty"`
+	// Sessions with the same group share one workspace and coordinate through advisory locks
+	SessionGroup string `json:"sessionGroup,omitempty"`
+	// Runner RuntimeClass, GPUs (nvidia.com/gpu) and node architecture; rejected when no node can provide them
+	Hardware *apiv1alpha1.RunnerHardware `json:"hardware,omitempty"`
+	// Session this one follows up on; the thread's context is prepended to the prompt
+	ParentSession string `json:"parentSession,omitempty"`
+}
+
+// BulkDeleteSessionsRequest selects sessions to delete in the background, by name or phase
+type BulkDeleteSessionsRequest struct {
+	Names []string `json:"names,omitempty"`
+	Phase string   `json:"phase,omitempty"`
+}
+
+// SpecFieldError is one rule a session spec breaks, as returned with a 400 from create and
+// update; Type is the Kubernetes field error type, e.g. FieldValueInvalid
+type SpecFieldError struct {
+	Field  string `json:"field"`
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// FanoutSessionsRequest creates one session per parameter set from a single template. The
+// template is a create request whose string values may use {{.Params.<name>}}, {{.Index}},
+// {{.Project}} and {{.User}}. When Variables are declared, every parameter set is checked
+// against them, with defaults filled in, and the template may use only declared names.
+type FanoutSessionsRequest struct {
+	Template   json.RawMessage                `json:"template" binding:"required"`
+	Parameters []map[string]string            `json:"parameters" binding:"required"`
+	Variables  []apiv1alpha1.RunbookParameter `json:"variables,omitempty"`
+}
+
+// FanoutSessionResult is one parameter set of a fan-out: the session created for it, or why not
+type FanoutSessionResult struct {
+	Index      int               `json:"index"`
+	Name       string            `json:"name,omitempty"`
+	Status     int               `json:"status,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Phase      string            `json:"phase,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// FanoutBatch is the aggregate status of the sessions one fan-out request created
+type FanoutBatch struct {
+	ID string `json:"id"`
+	// SessionBatch names the SessionBatch the operator aggregates the batch into
+	SessionBatch string                `json:"sessionBatch,omitempty"`
+	Total        int                   `json:"total"`
+	Created      int                   `json:"created"`
+	Phases       map[string]int        `json:"phases,omitempty"`
+	Done         bool                  `json:"done"`
+	Sessions     []FanoutSessionResult `json:"sessions"`
+}
+
+// SessionBatch is a SessionBatch resource: the operator aggregates the phases and cost of the
+// sessions its selector matches into the status
+type SessionBatch struct {
+	Name              string                          `json:"name"`
+	CreationTimestamp string                          `json:"creationTimestamp,omitempty"`
+	Spec              apiv1alpha1.SessionBatchSpec    `json:"spec"`
+	Status            *apiv1alpha1.SessionBatchStatus `json:"status,omitempty"`
+}
+
+// CreateSessionBatchRequest groups existing or future sessions of the project into a batch
+type CreateSessionBatchRequest struct {
+	Name             string                `json:"name" binding:"required"`
+	Selector         *metav1.LabelSelector `json:"selector" binding:"required"`
+	ExpectedSessions int                   `json:"expectedSessions,omitempty"`
+}
+
+// Agent is an Agent resource: a persona sessions of the project can hand work to
+type Agent struct {
+	Name              string `json:"name"`
+	CreationTimestamp string `json:"creationTimestamp,omitempty"`
+	apiv1alpha1.AgentSpec
+}
+
+// CreateAgentRequest adds an agent persona to the project. PUT takes the spec fields alone.
+type CreateAgentRequest struct {
+	Name string `json:"name" binding:"required"`
+	apiv1alpha1.AgentSpec
+}
+
+// Preview is a session's Preview resource: the operator runs the session's artifact and
+// reports where it is reachable until the preview expires
+type Preview struct {
+	Name              string                     `json:"name"`
+	CreationTimestamp string                     `json:"creationTimestamp,omitempty"`
+	Spec              apiv1alpha1.PreviewSpec    `json:"spec"`
+	Status            *apiv1alpha1.PreviewStatus `json:"status,omitempty"`
+}
+
+// CreatePreviewRequest starts a preview of a completed session. Image and port default to
+// the artifact the session reported in status.result.preview.
+type CreatePreviewRequest struct {
+	Image string `json:"image,omitempty"`
+	Port  int32  `json:"port,omitempty"`
+	TTL   string `json:"ttl,omitempty"`
 }
 
 type CloneSessionRequest struct {
@@ -107,3 +252,110 @@ type WorkflowSelection struct {
 	Branch string `json:"branch,omitempty"`
 	Path   string `json:"path,omitempty"`
 }
+
+// SchedulingHint is returned under "scheduling" when a session cannot be created now, so
+// clients can explain why and retry at a sensible time instead of in a tight loop
+type SchedulingHint struct {
+	// Cause is "quota" (project maxActiveSessions) or "maintenance"; the capacity preflight
+	// also reports "resourceQuota", "queue", "capacity" and "hardware"
+	Cause string `json:"cause"`
+	// Message explains the cause (capacity preflight only)
+	Message string `json:"message,omitempty"`
+	// Used and Limit are the project's active sessions and its limit (quota only)
+	Used  int64 `json:"used,omitempty"`
+	Limit int64 `json:"limit,omitempty"`
+	// QueuePosition is where a session created now would wait: project sessions already
+	// queued for a runner slot, plus one
+	QueuePosition int64 `json:"queuePosition,omitempty"`
+	// EstimatedWaitSeconds is when a slot is expected to free up: the earliest timeout of the
+	// running sessions, or the end of the maintenance window. Omitted when unknown.
+	EstimatedWaitSeconds *int64 `json:"estimatedWaitSeconds,omitempty"`
+}
+
+// CapacityEstimate is the answer of the capacity preflight: whether a session with the given
+// requirements would start now and, if not, what it would wait for
+type CapacityEstimate struct {
+	// Schedulable is true when nothing holds the session back
+	Schedulable bool `json:"schedulable"`
+	// Feasible is false when no node could ever run the session, however long it waits
+	Feasible bool `json:"feasible"`
+	// MatchingNodes can run the session by capacity; AvailableNodes also have the requested
+	// CPU, memory and GPUs free right now
+	MatchingNodes  int `json:"matchingNodes"`
+	AvailableNodes int `json:"availableNodes"`
+	// QueuedSessions are waiting for a cluster-wide runner slot
+	QueuedSessions int64 `json:"queuedSessions"`
+	// Blockers are what the session would wait for
+	Blockers []SchedulingHint `json:"blockers,omitempty"`
+	// EstimatedWaitSeconds is the longest wait of the blockers. Omitted when schedulable,
+	// infeasible, or when a blocker's wait is unknown.
+	EstimatedWaitSeconds *int64 `json:"estimatedWaitSeconds,omitempty"`
+}
+
+// ResourceUsage is the CPU and memory a session's runner pod uses, next to its requests and
+// limits, so a slow session can be told apart from a starved one
+type ResourceUsage struct {
+	Pod string `json:"pod"`
+	// Source is where the sample came from: metrics-server or prometheus
+	Source    string `json:"source"`
+	Timestamp string `json:"timestamp"`
+
+	CPUMillicores int64 `json:"cpuMillicores"`
+	MemoryBytes   int64 `json:"memoryBytes"`
+	// Requests and limits summed over the pod's containers; zero when not set
+	CPURequestMillicores int64 `json:"cpuRequestMillicores,omitempty"`
+	CPULimitMillicores   int64 `json:"cpuLimitMillicores,omitempty"`
+	MemoryRequestBytes   int64 `json:"memoryRequestBytes,omitempty"`
+	MemoryLimitBytes     int64 `json:"memoryLimitBytes,omitempty"`
+	// Pressure names the resources ("cpu", "memory") used at 90% of their limit or more
+	Pressure   []string                 `json:"pressure,omitempty"`
+	Containers []ContainerResourceUsage `json:"containers,omitempty"`
+}
+
+// ContainerResourceUsage is the usage of one container of the runner pod
+type ContainerResourceUsage struct {
+	Name          string `json:"name"`
+	CPUMillicores int64  `json:"cpuMillicores"`
+	MemoryBytes   int64  `json:"memoryBytes"`
+}
+
+// ResourceUsageSample is one point of a usage time series
+type ResourceUsageSample struct {
+	Timestamp     string `json:"timestamp"`
+	CPUMillicores int64  `json:"cpuMillicores"`
+	MemoryBytes   int64  `json:"memoryBytes"`
+}
+
+// ResourceUsageSeries is the runner pod's usage over time, oldest sample first
+type ResourceUsageSeries struct {
+	Pod     string                `json:"pod"`
+	Source  string                `json:"source"`
+	Current *ResourceUsage        `json:"current,omitempty"`
+	Samples []ResourceUsageSample `json:"samples"`
+}
+
+// TimelineEntry is one point (or span, with EndTime) of a session's timeline
+type TimelineEntry struct {
+	Time string `json:"time"`
+	// Type is lifecycle, condition, event, milestone or action
+	Type    string `json:"type"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Status of a condition (True/False/Unknown), a milestone's state or an event's type
+	Status string `json:"status,omitempty"`
+	// Object the entry is about: "Job/s1-job" for Kubernetes events, the type of a condition
+	Object          string   `json:"object,omitempty"`
+	User            string   `json:"user,omitempty"`
+	EndTime         string   `json:"endTime,omitempty"`
+	DurationSeconds *float64 `json:"durationSeconds,omitempty"`
+	// Count of occurrences folded into an event entry
+	Count int32 `json:"count,omitempty"`
+}
+
+// SessionTimeline is a session's history, oldest entry first
+type SessionTimeline struct {
+	Session string          `json:"session"`
+	Entries []TimelineEntry `json:"entries"`
+	// Warnings name sources that could not be read, e.g. events the caller may not list
+	Warnings []string `json:"warnings,omitempty"`
+}
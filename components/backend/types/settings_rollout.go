@@ -0,0 +1,65 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/settingshistory"
+)
+
+// Per-project outcomes of a settings rollout or its rollback
+const (
+	RolloutUpdated     = "Updated"
+	RolloutWouldUpdate = "WouldUpdate"
+	RolloutUnchanged   = "Unchanged"
+	RolloutSkipped     = "Skipped"
+	RolloutFailed      = "Failed"
+)
+
+// SettingsRolloutRequest applies one JSON Merge Patch to the ProjectSettings spec of many projects.
+// POST /api/admin/settings/rollout
+type SettingsRolloutRequest struct {
+	// Patch is merged into each project's spec (RFC 7386: null deletes a field)
+	Patch json.RawMessage `json:"patch"`
+	// Projects to update; empty selects every managed project (narrowed by LabelSelector)
+	Projects []string `json:"projects,omitempty"`
+	// LabelSelector on the project namespaces
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// DryRun validates and diffs every project without writing anything
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// SettingsRolloutResult is the outcome for one project
+type SettingsRolloutResult struct {
+	Project string                   `json:"project"`
+	Status  string                   `json:"status"`
+	Changes []settingshistory.Change `json:"changes,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// SettingsRollout is the response of a rollout or rollback. ID is empty for dry runs, which
+// are not recorded.
+type SettingsRollout struct {
+	ID           string                  `json:"id,omitempty"`
+	DryRun       bool                    `json:"dryRun,omitempty"`
+	CreatedBy    string                  `json:"createdBy,omitempty"`
+	CreatedAt    time.Time               `json:"createdAt"`
+	RolledBackAt *time.Time              `json:"rolledBackAt,omitempty"`
+	Patch        json.RawMessage         `json:"patch,omitempty"`
+	Results      []SettingsRolloutResult `json:"results"`
+}
+
+// SettingsRolloutRecord is what a recorded rollout keeps to undo itself: the spec of every
+// updated project before and after the rollout
+type SettingsRolloutRecord struct {
+	SettingsRollout
+	Projects []SettingsRolloutProject `json:"projects"`
+}
+
+// SettingsRolloutProject is one project changed by a rollout
+type SettingsRolloutProject struct {
+	Project string                          `json:"project"`
+	Before  apiv1alpha1.ProjectSettingsSpec `json:"before"`
+	After   apiv1alpha1.ProjectSettingsSpec `json:"after"`
+}
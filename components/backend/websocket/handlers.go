@@ -5,17 +5,21 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"ambient-code-backend/handlers"
+	"ambient-code-backend/telemetry"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
-// WebSocket upgrader
+// WebSocket upgrader; the handshake response must go out within HandshakeTimeout
 var upgrader = websocket.Upgrader{
+	HandshakeTimeout: 10 * time.Second,
 	CheckOrigin: func(r *http.Request) bool {
 		// Allow all origins for development - should be restricted in production
 		return true
@@ -24,10 +28,22 @@ var upgrader = websocket.Upgrader{
 
 // HandleSessionWebSocket handles WebSocket connections for sessions
 // Route: /projects/:projectName/sessions/:sessionId/ws
+// Every frame carries a per-session seq; a client reconnecting with ?since=<seq> first
+// receives the frames it missed (or a stream.resync frame when they are gone).
 func HandleSessionWebSocket(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 	log.Printf("handleSessionWebSocket for session: %s", sessionID)
 
+	var resumeFrom *int64
+	if v := strings.TrimSpace(c.Query("since")); v != "" {
+		since, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || since < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be a non-negative sequence number"})
+			return
+		}
+		resumeFrom = &since
+	}
+
 	// Access enforced by RBAC on downstream resources
 
 	// Best-effort user identity: prefer forwarded user, else extract ServiceAccount from bearer token
@@ -51,9 +67,11 @@ func HandleSessionWebSocket(c *gin.Context) {
 	}
 
 	sessionConn := &SessionConnection{
-		SessionID: sessionID,
-		Conn:      conn,
-		UserID:    userIDStr,
+		SessionID:  sessionID,
+		Project:    c.Param("projectName"),
+		Conn:       conn,
+		UserID:     userIDStr,
+		ResumeFrom: resumeFrom,
 	}
 
 	// Register connection
@@ -72,6 +90,14 @@ func handleWebSocketMessages(conn *SessionConnection) {
 		Hub.unregister <- conn
 	}()
 
+	// A client that holds the connection without sending or answering pings is dropped, as is
+	// one that sends an oversized message
+	conn.Conn.SetReadLimit(MaxMessageBytes)
+	_ = conn.Conn.SetReadDeadline(time.Now().Add(IdleTimeout))
+	conn.Conn.SetPongHandler(func(string) error {
+		return conn.Conn.SetReadDeadline(time.Now().Add(IdleTimeout))
+	})
+
 	for {
 		messageType, messageData, err := conn.Conn.ReadMessage()
 		if err != nil {
@@ -80,6 +106,7 @@ func handleWebSocketMessages(conn *SessionConnection) {
 			}
 			break
 		}
+		_ = conn.Conn.SetReadDeadline(time.Now().Add(IdleTimeout))
 
 		if messageType == websocket.TextMessage {
 			var msg map[string]interface{}
@@ -99,17 +126,25 @@ func handleWebSocketMessages(conn *SessionConnection) {
 					pongData, _ := json.Marshal(pong)
 					// Lock write mutex before writing pong
 					conn.writeMu.Lock()
-					_ = conn.Conn.WriteMessage(websocket.TextMessage, pongData)
+					_ = conn.write(websocket.TextMessage, pongData)
 					conn.writeMu.Unlock()
 					continue
 				}
+				// A read-only demo (DEMO_MODE) accepts no messages from clients
+				if os.Getenv("DEMO_MODE") == "true" {
+					continue
+				}
 				// Extract payload from runner message to avoid double-nesting
 				// Runner sends: {type, seq, timestamp, payload}
-				// We only want to store the payload field
+				// We only want to store the payload field; the hub assigns its own seq
 				payload, ok := msg["payload"].(map[string]interface{})
 				if !ok {
 					payload = msg // Fallback for legacy format
 				}
+				// Any real message (not a transport ping) keeps the session from being reaped as idle
+				go handlers.RecordSessionActivity(conn.Project, conn.SessionID)
+				// Agent turns and tool calls are exported as GenAI spans when telemetry is configured
+				telemetry.ObserveRunnerMessage(conn.Project, conn.SessionID, msgType, payload)
 				// Broadcast all other messages to session listeners (UI and others)
 				sessionMsg := &SessionMessage{
 					SessionID: conn.SessionID,
@@ -131,7 +166,7 @@ func handleWebSocketPing(conn *SessionConnection) {
 	for range ticker.C {
 		// Lock write mutex before writing ping
 		conn.writeMu.Lock()
-		err := conn.Conn.WriteMessage(websocket.PingMessage, nil)
+		err := conn.write(websocket.PingMessage, nil)
 		conn.writeMu.Unlock()
 		if err != nil {
 			return
@@ -140,12 +175,19 @@ func handleWebSocketPing(conn *SessionConnection) {
 }
 
 // GetSessionMessagesWS handles GET /projects/:projectName/sessions/:sessionId/messages
-// Retrieves messages from S3 storage
+// Retrieves messages from S3 storage. ?redaction=strict strips file contents and code so the
+// transcript can be shared outside the engineering org (see redactMessagesStrict).
 func GetSessionMessagesWS(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
 	// Access enforced by RBAC on downstream resources
 
+	redaction, err := parseRedaction(c.Query("redaction"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	messages, err := retrieveMessagesFromS3(sessionID)
 	if err != nil {
 		log.Printf("getSessionMessagesWS: retrieve failed: %v", err)
@@ -159,6 +201,14 @@ func GetSessionMessagesWS(c *gin.Context) {
 	includeParam := strings.ToLower(strings.TrimSpace(c.Query("include_partial_messages")))
 	includePartials := includeParam == "1" || includeParam == "true" || includeParam == "yes"
 
+	// Clients resume the live stream from the last seq in the response
+	var lastSeq int64
+	for _, m := range messages {
+		if m.Seq > lastSeq {
+			lastSeq = m.Seq
+		}
+	}
+
 	collapsed := make([]SessionMessage, 0, len(messages))
 	activePartialIndex := -1
 	for _, m := range messages {
@@ -179,10 +229,60 @@ func GetSessionMessagesWS(c *gin.Context) {
 		collapsed = append(collapsed, m)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"sessionId": sessionID,
 		"messages":  collapsed,
-	})
+		"lastSeq":   lastSeq,
+	}
+	if redaction == redactionStrict {
+		resp["messages"] = redactMessagesStrict(collapsed)
+		resp["redaction"] = redaction
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSharedSessionMessages handles GET /shared/:token/messages
+// Read-only message history for a session share link; partial messages are collapsed away.
+// ?redaction=strict returns the compliance-safe transcript, as for the session endpoint.
+func GetSharedSessionMessages(c *gin.Context) {
+	// Messages are stored by session name only, so the link's project and session UID are
+	// checked against the live session before any are read
+	session, status, err := handlers.LoadSharedSession(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	sessionID := session.GetName()
+	redaction, err := parseRedaction(c.Query("redaction"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	messages, err := retrieveMessagesFromS3(sessionID)
+	if err != nil {
+		log.Printf("GetSharedSessionMessages: retrieve failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve messages"})
+		return
+	}
+
+	filtered := make([]SessionMessage, 0, len(messages))
+	for _, m := range messages {
+		if m.Type == "message.partial" {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+
+	resp := gin.H{
+		"sessionId": sessionID,
+		"messages":  filtered,
+	}
+	if redaction == redactionStrict {
+		resp["messages"] = redactMessagesStrict(filtered)
+		resp["redaction"] = redaction
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // PostSessionMessageWS handles POST /projects/:projectName/sessions/:sessionId/messages
@@ -214,6 +314,7 @@ func PostSessionMessageWS(c *gin.Context) {
 
 	// Broadcast to session listeners (runner) and persist
 	Hub.broadcast <- message
+	go handlers.RecordSessionActivity(c.Param("projectName"), sessionID)
 
 	c.JSON(http.StatusAccepted, gin.H{"status": "queued"})
 }
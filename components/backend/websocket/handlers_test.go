@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ambient-code-backend/handlers"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// shareToken signs claims the way handlers.CreateSessionShareLink does
+func shareToken(secret, project, session, uid string) string {
+	raw, _ := json.Marshal(map[string]interface{}{"p": project, "s": session, "uid": uid, "exp": time.Now().Add(time.Hour).Unix()})
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return payload + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestGetSharedSessionMessages_CrossProject verifies a link is checked against the live
+// session before the transcript, stored by session name only, is read
+func TestGetSharedSessionMessages_CrossProject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SHARE_LINK_SECRET", "share-secret")
+	StateBaseDir = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(StateBaseDir, "sessions", "foo"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(StateBaseDir, "sessions", "foo", "messages.jsonl"),
+		[]byte(`{"sessionId":"foo","type":"agent.message","payload":{"text":"team-a only"}}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gvr := schema.GroupVersionResource{Group: "vteam.ambient-code", Version: "v1alpha1", Resource: "agenticsessions"}
+	handlers.GetAgenticSessionV1Alpha1Resource = func() schema.GroupVersionResource { return gvr }
+	prev := handlers.DynamicClient
+	handlers.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "AgenticSessionList"},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "AgenticSession",
+			"metadata":   map[string]interface{}{"name": "foo", "namespace": "team-a", "uid": "uid-a"},
+		}})
+	t.Cleanup(func() { handlers.DynamicClient = prev })
+
+	get := func(token string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/shared/"+token+"/messages", nil)
+		c.Params = gin.Params{{Key: "token", Value: token}}
+		GetSharedSessionMessages(c)
+		var body map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	if code, body := get(shareToken("share-secret", "team-a", "foo", "uid-a")); code != http.StatusOK || len(body["messages"].([]interface{})) != 1 {
+		t.Fatalf("link for team-a/foo: code=%d body=%v", code, body)
+	}
+	for name, token := range map[string]string{
+		"other project":     shareToken("share-secret", "team-b", "foo", "uid-a"),
+		"recreated session": shareToken("share-secret", "team-a", "foo", "uid-old"),
+	} {
+		if code, body := get(token); code != http.StatusNotFound || body["messages"] != nil {
+			t.Errorf("%s: code=%d body=%v", name, code, body)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"ambient-code-pkg/runtimeconfig"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -23,25 +25,56 @@ type SessionWebSocketHub struct {
 	unregister chan *SessionConnection
 	// Broadcast messages to session
 	broadcast chan *SessionMessage
-	mu        sync.RWMutex
+	// Sequence counters and replay buffers per session; only touched by run()
+	streams map[string]*sessionStream
+	mu      sync.RWMutex
+}
+
+// sessionStream numbers a session's frames and keeps the most recent ones so a reconnecting
+// client can resume from the last sequence number it saw
+type sessionStream struct {
+	seq    int64
+	buffer []*SessionMessage
 }
 
+// replayBufferSize is how many recent frames per session are kept for resume
+const replayBufferSize = 2000
+
+// Frame types handled specially by the hub
+const (
+	// MessageTypeDelta carries a fragment of assistant text as it is generated. Deltas are
+	// broadcast and kept for resume but not persisted: the complete message follows them.
+	MessageTypeDelta = "message.delta"
+	// MessageTypeResync tells a client it missed frames: those after its resume point are no
+	// longer buffered, or the relay dropped one. It should reload the history with
+	// GET .../messages and continue from the frame's seq.
+	MessageTypeResync = "stream.resync"
+)
+
 // SessionConnection represents a WebSocket connection to a session
 type SessionConnection struct {
 	SessionID string
+	Project   string
 	Conn      *websocket.Conn
 	UserID    string
-	writeMu   sync.Mutex // Protects concurrent writes to Conn
+	// ResumeFrom is the last sequence number the client saw; frames after it are replayed on
+	// registration. Nil for a fresh connection.
+	ResumeFrom *int64
+	writeMu    sync.Mutex // Protects concurrent writes to Conn
 }
 
 // SessionMessage represents a message in a session
 type SessionMessage struct {
-	SessionID string                 `json:"sessionId"`
+	SessionID string `json:"sessionId"`
+	// Seq is assigned by the hub and increases monotonically within a session
+	Seq       int64                  `json:"seq,omitempty"`
 	Type      string                 `json:"type"`
 	Timestamp string                 `json:"timestamp"`
 	Payload   map[string]interface{} `json:"payload"`
 	// Partial message support
 	Partial *PartialMessageInfo `json:"partial,omitempty"`
+	// origin tells the hub whether to forward, number or only deliver the frame (see Relay)
+	origin frameOrigin
 }
 
 // PartialMessageInfo for fragmented messages
@@ -56,8 +89,23 @@ type PartialMessageInfo struct {
 var (
 	Hub          *SessionWebSocketHub
 	StateBaseDir string
+
+	// MaxMessageBytes caps one message read from a client; larger messages close the connection
+	MaxMessageBytes int64 = 8 << 20
+	// IdleTimeout drops a client that neither sends a message nor answers a ping for this long
+	IdleTimeout = 90 * time.Second
 )
 
+// writeTimeout bounds each write, so a client that stops reading cannot stall the hub's
+// broadcast loop
+const writeTimeout = 15 * time.Second
+
+// write sends one message with the write deadline set; callers hold writeMu
+func (c *SessionConnection) write(messageType int, data []byte) error {
+	_ = c.Conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.Conn.WriteMessage(messageType, data)
+}
+
 // Initialize WebSocket hub
 func init() {
 	Hub = &SessionWebSocketHub{
@@ -65,6 +113,7 @@ func init() {
 		register:   make(chan *SessionConnection),
 		unregister: make(chan *SessionConnection),
 		broadcast:  make(chan *SessionMessage),
+		streams:    make(map[string]*sessionStream),
 	}
 	go Hub.run()
 }
@@ -80,6 +129,10 @@ func (h *SessionWebSocketHub) run() {
 			}
 			h.sessions[conn.SessionID][conn] = true
 			h.mu.Unlock()
+			// Replayed before any later broadcast, so the client sees no gap or duplicate
+			if conn.ResumeFrom != nil {
+				h.replay(conn, *conn.ResumeFrom)
+			}
 			log.Printf("WebSocket connection registered for session %s", conn.SessionID)
 
 		case conn := <-h.unregister:
@@ -90,6 +143,7 @@ func (h *SessionWebSocketHub) run() {
 					conn.Conn.Close()
 					if len(connections) == 0 {
 						delete(h.sessions, conn.SessionID)
+						delete(h.streams, conn.SessionID)
 					}
 				}
 			}
@@ -97,33 +151,133 @@ func (h *SessionWebSocketHub) run() {
 			log.Printf("WebSocket connection unregistered for session %s", conn.SessionID)
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
-			connections := h.sessions[message.SessionID]
-			h.mu.RUnlock()
-
-			if connections != nil {
-				messageData, _ := json.Marshal(message)
-				for sessionConn := range connections {
-					// Lock write mutex before writing
-					sessionConn.writeMu.Lock()
-					err := sessionConn.Conn.WriteMessage(websocket.TextMessage, messageData)
-					sessionConn.writeMu.Unlock()
+			// With several replicas, the session's owner numbers the frame and pushes it back
+			if message.origin == originLocal {
+				if forwarded, err := PeerRelay.forward(message); forwarded {
 					if err != nil {
-						// Unregister in goroutine to avoid deadlock - hub select loop
-						// can only process one case at a time, so blocking send would hang
-						go func(conn *SessionConnection) {
-							h.unregister <- conn
-						}(sessionConn)
+						// The frame is lost; the session's clients here reload the history
+						h.deliver(h.resyncFrame(message.SessionID, h.stream(message.SessionID).seq))
 					}
+					continue
 				}
 			}
+			if message.origin == originPeer {
+				h.record(message)
+			} else {
+				h.sequence(message)
+				PeerRelay.push(message)
+			}
+			h.deliver(message)
 
-			// Also persist to S3
-			go persistMessageToS3(message)
+			// Also persist to S3; the owner already did for frames from other replicas
+			if message.Type != MessageTypeDelta && message.origin != originPeer {
+				go persistMessageToS3(message)
+			}
 		}
 	}
 }
 
+// deliver writes message to the session's connections on this replica
+func (h *SessionWebSocketHub) deliver(message *SessionMessage) {
+	h.mu.RLock()
+	connections := h.sessions[message.SessionID]
+	h.mu.RUnlock()
+	if connections == nil {
+		return
+	}
+	messageData, _ := json.Marshal(message)
+	for sessionConn := range connections {
+		// Lock write mutex before writing
+		sessionConn.writeMu.Lock()
+		err := sessionConn.write(websocket.TextMessage, messageData)
+		sessionConn.writeMu.Unlock()
+		if err != nil {
+			// Unregister in goroutine to avoid deadlock - hub select loop
+			// can only process one case at a time, so blocking send would hang
+			go func(conn *SessionConnection) {
+				h.unregister <- conn
+			}(sessionConn)
+		}
+	}
+}
+
+// resyncFrame tells a client that frames after since cannot be sent to it
+func (h *SessionWebSocketHub) resyncFrame(sessionID string, since int64) *SessionMessage {
+	return &SessionMessage{
+		SessionID: sessionID,
+		Seq:       h.stream(sessionID).seq,
+		Type:      MessageTypeResync,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Payload:   map[string]interface{}{"since": since},
+	}
+}
+
+// stream returns the session's stream state. A new counter continues from the last persisted
+// frame, so sequence numbers keep increasing across backend restarts.
+func (h *SessionWebSocketHub) stream(sessionID string) *sessionStream {
+	if st, ok := h.streams[sessionID]; ok {
+		return st
+	}
+	st := &sessionStream{seq: lastPersistedSeq(sessionID)}
+	h.streams[sessionID] = st
+	return st
+}
+
+// sequence numbers a frame and adds it to the session's replay buffer
+func (h *SessionWebSocketHub) sequence(message *SessionMessage) {
+	st := h.stream(message.SessionID)
+	st.seq++
+	message.Seq = st.seq
+	if len(st.buffer) >= replayBufferSize {
+		st.buffer = append(st.buffer[:0], st.buffer[1:]...)
+	}
+	st.buffer = append(st.buffer, message)
+}
+
+// record adds a frame numbered by the session's owner replica to the replay buffer
+func (h *SessionWebSocketHub) record(message *SessionMessage) {
+	st := h.stream(message.SessionID)
+	if message.Seq <= st.seq {
+		// Already in the persisted history the stream was loaded from
+		return
+	}
+	st.seq = message.Seq
+	if len(st.buffer) >= replayBufferSize {
+		st.buffer = append(st.buffer[:0], st.buffer[1:]...)
+	}
+	st.buffer = append(st.buffer, message)
+}
+
+// replay writes the buffered frames after since to conn, or a resync frame when they are no
+// longer buffered
+func (h *SessionWebSocketHub) replay(conn *SessionConnection, since int64) {
+	st := h.stream(conn.SessionID)
+	first := st.seq + 1
+	if len(st.buffer) > 0 {
+		first = st.buffer[0].Seq
+	}
+	frames := []*SessionMessage{}
+	if since < first-1 || since > st.seq {
+		frames = append(frames, h.resyncFrame(conn.SessionID, since))
+	} else {
+		for _, m := range st.buffer {
+			if m.Seq > since {
+				frames = append(frames, m)
+			}
+		}
+	}
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	for _, m := range frames {
+		data, _ := json.Marshal(m)
+		if err := conn.write(websocket.TextMessage, data); err != nil {
+			go func() { h.unregister <- conn }()
+			return
+		}
+	}
+	log.Printf("Replayed %d frames after seq %d to session %s", len(frames), since, conn.SessionID)
+}
+
 // SendMessageToSession sends a message to all connections for a session
 func SendMessageToSession(sessionID string, messageType string, payload map[string]interface{}) {
 	message := &SessionMessage{
@@ -160,7 +314,7 @@ func persistMessageToS3(message *SessionMessage) {
 	// Write messages to per-project content service path as JSONL append for now
 	// Backend does not have project in this scope; persist to local state dir for durability
 	path := fmt.Sprintf("%s/sessions/%s/messages.jsonl", StateBaseDir, message.SessionID)
-	log.Printf("persistMessageToS3: path: %s", path)
+	runtimeconfig.Debugf("persistMessageToS3: path: %s", path)
 	b, _ := json.Marshal(message)
 	// Ensure dir
 	_ = os.MkdirAll(fmt.Sprintf("%s/sessions/%s", StateBaseDir, message.SessionID), 0o755)
@@ -175,6 +329,21 @@ func persistMessageToS3(message *SessionMessage) {
 	}
 }
 
+// lastPersistedSeq is the highest sequence number in a session's persisted messages
+func lastPersistedSeq(sessionID string) int64 {
+	msgs, err := retrieveMessagesFromS3(sessionID)
+	if err != nil {
+		return 0
+	}
+	var last int64
+	for _, m := range msgs {
+		if m.Seq > last {
+			last = m.Seq
+		}
+	}
+	return last
+}
+
 func retrieveMessagesFromS3(sessionID string) ([]SessionMessage, error) {
 	// Read from local state JSONL path for now
 	path := fmt.Sprintf("%s/sessions/%s/messages.jsonl", StateBaseDir, sessionID)
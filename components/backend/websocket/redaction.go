@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ambient-code-pkg/redact"
+)
+
+// redactionStrict is the ?redaction= value of the compliance-safe transcript
+const redactionStrict = "strict"
+
+// omitted replaces content that strict redaction removes
+const omitted = "[omitted]"
+
+// fencedCodeBlock matches Markdown code fences, unterminated ones up to the end of the text
+var fencedCodeBlock = regexp.MustCompile("(?s)```.*?(?:```|$)")
+
+// parseRedaction validates the ?redaction= query parameter; empty means no redaction
+func parseRedaction(value string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(value)); v {
+	case "", redactionStrict:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unsupported redaction %q, use %q", value, redactionStrict)
+	}
+}
+
+// redactMessagesStrict returns a transcript that can be shared outside the engineering org.
+// The conversation keeps its shape: message types, assistant and user text, tool names,
+// errors, costs and usage. File contents and code are dropped: tool inputs keep only their
+// argument names, tool results lose their content and fenced code blocks in text are replaced.
+// Credentials left in the remaining text are masked.
+func redactMessagesStrict(messages []SessionMessage) []SessionMessage {
+	out := make([]SessionMessage, 0, len(messages))
+	for _, m := range messages {
+		m.Payload = strictPayload(m.Payload)
+		out = append(out, m)
+	}
+	return out
+}
+
+func strictPayload(p map[string]interface{}) map[string]interface{} {
+	if p == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(p))
+	_, isToolUse := p["tool"].(string)
+	for k, v := range p {
+		switch {
+		case k == "input" && isToolUse:
+			out[k] = strictToolInput(v)
+		case k == "tool_result":
+			out[k] = strictToolResult(v)
+		default:
+			out[k] = strictValue(v)
+		}
+	}
+	return out
+}
+
+// strictToolInput keeps the argument names of a tool call; the values, including file paths,
+// commands and edits, are dropped
+func strictToolInput(v interface{}) interface{} {
+	input, ok := v.(map[string]interface{})
+	if !ok {
+		return omitted
+	}
+	keys := make([]string, 0, len(input))
+	for k := range input {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return map[string]interface{}{"arguments": keys, "content": omitted}
+}
+
+func strictToolResult(v interface{}) interface{} {
+	result, ok := v.(map[string]interface{})
+	if !ok {
+		return omitted
+	}
+	out := map[string]interface{}{}
+	for k, val := range result {
+		if k == "content" {
+			out[k] = omitted
+			continue
+		}
+		out[k] = strictValue(val)
+	}
+	return out
+}
+
+func strictValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return redact.String(fencedCodeBlock.ReplaceAllString(t, "```"+omitted+"```"))
+	case map[string]interface{}:
+		return strictPayload(t)
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			out[i] = strictValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactMessagesStrict(t *testing.T) {
+	messages := []SessionMessage{
+		{Type: "agent.message", Payload: map[string]interface{}{
+			"type":    "agent_message",
+			"content": map[string]interface{}{"type": "text_block", "text": "Use a mutex:\n```go\nmu.Lock()\n```\nDone, token=ghp_abcdefghijklmnopqrstuvwx"},
+		}},
+		{Type: "agent.message", Payload: map[string]interface{}{
+			"tool": "Edit", "id": "toolu_1",
+			"input": map[string]interface{}{"file_path": "/workspace/main.go", "new_string": "secret code"},
+		}},
+		{Type: "agent.message", Payload: map[string]interface{}{
+			"tool_result": map[string]interface{}{"tool_use_id": "toolu_1", "content": "package main", "is_error": false},
+		}},
+		{Type: "agent.message", Payload: map[string]interface{}{
+			"type":    "result.message",
+			"payload": map[string]interface{}{"total_cost_usd": 0.42, "num_turns": float64(3), "result": "Fixed the race"},
+		}},
+	}
+	got := redactMessagesStrict(messages)
+
+	text := got[0].Payload["content"].(map[string]interface{})["text"].(string)
+	if strings.Contains(text, "mu.Lock") || strings.Contains(text, "ghp_") || !strings.HasPrefix(text, "Use a mutex:") {
+		t.Errorf("text = %q, want prose kept and code and credentials removed", text)
+	}
+
+	input := got[1].Payload["input"].(map[string]interface{})
+	if args := input["arguments"].([]string); len(args) != 2 || args[0] != "file_path" || args[1] != "new_string" {
+		t.Errorf("input arguments = %v", args)
+	}
+	if got[1].Payload["tool"] != "Edit" || input["content"] != omitted {
+		t.Errorf("tool call = %v, want the tool name without its input", got[1].Payload)
+	}
+
+	result := got[2].Payload["tool_result"].(map[string]interface{})
+	if result["content"] != omitted || result["tool_use_id"] != "toolu_1" || result["is_error"] != false {
+		t.Errorf("tool result = %v", result)
+	}
+
+	summary := got[3].Payload["payload"].(map[string]interface{})
+	if summary["total_cost_usd"] != 0.42 || summary["result"] != "Fixed the race" {
+		t.Errorf("result message = %v, want costs and decisions kept", summary)
+	}
+
+	if messages[1].Payload["input"].(map[string]interface{})["new_string"] != "secret code" {
+		t.Error("the stored messages must not be modified")
+	}
+}
+
+func TestParseRedaction(t *testing.T) {
+	for value, want := range map[string]string{"": "", "strict": "strict", " Strict ": "strict"} {
+		if got, err := parseRedaction(value); err != nil || got != want {
+			t.Errorf("parseRedaction(%q) = %q, %v", value, got, err)
+		}
+	}
+	if _, err := parseRedaction("partial"); err == nil {
+		t.Error("unknown redaction levels must be rejected")
+	}
+}
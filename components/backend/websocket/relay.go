@@ -0,0 +1,404 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// With several backend replicas behind one Service, each replica holds the WebSocket clients
+// that happened to land on it. The relay makes the replicas behave like one hub. Every session
+// has an owner replica, chosen by rendezvous hashing over the replicas, that numbers the
+// session's frames, persists them and pushes each numbered frame to the other replicas; they
+// deliver it to their clients and keep it for resume. A frame that arrives on any other
+// replica (a runner or browser connected there, a POST, a backend notice) is forwarded to the
+// owner first, so every client sees the same frames with the same sequence numbers. A
+// replica never numbers a session it does not own: frames for an unreachable owner wait until
+// it answers or leaves the replica list.
+//
+// Replicas find each other through the DNS of a headless Service (WS_RELAY_SERVICE) and call
+// each other's POST /relay/frames with a shared secret (WS_RELAY_SECRET). The relay is off
+// when WS_RELAY_SERVICE is unset, as for a single replica. The replicas must share the state
+// volume (ReadWriteMany) so that history and resume counters read the owner's frames.
+
+// relayTokenHeader carries WS_RELAY_SECRET on relay calls. It is not the Authorization
+// header, so peer calls never count as failed user authentications.
+const relayTokenHeader = "X-Ambient-Relay-Token"
+
+const (
+	// relayRefreshInterval is how often the replica list is looked up again
+	relayRefreshInterval = 10 * time.Second
+	// relayQueueSize bounds the frames waiting for one peer; beyond it frames are dropped
+	relayQueueSize = 4096
+	// relayBatchSize bounds the frames sent to a peer in one call
+	relayBatchSize = 256
+	// maxRelayBodyBytes bounds one relay call received
+	maxRelayBodyBytes = 64 << 20
+	// relayRetryInterval and relayRetryMax bound the wait between attempts to reach an owner
+	relayRetryInterval = 250 * time.Millisecond
+	relayRetryMax      = 5 * time.Second
+)
+
+// frameOrigin says where a frame in the hub came from
+type frameOrigin int
+
+const (
+	// originLocal frames were produced on this replica and go to their session's owner
+	originLocal frameOrigin = iota
+	// originForwarded frames were forwarded by another replica; this replica numbers them
+	originForwarded
+	// originPeer frames were numbered and persisted by their owner; they are only delivered
+	originPeer
+)
+
+// relayFrame is one frame on the wire between replicas
+type relayFrame struct {
+	// Forward is set on frames sent to the session's owner to be numbered, unset on numbered
+	// frames the owner pushes to the other replicas
+	Forward bool            `json:"forward,omitempty"`
+	Message *SessionMessage `json:"message"`
+}
+
+// relayFrames counts frames by result: forwarded, pushed, received, dropped (queue full),
+// failed (peer unreachable) and rerouted (owner gone before it took them); shown as
+// ambient_ws_relay_frames_total
+var relayFrames = expvar.NewMap("ws_relay_frames")
+
+// errRelayQueueFull is returned by forward when the owner's queue is full and the frame was
+// dropped
+var errRelayQueueFull = errors.New("relay queue full")
+
+// Relay shares session frames between backend replicas. A nil Relay relays nothing.
+type Relay struct {
+	// Service is the headless Service whose addresses are the backend replicas
+	Service string
+	// Self is this replica's address (POD_IP)
+	Self   string
+	Port   string
+	Secret string
+
+	client *http.Client
+	lookup func(host string) ([]string, error)
+
+	mu sync.RWMutex
+	// peers are the replica addresses, this one included, sorted
+	peers []string
+	links map[string]*peerLink
+}
+
+// peerLink sends queued frames to one replica, in order
+type peerLink struct {
+	addr  string
+	queue chan relayFrame
+	// stop is closed when the replica leaves the list
+	stop chan struct{}
+}
+
+// PeerRelay is the relay of this replica, nil when WS_RELAY_SERVICE is unset
+var PeerRelay *Relay
+
+// NewRelayFromEnv configures the relay from WS_RELAY_SERVICE (the headless Service of the
+// backend pods, e.g. backend-peers), POD_IP, WS_RELAY_SECRET and PORT. It returns nil when
+// WS_RELAY_SERVICE is unset.
+func NewRelayFromEnv() (*Relay, error) {
+	service := strings.TrimSpace(os.Getenv("WS_RELAY_SERVICE"))
+	if service == "" {
+		return nil, nil
+	}
+	self := strings.TrimSpace(os.Getenv("POD_IP"))
+	if net.ParseIP(self) == nil {
+		return nil, fmt.Errorf("WS_RELAY_SERVICE needs POD_IP set to the pod's IP address, got %q", self)
+	}
+	secret := strings.TrimSpace(os.Getenv("WS_RELAY_SECRET"))
+	if secret == "" {
+		return nil, fmt.Errorf("WS_RELAY_SERVICE needs WS_RELAY_SECRET")
+	}
+	port := strings.TrimSpace(os.Getenv("PORT"))
+	if port == "" {
+		port = "8080"
+	}
+	return NewRelay(service, self, port, secret), nil
+}
+
+// NewRelay returns a relay between the replicas behind service
+func NewRelay(service, self, port, secret string) *Relay {
+	return &Relay{
+		Service: service,
+		Self:    self,
+		Port:    port,
+		Secret:  secret,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		lookup:  net.LookupHost,
+		peers:   []string{self},
+		links:   map[string]*peerLink{},
+	}
+}
+
+// Run looks the replicas up every relayRefreshInterval until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.refresh()
+	ticker := time.NewTicker(relayRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.setPeers(nil)
+			return
+		case <-ticker.C:
+			r.refresh()
+		}
+	}
+}
+
+// refresh replaces the replica list with the Service's addresses; a failed lookup keeps the
+// previous list
+func (r *Relay) refresh() {
+	addrs, err := r.lookup(r.Service)
+	if err != nil {
+		log.Printf("WebSocket relay: failed to look up %s, keeping %d replicas: %v", r.Service, len(r.Peers()), err)
+		return
+	}
+	r.setPeers(addrs)
+}
+
+// setPeers starts links to new replicas and stops those to replicas that are gone. This
+// replica is always in the list, so it owns every session when alone.
+func (r *Relay) setPeers(addrs []string) {
+	peers := []string{r.Self}
+	for _, a := range addrs {
+		if a != r.Self && !slices.Contains(peers, a) {
+			peers = append(peers, a)
+		}
+	}
+	sort.Strings(peers)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if addrs != nil && !slices.Equal(peers, r.peers) {
+		log.Printf("WebSocket relay: replicas %s", strings.Join(peers, ", "))
+	}
+	for addr, link := range r.links {
+		if addrs == nil || !slices.Contains(peers, addr) {
+			close(link.stop)
+			close(link.queue)
+			delete(r.links, addr)
+		}
+	}
+	if addrs == nil {
+		r.peers = []string{r.Self}
+		return
+	}
+	for _, addr := range peers {
+		if _, ok := r.links[addr]; addr != r.Self && !ok {
+			link := &peerLink{addr: addr, queue: make(chan relayFrame, relayQueueSize), stop: make(chan struct{})}
+			r.links[addr] = link
+			go r.send(link)
+		}
+	}
+	r.peers = peers
+}
+
+// Peers are the replica addresses, this one included
+func (r *Relay) Peers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.peers...)
+}
+
+// Owner is the replica that numbers the session's frames: the one with the highest hash of
+// address and session, so a replica joining or leaving moves only its share of sessions
+func (r *Relay) Owner(sessionID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var owner string
+	var best uint64
+	for _, addr := range r.peers {
+		sum := sha256.Sum256([]byte(addr + "/" + sessionID))
+		if score := binary.BigEndian.Uint64(sum[:8]); owner == "" || score > best {
+			owner, best = addr, score
+		}
+	}
+	return owner
+}
+
+// forward queues a local frame for its session's owner and reports whether the frame is
+// taken care of; a frame this replica owns is not. Only the owner numbers frames, so one that
+// cannot be queued is dropped with errRelayQueueFull rather than numbered here.
+func (r *Relay) forward(message *SessionMessage) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+	owner := r.Owner(message.SessionID)
+	if owner == r.Self {
+		return false, nil
+	}
+	if !r.enqueue(owner, relayFrame{Forward: true, Message: message}) {
+		log.Printf("WebSocket relay: dropped a %s frame of session %s, %s is not keeping up", message.Type, message.SessionID, owner)
+		return true, errRelayQueueFull
+	}
+	relayFrames.Add("forwarded", 1)
+	return true, nil
+}
+
+// push queues a numbered frame for every other replica
+func (r *Relay) push(message *SessionMessage) {
+	if r == nil {
+		return
+	}
+	for _, addr := range r.Peers() {
+		if addr != r.Self && r.enqueue(addr, relayFrame{Message: message}) {
+			relayFrames.Add("pushed", 1)
+		}
+	}
+}
+
+// enqueue never blocks: the hub's loop calls it
+func (r *Relay) enqueue(addr string, frame relayFrame) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	link, ok := r.links[addr]
+	if !ok {
+		return false
+	}
+	select {
+	case link.queue <- frame:
+		return true
+	default:
+		relayFrames.Add("dropped", 1)
+		return false
+	}
+}
+
+// send posts the link's frames in batches until the link is stopped
+func (r *Relay) send(link *peerLink) {
+	for frame := range link.queue {
+		batch := []relayFrame{frame}
+	fill:
+		for len(batch) < relayBatchSize {
+			select {
+			case next, ok := <-link.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+			default:
+				break fill
+			}
+		}
+		r.deliver(link, batch)
+	}
+}
+
+// deliver posts batch to the link's replica. Numbered frames it did not take are lost to its
+// clients, who resume from the history. Frames forwarded to the session's owner are retried
+// while it remains a replica, since no other replica may number them, and the frames queued
+// behind them wait. Once the owner is gone they go back to the hub, which forwards them to
+// the session's new owner.
+func (r *Relay) deliver(link *peerLink, batch []relayFrame) {
+	wait := relayRetryInterval
+	for {
+		err := r.post(link.addr, batch)
+		if err == nil {
+			return
+		}
+		log.Printf("WebSocket relay: failed to send %d frames to %s: %v", len(batch), link.addr, err)
+		relayFrames.Add("failed", int64(len(batch)))
+		forwarded := []relayFrame{}
+		for _, f := range batch {
+			if f.Forward {
+				forwarded = append(forwarded, f)
+			}
+		}
+		if len(forwarded) == 0 {
+			return
+		}
+		batch = forwarded
+		select {
+		case <-link.stop:
+			relayFrames.Add("rerouted", int64(len(batch)))
+			for _, f := range batch {
+				f.Message.origin = originLocal
+				Hub.broadcast <- f.Message
+			}
+			return
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, relayRetryMax)
+	}
+}
+
+func (r *Relay) post(addr string, frames []relayFrame) error {
+	body, err := json.Marshal(frames)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://"+net.JoinHostPort(addr, r.Port)+"/relay/frames", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(relayTokenHeader, r.Secret)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// HandleRelayFrames handles POST /relay/frames, the frames another backend replica forwards
+// or pushes to this one. It is authenticated by WS_RELAY_SECRET and not found without a relay.
+func HandleRelayFrames(c *gin.Context) {
+	r := PeerRelay
+	if r == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "WebSocket relay is not enabled"})
+		return
+	}
+	if !hmac.Equal([]byte(c.GetHeader(relayTokenHeader)), []byte(r.Secret)) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid relay token"})
+		return
+	}
+	var frames []relayFrame
+	if err := json.NewDecoder(io.LimitReader(c.Request.Body, maxRelayBodyBytes)).Decode(&frames); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid frames: %v", err)})
+		return
+	}
+	for _, f := range frames {
+		if f.Message == nil || f.Message.SessionID == "" {
+			continue
+		}
+		f.Message.origin = originPeer
+		if f.Forward {
+			f.Message.origin = originForwarded
+			f.Message.Seq = 0
+		}
+		Hub.broadcast <- f.Message
+	}
+	relayFrames.Add("received", int64(len(frames)))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,211 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRelayOwner verifies replicas with the same view agree on each session's owner, and a
+// replica leaving moves only the sessions it owned
+func TestRelayOwner(t *testing.T) {
+	addrs := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	relays := make([]*Relay, len(addrs))
+	for i, a := range addrs {
+		relays[i] = NewRelay("backend-peers", a, "8080", "s")
+		relays[i].lookup = func(string) ([]string, error) { return addrs, nil }
+		relays[i].refresh()
+		defer relays[i].setPeers(nil)
+	}
+
+	owned := map[string]int{}
+	before := map[string]string{}
+	for i := 0; i < 300; i++ {
+		id := fmt.Sprintf("session-%d", i)
+		owner := relays[0].Owner(id)
+		for _, r := range relays[1:] {
+			if r.Owner(id) != owner {
+				t.Fatalf("replicas disagree on the owner of %s", id)
+			}
+		}
+		owned[owner]++
+		before[id] = owner
+	}
+	for _, a := range addrs {
+		if owned[a] < 50 {
+			t.Errorf("%s owns only %d of 300 sessions", a, owned[a])
+		}
+	}
+
+	relays[0].setPeers(addrs[:2])
+	for id, owner := range before {
+		if now := relays[0].Owner(id); owner != "10.0.0.3" && now != owner {
+			t.Fatalf("%s moved from %s to %s", id, owner, now)
+		}
+	}
+
+	// A replica alone, or not yet listed by DNS, owns everything
+	alone := NewRelay("backend-peers", "10.0.0.9", "8080", "s")
+	if forwarded, _ := alone.forward(&SessionMessage{SessionID: "x"}); alone.Owner("x") != "10.0.0.9" || forwarded {
+		t.Error("a lone replica forwards its frames")
+	}
+	var disabled *Relay
+	if forwarded, _ := disabled.forward(&SessionMessage{SessionID: "x"}); forwarded {
+		t.Error("a disabled relay forwards frames")
+	}
+}
+
+// TestRelayPush verifies numbered frames reach the other replicas with the shared secret and
+// their sequence numbers, and forwarded frames are marked for the owner
+func TestRelayPush(t *testing.T) {
+	received := make(chan []relayFrame, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/relay/frames" || req.Header.Get(relayTokenHeader) != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var frames []relayFrame
+		_ = json.NewDecoder(req.Body).Decode(&frames)
+		received <- frames
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	r := NewRelay("backend-peers", "10.0.0.1", port, "s3cret")
+	r.setPeers([]string{"10.0.0.1", host})
+	defer r.setPeers(nil)
+
+	r.push(&SessionMessage{SessionID: "s1", Seq: 7, Type: "agent.message"})
+	select {
+	case frames := <-received:
+		if len(frames) != 1 || frames[0].Forward || frames[0].Message.Seq != 7 {
+			t.Errorf("unexpected frames %+v", frames)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pushed frame not received")
+	}
+
+	// Find a session the other replica owns; its frames go there to be numbered
+	id := ownedBy(r, host)
+	if forwarded, err := r.forward(&SessionMessage{SessionID: id, Type: "user_message"}); !forwarded || err != nil {
+		t.Fatalf("frame of a session owned elsewhere not forwarded: %v", err)
+	}
+	select {
+	case frames := <-received:
+		if len(frames) != 1 || !frames[0].Forward || frames[0].Message.SessionID != id {
+			t.Errorf("unexpected frames %+v", frames)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("forwarded frame not received")
+	}
+}
+
+// ownedBy returns a session ID the replica at addr owns
+func ownedBy(r *Relay, addr string) string {
+	for i := 0; ; i++ {
+		if id := fmt.Sprintf("s%d", i); r.Owner(id) == addr {
+			return id
+		}
+	}
+}
+
+// TestRelayRetriesOwner verifies a frame for an unreachable owner is retried until the owner
+// takes it, never numbered by the replica it arrived on
+func TestRelayRetriesOwner(t *testing.T) {
+	failures := int32(2)
+	received := make(chan []relayFrame, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var frames []relayFrame
+		_ = json.NewDecoder(req.Body).Decode(&frames)
+		received <- frames
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	r := NewRelay("backend-peers", "10.0.0.1", port, "s")
+	r.setPeers([]string{"10.0.0.1", host})
+	defer r.setPeers(nil)
+
+	id := ownedBy(r, host)
+	if forwarded, err := r.forward(&SessionMessage{SessionID: id, Type: "user_message"}); !forwarded || err != nil {
+		t.Fatalf("frame not forwarded: %v", err)
+	}
+	select {
+	case frames := <-received:
+		if len(frames) != 1 || !frames[0].Forward || frames[0].Message.SessionID != id || frames[0].Message.Seq != 0 {
+			t.Errorf("unexpected frames %+v", frames)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("forwarded frame not retried")
+	}
+}
+
+// TestRelayReroutesWhenOwnerLeaves verifies frames an owner never took go back to the hub,
+// to be forwarded again, once the owner leaves the replica list, and a frame that cannot be
+// queued is dropped rather than numbered here
+func TestRelayReroutesWhenOwnerLeaves(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	hub := Hub
+	Hub = &SessionWebSocketHub{broadcast: make(chan *SessionMessage, 1)}
+	defer func() { Hub = hub }()
+
+	r := NewRelay("backend-peers", "10.0.0.1", port, "s")
+	r.setPeers([]string{"10.0.0.1", host})
+	id := ownedBy(r, host)
+	if forwarded, err := r.forward(&SessionMessage{SessionID: id, Type: "user_message"}); !forwarded || err != nil {
+		t.Fatalf("frame not forwarded: %v", err)
+	}
+	r.setPeers([]string{"10.0.0.1"})
+	select {
+	case m := <-Hub.broadcast:
+		if m.SessionID != id || m.origin != originLocal || m.Seq != 0 {
+			t.Errorf("rerouted frame %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("frame for a departed owner not rerouted")
+	}
+
+	// A full queue drops the frame
+	stuck := NewRelay("backend-peers", "10.0.0.1", port, "s")
+	stuck.peers = []string{"10.0.0.1", host}
+	stuck.links[host] = &peerLink{addr: host, queue: make(chan relayFrame), stop: make(chan struct{})}
+	if forwarded, err := stuck.forward(&SessionMessage{SessionID: id}); !forwarded || err != errRelayQueueFull {
+		t.Errorf("full queue: forwarded %v, %v", forwarded, err)
+	}
+}
+
+// TestHubRecord verifies frames numbered by another replica keep their sequence numbers for
+// resume
+func TestHubRecord(t *testing.T) {
+	StateBaseDir = t.TempDir()
+	h := &SessionWebSocketHub{streams: map[string]*sessionStream{}}
+	h.record(&SessionMessage{SessionID: "s1", Seq: 41})
+	h.record(&SessionMessage{SessionID: "s1", Seq: 42})
+	h.record(&SessionMessage{SessionID: "s1", Seq: 42})
+	st := h.streams["s1"]
+	if st.seq != 42 || len(st.buffer) != 2 {
+		t.Fatalf("seq %d, %d buffered", st.seq, len(st.buffer))
+	}
+	// A frame produced here after ownership moved continues the numbering
+	m := &SessionMessage{SessionID: "s1"}
+	h.sequence(m)
+	if m.Seq != 43 {
+		t.Errorf("seq %d after recorded frames", m.Seq)
+	}
+}
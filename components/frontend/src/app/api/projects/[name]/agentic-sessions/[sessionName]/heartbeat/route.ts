@@ -0,0 +1,24 @@
+import { BACKEND_URL } from '@/lib/config';
+import { buildForwardHeadersAsync } from '@/lib/auth';
+
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ name: string; sessionName: string }> }
+) {
+  try {
+    const { name, sessionName } = await params;
+    const headers = await buildForwardHeadersAsync(request);
+    const response = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agentic-sessions/${encodeURIComponent(sessionName)}/heartbeat`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', ...headers },
+    });
+    if (response.status === 204) {
+      return new Response(null, { status: 204 });
+    }
+    const text = await response.text();
+    return new Response(text, { status: response.status, headers: { 'Content-Type': 'application/json' } });
+  } catch (error) {
+    console.error('Error sending session heartbeat:', error);
+    return Response.json({ error: 'Failed to send session heartbeat' }, { status: 500 });
+  }
+}
@@ -9,7 +9,9 @@ export async function GET(
   try {
     const { name } = await params;
     const headers = await buildForwardHeadersAsync(request);
-    const response = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agentic-sessions`, { headers });
+    // Forward query parameters such as ?thread=
+    const { search } = new URL(request.url);
+    const response = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agentic-sessions${search}`, { headers });
     const text = await response.text();
     return new Response(text, { status: response.status, headers: { 'Content-Type': 'application/json' } });
   } catch (error) {
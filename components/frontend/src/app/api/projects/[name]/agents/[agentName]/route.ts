@@ -0,0 +1,57 @@
+import { BACKEND_URL } from '@/lib/config';
+import { buildForwardHeadersAsync } from '@/lib/auth';
+import { catalogRequestHeaders, forwardCatalogResponse } from '@/lib/catalog-cache';
+
+type Params = { params: Promise<{ name: string; agentName: string }> };
+
+function agentUrl(name: string, agentName: string) {
+  return `${BACKEND_URL}/projects/${encodeURIComponent(name)}/agents/${encodeURIComponent(agentName)}`;
+}
+
+// GET /api/projects/[name]/agents/[agentName] - Get one agent
+export async function GET(request: Request, { params }: Params) {
+  try {
+    const { name, agentName } = await params;
+    const headers = await buildForwardHeadersAsync(request, catalogRequestHeaders(request));
+
+    const response = await fetch(agentUrl(name, agentName), { headers, cache: 'no-store' });
+    return forwardCatalogResponse(response);
+  } catch (error) {
+    console.error('Error fetching agent:', error);
+    return Response.json({ error: 'Failed to fetch agent' }, { status: 500 });
+  }
+}
+
+// PUT /api/projects/[name]/agents/[agentName] - Replace an agent's definition
+export async function PUT(request: Request, { params }: Params) {
+  try {
+    const { name, agentName } = await params;
+    const body = await request.text();
+    const headers = await buildForwardHeadersAsync(request);
+
+    const response = await fetch(agentUrl(name, agentName), { method: 'PUT', headers, body });
+    const data = await response.json().catch(() => ({}));
+    return Response.json(data, { status: response.status });
+  } catch (error) {
+    console.error('Error updating agent:', error);
+    return Response.json({ error: 'Failed to update agent' }, { status: 500 });
+  }
+}
+
+// DELETE /api/projects/[name]/agents/[agentName] - Delete an agent
+export async function DELETE(request: Request, { params }: Params) {
+  try {
+    const { name, agentName } = await params;
+    const headers = await buildForwardHeadersAsync(request);
+
+    const response = await fetch(agentUrl(name, agentName), { method: 'DELETE', headers });
+    if (response.status === 204) {
+      return new Response(null, { status: 204 });
+    }
+    const data = await response.json().catch(() => ({}));
+    return Response.json(data, { status: response.status });
+  } catch (error) {
+    console.error('Error deleting agent:', error);
+    return Response.json({ error: 'Failed to delete agent' }, { status: 500 });
+  }
+}
@@ -0,0 +1,43 @@
+import { BACKEND_URL } from '@/lib/config';
+import { buildForwardHeadersAsync } from '@/lib/auth';
+import { catalogRequestHeaders, forwardCatalogResponse } from '@/lib/catalog-cache';
+
+// GET /api/projects/[name]/agents - List the project's agent personas
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  try {
+    const { name } = await params;
+    const headers = await buildForwardHeadersAsync(request, catalogRequestHeaders(request));
+
+    const response = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agents`, { headers, cache: 'no-store' });
+    return forwardCatalogResponse(response);
+  } catch (error) {
+    console.error('Error listing agents:', error);
+    return Response.json({ error: 'Failed to list agents' }, { status: 500 });
+  }
+}
+
+// POST /api/projects/[name]/agents - Create an agent
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  try {
+    const { name } = await params;
+    const body = await request.text();
+    const headers = await buildForwardHeadersAsync(request);
+
+    const response = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agents`, {
+      method: 'POST',
+      headers,
+      body,
+    });
+    const data = await response.json().catch(() => ({}));
+    return Response.json(data, { status: response.status });
+  } catch (error) {
+    console.error('Error creating agent:', error);
+    return Response.json({ error: 'Failed to create agent' }, { status: 500 });
+  }
+}
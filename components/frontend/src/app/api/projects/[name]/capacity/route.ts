@@ -0,0 +1,20 @@
+import { BACKEND_URL } from '@/lib/config';
+import { buildForwardHeadersAsync } from '@/lib/auth';
+
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  try {
+    const { name } = await params;
+    const headers = await buildForwardHeadersAsync(request);
+    const { search } = new URL(request.url);
+
+    const resp = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/capacity${search}`, { headers });
+    const data = await resp.json().catch(() => ({}));
+    return Response.json(data, { status: resp.status });
+  } catch (error) {
+    console.error('Error estimating capacity:', error);
+    return Response.json({ error: 'Failed to estimate capacity' }, { status: 500 });
+  }
+}
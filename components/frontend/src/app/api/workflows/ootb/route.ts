@@ -1,24 +1,21 @@
 import { BACKEND_URL } from "@/lib/config";
+import { catalogRequestHeaders, forwardCatalogResponse } from "@/lib/catalog-cache";
 
-export async function GET() {
+export async function GET(request: Request) {
   try {
     // No auth required for public OOTB workflows endpoint
     const response = await fetch(`${BACKEND_URL}/workflows/ootb`, {
       method: 'GET',
       headers: {
         "Content-Type": "application/json",
+        ...catalogRequestHeaders(request),
       },
+      // Revalidation is the backend's job; never serve this from Next's fetch cache
+      cache: 'no-store',
     });
 
-    // Forward the response from backend
-    const data = await response.text();
-
-    return new Response(data, {
-      status: response.status,
-      headers: {
-        "Content-Type": "application/json",
-      },
-    });
+    // Forward the response from backend, with its ETag and Cache-Control
+    return forwardCatalogResponse(response);
   } catch (error) {
     console.error("Failed to fetch OOTB workflows:", error);
     return new Response(
@@ -30,4 +27,3 @@ export async function GET() {
     );
   }
 }
-
@@ -0,0 +1,32 @@
+"use client";
+
+import { useEffect } from "react";
+import { sessionsApi } from "@/services/api";
+
+// The backend records at most one view per session every few minutes
+const HEARTBEAT_INTERVAL_MS = 60_000;
+
+/**
+ * Tells the backend someone is watching the session while the page is visible,
+ * so the project's abandoned-session policy leaves it alone
+ */
+export function useSessionHeartbeat(projectName: string, sessionName: string, enabled: boolean) {
+  useEffect(() => {
+    if (!enabled || !projectName || !sessionName) return;
+
+    const beat = () => {
+      if (document.visibilityState !== "visible") return;
+      sessionsApi.sendSessionHeartbeat(projectName, sessionName).catch(() => {
+        // Best effort; the next beat retries
+      });
+    };
+
+    beat();
+    const interval = setInterval(beat, HEARTBEAT_INTERVAL_MS);
+    document.addEventListener("visibilitychange", beat);
+    return () => {
+      clearInterval(interval);
+      document.removeEventListener("visibilitychange", beat);
+    };
+  }, [projectName, sessionName, enabled]);
+}
@@ -33,6 +33,7 @@ import { ArtifactsAccordion } from "./components/accordions/artifacts-accordion"
 import { useGitOperations } from "./hooks/use-git-operations";
 import { useWorkflowManagement } from "./hooks/use-workflow-management";
 import { useFileOperations } from "./hooks/use-file-operations";
+import { useSessionHeartbeat } from "./hooks/use-session-heartbeat";
 import { adaptSessionMessages } from "./lib/message-adapter";
 import type { DirectoryOption, DirectoryRemote } from "./lib/types";
 
@@ -107,6 +108,7 @@ export default function ProjectSessionDetailPage({
   const continueMutation = useContinueSession();
   const sendChatMutation = useSendChatMessage();
   const sendControlMutation = useSendControlMessage();
+  useSessionHeartbeat(projectName, sessionName, session?.status?.phase === "Running");
   
   // Workflow management hook
   const workflowManagement = useWorkflowManagement({
@@ -11,6 +11,12 @@ const models = [
   { value: "claude-haiku-4-5", label: "Claude Haiku 4.5" },
 ];
 
+const contextStrategies = [
+  { value: "summarize", label: "Summarize" },
+  { value: "truncate", label: "Truncate" },
+  { value: "retrieval", label: "Retrieval" },
+];
+
 type ModelConfigurationProps = {
   // eslint-disable-next-line @typescript-eslint/no-explicit-any
   control: Control<any>;
@@ -113,6 +119,55 @@ export function ModelConfiguration({ control }: ModelConfigurationProps) {
           )}
         />
       </div>
+
+      <div className="grid grid-cols-1 md:grid-cols-2 gap-4">
+        <FormField
+          control={control}
+          name="contextStrategy"
+          render={({ field }) => (
+            <FormItem>
+              <FormLabel>Context Strategy</FormLabel>
+              <Select onValueChange={field.onChange} defaultValue={field.value}>
+                <FormControl>
+                  <SelectTrigger>
+                    <SelectValue placeholder="Select a strategy" />
+                  </SelectTrigger>
+                </FormControl>
+                <SelectContent>
+                  {contextStrategies.map((s) => (
+                    <SelectItem key={s.value} value={s.value}>
+                      {s.label}
+                    </SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
+              <FormDescription>How the conversation shrinks at the context limit</FormDescription>
+              <FormMessage />
+            </FormItem>
+          )}
+        />
+
+        <FormField
+          control={control}
+          name="maxContextTokens"
+          render={({ field }) => (
+            <FormItem>
+              <FormLabel>Max Context Tokens</FormLabel>
+              <FormControl>
+                <Input
+                  type="number"
+                  step="10000"
+                  min="0"
+                  {...field}
+                  onChange={(e) => field.onChange(parseInt(e.target.value) || 0)}
+                />
+              </FormControl>
+              <FormDescription>0 uses the model&apos;s full context window</FormDescription>
+              <FormMessage />
+            </FormItem>
+          )}
+        />
+      </div>
     </div>
   );
 }
@@ -3,11 +3,12 @@
 import { useEffect, useState } from "react";
 import { useRouter, useSearchParams } from "next/navigation";
 import Link from "next/link";
-import { Loader2 } from "lucide-react";
+import { AlertTriangle, Loader2 } from "lucide-react";
 import { useForm, useFieldArray } from "react-hook-form";
 import { zodResolver } from "@hookform/resolvers/zod";
 import * as z from "zod";
 
+import { Alert, AlertDescription, AlertTitle } from "@/components/ui/alert";
 import { Button } from "@/components/ui/button";
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
 import { Form, FormControl, FormDescription, FormField, FormItem, FormLabel, FormMessage } from "@/components/ui/form";
@@ -19,7 +20,7 @@ import { Breadcrumbs } from "@/components/breadcrumbs";
 import { RepositoryDialog } from "./repository-dialog";
 import { RepositoryList } from "./repository-list";
 import { ModelConfiguration } from "./model-configuration";
-import { useCreateSession } from "@/services/queries/use-sessions";
+import { useCreateSession, useProjectCapacity } from "@/services/queries/use-sessions";
 
 const formSchema = z
   .object({
@@ -27,6 +28,9 @@ const formSchema = z
     model: z.string().min(1, "Please select a model"),
     temperature: z.number().min(0).max(2),
     maxTokens: z.number().min(100).max(8000),
+    contextStrategy: z.enum(["truncate", "summarize", "retrieval"]).default("summarize"),
+    // 0 leaves compaction to the model's context window
+    maxContextTokens: z.number().refine((n) => n === 0 || n >= 10000, "Use 0 or at least 10000 tokens").default(0),
     timeout: z.number().min(60).max(1800),
     interactive: z.boolean().default(false),
     // Unified multi-repo array
@@ -68,6 +72,7 @@ export default function NewProjectSessionPage({ params }: { params: Promise<{ na
 
   // React Query hooks
   const createSessionMutation = useCreateSession();
+  const { data: capacity } = useProjectCapacity(projectName);
 
   useEffect(() => {
     params.then(({ name }) => setProjectName(name));
@@ -85,6 +90,8 @@ export default function NewProjectSessionPage({ params }: { params: Promise<{ na
       model: "claude-sonnet-4-5",
       temperature: 0.7,
       maxTokens: 4000,
+      contextStrategy: "summarize",
+      maxContextTokens: 0,
       timeout: 300,
       interactive: false,
       autoPushOnComplete: false,
@@ -116,6 +123,9 @@ export default function NewProjectSessionPage({ params }: { params: Promise<{ na
         model: values.model,
         temperature: values.temperature,
         maxTokens: values.maxTokens,
+        ...(values.maxContextTokens
+          ? { contextStrategy: values.contextStrategy, maxContextTokens: values.maxContextTokens }
+          : {}),
       },
       timeout: values.timeout,
       interactive: values.interactive,
@@ -299,6 +309,26 @@ export default function NewProjectSessionPage({ params }: { params: Promise<{ na
 
               {/* Storage paths are managed automatically by the backend/operator */}
 
+              {capacity && !capacity.schedulable && (
+                <Alert variant="warning">
+                  <AlertTriangle />
+                  <AlertTitle>
+                    {!capacity.feasible
+                      ? "This session cannot be scheduled"
+                      : capacity.estimatedWaitSeconds !== undefined
+                        ? `This session will queue for ~${Math.max(1, Math.round(capacity.estimatedWaitSeconds / 60))} minutes`
+                        : "This session will queue before it starts"}
+                  </AlertTitle>
+                  <AlertDescription>
+                    <ul className="list-disc pl-4">
+                      {(capacity.blockers || []).map((b) => (
+                        <li key={b.cause}>{b.message}</li>
+                      ))}
+                    </ul>
+                  </AlertDescription>
+                </Alert>
+              )}
+
               {createSessionMutation.isError && (
                 <div className="bg-red-50 border border-red-200 rounded-md p-3">
                   <p className="text-red-700 text-sm">{createSessionMutation.error?.message || "Failed to create session"}</p>
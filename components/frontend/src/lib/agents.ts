@@ -1,6 +1,7 @@
 import { AgentPersona } from "@/types/agentic-session";
 
-// Agent persona definitions based on the YAML files in claude-runner
+// Built-in persona list, used where a project defines no Agent resources
+// (see services/api/agents.ts for the project's own)
 export const AVAILABLE_AGENTS: AgentPersona[] = [
   {
     persona: "emma-engineering_manager",
@@ -41,6 +41,8 @@ export function buildForwardHeaders(request: Request, extra?: Record<string, str
   const xfUsername = request.headers.get('X-Forwarded-Preferred-Username');
   const xfGroups = request.headers.get('X-Forwarded-Groups');
   const project = request.headers.get('X-OpenShift-Project');
+  // The backend renders error messages in the browser's language
+  const acceptLanguage = request.headers.get('Accept-Language');
   const token = extractAccessToken(request);
 
   if (xfUser) headers['X-Forwarded-User'] = xfUser;
@@ -48,6 +50,7 @@ export function buildForwardHeaders(request: Request, extra?: Record<string, str
   if (xfUsername) headers['X-Forwarded-Preferred-Username'] = xfUsername;
   if (xfGroups) headers['X-Forwarded-Groups'] = xfGroups;
   if (project) headers['X-OpenShift-Project'] = project;
+  if (acceptLanguage) headers['Accept-Language'] = acceptLanguage;
   if (token) headers['X-Forwarded-Access-Token'] = token;
 
   // If still missing identity info, use environment (helpful for local oc login)
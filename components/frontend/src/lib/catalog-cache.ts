@@ -0,0 +1,23 @@
+// Catalog endpoints (OOTB workflows, agents) answer with an ETag and Cache-Control and
+// return 304 to a current If-None-Match. These helpers pass both through the proxy routes
+// so the browser's HTTP cache does the revalidation.
+
+// The browser's If-None-Match, to add to the headers sent to the backend
+export function catalogRequestHeaders(request: Request): Record<string, string> {
+  const ifNoneMatch = request.headers.get('If-None-Match');
+  return ifNoneMatch ? { 'If-None-Match': ifNoneMatch } : {};
+}
+
+// Relay a backend catalog response with its caching headers
+export async function forwardCatalogResponse(response: Response): Promise<Response> {
+  const headers: Record<string, string> = {};
+  for (const name of ['ETag', 'Cache-Control']) {
+    const value = response.headers.get(name);
+    if (value) headers[name] = value;
+  }
+  if (response.status === 304) {
+    return new Response(null, { status: 304, headers });
+  }
+  headers['Content-Type'] = 'application/json';
+  return new Response(await response.text(), { status: response.status, headers });
+}
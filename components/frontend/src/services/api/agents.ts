@@ -0,0 +1,60 @@
+/**
+ * Agents API service
+ * Agent personas of a project, such as the RFE council. Sessions select them by name
+ * in the AGENT_PERSONAS environment variable.
+ */
+
+import { apiClient } from './client';
+import type { Agent, AgentPersona, CreateAgentRequest, UpdateAgentRequest } from '@/types/api';
+
+/**
+ * List the project's agents
+ */
+export async function listAgents(projectName: string): Promise<Agent[]> {
+  const response = await apiClient.get<{ items: Agent[] }>(`/projects/${projectName}/agents`);
+  return response.items || [];
+}
+
+/**
+ * Get one agent
+ */
+export async function getAgent(projectName: string, name: string): Promise<Agent> {
+  return apiClient.get<Agent>(`/projects/${projectName}/agents/${name}`);
+}
+
+/**
+ * Create an agent (project admins)
+ */
+export async function createAgent(projectName: string, data: CreateAgentRequest): Promise<Agent> {
+  return apiClient.post<Agent, CreateAgentRequest>(`/projects/${projectName}/agents`, data);
+}
+
+/**
+ * Replace an agent's definition (project admins)
+ */
+export async function updateAgent(
+  projectName: string,
+  name: string,
+  data: UpdateAgentRequest
+): Promise<Agent> {
+  return apiClient.put<Agent, UpdateAgentRequest>(`/projects/${projectName}/agents/${name}`, data);
+}
+
+/**
+ * Delete an agent (project admins)
+ */
+export async function deleteAgent(projectName: string, name: string): Promise<void> {
+  await apiClient.delete<void>(`/projects/${projectName}/agents/${name}`);
+}
+
+/**
+ * Agents in the shape of the persona pickers
+ */
+export function toAgentPersonas(agents: Agent[]): AgentPersona[] {
+  return agents.map((agent) => ({
+    persona: agent.name,
+    name: agent.displayName || agent.name,
+    role: agent.role || '',
+    description: agent.description,
+  }));
+}
@@ -8,6 +8,7 @@ export * as clusterApi from './cluster';
 export * as projectsApi from './projects';
 export * as sessionsApi from './sessions';
 export * as rfeApi from './rfe';
+export * as agentsApi from './agents';
 export * as githubApi from './github';
 export * as keysApi from './keys';
 export * as repoApi from './repo';
@@ -16,6 +16,9 @@ import type {
   CloneAgenticSessionResponse,
   Message,
   GetSessionMessagesResponse,
+  CapacityRequest,
+  CapacityEstimate,
+  SessionTimeline,
 } from '@/types/api';
 
 /**
@@ -32,6 +35,20 @@ export async function listSessions(projectName: string): Promise<AgenticSession[
   return response.items || [];
 }
 
+/**
+ * List the thread a session belongs to, oldest first
+ */
+export async function listSessionThread(projectName: string, sessionName: string): Promise<AgenticSession[]> {
+  const response = await apiClient.get<ListAgenticSessionsResponse | AgenticSession[]>(
+    `/projects/${projectName}/agentic-sessions`,
+    { params: { thread: sessionName } }
+  );
+  if (Array.isArray(response)) {
+    return response;
+  }
+  return response.items || [];
+}
+
 /**
  * Get a single session
  */
@@ -65,6 +82,34 @@ export async function createSession(
   return await getSession(projectName, response.name);
 }
 
+/**
+ * Estimate whether a session with these requirements would start now
+ */
+export async function getProjectCapacity(
+  projectName: string,
+  request: CapacityRequest = {}
+): Promise<CapacityEstimate> {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(request)) {
+    if (value !== undefined && value !== '') params.set(key, String(value));
+  }
+  const query = params.toString();
+  return apiClient.get<CapacityEstimate>(
+    `/projects/${projectName}/capacity${query ? `?${query}` : ''}`
+  );
+}
+
+/**
+ * Record that the session is being viewed; the project's abandoned-session
+ * policy acts on sessions nobody has viewed for a while
+ */
+export async function sendSessionHeartbeat(
+  projectName: string,
+  sessionName: string
+): Promise<void> {
+  await apiClient.post<void>(`/projects/${projectName}/agentic-sessions/${sessionName}/heartbeat`);
+}
+
 /**
  * Stop a running session
  */
@@ -184,6 +229,16 @@ export async function getSessionK8sResources(
   return apiClient.get(`/projects/${projectName}/agentic-sessions/${sessionName}/k8s-resources`);
 }
 
+/**
+ * Get the session's conditions, events, runner steps and user actions, oldest first
+ */
+export async function getSessionTimeline(
+  projectName: string,
+  sessionName: string
+): Promise<SessionTimeline> {
+  return apiClient.get<SessionTimeline>(`/projects/${projectName}/agentic-sessions/${sessionName}/timeline`);
+}
+
 /**
  * Spawn temporary content pod for workspace access
  */
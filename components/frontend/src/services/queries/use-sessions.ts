@@ -9,6 +9,7 @@ import type {
   CreateAgenticSessionRequest,
   StopAgenticSessionRequest,
   CloneAgenticSessionRequest,
+  CapacityRequest,
 } from '@/types/api';
 
 /**
@@ -56,6 +57,19 @@ export function useSession(projectName: string, sessionName: string) {
   });
 }
 
+/**
+ * Hook to check whether a new session would start now or queue
+ */
+export function useProjectCapacity(projectName: string, request: CapacityRequest = {}) {
+  return useQuery({
+    queryKey: [...sessionKeys.all, 'capacity', projectName, request] as const,
+    queryFn: () => sessionsApi.getProjectCapacity(projectName, request),
+    enabled: !!projectName,
+    staleTime: 30 * 1000,
+    refetchInterval: 60 * 1000,
+  });
+}
+
 /**
  * Hook to fetch session messages
  */
@@ -285,6 +299,28 @@ export function useSessionK8sResources(projectName: string, sessionName: string)
   });
 }
 
+/**
+ * Hook to fetch a session's timeline
+ */
+export function useSessionTimeline(projectName: string, sessionName: string) {
+  return useQuery({
+    queryKey: [...sessionKeys.detail(projectName, sessionName), 'timeline'] as const,
+    queryFn: () => sessionsApi.getSessionTimeline(projectName, sessionName),
+    enabled: !!projectName && !!sessionName,
+  });
+}
+
+/**
+ * Hook to fetch the thread a session belongs to, oldest first
+ */
+export function useSessionThread(projectName: string, sessionName: string) {
+  return useQuery({
+    queryKey: [...sessionKeys.list(projectName), 'thread', sessionName] as const,
+    queryFn: () => sessionsApi.listSessionThread(projectName, sessionName),
+    enabled: !!projectName && !!sessionName,
+  });
+}
+
 /**
  * Hook to continue a session (restarts the existing session)
  */
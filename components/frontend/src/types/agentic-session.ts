@@ -1,9 +1,13 @@
 export type AgenticSessionPhase = "Pending" | "Creating" | "Running" | "Completed" | "Failed" | "Stopped" | "Error";
 
+export type ContextStrategy = "truncate" | "summarize" | "retrieval";
+
 export type LLMSettings = {
 	model: string;
 	temperature: number;
 	maxTokens: number;
+	contextStrategy?: ContextStrategy;
+	maxContextTokens?: number;
 };
 
 // Generic repo type used by RFE workflows (retains optional clonePath)
@@ -43,6 +47,8 @@ export type AgenticSessionSpec = {
 		branch: string;
 		path?: string;
 	};
+	// Session this one follows up on, in the same thread
+	parentSession?: string;
 };
 
 // -----------------------------
@@ -143,7 +149,47 @@ export type AgenticSessionStatus = {
 	session_id?: string;
 	total_cost_usd?: number | null;
 	usage?: Record<string, unknown> | null;
-	result?: string | null;
+	result?: SessionResult | null;
+};
+
+export type SessionOutcome = "Succeeded" | "Partial" | "NoChanges" | "Failed" | "Interrupted";
+
+// Typed status.result (mirrors SessionResult in components/pkg/apis/vteam/v1alpha1)
+export type SessionResult = {
+	outcome: SessionOutcome;
+	summary?: string;
+	prURLs?: string[];
+	filesChanged?: number;
+	testsRun?: number;
+	followUps?: string[];
+	preview?: PreviewArtifact;
+};
+
+// Container image a session built from its change, runnable as a Preview
+export type PreviewArtifact = {
+	image: string;
+	port?: number;
+};
+
+export type PreviewPhase = "Pending" | "Ready" | "Failed";
+
+// A session's Preview (GET /agentic-sessions/:name/preview)
+export type Preview = {
+	name: string;
+	creationTimestamp?: string;
+	spec: {
+		sessionName: string;
+		image: string;
+		port?: number;
+		ttl?: string;
+	};
+	status?: {
+		observedGeneration?: number;
+		phase?: PreviewPhase;
+		message?: string;
+		url?: string;
+		expiresAt?: string;
+	};
 };
 
 export type AgenticSession = {
@@ -157,6 +203,36 @@ export type AgenticSession = {
 	};
 	spec: AgenticSessionSpec;
 	status?: AgenticSessionStatus;
+	// Live runner pod usage, present on session detail while Running
+	resourceUsage?: ResourceUsage;
+};
+
+export type ResourceUsage = {
+	pod: string;
+	source: string;
+	timestamp: string;
+	cpuMillicores: number;
+	memoryBytes: number;
+	cpuRequestMillicores?: number;
+	cpuLimitMillicores?: number;
+	memoryRequestBytes?: number;
+	memoryLimitBytes?: number;
+	// Resources at or above 90% of their limit ("cpu", "memory")
+	pressure?: string[];
+	containers?: { name: string; cpuMillicores: number; memoryBytes: number }[];
+};
+
+export type ResourceUsageSample = {
+	timestamp: string;
+	cpuMillicores: number;
+	memoryBytes: number;
+};
+
+export type ResourceUsageSeries = {
+	pod: string;
+	source: string;
+	current?: ResourceUsage;
+	samples: ResourceUsageSample[];
 };
 
 export type CreateAgenticSessionRequest = {
@@ -166,6 +242,8 @@ export type CreateAgenticSessionRequest = {
 	timeout?: number;
 	project?: string;
 	parent_session_id?: string;
+	// Follow up on a session; its thread's context is prepended to the prompt
+	parentSession?: string;
   	environmentVariables?: Record<string, string>;
 	interactive?: boolean;
 	workspacePath?: string;
@@ -28,6 +28,23 @@ export type AgentPersona = {
   description: string;
 };
 
+// Agent resource of a project (GET /projects/:name/agents); its name is the persona
+export type Agent = {
+  name: string;
+  creationTimestamp?: string;
+  displayName?: string;
+  role?: string;
+  description: string;
+  prompt: string;
+  capabilities?: string[];
+  tools?: string[];
+  model?: 'inherit' | 'sonnet' | 'opus' | 'haiku';
+};
+
+export type CreateAgentRequest = Omit<Agent, 'creationTimestamp'>;
+
+export type UpdateAgentRequest = Omit<Agent, 'name' | 'creationTimestamp'>;
+
 export type ArtifactFile = {
   path: string;
   name: string;
@@ -29,10 +29,14 @@ export type AgenticSessionPhase =
   | 'Stopped'
   | 'Error';
 
+export type ContextStrategy = 'truncate' | 'summarize' | 'retrieval';
+
 export type LLMSettings = {
   model: string;
   temperature: number;
   maxTokens: number;
+  contextStrategy?: ContextStrategy;
+  maxContextTokens?: number;
 };
 
 export type SessionRepoInput = {
@@ -67,6 +71,7 @@ export type AgenticSessionSpec = {
     branch: string;
     path?: string;
   };
+  parentSession?: string;
 };
 
 export type AgenticSessionStatus = {
@@ -82,7 +87,32 @@ export type AgenticSessionStatus = {
   session_id?: string;
   total_cost_usd?: number | null;
   usage?: Record<string, unknown> | null;
-  result?: string | null;
+  result?: SessionResult | null;
+  rateLimit?: RateLimitStatus | null;
+  runAttempt?: number;
+};
+
+// Provider rate limits the runner last reported (mirrors RateLimitStatus in components/pkg/apis/vteam/v1alpha1)
+export type RateLimitStatus = {
+  limited: boolean;
+  retryAfter?: string;
+  requestsRemaining?: number;
+  tokensRemaining?: number;
+  resetAt?: string;
+  throttled?: number;
+  observedAt: string;
+};
+
+export type SessionOutcome = 'Succeeded' | 'Partial' | 'NoChanges' | 'Failed' | 'Interrupted';
+
+// Typed status.result (mirrors SessionResult in components/pkg/apis/vteam/v1alpha1)
+export type SessionResult = {
+  outcome: SessionOutcome;
+  summary?: string;
+  prURLs?: string[];
+  filesChanged?: number;
+  testsRun?: number;
+  followUps?: string[];
 };
 
 export type AgenticSession = {
@@ -105,6 +135,7 @@ export type CreateAgenticSessionRequest = {
   timeout?: number;
   project?: string;
   parent_session_id?: string;
+  parentSession?: string;
   environmentVariables?: Record<string, string>;
   interactive?: boolean;
   workspacePath?: string;
@@ -116,6 +147,8 @@ export type CreateAgenticSessionRequest = {
   resourceOverrides?: ResourceOverrides;
   labels?: Record<string, string>;
   annotations?: Record<string, string>;
+  /** Issue URL or reference, available to the project system prompt as {{.Issue}} */
+  issue?: string;
 };
 
 export type CreateAgenticSessionResponse = {
@@ -241,3 +274,51 @@ export type Message =
 export type GetSessionMessagesResponse = {
   messages: Message[];
 };
+
+export type SchedulingHint = {
+  cause: 'quota' | 'maintenance' | 'resourceQuota' | 'queue' | 'capacity' | 'hardware';
+  message?: string;
+  used?: number;
+  limit?: number;
+  queuePosition?: number;
+  estimatedWaitSeconds?: number;
+};
+
+export type CapacityRequest = {
+  cpu?: string;
+  memory?: string;
+  gpus?: number;
+  architecture?: string;
+  runtimeClassName?: string;
+};
+
+export type CapacityEstimate = {
+  schedulable: boolean;
+  feasible: boolean;
+  matchingNodes: number;
+  availableNodes: number;
+  queuedSessions: number;
+  blockers?: SchedulingHint[];
+  estimatedWaitSeconds?: number;
+};
+
+export type TimelineEntryType = 'lifecycle' | 'condition' | 'event' | 'milestone' | 'action';
+
+export type TimelineEntry = {
+  time: string;
+  type: TimelineEntryType;
+  reason?: string;
+  message?: string;
+  status?: string;
+  object?: string;
+  user?: string;
+  endTime?: string;
+  durationSeconds?: number;
+  count?: number;
+};
+
+export type SessionTimeline = {
+  session: string;
+  entries: TimelineEntry[];
+  warnings?: string[];
+};
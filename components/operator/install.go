@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/install"
+)
+
+// runInstall implements `operator install`: apply the platform namespace, CRDs, RBAC and the
+// default ProjectSettings of the given projects from the manifests built into the binary, or
+// print them with --dry-run. It returns the process exit code.
+func runInstall(args []string) int {
+	namespace := os.Getenv("NAMESPACE")
+	if namespace == "" {
+		namespace = "ambient-code"
+	}
+	flags := flag.NewFlagSet("install", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "Print the manifests as YAML instead of applying them; needs no cluster access")
+	flags.StringVar(&namespace, "namespace", namespace, "Namespace the platform runs in; the service accounts and their bindings use it (default from NAMESPACE)")
+	projects := &repeatedFlag{}
+	flags.Var(projects, "project", "Create the default ProjectSettings in this existing project namespace; repeatable")
+	_ = flags.Parse(args)
+
+	objs, err := install.Objects(namespace, projects.values)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "install: %v\n", err)
+		return 1
+	}
+	if *dryRun {
+		if err := install.Print(os.Stdout, objs); err != nil {
+			fmt.Fprintf(os.Stderr, "install: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := config.InitK8sClients(); err != nil {
+		fmt.Fprintf(os.Stderr, "install: %v\n", err)
+		return 1
+	}
+	if err := install.Apply(context.Background(), config.DynamicClient, objs, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "install: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+// Package assets embeds the manifests needed to bootstrap the platform, so an install can be
+// reproduced from the operator binary alone (operator install).
+//
+// manifests/ is generated from components/manifests/base with go generate: the Namespace,
+// and the CRDs and RBAC listed in their kustomization.yaml. Edit the originals, then
+// regenerate; a test fails while the copies differ. The default ProjectSettings lives here.
+package assets
+
+//go:generate go run gen.go
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+//go:embed manifests
+var manifests embed.FS
+
+//go:embed projectsettings-default.yaml
+var defaultProjectSettings []byte
+
+// Manifests is the embedded copy of components/manifests/base: namespace.yaml, crds/ and rbac/
+func Manifests() fs.FS {
+	sub, err := fs.Sub(manifests, "manifests")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Decode reads every object of a multi-document YAML or JSON stream; empty documents are skipped
+func Decode(r io.Reader) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	var out []*unstructured.Unstructured
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if err == io.EOF {
+				return out, nil
+			}
+			return nil, err
+		}
+		if len(obj) > 0 {
+			out = append(out, &unstructured.Unstructured{Object: obj})
+		}
+	}
+}
+
+// DecodeDir reads the objects of the YAML files in dir of fsys, in file name order
+func DecodeDir(fsys fs.FS, dir string) ([]*unstructured.Unstructured, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []*unstructured.Unstructured
+	for _, e := range entries {
+		if e.IsDir() || !(strings.HasSuffix(e.Name(), ".yaml") || strings.HasSuffix(e.Name(), ".yml")) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		objs, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", e.Name(), err)
+		}
+		out = append(out, objs...)
+	}
+	return out, nil
+}
+
+// DefaultProjectSettings is the ProjectSettings a new project namespace starts with
+func DefaultProjectSettings(namespace string) (*unstructured.Unstructured, error) {
+	objs, err := Decode(bytes.NewReader(defaultProjectSettings))
+	if err != nil || len(objs) != 1 {
+		return nil, fmt.Errorf("invalid embedded default ProjectSettings: %v", err)
+	}
+	objs[0].SetNamespace(namespace)
+	return objs[0], nil
+}
@@ -0,0 +1,47 @@
+package assets
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestManifestsCurrent verifies the embedded copies match components/manifests/base, so a
+// manifest change without go generate fails here instead of shipping stale CRDs
+func TestManifestsCurrent(t *testing.T) {
+	embedded := Manifests()
+	count := 0
+	err := fs.WalkDir(embedded, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		count++
+		got, _ := fs.ReadFile(embedded, name)
+		want, err := os.ReadFile(filepath.Join("../../../manifests/base", name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from components/manifests/base; run go generate ./internal/assets", name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A new CRD file only reaches the binary once it is listed in the kustomization and generated
+	source, _ := filepath.Glob("../../../manifests/base/crds/*-crd.yaml")
+	copies, _ := fs.Glob(embedded, "crds/*.yaml")
+	if len(copies) != len(source) {
+		t.Errorf("%d embedded CRD files for %d in components/manifests/base/crds; run go generate ./internal/assets", len(copies), len(source))
+	}
+	if count == 0 {
+		t.Fatal("no manifests embedded")
+	}
+
+	ps, err := DefaultProjectSettings("team-a")
+	if err != nil || ps.GetKind() != "ProjectSettings" || ps.GetNamespace() != "team-a" {
+		t.Errorf("DefaultProjectSettings = %v, %v", ps, err)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build ignore
+
+// gen copies the manifests the operator embeds from components/manifests/base: the Namespace,
+// and the CRDs and RBAC listed in their kustomization.yaml. Run with go generate.
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const source = "../../../manifests/base"
+
+func main() {
+	if err := os.RemoveAll("manifests"); err != nil {
+		log.Fatal(err)
+	}
+	copyFile("namespace.yaml")
+	for _, dir := range []string{"crds", "rbac"} {
+		data, err := os.ReadFile(filepath.Join(source, dir, "kustomization.yaml"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		var kustomization struct {
+			Resources []string `json:"resources"`
+		}
+		if err := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(&kustomization); err != nil {
+			log.Fatalf("%s/kustomization.yaml: %v", dir, err)
+		}
+		for _, name := range kustomization.Resources {
+			copyFile(filepath.Join(dir, name))
+		}
+	}
+}
+
+func copyFile(name string) {
+	data, err := os.ReadFile(filepath.Join(source, name))
+	if err != nil {
+		log.Fatal(err)
+	}
+	target := filepath.Join("manifests", name)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(target, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -4,7 +4,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"ambient-code-pkg/client/clientset/versioned"
+	"ambient-code-pkg/runtimeconfig"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -16,6 +21,7 @@ import (
 var (
 	K8sClient     kubernetes.Interface
 	DynamicClient dynamic.Interface
+	VteamClient   versioned.Interface
 )
 
 // Config holds the operator configuration
@@ -25,8 +31,80 @@ type Config struct {
 	AmbientCodeRunnerImage string
 	ContentServiceImage    string
 	ImagePullPolicy        corev1.PullPolicy
+	// Taint keys tolerated and node label preferred by preemptible sessions
+	PreemptibleTolerationKeys []string
+	PreemptibleNodeLabel      string
+	// Cluster-wide cap on running runner Jobs (0 = unlimited); default for --max-concurrent-jobs
+	MaxConcurrentJobs int
+	// CRD manifests shipped in the image, and whether the operator may install/upgrade them
+	CRDDir     string
+	ManageCRDs bool
+	// Exit at startup when a required CRD is missing instead of waiting for it (REQUIRE_CRDS=true)
+	RequireCRDs bool
+	// Runner callbacks to the backend's internal endpoints use mutual TLS (RUNNER_MTLS=true)
+	RunnerMTLS bool
+	// How spec.workspaceFrom warms a new workspace: "copy" (init container) or "volume-clone" (CSI)
+	WorkspaceCloneStrategy string
+	// Namespaces served and skipped by this install (names or label selectors); defaults for
+	// --watch-namespaces and --exclude-namespaces
+	WatchNamespaces   []string
+	ExcludeNamespaces []string
+	// Listen address of the pprof/expvar/diagnostics endpoints (empty = off); default for
+	// --diagnostics-addr
+	DiagnosticsAddr string
+	// Listen address of the AgenticSession validating webhook (empty = off) and the directory
+	// holding its tls.crt and tls.key; defaults for --webhook-addr and --webhook-cert-dir
+	WebhookAddr    string
+	WebhookCertDir string
+	// Submit runner Jobs to Kueue (KUEUE_ENABLED=true) instead of the --max-concurrent-jobs
+	// limit, using ProjectSettings spec.kueue.queueName or this default LocalQueue
+	KueueEnabled      bool
+	KueueDefaultQueue string
+	// Render ProjectSettings spec.networkPolicy into a per-session NetworkPolicy
+	// (RUNNER_EGRESS_POLICY=true). RunnerEgressHosts are always reachable, e.g. the model API.
+	RunnerEgressPolicy bool
+	RunnerEgressHosts  []string
+	// Keep the session pod images cached on runner nodes with a prepull DaemonSet
+	// (IMAGE_PREPULL=true) on nodes matching ImagePrepullNodeSelector ("key=value,...";
+	// empty = every node). ImagePrepullExtraImages are pulled too, e.g. workflow images.
+	ImagePrepull             bool
+	ImagePrepullNodeSelector string
+	ImagePrepullExtraImages  []string
+	// Cluster autoscaler signalling for runner pods waiting for nodes: annotate them so a
+	// scale-up starts without the autoscaler's pod delay (SCALE_UP_ANNOTATE=true), and run up
+	// to ScaleUpBalloons placeholder pods shaped like them (SCALE_UP_BALLOONS, 0 = off)
+	ScaleUpAnnotate bool
+	ScaleUpBalloons int
+	// How Previews are exposed: "route" (OpenShift, default), "ingress" or "none" (Service
+	// only). PreviewDomain gives each preview the host <preview>-<namespace>.<domain>; it is
+	// required for ingress, and without it OpenShift generates route hosts.
+	PreviewExpose       string
+	PreviewDomain       string
+	PreviewIngressClass string
+	// Hold new sessions that share a provider credential while one of its sessions reports a
+	// rate limit (RATE_LIMIT_SCHEDULING=true), and run at most RateLimitMaxSessionsPerKey
+	// at once per credential (0 = unlimited). Reports without a retry time hold for
+	// RateLimitBackoff. Both follow the runtime config's operator.rateLimit.
+	RateLimitScheduling        bool
+	RateLimitMaxSessionsPerKey int
+	RateLimitBackoff           time.Duration
 }
 
+// Preview exposure modes (PREVIEW_EXPOSE)
+const (
+	PreviewExposeRoute   = "route"
+	PreviewExposeIngress = "ingress"
+	PreviewExposeNone    = "none"
+)
+
+// Workspace clone strategies for spec.workspaceFrom (WORKSPACE_CLONE_STRATEGY)
+const (
+	// WorkspaceCloneCopy copies the source workspace in an init container; works on any storage
+	WorkspaceCloneCopy = "copy"
+	// WorkspaceCloneVolume provisions the new PVC as a CSI volume clone of the source PVC
+	WorkspaceCloneVolume = "volume-clone"
+)
+
 // InitK8sClients initializes the Kubernetes clients
 func InitK8sClients() error {
 	var config *rest.Config
@@ -57,6 +135,12 @@ func InitK8sClients() error {
 		return fmt.Errorf("failed to create dynamic client: %v", err)
 	}
 
+	// Create typed client for vteam custom resources
+	VteamClient, err = versioned.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create vteam client: %v", err)
+	}
+
 	return nil
 }
 
@@ -93,11 +177,120 @@ func LoadConfig() *Config {
 	}
 	imagePullPolicy := corev1.PullPolicy(imagePullPolicyStr)
 
+	// Spot node taints/labels differ per cloud; defaults cover GKE, AKS and Karpenter
+	preemptibleTolerationKeys := []string{}
+	tolerationKeysStr := os.Getenv("PREEMPTIBLE_TOLERATION_KEYS")
+	if tolerationKeysStr == "" {
+		tolerationKeysStr = "cloud.google.com/gke-spot,kubernetes.azure.com/scalesetpriority,karpenter.sh/capacity-type"
+	}
+	for _, k := range strings.Split(tolerationKeysStr, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			preemptibleTolerationKeys = append(preemptibleTolerationKeys, k)
+		}
+	}
+	preemptibleNodeLabel := os.Getenv("PREEMPTIBLE_NODE_LABEL")
+	if preemptibleNodeLabel == "" {
+		preemptibleNodeLabel = "karpenter.sh/capacity-type=spot"
+	}
+
+	maxConcurrentJobs := 0
+	if v := strings.TrimSpace(os.Getenv("MAX_CONCURRENT_JOBS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxConcurrentJobs = n
+		}
+	}
+
+	workspaceCloneStrategy := WorkspaceCloneCopy
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("WORKSPACE_CLONE_STRATEGY")), WorkspaceCloneVolume) {
+		workspaceCloneStrategy = WorkspaceCloneVolume
+	}
+
+	// Values are separated by ';' since label selectors contain commas
+	splitValues := func(v string) []string {
+		values := []string{}
+		for _, value := range strings.Split(v, ";") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+
+	scaleUpBalloons := 0
+	if v := strings.TrimSpace(os.Getenv("SCALE_UP_BALLOONS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			scaleUpBalloons = n
+		}
+	}
+
+	rateLimitMaxSessionsPerKey := 0
+	if v := strings.TrimSpace(os.Getenv("RATE_LIMIT_MAX_SESSIONS_PER_KEY")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			rateLimitMaxSessionsPerKey = n
+		}
+	}
+	rateLimitBackoff := time.Minute
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("RATE_LIMIT_BACKOFF"))); err == nil && d > 0 {
+		rateLimitBackoff = d
+	}
+	// The runtime config changes these without a restart
+	if o := runtimeconfig.Current().Operator; o != nil && o.RateLimit != nil {
+		if o.RateLimit.MaxSessionsPerKey != nil {
+			rateLimitMaxSessionsPerKey = *o.RateLimit.MaxSessionsPerKey
+		}
+		if o.RateLimit.Backoff != nil {
+			rateLimitBackoff = o.RateLimit.Backoff.Duration
+		}
+	}
+
+	previewExpose := strings.ToLower(strings.TrimSpace(os.Getenv("PREVIEW_EXPOSE")))
+	if previewExpose != PreviewExposeIngress && previewExpose != PreviewExposeNone {
+		previewExpose = PreviewExposeRoute
+	}
+
+	crdDir := os.Getenv("CRD_DIR")
+	if crdDir == "" {
+		crdDir = "/app/crds"
+	}
+	webhookCertDir := strings.TrimSpace(os.Getenv("WEBHOOK_CERT_DIR"))
+	if webhookCertDir == "" {
+		webhookCertDir = "/etc/webhook/certs"
+	}
+
 	return &Config{
 		Namespace:              namespace,
 		BackendNamespace:       backendNamespace,
 		AmbientCodeRunnerImage: ambientCodeRunnerImage,
 		ContentServiceImage:    contentServiceImage,
 		ImagePullPolicy:        imagePullPolicy,
+
+		PreemptibleTolerationKeys:  preemptibleTolerationKeys,
+		PreemptibleNodeLabel:       preemptibleNodeLabel,
+		MaxConcurrentJobs:          maxConcurrentJobs,
+		CRDDir:                     crdDir,
+		ManageCRDs:                 strings.EqualFold(strings.TrimSpace(os.Getenv("MANAGE_CRDS")), "true"),
+		RequireCRDs:                strings.EqualFold(strings.TrimSpace(os.Getenv("REQUIRE_CRDS")), "true"),
+		RunnerMTLS:                 strings.EqualFold(strings.TrimSpace(os.Getenv("RUNNER_MTLS")), "true"),
+		WorkspaceCloneStrategy:     workspaceCloneStrategy,
+		WatchNamespaces:            splitValues(os.Getenv("WATCH_NAMESPACES")),
+		ExcludeNamespaces:          splitValues(os.Getenv("EXCLUDE_NAMESPACES")),
+		DiagnosticsAddr:            strings.TrimSpace(os.Getenv("DIAGNOSTICS_ADDR")),
+		WebhookAddr:                strings.TrimSpace(os.Getenv("WEBHOOK_ADDR")),
+		WebhookCertDir:             webhookCertDir,
+		KueueEnabled:               strings.EqualFold(strings.TrimSpace(os.Getenv("KUEUE_ENABLED")), "true"),
+		KueueDefaultQueue:          strings.TrimSpace(os.Getenv("KUEUE_DEFAULT_QUEUE")),
+		RunnerEgressPolicy:         strings.EqualFold(strings.TrimSpace(os.Getenv("RUNNER_EGRESS_POLICY")), "true"),
+		RunnerEgressHosts:          splitValues(os.Getenv("RUNNER_EGRESS_HOSTS")),
+		ImagePrepull:               strings.EqualFold(strings.TrimSpace(os.Getenv("IMAGE_PREPULL")), "true"),
+		ImagePrepullNodeSelector:   strings.TrimSpace(os.Getenv("IMAGE_PREPULL_NODE_SELECTOR")),
+		ImagePrepullExtraImages:    splitValues(os.Getenv("IMAGE_PREPULL_EXTRA_IMAGES")),
+		ScaleUpAnnotate:            strings.EqualFold(strings.TrimSpace(os.Getenv("SCALE_UP_ANNOTATE")), "true"),
+		ScaleUpBalloons:            scaleUpBalloons,
+		PreviewExpose:              previewExpose,
+		PreviewDomain:              strings.TrimPrefix(strings.TrimSpace(os.Getenv("PREVIEW_DOMAIN")), "."),
+		PreviewIngressClass:        strings.TrimSpace(os.Getenv("PREVIEW_INGRESS_CLASS")),
+		RateLimitScheduling:        strings.EqualFold(strings.TrimSpace(os.Getenv("RATE_LIMIT_SCHEDULING")), "true"),
+		RateLimitMaxSessionsPerKey: rateLimitMaxSessionsPerKey,
+		RateLimitBackoff:           rateLimitBackoff,
 	}
 }
@@ -0,0 +1,218 @@
+// Package crds keeps the cluster's CustomResourceDefinitions in step with the operator.
+//
+// At startup the operator compares the schema revision of each installed CRD with the CRD
+// manifests shipped in its image. Older CRDs are upgraded (when the operator is allowed to
+// manage CRDs) before any watcher reads objects, and registered data migrations then rewrite
+// objects stored under an older schema. Bump the ambient-code.io/schema-revision annotation
+// in the manifest with every schema change, then run go generate ./internal/assets so the
+// copy embedded in the binary follows.
+package crds
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ambient-code-operator/internal/assets"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// RevisionAnnotation holds the schema revision of a CRD manifest; a missing annotation is revision 0
+const RevisionAnnotation = "ambient-code.io/schema-revision"
+
+// establishTimeout bounds how long an applied CRD may take to be served
+const establishTimeout = 60 * time.Second
+
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// Sync checks every CRD manifest in dir against the cluster. With apply, missing or older CRDs
+// are created or updated and waited on until established; without it an outdated CRD is only
+// reported. CRDs newer than the manifests (an operator rollback) are left alone. A missing dir
+// (e.g. running the operator outside its image) falls back to the CRDs embedded in the binary.
+func Sync(ctx context.Context, client dynamic.Interface, dir string, apply bool) error {
+	desired, err := Load(dir)
+	if os.IsNotExist(err) {
+		log.Printf("CRD manifests not found in %s, using the CRDs built into the operator", dir)
+		desired, err = Embedded()
+	}
+	if err != nil {
+		return err
+	}
+	return SyncObjects(ctx, client, desired, apply)
+}
+
+// SyncObjects is Sync for CRDs already loaded
+func SyncObjects(ctx context.Context, client dynamic.Interface, desired []*unstructured.Unstructured, apply bool) error {
+	for _, crd := range desired {
+		if err := syncCRD(ctx, client, crd, apply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load reads the CustomResourceDefinitions from the YAML files in dir, sorted by name
+func Load(dir string) ([]*unstructured.Unstructured, error) {
+	return LoadFS(os.DirFS(dir))
+}
+
+// LoadFS reads the CustomResourceDefinitions from the YAML files at the root of fsys, sorted by name
+func LoadFS(fsys fs.FS) ([]*unstructured.Unstructured, error) {
+	objs, err := assets.DecodeDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var out []*unstructured.Unstructured
+	for _, obj := range objs {
+		if obj.GetKind() == "CustomResourceDefinition" {
+			out = append(out, obj)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GetName() < out[j].GetName() })
+	return out, nil
+}
+
+// Embedded reads the CustomResourceDefinitions compiled into the operator binary
+func Embedded() ([]*unstructured.Unstructured, error) {
+	sub, err := fs.Sub(assets.Manifests(), "crds")
+	if err != nil {
+		return nil, err
+	}
+	return LoadFS(sub)
+}
+
+// Revision returns the schema revision recorded on a CRD
+func Revision(crd *unstructured.Unstructured) int {
+	n, err := strconv.Atoi(crd.GetAnnotations()[RevisionAnnotation])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func syncCRD(ctx context.Context, client dynamic.Interface, desired *unstructured.Unstructured, apply bool) error {
+	name := desired.GetName()
+	if problems := StructuralProblems(desired); len(problems) > 0 {
+		return fmt.Errorf("CRD %s does not have a structural schema: %s", name, strings.Join(problems, "; "))
+	}
+	want := Revision(desired)
+
+	current, err := client.Resource(crdResource).Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if !apply {
+			return fmt.Errorf("CRD %s is not installed; apply components/manifests/base/crds or set MANAGE_CRDS=true", name)
+		}
+		log.Printf("Installing CRD %s at schema revision %d", name, want)
+		if _, err := client.Resource(crdResource).Create(ctx, desired, v1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create CRD %s: %w", name, err)
+		}
+		return waitEstablished(ctx, client, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get CRD %s: %w", name, err)
+	}
+
+	have := Revision(current)
+	switch {
+	case have == want:
+		log.Printf("CRD %s is up to date (schema revision %d)", name, have)
+		return nil
+	case have > want:
+		log.Printf("WARNING: CRD %s is at schema revision %d, newer than this operator's %d; leaving it unchanged", name, have, want)
+		return nil
+	case !apply:
+		log.Printf("WARNING: CRD %s is at schema revision %d, expected %d; apply components/manifests/base/crds or set MANAGE_CRDS=true", name, have, want)
+		return nil
+	}
+
+	log.Printf("Upgrading CRD %s from schema revision %d to %d", name, have, want)
+	updated := desired.DeepCopy()
+	updated.SetResourceVersion(current.GetResourceVersion())
+	if _, err := client.Resource(crdResource).Update(ctx, updated, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update CRD %s: %w", name, err)
+	}
+	return waitEstablished(ctx, client, name)
+}
+
+// waitEstablished waits until the API server serves the CRD (Established=True)
+func waitEstablished(ctx context.Context, client dynamic.Interface, name string) error {
+	err := wait.PollUntilContextTimeout(ctx, time.Second, establishTimeout, true, func(ctx context.Context) (bool, error) {
+		crd, err := client.Resource(crdResource).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+		for _, item := range conditions {
+			cond, _ := item.(map[string]interface{})
+			if cond["type"] == "Established" && cond["status"] == "True" {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("CRD %s was not established: %w", name, err)
+	}
+	log.Printf("CRD %s established", name)
+	return nil
+}
+
+// StructuralProblems lists the places where a CRD's schemas are not structural: every node
+// needs a type unless it preserves unknown fields or is int-or-string. The API server rejects
+// such CRDs; checking first gives a clear startup error instead of a half-applied upgrade.
+func StructuralProblems(crd *unstructured.Unstructured) []string {
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if len(versions) == 0 {
+		return []string{"spec.versions is empty"}
+	}
+	var problems []string
+	for _, item := range versions {
+		version, _ := item.(map[string]interface{})
+		name, _ := version["name"].(string)
+		root, found, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+		if !found {
+			problems = append(problems, fmt.Sprintf("version %s has no openAPIV3Schema", name))
+			continue
+		}
+		problems = append(problems, structuralProblems(root, name)...)
+	}
+	return problems
+}
+
+func structuralProblems(node map[string]interface{}, path string) []string {
+	var problems []string
+	if node["type"] == nil && node["x-kubernetes-preserve-unknown-fields"] != true && node["x-kubernetes-int-or-string"] != true {
+		problems = append(problems, path+": missing type")
+	}
+	if props, ok := node["properties"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if child, ok := props[name].(map[string]interface{}); ok {
+				problems = append(problems, structuralProblems(child, path+"."+name)...)
+			}
+		}
+	}
+	if items, ok := node["items"].(map[string]interface{}); ok {
+		problems = append(problems, structuralProblems(items, path+"[]")...)
+	}
+	if additional, ok := node["additionalProperties"].(map[string]interface{}); ok {
+		problems = append(problems, structuralProblems(additional, path+"{}")...)
+	}
+	return problems
+}
@@ -0,0 +1,175 @@
+package crds
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// shippedCRDDir is the manifests directory copied into the operator image
+const shippedCRDDir = "../../../manifests/base/crds"
+
+func newDynamicClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdResource:                       "CustomResourceDefinitionList",
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, objs...)
+}
+
+func testCRD(revision string, established bool) *unstructured.Unstructured {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name":        "widgets.example.com",
+			"annotations": map[string]interface{}{RevisionAnnotation: revision},
+		},
+		"spec": map[string]interface{}{
+			"versions": []interface{}{map[string]interface{}{
+				"name": "v1",
+				"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"spec": map[string]interface{}{"type": "object"}},
+				}},
+			}},
+		},
+	}}
+	if established {
+		// The fake client does not run the API server's CRD controller
+		crd.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Established", "status": "True"}},
+		}
+	}
+	return crd
+}
+
+// TestShippedCRDs verifies the manifests in the image are structural and versioned
+func TestShippedCRDs(t *testing.T) {
+	crds, err := Load(shippedCRDDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(crds) != 6 {
+		t.Fatalf("Expected 6 CRDs, got %d", len(crds))
+	}
+	for _, crd := range crds {
+		if problems := StructuralProblems(crd); len(problems) > 0 {
+			t.Errorf("%s is not structural: %v", crd.GetName(), problems)
+		}
+		if Revision(crd) < 1 {
+			t.Errorf("%s has no %s annotation", crd.GetName(), RevisionAnnotation)
+		}
+	}
+}
+
+// TestStructuralProblems verifies untyped nodes are reported unless they preserve unknown fields
+func TestStructuralProblems(t *testing.T) {
+	crd := testCRD("1", false)
+	versions := crd.Object["spec"].(map[string]interface{})["versions"].([]interface{})
+	root := versions[0].(map[string]interface{})["schema"].(map[string]interface{})["openAPIV3Schema"].(map[string]interface{})
+	root["properties"].(map[string]interface{})["status"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"usage":  map[string]interface{}{"x-kubernetes-preserve-unknown-fields": true},
+			"result": map[string]interface{}{"description": "untyped"},
+		},
+	}
+
+	problems := StructuralProblems(crd)
+	if len(problems) != 1 || problems[0] != "v1.status.result: missing type" {
+		t.Errorf("Unexpected problems: %v", problems)
+	}
+}
+
+// TestSyncCRD verifies older CRDs are upgraded, newer ones left alone and nothing is applied without apply
+func TestSyncCRD(t *testing.T) {
+	ctx := context.Background()
+
+	client := newDynamicClient(testCRD("1", true))
+	if err := syncCRD(ctx, client, testCRD("2", true), false); err != nil {
+		t.Fatalf("check-only sync failed: %v", err)
+	}
+	got, _ := client.Resource(crdResource).Get(ctx, "widgets.example.com", v1.GetOptions{})
+	if Revision(got) != 1 {
+		t.Errorf("Expected CRD untouched without apply, got revision %d", Revision(got))
+	}
+
+	if err := syncCRD(ctx, client, testCRD("2", true), true); err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	got, _ = client.Resource(crdResource).Get(ctx, "widgets.example.com", v1.GetOptions{})
+	if Revision(got) != 2 {
+		t.Errorf("Expected revision 2 after upgrade, got %d", Revision(got))
+	}
+
+	if err := syncCRD(ctx, client, testCRD("1", true), true); err != nil {
+		t.Fatalf("downgrade check failed: %v", err)
+	}
+	got, _ = client.Resource(crdResource).Get(ctx, "widgets.example.com", v1.GetOptions{})
+	if Revision(got) != 2 {
+		t.Errorf("Expected newer CRD to be kept, got revision %d", Revision(got))
+	}
+
+	if err := syncCRD(ctx, newDynamicClient(), testCRD("1", true), false); err == nil {
+		t.Error("Expected an error for a missing CRD without apply")
+	}
+}
+
+// TestRunMigrations verifies string results are converted once and progress is recorded
+func TestRunMigrations(t *testing.T) {
+	ctx := context.Background()
+	legacy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "old", "namespace": "project-a"},
+		"status":     map[string]interface{}{"phase": "Failed", "is_error": true, "result": "it broke"},
+	}}
+	typed := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "new", "namespace": "project-a"},
+		"status":     map[string]interface{}{"phase": "Completed", "result": map[string]interface{}{"outcome": "Succeeded"}},
+	}}
+	client := newDynamicClient(legacy, typed)
+	config.DynamicClient = client
+	k8s := fake.NewSimpleClientset()
+
+	RunMigrations(ctx, client, k8s, "ambient-code", Migrations)
+
+	got, _ := client.Resource(types.GetAgenticSessionResource()).Namespace("project-a").Get(ctx, "old", v1.GetOptions{})
+	result, _, _ := unstructured.NestedMap(got.Object, "status", "result")
+	if result["outcome"] != "Failed" || result["summary"] != "it broke" {
+		t.Errorf("Expected typed Failed result, got %v", result)
+	}
+
+	cm, err := k8s.CoreV1().ConfigMaps("ambient-code").Get(ctx, migrationStateConfigMap, v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected migration state ConfigMap: %v", err)
+	}
+	var state MigrationState
+	_ = json.Unmarshal([]byte(cm.Data["0001-typed-session-result"]), &state)
+	if state.State != migrationComplete || state.Total != 2 || state.Migrated != 1 {
+		t.Errorf("Unexpected migration state: %+v", state)
+	}
+
+	// A completed migration is not run again
+	seen := false
+	RunMigrations(ctx, client, k8s, "ambient-code", []Migration{{
+		Name:     "0001-typed-session-result",
+		Resource: types.GetAgenticSessionResource(),
+		Migrate:  func(*unstructured.Unstructured) (bool, error) { seen = true; return false, nil },
+	}})
+	if seen {
+		t.Error("Expected completed migration to be skipped")
+	}
+}
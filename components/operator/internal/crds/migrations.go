@@ -0,0 +1,256 @@
+package crds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// migrationStateConfigMap records the progress of every data migration in the operator namespace
+const migrationStateConfigMap = "ambient-operator-migrations"
+
+// progressEvery is how many objects are processed between progress reports
+const progressEvery = 100
+
+// Migration rewrites stored objects of one resource that predate a schema change. Migrations
+// run once, in order, after the CRDs are synced and before the operator starts watching; one
+// that fails for some objects is retried on the next start, so Migrate must be idempotent.
+type Migration struct {
+	// Name identifies the migration in the state ConfigMap; never rename a shipped migration
+	Name     string
+	Resource schema.GroupVersionResource
+	// Subresource is "status" when Migrate only changes .status
+	Subresource string
+	// Migrate updates obj in place and reports whether it changed
+	Migrate func(obj *unstructured.Unstructured) (bool, error)
+}
+
+// MigrationState is the progress of one migration, stored as JSON in the state ConfigMap
+type MigrationState struct {
+	State       string     `json:"state"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Migrated    int        `json:"migrated"`
+	Failed      int        `json:"failed"`
+	StartedAt   time.Time  `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+const (
+	migrationRunning  = "Running"
+	migrationComplete = "Complete"
+	migrationFailed   = "Failed"
+)
+
+// Migrations are the data migrations shipped with this operator, oldest first
+var Migrations = []Migration{
+	{
+		// Sessions written before the typed result stored the runner's output as a plain string
+		Name:        "0001-typed-session-result",
+		Resource:    types.GetAgenticSessionResource(),
+		Subresource: "status",
+		Migrate:     migrateStringResult,
+	},
+}
+
+// RunMigrations applies every migration not yet recorded as complete. Failures are logged and
+// leave the migration to be retried on the next start; they never block the operator.
+func RunMigrations(ctx context.Context, client dynamic.Interface, k8s kubernetes.Interface, namespace string, migrations []Migration) {
+	done, err := loadMigrationStates(ctx, k8s, namespace)
+	if err != nil {
+		log.Printf("Skipping data migrations: %v", err)
+		return
+	}
+	for _, m := range migrations {
+		if done[m.Name].State == migrationComplete {
+			continue
+		}
+		if err := runMigration(ctx, client, k8s, namespace, m); err != nil {
+			log.Printf("Data migration %s did not complete: %v", m.Name, err)
+		}
+	}
+}
+
+func runMigration(ctx context.Context, client dynamic.Interface, k8s kubernetes.Interface, namespace string, m Migration) error {
+	list, err := client.Resource(m.Resource).Namespace("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", m.Resource.Resource, err)
+	}
+	state := MigrationState{State: migrationRunning, Total: len(list.Items), StartedAt: time.Now().UTC()}
+	log.Printf("Data migration %s: checking %d %s", m.Name, state.Total, m.Resource.Resource)
+	saveMigrationState(ctx, k8s, namespace, m.Name, state)
+
+	for i := range list.Items {
+		changed, err := migrateObject(ctx, client, m, &list.Items[i])
+		state.Processed++
+		switch {
+		case err != nil:
+			state.Failed++
+			log.Printf("Data migration %s: failed on %s/%s: %v", m.Name, list.Items[i].GetNamespace(), list.Items[i].GetName(), err)
+		case changed:
+			state.Migrated++
+		}
+		if state.Processed%progressEvery == 0 {
+			log.Printf("Data migration %s: %d/%d processed, %d migrated, %d failed", m.Name, state.Processed, state.Total, state.Migrated, state.Failed)
+			saveMigrationState(ctx, k8s, namespace, m.Name, state)
+		}
+	}
+
+	now := time.Now().UTC()
+	state.CompletedAt = &now
+	state.State = migrationComplete
+	if state.Failed > 0 {
+		state.State = migrationFailed
+	}
+	saveMigrationState(ctx, k8s, namespace, m.Name, state)
+	log.Printf("Data migration %s %s: %d/%d processed, %d migrated, %d failed", m.Name, state.State, state.Processed, state.Total, state.Migrated, state.Failed)
+	if state.Failed > 0 {
+		return fmt.Errorf("%d objects failed", state.Failed)
+	}
+	return nil
+}
+
+// migrateObject applies the migration to one object, re-reading it on conflict. Status
+// migrations are written through statusupdater like every other operator status write.
+func migrateObject(ctx context.Context, client dynamic.Interface, m Migration, obj *unstructured.Unstructured) (bool, error) {
+	if m.Subresource == "status" {
+		return migrateStatus(ctx, m, obj)
+	}
+	changed := false
+	first := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		res := client.Resource(m.Resource).Namespace(obj.GetNamespace())
+		if !first {
+			fresh, err := res.Get(ctx, obj.GetName(), v1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			obj = fresh
+		}
+		first = false
+		var err error
+		if changed, err = m.Migrate(obj); err != nil || !changed {
+			return err
+		}
+		_, err = res.Update(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return changed, err
+}
+
+// migrateStatus runs a status migration on the object's fresh status
+func migrateStatus(ctx context.Context, m Migration, obj *unstructured.Unstructured) (bool, error) {
+	changed := false
+	err := statusupdater.Mutate(ctx, m.Resource, obj.GetNamespace(), obj.GetName(), func(status map[string]interface{}) error {
+		// Migrate works on a copy so status can be replaced with the result
+		migrated := obj.DeepCopy()
+		migrated.Object["status"] = runtime.DeepCopyJSON(status)
+		var err error
+		if changed, err = m.Migrate(migrated); err != nil {
+			return err
+		}
+		if !changed {
+			return statusupdater.ErrNoChange
+		}
+		updated, _ := migrated.Object["status"].(map[string]interface{})
+		for k := range status {
+			delete(status, k)
+		}
+		for k, v := range updated {
+			status[k] = v
+		}
+		return nil
+	})
+	return changed, err
+}
+
+func loadMigrationStates(ctx context.Context, k8s kubernetes.Interface, namespace string) (map[string]MigrationState, error) {
+	states := map[string]MigrationState{}
+	cm, err := k8s.CoreV1().ConfigMaps(namespace).Get(ctx, migrationStateConfigMap, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", migrationStateConfigMap, err)
+	}
+	for name, raw := range cm.Data {
+		var s MigrationState
+		if err := json.Unmarshal([]byte(raw), &s); err == nil {
+			states[name] = s
+		}
+	}
+	return states, nil
+}
+
+// saveMigrationState records progress; failures are only logged since the migration itself
+// does not depend on it
+func saveMigrationState(ctx context.Context, k8s kubernetes.Interface, namespace, name string, state MigrationState) {
+	data, _ := json.Marshal(state)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := k8s.CoreV1().ConfigMaps(namespace).Get(ctx, migrationStateConfigMap, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: v1.ObjectMeta{Name: migrationStateConfigMap, Namespace: namespace},
+				Data:       map[string]string{name: string(data)},
+			}
+			_, err = k8s.CoreV1().ConfigMaps(namespace).Create(ctx, cm, v1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[name] = string(data)
+		_, err = k8s.CoreV1().ConfigMaps(namespace).Update(ctx, cm, v1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to record progress of data migration %s: %v", name, err)
+	}
+}
+
+// migrateStringResult converts a plain-string status.result into the typed SessionResult
+func migrateStringResult(obj *unstructured.Unstructured) (bool, error) {
+	raw, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "status", "result")
+	if !found {
+		return false, nil
+	}
+	if _, ok := raw.(string); !ok {
+		return false, nil
+	}
+	isError, _, _ := unstructured.NestedBool(obj.Object, "status", "is_error")
+	result, err := apiv1alpha1.ParseSessionResult(raw, isError)
+	if err != nil {
+		return false, err
+	}
+	typed, err := result.ToUnstructured()
+	if err != nil {
+		return false, err
+	}
+	if err := unstructured.SetNestedField(obj.Object, typed, "status", "result"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
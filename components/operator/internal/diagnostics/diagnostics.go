@@ -0,0 +1,286 @@
+// Package diagnostics serves the operator's optional debug endpoints: pprof profiles, expvar
+// and a page describing what the operator is doing right now (reconciles in flight, watch
+// activity, in-memory state and the last error seen per namespace). It is meant for
+// investigating memory or goroutine growth in long-running operators and is off by default.
+package diagnostics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxErrorNamespaces bounds how many namespaces keep a last error, so the tracker itself
+// cannot grow without limit; the oldest entry is dropped first
+const maxErrorNamespaces = 500
+
+// Reconcile is a reconcile that has started and not yet finished
+type Reconcile struct {
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Started   time.Time `json:"started"`
+}
+
+// LastError is the most recent failed reconcile in a namespace
+type LastError struct {
+	Kind  string    `json:"kind"`
+	Name  string    `json:"name"`
+	Error string    `json:"error"`
+	At    time.Time `json:"at"`
+}
+
+// WatchStats counts the events one watch loop has received
+type WatchStats struct {
+	Events    int64     `json:"events"`
+	Restarts  int64     `json:"restarts"`
+	LastEvent time.Time `json:"lastEvent"`
+}
+
+// Snapshot is the state shown on the diagnostics page and published as the "operator" expvar
+type Snapshot struct {
+	Uptime        string                `json:"uptime"`
+	Goroutines    int                   `json:"goroutines"`
+	HeapAllocMiB  float64               `json:"heapAllocMiB"`
+	HeapObjects   uint64                `json:"heapObjects"`
+	NumGC         uint32                `json:"numGC"`
+	Reconciles    int64                 `json:"reconciles"`
+	Failures      int64                 `json:"failures"`
+	Active        []Reconcile           `json:"active"`
+	Watches       map[string]WatchStats `json:"watches"`
+	Gauges        map[string]int        `json:"gauges"`
+	LastErrors    map[string]LastError  `json:"lastErrors"`
+	SnapshotTaken time.Time             `json:"snapshotTaken"`
+}
+
+// Tracker records reconcile and watch activity. The zero value is not usable; use NewTracker.
+type Tracker struct {
+	mu         sync.Mutex
+	started    time.Time
+	nextID     uint64
+	active     map[uint64]Reconcile
+	reconciles int64
+	failures   int64
+	watches    map[string]*WatchStats
+	gauges     map[string]func() int
+	lastErrors map[string]LastError
+}
+
+// NewTracker returns an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		started:    time.Now(),
+		active:     map[uint64]Reconcile{},
+		watches:    map[string]*WatchStats{},
+		gauges:     map[string]func() int{},
+		lastErrors: map[string]LastError{},
+	}
+}
+
+// Default is the tracker the handlers report to and the endpoints serve
+var Default = NewTracker()
+
+// BeginReconcile marks a reconcile as in flight; call the returned function with its result
+func (t *Tracker) BeginReconcile(kind, namespace, name string) func(error) {
+	t.mu.Lock()
+	t.nextID++
+	id := t.nextID
+	t.active[id] = Reconcile{Kind: kind, Namespace: namespace, Name: name, Started: time.Now()}
+	t.mu.Unlock()
+
+	return func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.active, id)
+		t.reconciles++
+		if err == nil {
+			return
+		}
+		t.failures++
+		if _, ok := t.lastErrors[namespace]; !ok && len(t.lastErrors) >= maxErrorNamespaces {
+			t.dropOldestError()
+		}
+		t.lastErrors[namespace] = LastError{Kind: kind, Name: name, Error: err.Error(), At: time.Now()}
+	}
+}
+
+func (t *Tracker) dropOldestError() {
+	oldest := ""
+	for ns, e := range t.lastErrors {
+		if oldest == "" || e.At.Before(t.lastErrors[oldest].At) {
+			oldest = ns
+		}
+	}
+	delete(t.lastErrors, oldest)
+}
+
+// WatchEvent counts an event received by the named watch loop
+func (t *Tracker) WatchEvent(watch string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.watchStats(watch)
+	s.Events++
+	s.LastEvent = time.Now()
+}
+
+// WatchRestarted counts a reconnect of the named watch loop
+func (t *Tracker) WatchRestarted(watch string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.watchStats(watch).Restarts++
+}
+
+func (t *Tracker) watchStats(watch string) *WatchStats {
+	s, ok := t.watches[watch]
+	if !ok {
+		s = &WatchStats{}
+		t.watches[watch] = s
+	}
+	return s
+}
+
+// RegisterGauge reports the size of some in-memory state, such as running job monitors
+func (t *Tracker) RegisterGauge(name string, value func() int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.gauges[name] = value
+}
+
+// Snapshot returns the current state, active reconciles oldest first
+func (t *Tracker) Snapshot() Snapshot {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	t.mu.Lock()
+	s := Snapshot{
+		Uptime:        time.Since(t.started).Round(time.Second).String(),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAllocMiB:  float64(mem.HeapAlloc) / (1 << 20),
+		HeapObjects:   mem.HeapObjects,
+		NumGC:         mem.NumGC,
+		Reconciles:    t.reconciles,
+		Failures:      t.failures,
+		Active:        make([]Reconcile, 0, len(t.active)),
+		Watches:       make(map[string]WatchStats, len(t.watches)),
+		Gauges:        make(map[string]int, len(t.gauges)),
+		LastErrors:    make(map[string]LastError, len(t.lastErrors)),
+		SnapshotTaken: time.Now(),
+	}
+	for _, r := range t.active {
+		s.Active = append(s.Active, r)
+	}
+	for name, w := range t.watches {
+		s.Watches[name] = *w
+	}
+	for ns, e := range t.lastErrors {
+		s.LastErrors[ns] = e
+	}
+	gauges := make(map[string]func() int, len(t.gauges))
+	for name, fn := range t.gauges {
+		gauges[name] = fn
+	}
+	t.mu.Unlock()
+
+	// Gauges may take their own locks; call them outside ours
+	for name, fn := range gauges {
+		s.Gauges[name] = fn()
+	}
+	sort.Slice(s.Active, func(i, j int) bool { return s.Active[i].Started.Before(s.Active[j].Started) })
+	return s
+}
+
+// Handler serves /debug/pprof/, /debug/vars (expvar) and /debug/diagnostics for the tracker
+func (t *Tracker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/diagnostics", t.serveDiagnostics)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, "/debug/diagnostics", http.StatusFound)
+	})
+	return mux
+}
+
+// serveDiagnostics renders the snapshot as plain text, or JSON with ?format=json
+func (t *Tracker) serveDiagnostics(w http.ResponseWriter, r *http.Request) {
+	s := t.Snapshot()
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "uptime %s  goroutines %d  heap %.1f MiB (%d objects)  gc cycles %d\n",
+		s.Uptime, s.Goroutines, s.HeapAllocMiB, s.HeapObjects, s.NumGC)
+	fmt.Fprintf(&b, "reconciles %d  failed %d\n", s.Reconciles, s.Failures)
+
+	fmt.Fprintf(&b, "\nActive reconciles (%d)\n", len(s.Active))
+	for _, a := range s.Active {
+		fmt.Fprintf(&b, "  %-20s %s/%s  running %s\n", a.Kind, a.Namespace, a.Name, s.SnapshotTaken.Sub(a.Started).Round(time.Millisecond))
+	}
+
+	b.WriteString("\nWatches\n")
+	for _, name := range sortedKeys(s.Watches) {
+		ws := s.Watches[name]
+		last := "never"
+		if !ws.LastEvent.IsZero() {
+			last = s.SnapshotTaken.Sub(ws.LastEvent).Round(time.Second).String() + " ago"
+		}
+		fmt.Fprintf(&b, "  %-20s events %d  restarts %d  last event %s\n", name, ws.Events, ws.Restarts, last)
+	}
+
+	b.WriteString("\nIn-memory state\n")
+	for _, name := range sortedKeys(s.Gauges) {
+		fmt.Fprintf(&b, "  %-20s %d\n", name, s.Gauges[name])
+	}
+
+	fmt.Fprintf(&b, "\nLast error per namespace (%d)\n", len(s.LastErrors))
+	for _, ns := range sortedKeys(s.LastErrors) {
+		e := s.LastErrors[ns]
+		fmt.Fprintf(&b, "  %s  %s %s/%s: %s\n", e.At.UTC().Format(time.RFC3339), e.Kind, ns, e.Name, e.Error)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var publishOnce sync.Once
+
+// Serve publishes the default tracker as the "operator" expvar and serves the debug endpoints
+// on addr. Blocks; logs and returns if the listener fails.
+func Serve(addr string) {
+	publishOnce.Do(func() {
+		expvar.Publish("operator", expvar.Func(func() any { return Default.Snapshot() }))
+	})
+	log.Printf("Serving diagnostics (pprof, expvar) on %s", addr)
+	server := &http.Server{Addr: addr, Handler: Default.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		log.Printf("Diagnostics server stopped: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTrackerReconciles verifies in-flight reconciles and the last error per namespace
+func TestTrackerReconciles(t *testing.T) {
+	tr := NewTracker()
+	doneA := tr.BeginReconcile("AgenticSession", "team-a", "s1")
+	doneB := tr.BeginReconcile("AgenticSession", "team-b", "s2")
+
+	s := tr.Snapshot()
+	if len(s.Active) != 2 || s.Active[0].Name != "s1" {
+		t.Fatalf("active = %+v, want s1 then s2", s.Active)
+	}
+
+	doneA(errors.New("job create failed"))
+	doneB(nil)
+	s = tr.Snapshot()
+	if len(s.Active) != 0 || s.Reconciles != 2 || s.Failures != 1 {
+		t.Fatalf("snapshot = %+v, want 2 reconciles, 1 failure, none active", s)
+	}
+	if e := s.LastErrors["team-a"]; e.Name != "s1" || e.Error != "job create failed" {
+		t.Errorf("last error for team-a = %+v", e)
+	}
+	if _, ok := s.LastErrors["team-b"]; ok {
+		t.Errorf("team-b has no failed reconcile but has a last error")
+	}
+}
+
+// TestTrackerBoundsErrors verifies the last-error map does not grow past its limit
+func TestTrackerBoundsErrors(t *testing.T) {
+	tr := NewTracker()
+	for i := 0; i < maxErrorNamespaces+10; i++ {
+		tr.BeginReconcile("ProjectSettings", fmt.Sprintf("ns-%d", i), "settings")(errors.New("boom"))
+	}
+	s := tr.Snapshot()
+	if len(s.LastErrors) != maxErrorNamespaces {
+		t.Fatalf("kept %d namespaces, want %d", len(s.LastErrors), maxErrorNamespaces)
+	}
+	if _, ok := s.LastErrors[fmt.Sprintf("ns-%d", maxErrorNamespaces+9)]; !ok {
+		t.Errorf("newest error was dropped")
+	}
+}
+
+// TestDiagnosticsHandler verifies the text and JSON pages and that pprof is served
+func TestDiagnosticsHandler(t *testing.T) {
+	tr := NewTracker()
+	tr.WatchEvent("AgenticSession")
+	tr.WatchRestarted("AgenticSession")
+	tr.RegisterGauge("jobMonitors", func() int { return 3 })
+	tr.BeginReconcile("RunnerPod", "team-a", "pod-1")(errors.New("patch conflict"))
+	h := tr.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/diagnostics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"AgenticSession", "restarts 1", "jobMonitors", "patch conflict"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("diagnostics page missing %q:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/diagnostics?format=json", nil))
+	var s Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if s.Gauges["jobMonitors"] != 3 || s.Watches["AgenticSession"].Events != 1 {
+		t.Errorf("snapshot = %+v", s)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("pprof index returned %d", rec.Code)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// creationSourceAnnotation is set by the backend: "api" on sessions it created, "external"
+	// once it has validated a session created with kubectl, GitOps or by the operator
+	creationSourceAnnotation = "ambient-code.io/creation-source"
+	// awaitingAdoptionReason is the Queued condition reason of sessions the backend has not
+	// validated yet
+	awaitingAdoptionReason = "AwaitingValidation"
+)
+
+// sessionAdoptionHold returns why the session may not run yet, or "" once the backend has
+// created or adopted it. Without the hold a session applied with kubectl would start before
+// the backend checked its spec and the project's quotas.
+func sessionAdoptionHold(session *unstructured.Unstructured) string {
+	if session.GetAnnotations()[creationSourceAnnotation] != "" {
+		return ""
+	}
+	return "Waiting for the backend to validate the session"
+}
+
+// markSessionAwaitingAdoption holds a Pending session until the backend adopts it; its
+// annotation patch reprocesses the session, and RequeueQueuedSessions retries it
+func markSessionAwaitingAdoption(session *unstructured.Unstructured, msg string) error {
+	if queuedReason(session) == awaitingAdoptionReason {
+		return nil
+	}
+	return setQueuedCondition(session, v1.ConditionTrue, awaitingAdoptionReason, msg, msg)
+}
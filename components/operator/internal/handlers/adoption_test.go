@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestHandleAgenticSessionEvent_AwaitsAdoption verifies a session applied with kubectl is held
+// until the backend adopts it, and no Job is created for it
+func TestHandleAgenticSessionEvent_AwaitsAdoption(t *testing.T) {
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": "team"},
+		"spec":       map[string]interface{}{"prompt": "hi"},
+		"status":     map[string]interface{}{"phase": "Pending"},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, session)
+	config.K8sClient = fake.NewSimpleClientset()
+
+	if err := handleAgenticSessionEvent(session); err != nil {
+		t.Fatal(err)
+	}
+	held, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("team").Get(context.Background(), "s1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason := queuedReason(held); reason != awaitingAdoptionReason {
+		t.Errorf("Queued reason %q, want %q", reason, awaitingAdoptionReason)
+	}
+	jobs, _ := config.K8sClient.BatchV1().Jobs("team").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 0 {
+		t.Errorf("%d Jobs created for a session the backend has not validated", len(jobs.Items))
+	}
+
+	held.SetAnnotations(map[string]string{creationSourceAnnotation: "external"})
+	if sessionAdoptionHold(held) != "" {
+		t.Error("adopted session still held")
+	}
+}
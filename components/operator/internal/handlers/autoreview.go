@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+// Annotations linking an implementation session and the review session spawned for it
+const (
+	// reviewOfAnnotation on a review session names the session whose pull requests it reviews
+	reviewOfAnnotation = "vteam.ambient-code/review-of"
+	// reviewSessionAnnotation on the reviewed session names its review session
+	reviewSessionAnnotation = "vteam.ambient-code/review-session"
+	// reviewPRAnnotation on a review session lists the reviewed pull requests, comma-separated
+	reviewPRAnnotation = "vteam.ambient-code/review-pull-requests"
+)
+
+const defaultReviewPromptTemplate = `Review the pull request {{.PullRequestURL}} opened by session {{.Session}}.
+{{- if gt (len .PullRequestURLs) 1}} The session also opened: {{range $i, $u := .PullRequestURLs}}{{if $i}} {{$u}}{{end}}{{end}}.{{end}}
+Check correctness, test coverage, security and consistency with the surrounding code. Report concrete, actionable findings with file and line references, most important first.
+{{- if .Summary}}
+
+The implementing session summarized its work as:
+{{.Summary}}{{end}}`
+
+// reviewPromptData are the variables available to spec.autoReview.promptTemplate
+type reviewPromptData struct {
+	PullRequestURL  string
+	PullRequestURLs []string
+	Session         string
+	Summary         string
+}
+
+// reviewSpecFields are copied from the implementation session so the review runs against the
+// same repos, model and identity
+var reviewSpecFields = []string{
+	"repos", "mainRepoIndex", "userContext", "llmSettings", "timeout", "preemptible", "costLimit",
+}
+
+// maybeSpawnReviewSession creates the review session for a completed session that opened pull
+// requests, when the project enables spec.autoReview. It is idempotent: the review session
+// name is derived from the source and recorded on it.
+func maybeSpawnReviewSession(ctx context.Context, session *unstructured.Unstructured) error {
+	annotations := session.GetAnnotations()
+	if annotations[reviewOfAnnotation] != "" || annotations[reviewSessionAnnotation] != "" {
+		return nil
+	}
+	prURLs, _, _ := unstructured.NestedStringSlice(session.Object, "status", "result", "prURLs")
+	if len(prURLs) == 0 {
+		return nil
+	}
+	namespace := session.GetNamespace()
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("read ProjectSettings: %w", err)
+	}
+	policy := ps.Spec.AutoReview
+	if policy == nil || !policy.Enabled {
+		return nil
+	}
+
+	review, err := buildReviewSession(session, prURLs, policy)
+	if err != nil {
+		return err
+	}
+	// The review needs the keys the project requires on sessions, which only the reviewed session has
+	ps.Spec.ObjectMetadata.For(session.GetLabels(), session.GetAnnotations()).Apply(review)
+	gvr := types.GetAgenticSessionResource()
+	if _, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Create(ctx, review, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("create review session: %w", err)
+	}
+	log.Printf("Started review session %s/%s for %s (%s)", namespace, review.GetName(), session.GetName(), strings.Join(prURLs, ", "))
+
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]string{reviewSessionAnnotation: review.GetName()}},
+	})
+	if _, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Patch(ctx, session.GetName(), ktypes.MergePatchType, patch, v1.PatchOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("link review session: %w", err)
+	}
+	return nil
+}
+
+// buildReviewSession derives the review session from the implementation session
+func buildReviewSession(session *unstructured.Unstructured, prURLs []string, policy *apiv1alpha1.AutoReview) (*unstructured.Unstructured, error) {
+	name := session.GetName()
+	summary, _, _ := unstructured.NestedString(session.Object, "status", "result", "summary")
+	prompt, err := renderReviewPrompt(policy.PromptTemplate, reviewPromptData{
+		PullRequestURL:  prURLs[0],
+		PullRequestURLs: prURLs,
+		Session:         name,
+		Summary:         summary,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sourceSpec, _, _ := unstructured.NestedMap(session.Object, "spec")
+	spec := map[string]interface{}{}
+	for _, field := range reviewSpecFields {
+		if v, ok := sourceSpec[field]; ok {
+			spec[field] = runtime.DeepCopyJSONValue(v)
+		}
+	}
+	displayName, _ := sourceSpec["displayName"].(string)
+	if displayName == "" {
+		displayName = name
+	}
+	spec["displayName"] = "Review: " + displayName
+	spec["prompt"] = prompt
+	spec["interactive"] = false
+	spec["autoPushOnComplete"] = false
+	if policy.Workflow != nil && policy.Workflow.GitURL != "" {
+		workflow, err := runtime.DefaultUnstructuredConverter.ToUnstructured(policy.Workflow)
+		if err != nil {
+			return nil, err
+		}
+		spec["activeWorkflow"] = workflow
+	}
+
+	review := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata": map[string]interface{}{
+			"name":      reviewSessionName(name),
+			"namespace": session.GetNamespace(),
+			"annotations": map[string]interface{}{
+				reviewOfAnnotation: name,
+				reviewPRAnnotation: strings.Join(prURLs, ","),
+			},
+		},
+		"spec": spec,
+	}}
+	return review, nil
+}
+
+func renderReviewPrompt(tmpl string, data reviewPromptData) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = defaultReviewPromptTemplate
+	}
+	t, err := template.New("review").Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid autoReview.promptTemplate: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render autoReview.promptTemplate: %w", err)
+	}
+	return b.String(), nil
+}
+
+// reviewSessionName is "<session>-review", shortened with a hash so Job names and labels
+// derived from it stay within 63 characters
+func reviewSessionName(session string) string {
+	const suffix = "-review"
+	const maxLen = 52
+	if len(session)+len(suffix) <= maxLen {
+		return session + suffix
+	}
+	sum := sha256.Sum256([]byte(session))
+	hash := hex.EncodeToString(sum[:])[:8]
+	prefix := strings.TrimRight(session[:maxLen-len(suffix)-len(hash)-1], "-")
+	return prefix + "-" + hash + suffix
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestMaybeSpawnReviewSession verifies a completed session with a pull request gets exactly one
+// linked review session carrying over its repos and model
+func TestMaybeSpawnReviewSession(t *testing.T) {
+	ctx := context.Background()
+	src := testSession("impl", "Completed", map[string]interface{}{
+		"displayName":        "Fix login",
+		"prompt":             "fix the login bug",
+		"repos":              []interface{}{map[string]interface{}{"input": map[string]interface{}{"url": "https://github.com/org/app"}}},
+		"llmSettings":        map[string]interface{}{"model": "claude-sonnet-4"},
+		"autoPushOnComplete": true,
+		"workspaceFrom":      "older",
+	})
+	_ = unstructured.SetNestedField(src.Object, map[string]interface{}{
+		"outcome": "Succeeded",
+		"summary": "Fixed the redirect loop",
+		"prURLs":  []interface{}{"https://github.com/org/app/pull/7"},
+	}, "status", "result")
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, src)
+	// Created through the client: the fake's object tracker guesses the wrong plural otherwise
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	if _, err := config.VteamClient.VteamV1alpha1().ProjectSettings("proj").Create(ctx, &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "proj"},
+		Spec: apiv1alpha1.ProjectSettingsSpec{AutoReview: &apiv1alpha1.AutoReview{
+			Enabled:        true,
+			PromptTemplate: "Review {{.PullRequestURL}} from {{.Session}}: {{.Summary}}",
+			Workflow:       &apiv1alpha1.WorkflowSelection{GitURL: "https://github.com/org/review-workflow"},
+		}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create ProjectSettings: %v", err)
+	}
+	sessions := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj")
+
+	if err := maybeSpawnReviewSession(ctx, src); err != nil {
+		t.Fatalf("maybeSpawnReviewSession: %v", err)
+	}
+	review, err := sessions.Get(ctx, "impl-review", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("review session not created: %v", err)
+	}
+	if got := review.GetAnnotations()[reviewOfAnnotation]; got != "impl" {
+		t.Errorf("review-of annotation = %q", got)
+	}
+	prompt, _, _ := unstructured.NestedString(review.Object, "spec", "prompt")
+	if prompt != "Review https://github.com/org/app/pull/7 from impl: Fixed the redirect loop" {
+		t.Errorf("unexpected prompt %q", prompt)
+	}
+	spec, _, _ := unstructured.NestedMap(review.Object, "spec")
+	if spec["autoPushOnComplete"] != false || spec["interactive"] != false {
+		t.Errorf("review sessions must not push and must run to completion: %v", spec)
+	}
+	if _, ok := spec["workspaceFrom"]; ok {
+		t.Errorf("workspaceFrom should not be carried over")
+	}
+	if model, _, _ := unstructured.NestedString(spec, "llmSettings", "model"); model != "claude-sonnet-4" {
+		t.Errorf("llmSettings not carried over: %v", spec["llmSettings"])
+	}
+	if url, _, _ := unstructured.NestedString(spec, "activeWorkflow", "gitUrl"); url != "https://github.com/org/review-workflow" {
+		t.Errorf("review workflow not selected: %v", spec["activeWorkflow"])
+	}
+
+	updated, _ := sessions.Get(ctx, "impl", metav1.GetOptions{})
+	if got := updated.GetAnnotations()[reviewSessionAnnotation]; got != "impl-review" {
+		t.Fatalf("source not linked to its review, annotation = %q", got)
+	}
+	// The linked source and the review itself never spawn another review
+	for _, s := range []*unstructured.Unstructured{updated, review} {
+		if err := maybeSpawnReviewSession(ctx, s); err != nil {
+			t.Fatalf("maybeSpawnReviewSession(%s): %v", s.GetName(), err)
+		}
+	}
+	list, _ := sessions.List(ctx, metav1.ListOptions{})
+	if len(list.Items) != 2 {
+		t.Errorf("expected 2 sessions, got %d", len(list.Items))
+	}
+}
+
+// TestMaybeSpawnReviewSession_Disabled verifies nothing is created without the policy
+func TestMaybeSpawnReviewSession_Disabled(t *testing.T) {
+	src := testSession("impl", "Completed", nil)
+	_ = unstructured.SetNestedStringSlice(src.Object, []string{"https://github.com/org/app/pull/7"}, "status", "result", "prURLs")
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, src)
+	config.VteamClient = vteamfake.NewSimpleClientset()
+
+	if err := maybeSpawnReviewSession(context.Background(), src); err != nil {
+		t.Fatalf("maybeSpawnReviewSession: %v", err)
+	}
+	list, _ := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").List(context.Background(), metav1.ListOptions{})
+	if len(list.Items) != 1 {
+		t.Errorf("no review session expected without spec.autoReview, got %d sessions", len(list.Items))
+	}
+}
+
+// TestReviewSessionName verifies long names stay within the limit and stay distinct
+func TestReviewSessionName(t *testing.T) {
+	if got := reviewSessionName("impl"); got != "impl-review" {
+		t.Errorf("reviewSessionName(impl) = %q", got)
+	}
+	a := reviewSessionName(strings.Repeat("a", 60) + "-1")
+	b := reviewSessionName(strings.Repeat("a", 60) + "-2")
+	if len(a) > 52 || !strings.HasSuffix(a, "-review") || a == b {
+		t.Errorf("long names must be shortened and distinct: %q %q", a, b)
+	}
+}
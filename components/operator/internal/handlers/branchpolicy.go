@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// runnerGitPolicy is the ProjectSettings git configuration handed to the runner: the branch
+// protection its git wrapper enforces and whether the project's mirror cache is mounted
+type runnerGitPolicy struct {
+	AllowedTargetBranches []string
+	RequirePR             bool
+	ForbidForcePush       bool
+	MirrorEnabled         bool
+}
+
+// loadRunnerGitPolicy reads the project's git policy. A missing ProjectSettings object means
+// no policy; a read error is returned so the runner never starts without its protection.
+func loadRunnerGitPolicy(ctx context.Context, namespace string) (runnerGitPolicy, error) {
+	policy := runnerGitPolicy{AllowedTargetBranches: []string{}}
+	psObj, err := config.DynamicClient.Resource(types.GetProjectSettingsResource()).Namespace(namespace).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to read ProjectSettings for the branch policy: %w", err)
+	}
+	if branches, found, _ := unstructured.NestedStringSlice(psObj.Object, "spec", "branchProtection", "allowedTargetBranches"); found {
+		policy.AllowedTargetBranches = branches
+	}
+	policy.RequirePR, _, _ = unstructured.NestedBool(psObj.Object, "spec", "branchProtection", "requirePR")
+	policy.ForbidForcePush, _, _ = unstructured.NestedBool(psObj.Object, "spec", "branchProtection", "forbidForcePush")
+	policy.MirrorEnabled, _, _ = unstructured.NestedBool(psObj.Object, "spec", "gitMirror", "enabled")
+	return policy, nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestHandleAgenticSessionEvent_BranchPolicyReadError verifies a session whose branch policy
+// cannot be read is retried rather than started without the policy
+func TestHandleAgenticSessionEvent_BranchPolicyReadError(t *testing.T) {
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata": map[string]interface{}{
+			"name":        "s1",
+			"namespace":   "team",
+			"annotations": map[string]interface{}{creationSourceAnnotation: "api"},
+		},
+		"spec":   map[string]interface{}{"prompt": "hi"},
+		"status": map[string]interface{}{"phase": "Pending"},
+	}}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource():  "AgenticSessionList",
+		types.GetProjectSettingsResource(): "ProjectSettingsList",
+	}, session)
+	dyn.PrependReactor("get", "projectsettings", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("etcdserver: request timed out")
+	})
+	config.DynamicClient = dyn
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	config.K8sClient = fake.NewSimpleClientset()
+
+	if err := handleAgenticSessionEvent(session); err == nil || !strings.Contains(err.Error(), "branch policy") {
+		t.Fatalf("expected the branch policy read error, got %v", err)
+	}
+	jobs, _ := config.K8sClient.BatchV1().Jobs("team").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 0 {
+		t.Errorf("%d Jobs created without the project's branch policy", len(jobs.Items))
+	}
+}
+
+// TestLoadRunnerGitPolicy verifies the policy is read from ProjectSettings and is empty without one
+func TestLoadRunnerGitPolicy(t *testing.T) {
+	settings := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "ProjectSettings",
+		"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": "team"},
+		"spec": map[string]interface{}{
+			"branchProtection": map[string]interface{}{
+				"allowedTargetBranches": []interface{}{"feature/*"},
+				"requirePR":             true,
+				"forbidForcePush":       true,
+			},
+		},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetProjectSettingsResource(): "ProjectSettingsList",
+	})
+	// Created through the client: the fake tracker would guess "projectsettingses" as the resource
+	if _, err := config.DynamicClient.Resource(types.GetProjectSettingsResource()).Namespace("team").Create(context.Background(), settings, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := loadRunnerGitPolicy(context.Background(), "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.AllowedTargetBranches) != 1 || !policy.RequirePR || !policy.ForbidForcePush || policy.MirrorEnabled {
+		t.Errorf("policy = %+v", policy)
+	}
+	if policy, err := loadRunnerGitPolicy(context.Background(), "other"); err != nil || policy.RequirePR || len(policy.AllowedTargetBranches) != 0 {
+		t.Errorf("without ProjectSettings: policy = %+v, err = %v", policy, err)
+	}
+}
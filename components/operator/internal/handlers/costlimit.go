@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// costLimitReachedConditionType is set to True once the usage reported by the runner reaches
+// spec.costLimit. The backend relays it to the runner, which summarizes its work and ends.
+const costLimitReachedConditionType = "CostLimitReached"
+
+// costLimitGracePeriod is how long the runner may spend winding down after the limit is
+// reached before the operator stops the session outright
+var costLimitGracePeriod = 5 * time.Minute
+
+// usageTokenFields are the status.usage counters that count towards a token limit
+var usageTokenFields = []string{"input_tokens", "output_tokens", "cache_creation_input_tokens", "cache_read_input_tokens"}
+
+// sessionSpend is what a session has used so far, as reported by the runner
+type sessionSpend struct {
+	USD    float64
+	Tokens int64
+}
+
+// spendFromStatus reads status.total_cost_usd and sums the token counters in status.usage
+func spendFromStatus(status map[string]interface{}) sessionSpend {
+	spend := sessionSpend{USD: numberAsFloat(status["total_cost_usd"])}
+	if usage, ok := status["usage"].(map[string]interface{}); ok {
+		for _, field := range usageTokenFields {
+			spend.Tokens += int64(numberAsFloat(usage[field]))
+		}
+	}
+	return spend
+}
+
+func numberAsFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	case int:
+		return float64(n)
+	}
+	return 0
+}
+
+// costLimitExceeded reports whether spend has reached either bound of limit, with a message
+// naming the bound. Zero bounds are unlimited.
+func costLimitExceeded(limit apiv1alpha1.CostLimit, spend sessionSpend) (string, bool) {
+	if limit.USD > 0 && spend.USD >= limit.USD {
+		return fmt.Sprintf("Session cost $%.2f reached the limit of $%.2f", spend.USD, limit.USD), true
+	}
+	if limit.Tokens > 0 && spend.Tokens >= limit.Tokens {
+		return fmt.Sprintf("Session used %d tokens, reaching the limit of %d", spend.Tokens, limit.Tokens), true
+	}
+	return "", false
+}
+
+// enforceCostLimit checks a running session against spec.costLimit. The first time the limit
+// is reached it sets CostLimitReached so the runner can wind down; if the session is still
+// running costLimitGracePeriod later it is stopped.
+func enforceCostLimit(obj *unstructured.Unstructured) {
+	rawLimit, found, _ := unstructured.NestedMap(obj.Object, "spec", "costLimit")
+	if !found {
+		return
+	}
+	var limit apiv1alpha1.CostLimit
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawLimit, &limit); err != nil {
+		log.Printf("Ignoring invalid costLimit on %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return
+	}
+	status, _, _ := unstructured.NestedMap(obj.Object, "status")
+	if phase, _ := status["phase"].(string); phase != "Running" {
+		return
+	}
+
+	if reachedAt, ok := costLimitReachedAt(status); ok {
+		if time.Since(reachedAt) < costLimitGracePeriod {
+			return
+		}
+		msg := "Session stopped: cost limit reached and the runner did not finish within the grace period"
+		log.Printf("Stopping session %s/%s: %s", obj.GetNamespace(), obj.GetName(), msg)
+		if err := updateAgenticSessionStatus(obj.GetNamespace(), obj.GetName(), map[string]interface{}{
+			"phase":          "Stopped",
+			"message":        msg,
+			"completionTime": time.Now().Format(time.RFC3339),
+			"result":         fallbackResult(apiv1alpha1.OutcomeInterrupted, msg),
+		}); err != nil {
+			log.Printf("Failed to stop session %s/%s over its cost limit: %v", obj.GetNamespace(), obj.GetName(), err)
+		}
+		return
+	}
+
+	msg, exceeded := costLimitExceeded(limit, spendFromStatus(status))
+	if !exceeded {
+		return
+	}
+	log.Printf("Session %s/%s reached its cost limit: %s", obj.GetNamespace(), obj.GetName(), msg)
+	err := statusupdater.Mutate(context.TODO(), types.GetAgenticSessionResource(), obj.GetNamespace(), obj.GetName(), func(status map[string]interface{}) error {
+		if phase, _ := status["phase"].(string); phase != "Running" {
+			return statusupdater.ErrNoChange
+		}
+		if !setSessionCondition(status, sessionCondition(costLimitReachedConditionType, v1.ConditionTrue, "LimitExceeded", msg)) {
+			return statusupdater.ErrNoChange
+		}
+		status["message"] = msg + "; winding down"
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to set %s on %s/%s: %v", costLimitReachedConditionType, obj.GetNamespace(), obj.GetName(), err)
+	}
+}
+
+// costLimitReachedAt returns when CostLimitReached became True, if it has
+func costLimitReachedAt(status map[string]interface{}) (time.Time, bool) {
+	conditions, _ := status["conditions"].([]interface{})
+	for _, item := range conditions {
+		cond, _ := item.(map[string]interface{})
+		if cond["type"] != costLimitReachedConditionType || cond["status"] != string(v1.ConditionTrue) {
+			continue
+		}
+		// A missing timestamp yields the zero time, i.e. the grace period is over
+		raw, _ := cond["lastTransitionTime"].(string)
+		t, _ := time.Parse(time.RFC3339, raw)
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestSpendFromStatus verifies cache tokens count towards the total and JSON number types are accepted
+func TestSpendFromStatus(t *testing.T) {
+	status := map[string]interface{}{
+		"total_cost_usd": 1.25,
+		"usage": map[string]interface{}{
+			"input_tokens":                int64(100),
+			"output_tokens":               float64(50),
+			"cache_creation_input_tokens": int64(10),
+			"cache_read_input_tokens":     int64(1000),
+			"service_tier":                "standard",
+		},
+	}
+	spend := spendFromStatus(status)
+	if spend.USD != 1.25 || spend.Tokens != 1160 {
+		t.Errorf("Unexpected spend: %+v", spend)
+	}
+	if spend := spendFromStatus(map[string]interface{}{}); spend.USD != 0 || spend.Tokens != 0 {
+		t.Errorf("Expected no spend for empty status, got %+v", spend)
+	}
+}
+
+// TestCostLimitExceeded verifies either bound trips the limit and zero bounds are unlimited
+func TestCostLimitExceeded(t *testing.T) {
+	cases := []struct {
+		name     string
+		limit    apiv1alpha1.CostLimit
+		spend    sessionSpend
+		exceeded bool
+		contains string
+	}{
+		{"under usd", apiv1alpha1.CostLimit{USD: 5}, sessionSpend{USD: 4.99}, false, ""},
+		{"at usd", apiv1alpha1.CostLimit{USD: 5}, sessionSpend{USD: 5}, true, "$5.00"},
+		{"tokens", apiv1alpha1.CostLimit{USD: 5, Tokens: 1000}, sessionSpend{USD: 1, Tokens: 1200}, true, "1200 tokens"},
+		{"unlimited", apiv1alpha1.CostLimit{}, sessionSpend{USD: 100, Tokens: 1 << 30}, false, ""},
+	}
+	for _, tc := range cases {
+		msg, exceeded := costLimitExceeded(tc.limit, tc.spend)
+		if exceeded != tc.exceeded || !strings.Contains(msg, tc.contains) {
+			t.Errorf("%s: got (%q, %v)", tc.name, msg, exceeded)
+		}
+	}
+}
+
+// TestCostLimitReachedAt verifies only a True condition starts the grace period
+func TestCostLimitReachedAt(t *testing.T) {
+	if _, ok := costLimitReachedAt(map[string]interface{}{}); ok {
+		t.Error("Expected no condition on empty status")
+	}
+
+	status := map[string]interface{}{}
+	setSessionCondition(status, sessionCondition(costLimitReachedConditionType, metav1.ConditionFalse, "WithinLimit", ""))
+	if _, ok := costLimitReachedAt(status); ok {
+		t.Error("Expected False condition to be ignored")
+	}
+
+	setSessionCondition(status, sessionCondition(costLimitReachedConditionType, metav1.ConditionTrue, "LimitExceeded", "over"))
+	at, ok := costLimitReachedAt(status)
+	if !ok || time.Since(at) > time.Minute {
+		t.Errorf("Expected a recent transition time, got %v (%v)", at, ok)
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ProjectSettings spec.networkPolicy.allowedDomains is enforced in two places. The runner
+// limits its web tools to the domains (WEB_ALLOWED_DOMAINS), and with RUNNER_EGRESS_POLICY=true
+// the operator also renders a NetworkPolicy that lets the runner pod reach only DNS, the
+// backend, and the resolved addresses of the allowed domains, the session's Git hosts and
+// RUNNER_EGRESS_HOSTS. NetworkPolicies match addresses, not names: the addresses are
+// resolved when the Job is created, and wildcard domains are enforced by the runner only.
+
+// lookupHost resolves egress hosts; replaced in tests
+var lookupHost = net.LookupHost
+
+func runnerEgressPolicyName(session string) string {
+	return fmt.Sprintf("ambient-egress-%s", session)
+}
+
+// projectNetworkPolicy returns the project's agent network policy, nil when unrestricted
+func projectNetworkPolicy(ctx context.Context, namespace string) (*apiv1alpha1.AgentNetworkPolicy, error) {
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	if !ps.Spec.NetworkPolicy.Restricted() {
+		return nil, nil
+	}
+	return ps.Spec.NetworkPolicy, nil
+}
+
+// egressHosts lists the hosts the runner must reach: the allowed domains without wildcards,
+// the hosts of the session's repo URLs and the operator's always-allowed hosts
+func egressHosts(policy *apiv1alpha1.AgentNetworkPolicy, repoURLs, extra []string) []string {
+	seen := map[string]bool{}
+	add := func(host string) {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" && !strings.HasPrefix(host, "*.") {
+			seen[host] = true
+		}
+	}
+	for _, d := range policy.AllowedDomains {
+		add(d)
+	}
+	for _, raw := range repoURLs {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		add(u.Hostname())
+		if u.Hostname() == "github.com" {
+			// git pushes and the runner's token checks go through the REST API
+			add("api.github.com")
+		}
+	}
+	for _, h := range extra {
+		add(h)
+	}
+	hosts := make([]string, 0, len(seen))
+	for h := range seen {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// sessionRepoURLs lists every Git URL the runner clones from or pushes to
+func sessionRepoURLs(session *unstructured.Unstructured) []string {
+	var urls []string
+	for _, path := range [][]string{
+		{"spec", "inputRepo"}, {"spec", "outputRepo"},
+		{"spec", "input", "repo"}, {"spec", "output", "repo"},
+		{"spec", "activeWorkflow", "gitUrl"},
+	} {
+		if v, _, _ := unstructured.NestedString(session.Object, path...); v != "" {
+			urls = append(urls, v)
+		}
+	}
+	repos, _, _ := unstructured.NestedSlice(session.Object, "spec", "repos")
+	for _, r := range repos {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, side := range []string{"input", "output"} {
+			if v, _, _ := unstructured.NestedString(m, side, "url"); v != "" {
+				urls = append(urls, v)
+			}
+		}
+	}
+	return urls
+}
+
+// resolveEgressCIDRs resolves hosts to single-address CIDRs. Hosts that do not resolve are
+// logged and skipped: the runner reports the failed connection more clearly than a failed Job.
+func resolveEgressCIDRs(hosts []string) []string {
+	seen := map[string]bool{}
+	var cidrs []string
+	for _, h := range hosts {
+		addrs, err := lookupHost(h)
+		if err != nil {
+			log.Printf("Egress policy: cannot resolve %s: %v", h, err)
+			continue
+		}
+		for _, a := range addrs {
+			ip := net.ParseIP(a)
+			if ip == nil {
+				continue
+			}
+			cidr := ip.String() + "/32"
+			if ip.To4() == nil {
+				cidr = ip.String() + "/128"
+			}
+			if !seen[cidr] {
+				seen[cidr] = true
+				cidrs = append(cidrs, cidr)
+			}
+		}
+	}
+	sort.Strings(cidrs)
+	return cidrs
+}
+
+// runnerEgressPolicy allows the session's runner pod DNS, the backend namespace and HTTP(S)
+// and SSH to the given addresses; all other egress is denied
+func runnerEgressPolicy(namespace, session, backendNamespace string, cidrs []string, ownerRefs []v1.OwnerReference) *networkingv1.NetworkPolicy {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	port := func(proto corev1.Protocol, n int) networkingv1.NetworkPolicyPort {
+		p := intstr.FromInt(n)
+		return networkingv1.NetworkPolicyPort{Protocol: &proto, Port: &p}
+	}
+	rules := []networkingv1.NetworkPolicyEgressRule{
+		{Ports: []networkingv1.NetworkPolicyPort{port(udp, 53), port(tcp, 53)}},
+		{To: []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &v1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": backendNamespace}},
+		}}},
+	}
+	if len(cidrs) > 0 {
+		peers := make([]networkingv1.NetworkPolicyPeer, 0, len(cidrs))
+		for _, c := range cidrs {
+			peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: c}})
+		}
+		rules = append(rules, networkingv1.NetworkPolicyEgressRule{
+			To:    peers,
+			Ports: []networkingv1.NetworkPolicyPort{port(tcp, 443), port(tcp, 80), port(tcp, 22)},
+		})
+	}
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: v1.ObjectMeta{
+			Name:            runnerEgressPolicyName(session),
+			Namespace:       namespace,
+			Labels:          map[string]string{"app": "ambient-runner-egress", "agentic-session": session},
+			OwnerReferences: ownerRefs,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: v1.LabelSelector{MatchLabels: map[string]string{"agentic-session": session}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress:      rules,
+		},
+	}
+}
+
+// ensureRunnerEgressPolicy creates or refreshes the session's egress NetworkPolicy before its
+// Job starts. Addresses are re-resolved on every run since CDN-hosted sites move.
+func ensureRunnerEgressPolicy(ctx context.Context, namespace, session string, policy *apiv1alpha1.AgentNetworkPolicy, repoURLs []string, appConfig *config.Config, ownerRefs []v1.OwnerReference, meta apiv1alpha1.ObjectMetadata) error {
+	cidrs := resolveEgressCIDRs(egressHosts(policy, repoURLs, appConfig.RunnerEgressHosts))
+	desired := runnerEgressPolicy(namespace, session, appConfig.BackendNamespace, cidrs, ownerRefs)
+	meta.Apply(desired)
+	policies := config.K8sClient.NetworkingV1().NetworkPolicies(namespace)
+	existing, err := policies.Get(ctx, desired.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = policies.Create(ctx, desired, v1.CreateOptions{})
+	} else if err == nil {
+		existing.Spec = desired.Spec
+		_, err = policies.Update(ctx, existing, v1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply NetworkPolicy %s: %w", desired.Name, err)
+	}
+	log.Printf("Session %s/%s egress limited to %d address(es) of the allowed domains", namespace, session, len(cidrs))
+	return nil
+}
+
+// removeEnv drops the named variable from env
+func removeEnv(env []corev1.EnvVar, name string) []corev1.EnvVar {
+	out := env[:0]
+	for _, e := range env {
+		if e.Name != name {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func fakeLookup(addrs map[string][]string) func(string) ([]string, error) {
+	return func(host string) ([]string, error) {
+		if a, ok := addrs[host]; ok {
+			return a, nil
+		}
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+}
+
+// TestEgressHosts verifies wildcards are left to the runner and Git hosts are always reachable
+func TestEgressHosts(t *testing.T) {
+	policy := &apiv1alpha1.AgentNetworkPolicy{AllowedDomains: []string{"docs.python.org", "*.readthedocs.io"}}
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"repos": []interface{}{
+				map[string]interface{}{
+					"input":  map[string]interface{}{"url": "https://github.com/org/repo"},
+					"output": map[string]interface{}{"url": "https://gitlab.example.com/me/repo.git"},
+				},
+			},
+		},
+	}}
+	got := egressHosts(policy, sessionRepoURLs(session), []string{"api.anthropic.com"})
+	want := []string{"api.anthropic.com", "api.github.com", "docs.python.org", "github.com", "gitlab.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("egressHosts() = %v, want %v", got, want)
+	}
+}
+
+// TestEnsureRunnerEgressPolicy verifies the policy selects the session's pod, allows DNS and
+// the backend, and pins the remaining egress to the resolved addresses
+func TestEnsureRunnerEgressPolicy(t *testing.T) {
+	setupTestClient()
+	defer func(orig func(string) ([]string, error)) { lookupHost = orig }(lookupHost)
+	lookupHost = fakeLookup(map[string][]string{
+		"docs.python.org": {"151.101.0.223", "2a04:4e42::223"},
+		"github.com":      {"140.82.112.3"},
+		"api.github.com":  {"140.82.112.5"},
+	})
+
+	ctx := context.Background()
+	policy := &apiv1alpha1.AgentNetworkPolicy{AllowedDomains: []string{"docs.python.org"}}
+	cfg := &config.Config{BackendNamespace: "ambient-code"}
+	repos := []string{"https://github.com/org/repo"}
+	if err := ensureRunnerEgressPolicy(ctx, "team-a", "s1", policy, repos, cfg, nil, apiv1alpha1.ObjectMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	np, err := config.K8sClient.NetworkingV1().NetworkPolicies("team-a").Get(ctx, runnerEgressPolicyName("s1"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if np.Spec.PodSelector.MatchLabels["agentic-session"] != "s1" || len(np.Spec.Egress) != 3 {
+		t.Fatalf("spec = %+v", np.Spec)
+	}
+	if *np.Spec.Egress[0].Ports[0].Protocol != corev1.ProtocolUDP || np.Spec.Egress[0].Ports[0].Port.IntValue() != 53 {
+		t.Errorf("first rule should allow DNS: %+v", np.Spec.Egress[0])
+	}
+	if np.Spec.Egress[1].To[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"] != "ambient-code" {
+		t.Errorf("second rule should allow the backend: %+v", np.Spec.Egress[1])
+	}
+	var cidrs []string
+	for _, peer := range np.Spec.Egress[2].To {
+		cidrs = append(cidrs, peer.IPBlock.CIDR)
+	}
+	want := []string{"140.82.112.3/32", "140.82.112.5/32", "151.101.0.223/32", "2a04:4e42::223/128"}
+	if !reflect.DeepEqual(cidrs, want) {
+		t.Errorf("cidrs = %v, want %v", cidrs, want)
+	}
+
+	// A later run re-resolves and updates the existing policy
+	lookupHost = fakeLookup(map[string][]string{"docs.python.org": {"151.101.64.223"}})
+	if err := ensureRunnerEgressPolicy(ctx, "team-a", "s1", policy, nil, cfg, nil, apiv1alpha1.ObjectMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	np, _ = config.K8sClient.NetworkingV1().NetworkPolicies("team-a").Get(ctx, runnerEgressPolicyName("s1"), metav1.GetOptions{})
+	if len(np.Spec.Egress) != 3 || len(np.Spec.Egress[2].To) != 1 || np.Spec.Egress[2].To[0].IPBlock.CIDR != "151.101.64.223/32" {
+		t.Errorf("policy not refreshed: %+v", np.Spec.Egress)
+	}
+}
+
+func TestRemoveEnv(t *testing.T) {
+	env := []corev1.EnvVar{{Name: "A"}, {Name: "WEB_ALLOWED_DOMAINS", Value: "evil.example.com"}, {Name: "B"}}
+	got := removeEnv(env, "WEB_ALLOWED_DOMAINS")
+	if len(got) != 2 || got[0].Name != "A" || got[1].Name != "B" {
+		t.Errorf("removeEnv() = %v", got)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// encryptedStorageAnnotation lets a cluster admin vouch for a StorageClass whose encryption
+// the operator cannot see in its parameters, e.g. encryption configured on the storage array
+const encryptedStorageAnnotation = "ambient-code.io/encrypted"
+
+// encryptionParameters are StorageClass parameters that turn on volume encryption, by
+// provisioner: "true" for the boolean ones, any value for key references
+var encryptionParameters = []struct {
+	name    string
+	boolean bool
+}{
+	{"encrypted", true},                // AWS EBS, Ceph RBD, Cinder
+	{"kmsKeyId", false},                // AWS EBS with a customer-managed key
+	{"disk-encryption-kms-key", false}, // GCE PD
+	{"diskEncryptionSetID", false},     // Azure Disk
+	{"encryptionKMSID", false},         // Ceph CSI
+}
+
+// loadWorkspaceEncryption returns ProjectSettings spec.workspaceEncryption, nil when unset
+func loadWorkspaceEncryption(ctx context.Context, namespace string) (*apiv1alpha1.WorkspaceEncryption, error) {
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	return ps.Spec.WorkspaceEncryption, nil
+}
+
+// encryptedStorageClass returns the StorageClass the project requires for session workspaces,
+// or "" when it requires none. A class that is missing or does not encrypt its volumes is an
+// error, so sessions never silently fall back to unencrypted storage.
+func encryptedStorageClass(ctx context.Context, enc *apiv1alpha1.WorkspaceEncryption) (string, error) {
+	if enc == nil || strings.TrimSpace(enc.StorageClassName) == "" {
+		return "", nil
+	}
+	name := strings.TrimSpace(enc.StorageClassName)
+	sc, err := config.K8sClient.StorageV1().StorageClasses().Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", fmt.Errorf("StorageClass %s required by spec.workspaceEncryption does not exist", name)
+		}
+		return "", fmt.Errorf("failed to read StorageClass %s: %w", name, err)
+	}
+	if !storageClassEncrypted(sc) {
+		return "", fmt.Errorf("StorageClass %s does not encrypt its volumes; set an encryption parameter or annotate it %s=true", name, encryptedStorageAnnotation)
+	}
+	return name, nil
+}
+
+// storageClassEncrypted recognizes the encryption parameters of common provisioners
+func storageClassEncrypted(sc *storagev1.StorageClass) bool {
+	if strings.EqualFold(sc.Annotations[encryptedStorageAnnotation], "true") {
+		return true
+	}
+	for _, p := range encryptionParameters {
+		v := strings.TrimSpace(sc.Parameters[p.name])
+		if (p.boolean && strings.EqualFold(v, "true")) || (!p.boolean && v != "") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWorkspaceVolumeClass verifies an existing workspace volume (a continuation's parent or
+// a session group's) was provisioned from the required StorageClass
+func checkWorkspaceVolumeClass(ctx context.Context, namespace, pvcName, storageClass string) error {
+	pvc, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != storageClass {
+		return fmt.Errorf("workspace volume %s was not provisioned from the encrypted StorageClass %s", pvcName, storageClass)
+	}
+	return nil
+}
+
+// recordWorkspaceEncryption sets status.encryption.storageClassName
+func recordWorkspaceEncryption(namespace, name, storageClass string) error {
+	return statusupdater.Mutate(context.TODO(), types.GetAgenticSessionResource(), namespace, name, func(st map[string]interface{}) error {
+		enc, _ := st["encryption"].(map[string]interface{})
+		if enc == nil {
+			enc = map[string]interface{}{}
+		}
+		if enc["storageClassName"] == storageClass {
+			return statusupdater.ErrNoChange
+		}
+		enc["storageClassName"] = storageClass
+		st["encryption"] = enc
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/services"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testStorageClass(name string, annotations, params map[string]string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: name, Annotations: annotations},
+		Provisioner: "ebs.csi.aws.com",
+		Parameters:  params,
+	}
+}
+
+// TestEncryptedStorageClass verifies only classes that encrypt their volumes are accepted
+func TestEncryptedStorageClass(t *testing.T) {
+	setupTestClient(
+		testStorageClass("ebs-encrypted", nil, map[string]string{"encrypted": "true"}),
+		testStorageClass("ebs-kms", nil, map[string]string{"kmsKeyId": "arn:aws:kms:us-east-1:1:key/abc"}),
+		testStorageClass("san", map[string]string{encryptedStorageAnnotation: "true"}, nil),
+		testStorageClass("plain", nil, map[string]string{"encrypted": "false", "type": "gp3"}),
+	)
+	ctx := context.Background()
+
+	for _, name := range []string{"ebs-encrypted", "ebs-kms", "san"} {
+		got, err := encryptedStorageClass(ctx, &apiv1alpha1.WorkspaceEncryption{StorageClassName: name})
+		if err != nil || got != name {
+			t.Errorf("encryptedStorageClass(%s) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := encryptedStorageClass(ctx, &apiv1alpha1.WorkspaceEncryption{StorageClassName: "plain"}); err == nil || !strings.Contains(err.Error(), "does not encrypt") {
+		t.Errorf("expected plain class to be rejected, got %v", err)
+	}
+	if _, err := encryptedStorageClass(ctx, &apiv1alpha1.WorkspaceEncryption{StorageClassName: "missing"}); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected missing class to be rejected, got %v", err)
+	}
+	// Without a required class the cluster default is used
+	if got, err := encryptedStorageClass(ctx, &apiv1alpha1.WorkspaceEncryption{}); got != "" || err != nil {
+		t.Errorf("expected no class, got %q, %v", got, err)
+	}
+}
+
+// TestCheckWorkspaceVolumeClass verifies inherited volumes must come from the required class
+func TestCheckWorkspaceVolumeClass(t *testing.T) {
+	setupTestClient()
+	ctx := context.Background()
+	if err := services.EnsureSessionWorkspacePVC("proj", "ambient-workspace-enc", "ebs-encrypted", nil, apiv1alpha1.ObjectMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := services.EnsureSessionWorkspacePVC("proj", "ambient-workspace-old", "", nil, apiv1alpha1.ObjectMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkWorkspaceVolumeClass(ctx, "proj", "ambient-workspace-enc", "ebs-encrypted"); err != nil {
+		t.Errorf("expected volume of the encrypted class to pass, got %v", err)
+	}
+	if err := checkWorkspaceVolumeClass(ctx, "proj", "ambient-workspace-old", "ebs-encrypted"); err == nil {
+		t.Error("expected volume of the default class to be rejected")
+	}
+}
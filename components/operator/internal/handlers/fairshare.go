@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fairShareReason is the Queued condition reason of sessions letting other users of their
+// project start first
+const fairShareReason = "FairShare"
+
+// projectSessionFairness returns ProjectSettings spec.sessionFairness, nil when unset
+func projectSessionFairness(ctx context.Context, namespace string) (*apiv1alpha1.SessionFairness, error) {
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	return ps.Spec.SessionFairness, nil
+}
+
+// listProjectSessions returns the AgenticSessions of a namespace
+func listProjectSessions(ctx context.Context, namespace string) ([]unstructured.Unstructured, error) {
+	list, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions in %s: %w", namespace, err)
+	}
+	return list.Items, nil
+}
+
+// sessionShareKey is the user or team the session counts for under the project's fairness
+func sessionShareKey(session *unstructured.Unstructured, fairness *apiv1alpha1.SessionFairness) string {
+	raw, ok, _ := unstructured.NestedMap(session.Object, "spec", "userContext")
+	if !ok {
+		return fairness.ShareKey(nil)
+	}
+	var user apiv1alpha1.UserContext
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &user); err != nil {
+		return fairness.ShareKey(nil)
+	}
+	return fairness.ShareKey(&user)
+}
+
+// fairShareHold returns why the session must let other users of its project go first, or ""
+// when it may start. sessions are the project's. Sessions starting or running count against
+// their user or team; only sessions waiting for a runner slot compete, so a user held for
+// another reason (schedule, rate limit) holds nobody back. An invalid setting holds nothing;
+// the backend rejects it on save.
+func fairShareHold(session *unstructured.Unstructured, sessions []unstructured.Unstructured, fairness *apiv1alpha1.SessionFairness) string {
+	if fairness == nil || fairness.Validate() != nil {
+		return ""
+	}
+	key := sessionShareKey(session, fairness)
+	running := map[string]int{}
+	waiting := map[string]bool{}
+	for i := range sessions {
+		s := &sessions[i]
+		if s.GetName() == session.GetName() {
+			continue
+		}
+		phase, _, _ := unstructured.NestedString(s.Object, "status", "phase")
+		switch {
+		case phase == "Creating" || phase == "Running":
+			running[sessionShareKey(s, fairness)]++
+		case phase == "Pending" && (queuedReason(s) == clusterJobLimitReason || queuedReason(s) == fairShareReason):
+			waiting[sessionShareKey(s, fairness)] = true
+		}
+	}
+
+	mine := running[key]
+	if fairness.MaxRunning > 0 && mine >= fairness.MaxRunning {
+		return fmt.Sprintf("Waiting for a fair share: %d %s are running, the project's maximum per user or team", mine, sharedSessions(key))
+	}
+	// Weighted comparison of running/weight without division
+	for other := range waiting {
+		if other != key && running[other]*fairness.Weight(key) < mine*fairness.Weight(other) {
+			return fmt.Sprintf("Waiting for a fair share: %d %s are running while users of the project with fewer wait", mine, sharedSessions(key))
+		}
+	}
+	return ""
+}
+
+func sharedSessions(key string) string {
+	if key == "" {
+		return "sessions without a user"
+	}
+	return "sessions of " + key
+}
+
+// markSessionFairShare holds a Pending session for other users; like the other holds it
+// writes only once and RequeueQueuedSessions retries it
+func markSessionFairShare(session *unstructured.Unstructured, msg string) error {
+	if queuedReason(session) == fairShareReason {
+		return nil
+	}
+	return setQueuedCondition(session, v1.ConditionTrue, fairShareReason, msg, msg)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func fairShareSession(name, phase, user string, groups ...string) unstructured.Unstructured {
+	g := []interface{}{}
+	for _, group := range groups {
+		g = append(g, group)
+	}
+	s := testSession(name, phase, map[string]interface{}{"userContext": map[string]interface{}{"userId": user, "groups": g}})
+	if phase == "Pending" {
+		s.Object["status"].(map[string]interface{})["conditions"] = []interface{}{queuedCondition(metav1.ConditionTrue, clusterJobLimitReason, "waiting")}
+	}
+	return *s
+}
+
+// TestFairShareHold verifies a user with many running sessions lets a waiting user go
+// first, weights and teams change the shares, and sessions held for other reasons compete
+// for nothing
+func TestFairShareHold(t *testing.T) {
+	fairness := &apiv1alpha1.SessionFairness{}
+	sessions := []unstructured.Unstructured{
+		fairShareSession("a1", "Running", "alice"),
+		fairShareSession("a2", "Running", "alice"),
+		fairShareSession("b1", "Pending", "bob"),
+	}
+	next := fairShareSession("a3", "Pending", "alice")
+	if msg := fairShareHold(&next, sessions, fairness); !strings.Contains(msg, "2 sessions of alice") {
+		t.Errorf("alice not held for bob: %q", msg)
+	}
+	bob := sessions[2]
+	if msg := fairShareHold(&bob, append(sessions, next), fairness); msg != "" {
+		t.Errorf("bob held: %q", msg)
+	}
+	if msg := fairShareHold(&next, sessions, nil); msg != "" {
+		t.Errorf("project without fairness held a session: %q", msg)
+	}
+
+	// Weight 2 lets alice run twice bob's sessions before yielding
+	weighted := &apiv1alpha1.SessionFairness{Weights: map[string]int{"alice": 2}}
+	withBobRunning := append(sessions, fairShareSession("b0", "Running", "bob"))
+	if msg := fairShareHold(&next, withBobRunning, weighted); msg != "" {
+		t.Errorf("weighted alice held at her share: %q", msg)
+	}
+
+	// Team members share one share
+	team := &apiv1alpha1.SessionFairness{Teams: []string{"platform"}}
+	teamSessions := []unstructured.Unstructured{
+		fairShareSession("c1", "Running", "carol", "platform"),
+		fairShareSession("d1", "Pending", "dave", "platform"),
+		fairShareSession("b1", "Pending", "bob"),
+	}
+	erin := fairShareSession("e1", "Pending", "erin", "platform")
+	if msg := fairShareHold(&erin, teamSessions, team); !strings.Contains(msg, "sessions of platform") {
+		t.Errorf("team member not held: %q", msg)
+	}
+
+	// A waiting user held outside its schedule does not compete
+	notWaiting := fairShareSession("b1", "Pending", "bob")
+	notWaiting.Object["status"].(map[string]interface{})["conditions"] = []interface{}{queuedCondition(metav1.ConditionTrue, outsideScheduleReason, "night")}
+	if msg := fairShareHold(&next, []unstructured.Unstructured{sessions[0], sessions[1], notWaiting}, fairness); msg != "" {
+		t.Errorf("held for a user outside its schedule: %q", msg)
+	}
+
+	capped := &apiv1alpha1.SessionFairness{MaxRunning: 2}
+	if msg := fairShareHold(&next, sessions[:2], capped); !strings.Contains(msg, "maximum") {
+		t.Errorf("cap not applied: %q", msg)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	githubTokenVolumeName = "github-token"
+	githubTokenMountPath  = "/var/run/ambient/github"
+	// githubTokenKey is the key of the token in the session's GitHub token Secret
+	githubTokenKey = "GITHUB_TOKEN"
+)
+
+// githubTokenSecretName is the Secret in which the backend keeps the session's short-lived,
+// repository-scoped GitHub App token (see backend handlers/github_session_token.go)
+func githubTokenSecretName(session string) string {
+	return fmt.Sprintf("ambient-github-token-%s", session)
+}
+
+// applyGitHubToken mounts the session's GitHub App token into the runner container. The
+// volume is optional: the backend may write the Secret after the pod starts, or not at all
+// when the session's creator has no GitHub App installation. The kubelet updates the mounted
+// file as the backend refreshes the token, so the runner reads it from GITHUB_TOKEN_FILE
+// rather than from an env var fixed at pod start.
+func applyGitHubToken(podSpec *corev1.PodSpec, containerName, session string) {
+	optional := true
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: githubTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+			SecretName: githubTokenSecretName(session),
+			Items:      []corev1.KeyToPath{{Key: githubTokenKey, Path: "token"}},
+			Optional:   &optional,
+		}},
+	})
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == containerName {
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      githubTokenVolumeName,
+				MountPath: githubTokenMountPath,
+				ReadOnly:  true,
+			})
+			podSpec.Containers[i].Env = append(podSpec.Containers[i].Env, corev1.EnvVar{Name: "GITHUB_TOKEN_FILE", Value: githubTokenMountPath + "/token"})
+			break
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestApplyGitHubToken verifies only the runner container gets the optional token mount
+func TestApplyGitHubToken(t *testing.T) {
+	podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "ambient-content"}, {Name: "ambient-code-runner"}}}
+	applyGitHubToken(&podSpec, "ambient-code-runner", "sess-1")
+
+	if len(podSpec.Containers[0].VolumeMounts) != 0 || len(podSpec.Containers[0].Env) != 0 {
+		t.Errorf("content container should not receive the GitHub token")
+	}
+	runner := podSpec.Containers[1]
+	if len(runner.VolumeMounts) != 1 || runner.VolumeMounts[0].MountPath != githubTokenMountPath || !runner.VolumeMounts[0].ReadOnly {
+		t.Fatalf("expected read-only token mount at %s, got %v", githubTokenMountPath, runner.VolumeMounts)
+	}
+	if len(runner.Env) != 1 || runner.Env[0].Name != "GITHUB_TOKEN_FILE" || runner.Env[0].Value != githubTokenMountPath+"/token" {
+		t.Errorf("unexpected env: %v", runner.Env)
+	}
+	if len(podSpec.Volumes) != 1 {
+		t.Fatalf("expected one volume, got %v", podSpec.Volumes)
+	}
+	secret := podSpec.Volumes[0].Secret
+	if secret == nil || secret.SecretName != "ambient-github-token-sess-1" || secret.Optional == nil || !*secret.Optional {
+		t.Errorf("expected optional Secret volume ambient-github-token-sess-1, got %v", podSpec.Volumes[0])
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The git mirror cache (ProjectSettings spec.gitMirror) is one Deployment per project that keeps
+// bare mirrors of the configured repos on a ReadWriteMany volume. Runner Jobs mount the volume
+// read-only and clone with --reference, so only objects newer than the mirror are downloaded.
+const (
+	gitMirrorName = "ambient-git-mirror"
+	// gitMirrorMountPath is where runners find the mirrors (GIT_MIRROR_DIR)
+	gitMirrorMountPath = "/var/cache/ambient/git-mirror"
+
+	defaultGitMirrorRefreshSeconds = 300
+	minGitMirrorRefreshSeconds     = 30
+	defaultGitMirrorStorageSize    = "20Gi"
+)
+
+// gitMirrorScript refreshes every "<key> <url>" line of the repos file into /mirror/<key>.
+// New mirrors are cloned to a temporary directory first so runners never see a partial one.
+const gitMirrorScript = `set -u
+while true; do
+  while read -r key url; do
+    [ -n "$key" ] || continue
+    dir="/mirror/$key"
+    if [ -d "$dir" ]; then
+      git -C "$dir" fetch --prune --quiet origin || echo "fetch failed: $url"
+    else
+      mkdir -p "$(dirname "$dir")"
+      rm -rf "$dir.tmp"
+      if git clone --mirror --quiet "$url" "$dir.tmp"; then mv "$dir.tmp" "$dir"; else rm -rf "$dir.tmp"; echo "clone failed: $url"; fi
+    fi
+  done < /etc/git-mirror/repos
+  sleep "$REFRESH_INTERVAL"
+done`
+
+// gitMirrorCredentialHelper answers git's credential requests from GIT_TOKEN, if set
+const gitMirrorCredentialHelper = `!f() { test -n "${GIT_TOKEN:-}" && echo username=x-access-token && echo password=$GIT_TOKEN; }; f`
+
+// gitMirrorKey is the directory of a repo's mirror relative to the mirror root, e.g.
+// "github.com/org/repo.git" for https://github.com/org/repo. The runner derives the same key
+// (see git_mirror_key in the runner wrapper). Unusable URLs yield "".
+func gitMirrorKey(repoURL string) string {
+	u := strings.TrimSpace(repoURL)
+	hasScheme := false
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+		hasScheme = true
+	}
+	if at := strings.Index(u, "@"); at >= 0 && (strings.Index(u, "/") < 0 || at < strings.Index(u, "/")) {
+		u = u[at+1:]
+	}
+	// scp-like syntax: host:org/repo
+	if colon := strings.Index(u, ":"); !hasScheme && colon >= 0 && (strings.Index(u, "/") < 0 || colon < strings.Index(u, "/")) {
+		u = u[:colon] + "/" + u[colon+1:]
+	}
+	u = strings.TrimSuffix(strings.TrimRight(strings.ToLower(u), "/"), ".git")
+	parts := strings.Split(u, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	for _, p := range parts {
+		if p == "" || p == "." || p == ".." || strings.ContainsAny(p, " \t") {
+			return ""
+		}
+	}
+	return u + ".git"
+}
+
+// reconcileGitMirror creates, updates or removes the project's mirror cache to match
+// spec.gitMirror
+func reconcileGitMirror(ps *apiv1alpha1.ProjectSettings) error {
+	ctx := context.TODO()
+	namespace := ps.Namespace
+	mirror := ps.Spec.GitMirror
+	if mirror == nil || !mirror.Enabled {
+		return deleteGitMirror(ctx, namespace)
+	}
+
+	ownerRefs := []v1.OwnerReference{{
+		APIVersion: apiv1alpha1.SchemeGroupVersion.String(),
+		Kind:       "ProjectSettings",
+		Name:       ps.Name,
+		UID:        ps.UID,
+		Controller: boolPtr(true),
+	}}
+	labels := map[string]string{"app": gitMirrorName}
+	meta := ps.Spec.ObjectMetadata.For(nil, nil)
+
+	size, err := resource.ParseQuantity(defaultGitMirrorStorageSize)
+	if err != nil {
+		return err
+	}
+	if mirror.StorageSize != "" {
+		if size, err = resource.ParseQuantity(mirror.StorageSize); err != nil {
+			return fmt.Errorf("invalid gitMirror.storageSize %q: %w", mirror.StorageSize, err)
+		}
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: v1.ObjectMeta{Name: gitMirrorName, Namespace: namespace, Labels: labels, OwnerReferences: ownerRefs},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			Resources:   corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: size}},
+		},
+	}
+	if mirror.StorageClassName != "" {
+		pvc.Spec.StorageClassName = &mirror.StorageClassName
+	}
+	meta.Apply(pvc)
+	// Size and class are fixed once provisioned
+	if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("create git mirror PVC: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: gitMirrorName, Namespace: namespace, Labels: labels, OwnerReferences: ownerRefs},
+		Data:       map[string]string{"repos": gitMirrorRepoList(mirror.Repos)},
+	}
+	meta.Apply(cm)
+	if _, err := config.K8sClient.CoreV1().ConfigMaps(namespace).Create(ctx, cm, v1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("create git mirror ConfigMap: %w", err)
+		}
+		// The mounted file is refreshed in place; the loop picks it up on its next pass
+		if _, err := config.K8sClient.CoreV1().ConfigMaps(namespace).Update(ctx, cm, v1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("update git mirror ConfigMap: %w", err)
+		}
+	}
+
+	deploy := gitMirrorDeployment(namespace, mirror, ownerRefs, config.LoadConfig().AmbientCodeRunnerImage)
+	meta.Apply(deploy, &deploy.Spec.Template)
+	if _, err := config.K8sClient.AppsV1().Deployments(namespace).Create(ctx, deploy, v1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("create git mirror Deployment: %w", err)
+		}
+		if _, err := config.K8sClient.AppsV1().Deployments(namespace).Update(ctx, deploy, v1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("update git mirror Deployment: %w", err)
+		}
+	}
+	return nil
+}
+
+// gitMirrorRepoList renders the "<key> <url>" lines read by gitMirrorScript
+func gitMirrorRepoList(repos []string) string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	for _, repo := range repos {
+		key := gitMirrorKey(repo)
+		if key == "" {
+			log.Printf("Skipping git mirror repo %q: cannot derive a mirror path", repo)
+			continue
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		fmt.Fprintf(&b, "%s %s\n", key, strings.TrimSpace(repo))
+	}
+	return b.String()
+}
+
+func gitMirrorDeployment(namespace string, mirror *apiv1alpha1.GitMirror, ownerRefs []v1.OwnerReference, image string) *appsv1.Deployment {
+	interval := mirror.RefreshIntervalSeconds
+	if interval <= 0 {
+		interval = defaultGitMirrorRefreshSeconds
+	}
+	interval = max(interval, minGitMirrorRefreshSeconds)
+	labels := map[string]string{"app": gitMirrorName}
+
+	env := []corev1.EnvVar{
+		{Name: "REFRESH_INTERVAL", Value: strconv.Itoa(interval)},
+		{Name: "HOME", Value: "/tmp"},
+		{Name: "GIT_TERMINAL_PROMPT", Value: "0"},
+		{Name: "GIT_CONFIG_COUNT", Value: "1"},
+		{Name: "GIT_CONFIG_KEY_0", Value: "credential.helper"},
+		{Name: "GIT_CONFIG_VALUE_0", Value: gitMirrorCredentialHelper},
+	}
+	if mirror.CredentialsSecret != "" {
+		env = append(env, corev1.EnvVar{
+			Name: "GIT_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: mirror.CredentialsSecret},
+				Key:                  "token",
+				Optional:             boolPtr(true),
+			}},
+		})
+	}
+
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: gitMirrorName, Namespace: namespace, Labels: labels, OwnerReferences: ownerRefs},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &v1.LabelSelector{MatchLabels: labels},
+			// A single writer per mirror volume
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					AutomountServiceAccountToken: boolPtr(false),
+					Containers: []corev1.Container{{
+						Name:    "git-mirror",
+						Image:   image,
+						Command: []string{"sh", "-c", gitMirrorScript},
+						Env:     env,
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "mirror", MountPath: "/mirror"},
+							{Name: "repos", MountPath: "/etc/git-mirror", ReadOnly: true},
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("100m"),
+								corev1.ResourceMemory: resource.MustParse("256Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("2Gi"),
+							},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "mirror", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: gitMirrorName}}},
+						{Name: "repos", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: gitMirrorName}}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// deleteGitMirror removes a disabled mirror cache, including its volume
+func deleteGitMirror(ctx context.Context, namespace string) error {
+	deletes := []struct {
+		kind string
+		fn   func() error
+	}{
+		{"Deployment", func() error {
+			return config.K8sClient.AppsV1().Deployments(namespace).Delete(ctx, gitMirrorName, v1.DeleteOptions{})
+		}},
+		{"ConfigMap", func() error {
+			return config.K8sClient.CoreV1().ConfigMaps(namespace).Delete(ctx, gitMirrorName, v1.DeleteOptions{})
+		}},
+		{"PersistentVolumeClaim", func() error {
+			return config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, gitMirrorName, v1.DeleteOptions{})
+		}},
+	}
+	for _, d := range deletes {
+		if err := d.fn(); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete git mirror %s in %s: %w", d.kind, namespace, err)
+		}
+	}
+	return nil
+}
+
+// applyGitMirror mounts the project's mirror volume read-only into the runner container. Runners
+// fall back to plain clones for repos that are not mirrored yet.
+func applyGitMirror(podSpec *corev1.PodSpec, containerName string) {
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "git-mirror",
+		VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: gitMirrorName,
+			ReadOnly:  true,
+		}},
+	})
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == containerName {
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      "git-mirror",
+				MountPath: gitMirrorMountPath,
+				ReadOnly:  true,
+			})
+			podSpec.Containers[i].Env = append(podSpec.Containers[i].Env, corev1.EnvVar{Name: "GIT_MIRROR_DIR", Value: gitMirrorMountPath})
+			break
+		}
+	}
+}
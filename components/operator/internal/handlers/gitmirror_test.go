@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestGitMirrorKey verifies URL forms of the same repo share one mirror. The runner's
+// git_mirror_key is tested against the same cases.
+func TestGitMirrorKey(t *testing.T) {
+	cases := map[string]string{
+		"https://github.com/Org/Repo":              "github.com/org/repo.git",
+		"https://github.com/org/repo.git":          "github.com/org/repo.git",
+		"https://x-access-token:t@github.com/o/r/": "github.com/o/r.git",
+		"git@github.com:org/repo.git":              "github.com/org/repo.git",
+		"ssh://git@gitlab.example.com:22/a/b/c":    "gitlab.example.com:22/a/b/c.git",
+		"https://github.com":                       "",
+		"https://github.com/org/../etc":            "",
+		"":                                         "",
+	}
+	for in, want := range cases {
+		if got := gitMirrorKey(in); got != want {
+			t.Errorf("gitMirrorKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestReconcileGitMirror verifies the mirror is provisioned when enabled and removed when disabled
+func TestReconcileGitMirror(t *testing.T) {
+	setupTestClient()
+	ctx := context.Background()
+	ps := &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "proj", UID: "ps-uid"},
+		Spec: apiv1alpha1.ProjectSettingsSpec{GitMirror: &apiv1alpha1.GitMirror{
+			Enabled:                true,
+			Repos:                  []string{"https://github.com/org/mono", "https://github.com/org/mono.git", "not a url"},
+			RefreshIntervalSeconds: 5,
+			CredentialsSecret:      "mirror-token",
+		}},
+	}
+
+	if err := reconcileGitMirror(ps); err != nil {
+		t.Fatalf("reconcileGitMirror: %v", err)
+	}
+	pvc, err := config.K8sClient.CoreV1().PersistentVolumeClaims("proj").Get(ctx, gitMirrorName, metav1.GetOptions{})
+	if err != nil || pvc.Spec.AccessModes[0] != corev1.ReadWriteMany {
+		t.Fatalf("expected ReadWriteMany mirror PVC, got %v (err %v)", pvc, err)
+	}
+	cm, _ := config.K8sClient.CoreV1().ConfigMaps("proj").Get(ctx, gitMirrorName, metav1.GetOptions{})
+	if got := cm.Data["repos"]; got != "github.com/org/mono.git https://github.com/org/mono\n" {
+		t.Errorf("unexpected repo list %q", got)
+	}
+	deploy, err := config.K8sClient.AppsV1().Deployments("proj").Get(ctx, gitMirrorName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("mirror Deployment missing: %v", err)
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range deploy.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	if env["REFRESH_INTERVAL"].Value != "30" {
+		t.Errorf("refresh interval should be raised to the minimum, got %q", env["REFRESH_INTERVAL"].Value)
+	}
+	if ref := env["GIT_TOKEN"].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "mirror-token" {
+		t.Errorf("expected GIT_TOKEN from mirror-token, got %+v", env["GIT_TOKEN"])
+	}
+	if owner := deploy.OwnerReferences; len(owner) != 1 || owner[0].UID != "ps-uid" {
+		t.Errorf("mirror should be owned by ProjectSettings: %v", owner)
+	}
+
+	ps.Spec.GitMirror.Enabled = false
+	if err := reconcileGitMirror(ps); err != nil {
+		t.Fatalf("reconcileGitMirror (disable): %v", err)
+	}
+	if _, err := config.K8sClient.AppsV1().Deployments("proj").Get(ctx, gitMirrorName, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("mirror Deployment should be deleted: %v", err)
+	}
+	if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims("proj").Get(ctx, gitMirrorName, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("mirror PVC should be deleted: %v", err)
+	}
+}
+
+// TestApplyGitMirror_RunnerOnly verifies only the runner sees the read-only mirror
+func TestApplyGitMirror_RunnerOnly(t *testing.T) {
+	podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "ambient-content"}, {Name: "ambient-code-runner"}}}
+	applyGitMirror(&podSpec, "ambient-code-runner")
+
+	if len(podSpec.Containers[0].VolumeMounts) != 0 {
+		t.Errorf("content container should not mount the mirror")
+	}
+	runner := podSpec.Containers[1]
+	if len(runner.VolumeMounts) != 1 || !runner.VolumeMounts[0].ReadOnly || runner.VolumeMounts[0].MountPath != gitMirrorMountPath {
+		t.Errorf("unexpected runner mounts: %v", runner.VolumeMounts)
+	}
+	if len(runner.Env) != 1 || runner.Env[0].Name != "GIT_MIRROR_DIR" || !strings.HasPrefix(runner.Env[0].Value, "/") {
+		t.Errorf("unexpected runner env: %v", runner.Env)
+	}
+}
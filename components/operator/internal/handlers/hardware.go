@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// sessionHardware returns the runner hardware for the session: ProjectSettings
+// spec.runnerHardware with the session's spec.hardware applied on top; nil when neither is set
+func sessionHardware(ctx context.Context, session *unstructured.Unstructured) (*apiv1alpha1.RunnerHardware, error) {
+	var project *apiv1alpha1.RunnerHardware
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(session.GetNamespace()).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	if err == nil {
+		project = ps.Spec.RunnerHardware
+	}
+
+	var override *apiv1alpha1.RunnerHardware
+	if raw, found, _ := unstructured.NestedMap(session.Object, "spec", "hardware"); found {
+		override = &apiv1alpha1.RunnerHardware{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, override); err != nil {
+			return nil, fmt.Errorf("invalid spec.hardware: %w", err)
+		}
+	}
+	hw := project.Merge(override)
+	if err := hw.Validate(); err != nil {
+		return nil, err
+	}
+	return hw, nil
+}
+
+// applyRunnerHardware sets the runner pod's RuntimeClass, requests GPUs for the named
+// container and requires nodes of the architecture
+func applyRunnerHardware(podSpec *corev1.PodSpec, containerName string, hw *apiv1alpha1.RunnerHardware) {
+	if hw.RuntimeClassName != "" {
+		name := hw.RuntimeClassName
+		podSpec.RuntimeClassName = &name
+	}
+
+	if hw.GPUs > 0 {
+		gpus := *resource.NewQuantity(hw.GPUs, resource.DecimalSI)
+		for i := range podSpec.Containers {
+			c := &podSpec.Containers[i]
+			if c.Name != containerName {
+				continue
+			}
+			if c.Resources.Limits == nil {
+				c.Resources.Limits = corev1.ResourceList{}
+			}
+			if c.Resources.Requests == nil {
+				c.Resources.Requests = corev1.ResourceList{}
+			}
+			// Extended resources cannot be overcommitted: requests must equal limits
+			c.Resources.Limits[apiv1alpha1.GPUResourceName] = gpus
+			c.Resources.Requests[apiv1alpha1.GPUResourceName] = gpus
+		}
+		// GPU node pools are commonly tainted so only GPU workloads land there
+		podSpec.Tolerations = append(podSpec.Tolerations, corev1.Toleration{
+			Key:      apiv1alpha1.GPUResourceName,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+
+	if hw.Architecture != "" {
+		if podSpec.Affinity == nil {
+			podSpec.Affinity = &corev1.Affinity{}
+		}
+		if podSpec.Affinity.NodeAffinity == nil {
+			podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+		}
+		na := podSpec.Affinity.NodeAffinity
+		req := corev1.NodeSelectorRequirement{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{hw.Architecture}}
+		if na.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+			na.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{}},
+			}
+		}
+		// Terms are ORed; the requirement must hold in each of them
+		terms := na.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		for i := range terms {
+			terms[i].MatchExpressions = append(terms[i].MatchExpressions, req)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TestSessionHardware verifies the session's spec.hardware overrides the project defaults field by field
+func TestSessionHardware(t *testing.T) {
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	ctx := context.Background()
+	if _, err := config.VteamClient.VteamV1alpha1().ProjectSettings("team-a").Create(ctx, &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "team-a"},
+		Spec:       apiv1alpha1.ProjectSettingsSpec{RunnerHardware: &apiv1alpha1.RunnerHardware{RuntimeClassName: "nvidia", GPUs: 1}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	session := func(ns string, hw map[string]interface{}) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{}}}
+		u.SetNamespace(ns)
+		if hw != nil {
+			u.Object["spec"].(map[string]interface{})["hardware"] = hw
+		}
+		return u
+	}
+
+	hw, err := sessionHardware(ctx, session("team-a", map[string]interface{}{"gpus": int64(2), "architecture": "arm64"}))
+	want := apiv1alpha1.RunnerHardware{RuntimeClassName: "nvidia", GPUs: 2, Architecture: "arm64"}
+	if err != nil || hw == nil || *hw != want {
+		t.Errorf("team-a: hardware = %+v, err = %v, want %+v", hw, err, want)
+	}
+	if hw, err := sessionHardware(ctx, session("team-b", nil)); err != nil || hw != nil {
+		t.Errorf("team-b: hardware = %+v, err = %v, want none", hw, err)
+	}
+	if _, err := sessionHardware(ctx, session("team-b", map[string]interface{}{"architecture": "s390x"})); err == nil {
+		t.Error("an unsupported architecture must be rejected")
+	}
+}
+
+// TestApplyRunnerHardware verifies GPUs go to the runner container only and the architecture
+// is required alongside existing node affinity terms
+func TestApplyRunnerHardware(t *testing.T) {
+	podSpec := &corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "ambient-content"}, {Name: "ambient-code-runner"}},
+		Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}}},
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}}}},
+			}},
+		}},
+	}
+	applyRunnerHardware(podSpec, "ambient-code-runner", &apiv1alpha1.RunnerHardware{RuntimeClassName: "nvidia", GPUs: 2, Architecture: "amd64"})
+
+	if podSpec.RuntimeClassName == nil || *podSpec.RuntimeClassName != "nvidia" {
+		t.Errorf("runtimeClassName = %v", podSpec.RuntimeClassName)
+	}
+	if _, ok := podSpec.Containers[0].Resources.Limits[apiv1alpha1.GPUResourceName]; ok {
+		t.Error("GPUs must only be requested for the runner container")
+	}
+	gpus := podSpec.Containers[1].Resources.Limits[apiv1alpha1.GPUResourceName]
+	if gpus.Value() != 2 || podSpec.Containers[1].Resources.Requests.Name(apiv1alpha1.GPUResourceName, "").Value() != 2 {
+		t.Errorf("runner resources = %+v", podSpec.Containers[1].Resources)
+	}
+	if len(podSpec.Tolerations) != 1 || podSpec.Tolerations[0].Key != apiv1alpha1.GPUResourceName {
+		t.Errorf("tolerations = %+v", podSpec.Tolerations)
+	}
+	for i, term := range podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		last := term.MatchExpressions[len(term.MatchExpressions)-1]
+		if len(term.MatchExpressions) != 2 || last.Key != corev1.LabelArchStable || last.Values[0] != "amd64" {
+			t.Errorf("term %d = %+v", i, term)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// issueAnnotation names the issue a session works on; the backend sets it from the
+	// create request's issue field
+	issueAnnotation = "ambient-code.io/issue"
+	// issueUpdateAnnotation records the outcome of the issue update ("Updated" or
+	// "Failed: <reason>") so each session updates its issue at most once
+	issueUpdateAnnotation = "vteam.ambient-code/issue-update"
+)
+
+// Issue providers
+const (
+	issueProviderGitHub = "github"
+	issueProviderJira   = "jira"
+)
+
+const defaultIssueCommentTemplate = `Session {{.Session}} opened {{if gt (len .PullRequestURLs) 1}}pull requests{{else}}a pull request{{end}} for this issue:
+{{range .PullRequestURLs}}
+- {{.}}{{end}}
+{{- if .Summary}}
+
+{{.Summary}}{{end}}`
+
+// issueHTTPClient calls the GitHub and Jira APIs
+var issueHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+var (
+	jiraKeyPattern        = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-[0-9]+$`)
+	githubShorthandIssue  = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#([0-9]+)$`)
+	githubIssuePathSuffix = regexp.MustCompile(`^/([\w.-]+)/([\w.-]+)/issues/([0-9]+)/?$`)
+)
+
+// issueCommentData are the variables available to spec.issueUpdates.commentTemplate
+type issueCommentData struct {
+	PullRequestURL  string
+	PullRequestURLs []string
+	Session         string
+	Summary         string
+	Outcome         string
+}
+
+// issueRef is a parsed ambient-code.io/issue annotation
+type issueRef struct {
+	Provider string
+	// APIBase is the GitHub REST API root, or the Jira site URL
+	APIBase string
+	Owner   string
+	Repo    string
+	Number  string
+	// Key is the Jira issue key
+	Key string
+}
+
+// issueCredentials are read from the project's integration secret
+type issueCredentials struct {
+	GitHubToken  string
+	JiraURL      string
+	JiraEmail    string
+	JiraAPIToken string
+}
+
+// parseIssueRef recognizes GitHub issue URLs (github.com or GitHub Enterprise), owner/repo#N,
+// Jira issue URLs (…/browse/KEY-1) and bare Jira keys, which resolve against jiraURL
+func parseIssueRef(issue, jiraURL string) (*issueRef, error) {
+	issue = strings.TrimSpace(issue)
+	if m := githubShorthandIssue.FindStringSubmatch(issue); m != nil {
+		return &issueRef{Provider: issueProviderGitHub, APIBase: "https://api.github.com", Owner: m[1], Repo: m[2], Number: m[3]}, nil
+	}
+	if jiraKeyPattern.MatchString(issue) {
+		if jiraURL == "" {
+			return nil, fmt.Errorf("Jira issue %s needs JIRA_URL in the integration secret", issue)
+		}
+		return &issueRef{Provider: issueProviderJira, APIBase: strings.TrimRight(jiraURL, "/"), Key: issue}, nil
+	}
+	u, err := url.Parse(issue)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("unrecognized issue reference %q", issue)
+	}
+	if i := strings.Index(u.Path, "/browse/"); i >= 0 {
+		key := strings.Trim(u.Path[i+len("/browse/"):], "/")
+		if jiraKeyPattern.MatchString(key) {
+			return &issueRef{Provider: issueProviderJira, APIBase: u.Scheme + "://" + u.Host + u.Path[:i], Key: key}, nil
+		}
+	}
+	if m := githubIssuePathSuffix.FindStringSubmatch(u.Path); m != nil {
+		api := u.Scheme + "://" + u.Host + "/api/v3"
+		if u.Host == "github.com" {
+			api = "https://api.github.com"
+		}
+		return &issueRef{Provider: issueProviderGitHub, APIBase: api, Owner: m[1], Repo: m[2], Number: m[3]}, nil
+	}
+	return nil, fmt.Errorf("unrecognized issue reference %q", issue)
+}
+
+// maybeUpdateIssue comments on, labels and links the issue a completed session worked on,
+// when the project enables spec.issueUpdates and the session opened pull requests. The
+// outcome is recorded on the session so the issue is updated at most once; failures are
+// recorded rather than retried, since they are usually missing credentials or access.
+func maybeUpdateIssue(ctx context.Context, session *unstructured.Unstructured) error {
+	annotations := session.GetAnnotations()
+	issue := annotations[issueAnnotation]
+	if issue == "" || annotations[issueUpdateAnnotation] != "" {
+		return nil
+	}
+	prURLs, _, _ := unstructured.NestedStringSlice(session.Object, "status", "result", "prURLs")
+	if len(prURLs) == 0 {
+		return nil
+	}
+	namespace := session.GetNamespace()
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("read ProjectSettings: %w", err)
+	}
+	policy := ps.Spec.IssueUpdates
+	if policy == nil || !policy.Enabled {
+		return nil
+	}
+
+	outcome := "Updated"
+	if err := updateIssue(ctx, session, issue, prURLs, policy); err != nil {
+		log.Printf("Failed to update issue %s for %s/%s: %v", issue, namespace, session.GetName(), err)
+		outcome = "Failed: " + err.Error()
+	} else {
+		log.Printf("Updated issue %s with the pull requests of %s/%s", issue, namespace, session.GetName())
+	}
+
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]string{issueUpdateAnnotation: outcome}},
+	})
+	gvr := types.GetAgenticSessionResource()
+	if _, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Patch(ctx, session.GetName(), ktypes.MergePatchType, patch, v1.PatchOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("record issue update: %w", err)
+	}
+	return nil
+}
+
+func updateIssue(ctx context.Context, session *unstructured.Unstructured, issue string, prURLs []string, policy *apiv1alpha1.IssueUpdates) error {
+	creds, err := readIssueCredentials(ctx, session.GetNamespace(), session.GetName())
+	if err != nil {
+		return err
+	}
+	ref, err := parseIssueRef(issue, creds.JiraURL)
+	if err != nil {
+		return err
+	}
+	summary, _, _ := unstructured.NestedString(session.Object, "status", "result", "summary")
+	outcome, _, _ := unstructured.NestedString(session.Object, "status", "result", "outcome")
+	comment, err := renderIssueComment(policy.CommentTemplate, issueCommentData{
+		PullRequestURL:  prURLs[0],
+		PullRequestURLs: prURLs,
+		Session:         session.GetName(),
+		Summary:         summary,
+		Outcome:         outcome,
+	})
+	if err != nil {
+		return err
+	}
+	if ref.Provider == issueProviderJira {
+		return updateJiraIssue(ctx, ref, creds, comment, prURLs, policy)
+	}
+	return updateGitHubIssue(ctx, ref, creds.GitHubToken, comment, policy.Labels)
+}
+
+// readIssueCredentials reads GITHUB_TOKEN and JIRA_* from the integration secret. Without a
+// GITHUB_TOKEN there, the session's GitHub App token is used.
+func readIssueCredentials(ctx context.Context, namespace, session string) (issueCredentials, error) {
+	var creds issueCredentials
+	secret, err := config.K8sClient.CoreV1().Secrets(namespace).Get(ctx, integrationSecretsName, v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return creds, fmt.Errorf("read %s: %w", integrationSecretsName, err)
+	}
+	if err == nil {
+		creds.GitHubToken = strings.TrimSpace(string(secret.Data["GITHUB_TOKEN"]))
+		creds.JiraURL = strings.TrimSpace(string(secret.Data["JIRA_URL"]))
+		creds.JiraEmail = strings.TrimSpace(string(secret.Data["JIRA_EMAIL"]))
+		creds.JiraAPIToken = strings.TrimSpace(string(secret.Data["JIRA_API_TOKEN"]))
+	}
+	if creds.GitHubToken == "" {
+		if s, err := config.K8sClient.CoreV1().Secrets(namespace).Get(ctx, githubTokenSecretName(session), v1.GetOptions{}); err == nil {
+			creds.GitHubToken = strings.TrimSpace(string(s.Data[githubTokenKey]))
+		}
+	}
+	return creds, nil
+}
+
+func renderIssueComment(tmpl string, data issueCommentData) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = defaultIssueCommentTemplate
+	}
+	t, err := template.New("issue").Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid issueUpdates.commentTemplate: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render issueUpdates.commentTemplate: %w", err)
+	}
+	return b.String(), nil
+}
+
+// updateGitHubIssue comments on the issue and adds the labels. The comment links the pull
+// requests, which GitHub cross-references on the issue timeline.
+func updateGitHubIssue(ctx context.Context, ref *issueRef, token, comment string, labels []string) error {
+	if token == "" {
+		return fmt.Errorf("no GitHub token: set GITHUB_TOKEN in the integration secret")
+	}
+	auth := "Bearer " + token
+	base := fmt.Sprintf("%s/repos/%s/%s/issues/%s", ref.APIBase, ref.Owner, ref.Repo, ref.Number)
+	if err := issueRequest(ctx, http.MethodPost, base+"/comments", auth, map[string]interface{}{"body": comment}, nil); err != nil {
+		return fmt.Errorf("comment on GitHub issue: %w", err)
+	}
+	if len(labels) > 0 {
+		if err := issueRequest(ctx, http.MethodPost, base+"/labels", auth, map[string]interface{}{"labels": labels}, nil); err != nil {
+			return fmt.Errorf("label GitHub issue: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateJiraIssue comments on the issue, links each pull request, adds the labels and
+// applies the configured transition. Remote links use the pull request URL as their global
+// ID, so relinking the same pull request updates the link instead of duplicating it.
+func updateJiraIssue(ctx context.Context, ref *issueRef, creds issueCredentials, comment string, prURLs []string, policy *apiv1alpha1.IssueUpdates) error {
+	if creds.JiraAPIToken == "" {
+		return fmt.Errorf("no Jira credentials: set JIRA_API_TOKEN in the integration secret")
+	}
+	// Jira Cloud takes the account email and an API token; Jira Data Center a personal access token
+	auth := "Bearer " + creds.JiraAPIToken
+	if creds.JiraEmail != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(creds.JiraEmail+":"+creds.JiraAPIToken))
+	}
+	base := fmt.Sprintf("%s/rest/api/2/issue/%s", ref.APIBase, ref.Key)
+
+	if err := issueRequest(ctx, http.MethodPost, base+"/comment", auth, map[string]interface{}{"body": comment}, nil); err != nil {
+		return fmt.Errorf("comment on Jira issue: %w", err)
+	}
+	for _, pr := range prURLs {
+		link := map[string]interface{}{
+			"globalId": pr,
+			"object":   map[string]interface{}{"url": pr, "title": "Pull request " + pr},
+		}
+		if err := issueRequest(ctx, http.MethodPost, base+"/remotelink", auth, link, nil); err != nil {
+			return fmt.Errorf("link pull request to Jira issue: %w", err)
+		}
+	}
+	if len(policy.Labels) > 0 {
+		add := make([]interface{}, 0, len(policy.Labels))
+		for _, l := range policy.Labels {
+			add = append(add, map[string]interface{}{"add": l})
+		}
+		if err := issueRequest(ctx, http.MethodPut, base, auth, map[string]interface{}{"update": map[string]interface{}{"labels": add}}, nil); err != nil {
+			return fmt.Errorf("label Jira issue: %w", err)
+		}
+	}
+	if policy.JiraTransition == "" {
+		return nil
+	}
+	var transitions struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := issueRequest(ctx, http.MethodGet, base+"/transitions", auth, nil, &transitions); err != nil {
+		return fmt.Errorf("list Jira transitions: %w", err)
+	}
+	for _, t := range transitions.Transitions {
+		if strings.EqualFold(t.Name, policy.JiraTransition) || strings.EqualFold(t.To.Name, policy.JiraTransition) {
+			body := map[string]interface{}{"transition": map[string]interface{}{"id": t.ID}}
+			if err := issueRequest(ctx, http.MethodPost, base+"/transitions", auth, body, nil); err != nil {
+				return fmt.Errorf("transition Jira issue: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("Jira transition %q is not available for %s", policy.JiraTransition, ref.Key)
+}
+
+// issueRequest sends a JSON request to an issue tracker and decodes the response into out
+func issueRequest(ctx context.Context, method, target, auth string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := issueHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned %d: %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
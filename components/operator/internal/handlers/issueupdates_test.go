@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestParseIssueRef(t *testing.T) {
+	cases := []struct {
+		issue string
+		want  issueRef
+	}{
+		{"https://github.com/org/app/issues/12", issueRef{Provider: issueProviderGitHub, APIBase: "https://api.github.com", Owner: "org", Repo: "app", Number: "12"}},
+		{"https://git.example.com/org/app/issues/3", issueRef{Provider: issueProviderGitHub, APIBase: "https://git.example.com/api/v3", Owner: "org", Repo: "app", Number: "3"}},
+		{"org/app#7", issueRef{Provider: issueProviderGitHub, APIBase: "https://api.github.com", Owner: "org", Repo: "app", Number: "7"}},
+		{"https://acme.atlassian.net/browse/PLAT-42", issueRef{Provider: issueProviderJira, APIBase: "https://acme.atlassian.net", Key: "PLAT-42"}},
+		{"PLAT-42", issueRef{Provider: issueProviderJira, APIBase: "https://jira.example.com", Key: "PLAT-42"}},
+	}
+	for _, tc := range cases {
+		got, err := parseIssueRef(tc.issue, "https://jira.example.com/")
+		if err != nil || *got != tc.want {
+			t.Errorf("parseIssueRef(%q) = %+v, %v; want %+v", tc.issue, got, err, tc.want)
+		}
+	}
+	for _, issue := range []string{"fix the login bug", "https://github.com/org/app/pull/7"} {
+		if _, err := parseIssueRef(issue, ""); err == nil {
+			t.Errorf("parseIssueRef(%q) should fail", issue)
+		}
+	}
+	if _, err := parseIssueRef("PLAT-42", ""); err == nil {
+		t.Error("a bare Jira key needs JIRA_URL")
+	}
+}
+
+// TestMaybeUpdateIssueGitHub verifies a completed session comments on and labels its GitHub
+// issue once, and records the outcome on the session
+func TestMaybeUpdateIssueGitHub(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var calls []string
+	var comment string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer ghp_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/comments") {
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			comment = body["body"]
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	src := testSession("impl", "Completed", map[string]interface{}{"prompt": "fix it"})
+	src.SetAnnotations(map[string]string{issueAnnotation: srv.URL + "/org/app/issues/12"})
+	_ = unstructured.SetNestedField(src.Object, map[string]interface{}{
+		"outcome": "Succeeded",
+		"summary": "Fixed the redirect loop",
+		"prURLs":  []interface{}{"https://github.com/org/app/pull/7"},
+	}, "status", "result")
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, src)
+	setupTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: integrationSecretsName, Namespace: "proj"},
+		Data:       map[string][]byte{"GITHUB_TOKEN": []byte("ghp_test")},
+	})
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	if _, err := config.VteamClient.VteamV1alpha1().ProjectSettings("proj").Create(ctx, &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "proj"},
+		Spec: apiv1alpha1.ProjectSettingsSpec{IssueUpdates: &apiv1alpha1.IssueUpdates{
+			Enabled: true,
+			Labels:  []string{"has-pr"},
+		}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create ProjectSettings: %v", err)
+	}
+
+	if err := maybeUpdateIssue(ctx, src); err != nil {
+		t.Fatalf("maybeUpdateIssue: %v", err)
+	}
+	want := []string{"POST /api/v3/repos/org/app/issues/12/comments", "POST /api/v3/repos/org/app/issues/12/labels"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if !strings.Contains(comment, "https://github.com/org/app/pull/7") || !strings.Contains(comment, "Fixed the redirect loop") {
+		t.Errorf("comment = %q", comment)
+	}
+	updated, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Get(ctx, "impl", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := updated.GetAnnotations()[issueUpdateAnnotation]; got != "Updated" {
+		t.Fatalf("%s = %q, want Updated", issueUpdateAnnotation, got)
+	}
+
+	// The recorded outcome keeps later watch events from commenting again
+	calls = nil
+	if err := maybeUpdateIssue(ctx, updated); err != nil || len(calls) != 0 {
+		t.Errorf("second update made calls %v (err %v)", calls, err)
+	}
+}
+
+// TestUpdateJiraIssue verifies the comment, pull request links, labels and the transition
+// matched by name
+func TestUpdateJiraIssue(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "bot@example.com" || pass != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/transitions") {
+			var body struct {
+				Transition struct {
+					ID string `json:"id"`
+				} `json:"transition"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			calls[len(calls)-1] += " " + body.Transition.ID
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"transitions": [{"id": "11", "name": "Start", "to": {"name": "In Progress"}}, {"id": "31", "name": "Review", "to": {"name": "In Review"}}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ref := &issueRef{Provider: issueProviderJira, APIBase: srv.URL, Key: "PLAT-42"}
+	creds := issueCredentials{JiraEmail: "bot@example.com", JiraAPIToken: "tok"}
+	policy := &apiv1alpha1.IssueUpdates{Enabled: true, Labels: []string{"ai"}, JiraTransition: "in review"}
+	if err := updateJiraIssue(context.Background(), ref, creds, "done", []string{"https://github.com/org/app/pull/7"}, policy); err != nil {
+		t.Fatalf("updateJiraIssue: %v", err)
+	}
+	want := []string{
+		"POST /rest/api/2/issue/PLAT-42/comment",
+		"POST /rest/api/2/issue/PLAT-42/remotelink",
+		"PUT /rest/api/2/issue/PLAT-42",
+		"GET /rest/api/2/issue/PLAT-42/transitions",
+		"POST /rest/api/2/issue/PLAT-42/transitions 31",
+	}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	policy.JiraTransition = "Done"
+	if err := updateJiraIssue(context.Background(), ref, creds, "done", nil, policy); err == nil {
+		t.Error("an unavailable transition should fail")
+	}
+}
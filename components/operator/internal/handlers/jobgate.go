@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/diagnostics"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+	"ambient-code-pkg/runtimeconfig"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MaxConcurrentJobs caps runner Jobs running across the whole cluster, independent of any
+// per-project quota. 0 means unlimited. Set from the --max-concurrent-jobs flag; the runtime
+// config's operator.maxConcurrentJobs overrides it without a restart.
+var MaxConcurrentJobs int
+
+// maxConcurrentJobs is the cluster-wide limit in effect
+func maxConcurrentJobs() int {
+	if o := runtimeconfig.Current().Operator; o != nil && o.MaxConcurrentJobs != nil {
+		return *o.MaxConcurrentJobs
+	}
+	return MaxConcurrentJobs
+}
+
+// jobGateMu serializes the "count running Jobs, then create one" sequence so two sessions
+// cannot both take the last free slot.
+var jobGateMu sync.Mutex
+
+// queuedConditionType marks a Pending session that is waiting for a cluster-wide runner slot
+const queuedConditionType = "Queued"
+
+// clusterJobLimitReason is the Queued condition reason of sessions waiting for a runner slot
+const clusterJobLimitReason = "ClusterJobLimit"
+
+// requeueInterval is how often queued sessions are retried
+const requeueInterval = 15 * time.Second
+
+// reserveJobSlot checks the cluster-wide limit before a runner Job is created. When a slot
+// is free it returns ok=true and holds the gate; the caller must call release once the Job
+// create call has returned. When the cluster is full it returns ok=false and the number of
+// runner Jobs currently active.
+func reserveJobSlot() (release func(), running int, ok bool, err error) {
+	limit := maxConcurrentJobs()
+	if limit <= 0 {
+		return func() {}, 0, true, nil
+	}
+	jobGateMu.Lock()
+	running, err = countActiveRunnerJobs()
+	if err != nil {
+		jobGateMu.Unlock()
+		return nil, 0, false, err
+	}
+	if running >= limit {
+		jobGateMu.Unlock()
+		return nil, running, false, nil
+	}
+	return jobGateMu.Unlock, running, true, nil
+}
+
+// countActiveRunnerJobs counts runner Jobs in all namespaces that are running and not finished
+func countActiveRunnerJobs() (int, error) {
+	jobs, err := config.K8sClient.BatchV1().Jobs("").List(context.TODO(), v1.ListOptions{LabelSelector: "app=ambient-code-runner"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list runner jobs: %w", err)
+	}
+	active := 0
+	for i := range jobs.Items {
+		// Suspended Jobs (waiting for Kueue) hold no runner
+		if !isJobFinished(&jobs.Items[i]) && !isJobSuspended(&jobs.Items[i]) {
+			active++
+		}
+	}
+	return active, nil
+}
+
+func isJobFinished(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if (cond.Type == batchv1.JobComplete || cond.Type == batchv1.JobFailed) && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// markSessionQueued records why a Pending session is waiting. The session stays Pending
+// and is retried by RequeueQueuedSessions. Already-queued sessions are left untouched so
+// the status write does not retrigger the watch on every retry.
+func markSessionQueued(session *unstructured.Unstructured, running int) error {
+	if queuedReason(session) == clusterJobLimitReason {
+		return nil
+	}
+	msg := fmt.Sprintf("Waiting for a runner slot: %d of %d runner jobs are running cluster-wide", running, maxConcurrentJobs())
+	return setQueuedCondition(session, v1.ConditionTrue, clusterJobLimitReason, msg, msg)
+}
+
+// clearQueuedCondition flips the Queued condition once the session got a slot
+func clearQueuedCondition(session *unstructured.Unstructured) error {
+	if !isSessionQueued(session) {
+		return nil
+	}
+	return setQueuedCondition(session, v1.ConditionFalse, "SlotAvailable", "A runner slot became available", "")
+}
+
+// setQueuedCondition upserts the session's Queued condition, keeping the other conditions
+// (WorkspaceCloned and the like), and sets status.message when message is not empty. A
+// merge patch of status.conditions would replace the whole list.
+func setQueuedCondition(session *unstructured.Unstructured, status v1.ConditionStatus, reason, condMessage, message string) error {
+	return statusupdater.Mutate(context.TODO(), types.GetAgenticSessionResource(), session.GetNamespace(), session.GetName(), func(st map[string]interface{}) error {
+		if !setSessionCondition(st, queuedCondition(status, reason, condMessage)) {
+			return statusupdater.ErrNoChange
+		}
+		if message != "" {
+			st["message"] = message
+		}
+		return nil
+	})
+}
+
+func queuedCondition(status v1.ConditionStatus, reason, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               queuedConditionType,
+		"status":             string(status),
+		"reason":             reason,
+		"message":            message,
+		"lastTransitionTime": time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func isSessionQueued(session *unstructured.Unstructured) bool {
+	return queuedReason(session) != ""
+}
+
+// RequeueQueuedSessions periodically retries sessions waiting for a runner slot, for their
+// provider's rate limit or for a window of their project's schedule, oldest first, so
+// capacity freed by finished Jobs is handed out in arrival order.
+func RequeueQueuedSessions() {
+	rateLimitScheduling := config.LoadConfig().RateLimitScheduling
+	if MaxConcurrentJobs > 0 {
+		log.Printf("Cluster-wide runner job limit: %d", MaxConcurrentJobs)
+	}
+	if rateLimitScheduling {
+		log.Printf("Rate limit aware scheduling enabled")
+	}
+	for {
+		time.Sleep(requeueInterval)
+		retryQueuedSessions()
+	}
+}
+
+// retryQueuedSessions reprocesses all queued Pending sessions, oldest first
+func retryQueuedSessions() {
+	list, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		log.Printf("Failed to list queued sessions: %v", err)
+		return
+	}
+	queued := []unstructured.Unstructured{}
+	for _, item := range list.Items {
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		if phase == "Pending" && isSessionQueued(&item) && WatchScope.Allows(context.TODO(), item.GetNamespace()) {
+			queued = append(queued, item)
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].GetCreationTimestamp().Time.Before(queued[j].GetCreationTimestamp().Time)
+	})
+	for i := range queued {
+		done := diagnostics.Default.BeginReconcile("AgenticSession", queued[i].GetNamespace(), queued[i].GetName())
+		err := handleAgenticSessionEvent(&queued[i])
+		done(err)
+		if err != nil {
+			log.Printf("Error retrying queued session %s/%s: %v", queued[i].GetNamespace(), queued[i].GetName(), err)
+		}
+	}
+}
+
+// reserveRunnerCapacity reserves the session's provider rate limit key (unless key is "")
+// and a cluster-wide runner slot. When either is taken the session is marked waiting and
+// held is true; otherwise release frees both once the runner Job exists.
+func reserveRunnerCapacity(session *unstructured.Unstructured, key string, appConfig *config.Config) (release func(), held bool, err error) {
+	releaseKey := func() {}
+	if key != "" {
+		r, msg, ok, err := reserveRateLimitSlot(context.TODO(), key, appConfig, time.Now())
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to check provider rate limits: %w", err)
+		}
+		if !ok {
+			log.Printf("Session %s/%s held for rate limit key %s: %s", session.GetNamespace(), session.GetName(), key, msg)
+			return nil, true, markSessionRateLimited(session, msg)
+		}
+		releaseKey = r
+	}
+	releaseSlot, running, ok, err := reserveJobSlot()
+	if err != nil {
+		releaseKey()
+		return nil, false, fmt.Errorf("failed to check runner job capacity: %w", err)
+	}
+	if !ok {
+		releaseKey()
+		log.Printf("Session %s/%s queued: %d of %d runner jobs running cluster-wide", session.GetNamespace(), session.GetName(), running, maxConcurrentJobs())
+		return nil, true, markSessionQueued(session, running)
+	}
+	return func() {
+		releaseSlot()
+		releaseKey()
+	}, false, nil
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	"ambient-code-pkg/runtimeconfig"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newRunnerJob(namespace, name string, finished bool) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "ambient-code-runner"},
+		},
+	}
+	if finished {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	}
+	return job
+}
+
+// TestReserveJobSlot_CountsActiveRunnerJobsClusterWide verifies the cap spans namespaces and ignores finished or unrelated Jobs
+func TestReserveJobSlot_CountsActiveRunnerJobsClusterWide(t *testing.T) {
+	unrelated := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "project-a"}}
+	setupTestClient(
+		newRunnerJob("project-a", "s1-job", false),
+		newRunnerJob("project-b", "s2-job", false),
+		newRunnerJob("project-b", "s3-job", true),
+		unrelated,
+	)
+	defer func() { MaxConcurrentJobs = 0 }()
+
+	MaxConcurrentJobs = 2
+	_, running, ok, err := reserveJobSlot()
+	if err != nil {
+		t.Fatalf("reserveJobSlot failed: %v", err)
+	}
+	if ok {
+		t.Error("Expected no free slot with 2 of 2 runner jobs active")
+	}
+	if running != 2 {
+		t.Errorf("Expected 2 active runner jobs, got %d", running)
+	}
+
+	MaxConcurrentJobs = 3
+	release, _, ok, err := reserveJobSlot()
+	if err != nil || !ok {
+		t.Fatalf("Expected a free slot with 2 of 3 runner jobs active, got ok=%v err=%v", ok, err)
+	}
+	release()
+
+	// The runtime config's limit replaces the flag's
+	limit := 2
+	runtimeconfig.SetCurrent(&runtimeconfig.Settings{Operator: &runtimeconfig.OperatorSettings{MaxConcurrentJobs: &limit}})
+	defer runtimeconfig.SetCurrent(nil)
+	if _, _, ok, _ := reserveJobSlot(); ok {
+		t.Error("Expected the runtime config's limit of 2 to leave no free slot")
+	}
+}
+
+// TestIsSessionQueued verifies only a true Queued condition holds a session back
+func TestIsSessionQueued(t *testing.T) {
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":      "Pending",
+			"conditions": []interface{}{queuedCondition(metav1.ConditionTrue, "ClusterJobLimit", "waiting")},
+		},
+	}}
+	if !isSessionQueued(session) {
+		t.Error("Expected session with Queued=True to be queued")
+	}
+
+	session.Object["status"].(map[string]interface{})["conditions"] = []interface{}{queuedCondition(metav1.ConditionFalse, "SlotAvailable", "go")}
+	if isSessionQueued(session) {
+		t.Error("Expected session with Queued=False not to be queued")
+	}
+}
+
+// TestMarkSessionQueued_KeepsOtherConditions verifies queueing and releasing a session
+// upsert only the Queued condition, so WorkspaceCloned and the like survive
+func TestMarkSessionQueued_KeepsOtherConditions(t *testing.T) {
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": "busy"},
+		"status": map[string]interface{}{
+			"phase":      "Pending",
+			"conditions": []interface{}{sessionCondition("WorkspaceCloned", metav1.ConditionTrue, "Cloned", "cloned")},
+		},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, session)
+	get := func() *unstructured.Unstructured {
+		obj, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("busy").Get(context.Background(), "s1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+	hasCloned := func(obj *unstructured.Unstructured) bool {
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			if c.(map[string]interface{})["type"] == "WorkspaceCloned" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if err := markSessionQueued(session, 3); err != nil {
+		t.Fatal(err)
+	}
+	queued := get()
+	if queuedReason(queued) != clusterJobLimitReason || !hasCloned(queued) {
+		t.Fatalf("after queueing: reason %q, WorkspaceCloned kept %v", queuedReason(queued), hasCloned(queued))
+	}
+	if err := clearQueuedCondition(queued); err != nil {
+		t.Fatal(err)
+	}
+	released := get()
+	if isSessionQueued(released) || !hasCloned(released) {
+		t.Errorf("after release: queued %v, WorkspaceCloned kept %v", isSessionQueued(released), hasCloned(released))
+	}
+}
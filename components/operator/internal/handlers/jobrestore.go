@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+// Runner Jobs are named after their session, so a Job left behind by an operator crash, an
+// earlier run or an earlier session of the same name could be mistaken for the one the
+// session needs now. Each Job carries an idempotency key, the session UID and run attempt
+// (status.runAttempt, raised by restarts and preemption retries). The operator adopts a
+// Job with the session's key instead of creating a second one, and replaces any other.
+
+// jobIdempotencyKeyAnnotation holds "<session UID>-<run attempt>" on runner Jobs
+const jobIdempotencyKeyAnnotation = "ambient-code.io/idempotency-key"
+
+// jobGoneInterval and jobGoneTimeout bound the wait for a replaced Job to disappear
+var (
+	jobGoneInterval = 2 * time.Second
+	jobGoneTimeout  = 2 * time.Minute
+)
+
+func runAttempt(session *unstructured.Unstructured) int64 {
+	attempt, _, _ := unstructured.NestedInt64(session.Object, "status", "runAttempt")
+	return attempt
+}
+
+func jobIdempotencyKey(session *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s-%d", session.GetUID(), runAttempt(session))
+}
+
+// jobBelongsToRun reports whether job was created for the session's current run
+func jobBelongsToRun(job *batchv1.Job, session *unstructured.Unstructured) bool {
+	if key, ok := job.Annotations[jobIdempotencyKeyAnnotation]; ok {
+		return key == jobIdempotencyKey(session)
+	}
+	// Jobs created before idempotency keys can only be the first run's
+	if runAttempt(session) != 0 {
+		return false
+	}
+	for _, ref := range job.OwnerReferences {
+		if ref.UID == session.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileExistingJob handles a Pending session whose Job name is taken. A Job of the
+// current run was created before a crash cut off the status update, and is adopted. Any
+// other Job is deleted and the session retried once it is gone.
+func reconcileExistingJob(session *unstructured.Unstructured, job *batchv1.Job) error {
+	namespace, name := session.GetNamespace(), session.GetName()
+	if job.DeletionTimestamp == nil && jobBelongsToRun(job, session) {
+		log.Printf("Adopting job %s/%s of session %s (run %d); it was created before the session status was updated", namespace, job.Name, name, runAttempt(session))
+		if err := updateAgenticSessionStatus(namespace, name, map[string]interface{}{
+			"phase":     "Creating",
+			"message":   "Job is being set up",
+			"startTime": job.CreationTimestamp.UTC().Format(time.RFC3339),
+			"jobName":   job.Name,
+		}); err != nil {
+			return err
+		}
+		startJobMonitor(job.Name, name, namespace)
+		return nil
+	}
+
+	if job.DeletionTimestamp == nil {
+		log.Printf("Replacing job %s/%s: its key %q is not the key of session %s run %d", namespace, job.Name, job.Annotations[jobIdempotencyKeyAnnotation], name, runAttempt(session))
+		if err := deleteStaleJob(namespace, name, job); err != nil {
+			return err
+		}
+	}
+	requeueWhenJobGone(namespace, name, job.Name, job.UID)
+	return nil
+}
+
+// deleteStaleJob deletes exactly this Job, never a newer one of the same name, and the
+// content Service pointing at it
+func deleteStaleJob(namespace, sessionName string, job *batchv1.Job) error {
+	policy := v1.DeletePropagationBackground
+	uid := job.UID
+	err := config.K8sClient.BatchV1().Jobs(namespace).Delete(context.TODO(), job.Name, v1.DeleteOptions{
+		PropagationPolicy: &policy,
+		Preconditions:     &v1.Preconditions{UID: &uid},
+	})
+	if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+		return fmt.Errorf("failed to delete stale job %s/%s: %w", namespace, job.Name, err)
+	}
+	svcName := fmt.Sprintf("ambient-content-%s", sessionName)
+	if err := config.K8sClient.CoreV1().Services(namespace).Delete(context.TODO(), svcName, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		log.Printf("Failed to delete content service %s/%s of stale job: %v", namespace, svcName, err)
+	}
+	return nil
+}
+
+// pendingJobRequeues holds the sessions waiting for a replaced Job to disappear
+var pendingJobRequeues sync.Map
+
+// requeueWhenJobGone reprocesses the session once the Job with uid is gone
+func requeueWhenJobGone(namespace, sessionName, jobName string, uid k8stypes.UID) {
+	key := namespace + "/" + sessionName
+	if _, waiting := pendingJobRequeues.LoadOrStore(key, struct{}{}); waiting {
+		return
+	}
+	interval, timeout := jobGoneInterval, jobGoneTimeout
+	go func() {
+		defer pendingJobRequeues.Delete(key)
+		for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+			time.Sleep(interval)
+			job, err := config.K8sClient.BatchV1().Jobs(namespace).Get(context.TODO(), jobName, v1.GetOptions{})
+			if errors.IsNotFound(err) || (err == nil && job.UID != uid) {
+				break
+			}
+		}
+		session, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).Get(context.TODO(), sessionName, v1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				log.Printf("Failed to requeue session %s after replacing its job: %v", key, err)
+			}
+			return
+		}
+		if err := handleAgenticSessionEvent(session); err != nil {
+			log.Printf("Error retrying session %s after replacing its job: %v", key, err)
+		}
+	}()
+}
+
+// restoreJobMonitor resumes monitoring a Creating or Running session's Job after an
+// operator restart. A session marked Creating whose Job was never created goes back to
+// Pending so the Job is created.
+func restoreJobMonitor(session *unstructured.Unstructured) error {
+	namespace, name := session.GetNamespace(), session.GetName()
+	jobName := fmt.Sprintf("%s-job", name)
+	if isJobMonitored(namespace, jobName) {
+		return nil
+	}
+	job, err := config.K8sClient.BatchV1().Jobs(namespace).Get(context.TODO(), jobName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		phase, _, _ := unstructured.NestedString(session.Object, "status", "phase")
+		recordedJob, _, _ := unstructured.NestedString(session.Object, "status", "jobName")
+		if phase == "Creating" && recordedJob == "" {
+			log.Printf("Session %s/%s was left Creating without a job; creating it again", namespace, name)
+			return updateAgenticSessionStatus(namespace, name, map[string]interface{}{
+				"phase":   "Pending",
+				"message": "Retrying job creation after an operator restart",
+			})
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get job %s/%s: %w", namespace, jobName, err)
+	}
+	if !jobBelongsToRun(job, session) {
+		log.Printf("Job %s/%s does not belong to the current run of session %s, not monitoring it", namespace, jobName, name)
+		return nil
+	}
+	log.Printf("Resuming monitoring of job %s/%s", namespace, jobName)
+	startJobMonitor(jobName, name, namespace)
+	return nil
+}
+
+// jobMonitors holds the Jobs being monitored, so each Job has one monitor however often
+// it is adopted or restored
+var jobMonitors sync.Map
+
+// runJobMonitor is monitorJob; tests replace it
+var runJobMonitor = monitorJob
+
+func startJobMonitor(jobName, sessionName, namespace string) {
+	key := namespace + "/" + jobName
+	if _, running := jobMonitors.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	run := runJobMonitor
+	go func() {
+		defer jobMonitors.Delete(key)
+		run(jobName, sessionName, namespace)
+	}()
+}
+
+func isJobMonitored(namespace, jobName string) bool {
+	_, ok := jobMonitors.Load(namespace + "/" + jobName)
+	return ok
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newRestoreSession(phase string, attempt int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": "proj", "uid": "uid-1"},
+		"status":     map[string]interface{}{"phase": phase, "runAttempt": attempt},
+	}}
+}
+
+func newKeyedJob(key string, ownerUID k8stypes.UID) *batchv1.Job {
+	job := newRunnerJob("proj", "s1-job", false)
+	job.UID = "job-uid"
+	job.OwnerReferences = []metav1.OwnerReference{{Kind: "AgenticSession", Name: "s1", UID: ownerUID}}
+	if key != "" {
+		job.Annotations = map[string]string{jobIdempotencyKeyAnnotation: key}
+	}
+	return job
+}
+
+// monitorRecorder records monitor starts; stubbed monitors run until the test ends
+type monitorRecorder struct {
+	mu      sync.Mutex
+	started []string
+}
+
+func (r *monitorRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.started)
+}
+
+func stubJobMonitors(t *testing.T) *monitorRecorder {
+	recorder := &monitorRecorder{}
+	release := make(chan struct{})
+	prev := runJobMonitor
+	runJobMonitor = func(jobName, sessionName, namespace string) {
+		recorder.mu.Lock()
+		recorder.started = append(recorder.started, namespace+"/"+jobName)
+		recorder.mu.Unlock()
+		<-release
+	}
+	t.Cleanup(func() {
+		close(release)
+		runJobMonitor = prev
+		// Let the monitor goroutines unregister before the next test
+		for i := 0; i < 100 && isJobMonitored("proj", "s1-job"); i++ {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	return recorder
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+// TestReconcileExistingJob_AdoptsJobOfCurrentRun reproduces the double-Job bug: the operator
+// created the Job and crashed before recording it, so the session is still Pending. The Job
+// must be adopted and monitored once, however often the session is reprocessed.
+func TestReconcileExistingJob_AdoptsJobOfCurrentRun(t *testing.T) {
+	started := stubJobMonitors(t)
+	session := newRestoreSession("Pending", 0)
+	setupTestClient(newKeyedJob("uid-1-0", "uid-1"))
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, session)
+
+	for i := 0; i < 3; i++ {
+		job, err := config.K8sClient.BatchV1().Jobs("proj").Get(context.Background(), "s1-job", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reconcileExistingJob(session, job); err != nil {
+			t.Fatalf("reconcile %d: %v", i, err)
+		}
+	}
+
+	waitFor(t, "the job monitor", func() bool { return started.count() > 0 })
+	time.Sleep(10 * time.Millisecond)
+	if n := started.count(); n != 1 {
+		t.Errorf("monitors started = %d, want one", n)
+	}
+	jobs, _ := config.K8sClient.BatchV1().Jobs("proj").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 1 || jobs.Items[0].UID != "job-uid" {
+		t.Errorf("jobs = %d, want the adopted job only", len(jobs.Items))
+	}
+	obj, _ := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Get(context.Background(), "s1", metav1.GetOptions{})
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	jobName, _, _ := unstructured.NestedString(obj.Object, "status", "jobName")
+	if phase != "Creating" || jobName != "s1-job" {
+		t.Errorf("status phase=%q jobName=%q, want Creating with the adopted job", phase, jobName)
+	}
+}
+
+// TestReconcileExistingJob_ReplacesJobOfEarlierRun verifies a Job left by the previous run
+// (or a deleted session of the same name) is deleted rather than adopted
+func TestReconcileExistingJob_ReplacesJobOfEarlierRun(t *testing.T) {
+	stubJobMonitors(t)
+	prevInterval := jobGoneInterval
+	jobGoneInterval = time.Hour
+	t.Cleanup(func() { jobGoneInterval = prevInterval })
+
+	for _, tc := range []struct {
+		name string
+		job  *batchv1.Job
+	}{
+		{"previous run", newKeyedJob("uid-1-0", "uid-1")},
+		{"previous session", newKeyedJob("uid-0-1", "uid-0")},
+		{"unkeyed job of the first run", newKeyedJob("", "uid-1")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setupTestClient(tc.job)
+			session := newRestoreSession("Pending", 1)
+			if jobBelongsToRun(tc.job, session) {
+				t.Fatal("job of an earlier run belongs to the current run")
+			}
+			if err := reconcileExistingJob(session, tc.job); err != nil {
+				t.Fatal(err)
+			}
+			jobs, _ := config.K8sClient.BatchV1().Jobs("proj").List(context.Background(), metav1.ListOptions{})
+			if len(jobs.Items) != 0 {
+				t.Errorf("stale job was not deleted")
+			}
+			pendingJobRequeues.Delete("proj/s1")
+		})
+	}
+
+	if !jobBelongsToRun(newKeyedJob("", "uid-1"), newRestoreSession("Pending", 0)) {
+		t.Error("unkeyed job owned by the session should belong to its first run")
+	}
+}
+
+// TestRestoreJobMonitor verifies an operator restart resumes monitoring of a running
+// session's Job
+func TestRestoreJobMonitor(t *testing.T) {
+	started := stubJobMonitors(t)
+	running := newRestoreSession("Running", 2)
+	setupTestClient(newKeyedJob("uid-1-2", "uid-1"))
+	if err := restoreJobMonitor(running); err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreJobMonitor(running); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the job monitor", func() bool { return started.count() > 0 })
+	time.Sleep(10 * time.Millisecond)
+	if n := started.count(); n != 1 {
+		t.Errorf("monitors started = %d, want one", n)
+	}
+}
+
+// TestRestoreJobMonitor_RetriesSessionLeftCreating verifies a session the operator marked
+// Creating before crashing, without creating its Job, goes back to Pending
+func TestRestoreJobMonitor_RetriesSessionLeftCreating(t *testing.T) {
+	creating := newRestoreSession("Creating", 0)
+	setupTestClient()
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, creating)
+	if err := restoreJobMonitor(creating); err != nil {
+		t.Fatal(err)
+	}
+	obj, _ := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Get(context.Background(), "s1", metav1.GetOptions{})
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "Pending" {
+		t.Errorf("phase = %q, want Pending to create the job", phase)
+	}
+}
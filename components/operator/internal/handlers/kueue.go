@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// With KUEUE_ENABLED=true runner Jobs are created suspended and labelled with a Kueue
+// LocalQueue; Kueue unsuspends them once its ClusterQueue admits them (and may suspend them
+// again to preempt). Cluster-wide fairness and quotas then come from Kueue, so these Jobs
+// bypass the --max-concurrent-jobs limit.
+
+const (
+	kueueQueueLabel         = "kueue.x-k8s.io/queue-name"
+	kueuePriorityClassLabel = "kueue.x-k8s.io/priority-class"
+
+	// kueueAdmissionReason is the Queued condition reason of sessions whose Job waits for Kueue
+	kueueAdmissionReason = "KueueAdmission"
+)
+
+// kueueSettings returns the LocalQueue and priority class for the project's runner Jobs. The
+// queue is "" when the integration is off or neither the project nor the operator names one.
+func kueueSettings(ctx context.Context, namespace string, appConfig *config.Config) (queue, priorityClass string, err error) {
+	if !appConfig.KueueEnabled {
+		return "", "", nil
+	}
+	queue = appConfig.KueueDefaultQueue
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return "", "", fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	if err == nil && ps.Spec.Kueue != nil {
+		if q := strings.TrimSpace(ps.Spec.Kueue.QueueName); q != "" {
+			queue = q
+		}
+		priorityClass = strings.TrimSpace(ps.Spec.Kueue.PriorityClassName)
+	}
+	if queue == "" {
+		return "", "", nil
+	}
+	return queue, priorityClass, nil
+}
+
+// applyKueue hands the Job to Kueue: queue labels on the Job, created suspended
+func applyKueue(job *batchv1.Job, queue, priorityClass string) {
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	job.Labels[kueueQueueLabel] = queue
+	if priorityClass != "" {
+		job.Labels[kueuePriorityClassLabel] = priorityClass
+	}
+	job.Spec.Suspend = boolPtr(true)
+}
+
+func isJobSuspended(job *batchv1.Job) bool {
+	return job.Spec.Suspend != nil && *job.Spec.Suspend
+}
+
+// syncKueueAdmission mirrors a Kueue-managed Job's admission onto the session's Queued
+// condition. It writes only on changes so the watch is not retriggered every poll. The runner
+// token was minted when the Job was created, so it is kept fresh while the Job waits and is
+// checked again on admission: a Job queued for longer than a run must not start with an
+// expired token.
+func syncKueueAdmission(session *unstructured.Unstructured, job *batchv1.Job) {
+	queue := job.Labels[kueueQueueLabel]
+	if queue == "" || session == nil {
+		return
+	}
+	waiting := queuedReason(session) == kueueAdmissionReason
+	if isJobSuspended(job) || waiting {
+		if err := refreshRunnerToken(context.TODO(), session.GetNamespace(), session.GetName(), time.Now()); err != nil {
+			log.Printf("Failed to refresh the runner token of %s/%s: %v", session.GetNamespace(), session.GetName(), err)
+		}
+	}
+	var err error
+	switch {
+	case isJobSuspended(job) && !waiting:
+		msg := fmt.Sprintf("Waiting for Kueue to admit the runner job from LocalQueue %s", queue)
+		err = setQueuedCondition(session, v1.ConditionTrue, kueueAdmissionReason, msg, msg)
+	case !isJobSuspended(job) && waiting:
+		err = setQueuedCondition(session, v1.ConditionFalse, "Admitted", "Kueue admitted the runner job", "Runner job admitted by Kueue")
+	default:
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update Kueue admission of %s/%s: %v", session.GetNamespace(), session.GetName(), err)
+	}
+}
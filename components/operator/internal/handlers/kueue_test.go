@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestKueueSettings verifies the project queue overrides the default and the integration is opt-in
+func TestKueueSettings(t *testing.T) {
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	ctx := context.Background()
+	if _, err := config.VteamClient.VteamV1alpha1().ProjectSettings("team-a").Create(ctx, &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "team-a"},
+		Spec:       apiv1alpha1.ProjectSettingsSpec{Kueue: &apiv1alpha1.KueueSettings{QueueName: "team-a-queue", PriorityClassName: "high"}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{KueueEnabled: true, KueueDefaultQueue: "sessions"}
+	if q, p, err := kueueSettings(ctx, "team-a", cfg); err != nil || q != "team-a-queue" || p != "high" {
+		t.Errorf("team-a: queue=%q priority=%q err=%v", q, p, err)
+	}
+	if q, p, err := kueueSettings(ctx, "team-b", cfg); err != nil || q != "sessions" || p != "" {
+		t.Errorf("team-b should use the default queue: queue=%q priority=%q err=%v", q, p, err)
+	}
+	if q, _, _ := kueueSettings(ctx, "team-b", &config.Config{KueueEnabled: true}); q != "" {
+		t.Errorf("without a queue Kueue must not be used, got %q", q)
+	}
+	if q, _, _ := kueueSettings(ctx, "team-a", &config.Config{KueueDefaultQueue: "sessions"}); q != "" {
+		t.Errorf("disabled integration must not use Kueue, got %q", q)
+	}
+}
+
+// TestApplyKueue verifies the Job is labelled for Kueue and created suspended
+func TestApplyKueue(t *testing.T) {
+	job := &batchv1.Job{}
+	applyKueue(job, "sessions", "high")
+	if job.Labels[kueueQueueLabel] != "sessions" || job.Labels[kueuePriorityClassLabel] != "high" {
+		t.Errorf("labels = %v", job.Labels)
+	}
+	if !isJobSuspended(job) {
+		t.Error("Kueue jobs must be created suspended")
+	}
+}
+
+// TestReserveJobSlot_IgnoresSuspendedJobs verifies Jobs waiting for Kueue do not use a runner slot
+func TestReserveJobSlot_IgnoresSuspendedJobs(t *testing.T) {
+	waiting := newRunnerJob("project-a", "s1-job", false)
+	applyKueue(waiting, "sessions", "")
+	setupTestClient(waiting, newRunnerJob("project-b", "s2-job", false))
+	defer func() { MaxConcurrentJobs = 0 }()
+
+	MaxConcurrentJobs = 2
+	release, running, ok, err := reserveJobSlot()
+	if err != nil || !ok || running != 1 {
+		t.Fatalf("Expected 1 of 2 slots used, got running=%d ok=%v err=%v", running, ok, err)
+	}
+	release()
+}
+
+// TestSyncKueueAdmission_RefreshesRunnerToken verifies a Job held by Kueue for longer than a
+// run gets a new runner token before it starts, and a fresh token is left alone
+func TestSyncKueueAdmission_RefreshesRunnerToken(t *testing.T) {
+	tokenSecret := func(expires time.Time) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        runnerTokenSecretName("s1"),
+				Namespace:   "team",
+				Annotations: map[string]string{runnerTokenExpiresAnnotation: expires.Format(time.RFC3339)},
+			},
+			Data: map[string][]byte{"k8s-token": []byte("old")},
+		}
+	}
+	minted := 0
+	setup := func(sec *corev1.Secret) {
+		client := fake.NewSimpleClientset(sec)
+		client.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetSubresource() != "token" {
+				return false, nil, nil
+			}
+			minted++
+			expires := metav1.NewTime(time.Now().Add((runnerTokenExpirationSeconds + runnerTokenRefreshSeconds) * time.Second))
+			return true, &authnv1.TokenRequest{Status: authnv1.TokenRequestStatus{Token: "new", ExpirationTimestamp: expires}}, nil
+		})
+		config.K8sClient = client
+	}
+	// Already marked as waiting for Kueue, so no status is written
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "s1", "namespace": "team"},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": queuedConditionType, "status": "True", "reason": kueueAdmissionReason},
+		}},
+	}}
+	job := &batchv1.Job{}
+	applyKueue(job, "sessions", "")
+
+	// Minted when the Job was created, five hours ago
+	setup(tokenSecret(time.Now().Add(-time.Hour)))
+	syncKueueAdmission(session, job)
+	sec, err := config.K8sClient.CoreV1().Secrets("team").Get(context.Background(), runnerTokenSecretName("s1"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minted != 1 || string(sec.Data["k8s-token"]) != "new" {
+		t.Fatalf("stale token not replaced: minted=%d token=%q", minted, sec.Data["k8s-token"])
+	}
+	expires, err := time.Parse(time.RFC3339, sec.Annotations[runnerTokenExpiresAnnotation])
+	if err != nil || time.Until(expires) < runnerTokenExpirationSeconds*time.Second {
+		t.Errorf("refreshed token expires %v (%v), want at least a full run away", expires, err)
+	}
+
+	// Minted recently: enough left for a full run
+	minted = 0
+	setup(tokenSecret(time.Now().Add((runnerTokenExpirationSeconds + runnerTokenRefreshSeconds/2) * time.Second)))
+	syncKueueAdmission(session, job)
+	if minted != 0 {
+		t.Errorf("fresh token replaced %d times", minted)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	llmProviderAnthropic = "anthropic"
+	llmProviderVertex    = "vertex"
+	llmProviderBedrock   = "bedrock"
+
+	// llmProviderReadyConditionType is False while the project's provider cannot be used
+	llmProviderReadyConditionType = "LLMProviderReady"
+
+	// bedrockTokenMountPath holds the projected SA token exchanged for the Bedrock IAM role
+	bedrockTokenMountPath = "/var/run/secrets/aws"
+	bedrockTokenAudience  = "sts.amazonaws.com"
+)
+
+// llmProviderSettings mirrors ProjectSettings spec.llmProvider. An empty Provider means
+// the cluster default (Vertex when CLAUDE_CODE_USE_VERTEX=1, otherwise the Anthropic API).
+type llmProviderSettings struct {
+	Provider                 string
+	BedrockRegion            string
+	BedrockRoleARN           string
+	BedrockCredentialsSecret string
+}
+
+// llmProviderMisconfiguration explains why the project's provider cannot be used with this
+// operator, "" when it can
+func llmProviderMisconfiguration(namespace string, settings llmProviderSettings, vertexEnabled bool) (reason, message string) {
+	switch {
+	case settings.Provider == llmProviderVertex && !vertexEnabled:
+		return "VertexNotEnabled", fmt.Sprintf("project %s selects the Vertex AI provider but CLAUDE_CODE_USE_VERTEX is not enabled on the operator", namespace)
+	case settings.Provider == llmProviderBedrock && strings.TrimSpace(settings.BedrockRegion) == "":
+		return "BedrockRegionMissing", fmt.Sprintf("project %s selects the Bedrock provider but spec.llmProvider.bedrock.region is not set", namespace)
+	}
+	return "", ""
+}
+
+// setLLMProviderCondition records on the session whether its provider can be used. While it
+// cannot, status.message says why, since the session stays Pending without other progress.
+func setLLMProviderCondition(session *unstructured.Unstructured, status v1.ConditionStatus, reason, message string) error {
+	return statusupdater.Mutate(context.TODO(), types.GetAgenticSessionResource(), session.GetNamespace(), session.GetName(), func(st map[string]interface{}) error {
+		if !setSessionCondition(st, sessionCondition(llmProviderReadyConditionType, status, reason, message)) {
+			return statusupdater.ErrNoChange
+		}
+		if status == v1.ConditionFalse {
+			st["message"] = message
+		}
+		return nil
+	})
+}
+
+// hasLLMProviderProblem reports whether the session carries LLMProviderReady=False
+func hasLLMProviderProblem(session *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(session.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, _ := c.(map[string]interface{})
+		if cond["type"] == llmProviderReadyConditionType && cond["status"] == string(v1.ConditionFalse) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadLLMProviderSettings reads the project's LLM provider selection. A missing
+// ProjectSettings object yields the cluster default; a read error is returned so the session
+// is retried rather than started with the default provider's credentials.
+func loadLLMProviderSettings(namespace string) (llmProviderSettings, error) {
+	settings := llmProviderSettings{}
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(context.TODO(), apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return settings, nil
+		}
+		return settings, fmt.Errorf("failed to read ProjectSettings for the LLM provider: %w", err)
+	}
+	provider := ps.Spec.LLMProvider
+	if provider == nil {
+		return settings, nil
+	}
+	settings.Provider = strings.ToLower(strings.TrimSpace(provider.Provider))
+	if provider.Bedrock != nil {
+		settings.BedrockRegion = provider.Bedrock.Region
+		settings.BedrockRoleARN = provider.Bedrock.RoleARN
+		settings.BedrockCredentialsSecret = provider.Bedrock.CredentialsSecretName
+	}
+	return settings, nil
+}
+
+// bedrockEnv returns the runner env vars that switch the Claude SDK to Bedrock. Static
+// credentials come from the credentials secret via EnvFrom; a role ARN is assumed with
+// the projected web identity token added by applyBedrockWebIdentity.
+func bedrockEnv(settings llmProviderSettings) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "CLAUDE_CODE_USE_BEDROCK", Value: "1"},
+		{Name: "AWS_REGION", Value: settings.BedrockRegion},
+	}
+	if settings.BedrockRoleARN != "" {
+		env = append(env,
+			corev1.EnvVar{Name: "AWS_ROLE_ARN", Value: settings.BedrockRoleARN},
+			corev1.EnvVar{Name: "AWS_WEB_IDENTITY_TOKEN_FILE", Value: bedrockTokenMountPath + "/token"},
+		)
+	}
+	return env
+}
+
+// applyBedrockWebIdentity mounts a projected ServiceAccount token with the STS audience
+// into the runner container so the AWS SDK can assume the configured role.
+func applyBedrockWebIdentity(podSpec *corev1.PodSpec, containerName string) {
+	expiry := int64(3600)
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "aws-web-identity",
+		VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+			Sources: []corev1.VolumeProjection{{
+				ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+					Audience:          bedrockTokenAudience,
+					ExpirationSeconds: &expiry,
+					Path:              "token",
+				},
+			}},
+		}},
+	})
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == containerName {
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      "aws-web-identity",
+				MountPath: bedrockTokenMountPath,
+				ReadOnly:  true,
+			})
+			break
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestBedrockEnv_RoleARN verifies role-based Bedrock auth points the AWS SDK at the projected token
+func TestBedrockEnv_RoleARN(t *testing.T) {
+	env := bedrockEnv(llmProviderSettings{
+		Provider:       llmProviderBedrock,
+		BedrockRegion:  "us-east-1",
+		BedrockRoleARN: "arn:aws:iam::123456789012:role/bedrock-runner",
+	})
+
+	got := map[string]string{}
+	for _, e := range env {
+		got[e.Name] = e.Value
+	}
+	expected := map[string]string{
+		"CLAUDE_CODE_USE_BEDROCK":     "1",
+		"AWS_REGION":                  "us-east-1",
+		"AWS_ROLE_ARN":                "arn:aws:iam::123456789012:role/bedrock-runner",
+		"AWS_WEB_IDENTITY_TOKEN_FILE": bedrockTokenMountPath + "/token",
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, got[k])
+		}
+	}
+
+	static := bedrockEnv(llmProviderSettings{Provider: llmProviderBedrock, BedrockRegion: "us-east-1", BedrockCredentialsSecret: "aws-creds"})
+	for _, e := range static {
+		if e.Name == "AWS_ROLE_ARN" || e.Name == "AWS_WEB_IDENTITY_TOKEN_FILE" {
+			t.Errorf("Expected no web identity env for static credentials, got %s", e.Name)
+		}
+	}
+}
+
+// TestApplyBedrockWebIdentity_MountsRunnerOnly verifies the token is mounted only into the runner container
+func TestApplyBedrockWebIdentity_MountsRunnerOnly(t *testing.T) {
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "ambient-content"}, {Name: "ambient-code-runner"}}}
+
+	applyBedrockWebIdentity(podSpec, "ambient-code-runner")
+
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].Projected == nil {
+		t.Fatalf("Expected one projected volume, got %+v", podSpec.Volumes)
+	}
+	projection := podSpec.Volumes[0].Projected.Sources[0].ServiceAccountToken
+	if projection == nil || projection.Audience != bedrockTokenAudience {
+		t.Errorf("Expected STS audience on projected token, got %+v", projection)
+	}
+	if len(podSpec.Containers[0].VolumeMounts) != 0 {
+		t.Error("Expected no mount on non-runner container")
+	}
+	mounts := podSpec.Containers[1].VolumeMounts
+	if len(mounts) != 1 || mounts[0].MountPath != bedrockTokenMountPath || !mounts[0].ReadOnly {
+		t.Errorf("Unexpected runner mounts: %+v", mounts)
+	}
+}
+
+// TestLoadLLMProviderSettings_Bedrock verifies the typed ProjectSettings spec is read, and a missing object yields defaults
+func TestLoadLLMProviderSettings_Bedrock(t *testing.T) {
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	// Created through the client: the fake tracker would guess "projectsettingses" as the resource
+	_, err := config.VteamClient.VteamV1alpha1().ProjectSettings("project-a").Create(context.TODO(), &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "project-a"},
+		Spec: apiv1alpha1.ProjectSettingsSpec{
+			LLMProvider: &apiv1alpha1.LLMProvider{
+				Provider: " Bedrock ",
+				Bedrock:  &apiv1alpha1.BedrockSettings{Region: "us-west-2", CredentialsSecretName: "aws-creds"},
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create ProjectSettings: %v", err)
+	}
+
+	settings, err := loadLLMProviderSettings("project-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Provider != llmProviderBedrock || settings.BedrockRegion != "us-west-2" || settings.BedrockCredentialsSecret != "aws-creds" {
+		t.Errorf("Unexpected settings: %+v", settings)
+	}
+
+	if settings, err := loadLLMProviderSettings("project-b"); err != nil || settings != (llmProviderSettings{}) {
+		t.Errorf("Expected defaults without ProjectSettings, got %+v, %v", settings, err)
+	}
+}
+
+// TestHandleAgenticSessionEvent_LLMProviderMisconfigured verifies a session of a project whose
+// provider cannot be used says why on its status, and no Job is created for it
+func TestHandleAgenticSessionEvent_LLMProviderMisconfigured(t *testing.T) {
+	t.Setenv("CLAUDE_CODE_USE_VERTEX", "0")
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata": map[string]interface{}{
+			"name":        "s1",
+			"namespace":   "team",
+			"annotations": map[string]interface{}{creationSourceAnnotation: "api"},
+		},
+		"spec":   map[string]interface{}{"prompt": "hi"},
+		"status": map[string]interface{}{"phase": "Pending"},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, session)
+	config.K8sClient = fake.NewSimpleClientset()
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	if _, err := config.VteamClient.VteamV1alpha1().ProjectSettings("team").Create(context.TODO(), &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "team"},
+		Spec:       apiv1alpha1.ProjectSettingsSpec{LLMProvider: &apiv1alpha1.LLMProvider{Provider: "vertex"}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := handleAgenticSessionEvent(session); err == nil {
+		t.Fatal("expected the misconfiguration to be returned")
+	}
+	held, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("team").Get(context.Background(), "s1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasLLMProviderProblem(held) {
+		t.Errorf("no LLMProviderReady=False condition: %v", held.Object["status"])
+	}
+	if msg, _, _ := unstructured.NestedString(held.Object, "status", "message"); !strings.Contains(msg, "CLAUDE_CODE_USE_VERTEX") {
+		t.Errorf("status.message = %q", msg)
+	}
+	jobs, _ := config.K8sClient.BatchV1().Jobs("team").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 0 {
+		t.Errorf("%d Jobs created for a misconfigured provider", len(jobs.Items))
+	}
+}
+
+// TestLLMProviderMisconfiguration verifies Vertex needs the operator's Vertex setup and Bedrock a region
+func TestLLMProviderMisconfiguration(t *testing.T) {
+	cases := []struct {
+		settings      llmProviderSettings
+		vertexEnabled bool
+		reason        string
+	}{
+		{llmProviderSettings{}, false, ""},
+		{llmProviderSettings{Provider: llmProviderVertex}, true, ""},
+		{llmProviderSettings{Provider: llmProviderVertex}, false, "VertexNotEnabled"},
+		{llmProviderSettings{Provider: llmProviderBedrock, BedrockRegion: "us-east-1"}, false, ""},
+		{llmProviderSettings{Provider: llmProviderBedrock, BedrockRegion: " "}, true, "BedrockRegionMissing"},
+	}
+	for _, tc := range cases {
+		if reason, _ := llmProviderMisconfiguration("team", tc.settings, tc.vertexEnabled); reason != tc.reason {
+			t.Errorf("%+v (vertex %v): reason %q, want %q", tc.settings, tc.vertexEnabled, reason, tc.reason)
+		}
+	}
+}
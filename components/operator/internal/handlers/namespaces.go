@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/diagnostics"
 	"ambient-code-operator/internal/services"
 
 	corev1 "k8s.io/api/core/v1"
@@ -29,9 +30,13 @@ func WatchNamespaces() {
 		log.Println("Watching for managed namespaces...")
 
 		for event := range watcher.ResultChan() {
+			diagnostics.Default.WatchEvent("Namespace")
 			switch event.Type {
 			case watch.Added:
 				namespace := event.Object.(*corev1.Namespace)
+				if !WatchScope.AllowsNamespace(namespace) {
+					continue
+				}
 				log.Printf("Detected new managed namespace: %s", namespace.Name)
 
 				// Auto-create ProjectSettings for this namespace
@@ -50,6 +55,7 @@ func WatchNamespaces() {
 		}
 
 		log.Println("Namespace watch channel closed, restarting...")
+		diagnostics.Default.WatchRestarted("Namespace")
 		watcher.Stop()
 		time.Sleep(2 * time.Second)
 	}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespaceScope limits the managed namespaces one operator install serves, so several
+// installs can split a multi-team cluster between them. Set from the --watch-namespaces and
+// --exclude-namespaces flags; the zero value serves every namespace.
+type NamespaceScope struct {
+	include namespaceMatcher
+	exclude namespaceMatcher
+}
+
+// WatchScope is the scope every watcher and periodic loop of this operator filters by
+var WatchScope = &NamespaceScope{}
+
+// namespaceMatcher matches namespaces by name or by any of its label selectors
+type namespaceMatcher struct {
+	names     map[string]bool
+	selectors []labels.Selector
+}
+
+// ParseNamespaceScope builds a scope from the flag values. Each value is either a
+// comma-separated list of namespace names or a label selector; a value with a selector
+// operator (=, !=, !, in, notin) is a selector, e.g. "tenant=team-a" or "tenant in (a,b)".
+// A namespace matches a flag if it matches any of its values. Empty include serves every
+// namespace; exclusion wins over inclusion.
+func ParseNamespaceScope(include, exclude []string) (*NamespaceScope, error) {
+	in, err := parseNamespaceMatcher(include)
+	if err != nil {
+		return nil, fmt.Errorf("--watch-namespaces: %w", err)
+	}
+	ex, err := parseNamespaceMatcher(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("--exclude-namespaces: %w", err)
+	}
+	return &NamespaceScope{include: in, exclude: ex}, nil
+}
+
+func parseNamespaceMatcher(values []string) (namespaceMatcher, error) {
+	m := namespaceMatcher{names: map[string]bool{}}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !isNamespaceSelector(value) {
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					m.names[name] = true
+				}
+			}
+			continue
+		}
+		selector, err := labels.Parse(value)
+		if err != nil {
+			return m, fmt.Errorf("invalid label selector %q: %w", value, err)
+		}
+		m.selectors = append(m.selectors, selector)
+	}
+	return m, nil
+}
+
+// isNamespaceSelector tells a label selector from a list of names, which cannot contain
+// selector operators
+func isNamespaceSelector(value string) bool {
+	if strings.ContainsAny(value, "=!()") {
+		return true
+	}
+	for _, field := range strings.Fields(value) {
+		if field == "in" || field == "notin" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m namespaceMatcher) empty() bool {
+	return len(m.names) == 0 && len(m.selectors) == 0
+}
+
+func (m namespaceMatcher) matches(name string, nsLabels map[string]string) bool {
+	if m.names[name] {
+		return true
+	}
+	for _, selector := range m.selectors {
+		if selector.Matches(labels.Set(nsLabels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsLabels reports whether deciding on a namespace requires reading its labels
+func (s *NamespaceScope) needsLabels() bool {
+	return len(s.include.selectors) > 0 || len(s.exclude.selectors) > 0
+}
+
+// AllowsNamespace reports whether the operator serves the namespace
+func (s *NamespaceScope) AllowsNamespace(ns *corev1.Namespace) bool {
+	if !s.include.empty() && !s.include.matches(ns.Name, ns.Labels) {
+		return false
+	}
+	return s.exclude.empty() || !s.exclude.matches(ns.Name, ns.Labels)
+}
+
+// Allows reports whether the operator serves the named namespace. The namespace is read only
+// when a label selector needs its labels; a namespace that cannot be read is not served.
+func (s *NamespaceScope) Allows(ctx context.Context, name string) bool {
+	if s.include.empty() && s.exclude.empty() {
+		return true
+	}
+	if !s.needsLabels() {
+		return s.AllowsNamespace(&corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: name}})
+	}
+	ns, err := config.K8sClient.CoreV1().Namespaces().Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		log.Printf("Failed to read namespace %s for the watch scope: %v", name, err)
+		return false
+	}
+	return s.AllowsNamespace(ns)
+}
+
+// String describes the scope for the startup log
+func (s *NamespaceScope) String() string {
+	describe := func(m namespaceMatcher) string {
+		parts := []string{}
+		for name := range m.names {
+			parts = append(parts, name)
+		}
+		sort.Strings(parts)
+		for _, selector := range m.selectors {
+			parts = append(parts, selector.String())
+		}
+		return strings.Join(parts, ", ")
+	}
+	switch {
+	case s.include.empty() && s.exclude.empty():
+		return "all managed namespaces"
+	case s.exclude.empty():
+		return "namespaces matching " + describe(s.include)
+	case s.include.empty():
+		return "all managed namespaces except " + describe(s.exclude)
+	}
+	return fmt.Sprintf("namespaces matching %s except %s", describe(s.include), describe(s.exclude))
+}
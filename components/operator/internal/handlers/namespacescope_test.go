@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+// TestNamespaceScope verifies names and label selectors, and that exclusion wins
+func TestNamespaceScope(t *testing.T) {
+	teamA := testNamespace("team-a-proj", map[string]string{"tenant": "team-a"})
+	teamB := testNamespace("team-b-proj", map[string]string{"tenant": "team-b"})
+	sandbox := testNamespace("sandbox", map[string]string{"tenant": "team-a", "sandbox": "true"})
+	other := testNamespace("other", nil)
+
+	cases := []struct {
+		name             string
+		include, exclude []string
+		allowed          []*corev1.Namespace
+	}{
+		{"unscoped", nil, nil, []*corev1.Namespace{teamA, teamB, sandbox, other}},
+		{"names", []string{"team-a-proj, other"}, nil, []*corev1.Namespace{teamA, other}},
+		{"selector", []string{"tenant=team-a"}, nil, []*corev1.Namespace{teamA, sandbox}},
+		{"set selector", []string{"tenant in (team-a,team-b)"}, nil, []*corev1.Namespace{teamA, teamB, sandbox}},
+		{"names or selector", []string{"other", "tenant=team-b"}, nil, []*corev1.Namespace{teamB, other}},
+		{"exclude wins", []string{"tenant=team-a"}, []string{"sandbox=true"}, []*corev1.Namespace{teamA}},
+		{"exclude only", nil, []string{"team-b-proj"}, []*corev1.Namespace{teamA, sandbox, other}},
+	}
+	for _, tc := range cases {
+		scope, err := ParseNamespaceScope(tc.include, tc.exclude)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		allowed := map[string]bool{}
+		for _, ns := range tc.allowed {
+			allowed[ns.Name] = true
+		}
+		for _, ns := range []*corev1.Namespace{teamA, teamB, sandbox, other} {
+			if got := scope.AllowsNamespace(ns); got != allowed[ns.Name] {
+				t.Errorf("%s: AllowsNamespace(%s) = %v, want %v", tc.name, ns.Name, got, allowed[ns.Name])
+			}
+		}
+	}
+
+	if _, err := ParseNamespaceScope([]string{"tenant in (a"}, nil); err == nil {
+		t.Error("expected an invalid selector to be rejected")
+	}
+}
+
+// TestNamespaceScopeAllowsReadsLabels verifies labels are looked up only for selectors
+func TestNamespaceScopeAllowsReadsLabels(t *testing.T) {
+	setupTestClient(testNamespace("team-a-proj", map[string]string{"tenant": "team-a"}))
+	ctx := context.Background()
+
+	byName, _ := ParseNamespaceScope([]string{"missing"}, nil)
+	if !byName.Allows(ctx, "missing") {
+		t.Error("a name-only scope should not need to read the namespace")
+	}
+	bySelector, _ := ParseNamespaceScope([]string{"tenant=team-a"}, nil)
+	if !bySelector.Allows(ctx, "team-a-proj") {
+		t.Error("expected team-a-proj to match by label")
+	}
+	if bySelector.Allows(ctx, "missing") {
+		t.Error("a namespace that cannot be read must not be served")
+	}
+}
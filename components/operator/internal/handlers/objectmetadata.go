@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// missingMetadataReason is the Queued condition reason of sessions lacking the labels or
+// annotations the project's objectMetadata policy requires
+const missingMetadataReason = "MissingRequiredMetadata"
+
+// projectObjectMetadataPolicy returns ProjectSettings spec.objectMetadata, nil when unset
+func projectObjectMetadataPolicy(ctx context.Context, namespace string) (*apiv1alpha1.ObjectMetadataPolicy, error) {
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	return ps.Spec.ObjectMetadata, nil
+}
+
+// projectObjectMetadata is what the policy adds to objects of the project that belong to no
+// session, such as the git mirror
+func projectObjectMetadata(ctx context.Context, namespace string) (apiv1alpha1.ObjectMetadata, error) {
+	policy, err := projectObjectMetadataPolicy(ctx, namespace)
+	if err != nil {
+		return apiv1alpha1.ObjectMetadata{}, err
+	}
+	return policy.For(nil, nil), nil
+}
+
+// sessionObjectMetadata is what the policy adds to the objects created for session
+func sessionObjectMetadata(ctx context.Context, session *unstructured.Unstructured) (apiv1alpha1.ObjectMetadata, error) {
+	policy, err := projectObjectMetadataPolicy(ctx, session.GetNamespace())
+	if err != nil {
+		return apiv1alpha1.ObjectMetadata{}, err
+	}
+	return policy.For(session.GetLabels(), session.GetAnnotations()), nil
+}
+
+// namedSessionObjectMetadata is sessionObjectMetadata for objects that outlive their
+// session, such as previews; once the session is gone only the project's metadata is added
+func namedSessionObjectMetadata(ctx context.Context, namespace, name string) (apiv1alpha1.ObjectMetadata, error) {
+	session, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return projectObjectMetadata(ctx, namespace)
+	}
+	if err != nil {
+		return apiv1alpha1.ObjectMetadata{}, fmt.Errorf("failed to read session %s: %w", name, err)
+	}
+	return sessionObjectMetadata(ctx, session)
+}
+
+// sessionMetadataHold returns why the session may not run under policy, or "" when it
+// carries every required key
+func sessionMetadataHold(session *unstructured.Unstructured, policy *apiv1alpha1.ObjectMetadataPolicy) string {
+	missing := policy.Missing(session.GetLabels(), session.GetAnnotations())
+	if len(missing) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Waiting for metadata the project requires on sessions: add %s", strings.Join(missing, ", "))
+}
+
+// markSessionMissingMetadata holds a Pending session until its labels and annotations are
+// completed; editing them reprocesses the session, and RequeueQueuedSessions retries it
+func markSessionMissingMetadata(session *unstructured.Unstructured, msg string) error {
+	if queuedReason(session) == missingMetadataReason {
+		return nil
+	}
+	return setQueuedCondition(session, v1.ConditionTrue, missingMetadataReason, msg, msg)
+}
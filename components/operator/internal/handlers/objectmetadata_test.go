@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func setupObjectMetadataPolicy(t *testing.T, namespace string) {
+	t.Helper()
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	if _, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Create(context.Background(), &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: namespace},
+		Spec: apiv1alpha1.ProjectSettingsSpec{ObjectMetadata: &apiv1alpha1.ObjectMetadataPolicy{
+			Labels:         map[string]string{"data-classification": "confidential"},
+			RequiredLabels: []string{"owner-team"},
+		}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSessionMetadataHold verifies a session without the required label waits with a
+// Queued condition RequeueQueuedSessions retries, and runs once it has the label
+func TestSessionMetadataHold(t *testing.T) {
+	setupObjectMetadataPolicy(t, "regulated")
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": "regulated"},
+		"status":     map[string]interface{}{"phase": "Pending"},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, session)
+
+	policy, err := projectObjectMetadataPolicy(context.Background(), "regulated")
+	if err != nil || policy == nil {
+		t.Fatalf("projectObjectMetadataPolicy() = %v, %v", policy, err)
+	}
+	msg := sessionMetadataHold(session, policy)
+	if !strings.Contains(msg, "label owner-team") {
+		t.Fatalf("hold = %q", msg)
+	}
+	if err := markSessionMissingMetadata(session, msg); err != nil {
+		t.Fatal(err)
+	}
+	obj, _ := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("regulated").Get(context.Background(), "s1", metav1.GetOptions{})
+	if reason := queuedReason(obj); reason != missingMetadataReason {
+		t.Errorf("queued reason = %q, want %s", reason, missingMetadataReason)
+	}
+
+	session.SetLabels(map[string]string{"owner-team": "payments"})
+	if msg := sessionMetadataHold(session, policy); msg != "" {
+		t.Errorf("held with the required label: %q", msg)
+	}
+}
+
+// TestReconcilePreview_ObjectMetadata verifies objects created outside the session's own
+// reconcile carry the policy's labels and the session's required ones
+func TestReconcilePreview_ObjectMetadata(t *testing.T) {
+	ctx := context.Background()
+	setupTestClient()
+	setupObjectMetadataPolicy(t, "regulated")
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": "regulated", "labels": map[string]interface{}{"owner-team": "payments"}},
+	}}
+	preview := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "Preview",
+		"metadata": map[string]interface{}{
+			"name": "preview-s1", "namespace": "regulated", "generation": int64(1), "uid": "p-uid",
+			"creationTimestamp": time.Now().UTC().Format(time.RFC3339),
+		},
+		"spec": map[string]interface{}{"sessionName": "s1", "image": "quay.io/team/app:abc", "port": int64(3000), "ttl": "2h"},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+		types.GetPreviewResource():        "PreviewList",
+		routeResource:                     "RouteList",
+	}, session, preview)
+
+	reconcilePreview(ctx, preview, &config.Config{PreviewExpose: config.PreviewExposeNone}, time.Now())
+
+	d, err := config.K8sClient.AppsV1().Deployments("regulated").Get(ctx, "preview-s1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Deployment not created: %v", err)
+	}
+	for what, labels := range map[string]map[string]string{"deployment": d.Labels, "pod template": d.Spec.Template.Labels} {
+		if labels["data-classification"] != "confidential" || labels["owner-team"] != "payments" || labels["app"] != "ambient-preview" {
+			t.Errorf("%s labels = %v", what, labels)
+		}
+	}
+	svc, err := config.K8sClient.CoreV1().Services("regulated").Get(ctx, "preview-s1", metav1.GetOptions{})
+	if err != nil || svc.Labels["owner-team"] != "payments" {
+		t.Errorf("service labels = %v, %v", svc.Labels, err)
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/diagnostics"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// scheduledConditionType reports whether the runner pod has been placed on a node
+	scheduledConditionType = "Scheduled"
+	// runnerHealthyConditionType reports image pull, OOM and crash problems in the runner pod
+	runnerHealthyConditionType = "RunnerHealthy"
+)
+
+// containerWaitingFailures are waiting reasons that will not resolve without user action
+var containerWaitingFailures = map[string]bool{
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CrashLoopBackOff":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// WatchRunnerPods watches runner pods in all namespaces and mirrors scheduling and container
+// problems into the owning AgenticSession's conditions as soon as they happen, so users see
+// "0/3 nodes are available: 3 Insufficient nvidia.com/gpu" instead of a generic Pending.
+// monitorJob still decides when a session has failed; this only enriches the status.
+func WatchRunnerPods() {
+	for {
+		watcher, err := config.K8sClient.CoreV1().Pods("").Watch(context.TODO(), v1.ListOptions{LabelSelector: "app=ambient-code-runner"})
+		if err != nil {
+			log.Printf("Failed to create runner pod watcher: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		log.Println("Watching for runner pod events...")
+		appConfig := config.LoadConfig()
+
+		for event := range watcher.ResultChan() {
+			diagnostics.Default.WatchEvent("RunnerPod")
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok || !WatchScope.Allows(context.TODO(), pod.Namespace) {
+				continue
+			}
+			done := diagnostics.Default.BeginReconcile("RunnerPod", pod.Namespace, pod.Name)
+			err := syncPodConditions(pod, appConfig)
+			done(err)
+			if err != nil {
+				log.Printf("Error syncing conditions from pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			}
+		}
+
+		log.Println("Runner pod watch channel closed, restarting...")
+		diagnostics.Default.WatchRestarted("RunnerPod")
+		watcher.Stop()
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// syncPodConditions writes the pod's diagnosis to its session, skipping the write when nothing
+// changed so pod status churn does not turn into session status churn
+func syncPodConditions(pod *corev1.Pod, appConfig *config.Config) error {
+	sessionName := pod.Labels["agentic-session"]
+	if sessionName == "" || pod.DeletionTimestamp != nil {
+		return nil
+	}
+	conditions, message := diagnoseRunnerPod(pod)
+	if cond, waitMessage := waitingForNodesCondition(context.TODO(), pod, appConfig); cond != nil {
+		conditions = append(conditions, cond)
+		if waitMessage != "" {
+			message = waitMessage
+		}
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	return statusupdater.Mutate(context.TODO(), types.GetAgenticSessionResource(), pod.Namespace, sessionName, func(status map[string]interface{}) error {
+		phase, _ := status["phase"].(string)
+		if phase == "Completed" || phase == "Failed" || phase == "Stopped" || phase == "Error" {
+			return statusupdater.ErrNoChange
+		}
+		changed := false
+		for _, cond := range conditions {
+			if setSessionCondition(status, cond) {
+				changed = true
+			}
+		}
+		if !changed {
+			return statusupdater.ErrNoChange
+		}
+		if message != "" {
+			status["message"] = message
+		}
+		return nil
+	})
+}
+
+// diagnoseRunnerPod derives the Scheduled and RunnerHealthy conditions from a runner pod.
+// message is a user-facing explanation for the session status when something is wrong.
+func diagnoseRunnerPod(pod *corev1.Pod) (conditions []map[string]interface{}, message string) {
+	for _, c := range pod.Status.Conditions {
+		if c.Type != corev1.PodScheduled {
+			continue
+		}
+		if c.Status == corev1.ConditionTrue {
+			conditions = append(conditions, sessionCondition(scheduledConditionType, v1.ConditionTrue, "Scheduled", fmt.Sprintf("Runner pod scheduled to %s", pod.Spec.NodeName)))
+		} else if c.Reason == corev1.PodReasonUnschedulable {
+			message = fmt.Sprintf("Runner pod cannot be scheduled: %s", c.Message)
+			conditions = append(conditions, sessionCondition(scheduledConditionType, v1.ConditionFalse, c.Reason, c.Message))
+		}
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if w := cs.State.Waiting; w != nil && containerWaitingFailures[w.Reason] {
+			// A crash loop caused by the OOM killer is reported as OOMKilled
+			reason, detail := w.Reason, w.Message
+			if t := cs.LastTerminationState.Terminated; t != nil && t.Reason == "OOMKilled" {
+				reason, detail = "OOMKilled", "container exceeded its memory limit"
+			}
+			msg := fmt.Sprintf("Container %s: %s", cs.Name, reason)
+			if detail != "" {
+				msg = fmt.Sprintf("%s - %s", msg, detail)
+			}
+			return append(conditions, sessionCondition(runnerHealthyConditionType, v1.ConditionFalse, reason, msg)), msg
+		}
+		if t := cs.State.Terminated; t != nil && t.Reason == "OOMKilled" {
+			msg := fmt.Sprintf("Container %s: OOMKilled - container exceeded its memory limit", cs.Name)
+			return append(conditions, sessionCondition(runnerHealthyConditionType, v1.ConditionFalse, "OOMKilled", msg)), msg
+		}
+	}
+	if pod.Status.Phase == corev1.PodRunning {
+		conditions = append(conditions, sessionCondition(runnerHealthyConditionType, v1.ConditionTrue, "ContainersRunning", "Runner containers are running"))
+	}
+	return conditions, message
+}
+
+func sessionCondition(condType string, status v1.ConditionStatus, reason, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               condType,
+		"status":             string(status),
+		"reason":             reason,
+		"message":            message,
+		"lastTransitionTime": time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// setSessionCondition upserts cond into status.conditions by type, keeping the other
+// conditions. It reports whether anything changed; an identical condition keeps its
+// original lastTransitionTime.
+func setSessionCondition(status map[string]interface{}, cond map[string]interface{}) bool {
+	existing, _ := status["conditions"].([]interface{})
+	for i, item := range existing {
+		current, _ := item.(map[string]interface{})
+		if current["type"] != cond["type"] {
+			continue
+		}
+		if current["status"] == cond["status"] && current["reason"] == cond["reason"] && current["message"] == cond["message"] {
+			return false
+		}
+		if current["status"] == cond["status"] {
+			cond["lastTransitionTime"] = current["lastTransitionTime"]
+		}
+		existing[i] = cond
+		status["conditions"] = existing
+		return true
+	}
+	status["conditions"] = append(existing, cond)
+	return true
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestDiagnoseRunnerPod_Unschedulable verifies the scheduler's reason reaches the session message
+func TestDiagnoseRunnerPod_Unschedulable(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{
+		Phase: corev1.PodPending,
+		Conditions: []corev1.PodCondition{{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  corev1.PodReasonUnschedulable,
+			Message: "0/3 nodes are available: 3 Insufficient nvidia.com/gpu.",
+		}},
+	}}
+
+	conditions, message := diagnoseRunnerPod(pod)
+	if len(conditions) != 1 || conditions[0]["type"] != scheduledConditionType || conditions[0]["status"] != string(metav1.ConditionFalse) {
+		t.Fatalf("Expected Scheduled=False, got %v", conditions)
+	}
+	if !strings.Contains(message, "Insufficient nvidia.com/gpu") {
+		t.Errorf("Expected scheduler message in %q", message)
+	}
+}
+
+// TestDiagnoseRunnerPod_OOMCrashLoop verifies a crash loop caused by the OOM killer is reported as OOMKilled
+func TestDiagnoseRunnerPod_OOMCrashLoop(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "ambient-code-runner",
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+		}},
+	}}
+
+	conditions, message := diagnoseRunnerPod(pod)
+	if len(conditions) != 1 || conditions[0]["type"] != runnerHealthyConditionType || conditions[0]["reason"] != "OOMKilled" {
+		t.Fatalf("Expected RunnerHealthy=False/OOMKilled, got %v", conditions)
+	}
+	if !strings.Contains(message, "ambient-code-runner") {
+		t.Errorf("Expected container name in %q", message)
+	}
+}
+
+// TestSetSessionCondition verifies upserts keep other conditions and skip identical writes
+func TestSetSessionCondition(t *testing.T) {
+	status := map[string]interface{}{
+		"conditions": []interface{}{queuedCondition(metav1.ConditionFalse, "SlotAvailable", "go")},
+	}
+	cond := sessionCondition(scheduledConditionType, metav1.ConditionFalse, "Unschedulable", "no nodes")
+	if !setSessionCondition(status, cond) {
+		t.Fatal("Expected new condition to be added")
+	}
+	if setSessionCondition(status, sessionCondition(scheduledConditionType, metav1.ConditionFalse, "Unschedulable", "no nodes")) {
+		t.Error("Expected identical condition to be a no-op")
+	}
+	if !setSessionCondition(status, sessionCondition(scheduledConditionType, metav1.ConditionTrue, "Scheduled", "node-1")) {
+		t.Error("Expected changed condition to be updated")
+	}
+	if n := len(status["conditions"].([]interface{})); n != 2 {
+		t.Errorf("Expected 2 conditions, got %d", n)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxPreemptionRetries bounds how many times a preemptible session is restarted
+// after its node is reclaimed before it is marked Failed.
+const maxPreemptionRetries = 3
+
+// applyPreemptibleScheduling lets the runner pod land on spot/preemptible nodes.
+// Tolerations are harmless for taints that do not exist on the cluster; the node
+// affinity is only preferred so sessions still schedule when no spot capacity exists.
+func applyPreemptibleScheduling(podSpec *corev1.PodSpec, appConfig *config.Config) {
+	for _, key := range appConfig.PreemptibleTolerationKeys {
+		podSpec.Tolerations = append(podSpec.Tolerations, corev1.Toleration{
+			Key:      key,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+
+	labelKey, labelValue, _ := strings.Cut(appConfig.PreemptibleNodeLabel, "=")
+	if labelKey == "" {
+		return
+	}
+	req := corev1.NodeSelectorRequirement{Key: labelKey, Operator: corev1.NodeSelectorOpExists}
+	if labelValue != "" {
+		req = corev1.NodeSelectorRequirement{Key: labelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{labelValue}}
+	}
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.PreferredSchedulingTerm{
+			Weight:     100,
+			Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{req}},
+		},
+	)
+}
+
+// isPodPreempted reports whether a pod failed because its node was reclaimed
+// (spot termination, graceful node shutdown or eviction) rather than a runner error.
+func isPodPreempted(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.DisruptionTarget && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	switch pod.Status.Reason {
+	case "Shutdown", "NodeShutdown", "Terminated", "Evicted", "NodeLost":
+		return true
+	}
+	return false
+}
+
+// podLostToNode reports whether the Job's last seen pod went away with its node: the pod was
+// marked as a disruption target, or its node is gone, being deleted or not ready. Without such
+// evidence a pod that disappeared was deleted by someone, which is a failure and not a
+// preemption.
+func podLostToNode(ctx context.Context, pod *corev1.Pod) bool {
+	if pod == nil {
+		return false
+	}
+	if isPodPreempted(pod) {
+		return true
+	}
+	if pod.Spec.NodeName == "" {
+		return false
+	}
+	node, err := config.K8sClient.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return true
+	}
+	if err != nil {
+		log.Printf("Failed to read node %s of pod %s/%s: %v", pod.Spec.NodeName, pod.Namespace, pod.Name, err)
+		return false
+	}
+	if node.DeletionTimestamp != nil {
+		return true
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status != corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// retryPreemptedSession restarts a preemptible session whose pod was lost to a node
+// reclaim. The workspace PVC is retained, so the new Job resumes from that checkpoint.
+// Returns false when the session is not preemptible or has exhausted its retries, in
+// which case the caller should fail the session as usual.
+func retryPreemptedSession(session *unstructured.Unstructured, jobName string) bool {
+	sessionName := session.GetName()
+	sessionNamespace := session.GetNamespace()
+
+	preemptible, _, _ := unstructured.NestedBool(session.Object, "spec", "preemptible")
+	if !preemptible {
+		return false
+	}
+	retries, _, _ := unstructured.NestedInt64(session.Object, "status", "preemptionRetries")
+	if retries >= maxPreemptionRetries {
+		log.Printf("Session %s/%s was preempted but exhausted %d retries", sessionNamespace, sessionName, maxPreemptionRetries)
+		return false
+	}
+
+	log.Printf("Session %s/%s lost its node, retrying from checkpoint (attempt %d/%d)", sessionNamespace, sessionName, retries+1, maxPreemptionRetries)
+	if err := deleteJobAndPerJobService(sessionNamespace, jobName, sessionName); err != nil {
+		log.Printf("Failed to delete preempted job %s/%s: %v", sessionNamespace, jobName, err)
+		return false
+	}
+
+	// The next run gets a new idempotency key, so the Pending reconcile waits for the old Job
+	// to disappear instead of adopting it. Read-modify-write so the counters are never lost
+	// to a concurrent status write.
+	gvr := types.GetAgenticSessionResource()
+	if err := statusupdater.Mutate(context.TODO(), gvr, sessionNamespace, sessionName, func(status map[string]interface{}) error {
+		current, _, _ := unstructured.NestedInt64(status, "preemptionRetries")
+		status["phase"] = "Pending"
+		status["message"] = fmt.Sprintf("Node was reclaimed; retrying from checkpoint (attempt %d/%d)", current+1, maxPreemptionRetries)
+		status["preemptionRetries"] = current + 1
+		attempt, _, _ := unstructured.NestedInt64(status, "runAttempt")
+		status["runAttempt"] = attempt + 1
+		return nil
+	}); err != nil {
+		log.Printf("Failed to requeue preempted session %s/%s: %v", sessionNamespace, sessionName, err)
+		return false
+	}
+	return true
+}
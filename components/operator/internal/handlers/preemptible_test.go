@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestApplyPreemptibleScheduling_AddsTolerationsAndAffinity verifies spot tolerations and preferred affinity are set
+func TestApplyPreemptibleScheduling_AddsTolerationsAndAffinity(t *testing.T) {
+	podSpec := &corev1.PodSpec{}
+	appConfig := &config.Config{
+		PreemptibleTolerationKeys: []string{"cloud.google.com/gke-spot", "karpenter.sh/capacity-type"},
+		PreemptibleNodeLabel:      "karpenter.sh/capacity-type=spot",
+	}
+
+	applyPreemptibleScheduling(podSpec, appConfig)
+
+	if len(podSpec.Tolerations) != 2 {
+		t.Fatalf("Expected 2 tolerations, got %d", len(podSpec.Tolerations))
+	}
+	for _, tol := range podSpec.Tolerations {
+		if tol.Operator != corev1.TolerationOpExists {
+			t.Errorf("Expected toleration %s to use Exists operator, got %s", tol.Key, tol.Operator)
+		}
+	}
+
+	if podSpec.Affinity == nil || podSpec.Affinity.NodeAffinity == nil {
+		t.Fatal("Expected node affinity to be set")
+	}
+	terms := podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	if len(terms) != 1 {
+		t.Fatalf("Expected 1 preferred scheduling term, got %d", len(terms))
+	}
+	req := terms[0].Preference.MatchExpressions[0]
+	if req.Key != "karpenter.sh/capacity-type" || req.Operator != corev1.NodeSelectorOpIn || len(req.Values) != 1 || req.Values[0] != "spot" {
+		t.Errorf("Unexpected node selector requirement: %+v", req)
+	}
+	if podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		t.Error("Expected no required node affinity so sessions still schedule without spot capacity")
+	}
+}
+
+// TestIsPodPreempted verifies node reclaims are distinguished from runner failures
+func TestIsPodPreempted(t *testing.T) {
+	disrupted := &corev1.Pod{Status: corev1.PodStatus{
+		Phase:      corev1.PodFailed,
+		Conditions: []corev1.PodCondition{{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue}},
+	}}
+	if !isPodPreempted(disrupted) {
+		t.Error("Expected pod with DisruptionTarget condition to be treated as preempted")
+	}
+
+	shutdown := &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "NodeShutdown"}}
+	if !isPodPreempted(shutdown) {
+		t.Error("Expected pod failed by node shutdown to be treated as preempted")
+	}
+
+	crashed := &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Error"}}
+	if isPodPreempted(crashed) {
+		t.Error("Expected ordinary pod failure not to be treated as preempted")
+	}
+}
+
+// TestPodLostToNode verifies a vanished pod counts as preempted only with evidence from the
+// pod or its node
+func TestPodLostToNode(t *testing.T) {
+	ctx := context.Background()
+	config.K8sClient = fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ready"}, Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unreachable"}, Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}},
+		}},
+	)
+	onNode := func(node string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "runner", Namespace: "team"}, Spec: corev1.PodSpec{NodeName: node}}
+	}
+
+	if podLostToNode(ctx, nil) {
+		t.Error("Expected a pod never seen not to count as preempted")
+	}
+	if podLostToNode(ctx, onNode("ready")) {
+		t.Error("Expected a pod deleted from a healthy node not to count as preempted")
+	}
+	if !podLostToNode(ctx, onNode("reclaimed")) {
+		t.Error("Expected a pod whose node is gone to count as preempted")
+	}
+	if !podLostToNode(ctx, onNode("unreachable")) {
+		t.Error("Expected a pod on a node that is not ready to count as preempted")
+	}
+	disrupted := onNode("ready")
+	disrupted.Status.Conditions = []corev1.PodCondition{{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue}}
+	if !podLostToNode(ctx, disrupted) {
+		t.Error("Expected a pod marked as a disruption target to count as preempted")
+	}
+}
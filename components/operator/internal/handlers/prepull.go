@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// imagePrepullName is the DaemonSet that keeps session images cached on runner nodes
+	imagePrepullName = "ambient-image-prepull"
+	// imagePrepullPauseImage is the only long-running container of the prepull pods
+	imagePrepullPauseImage = "registry.k8s.io/pause:3.10"
+	// imagePrepullInterval is how often the DaemonSet is checked, so it is recreated when
+	// deleted and updated when the configured images change
+	imagePrepullInterval = 10 * time.Minute
+	// workspaceInitImage initializes and clones session workspaces
+	workspaceInitImage = "registry.access.redhat.com/ubi8/ubi-minimal:latest"
+)
+
+// MaintainImagePrepull keeps the prepull DaemonSet in line with the operator configuration:
+// with IMAGE_PREPULL=true every matching node pulls the runner, content service and workspace
+// init images ahead of time, so session pods start without a multi-gigabyte pull. Turning
+// the option off removes the DaemonSet.
+func MaintainImagePrepull() {
+	for {
+		if err := reconcileImagePrepull(context.TODO(), config.LoadConfig()); err != nil {
+			log.Printf("Failed to reconcile image prepull DaemonSet: %v", err)
+		}
+		time.Sleep(imagePrepullInterval)
+	}
+}
+
+func reconcileImagePrepull(ctx context.Context, cfg *config.Config) error {
+	daemonSets := config.K8sClient.AppsV1().DaemonSets(cfg.Namespace)
+	if !cfg.ImagePrepull {
+		if err := daemonSets.Delete(ctx, imagePrepullName, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete image prepull DaemonSet: %w", err)
+		}
+		return nil
+	}
+	ds, err := imagePrepullDaemonSet(cfg)
+	if err != nil {
+		return err
+	}
+	existing, err := daemonSets.Get(ctx, imagePrepullName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := daemonSets.Create(ctx, ds, v1.CreateOptions{}); err != nil {
+			return fmt.Errorf("create image prepull DaemonSet: %w", err)
+		}
+		log.Printf("Created image prepull DaemonSet %s/%s for %d images", cfg.Namespace, imagePrepullName, len(ds.Spec.Template.Spec.InitContainers))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get image prepull DaemonSet: %w", err)
+	}
+	// A new image rolls the DaemonSet, so each node pulls it before sessions need it
+	existing.Labels = ds.Labels
+	existing.Spec.Template = ds.Spec.Template
+	if _, err := daemonSets.Update(ctx, existing, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update image prepull DaemonSet: %w", err)
+	}
+	return nil
+}
+
+// imagePrepullImages are the images of session pods, then the extra images, without duplicates
+func imagePrepullImages(cfg *config.Config) []string {
+	images := []string{}
+	seen := map[string]bool{}
+	for _, image := range append([]string{cfg.AmbientCodeRunnerImage, cfg.ContentServiceImage, workspaceInitImage}, cfg.ImagePrepullExtraImages...) {
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+		images = append(images, image)
+	}
+	return images
+}
+
+// imagePrepullDaemonSet pulls each image in an init container that exits at once, then idles
+// in a pause container. The pods tolerate the taints session pods tolerate (spot and GPU
+// nodes) and request almost nothing.
+func imagePrepullDaemonSet(cfg *config.Config) (*appsv1.DaemonSet, error) {
+	var nodeSelector map[string]string
+	if cfg.ImagePrepullNodeSelector != "" {
+		selector, err := labels.ConvertSelectorToLabelsMap(cfg.ImagePrepullNodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IMAGE_PREPULL_NODE_SELECTOR %q: %w", cfg.ImagePrepullNodeSelector, err)
+		}
+		nodeSelector = selector
+	}
+	podLabels := map[string]string{"app": imagePrepullName}
+	minimal := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1m"),
+			corev1.ResourceMemory: resource.MustParse("8Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+	}
+
+	var initContainers []corev1.Container
+	for i, image := range imagePrepullImages(cfg) {
+		initContainers = append(initContainers, corev1.Container{
+			Name:            fmt.Sprintf("pull-%d", i),
+			Image:           image,
+			ImagePullPolicy: cfg.ImagePullPolicy,
+			Command:         []string{"sh", "-c", "true"},
+			Resources:       minimal,
+		})
+	}
+	tolerations := []corev1.Toleration{{Key: apiv1alpha1.GPUResourceName, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}}
+	for _, key := range cfg.PreemptibleTolerationKeys {
+		tolerations = append(tolerations, corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists})
+	}
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: v1.ObjectMeta{Name: imagePrepullName, Namespace: cfg.Namespace, Labels: podLabels},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &v1.LabelSelector{MatchLabels: podLabels},
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
+				Type: appsv1.RollingUpdateDaemonSetStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					AutomountServiceAccountToken: boolPtr(false),
+					NodeSelector:                 nodeSelector,
+					Tolerations:                  tolerations,
+					InitContainers:               initContainers,
+					Containers: []corev1.Container{{
+						Name:      "pause",
+						Image:     imagePrepullPauseImage,
+						Resources: minimal,
+					}},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestReconcileImagePrepull verifies the DaemonSet pulls each session image once on the
+// selected nodes, follows image changes and is removed when prepull is turned off
+func TestReconcileImagePrepull(t *testing.T) {
+	setupTestClient()
+	ctx := context.Background()
+	cfg := &config.Config{
+		Namespace:                 "ambient-code",
+		AmbientCodeRunnerImage:    "quay.io/ambient_code/vteam_claude_runner:v1",
+		ContentServiceImage:       "quay.io/ambient_code/vteam_backend:v1",
+		ImagePullPolicy:           corev1.PullIfNotPresent,
+		PreemptibleTolerationKeys: []string{"cloud.google.com/gke-spot"},
+		ImagePrepull:              true,
+		ImagePrepullNodeSelector:  "pool=runners",
+		ImagePrepullExtraImages:   []string{"quay.io/ambient_code/vteam_backend:v1", "quay.io/org/workflow-tools:v2"},
+	}
+	if err := reconcileImagePrepull(ctx, cfg); err != nil {
+		t.Fatalf("reconcileImagePrepull: %v", err)
+	}
+	daemonSets := config.K8sClient.AppsV1().DaemonSets("ambient-code")
+	ds, err := daemonSets.Get(ctx, imagePrepullName, v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("DaemonSet not created: %v", err)
+	}
+	pod := ds.Spec.Template.Spec
+	want := []string{cfg.AmbientCodeRunnerImage, cfg.ContentServiceImage, workspaceInitImage, "quay.io/org/workflow-tools:v2"}
+	if len(pod.InitContainers) != len(want) {
+		t.Fatalf("got %d init containers, want %d", len(pod.InitContainers), len(want))
+	}
+	for i, c := range pod.InitContainers {
+		if c.Image != want[i] {
+			t.Errorf("init container %d pulls %s, want %s", i, c.Image, want[i])
+		}
+	}
+	if pod.NodeSelector["pool"] != "runners" {
+		t.Errorf("nodeSelector = %v", pod.NodeSelector)
+	}
+	if len(pod.Tolerations) != 2 {
+		t.Errorf("tolerations = %v", pod.Tolerations)
+	}
+
+	cfg.AmbientCodeRunnerImage = "quay.io/ambient_code/vteam_claude_runner:v2"
+	if err := reconcileImagePrepull(ctx, cfg); err != nil {
+		t.Fatalf("reconcileImagePrepull: %v", err)
+	}
+	if ds, _ = daemonSets.Get(ctx, imagePrepullName, v1.GetOptions{}); ds.Spec.Template.Spec.InitContainers[0].Image != cfg.AmbientCodeRunnerImage {
+		t.Errorf("runner image not updated: %s", ds.Spec.Template.Spec.InitContainers[0].Image)
+	}
+
+	cfg.ImagePrepull = false
+	if err := reconcileImagePrepull(ctx, cfg); err != nil {
+		t.Fatalf("reconcileImagePrepull: %v", err)
+	}
+	if _, err := daemonSets.Get(ctx, imagePrepullName, v1.GetOptions{}); err == nil {
+		t.Error("DaemonSet should be deleted when prepull is off")
+	}
+	if err := reconcileImagePrepull(ctx, cfg); err != nil {
+		t.Errorf("reconciling with nothing to delete: %v", err)
+	}
+
+	cfg.ImagePrepull, cfg.ImagePrepullNodeSelector = true, "pool in (a b)"
+	if err := reconcileImagePrepull(ctx, cfg); err == nil {
+		t.Error("an invalid node selector should fail")
+	}
+}
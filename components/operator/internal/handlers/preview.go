@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/diagnostics"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// A Preview runs a completed session's deployable artifact as a Deployment, Service and
+// Route (or Ingress) owned by the Preview, so deleting the Preview removes them. The resync
+// follows the rollout into status.phase and deletes previews whose TTL has passed.
+
+// previewResync is how often every preview is checked for readiness and expiry
+const previewResync = time.Minute
+
+// previewServicePort is the port of the preview Service the Route and Ingress point at
+const previewServicePort = 80
+
+var routeResource = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+
+// WatchPreviews reconciles Previews on change and on a periodic resync
+func WatchPreviews() {
+	gvr := types.GetPreviewResource()
+	go func() {
+		for range time.Tick(previewResync) {
+			resyncPreviews(context.TODO())
+		}
+	}()
+	for {
+		watcher, err := config.DynamicClient.Resource(gvr).Namespace("").Watch(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			log.Printf("Failed to create Preview watcher (is the CRD installed?): %v", err)
+			time.Sleep(30 * time.Second)
+			continue
+		}
+		log.Println("Watching for Preview events...")
+		for event := range watcher.ResultChan() {
+			diagnostics.Default.WatchEvent("Preview")
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				// Status writes also arrive as modifications; only spec changes need work now
+				observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+				if observed != obj.GetGeneration() && WatchScope.Allows(context.TODO(), obj.GetNamespace()) {
+					reconcilePreview(context.TODO(), obj, config.LoadConfig(), time.Now())
+				}
+			case watch.Error:
+				log.Printf("Watch error for Previews: %v", obj)
+			}
+		}
+		log.Println("Preview watch channel closed, restarting...")
+		diagnostics.Default.WatchRestarted("Preview")
+		watcher.Stop()
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func resyncPreviews(ctx context.Context) {
+	list, err := config.DynamicClient.Resource(types.GetPreviewResource()).Namespace("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to list Previews: %v", err)
+		}
+		return
+	}
+	cfg := config.LoadConfig()
+	for i := range list.Items {
+		if WatchScope.Allows(ctx, list.Items[i].GetNamespace()) {
+			reconcilePreview(ctx, &list.Items[i], cfg, time.Now())
+		}
+	}
+}
+
+// reconcilePreview brings the preview's objects in line with its spec and reports the
+// rollout, or deletes the preview once it has expired
+func reconcilePreview(ctx context.Context, preview *unstructured.Unstructured, cfg *config.Config, now time.Time) {
+	done := diagnostics.Default.BeginReconcile("Preview", preview.GetNamespace(), preview.GetName())
+	var err error
+	defer func() { done(err) }()
+
+	status := apiv1alpha1.PreviewStatus{ObservedGeneration: preview.GetGeneration(), Phase: apiv1alpha1.PreviewPending}
+	var spec apiv1alpha1.PreviewSpec
+	raw, _ := json.Marshal(preview.Object["spec"])
+	_ = json.Unmarshal(raw, &spec)
+	if specErr := spec.Validate(); specErr != nil {
+		status.Phase, status.Message = apiv1alpha1.PreviewFailed, specErr.Error()
+		updatePreviewStatus(ctx, preview, status)
+		return
+	}
+
+	ttl, _ := apiv1alpha1.ParsePreviewTTL(spec.TTL)
+	expiresAt := preview.GetCreationTimestamp().Add(ttl)
+	if !now.Before(expiresAt) {
+		// The Deployment, Service and Route are owned by the preview and go with it
+		err = config.DynamicClient.Resource(types.GetPreviewResource()).Namespace(preview.GetNamespace()).Delete(ctx, preview.GetName(), v1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			log.Printf("Failed to delete expired Preview %s/%s: %v", preview.GetNamespace(), preview.GetName(), err)
+			return
+		}
+		log.Printf("Deleted Preview %s/%s after its TTL of %s", preview.GetNamespace(), preview.GetName(), ttl)
+		err = nil
+		return
+	}
+	expires := v1.NewTime(expiresAt)
+	status.ExpiresAt = &expires
+
+	owner := v1.OwnerReference{
+		APIVersion: apiv1alpha1.SchemeGroupVersion.String(),
+		Kind:       "Preview",
+		Name:       preview.GetName(),
+		UID:        preview.GetUID(),
+		Controller: boolPtr(true),
+	}
+	meta, err := namedSessionObjectMetadata(ctx, preview.GetNamespace(), spec.SessionName)
+	if err != nil {
+		status.Message = fmt.Sprintf("Failed to read the project's object metadata policy: %v", err)
+		updatePreviewStatus(ctx, preview, status)
+		return
+	}
+	deployment, err := ensurePreviewDeployment(ctx, preview.GetNamespace(), preview.GetName(), &spec, owner, meta)
+	if err != nil {
+		status.Message = fmt.Sprintf("Failed to create the preview Deployment: %v", err)
+		updatePreviewStatus(ctx, preview, status)
+		return
+	}
+	if err = ensurePreviewService(ctx, preview.GetNamespace(), preview.GetName(), &spec, owner, meta); err != nil {
+		status.Message = fmt.Sprintf("Failed to create the preview Service: %v", err)
+		updatePreviewStatus(ctx, preview, status)
+		return
+	}
+	status.URL, err = ensurePreviewExposure(ctx, preview.GetNamespace(), preview.GetName(), spec.SessionName, cfg, owner, meta)
+	if err != nil {
+		status.Message = fmt.Sprintf("Failed to expose the preview: %v", err)
+		updatePreviewStatus(ctx, preview, status)
+		return
+	}
+
+	status.Phase, status.Message = previewRolloutPhase(deployment)
+	if status.Phase == apiv1alpha1.PreviewPending && status.URL == "" {
+		status.Message = "Waiting for the route host"
+	}
+	updatePreviewStatus(ctx, preview, status)
+}
+
+// previewRolloutPhase is Ready once a pod serves, Failed when the rollout exceeded its deadline
+func previewRolloutPhase(deployment *appsv1.Deployment) (apiv1alpha1.PreviewPhase, string) {
+	for _, c := range deployment.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+			return apiv1alpha1.PreviewFailed, fmt.Sprintf("Preview did not become ready: %s", c.Message)
+		}
+	}
+	if deployment.Status.AvailableReplicas > 0 {
+		return apiv1alpha1.PreviewReady, "Preview is serving"
+	}
+	return apiv1alpha1.PreviewPending, "Waiting for the preview pod to become ready"
+}
+
+func previewLabels(name, session string) map[string]string {
+	return map[string]string{
+		"app":                           "ambient-preview",
+		"ambient-code.io/preview":       name,
+		apiv1alpha1.PreviewSessionLabel: session,
+	}
+}
+
+// ensurePreviewDeployment creates the preview's Deployment, or updates its pod template when
+// the image or port changed. The pod runs unprivileged: the image is built by an agent.
+func ensurePreviewDeployment(ctx context.Context, namespace, name string, spec *apiv1alpha1.PreviewSpec, owner v1.OwnerReference, meta apiv1alpha1.ObjectMetadata) (*appsv1.Deployment, error) {
+	labels := previewLabels(name, spec.SessionName)
+	port := spec.ContainerPort()
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: v1.ObjectMeta{Labels: labels},
+		Spec: corev1.PodSpec{
+			AutomountServiceAccountToken: boolPtr(false),
+			EnableServiceLinks:           boolPtr(false),
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   boolPtr(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{{
+				Name:  "preview",
+				Image: spec.Image,
+				Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: port}},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler:  corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(port)}},
+					PeriodSeconds: 5,
+				},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("512Mi")},
+				},
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: boolPtr(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+		},
+	}
+	deployments := config.K8sClient.AppsV1().Deployments(namespace)
+	existing, err := deployments.Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels, OwnerReferences: []v1.OwnerReference{owner}},
+			Spec: appsv1.DeploymentSpec{
+				Replicas:                int32Ptr(1),
+				Selector:                &v1.LabelSelector{MatchLabels: map[string]string{"ambient-code.io/preview": name}},
+				Template:                template,
+				ProgressDeadlineSeconds: int32Ptr(300),
+			},
+		}
+		meta.Apply(deployment, &deployment.Spec.Template)
+		log.Printf("Creating preview Deployment %s/%s for session %s", namespace, name, spec.SessionName)
+		return deployments.Create(ctx, deployment, v1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := existing.Spec.Template.Spec.Containers
+	if len(c) == 1 && c[0].Image == spec.Image && len(c[0].Ports) == 1 && c[0].Ports[0].ContainerPort == port {
+		return existing, nil
+	}
+	existing.Spec.Template = template
+	return deployments.Update(ctx, existing, v1.UpdateOptions{})
+}
+
+func ensurePreviewService(ctx context.Context, namespace, name string, spec *apiv1alpha1.PreviewSpec, owner v1.OwnerReference, meta apiv1alpha1.ObjectMetadata) error {
+	services := config.K8sClient.CoreV1().Services(namespace)
+	if _, err := services.Get(ctx, name, v1.GetOptions{}); !errors.IsNotFound(err) {
+		return err
+	}
+	service := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace, Labels: previewLabels(name, spec.SessionName), OwnerReferences: []v1.OwnerReference{owner}},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"ambient-code.io/preview": name},
+			Ports:    []corev1.ServicePort{{Name: "http", Port: previewServicePort, TargetPort: intstr.FromString("http")}},
+		},
+	}
+	meta.Apply(service)
+	_, err := services.Create(ctx, service, v1.CreateOptions{})
+	return err
+}
+
+// ensurePreviewExposure creates the preview's Route or Ingress and returns its URL, empty
+// until OpenShift has assigned a generated route host
+func ensurePreviewExposure(ctx context.Context, namespace, name, session string, cfg *config.Config, owner v1.OwnerReference, meta apiv1alpha1.ObjectMetadata) (string, error) {
+	host := ""
+	if cfg.PreviewDomain != "" {
+		host = fmt.Sprintf("%s-%s.%s", name, namespace, cfg.PreviewDomain)
+	}
+	switch cfg.PreviewExpose {
+	case config.PreviewExposeNone:
+		return fmt.Sprintf("http://%s.%s.svc:%d", name, namespace, previewServicePort), nil
+	case config.PreviewExposeIngress:
+		if host == "" {
+			return "", fmt.Errorf("PREVIEW_DOMAIN is required to expose previews with an Ingress")
+		}
+		return "http://" + host, ensurePreviewIngress(ctx, namespace, name, session, host, cfg.PreviewIngressClass, owner, meta)
+	}
+
+	routes := config.DynamicClient.Resource(routeResource).Namespace(namespace)
+	route, err := routes.Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		spec := map[string]interface{}{
+			"to":   map[string]interface{}{"kind": "Service", "name": name},
+			"port": map[string]interface{}{"targetPort": "http"},
+			"tls":  map[string]interface{}{"termination": "edge", "insecureEdgeTerminationPolicy": "Redirect"},
+		}
+		if host != "" {
+			spec["host"] = host
+		}
+		labels := map[string]interface{}{}
+		for k, v := range previewLabels(name, session) {
+			labels[k] = v
+		}
+		route = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "route.openshift.io/v1",
+			"kind":       "Route",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels":    labels,
+				"ownerReferences": []interface{}{map[string]interface{}{
+					"apiVersion": owner.APIVersion, "kind": owner.Kind, "name": owner.Name, "uid": string(owner.UID), "controller": true,
+				}},
+			},
+			"spec": spec,
+		}}
+		meta.Apply(route)
+		route, err = routes.Create(ctx, route, v1.CreateOptions{})
+	}
+	if err != nil {
+		return "", err
+	}
+	if h, _, _ := unstructured.NestedString(route.Object, "spec", "host"); h != "" {
+		return "https://" + h, nil
+	}
+	ingress, _, _ := unstructured.NestedSlice(route.Object, "status", "ingress")
+	for _, item := range ingress {
+		if h, _ := item.(map[string]interface{})["host"].(string); h != "" {
+			return "https://" + h, nil
+		}
+	}
+	return "", nil
+}
+
+func ensurePreviewIngress(ctx context.Context, namespace, name, session, host, class string, owner v1.OwnerReference, meta apiv1alpha1.ObjectMetadata) error {
+	ingresses := config.K8sClient.NetworkingV1().Ingresses(namespace)
+	if _, err := ingresses.Get(ctx, name, v1.GetOptions{}); !errors.IsNotFound(err) {
+		return err
+	}
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace, Labels: previewLabels(name, session), OwnerReferences: []v1.OwnerReference{owner}},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: name, Port: networkingv1.ServiceBackendPort{Number: previewServicePort},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+	if class != "" {
+		ingress.Spec.IngressClassName = &class
+	}
+	meta.Apply(ingress)
+	_, err := ingresses.Create(ctx, ingress, v1.CreateOptions{})
+	return err
+}
+
+func updatePreviewStatus(ctx context.Context, preview *unstructured.Unstructured, desired apiv1alpha1.PreviewStatus) {
+	raw, _ := json.Marshal(desired)
+	err := statusupdater.Mutate(ctx, types.GetPreviewResource(), preview.GetNamespace(), preview.GetName(), func(current map[string]interface{}) error {
+		status := map[string]interface{}{}
+		_ = json.Unmarshal(raw, &status)
+		return replaceStatus(current, status)
+	})
+	if err != nil {
+		log.Printf("Failed to update Preview %s/%s status: %v", preview.GetNamespace(), preview.GetName(), err)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestReconcilePreview verifies a preview gets an unprivileged Deployment, a Service and a
+// Route owned by it, turns Ready once the pod is available, and is deleted after its TTL
+func TestReconcilePreview(t *testing.T) {
+	ctx := context.Background()
+	setupTestClient()
+	created := time.Now().Add(-time.Hour)
+	preview := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "Preview",
+		"metadata": map[string]interface{}{
+			"name": "preview-s1", "namespace": "proj", "generation": int64(1), "uid": "p-uid",
+			"creationTimestamp": created.UTC().Format(time.RFC3339),
+		},
+		"spec": map[string]interface{}{"sessionName": "s1", "image": "quay.io/team/app:abc", "port": int64(3000), "ttl": "2h"},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetPreviewResource(): "PreviewList",
+		routeResource:              "RouteList",
+	})
+	previews := config.DynamicClient.Resource(types.GetPreviewResource()).Namespace("proj")
+	if _, err := previews.Create(ctx, preview, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{PreviewExpose: config.PreviewExposeRoute, PreviewDomain: "apps.example.com"}
+
+	reconcilePreview(ctx, preview, cfg, time.Now())
+
+	d, err := config.K8sClient.AppsV1().Deployments("proj").Get(ctx, "preview-s1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Deployment not created: %v", err)
+	}
+	pod := d.Spec.Template.Spec
+	c := pod.Containers[0]
+	if c.Image != "quay.io/team/app:abc" || c.Ports[0].ContainerPort != 3000 || !*pod.SecurityContext.RunAsNonRoot || *c.SecurityContext.AllowPrivilegeEscalation {
+		t.Errorf("preview pod = %+v", pod)
+	}
+	if len(d.OwnerReferences) != 1 || d.OwnerReferences[0].Kind != "Preview" {
+		t.Errorf("owner references = %v", d.OwnerReferences)
+	}
+	if _, err := config.K8sClient.CoreV1().Services("proj").Get(ctx, "preview-s1", metav1.GetOptions{}); err != nil {
+		t.Errorf("Service not created: %v", err)
+	}
+	route, err := config.DynamicClient.Resource(routeResource).Namespace("proj").Get(ctx, "preview-s1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Route not created: %v", err)
+	}
+	if host, _, _ := unstructured.NestedString(route.Object, "spec", "host"); host != "preview-s1-proj.apps.example.com" {
+		t.Errorf("route host = %q", host)
+	}
+
+	status := func() map[string]interface{} {
+		obj, err := previews.Get(ctx, "preview-s1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, _, _ := unstructured.NestedMap(obj.Object, "status")
+		return s
+	}
+	if s := status(); s["phase"] != "Pending" || s["url"] != "https://preview-s1-proj.apps.example.com" || s["expiresAt"] == nil {
+		t.Errorf("pending status = %v", s)
+	}
+
+	d.Status = appsv1.DeploymentStatus{AvailableReplicas: 1}
+	if _, err := config.K8sClient.AppsV1().Deployments("proj").UpdateStatus(ctx, d, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	reconcilePreview(ctx, preview, cfg, time.Now())
+	if s := status(); s["phase"] != "Ready" {
+		t.Errorf("ready status = %v", s)
+	}
+
+	reconcilePreview(ctx, preview, cfg, created.Add(2*time.Hour))
+	if _, err := previews.Get(ctx, "preview-s1", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expired preview kept: %v", err)
+	}
+}
+
+func TestReconcilePreviewInvalidSpec(t *testing.T) {
+	ctx := context.Background()
+	setupTestClient()
+	preview := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "Preview",
+		"metadata":   map[string]interface{}{"name": "preview-s2", "namespace": "proj", "generation": int64(1)},
+		"spec":       map[string]interface{}{"sessionName": "s2", "image": "app:1", "ttl": "30d"},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetPreviewResource(): "PreviewList",
+	})
+	previews := config.DynamicClient.Resource(types.GetPreviewResource()).Namespace("proj")
+	if _, err := previews.Create(ctx, preview, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcilePreview(ctx, preview, &config.Config{PreviewExpose: config.PreviewExposeNone}, time.Now())
+
+	obj, _ := previews.Get(ctx, "preview-s2", metav1.GetOptions{})
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "Failed" {
+		t.Errorf("phase = %q, want Failed for a TTL above the maximum", phase)
+	}
+	if _, err := config.K8sClient.AppsV1().Deployments("proj").Get(ctx, "preview-s2", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Deployment created for an invalid preview: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// syncSessionProgress maps the runner's step reports (apiv1alpha1.ProgressAnnotation) to
+// status.steps and status.progress. A successfully completed session is at 100% whatever
+// the runner last reported.
+func syncSessionProgress(ctx context.Context, obj *unstructured.Unstructured, phase string) error {
+	raw, ok := obj.GetAnnotations()[apiv1alpha1.ProgressAnnotation]
+	if !ok {
+		return nil
+	}
+	reports, err := apiv1alpha1.ParseStepReports(raw)
+	if err != nil {
+		return err
+	}
+	return statusupdater.Mutate(ctx, types.GetAgenticSessionResource(), obj.GetNamespace(), obj.GetName(), func(status map[string]interface{}) error {
+		var current []apiv1alpha1.SessionStep
+		if existing, ok := status["steps"].([]interface{}); ok {
+			b, _ := json.Marshal(existing)
+			_ = json.Unmarshal(b, &current)
+		}
+		steps := apiv1alpha1.MergeSteps(current, reports, v1.Now())
+		progress := apiv1alpha1.ProgressPercent(steps)
+		if phase == "Completed" {
+			progress = 100
+		}
+
+		b, err := json.Marshal(steps)
+		if err != nil {
+			return fmt.Errorf("failed to encode steps: %w", err)
+		}
+		var encoded []interface{}
+		if err := json.Unmarshal(b, &encoded); err != nil {
+			return fmt.Errorf("failed to encode steps: %w", err)
+		}
+		if reflect.DeepEqual(current, steps) && progressOf(status) == progress {
+			return statusupdater.ErrNoChange
+		}
+		status["steps"] = encoded
+		status["progress"] = int64(progress)
+		return nil
+	})
+}
+
+func progressOf(status map[string]interface{}) int {
+	switch v := status["progress"].(type) {
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return -1
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestSyncSessionProgress verifies step reports are mapped to status.steps and status.progress
+func TestSyncSessionProgress(t *testing.T) {
+	ctx := context.Background()
+	session := testSession("s1", "Running", nil)
+	session.SetAnnotations(map[string]string{
+		apiv1alpha1.ProgressAnnotation: `[{"name":"clone","state":"Completed"},{"name":"analyze","state":"Running","message":"reading src/"}]`,
+	})
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, session)
+	config.DynamicClient = client
+	sessions := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj")
+
+	if err := syncSessionProgress(ctx, session, "Running"); err != nil {
+		t.Fatalf("syncSessionProgress: %v", err)
+	}
+	got, _ := sessions.Get(ctx, "s1", metav1.GetOptions{})
+	steps, _, _ := unstructured.NestedSlice(got.Object, "status", "steps")
+	progress, _, _ := unstructured.NestedInt64(got.Object, "status", "progress")
+	if len(steps) != len(apiv1alpha1.SessionStepNames) || progress != 30 {
+		t.Fatalf("unexpected status %v", got.Object["status"])
+	}
+	analyze := steps[1].(map[string]interface{})
+	if analyze["state"] != "Running" || analyze["message"] != "reading src/" || analyze["startedAt"] == nil {
+		t.Errorf("unexpected analyze step %v", analyze)
+	}
+
+	// Reconciling unchanged reports must not write again (status writes re-trigger the watch)
+	client.ClearActions()
+	if err := syncSessionProgress(ctx, got, "Running"); err != nil {
+		t.Fatalf("syncSessionProgress: %v", err)
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "get" {
+			t.Errorf("expected no status write for unchanged reports, got %s", action.GetVerb())
+		}
+	}
+
+	if err := syncSessionProgress(ctx, got, "Completed"); err != nil {
+		t.Fatalf("syncSessionProgress: %v", err)
+	}
+	got, _ = sessions.Get(ctx, "s1", metav1.GetOptions{})
+	if progress, _, _ := unstructured.NestedInt64(got.Object, "status", "progress"); progress != 100 {
+		t.Errorf("expected a completed session at 100%%, got %d", progress)
+	}
+}
@@ -10,20 +10,23 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 
+	"ambient-code-operator/internal/assets"
 	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/diagnostics"
+	"ambient-code-operator/internal/statusupdater"
 	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/settingshistory"
 )
 
 // WatchProjectSettings watches for ProjectSettings resources and reconciles them
 func WatchProjectSettings() {
-	gvr := types.GetProjectSettingsResource()
-
 	for {
 		// Watch across all namespaces for ProjectSettings
-		watcher, err := config.DynamicClient.Resource(gvr).Watch(context.TODO(), v1.ListOptions{})
+		watcher, err := config.VteamClient.VteamV1alpha1().ProjectSettings("").Watch(context.TODO(), v1.ListOptions{})
 		if err != nil {
 			log.Printf("Failed to create ProjectSettings watcher: %v", err)
 			time.Sleep(5 * time.Second)
@@ -33,38 +36,44 @@ func WatchProjectSettings() {
 		log.Println("Watching for ProjectSettings events...")
 
 		for event := range watcher.ResultChan() {
+			diagnostics.Default.WatchEvent("ProjectSettings")
 			switch event.Type {
 			case watch.Added, watch.Modified:
-				obj := event.Object.(*unstructured.Unstructured)
+				ps, ok := event.Object.(*apiv1alpha1.ProjectSettings)
+				if !ok || !WatchScope.Allows(context.TODO(), ps.Namespace) {
+					continue
+				}
 
 				// Add small delay to avoid race conditions
 				time.Sleep(100 * time.Millisecond)
 
-				if err := handleProjectSettingsEvent(obj); err != nil {
+				done := diagnostics.Default.BeginReconcile("ProjectSettings", ps.Namespace, ps.Name)
+				err := handleProjectSettingsEvent(ps)
+				done(err)
+				if err != nil {
 					log.Printf("Error handling ProjectSettings event: %v", err)
 				}
 			case watch.Deleted:
-				obj := event.Object.(*unstructured.Unstructured)
-				settingsName := obj.GetName()
-				settingsNamespace := obj.GetNamespace()
-				log.Printf("ProjectSettings %s/%s deleted", settingsNamespace, settingsName)
+				if ps, ok := event.Object.(*apiv1alpha1.ProjectSettings); ok {
+					log.Printf("ProjectSettings %s/%s deleted", ps.Namespace, ps.Name)
+				}
 			case watch.Error:
-				obj := event.Object.(*unstructured.Unstructured)
-				log.Printf("Watch error for ProjectSettings: %v", obj)
+				log.Printf("Watch error for ProjectSettings: %v", errors.FromObject(event.Object))
 			}
 		}
 
 		log.Println("ProjectSettings watch channel closed, restarting...")
+		diagnostics.Default.WatchRestarted("ProjectSettings")
 		watcher.Stop()
 		time.Sleep(2 * time.Second)
 	}
 }
 
 func createDefaultProjectSettings(namespaceName string) error {
-	gvr := types.GetProjectSettingsResource()
+	client := config.VteamClient.VteamV1alpha1().ProjectSettings(namespaceName)
 
 	// Check if ProjectSettings already exists in this namespace (singleton named 'projectsettings')
-	_, err := config.DynamicClient.Resource(gvr).Namespace(namespaceName).Get(context.TODO(), "projectsettings", v1.GetOptions{})
+	_, err := client.Get(context.TODO(), apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
 	if err == nil {
 		log.Printf("ProjectSettings already exists in namespace %s", namespaceName)
 		return nil
@@ -74,23 +83,19 @@ func createDefaultProjectSettings(namespaceName string) error {
 		return fmt.Errorf("error checking existing ProjectSettings: %v", err)
 	}
 
-	// Create default ProjectSettings (minimal: only groupAccess)
-	defaultSettings := &unstructured.Unstructured{
-		Object: map[string]interface{}{
-			"apiVersion": "vteam.ambient-code/v1alpha1",
-			"kind":       "ProjectSettings",
-			"metadata": map[string]interface{}{
-				// Enforce singleton: fixed name 'projectsettings'
-				"name":      "projectsettings",
-				"namespace": namespaceName,
-			},
-			"spec": map[string]interface{}{
-				"groupAccess": []interface{}{},
-			},
-		},
+	// Create default ProjectSettings (minimal: only groupAccess), shared with `operator install`
+	defaults, err := assets.DefaultProjectSettings(namespaceName)
+	if err != nil {
+		return err
 	}
+	defaultSettings := &apiv1alpha1.ProjectSettings{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(defaults.Object, defaultSettings); err != nil {
+		return fmt.Errorf("invalid default ProjectSettings: %v", err)
+	}
+	// Enforce singleton: fixed name 'projectsettings'
+	defaultSettings.Name = apiv1alpha1.ProjectSettingsName
 
-	_, err = config.DynamicClient.Resource(gvr).Namespace(namespaceName).Create(context.TODO(), defaultSettings, v1.CreateOptions{})
+	_, err = client.Create(context.TODO(), defaultSettings, v1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create default ProjectSettings: %v", err)
 	}
@@ -99,13 +104,12 @@ func createDefaultProjectSettings(namespaceName string) error {
 	return nil
 }
 
-func handleProjectSettingsEvent(obj *unstructured.Unstructured) error {
-	name := obj.GetName()
-	namespace := obj.GetNamespace()
+func handleProjectSettingsEvent(ps *apiv1alpha1.ProjectSettings) error {
+	name := ps.Name
+	namespace := ps.Namespace
 
 	// Verify the resource still exists before processing
-	gvr := types.GetProjectSettingsResource()
-	currentObj, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, v1.GetOptions{})
+	current, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(context.TODO(), name, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			log.Printf("ProjectSettings %s/%s no longer exists, skipping processing", namespace, name)
@@ -114,31 +118,34 @@ func handleProjectSettingsEvent(obj *unstructured.Unstructured) error {
 		return fmt.Errorf("failed to verify ProjectSettings %s/%s exists: %v", namespace, name, err)
 	}
 
+	// History is best effort: a failed snapshot must not block reconciliation
+	if _, err := settingshistory.Record(context.TODO(), config.K8sClient, current, "", 0); err != nil {
+		log.Printf("Failed to record ProjectSettings revision for %s: %v", namespace, err)
+	}
+
 	log.Printf("Reconciling ProjectSettings %s/%s", namespace, name)
-	return reconcileProjectSettings(currentObj)
+	return reconcileProjectSettings(current)
 }
 
-func reconcileProjectSettings(obj *unstructured.Unstructured) error {
-	namespace := obj.GetNamespace()
-	name := obj.GetName()
-
-	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+func reconcileProjectSettings(ps *apiv1alpha1.ProjectSettings) error {
+	namespace := ps.Namespace
 
 	// Reconcile group access (RoleBindings)
 	groupBindingsCreated := 0
-	if groupAccess, found, _ := unstructured.NestedSlice(spec, "groupAccess"); found {
-		for _, accessInterface := range groupAccess {
-			access := accessInterface.(map[string]interface{})
-			groupName, _, _ := unstructured.NestedString(access, "groupName")
-			role, _, _ := unstructured.NestedString(access, "role")
-			if groupName != "" && role != "" {
-				if err := ensureRoleBinding(namespace, groupName, role); err != nil {
-					log.Printf("Error creating RoleBinding for group %s in namespace %s: %v", groupName, namespace, err)
-					continue
-				}
-				groupBindingsCreated++
-			}
+	for _, access := range ps.Spec.GroupAccess {
+		if access.GroupName == "" || access.Role == "" {
+			continue
+		}
+		if err := ensureRoleBinding(namespace, access.GroupName, access.Role, ps.Spec.ObjectMetadata.For(nil, nil)); err != nil {
+			log.Printf("Error creating RoleBinding for group %s in namespace %s: %v", access.GroupName, namespace, err)
+			continue
 		}
+		groupBindingsCreated++
+	}
+
+	// Git mirror cache; a failure must not block the status update
+	if err := reconcileGitMirror(ps); err != nil {
+		log.Printf("Error reconciling git mirror in namespace %s: %v", namespace, err)
 	}
 
 	// Update status with reconciliation results (only fields defined in CRD)
@@ -146,10 +153,10 @@ func reconcileProjectSettings(obj *unstructured.Unstructured) error {
 		"groupBindingsCreated": groupBindingsCreated,
 	}
 
-	return updateProjectSettingsStatus(namespace, name, statusUpdate)
+	return updateProjectSettingsStatus(namespace, ps.Name, statusUpdate)
 }
 
-func ensureRoleBinding(namespace, groupName, role string) error {
+func ensureRoleBinding(namespace, groupName, role string, meta apiv1alpha1.ObjectMetadata) error {
 	// Map role to ClusterRole used for ambient project access
 	roleName := mapRoleToKubernetesRole(role)
 	rbName := fmt.Sprintf("%s-%s", groupName, role)
@@ -188,6 +195,7 @@ func ensureRoleBinding(namespace, groupName, role string) error {
 		},
 	}
 
+	meta.Apply(rb)
 	_, err = config.K8sClient.RbacV1().RoleBindings(namespace).Create(context.TODO(), rb, v1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create RoleBinding: %v", err)
@@ -211,37 +219,5 @@ func mapRoleToKubernetesRole(role string) string {
 }
 
 func updateProjectSettingsStatus(namespace, name string, statusUpdate map[string]interface{}) error {
-	gvr := types.GetProjectSettingsResource()
-
-	// Get current resource
-	obj, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, v1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Printf("ProjectSettings %s/%s no longer exists, skipping status update", namespace, name)
-			return nil
-		}
-		return fmt.Errorf("failed to get ProjectSettings %s/%s: %v", namespace, name, err)
-	}
-
-	// Update status
-	if obj.Object["status"] == nil {
-		obj.Object["status"] = make(map[string]interface{})
-	}
-
-	status := obj.Object["status"].(map[string]interface{})
-	for key, value := range statusUpdate {
-		status[key] = value
-	}
-
-	// Update the resource
-	_, err = config.DynamicClient.Resource(gvr).Namespace(namespace).UpdateStatus(context.TODO(), obj, v1.UpdateOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Printf("ProjectSettings %s/%s was deleted during status update, skipping", namespace, name)
-			return nil
-		}
-		return fmt.Errorf("failed to update ProjectSettings status: %v", err)
-	}
-
-	return nil
+	return statusupdater.Patch(context.TODO(), types.GetProjectSettingsResource(), namespace, name, statusUpdate)
 }
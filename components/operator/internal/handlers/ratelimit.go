@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Sessions that share a provider credential share its org rate limits. With
+// RATE_LIMIT_SCHEDULING on, runner Jobs carry the credential's rate limit key and a new
+// session is held while another session on the key reports a rate limit, or while the key
+// already runs RATE_LIMIT_MAX_SESSIONS_PER_KEY sessions.
+
+// rateLimitGateMu serializes "count the key's sessions, then create a Job" per operator,
+// like jobGateMu does for the cluster-wide limit
+var rateLimitGateMu sync.Mutex
+
+// providerRateLimitReason is the Queued condition reason of sessions held for their key
+const providerRateLimitReason = "ProviderRateLimited"
+
+// rateLimitKey names the credential a session's runner uses. Keys are fingerprints so the
+// label never carries a secret; namespaces without a readable API key get their own key.
+func rateLimitKey(ctx context.Context, namespace string, llmProvider llmProviderSettings, vertexEnabled bool) string {
+	switch {
+	case llmProvider.Provider == llmProviderBedrock:
+		account := llmProvider.BedrockRoleARN
+		if account == "" {
+			account = namespace + "/" + llmProvider.BedrockCredentialsSecret
+		}
+		return "bedrock-" + llmProvider.BedrockRegion + "-" + fingerprint(account)
+	case vertexEnabled:
+		return "vertex-" + fingerprint(os.Getenv("ANTHROPIC_VERTEX_PROJECT_ID"))
+	}
+	secret, err := config.K8sClient.CoreV1().Secrets(namespace).Get(ctx, runnerSecretsName, v1.GetOptions{})
+	if err == nil && len(secret.Data["ANTHROPIC_API_KEY"]) > 0 {
+		return "anthropic-" + fingerprint(strings.TrimSpace(string(secret.Data["ANTHROPIC_API_KEY"])))
+	}
+	return "anthropic-" + fingerprint(namespace)
+}
+
+func fingerprint(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// reserveRateLimitSlot checks the session's key before its runner Job is created. When the
+// key has room it returns ok=true and holds the gate; the caller must call release once the
+// Job create call has returned. Otherwise msg says why the session waits.
+func reserveRateLimitSlot(ctx context.Context, key string, cfg *config.Config, now time.Time) (release func(), msg string, ok bool, err error) {
+	if !cfg.RateLimitScheduling {
+		return func() {}, "", true, nil
+	}
+	rateLimitGateMu.Lock()
+	active, holdUntil, err := rateLimitKeyUsage(ctx, key, cfg.RateLimitBackoff)
+	if err != nil {
+		rateLimitGateMu.Unlock()
+		return nil, "", false, err
+	}
+	if holdUntil.After(now) {
+		rateLimitGateMu.Unlock()
+		return nil, fmt.Sprintf("Waiting for the model provider's rate limit to reset at %s", holdUntil.UTC().Format(time.RFC3339)), false, nil
+	}
+	if cfg.RateLimitMaxSessionsPerKey > 0 && active >= cfg.RateLimitMaxSessionsPerKey {
+		rateLimitGateMu.Unlock()
+		return nil, fmt.Sprintf("Waiting for a provider slot: %d of %d sessions are running on the same provider credential", active, cfg.RateLimitMaxSessionsPerKey), false, nil
+	}
+	return rateLimitGateMu.Unlock, "", true, nil
+}
+
+// rateLimitKeyUsage counts the active runner Jobs on the key and returns the latest time
+// one of their sessions asked to be left alone until
+func rateLimitKeyUsage(ctx context.Context, key string, backoff time.Duration) (active int, holdUntil time.Time, err error) {
+	selector := fmt.Sprintf("app=ambient-code-runner,%s=%s", apiv1alpha1.RateLimitKeyLabel, key)
+	jobs, err := config.K8sClient.BatchV1().Jobs("").List(ctx, v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to list runner jobs for rate limit key %s: %w", key, err)
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if isJobFinished(job) || isJobSuspended(job) {
+			continue
+		}
+		active++
+		session, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(job.Namespace).Get(ctx, job.Labels["agentic-session"], v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return 0, time.Time{}, fmt.Errorf("failed to read session of job %s/%s: %w", job.Namespace, job.Name, err)
+		}
+		if until := sessionRateLimitHold(session, backoff); until.After(holdUntil) {
+			holdUntil = until
+		}
+	}
+	return active, holdUntil, nil
+}
+
+// sessionRateLimitHold is when the session's last rate limit report lets new sessions start
+func sessionRateLimitHold(session *unstructured.Unstructured, backoff time.Duration) time.Time {
+	raw, ok, _ := unstructured.NestedMap(session.Object, "status", "rateLimit")
+	if !ok {
+		return time.Time{}
+	}
+	var rl apiv1alpha1.RateLimitStatus
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &rl); err != nil {
+		return time.Time{}
+	}
+	return rl.HoldUntil(backoff)
+}
+
+// markSessionRateLimited records why a Pending session waits for its key. Like
+// markSessionQueued it leaves an already-held session untouched.
+func markSessionRateLimited(session *unstructured.Unstructured, msg string) error {
+	if queuedReason(session) == providerRateLimitReason {
+		return nil
+	}
+	return setQueuedCondition(session, v1.ConditionTrue, providerRateLimitReason, msg, msg)
+}
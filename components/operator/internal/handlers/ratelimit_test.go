@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestRateLimitKey verifies projects sharing an API key share a key that does not reveal it
+func TestRateLimitKey(t *testing.T) {
+	apiKey := func(namespace, key string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: runnerSecretsName, Namespace: namespace},
+			Data:       map[string][]byte{"ANTHROPIC_API_KEY": []byte(key)},
+		}
+	}
+	setupTestClient(apiKey("a", "sk-ant-shared"), apiKey("b", "sk-ant-shared"), apiKey("c", "sk-ant-other"))
+	ctx := context.Background()
+
+	a := rateLimitKey(ctx, "a", llmProviderSettings{}, false)
+	if a != rateLimitKey(ctx, "b", llmProviderSettings{}, false) {
+		t.Error("Expected projects with the same API key to share a rate limit key")
+	}
+	if a == rateLimitKey(ctx, "c", llmProviderSettings{}, false) || a == rateLimitKey(ctx, "d", llmProviderSettings{}, false) {
+		t.Error("Expected different API keys to get different rate limit keys")
+	}
+	if !strings.HasPrefix(a, "anthropic-") || strings.Contains(a, "sk-ant") {
+		t.Errorf("Unexpected key %q", a)
+	}
+	bedrock := rateLimitKey(ctx, "a", llmProviderSettings{Provider: llmProviderBedrock, BedrockRegion: "us-east-1", BedrockRoleARN: "arn:aws:iam::1:role/r"}, false)
+	if !strings.HasPrefix(bedrock, "bedrock-us-east-1-") {
+		t.Errorf("Unexpected Bedrock key %q", bedrock)
+	}
+}
+
+// TestReserveRateLimitSlot verifies a rate limited session holds its key until the provider's
+// retry time, and the per-key cap counts only the key's active runner Jobs
+func TestReserveRateLimitSlot(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	keyed := func(namespace, name, key string, finished bool) runtime.Object {
+		job := newRunnerJob(namespace, name+"-job", finished)
+		job.Labels["agentic-session"] = name
+		job.Labels[apiv1alpha1.RateLimitKeyLabel] = key
+		return job
+	}
+	setupTestClient(
+		keyed("a", "s1", "anthropic-1", false),
+		keyed("b", "s2", "anthropic-1", false),
+		keyed("b", "s3", "anthropic-1", true),
+		keyed("a", "s4", "anthropic-2", false),
+	)
+	limited := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s2", "namespace": "b"},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"rateLimit": map[string]interface{}{
+				"limited":    true,
+				"retryAfter": now.Add(30 * time.Second).Format(time.RFC3339),
+				"observedAt": now.Format(time.RFC3339),
+			},
+		},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, limited)
+	cfg := &config.Config{RateLimitScheduling: true, RateLimitBackoff: time.Minute}
+
+	if _, msg, ok, err := reserveRateLimitSlot(ctx, "anthropic-1", cfg, now); err != nil || ok || !strings.Contains(msg, "rate limit") {
+		t.Errorf("Expected the key to be held while s2 is rate limited, got ok=%v msg=%q err=%v", ok, msg, err)
+	}
+	release, _, ok, err := reserveRateLimitSlot(ctx, "anthropic-1", cfg, now.Add(time.Minute))
+	if err != nil || !ok {
+		t.Fatalf("Expected the key to be free after the retry time, got ok=%v err=%v", ok, err)
+	}
+	release()
+
+	cfg.RateLimitMaxSessionsPerKey = 2
+	if _, msg, ok, _ := reserveRateLimitSlot(ctx, "anthropic-1", cfg, now.Add(time.Minute)); ok || !strings.Contains(msg, "2 of 2") {
+		t.Errorf("Expected the key to be full with 2 active sessions, got ok=%v msg=%q", ok, msg)
+	}
+	release, _, ok, err = reserveRateLimitSlot(ctx, "anthropic-2", cfg, now)
+	if err != nil || !ok {
+		t.Fatalf("Expected another key to be unaffected, got ok=%v err=%v", ok, err)
+	}
+	release()
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// projectRunnerDNS returns the project's runnerDNS, nil when unset
+func projectRunnerDNS(ctx context.Context, namespace string) (*apiv1alpha1.RunnerDNS, error) {
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	if err := ps.Spec.RunnerDNS.Validate(); err != nil {
+		return nil, err
+	}
+	return ps.Spec.RunnerDNS, nil
+}
+
+// applyRunnerDNS adds the project's host entries that have not expired at now, and its
+// resolver settings, to the runner pod
+func applyRunnerDNS(podSpec *corev1.PodSpec, dns *apiv1alpha1.RunnerDNS, now time.Time) {
+	if dns == nil {
+		return
+	}
+	for _, a := range dns.ActiveHostAliases(now) {
+		podSpec.HostAliases = append(podSpec.HostAliases, corev1.HostAlias{IP: a.IP, Hostnames: a.Hostnames})
+	}
+	if dns.Policy == apiv1alpha1.DNSPolicyNone {
+		podSpec.DNSPolicy = corev1.DNSNone
+	}
+	if len(dns.Nameservers) == 0 && len(dns.Searches) == 0 && len(dns.Options) == 0 {
+		return
+	}
+	podSpec.DNSConfig = &corev1.PodDNSConfig{Nameservers: dns.Nameservers, Searches: dns.Searches}
+	for _, o := range dns.Options {
+		option := corev1.PodDNSConfigOption{Name: o.Name}
+		if o.Value != "" {
+			value := o.Value
+			option.Value = &value
+		}
+		podSpec.DNSConfig.Options = append(podSpec.DNSConfig.Options, option)
+	}
+}
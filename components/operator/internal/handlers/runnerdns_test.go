@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestApplyRunnerDNS verifies expired host entries are left out and the resolver settings
+// reach the pod
+func TestApplyRunnerDNS(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	podSpec := &corev1.PodSpec{}
+	applyRunnerDNS(podSpec, &apiv1alpha1.RunnerDNS{
+		HostAliases: []apiv1alpha1.RunnerHostAlias{
+			{IP: "10.0.0.5", Hostnames: []string{"git.corp.example.com"}},
+			{IP: "10.0.0.6", Hostnames: []string{"old-git.corp.example.com"}, Expires: &metav1.Time{Time: now.Add(-time.Minute)}},
+		},
+		Policy:      apiv1alpha1.DNSPolicyNone,
+		Nameservers: []string{"10.0.0.53"},
+		Options:     []apiv1alpha1.DNSOption{{Name: "ndots", Value: "2"}, {Name: "edns0"}},
+	}, now)
+
+	if len(podSpec.HostAliases) != 1 || podSpec.HostAliases[0].IP != "10.0.0.5" {
+		t.Errorf("host aliases = %+v", podSpec.HostAliases)
+	}
+	if podSpec.DNSPolicy != corev1.DNSNone {
+		t.Errorf("dnsPolicy = %q", podSpec.DNSPolicy)
+	}
+	cfg := podSpec.DNSConfig
+	if cfg == nil || len(cfg.Nameservers) != 1 || len(cfg.Options) != 2 || *cfg.Options[0].Value != "2" || cfg.Options[1].Value != nil {
+		t.Errorf("dnsConfig = %+v", cfg)
+	}
+
+	unchanged := &corev1.PodSpec{}
+	applyRunnerDNS(unchanged, &apiv1alpha1.RunnerDNS{HostAliases: []apiv1alpha1.RunnerHostAlias{{IP: "10.0.0.5", Hostnames: []string{"git"}}}}, now)
+	if unchanged.DNSConfig != nil || unchanged.DNSPolicy != "" || len(unchanged.HostAliases) != 1 {
+		t.Errorf("pod with host aliases only = %+v", unchanged)
+	}
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// runnerRBACFinalizer keeps a session until its runner ServiceAccount, Role, RoleBinding and
+	// token Secret are gone, so the runner's credentials never outlive the session
+	runnerRBACFinalizer = "ambient-code.io/runner-rbac"
+
+	// runnerTokenExpirationSeconds matches the runner Job's ActiveDeadlineSeconds; a restart
+	// mints a fresh token
+	runnerTokenExpirationSeconds = 14400
+	// runnerTokenRefreshSeconds is how much longer than a run tokens are minted for. While a
+	// Job waits for Kueue its token is replaced once less than a full run is left, so a runner
+	// admitted at any time starts with a token that outlives its deadline.
+	runnerTokenRefreshSeconds = 3600
+	// runnerTokenExpiresAnnotation on the runner token Secret records when its token expires
+	runnerTokenExpiresAnnotation = "ambient-code.io/token-expires-at"
+
+	runnerSecretsName      = "ambient-runner-secrets"          // ANTHROPIC_API_KEY only (ignored when Vertex enabled)
+	integrationSecretsName = "ambient-non-vertex-integrations" // GIT_*, JIRA_*, custom keys (optional)
+)
+
+// Per-session runner resource names. The backend matches the runner token's ServiceAccount
+// against the ambient-code.io/runner-sa annotation, so these names are part of the contract.
+func runnerServiceAccountName(session string) string {
+	return fmt.Sprintf("ambient-session-%s", session)
+}
+
+func runnerRoleName(session string) string {
+	return fmt.Sprintf("ambient-session-%s-role", session)
+}
+
+func runnerRoleBindingName(session string) string {
+	return fmt.Sprintf("ambient-session-%s-rb", session)
+}
+
+func runnerTokenSecretName(session string) string {
+	return fmt.Sprintf("ambient-runner-token-%s", session)
+}
+
+// runnerRole grants exactly what one runner needs: read and update its own AgenticSession
+// and its status, read its own secrets, and list sessions in the namespace (the backend's
+// project access check). Nothing else in the namespace is reachable with the runner token.
+func runnerRole(namespace, session string, ownerRefs []v1.OwnerReference) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: v1.ObjectMeta{
+			Name:            runnerRoleName(session),
+			Namespace:       namespace,
+			Labels:          map[string]string{"app": "ambient-runner", "agentic-session": session},
+			OwnerReferences: ownerRefs,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{"vteam.ambient-code"},
+				Resources:     []string{"agenticsessions", "agenticsessions/status"},
+				ResourceNames: []string{session},
+				Verbs:         []string{"get", "update", "patch"},
+			},
+			{
+				APIGroups: []string{"vteam.ambient-code"},
+				Resources: []string{"agenticsessions"},
+				Verbs:     []string{"list"},
+			},
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				ResourceNames: []string{runnerSecretsName, integrationSecretsName, runnerTokenSecretName(session)},
+				Verbs:         []string{"get"},
+			},
+			{
+				APIGroups: []string{"authorization.k8s.io"},
+				Resources: []string{"selfsubjectaccessreviews"},
+				Verbs:     []string{"create"},
+			},
+		},
+	}
+}
+
+// ensureRunnerIdentity creates the per-session ServiceAccount, Role and RoleBinding, mints a
+// fresh short-lived token into the runner token Secret and records both names on the
+// session. The finalizer is added first so nothing is created that cleanup would miss.
+func ensureRunnerIdentity(ctx context.Context, session *unstructured.Unstructured, meta apiv1alpha1.ObjectMetadata) (string, error) {
+	name := session.GetName()
+	namespace := session.GetNamespace()
+	if err := addSessionFinalizer(ctx, namespace, name); err != nil {
+		return "", fmt.Errorf("add finalizer: %w", err)
+	}
+
+	ownerRefs := []v1.OwnerReference{{
+		APIVersion: session.GetAPIVersion(),
+		Kind:       session.GetKind(),
+		Name:       name,
+		UID:        session.GetUID(),
+		Controller: boolPtr(true),
+	}}
+	labels := map[string]string{"app": "ambient-runner", "agentic-session": name}
+
+	saName := runnerServiceAccountName(name)
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: v1.ObjectMeta{Name: saName, Namespace: namespace, Labels: labels, OwnerReferences: ownerRefs},
+		// The token is delivered through the runner token Secret, never auto-mounted
+		AutomountServiceAccountToken: boolPtr(false),
+	}
+	meta.Apply(sa)
+	if _, err := config.K8sClient.CoreV1().ServiceAccounts(namespace).Create(ctx, sa, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("create ServiceAccount: %w", err)
+	}
+
+	role := runnerRole(namespace, name, ownerRefs)
+	meta.Apply(role)
+	if _, err := config.K8sClient.RbacV1().Roles(namespace).Create(ctx, role, v1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("create Role: %w", err)
+		}
+		// Sessions provisioned before the role was narrowed get the current rules on restart
+		if _, err := config.K8sClient.RbacV1().Roles(namespace).Update(ctx, role, v1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("update Role: %w", err)
+		}
+	}
+
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: v1.ObjectMeta{Name: runnerRoleBindingName(name), Namespace: namespace, Labels: labels, OwnerReferences: ownerRefs},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: role.Name},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: saName, Namespace: namespace}},
+	}
+	meta.Apply(rb)
+	if _, err := config.K8sClient.RbacV1().RoleBindings(namespace).Create(ctx, rb, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("create RoleBinding: %w", err)
+	}
+
+	token, expires, err := mintRunnerToken(ctx, namespace, saName)
+	if err != nil {
+		return "", err
+	}
+
+	secretName := runnerTokenSecretName(name)
+	sec := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:            secretName,
+			Namespace:       namespace,
+			Labels:          map[string]string{"app": "ambient-runner-token", "agentic-session": name},
+			Annotations:     map[string]string{runnerTokenExpiresAnnotation: expires.Format(time.RFC3339)},
+			OwnerReferences: ownerRefs,
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: map[string]string{"k8s-token": token},
+	}
+	meta.Apply(sec)
+	if _, err := config.K8sClient.CoreV1().Secrets(namespace).Create(ctx, sec, v1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("create token Secret: %w", err)
+		}
+		if _, err := config.K8sClient.CoreV1().Secrets(namespace).Update(ctx, sec, v1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("update token Secret: %w", err)
+		}
+	}
+
+	// The backend authorizes runner calls by matching the token's SA against this annotation
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				"ambient-code.io/runner-token-secret": secretName,
+				"ambient-code.io/runner-sa":           saName,
+			},
+		},
+	})
+	if _, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).Patch(ctx, name, ktypes.MergePatchType, patch, v1.PatchOptions{}); err != nil {
+		return "", fmt.Errorf("annotate AgenticSession: %w", err)
+	}
+
+	log.Printf("Provisioned runner ServiceAccount %s/%s for session %s", namespace, saName, name)
+	return secretName, nil
+}
+
+// mintRunnerToken requests a token for the runner ServiceAccount and returns it with its expiry
+func mintRunnerToken(ctx context.Context, namespace, saName string) (string, time.Time, error) {
+	tr := &authnv1.TokenRequest{Spec: authnv1.TokenRequestSpec{ExpirationSeconds: int64Ptr(runnerTokenExpirationSeconds + runnerTokenRefreshSeconds)}}
+	tok, err := config.K8sClient.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, saName, tr, v1.CreateOptions{})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("mint token: %w", err)
+	}
+	if strings.TrimSpace(tok.Status.Token) == "" {
+		return "", time.Time{}, fmt.Errorf("received empty token for ServiceAccount %s", saName)
+	}
+	return tok.Status.Token, tok.Status.ExpirationTimestamp.Time, nil
+}
+
+// refreshRunnerToken replaces the token in the runner token Secret of a session whose Job
+// has not started yet once it has less than a full run left
+func refreshRunnerToken(ctx context.Context, namespace, session string, now time.Time) error {
+	sec, err := config.K8sClient.CoreV1().Secrets(namespace).Get(ctx, runnerTokenSecretName(session), v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get token Secret: %w", err)
+	}
+	// Secrets minted before the expiry was recorded are refreshed once
+	if expires, err := time.Parse(time.RFC3339, sec.Annotations[runnerTokenExpiresAnnotation]); err == nil && expires.Sub(now) >= runnerTokenExpirationSeconds*time.Second {
+		return nil
+	}
+	token, expires, err := mintRunnerToken(ctx, namespace, runnerServiceAccountName(session))
+	if err != nil {
+		return err
+	}
+	if sec.Data == nil {
+		sec.Data = map[string][]byte{}
+	}
+	sec.Data["k8s-token"] = []byte(token)
+	if sec.Annotations == nil {
+		sec.Annotations = map[string]string{}
+	}
+	sec.Annotations[runnerTokenExpiresAnnotation] = expires.Format(time.RFC3339)
+	if _, err := config.K8sClient.CoreV1().Secrets(namespace).Update(ctx, sec, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update token Secret: %w", err)
+	}
+	log.Printf("Refreshed the runner token of waiting session %s/%s", namespace, session)
+	return nil
+}
+
+// finalizeSession revokes the runner identity of a deleted session and releases the finalizer
+func finalizeSession(ctx context.Context, session *unstructured.Unstructured) error {
+	if !hasFinalizer(session, runnerRBACFinalizer) {
+		return nil
+	}
+	if err := deleteRunnerIdentity(ctx, session.GetNamespace(), session.GetName()); err != nil {
+		return err
+	}
+	if err := removeSessionFinalizer(ctx, session.GetNamespace(), session.GetName()); err != nil {
+		return fmt.Errorf("remove finalizer: %w", err)
+	}
+	log.Printf("Removed runner identity for deleted session %s/%s", session.GetNamespace(), session.GetName())
+	return nil
+}
+
+// deleteRunnerIdentity deletes the binding first so the token stops working before the
+// ServiceAccount itself is gone
+func deleteRunnerIdentity(ctx context.Context, namespace, session string) error {
+	deletes := []struct {
+		kind string
+		fn   func() error
+	}{
+		{"RoleBinding", func() error {
+			return config.K8sClient.RbacV1().RoleBindings(namespace).Delete(ctx, runnerRoleBindingName(session), v1.DeleteOptions{})
+		}},
+		{"Role", func() error {
+			return config.K8sClient.RbacV1().Roles(namespace).Delete(ctx, runnerRoleName(session), v1.DeleteOptions{})
+		}},
+		{"Secret", func() error {
+			return config.K8sClient.CoreV1().Secrets(namespace).Delete(ctx, runnerTokenSecretName(session), v1.DeleteOptions{})
+		}},
+		{"client certificate Secret", func() error {
+			return config.K8sClient.CoreV1().Secrets(namespace).Delete(ctx, runnerTLSSecretName(session), v1.DeleteOptions{})
+		}},
+		{"session env Secret", func() error {
+			return config.K8sClient.CoreV1().Secrets(namespace).Delete(ctx, sessionEnvSecretName(session), v1.DeleteOptions{})
+		}},
+		{"ServiceAccount", func() error {
+			return config.K8sClient.CoreV1().ServiceAccounts(namespace).Delete(ctx, runnerServiceAccountName(session), v1.DeleteOptions{})
+		}},
+	}
+	for _, d := range deletes {
+		if err := d.fn(); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete runner %s for session %s/%s: %w", d.kind, namespace, session, err)
+		}
+	}
+	return nil
+}
+
+func hasFinalizer(obj *unstructured.Unstructured, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func addSessionFinalizer(ctx context.Context, namespace, name string) error {
+	return updateSessionFinalizers(ctx, namespace, name, func(finalizers []string) []string {
+		for _, f := range finalizers {
+			if f == runnerRBACFinalizer {
+				return nil
+			}
+		}
+		return append(finalizers, runnerRBACFinalizer)
+	})
+}
+
+func removeSessionFinalizer(ctx context.Context, namespace, name string) error {
+	return updateSessionFinalizers(ctx, namespace, name, func(finalizers []string) []string {
+		kept := []string{}
+		for _, f := range finalizers {
+			if f != runnerRBACFinalizer {
+				kept = append(kept, f)
+			}
+		}
+		if len(kept) == len(finalizers) {
+			return nil
+		}
+		return kept
+	})
+}
+
+// updateSessionFinalizers applies mutate to the session's finalizers with a conflict-retried
+// update. mutate returns nil when nothing needs to change.
+func updateSessionFinalizers(ctx context.Context, namespace, name string, mutate func([]string) []string) error {
+	gvr := types.GetAgenticSessionResource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		finalizers := mutate(obj.GetFinalizers())
+		if finalizers == nil {
+			return nil
+		}
+		obj.SetFinalizers(finalizers)
+		_, err = config.DynamicClient.Resource(gvr).Namespace(namespace).Update(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+}
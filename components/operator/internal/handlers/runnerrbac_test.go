@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunnerRole_ScopedToSession(t *testing.T) {
+	role := runnerRole("proj", "sess-1", nil)
+
+	for _, rule := range role.Rules {
+		for _, res := range rule.Resources {
+			switch res {
+			case "agenticsessions", "agenticsessions/status":
+				for _, verb := range rule.Verbs {
+					if verb != "list" && (len(rule.ResourceNames) != 1 || rule.ResourceNames[0] != "sess-1") {
+						t.Errorf("verb %q on %s is not restricted to the session: %v", verb, res, rule.ResourceNames)
+					}
+				}
+			case "secrets":
+				if len(rule.ResourceNames) == 0 {
+					t.Errorf("secrets access is not restricted by name")
+				}
+				for _, verb := range rule.Verbs {
+					if verb != "get" {
+						t.Errorf("unexpected secrets verb %q", verb)
+					}
+				}
+			case "selfsubjectaccessreviews":
+			default:
+				t.Errorf("unexpected resource %q in runner role", res)
+			}
+		}
+	}
+}
+
+func TestDeleteRunnerIdentity(t *testing.T) {
+	meta := func(name string) metav1.ObjectMeta { return metav1.ObjectMeta{Name: name, Namespace: "proj"} }
+	setupTestClient(
+		&corev1.ServiceAccount{ObjectMeta: meta(runnerServiceAccountName("sess-1"))},
+		&rbacv1.Role{ObjectMeta: meta(runnerRoleName("sess-1"))},
+		&rbacv1.RoleBinding{ObjectMeta: meta(runnerRoleBindingName("sess-1"))},
+		&corev1.Secret{ObjectMeta: meta(runnerTokenSecretName("sess-1"))},
+		&corev1.ServiceAccount{ObjectMeta: meta(runnerServiceAccountName("sess-2"))},
+	)
+	ctx := context.Background()
+
+	if err := deleteRunnerIdentity(ctx, "proj", "sess-1"); err != nil {
+		t.Fatalf("deleteRunnerIdentity: %v", err)
+	}
+	if _, err := config.K8sClient.CoreV1().ServiceAccounts("proj").Get(ctx, runnerServiceAccountName("sess-1"), metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("ServiceAccount still present: %v", err)
+	}
+	if _, err := config.K8sClient.RbacV1().RoleBindings("proj").Get(ctx, runnerRoleBindingName("sess-1"), metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("RoleBinding still present: %v", err)
+	}
+	if _, err := config.K8sClient.CoreV1().ServiceAccounts("proj").Get(ctx, runnerServiceAccountName("sess-2"), metav1.GetOptions{}); err != nil {
+		t.Errorf("other session's ServiceAccount was removed: %v", err)
+	}
+
+	// Already cleaned up: a second pass is a no-op
+	if err := deleteRunnerIdentity(ctx, "proj", "sess-1"); err != nil {
+		t.Fatalf("second deleteRunnerIdentity: %v", err)
+	}
+}
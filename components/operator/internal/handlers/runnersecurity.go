@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/podsecurity"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// projectRunnerSecurity returns the project's runnerSecurity, nil when unset
+func projectRunnerSecurity(ctx context.Context, namespace string) (*apiv1alpha1.RunnerSecurity, error) {
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	if err := ps.Spec.RunnerSecurity.Validate(); err != nil {
+		return nil, err
+	}
+	return ps.Spec.RunnerSecurity, nil
+}
+
+// applyRunnerSecurity hardens the runner pod with the project's runnerSecurity and checks it
+// against the level the namespace enforces. Admission would reject the pods of a violating Job
+// and leave the session starting forever, so a hardened project fails it instead. Projects
+// without runnerSecurity only get a warning: their pods were admitted before the check existed
+// on clusters, like OpenShift, that adjust security contexts at admission.
+func applyRunnerSecurity(ctx context.Context, podSpec *corev1.PodSpec, namespace string, security *apiv1alpha1.RunnerSecurity) error {
+	podsecurity.Apply(podSpec, security)
+
+	ns, err := config.K8sClient.CoreV1().Namespaces().Get(ctx, namespace, v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to read namespace: %w", err)
+	}
+	level, ok := podsecurity.Levels(ns)[podsecurity.ModeEnforce]
+	if !ok {
+		return nil
+	}
+	violations := podsecurity.Check(level, podSpec)
+	if len(violations) == 0 {
+		return nil
+	}
+	if security == nil {
+		log.Printf("Runner pod in %s may be rejected by Pod Security level %s: %s", namespace, level, strings.Join(violations, "; "))
+		return nil
+	}
+	return fmt.Errorf("runner pod violates the namespace's %s Pod Security level: %s", level, strings.Join(violations, "; "))
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/podsecurity"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestApplyRunnerSecurity verifies a hardened project fails on a violating pod while projects
+// without runnerSecurity are only warned
+func TestApplyRunnerSecurity(t *testing.T) {
+	ctx := context.Background()
+	config.K8sClient = fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "restricted", Labels: map[string]string{"pod-security.kubernetes.io/enforce": "restricted"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "open"}},
+	)
+
+	if err := applyRunnerSecurity(ctx, podsecurity.RunnerPod(nil), "restricted", nil); err != nil {
+		t.Errorf("project without runnerSecurity: %v", err)
+	}
+
+	partial := &apiv1alpha1.RunnerSecurity{ReadOnlyRootFilesystem: true}
+	err := applyRunnerSecurity(ctx, podsecurity.RunnerPod(nil), "restricted", partial)
+	if err == nil || !strings.Contains(err.Error(), "runAsNonRoot must be true") {
+		t.Errorf("partially hardened pod: err = %v, want a runAsNonRoot violation", err)
+	}
+	if err := applyRunnerSecurity(ctx, podsecurity.RunnerPod(nil), "open", partial); err != nil {
+		t.Errorf("namespace without an enforce level: %v", err)
+	}
+
+	hardened := &apiv1alpha1.RunnerSecurity{RunAsNonRoot: true, SeccompProfile: apiv1alpha1.SeccompRuntimeDefault, DropCapabilities: []string{"ALL"}}
+	podSpec := podsecurity.RunnerPod(nil)
+	if err := applyRunnerSecurity(ctx, podSpec, "restricted", hardened); err != nil {
+		t.Errorf("hardened pod: %v", err)
+	}
+	if podSpec.SecurityContext == nil || podSpec.SecurityContext.RunAsNonRoot == nil || !*podSpec.SecurityContext.RunAsNonRoot {
+		t.Error("runnerSecurity not applied to the pod")
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/runnertls"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RunnerCA issues runner client certificates when RUNNER_MTLS is enabled; nil disables mutual
+// TLS for runner callbacks. Set by main.
+var RunnerCA *runnertls.CA
+
+const (
+	runnerTLSVolumeName = "runner-tls"
+	runnerTLSMountPath  = "/var/run/ambient/runner-tls"
+	// internalTLSPort is the backend's mutual TLS listener for /internal endpoints
+	internalTLSPort = 8443
+	// backendCertCheckInterval is how often the backend server certificate is checked for renewal
+	backendCertCheckInterval = 12 * time.Hour
+)
+
+func runnerTLSSecretName(session string) string {
+	return fmt.Sprintf("ambient-runner-tls-%s", session)
+}
+
+// internalAPIURL is the base URL of the backend's mutual TLS listener
+func internalAPIURL(backendNamespace string) string {
+	return fmt.Sprintf("https://backend-service.%s.svc.cluster.local:%d", backendNamespace, internalTLSPort)
+}
+
+// KeepBackendCertCurrent issues the backend's internal listener certificate and renews it
+// before it expires. The backend reloads it from the mounted Secret.
+func KeepBackendCertCurrent() {
+	backendNamespace := config.LoadConfig().BackendNamespace
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := RunnerCA.EnsureBackendCert(ctx, config.K8sClient, backendNamespace); err != nil {
+			log.Printf("Failed to ensure backend internal certificate: %v", err)
+		}
+		cancel()
+		time.Sleep(backendCertCheckInterval)
+	}
+}
+
+// ensureRunnerClientCert issues a fresh client certificate for the session's runner into a
+// Secret owned by the session, replacing the one from any previous run
+func ensureRunnerClientCert(ctx context.Context, session *unstructured.Unstructured, meta apiv1alpha1.ObjectMetadata) (string, error) {
+	name := session.GetName()
+	namespace := session.GetNamespace()
+	certPEM, keyPEM, err := RunnerCA.IssueClientCert(namespace, name)
+	if err != nil {
+		return "", fmt.Errorf("issue client certificate: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      runnerTLSSecretName(name),
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "ambient-runner", "agentic-session": name},
+			OwnerReferences: []v1.OwnerReference{{
+				APIVersion: session.GetAPIVersion(),
+				Kind:       session.GetKind(),
+				Name:       name,
+				UID:        session.GetUID(),
+				Controller: boolPtr(true),
+			}},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+			runnertls.CAKey:         RunnerCA.CertPEM,
+		},
+	}
+	meta.Apply(secret)
+	secrets := config.K8sClient.CoreV1().Secrets(namespace)
+	if _, err := secrets.Create(ctx, secret, v1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("create client certificate Secret: %w", err)
+		}
+		if _, err := secrets.Update(ctx, secret, v1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("update client certificate Secret: %w", err)
+		}
+	}
+	return secret.Name, nil
+}
+
+// applyRunnerTLS mounts the runner's client certificate and points its internal callbacks at
+// the backend's mutual TLS listener
+func applyRunnerTLS(podSpec *corev1.PodSpec, containerName, secretName, backendNamespace, session string) {
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         runnerTLSVolumeName,
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName}},
+	})
+	base := internalAPIURL(backendNamespace)
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != containerName {
+			continue
+		}
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      runnerTLSVolumeName,
+			MountPath: runnerTLSMountPath,
+			ReadOnly:  true,
+		})
+		for j := range c.Env {
+			if c.Env[j].Name == "RUNNER_CONFIG_URL" {
+				c.Env[j].Value = fmt.Sprintf("%s/internal/runner-config/%s", base, session)
+			}
+		}
+		c.Env = append(c.Env,
+			corev1.EnvVar{Name: "RUNNER_TLS_DIR", Value: runnerTLSMountPath},
+			corev1.EnvVar{Name: "INTERNAL_API_URL", Value: base},
+		)
+		break
+	}
+}
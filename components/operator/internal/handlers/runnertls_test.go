@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestApplyRunnerTLS_RunnerOnly verifies the client certificate is mounted into the runner only
+// and its internal callbacks move to the mutual TLS listener
+func TestApplyRunnerTLS_RunnerOnly(t *testing.T) {
+	podSpec := corev1.PodSpec{Containers: []corev1.Container{
+		{Name: "ambient-content"},
+		{Name: "ambient-code-runner", Env: []corev1.EnvVar{
+			{Name: "RUNNER_CONFIG_URL", Value: "http://backend-service.ambient-code.svc.cluster.local:8080/internal/runner-config/sess-1"},
+		}},
+	}}
+
+	applyRunnerTLS(&podSpec, "ambient-code-runner", runnerTLSSecretName("sess-1"), "ambient-code", "sess-1")
+
+	if len(podSpec.Containers[0].VolumeMounts) != 0 || len(podSpec.Containers[0].Env) != 0 {
+		t.Errorf("content container should not receive the runner certificate")
+	}
+	runner := podSpec.Containers[1]
+	if len(runner.VolumeMounts) != 1 || runner.VolumeMounts[0].MountPath != runnerTLSMountPath {
+		t.Fatalf("expected certificate mount at %s, got %v", runnerTLSMountPath, runner.VolumeMounts)
+	}
+	env := map[string]string{}
+	for _, e := range runner.Env {
+		env[e.Name] = e.Value
+	}
+	expected := map[string]string{
+		"RUNNER_CONFIG_URL": "https://backend-service.ambient-code.svc.cluster.local:8443/internal/runner-config/sess-1",
+		"INTERNAL_API_URL":  "https://backend-service.ambient-code.svc.cluster.local:8443",
+		"RUNNER_TLS_DIR":    runnerTLSMountPath,
+	}
+	for k, v := range expected {
+		if env[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, env[k])
+		}
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].Secret == nil || podSpec.Volumes[0].Secret.SecretName != "ambient-runner-tls-sess-1" {
+		t.Errorf("unexpected volumes: %v", podSpec.Volumes)
+	}
+}
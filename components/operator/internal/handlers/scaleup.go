@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"ambient-code-operator/internal/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// waitingForNodesConditionType is True while the runner pod waits for a node to fit on
+	waitingForNodesConditionType = "WaitingForNodes"
+	// scaleUpDelayAnnotation lets the cluster autoscaler act on a pending pod without its
+	// --new-pod-scale-up-delay
+	scaleUpDelayAnnotation = "cluster-autoscaler.kubernetes.io/pod-scale-up-delay"
+	// balloonName names the placeholder Deployment and its PriorityClass
+	balloonName = "ambient-runner-balloon"
+	// balloonPriority is below every runner pod, so a runner preempts a balloon at once, and
+	// above the autoscaler's default expendable cutoff (-10), so balloons still trigger scale-up
+	balloonPriority = -5
+	// balloonInterval is how often the balloon Deployment is sized to the waiting runners
+	balloonInterval = 30 * time.Second
+	// balloonCooldown keeps the balloons this long after the last runner stopped waiting, so
+	// the next sessions of a burst start on the nodes they hold
+	balloonCooldown = 10 * time.Minute
+)
+
+// runnerUnschedulable returns the scheduler's message when the pod is pending for lack of a node
+func runnerUnschedulable(pod *corev1.Pod) (string, bool) {
+	if pod.Spec.NodeName != "" || pod.Status.Phase != corev1.PodPending {
+		return "", false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
+			return c.Message, true
+		}
+	}
+	return "", false
+}
+
+// waitingForNodesCondition mirrors the scheduler into the WaitingForNodes condition. With
+// scale-up signalling on, an unschedulable runner pod is annotated so the cluster autoscaler
+// acts on it immediately, and the condition says a scale-up was requested. It returns nil
+// until the pod has been looked at by the scheduler.
+func waitingForNodesCondition(ctx context.Context, pod *corev1.Pod, cfg *config.Config) (cond map[string]interface{}, message string) {
+	schedulerMessage, waiting := runnerUnschedulable(pod)
+	if !waiting {
+		if pod.Spec.NodeName == "" {
+			return nil, ""
+		}
+		return sessionCondition(waitingForNodesConditionType, v1.ConditionFalse, "Scheduled", "Runner pod has a node"), ""
+	}
+	if !cfg.ScaleUpAnnotate && cfg.ScaleUpBalloons == 0 {
+		return sessionCondition(waitingForNodesConditionType, v1.ConditionTrue, corev1.PodReasonUnschedulable, schedulerMessage), ""
+	}
+	if cfg.ScaleUpAnnotate && pod.Annotations[scaleUpDelayAnnotation] != "0s" {
+		patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:"0s"}}}`, scaleUpDelayAnnotation))
+		if _, err := config.K8sClient.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, ktypes.MergePatchType, patch, v1.PatchOptions{}); err != nil {
+			log.Printf("Failed to annotate runner pod %s/%s for scale-up: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	return sessionCondition(waitingForNodesConditionType, v1.ConditionTrue, "ScaleUpRequested", schedulerMessage),
+		fmt.Sprintf("Waiting for nodes, cluster scale-up requested: %s", schedulerMessage)
+}
+
+// balloonState remembers when a runner last waited for nodes, for the cooldown
+var balloonState struct {
+	sync.Mutex
+	lastWaiting time.Time
+}
+
+// MaintainScaleUpBalloons sizes the balloon Deployment to the runner pods waiting for nodes
+// (SCALE_UP_BALLOONS is the most replicas). Balloons are pause pods shaped like the newest
+// waiting runner: the cluster autoscaler adds nodes for them too, and the next sessions of
+// a burst preempt them instead of waiting for another scale-up.
+func MaintainScaleUpBalloons() {
+	for {
+		if err := reconcileScaleUpBalloons(context.TODO(), config.LoadConfig(), time.Now()); err != nil {
+			log.Printf("Failed to reconcile scale-up balloons: %v", err)
+		}
+		time.Sleep(balloonInterval)
+	}
+}
+
+func reconcileScaleUpBalloons(ctx context.Context, cfg *config.Config, now time.Time) error {
+	deployments := config.K8sClient.AppsV1().Deployments(cfg.Namespace)
+	remove := func() error {
+		if err := deployments.Delete(ctx, balloonName, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete balloon Deployment: %w", err)
+		}
+		return nil
+	}
+	if cfg.ScaleUpBalloons <= 0 {
+		return remove()
+	}
+
+	pods, err := config.K8sClient.CoreV1().Pods("").List(ctx, v1.ListOptions{LabelSelector: "app=ambient-code-runner", FieldSelector: "status.phase=Pending"})
+	if err != nil {
+		return fmt.Errorf("list pending runner pods: %w", err)
+	}
+	var waiting []*corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if _, ok := runnerUnschedulable(pod); ok && WatchScope.Allows(ctx, pod.Namespace) {
+			waiting = append(waiting, pod)
+		}
+	}
+
+	balloonState.Lock()
+	if len(waiting) > 0 {
+		balloonState.lastWaiting = now
+	}
+	cooling := !balloonState.lastWaiting.IsZero() && now.Sub(balloonState.lastWaiting) < balloonCooldown
+	balloonState.Unlock()
+	if len(waiting) == 0 {
+		if cooling {
+			// Hold the current balloons; their nodes serve the rest of the burst
+			return nil
+		}
+		return remove()
+	}
+
+	if err := ensureBalloonPriorityClass(ctx); err != nil {
+		return err
+	}
+	sort.Slice(waiting, func(i, j int) bool {
+		return waiting[j].CreationTimestamp.Before(&waiting[i].CreationTimestamp)
+	})
+	replicas := len(waiting)
+	if replicas > cfg.ScaleUpBalloons {
+		replicas = cfg.ScaleUpBalloons
+	}
+	desired := balloonDeployment(cfg.Namespace, waiting[0], int32(replicas))
+
+	existing, err := deployments.Get(ctx, balloonName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := deployments.Create(ctx, desired, v1.CreateOptions{}); err != nil {
+			return fmt.Errorf("create balloon Deployment: %w", err)
+		}
+		log.Printf("Created %d scale-up balloon pods for %d runner pods waiting for nodes", replicas, len(waiting))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get balloon Deployment: %w", err)
+	}
+	existing.Spec.Replicas = desired.Spec.Replicas
+	existing.Spec.Template = desired.Spec.Template
+	if _, err := deployments.Update(ctx, existing, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update balloon Deployment: %w", err)
+	}
+	return nil
+}
+
+// ensureBalloonPriorityClass creates the negative PriorityClass balloons run at; balloons
+// never preempt anything themselves
+func ensureBalloonPriorityClass(ctx context.Context) error {
+	never := corev1.PreemptNever
+	pc := &schedulingv1.PriorityClass{
+		ObjectMeta:       v1.ObjectMeta{Name: balloonName},
+		Value:            balloonPriority,
+		PreemptionPolicy: &never,
+		Description:      "Placeholder pods holding capacity for ambient-code runner pods",
+	}
+	_, err := config.K8sClient.SchedulingV1().PriorityClasses().Create(ctx, pc, v1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("create balloon PriorityClass: %w", err)
+	}
+	return nil
+}
+
+// balloonDeployment runs pause pods with the runner's node placement and the sum of its
+// container requests, so each balloon needs the same kind of node the runner does
+func balloonDeployment(namespace string, runner *corev1.Pod, replicas int32) *appsv1.Deployment {
+	requests := corev1.ResourceList{}
+	for _, c := range runner.Spec.Containers {
+		for name, q := range c.Resources.Requests {
+			total := requests[name]
+			total.Add(q)
+			requests[name] = total
+		}
+	}
+	// GPUs are requested as limits only
+	for _, c := range runner.Spec.Containers {
+		for name, q := range c.Resources.Limits {
+			if _, ok := requests[name]; !ok && name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+				requests[name] = q.DeepCopy()
+			}
+		}
+	}
+	if len(requests) == 0 {
+		requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("2Gi")}
+	}
+	var affinity *corev1.Affinity
+	if runner.Spec.Affinity != nil && runner.Spec.Affinity.NodeAffinity != nil {
+		// Pod (anti-)affinity refers to the session's pods and would keep balloons apart
+		affinity = &corev1.Affinity{NodeAffinity: runner.Spec.Affinity.NodeAffinity}
+	}
+	podLabels := map[string]string{"app": balloonName}
+	return &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: balloonName, Namespace: namespace, Labels: podLabels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &v1.LabelSelector{MatchLabels: podLabels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					AutomountServiceAccountToken:  boolPtr(false),
+					PriorityClassName:             balloonName,
+					TerminationGracePeriodSeconds: int64Ptr(0),
+					NodeSelector:                  runner.Spec.NodeSelector,
+					Affinity:                      affinity,
+					Tolerations:                   runner.Spec.Tolerations,
+					RuntimeClassName:              runner.Spec.RuntimeClassName,
+					Containers: []corev1.Container{{
+						Name:      "pause",
+						Image:     imagePrepullPauseImage,
+						Resources: corev1.ResourceRequirements{Requests: requests, Limits: extendedOnly(requests)},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// extendedOnly keeps the extended resources (GPUs), which must be set as limits too
+func extendedOnly(list corev1.ResourceList) corev1.ResourceList {
+	var out corev1.ResourceList
+	for name, q := range list {
+		if name == corev1.ResourceCPU || name == corev1.ResourceMemory || name == corev1.ResourceEphemeralStorage {
+			continue
+		}
+		if out == nil {
+			out = corev1.ResourceList{}
+		}
+		out[name] = q
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func unschedulableRunner(name string, created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name: name, Namespace: "team-a", CreationTimestamp: v1.NewTime(created),
+			Labels: map[string]string{"app": "ambient-code-runner", "agentic-session": name},
+		},
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{"pool": "gpu"},
+			Containers: []corev1.Container{
+				{Name: "ambient-code-runner", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+					Limits:   corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				}},
+				{Name: "content", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+				}},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient nvidia.com/gpu.",
+			}},
+		},
+	}
+}
+
+// TestWaitingForNodesCondition verifies an unschedulable runner is annotated for the
+// autoscaler and reported as waiting for nodes, and a placed runner clears the condition
+func TestWaitingForNodesCondition(t *testing.T) {
+	setupTestClient()
+	ctx := context.Background()
+	pod := unschedulableRunner("s1", time.Now())
+	if _, err := config.K8sClient.CoreV1().Pods("team-a").Create(ctx, pod, v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cond, message := waitingForNodesCondition(ctx, pod, &config.Config{})
+	if cond["status"] != string(v1.ConditionTrue) || cond["reason"] != corev1.PodReasonUnschedulable || message != "" {
+		t.Errorf("without signalling: %v, %q", cond, message)
+	}
+
+	cond, message = waitingForNodesCondition(ctx, pod, &config.Config{ScaleUpAnnotate: true})
+	if cond["reason"] != "ScaleUpRequested" || message == "" {
+		t.Errorf("with signalling: %v, %q", cond, message)
+	}
+	got, _ := config.K8sClient.CoreV1().Pods("team-a").Get(ctx, "s1", v1.GetOptions{})
+	if got.Annotations[scaleUpDelayAnnotation] != "0s" {
+		t.Errorf("annotations = %v", got.Annotations)
+	}
+
+	pod.Spec.NodeName = "node-1"
+	pod.Status.Phase = corev1.PodRunning
+	if cond, _ := waitingForNodesCondition(ctx, pod, &config.Config{ScaleUpAnnotate: true}); cond["status"] != string(v1.ConditionFalse) {
+		t.Errorf("scheduled pod: %v", cond)
+	}
+}
+
+// TestReconcileScaleUpBalloons verifies balloons follow the newest waiting runner's shape,
+// are capped, outlive the waiting runners for the cooldown and are then removed
+func TestReconcileScaleUpBalloons(t *testing.T) {
+	setupTestClient()
+	balloonState.lastWaiting = time.Time{}
+	ctx := context.Background()
+	now := time.Now()
+	cfg := &config.Config{Namespace: "ambient-code", ScaleUpBalloons: 2}
+	for i, name := range []string{"s1", "s2", "s3"} {
+		if _, err := config.K8sClient.CoreV1().Pods("team-a").Create(ctx, unschedulableRunner(name, now.Add(time.Duration(i)*time.Second)), v1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := reconcileScaleUpBalloons(ctx, cfg, now); err != nil {
+		t.Fatal(err)
+	}
+	deployments := config.K8sClient.AppsV1().Deployments("ambient-code")
+	d, err := deployments.Get(ctx, balloonName, v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("balloon Deployment not created: %v", err)
+	}
+	if *d.Spec.Replicas != 2 {
+		t.Errorf("replicas = %d, want the cap of 2", *d.Spec.Replicas)
+	}
+	spec := d.Spec.Template.Spec
+	requests := spec.Containers[0].Resources.Requests
+	if cpu := requests[corev1.ResourceCPU]; cpu.String() != "1500m" || spec.NodeSelector["pool"] != "gpu" || spec.PriorityClassName != balloonName {
+		t.Errorf("balloon pod = %+v", spec)
+	}
+	if gpu := spec.Containers[0].Resources.Limits["nvidia.com/gpu"]; gpu.String() != "1" {
+		t.Errorf("GPU limit = %v", spec.Containers[0].Resources.Limits)
+	}
+	if _, err := config.K8sClient.SchedulingV1().PriorityClasses().Get(ctx, balloonName, v1.GetOptions{}); err != nil {
+		t.Errorf("PriorityClass not created: %v", err)
+	}
+
+	for _, name := range []string{"s1", "s2", "s3"} {
+		_ = config.K8sClient.CoreV1().Pods("team-a").Delete(ctx, name, v1.DeleteOptions{})
+	}
+	if err := reconcileScaleUpBalloons(ctx, cfg, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := deployments.Get(ctx, balloonName, v1.GetOptions{}); err != nil {
+		t.Errorf("balloons removed during the cooldown: %v", err)
+	}
+	if err := reconcileScaleUpBalloons(ctx, cfg, now.Add(balloonCooldown+time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := deployments.Get(ctx, balloonName, v1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("balloons kept after the cooldown: %v", err)
+	}
+}
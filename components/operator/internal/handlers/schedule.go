@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// outsideScheduleReason is the Queued condition reason of sessions waiting for a window
+// of the project's sessionSchedule
+const outsideScheduleReason = "OutsideScheduleWindow"
+
+// projectSessionSchedule returns ProjectSettings spec.sessionSchedule, nil when unset
+func projectSessionSchedule(ctx context.Context, namespace string) (*apiv1alpha1.SessionSchedule, error) {
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ProjectSettings: %w", err)
+	}
+	return ps.Spec.SessionSchedule, nil
+}
+
+// sessionScheduleHold returns why the session must wait for the project's schedule, or ""
+// when it may start now. An invalid schedule holds nothing; the backend rejects it on save.
+func sessionScheduleHold(session *unstructured.Unstructured, schedule *apiv1alpha1.SessionSchedule, now time.Time) string {
+	interactive, _, _ := unstructured.NestedBool(session.Object, "spec", "interactive")
+	if !schedule.Applies(interactive) || schedule.Validate() != nil {
+		return ""
+	}
+	open, next := schedule.Open(now)
+	if open {
+		return ""
+	}
+	zone := schedule.TimeZone
+	if zone == "" {
+		zone = "UTC"
+	}
+	loc, _ := time.LoadLocation(zone)
+	return fmt.Sprintf("Waiting for the project's session schedule: the next window opens %s (%s)", next.In(loc).Format("Mon 2006-01-02 15:04"), zone)
+}
+
+// markSessionOutsideSchedule holds a Pending session until a window opens; like the other
+// holds, RequeueQueuedSessions retries it
+func markSessionOutsideSchedule(session *unstructured.Unstructured, msg string) error {
+	if queuedReason(session) == outsideScheduleReason {
+		return nil
+	}
+	return setQueuedCondition(session, v1.ConditionTrue, outsideScheduleReason, msg, msg)
+}
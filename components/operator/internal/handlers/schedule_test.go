@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestSessionScheduleHold verifies a night-only project holds batch sessions during the
+// day, says when the window opens, and lets interactive sessions through
+func TestSessionScheduleHold(t *testing.T) {
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	ctx := context.Background()
+	if _, err := config.VteamClient.VteamV1alpha1().ProjectSettings("nightly").Create(ctx, &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "nightly"},
+		Spec: apiv1alpha1.ProjectSettingsSpec{SessionSchedule: &apiv1alpha1.SessionSchedule{
+			TimeZone: "America/New_York",
+			Windows:  []apiv1alpha1.ScheduleWindow{{Start: "22:00", End: "06:00"}},
+		}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	schedule, err := projectSessionSchedule(ctx, "nightly")
+	if err != nil || schedule == nil {
+		t.Fatalf("projectSessionSchedule() = %v, %v", schedule, err)
+	}
+	if other, err := projectSessionSchedule(ctx, "other"); err != nil || other != nil {
+		t.Errorf("project without settings: %v, %v", other, err)
+	}
+
+	session := func(interactive bool) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "s1", "namespace": "nightly"},
+			"spec":     map[string]interface{}{"interactive": interactive},
+		}}
+	}
+	noon := time.Date(2026, 3, 2, 17, 0, 0, 0, time.UTC) // 12:00 in New York
+	msg := sessionScheduleHold(session(false), schedule, noon)
+	if !strings.Contains(msg, "opens Mon 2026-03-02 22:00 (America/New_York)") {
+		t.Errorf("hold at noon = %q", msg)
+	}
+	if msg := sessionScheduleHold(session(false), schedule, noon.Add(11*time.Hour)); msg != "" {
+		t.Errorf("held inside the window: %q", msg)
+	}
+	if msg := sessionScheduleHold(session(true), schedule, noon); msg != "" {
+		t.Errorf("interactive session held by a Batch schedule: %q", msg)
+	}
+}
+
+// TestMarkSessionOutsideSchedule verifies the hold is a Queued condition RequeueQueuedSessions retries
+func TestMarkSessionOutsideSchedule(t *testing.T) {
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": "nightly"},
+		"status":     map[string]interface{}{"phase": "Pending"},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, session)
+	if err := markSessionOutsideSchedule(session, "Waiting for the project's session schedule"); err != nil {
+		t.Fatal(err)
+	}
+	obj, _ := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("nightly").Get(context.Background(), "s1", metav1.GetOptions{})
+	if reason := queuedReason(obj); reason != outsideScheduleReason {
+		t.Errorf("queued reason = %q, want %s", reason, outsideScheduleReason)
+	}
+}
@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/diagnostics"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// SecretDistribution copies a platform secret (registry credentials, CA bundles, ...) into
+// every selected project namespace and keeps the copies in sync. Each resync rewrites copies
+// whose data no longer matches the source (source rotated, or a copy edited by hand),
+// recreates deleted copies and removes copies from namespaces that left the selection.
+// Copies are owned by the SecretDistribution, so deleting it deletes them.
+const (
+	// secretDistributionLabel names the distribution that manages a copy
+	secretDistributionLabel = "ambient-code.io/secret-distribution"
+	// sourceHashAnnotation records the hash of the data a copy was written with
+	sourceHashAnnotation = "ambient-code.io/source-hash"
+	// secretDistributionResync is how often every distribution is reconciled, catching source
+	// rotation, drifted copies and new namespaces
+	secretDistributionResync = 2 * time.Minute
+)
+
+type secretDistributionSpec struct {
+	Source struct {
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+	} `json:"source"`
+	NamespaceSelector *v1.LabelSelector `json:"namespaceSelector,omitempty"`
+	Namespaces        []string          `json:"namespaces,omitempty"`
+	Transform         struct {
+		Name        string            `json:"name,omitempty"`
+		Type        string            `json:"type,omitempty"`
+		IncludeKeys []string          `json:"includeKeys,omitempty"`
+		RenameKeys  map[string]string `json:"renameKeys,omitempty"`
+		Labels      map[string]string `json:"labels,omitempty"`
+		Annotations map[string]string `json:"annotations,omitempty"`
+	} `json:"transform"`
+}
+
+// WatchSecretDistributions reconciles SecretDistributions on change and on a periodic resync
+func WatchSecretDistributions() {
+	gvr := types.GetSecretDistributionResource()
+	go func() {
+		for range time.Tick(secretDistributionResync) {
+			resyncSecretDistributions(context.TODO())
+		}
+	}()
+	for {
+		watcher, err := config.DynamicClient.Resource(gvr).Watch(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			log.Printf("Failed to create SecretDistribution watcher (is the CRD installed?): %v", err)
+			time.Sleep(30 * time.Second)
+			continue
+		}
+		log.Println("Watching for SecretDistribution events...")
+		for event := range watcher.ResultChan() {
+			diagnostics.Default.WatchEvent("SecretDistribution")
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added:
+				reconcileSecretDistribution(context.TODO(), obj)
+			case watch.Modified:
+				// Status writes also arrive as modifications; only spec changes need work now
+				observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+				if observed != obj.GetGeneration() {
+					reconcileSecretDistribution(context.TODO(), obj)
+				}
+			case watch.Error:
+				log.Printf("Watch error for SecretDistributions: %v", obj)
+			}
+		}
+		log.Println("SecretDistribution watch channel closed, restarting...")
+		diagnostics.Default.WatchRestarted("SecretDistribution")
+		watcher.Stop()
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func resyncSecretDistributions(ctx context.Context) {
+	list, err := config.DynamicClient.Resource(types.GetSecretDistributionResource()).List(ctx, v1.ListOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to list SecretDistributions: %v", err)
+		}
+		return
+	}
+	for i := range list.Items {
+		reconcileSecretDistribution(ctx, &list.Items[i])
+	}
+}
+
+// reconcileSecretDistribution brings the copies of one distribution in line with its source
+// and records the outcome in its status
+func reconcileSecretDistribution(ctx context.Context, dist *unstructured.Unstructured) {
+	// Failures are reported in the distribution's status.message
+	done := diagnostics.Default.BeginReconcile("SecretDistribution", dist.GetNamespace(), dist.GetName())
+	status := map[string]interface{}{"observedGeneration": dist.GetGeneration()}
+	defer func() {
+		updateSecretDistributionStatus(ctx, dist, status)
+		done(nil)
+	}()
+
+	var spec secretDistributionSpec
+	raw, _ := json.Marshal(dist.Object["spec"])
+	if err := json.Unmarshal(raw, &spec); err != nil || spec.Source.Name == "" || spec.Source.Namespace == "" {
+		status["message"] = "spec.source.namespace and spec.source.name are required"
+		return
+	}
+	source, err := config.K8sClient.CoreV1().Secrets(spec.Source.Namespace).Get(ctx, spec.Source.Name, v1.GetOptions{})
+	if err != nil {
+		// Existing copies are kept until the source is back
+		status["message"] = fmt.Sprintf("source secret %s/%s: %v", spec.Source.Namespace, spec.Source.Name, err)
+		return
+	}
+	desired := desiredDistributedSecret(dist, &spec, source)
+	hash := desired.Annotations[sourceHashAnnotation]
+	status["sourceHash"] = hash
+
+	targets, err := secretDistributionTargets(ctx, &spec)
+	if err != nil {
+		status["message"] = err.Error()
+		return
+	}
+	// Never overwrite the source with its own copy
+	if desired.Name == source.Name {
+		delete(targets, source.Namespace)
+	}
+
+	synced := []interface{}{}
+	var conflicts []interface{}
+	var failures []string
+	for _, ns := range sortedKeys(targets) {
+		err := applyDistributedSecret(ctx, ns, desired)
+		switch {
+		case err == errSecretNotManaged:
+			conflicts = append(conflicts, ns)
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s: %v", ns, err))
+		default:
+			synced = append(synced, ns)
+		}
+	}
+	pruneDistributedSecrets(ctx, dist.GetName(), desired.Name, targets)
+
+	status["namespaces"] = synced
+	if len(conflicts) > 0 {
+		status["conflicts"] = conflicts
+	}
+	switch {
+	case len(failures) > 0:
+		status["message"] = "failed to sync " + strings.Join(failures, "; ")
+	case len(conflicts) > 0:
+		status["message"] = fmt.Sprintf("%d namespaces already hold an unmanaged secret %s", len(conflicts), desired.Name)
+	default:
+		status["message"] = fmt.Sprintf("synced to %d namespaces", len(synced))
+	}
+}
+
+// desiredDistributedSecret applies the transform to the source: keys are renamed first, then
+// filtered by includeKeys
+func desiredDistributedSecret(dist *unstructured.Unstructured, spec *secretDistributionSpec, source *corev1.Secret) *corev1.Secret {
+	data := map[string][]byte{}
+	include := map[string]bool{}
+	for _, k := range spec.Transform.IncludeKeys {
+		include[k] = true
+	}
+	for k, v := range source.Data {
+		if renamed, ok := spec.Transform.RenameKeys[k]; ok && renamed != "" {
+			k = renamed
+		}
+		if len(include) > 0 && !include[k] {
+			continue
+		}
+		data[k] = v
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        source.Name,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+			OwnerReferences: []v1.OwnerReference{{
+				APIVersion: dist.GetAPIVersion(),
+				Kind:       dist.GetKind(),
+				Name:       dist.GetName(),
+				UID:        dist.GetUID(),
+				Controller: boolPtr(true),
+			}},
+		},
+		Type: source.Type,
+		Data: data,
+	}
+	if spec.Transform.Name != "" {
+		secret.Name = spec.Transform.Name
+	}
+	if spec.Transform.Type != "" {
+		secret.Type = corev1.SecretType(spec.Transform.Type)
+	}
+	for k, v := range spec.Transform.Labels {
+		secret.Labels[k] = v
+	}
+	for k, v := range spec.Transform.Annotations {
+		secret.Annotations[k] = v
+	}
+	secret.Labels[secretDistributionLabel] = dist.GetName()
+	secret.Annotations[types.CopiedFromAnnotation] = fmt.Sprintf("%s/%s", source.Namespace, source.Name)
+	secret.Annotations[sourceHashAnnotation] = secretDataHash(secret.Type, data)
+	return secret
+}
+
+// secretDistributionTargets resolves the selector (default: managed projects) and the named
+// namespaces, limited to the operator's watch scope
+func secretDistributionTargets(ctx context.Context, spec *secretDistributionSpec) (map[string]bool, error) {
+	selector := labels.SelectorFromSet(labels.Set{"ambient-code.io/managed": "true"})
+	if spec.NamespaceSelector != nil {
+		s, err := v1.LabelSelectorAsSelector(spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector: %w", err)
+		}
+		selector = s
+	}
+	targets := map[string]bool{}
+	// An empty selector selects nothing here; only the named namespaces are targeted
+	if !selector.Empty() {
+		list, err := config.K8sClient.CoreV1().Namespaces().List(ctx, v1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		for i := range list.Items {
+			if WatchScope.AllowsNamespace(&list.Items[i]) {
+				targets[list.Items[i].Name] = true
+			}
+		}
+	}
+	for _, name := range spec.Namespaces {
+		ns, err := config.K8sClient.CoreV1().Namespaces().Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if WatchScope.AllowsNamespace(ns) {
+			targets[name] = true
+		}
+	}
+	return targets, nil
+}
+
+// errSecretNotManaged is returned for a target secret that exists but is not a copy of the
+// same distribution; it is left alone
+var errSecretNotManaged = fmt.Errorf("secret exists and is not managed by this distribution")
+
+// applyDistributedSecret creates the copy in a namespace, or rewrites it when it has drifted
+// from the desired data, type or metadata
+func applyDistributedSecret(ctx context.Context, namespace string, desired *corev1.Secret) error {
+	secrets := config.K8sClient.CoreV1().Secrets(namespace)
+	copy := desired.DeepCopy()
+	copy.Namespace = namespace
+	meta, err := projectObjectMetadata(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	meta.Apply(copy)
+
+	current, err := secrets.Get(ctx, desired.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, copy, v1.CreateOptions{})
+		if err == nil {
+			log.Printf("SecretDistribution %s: created %s/%s", desired.Labels[secretDistributionLabel], namespace, desired.Name)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if current.Labels[secretDistributionLabel] != desired.Labels[secretDistributionLabel] {
+		return errSecretNotManaged
+	}
+	if !distributedSecretDrifted(current, desired) {
+		return nil
+	}
+	log.Printf("SecretDistribution %s: %s/%s drifted from the source, rewriting", desired.Labels[secretDistributionLabel], namespace, desired.Name)
+	// The type of a secret cannot be changed in place
+	if current.Type != desired.Type {
+		if err := secrets.Delete(ctx, current.Name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = secrets.Create(ctx, copy, v1.CreateOptions{})
+		return err
+	}
+	copy.ResourceVersion = current.ResourceVersion
+	_, err = secrets.Update(ctx, copy, v1.UpdateOptions{})
+	return err
+}
+
+// distributedSecretDrifted compares the live data (not only the recorded hash, so hand edits
+// are caught) and the metadata the distribution sets
+func distributedSecretDrifted(current, desired *corev1.Secret) bool {
+	if secretDataHash(current.Type, current.Data) != desired.Annotations[sourceHashAnnotation] {
+		return true
+	}
+	for k, v := range desired.Labels {
+		if current.Labels[k] != v {
+			return true
+		}
+	}
+	for k, v := range desired.Annotations {
+		if current.Annotations[k] != v {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneDistributedSecrets deletes copies outside the targets, or left under an old name
+func pruneDistributedSecrets(ctx context.Context, distName, secretName string, targets map[string]bool) {
+	list, err := config.K8sClient.CoreV1().Secrets("").List(ctx, v1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{secretDistributionLabel: distName}).String(),
+	})
+	if err != nil {
+		log.Printf("SecretDistribution %s: failed to list copies: %v", distName, err)
+		return
+	}
+	for _, s := range list.Items {
+		if targets[s.Namespace] && s.Name == secretName {
+			continue
+		}
+		if err := config.K8sClient.CoreV1().Secrets(s.Namespace).Delete(ctx, s.Name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("SecretDistribution %s: failed to remove %s/%s: %v", distName, s.Namespace, s.Name, err)
+			continue
+		}
+		log.Printf("SecretDistribution %s: removed %s/%s", distName, s.Namespace, s.Name)
+	}
+}
+
+// secretDataHash fingerprints a secret's type and data independently of map order
+func secretDataHash(secretType corev1.SecretType, data map[string][]byte) string {
+	h := sha256.New()
+	h.Write([]byte(secretType))
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, "\x00%s\x00%d\x00", k, len(data[k]))
+		h.Write(data[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updateSecretDistributionStatus writes status, moving lastSyncTime only when something else
+// changed
+func updateSecretDistributionStatus(ctx context.Context, dist *unstructured.Unstructured, status map[string]interface{}) {
+	err := statusupdater.Mutate(ctx, types.GetSecretDistributionResource(), dist.GetNamespace(), dist.GetName(), func(current map[string]interface{}) error {
+		delete(status, "lastSyncTime")
+		status["lastSyncTime"] = current["lastSyncTime"]
+		if statusEqual(current, status) {
+			return statusupdater.ErrNoChange
+		}
+		status["lastSyncTime"] = time.Now().UTC().Format(time.RFC3339)
+		return replaceStatus(current, status)
+	})
+	if err != nil {
+		log.Printf("Failed to update SecretDistribution %s status: %v", dist.GetName(), err)
+	}
+}
+
+// statusEqual compares statuses through JSON, so int64 and float64 numbers match
+func statusEqual(a, b map[string]interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	var na, nb interface{}
+	_ = json.Unmarshal(ja, &na)
+	_ = json.Unmarshal(jb, &nb)
+	ra, _ := json.Marshal(na)
+	rb, _ := json.Marshal(nb)
+	return string(ra) == string(rb)
+}
+
+// replaceStatus makes current, the status a statusupdater.Mutate callback receives, equal to
+// status, or returns ErrNoChange when it already is
+func replaceStatus(current, status map[string]interface{}) error {
+	if statusEqual(current, status) {
+		return statusupdater.ErrNoChange
+	}
+	for k := range current {
+		delete(current, k)
+	}
+	for k, v := range status {
+		current[k] = v
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestReconcileSecretDistribution verifies copies are created with the transform applied,
+// drift is repaired, unmanaged secrets are reported as conflicts and stale copies are pruned
+func TestReconcileSecretDistribution(t *testing.T) {
+	ctx := context.Background()
+	managed := map[string]string{"ambient-code.io/managed": "true"}
+	setupTestClient(
+		testNamespace("platform", nil),
+		testNamespace("proj-a", managed),
+		testNamespace("proj-b", managed),
+		testNamespace("proj-c", managed),
+		testNamespace("other", nil),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-ca", Namespace: "platform"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"ca.crt": []byte("v1"), "ca.key": []byte("private")},
+		},
+		// A user's own secret of the same name must not be overwritten
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "proj-c"}},
+	)
+	dist := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "SecretDistribution",
+		"metadata":   map[string]interface{}{"name": "corp-ca", "generation": int64(1)},
+		"spec": map[string]interface{}{
+			"source":     map[string]interface{}{"namespace": "platform", "name": "corp-ca"},
+			"namespaces": []interface{}{"other", "missing"},
+			"transform": map[string]interface{}{
+				"name":        "ca-bundle",
+				"renameKeys":  map[string]interface{}{"ca.crt": "ca-bundle.crt"},
+				"includeKeys": []interface{}{"ca-bundle.crt"},
+				"labels":      map[string]interface{}{"team": "platform"},
+			},
+		},
+	}}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetSecretDistributionResource(): "SecretDistributionList",
+	}, dist)
+	secrets := func(ns string) *corev1.Secret {
+		s, err := config.K8sClient.CoreV1().Secrets(ns).Get(ctx, "ca-bundle", metav1.GetOptions{})
+		if err != nil {
+			return nil
+		}
+		return s
+	}
+
+	reconcileSecretDistribution(ctx, dist)
+
+	for _, ns := range []string{"proj-a", "proj-b", "other"} {
+		s := secrets(ns)
+		if s == nil {
+			t.Fatalf("no copy in %s", ns)
+		}
+		if len(s.Data) != 1 || string(s.Data["ca-bundle.crt"]) != "v1" {
+			t.Errorf("%s: unexpected data %v", ns, s.Data)
+		}
+		if s.Labels["team"] != "platform" || s.Labels[secretDistributionLabel] != "corp-ca" {
+			t.Errorf("%s: unexpected labels %v", ns, s.Labels)
+		}
+		if s.Annotations[types.CopiedFromAnnotation] != "platform/corp-ca" {
+			t.Errorf("%s: unexpected annotations %v", ns, s.Annotations)
+		}
+	}
+	if s := secrets("proj-c"); s == nil || len(s.Data) != 0 {
+		t.Errorf("unmanaged secret in proj-c was modified: %+v", s)
+	}
+	got, err := config.DynamicClient.Resource(types.GetSecretDistributionResource()).Get(ctx, "corp-ca", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicts, _, _ := unstructured.NestedStringSlice(got.Object, "status", "conflicts")
+	synced, _, _ := unstructured.NestedStringSlice(got.Object, "status", "namespaces")
+	if len(conflicts) != 1 || conflicts[0] != "proj-c" || len(synced) != 3 {
+		t.Errorf("unexpected status %v", got.Object["status"])
+	}
+
+	// Hand-edit a copy, rotate the source and drop a namespace from the selection
+	edited := secrets("proj-a")
+	edited.Data["ca-bundle.crt"] = []byte("tampered")
+	if _, err := config.K8sClient.CoreV1().Secrets("proj-a").Update(ctx, edited, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	source, _ := config.K8sClient.CoreV1().Secrets("platform").Get(ctx, "corp-ca", metav1.GetOptions{})
+	source.Data["ca.crt"] = []byte("v2")
+	if _, err := config.K8sClient.CoreV1().Secrets("platform").Update(ctx, source, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_ = unstructured.SetNestedStringSlice(dist.Object, []string{}, "spec", "namespaces")
+
+	reconcileSecretDistribution(ctx, dist)
+
+	for _, ns := range []string{"proj-a", "proj-b"} {
+		if s := secrets(ns); s == nil || string(s.Data["ca-bundle.crt"]) != "v2" {
+			t.Errorf("%s: copy not updated to the rotated source: %+v", ns, s)
+		}
+	}
+	if secrets("other") != nil {
+		t.Error("copy in a namespace no longer targeted was not removed")
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// sessionGroupLabel marks the sessions of a group and the group's workspace PVC
+const sessionGroupLabel = "ambient-code.io/session-group"
+
+// defaultSessionGroupStorageSize is the size of a new group workspace volume
+const defaultSessionGroupStorageSize = "5Gi"
+
+// sessionGroupOf returns spec.sessionGroup, empty for a session with its own workspace
+func sessionGroupOf(session *unstructured.Unstructured) string {
+	group, _, _ := unstructured.NestedString(session.Object, "spec", "sessionGroup")
+	return strings.TrimSpace(group)
+}
+
+// sessionGroupPVCName is the workspace volume shared by the sessions of a group
+func sessionGroupPVCName(group string) string {
+	return fmt.Sprintf("ambient-workspace-group-%s", group)
+}
+
+// ensureSessionGroupPVC provisions the group's ReadWriteMany workspace volume and adds the
+// session as one of its owners. No session controls the volume: it is garbage collected once
+// every member session is deleted.
+func ensureSessionGroupPVC(ctx context.Context, namespace, group string, session *unstructured.Unstructured, storageClass string, meta apiv1alpha1.ObjectMetadata) (string, error) {
+	pvcName := sessionGroupPVCName(group)
+	owner := v1.OwnerReference{
+		APIVersion: session.GetAPIVersion(),
+		Kind:       "AgenticSession",
+		Name:       session.GetName(),
+		UID:        session.GetUID(),
+	}
+	pvcs := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace)
+	pvc, err := pvcs.Get(ctx, pvcName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Name:            pvcName,
+				Namespace:       namespace,
+				Labels:          map[string]string{"app": "ambient-workspace", sessionGroupLabel: group},
+				OwnerReferences: []v1.OwnerReference{owner},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				// Members run concurrently and may land on different nodes
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(defaultSessionGroupStorageSize)},
+				},
+			},
+		}
+		if storageClass != "" {
+			pvc.Spec.StorageClassName = &storageClass
+		}
+		meta.Apply(pvc)
+		if _, err := pvcs.Create(ctx, pvc, v1.CreateOptions{}); err == nil || !errors.IsAlreadyExists(err) {
+			return pvcName, err
+		}
+		// Another member created it first
+		pvc, err = pvcs.Get(ctx, pvcName, v1.GetOptions{})
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range pvc.OwnerReferences {
+		if ref.UID == owner.UID {
+			return pvcName, nil
+		}
+	}
+	updated := pvc.DeepCopy()
+	updated.OwnerReferences = append(updated.OwnerReferences, owner)
+	if _, err := pvcs.Update(ctx, updated, v1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("add session %s as owner of %s: %w", session.GetName(), pvcName, err)
+	}
+	return pvcName, nil
+}
+
+// applySessionGroup points the session's workspace at the group's shared checkout. The
+// session directory keeps its usual path (the runner, content service and backend all use it)
+// but its workspace is a relative symlink into groups/<group>, which resolves wherever the
+// volume is mounted.
+func applySessionGroup(podSpec *corev1.PodSpec, session, group string) {
+	groupDir := fmt.Sprintf("/workspace/groups/%s/workspace", group)
+	sessionDir := fmt.Sprintf("/workspace/sessions/%s", session)
+	script := fmt.Sprintf(`set -e
+mkdir -p %[1]s %[2]s
+chmod 777 %[1]s
+if [ ! -e %[2]s/workspace ]; then ln -s ../../groups/%[3]s/workspace %[2]s/workspace; fi
+echo 'Workspace shared with session group %[3]s'`, groupDir, sessionDir, group)
+	for i := range podSpec.InitContainers {
+		if podSpec.InitContainers[i].Name == "init-workspace" {
+			podSpec.InitContainers[i].Command = []string{"sh", "-c", script}
+		}
+	}
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "ambient-code-runner" {
+			podSpec.Containers[i].Env = append(podSpec.Containers[i].Env, corev1.EnvVar{Name: "SESSION_GROUP", Value: group})
+		}
+	}
+}
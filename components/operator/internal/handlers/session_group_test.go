@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+// TestEnsureSessionGroupPVC verifies members share one ReadWriteMany volume they all own
+func TestEnsureSessionGroupPVC(t *testing.T) {
+	setupTestClient()
+	ctx := context.Background()
+	group := map[string]interface{}{"sessionGroup": "pair"}
+	specWriter := testSession("spec-writer", "Pending", group)
+	specWriter.SetUID(k8stypes.UID("uid-1"))
+	testWriter := testSession("test-writer", "Pending", group)
+	testWriter.SetUID(k8stypes.UID("uid-2"))
+
+	// The repeated member must not be added twice
+	for _, s := range []*unstructured.Unstructured{specWriter, testWriter, specWriter} {
+		name, err := ensureSessionGroupPVC(ctx, "proj", sessionGroupOf(s), s, "", apiv1alpha1.ObjectMetadata{})
+		if err != nil || name != "ambient-workspace-group-pair" {
+			t.Fatalf("ensureSessionGroupPVC(%s) = %q, %v", s.GetName(), name, err)
+		}
+	}
+	pvc, err := config.K8sClient.CoreV1().PersistentVolumeClaims("proj").Get(ctx, "ambient-workspace-group-pair", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("group PVC not created: %v", err)
+	}
+	if pvc.Spec.AccessModes[0] != corev1.ReadWriteMany {
+		t.Errorf("expected ReadWriteMany, got %v", pvc.Spec.AccessModes)
+	}
+	if len(pvc.OwnerReferences) != 2 {
+		t.Fatalf("expected both members as owners, got %+v", pvc.OwnerReferences)
+	}
+	for _, ref := range pvc.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			t.Errorf("no member should control the group PVC: %+v", ref)
+		}
+	}
+}
+
+// TestApplySessionGroup verifies the session workspace is linked into the group checkout
+func TestApplySessionGroup(t *testing.T) {
+	podSpec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init-workspace", Command: []string{"sh", "-c", "mkdir -p /workspace/sessions/s1/workspace"}}},
+		Containers:     []corev1.Container{{Name: "ambient-content"}, {Name: "ambient-code-runner"}},
+	}
+	applySessionGroup(podSpec, "s1", "pair")
+
+	script := podSpec.InitContainers[0].Command[2]
+	if !strings.Contains(script, "ln -s ../../groups/pair/workspace /workspace/sessions/s1/workspace") {
+		t.Errorf("workspace not linked into the group checkout:\n%s", script)
+	}
+	env := podSpec.Containers[1].Env
+	if len(env) != 1 || env[0].Name != "SESSION_GROUP" || env[0].Value != "pair" {
+		t.Errorf("expected SESSION_GROUP on the runner, got %+v", env)
+	}
+	if len(podSpec.Containers[0].Env) != 0 {
+		t.Errorf("content container should be unchanged, got %+v", podSpec.Containers[0].Env)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// sessionEnvSecretName is the per-run copy of the Secrets listed in spec.envFromSecrets.
+// It exists only while the session's Job does, so the values are not readable through the
+// session once it has stopped.
+func sessionEnvSecretName(session string) string {
+	return fmt.Sprintf("ambient-session-env-%s", session)
+}
+
+// ensureSessionEnvSecret copies the keys selected by spec.envFromSecrets into the session's
+// env Secret, re-checking the project's allowedSessionSecrets since the allowlist may have
+// changed after the session was created. It returns "" when nothing is injected.
+func ensureSessionEnvSecret(ctx context.Context, session *unstructured.Unstructured, ownerRefs []v1.OwnerReference, meta apiv1alpha1.ObjectMetadata) (string, error) {
+	raw, found, _ := unstructured.NestedSlice(session.Object, "spec", "envFromSecrets")
+	if !found || len(raw) == 0 {
+		return "", nil
+	}
+	var sources []apiv1alpha1.SecretEnvSource
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		src := apiv1alpha1.SecretEnvSource{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &src); err != nil {
+			return "", fmt.Errorf("invalid spec.envFromSecrets: %w", err)
+		}
+		sources = append(sources, src)
+	}
+
+	namespace := session.GetNamespace()
+	allowed := &apiv1alpha1.ProjectSettingsSpec{}
+	ps, err := config.VteamClient.VteamV1alpha1().ProjectSettings(namespace).Get(ctx, apiv1alpha1.ProjectSettingsName, v1.GetOptions{})
+	if err == nil {
+		allowed = &ps.Spec
+	} else if !errors.IsNotFound(err) {
+		return "", fmt.Errorf("read ProjectSettings: %w", err)
+	}
+
+	data := map[string][]byte{}
+	for _, src := range sources {
+		if !allowed.SessionSecretAllowed(src.Name) {
+			return "", fmt.Errorf("secret %q is not in the project's allowedSessionSecrets", src.Name)
+		}
+		secret, err := config.K8sClient.CoreV1().Secrets(namespace).Get(ctx, src.Name, v1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("read secret %s: %w", src.Name, err)
+		}
+		if len(src.Keys) == 0 {
+			for k, v := range secret.Data {
+				data[k] = v
+			}
+			continue
+		}
+		for _, k := range src.Keys {
+			v, ok := secret.Data[k]
+			if !ok {
+				return "", fmt.Errorf("secret %s has no key %q", src.Name, k)
+			}
+			data[k] = v
+		}
+	}
+
+	name := sessionEnvSecretName(session.GetName())
+	desired := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			Labels:          map[string]string{"app": "ambient-session-env", "agentic-session": session.GetName()},
+			OwnerReferences: ownerRefs,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	meta.Apply(desired)
+	secrets := config.K8sClient.CoreV1().Secrets(namespace)
+	if _, err := secrets.Create(ctx, desired, v1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("create secret %s: %w", name, err)
+		}
+		// Left over from an earlier attempt; refresh it from the current sources
+		if _, err := secrets.Update(ctx, desired, v1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("update secret %s: %w", name, err)
+		}
+	}
+	log.Printf("Injecting %d key(s) from spec.envFromSecrets into session %s/%s", len(data), namespace, session.GetName())
+	return name, nil
+}
+
+// applySessionEnvSecret adds the session's env Secret to the named container's envFrom
+func applySessionEnvSecret(podSpec *corev1.PodSpec, containerName, secretName string) {
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name != containerName {
+			continue
+		}
+		podSpec.Containers[i].EnvFrom = append(podSpec.Containers[i].EnvFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: secretName}},
+		})
+	}
+}
+
+// deleteSessionEnvSecret removes the session's env Secret once its run is over
+func deleteSessionEnvSecret(ctx context.Context, namespace, session string) error {
+	err := config.K8sClient.CoreV1().Secrets(namespace).Delete(ctx, sessionEnvSecretName(session), v1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
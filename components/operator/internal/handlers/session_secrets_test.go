@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamfake "ambient-code-pkg/client/clientset/versioned/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestEnsureSessionEnvSecret verifies only allowlisted Secrets are copied, limited to the
+// selected keys, and that the copy is removed with the run
+func TestEnsureSessionEnvSecret(t *testing.T) {
+	ctx := context.Background()
+	setupTestClient(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "deploy-creds", Namespace: "proj"}, Data: map[string][]byte{
+			"DEPLOY_TOKEN": []byte("tok-123456"),
+			"ADMIN_TOKEN":  []byte("admin-secret"),
+		}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "proj"}, Data: map[string][]byte{"X": []byte("y")}},
+	)
+	// Created through the client: the fake's object tracker guesses the wrong plural otherwise
+	config.VteamClient = vteamfake.NewSimpleClientset()
+	if _, err := config.VteamClient.VteamV1alpha1().ProjectSettings("proj").Create(ctx, &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "proj"},
+		Spec:       apiv1alpha1.ProjectSettingsSpec{AllowedSessionSecrets: []string{"deploy-creds"}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create ProjectSettings: %v", err)
+	}
+
+	name, err := ensureSessionEnvSecret(ctx, testSession("s1", "Pending", map[string]interface{}{
+		"envFromSecrets": []interface{}{map[string]interface{}{"name": "deploy-creds", "keys": []interface{}{"DEPLOY_TOKEN"}}},
+	}), nil, apiv1alpha1.ObjectMetadata{})
+	if err != nil {
+		t.Fatalf("ensureSessionEnvSecret: %v", err)
+	}
+	secret, err := config.K8sClient.CoreV1().Secrets("proj").Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("session env secret not created: %v", err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["DEPLOY_TOKEN"]) != "tok-123456" {
+		t.Errorf("unexpected session env secret data: %v", secret.Data)
+	}
+
+	if _, err := ensureSessionEnvSecret(ctx, testSession("s2", "Pending", map[string]interface{}{
+		"envFromSecrets": []interface{}{map[string]interface{}{"name": "other"}},
+	}), nil, apiv1alpha1.ObjectMetadata{}); err == nil || !strings.Contains(err.Error(), "allowedSessionSecrets") {
+		t.Errorf("expected allowlist error, got %v", err)
+	}
+	if name, err := ensureSessionEnvSecret(ctx, testSession("s3", "Pending", map[string]interface{}{}), nil, apiv1alpha1.ObjectMetadata{}); err != nil || name != "" {
+		t.Errorf("session without envFromSecrets: got %q, %v", name, err)
+	}
+
+	if err := deleteSessionEnvSecret(ctx, "proj", "s1"); err != nil {
+		t.Fatalf("deleteSessionEnvSecret: %v", err)
+	}
+	if _, err := config.K8sClient.CoreV1().Secrets("proj").Get(ctx, name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("session env secret still present: %v", err)
+	}
+}
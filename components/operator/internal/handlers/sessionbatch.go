@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/diagnostics"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// A SessionBatch aggregates the sessions its selector matches in its namespace: phase counts,
+// cost and token totals and a Complete condition, so a fan-out's progress can be followed with
+// kubectl get -w. Session changes are picked up on the resync; the batch's own changes at once.
+
+// sessionBatchResync is how often every batch is re-aggregated from its sessions
+const sessionBatchResync = 15 * time.Second
+
+// WatchSessionBatches reconciles SessionBatches on change and on a periodic resync
+func WatchSessionBatches() {
+	gvr := types.GetSessionBatchResource()
+	go func() {
+		for range time.Tick(sessionBatchResync) {
+			resyncSessionBatches(context.TODO())
+		}
+	}()
+	for {
+		watcher, err := config.DynamicClient.Resource(gvr).Namespace("").Watch(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			log.Printf("Failed to create SessionBatch watcher (is the CRD installed?): %v", err)
+			time.Sleep(30 * time.Second)
+			continue
+		}
+		log.Println("Watching for SessionBatch events...")
+		for event := range watcher.ResultChan() {
+			diagnostics.Default.WatchEvent("SessionBatch")
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				// Status writes also arrive as modifications; only spec changes need work now
+				observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+				if observed != obj.GetGeneration() && WatchScope.Allows(context.TODO(), obj.GetNamespace()) {
+					reconcileSessionBatch(context.TODO(), obj)
+				}
+			case watch.Error:
+				log.Printf("Watch error for SessionBatches: %v", obj)
+			}
+		}
+		log.Println("SessionBatch watch channel closed, restarting...")
+		diagnostics.Default.WatchRestarted("SessionBatch")
+		watcher.Stop()
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func resyncSessionBatches(ctx context.Context) {
+	list, err := config.DynamicClient.Resource(types.GetSessionBatchResource()).Namespace("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to list SessionBatches: %v", err)
+		}
+		return
+	}
+	for i := range list.Items {
+		if WatchScope.Allows(ctx, list.Items[i].GetNamespace()) {
+			reconcileSessionBatch(ctx, &list.Items[i])
+		}
+	}
+}
+
+// reconcileSessionBatch aggregates the batch's sessions into its status
+func reconcileSessionBatch(ctx context.Context, batch *unstructured.Unstructured) {
+	done := diagnostics.Default.BeginReconcile("SessionBatch", batch.GetNamespace(), batch.GetName())
+	var err error
+	defer func() { done(err) }()
+
+	var spec apiv1alpha1.SessionBatchSpec
+	raw, _ := json.Marshal(batch.Object["spec"])
+	_ = json.Unmarshal(raw, &spec)
+	selector, selErr := v1.LabelSelectorAsSelector(spec.Selector)
+	if spec.Selector == nil || selErr != nil || selector.Empty() {
+		updateSessionBatchStatus(ctx, batch, func(current map[string]interface{}) (map[string]interface{}, error) {
+			status := copySessionBatchStatus(current)
+			status["observedGeneration"] = batch.GetGeneration()
+			setSessionCondition(status, sessionCondition(apiv1alpha1.SessionBatchCompleteCondition, v1.ConditionFalse, "InvalidSelector", "spec.selector must select sessions"))
+			return status, nil
+		})
+		return
+	}
+
+	list, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(batch.GetNamespace()).List(ctx, v1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		log.Printf("SessionBatch %s/%s: failed to list sessions: %v", batch.GetNamespace(), batch.GetName(), err)
+		return
+	}
+	items := make([]apiv1alpha1.SessionBatchItem, 0, len(list.Items))
+	for _, session := range list.Items {
+		sessionStatus, _, _ := unstructured.NestedMap(session.Object, "status")
+		phase, _ := sessionStatus["phase"].(string)
+		spend := spendFromStatus(sessionStatus)
+		items = append(items, apiv1alpha1.SessionBatchItem{Name: session.GetName(), Phase: phase, CostUSD: spend.USD, Tokens: spend.Tokens})
+	}
+	summary, complete := apiv1alpha1.SummarizeSessionBatch(spec.ExpectedSessions, items)
+
+	updateSessionBatchStatus(ctx, batch, func(current map[string]interface{}) (map[string]interface{}, error) {
+		status, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&summary)
+		if err != nil {
+			return nil, err
+		}
+		status["observedGeneration"] = batch.GetGeneration()
+		if conditions, ok := current["conditions"].([]interface{}); ok {
+			status["conditions"] = append([]interface{}{}, conditions...)
+		}
+		setSessionCondition(status, sessionBatchCondition(summary, complete, spec.ExpectedSessions))
+		if complete {
+			status["completionTime"] = current["completionTime"]
+			if status["completionTime"] == nil {
+				status["completionTime"] = time.Now().UTC().Format(time.RFC3339)
+			}
+		}
+		return status, nil
+	})
+}
+
+// sessionBatchCondition is the batch's Complete condition for the summary
+func sessionBatchCondition(summary apiv1alpha1.SessionBatchStatus, complete bool, expected int) map[string]interface{} {
+	finished := summary.Succeeded + summary.Failed
+	switch {
+	case !complete:
+		total := summary.Total
+		if expected > total {
+			total = expected
+		}
+		return sessionCondition(apiv1alpha1.SessionBatchCompleteCondition, v1.ConditionFalse, apiv1alpha1.SessionBatchInProgress,
+			fmt.Sprintf("%d of %d sessions finished", finished, total))
+	case summary.Failed > 0:
+		return sessionCondition(apiv1alpha1.SessionBatchCompleteCondition, v1.ConditionTrue, apiv1alpha1.SessionBatchFailed,
+			fmt.Sprintf("%d of %d sessions did not complete", summary.Failed, finished))
+	}
+	return sessionCondition(apiv1alpha1.SessionBatchCompleteCondition, v1.ConditionTrue, apiv1alpha1.SessionBatchSucceeded,
+		fmt.Sprintf("All %d sessions completed", finished))
+}
+
+func copySessionBatchStatus(current map[string]interface{}) map[string]interface{} {
+	status := make(map[string]interface{}, len(current))
+	for k, v := range current {
+		status[k] = v
+	}
+	// setSessionCondition replaces conditions in place
+	if conditions, ok := current["conditions"].([]interface{}); ok {
+		status["conditions"] = append([]interface{}{}, conditions...)
+	}
+	return status
+}
+
+// updateSessionBatchStatus writes the status build derives from the batch's current one,
+// building it again from a fresh read when another writer got in first
+func updateSessionBatchStatus(ctx context.Context, batch *unstructured.Unstructured, build func(current map[string]interface{}) (map[string]interface{}, error)) {
+	err := statusupdater.Mutate(ctx, types.GetSessionBatchResource(), batch.GetNamespace(), batch.GetName(), func(current map[string]interface{}) error {
+		status, err := build(current)
+		if err != nil {
+			return err
+		}
+		return replaceStatus(current, status)
+	})
+	if err != nil {
+		log.Printf("Failed to update SessionBatch %s/%s status: %v", batch.GetNamespace(), batch.GetName(), err)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestReconcileSessionBatch verifies phases and cost are aggregated from the selected sessions
+// and the batch completes once every session has finished
+func TestReconcileSessionBatch(t *testing.T) {
+	ctx := context.Background()
+	session := func(name, batch, phase string, cost float64) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "AgenticSession",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "proj", "labels": map[string]interface{}{"ambient-code.io/fanout": batch}},
+			"status": map[string]interface{}{
+				"phase":          phase,
+				"total_cost_usd": cost,
+				"usage":          map[string]interface{}{"input_tokens": int64(100), "output_tokens": int64(50)},
+			},
+		}}
+		return obj
+	}
+	batch := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "SessionBatch",
+		"metadata":   map[string]interface{}{"name": "fanout-b1", "namespace": "proj", "generation": int64(1)},
+		"spec": map[string]interface{}{
+			"selector":         map[string]interface{}{"matchLabels": map[string]interface{}{"ambient-code.io/fanout": "b1"}},
+			"expectedSessions": int64(2),
+		},
+	}}
+	running := session("s2", "b1", "Running", 0.25)
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetSessionBatchResource():   "SessionBatchList",
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, session("s1", "b1", "Completed", 1.5), running, session("other", "b2", "Failed", 9))
+	// Created through the resource: the fake client would guess "sessionbatchs" from the kind
+	if _, err := config.DynamicClient.Resource(types.GetSessionBatchResource()).Namespace("proj").Create(ctx, batch, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	get := func() map[string]interface{} {
+		obj, err := config.DynamicClient.Resource(types.GetSessionBatchResource()).Namespace("proj").Get(ctx, "fanout-b1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj.Object
+	}
+
+	reconcileSessionBatch(ctx, batch)
+
+	obj := get()
+	total, _, _ := unstructured.NestedInt64(obj, "status", "total")
+	active, _, _ := unstructured.NestedInt64(obj, "status", "active")
+	cost, _, _ := unstructured.NestedFloat64(obj, "status", "totalCostUSD")
+	tokens, _, _ := unstructured.NestedInt64(obj, "status", "totalTokens")
+	if total != 2 || active != 1 || cost != 1.75 || tokens != 300 {
+		t.Errorf("status = %v", obj["status"])
+	}
+	if cond := sessionBatchCompleteCondition(obj); cond["status"] != "False" || cond["message"] != "1 of 2 sessions finished" {
+		t.Errorf("in-progress condition = %v", cond)
+	}
+
+	_ = unstructured.SetNestedField(running.Object, "Failed", "status", "phase")
+	if _, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Update(ctx, running, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	reconcileSessionBatch(ctx, &unstructured.Unstructured{Object: get()})
+
+	obj = get()
+	if cond := sessionBatchCompleteCondition(obj); cond["status"] != "True" || cond["reason"] != "SessionsFailed" {
+		t.Errorf("complete condition = %v", cond)
+	}
+	if completed, _, _ := unstructured.NestedString(obj, "status", "completionTime"); completed == "" {
+		t.Error("completionTime not set")
+	}
+}
+
+func sessionBatchCompleteCondition(obj map[string]interface{}) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	for _, c := range conditions {
+		if cond, _ := c.(map[string]interface{}); cond["type"] == "Complete" {
+			return cond
+		}
+	}
+	return nil
+}
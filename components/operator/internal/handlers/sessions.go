@@ -8,17 +8,24 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/diagnostics"
 	"ambient-code-operator/internal/services"
+	"ambient-code-operator/internal/statusupdater"
 	"ambient-code-operator/internal/types"
 
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/hardware"
+	"ambient-code-pkg/runtimeconfig"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
 	intstr "k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/util/retry"
@@ -40,6 +47,7 @@ func WatchAgenticSessions() {
 		log.Println("Watching for AgenticSession events across all namespaces...")
 
 		for event := range watcher.ResultChan() {
+			diagnostics.Default.WatchEvent("AgenticSession")
 			switch event.Type {
 			case watch.Added, watch.Modified:
 				obj := event.Object.(*unstructured.Unstructured)
@@ -58,11 +66,18 @@ func WatchAgenticSessions() {
 					// Skip unmanaged namespaces
 					continue
 				}
+				if !WatchScope.AllowsNamespace(nsObj) {
+					// Served by another operator install (--watch-namespaces/--exclude-namespaces)
+					continue
+				}
 
 				// Add small delay to avoid race conditions with rapid create/delete cycles
 				time.Sleep(100 * time.Millisecond)
 
-				if err := handleAgenticSessionEvent(obj); err != nil {
+				done := diagnostics.Default.BeginReconcile("AgenticSession", ns, obj.GetName())
+				err = handleAgenticSessionEvent(obj)
+				done(err)
+				if err != nil {
 					log.Printf("Error handling AgenticSession event: %v", err)
 				}
 			case watch.Deleted:
@@ -81,6 +96,7 @@ func WatchAgenticSessions() {
 		}
 
 		log.Println("AgenticSession watch channel closed, restarting...")
+		diagnostics.Default.WatchRestarted("AgenticSession")
 		watcher.Stop()
 		time.Sleep(2 * time.Second)
 	}
@@ -101,6 +117,11 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		return fmt.Errorf("failed to verify AgenticSession %s exists: %v", name, err)
 	}
 
+	// Deleted: revoke the runner identity so the finalizer can be released
+	if currentObj.GetDeletionTimestamp() != nil {
+		return finalizeSession(context.TODO(), currentObj)
+	}
+
 	// Get the current status from the fresh object (status may be empty right after creation
 	// because the API server drops .status on create when the status subresource is enabled)
 	stMap, found, _ := unstructured.NestedMap(currentObj.Object, "status")
@@ -175,6 +196,9 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		// Also cleanup ambient-vertex secret when session is stopped
 		deleteCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
+		if err := deleteSessionEnvSecret(deleteCtx, sessionNamespace, name); err != nil {
+			log.Printf("Warning: Failed to delete session env secret for %s/%s: %v", sessionNamespace, name, err)
+		}
 		if err := deleteAmbientVertexSecret(deleteCtx, sessionNamespace); err != nil {
 			log.Printf("Warning: Failed to cleanup %s secret from %s: %v", types.AmbientVertexSecretName, sessionNamespace, err)
 			// Continue - session cleanup is still successful
@@ -183,11 +207,39 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		return nil
 	}
 
+	// Map the runner's step reports to status.steps and status.progress
+	if err := syncSessionProgress(context.TODO(), currentObj, phase); err != nil {
+		log.Printf("Failed to update progress of %s/%s: %v", sessionNamespace, name, err)
+	}
+
+	// Completed sessions that opened pull requests may get a review session (spec.autoReview)
+	if phase == "Completed" {
+		if err := maybeSpawnReviewSession(context.TODO(), currentObj); err != nil {
+			log.Printf("Failed to start review session for %s/%s: %v", sessionNamespace, name, err)
+		}
+		// ...and may report them on the issue they worked on (spec.issueUpdates)
+		if err := maybeUpdateIssue(context.TODO(), currentObj); err != nil {
+			log.Printf("Failed to update the issue of %s/%s: %v", sessionNamespace, name, err)
+		}
+		return nil
+	}
+
+	// After an operator restart, pick up the Jobs of sessions that were already starting
+	if phase == "Creating" || phase == "Running" {
+		return restoreJobMonitor(currentObj)
+	}
+
 	// Only process if status is Pending
 	if phase != "Pending" {
 		return nil
 	}
 
+	// Sessions not created through the API run only once the backend has validated them
+	if msg := sessionAdoptionHold(currentObj); msg != "" {
+		log.Printf("Session %s/%s held: %s", sessionNamespace, name, msg)
+		return markSessionAwaitingAdoption(currentObj, msg)
+	}
+
 	// Check for session continuation (parent session ID)
 	parentSessionID := ""
 	// Check annotations first
@@ -205,6 +257,128 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		}
 	}
 
+	// Compliance metadata: hold the session until it carries the keys the project requires,
+	// then add the project's labels and annotations to everything created for it
+	metadataPolicy, err := projectObjectMetadataPolicy(context.TODO(), sessionNamespace)
+	if err != nil {
+		return err
+	}
+	if msg := sessionMetadataHold(currentObj, metadataPolicy); msg != "" {
+		log.Printf("Session %s/%s held: %s", sessionNamespace, name, msg)
+		return markSessionMissingMetadata(currentObj, msg)
+	}
+	objectMeta := metadataPolicy.For(currentObj.GetLabels(), currentObj.GetAnnotations())
+
+	// Create a Kubernetes Job for this AgenticSession
+	jobName := fmt.Sprintf("%s-job", name)
+
+	// An existing Job is adopted when it belongs to this run, otherwise replaced
+	existingJob, err := config.K8sClient.BatchV1().Jobs(sessionNamespace).Get(context.TODO(), jobName, v1.GetOptions{})
+	if err == nil {
+		return reconcileExistingJob(currentObj, existingJob)
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check for existing job %s: %w", jobName, err)
+	}
+
+	// Load config for this session
+	appConfig := config.LoadConfig()
+	vertexEnabled := os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1"
+
+	// Per-project provider selection overrides the cluster-wide Vertex default
+	llmProvider, err := loadLLMProviderSettings(sessionNamespace)
+	if err != nil {
+		return err
+	}
+	if reason, msg := llmProviderMisconfiguration(sessionNamespace, llmProvider, vertexEnabled); reason != "" {
+		if err := setLLMProviderCondition(currentObj, v1.ConditionFalse, reason, msg); err != nil {
+			log.Printf("Failed to record the LLM provider problem of %s/%s: %v", sessionNamespace, name, err)
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	if hasLLMProviderProblem(currentObj) {
+		if err := setLLMProviderCondition(currentObj, v1.ConditionTrue, "Configured", "The project's LLM provider is configured"); err != nil {
+			log.Printf("Failed to clear the LLM provider problem of %s/%s: %v", sessionNamespace, name, err)
+		}
+	}
+	bedrockEnabled := llmProvider.Provider == llmProviderBedrock
+	if llmProvider.Provider == llmProviderAnthropic || bedrockEnabled {
+		vertexEnabled = false
+	}
+
+	// Branch protection policy from ProjectSettings, enforced by the runner's git wrapper
+	gitPolicy, err := loadRunnerGitPolicy(context.TODO(), sessionNamespace)
+	if err != nil {
+		return err
+	}
+
+	// The holds below run before the workspace, secrets and runner identity are provisioned,
+	// so a waiting session costs only these checks each time it is retried
+
+	// Maintenance: hold the session until runner job creation is resumed
+	if jobCreationSuspended.Load() {
+		log.Printf("Session %s/%s held: runner job creation is suspended", sessionNamespace, name)
+		return markSessionSuspended(currentObj)
+	}
+
+	// Project time windows: hold the session until its schedule allows it to start
+	schedule, err := projectSessionSchedule(context.TODO(), sessionNamespace)
+	if err != nil {
+		return err
+	}
+	if msg := sessionScheduleHold(currentObj, schedule, time.Now()); msg != "" {
+		log.Printf("Session %s/%s held: %s", sessionNamespace, name, msg)
+		return markSessionOutsideSchedule(currentObj, msg)
+	}
+
+	// Admission: Kueue when the project has a LocalQueue, otherwise the cluster-wide runner slot limit
+	kueueQueue, kueuePriorityClass, err := kueueSettings(context.TODO(), sessionNamespace, appConfig)
+	if err != nil {
+		return err
+	}
+	rateKey := ""
+	if kueueQueue == "" {
+		// Per-user fairness: let users of the project with fewer running sessions go first
+		fairness, err := projectSessionFairness(context.TODO(), sessionNamespace)
+		if err != nil {
+			return err
+		}
+		if fairness != nil {
+			projectSessions, err := listProjectSessions(context.TODO(), sessionNamespace)
+			if err != nil {
+				return err
+			}
+			if msg := fairShareHold(currentObj, projectSessions, fairness); msg != "" {
+				log.Printf("Session %s/%s held: %s", sessionNamespace, name, msg)
+				return markSessionFairShare(currentObj, msg)
+			}
+		}
+		// Provider back-pressure: sessions sharing a credential wait while it is rate limited
+		if appConfig.RateLimitScheduling {
+			rateKey = rateLimitKey(context.TODO(), sessionNamespace, llmProvider, vertexEnabled)
+		}
+		// Only a check here; the slots are reserved again once the Job is about to be created
+		release, held, err := reserveRunnerCapacity(currentObj, rateKey, appConfig)
+		if held || err != nil {
+			return err
+		}
+		release()
+	}
+
+	// Projects holding regulated code require workspaces on an encrypted StorageClass
+	encryption, err := loadWorkspaceEncryption(context.TODO(), sessionNamespace)
+	if err != nil {
+		return err
+	}
+	storageClass, err := encryptedStorageClass(context.TODO(), encryption)
+	if err != nil {
+		log.Printf("Session %s/%s: %v", sessionNamespace, name, err)
+		return updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
+			"phase":   "Failed",
+			"message": fmt.Sprintf("Workspace encryption: %v", err),
+		})
+	}
+
 	// Determine PVC name and owner references
 	var pvcName string
 	var ownerRefs []v1.OwnerReference
@@ -232,8 +406,32 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	}
 
 	// Ensure PVC exists (skip for continuation if parent's PVC should exist)
-	if !reusingPVC {
-		if err := services.EnsureSessionWorkspacePVC(sessionNamespace, pvcName, ownerRefs); err != nil {
+	var warmStart *workspaceClone
+	sessionGroup := sessionGroupOf(currentObj)
+	if sessionGroup != "" {
+		// Session group: every member works in the group's shared workspace
+		groupPVC, err := ensureSessionGroupPVC(context.TODO(), sessionNamespace, sessionGroup, currentObj, storageClass, objectMeta)
+		if err != nil {
+			return fmt.Errorf("failed to ensure workspace of session group %s: %w", sessionGroup, err)
+		}
+		pvcName = groupPVC
+		log.Printf("Session %s joins session group %s, using shared PVC %s", name, sessionGroup, pvcName)
+	} else if !reusingPVC {
+		// Warm start from a previous session's workspace (spec.workspaceFrom)
+		warmStart = resolveWorkspaceFrom(context.TODO(), currentObj)
+		if warmStart != nil {
+			if err := ensureClonedWorkspacePVC(sessionNamespace, pvcName, storageClass, ownerRefs, objectMeta, warmStart, config.LoadConfig().WorkspaceCloneStrategy); err != nil {
+				log.Printf("Failed to ensure cloned session PVC %s in %s: %v", pvcName, sessionNamespace, err)
+				warmStart = nil
+			} else {
+				method := "copied"
+				if warmStart.volumeClone {
+					method = "cloned as a volume"
+				}
+				recordWorkspaceCloned(sessionNamespace, name, v1.ConditionTrue, "WorkspaceFrom",
+					fmt.Sprintf("Workspace of session %s is %s before the runner starts", warmStart.source, method))
+			}
+		} else if err := services.EnsureSessionWorkspacePVC(sessionNamespace, pvcName, storageClass, ownerRefs, objectMeta); err != nil {
 			log.Printf("Failed to ensure session PVC %s in %s: %v", pvcName, sessionNamespace, err)
 			// Continue; job may still run with ephemeral storage
 		}
@@ -252,20 +450,30 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 					Controller: boolPtr(true),
 				},
 			}
-			if err := services.EnsureSessionWorkspacePVC(sessionNamespace, pvcName, ownerRefs); err != nil {
+			if err := services.EnsureSessionWorkspacePVC(sessionNamespace, pvcName, storageClass, ownerRefs, objectMeta); err != nil {
 				log.Printf("Failed to create fallback PVC %s: %v", pvcName, err)
 			}
 		}
 	}
 
-	// Load config for this session
-	appConfig := config.LoadConfig()
+	if storageClass != "" {
+		// Shared and inherited volumes may predate the project's encryption requirement
+		if err := checkWorkspaceVolumeClass(context.TODO(), sessionNamespace, pvcName, storageClass); err != nil {
+			log.Printf("Session %s/%s: %v", sessionNamespace, name, err)
+			return updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
+				"phase":   "Failed",
+				"message": fmt.Sprintf("Workspace encryption: %v", err),
+			})
+		}
+		if err := recordWorkspaceEncryption(sessionNamespace, name, storageClass); err != nil {
+			log.Printf("Failed to record workspace encryption of %s/%s: %v", sessionNamespace, name, err)
+		}
+	}
 
 	// Check for ambient-vertex secret in the operator's namespace and copy it if Vertex is enabled
 	// This will be used to conditionally mount the secret as a volume
 	ambientVertexSecretCopied := false
 	operatorNamespace := appConfig.BackendNamespace // Assuming operator runs in same namespace as backend
-	vertexEnabled := os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1"
 
 	// Only attempt to copy the secret if Vertex AI is enabled
 	if vertexEnabled {
@@ -287,17 +495,19 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 			return fmt.Errorf("CLAUDE_CODE_USE_VERTEX=1 but %s secret not found in namespace %s", types.AmbientVertexSecretName, operatorNamespace)
 		}
 	} else {
-		log.Printf("Vertex AI disabled (CLAUDE_CODE_USE_VERTEX=0), skipping %s secret copy", types.AmbientVertexSecretName)
+		runtimeconfig.Debugf("Vertex AI disabled (CLAUDE_CODE_USE_VERTEX=0), skipping %s secret copy", types.AmbientVertexSecretName)
 	}
 
-	// Create a Kubernetes Job for this AgenticSession
-	jobName := fmt.Sprintf("%s-job", name)
-
-	// Check if job already exists in the session's namespace
-	_, err = config.K8sClient.BatchV1().Jobs(sessionNamespace).Get(context.TODO(), jobName, v1.GetOptions{})
-	if err == nil {
-		log.Printf("Job %s already exists for AgenticSession %s", jobName, name)
-		return nil
+	// Per-session ServiceAccount and Role; the runner authenticates with a fresh token each run
+	runnerTokenSecret, err := ensureRunnerIdentity(context.TODO(), currentObj, objectMeta)
+	if err != nil {
+		return fmt.Errorf("failed to provision runner identity for %s: %w", name, err)
+	}
+	runnerTLSSecret := ""
+	if RunnerCA != nil {
+		if runnerTLSSecret, err = ensureRunnerClientCert(context.TODO(), currentObj, objectMeta); err != nil {
+			return fmt.Errorf("failed to issue runner client certificate for %s: %w", name, err)
+		}
 	}
 
 	// Extract spec information from the fresh object
@@ -310,10 +520,8 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	model, _, _ := unstructured.NestedString(llmSettings, "model")
 	temperature, _, _ := unstructured.NestedFloat64(llmSettings, "temperature")
 	maxTokens, _, _ := unstructured.NestedInt64(llmSettings, "maxTokens")
-
-	// Hardcoded secret names (convention over configuration)
-	const runnerSecretsName = "ambient-runner-secrets"               // ANTHROPIC_API_KEY only (ignored when Vertex enabled)
-	const integrationSecretsName = "ambient-non-vertex-integrations" // GIT_*, JIRA_*, custom keys (optional)
+	contextStrategy, _, _ := unstructured.NestedString(llmSettings, "contextStrategy")
+	maxContextTokens, _, _ := unstructured.NestedInt64(llmSettings, "maxContextTokens")
 
 	// Check if integration secrets exist (optional)
 	integrationSecretsExist := false
@@ -323,7 +531,7 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	} else if !errors.IsNotFound(err) {
 		log.Printf("Error checking for %s secret in %s: %v", integrationSecretsName, sessionNamespace, err)
 	} else {
-		log.Printf("No %s secret found in %s (optional, skipping)", integrationSecretsName, sessionNamespace)
+		runtimeconfig.Debugf("No %s secret found in %s (optional, skipping)", integrationSecretsName, sessionNamespace)
 	}
 
 	// Extract input/output git configuration (support flat and nested forms)
@@ -347,6 +555,26 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	// Read autoPushOnComplete flag
 	autoPushOnComplete, _, _ := unstructured.NestedBool(spec, "autoPushOnComplete")
 
+	// Allowed external domains for the agent's web access (nil when unrestricted)
+	networkPolicy, err := projectNetworkPolicy(context.TODO(), sessionNamespace)
+	if err != nil {
+		return err
+	}
+
+	// RuntimeClass, GPUs and architecture from the project defaults and spec.hardware
+	runnerHardware, err := sessionHardware(context.TODO(), currentObj)
+	if err != nil {
+		log.Printf("Session %s/%s: invalid runner hardware: %v", sessionNamespace, name, err)
+		return updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
+			"phase":   "Failed",
+			"message": fmt.Sprintf("Invalid runner hardware: %v", err),
+		})
+	}
+
+	// Spot/preemptible scheduling hint and retry counter from previous node reclaims
+	preemptible, _, _ := unstructured.NestedBool(spec, "preemptible")
+	preemptionRetries, _, _ := unstructured.NestedInt64(currentObj.Object, "status", "preemptionRetries")
+
 	// Create the Job
 	job := &batchv1.Job{
 		ObjectMeta: v1.ObjectMeta{
@@ -356,6 +584,7 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 				"agentic-session": name,
 				"app":             "ambient-code-runner",
 			},
+			Annotations: map[string]string{jobIdempotencyKeyAnnotation: jobIdempotencyKey(currentObj)},
 			OwnerReferences: []v1.OwnerReference{
 				{
 					APIVersion: "vteam.ambient-code/v1",
@@ -384,7 +613,8 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 				},
 				Spec: corev1.PodSpec{
 					RestartPolicy: corev1.RestartPolicyNever,
-					// Explicitly set service account for pod creation permissions
+					// Run as the per-session ServiceAccount; its token reaches the runner via BOT_TOKEN only
+					ServiceAccountName:           runnerServiceAccountName(name),
 					AutomountServiceAccountToken: boolPtr(false),
 					Volumes: []corev1.Volume{
 						{
@@ -401,7 +631,7 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 					InitContainers: []corev1.Container{
 						{
 							Name:  "init-workspace",
-							Image: "registry.access.redhat.com/ubi8/ubi-minimal:latest",
+							Image: workspaceInitImage,
 							Command: []string{
 								"sh", "-c",
 								fmt.Sprintf("mkdir -p /workspace/sessions/%s/workspace && chmod 777 /workspace/sessions/%s/workspace && echo 'Workspace initialized'", name, name),
@@ -474,11 +704,15 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 									{Name: "LLM_MODEL", Value: model},
 									{Name: "LLM_TEMPERATURE", Value: fmt.Sprintf("%.2f", temperature)},
 									{Name: "LLM_MAX_TOKENS", Value: fmt.Sprintf("%d", maxTokens)},
+									{Name: "LLM_CONTEXT_STRATEGY", Value: contextStrategy},
+									{Name: "LLM_MAX_CONTEXT_TOKENS", Value: fmt.Sprintf("%d", maxContextTokens)},
 									{Name: "TIMEOUT", Value: fmt.Sprintf("%d", timeout)},
 									{Name: "AUTO_PUSH_ON_COMPLETE", Value: fmt.Sprintf("%t", autoPushOnComplete)},
 									{Name: "BACKEND_API_URL", Value: fmt.Sprintf("http://backend-service.%s.svc.cluster.local:8080/api", appConfig.BackendNamespace)},
 									// WebSocket URL used by runner-shell to connect back to backend
 									{Name: "WEBSOCKET_URL", Value: fmt.Sprintf("ws://backend-service.%s.svc.cluster.local:8080/api/projects/%s/sessions/%s/ws", appConfig.BackendNamespace, sessionNamespace, name)},
+									// Resolved runner configuration document (supersedes most env vars above)
+									{Name: "RUNNER_CONFIG_URL", Value: fmt.Sprintf("http://backend-service.%s.svc.cluster.local:8080/internal/runner-config/%s", appConfig.BackendNamespace, name)},
 									// S3 disabled; backend persists messages
 								}
 
@@ -495,29 +729,26 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 									base = append(base, corev1.EnvVar{Name: "CLAUDE_CODE_USE_VERTEX", Value: "0"})
 								}
 
+								// Add Bedrock configuration when the project selects it
+								if bedrockEnabled {
+									base = append(base, bedrockEnv(llmProvider)...)
+								}
+
 								// Add PARENT_SESSION_ID if this is a continuation
 								if parentSessionID != "" {
 									base = append(base, corev1.EnvVar{Name: "PARENT_SESSION_ID", Value: parentSessionID})
 									log.Printf("Session %s: passing PARENT_SESSION_ID=%s to runner", name, parentSessionID)
+								} else if preemptionRetries > 0 {
+									// Retry after node reclaim: resume this session's own SDK state from the retained PVC
+									base = append(base, corev1.EnvVar{Name: "PARENT_SESSION_ID", Value: name})
+									log.Printf("Session %s: resuming from checkpoint after preemption (retry %d)", name, preemptionRetries)
 								}
-								// If backend annotated the session with a runner token secret, inject only BOT_TOKEN
+								// Runner token Secret minted by ensureRunnerIdentity
 								// Secret contains: 'k8s-token' (for CR updates)
-								// Prefer annotated secret name; fallback to deterministic name
-								secretName := ""
-								if meta, ok := currentObj.Object["metadata"].(map[string]interface{}); ok {
-									if anns, ok := meta["annotations"].(map[string]interface{}); ok {
-										if v, ok := anns["ambient-code.io/runner-token-secret"].(string); ok && strings.TrimSpace(v) != "" {
-											secretName = strings.TrimSpace(v)
-										}
-									}
-								}
-								if secretName == "" {
-									secretName = fmt.Sprintf("ambient-runner-token-%s", name)
-								}
 								base = append(base, corev1.EnvVar{
 									Name: "BOT_TOKEN",
 									ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
-										LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+										LocalObjectReference: corev1.LocalObjectReference{Name: runnerTokenSecret},
 										Key:                  "k8s-token",
 									}},
 								})
@@ -581,6 +812,18 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 										}
 									}
 								}
+								// Branch protection is set after the CR envs so sessions cannot switch it off
+								for _, e := range []corev1.EnvVar{
+									{Name: "GIT_ALLOWED_TARGET_BRANCHES", Value: strings.Join(gitPolicy.AllowedTargetBranches, ",")},
+									{Name: "GIT_REQUIRE_PR", Value: fmt.Sprintf("%t", gitPolicy.RequirePR)},
+									{Name: "GIT_FORBID_FORCE_PUSH", Value: fmt.Sprintf("%t", gitPolicy.ForbidForcePush)},
+								} {
+									base = append(removeEnv(base, e.Name), e)
+								}
+								// The project's web allowlist is set after the CR envs so sessions cannot override it
+								if networkPolicy.Restricted() {
+									base = append(removeEnv(base, "WEB_ALLOWED_DOMAINS"), corev1.EnvVar{Name: "WEB_ALLOWED_DOMAINS", Value: strings.Join(networkPolicy.AllowedDomains, ",")})
+								}
 
 								return base
 							}(),
@@ -600,11 +843,23 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 									})
 									log.Printf("Injecting integration secrets from '%s' for session %s", integrationSecretsName, name)
 								} else {
-									log.Printf("Skipping integration secrets '%s' for session %s (not found or not configured)", integrationSecretsName, name)
+									runtimeconfig.Debugf("Skipping integration secrets '%s' for session %s (not found or not configured)", integrationSecretsName, name)
 								}
 
-								// Only inject runner secrets (ANTHROPIC_API_KEY) when Vertex is disabled
-								if !vertexEnabled && runnerSecretsName != "" {
+								// Bedrock static credentials (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY)
+								if bedrockEnabled && llmProvider.BedrockCredentialsSecret != "" {
+									sources = append(sources, corev1.EnvFromSource{
+										SecretRef: &corev1.SecretEnvSource{
+											LocalObjectReference: corev1.LocalObjectReference{Name: llmProvider.BedrockCredentialsSecret},
+										},
+									})
+									log.Printf("Injecting Bedrock credentials from '%s' for session %s", llmProvider.BedrockCredentialsSecret, name)
+								}
+
+								// Only inject runner secrets (ANTHROPIC_API_KEY) when Vertex and Bedrock are disabled
+								if bedrockEnabled && runnerSecretsName != "" {
+									log.Printf("Skipping runner secrets '%s' for session %s (Bedrock enabled)", runnerSecretsName, name)
+								} else if !vertexEnabled && runnerSecretsName != "" {
 									sources = append(sources, corev1.EnvFromSource{
 										SecretRef: &corev1.SecretEnvSource{
 											LocalObjectReference: corev1.LocalObjectReference{Name: runnerSecretsName},
@@ -649,8 +904,128 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		}
 	}
 
+	// Bedrock via IAM role: mount a projected token the AWS SDK exchanges for role credentials
+	if bedrockEnabled && llmProvider.BedrockRoleARN != "" {
+		applyBedrockWebIdentity(&job.Spec.Template.Spec, "ambient-code-runner")
+		log.Printf("Mounted web identity token for Bedrock role %s in runner container for session %s", llmProvider.BedrockRoleARN, name)
+	}
+
+	if sessionGroup != "" {
+		applySessionGroup(&job.Spec.Template.Spec, name, sessionGroup)
+	}
+
+	// Populate the workspace from spec.workspaceFrom before init-workspace and the runner
+	if warmStart != nil {
+		applyWorkspaceClone(&job.Spec.Template.Spec, name, warmStart)
+	}
+
+	// Clone repos with --reference to the project's git mirror cache once its volume exists
+	if gitPolicy.MirrorEnabled {
+		if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims(sessionNamespace).Get(context.TODO(), gitMirrorName, v1.GetOptions{}); err == nil {
+			applyGitMirror(&job.Spec.Template.Spec, "ambient-code-runner")
+		} else {
+			log.Printf("Git mirror enabled in %s but its volume is unavailable, cloning without it: %v", sessionNamespace, err)
+		}
+	}
+
+	// Runner callbacks to /internal go over mutual TLS with the session's client certificate
+	if runnerTLSSecret != "" {
+		applyRunnerTLS(&job.Spec.Template.Spec, "ambient-code-runner", runnerTLSSecret, appConfig.BackendNamespace, name)
+	}
+
+	// Short-lived GitHub App token the backend keeps fresh; replaces PATs when available
+	applyGitHubToken(&job.Spec.Template.Spec, "ambient-code-runner", name)
+
 	// Do not mount runner Secret volume; runner fetches tokens on demand
 
+	if preemptible {
+		applyPreemptibleScheduling(&job.Spec.Template.Spec, appConfig)
+		log.Printf("Session %s is preemptible, scheduling onto spot nodes", name)
+	}
+
+	// Fail now rather than leave the pod Pending when no node can ever run it
+	if runnerHardware != nil {
+		if err := hardware.Check(context.TODO(), config.K8sClient, runnerHardware); err != nil {
+			log.Printf("Session %s/%s: runner hardware unavailable: %v", sessionNamespace, name, err)
+			return updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
+				"phase":   "Failed",
+				"message": fmt.Sprintf("Requested runner hardware is unavailable: %v", err),
+			})
+		}
+		applyRunnerHardware(&job.Spec.Template.Spec, "ambient-code-runner", runnerHardware)
+		log.Printf("Session %s/%s runner hardware: %+v", sessionNamespace, name, *runnerHardware)
+	}
+
+	// Harden every container with the project's runnerSecurity, once the pod is complete
+	runnerSecurity, err := projectRunnerSecurity(context.TODO(), sessionNamespace)
+	if err == nil {
+		err = applyRunnerSecurity(context.TODO(), &job.Spec.Template.Spec, sessionNamespace, runnerSecurity)
+	}
+	if err != nil {
+		log.Printf("Session %s/%s: runner security: %v", sessionNamespace, name, err)
+		return updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
+			"phase":   "Failed",
+			"message": fmt.Sprintf("Cannot apply the project's runner security settings: %v", err),
+		})
+	}
+
+	// Resolve internal Git servers the cluster DNS cannot
+	runnerDNS, err := projectRunnerDNS(context.TODO(), sessionNamespace)
+	if err != nil {
+		log.Printf("Session %s/%s: runner DNS: %v", sessionNamespace, name, err)
+		return updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
+			"phase":   "Failed",
+			"message": fmt.Sprintf("Cannot apply the project's runner DNS settings: %v", err),
+		})
+	}
+	applyRunnerDNS(&job.Spec.Template.Spec, runnerDNS, time.Now())
+
+	// Admission, as checked by the holds above
+	if kueueQueue != "" {
+		applyKueue(job, kueueQueue, kueuePriorityClass)
+		log.Printf("Session %s/%s submitted to Kueue LocalQueue %s", sessionNamespace, name, kueueQueue)
+		if err := clearQueuedCondition(currentObj); err != nil {
+			log.Printf("Failed to clear queued condition on %s/%s: %v", sessionNamespace, name, err)
+		}
+	} else {
+		if rateKey != "" {
+			job.Labels[apiv1alpha1.RateLimitKeyLabel] = rateKey
+		}
+		// Hold the provider key and a runner slot until the Job exists
+		releaseCapacity, held, err := reserveRunnerCapacity(currentObj, rateKey, appConfig)
+		if held || err != nil {
+			return err
+		}
+		defer releaseCapacity()
+		if err := clearQueuedCondition(currentObj); err != nil {
+			log.Printf("Failed to clear queued condition on %s/%s: %v", sessionNamespace, name, err)
+		}
+	}
+
+	// Session secrets are materialized just before the Job and removed with it
+	sessionEnvSecret, err := ensureSessionEnvSecret(context.TODO(), currentObj, ownerRefs, objectMeta)
+	if err != nil {
+		log.Printf("Session %s/%s: cannot inject spec.envFromSecrets: %v", sessionNamespace, name, err)
+		return updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
+			"phase":   "Failed",
+			"message": fmt.Sprintf("Cannot inject session secrets: %v", err),
+		})
+	}
+	if sessionEnvSecret != "" {
+		applySessionEnvSecret(&job.Spec.Template.Spec, "ambient-code-runner", sessionEnvSecret)
+	}
+
+	// Pin the runner pod's egress to the allowed domains before it can start
+	if appConfig.RunnerEgressPolicy && networkPolicy != nil {
+		if err := ensureRunnerEgressPolicy(context.TODO(), sessionNamespace, name, networkPolicy, sessionRepoURLs(currentObj), appConfig, job.OwnerReferences, objectMeta); err != nil {
+			log.Printf("Session %s/%s: cannot limit runner egress: %v", sessionNamespace, name, err)
+			return updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
+				"phase":   "Failed",
+				"message": fmt.Sprintf("Cannot apply the project's network policy: %v", err),
+			})
+		}
+	}
+
 	// Update status to Creating before attempting job creation
 	if err := updateAgenticSessionStatus(sessionNamespace, name, map[string]interface{}{
 		"phase":   "Creating",
@@ -661,12 +1036,16 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	}
 
 	// Create the job
+	objectMeta.Apply(job, &job.Spec.Template)
 	createdJob, err := config.K8sClient.BatchV1().Jobs(sessionNamespace).Create(context.TODO(), job, v1.CreateOptions{})
 	if err != nil {
-		// If job already exists, this is likely a race condition from duplicate watch events - not an error
+		// Another reconcile of the session got there first; adopt or replace its Job
 		if errors.IsAlreadyExists(err) {
-			log.Printf("Job %s already exists (race condition), continuing", jobName)
-			return nil
+			existingJob, gerr := config.K8sClient.BatchV1().Jobs(sessionNamespace).Get(context.TODO(), jobName, v1.GetOptions{})
+			if gerr != nil {
+				return fmt.Errorf("job %s already exists but cannot be read: %w", jobName, gerr)
+			}
+			return reconcileExistingJob(currentObj, existingJob)
 		}
 		log.Printf("Failed to create job %s: %v", jobName, err)
 		// Update status to Error if job creation fails and resource still exists
@@ -711,36 +1090,52 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 			Type:     corev1.ServiceTypeClusterIP,
 		},
 	}
+	objectMeta.Apply(svc)
 	if _, serr := config.K8sClient.CoreV1().Services(sessionNamespace).Create(context.TODO(), svc, v1.CreateOptions{}); serr != nil && !errors.IsAlreadyExists(serr) {
 		log.Printf("Failed to create per-job content service for %s: %v", name, serr)
 	}
 
 	// Start monitoring the job
-	go monitorJob(jobName, name, sessionNamespace)
+	startJobMonitor(jobName, name, sessionNamespace)
 
 	return nil
 }
 
+// activeJobMonitors counts running monitorJob goroutines; each holds until its Job or session is gone
+var activeJobMonitors atomic.Int64
+
+// ActiveJobMonitors reports the number of runner Jobs being monitored
+func ActiveJobMonitors() int {
+	return int(activeJobMonitors.Load())
+}
+
 func monitorJob(jobName, sessionName, sessionNamespace string) {
 	log.Printf("Starting job monitoring for %s (session: %s/%s)", jobName, sessionNamespace, sessionName)
+	activeJobMonitors.Add(1)
+	defer activeJobMonitors.Add(-1)
 
 	// Main is now the content container to keep service alive
 	mainContainerName := "ambient-content"
 
 	// Track if we've verified owner references
 	ownerRefsChecked := false
+	// The pod last seen, to tell a node reclaim from a deletion once the pod is gone
+	var lastPod *corev1.Pod
 
 	for {
 		time.Sleep(5 * time.Second)
 
 		// Ensure the AgenticSession still exists
 		gvr := types.GetAgenticSessionResource()
-		if _, err := config.DynamicClient.Resource(gvr).Namespace(sessionNamespace).Get(context.TODO(), sessionName, v1.GetOptions{}); err != nil {
+		sessionObj, err := config.DynamicClient.Resource(gvr).Namespace(sessionNamespace).Get(context.TODO(), sessionName, v1.GetOptions{})
+		if err != nil {
 			if errors.IsNotFound(err) {
 				log.Printf("AgenticSession %s no longer exists, stopping job monitoring for %s", sessionName, jobName)
 				return
 			}
 			log.Printf("Error checking AgenticSession %s existence: %v", sessionName, err)
+		} else {
+			enforceCostLimit(sessionObj)
 		}
 
 		// Get Job
@@ -754,6 +1149,14 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 			continue
 		}
 
+		// Kueue holds the Job suspended until admission, and suspends it again to preempt it
+		if job.Labels[kueueQueueLabel] != "" {
+			syncKueueAdmission(sessionObj, job)
+			if isJobSuspended(job) {
+				continue
+			}
+		}
+
 		// Verify pod owner references once (diagnostic)
 		if !ownerRefsChecked && job.Status.Active > 0 {
 			pods, err := config.K8sClient.CoreV1().Pods(sessionNamespace).List(context.TODO(), v1.ListOptions{
@@ -836,6 +1239,7 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 						"phase":          "Failed",
 						"message":        failureMsg,
 						"completionTime": time.Now().Format(time.RFC3339),
+						"result":         fallbackResult(apiv1alpha1.OutcomeFailed, failureMsg),
 					})
 					// Ensure session is interactive so it can be restarted
 					_ = ensureSessionIsInteractive(sessionNamespace, sessionName)
@@ -852,6 +1256,10 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 			continue
 		}
 
+		if len(pods.Items) > 0 {
+			lastPod = &pods.Items[0]
+		}
+
 		// Check for job with no active pods (pod evicted/preempted/deleted)
 		if len(pods.Items) == 0 && job.Status.Active == 0 && job.Status.Succeeded == 0 && job.Status.Failed == 0 {
 			// Check current phase to see if this is unexpected
@@ -865,6 +1273,9 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 				}
 				// If session is Running but pod is gone, mark as Failed
 				if currentPhase == "Running" || currentPhase == "Creating" {
+					if podLostToNode(context.TODO(), lastPod) && retryPreemptedSession(currentObj, jobName) {
+						return
+					}
 					log.Printf("Job %s has no pods but session is %s, marking as Failed", jobName, currentPhase)
 					_ = updateAgenticSessionStatus(sessionNamespace, sessionName, map[string]interface{}{
 						"phase":          "Failed",
@@ -895,6 +1306,9 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 				}
 				// Only update if not already in terminal state
 				if currentPhase != "Failed" && currentPhase != "Completed" && currentPhase != "Stopped" {
+					if isPodPreempted(&pod) && retryPreemptedSession(currentObj, jobName) {
+						return
+					}
 					failureMsg := fmt.Sprintf("Pod failed: %s - %s", pod.Status.Reason, pod.Status.Message)
 					log.Printf("Job %s pod in Failed phase, updating session to Failed: %s", jobName, failureMsg)
 					_ = updateAgenticSessionStatus(sessionNamespace, sessionName, map[string]interface{}{
@@ -1015,6 +1429,7 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 					"phase":          "Completed",
 					"message":        "Runner completed successfully",
 					"completionTime": time.Now().Format(time.RFC3339),
+					"result":         fallbackResult(apiv1alpha1.OutcomeSucceeded, "Runner exited without reporting a result"),
 				})
 				// Ensure session is interactive so it can be restarted
 				_ = ensureSessionIsInteractive(sessionNamespace, sessionName)
@@ -1031,6 +1446,7 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 			_ = updateAgenticSessionStatus(sessionNamespace, sessionName, map[string]interface{}{
 				"phase":   "Failed",
 				"message": msg,
+				"result":  fallbackResult(apiv1alpha1.OutcomeFailed, msg),
 			})
 			// Ensure session is interactive so it can be restarted
 			_ = ensureSessionIsInteractive(sessionNamespace, sessionName)
@@ -1089,6 +1505,11 @@ func deleteJobAndPerJobService(namespace, jobName, sessionName string) error {
 		// Don't return error - this is a non-critical cleanup step
 	}
 
+	// The injected session secrets must not outlive the run
+	if err := deleteSessionEnvSecret(deleteCtx, namespace, sessionName); err != nil {
+		log.Printf("Failed to delete session env secret for %s/%s: %v", namespace, sessionName, err)
+	}
+
 	// NOTE: PVC is kept for all sessions and only deleted via garbage collection
 	// when the session CR is deleted. This allows sessions to be restarted.
 
@@ -1096,39 +1517,19 @@ func deleteJobAndPerJobService(namespace, jobName, sessionName string) error {
 }
 
 func updateAgenticSessionStatus(sessionNamespace, name string, statusUpdate map[string]interface{}) error {
-	gvr := types.GetAgenticSessionResource()
-
-	// Get current resource
-	obj, err := config.DynamicClient.Resource(gvr).Namespace(sessionNamespace).Get(context.TODO(), name, v1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Printf("AgenticSession %s no longer exists, skipping status update", name)
-			return nil // Don't treat this as an error - resource was deleted
-		}
-		return fmt.Errorf("failed to get AgenticSession %s: %v", name, err)
-	}
-
-	// Update status
-	if obj.Object["status"] == nil {
-		obj.Object["status"] = make(map[string]interface{})
-	}
+	return statusupdater.Patch(context.TODO(), types.GetAgenticSessionResource(), sessionNamespace, name, statusUpdate)
+}
 
-	status := obj.Object["status"].(map[string]interface{})
-	for key, value := range statusUpdate {
-		status[key] = value
+// fallbackResult builds status.result for runs that ended without the runner reporting one
+func fallbackResult(outcome apiv1alpha1.SessionOutcome, summary string) map[string]interface{} {
+	if len(summary) > apiv1alpha1.MaxResultSummaryLength {
+		summary = summary[:apiv1alpha1.MaxResultSummaryLength]
 	}
-
-	// Update the resource with retry logic
-	_, err = config.DynamicClient.Resource(gvr).Namespace(sessionNamespace).UpdateStatus(context.TODO(), obj, v1.UpdateOptions{})
+	result, err := (&apiv1alpha1.SessionResult{Outcome: outcome, Summary: summary}).ToUnstructured()
 	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Printf("AgenticSession %s was deleted during status update, skipping", name)
-			return nil // Don't treat this as an error - resource was deleted
-		}
-		return fmt.Errorf("failed to update AgenticSession status: %v", err)
+		return map[string]interface{}{"outcome": string(outcome)}
 	}
-
-	return nil
+	return result
 }
 
 // ensureSessionIsInteractive updates a session's spec to set interactive: true
@@ -1163,15 +1564,11 @@ func ensureSessionIsInteractive(sessionNamespace, name string) error {
 		return nil
 	}
 
-	// Update spec to set interactive: true
-	if err := unstructured.SetNestedField(obj.Object, true, "spec", "interactive"); err != nil {
-		return fmt.Errorf("failed to set interactive field for AgenticSession %s: %v", name, err)
-	}
-
 	log.Printf("Setting interactive: true for AgenticSession %s to allow restart", name)
 
-	// Update the resource (not UpdateStatus, since we're modifying spec)
-	_, err = config.DynamicClient.Resource(gvr).Namespace(sessionNamespace).Update(context.TODO(), obj, v1.UpdateOptions{})
+	// Merge patch the spec (no resourceVersion) so concurrent status writers cannot make this conflict
+	patch := []byte(`{"spec":{"interactive":true}}`)
+	_, err = config.DynamicClient.Resource(gvr).Namespace(sessionNamespace).Patch(context.TODO(), name, ktypes.MergePatchType, patch, v1.PatchOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			log.Printf("AgenticSession %s was deleted during spec update, skipping", name)
@@ -1200,6 +1597,9 @@ func CleanupExpiredTempContentPods() {
 		}
 
 		for _, pod := range pods.Items {
+			if !WatchScope.Allows(context.TODO(), pod.Namespace) {
+				continue
+			}
 			// Check TTL annotation
 			createdAtStr := pod.Annotations["vteam.ambient-code/created-at"]
 			ttlStr := pod.Annotations["vteam.ambient-code/ttl"]
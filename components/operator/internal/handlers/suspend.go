@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Maintenance switch: annotating the operator ConfigMap with
+//
+//	kubectl annotate configmap operator-config ambient-code.io/suspend-job-creation=true
+//
+// stops the operator from creating runner Jobs cluster-wide. Everything else keeps running
+// (status tracking, cleanup, watches), and new sessions wait Pending with Queued=True until
+// the annotation is removed or set to anything but "true". SIGHUP re-reads the ConfigMap.
+const (
+	operatorConfigMapName = "operator-config"
+	suspendAnnotation     = "ambient-code.io/suspend-job-creation"
+	// suspendedReason is the Queued condition reason of sessions held by the switch
+	suspendedReason = "JobCreationSuspended"
+)
+
+// jobCreationSuspended is the maintenance switch
+var jobCreationSuspended atomic.Bool
+
+// LoadOperatorConfig reads the maintenance switch once; main calls it before the session
+// watch starts so no Job is created before a suspension is seen
+func LoadOperatorConfig() {
+	namespace := config.LoadConfig().Namespace
+	cm, err := config.K8sClient.CoreV1().ConfigMaps(namespace).Get(context.TODO(), operatorConfigMapName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		cm, err = nil, nil
+	}
+	if err != nil {
+		log.Printf("Failed to read %s: %v", operatorConfigMapName, err)
+		return
+	}
+	applySuspendSwitch(cm)
+}
+
+// WatchOperatorConfig keeps the maintenance switch in sync with the operator ConfigMap
+func WatchOperatorConfig() {
+	namespace := config.LoadConfig().Namespace
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Printf("SIGHUP received, reloading %s", operatorConfigMapName)
+			LoadOperatorConfig()
+		}
+	}()
+
+	for {
+		watcher, err := config.K8sClient.CoreV1().ConfigMaps(namespace).Watch(context.TODO(), v1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", operatorConfigMapName).String(),
+		})
+		if err != nil {
+			log.Printf("Failed to watch %s: %v", operatorConfigMapName, err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for event := range watcher.ResultChan() {
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if cm, ok := event.Object.(*corev1.ConfigMap); ok {
+					applySuspendSwitch(cm)
+				}
+			case watch.Deleted:
+				applySuspendSwitch(nil)
+			}
+		}
+		watcher.Stop()
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// resumeQueuedSessions starts the held sessions once the switch is turned off; tests stub it
+var resumeQueuedSessions = func() { go retryQueuedSessions() }
+
+// applySuspendSwitch updates the switch from the ConfigMap (nil when it does not exist).
+// Sessions held while suspended are started as soon as it is turned off.
+func applySuspendSwitch(cm *corev1.ConfigMap) {
+	suspend := cm != nil && cm.Annotations[suspendAnnotation] == "true"
+	if jobCreationSuspended.Swap(suspend) == suspend {
+		return
+	}
+	if suspend {
+		log.Printf("Runner job creation suspended (%s=true on %s)", suspendAnnotation, operatorConfigMapName)
+		return
+	}
+	log.Printf("Runner job creation resumed")
+	resumeQueuedSessions()
+}
+
+// markSessionSuspended holds a Pending session while the maintenance switch is on. Like
+// markSessionQueued it writes only once, so retries do not retrigger the watch.
+func markSessionSuspended(session *unstructured.Unstructured) error {
+	if queuedReason(session) == suspendedReason {
+		return nil
+	}
+	msg := "Waiting for maintenance to end: runner job creation is suspended cluster-wide"
+	return setQueuedCondition(session, v1.ConditionTrue, suspendedReason, msg, msg)
+}
+
+// queuedReason is the reason of a session's Queued=True condition, or ""
+func queuedReason(session *unstructured.Unstructured) string {
+	conditions, _, _ := unstructured.NestedSlice(session.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, _ := c.(map[string]interface{})
+		if cond["type"] == queuedConditionType && cond["status"] == string(v1.ConditionTrue) {
+			reason, _ := cond["reason"].(string)
+			return reason
+		}
+	}
+	return ""
+}
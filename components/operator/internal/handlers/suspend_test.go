@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TestApplySuspendSwitch verifies only the annotation set to "true" suspends job creation
+func TestApplySuspendSwitch(t *testing.T) {
+	resumed := 0
+	defer func(orig func()) { resumeQueuedSessions = orig }(resumeQueuedSessions)
+	resumeQueuedSessions = func() { resumed++ }
+	defer jobCreationSuspended.Store(false)
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: operatorConfigMapName}}
+	cases := []struct {
+		annotations map[string]string
+		deleted     bool
+		want        bool
+	}{
+		{annotations: map[string]string{suspendAnnotation: "true"}, want: true},
+		{annotations: map[string]string{suspendAnnotation: "true", "other": "x"}, want: true},
+		{annotations: map[string]string{suspendAnnotation: "false"}, want: false},
+		{annotations: map[string]string{suspendAnnotation: "true"}, want: true},
+		{deleted: true, want: false},
+		{annotations: nil, want: false},
+	}
+	for i, tc := range cases {
+		cm.Annotations = tc.annotations
+		if tc.deleted {
+			applySuspendSwitch(nil)
+		} else {
+			applySuspendSwitch(cm)
+		}
+		if got := jobCreationSuspended.Load(); got != tc.want {
+			t.Errorf("case %d: suspended = %v, want %v", i, got, tc.want)
+		}
+	}
+	// Only the two transitions from suspended to running resume held sessions
+	if resumed != 2 {
+		t.Errorf("resumed %d times, want 2", resumed)
+	}
+}
+
+// TestQueuedReason verifies suspended and capacity-queued sessions are told apart
+func TestQueuedReason(t *testing.T) {
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{queuedCondition(metav1.ConditionTrue, suspendedReason, "maintenance")},
+		},
+	}}
+	if got := queuedReason(session); got != suspendedReason {
+		t.Errorf("queuedReason = %q, want %q", got, suspendedReason)
+	}
+	if !isSessionQueued(session) {
+		t.Error("Expected a suspended session to be retried with the queued sessions")
+	}
+	session.Object["status"].(map[string]interface{})["conditions"] = []interface{}{queuedCondition(metav1.ConditionFalse, "SlotAvailable", "go")}
+	if got := queuedReason(session); got != "" {
+		t.Errorf("queuedReason of Queued=False = %q, want empty", got)
+	}
+}
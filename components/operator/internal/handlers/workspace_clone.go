@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/services"
+	"ambient-code-operator/internal/statusupdater"
+	"ambient-code-operator/internal/types"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// workspaceClonedConditionType reports whether spec.workspaceFrom could be honoured
+const workspaceClonedConditionType = "WorkspaceCloned"
+
+// workspaceClonedMarker is written next to the session's workspace once it was populated, so
+// Job retries do not copy over the runner's changes
+const workspaceClonedMarker = ".workspace-cloned"
+
+// workspaceClone is a resolved spec.workspaceFrom
+type workspaceClone struct {
+	source    string
+	sourcePVC string
+	// volumeClone is set when the session's PVC was provisioned as a CSI clone of sourcePVC;
+	// otherwise the workspace is copied from the mounted source PVC
+	volumeClone bool
+}
+
+// resolveWorkspaceFrom validates spec.workspaceFrom. A source that cannot be cloned is not an
+// error: the session starts with an empty workspace and the reason is recorded in the
+// WorkspaceCloned condition.
+func resolveWorkspaceFrom(ctx context.Context, session *unstructured.Unstructured) *workspaceClone {
+	source, _, _ := unstructured.NestedString(session.Object, "spec", "workspaceFrom")
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil
+	}
+	name := session.GetName()
+	namespace := session.GetNamespace()
+	skip := func(reason, message string) *workspaceClone {
+		log.Printf("Session %s/%s: not cloning workspace: %s", namespace, name, message)
+		recordWorkspaceCloned(namespace, name, v1.ConditionFalse, reason, message)
+		return nil
+	}
+
+	if source == name {
+		return skip("InvalidSource", "a session cannot clone its own workspace")
+	}
+	src, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).Get(ctx, source, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return skip("SourceNotFound", fmt.Sprintf("session %s does not exist", source))
+		}
+		return skip("SourceUnavailable", fmt.Sprintf("failed to read session %s: %v", source, err))
+	}
+	// The workspace volume is ReadWriteOnce and still changing while the source runs
+	phase, _, _ := unstructured.NestedString(src.Object, "status", "phase")
+	switch phase {
+	case "Completed", "Failed", "Stopped", "Error":
+	default:
+		return skip("SourceInUse", fmt.Sprintf("session %s has not finished (phase %q)", source, phase))
+	}
+	for _, pvc := range sourceWorkspacePVCs(src) {
+		if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvc, v1.GetOptions{}); err == nil {
+			return &workspaceClone{source: source, sourcePVC: pvc}
+		}
+	}
+	return skip("SourceWorkspaceMissing", fmt.Sprintf("the workspace volume of session %s no longer exists", source))
+}
+
+// sourceWorkspacePVCs lists where a session's workspace may live: continuations run on their
+// parent's PVC, other sessions (and continuations whose parent PVC was gone) on their own
+func sourceWorkspacePVCs(session *unstructured.Unstructured) []string {
+	pvcs := []string{}
+	if parent := strings.TrimSpace(session.GetAnnotations()["vteam.ambient-code/parent-session-id"]); parent != "" {
+		pvcs = append(pvcs, fmt.Sprintf("ambient-workspace-%s", parent))
+	}
+	return append(pvcs, fmt.Sprintf("ambient-workspace-%s", session.GetName()))
+}
+
+// ensureClonedWorkspacePVC provisions the session's PVC for a warm start. With the
+// volume-clone strategy the PVC is a CSI clone of the source; if it cannot be created the
+// workspace is copied instead. A required storageClass rules out cloning a source volume of
+// another class, since the clone would keep the source's class.
+func ensureClonedWorkspacePVC(namespace, pvcName, storageClass string, ownerRefs []v1.OwnerReference, meta apiv1alpha1.ObjectMetadata, clone *workspaceClone, strategy string) error {
+	if strategy == config.WorkspaceCloneVolume {
+		if storageClass != "" && checkWorkspaceVolumeClass(context.TODO(), namespace, clone.sourcePVC, storageClass) != nil {
+			log.Printf("Not cloning PVC %s into %s: it is not of the encrypted StorageClass %s, copying the workspace instead", clone.sourcePVC, pvcName, storageClass)
+		} else if err := services.EnsureClonedWorkspacePVC(namespace, pvcName, clone.sourcePVC, ownerRefs, meta); err != nil {
+			log.Printf("Failed to clone PVC %s into %s, copying the workspace instead: %v", clone.sourcePVC, pvcName, err)
+		}
+	}
+	if err := services.EnsureSessionWorkspacePVC(namespace, pvcName, storageClass, ownerRefs, meta); err != nil {
+		return err
+	}
+	// Decide from the PVC itself so a retried Job matches how the PVC was first provisioned
+	pvc, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), pvcName, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	ds := pvc.Spec.DataSource
+	clone.volumeClone = ds != nil && ds.Kind == "PersistentVolumeClaim" && ds.Name == clone.sourcePVC
+	return nil
+}
+
+// applyWorkspaceClone adds the init container that moves (volume clone) or copies the source
+// session's workspace into this session's workspace before the runner starts. It runs once:
+// the marker file keeps Job retries from overwriting the runner's work.
+func applyWorkspaceClone(podSpec *corev1.PodSpec, session string, clone *workspaceClone) {
+	sessionDir := fmt.Sprintf("/workspace/sessions/%s", session)
+	var populate string
+	mounts := []corev1.VolumeMount{{Name: "workspace", MountPath: "/workspace"}}
+	if clone.volumeClone {
+		// The cloned volume holds the source session's directory tree
+		populate = fmt.Sprintf(`if [ -d /workspace/sessions/%[1]s/workspace ] && [ ! -d %[2]s/workspace ]; then mv /workspace/sessions/%[1]s/workspace %[2]s/workspace; fi`, clone.source, sessionDir)
+	} else {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "workspace-source",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: clone.sourcePVC,
+				ReadOnly:  true,
+			}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: "workspace-source", MountPath: "/source", ReadOnly: true})
+		populate = fmt.Sprintf(`mkdir -p %[2]s/workspace && if [ -d /source/sessions/%[1]s/workspace ]; then cp -a /source/sessions/%[1]s/workspace/. %[2]s/workspace/; fi`, clone.source, sessionDir)
+	}
+	script := fmt.Sprintf(`set -e
+if [ -e %[1]s/%[2]s ]; then echo 'Workspace already cloned'; exit 0; fi
+mkdir -p %[1]s
+%[3]s
+touch %[1]s/%[2]s
+echo 'Workspace cloned from session %[4]s'`, sessionDir, workspaceClonedMarker, populate, clone.source)
+
+	initContainer := corev1.Container{
+		Name:         "clone-workspace",
+		Image:        workspaceInitImage,
+		Command:      []string{"sh", "-c", script},
+		VolumeMounts: mounts,
+	}
+	podSpec.InitContainers = append([]corev1.Container{initContainer}, podSpec.InitContainers...)
+}
+
+// recordWorkspaceCloned sets the WorkspaceCloned condition; failures are logged only
+func recordWorkspaceCloned(namespace, name string, status v1.ConditionStatus, reason, message string) {
+	err := statusupdater.Mutate(context.TODO(), types.GetAgenticSessionResource(), namespace, name, func(st map[string]interface{}) error {
+		if !setSessionCondition(st, sessionCondition(workspaceClonedConditionType, status, reason, message)) {
+			return statusupdater.ErrNoChange
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to record %s condition for %s/%s: %v", workspaceClonedConditionType, namespace, name, err)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func testSession(name, phase string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "proj"},
+		"spec":       spec,
+		"status":     map[string]interface{}{"phase": phase},
+	}}
+}
+
+// TestResolveWorkspaceFrom verifies only finished sessions with a workspace volume are cloned
+func TestResolveWorkspaceFrom(t *testing.T) {
+	setupTestClient(&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "ambient-workspace-done", Namespace: "proj"}})
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		types.GetAgenticSessionResource(): "AgenticSessionList",
+	}, testSession("done", "Completed", nil), testSession("busy", "Running", nil), testSession("gone", "Completed", nil))
+	ctx := context.Background()
+
+	clone := resolveWorkspaceFrom(ctx, testSession("new", "Pending", map[string]interface{}{"workspaceFrom": "done"}))
+	if clone == nil || clone.sourcePVC != "ambient-workspace-done" {
+		t.Fatalf("expected clone from ambient-workspace-done, got %+v", clone)
+	}
+	for _, source := range []string{"busy", "gone", "missing", "new"} {
+		if clone := resolveWorkspaceFrom(ctx, testSession("new", "Pending", map[string]interface{}{"workspaceFrom": source})); clone != nil {
+			t.Errorf("workspaceFrom=%s should not be cloned, got %+v", source, clone)
+		}
+	}
+	if clone := resolveWorkspaceFrom(ctx, testSession("new", "Pending", nil)); clone != nil {
+		t.Errorf("session without workspaceFrom should not be cloned")
+	}
+}
+
+// TestApplyWorkspaceClone_Copy verifies the copy runs first and mounts the source read-only
+func TestApplyWorkspaceClone_Copy(t *testing.T) {
+	podSpec := corev1.PodSpec{InitContainers: []corev1.Container{{Name: "init-workspace"}}}
+	applyWorkspaceClone(&podSpec, "new", &workspaceClone{source: "done", sourcePVC: "ambient-workspace-done"})
+
+	if len(podSpec.InitContainers) != 2 || podSpec.InitContainers[0].Name != "clone-workspace" {
+		t.Fatalf("clone-workspace must run before init-workspace: %v", podSpec.InitContainers)
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].PersistentVolumeClaim == nil ||
+		podSpec.Volumes[0].PersistentVolumeClaim.ClaimName != "ambient-workspace-done" || !podSpec.Volumes[0].PersistentVolumeClaim.ReadOnly {
+		t.Errorf("expected read-only source volume, got %v", podSpec.Volumes)
+	}
+	script := podSpec.InitContainers[0].Command[2]
+	if !strings.Contains(script, "cp -a /source/sessions/done/workspace/. /workspace/sessions/new/workspace/") {
+		t.Errorf("unexpected copy script: %s", script)
+	}
+}
+
+// TestApplyWorkspaceClone_Volume verifies a cloned volume is rearranged in place
+func TestApplyWorkspaceClone_Volume(t *testing.T) {
+	podSpec := corev1.PodSpec{}
+	applyWorkspaceClone(&podSpec, "new", &workspaceClone{source: "done", sourcePVC: "ambient-workspace-done", volumeClone: true})
+
+	if len(podSpec.Volumes) != 0 {
+		t.Errorf("volume clones must not mount the source PVC: %v", podSpec.Volumes)
+	}
+	script := podSpec.InitContainers[0].Command[2]
+	if !strings.Contains(script, "mv /workspace/sessions/done/workspace /workspace/sessions/new/workspace") {
+		t.Errorf("unexpected clone script: %s", script)
+	}
+}
+
+// TestSourceWorkspacePVCs verifies continuations are looked up on their parent's PVC first
+func TestSourceWorkspacePVCs(t *testing.T) {
+	src := testSession("child", "Completed", nil)
+	src.SetAnnotations(map[string]string{"vteam.ambient-code/parent-session-id": "parent"})
+	got := sourceWorkspacePVCs(src)
+	if len(got) != 2 || got[0] != "ambient-workspace-parent" || got[1] != "ambient-workspace-child" {
+		t.Errorf("unexpected PVC candidates: %v", got)
+	}
+}
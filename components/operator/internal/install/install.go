@@ -0,0 +1,170 @@
+// Package install bootstraps the platform from the manifests embedded in the operator binary:
+// the platform namespace, the CRDs, the RBAC of the platform components and the default
+// ProjectSettings of existing project namespaces. It backs `operator install`.
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"ambient-code-operator/internal/assets"
+	"ambient-code-operator/internal/crds"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// manifestNamespace is the namespace the embedded manifests are written for
+const manifestNamespace = "ambient-code"
+
+// resources maps the kinds in the embedded manifests to their API resources
+var resources = map[string]string{
+	"Namespace":                "namespaces",
+	"ServiceAccount":           "serviceaccounts",
+	"ClusterRole":              "clusterroles",
+	"ClusterRoleBinding":       "clusterrolebindings",
+	"CustomResourceDefinition": "customresourcedefinitions",
+	"ProjectSettings":          "projectsettings",
+}
+
+// keepExisting are kinds an install creates but never overwrites: other controllers and
+// users add to namespaces and service accounts, and ProjectSettings belong to the project
+var keepExisting = map[string]bool{
+	"Namespace":       true,
+	"ServiceAccount":  true,
+	"ProjectSettings": true,
+}
+
+// Objects returns what an install applies, in order: the platform namespace, the CRDs, the
+// RBAC with its service accounts moved to namespace, and the default ProjectSettings of each
+// project
+func Objects(namespace string, projects []string) ([]*unstructured.Unstructured, error) {
+	manifests := assets.Manifests()
+	nsObjs, err := assets.DecodeDir(manifests, ".")
+	if err != nil {
+		return nil, err
+	}
+	crdObjs, err := crds.Embedded()
+	if err != nil {
+		return nil, err
+	}
+	rbacObjs, err := assets.DecodeDir(manifests, "rbac")
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*unstructured.Unstructured
+	for _, obj := range nsObjs {
+		if obj.GetKind() == "Namespace" {
+			obj.SetName(namespace)
+			labels := obj.GetLabels()
+			if labels["name"] != "" {
+				labels["name"] = namespace
+				obj.SetLabels(labels)
+			}
+			out = append(out, obj)
+		}
+	}
+	out = append(out, crdObjs...)
+	for _, obj := range rbacObjs {
+		if obj.GetNamespace() == manifestNamespace {
+			obj.SetNamespace(namespace)
+		}
+		if subjects, ok := obj.Object["subjects"].([]interface{}); ok {
+			for _, item := range subjects {
+				if subject, ok := item.(map[string]interface{}); ok && subject["namespace"] == manifestNamespace {
+					subject["namespace"] = namespace
+				}
+			}
+		}
+		out = append(out, obj)
+	}
+	for _, project := range projects {
+		ps, err := assets.DefaultProjectSettings(project)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ps)
+	}
+	for _, obj := range out {
+		if _, ok := resources[obj.GetKind()]; !ok {
+			return nil, fmt.Errorf("embedded manifest %s %s has a kind install does not know", obj.GetKind(), obj.GetName())
+		}
+	}
+	return out, nil
+}
+
+// Print writes the objects as a multi-document YAML stream kubectl apply -f accepts
+func Print(w io.Writer, objs []*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply creates the objects, or brings existing ones up to date, reporting each on w like
+// kubectl apply. CRDs are upgraded by schema revision and waited on until established.
+func Apply(ctx context.Context, client dynamic.Interface, objs []*unstructured.Unstructured, w io.Writer) error {
+	for _, obj := range objs {
+		ref := fmt.Sprintf("%s/%s", resources[obj.GetKind()], obj.GetName())
+		if obj.GetNamespace() != "" {
+			ref = fmt.Sprintf("%s (namespace %s)", ref, obj.GetNamespace())
+		}
+		if obj.GetKind() == "CustomResourceDefinition" {
+			if err := crds.SyncObjects(ctx, client, []*unstructured.Unstructured{obj}, true); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s synced\n", ref)
+			continue
+		}
+		result, err := apply(ctx, client, obj)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		fmt.Fprintf(w, "%s %s\n", ref, result)
+	}
+	return nil
+}
+
+func apply(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured) (string, error) {
+	gv, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil {
+		return "", err
+	}
+	gvr := gv.WithResource(resources[obj.GetKind()])
+	var resource dynamic.ResourceInterface = client.Resource(gvr)
+	if ns := obj.GetNamespace(); ns != "" {
+		resource = client.Resource(gvr).Namespace(ns)
+	}
+
+	existing, err := resource.Get(ctx, obj.GetName(), v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := resource.Create(ctx, obj, v1.CreateOptions{}); err != nil {
+			return "", err
+		}
+		return "created", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if keepExisting[obj.GetKind()] {
+		return "unchanged", nil
+	}
+	updated := obj.DeepCopy()
+	updated.SetResourceVersion(existing.GetResourceVersion())
+	if _, err := resource.Update(ctx, updated, v1.UpdateOptions{}); err != nil {
+		return "", err
+	}
+	return "configured", nil
+}
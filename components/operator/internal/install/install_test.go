@@ -0,0 +1,103 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"ambient-code-operator/internal/crds"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestObjects verifies the install moves the platform to the chosen namespace and adds the
+// default ProjectSettings of each project after the CRDs
+func TestObjects(t *testing.T) {
+	objs, err := Objects("vteam", []string{"team-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objs[0].GetKind() != "Namespace" || objs[0].GetName() != "vteam" {
+		t.Errorf("first object = %s %s, want Namespace vteam", objs[0].GetKind(), objs[0].GetName())
+	}
+	last := objs[len(objs)-1]
+	if last.GetKind() != "ProjectSettings" || last.GetNamespace() != "team-a" {
+		t.Errorf("last object = %s %s/%s", last.GetKind(), last.GetNamespace(), last.GetName())
+	}
+	var out bytes.Buffer
+	if err := Print(&out, objs); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "namespace: "+manifestNamespace) {
+		t.Error("printed manifests still refer to the ambient-code namespace")
+	}
+	if n := strings.Count(out.String(), "kind: CustomResourceDefinition"); n != 6 {
+		t.Errorf("%d CRDs printed, want 6", n)
+	}
+}
+
+// TestApply verifies missing objects are created, cluster roles brought up to date, and an
+// existing ProjectSettings left as the project configured it
+func TestApply(t *testing.T) {
+	embedded, err := crds.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := []runtime.Object{}
+	for _, crd := range embedded {
+		// Installed at the same revision, so the CRD step has nothing to wait for
+		seed = append(seed, crd.DeepCopy())
+	}
+	ps := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "ProjectSettings",
+		"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": "team-a"},
+		"spec":       map[string]interface{}{"groupAccess": []interface{}{map[string]interface{}{"groupName": "devs", "role": "edit"}}},
+	}}
+	listKinds := map[schema.GroupVersionResource]string{}
+	for kind, resource := range resources {
+		gv := schema.GroupVersion{Version: "v1"}
+		switch kind {
+		case "ClusterRole", "ClusterRoleBinding":
+			gv.Group = "rbac.authorization.k8s.io"
+		case "CustomResourceDefinition":
+			gv.Group = "apiextensions.k8s.io"
+		case "ProjectSettings":
+			gv = schema.GroupVersion{Group: "vteam.ambient-code", Version: "v1alpha1"}
+		}
+		listKinds[gv.WithResource(resource)] = kind + "List"
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, seed...)
+	psResource := client.Resource(schema.GroupVersionResource{Group: "vteam.ambient-code", Version: "v1alpha1", Resource: "projectsettings"}).Namespace("team-a")
+	if _, err := psResource.Create(context.Background(), ps, v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	objs, err := Objects("vteam", []string{"team-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Apply(context.Background(), client, objs, &out); err != nil {
+		t.Fatalf("Apply: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"namespaces/vteam created", "clusterroles/agentic-operator created", "serviceaccounts/agentic-operator (namespace vteam) created", "projectsettings/projectsettings (namespace team-a) unchanged"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	got, _ := psResource.Get(context.Background(), "projectsettings", v1.GetOptions{})
+	if access, _, _ := unstructured.NestedSlice(got.Object, "spec", "groupAccess"); len(access) != 1 {
+		t.Errorf("existing ProjectSettings overwritten: %v", got.Object["spec"])
+	}
+
+	out.Reset()
+	if err := Apply(context.Background(), client, objs, &out); err != nil || !strings.Contains(out.String(), "clusterroles/agentic-operator configured") {
+		t.Errorf("second Apply: %v\n%s", err, out.String())
+	}
+}
@@ -0,0 +1,236 @@
+// Package runnertls issues the certificates for mutual TLS between runner pods and the
+// backend's internal endpoints (runner config, artifact uploads).
+//
+// The operator keeps a private CA in a Secret in its own namespace, issues the backend a
+// server certificate for backend-service, and gives every runner Job a short-lived client
+// certificate whose common name binds it to one session. The backend only accepts internal
+// calls over TLS from certificates signed by this CA.
+package runnertls
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CASecretName holds the runner CA (tls.crt, tls.key) in the operator namespace
+	CASecretName = "ambient-runner-ca"
+	// BackendSecretName holds the backend's internal listener certificate (tls.crt, tls.key,
+	// ca.crt) in the backend namespace; the backend Deployment mounts it
+	BackendSecretName = "ambient-backend-internal-tls"
+
+	// CAKey is the key of the CA certificate in issued Secrets
+	CAKey = "ca.crt"
+
+	// ClientCommonNamePrefix starts the common name of runner client certificates:
+	// "ambient-session:<namespace>:<session>"
+	ClientCommonNamePrefix = "ambient-session:"
+
+	caValidity     = 10 * 365 * 24 * time.Hour
+	serverValidity = 365 * 24 * time.Hour
+	// ClientValidity outlasts the runner Job's 4h deadline; every Job start issues a new one
+	ClientValidity = 24 * time.Hour
+	// serverRenewBefore renews the backend certificate this long before it expires
+	serverRenewBefore = 30 * 24 * time.Hour
+)
+
+// CA signs runner client and backend server certificates
+type CA struct {
+	Cert    *x509.Certificate
+	CertPEM []byte
+	key     crypto.Signer
+}
+
+// ClientCommonName is the certificate common name binding a runner to its session
+func ClientCommonName(namespace, session string) string {
+	return ClientCommonNamePrefix + namespace + ":" + session
+}
+
+// EnsureCA loads the runner CA from its Secret in namespace, creating it on first start
+func EnsureCA(ctx context.Context, client kubernetes.Interface, namespace string) (*CA, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, CASecretName, v1.GetOptions{})
+	if err == nil {
+		return parseCA(secret)
+	}
+	if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", CASecretName, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ambient-runner-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certPEM, err := sign(tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: CASecretName, Namespace: namespace, Labels: map[string]string{"app": "ambient-runner-ca"}},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+	}
+	if _, err := client.CoreV1().Secrets(namespace).Create(ctx, secret, v1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			// Another operator instance won the race; use its CA
+			return EnsureCA(ctx, client, namespace)
+		}
+		return nil, fmt.Errorf("failed to create %s: %w", CASecretName, err)
+	}
+	return parseCA(secret)
+}
+
+func parseCA(secret *corev1.Secret) (*CA, error) {
+	certBlock, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	keyBlock, _ := pem.Decode(secret.Data[corev1.TLSPrivateKeyKey])
+	if certBlock == nil || keyBlock == nil {
+		return nil, fmt.Errorf("secret %s does not contain a PEM certificate and key", secret.Name)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate in %s: %w", secret.Name, err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA key in %s: %w", secret.Name, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("CA key in %s cannot sign", secret.Name)
+	}
+	return &CA{Cert: cert, CertPEM: secret.Data[corev1.TLSCertKey], key: signer}, nil
+}
+
+// EnsureBackendCert keeps the backend's server certificate Secret current: it is (re)issued
+// when missing, close to expiry or signed by a different CA.
+func (ca *CA) EnsureBackendCert(ctx context.Context, client kubernetes.Interface, backendNamespace string) error {
+	secrets := client.CoreV1().Secrets(backendNamespace)
+	existing, err := secrets.Get(ctx, BackendSecretName, v1.GetOptions{})
+	found := err == nil
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to read %s: %w", BackendSecretName, err)
+	}
+	if found && ca.stillValid(existing, serverRenewBefore) {
+		return nil
+	}
+
+	dnsNames := []string{
+		"backend-service",
+		fmt.Sprintf("backend-service.%s", backendNamespace),
+		fmt.Sprintf("backend-service.%s.svc", backendNamespace),
+		fmt.Sprintf("backend-service.%s.svc.cluster.local", backendNamespace),
+	}
+	certPEM, keyPEM, err := ca.issue(pkix.Name{CommonName: dnsNames[3]}, dnsNames, x509.ExtKeyUsageServerAuth, serverValidity)
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: BackendSecretName, Namespace: backendNamespace, Labels: map[string]string{"app": "backend-api"}},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM, CAKey: ca.CertPEM},
+	}
+	if found {
+		secret.ResourceVersion = existing.ResourceVersion
+		_, err = secrets.Update(ctx, secret, v1.UpdateOptions{})
+	} else {
+		_, err = secrets.Create(ctx, secret, v1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", BackendSecretName, err)
+	}
+	return nil
+}
+
+// stillValid reports whether the Secret's certificate was signed by this CA and is valid for
+// at least renewBefore
+func (ca *CA) stillValid(secret *corev1.Secret, renewBefore time.Duration) bool {
+	if !bytes.Equal(secret.Data[CAKey], ca.CertPEM) {
+		return false
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return time.Until(cert.NotAfter) > renewBefore
+}
+
+// IssueClientCert issues a runner client certificate for one session
+func (ca *CA) IssueClientCert(namespace, session string) (certPEM, keyPEM []byte, err error) {
+	return ca.issue(pkix.Name{CommonName: ClientCommonName(namespace, session)}, nil, x509.ExtKeyUsageClientAuth, ClientValidity)
+}
+
+func (ca *CA) issue(subject pkix.Name, dnsNames []string, usage x509.ExtKeyUsage, validity time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := &x509.Certificate{
+		Subject:     subject,
+		DNSNames:    dnsNames,
+		NotBefore:   time.Now().Add(-5 * time.Minute),
+		NotAfter:    time.Now().Add(validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	}
+	certPEM, err := sign(tmpl, ca.Cert, key.Public(), ca.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certPEM, keyPEM, nil
+}
+
+func sign(tmpl, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	tmpl.SerialNumber = serial
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate for %s: %w", strings.TrimSpace(tmpl.Subject.CommonName), err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,107 @@
+package runnertls
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func parseCert(t *testing.T, data []byte) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("no PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return cert
+}
+
+// TestEnsureCA_Reused verifies the CA is created once and loaded from its Secret afterwards
+func TestEnsureCA_Reused(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.Background()
+
+	first, err := EnsureCA(ctx, client, "ambient-code")
+	if err != nil {
+		t.Fatalf("EnsureCA: %v", err)
+	}
+	second, err := EnsureCA(ctx, client, "ambient-code")
+	if err != nil {
+		t.Fatalf("EnsureCA (reload): %v", err)
+	}
+	if !first.Cert.Equal(second.Cert) {
+		t.Errorf("CA was regenerated instead of reused")
+	}
+}
+
+// TestClientCertVerifiesForSession verifies issued client certificates chain to the CA and
+// carry the session binding
+func TestClientCertVerifiesForSession(t *testing.T) {
+	ca, err := EnsureCA(context.Background(), fake.NewSimpleClientset(), "ambient-code")
+	if err != nil {
+		t.Fatalf("EnsureCA: %v", err)
+	}
+	certPEM, _, err := ca.IssueClientCert("proj", "sess-1")
+	if err != nil {
+		t.Fatalf("IssueClientCert: %v", err)
+	}
+	cert := parseCert(t, certPEM)
+	if cert.Subject.CommonName != "ambient-session:proj:sess-1" {
+		t.Errorf("unexpected common name %q", cert.Subject.CommonName)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Errorf("client certificate does not verify: %v", err)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}); err == nil {
+		t.Errorf("client certificate must not be usable as a server certificate")
+	}
+}
+
+// TestEnsureBackendCert_RenewsOnCAChange verifies the backend certificate is kept while valid
+// and reissued when the CA changes
+func TestEnsureBackendCert_RenewsOnCAChange(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.Background()
+	ca, err := EnsureCA(ctx, client, "ambient-code")
+	if err != nil {
+		t.Fatalf("EnsureCA: %v", err)
+	}
+	if err := ca.EnsureBackendCert(ctx, client, "ambient-code"); err != nil {
+		t.Fatalf("EnsureBackendCert: %v", err)
+	}
+	secret, _ := client.CoreV1().Secrets("ambient-code").Get(ctx, BackendSecretName, metav1.GetOptions{})
+	cert := parseCert(t, secret.Data[corev1.TLSCertKey])
+	if err := cert.VerifyHostname("backend-service.ambient-code.svc.cluster.local"); err != nil {
+		t.Errorf("backend certificate: %v", err)
+	}
+
+	if err := ca.EnsureBackendCert(ctx, client, "ambient-code"); err != nil {
+		t.Fatalf("EnsureBackendCert (second): %v", err)
+	}
+	again, _ := client.CoreV1().Secrets("ambient-code").Get(ctx, BackendSecretName, metav1.GetOptions{})
+	if string(again.Data[corev1.TLSCertKey]) != string(secret.Data[corev1.TLSCertKey]) {
+		t.Errorf("valid backend certificate was reissued")
+	}
+
+	otherCA, err := EnsureCA(ctx, fake.NewSimpleClientset(), "other")
+	if err != nil {
+		t.Fatalf("EnsureCA (other): %v", err)
+	}
+	if err := otherCA.EnsureBackendCert(ctx, client, "ambient-code"); err != nil {
+		t.Fatalf("EnsureBackendCert (new CA): %v", err)
+	}
+	rotated, _ := client.CoreV1().Secrets("ambient-code").Get(ctx, BackendSecretName, metav1.GetOptions{})
+	if string(rotated.Data[CAKey]) != string(otherCA.CertPEM) {
+		t.Errorf("backend certificate was not reissued for the new CA")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 
 	"ambient-code-operator/internal/config"
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -51,8 +52,9 @@ func EnsureContentService(namespace string) error {
 	return nil
 }
 
-// EnsureSessionWorkspacePVC creates a per-session PVC owned by the AgenticSession to avoid multi-attach conflicts
-func EnsureSessionWorkspacePVC(namespace, pvcName string, ownerRefs []v1.OwnerReference) error {
+// EnsureSessionWorkspacePVC creates a per-session PVC owned by the AgenticSession to avoid multi-attach conflicts.
+// An empty storageClass uses the cluster default; meta is the project's objectMetadata policy.
+func EnsureSessionWorkspacePVC(namespace, pvcName, storageClass string, ownerRefs []v1.OwnerReference, meta apiv1alpha1.ObjectMetadata) error {
 	// Check if PVC exists
 	if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), pvcName, v1.GetOptions{}); err == nil {
 		return nil
@@ -76,6 +78,55 @@ func EnsureSessionWorkspacePVC(namespace, pvcName string, ownerRefs []v1.OwnerRe
 			},
 		},
 	}
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	meta.Apply(pvc)
+	if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Create(context.TODO(), pvc, v1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// EnsureClonedWorkspacePVC creates a per-session PVC as a CSI volume clone of sourcePVC. The
+// clone keeps the source's storage class and is at least as large as the source.
+func EnsureClonedWorkspacePVC(namespace, pvcName, sourcePVC string, ownerRefs []v1.OwnerReference, meta apiv1alpha1.ObjectMetadata) error {
+	if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), pvcName, v1.GetOptions{}); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	source, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), sourcePVC, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	size := resource.MustParse("5Gi")
+	if req, ok := source.Spec.Resources.Requests[corev1.ResourceStorage]; ok && req.Cmp(size) > 0 {
+		size = req
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: v1.ObjectMeta{
+			Name:            pvcName,
+			Namespace:       namespace,
+			Labels:          map[string]string{"app": "ambient-workspace", "agentic-session": pvcName},
+			OwnerReferences: ownerRefs,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: source.Spec.StorageClassName,
+			DataSource:       &corev1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: sourcePVC},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	meta.Apply(pvc)
 	if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Create(context.TODO(), pvc, v1.CreateOptions{}); err != nil {
 		if errors.IsAlreadyExists(err) {
 			return nil
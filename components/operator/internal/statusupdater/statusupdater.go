@@ -0,0 +1,99 @@
+// Package statusupdater provides conflict-safe status writes for the operator's custom resources.
+//
+// Busy namespaces run several reconciles and job monitors against the same object. A plain
+// Get+UpdateStatus loses the race whenever another writer bumps the resourceVersion in between,
+// which shows up as floods of "the object has been modified" errors. All operator status writes
+// go through this package instead.
+package statusupdater
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-pkg/redact"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+// Backoff is used when retrying writes that hit a conflict
+var Backoff = wait.Backoff{
+	Steps:    10,
+	Duration: 10 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.5,
+	Cap:      2 * time.Second,
+}
+
+// ErrNoChange may be returned by a Mutate callback to skip the write when the status is
+// already up to date. Mutate then returns nil.
+var ErrNoChange = stderrors.New("status unchanged")
+
+// Patch merges fields into .status of the named object using a JSON merge patch on the
+// status subresource. Merge patches carry no resourceVersion, so concurrent writers touching
+// different fields do not conflict; conflicts raised by the API server are retried.
+// A missing object is not an error (it was deleted while we were working).
+func Patch(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	// Status is readable by every project viewer; job and git errors quoted in messages must
+	// not carry credentials into it
+	data, err := json.Marshal(map[string]interface{}{"status": redact.Map(fields)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal status patch for %s/%s: %w", namespace, name, err)
+	}
+
+	err = retry.OnError(Backoff, errors.IsConflict, func() error {
+		_, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Patch(ctx, name, ktypes.MergePatchType, data, v1.PatchOptions{}, "status")
+		return err
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to patch status of %s %s/%s: %w", gvr.Resource, namespace, name, err)
+	}
+	return nil
+}
+
+// Mutate performs a read-modify-write of .status for updates that depend on the current value
+// (counters, list appends). fn receives the current status map (never nil) and may modify it in
+// place. The write uses the object's resourceVersion and is retried from a fresh read on conflict.
+// A missing object is not an error.
+func Mutate(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, fn func(status map[string]interface{}) error) error {
+	err := retry.OnError(Backoff, errors.IsConflict, func() error {
+		obj, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		status, ok := obj.Object["status"].(map[string]interface{})
+		if !ok || status == nil {
+			status = map[string]interface{}{}
+		}
+		if err := fn(status); err != nil {
+			if stderrors.Is(err, ErrNoChange) {
+				return nil
+			}
+			return err
+		}
+		obj.Object["status"] = redact.Map(status)
+		_, err = config.DynamicClient.Resource(gvr).Namespace(namespace).UpdateStatus(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update status of %s %s/%s: %w", gvr.Resource, namespace, name, err)
+	}
+	return nil
+}
@@ -1,7 +1,12 @@
 // Package types defines GVR (GroupVersionResource) definitions and resource helpers for custom resources.
 package types
 
-import "k8s.io/apimachinery/pkg/runtime/schema"
+import (
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/crdcheck"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
 
 const (
 	// AmbientVertexSecretName is the name of the secret containing Vertex AI credentials
@@ -13,18 +18,31 @@ const (
 
 // GetAgenticSessionResource returns the GroupVersionResource for AgenticSession
 func GetAgenticSessionResource() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "vteam.ambient-code",
-		Version:  "v1alpha1",
-		Resource: "agenticsessions",
-	}
+	return apiv1alpha1.AgenticSessionGVR()
 }
 
 // GetProjectSettingsResource returns the GroupVersionResource for ProjectSettings
 func GetProjectSettingsResource() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "vteam.ambient-code",
-		Version:  "v1alpha1",
-		Resource: "projectsettings",
-	}
+	return apiv1alpha1.ProjectSettingsGVR()
+}
+
+// GetSecretDistributionResource returns the GroupVersionResource for SecretDistribution
+func GetSecretDistributionResource() schema.GroupVersionResource {
+	return apiv1alpha1.SecretDistributionGVR()
+}
+
+// GetSessionBatchResource returns the GroupVersionResource for SessionBatch
+func GetSessionBatchResource() schema.GroupVersionResource {
+	return apiv1alpha1.SessionBatchGVR()
+}
+
+// GetPreviewResource returns the GroupVersionResource for Preview
+func GetPreviewResource() schema.GroupVersionResource {
+	return apiv1alpha1.PreviewGVR()
+}
+
+// VerifyCRDsInstalled checks that the cluster serves every custom resource the operator
+// watches. A missing CRD or version is reported as a *crdcheck.NotInstalledError.
+func VerifyCRDsInstalled(client discovery.DiscoveryInterface) error {
+	return crdcheck.Verify(client, GetAgenticSessionResource(), GetProjectSettingsResource())
 }
@@ -0,0 +1,151 @@
+// Package webhook serves the validating admission webhook for AgenticSessions.
+//
+// Sessions created through the backend API are validated there; sessions created with
+// kubectl or GitOps reach the cluster directly. The webhook applies the same rules
+// (ambient-code-pkg/validation) at admission, so an invalid manifest is rejected with the
+// failing field paths instead of being adopted and failed later. Updates are only checked
+// when the spec changes; status writes go to the status subresource and are not sent here.
+package webhook
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/validation"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateSessionsPath is the path the ValidatingWebhookConfiguration calls
+const ValidateSessionsPath = "/validate-agenticsessions"
+
+// maxReviewBytes bounds an AdmissionReview body; the API server sends at most a few MiB
+const maxReviewBytes = 8 << 20
+
+// Serve serves the webhook over TLS on addr with tls.crt and tls.key from certDir, which are
+// re-read when they change (service-ca and cert-manager rotate them in place)
+func Serve(addr, certDir string) error {
+	certs := &certCache{certFile: filepath.Join(certDir, "tls.crt"), keyFile: filepath.Join(certDir, "tls.key")}
+	if _, err := certs.get(nil); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidateSessionsPath, HandleValidateSession)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.get},
+	}
+	log.Printf("Serving AgenticSession admission webhook on %s", addr)
+	return server.ListenAndServeTLS("", "")
+}
+
+// HandleValidateSession answers an AdmissionReview for an AgenticSession create or update
+func HandleValidateSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReviewBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "expected an AdmissionReview", http.StatusBadRequest)
+		return
+	}
+	resp := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	if errs := reviewErrors(review.Request); len(errs) > 0 {
+		resp.Allowed = false
+		resp.Result = invalidStatus(review.Request.Name, errs)
+		log.Printf("Rejected AgenticSession %s/%s (%s): %v", review.Request.Namespace, review.Request.Name, review.Request.Operation, errs.ToAggregate())
+	}
+	out := admissionv1.AdmissionReview{TypeMeta: review.TypeMeta, Response: resp}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// reviewErrors validates the request's object; requests for other operations are allowed
+func reviewErrors(req *admissionv1.AdmissionRequest) field.ErrorList {
+	path := field.NewPath("spec")
+	switch req.Operation {
+	case admissionv1.Create, admissionv1.Update:
+	default:
+		return nil
+	}
+	session := &apiv1alpha1.AgenticSession{}
+	if err := json.Unmarshal(req.Object.Raw, session); err != nil {
+		return field.ErrorList{field.Invalid(path, nil, fmt.Sprintf("cannot decode: %v", err))}
+	}
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		old := &apiv1alpha1.AgenticSession{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err == nil {
+			return validation.ValidateAgenticSessionSpecUpdate(&session.Spec, &old.Spec, path)
+		}
+	}
+	return validation.ValidateAgenticSessionSpec(&session.Spec, path)
+}
+
+// invalidStatus is the 422 Invalid status kubectl prints with one cause per failing field
+func invalidStatus(name string, errs field.ErrorList) *metav1.Status {
+	causes := make([]metav1.StatusCause, 0, len(errs))
+	for _, e := range errs {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseType(e.Type), Message: e.ErrorBody(), Field: e.Field})
+	}
+	return &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusUnprocessableEntity,
+		Reason:  metav1.StatusReasonInvalid,
+		Message: fmt.Sprintf("AgenticSession %q is invalid: %v", name, errs.ToAggregate()),
+		Details: &metav1.StatusDetails{Name: name, Group: apiv1alpha1.SchemeGroupVersion.Group, Kind: "AgenticSession", Causes: causes},
+	}
+}
+
+// certCache reloads the serving certificate when its file changes
+type certCache struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	modTime time.Time
+	cert    *tls.Certificate
+}
+
+func (c *certCache) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		if c.cert != nil {
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("webhook certificate: %v", err)
+	}
+	if c.cert != nil && info.ModTime().Equal(c.modTime) {
+		return c.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			// Keep serving the old pair while a rotation is half written
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("webhook certificate: %v", err)
+	}
+	c.cert, c.modTime = &cert, info.ModTime()
+	return c.cert, nil
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TestHandleValidateSession verifies invalid specs are denied with one cause per field, valid
+// ones allowed, and an update that leaves the spec alone allowed even if the spec is invalid
+func TestHandleValidateSession(t *testing.T) {
+	review := func(op admissionv1.Operation, spec, oldSpec string) *admissionv1.AdmissionResponse {
+		req := &admissionv1.AdmissionRequest{
+			UID:       "uid-1",
+			Name:      "s1",
+			Namespace: "proj",
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion":"vteam.ambient-code/v1alpha1","kind":"AgenticSession","spec":` + spec + `}`)},
+		}
+		if oldSpec != "" {
+			req.OldObject = runtime.RawExtension{Raw: []byte(`{"spec":` + oldSpec + `}`)}
+		}
+		body, _ := json.Marshal(admissionv1.AdmissionReview{Request: req})
+		w := httptest.NewRecorder()
+		HandleValidateSession(w, httptest.NewRequest(http.MethodPost, ValidateSessionsPath, bytes.NewReader(body)))
+		var out admissionv1.AdmissionReview
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil || out.Response == nil {
+			t.Fatalf("bad response %d: %s", w.Code, w.Body.String())
+		}
+		if out.Response.UID != "uid-1" {
+			t.Errorf("response UID = %q", out.Response.UID)
+		}
+		return out.Response
+	}
+
+	if resp := review(admissionv1.Create, `{"prompt":"hi","timeout":300,"repos":[{"input":{"url":"https://github.com/org/repo"}}]}`, ""); !resp.Allowed {
+		t.Errorf("valid session denied: %v", resp.Result)
+	}
+
+	invalid := `{"prompt":"hi","sessionGroup":"Bad_Group","costLimit":{"usd":-1}}`
+	resp := review(admissionv1.Create, invalid, "")
+	if resp.Allowed || resp.Result == nil || resp.Result.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid session not denied: %+v", resp)
+	}
+	var fields []string
+	for _, cause := range resp.Result.Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	if len(fields) != 2 || fields[0] != "spec.costLimit.usd" || fields[1] != "spec.sessionGroup" {
+		t.Errorf("causes = %v", fields)
+	}
+
+	if resp := review(admissionv1.Update, invalid, invalid); !resp.Allowed {
+		t.Errorf("update leaving the spec unchanged denied: %v", resp.Result)
+	}
+	if resp := review(admissionv1.Update, `{"prompt":"edited","costLimit":{"usd":-1}}`, invalid); resp.Allowed {
+		t.Error("update to an invalid spec allowed")
+	}
+}
@@ -1,26 +1,91 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/crds"
+	"ambient-code-operator/internal/diagnostics"
 	"ambient-code-operator/internal/handlers"
 	"ambient-code-operator/internal/preflight"
+	"ambient-code-operator/internal/runnertls"
+	"ambient-code-operator/internal/types"
+	"ambient-code-operator/internal/webhook"
+	"ambient-code-pkg/redact"
+	"ambient-code-pkg/runtimeconfig"
 )
 
 func main() {
+	// Job and git errors end up in log lines; mask any credentials they carry
+	log.SetOutput(redact.NewWriter(os.Stderr))
+
+	// `operator install` bootstraps a cluster from the manifests built into the binary
+	if len(os.Args) > 1 && os.Args[1] == "install" {
+		os.Exit(runInstall(os.Args[2:]))
+	}
+
 	// Initialize Kubernetes clients
 	if err := config.InitK8sClients(); err != nil {
 		log.Fatalf("Failed to initialize Kubernetes clients: %v", err)
 	}
 
+	// Log level, feature flags and limits that apply without a restart (ambient-runtime-config);
+	// read before the configuration so its overrides apply from the start
+	runtimeConfig := runtimeconfig.NewWatcherFromEnv()
+	if _, err := runtimeConfig.Reload(); err != nil {
+		log.Printf("Ignoring runtime config: %v", err)
+	}
+	go runtimeConfig.Run(context.Background())
+
 	// Load application configuration
 	appConfig := config.LoadConfig()
 
+	flag.IntVar(&handlers.MaxConcurrentJobs, "max-concurrent-jobs", appConfig.MaxConcurrentJobs,
+		"Maximum runner Jobs running cluster-wide; excess sessions queue (0 = unlimited, default from MAX_CONCURRENT_JOBS)")
+	watchNamespaces := &repeatedFlag{values: appConfig.WatchNamespaces}
+	excludeNamespaces := &repeatedFlag{values: appConfig.ExcludeNamespaces}
+	flag.Var(watchNamespaces, "watch-namespaces",
+		"Only serve these projects: comma-separated namespace names or a label selector such as tenant=team-a; repeatable (default from WATCH_NAMESPACES, ';'-separated)")
+	flag.Var(excludeNamespaces, "exclude-namespaces",
+		"Never serve these projects, even if --watch-namespaces matches them: names or a label selector; repeatable (default from EXCLUDE_NAMESPACES)")
+	diagnosticsAddr := flag.String("diagnostics-addr", appConfig.DiagnosticsAddr,
+		"Serve pprof, expvar and the diagnostics page on this address, e.g. 127.0.0.1:6060 (empty = off, default from DIAGNOSTICS_ADDR)")
+	webhookAddr := flag.String("webhook-addr", appConfig.WebhookAddr,
+		"Serve the AgenticSession validating webhook over TLS on this address, e.g. :9443 (empty = off, default from WEBHOOK_ADDR)")
+	webhookCertDir := flag.String("webhook-cert-dir", appConfig.WebhookCertDir,
+		"Directory with the webhook's tls.crt and tls.key (default from WEBHOOK_CERT_DIR)")
+	flag.Parse()
+
+	scope, err := handlers.ParseNamespaceScope(watchNamespaces.values, excludeNamespaces.values)
+	if err != nil {
+		log.Fatalf("Invalid namespace scope: %v", err)
+	}
+	handlers.WatchScope = scope
+
 	log.Printf("Agentic Session Operator starting in namespace: %s", appConfig.Namespace)
+	log.Printf("Serving %s", handlers.WatchScope)
 	log.Printf("Using ambient-code runner image: %s", appConfig.AmbientCodeRunnerImage)
 
+	if *diagnosticsAddr != "" {
+		diagnostics.Default.RegisterGauge("jobMonitors", handlers.ActiveJobMonitors)
+		go diagnostics.Serve(*diagnosticsAddr)
+	}
+
+	// Reject invalid AgenticSessions created with kubectl or GitOps at admission, with the
+	// rules the backend applies to API requests
+	if *webhookAddr != "" {
+		go func() {
+			if err := webhook.Serve(*webhookAddr, *webhookCertDir); err != nil {
+				log.Fatalf("Admission webhook failed: %v", err)
+			}
+		}()
+	}
+
 	// Validate Vertex AI configuration at startup if enabled
 	if os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1" {
 		if err := preflight.ValidateVertexConfig(appConfig.Namespace); err != nil {
@@ -28,18 +93,102 @@ func main() {
 		}
 	}
 
+	// Upgrade the CRDs and migrate objects stored under an older schema before any watcher reads them
+	if err := crds.Sync(context.Background(), config.DynamicClient, appConfig.CRDDir, appConfig.ManageCRDs); err != nil {
+		log.Fatalf("CRD check failed: %v", err)
+	}
+	waitForCRDs(appConfig.RequireCRDs)
+	crds.RunMigrations(context.Background(), config.DynamicClient, config.K8sClient, appConfig.Namespace, crds.Migrations)
+
+	// Issue the backend's internal listener certificate and per-session runner client certificates
+	if appConfig.RunnerMTLS {
+		ca, err := runnertls.EnsureCA(context.Background(), config.K8sClient, appConfig.Namespace)
+		if err != nil {
+			log.Fatalf("Runner mTLS setup failed: %v", err)
+		}
+		handlers.RunnerCA = ca
+		go handlers.KeepBackendCertCurrent()
+	}
+
+	// Read the maintenance switch before any session is processed, then follow it
+	handlers.LoadOperatorConfig()
+	go handlers.WatchOperatorConfig()
+
 	// Start watching AgenticSession resources
 	go handlers.WatchAgenticSessions()
 
 	// Start watching for managed namespaces
 	go handlers.WatchNamespaces()
 
+	// Surface runner pod scheduling, image pull and OOM problems on sessions
+	go handlers.WatchRunnerPods()
+
 	// Start watching ProjectSettings resources
 	go handlers.WatchProjectSettings()
 
+	// Fan platform secrets out to project namespaces
+	go handlers.WatchSecretDistributions()
+
+	// Aggregate session phases and cost into SessionBatches
+	go handlers.WatchSessionBatches()
+
+	// Run session previews and delete them after their TTL
+	go handlers.WatchPreviews()
+
+	// Retry sessions queued behind the cluster-wide runner job limit, a provider rate limit or a project schedule
+	go handlers.RequeueQueuedSessions()
+
+	// Keep session images cached on runner nodes (IMAGE_PREPULL)
+	go handlers.MaintainImagePrepull()
+
+	// Hold node capacity for runner pods waiting for a cluster scale-up (SCALE_UP_BALLOONS)
+	go handlers.MaintainScaleUpBalloons()
+
 	// Start cleanup of expired temporary content pods
 	go handlers.CleanupExpiredTempContentPods()
 
 	// Keep the operator running
 	select {}
 }
+
+// crdPollInterval is how often a missing CRD is looked for again
+const crdPollInterval = 30 * time.Second
+
+// waitForCRDs holds startup until the cluster serves the custom resources the watchers read,
+// naming what is missing; with require it exits instead so the pod never becomes ready
+func waitForCRDs(require bool) {
+	for {
+		err := types.VerifyCRDsInstalled(config.K8sClient.Discovery())
+		if err == nil {
+			return
+		}
+		if require {
+			log.Fatalf("CRD check failed: %v", err)
+		}
+		log.Printf("%v; retrying in %s", err, crdPollInterval)
+		time.Sleep(crdPollInterval)
+	}
+}
+
+// repeatedFlag collects the values of a flag given several times; the first use on the
+// command line replaces the default from the environment
+type repeatedFlag struct {
+	values []string
+	set    bool
+}
+
+func (f *repeatedFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(f.values, ";")
+}
+
+func (f *repeatedFlag) Set(value string) error {
+	if !f.set {
+		f.values = nil
+		f.set = true
+	}
+	f.values = append(f.values, value)
+	return nil
+}
@@ -0,0 +1,19 @@
+// Package e2e runs the operator against a real cluster: it creates a kind cluster, installs
+// the CRDs, deploys the operator image and drives AgenticSessions through their lifecycle
+// with a stub runner image instead of Claude.
+//
+// The tests are behind the e2e build tag and need kind, kubectl and docker (or podman):
+//
+//	make e2e-operator
+//	# or, from components/operator
+//	go test -tags e2e -v -timeout 30m ./test/e2e/
+//
+// Environment:
+//
+//	E2E_KUBECONFIG       use this cluster instead of creating a kind cluster
+//	E2E_KIND_CLUSTER     kind cluster name (default ambient-operator-e2e)
+//	E2E_KEEP_CLUSTER     keep the kind cluster after the run (true/false)
+//	E2E_OPERATOR_IMAGE   operator image to deploy; built from this tree when unset
+//	E2E_STUB_IMAGE       stub runner image; built from test/e2e/stub-runner when unset
+//	CONTAINER_ENGINE     docker or podman (default: whichever is running)
+package e2e
@@ -0,0 +1,277 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// operatorNamespace matches the namespace of the operator ServiceAccount in the base RBAC
+	operatorNamespace  = "ambient-code"
+	defaultClusterName = "ambient-operator-e2e"
+	builtOperatorImage = "localhost/vteam_operator:e2e"
+	builtStubImage     = "localhost/vteam_stub_runner:e2e"
+)
+
+// Clients for the cluster under test, set up by TestMain
+var (
+	kubeClient kubernetes.Interface
+	dynClient  dynamic.Interface
+	kubeconfig string
+)
+
+func TestMain(m *testing.M) {
+	code, err := run(m)
+	if err != nil {
+		log.Printf("e2e setup failed: %v", err)
+		os.Exit(1)
+	}
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	components, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	if err != nil {
+		return 0, err
+	}
+	engine := containerEngine()
+
+	kubeconfig = os.Getenv("E2E_KUBECONFIG")
+	if kubeconfig == "" {
+		cluster := envOr("E2E_KIND_CLUSTER", defaultClusterName)
+		if kubeconfig, err = createKindCluster(engine, cluster); err != nil {
+			return 0, err
+		}
+		if os.Getenv("E2E_KEEP_CLUSTER") != "true" {
+			defer func() {
+				if err := kind(engine, "delete", "cluster", "--name", cluster); err != nil {
+					log.Printf("Failed to delete kind cluster %s: %v", cluster, err)
+				}
+				_ = os.Remove(kubeconfig)
+			}()
+		}
+	}
+
+	operatorImage := os.Getenv("E2E_OPERATOR_IMAGE")
+	if operatorImage == "" {
+		operatorImage = builtOperatorImage
+		if err := buildImage(engine, operatorImage, filepath.Join(components, "operator", "Dockerfile"), components); err != nil {
+			return 0, err
+		}
+	}
+	stubImage := os.Getenv("E2E_STUB_IMAGE")
+	if stubImage == "" {
+		stubImage = builtStubImage
+		stubDir := filepath.Join(components, "operator", "test", "e2e", "stub-runner")
+		if err := buildImage(engine, stubImage, filepath.Join(stubDir, "Dockerfile"), stubDir); err != nil {
+			return 0, err
+		}
+	}
+	if os.Getenv("E2E_KUBECONFIG") == "" {
+		cluster := envOr("E2E_KIND_CLUSTER", defaultClusterName)
+		for _, image := range []string{operatorImage, stubImage} {
+			if err := loadImage(engine, cluster, image); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load kubeconfig %s: %w", kubeconfig, err)
+	}
+	if kubeClient, err = kubernetes.NewForConfig(cfg); err != nil {
+		return 0, err
+	}
+	if dynClient, err = dynamic.NewForConfig(cfg); err != nil {
+		return 0, err
+	}
+
+	if err := installOperator(components, operatorImage, stubImage); err != nil {
+		dumpOperatorLogs()
+		return 0, err
+	}
+	code := m.Run()
+	if code != 0 {
+		dumpOperatorLogs()
+	}
+	return code, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// containerEngine picks docker or podman the same way e2e/scripts/setup-kind.sh does
+func containerEngine() string {
+	if engine := os.Getenv("CONTAINER_ENGINE"); engine != "" {
+		return engine
+	}
+	if exec.Command("docker", "ps").Run() == nil {
+		return "docker"
+	}
+	return "podman"
+}
+
+func command(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	log.Printf("+ %s %s", name, strings.Join(args, " "))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func kind(engine string, args ...string) error {
+	if engine == "podman" {
+		os.Setenv("KIND_EXPERIMENTAL_PROVIDER", "podman")
+	}
+	return command("kind", args...)
+}
+
+func kubectl(args ...string) error {
+	return command("kubectl", append([]string{"--kubeconfig", kubeconfig}, args...)...)
+}
+
+func createKindCluster(engine, cluster string) (string, error) {
+	f, err := os.CreateTemp("", cluster+"-kubeconfig-*")
+	if err != nil {
+		return "", err
+	}
+	_ = f.Close()
+	if err := kind(engine, "create", "cluster", "--name", cluster, "--kubeconfig", f.Name(), "--wait", "120s"); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func buildImage(engine, image, dockerfile, contextDir string) error {
+	return command(engine, "build", "-t", image, "-f", dockerfile, contextDir)
+}
+
+// loadImage copies a locally built image into the kind nodes (podman needs an archive)
+func loadImage(engine, cluster, image string) error {
+	if engine != "podman" {
+		return kind(engine, "load", "docker-image", image, "--name", cluster)
+	}
+	archive := filepath.Join(os.TempDir(), strings.NewReplacer("/", "_", ":", "_").Replace(image)+".tar")
+	defer os.Remove(archive)
+	if err := command(engine, "save", "-o", archive, image); err != nil {
+		return err
+	}
+	return kind(engine, "load", "image-archive", archive, "--name", cluster)
+}
+
+// installOperator applies the CRDs and the operator RBAC from the manifests, then deploys the
+// operator with the stub image as both runner and content service
+func installOperator(components, operatorImage, stubImage string) error {
+	ctx := context.Background()
+	manifests := filepath.Join(components, "manifests", "base")
+	if err := kubectl("apply", "-k", filepath.Join(manifests, "crds")); err != nil {
+		return err
+	}
+	for _, crd := range []string{"agenticsessions.vteam.ambient-code", "projectsettings.vteam.ambient-code", "secretdistributions.vteam.ambient-code", "sessionbatches.vteam.ambient-code", "previews.vteam.ambient-code", "agents.vteam.ambient-code"} {
+		if err := kubectl("wait", "--for=condition=Established", "--timeout=60s", "crd/"+crd); err != nil {
+			return err
+		}
+	}
+
+	if _, err := kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: operatorNamespace}}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	rbac := filepath.Join(manifests, "rbac")
+	for _, file := range []string{"operator-sa.yaml", "operator-clusterrole.yaml", "operator-clusterrolebinding.yaml"} {
+		if err := kubectl("apply", "-f", filepath.Join(rbac, file)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := kubeClient.CoreV1().ConfigMaps(operatorNamespace).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator-config"},
+		Data:       map[string]string{"CLAUDE_CODE_USE_VERTEX": "0"},
+	}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	replicas := int32(1)
+	labels := map[string]string{"app": "agentic-operator"}
+	env := []corev1.EnvVar{
+		{Name: "NAMESPACE", Value: operatorNamespace},
+		{Name: "BACKEND_NAMESPACE", Value: operatorNamespace},
+		{Name: "AMBIENT_CODE_RUNNER_IMAGE", Value: stubImage},
+		{Name: "CONTENT_SERVICE_IMAGE", Value: stubImage},
+		{Name: "IMAGE_PULL_POLICY", Value: "IfNotPresent"},
+		{Name: "MANAGE_CRDS", Value: "false"},
+		{Name: "REQUIRE_CRDS", Value: "true"},
+		{Name: "CLAUDE_CODE_USE_VERTEX", Value: "0"},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "agentic-operator", Namespace: operatorNamespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "agentic-operator",
+					Containers: []corev1.Container{{
+						Name:            "agentic-operator",
+						Image:           operatorImage,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Env:             env,
+					}},
+				},
+			},
+		},
+	}
+	deployments := kubeClient.AppsV1().Deployments(operatorNamespace)
+	if existing, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{}); err == nil {
+		deployment.ResourceVersion = existing.ResourceVersion
+		_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	} else if _, err := deployments.Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+		d, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		return d.Status.ObservedGeneration >= d.Generation && d.Status.AvailableReplicas == 1 && d.Status.UpdatedReplicas == 1, nil
+	})
+}
+
+// dumpOperatorLogs prints the operator's log to help diagnose a failed run
+func dumpOperatorLogs() {
+	if kubeconfig == "" {
+		return
+	}
+	_ = kubectl("-n", operatorNamespace, "logs", "deployment/agentic-operator", "--tail=200")
+}
@@ -0,0 +1,220 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// sessionTimeout bounds one phase transition; image pulls for the init container dominate
+	sessionTimeout = 4 * time.Minute
+	// runnerSecretsName is the runner Secret the operator injects when Vertex is disabled
+	runnerSecretsName = "ambient-runner-secrets"
+)
+
+// newProject creates a managed project namespace with the runner Secret and deletes it when
+// the test ends
+func newProject(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	name := "e2e-" + rand.String(6)
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"ambient-code.io/managed": "true"}}}
+	if _, err := kubeClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create namespace: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = kubeClient.CoreV1().Namespaces().Delete(context.Background(), name, metav1.DeleteOptions{})
+	})
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: runnerSecretsName},
+		StringData: map[string]string{"ANTHROPIC_API_KEY": "stub"},
+	}
+	if _, err := kubeClient.CoreV1().Secrets(name).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create runner secret: %v", err)
+	}
+	return name
+}
+
+func createSession(t *testing.T, namespace, name, prompt string) {
+	t.Helper()
+	session := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"spec": map[string]interface{}{
+			"prompt":      prompt,
+			"displayName": name,
+			"timeout":     int64(600),
+		},
+	}}
+	if _, err := dynClient.Resource(apiv1alpha1.AgenticSessionGVR()).Namespace(namespace).Create(context.Background(), session, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create session %s: %v", name, err)
+	}
+}
+
+func getSession(namespace, name string) (*unstructured.Unstructured, error) {
+	return dynClient.Resource(apiv1alpha1.AgenticSessionGVR()).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+}
+
+func phaseOf(obj *unstructured.Unstructured) string {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	return phase
+}
+
+// waitForPhase waits until the session reaches one of the phases and returns it
+func waitForPhase(t *testing.T, namespace, name string, phases ...string) *unstructured.Unstructured {
+	t.Helper()
+	var last *unstructured.Unstructured
+	err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, sessionTimeout, true, func(ctx context.Context) (bool, error) {
+		obj, err := getSession(namespace, name)
+		if err != nil {
+			return false, nil
+		}
+		last = obj
+		for _, p := range phases {
+			if phaseOf(obj) == p {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		status := "<none>"
+		if last != nil {
+			status = fmt.Sprintf("%v", last.Object["status"])
+		}
+		t.Fatalf("session %s/%s did not reach %v: last status %s", namespace, name, phases, status)
+	}
+	return last
+}
+
+// waitForGone waits until get returns NotFound
+func waitForGone(t *testing.T, what string, get func(ctx context.Context) error) {
+	t.Helper()
+	err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, sessionTimeout, true, func(ctx context.Context) (bool, error) {
+		return errors.IsNotFound(get(ctx)), nil
+	})
+	if err != nil {
+		t.Fatalf("%s was not removed", what)
+	}
+}
+
+func jobGetter(namespace, session string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := kubeClient.BatchV1().Jobs(namespace).Get(ctx, session+"-job", metav1.GetOptions{})
+		return err
+	}
+}
+
+// TestSessionLifecycle creates a session that succeeds and follows it through its runner Job,
+// completion, cleanup of the Job and deletion of the session
+func TestSessionLifecycle(t *testing.T) {
+	ns := newProject(t)
+	createSession(t, ns, "succeeds", "stub:succeed")
+
+	waitForPhase(t, ns, "succeeds", "Running", "Completed")
+	if _, err := kubeClient.CoreV1().PersistentVolumeClaims(ns).Get(context.Background(), "ambient-workspace-succeeds", metav1.GetOptions{}); err != nil {
+		t.Errorf("workspace PVC not created: %v", err)
+	}
+
+	obj := waitForPhase(t, ns, "succeeds", "Completed")
+	if outcome, _, _ := unstructured.NestedString(obj.Object, "status", "result", "outcome"); outcome != string(apiv1alpha1.OutcomeSucceeded) {
+		t.Errorf("expected outcome Succeeded, got %q (status %v)", outcome, obj.Object["status"])
+	}
+	waitForGone(t, "runner job", jobGetter(ns, "succeeds"))
+
+	// The workspace is kept for restarts until the session itself is deleted
+	if _, err := kubeClient.CoreV1().PersistentVolumeClaims(ns).Get(context.Background(), "ambient-workspace-succeeds", metav1.GetOptions{}); err != nil {
+		t.Errorf("workspace PVC removed with the job: %v", err)
+	}
+
+	if err := dynClient.Resource(apiv1alpha1.AgenticSessionGVR()).Namespace(ns).Delete(context.Background(), "succeeds", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete session: %v", err)
+	}
+	// The runner RBAC finalizer must be released by the operator
+	waitForGone(t, "session", func(ctx context.Context) error {
+		_, err := getSession(ns, "succeeds")
+		return err
+	})
+}
+
+// TestSessionRunnerFailure verifies a runner exiting non-zero fails the session
+func TestSessionRunnerFailure(t *testing.T) {
+	ns := newProject(t)
+	createSession(t, ns, "fails", "stub:fail")
+
+	obj := waitForPhase(t, ns, "fails", "Failed")
+	if outcome, _, _ := unstructured.NestedString(obj.Object, "status", "result", "outcome"); outcome != string(apiv1alpha1.OutcomeFailed) {
+		t.Errorf("expected outcome Failed, got %q", outcome)
+	}
+	waitForGone(t, "runner job", jobGetter(ns, "fails"))
+}
+
+// TestSessionStop verifies stopping a running session removes its Job and pods
+func TestSessionStop(t *testing.T) {
+	ns := newProject(t)
+	createSession(t, ns, "stopped", "stub:sleep 600")
+	obj := waitForPhase(t, ns, "stopped", "Running")
+
+	// Stop the session the way the backend does
+	_ = unstructured.SetNestedField(obj.Object, "Stopped", "status", "phase")
+	_ = unstructured.SetNestedField(obj.Object, "Stopped by e2e test", "status", "message")
+	if _, err := dynClient.Resource(apiv1alpha1.AgenticSessionGVR()).Namespace(ns).UpdateStatus(context.Background(), obj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("stop session: %v", err)
+	}
+	waitForGone(t, "runner job", jobGetter(ns, "stopped"))
+
+	pods, err := kubeClient.CoreV1().Pods(ns).List(context.Background(), metav1.ListOptions{LabelSelector: "job-name=stopped-job"})
+	if err != nil {
+		t.Fatalf("list pods: %v", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			t.Errorf("runner pod %s still running after stop", pod.Name)
+		}
+	}
+	if obj, _ := getSession(ns, "stopped"); obj != nil && phaseOf(obj) != "Stopped" {
+		t.Errorf("expected the session to stay Stopped, got %s", phaseOf(obj))
+	}
+}
+
+// TestUnmanagedNamespaceIgnored verifies sessions outside managed projects are not reconciled
+func TestUnmanagedNamespaceIgnored(t *testing.T) {
+	ctx := context.Background()
+	name := "e2e-unmanaged-" + rand.String(6)
+	if _, err := kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create namespace: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = kubeClient.CoreV1().Namespaces().Delete(context.Background(), name, metav1.DeleteOptions{})
+	})
+	createSession(t, name, "ignored", "stub:succeed")
+
+	time.Sleep(15 * time.Second)
+	obj, err := getSession(name, "ignored")
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if phase := phaseOf(obj); phase != "" {
+		t.Errorf("expected no phase in an unmanaged namespace, got %s", phase)
+	}
+	if err := jobGetter(name, "ignored")(ctx); !errors.IsNotFound(err) {
+		t.Errorf("expected no runner job in an unmanaged namespace, got %v", err)
+	}
+	if len(obj.GetFinalizers()) > 0 {
+		t.Errorf("expected no finalizers in an unmanaged namespace, got %v", obj.GetFinalizers())
+	}
+}
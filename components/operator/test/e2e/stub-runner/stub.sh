@@ -0,0 +1,31 @@
+#!/bin/sh
+# Stub runner for operator e2e tests.
+#
+# As the content service (CONTENT_SERVICE_MODE=true) it serves /health on :8080 until killed.
+# As the runner it follows the directive in the session prompt:
+#   stub:succeed          exit 0 after a few seconds
+#   stub:fail             exit 1 with a termination message
+#   stub:sleep <seconds>  sleep, then exit 0 (for stop and timeout scenarios)
+set -eu
+
+if [ "${CONTENT_SERVICE_MODE:-}" = "true" ]; then
+  echo ok > /www/health
+  exec httpd -f -p 8080 -h /www
+fi
+
+echo "stub runner: session ${AGENTIC_SESSION_NAMESPACE:-?}/${AGENTIC_SESSION_NAME:-?}: ${PROMPT:-}"
+
+case "${PROMPT:-}" in
+  *stub:fail*)
+    echo "stub runner failed as requested" > /dev/termination-log 2>/dev/null || true
+    exit 1
+    ;;
+  *stub:sleep*)
+    seconds=$(echo "$PROMPT" | sed -n 's/.*stub:sleep \([0-9][0-9]*\).*/\1/p')
+    sleep "${seconds:-60}"
+    ;;
+  *)
+    sleep 3
+    ;;
+esac
+exit 0
@@ -0,0 +1,90 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// An Agent is a persona a session can hand work to, such as the members of the RFE council.
+// The backend resolves the personas a session names in AGENT_PERSONAS to the project's Agents
+// and serves them to the runner, which registers each as a subagent.
+
+const (
+	// MaxAgentDescriptionLength bounds the text the main agent reads to pick a subagent
+	MaxAgentDescriptionLength = 1024
+	// MaxAgentPromptLength bounds an agent's system prompt
+	MaxAgentPromptLength = 32 * 1024
+)
+
+// AgentModels are the models an Agent may pin; "inherit" uses the session's model
+var AgentModels = []string{"inherit", "sonnet", "opus", "haiku"}
+
+var (
+	// Tool names as the runner knows them, optionally with a rule such as Bash(git:*)
+	agentToolPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\(.+\))?$`)
+	// Capabilities are short labels for filtering agents
+	agentCapabilityPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// AgentSpec mirrors spec in agents-crd.yaml
+type AgentSpec struct {
+	// DisplayName is shown in the UI, e.g. "Stella Staff Engineer"
+	DisplayName string `json:"displayName,omitempty"`
+	Role        string `json:"role,omitempty"`
+	// Description tells the main agent when to hand work to this agent
+	Description string `json:"description"`
+	// Prompt is the agent's system prompt
+	Prompt       string   `json:"prompt"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Tools the agent may use; empty means the session's tools
+	Tools []string `json:"tools,omitempty"`
+	// Model is one of AgentModels; empty means inherit
+	Model string `json:"model,omitempty"`
+}
+
+// Validate checks the fields the CRD schema cannot
+func (s *AgentSpec) Validate() error {
+	if strings.TrimSpace(s.Description) == "" || strings.TrimSpace(s.Prompt) == "" {
+		return fmt.Errorf("description and prompt are required")
+	}
+	if len(s.Description) > MaxAgentDescriptionLength {
+		return fmt.Errorf("description is longer than %d characters", MaxAgentDescriptionLength)
+	}
+	if len(s.Prompt) > MaxAgentPromptLength {
+		return fmt.Errorf("prompt is longer than %d characters", MaxAgentPromptLength)
+	}
+	for _, tool := range s.Tools {
+		if !agentToolPattern.MatchString(tool) {
+			return fmt.Errorf("invalid tool %q", tool)
+		}
+	}
+	for _, c := range s.Capabilities {
+		if !agentCapabilityPattern.MatchString(c) {
+			return fmt.Errorf("invalid capability %q: use lowercase letters, digits and dashes", c)
+		}
+	}
+	if s.Model != "" {
+		for _, m := range AgentModels {
+			if s.Model == m {
+				return nil
+			}
+		}
+		return fmt.Errorf("model must be one of %s", strings.Join(AgentModels, ", "))
+	}
+	return nil
+}
+
+// ParseAgentPersonas splits the comma-separated AGENT_PERSONAS value into distinct names
+func ParseAgentPersonas(value string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, p := range strings.Split(value, ",") {
+		name := strings.TrimSpace(p)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
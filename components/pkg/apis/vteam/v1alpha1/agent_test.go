@@ -0,0 +1,46 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAgentSpecValidate(t *testing.T) {
+	spec := AgentSpec{
+		DisplayName:  "Stella Staff Engineer",
+		Description:  "Use for complex technical problems and code review",
+		Prompt:       "You are a staff engineer.",
+		Capabilities: []string{"engineering", "code-review"},
+		Tools:        []string{"Read", "Grep", "Bash(git:*)", "mcp__github__get_issue"},
+		Model:        "opus",
+	}
+	if err := spec.Validate(); err != nil {
+		t.Fatalf("valid spec rejected: %v", err)
+	}
+	invalid := map[string]func(s *AgentSpec){
+		"no prompt":          func(s *AgentSpec) { s.Prompt = " " },
+		"long description":   func(s *AgentSpec) { s.Description = strings.Repeat("x", MaxAgentDescriptionLength+1) },
+		"tool with spaces":   func(s *AgentSpec) { s.Tools = []string{"rm -rf"} },
+		"uppercase label":    func(s *AgentSpec) { s.Capabilities = []string{"UX"} },
+		"unknown model":      func(s *AgentSpec) { s.Model = "gpt-4" },
+		"empty rule in tool": func(s *AgentSpec) { s.Tools = []string{"Bash()"} },
+	}
+	for name, mutate := range invalid {
+		s := spec
+		mutate(&s)
+		if s.Validate() == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestParseAgentPersonas(t *testing.T) {
+	got := ParseAgentPersonas(" stella, parker,,stella ")
+	if !reflect.DeepEqual(got, []string{"stella", "parker"}) {
+		t.Errorf("ParseAgentPersonas = %v", got)
+	}
+	if ParseAgentPersonas("") != nil {
+		t.Error("empty value should name no personas")
+	}
+}
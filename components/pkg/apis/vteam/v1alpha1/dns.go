@@ -0,0 +1,82 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Kubernetes limits of a pod's dnsConfig
+const (
+	maxDNSNameservers = 3
+	maxDNSSearches    = 32
+)
+
+// Validate checks the addresses and names the way pod validation would, so a bad entry is
+// rejected when the settings are saved rather than when a runner pod is created
+func (d *RunnerDNS) Validate() error {
+	if d == nil {
+		return nil
+	}
+	for i, a := range d.HostAliases {
+		if net.ParseIP(a.IP) == nil {
+			return fmt.Errorf("hostAliases[%d].ip: %q is not an IP address", i, a.IP)
+		}
+		if len(a.Hostnames) == 0 {
+			return fmt.Errorf("hostAliases[%d].hostnames: at least one host name is required", i)
+		}
+		for _, h := range a.Hostnames {
+			if errs := validation.IsDNS1123Subdomain(h); len(errs) > 0 {
+				return fmt.Errorf("hostAliases[%d].hostnames: %q: %s", i, h, strings.Join(errs, "; "))
+			}
+		}
+	}
+	switch d.Policy {
+	case "", DNSPolicyClusterFirst:
+	case DNSPolicyNone:
+		if len(d.Nameservers) == 0 {
+			return fmt.Errorf("policy %s requires nameservers", DNSPolicyNone)
+		}
+	default:
+		return fmt.Errorf("policy: must be %s or %s", DNSPolicyClusterFirst, DNSPolicyNone)
+	}
+	if len(d.Nameservers) > maxDNSNameservers {
+		return fmt.Errorf("nameservers: at most %d", maxDNSNameservers)
+	}
+	for _, ns := range d.Nameservers {
+		if net.ParseIP(ns) == nil {
+			return fmt.Errorf("nameservers: %q is not an IP address", ns)
+		}
+	}
+	if len(d.Searches) > maxDNSSearches {
+		return fmt.Errorf("searches: at most %d", maxDNSSearches)
+	}
+	for _, s := range d.Searches {
+		if errs := validation.IsDNS1123Subdomain(strings.TrimSuffix(s, ".")); len(errs) > 0 {
+			return fmt.Errorf("searches: %q: %s", s, strings.Join(errs, "; "))
+		}
+	}
+	for i, o := range d.Options {
+		if strings.TrimSpace(o.Name) == "" {
+			return fmt.Errorf("options[%d].name: required", i)
+		}
+	}
+	return nil
+}
+
+// ActiveHostAliases returns the host aliases that have not expired at now
+func (d *RunnerDNS) ActiveHostAliases(now time.Time) []RunnerHostAlias {
+	if d == nil {
+		return nil
+	}
+	var active []RunnerHostAlias
+	for _, a := range d.HostAliases {
+		if a.Expires == nil || now.Before(a.Expires.Time) {
+			active = append(active, a)
+		}
+	}
+	return active
+}
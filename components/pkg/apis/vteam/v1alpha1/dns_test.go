@@ -0,0 +1,51 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunnerDNS_Validate(t *testing.T) {
+	valid := []*RunnerDNS{
+		nil,
+		{},
+		{HostAliases: []RunnerHostAlias{{IP: "10.0.0.5", Hostnames: []string{"git.corp.example.com", "bitbucket"}}}},
+		{Nameservers: []string{"10.0.0.53"}, Searches: []string{"corp.example.com."}, Options: []DNSOption{{Name: "ndots", Value: "2"}}},
+		{Policy: DNSPolicyNone, Nameservers: []string{"10.0.0.53", "fd00::53"}},
+	}
+	for _, d := range valid {
+		if err := d.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", d, err)
+		}
+	}
+	invalid := []RunnerDNS{
+		{HostAliases: []RunnerHostAlias{{IP: "git.corp", Hostnames: []string{"git"}}}},
+		{HostAliases: []RunnerHostAlias{{IP: "10.0.0.5"}}},
+		{HostAliases: []RunnerHostAlias{{IP: "10.0.0.5", Hostnames: []string{"Git_Server"}}}},
+		{Policy: DNSPolicyNone},
+		{Policy: "Default"},
+		{Nameservers: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}},
+		{Nameservers: []string{"dns.corp"}},
+		{Options: []DNSOption{{Value: "2"}}},
+	}
+	for _, d := range invalid {
+		if err := d.Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v", d)
+		}
+	}
+}
+
+func TestRunnerDNS_ActiveHostAliases(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	d := &RunnerDNS{HostAliases: []RunnerHostAlias{
+		{IP: "10.0.0.5", Hostnames: []string{"permanent"}},
+		{IP: "10.0.0.6", Hostnames: []string{"migrating"}, Expires: &metav1.Time{Time: now.Add(time.Hour)}},
+		{IP: "10.0.0.7", Hostnames: []string{"migrated"}, Expires: &metav1.Time{Time: now}},
+	}}
+	active := d.ActiveHostAliases(now)
+	if len(active) != 2 || active[0].Hostnames[0] != "permanent" || active[1].Hostnames[0] != "migrating" {
+		t.Errorf("ActiveHostAliases() = %+v", active)
+	}
+}
@@ -0,0 +1,6 @@
+// +k8s:deepcopy-gen=package
+// +groupName=vteam.ambient-code
+
+// Package v1alpha1 contains the API types for the vteam.ambient-code/v1alpha1 custom resources
+// shared by the backend, the operator and (as JSON) the runner.
+package v1alpha1
@@ -0,0 +1,56 @@
+package v1alpha1
+
+import "fmt"
+
+// Validate checks teams are named once and weights and the cap are in range
+func (f *SessionFairness) Validate() error {
+	if f == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	for i, team := range f.Teams {
+		if team == "" {
+			return fmt.Errorf("teams[%d]: must not be empty", i)
+		}
+		if seen[team] {
+			return fmt.Errorf("teams[%d]: %q is listed twice", i, team)
+		}
+		seen[team] = true
+	}
+	for name, weight := range f.Weights {
+		if name == "" {
+			return fmt.Errorf("weights: names must not be empty")
+		}
+		if weight < 1 || weight > 100 {
+			return fmt.Errorf("weights[%s]: must be between 1 and 100", name)
+		}
+	}
+	if f.MaxRunning < 0 {
+		return fmt.Errorf("maxRunning: must not be negative")
+	}
+	return nil
+}
+
+// ShareKey is who a session counts for: the first of Teams its creator belongs to, else the
+// creator's user ID ("" for sessions created without one, which share a single share)
+func (f *SessionFairness) ShareKey(user *UserContext) string {
+	if user == nil {
+		return ""
+	}
+	for _, team := range f.Teams {
+		for _, group := range user.Groups {
+			if group == team {
+				return team
+			}
+		}
+	}
+	return user.UserID
+}
+
+// Weight of a user or team, 1 unless Weights sets it
+func (f *SessionFairness) Weight(key string) int {
+	if w := f.Weights[key]; w > 0 {
+		return w
+	}
+	return 1
+}
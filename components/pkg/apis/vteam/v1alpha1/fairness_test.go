@@ -0,0 +1,49 @@
+package v1alpha1
+
+import "testing"
+
+func TestSessionFairness_Validate(t *testing.T) {
+	valid := []*SessionFairness{
+		nil,
+		{},
+		{Teams: []string{"platform", "data"}, Weights: map[string]int{"platform": 3, "alice": 1}, MaxRunning: 5},
+	}
+	for _, f := range valid {
+		if err := f.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", f, err)
+		}
+	}
+	invalid := []SessionFairness{
+		{Teams: []string{""}},
+		{Teams: []string{"platform", "platform"}},
+		{Weights: map[string]int{"alice": 0}},
+		{Weights: map[string]int{"alice": 101}},
+		{MaxRunning: -1},
+	}
+	for _, f := range invalid {
+		if err := f.Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v", f)
+		}
+	}
+}
+
+func TestSessionFairness_ShareKey(t *testing.T) {
+	f := &SessionFairness{Teams: []string{"platform", "data"}, Weights: map[string]int{"platform": 2}}
+	tests := []struct {
+		user *UserContext
+		want string
+	}{
+		{nil, ""},
+		{&UserContext{UserID: "alice"}, "alice"},
+		{&UserContext{UserID: "bob", Groups: []string{"data", "platform"}}, "platform"},
+		{&UserContext{UserID: "carol", Groups: []string{"sales"}}, "carol"},
+	}
+	for _, tt := range tests {
+		if got := f.ShareKey(tt.user); got != tt.want {
+			t.Errorf("ShareKey(%+v) = %q, want %q", tt.user, got, tt.want)
+		}
+	}
+	if f.Weight("platform") != 2 || f.Weight("alice") != 1 {
+		t.Errorf("weights = %d, %d", f.Weight("platform"), f.Weight("alice"))
+	}
+}
@@ -0,0 +1,20 @@
+package v1alpha1
+
+import "fmt"
+
+// Validate checks the context is one of the FollowUpContext values and the length is not
+// negative
+func (p *FollowUpPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	switch p.Context {
+	case "", FollowUpContextSummary, FollowUpContextTranscript, FollowUpContextNone:
+	default:
+		return fmt.Errorf("context: must be %s, %s or %s", FollowUpContextSummary, FollowUpContextTranscript, FollowUpContextNone)
+	}
+	if p.MaxContextLength < 0 {
+		return fmt.Errorf("maxContextLength: must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// GPUResourceName is the extended resource the NVIDIA device plugin advertises
+const GPUResourceName = "nvidia.com/gpu"
+
+// Validate checks the fields' format; whether the cluster can satisfy them is checked by
+// the hardware package
+func (h *RunnerHardware) Validate() error {
+	if h == nil {
+		return nil
+	}
+	if h.RuntimeClassName != "" {
+		if errs := validation.IsDNS1123Subdomain(h.RuntimeClassName); len(errs) > 0 {
+			return fmt.Errorf("runtimeClassName: %s", errs[0])
+		}
+	}
+	if h.GPUs < 0 {
+		return fmt.Errorf("gpus must not be negative")
+	}
+	switch h.Architecture {
+	case "", ArchitectureAMD64, ArchitectureARM64:
+	default:
+		return fmt.Errorf("architecture must be %s or %s", ArchitectureAMD64, ArchitectureARM64)
+	}
+	return nil
+}
+
+// Merge returns the project defaults h with the session's non-empty fields applied;
+// nil when neither sets anything
+func (h *RunnerHardware) Merge(session *RunnerHardware) *RunnerHardware {
+	out := &RunnerHardware{}
+	if h != nil {
+		*out = *h
+	}
+	if session != nil {
+		if session.RuntimeClassName != "" {
+			out.RuntimeClassName = session.RuntimeClassName
+		}
+		if session.GPUs != 0 {
+			out.GPUs = session.GPUs
+		}
+		if session.Architecture != "" {
+			out.Architecture = session.Architecture
+		}
+	}
+	if *out == (RunnerHardware{}) {
+		return nil
+	}
+	return out
+}
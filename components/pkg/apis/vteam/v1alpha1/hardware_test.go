@@ -0,0 +1,30 @@
+package v1alpha1
+
+import "testing"
+
+func TestRunnerHardware_Merge(t *testing.T) {
+	project := &RunnerHardware{RuntimeClassName: "nvidia", GPUs: 1}
+	got := project.Merge(&RunnerHardware{GPUs: 2, Architecture: ArchitectureARM64})
+	want := RunnerHardware{RuntimeClassName: "nvidia", GPUs: 2, Architecture: ArchitectureARM64}
+	if got == nil || *got != want {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+	if project.GPUs != 1 {
+		t.Error("Merge() modified the project defaults")
+	}
+	var none *RunnerHardware
+	if none.Merge(nil) != nil || none.Merge(&RunnerHardware{}) != nil {
+		t.Error("Merge() of nothing should be nil")
+	}
+}
+
+func TestRunnerHardware_Validate(t *testing.T) {
+	if err := (&RunnerHardware{RuntimeClassName: "nvidia", GPUs: 1, Architecture: ArchitectureAMD64}).Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	for _, h := range []RunnerHardware{{RuntimeClassName: "Nvidia"}, {GPUs: -1}, {Architecture: "x86_64"}} {
+		if err := h.Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v", h)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package v1alpha1
+
+import "fmt"
+
+// Context strategies of spec.llmSettings.contextStrategy: what the runner does when the
+// conversation outgrows maxContextTokens
+const (
+	// ContextStrategyTruncate starts a fresh conversation; cheapest, the agent keeps only
+	// what is in the workspace
+	ContextStrategyTruncate = "truncate"
+	// ContextStrategySummarize compacts the conversation into a summary; the default
+	ContextStrategySummarize = "summarize"
+	// ContextStrategyRetrieval has the agent keep notes in the workspace and re-read them
+	// after the conversation is cleared
+	ContextStrategyRetrieval = "retrieval"
+)
+
+// MinContextTokens is the smallest maxContextTokens; below it the runner would spend most
+// turns compacting
+const MinContextTokens = 10000
+
+// Validate checks the context window settings; model, temperature and maxTokens are passed
+// to the runner as given
+func (l *LLMSettings) Validate() error {
+	if l == nil {
+		return nil
+	}
+	switch l.ContextStrategy {
+	case "", ContextStrategyTruncate, ContextStrategySummarize, ContextStrategyRetrieval:
+	default:
+		return fmt.Errorf("contextStrategy must be %s, %s or %s", ContextStrategyTruncate, ContextStrategySummarize, ContextStrategyRetrieval)
+	}
+	if l.MaxContextTokens != 0 && l.MaxContextTokens < MinContextTokens {
+		return fmt.Errorf("maxContextTokens must be at least %d", MinContextTokens)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package v1alpha1
+
+import "testing"
+
+func TestLLMSettings_Validate(t *testing.T) {
+	for _, l := range []*LLMSettings{nil, {}, {ContextStrategy: ContextStrategyRetrieval, MaxContextTokens: 120000}} {
+		if err := l.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", l, err)
+		}
+	}
+	for _, l := range []LLMSettings{{ContextStrategy: "sliding"}, {MaxContextTokens: 500}, {MaxContextTokens: -1}} {
+		if err := l.Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v", l)
+		}
+	}
+}
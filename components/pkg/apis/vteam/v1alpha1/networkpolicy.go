@@ -0,0 +1,48 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// allowedDomainPattern matches a lower-case DNS name of at least two labels, optionally
+// prefixed with "*." for its subdomains. It mirrors the pattern in projectsettings-crd.yaml.
+var allowedDomainPattern = regexp.MustCompile(`^(\*\.)?([a-z0-9]([-a-z0-9]*[a-z0-9])?\.)+[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Validate checks every allowed domain
+func (p *AgentNetworkPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for i, d := range p.AllowedDomains {
+		if len(d) > 253 || !allowedDomainPattern.MatchString(d) {
+			return fmt.Errorf("allowedDomains[%d]: %q is not a lower-case domain name or *.domain", i, d)
+		}
+	}
+	return nil
+}
+
+// Restricted reports whether the policy limits web access at all
+func (p *AgentNetworkPolicy) Restricted() bool {
+	return p != nil && len(p.AllowedDomains) > 0
+}
+
+// DomainAllowed reports whether the agent may fetch from host. An unrestricted policy
+// allows every host; "*.example.com" allows the subdomains of example.com but not itself.
+func (p *AgentNetworkPolicy) DomainAllowed(host string) bool {
+	if !p.Restricted() {
+		return true
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, d := range p.AllowedDomains {
+		if suffix, ok := strings.CutPrefix(d, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if host == d {
+			return true
+		}
+	}
+	return false
+}
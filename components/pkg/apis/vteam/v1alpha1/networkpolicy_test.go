@@ -0,0 +1,39 @@
+package v1alpha1
+
+import "testing"
+
+func TestAgentNetworkPolicy_DomainAllowed(t *testing.T) {
+	p := &AgentNetworkPolicy{AllowedDomains: []string{"docs.python.org", "*.readthedocs.io"}}
+	cases := map[string]bool{
+		"docs.python.org":         true,
+		"Docs.Python.org.":        true,
+		"python.org":              false,
+		"evil-docs.python.org":    false,
+		"requests.readthedocs.io": true,
+		"a.b.readthedocs.io":      true,
+		"readthedocs.io":          false,
+		"notreadthedocs.io":       false,
+	}
+	for host, want := range cases {
+		if got := p.DomainAllowed(host); got != want {
+			t.Errorf("DomainAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+
+	var open *AgentNetworkPolicy
+	if open.Restricted() || !open.DomainAllowed("example.com") {
+		t.Error("a nil policy must leave web access open")
+	}
+}
+
+func TestAgentNetworkPolicy_Validate(t *testing.T) {
+	valid := &AgentNetworkPolicy{AllowedDomains: []string{"docs.python.org", "*.readthedocs.io", "pkg.go.dev"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	for _, d := range []string{"localhost", "Docs.Python.org", "https://docs.python.org", "docs.python.org/3", "*", "*.io.", "a.*.io", "-bad.example.com"} {
+		if err := (&AgentNetworkPolicy{AllowedDomains: []string{d}}).Validate(); err == nil {
+			t.Errorf("Validate() accepted %q", d)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ObjectMetadata is what an ObjectMetadataPolicy adds to the objects of one session, or to
+// the project's own objects
+type ObjectMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Validate checks the keys are valid label and annotation keys and the label values are
+// valid label values
+func (p *ObjectMetadataPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for key, value := range p.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("labels: key %q: %s", key, errs[0])
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("labels: value of %q: %s", key, errs[0])
+		}
+	}
+	for key := range p.Annotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("annotations: key %q: %s", key, errs[0])
+		}
+	}
+	for _, key := range p.RequiredLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("requiredLabels: %q: %s", key, errs[0])
+		}
+	}
+	for _, key := range p.RequiredAnnotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("requiredAnnotations: %q: %s", key, errs[0])
+		}
+	}
+	return nil
+}
+
+// Missing lists the required keys neither the session's labels and annotations nor the
+// policy set, as "label <key>" and "annotation <key>"
+func (p *ObjectMetadataPolicy) Missing(labels, annotations map[string]string) []string {
+	if p == nil {
+		return nil
+	}
+	var missing []string
+	for _, key := range p.RequiredLabels {
+		if _, ok := p.Labels[key]; !ok && labels[key] == "" {
+			missing = append(missing, "label "+key)
+		}
+	}
+	for _, key := range p.RequiredAnnotations {
+		if _, ok := p.Annotations[key]; !ok && annotations[key] == "" {
+			missing = append(missing, "annotation "+key)
+		}
+	}
+	return missing
+}
+
+// For returns the metadata for the objects of a session carrying labels and annotations:
+// the policy's labels and annotations, and the session's values of the required keys. The
+// project's own objects get For(nil, nil).
+func (p *ObjectMetadataPolicy) For(labels, annotations map[string]string) ObjectMetadata {
+	var m ObjectMetadata
+	if p == nil {
+		return m
+	}
+	m.Labels = pickKeys(labels, p.RequiredLabels, p.Labels)
+	m.Annotations = pickKeys(annotations, p.RequiredAnnotations, p.Annotations)
+	return m
+}
+
+func pickKeys(from map[string]string, keys []string, fixed map[string]string) map[string]string {
+	out := map[string]string{}
+	for _, key := range keys {
+		if v := from[key]; v != "" {
+			out[key] = v
+		}
+	}
+	for key, v := range fixed {
+		out[key] = v
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// Apply adds the metadata to each object. Keys an object already sets are kept, so the
+// policy never breaks the labels the operator selects its objects by.
+func (m ObjectMetadata) Apply(objs ...metav1.Object) {
+	for _, obj := range objs {
+		if len(m.Labels) > 0 {
+			obj.SetLabels(mergeMissing(obj.GetLabels(), m.Labels))
+		}
+		if len(m.Annotations) > 0 {
+			obj.SetAnnotations(mergeMissing(obj.GetAnnotations(), m.Annotations))
+		}
+	}
+}
+
+func mergeMissing(current, add map[string]string) map[string]string {
+	if current == nil {
+		current = make(map[string]string, len(add))
+	}
+	for key, value := range add {
+		if _, ok := current[key]; !ok {
+			current[key] = value
+		}
+	}
+	return current
+}
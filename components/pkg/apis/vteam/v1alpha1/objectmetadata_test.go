@@ -0,0 +1,72 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObjectMetadataPolicy_Missing(t *testing.T) {
+	p := &ObjectMetadataPolicy{
+		Labels:              map[string]string{"data-classification": "internal"},
+		RequiredLabels:      []string{"data-classification", "owner-team"},
+		RequiredAnnotations: []string{"example.com/cost-center"},
+	}
+	got := p.Missing(map[string]string{"owner-team": ""}, nil)
+	want := []string{"label owner-team", "annotation example.com/cost-center"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Missing() = %v, want %v", got, want)
+	}
+	if got := p.Missing(map[string]string{"owner-team": "payments"}, map[string]string{"example.com/cost-center": "42"}); got != nil {
+		t.Errorf("Missing() = %v for a complete session", got)
+	}
+	var none *ObjectMetadataPolicy
+	if none.Missing(nil, nil) != nil || none.For(nil, nil).Labels != nil {
+		t.Error("no policy should require or add nothing")
+	}
+}
+
+// TestObjectMetadata_Apply verifies the session's required values and the policy's values
+// are added, and keys the object sets are kept
+func TestObjectMetadata_Apply(t *testing.T) {
+	p := &ObjectMetadataPolicy{
+		Labels:         map[string]string{"data-classification": "internal", "app": "compliance"},
+		Annotations:    map[string]string{"example.com/contact": "sec@example.com"},
+		RequiredLabels: []string{"owner-team"},
+	}
+	meta := p.For(map[string]string{"owner-team": "payments", "data-classification": "public", "other": "x"}, nil)
+	obj := &metav1.ObjectMeta{Labels: map[string]string{"app": "ambient-code-runner"}}
+	meta.Apply(obj)
+
+	wantLabels := map[string]string{"app": "ambient-code-runner", "owner-team": "payments", "data-classification": "internal"}
+	if !reflect.DeepEqual(obj.Labels, wantLabels) {
+		t.Errorf("labels = %v, want %v", obj.Labels, wantLabels)
+	}
+	if obj.Annotations["example.com/contact"] != "sec@example.com" {
+		t.Errorf("annotations = %v", obj.Annotations)
+	}
+}
+
+func TestObjectMetadataPolicy_Validate(t *testing.T) {
+	ok := &ObjectMetadataPolicy{
+		Labels:              map[string]string{"example.com/data-classification": "internal"},
+		Annotations:         map[string]string{"example.com/contact": "Security team <sec@example.com>"},
+		RequiredLabels:      []string{"owner-team"},
+		RequiredAnnotations: []string{"example.com/cost-center"},
+	}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	for _, p := range []ObjectMetadataPolicy{
+		{Labels: map[string]string{"bad key": "x"}},
+		{Labels: map[string]string{"team": "not a label value"}},
+		{Annotations: map[string]string{"-bad": "x"}},
+		{RequiredLabels: []string{"a/b/c"}},
+		{RequiredAnnotations: []string{""}},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v", p)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// A Preview runs the deployable artifact of a completed session (status.result.preview) as a
+// Deployment, Service and Route in the session's namespace, so reviewers can click through the
+// change. The operator deletes the Preview, and with it everything it created, after its TTL.
+
+// PreviewPhase is the lifecycle phase of a Preview
+type PreviewPhase string
+
+const (
+	PreviewPending PreviewPhase = "Pending"
+	PreviewReady   PreviewPhase = "Ready"
+	PreviewFailed  PreviewPhase = "Failed"
+)
+
+const (
+	// DefaultPreviewTTL is how long a Preview lives when spec.ttl is empty
+	DefaultPreviewTTL = 24 * time.Hour
+	// MaxPreviewTTL bounds spec.ttl so forgotten previews do not hold resources for long
+	MaxPreviewTTL = 7 * 24 * time.Hour
+	// DefaultPreviewPort is the container port when neither the spec nor the artifact sets one
+	DefaultPreviewPort = 8080
+	// PreviewSessionLabel names the session on a Preview and the objects created for it
+	PreviewSessionLabel = "ambient-code.io/preview-session"
+)
+
+// PreviewSpec mirrors spec in previews-crd.yaml
+type PreviewSpec struct {
+	// SessionName is the completed session whose artifact is previewed
+	SessionName string `json:"sessionName"`
+	Image       string `json:"image"`
+	Port        int32  `json:"port,omitempty"`
+	// TTL is a duration such as "4h"; empty means DefaultPreviewTTL
+	TTL string `json:"ttl,omitempty"`
+}
+
+// PreviewStatus mirrors status in previews-crd.yaml
+type PreviewStatus struct {
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	Phase              PreviewPhase `json:"phase,omitempty"`
+	Message            string       `json:"message,omitempty"`
+	// URL is where the preview is reachable once it is Ready
+	URL       string       `json:"url,omitempty"`
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// PreviewName is the name of a session's Preview; a session has at most one
+func PreviewName(sessionName string) string {
+	name := "preview-" + sessionName
+	// Route and Service names are DNS labels
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// ParsePreviewTTL returns spec.ttl as a duration, DefaultPreviewTTL when empty
+func ParsePreviewTTL(ttl string) (time.Duration, error) {
+	if strings.TrimSpace(ttl) == "" {
+		return DefaultPreviewTTL, nil
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl %q: %v", ttl, err)
+	}
+	if d <= 0 || d > MaxPreviewTTL {
+		return 0, fmt.Errorf("ttl must be between 0 and %s", MaxPreviewTTL)
+	}
+	return d, nil
+}
+
+// Validate checks the fields the CRD schema cannot
+func (s *PreviewSpec) Validate() error {
+	if s.SessionName == "" || strings.TrimSpace(s.Image) == "" {
+		return fmt.Errorf("sessionName and image are required")
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("invalid port %d", s.Port)
+	}
+	_, err := ParsePreviewTTL(s.TTL)
+	return err
+}
+
+// ContainerPort is spec.port, DefaultPreviewPort when unset
+func (s *PreviewSpec) ContainerPort() int32 {
+	if s.Port == 0 {
+		return DefaultPreviewPort
+	}
+	return s.Port
+}
@@ -0,0 +1,35 @@
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviewSpec(t *testing.T) {
+	spec := PreviewSpec{SessionName: "s1", Image: "quay.io/org/app:pr-1"}
+	if err := spec.Validate(); err != nil || spec.ContainerPort() != DefaultPreviewPort {
+		t.Errorf("default spec: %v, port %d", err, spec.ContainerPort())
+	}
+	if ttl, _ := ParsePreviewTTL(""); ttl != DefaultPreviewTTL {
+		t.Errorf("default ttl = %s", ttl)
+	}
+	for _, ttl := range []string{"8d", "-1h", "0s", "soon"} {
+		spec.TTL = ttl
+		if spec.Validate() == nil {
+			t.Errorf("ttl %q accepted", ttl)
+		}
+	}
+	spec.TTL = "4h"
+	if d, err := ParsePreviewTTL(spec.TTL); err != nil || d != 4*time.Hour {
+		t.Errorf("ParsePreviewTTL(4h) = %s, %v", d, err)
+	}
+	if (&PreviewSpec{SessionName: "s1"}).Validate() == nil {
+		t.Error("spec without image accepted")
+	}
+
+	name := PreviewName(strings.Repeat("a", 60) + "-b")
+	if len(name) > 63 || strings.HasSuffix(name, "-") {
+		t.Errorf("PreviewName = %q", name)
+	}
+}
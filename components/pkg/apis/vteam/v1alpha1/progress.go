@@ -0,0 +1,138 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProgressAnnotation carries the runner's step reports: a JSON list of StepReport. The runner
+// owns the annotation and the operator maps it to status.steps and status.progress, so the
+// reports survive the status being reset when a session restarts.
+const ProgressAnnotation = "ambient-code.io/progress"
+
+// SessionStepState is the state of one step of a session run
+type SessionStepState string
+
+const (
+	StepPending   SessionStepState = "Pending"
+	StepRunning   SessionStepState = "Running"
+	StepCompleted SessionStepState = "Completed"
+	StepFailed    SessionStepState = "Failed"
+	StepSkipped   SessionStepState = "Skipped"
+)
+
+// IsValid reports whether s is one of the defined step states
+func (s SessionStepState) IsValid() bool {
+	switch s {
+	case StepPending, StepRunning, StepCompleted, StepFailed, StepSkipped:
+		return true
+	}
+	return false
+}
+
+// IsDone reports whether the step will not change again
+func (s SessionStepState) IsDone() bool {
+	return s == StepCompleted || s == StepFailed || s == StepSkipped
+}
+
+// SessionStepNames are the steps of a run, in order. status.steps always lists all of them.
+var SessionStepNames = []string{"clone", "analyze", "edit", "test", "publish"}
+
+// StepReport is what the runner reports for one step
+type StepReport struct {
+	Name    string           `json:"name"`
+	State   SessionStepState `json:"state"`
+	Message string           `json:"message,omitempty"`
+}
+
+// SessionStep is one entry of status.steps
+type SessionStep struct {
+	Name        string           `json:"name"`
+	State       SessionStepState `json:"state"`
+	Message     string           `json:"message,omitempty"`
+	StartedAt   *metav1.Time     `json:"startedAt,omitempty"`
+	CompletedAt *metav1.Time     `json:"completedAt,omitempty"`
+}
+
+// ParseStepReports decodes the progress annotation. Reports for unknown steps are dropped so
+// a newer runner can report steps an older operator does not know.
+func ParseStepReports(raw string) ([]StepReport, error) {
+	var reports []StepReport
+	if err := json.Unmarshal([]byte(raw), &reports); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", ProgressAnnotation, err)
+	}
+	known := map[string]bool{}
+	for _, name := range SessionStepNames {
+		known[name] = true
+	}
+	valid := reports[:0]
+	for _, r := range reports {
+		if !known[r.Name] {
+			continue
+		}
+		if !r.State.IsValid() {
+			return nil, fmt.Errorf("invalid state %q for step %s", r.State, r.Name)
+		}
+		valid = append(valid, r)
+	}
+	return valid, nil
+}
+
+// MergeSteps applies reports to the current status.steps and returns every step in order.
+// Steps that were not reported stay Pending. A step gets startedAt when it is first seen
+// past Pending and completedAt when it is done; existing timestamps are kept.
+func MergeSteps(current []SessionStep, reports []StepReport, now metav1.Time) []SessionStep {
+	byName := map[string]SessionStep{}
+	for _, s := range current {
+		byName[s.Name] = s
+	}
+	reported := map[string]StepReport{}
+	for _, r := range reports {
+		reported[r.Name] = r
+	}
+	steps := make([]SessionStep, 0, len(SessionStepNames))
+	for _, name := range SessionStepNames {
+		step := byName[name]
+		step.Name = name
+		if step.State == "" {
+			step.State = StepPending
+		}
+		if r, ok := reported[name]; ok {
+			step.State = r.State
+			step.Message = r.Message
+		}
+		if step.State != StepPending && step.StartedAt == nil {
+			t := now
+			step.StartedAt = &t
+		}
+		if step.State.IsDone() && step.CompletedAt == nil {
+			t := now
+			step.CompletedAt = &t
+		}
+		if !step.State.IsDone() {
+			step.CompletedAt = nil
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// ProgressPercent is the overall progress of the steps: done steps count fully and a running
+// step counts half. A failed step is done; the session phase tells whether the run failed.
+func ProgressPercent(steps []SessionStep) int {
+	if len(steps) == 0 {
+		return 0
+	}
+	halves := 0
+	for _, s := range steps {
+		switch {
+		case s.State.IsDone():
+			halves += 2
+		case s.State == StepRunning:
+			halves++
+		}
+	}
+	return halves * 100 / (2 * len(steps))
+}
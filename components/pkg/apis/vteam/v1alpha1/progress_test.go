@@ -0,0 +1,67 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseStepReports verifies unknown steps are dropped and unknown states rejected
+func TestParseStepReports(t *testing.T) {
+	reports, err := ParseStepReports(`[{"name":"clone","state":"Completed"},{"name":"lint","state":"Running"}]`)
+	if err != nil {
+		t.Fatalf("ParseStepReports failed: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Name != "clone" {
+		t.Errorf("Unexpected reports: %+v", reports)
+	}
+	if _, err := ParseStepReports(`[{"name":"edit","state":"Done"}]`); err == nil {
+		t.Error("Expected an error for an unknown state")
+	}
+	if _, err := ParseStepReports(`not json`); err == nil {
+		t.Error("Expected an error for malformed JSON")
+	}
+}
+
+// TestMergeSteps verifies every step is listed in order and timestamps are set once
+func TestMergeSteps(t *testing.T) {
+	t0 := metav1.NewTime(time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC))
+	t1 := metav1.NewTime(t0.Add(time.Minute))
+
+	steps := MergeSteps(nil, []StepReport{{Name: "analyze", State: StepRunning}, {Name: "clone", State: StepCompleted}}, t0)
+	if len(steps) != len(SessionStepNames) || steps[0].Name != "clone" || steps[4].Name != "publish" {
+		t.Fatalf("Unexpected steps: %+v", steps)
+	}
+	if steps[0].CompletedAt == nil || steps[1].StartedAt == nil || steps[1].CompletedAt != nil || steps[2].StartedAt != nil {
+		t.Errorf("Unexpected timestamps: %+v", steps)
+	}
+	if got := ProgressPercent(steps); got != 30 {
+		t.Errorf("Expected 30%%, got %d", got)
+	}
+
+	steps = MergeSteps(steps, []StepReport{{Name: "analyze", State: StepCompleted, Message: "3 files"}}, t1)
+	if !steps[1].StartedAt.Equal(&t0) || !steps[1].CompletedAt.Equal(&t1) || steps[1].Message != "3 files" {
+		t.Errorf("Expected analyze started at t0 and completed at t1, got %+v", steps[1])
+	}
+	if steps[0].State != StepCompleted {
+		t.Errorf("Expected an unreported step to keep its state, got %+v", steps[0])
+	}
+}
+
+// TestProgressPercent verifies done steps count fully and running steps half
+func TestProgressPercent(t *testing.T) {
+	steps := MergeSteps(nil, []StepReport{
+		{Name: "clone", State: StepCompleted},
+		{Name: "analyze", State: StepCompleted},
+		{Name: "edit", State: StepCompleted},
+		{Name: "test", State: StepSkipped},
+		{Name: "publish", State: StepCompleted},
+	}, metav1.Now())
+	if got := ProgressPercent(steps); got != 100 {
+		t.Errorf("Expected 100%%, got %d", got)
+	}
+	if got := ProgressPercent(nil); got != 0 {
+		t.Errorf("Expected 0%% without steps, got %d", got)
+	}
+}
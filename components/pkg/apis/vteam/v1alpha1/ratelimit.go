@@ -0,0 +1,55 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Runners report the model provider's rate limits in status.rateLimit. The operator groups
+// sessions by the provider credential they use (RateLimitKeyLabel) and holds new session
+// starts for a key while one of its sessions is being rate limited.
+
+// RateLimitKeyLabel names the provider credential a session and its runner Job use: the
+// provider and a fingerprint of the key, account or project, never the credential itself
+const RateLimitKeyLabel = "ambient-code.io/rate-limit-key"
+
+// RateLimitStatus mirrors status.rateLimit in agenticsessions-crd.yaml
+type RateLimitStatus struct {
+	// Limited is true while the provider rejects the session's requests with 429s
+	Limited bool `json:"limited"`
+	// RetryAfter is when the provider said requests are accepted again
+	RetryAfter *metav1.Time `json:"retryAfter,omitempty"`
+	// RequestsRemaining and TokensRemaining are the provider's last reported headroom
+	RequestsRemaining *int64 `json:"requestsRemaining,omitempty"`
+	TokensRemaining   *int64 `json:"tokensRemaining,omitempty"`
+	// ResetAt is when the provider's current rate limit window ends
+	ResetAt *metav1.Time `json:"resetAt,omitempty"`
+	// Throttled counts the rate limit errors the session has seen
+	Throttled  int         `json:"throttled,omitempty"`
+	ObservedAt metav1.Time `json:"observedAt"`
+}
+
+// Validate checks the fields the CRD schema cannot
+func (s *RateLimitStatus) Validate() error {
+	if s.ObservedAt.IsZero() {
+		return fmt.Errorf("rateLimit.observedAt is required")
+	}
+	if s.Throttled < 0 || (s.RequestsRemaining != nil && *s.RequestsRemaining < 0) || (s.TokensRemaining != nil && *s.TokensRemaining < 0) {
+		return fmt.Errorf("rateLimit counts must not be negative")
+	}
+	return nil
+}
+
+// HoldUntil is when new sessions on the same key may start again, zero when the session is
+// not rate limited. Without a retry time from the provider the report holds for backoff.
+func (s *RateLimitStatus) HoldUntil(backoff time.Duration) time.Time {
+	if !s.Limited {
+		return time.Time{}
+	}
+	if s.RetryAfter != nil {
+		return s.RetryAfter.Time
+	}
+	return s.ObservedAt.Add(backoff)
+}
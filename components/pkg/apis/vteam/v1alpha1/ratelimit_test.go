@@ -0,0 +1,36 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRateLimitStatusHoldUntil(t *testing.T) {
+	observed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := RateLimitStatus{Limited: true, ObservedAt: metav1.NewTime(observed)}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.HoldUntil(time.Minute); !got.Equal(observed.Add(time.Minute)) {
+		t.Errorf("without retryAfter: %s", got)
+	}
+	retry := metav1.NewTime(observed.Add(20 * time.Second))
+	s.RetryAfter = &retry
+	if got := s.HoldUntil(time.Minute); !got.Equal(retry.Time) {
+		t.Errorf("with retryAfter: %s", got)
+	}
+	s.Limited = false
+	if got := s.HoldUntil(time.Minute); !got.IsZero() {
+		t.Errorf("not limited: %s", got)
+	}
+
+	negative := int64(-1)
+	if (&RateLimitStatus{ObservedAt: s.ObservedAt, TokensRemaining: &negative}).Validate() == nil {
+		t.Error("negative tokensRemaining accepted")
+	}
+	if (&RateLimitStatus{Limited: true}).Validate() == nil {
+		t.Error("report without observedAt accepted")
+	}
+}
@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the API group of the vteam custom resources
+const GroupName = "vteam.ambient-code"
+
+// SchemeGroupVersion is the group version used to register these objects
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// Resource takes an unqualified resource and returns a Group qualified GroupResource
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
+
+var (
+	// SchemeBuilder registers the vteam types with a scheme
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	// AddToScheme adds the vteam types to a scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion,
+		&AgenticSession{},
+		&AgenticSessionList{},
+		&ProjectSettings{},
+		&ProjectSettingsList{},
+	)
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+	return nil
+}
+
+// AgenticSessionGVR is the GroupVersionResource of AgenticSession
+func AgenticSessionGVR() schema.GroupVersionResource {
+	return SchemeGroupVersion.WithResource("agenticsessions")
+}
+
+// ProjectSettingsGVR is the GroupVersionResource of ProjectSettings
+func ProjectSettingsGVR() schema.GroupVersionResource {
+	return SchemeGroupVersion.WithResource("projectsettings")
+}
+
+// SecretDistributionGVR is the GroupVersionResource of the cluster-scoped SecretDistribution
+func SecretDistributionGVR() schema.GroupVersionResource {
+	return SchemeGroupVersion.WithResource("secretdistributions")
+}
+
+// SessionBatchGVR is the GroupVersionResource of SessionBatch
+func SessionBatchGVR() schema.GroupVersionResource {
+	return SchemeGroupVersion.WithResource("sessionbatches")
+}
+
+// AgentGVR is the GroupVersionResource of Agent
+func AgentGVR() schema.GroupVersionResource {
+	return SchemeGroupVersion.WithResource("agents")
+}
+
+// PreviewGVR is the GroupVersionResource of Preview
+func PreviewGVR() schema.GroupVersionResource {
+	return SchemeGroupVersion.WithResource("previews")
+}
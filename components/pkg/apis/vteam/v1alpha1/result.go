@@ -0,0 +1,129 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SessionOutcome is the overall outcome of a finished session run
+type SessionOutcome string
+
+const (
+	// OutcomeSucceeded means the task was completed
+	OutcomeSucceeded SessionOutcome = "Succeeded"
+	// OutcomePartial means some of the task was completed and follow-ups remain
+	OutcomePartial SessionOutcome = "Partial"
+	// OutcomeNoChanges means the run finished without changing anything
+	OutcomeNoChanges SessionOutcome = "NoChanges"
+	// OutcomeFailed means the run ended with an error
+	OutcomeFailed SessionOutcome = "Failed"
+	// OutcomeInterrupted means the run was stopped before it finished
+	OutcomeInterrupted SessionOutcome = "Interrupted"
+)
+
+// SessionOutcomes lists every valid outcome, in the order used by the CRD enum
+var SessionOutcomes = []SessionOutcome{OutcomeSucceeded, OutcomePartial, OutcomeNoChanges, OutcomeFailed, OutcomeInterrupted}
+
+// IsValid reports whether o is one of the defined outcomes
+func (o SessionOutcome) IsValid() bool {
+	for _, v := range SessionOutcomes {
+		if o == v {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxResultSummaryLength bounds status.result.summary so results cannot bloat the CR
+const MaxResultSummaryLength = 10000
+
+// SessionResult is the typed status.result of an AgenticSession. Downstream automation
+// should rely on these fields rather than parsing the runner's free-form output.
+type SessionResult struct {
+	Outcome      SessionOutcome `json:"outcome"`
+	Summary      string         `json:"summary,omitempty"`
+	PRURLs       []string       `json:"prURLs,omitempty"`
+	FilesChanged int            `json:"filesChanged,omitempty"`
+	TestsRun     int            `json:"testsRun,omitempty"`
+	FollowUps    []string       `json:"followUps,omitempty"`
+	// Preview is a deployable build of the change a Preview can run for reviewers
+	Preview *PreviewArtifact `json:"preview,omitempty"`
+}
+
+// PreviewArtifact is a container image the run built from its change, serving HTTP on Port
+type PreviewArtifact struct {
+	Image string `json:"image"`
+	Port  int32  `json:"port,omitempty"`
+}
+
+// Validate checks the outcome enum and field bounds
+func (r *SessionResult) Validate() error {
+	if !r.Outcome.IsValid() {
+		return fmt.Errorf("invalid result outcome %q", r.Outcome)
+	}
+	if len(r.Summary) > MaxResultSummaryLength {
+		return fmt.Errorf("result summary exceeds %d characters", MaxResultSummaryLength)
+	}
+	if r.FilesChanged < 0 || r.TestsRun < 0 {
+		return fmt.Errorf("result counts must not be negative")
+	}
+	for _, u := range r.PRURLs {
+		if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+			return fmt.Errorf("invalid PR URL %q", u)
+		}
+	}
+	if r.Preview != nil {
+		if strings.TrimSpace(r.Preview.Image) == "" {
+			return fmt.Errorf("result preview needs an image")
+		}
+		if r.Preview.Port < 0 || r.Preview.Port > 65535 {
+			return fmt.Errorf("invalid result preview port %d", r.Preview.Port)
+		}
+	}
+	return nil
+}
+
+// ParseSessionResult converts a status.result value read from an unstructured object into a
+// SessionResult. Sessions written before the typed schema stored the runner's output as a
+// plain string; that is returned as the summary, with the outcome taken from isError.
+func ParseSessionResult(v interface{}, isError bool) (*SessionResult, error) {
+	switch raw := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		outcome := OutcomeSucceeded
+		if isError {
+			outcome = OutcomeFailed
+		}
+		if len(raw) > MaxResultSummaryLength {
+			raw = raw[:MaxResultSummaryLength]
+		}
+		return &SessionResult{Outcome: outcome, Summary: raw}, nil
+	case map[string]interface{}:
+		b, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		result := &SessionResult{}
+		if err := json.Unmarshal(b, result); err != nil {
+			return nil, fmt.Errorf("invalid session result: %w", err)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported session result type %T", v)
+	}
+}
+
+// ToUnstructured renders the result as the map stored in status.result
+func (r *SessionResult) ToUnstructured() (map[string]interface{}, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
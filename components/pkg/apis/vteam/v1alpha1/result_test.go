@@ -0,0 +1,47 @@
+package v1alpha1
+
+import "testing"
+
+// TestParseSessionResult_Typed verifies a structured status.result round-trips
+func TestParseSessionResult_Typed(t *testing.T) {
+	raw := map[string]interface{}{
+		"outcome":      "Partial",
+		"summary":      "Fixed the parser",
+		"prURLs":       []interface{}{"https://github.com/org/repo/pull/7"},
+		"filesChanged": float64(3),
+		"testsRun":     int64(12),
+		"followUps":    []interface{}{"Add docs"},
+	}
+	result, err := ParseSessionResult(raw, false)
+	if err != nil {
+		t.Fatalf("ParseSessionResult failed: %v", err)
+	}
+	if result.Outcome != OutcomePartial || result.FilesChanged != 3 || result.TestsRun != 12 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.PRURLs) != 1 || len(result.FollowUps) != 1 {
+		t.Errorf("Expected 1 PR URL and 1 follow-up, got %+v", result)
+	}
+	if err := result.Validate(); err != nil {
+		t.Errorf("Expected valid result, got %v", err)
+	}
+}
+
+// TestParseSessionResult_LegacyString verifies pre-schema string results become a summary
+func TestParseSessionResult_LegacyString(t *testing.T) {
+	result, err := ParseSessionResult("all done", true)
+	if err != nil {
+		t.Fatalf("ParseSessionResult failed: %v", err)
+	}
+	if result.Outcome != OutcomeFailed || result.Summary != "all done" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+// TestSessionResultValidate_RejectsUnknownOutcome verifies the outcome enum is enforced
+func TestSessionResultValidate_RejectsUnknownOutcome(t *testing.T) {
+	result := &SessionResult{Outcome: "Done"}
+	if err := result.Validate(); err == nil {
+		t.Error("Expected an error for an unknown outcome")
+	}
+}
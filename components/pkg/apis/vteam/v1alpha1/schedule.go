@@ -0,0 +1,132 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+	// The operator and backend images may ship without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// scheduleDays maps the Days of a ScheduleWindow to weekdays
+var scheduleDays = map[string]time.Weekday{
+	"Sun": time.Sunday, "Mon": time.Monday, "Tue": time.Tuesday, "Wed": time.Wednesday,
+	"Thu": time.Thursday, "Fri": time.Friday, "Sat": time.Saturday,
+}
+
+// Validate checks the time zone, the sessions selector and every window
+func (s *SessionSchedule) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if _, err := s.location(); err != nil {
+		return err
+	}
+	switch s.Sessions {
+	case "", ScheduleBatchSessions, ScheduleAllSessions:
+	default:
+		return fmt.Errorf("sessions must be %s or %s", ScheduleBatchSessions, ScheduleAllSessions)
+	}
+	if len(s.Windows) == 0 {
+		return fmt.Errorf("windows must list at least one window")
+	}
+	for i, w := range s.Windows {
+		if _, _, err := parseClock(w.Start); err != nil {
+			return fmt.Errorf("windows[%d].start: %v", i, err)
+		}
+		if _, _, err := parseClock(w.End); err != nil {
+			return fmt.Errorf("windows[%d].end: %v", i, err)
+		}
+		for _, d := range w.Days {
+			if _, ok := scheduleDays[d]; !ok {
+				return fmt.Errorf("windows[%d].days: %q is not one of Mon, Tue, Wed, Thu, Fri, Sat, Sun", i, d)
+			}
+		}
+	}
+	return nil
+}
+
+// Applies reports whether the schedule holds a session; Batch schedules leave interactive
+// sessions alone
+func (s *SessionSchedule) Applies(interactive bool) bool {
+	if s == nil || len(s.Windows) == 0 {
+		return false
+	}
+	return s.Sessions == ScheduleAllSessions || !interactive
+}
+
+// Open reports whether a window is open at now. When none is, next is the time the next
+// window opens (zero if the schedule is invalid).
+func (s *SessionSchedule) Open(now time.Time) (open bool, next time.Time) {
+	loc, err := s.location()
+	if err != nil {
+		return false, time.Time{}
+	}
+	local := now.In(loc)
+	// A window that opened yesterday may still be open; one opens within the next week
+	for offset := -1; offset <= 7; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		for _, w := range s.Windows {
+			start, end, ok := w.onDay(day)
+			if !ok {
+				continue
+			}
+			if !now.Before(start) && now.Before(end) {
+				return true, time.Time{}
+			}
+			if start.After(now) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+	return false, next
+}
+
+func (s *SessionSchedule) location() (*time.Location, error) {
+	if s.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("timeZone: unknown time zone %q", s.TimeZone)
+	}
+	return loc, nil
+}
+
+// onDay returns when the window opens and closes if it opens on day (midnight, local time)
+func (w ScheduleWindow) onDay(day time.Time) (start, end time.Time, ok bool) {
+	if len(w.Days) > 0 {
+		ok = false
+		for _, d := range w.Days {
+			if wd, known := scheduleDays[d]; known && wd == day.Weekday() {
+				ok = true
+			}
+		}
+		if !ok {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	startH, startM, err := parseClock(w.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	endH, endM, err := parseClock(w.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	y, m, d := day.Date()
+	start = time.Date(y, m, d, startH, startM, 0, 0, day.Location())
+	end = time.Date(y, m, d, endH, endM, 0, 0, day.Location())
+	if !end.After(start) {
+		end = time.Date(y, m, d+1, endH, endM, 0, 0, day.Location())
+	}
+	return start, end, true
+}
+
+// parseClock parses HH:MM
+func parseClock(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a HH:MM time", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
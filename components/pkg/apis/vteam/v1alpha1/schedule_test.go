@@ -0,0 +1,72 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionSchedule_Open(t *testing.T) {
+	// Weeknights 20:00-06:00 and all weekend, Berlin time (UTC+1 in January)
+	s := &SessionSchedule{TimeZone: "Europe/Berlin", Windows: []ScheduleWindow{
+		{Days: []string{"Mon", "Tue", "Wed", "Thu", "Fri"}, Start: "20:00", End: "06:00"},
+		{Days: []string{"Sat", "Sun"}, Start: "00:00", End: "00:00"},
+	}}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 1, day, hour, minute, 0, 0, berlin) }
+
+	for _, tc := range []struct {
+		name string
+		now  time.Time
+		open bool
+		next time.Time
+	}{
+		{"Tuesday afternoon", at(6, 15, 0), false, at(6, 20, 0)},
+		{"Tuesday night", at(6, 23, 0), true, time.Time{}},
+		{"Wednesday early morning", at(7, 5, 59), true, time.Time{}},
+		{"Wednesday morning", at(7, 6, 0), false, at(7, 20, 0)},
+		{"Saturday noon", at(10, 12, 0), true, time.Time{}},
+		{"Monday early morning", at(12, 5, 0), false, at(12, 20, 0)},
+		{"just after the weekend", at(12, 0, 30), false, at(12, 20, 0)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			open, next := s.Open(tc.now.UTC())
+			if open != tc.open || !next.Equal(tc.next) {
+				t.Errorf("Open(%v) = %v, %v; want %v, %v", tc.now, open, next, tc.open, tc.next)
+			}
+		})
+	}
+}
+
+func TestSessionSchedule_Applies(t *testing.T) {
+	batch := &SessionSchedule{Windows: []ScheduleWindow{{Start: "22:00", End: "06:00"}}}
+	if !batch.Applies(false) || batch.Applies(true) {
+		t.Error("a Batch schedule should hold only non-interactive sessions")
+	}
+	all := &SessionSchedule{Sessions: ScheduleAllSessions, Windows: batch.Windows}
+	if !all.Applies(true) {
+		t.Error("an All schedule should hold interactive sessions")
+	}
+	var none *SessionSchedule
+	if none.Applies(false) {
+		t.Error("no schedule should hold nothing")
+	}
+}
+
+func TestSessionSchedule_Validate(t *testing.T) {
+	ok := SessionSchedule{TimeZone: "America/New_York", Windows: []ScheduleWindow{{Days: []string{"Sat"}, Start: "08:00", End: "18:30"}}}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	for _, s := range []SessionSchedule{
+		{TimeZone: "Mars/Olympus", Windows: ok.Windows},
+		{Sessions: "Interactive", Windows: ok.Windows},
+		{},
+		{Windows: []ScheduleWindow{{Start: "8am", End: "18:00"}}},
+		{Windows: []ScheduleWindow{{Start: "08:00", End: "24:00"}}},
+		{Windows: []ScheduleWindow{{Days: []string{"Saturday"}, Start: "08:00", End: "18:00"}}},
+	} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v", s)
+		}
+	}
+}
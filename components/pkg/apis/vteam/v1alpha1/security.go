@@ -0,0 +1,35 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// capabilityName matches Linux capability names as Kubernetes takes them, without the CAP_ prefix
+var capabilityName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// Validate checks the fields' format; whether the namespace's Pod Security level admits the
+// hardened pod is checked by the podsecurity package
+func (s *RunnerSecurity) Validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.SeccompProfile {
+	case "", SeccompRuntimeDefault, SeccompUnconfined:
+		if s.LocalhostProfile != "" {
+			return fmt.Errorf("localhostProfile requires seccompProfile %s", SeccompLocalhost)
+		}
+	case SeccompLocalhost:
+		if s.LocalhostProfile == "" {
+			return fmt.Errorf("seccompProfile %s requires localhostProfile", SeccompLocalhost)
+		}
+	default:
+		return fmt.Errorf("seccompProfile must be %s, %s or %s", SeccompRuntimeDefault, SeccompLocalhost, SeccompUnconfined)
+	}
+	for _, c := range s.DropCapabilities {
+		if !capabilityName.MatchString(c) {
+			return fmt.Errorf("dropCapabilities: invalid capability %q (use e.g. ALL or NET_RAW)", c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package v1alpha1
+
+import "testing"
+
+func TestRunnerSecurity_Validate(t *testing.T) {
+	valid := []*RunnerSecurity{
+		nil,
+		{},
+		{ReadOnlyRootFilesystem: true, RunAsNonRoot: true, SeccompProfile: SeccompRuntimeDefault, DropCapabilities: []string{"ALL"}},
+		{SeccompProfile: SeccompLocalhost, LocalhostProfile: "profiles/runner.json"},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", s, err)
+		}
+	}
+	invalid := []RunnerSecurity{
+		{SeccompProfile: "runtime/default"},
+		{SeccompProfile: SeccompLocalhost},
+		{LocalhostProfile: "profiles/runner.json"},
+		{DropCapabilities: []string{"net_raw"}},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v", s)
+		}
+	}
+}
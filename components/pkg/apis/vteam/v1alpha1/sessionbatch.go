@@ -0,0 +1,84 @@
+package v1alpha1
+
+import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SessionBatchNamePrefix names the SessionBatch the backend creates for a fan-out; the rest is
+// the batch ID in SessionBatchLabel
+const SessionBatchNamePrefix = "fanout-"
+
+// SessionBatchLabel groups the sessions of one fan-out request
+const SessionBatchLabel = "ambient-code.io/fanout"
+
+// SessionBatchCompleteCondition is True once every session of the batch is in a terminal
+// phase (and at least spec.expectedSessions exist). The reason says whether all succeeded.
+const SessionBatchCompleteCondition = "Complete"
+
+// SessionBatchComplete reasons
+const (
+	SessionBatchInProgress = "InProgress"
+	SessionBatchSucceeded  = "AllSucceeded"
+	SessionBatchFailed     = "SessionsFailed"
+)
+
+// SessionBatchSpec mirrors spec in sessionbatches-crd.yaml
+type SessionBatchSpec struct {
+	// Selector picks the batch's sessions in the SessionBatch's namespace
+	Selector *metav1.LabelSelector `json:"selector"`
+	// ExpectedSessions keeps the batch incomplete until that many sessions exist; zero
+	// aggregates whatever matches
+	ExpectedSessions int `json:"expectedSessions,omitempty"`
+}
+
+// SessionBatchStatus mirrors status in sessionbatches-crd.yaml
+type SessionBatchStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Total              int                `json:"total"`
+	Phases             map[string]int     `json:"phases,omitempty"`
+	Succeeded          int                `json:"succeeded"`
+	Failed             int                `json:"failed"`
+	Active             int                `json:"active"`
+	TotalCostUSD       float64            `json:"totalCostUSD"`
+	TotalTokens        int64              `json:"totalTokens"`
+	Sessions           []SessionBatchItem `json:"sessions,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+	CompletionTime     *metav1.Time       `json:"completionTime,omitempty"`
+}
+
+// SessionBatchItem is one session of a batch
+type SessionBatchItem struct {
+	Name    string  `json:"name"`
+	Phase   string  `json:"phase"`
+	CostUSD float64 `json:"costUSD,omitempty"`
+	Tokens  int64   `json:"tokens,omitempty"`
+}
+
+// SummarizeSessionBatch aggregates the batch's sessions into a status, sorted by name, and
+// reports whether the batch is complete. Sessions without a phase count as Pending; Completed
+// sessions succeeded and the other terminal phases failed.
+func SummarizeSessionBatch(expected int, items []SessionBatchItem) (SessionBatchStatus, bool) {
+	status := SessionBatchStatus{Total: len(items), Phases: map[string]int{}}
+	for _, item := range items {
+		if item.Phase == "" {
+			item.Phase = string(SessionPhasePending)
+		}
+		status.Phases[item.Phase]++
+		status.TotalCostUSD += item.CostUSD
+		status.TotalTokens += item.Tokens
+		switch phase := AgenticSessionPhase(item.Phase); {
+		case phase == SessionPhaseCompleted:
+			status.Succeeded++
+		case phase.IsTerminal():
+			status.Failed++
+		default:
+			status.Active++
+		}
+		status.Sessions = append(status.Sessions, item)
+	}
+	sort.Slice(status.Sessions, func(i, j int) bool { return status.Sessions[i].Name < status.Sessions[j].Name })
+	complete := len(items) > 0 && status.Active == 0 && len(items) >= expected
+	return status, complete
+}
@@ -0,0 +1,35 @@
+package v1alpha1
+
+import "testing"
+
+func TestSummarizeSessionBatch(t *testing.T) {
+	items := []SessionBatchItem{
+		{Name: "s3", Phase: "Failed", CostUSD: 0.5, Tokens: 100},
+		{Name: "s1", Phase: "Completed", CostUSD: 1.25, Tokens: 2000},
+		{Name: "s2"},
+	}
+	status, complete := SummarizeSessionBatch(0, items)
+	if complete {
+		t.Error("batch with a pending session is complete")
+	}
+	if status.Total != 3 || status.Succeeded != 1 || status.Failed != 1 || status.Active != 1 || status.Phases["Pending"] != 1 {
+		t.Errorf("counts = %+v", status)
+	}
+	if status.TotalCostUSD != 1.75 || status.TotalTokens != 2100 {
+		t.Errorf("totals = $%v, %d tokens", status.TotalCostUSD, status.TotalTokens)
+	}
+	if status.Sessions[0].Name != "s1" || status.Sessions[1].Phase != "Pending" {
+		t.Errorf("sessions = %+v", status.Sessions)
+	}
+
+	items[2].Phase = "Stopped"
+	if _, complete := SummarizeSessionBatch(3, items); !complete {
+		t.Error("batch of terminal sessions is not complete")
+	}
+	if _, complete := SummarizeSessionBatch(5, items); complete {
+		t.Error("batch missing expected sessions is complete")
+	}
+	if _, complete := SummarizeSessionBatch(0, nil); complete {
+		t.Error("empty batch is complete")
+	}
+}
@@ -0,0 +1,715 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// AgenticSessionPhase is the lifecycle phase recorded in status.phase
+type AgenticSessionPhase string
+
+const (
+	SessionPhasePending   AgenticSessionPhase = "Pending"
+	SessionPhaseCreating  AgenticSessionPhase = "Creating"
+	SessionPhaseRunning   AgenticSessionPhase = "Running"
+	SessionPhaseCompleted AgenticSessionPhase = "Completed"
+	SessionPhaseFailed    AgenticSessionPhase = "Failed"
+	SessionPhaseStopped   AgenticSessionPhase = "Stopped"
+	SessionPhaseError     AgenticSessionPhase = "Error"
+)
+
+// IsTerminal reports whether the phase is final for the current run
+func (p AgenticSessionPhase) IsTerminal() bool {
+	switch p {
+	case SessionPhaseCompleted, SessionPhaseFailed, SessionPhaseStopped, SessionPhaseError:
+		return true
+	}
+	return false
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AgenticSession is a single agent run (or interactive chat) against a set of repositories
+type AgenticSession struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AgenticSessionSpec   `json:"spec,omitempty"`
+	Status AgenticSessionStatus `json:"status,omitempty"`
+}
+
+// AgenticSessionSpec mirrors spec in agenticsessions-crd.yaml
+type AgenticSessionSpec struct {
+	Prompt               string             `json:"prompt,omitempty"`
+	DisplayName          string             `json:"displayName,omitempty"`
+	Interactive          bool               `json:"interactive,omitempty"`
+	Preemptible          bool               `json:"preemptible,omitempty"`
+	AutoPushOnComplete   bool               `json:"autoPushOnComplete,omitempty"`
+	Timeout              int                `json:"timeout,omitempty"`
+	LLMSettings          *LLMSettings       `json:"llmSettings,omitempty"`
+	UserContext          *UserContext       `json:"userContext,omitempty"`
+	BotAccount           *BotAccountRef     `json:"botAccount,omitempty"`
+	ResourceOverrides    *ResourceOverrides `json:"resourceOverrides,omitempty"`
+	EnvironmentVariables map[string]string  `json:"environmentVariables,omitempty"`
+	Project              string             `json:"project,omitempty"`
+	Repos                []SessionRepo      `json:"repos,omitempty"`
+	MainRepoIndex        *int               `json:"mainRepoIndex,omitempty"`
+	MainRepoName         string             `json:"mainRepoName,omitempty"`
+	ActiveWorkflow       *WorkflowSelection `json:"activeWorkflow,omitempty"`
+	CostLimit            *CostLimit         `json:"costLimit,omitempty"`
+	WorkspaceFrom        string             `json:"workspaceFrom,omitempty"`
+	EnvFromSecrets       []SecretEnvSource  `json:"envFromSecrets,omitempty"`
+	// SessionGroup shares one workspace among the sessions that name the same group
+	SessionGroup string `json:"sessionGroup,omitempty"`
+	// Hardware overrides the project's runnerHardware field by field
+	Hardware *RunnerHardware `json:"hardware,omitempty"`
+	// ParentSession is the session this one follows up on; together they form a thread
+	ParentSession string `json:"parentSession,omitempty"`
+}
+
+// SecretEnvSource injects keys of a project Secret into the runner as environment variables.
+// The Secret must be listed in ProjectSettings spec.allowedSessionSecrets.
+type SecretEnvSource struct {
+	// Name of the Secret in the project namespace
+	Name string `json:"name"`
+	// Keys limits injection to these keys; empty injects every key
+	Keys []string `json:"keys,omitempty"`
+}
+
+// LLMSettings configures the model used by the runner
+type LLMSettings struct {
+	Model       string  `json:"model,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+	MaxTokens   int     `json:"maxTokens,omitempty"`
+	// ContextStrategy is what the runner does when the conversation reaches MaxContextTokens:
+	// truncate, summarize (the default) or retrieval
+	ContextStrategy string `json:"contextStrategy,omitempty"`
+	// MaxContextTokens caps the conversation context; zero leaves it to the model's window
+	MaxContextTokens int `json:"maxContextTokens,omitempty"`
+}
+
+// CostLimit caps what a session may spend. Either bound (or both) may be set; zero means
+// unlimited. Tokens count input, output and cache tokens as reported by the runner.
+type CostLimit struct {
+	USD    float64 `json:"usd,omitempty"`
+	Tokens int64   `json:"tokens,omitempty"`
+}
+
+// UserContext is the authenticated caller identity captured at creation time
+type UserContext struct {
+	UserID      string   `json:"userId,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+}
+
+// BotAccountRef names the bot account a session runs as
+type BotAccountRef struct {
+	Name string `json:"name"`
+}
+
+// ResourceOverrides adjusts the runner pod's resources and scheduling
+type ResourceOverrides struct {
+	CPU           string `json:"cpu,omitempty"`
+	Memory        string `json:"memory,omitempty"`
+	StorageClass  string `json:"storageClass,omitempty"`
+	PriorityClass string `json:"priorityClass,omitempty"`
+}
+
+// SessionRepo maps an input repository to an optional output (fork or target) repository
+type SessionRepo struct {
+	Input  GitRepo  `json:"input"`
+	Output *GitRepo `json:"output,omitempty"`
+}
+
+// GitRepo is a repository URL and branch
+type GitRepo struct {
+	URL    string `json:"url"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// WorkflowSelection is the workflow loaded into the session
+type WorkflowSelection struct {
+	GitURL string `json:"gitUrl"`
+	Branch string `json:"branch,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// AgenticSessionStatus mirrors status in agenticsessions-crd.yaml. The snake_case fields
+// are copied from the runner's result message.
+type AgenticSessionStatus struct {
+	Phase          AgenticSessionPhase `json:"phase,omitempty"`
+	Message        string              `json:"message,omitempty"`
+	StartTime      *metav1.Time        `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time        `json:"completionTime,omitempty"`
+	JobName        string              `json:"jobName,omitempty"`
+	StateDir       string              `json:"stateDir,omitempty"`
+
+	Subtype      string                `json:"subtype,omitempty"`
+	IsError      bool                  `json:"is_error,omitempty"`
+	NumTurns     int                   `json:"num_turns,omitempty"`
+	SessionID    string                `json:"session_id,omitempty"`
+	TotalCostUSD *float64              `json:"total_cost_usd,omitempty"`
+	Usage        *runtime.RawExtension `json:"usage,omitempty"`
+	Result       *SessionResult        `json:"result,omitempty"`
+
+	PreemptionRetries   int                  `json:"preemptionRetries,omitempty"`
+	Conditions          []metav1.Condition   `json:"conditions,omitempty"`
+	HasWorkspaceChanges bool                 `json:"has_workspace_changes,omitempty"`
+	Repos               []SessionRepoStatus  `json:"repos,omitempty"`
+	Artifacts           []ArtifactStatus     `json:"artifacts,omitempty"`
+	PublishChecks       []PublishCheckStatus `json:"publishChecks,omitempty"`
+	// Steps and Progress (0-100) are mapped from the runner's ProgressAnnotation
+	Steps    []SessionStep `json:"steps,omitempty"`
+	Progress int           `json:"progress,omitempty"`
+	// Encryption records how the session's data is protected at rest
+	Encryption *SessionEncryption `json:"encryption,omitempty"`
+	// RateLimit is the model provider's rate limit as the runner last saw it
+	RateLimit *RateLimitStatus `json:"rateLimit,omitempty"`
+	// RunAttempt is raised by every restart and preemption retry; with the session UID it
+	// is the idempotency key of the runner Job
+	RunAttempt int64 `json:"runAttempt,omitempty"`
+}
+
+// SessionEncryption records the encryption applied to a session's workspace and artifacts
+type SessionEncryption struct {
+	// StorageClassName is the encrypted StorageClass the workspace volume was provisioned from
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// SessionRepoStatus tracks what happened to one repository of the session
+type SessionRepoStatus struct {
+	Name         string       `json:"name,omitempty"`
+	Status       string       `json:"status,omitempty"`
+	LastUpdated  *metav1.Time `json:"last_updated,omitempty"`
+	TotalAdded   int          `json:"total_added,omitempty"`
+	TotalRemoved int          `json:"total_removed,omitempty"`
+}
+
+// ArtifactUploadState is the state of a runner artifact upload
+type ArtifactUploadState string
+
+const (
+	ArtifactUploading ArtifactUploadState = "Uploading"
+	ArtifactComplete  ArtifactUploadState = "Complete"
+	ArtifactFailed    ArtifactUploadState = "Failed"
+)
+
+// ArtifactStatus tracks one artifact the runner uploads to the backend
+type ArtifactStatus struct {
+	Path          string              `json:"path"`
+	Size          int64               `json:"size"`
+	SHA256        string              `json:"sha256,omitempty"`
+	UploadedBytes int64               `json:"uploadedBytes"`
+	State         ArtifactUploadState `json:"state"`
+	Message       string              `json:"message,omitempty"`
+	LastUpdated   *metav1.Time        `json:"lastUpdated,omitempty"`
+	// KeyRef identifies the key-encryption key that wrapped the artifact's data key, when the
+	// project encrypts artifacts (see WorkspaceEncryption)
+	KeyRef string `json:"keyRef,omitempty"`
+}
+
+// PublishCheckStatus records the publish checks last run on one repository of the session
+type PublishCheckStatus struct {
+	Repo      string       `json:"repo"`
+	Passed    bool         `json:"passed"`
+	CheckedAt *metav1.Time `json:"checkedAt,omitempty"`
+	// OverriddenBy is the project admin who published despite failing checks
+	OverriddenBy string               `json:"overriddenBy,omitempty"`
+	Results      []PublishCheckResult `json:"results,omitempty"`
+}
+
+// PublishCheckResult is the outcome of one check on a workspace diff
+type PublishCheckResult struct {
+	Name     string                `json:"name"`
+	Passed   bool                  `json:"passed"`
+	Findings []PublishCheckFinding `json:"findings,omitempty"`
+}
+
+// PublishCheckFinding is one problem a check found; it never contains the offending content
+type PublishCheckFinding struct {
+	Path    string `json:"path,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AgenticSessionList is a list of AgenticSessions
+type AgenticSessionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []AgenticSession `json:"items"`
+}
+
+// ProjectSettingsName is the only allowed name of the ProjectSettings singleton in a project
+const ProjectSettingsName = "projectsettings"
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ProjectSettings is the per-project configuration singleton
+type ProjectSettings struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProjectSettingsSpec   `json:"spec,omitempty"`
+	Status ProjectSettingsStatus `json:"status,omitempty"`
+}
+
+// ProjectSettingsSpec mirrors spec in projectsettings-crd.yaml
+type ProjectSettingsSpec struct {
+	GroupAccess       []GroupAccess     `json:"groupAccess"`
+	RunnerSecretsName string            `json:"runnerSecretsName,omitempty"`
+	BranchProtection  *BranchProtection `json:"branchProtection,omitempty"`
+	MaxActiveSessions int               `json:"maxActiveSessions,omitempty"`
+	MaxIdleMinutes    int               `json:"maxIdleMinutes,omitempty"`
+	LLMProvider       *LLMProvider      `json:"llmProvider,omitempty"`
+	RunnerToolPolicy  *RunnerToolPolicy `json:"runnerToolPolicy,omitempty"`
+	PublishChecks     *PublishChecks    `json:"publishChecks,omitempty"`
+	GitMirror         *GitMirror        `json:"gitMirror,omitempty"`
+	AutoReview        *AutoReview       `json:"autoReview,omitempty"`
+	// AllowedSessionSecrets are the Secrets sessions may inject with spec.envFromSecrets
+	AllowedSessionSecrets []string      `json:"allowedSessionSecrets,omitempty"`
+	GitOps                *GitOpsSource `json:"gitOps,omitempty"`
+	// SystemPromptTemplate is a Go text/template the backend renders and prepends to
+	// every session prompt in the project
+	SystemPromptTemplate string        `json:"systemPromptTemplate,omitempty"`
+	PromptPolicy         *PromptPolicy `json:"promptPolicy,omitempty"`
+	// WorkspaceEncryption protects session workspaces and artifacts at rest
+	WorkspaceEncryption *WorkspaceEncryption `json:"workspaceEncryption,omitempty"`
+	// Runbooks are named, parameterized sessions members can launch by name
+	Runbooks []Runbook `json:"runbooks,omitempty"`
+	// Kueue submits runner Jobs to a Kueue LocalQueue when the operator's Kueue integration is on
+	Kueue *KueueSettings `json:"kueue,omitempty"`
+	// NetworkPolicy limits the external sites the project's agents may reach
+	NetworkPolicy *AgentNetworkPolicy `json:"networkPolicy,omitempty"`
+	// AbandonedSessions acts on running interactive sessions nobody has viewed for a while
+	AbandonedSessions *AbandonedSessionPolicy `json:"abandonedSessions,omitempty"`
+	// RunnerHardware is the default RuntimeClass, GPUs and architecture of the project's runners
+	RunnerHardware *RunnerHardware `json:"runnerHardware,omitempty"`
+	// RunnerSecurity hardens the security context of the project's runner pods
+	RunnerSecurity *RunnerSecurity `json:"runnerSecurity,omitempty"`
+	// IssueUpdates reports completed sessions' pull requests on the issue they worked on
+	IssueUpdates *IssueUpdates `json:"issueUpdates,omitempty"`
+	// SessionSchedule holds the project's sessions outside its time windows
+	SessionSchedule *SessionSchedule `json:"sessionSchedule,omitempty"`
+	// ObjectMetadata adds compliance labels and annotations to every object the operator
+	// creates in the project
+	ObjectMetadata *ObjectMetadataPolicy `json:"objectMetadata,omitempty"`
+	// FollowUps sets what follow-up sessions are told of their thread
+	FollowUps *FollowUpPolicy `json:"followUps,omitempty"`
+	// RunnerDNS adds host entries and resolver settings to the project's runner pods
+	RunnerDNS *RunnerDNS `json:"runnerDNS,omitempty"`
+	// SessionFairness shares runner slots among the project's users while sessions queue
+	SessionFairness *SessionFairness `json:"sessionFairness,omitempty"`
+}
+
+// SessionSecretAllowed reports whether sessions may inject the named Secret
+func (s *ProjectSettingsSpec) SessionSecretAllowed(name string) bool {
+	for _, allowed := range s.AllowedSessionSecrets {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupAccess grants a group a project role
+type GroupAccess struct {
+	GroupName string `json:"groupName"`
+	Role      string `json:"role"`
+}
+
+// BranchProtection is the push policy enforced by the backend and the runner's git wrapper
+type BranchProtection struct {
+	AllowedTargetBranches []string `json:"allowedTargetBranches,omitempty"`
+	RequirePR             bool     `json:"requirePR,omitempty"`
+	ForbidForcePush       bool     `json:"forbidForcePush,omitempty"`
+}
+
+// PublishChecks configures the checks the backend runs on a workspace diff before publishing it
+type PublishChecks struct {
+	// DisableSecretScan turns off scanning added lines for credentials (on by default)
+	DisableSecretScan bool `json:"disableSecretScan,omitempty"`
+	// LicenseHeader must appear near the top of every file the session adds
+	LicenseHeader string `json:"licenseHeader,omitempty"`
+	// LicenseHeaderExtensions limits the license check to these extensions (e.g. ".go"); empty checks every text file
+	LicenseHeaderExtensions []string `json:"licenseHeaderExtensions,omitempty"`
+	// ForbiddenPaths are glob patterns of files that may not be published (e.g. "*.pem", ".env")
+	ForbiddenPaths []string `json:"forbiddenPaths,omitempty"`
+}
+
+// WorkspaceEncryption protects session data at rest for projects holding regulated code
+type WorkspaceEncryption struct {
+	// StorageClassName provisions session workspace volumes from this StorageClass. The
+	// operator checks that it encrypts its volumes and fails sessions when it does not.
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// ArtifactKey makes the backend envelope-encrypt uploaded artifacts under this key
+	ArtifactKey *ArtifactKeyRef `json:"artifactKey,omitempty"`
+}
+
+// ArtifactKeyRef points at a key-encryption key (KEK) held in a Secret of the project, for
+// example one synced from a cloud KMS. Each artifact gets its own AES-256 data key, which is
+// stored wrapped by the KEK next to the artifact.
+type ArtifactKeyRef struct {
+	// SecretName holds the 32-byte KEK, raw or base64-encoded
+	SecretName string `json:"secretName"`
+	// Key in the Secret (default "kek")
+	Key string `json:"key,omitempty"`
+}
+
+// KueueSettings selects the Kueue LocalQueue (in the project namespace) that admits the
+// project's runner Jobs
+type KueueSettings struct {
+	// QueueName overrides the operator's default LocalQueue
+	QueueName string `json:"queueName,omitempty"`
+	// PriorityClassName is a Kueue WorkloadPriorityClass
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// Abandoned session actions
+const (
+	AbandonedSessionNotify = "Notify"
+	AbandonedSessionStop   = "Stop"
+)
+
+// AbandonedSessionPolicy catches interactive sessions started and then forgotten. Unlike
+// maxIdleMinutes, which measures messages, it measures how long since a user last had the
+// session open (heartbeats from the UI).
+type AbandonedSessionPolicy struct {
+	// MaxUnwatchedHours since the session was last viewed; 0 turns the policy off
+	MaxUnwatchedHours int `json:"maxUnwatchedHours,omitempty"`
+	// Action is Notify (post a notice to the session) or Stop (default)
+	Action string `json:"action,omitempty"`
+}
+
+// FollowUpContext is what a follow-up session is given of the sessions before it
+type FollowUpContext string
+
+const (
+	// FollowUpContextSummary gives the request and result of each earlier session
+	FollowUpContextSummary FollowUpContext = "Summary"
+	// FollowUpContextTranscript also gives the parent session's conversation
+	FollowUpContextTranscript FollowUpContext = "Transcript"
+	// FollowUpContextNone links the sessions without passing any context
+	FollowUpContextNone FollowUpContext = "None"
+)
+
+// FollowUpPolicy sets the context the backend prepends to the prompt of a session with
+// spec.parentSession
+type FollowUpPolicy struct {
+	// Context defaults to Summary
+	Context FollowUpContext `json:"context,omitempty"`
+	// MaxContextLength caps the context in characters (default 20000); the oldest is dropped
+	MaxContextLength int `json:"maxContextLength,omitempty"`
+}
+
+// Sessions a SessionSchedule applies to
+const (
+	ScheduleBatchSessions = "Batch"
+	ScheduleAllSessions   = "All"
+)
+
+// SessionSchedule limits when the project's sessions start, e.g. batch sessions only at
+// night and on weekends. Sessions already running are not stopped when a window closes.
+type SessionSchedule struct {
+	// TimeZone of the windows, an IANA name such as "Europe/Berlin"; default UTC
+	TimeZone string `json:"timeZone,omitempty"`
+	// Windows in which sessions may start
+	Windows []ScheduleWindow `json:"windows"`
+	// Sessions is Batch (default: non-interactive sessions) or All
+	Sessions string `json:"sessions,omitempty"`
+}
+
+// ScheduleWindow is a daily time range on some days of the week
+type ScheduleWindow struct {
+	// Days the window opens on (Mon, Tue, Wed, Thu, Fri, Sat, Sun); empty means every day
+	Days []string `json:"days,omitempty"`
+	// Start and End as HH:MM; an End at or before Start closes the window the next day
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ObjectMetadataPolicy injects labels and annotations mandated by compliance, such as a data
+// classification or the owning team, into the objects the operator creates for a project
+type ObjectMetadataPolicy struct {
+	// Labels and Annotations are set on every object, alongside the operator's own
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// RequiredLabels and RequiredAnnotations are keys every session must carry, unless Labels
+	// or Annotations set them. Sessions without them wait; their values are copied to the
+	// session's objects.
+	RequiredLabels      []string `json:"requiredLabels,omitempty"`
+	RequiredAnnotations []string `json:"requiredAnnotations,omitempty"`
+}
+
+// Runner architectures, matched against the nodes' kubernetes.io/arch label
+const (
+	ArchitectureAMD64 = "amd64"
+	ArchitectureARM64 = "arm64"
+)
+
+// Seccomp profile types of RunnerSecurity
+const (
+	SeccompRuntimeDefault = "RuntimeDefault"
+	SeccompLocalhost      = "Localhost"
+	SeccompUnconfined     = "Unconfined"
+)
+
+// RunnerSecurity hardens every container of the project's runner pods, e.g. to satisfy a
+// namespace that enforces the restricted Pod Security Standard. Setting it also turns off
+// privilege escalation in every container.
+type RunnerSecurity struct {
+	// ReadOnlyRootFilesystem makes the containers' root filesystems read-only; /tmp becomes an emptyDir
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+	// RunAsNonRoot refuses to start containers whose image runs as root
+	RunAsNonRoot bool `json:"runAsNonRoot,omitempty"`
+	// SeccompProfile is RuntimeDefault, Localhost or Unconfined
+	SeccompProfile string `json:"seccompProfile,omitempty"`
+	// LocalhostProfile is the node-local profile of the Localhost type
+	LocalhostProfile string `json:"localhostProfile,omitempty"`
+	// DropCapabilities are dropped from every container, e.g. ["ALL"]
+	DropCapabilities []string `json:"dropCapabilities,omitempty"`
+}
+
+// RunnerHardware places the runner pod on specific hardware
+type RunnerHardware struct {
+	// RuntimeClassName of the runner pod, e.g. "nvidia" or "kata"
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+	// GPUs requested as nvidia.com/gpu
+	GPUs int64 `json:"gpus,omitempty"`
+	// Architecture the runner must run on (amd64 or arm64); unset schedules anywhere
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// DNS policies of RunnerDNS
+const (
+	DNSPolicyClusterFirst = "ClusterFirst"
+	DNSPolicyNone         = "None"
+)
+
+// RunnerDNS resolves hosts the cluster DNS cannot, such as internal Git servers behind
+// split-horizon DNS, in runner pods
+type RunnerDNS struct {
+	// HostAliases are added to the runner pod's /etc/hosts
+	HostAliases []RunnerHostAlias `json:"hostAliases,omitempty"`
+	// Policy is ClusterFirst (default), which adds Nameservers after the cluster DNS, or
+	// None, which resolves with Nameservers only
+	Policy string `json:"policy,omitempty"`
+	// Nameservers are the IP addresses of extra DNS servers (at most 3)
+	Nameservers []string `json:"nameservers,omitempty"`
+	// Searches are extra search domains
+	Searches []string `json:"searches,omitempty"`
+	// Options are resolver options, e.g. ndots
+	Options []DNSOption `json:"options,omitempty"`
+}
+
+// RunnerHostAlias maps host names to an IP address in /etc/hosts
+type RunnerHostAlias struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+	// Expires ends a temporary entry: runner pods started after it do not get it
+	Expires *metav1.Time `json:"expires,omitempty"`
+}
+
+// DNSOption is a resolver option; Value is optional
+type DNSOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// SessionFairness keeps one user who submits many sessions from starving the project's
+// other users. While sessions wait for a runner slot, the next one to start belongs to the
+// user or team running the fewest sessions for its weight.
+type SessionFairness struct {
+	// Teams are groups (spec.userContext.groups) whose members share one share; a user in
+	// several counts for the first listed. Other users each have their own.
+	Teams []string `json:"teams,omitempty"`
+	// Weights of users and teams by name, default 1. A user or team with weight 2 may run
+	// twice as many sessions as one with weight 1 before it has to let the other go first.
+	Weights map[string]int `json:"weights,omitempty"`
+	// MaxRunning caps the sessions one user or team runs at once, even with free slots;
+	// 0 = no cap
+	MaxRunning int `json:"maxRunning,omitempty"`
+}
+
+// AgentNetworkPolicy is the project's egress policy for agent web access
+type AgentNetworkPolicy struct {
+	// AllowedDomains are the only hosts the agent's web tools may fetch, e.g.
+	// "docs.python.org" or "*.readthedocs.io" for its subdomains. Empty leaves web access open.
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+}
+
+// Runbook parameter types
+const (
+	RunbookParamString  = "string"
+	RunbookParamInteger = "integer"
+	RunbookParamBoolean = "boolean"
+	RunbookParamEnum    = "enum"
+	// RunbookParamRepo is a git repository URL; each repo parameter becomes a session repo
+	RunbookParamRepo = "repo"
+)
+
+// Runbook is a named operation ("upgrade Go version") launched as a session from a prompt template
+type Runbook struct {
+	// Name is a DNS label, unique in the project
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Parameters  []RunbookParameter `json:"parameters,omitempty"`
+	// PromptTemplate is a Go text/template; parameter values are available as .Params.<name>
+	PromptTemplate string `json:"promptTemplate"`
+	// Model, Timeout (seconds), Interactive and AutoPushOnComplete set the session's defaults
+	Model              string `json:"model,omitempty"`
+	Timeout            int    `json:"timeout,omitempty"`
+	Interactive        bool   `json:"interactive,omitempty"`
+	AutoPushOnComplete bool   `json:"autoPushOnComplete,omitempty"`
+}
+
+// RunbookParameter is a typed input of a runbook
+type RunbookParameter struct {
+	Name string `json:"name"`
+	// Type is string (default), integer, boolean, enum or repo
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	// Default is used when the caller omits the parameter
+	Default string `json:"default,omitempty"`
+	// Enum lists the allowed values of an enum parameter
+	Enum []string `json:"enum,omitempty"`
+}
+
+// PromptPolicy limits and moderates session prompts before the backend submits them
+type PromptPolicy struct {
+	// MaxLength is the maximum prompt length in characters; 0 means unlimited
+	MaxLength int `json:"maxLength,omitempty"`
+	// MaxTokens is the maximum estimated prompt size in tokens; 0 means unlimited
+	MaxTokens int `json:"maxTokens,omitempty"`
+	// Secrets is the action taken on credentials found in a prompt; empty skips the scan
+	Secrets PromptAction `json:"secrets,omitempty"`
+	// Rules are regular expressions that block or redact matching prompt text
+	Rules []PromptRule `json:"rules,omitempty"`
+	// Webhook is an external moderation endpoint consulted after the rules
+	Webhook *PromptWebhook `json:"webhook,omitempty"`
+}
+
+// PromptAction is what moderation does with matching prompt text
+type PromptAction string
+
+const (
+	PromptActionBlock  PromptAction = "Block"
+	PromptActionRedact PromptAction = "Redact"
+)
+
+// PromptRule is a regular expression (RE2 syntax) checked against session prompts
+type PromptRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	// Action defaults to Block
+	Action PromptAction `json:"action,omitempty"`
+	// Replacement for redacted text (default "[REDACTED]")
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// PromptWebhook is an external moderation endpoint. The backend POSTs
+// {"project", "prompt"} and expects {"action": "allow"|"block"|"redact", "prompt", "reason"}.
+type PromptWebhook struct {
+	URL string `json:"url"`
+	// TokenSecret names a Secret whose "token" key is sent as a bearer token
+	TokenSecret string `json:"tokenSecret,omitempty"`
+	// TimeoutSeconds bounds each call (default 5)
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// FailOpen accepts prompts when the endpoint cannot be reached; by default they are rejected
+	FailOpen bool `json:"failOpen,omitempty"`
+}
+
+// AutoReview makes the operator start a follow-up review session for the pull requests a
+// completed session opened (status.result.prURLs)
+type AutoReview struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// PromptTemplate is a Go text/template for the review prompt; empty uses a default.
+	// Variables: .PullRequestURL, .PullRequestURLs, .Session, .Summary
+	PromptTemplate string `json:"promptTemplate,omitempty"`
+	// Workflow is activated in the review session, e.g. a code review workflow
+	Workflow *WorkflowSelection `json:"workflow,omitempty"`
+}
+
+// IssueUpdates makes the operator update the GitHub issue or Jira issue a completed session
+// worked on (the ambient-code.io/issue annotation) when the session opened pull requests,
+// using the credentials in the project's integration secret
+type IssueUpdates struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// CommentTemplate is a Go text/template for the comment; empty uses a default.
+	// Variables: .PullRequestURL, .PullRequestURLs, .Session, .Summary, .Outcome
+	CommentTemplate string `json:"commentTemplate,omitempty"`
+	// Labels are added to the issue
+	Labels []string `json:"labels,omitempty"`
+	// JiraTransition names the Jira transition applied to the issue, e.g. "In Review"
+	JiraTransition string `json:"jiraTransition,omitempty"`
+}
+
+// GitOpsSource makes a directory of a Git repository (laid out like the bundle exported by
+// GET /projects/:project/gitops-bundle) the source of truth for the project's settings and
+// members; the backend imports it when the repository's push webhook fires
+type GitOpsSource struct {
+	RepoURL string `json:"repoUrl"`
+	// Branch whose pushes are imported (default main)
+	Branch string `json:"branch,omitempty"`
+	// Path of the bundle directory in the repository; empty is the repository root
+	Path string `json:"path,omitempty"`
+	// WebhookSecret names a Secret whose "secret" key verifies webhook signatures
+	WebhookSecret string `json:"webhookSecret"`
+}
+
+// GitMirror configures the project's git mirror cache: the operator keeps bare mirrors of
+// Repos up to date on a shared volume and runner Jobs clone with --reference to it
+type GitMirror struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Repos are the repository URLs to mirror
+	Repos []string `json:"repos,omitempty"`
+	// RefreshIntervalSeconds between mirror fetches (default 300)
+	RefreshIntervalSeconds int `json:"refreshIntervalSeconds,omitempty"`
+	// StorageSize of the mirror volume (default 20Gi)
+	StorageSize string `json:"storageSize,omitempty"`
+	// StorageClassName must provide ReadWriteMany volumes; empty uses the cluster default
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// CredentialsSecret names a Secret with a "token" key used to fetch private repos
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// LLMProvider selects the model provider for the project's runners
+type LLMProvider struct {
+	Provider string           `json:"provider,omitempty"`
+	Bedrock  *BedrockSettings `json:"bedrock,omitempty"`
+}
+
+// BedrockSettings configures AWS Bedrock; exactly one of RoleARN or CredentialsSecretName is set
+type BedrockSettings struct {
+	Region                string `json:"region"`
+	RoleARN               string `json:"roleArn,omitempty"`
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+}
+
+// RunnerToolPolicy is the tool policy served to runners
+type RunnerToolPolicy struct {
+	AllowedTools    []string `json:"allowedTools,omitempty"`
+	DisallowedTools []string `json:"disallowedTools,omitempty"`
+	PermissionMode  string   `json:"permissionMode,omitempty"`
+}
+
+// ProjectSettingsStatus is written by the operator
+type ProjectSettingsStatus struct {
+	GroupBindingsCreated int `json:"groupBindingsCreated,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ProjectSettingsList is a list of ProjectSettings
+type ProjectSettingsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ProjectSettings `json:"items"`
+}
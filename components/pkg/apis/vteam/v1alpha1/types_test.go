@@ -0,0 +1,62 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TestAgenticSession_FromUnstructured verifies the typed structs decode the shape stored by the CRD
+func TestAgenticSession_FromUnstructured(t *testing.T) {
+	obj := map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": "project-a"},
+		"spec": map[string]interface{}{
+			"prompt":        "Fix the build",
+			"timeout":       int64(300),
+			"llmSettings":   map[string]interface{}{"model": "sonnet", "temperature": 0.7, "maxTokens": int64(4000)},
+			"repos":         []interface{}{map[string]interface{}{"input": map[string]interface{}{"url": "https://github.com/org/repo", "branch": "main"}}},
+			"mainRepoIndex": int64(0),
+		},
+		"status": map[string]interface{}{
+			"phase":          "Completed",
+			"total_cost_usd": 0.12,
+			"result":         map[string]interface{}{"outcome": "Succeeded", "filesChanged": int64(2)},
+		},
+	}
+
+	session := &AgenticSession{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, session); err != nil {
+		t.Fatalf("FromUnstructured failed: %v", err)
+	}
+	if session.Spec.Timeout != 300 || session.Spec.LLMSettings.Model != "sonnet" || session.Spec.Repos[0].Input.Branch != "main" {
+		t.Errorf("Unexpected spec: %+v", session.Spec)
+	}
+	if !session.Status.Phase.IsTerminal() || session.Status.Result.FilesChanged != 2 {
+		t.Errorf("Unexpected status: %+v", session.Status)
+	}
+}
+
+// TestAgenticSession_DeepCopy verifies copies do not share nested state
+func TestAgenticSession_DeepCopy(t *testing.T) {
+	idx := 1
+	orig := &AgenticSession{
+		Spec: AgenticSessionSpec{
+			EnvironmentVariables: map[string]string{"A": "1"},
+			Repos:                []SessionRepo{{Input: GitRepo{URL: "https://github.com/org/repo"}, Output: &GitRepo{URL: "https://github.com/me/repo"}}},
+			MainRepoIndex:        &idx,
+		},
+		Status: AgenticSessionStatus{Result: &SessionResult{Outcome: OutcomeSucceeded, PRURLs: []string{"https://github.com/org/repo/pull/1"}}},
+	}
+
+	cp := orig.DeepCopy()
+	cp.Spec.EnvironmentVariables["A"] = "2"
+	cp.Spec.Repos[0].Output.URL = "changed"
+	*cp.Spec.MainRepoIndex = 5
+	cp.Status.Result.PRURLs[0] = "changed"
+
+	if orig.Spec.EnvironmentVariables["A"] != "1" || orig.Spec.Repos[0].Output.URL == "changed" || idx != 1 || orig.Status.Result.PRURLs[0] == "changed" {
+		t.Errorf("DeepCopy shares state with the original: %+v", orig)
+	}
+}
@@ -0,0 +1,36 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamv1alpha1 "ambient-code-pkg/client/clientset/versioned/typed/vteam/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeAgenticSessions implements AgenticSessionInterface
+type fakeAgenticSessions struct {
+	*gentype.FakeClientWithList[*v1alpha1.AgenticSession, *v1alpha1.AgenticSessionList]
+	Fake *FakeVteamV1alpha1
+}
+
+func newFakeAgenticSessions(fake *FakeVteamV1alpha1, namespace string) vteamv1alpha1.AgenticSessionInterface {
+	return &fakeAgenticSessions{
+		gentype.NewFakeClientWithList[*v1alpha1.AgenticSession, *v1alpha1.AgenticSessionList](
+			fake.Fake,
+			namespace,
+			v1alpha1.SchemeGroupVersion.WithResource("agenticsessions"),
+			v1alpha1.SchemeGroupVersion.WithKind("AgenticSession"),
+			func() *v1alpha1.AgenticSession { return &v1alpha1.AgenticSession{} },
+			func() *v1alpha1.AgenticSessionList { return &v1alpha1.AgenticSessionList{} },
+			func(dst, src *v1alpha1.AgenticSessionList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.AgenticSessionList) []*v1alpha1.AgenticSession {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1alpha1.AgenticSessionList, items []*v1alpha1.AgenticSession) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
@@ -0,0 +1,36 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	vteamv1alpha1 "ambient-code-pkg/client/clientset/versioned/typed/vteam/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeProjectSettings implements ProjectSettingsInterface
+type fakeProjectSettings struct {
+	*gentype.FakeClientWithList[*v1alpha1.ProjectSettings, *v1alpha1.ProjectSettingsList]
+	Fake *FakeVteamV1alpha1
+}
+
+func newFakeProjectSettings(fake *FakeVteamV1alpha1, namespace string) vteamv1alpha1.ProjectSettingsInterface {
+	return &fakeProjectSettings{
+		gentype.NewFakeClientWithList[*v1alpha1.ProjectSettings, *v1alpha1.ProjectSettingsList](
+			fake.Fake,
+			namespace,
+			v1alpha1.SchemeGroupVersion.WithResource("projectsettings"),
+			v1alpha1.SchemeGroupVersion.WithKind("ProjectSettings"),
+			func() *v1alpha1.ProjectSettings { return &v1alpha1.ProjectSettings{} },
+			func() *v1alpha1.ProjectSettingsList { return &v1alpha1.ProjectSettingsList{} },
+			func(dst, src *v1alpha1.ProjectSettingsList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.ProjectSettingsList) []*v1alpha1.ProjectSettings {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1alpha1.ProjectSettingsList, items []*v1alpha1.ProjectSettings) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
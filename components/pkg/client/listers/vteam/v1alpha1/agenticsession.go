@@ -0,0 +1,54 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	vteamv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// AgenticSessionLister helps list AgenticSessions.
+// All objects returned here must be treated as read-only.
+type AgenticSessionLister interface {
+	// List lists all AgenticSessions in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*vteamv1alpha1.AgenticSession, err error)
+	// AgenticSessions returns an object that can list and get AgenticSessions.
+	AgenticSessions(namespace string) AgenticSessionNamespaceLister
+	AgenticSessionListerExpansion
+}
+
+// agenticSessionLister implements the AgenticSessionLister interface.
+type agenticSessionLister struct {
+	listers.ResourceIndexer[*vteamv1alpha1.AgenticSession]
+}
+
+// NewAgenticSessionLister returns a new AgenticSessionLister.
+func NewAgenticSessionLister(indexer cache.Indexer) AgenticSessionLister {
+	return &agenticSessionLister{listers.New[*vteamv1alpha1.AgenticSession](indexer, vteamv1alpha1.Resource("agenticsession"))}
+}
+
+// AgenticSessions returns an object that can list and get AgenticSessions.
+func (s *agenticSessionLister) AgenticSessions(namespace string) AgenticSessionNamespaceLister {
+	return agenticSessionNamespaceLister{listers.NewNamespaced[*vteamv1alpha1.AgenticSession](s.ResourceIndexer, namespace)}
+}
+
+// AgenticSessionNamespaceLister helps list and get AgenticSessions.
+// All objects returned here must be treated as read-only.
+type AgenticSessionNamespaceLister interface {
+	// List lists all AgenticSessions in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*vteamv1alpha1.AgenticSession, err error)
+	// Get retrieves the AgenticSession from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*vteamv1alpha1.AgenticSession, error)
+	AgenticSessionNamespaceListerExpansion
+}
+
+// agenticSessionNamespaceLister implements the AgenticSessionNamespaceLister
+// interface.
+type agenticSessionNamespaceLister struct {
+	listers.ResourceIndexer[*vteamv1alpha1.AgenticSession]
+}
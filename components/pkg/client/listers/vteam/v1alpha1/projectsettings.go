@@ -0,0 +1,54 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	vteamv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ProjectSettingsLister helps list ProjectSettings.
+// All objects returned here must be treated as read-only.
+type ProjectSettingsLister interface {
+	// List lists all ProjectSettings in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*vteamv1alpha1.ProjectSettings, err error)
+	// ProjectSettings returns an object that can list and get ProjectSettings.
+	ProjectSettings(namespace string) ProjectSettingsNamespaceLister
+	ProjectSettingsListerExpansion
+}
+
+// projectSettingsLister implements the ProjectSettingsLister interface.
+type projectSettingsLister struct {
+	listers.ResourceIndexer[*vteamv1alpha1.ProjectSettings]
+}
+
+// NewProjectSettingsLister returns a new ProjectSettingsLister.
+func NewProjectSettingsLister(indexer cache.Indexer) ProjectSettingsLister {
+	return &projectSettingsLister{listers.New[*vteamv1alpha1.ProjectSettings](indexer, vteamv1alpha1.Resource("projectsettings"))}
+}
+
+// ProjectSettings returns an object that can list and get ProjectSettings.
+func (s *projectSettingsLister) ProjectSettings(namespace string) ProjectSettingsNamespaceLister {
+	return projectSettingsNamespaceLister{listers.NewNamespaced[*vteamv1alpha1.ProjectSettings](s.ResourceIndexer, namespace)}
+}
+
+// ProjectSettingsNamespaceLister helps list and get ProjectSettings.
+// All objects returned here must be treated as read-only.
+type ProjectSettingsNamespaceLister interface {
+	// List lists all ProjectSettings in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*vteamv1alpha1.ProjectSettings, err error)
+	// Get retrieves the ProjectSettings from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*vteamv1alpha1.ProjectSettings, error)
+	ProjectSettingsNamespaceListerExpansion
+}
+
+// projectSettingsNamespaceLister implements the ProjectSettingsNamespaceLister
+// interface.
+type projectSettingsNamespaceLister struct {
+	listers.ResourceIndexer[*vteamv1alpha1.ProjectSettings]
+}
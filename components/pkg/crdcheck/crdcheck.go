@@ -0,0 +1,109 @@
+// Package crdcheck verifies at startup that the custom resources a component needs are
+// served by the cluster.
+//
+// Without the CRDs every dynamic client call fails with a bare 404 ("the server could not
+// find the requested resource"), which tells a new installer nothing. Verify reports instead
+// which CRD is missing, or which version of it, and how to install it.
+package crdcheck
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// MissingCRD is one required resource the cluster does not serve
+type MissingCRD struct {
+	GVR schema.GroupVersionResource
+	// ServedVersions are the versions of the group the cluster serves, if any; empty means
+	// the group is unknown (the CRD is not installed at all)
+	ServedVersions []string
+}
+
+// CRDName is the name of the CustomResourceDefinition that provides the resource
+func (m MissingCRD) CRDName() string {
+	return m.GVR.Resource + "." + m.GVR.Group
+}
+
+func (m MissingCRD) String() string {
+	if len(m.ServedVersions) == 0 {
+		return fmt.Sprintf("CRD %s is not installed", m.CRDName())
+	}
+	return fmt.Sprintf("CRD %s does not serve %s (cluster serves %s)", m.CRDName(), m.GVR.Version, strings.Join(m.ServedVersions, ", "))
+}
+
+// NotInstalledError lists every required resource the cluster does not serve
+type NotInstalledError struct {
+	Missing []MissingCRD
+}
+
+func (e *NotInstalledError) Error() string {
+	parts := make([]string, 0, len(e.Missing))
+	for _, m := range e.Missing {
+		parts = append(parts, m.String())
+	}
+	return fmt.Sprintf("required custom resources are missing: %s; install them with `kubectl apply -k components/manifests/base/crds`", strings.Join(parts, "; "))
+}
+
+// IsNotInstalled reports whether err (or an error it wraps) is a *NotInstalledError
+func IsNotInstalled(err error) bool {
+	var notInstalled *NotInstalledError
+	return errors.As(err, &notInstalled)
+}
+
+// Verify checks that the cluster serves every resource. It returns a *NotInstalledError
+// naming each missing CRD and version, or another error if discovery itself failed.
+func Verify(client discovery.DiscoveryInterface, required ...schema.GroupVersionResource) error {
+	var missing []MissingCRD
+	served := map[string][]string{}
+	groupsRead := false
+	for _, gvr := range required {
+		list, err := client.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to discover %s: %w", gvr.GroupVersion(), err)
+		}
+		found := false
+		if list != nil {
+			for _, r := range list.APIResources {
+				if r.Name == gvr.Resource {
+					found = true
+					break
+				}
+			}
+		}
+		if found {
+			continue
+		}
+		if !groupsRead {
+			groups, err := client.ServerGroups()
+			if err != nil {
+				return fmt.Errorf("failed to discover API groups: %w", err)
+			}
+			for _, g := range groups.Groups {
+				for _, v := range g.Versions {
+					served[g.Name] = append(served[g.Name], v.Version)
+				}
+			}
+			groupsRead = true
+		}
+		versions := append([]string(nil), served[gvr.Group]...)
+		sort.Strings(versions)
+		// The version is served but not this resource: the CRD itself is missing
+		for _, v := range versions {
+			if v == gvr.Version {
+				versions = nil
+				break
+			}
+		}
+		missing = append(missing, MissingCRD{GVR: gvr, ServedVersions: versions})
+	}
+	if len(missing) > 0 {
+		return &NotInstalledError{Missing: missing}
+	}
+	return nil
+}
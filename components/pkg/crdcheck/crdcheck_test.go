@@ -0,0 +1,69 @@
+package crdcheck
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	sessions = schema.GroupVersionResource{Group: "vteam.ambient-code", Version: "v1alpha1", Resource: "agenticsessions"}
+	settings = schema.GroupVersionResource{Group: "vteam.ambient-code", Version: "v1alpha1", Resource: "projectsettings"}
+)
+
+func fakeDiscovery(lists ...*metav1.APIResourceList) *fakediscovery.FakeDiscovery {
+	return &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: lists}}
+}
+
+func resourceList(groupVersion string, names ...string) *metav1.APIResourceList {
+	list := &metav1.APIResourceList{GroupVersion: groupVersion}
+	for _, name := range names {
+		list.APIResources = append(list.APIResources, metav1.APIResource{Name: name})
+	}
+	return list
+}
+
+// TestVerify verifies each missing CRD and version is reported by name
+func TestVerify(t *testing.T) {
+	cases := []struct {
+		name    string
+		lists   []*metav1.APIResourceList
+		missing []string
+	}{
+		{"installed", []*metav1.APIResourceList{resourceList("vteam.ambient-code/v1alpha1", "agenticsessions", "projectsettings")}, nil},
+		{"nothing installed", nil, []string{
+			"CRD agenticsessions.vteam.ambient-code is not installed",
+			"CRD projectsettings.vteam.ambient-code is not installed",
+		}},
+		{"one CRD missing", []*metav1.APIResourceList{resourceList("vteam.ambient-code/v1alpha1", "agenticsessions")}, []string{
+			"CRD projectsettings.vteam.ambient-code is not installed",
+		}},
+		{"other version only", []*metav1.APIResourceList{resourceList("vteam.ambient-code/v1beta1", "agenticsessions", "projectsettings")}, []string{
+			"CRD agenticsessions.vteam.ambient-code does not serve v1alpha1 (cluster serves v1beta1)",
+			"CRD projectsettings.vteam.ambient-code does not serve v1alpha1 (cluster serves v1beta1)",
+		}},
+	}
+	for _, tc := range cases {
+		err := Verify(fakeDiscovery(tc.lists...), sessions, settings)
+		if tc.missing == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+		if !IsNotInstalled(err) {
+			t.Fatalf("%s: expected a NotInstalledError, got %v", tc.name, err)
+		}
+		var got []string
+		for _, m := range err.(*NotInstalledError).Missing {
+			got = append(got, m.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.missing, "\n") {
+			t.Errorf("%s: missing = %q, want %q", tc.name, got, tc.missing)
+		}
+	}
+}
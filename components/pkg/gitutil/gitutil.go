@@ -0,0 +1,231 @@
+// Package gitutil parses and normalizes Git repository URLs.
+//
+// It accepts https/http URLs, ssh:// and git:// URLs, scp-style SSH addresses
+// (git@host:owner/repo.git) and scheme-less host paths (github.com/owner/repo), detects
+// the hosting provider and extracts the owner and repository name. Use it instead of ad-hoc
+// string splitting wherever a repository URL from a user or a CR is interpreted.
+package gitutil
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Provider is the Git hosting service a repository lives on
+type Provider string
+
+const (
+	ProviderGitHub    Provider = "github"
+	ProviderGitLab    Provider = "gitlab"
+	ProviderGitea     Provider = "gitea"
+	ProviderBitbucket Provider = "bitbucket"
+	// ProviderBitbucketServer is Bitbucket Server or Data Center, which clones from /scm/<project>/<repo>
+	ProviderBitbucketServer Provider = "bitbucket-server"
+	// ProviderUnknown is a self-hosted or unrecognised server
+	ProviderUnknown Provider = ""
+)
+
+// Repo is a parsed repository location
+type Repo struct {
+	// Scheme is "https" or "http"; SSH and git:// addresses normalize to "https"
+	Scheme string
+	// Host includes the port when one was given on an http(s) URL
+	Host     string
+	Provider Provider
+	// Owner is the user or organization; for GitLab it may contain subgroups ("group/sub"),
+	// and for Bitbucket Server it is the project key or "~user" for personal repositories
+	Owner string
+	Name  string
+}
+
+var segmentRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// scpRe matches scp-style SSH addresses: [user@]host:path
+var scpRe = regexp.MustCompile(`^(?:[A-Za-z0-9_.-]+@)?([A-Za-z0-9.-]+):([^/].*)$`)
+
+// Parse parses a repository URL. Credentials embedded in the URL are discarded.
+func Parse(raw string) (*Repo, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return nil, fmt.Errorf("repository URL is empty")
+	}
+
+	// "github.com/org/repo": a host without a scheme is taken as https
+	if !strings.Contains(s, ":") {
+		if first, _, ok := strings.Cut(s, "/"); ok && strings.Contains(first, ".") {
+			s = "https://" + s
+		}
+	}
+
+	var scheme, host, path string
+	if !strings.Contains(s, "://") {
+		m := scpRe.FindStringSubmatch(s)
+		if m == nil {
+			return nil, fmt.Errorf("invalid repository URL %q: expected https://host/owner/repo or git@host:owner/repo", raw)
+		}
+		scheme, host, path = "https", m[1], m[2]
+	} else {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository URL %q: %v", raw, err)
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "https", "http":
+			scheme, host = strings.ToLower(u.Scheme), u.Host
+		case "ssh", "git", "git+ssh", "ssh+git":
+			// The SSH port says nothing about the HTTPS endpoint
+			scheme, host = "https", u.Hostname()
+		default:
+			return nil, fmt.Errorf("invalid repository URL %q: unsupported scheme %q", raw, u.Scheme)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("invalid repository URL %q: query and fragment are not allowed", raw)
+		}
+		path = u.Path
+	}
+
+	host = strings.ToLower(host)
+	if host == "" {
+		return nil, fmt.Errorf("invalid repository URL %q: missing host", raw)
+	}
+
+	provider := DetectProvider(host)
+	if provider == ProviderUnknown && isBitbucketServerPath(path) {
+		provider = ProviderBitbucketServer
+	}
+	owner, name, err := splitRepoPath(provider, path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL %q: %v", raw, err)
+	}
+	return &Repo{Scheme: scheme, Host: host, Provider: provider, Owner: owner, Name: name}, nil
+}
+
+// Normalize returns the canonical https form of a repository URL, without credentials or a
+// .git suffix, so two spellings of the same repository compare equal
+func Normalize(raw string) (string, error) {
+	r, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return r.URL(), nil
+}
+
+// DetectProvider guesses the hosting provider from the host name. Self-hosted GitHub
+// Enterprise, GitLab, Gitea and Bitbucket servers are recognised when the product name is
+// part of the host (github.example.com, gitlab.corp); anything else is ProviderUnknown.
+// Parse also recognises Bitbucket Server by its /scm/ and /projects/ paths.
+func DetectProvider(host string) Provider {
+	h := strings.ToLower(host)
+	if i := strings.LastIndex(h, ":"); i >= 0 {
+		h = h[:i]
+	}
+	switch {
+	case strings.Contains(h, "github"):
+		return ProviderGitHub
+	case strings.Contains(h, "gitlab"):
+		return ProviderGitLab
+	case h == "bitbucket.org":
+		return ProviderBitbucket
+	case strings.Contains(h, "bitbucket"):
+		return ProviderBitbucketServer
+	case h == "codeberg.org" || strings.Contains(h, "gitea") || strings.Contains(h, "forgejo"):
+		return ProviderGitea
+	}
+	return ProviderUnknown
+}
+
+// FullName returns "owner/name"
+func (r *Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// URL returns the canonical web/clone URL without the .git suffix
+func (r *Repo) URL() string {
+	if r.Provider == ProviderBitbucketServer {
+		return fmt.Sprintf("%s://%s/scm/%s", r.Scheme, r.Host, r.FullName())
+	}
+	return fmt.Sprintf("%s://%s/%s", r.Scheme, r.Host, r.FullName())
+}
+
+// CloneURL returns the https clone URL with the .git suffix
+func (r *Repo) CloneURL() string {
+	return r.URL() + ".git"
+}
+
+// SSHURL returns the scp-style SSH address
+func (r *Repo) SSHURL() string {
+	host := r.Host
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	return fmt.Sprintf("git@%s:%s.git", host, r.FullName())
+}
+
+// isBitbucketServerPath reports whether path is a Bitbucket Server clone (/scm/KEY/repo) or
+// web (/projects/KEY/repos/repo) path
+func isBitbucketServerPath(path string) bool {
+	segs := pathSegments(path)
+	return len(segs) >= 3 && segs[0] == "scm" ||
+		len(segs) >= 4 && (segs[0] == "projects" || segs[0] == "users") && segs[2] == "repos"
+}
+
+func pathSegments(path string) []string {
+	var segs []string
+	for _, seg := range strings.Split(path, "/") {
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+	return segs
+}
+
+// splitRepoPath extracts owner and name from a URL path. Trailing web paths such as
+// /tree/main (GitHub, Gitea), /src/main (Bitbucket), /-/tree/main (GitLab) or /browse
+// (Bitbucket Server) are ignored.
+func splitRepoPath(provider Provider, path string) (string, string, error) {
+	segs := pathSegments(path)
+
+	switch provider {
+	case ProviderGitLab:
+		// Projects can be nested in subgroups; "/-/" starts the web UI part of the path
+		for i, seg := range segs {
+			if seg == "-" {
+				segs = segs[:i]
+				break
+			}
+		}
+	case ProviderGitHub, ProviderGitea, ProviderBitbucket:
+		if len(segs) > 2 {
+			segs = segs[:2]
+		}
+	case ProviderBitbucketServer:
+		switch {
+		case len(segs) >= 3 && segs[0] == "scm":
+			segs = segs[1:3]
+		case len(segs) >= 4 && segs[0] == "projects" && segs[2] == "repos":
+			segs = []string{segs[1], segs[3]}
+		case len(segs) >= 4 && segs[0] == "users" && segs[2] == "repos":
+			// Personal repositories are cloned from /scm/~user/repo
+			segs = []string{"~" + segs[1], segs[3]}
+		case len(segs) > 2:
+			segs = segs[:2]
+		}
+	}
+	if len(segs) < 2 {
+		return "", "", fmt.Errorf("expected an owner/repo path")
+	}
+
+	name := strings.TrimSuffix(segs[len(segs)-1], ".git")
+	segs[len(segs)-1] = name
+	for i, seg := range segs {
+		if i == 0 && provider == ProviderBitbucketServer {
+			seg = strings.TrimPrefix(seg, "~")
+		}
+		if seg == "." || seg == ".." || !segmentRe.MatchString(seg) {
+			return "", "", fmt.Errorf("invalid path segment %q", seg)
+		}
+	}
+	return strings.Join(segs[:len(segs)-1], "/"), name, nil
+}
@@ -0,0 +1,85 @@
+package gitutil
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// APIBase returns the root of the provider's REST API for the repository's server, or ""
+// for an unknown provider
+func (r *Repo) APIBase() string {
+	switch r.Provider {
+	case ProviderGitHub:
+		if r.Host == "github.com" {
+			return "https://api.github.com"
+		}
+		return fmt.Sprintf("%s://%s/api/v3", r.Scheme, r.Host)
+	case ProviderGitLab:
+		return fmt.Sprintf("%s://%s/api/v4", r.Scheme, r.Host)
+	case ProviderGitea:
+		return fmt.Sprintf("%s://%s/api/v1", r.Scheme, r.Host)
+	case ProviderBitbucket:
+		return "https://api.bitbucket.org/2.0"
+	case ProviderBitbucketServer:
+		return fmt.Sprintf("%s://%s/rest/api/1.0", r.Scheme, r.Host)
+	}
+	return ""
+}
+
+// TokenEnv is the key of the project integration secret holding the token for provider.
+// Servers of unknown type use GIT_TOKEN.
+func TokenEnv(provider Provider) string {
+	switch provider {
+	case ProviderGitHub:
+		return "GITHUB_TOKEN"
+	case ProviderGitLab:
+		return "GITLAB_TOKEN"
+	case ProviderGitea:
+		return "GITEA_TOKEN"
+	case ProviderBitbucket, ProviderBitbucketServer:
+		return "BITBUCKET_TOKEN"
+	}
+	return "GIT_TOKEN"
+}
+
+// TokenUserinfo returns the credentials a token is sent as in an HTTPS clone URL. A token
+// of the form "user:secret" is sent as that user and password, as Bitbucket Server personal
+// access tokens and Bitbucket Cloud app passwords require; other tokens go with the user
+// name the provider expects for access tokens.
+func TokenUserinfo(provider Provider, token string) *url.Userinfo {
+	if user, secret, ok := splitUserToken(token); ok {
+		return url.UserPassword(user, secret)
+	}
+	switch provider {
+	case ProviderGitLab, ProviderGitea:
+		return url.UserPassword("oauth2", token)
+	case ProviderBitbucket, ProviderBitbucketServer:
+		return url.UserPassword("x-token-auth", token)
+	}
+	return url.UserPassword("x-access-token", token)
+}
+
+// AuthorizationHeader returns the Authorization header a token is sent with to the
+// provider's REST API: basic auth for "user:secret" tokens, "token <t>" for Gitea and a
+// bearer token otherwise
+func AuthorizationHeader(provider Provider, token string) string {
+	if user, secret, ok := splitUserToken(token); ok {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+secret))
+	}
+	if provider == ProviderGitea {
+		return "token " + token
+	}
+	return "Bearer " + token
+}
+
+// splitUserToken splits "user:secret". Access tokens of every supported provider are free
+// of colons, so one marks a user name.
+func splitUserToken(token string) (string, string, bool) {
+	user, secret, ok := strings.Cut(token, ":")
+	if !ok || user == "" || secret == "" {
+		return "", "", false
+	}
+	return user, secret, true
+}
@@ -0,0 +1,46 @@
+package gitutil
+
+import "testing"
+
+func TestRepo_APIBase(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://github.com/org/repo":             "https://api.github.com",
+		"https://github.example.com/org/repo":     "https://github.example.com/api/v3",
+		"https://gitlab.corp/group/repo":          "https://gitlab.corp/api/v4",
+		"https://gitea.corp:3000/team/repo":       "https://gitea.corp:3000/api/v1",
+		"https://bitbucket.org/team/repo":         "https://api.bitbucket.org/2.0",
+		"https://bitbucket.corp/scm/PLAT/api.git": "https://bitbucket.corp/rest/api/1.0",
+		"https://git.internal/team/repo":          "",
+	} {
+		r, err := Parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.APIBase(); got != want {
+			t.Errorf("APIBase(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTokenFormats(t *testing.T) {
+	cases := []struct {
+		provider Provider
+		token    string
+		userinfo string
+		header   string
+	}{
+		{ProviderGitHub, "ghs_abc", "x-access-token:ghs_abc", "Bearer ghs_abc"},
+		{ProviderGitLab, "glpat-abc", "oauth2:glpat-abc", "Bearer glpat-abc"},
+		{ProviderGitea, "abc123", "oauth2:abc123", "token abc123"},
+		{ProviderBitbucketServer, "BBDC-abc", "x-token-auth:BBDC-abc", "Bearer BBDC-abc"},
+		{ProviderBitbucketServer, "jdoe:BBDC-abc", "jdoe:BBDC-abc", "Basic amRvZTpCQkRDLWFiYw=="},
+	}
+	for _, tc := range cases {
+		if got := TokenUserinfo(tc.provider, tc.token).String(); got != tc.userinfo {
+			t.Errorf("TokenUserinfo(%s, %s) = %q, want %q", tc.provider, tc.token, got, tc.userinfo)
+		}
+		if got := AuthorizationHeader(tc.provider, tc.token); got != tc.header {
+			t.Errorf("AuthorizationHeader(%s, %s) = %q, want %q", tc.provider, tc.token, got, tc.header)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+#!/usr/bin/env bash
+# Regenerates deepcopy functions, the typed clientset, listers and informers for
+# apis/vteam/v1alpha1. Run from anywhere after changing types.go:
+#
+#   ./components/pkg/hack/update-codegen.sh
+#
+# Requires k8s.io/code-generator (matching the client-go version in go.mod) to be
+# downloadable; it is not a dependency of the module itself.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+PKG_ROOT="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
+CODEGEN_VERSION="${CODEGEN_VERSION:-$(cd "${PKG_ROOT}" && go list -m -f '{{.Version}}' k8s.io/client-go)}"
+CODEGEN_PKG="$(go env GOMODCACHE)/k8s.io/code-generator@${CODEGEN_VERSION}"
+
+if [ ! -d "${CODEGEN_PKG}" ]; then
+  (cd "${PKG_ROOT}" && go mod download "k8s.io/code-generator@${CODEGEN_VERSION}")
+fi
+
+source "${CODEGEN_PKG}/kube_codegen.sh"
+
+kube::codegen::gen_helpers \
+  --boilerplate "${PKG_ROOT}/hack/boilerplate.go.txt" \
+  "${PKG_ROOT}/apis"
+
+kube::codegen::gen_client \
+  --with-watch \
+  --output-dir "${PKG_ROOT}/client" \
+  --output-pkg "ambient-code-pkg/client" \
+  --plural-exceptions "ProjectSettings:ProjectSettings" \
+  --boilerplate "${PKG_ROOT}/hack/boilerplate.go.txt" \
+  "${PKG_ROOT}/apis"
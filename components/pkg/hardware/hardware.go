@@ -0,0 +1,127 @@
+// Package hardware checks that the cluster can run a runner pod with the requested
+// RunnerHardware before a session is accepted or its Job is created.
+//
+// A RuntimeClass that does not exist, or GPUs or an architecture no node offers, would
+// otherwise leave the runner pod Pending with only a scheduler event to explain why.
+package hardware
+
+import (
+	"context"
+	"fmt"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Check verifies the RuntimeClass exists and that at least one schedulable node matches the
+// architecture, the RuntimeClass's node selector and has enough allocatable GPUs. Only node
+// capacity is considered, not what is free right now: busy GPUs mean waiting, not failing.
+func Check(ctx context.Context, client kubernetes.Interface, h *apiv1alpha1.RunnerHardware) error {
+	if h == nil {
+		return nil
+	}
+	selector, err := runtimeClassSelector(ctx, client, h.RuntimeClassName)
+	if err != nil {
+		return err
+	}
+	if h.GPUs == 0 && h.Architecture == "" && selector.Empty() {
+		return nil
+	}
+	nodes, err := matchingNodes(ctx, client, selector, h)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no node offers %s", describe(h))
+	}
+	return nil
+}
+
+// Nodes returns the nodes that can run a runner needing h, by capacity; nil h matches every
+// schedulable node. Like Check, it fails when the RuntimeClass does not exist.
+func Nodes(ctx context.Context, client kubernetes.Interface, h *apiv1alpha1.RunnerHardware) ([]corev1.Node, error) {
+	if h == nil {
+		h = &apiv1alpha1.RunnerHardware{}
+	}
+	selector, err := runtimeClassSelector(ctx, client, h.RuntimeClassName)
+	if err != nil {
+		return nil, err
+	}
+	return matchingNodes(ctx, client, selector, h)
+}
+
+// runtimeClassSelector returns the node selector of a RuntimeClass; everything without one
+func runtimeClassSelector(ctx context.Context, client kubernetes.Interface, name string) (labels.Selector, error) {
+	if name == "" {
+		return labels.Everything(), nil
+	}
+	rc, err := client.NodeV1().RuntimeClasses().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("RuntimeClass %s does not exist", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RuntimeClass %s: %w", name, err)
+	}
+	if rc.Scheduling != nil && len(rc.Scheduling.NodeSelector) > 0 {
+		return labels.SelectorFromSet(rc.Scheduling.NodeSelector), nil
+	}
+	return labels.Everything(), nil
+}
+
+func matchingNodes(ctx context.Context, client kubernetes.Interface, selector labels.Selector, h *apiv1alpha1.RunnerHardware) ([]corev1.Node, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var out []corev1.Node
+	for i := range nodes.Items {
+		if NodeFits(&nodes.Items[i], h) {
+			out = append(out, nodes.Items[i])
+		}
+	}
+	return out, nil
+}
+
+// NodeFits reports whether node can run a runner needing h
+func NodeFits(node *corev1.Node, h *apiv1alpha1.RunnerHardware) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	if h.Architecture != "" && node.Labels[corev1.LabelArchStable] != h.Architecture {
+		return false
+	}
+	if h.GPUs > 0 {
+		gpus, ok := node.Status.Allocatable[corev1.ResourceName(apiv1alpha1.GPUResourceName)]
+		if !ok || gpus.Value() < h.GPUs {
+			return false
+		}
+	}
+	return true
+}
+
+func describe(h *apiv1alpha1.RunnerHardware) string {
+	s := ""
+	if h.GPUs > 0 {
+		s = fmt.Sprintf("%d %s", h.GPUs, apiv1alpha1.GPUResourceName)
+	}
+	if h.Architecture != "" {
+		if s != "" {
+			s += " on "
+		}
+		s += h.Architecture
+	}
+	if h.RuntimeClassName != "" {
+		if s == "" {
+			s = "the scheduling requirements of"
+		} else {
+			s += " matching"
+		}
+		s += " RuntimeClass " + h.RuntimeClassName
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package hardware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func node(name, arch string, gpus int64, extraLabels map[string]string) *corev1.Node {
+	n := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelArchStable: arch}},
+		Status:     corev1.NodeStatus{Allocatable: corev1.ResourceList{}},
+	}
+	for k, v := range extraLabels {
+		n.Labels[k] = v
+	}
+	if gpus > 0 {
+		n.Status.Allocatable[apiv1alpha1.GPUResourceName] = *resource.NewQuantity(gpus, resource.DecimalSI)
+	}
+	return n
+}
+
+func TestCheck(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		node("cpu-amd", "amd64", 0, nil),
+		node("gpu-amd", "amd64", 2, map[string]string{"gpu": "true"}),
+		node("cpu-arm", "arm64", 0, nil),
+		&nodev1.RuntimeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "nvidia"},
+			Handler:    "nvidia",
+			Scheduling: &nodev1.Scheduling{NodeSelector: map[string]string{"gpu": "true"}},
+		},
+		&nodev1.RuntimeClass{ObjectMeta: metav1.ObjectMeta{Name: "kata"}, Handler: "kata"},
+	)
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		h       *apiv1alpha1.RunnerHardware
+		wantErr string
+	}{
+		{"nothing requested", nil, ""},
+		{"arm64", &apiv1alpha1.RunnerHardware{Architecture: "arm64"}, ""},
+		{"two GPUs", &apiv1alpha1.RunnerHardware{GPUs: 2}, ""},
+		{"too many GPUs", &apiv1alpha1.RunnerHardware{GPUs: 4}, "no node offers 4 nvidia.com/gpu"},
+		{"GPUs on arm64", &apiv1alpha1.RunnerHardware{GPUs: 1, Architecture: "arm64"}, "no node offers 1 nvidia.com/gpu on arm64"},
+		{"RuntimeClass with GPUs", &apiv1alpha1.RunnerHardware{RuntimeClassName: "nvidia", GPUs: 1}, ""},
+		{"RuntimeClass nodes are amd64", &apiv1alpha1.RunnerHardware{RuntimeClassName: "nvidia", Architecture: "arm64"}, "arm64 matching RuntimeClass nvidia"},
+		{"RuntimeClass without scheduling", &apiv1alpha1.RunnerHardware{RuntimeClassName: "kata"}, ""},
+		{"missing RuntimeClass", &apiv1alpha1.RunnerHardware{RuntimeClassName: "gvisor"}, "RuntimeClass gvisor does not exist"},
+	}
+	for _, tc := range cases {
+		err := Check(ctx, client, tc.h)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%s: Check() = %v", tc.name, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%s: Check() = %v, want error containing %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestNodeFitsSkipsCordonedNodes(t *testing.T) {
+	n := node("gpu", "amd64", 1, nil)
+	n.Spec.Unschedulable = true
+	if NodeFits(n, &apiv1alpha1.RunnerHardware{GPUs: 1}) {
+		t.Error("a cordoned node must not count")
+	}
+}
+
+func TestNodes(t *testing.T) {
+	client := fake.NewSimpleClientset(node("cpu-amd", "amd64", 0, nil), node("gpu-amd", "amd64", 2, nil), node("cpu-arm", "arm64", 0, nil))
+	ctx := context.Background()
+	all, err := Nodes(ctx, client, nil)
+	if err != nil || len(all) != 3 {
+		t.Errorf("Nodes(nil) = %d nodes, %v; want all 3", len(all), err)
+	}
+	gpu, err := Nodes(ctx, client, &apiv1alpha1.RunnerHardware{GPUs: 1})
+	if err != nil || len(gpu) != 1 || gpu[0].Name != "gpu-amd" {
+		t.Errorf("Nodes(1 GPU) = %v, %v", gpu, err)
+	}
+	if _, err := Nodes(ctx, client, &apiv1alpha1.RunnerHardware{RuntimeClassName: "missing"}); err == nil {
+		t.Error("a missing RuntimeClass must be an error")
+	}
+}
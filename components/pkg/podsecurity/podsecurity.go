@@ -0,0 +1,287 @@
+// Package podsecurity applies a project's RunnerSecurity to runner pods and checks pods
+// against the Pod Security Standard a namespace enforces through Pod Security admission.
+//
+// Admission rejects the pods of a runner Job that violate the namespace's enforce level, and
+// the Job then retries without ever starting a runner. The operator checks the rendered pod
+// before it creates the Job; the backend reports whether a project's settings are compatible.
+// Only the checks that can fail for runner pods are implemented: the security context
+// fields, host namespaces and volume types.
+package podsecurity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Pod Security Standard levels
+const (
+	LevelPrivileged = "privileged"
+	LevelBaseline   = "baseline"
+	LevelRestricted = "restricted"
+)
+
+// Pod Security admission modes, each set by a pod-security.kubernetes.io/<mode> namespace label
+const (
+	ModeEnforce = "enforce"
+	ModeWarn    = "warn"
+	ModeAudit   = "audit"
+)
+
+const labelPrefix = "pod-security.kubernetes.io/"
+
+// tmpVolume backs /tmp when root filesystems are read-only
+const tmpVolume = "runner-tmp"
+
+// tmpSizeLimit caps the /tmp emptyDir; Playwright and tool caches write there
+var tmpSizeLimit = resource.MustParse("2Gi")
+
+// baselineCapabilities may be added under the baseline level
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true,
+	"KILL": true, "MKNOD": true, "NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true,
+	"SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+// Levels returns the namespace's level for each mode it labels. Modes without a label fall
+// back to the cluster's admission configuration, privileged unless changed.
+func Levels(ns *corev1.Namespace) map[string]string {
+	out := map[string]string{}
+	for _, mode := range []string{ModeEnforce, ModeWarn, ModeAudit} {
+		if v, ok := ns.Labels[labelPrefix+mode]; ok {
+			out[mode] = strings.ToLower(strings.TrimSpace(v))
+		}
+	}
+	return out
+}
+
+// Apply hardens every container of the pod with s: no privilege escalation, the dropped
+// capabilities and a read-only root filesystem with /tmp on an emptyDir; runAsNonRoot and the
+// seccomp profile are set for the whole pod. A nil s leaves the pod unchanged.
+func Apply(spec *corev1.PodSpec, s *apiv1alpha1.RunnerSecurity) {
+	if s == nil {
+		return
+	}
+	if s.RunAsNonRoot || s.SeccompProfile != "" {
+		if spec.SecurityContext == nil {
+			spec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		if s.RunAsNonRoot {
+			nonRoot := true
+			spec.SecurityContext.RunAsNonRoot = &nonRoot
+		}
+		if s.SeccompProfile != "" {
+			profile := &corev1.SeccompProfile{Type: corev1.SeccompProfileType(s.SeccompProfile)}
+			if s.SeccompProfile == apiv1alpha1.SeccompLocalhost {
+				path := s.LocalhostProfile
+				profile.LocalhostProfile = &path
+			}
+			spec.SecurityContext.SeccompProfile = profile
+		}
+	}
+	if s.ReadOnlyRootFilesystem && !hasVolume(spec, tmpVolume) {
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name:         tmpVolume,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &tmpSizeLimit}},
+		})
+	}
+	for i := range spec.InitContainers {
+		harden(&spec.InitContainers[i], s)
+	}
+	for i := range spec.Containers {
+		harden(&spec.Containers[i], s)
+	}
+}
+
+func harden(c *corev1.Container, s *apiv1alpha1.RunnerSecurity) {
+	if c.SecurityContext == nil {
+		c.SecurityContext = &corev1.SecurityContext{}
+	}
+	sc := c.SecurityContext
+	noEscalation := false
+	sc.AllowPrivilegeEscalation = &noEscalation
+	if s.ReadOnlyRootFilesystem {
+		readOnly := true
+		sc.ReadOnlyRootFilesystem = &readOnly
+		if !hasMount(c, "/tmp") {
+			c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: tmpVolume, MountPath: "/tmp"})
+		}
+	}
+	if len(s.DropCapabilities) > 0 {
+		if sc.Capabilities == nil {
+			sc.Capabilities = &corev1.Capabilities{}
+		}
+		for _, name := range s.DropCapabilities {
+			if !hasCapability(sc.Capabilities.Drop, corev1.Capability(name)) {
+				sc.Capabilities.Drop = append(sc.Capabilities.Drop, corev1.Capability(name))
+			}
+		}
+	}
+}
+
+// RunnerPod is the shape of the operator's runner pod: the runner container with its fixed
+// security context, the content service and workspace init containers without one and the
+// workspace volume. Hardened with s, it stands in for a project's runner pods when the
+// backend reports compatibility.
+func RunnerPod(s *apiv1alpha1.RunnerSecurity) *corev1.PodSpec {
+	noEscalation := false
+	spec := &corev1.PodSpec{
+		Volumes: []corev1.Volume{{
+			Name:         "workspace",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "workspace"}},
+		}},
+		InitContainers: []corev1.Container{{Name: "init-workspace"}},
+		Containers: []corev1.Container{
+			{Name: "ambient-content"},
+			{
+				Name: "ambient-code-runner",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: &noEscalation,
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			},
+		},
+	}
+	Apply(spec, s)
+	return spec
+}
+
+// Check returns what in the pod violates the level, sorted; nothing for privileged. Unknown
+// levels are checked as restricted, as admission treats them.
+func Check(level string, spec *corev1.PodSpec) []string {
+	if level == LevelPrivileged {
+		return nil
+	}
+	restricted := level != LevelBaseline
+	seen := map[string]bool{}
+	add := func(format string, args ...interface{}) {
+		seen[fmt.Sprintf(format, args...)] = true
+	}
+
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		add("pod: host namespaces are not allowed")
+	}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			add("volume %q: hostPath volumes are not allowed", v.Name)
+		} else if restricted && !restrictedVolume(v) {
+			add("volume %q: volume type is not allowed", v.Name)
+		}
+	}
+
+	pod := spec.SecurityContext
+	if pod == nil {
+		pod = &corev1.PodSecurityContext{}
+	}
+	podSeccomp := seccompType(pod.SeccompProfile)
+	if podSeccomp == corev1.SeccompProfileTypeUnconfined {
+		add("pod: seccompProfile must not be Unconfined")
+	}
+	if restricted && pod.RunAsUser != nil && *pod.RunAsUser == 0 {
+		add("pod: runAsUser must not be 0")
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		name := c.Name
+		if sc.Privileged != nil && *sc.Privileged {
+			add("container %q: privileged is not allowed", name)
+		}
+		seccomp := seccompType(sc.SeccompProfile)
+		if seccomp == corev1.SeccompProfileTypeUnconfined {
+			add("container %q: seccompProfile must not be Unconfined", name)
+		}
+		var added []corev1.Capability
+		if sc.Capabilities != nil {
+			added = sc.Capabilities.Add
+		}
+		for _, capability := range added {
+			if !baselineCapabilities[capability] || (restricted && capability != "NET_BIND_SERVICE") {
+				add("container %q: capability %s must not be added", name, capability)
+			}
+		}
+		if !restricted {
+			continue
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			add("container %q: allowPrivilegeEscalation must be false", name)
+		}
+		nonRoot := pod.RunAsNonRoot != nil && *pod.RunAsNonRoot
+		if sc.RunAsNonRoot != nil {
+			nonRoot = *sc.RunAsNonRoot
+		}
+		if !nonRoot {
+			add("container %q: runAsNonRoot must be true", name)
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			add("container %q: runAsUser must not be 0", name)
+		}
+		if seccomp == "" {
+			seccomp = podSeccomp
+		}
+		if seccomp != corev1.SeccompProfileTypeRuntimeDefault && seccomp != corev1.SeccompProfileTypeLocalhost {
+			add("container %q: seccompProfile must be RuntimeDefault or Localhost", name)
+		}
+		if sc.Capabilities == nil || !hasCapability(sc.Capabilities.Drop, "ALL") {
+			add("container %q: capabilities must drop ALL", name)
+		}
+	}
+
+	out := make([]string, 0, len(seen))
+	for v := range seen {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// restrictedVolume reports whether the restricted level allows the volume's type
+func restrictedVolume(v corev1.Volume) bool {
+	s := v.VolumeSource
+	return s.ConfigMap != nil || s.CSI != nil || s.DownwardAPI != nil || s.EmptyDir != nil ||
+		s.Ephemeral != nil || s.PersistentVolumeClaim != nil || s.Projected != nil || s.Secret != nil
+}
+
+func seccompType(p *corev1.SeccompProfile) corev1.SeccompProfileType {
+	if p == nil {
+		return ""
+	}
+	return p.Type
+}
+
+func hasCapability(caps []corev1.Capability, name corev1.Capability) bool {
+	for _, c := range caps {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVolume(spec *corev1.PodSpec, name string) bool {
+	for _, v := range spec.Volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMount(c *corev1.Container, path string) bool {
+	for _, m := range c.VolumeMounts {
+		if m.MountPath == path {
+			return true
+		}
+	}
+	return false
+}
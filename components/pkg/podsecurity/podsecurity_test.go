@@ -0,0 +1,91 @@
+package podsecurity
+
+import (
+	"strings"
+	"testing"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckRunnerPod(t *testing.T) {
+	// The default runner pod only satisfies baseline
+	if v := Check(LevelBaseline, RunnerPod(nil)); len(v) != 0 {
+		t.Errorf("baseline violations of the default pod: %v", v)
+	}
+	v := Check(LevelRestricted, RunnerPod(nil))
+	want := []string{
+		`container "ambient-code-runner": runAsNonRoot must be true`,
+		`container "ambient-content": allowPrivilegeEscalation must be false`,
+		`container "init-workspace": capabilities must drop ALL`,
+	}
+	for _, w := range want {
+		if !contains(v, w) {
+			t.Errorf("restricted violations %v miss %q", v, w)
+		}
+	}
+	if contains(v, `container "ambient-code-runner": allowPrivilegeEscalation must be false`) {
+		t.Errorf("runner container's own security context ignored: %v", v)
+	}
+
+	hardened := &apiv1alpha1.RunnerSecurity{RunAsNonRoot: true, SeccompProfile: apiv1alpha1.SeccompRuntimeDefault, DropCapabilities: []string{"ALL"}}
+	if v := Check(LevelRestricted, RunnerPod(hardened)); len(v) != 0 {
+		t.Errorf("restricted violations of the hardened pod: %v", v)
+	}
+	if v := Check("unknown", RunnerPod(nil)); len(v) == 0 {
+		t.Error("unknown level not checked as restricted")
+	}
+	if v := Check(LevelPrivileged, &corev1.PodSpec{HostNetwork: true}); v != nil {
+		t.Errorf("privileged violations: %v", v)
+	}
+
+	unconfined := &apiv1alpha1.RunnerSecurity{SeccompProfile: apiv1alpha1.SeccompUnconfined}
+	if v := Check(LevelBaseline, RunnerPod(unconfined)); !contains(v, "pod: seccompProfile must not be Unconfined") {
+		t.Errorf("baseline violations of an unconfined pod: %v", v)
+	}
+}
+
+func TestApplyReadOnlyRootFilesystem(t *testing.T) {
+	spec := RunnerPod(&apiv1alpha1.RunnerSecurity{ReadOnlyRootFilesystem: true})
+	if !hasVolume(spec, tmpVolume) {
+		t.Fatal("no /tmp volume")
+	}
+	for _, c := range append(spec.InitContainers, spec.Containers...) {
+		if c.SecurityContext.ReadOnlyRootFilesystem == nil || !*c.SecurityContext.ReadOnlyRootFilesystem || !hasMount(&c, "/tmp") {
+			t.Errorf("container %s: not read-only or /tmp missing", c.Name)
+		}
+	}
+	// Applying twice does not duplicate the volume
+	Apply(spec, &apiv1alpha1.RunnerSecurity{ReadOnlyRootFilesystem: true})
+	n := 0
+	for _, v := range spec.Volumes {
+		if v.Name == tmpVolume {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("%d /tmp volumes", n)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		"pod-security.kubernetes.io/enforce": "Restricted",
+		"pod-security.kubernetes.io/warn":    "baseline",
+	}}}
+	got := Levels(ns)
+	if got[ModeEnforce] != LevelRestricted || got[ModeWarn] != LevelBaseline || len(got) != 2 {
+		t.Errorf("Levels() = %v", got)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+// Package runtimeconfig loads the settings the backend and operator can change without a
+// restart. Deployments render them (e.g. from Helm values) into the ambient-runtime-config
+// ConfigMap, which is mounted as a file; a Watcher re-reads the file and applies the new
+// settings. Everything else stays in environment variables and needs a restart.
+//
+// The file is polled rather than watched with inotify: the kubelet updates ConfigMap volumes
+// by swapping a symlink, which file watches miss, and the read is cheap.
+package runtimeconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// DefaultPath is where the manifests mount the ConfigMap's config.yaml
+const DefaultPath = "/etc/ambient/runtime/config.yaml"
+
+// Log levels
+const (
+	LogLevelInfo  = "info"
+	LogLevelDebug = "debug"
+)
+
+// Settings is the runtime config file. Unset fields keep the value from the environment, so
+// removing a key reverts it at the next reload.
+type Settings struct {
+	// LogLevel is "info" (default) or "debug", which adds the Debugf logs
+	LogLevel string `json:"logLevel,omitempty"`
+	// Features are flags passed to new runner sessions; a session's own settings win
+	Features map[string]bool   `json:"features,omitempty"`
+	Backend  *BackendSettings  `json:"backend,omitempty"`
+	Operator *OperatorSettings `json:"operator,omitempty"`
+}
+
+// BackendSettings override the backend's environment
+type BackendSettings struct {
+	// SLOW_REQUEST_THRESHOLD; 0 disables slow-request logging
+	SlowRequestThreshold *metav1.Duration     `json:"slowRequestThreshold,omitempty"`
+	AuthLockout          *AuthLockoutSettings `json:"authLockout,omitempty"`
+}
+
+// AuthLockoutSettings override the AUTH_LOCKOUT_* limits; a zero failure limit disables its
+// scope. Lockouts already started keep their end time.
+type AuthLockoutSettings struct {
+	IdentityFailures *int             `json:"identityFailures,omitempty"`
+	IPFailures       *int             `json:"ipFailures,omitempty"`
+	Window           *metav1.Duration `json:"window,omitempty"`
+	Duration         *metav1.Duration `json:"duration,omitempty"`
+}
+
+// OperatorSettings override the operator's environment and flags
+type OperatorSettings struct {
+	// --max-concurrent-jobs; 0 = unlimited
+	MaxConcurrentJobs *int               `json:"maxConcurrentJobs,omitempty"`
+	RateLimit         *RateLimitSettings `json:"rateLimit,omitempty"`
+}
+
+// RateLimitSettings override RATE_LIMIT_MAX_SESSIONS_PER_KEY and RATE_LIMIT_BACKOFF
+type RateLimitSettings struct {
+	MaxSessionsPerKey *int             `json:"maxSessionsPerKey,omitempty"`
+	Backoff           *metav1.Duration `json:"backoff,omitempty"`
+}
+
+// Validate rejects settings that would be applied wrongly; the previous settings then stay
+func (s *Settings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.LogLevel != "" && s.LogLevel != LogLevelInfo && s.LogLevel != LogLevelDebug {
+		return fmt.Errorf("logLevel must be %q or %q", LogLevelInfo, LogLevelDebug)
+	}
+	negative := func(field string, n *int) error {
+		if n != nil && *n < 0 {
+			return fmt.Errorf("%s must not be negative", field)
+		}
+		return nil
+	}
+	positive := func(field string, d *metav1.Duration) error {
+		if d != nil && d.Duration <= 0 {
+			return fmt.Errorf("%s must be positive", field)
+		}
+		return nil
+	}
+	var errs []error
+	if b := s.Backend; b != nil {
+		if b.SlowRequestThreshold != nil && b.SlowRequestThreshold.Duration < 0 {
+			errs = append(errs, errors.New("backend.slowRequestThreshold must not be negative"))
+		}
+		if l := b.AuthLockout; l != nil {
+			errs = append(errs,
+				negative("backend.authLockout.identityFailures", l.IdentityFailures),
+				negative("backend.authLockout.ipFailures", l.IPFailures),
+				positive("backend.authLockout.window", l.Window),
+				positive("backend.authLockout.duration", l.Duration))
+		}
+	}
+	if o := s.Operator; o != nil {
+		errs = append(errs, negative("operator.maxConcurrentJobs", o.MaxConcurrentJobs))
+		if r := o.RateLimit; r != nil {
+			errs = append(errs,
+				negative("operator.rateLimit.maxSessionsPerKey", r.MaxSessionsPerKey),
+				positive("operator.rateLimit.backoff", r.Backoff))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Parse decodes a YAML or JSON runtime config file; unknown keys are rejected so typos are
+// not silently ignored
+func Parse(data []byte) (*Settings, error) {
+	s := &Settings{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return s, nil
+	}
+	raw, err := yaml.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+var current atomic.Pointer[Settings]
+
+// Current returns the settings last applied, empty before any file was read
+func Current() *Settings {
+	if s := current.Load(); s != nil {
+		return s
+	}
+	return &Settings{}
+}
+
+// SetCurrent replaces the current settings; tests use it instead of a file
+func SetCurrent(s *Settings) {
+	current.Store(s)
+}
+
+// Debugf logs like log.Printf when logLevel is "debug"
+func Debugf(format string, args ...interface{}) {
+	if Current().LogLevel == LogLevelDebug {
+		log.Printf(format, args...)
+	}
+}
+
+// Watcher re-reads the runtime config file and makes it Current when its content changes.
+// Components read Current() where they use a setting, so nothing has to be pushed to them.
+type Watcher struct {
+	Path     string
+	Interval time.Duration
+
+	mu   sync.Mutex
+	sum  [sha256.Size]byte
+	read bool
+}
+
+// NewWatcherFromEnv watches RUNTIME_CONFIG_FILE (default DefaultPath) every
+// RUNTIME_CONFIG_POLL_INTERVAL (Go duration, default 10s)
+func NewWatcherFromEnv() *Watcher {
+	w := &Watcher{Path: DefaultPath, Interval: 10 * time.Second}
+	if path := strings.TrimSpace(os.Getenv("RUNTIME_CONFIG_FILE")); path != "" {
+		w.Path = path
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("RUNTIME_CONFIG_POLL_INTERVAL"))); err == nil && d > 0 {
+		w.Interval = d
+	}
+	return w
+}
+
+// Reload reads the file and applies it if its content changed since the last read. A missing
+// file applies empty settings, i.e. the environment's. An invalid file is reported and the
+// previous settings stay.
+func (w *Watcher) Reload() (bool, error) {
+	data, err := os.ReadFile(w.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	sum := sha256.Sum256(data)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.read && sum == w.sum {
+		return false, nil
+	}
+	w.sum, w.read = sum, true
+	s, err := Parse(data)
+	if err != nil {
+		return false, fmt.Errorf("invalid runtime config %s: %w", w.Path, err)
+	}
+	current.Store(s)
+	return true, nil
+}
+
+// Run reloads the file every Interval until ctx is done
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if changed, err := w.Reload(); err != nil {
+				log.Printf("Keeping the previous runtime config: %v", err)
+			} else if changed {
+				log.Printf("Applied runtime config from %s", w.Path)
+			}
+		}
+	}
+}
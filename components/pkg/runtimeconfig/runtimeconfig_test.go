@@ -0,0 +1,81 @@
+package runtimeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(`
+logLevel: debug
+features:
+  newPlanner: true
+backend:
+  slowRequestThreshold: 500ms
+  authLockout:
+    identityFailures: 5
+operator:
+  maxConcurrentJobs: 0
+  rateLimit:
+    backoff: 2m
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if s.LogLevel != LogLevelDebug || !s.Features["newPlanner"] {
+		t.Errorf("settings = %+v", s)
+	}
+	if s.Backend.SlowRequestThreshold.Duration != 500*time.Millisecond || *s.Backend.AuthLockout.IdentityFailures != 5 || s.Backend.AuthLockout.IPFailures != nil {
+		t.Errorf("backend = %+v", s.Backend)
+	}
+	if *s.Operator.MaxConcurrentJobs != 0 || s.Operator.RateLimit.Backoff.Duration != 2*time.Minute {
+		t.Errorf("operator = %+v", s.Operator)
+	}
+
+	for _, invalid := range []string{
+		"logLevel: trace",
+		"logLevle: debug",
+		"operator:\n  maxConcurrentJobs: -1",
+		"backend:\n  authLockout:\n    window: 0s",
+		"operator:\n  rateLimit:\n    backoff: soon",
+	} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Errorf("Parse(%q) accepted", invalid)
+		}
+	}
+}
+
+// TestWatcherReload verifies a change is applied once, an invalid file keeps the previous
+// settings and a removed file reverts to the environment's
+func TestWatcherReload(t *testing.T) {
+	t.Cleanup(func() { SetCurrent(nil) })
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	w := &Watcher{Path: path, Interval: time.Second}
+
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("logLevel: debug\n")
+	if changed, err := w.Reload(); !changed || err != nil || Current().LogLevel != LogLevelDebug {
+		t.Fatalf("first reload: %v %v %+v", changed, err, Current())
+	}
+	if changed, _ := w.Reload(); changed {
+		t.Errorf("unchanged file applied again")
+	}
+
+	write("logLevel: verbose\n")
+	if _, err := w.Reload(); err == nil || Current().LogLevel != LogLevelDebug {
+		t.Errorf("invalid file: %v, level %q", err, Current().LogLevel)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := w.Reload(); !changed || err != nil || Current().LogLevel != "" {
+		t.Errorf("removed file: %v %v %+v", changed, err, Current())
+	}
+}
@@ -0,0 +1,314 @@
+// Package settingshistory keeps a revision history of a project's ProjectSettings.
+//
+// Every change to the settings spec is snapshotted into a ConfigMap in the project namespace,
+// numbered by a per-project revision counter. Who made the change, when, and the
+// field manager that wrote it are kept in annotations. The operator records every change it
+// observes (including kubectl edits); the backend records the changes it makes on behalf of a
+// user so they are attributed to that user. Snapshots are not owned by the ProjectSettings, so
+// the history survives an accidental delete of the settings object.
+package settingshistory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LabelRevision marks a ConfigMap as a ProjectSettings revision
+	LabelRevision = "ambient-code.io/projectsettings-revision"
+
+	AnnotationRevision    = "ambient-code.io/revision"
+	AnnotationGeneration  = "ambient-code.io/settings-generation"
+	AnnotationSettingsUID = "ambient-code.io/settings-uid"
+	AnnotationAuthor      = "ambient-code.io/changed-by"
+	AnnotationManager     = "ambient-code.io/field-manager"
+	AnnotationChangedAt   = "ambient-code.io/changed-at"
+	AnnotationRollbackOf  = "ambient-code.io/rollback-of"
+
+	specKey = "spec.json"
+
+	// MaxRevisions is the number of revisions kept per project; older ones are pruned
+	MaxRevisions = 50
+)
+
+// Revision is one recorded state of a ProjectSettings spec
+type Revision struct {
+	// Revision numbers increase by one per recorded change
+	Revision int64 `json:"revision"`
+	// Generation is the ProjectSettings metadata.generation that introduced this spec
+	Generation int64 `json:"generation"`
+	// Author is the user who made the change, when it was made through the backend
+	Author string `json:"author,omitempty"`
+	// Manager is the field manager that wrote the change (e.g. kubectl-edit)
+	Manager    string                          `json:"manager,omitempty"`
+	ChangedAt  time.Time                       `json:"changedAt"`
+	RollbackOf int64                           `json:"rollbackOf,omitempty"`
+	Spec       apiv1alpha1.ProjectSettingsSpec `json:"spec"`
+
+	// settingsUID tells a recreated ProjectSettings apart from the one it replaced, whose
+	// generations started over
+	settingsUID string
+}
+
+// Change is one field that differs between two revisions. Lists are compared as a whole.
+type Change struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ConfigMapName returns the name of the snapshot of a revision
+func ConfigMapName(revision int64) string {
+	return fmt.Sprintf("projectsettings-rev-%d", revision)
+}
+
+// Record snapshots ps unless its spec equals the latest recorded revision. A snapshot that
+// already exists for this object generation (the operator usually sees a change first) only
+// gets a missing author and rollbackOf filled in. Older revisions beyond MaxRevisions are
+// pruned. It returns the revision describing ps, or nil when nothing needed to be recorded.
+func Record(ctx context.Context, client kubernetes.Interface, ps *apiv1alpha1.ProjectSettings, author string, rollbackOf int64) (*Revision, error) {
+	cms := client.CoreV1().ConfigMaps(ps.Namespace)
+	list, err := cms.List(ctx, metav1.ListOptions{LabelSelector: LabelRevision + "=true"})
+	if err != nil {
+		return nil, fmt.Errorf("list revisions in %s: %w", ps.Namespace, err)
+	}
+	revs := fromConfigMaps(list.Items)
+
+	for i := range list.Items {
+		existing := &list.Items[i]
+		if existing.Annotations[AnnotationSettingsUID] != string(ps.UID) || existing.Annotations[AnnotationGeneration] != strconv.FormatInt(ps.Generation, 10) {
+			continue
+		}
+		// A no-op write keeps the generation, so never overwrite an author already recorded
+		setAuthor := author != "" && existing.Annotations[AnnotationAuthor] == ""
+		setRollback := rollbackOf != 0 && existing.Annotations[AnnotationRollbackOf] == ""
+		if !setAuthor && !setRollback {
+			return FromConfigMap(existing)
+		}
+		if setAuthor {
+			existing.Annotations[AnnotationAuthor] = author
+		}
+		if setRollback {
+			existing.Annotations[AnnotationRollbackOf] = strconv.FormatInt(rollbackOf, 10)
+		}
+		updated, err := cms.Update(ctx, existing, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("update revision %s/%s: %w", ps.Namespace, existing.Name, err)
+		}
+		return FromConfigMap(updated)
+	}
+
+	next := int64(1)
+	if len(revs) > 0 {
+		latest := revs[0]
+		if latest.settingsUID == string(ps.UID) && latest.Generation > ps.Generation {
+			// A newer change was already recorded; ps is a stale event
+			return nil, nil
+		}
+		if reflect.DeepEqual(normalize(latest.Spec), normalize(ps.Spec)) {
+			// Metadata or status-only update: generation moved, the spec did not
+			return nil, nil
+		}
+		next = latest.Revision + 1
+	}
+
+	rev := &Revision{
+		Revision:    next,
+		Generation:  ps.Generation,
+		Author:      author,
+		Manager:     LastSpecManager(ps),
+		ChangedAt:   time.Now().UTC().Truncate(time.Second),
+		RollbackOf:  rollbackOf,
+		Spec:        ps.Spec,
+		settingsUID: string(ps.UID),
+	}
+	cm, err := toConfigMap(ps.Namespace, rev)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cms.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		if errors.IsAlreadyExists(err) {
+			// Lost the race with the other recorder: attribute its snapshot instead
+			return Record(ctx, client, ps, author, rollbackOf)
+		}
+		return nil, fmt.Errorf("create revision %s/%s: %w", ps.Namespace, cm.Name, err)
+	}
+
+	all := append([]Revision{*rev}, revs...)
+	if len(all) <= MaxRevisions {
+		return rev, nil
+	}
+	for _, old := range all[MaxRevisions:] {
+		if err := cms.Delete(ctx, ConfigMapName(old.Revision), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return rev, fmt.Errorf("prune revision %d: %w", old.Revision, err)
+		}
+	}
+	return rev, nil
+}
+
+// List returns the recorded revisions of a project, newest first
+func List(ctx context.Context, client kubernetes.Interface, namespace string) ([]Revision, error) {
+	list, err := client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{LabelSelector: LabelRevision + "=true"})
+	if err != nil {
+		return nil, fmt.Errorf("list revisions in %s: %w", namespace, err)
+	}
+	return fromConfigMaps(list.Items), nil
+}
+
+func fromConfigMaps(items []corev1.ConfigMap) []Revision {
+	revs := make([]Revision, 0, len(items))
+	for i := range items {
+		rev, err := FromConfigMap(&items[i])
+		if err != nil {
+			continue
+		}
+		revs = append(revs, *rev)
+	}
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Revision > revs[j].Revision })
+	return revs
+}
+
+// Get returns a single revision
+func Get(ctx context.Context, client kubernetes.Interface, namespace string, revision int64) (*Revision, error) {
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, ConfigMapName(revision), metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if cm.Labels[LabelRevision] != "true" {
+		return nil, errors.NewNotFound(corev1.Resource("configmaps"), cm.Name)
+	}
+	return FromConfigMap(cm)
+}
+
+// FromConfigMap decodes a revision snapshot
+func FromConfigMap(cm *corev1.ConfigMap) (*Revision, error) {
+	rev := &Revision{
+		Author:      cm.Annotations[AnnotationAuthor],
+		Manager:     cm.Annotations[AnnotationManager],
+		settingsUID: cm.Annotations[AnnotationSettingsUID],
+	}
+	var err error
+	if rev.Revision, err = strconv.ParseInt(cm.Annotations[AnnotationRevision], 10, 64); err != nil {
+		return nil, fmt.Errorf("configmap %s: invalid revision: %w", cm.Name, err)
+	}
+	rev.Generation, _ = strconv.ParseInt(cm.Annotations[AnnotationGeneration], 10, 64)
+	if v := cm.Annotations[AnnotationRollbackOf]; v != "" {
+		rev.RollbackOf, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if t, err := time.Parse(time.RFC3339, cm.Annotations[AnnotationChangedAt]); err == nil {
+		rev.ChangedAt = t
+	} else {
+		rev.ChangedAt = cm.CreationTimestamp.UTC()
+	}
+	if err := json.Unmarshal([]byte(cm.Data[specKey]), &rev.Spec); err != nil {
+		return nil, fmt.Errorf("configmap %s: invalid spec: %w", cm.Name, err)
+	}
+	return rev, nil
+}
+
+func toConfigMap(namespace string, rev *Revision) (*corev1.ConfigMap, error) {
+	spec, err := json.Marshal(rev.Spec)
+	if err != nil {
+		return nil, err
+	}
+	annotations := map[string]string{
+		AnnotationRevision:    strconv.FormatInt(rev.Revision, 10),
+		AnnotationGeneration:  strconv.FormatInt(rev.Generation, 10),
+		AnnotationSettingsUID: rev.settingsUID,
+		AnnotationChangedAt:   rev.ChangedAt.Format(time.RFC3339),
+	}
+	if rev.Author != "" {
+		annotations[AnnotationAuthor] = rev.Author
+	}
+	if rev.Manager != "" {
+		annotations[AnnotationManager] = rev.Manager
+	}
+	if rev.RollbackOf != 0 {
+		annotations[AnnotationRollbackOf] = strconv.FormatInt(rev.RollbackOf, 10)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ConfigMapName(rev.Revision),
+			Namespace:   namespace,
+			Labels:      map[string]string{LabelRevision: "true", "ambient-code.io/managed": "true"},
+			Annotations: annotations,
+		},
+		Data: map[string]string{specKey: string(spec)},
+	}, nil
+}
+
+// LastSpecManager returns the field manager of the most recent write that touched the spec
+func LastSpecManager(ps *apiv1alpha1.ProjectSettings) string {
+	var manager string
+	var latest time.Time
+	for _, mf := range ps.ManagedFields {
+		if mf.FieldsV1 == nil || mf.Time == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if json.Unmarshal(mf.FieldsV1.Raw, &fields) != nil {
+			continue
+		}
+		if _, ok := fields["f:spec"]; !ok {
+			continue
+		}
+		if !mf.Time.Time.Before(latest) {
+			latest = mf.Time.Time
+			manager = mf.Manager
+		}
+	}
+	return manager
+}
+
+// Diff lists the fields that differ between two specs, as dotted JSON paths
+func Diff(from, to apiv1alpha1.ProjectSettingsSpec) []Change {
+	changes := []Change{}
+	diffValues("spec", normalize(from), normalize(to), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffValues(path string, from, to interface{}, changes *[]Change) {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if fromIsMap && toIsMap {
+		keys := map[string]struct{}{}
+		for k := range fromMap {
+			keys[k] = struct{}{}
+		}
+		for k := range toMap {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			diffValues(path+"."+k, fromMap[k], toMap[k], changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, Change{Path: path, Old: from, New: to})
+	}
+}
+
+// normalize converts a spec to its JSON object form, so omitted and empty fields compare equal
+func normalize(spec apiv1alpha1.ProjectSettingsSpec) interface{} {
+	b, _ := json.Marshal(spec)
+	var out map[string]interface{}
+	_ = json.Unmarshal(b, &out)
+	// groupAccess is not omitempty: null and [] both mean "no groups"
+	if ga, _ := out["groupAccess"].([]interface{}); len(ga) == 0 {
+		delete(out, "groupAccess")
+	}
+	return out
+}
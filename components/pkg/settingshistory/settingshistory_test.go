@@ -0,0 +1,112 @@
+package settingshistory
+
+import (
+	"context"
+	"testing"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func settings(generation int64, spec apiv1alpha1.ProjectSettingsSpec) *apiv1alpha1.ProjectSettings {
+	return &apiv1alpha1.ProjectSettings{
+		ObjectMeta: metav1.ObjectMeta{Name: apiv1alpha1.ProjectSettingsName, Namespace: "proj", Generation: generation},
+		Spec:       spec,
+	}
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	// Operator sees the change first, without an author
+	if rev, err := Record(ctx, client, settings(1, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 3}), "", 0); err != nil || rev == nil {
+		t.Fatalf("Record gen 1 = %v, %v", rev, err)
+	}
+	// Backend attributes the same generation
+	rev, err := Record(ctx, client, settings(1, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 3}), "alice", 0)
+	if err != nil || rev.Author != "alice" {
+		t.Fatalf("attribution = %+v, %v", rev, err)
+	}
+	// A later no-op write does not steal the attribution
+	if rev, _ := Record(ctx, client, settings(1, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 3}), "bob", 0); rev.Author != "alice" {
+		t.Errorf("author overwritten: %q", rev.Author)
+	}
+	// Status-only update: generation moves, spec does not
+	if rev, err := Record(ctx, client, settings(2, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 3, GroupAccess: []apiv1alpha1.GroupAccess{}}), "", 0); err != nil || rev != nil {
+		t.Fatalf("status-only update recorded: %v, %v", rev, err)
+	}
+	if _, err := Record(ctx, client, settings(3, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 5, MaxIdleMinutes: 30}), "bob", 0); err != nil {
+		t.Fatal(err)
+	}
+	// Stale event for an older generation
+	if rev, err := Record(ctx, client, settings(2, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 9}), "", 0); err != nil || rev != nil {
+		t.Fatalf("stale event recorded: %v, %v", rev, err)
+	}
+
+	revs, err := List(ctx, client, "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 || revs[0].Revision != 2 || revs[0].Generation != 3 || revs[1].Author != "alice" {
+		t.Fatalf("List = %+v", revs)
+	}
+	changes := Diff(revs[1].Spec, revs[0].Spec)
+	if len(changes) != 2 || changes[0].Path != "spec.maxActiveSessions" || changes[1].Path != "spec.maxIdleMinutes" {
+		t.Errorf("Diff = %+v", changes)
+	}
+}
+
+func TestRecord_RecreatedSettings(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	old := settings(7, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 3})
+	old.UID = "uid-1"
+	if _, err := Record(ctx, client, old, "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleted and restored: generations start over under a new UID
+	restored := settings(1, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: 4})
+	restored.UID = "uid-2"
+	rev, err := Record(ctx, client, restored, "alice", 1)
+	if err != nil || rev == nil {
+		t.Fatalf("Record restored = %v, %v", rev, err)
+	}
+	if rev.Revision != 2 || rev.RollbackOf != 1 {
+		t.Errorf("restored revision = %+v", rev)
+	}
+}
+
+func TestRecord_Prunes(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	for gen := int64(1); gen <= MaxRevisions+5; gen++ {
+		if _, err := Record(ctx, client, settings(gen, apiv1alpha1.ProjectSettingsSpec{MaxActiveSessions: int(gen)}), "", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	revs, _ := List(ctx, client, "proj")
+	if len(revs) != MaxRevisions || revs[len(revs)-1].Revision != 6 {
+		t.Errorf("kept %d revisions, oldest %d", len(revs), revs[len(revs)-1].Revision)
+	}
+}
+
+func TestDiff_NestedAndLists(t *testing.T) {
+	from := apiv1alpha1.ProjectSettingsSpec{
+		LLMProvider: &apiv1alpha1.LLMProvider{Provider: "anthropic"},
+		GroupAccess: []apiv1alpha1.GroupAccess{{GroupName: "devs", Role: "edit"}},
+	}
+	to := apiv1alpha1.ProjectSettingsSpec{
+		LLMProvider: &apiv1alpha1.LLMProvider{Provider: "vertex"},
+	}
+	changes := Diff(from, to)
+	if len(changes) != 2 || changes[0].Path != "spec.groupAccess" || changes[1].Path != "spec.llmProvider.provider" {
+		t.Fatalf("Diff = %+v", changes)
+	}
+	if changes[1].Old != "anthropic" || changes[1].New != "vertex" {
+		t.Errorf("provider change = %+v", changes[1])
+	}
+}
@@ -0,0 +1,191 @@
+// Package validation holds the AgenticSession spec rules. The backend applies them to
+// sessions created and updated through the API, and the operator's validating webhook to
+// sessions created with kubectl or GitOps, so both paths accept exactly the same specs.
+//
+// Only rules that need nothing but the spec live here. Checks against cluster state (the
+// project's allowed secrets, whether a node can satisfy the hardware request, whether the
+// workspaceFrom session exists) stay with the callers.
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+	"ambient-code-pkg/gitutil"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ParentSessionEnv is the environment variable a continuation names its parent session in
+const ParentSessionEnv = "PARENT_SESSION_ID"
+
+// MaxSessionGroupLength bounds spec.sessionGroup; the name ends up in PVC, Lease and label values
+const MaxSessionGroupLength = 40
+
+// ReservedEnvVars are set by the operator from project policy and cannot come from
+// spec.environmentVariables, which would otherwise switch the branch protection off
+var ReservedEnvVars = []string{"GIT_ALLOWED_TARGET_BRANCHES", "GIT_REQUIRE_PR", "GIT_FORBID_FORCE_PUSH"}
+
+var sessionGroupPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// ValidSessionGroupName reports whether name can be used as spec.sessionGroup
+func ValidSessionGroupName(name string) bool {
+	return len(name) <= MaxSessionGroupLength && sessionGroupPattern.MatchString(name)
+}
+
+// ValidateAgenticSessionSpec returns every rule spec breaks, with field paths under fldPath
+// (usually field.NewPath("spec"))
+func ValidateAgenticSessionSpec(spec *apiv1alpha1.AgenticSessionSpec, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if spec.Timeout < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("timeout"), spec.Timeout, "must not be negative"))
+	}
+	if err := spec.LLMSettings.Validate(); err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("llmSettings"), spec.LLMSettings, err.Error()))
+	}
+	errs = append(errs, validateRepos(spec, fldPath)...)
+	if spec.CostLimit != nil {
+		if spec.CostLimit.USD < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("costLimit", "usd"), spec.CostLimit.USD, "must not be negative"))
+		}
+		if spec.CostLimit.Tokens < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("costLimit", "tokens"), spec.CostLimit.Tokens, "must not be negative"))
+		}
+	}
+	names := make([]string, 0, len(spec.EnvironmentVariables))
+	for name := range spec.EnvironmentVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, msg := range validation.IsEnvVarName(name) {
+			errs = append(errs, field.Invalid(fldPath.Child("environmentVariables").Key(name), name, msg))
+		}
+		for _, reserved := range ReservedEnvVars {
+			if name == reserved {
+				errs = append(errs, field.Forbidden(fldPath.Child("environmentVariables").Key(name), "is set from the project's branch protection"))
+			}
+		}
+	}
+	errs = append(errs, validateWorkspace(spec, fldPath)...)
+	if spec.ParentSession != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.ParentSession) {
+			errs = append(errs, field.Invalid(fldPath.Child("parentSession"), spec.ParentSession, msg))
+		}
+	}
+	for i, src := range spec.EnvFromSecrets {
+		p := fldPath.Child("envFromSecrets").Index(i)
+		if src.Name == "" {
+			errs = append(errs, field.Required(p.Child("name"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1123Subdomain(src.Name) {
+				errs = append(errs, field.Invalid(p.Child("name"), src.Name, msg))
+			}
+		}
+		for j, key := range src.Keys {
+			for _, msg := range validation.IsConfigMapKey(key) {
+				errs = append(errs, field.Invalid(p.Child("keys").Index(j), key, msg))
+			}
+		}
+	}
+	if err := spec.Hardware.Validate(); err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("hardware"), spec.Hardware, err.Error()))
+	}
+	if spec.ResourceOverrides != nil {
+		errs = append(errs, validateResourceOverrides(spec.ResourceOverrides, fldPath.Child("resourceOverrides"))...)
+	}
+	if spec.BotAccount != nil && strings.TrimSpace(spec.BotAccount.Name) == "" {
+		errs = append(errs, field.Required(fldPath.Child("botAccount", "name"), ""))
+	}
+	if spec.ActiveWorkflow != nil && strings.TrimSpace(spec.ActiveWorkflow.GitURL) == "" {
+		errs = append(errs, field.Required(fldPath.Child("activeWorkflow", "gitUrl"), ""))
+	}
+	return errs
+}
+
+// ValidateAgenticSessionSpecUpdate validates a changed spec. An unchanged spec passes, so
+// sessions stored before a rule was added can still be labelled, annotated and deleted.
+func ValidateAgenticSessionSpecUpdate(newSpec, oldSpec *apiv1alpha1.AgenticSessionSpec, fldPath *field.Path) field.ErrorList {
+	if equality.Semantic.DeepEqual(newSpec, oldSpec) {
+		return nil
+	}
+	return ValidateAgenticSessionSpec(newSpec, fldPath)
+}
+
+func validateRepos(spec *apiv1alpha1.AgenticSessionSpec, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, repo := range spec.Repos {
+		p := fldPath.Child("repos").Index(i)
+		if strings.TrimSpace(repo.Input.URL) == "" {
+			errs = append(errs, field.Required(p.Child("input", "url"), ""))
+		} else if _, err := gitutil.Normalize(repo.Input.URL); err != nil {
+			errs = append(errs, field.Invalid(p.Child("input", "url"), repo.Input.URL, err.Error()))
+		}
+		if repo.Output != nil && strings.TrimSpace(repo.Output.URL) != "" {
+			if _, err := gitutil.Normalize(repo.Output.URL); err != nil {
+				errs = append(errs, field.Invalid(p.Child("output", "url"), repo.Output.URL, err.Error()))
+			}
+		}
+	}
+	// An index without repos is ignored, as clients send 0 by default
+	if spec.MainRepoIndex != nil && (*spec.MainRepoIndex < 0 || (len(spec.Repos) > 0 && *spec.MainRepoIndex >= len(spec.Repos))) {
+		errs = append(errs, field.Invalid(fldPath.Child("mainRepoIndex"), *spec.MainRepoIndex, fmt.Sprintf("must index one of the %d repos", len(spec.Repos))))
+	}
+	return errs
+}
+
+// validateWorkspace checks the fields that pick where the workspace comes from: a
+// continuation reuses its parent's, workspaceFrom copies another session's and a session
+// group shares one, so at most one may be set
+func validateWorkspace(spec *apiv1alpha1.AgenticSessionSpec, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	parent := spec.EnvironmentVariables[ParentSessionEnv] != ""
+	if spec.WorkspaceFrom != "" {
+		p := fldPath.Child("workspaceFrom")
+		for _, msg := range validation.IsDNS1123Subdomain(spec.WorkspaceFrom) {
+			errs = append(errs, field.Invalid(p, spec.WorkspaceFrom, msg))
+		}
+		if parent {
+			errs = append(errs, field.Forbidden(p, "cannot be combined with a parent session; continuations already reuse the parent's workspace"))
+		}
+	}
+	if spec.SessionGroup != "" {
+		p := fldPath.Child("sessionGroup")
+		if !ValidSessionGroupName(spec.SessionGroup) {
+			errs = append(errs, field.Invalid(p, spec.SessionGroup, fmt.Sprintf("must be a lowercase DNS label of at most %d characters", MaxSessionGroupLength)))
+		}
+		if spec.WorkspaceFrom != "" || parent {
+			errs = append(errs, field.Forbidden(p, "cannot be combined with workspaceFrom or a parent session; the group's workspace is shared"))
+		}
+	}
+	return errs
+}
+
+func validateResourceOverrides(o *apiv1alpha1.ResourceOverrides, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, q := range []struct{ name, value string }{{"cpu", o.CPU}, {"memory", o.Memory}} {
+		if q.value == "" {
+			continue
+		}
+		if parsed, err := resource.ParseQuantity(q.value); err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child(q.name), q.value, "must be a quantity such as 500m or 2Gi"))
+		} else if parsed.Sign() <= 0 {
+			errs = append(errs, field.Invalid(fldPath.Child(q.name), q.value, "must be positive"))
+		}
+	}
+	for _, class := range []struct{ name, value string }{{"storageClass", o.StorageClass}, {"priorityClass", o.PriorityClass}} {
+		if class.value == "" {
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(class.value) {
+			errs = append(errs, field.Invalid(fldPath.Child(class.name), class.value, msg))
+		}
+	}
+	return errs
+}
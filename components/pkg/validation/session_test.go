@@ -0,0 +1,75 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1alpha1 "ambient-code-pkg/apis/vteam/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateAgenticSessionSpec(t *testing.T) {
+	valid := apiv1alpha1.AgenticSessionSpec{
+		Prompt:       "Fix the flaky test",
+		Timeout:      300,
+		Repos:        []apiv1alpha1.SessionRepo{{Input: apiv1alpha1.GitRepo{URL: "https://github.com/org/repo"}}},
+		SessionGroup: "nightly",
+		LLMSettings:  &apiv1alpha1.LLMSettings{Model: "sonnet", ContextStrategy: apiv1alpha1.ContextStrategySummarize},
+	}
+	if errs := ValidateAgenticSessionSpec(&valid, field.NewPath("spec")); len(errs) != 0 {
+		t.Fatalf("valid spec rejected: %v", errs)
+	}
+
+	mainRepo := 3
+	invalid := apiv1alpha1.AgenticSessionSpec{
+		Timeout:              -1,
+		Repos:                []apiv1alpha1.SessionRepo{{Input: apiv1alpha1.GitRepo{URL: "not a repo"}}},
+		MainRepoIndex:        &mainRepo,
+		CostLimit:            &apiv1alpha1.CostLimit{USD: -1},
+		EnvironmentVariables: map[string]string{ParentSessionEnv: "parent", "1BAD": "x", "GIT_FORBID_FORCE_PUSH": "false"},
+		WorkspaceFrom:        "source",
+		SessionGroup:         "Nightly",
+		ParentSession:        "Previous_Run",
+		EnvFromSecrets:       []apiv1alpha1.SecretEnvSource{{Name: ""}},
+		ResourceOverrides:    &apiv1alpha1.ResourceOverrides{CPU: "lots"},
+		LLMSettings:          &apiv1alpha1.LLMSettings{MaxContextTokens: 10},
+	}
+	var got []string
+	for _, err := range ValidateAgenticSessionSpec(&invalid, field.NewPath("spec")) {
+		got = append(got, err.Field)
+	}
+	want := []string{
+		"spec.timeout",
+		"spec.llmSettings",
+		"spec.repos[0].input.url",
+		"spec.mainRepoIndex",
+		"spec.costLimit.usd",
+		"spec.environmentVariables[1BAD]",
+		"spec.environmentVariables[GIT_FORBID_FORCE_PUSH]",
+		"spec.workspaceFrom",
+		"spec.sessionGroup",
+		"spec.sessionGroup",
+		"spec.parentSession",
+		"spec.envFromSecrets[0].name",
+		"spec.resourceOverrides.cpu",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("error fields = %v, want %v", got, want)
+	}
+}
+
+// TestValidateAgenticSessionSpecUpdate verifies an unchanged spec passes even when it breaks
+// a rule, and a changed one is validated
+func TestValidateAgenticSessionSpecUpdate(t *testing.T) {
+	old := apiv1alpha1.AgenticSessionSpec{Prompt: "legacy", Timeout: -1}
+	same := old
+	if errs := ValidateAgenticSessionSpecUpdate(&same, &old, field.NewPath("spec")); len(errs) != 0 {
+		t.Errorf("unchanged spec rejected: %v", errs)
+	}
+	changed := old
+	changed.Prompt = "edited"
+	if errs := ValidateAgenticSessionSpecUpdate(&changed, &old, field.NewPath("spec")); len(errs) != 1 {
+		t.Errorf("changed spec errors = %v, want the timeout", errs)
+	}
+}
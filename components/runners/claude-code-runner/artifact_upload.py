@@ -0,0 +1,214 @@
+"""
+Resumable artifact uploads from the runner to the backend.
+
+Speaks the backend's tus-style protocol (see components/backend/handlers/artifact_upload.go):
+
+    POST  <base>/internal/artifacts/<session>/uploads      {"path", "size", "sha256"}
+    HEAD  <base>/internal/artifacts/<session>/uploads/<id>  -> Upload-Offset
+    PATCH <base>/internal/artifacts/<session>/uploads/<id>  Upload-Offset + chunk
+
+The upload ID is derived from path, size and checksum, so after a network blip (or a
+runner restart) creating the upload again returns the offset the backend already has and
+only the remainder is sent.
+
+When the backend requires mutual TLS for internal endpoints, the operator sets
+INTERNAL_API_URL to its TLS listener and mounts the session's client certificate in
+RUNNER_TLS_DIR (tls.crt, tls.key, ca.crt).
+"""
+
+import base64
+import hashlib
+import json
+import logging
+import os
+import ssl
+import time
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Callable, Dict, List, Optional, Tuple
+from urllib import error as urllib_error
+from urllib import request as urllib_request
+
+CHUNK_SIZE = 8 * 1024 * 1024
+MAX_ATTEMPTS = 8
+OFFSET_CONTENT_TYPE = "application/offset+octet-stream"
+
+# (status, headers, body)
+Response = Tuple[int, Dict[str, str], bytes]
+# transport(method, url, headers, body) -> Response; raises OSError on network failures
+Transport = Callable[[str, str, Dict[str, str], Optional[bytes]], Response]
+
+
+class ArtifactUploadError(Exception):
+    """An artifact could not be uploaded after all retries"""
+
+
+@dataclass
+class UploadResult:
+    path: str
+    size: int
+    sha256: str
+
+
+def internal_ssl_context() -> Optional[ssl.SSLContext]:
+    """Client certificate context for the backend's mTLS listener, if RUNNER_TLS_DIR is mounted"""
+    tls_dir = (os.getenv("RUNNER_TLS_DIR") or "").strip()
+    if not tls_dir:
+        return None
+    cert, key, ca = (Path(tls_dir) / name for name in ("tls.crt", "tls.key", "ca.crt"))
+    if not (cert.is_file() and key.is_file() and ca.is_file()):
+        return None
+    ctx = ssl.create_default_context(cafile=str(ca))
+    ctx.load_cert_chain(str(cert), str(key))
+    return ctx
+
+
+def urllib_transport(method: str, url: str, headers: Dict[str, str], body: Optional[bytes]) -> Response:
+    """Default transport; HTTP error statuses are returned, network errors raise"""
+    req = urllib_request.Request(url, data=body, headers=headers, method=method)
+    context = internal_ssl_context() if url.startswith("https://") else None
+    try:
+        with urllib_request.urlopen(req, timeout=60, context=context) as resp:
+            return resp.status, {k.lower(): v for k, v in resp.headers.items()}, resp.read()
+    except urllib_error.HTTPError as he:
+        return he.code, {k.lower(): v for k, v in (he.headers or {}).items()}, he.read()
+
+
+def file_sha256(path: Path) -> str:
+    h = hashlib.sha256()
+    with open(path, "rb") as f:
+        for block in iter(lambda: f.read(1024 * 1024), b""):
+            h.update(block)
+    return h.hexdigest()
+
+
+def uploads_url_from_env(session_name: str) -> Optional[str]:
+    """Derive the uploads endpoint from INTERNAL_API_URL, else BACKEND_API_URL (".../api")"""
+    base = (os.getenv("INTERNAL_API_URL") or os.getenv("BACKEND_API_URL") or "").strip().rstrip("/")
+    if not base or not session_name:
+        return None
+    if base.endswith("/api"):
+        base = base[: -len("/api")]
+    return f"{base}/internal/artifacts/{session_name}/uploads"
+
+
+class ArtifactUploader:
+    """Uploads files in chunks, resuming from the backend's offset after failures"""
+
+    def __init__(
+        self,
+        uploads_url: str,
+        token: str = "",
+        transport: Transport = urllib_transport,
+        chunk_size: int = CHUNK_SIZE,
+        max_attempts: int = MAX_ATTEMPTS,
+        sleep: Callable[[float], None] = time.sleep,
+    ):
+        self.uploads_url = uploads_url.rstrip("/")
+        self.token = token
+        self.transport = transport
+        self.chunk_size = chunk_size
+        self.max_attempts = max_attempts
+        self.sleep = sleep
+
+    def _headers(self, extra: Optional[Dict[str, str]] = None) -> Dict[str, str]:
+        headers = {}
+        if self.token:
+            headers["Authorization"] = f"Bearer {self.token}"
+        if extra:
+            headers.update(extra)
+        return headers
+
+    def _backoff(self, attempt: int) -> None:
+        self.sleep(min(30.0, 0.5 * (2 ** attempt)))
+
+    def upload_file(self, file_path: Path, rel_path: str) -> UploadResult:
+        size = file_path.stat().st_size
+        digest = file_sha256(file_path)
+        failures = 0
+        upload_id, offset = None, 0
+
+        while True:
+            try:
+                if upload_id is None:
+                    upload_id, offset, done = self._create(rel_path, size, digest)
+                    if done:
+                        return UploadResult(rel_path, size, digest)
+                with open(file_path, "rb") as f:
+                    while offset < size:
+                        f.seek(offset)
+                        chunk = f.read(self.chunk_size)
+                        status, headers, body = self._patch(upload_id, offset, chunk)
+                        if status == 204:
+                            offset = int(headers.get("upload-offset", offset + len(chunk)))
+                            failures = 0
+                        elif status == 200:
+                            return UploadResult(rel_path, size, digest)
+                        elif status in (409, 460):
+                            # Backend has a different offset (or the chunk got corrupted): resync
+                            offset = self._resync(upload_id, headers)
+                        elif status in (404, 422):
+                            # Upload vanished or failed whole-file verification: start over
+                            raise _Restart(f"HTTP {status}: {body[:200]!r}")
+                        elif status >= 500 or status == 408:
+                            raise OSError(f"HTTP {status}")
+                        else:
+                            raise ArtifactUploadError(f"{rel_path}: HTTP {status}: {body[:200]!r}")
+                # Every byte sent but no completion seen: the next create finalizes the upload
+                upload_id = None
+            except _Restart as r:
+                logging.warning(f"Restarting upload of {rel_path}: {r}")
+                upload_id, offset = None, 0
+            except OSError as e:
+                logging.warning(f"Upload of {rel_path} interrupted at offset {offset}: {e}")
+                # Ask the backend where to continue on the next attempt
+                upload_id = None
+            failures += 1
+            if failures >= self.max_attempts:
+                raise ArtifactUploadError(f"{rel_path}: giving up after {failures} attempts")
+            self._backoff(failures)
+
+    def _create(self, rel_path: str, size: int, digest: str) -> Tuple[str, int, bool]:
+        body = json.dumps({"path": rel_path, "size": size, "sha256": digest}).encode("utf-8")
+        status, headers, resp = self.transport(
+            "POST", self.uploads_url, self._headers({"Content-Type": "application/json"}), body
+        )
+        if status >= 500:
+            raise OSError(f"HTTP {status}")
+        if status == 422:
+            # A previously completed attempt failed verification and was discarded
+            raise _Restart(f"HTTP {status}: {resp[:200]!r}")
+        if status not in (200, 201):
+            raise ArtifactUploadError(f"{rel_path}: create upload failed: HTTP {status}: {resp[:200]!r}")
+        data = json.loads(resp.decode("utf-8") or "{}")
+        # 200 means the backend already has the complete file (empty artifacts, lost completion)
+        return data.get("id", ""), int(data.get("offset", 0)), status == 200
+
+    def _patch(self, upload_id: str, offset: int, chunk: bytes) -> Response:
+        checksum = base64.b64encode(hashlib.sha256(chunk).digest()).decode("ascii")
+        headers = self._headers({
+            "Content-Type": OFFSET_CONTENT_TYPE,
+            "Upload-Offset": str(offset),
+            "Upload-Checksum": f"sha256 {checksum}",
+        })
+        return self.transport("PATCH", f"{self.uploads_url}/{upload_id}", headers, chunk)
+
+    def _resync(self, upload_id: str, headers: Dict[str, str]) -> int:
+        if "upload-offset" in headers:
+            return int(headers["upload-offset"])
+        status, head, _ = self.transport("HEAD", f"{self.uploads_url}/{upload_id}", self._headers(), None)
+        if status != 200:
+            raise _Restart(f"HEAD returned {status}")
+        return int(head.get("upload-offset", 0))
+
+    def upload_dir(self, root: Path) -> List[UploadResult]:
+        """Upload every regular file under root; returns the uploaded files"""
+        results = []
+        for path in sorted(p for p in root.rglob("*") if p.is_file() and not p.is_symlink()):
+            rel = path.relative_to(root).as_posix()
+            results.append(self.upload_file(path, rel))
+        return results
+
+
+class _Restart(Exception):
+    """Internal: the upload has to be created again from offset 0"""
@@ -0,0 +1,96 @@
+"""
+Context window management for spec.llmSettings.contextStrategy and maxContextTokens.
+
+Claude Code compacts a conversation on its own when the model's context window is nearly
+full. A session can set a smaller budget: after every turn the runner compares the prompt size
+of the turn's last model call with maxContextTokens and, once it is reached, shrinks the
+conversation with the configured strategy before the next prompt:
+
+- truncate: /clear starts a fresh conversation. The workspace is kept; earlier discussion is not.
+- summarize: /compact replaces the conversation with a summary. The default.
+- retrieval: the agent keeps working notes in the workspace. /clear drops the conversation
+  and the next prompt tells the agent to re-read its notes.
+"""
+
+STRATEGY_TRUNCATE = "truncate"
+STRATEGY_SUMMARIZE = "summarize"
+STRATEGY_RETRIEVAL = "retrieval"
+STRATEGIES = (STRATEGY_TRUNCATE, STRATEGY_SUMMARIZE, STRATEGY_RETRIEVAL)
+
+# Notes file of the retrieval strategy, relative to the artifacts directory
+RETRIEVAL_NOTES = "context-notes.md"
+
+# Prompt usage fields that make up the context of a model call
+CONTEXT_FIELDS = ("input_tokens", "cache_creation_input_tokens", "cache_read_input_tokens")
+
+
+class ContextWindow:
+    """Tracks the conversation size and decides when to shrink it."""
+
+    def __init__(self, strategy: str = "", max_tokens: int = 0):
+        strategy = (strategy or "").strip().lower()
+        self.strategy = strategy if strategy in STRATEGIES else STRATEGY_SUMMARIZE
+        self.max_tokens = max(int(max_tokens or 0), 0)
+        self._context_tokens = 0
+        self._cleared = False
+
+    @classmethod
+    def from_env(cls, get_env) -> "ContextWindow":
+        """Reads LLM_CONTEXT_STRATEGY and LLM_MAX_CONTEXT_TOKENS; malformed values are ignored."""
+        try:
+            max_tokens = int(get_env('LLM_MAX_CONTEXT_TOKENS') or 0)
+        except (TypeError, ValueError):
+            max_tokens = 0
+        return cls(get_env('LLM_CONTEXT_STRATEGY') or "", max_tokens)
+
+    @property
+    def context_tokens(self) -> int:
+        return self._context_tokens
+
+    def on_event(self, event):
+        """Record the prompt size of a model call from its message_start stream event."""
+        if not isinstance(event, dict) or event.get("type") != "message_start":
+            return
+        usage = (event.get("message") or {}).get("usage")
+        if not isinstance(usage, dict):
+            return
+        tokens = 0
+        for field in CONTEXT_FIELDS:
+            value = usage.get(field)
+            if isinstance(value, (int, float)):
+                tokens += int(value)
+        self._context_tokens = tokens
+
+    def command(self) -> str | None:
+        """The slash command that shrinks the conversation, once it reached max_tokens."""
+        if not self.max_tokens or self._context_tokens < self.max_tokens:
+            return None
+        self._context_tokens = 0
+        if self.strategy == STRATEGY_SUMMARIZE:
+            return "/compact"
+        self._cleared = True
+        return "/clear"
+
+    def system_prompt(self, artifacts_path: str) -> str:
+        """Instructions added to the system prompt; only the retrieval strategy has any."""
+        if self.strategy != STRATEGY_RETRIEVAL or not self.max_tokens:
+            return ""
+        return (
+            "## Context Notes\n"
+            f"Your conversation history is cleared whenever it grows past {self.max_tokens} tokens. "
+            f"Keep {artifacts_path}/{RETRIEVAL_NOTES} up to date with the task, decisions made, files "
+            "involved and next steps, and prefer targeted Grep/Glob searches and partial Reads over "
+            "reading whole files.\n\n"
+        )
+
+    def prepare_prompt(self, text: str, artifacts_path: str) -> str:
+        """The next prompt; after the retrieval strategy cleared the conversation it points the
+        agent to its notes."""
+        if not self._cleared or self.strategy != STRATEGY_RETRIEVAL:
+            self._cleared = False
+            return text
+        self._cleared = False
+        return (
+            f"(The conversation history was cleared to save context. Read {artifacts_path}/{RETRIEVAL_NOTES} "
+            f"before continuing.)\n\n{text}"
+        )
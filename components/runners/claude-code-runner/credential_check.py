@@ -0,0 +1,182 @@
+"""
+Pre-flight check of the session's credentials before the agent starts.
+
+A revoked API key or a token without push access otherwise surfaces minutes into the run,
+after the clone and the first model call. Each check makes one cheap authenticated request:
+
+    Anthropic  GET /v1/models?limit=1 with the API key
+    Vertex AI  an OAuth token refresh of the service account key
+    GitHub     GET /rate_limit with the token; X-OAuth-Scopes lists a classic token's scopes
+
+Only a definite rejection (401/403, a refused refresh, missing scopes) fails the check.
+Network errors and unexpected statuses are logged and let the session start, so an
+outage of the check's endpoint never blocks work the agent could still do.
+"""
+
+import json
+import logging
+import os
+from dataclasses import dataclass
+from typing import Dict, List, Optional
+from urllib import error as urllib_error
+from urllib import request as urllib_request
+from urllib.parse import urlparse
+
+from artifact_upload import Transport
+
+ANTHROPIC_API_URL = "https://api.anthropic.com"
+ANTHROPIC_VERSION = "2023-06-01"
+VERTEX_SCOPE = "https://www.googleapis.com/auth/cloud-platform"
+CHECK_TIMEOUT = 10
+
+# Condition reasons reported to the backend in status.conditions[CredentialsVerified]
+REASON_ANTHROPIC_INVALID = "AnthropicCredentialsInvalid"
+REASON_VERTEX_INVALID = "VertexCredentialsInvalid"
+REASON_GIT_TOKEN_INVALID = "GitTokenInvalid"
+REASON_GIT_SCOPES = "GitTokenScopesInsufficient"
+
+# Classic token scopes that allow pushing to a repository
+PUSH_SCOPES = ("repo", "public_repo")
+
+
+class CredentialCheckError(Exception):
+    """A credential was rejected; reason is one of the REASON_* constants"""
+
+    def __init__(self, reason: str, message: str):
+        super().__init__(message)
+        self.reason = reason
+        self.message = message
+
+
+@dataclass
+class GitTarget:
+    """A repository host the session authenticates to and whether it pushes there"""
+
+    host: str
+    push: bool
+
+
+def enabled() -> bool:
+    """CREDENTIAL_PREFLIGHT=false turns the check off, e.g. for air-gapped model gateways"""
+    return (os.getenv("CREDENTIAL_PREFLIGHT") or "true").strip().lower() not in ("0", "false", "no")
+
+
+def external_transport(method: str, url: str, headers: Dict[str, str], body: Optional[bytes]):
+    """Transport for public APIs with a short timeout; HTTP error statuses are returned"""
+    req = urllib_request.Request(url, data=body, headers=headers, method=method)
+    try:
+        with urllib_request.urlopen(req, timeout=CHECK_TIMEOUT) as resp:
+            return resp.status, {k.lower(): v for k, v in resp.headers.items()}, resp.read()
+    except urllib_error.HTTPError as he:
+        return he.code, {k.lower(): v for k, v in (he.headers or {}).items()}, he.read()
+
+
+def check_anthropic(api_key: str, base_url: str = "", transport: Transport = external_transport) -> Dict[str, str]:
+    """Returns the response headers, which carry the key's rate limit headroom"""
+    base = (base_url or ANTHROPIC_API_URL).rstrip("/")
+    try:
+        status, headers, body = transport("GET", f"{base}/v1/models?limit=1", {
+            "x-api-key": api_key,
+            "anthropic-version": ANTHROPIC_VERSION,
+        }, None)
+    except Exception as e:
+        logging.warning(f"Anthropic credential check inconclusive: {e}")
+        return {}
+    if status in (401, 403):
+        raise CredentialCheckError(
+            REASON_ANTHROPIC_INVALID,
+            f"Anthropic API rejected the API key (HTTP {status}): {_error_message(body)}",
+        )
+    if status != 200:
+        logging.warning(f"Anthropic credential check inconclusive: HTTP {status}")
+    return headers or {}
+
+
+def check_vertex(credentials_path: str) -> None:
+    try:
+        from google.auth import exceptions as google_exceptions
+        from google.auth.transport.requests import Request
+        from google.oauth2 import service_account
+    except ImportError:
+        logging.warning("Vertex credential check skipped: google-auth is not installed")
+        return
+    try:
+        creds = service_account.Credentials.from_service_account_file(credentials_path, scopes=[VERTEX_SCOPE])
+        creds.refresh(Request())
+    except google_exceptions.TransportError as e:
+        logging.warning(f"Vertex credential check inconclusive: {e}")
+    except (google_exceptions.RefreshError, ValueError, OSError) as e:
+        raise CredentialCheckError(
+            REASON_VERTEX_INVALID,
+            f"Google rejected the Vertex AI service account key: {e}",
+        )
+
+
+def git_targets(repos: List[dict], auto_push: bool) -> List[GitTarget]:
+    """The GitHub hosts of the session's repos; auto-push only ever pushes to output repos"""
+    targets: Dict[str, GitTarget] = {}
+
+    def add(url: str, push: bool):
+        try:
+            host = (urlparse(url).hostname or "").lower()
+        except ValueError:
+            return
+        # Only github.com tokens are checked: other hosts have no common scope API
+        if host != "github.com":
+            return
+        t = targets.setdefault(host, GitTarget(host=host, push=False))
+        t.push = t.push or push
+
+    for r in repos:
+        input_url = str((r.get("input") or {}).get("url") or "")
+        output = r.get("output") or {}
+        output_url = str(output.get("url") or "") if isinstance(output, dict) else ""
+        add(input_url, False)
+        if output_url:
+            add(output_url, auto_push)
+    return list(targets.values())
+
+
+def check_github_token(token: str, target: GitTarget, transport: Transport = external_transport) -> None:
+    try:
+        status, headers, body = transport("GET", "https://api.github.com/rate_limit", {
+            "Authorization": f"Bearer {token}",
+            "Accept": "application/vnd.github+json",
+        }, None)
+    except Exception as e:
+        logging.warning(f"Git token check inconclusive: {e}")
+        return
+    if status == 401:
+        raise CredentialCheckError(
+            REASON_GIT_TOKEN_INVALID,
+            f"{target.host} rejected the Git token (HTTP 401): {_error_message(body)}",
+        )
+    if status != 200:
+        logging.warning(f"Git token check inconclusive: HTTP {status}")
+        return
+    # Only classic tokens report scopes; fine-grained and App tokens are checked at push time
+    raw = headers.get("x-oauth-scopes")
+    if raw is None or not target.push:
+        return
+    scopes = {s.strip() for s in raw.split(",") if s.strip()}
+    if not scopes.intersection(PUSH_SCOPES):
+        granted = ", ".join(sorted(scopes)) or "none"
+        raise CredentialCheckError(
+            REASON_GIT_SCOPES,
+            f"The Git token cannot push to {target.host}: it needs the repo or public_repo scope (granted: {granted})",
+        )
+
+
+def _error_message(body: bytes) -> str:
+    """The message of a JSON error body, else the start of the body"""
+    text = (body or b"").decode("utf-8", errors="replace")
+    try:
+        data = json.loads(text)
+        err = data.get("error") if isinstance(data, dict) else None
+        if isinstance(err, dict) and err.get("message"):
+            return str(err["message"])
+        if isinstance(data, dict) and data.get("message"):
+            return str(data["message"])
+    except ValueError:
+        pass
+    return text[:200]
@@ -0,0 +1,50 @@
+"""
+Turns the SDK's partial-message stream events into message.delta payloads, so the UI can show
+assistant text as it is generated. The complete text block is still sent once the message ends.
+"""
+
+
+class DeltaTracker:
+    """Maps raw Anthropic stream events (StreamEvent.event) to delta payloads.
+
+    Each text content block becomes one delta stream identified by "<message id>:<block index>";
+    fragments are numbered from 0 and the stream ends with a payload carrying "done": True.
+    Thinking and tool input deltas are not forwarded.
+    """
+
+    def __init__(self):
+        self._message_id = ""
+        self._text_blocks: dict[int, int] = {}  # block index -> next fragment index
+
+    def on_event(self, event) -> dict | None:
+        """Return the delta payload for one stream event, or None if nothing is sent."""
+        if not isinstance(event, dict):
+            return None
+        kind = event.get("type")
+        if kind == "message_start":
+            self._message_id = str((event.get("message") or {}).get("id") or "")
+            self._text_blocks = {}
+        elif kind == "content_block_start":
+            block = event.get("content_block") or {}
+            if block.get("type") == "text":
+                self._text_blocks[event.get("index", 0)] = 0
+        elif kind == "content_block_delta":
+            index = event.get("index", 0)
+            delta = event.get("delta") or {}
+            if index not in self._text_blocks or delta.get("type") != "text_delta":
+                return None
+            text = delta.get("text") or ""
+            if not text:
+                return None
+            fragment = self._text_blocks[index]
+            self._text_blocks[index] = fragment + 1
+            return {"id": self._stream_id(index), "index": fragment, "text": text}
+        elif kind == "content_block_stop":
+            index = event.get("index", 0)
+            if index in self._text_blocks:
+                fragments = self._text_blocks.pop(index)
+                return {"id": self._stream_id(index), "index": fragments, "done": True}
+        return None
+
+    def _stream_id(self, index) -> str:
+        return f"{self._message_id}:{index}"
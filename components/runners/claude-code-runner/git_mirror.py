@@ -0,0 +1,56 @@
+"""
+Clone acceleration from the project's git mirror cache.
+
+When ProjectSettings spec.gitMirror is enabled the operator mounts a volume of bare mirrors at
+GIT_MIRROR_DIR. Clones borrow objects from the matching mirror with --reference-if-able and
+--dissociate, so only objects newer than the mirror are downloaded and the clone stays valid
+after the mirror is pruned.
+"""
+
+import os
+from pathlib import Path
+from typing import List, Optional
+
+
+def git_mirror_key(url: str) -> str:
+    """Mirror directory of a repo relative to the mirror root, e.g. "github.com/org/repo.git".
+
+    Must match gitMirrorKey in the operator (internal/handlers/gitmirror.go). Unusable URLs
+    yield "".
+    """
+    u = (url or "").strip()
+    has_scheme = False
+    if "://" in u:
+        u = u.split("://", 1)[1]
+        has_scheme = True
+    slash = u.find("/")
+    at = u.find("@")
+    if at >= 0 and (slash < 0 or at < slash):
+        u = u[at + 1:]
+    # scp-like syntax: host:org/repo
+    slash = u.find("/")
+    colon = u.find(":")
+    if not has_scheme and colon >= 0 and (slash < 0 or colon < slash):
+        u = u[:colon] + "/" + u[colon + 1:]
+    u = u.lower().rstrip("/")
+    if u.endswith(".git"):
+        u = u[: -len(".git")]
+    parts = u.split("/")
+    if len(parts) < 2:
+        return ""
+    for p in parts:
+        if p in ("", ".", "..") or " " in p or "\t" in p:
+            return ""
+    return u + ".git"
+
+
+def reference_args(url: str, mirror_dir: Optional[str] = None) -> List[str]:
+    """Extra `git clone` arguments borrowing objects from the repo's mirror, if it has one"""
+    root = (mirror_dir if mirror_dir is not None else os.getenv("GIT_MIRROR_DIR", "")).strip()
+    key = git_mirror_key(url)
+    if not root or not key:
+        return []
+    mirror = Path(root) / key
+    if not (mirror / "objects").is_dir():
+        return []
+    return ["--reference-if-able", str(mirror), "--dissociate"]
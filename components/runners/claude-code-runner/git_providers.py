@@ -0,0 +1,155 @@
+"""
+Git hosting providers other than GitHub.
+
+Mirrors ambient-code-pkg/gitutil: the provider of a repository URL, the integration
+secret key (and so the env var) holding its token, how the token goes into a clone URL,
+and where its pull request API lives. GitHub itself keeps its App token handling in
+github_token.
+"""
+
+import base64
+import os
+from dataclasses import dataclass
+from typing import Optional, Tuple
+from urllib.parse import quote, urlparse
+
+GITHUB = "github"
+GITLAB = "gitlab"
+GITEA = "gitea"
+BITBUCKET = "bitbucket"
+BITBUCKET_SERVER = "bitbucket-server"
+
+TOKEN_ENV = {
+    GITHUB: "GITHUB_TOKEN",
+    GITLAB: "GITLAB_TOKEN",
+    GITEA: "GITEA_TOKEN",
+    BITBUCKET: "BITBUCKET_TOKEN",
+    BITBUCKET_SERVER: "BITBUCKET_TOKEN",
+}
+
+
+@dataclass
+class Repo:
+    provider: str
+    scheme: str
+    host: str
+    owner: str  # Bitbucket Server: the project key, or ~user for personal repos
+    name: str
+
+
+def detect_provider(host: str) -> str:
+    h = (host or "").lower().split(":", 1)[0]
+    if "github" in h:
+        return GITHUB
+    if "gitlab" in h:
+        return GITLAB
+    if h == "bitbucket.org":
+        return BITBUCKET
+    if "bitbucket" in h:
+        return BITBUCKET_SERVER
+    if h == "codeberg.org" or "gitea" in h or "forgejo" in h:
+        return GITEA
+    return ""
+
+
+def parse(url: str) -> Optional[Repo]:
+    """The repository an http(s) URL points at, or None"""
+    try:
+        p = urlparse((url or "").strip())
+    except ValueError:
+        return None
+    if p.scheme not in ("http", "https") or not p.hostname:
+        return None
+    host = p.hostname + (f":{p.port}" if p.port else "")
+    segs = [s for s in p.path.split("/") if s]
+    provider = detect_provider(host)
+    server_path = (len(segs) >= 3 and segs[0] == "scm") or (
+        len(segs) >= 4 and segs[0] in ("projects", "users") and segs[2] == "repos"
+    )
+    if provider == "" and server_path:
+        provider = BITBUCKET_SERVER
+    if provider == BITBUCKET_SERVER and server_path:
+        if segs[0] == "scm":
+            owner, name = segs[1], segs[2]
+        else:
+            owner, name = ("~" + segs[1] if segs[0] == "users" else segs[1]), segs[3]
+    elif len(segs) >= 2:
+        owner, name = segs[0], segs[1]
+    else:
+        return None
+    return Repo(provider=provider, scheme=p.scheme, host=host, owner=owner, name=name.removesuffix(".git"))
+
+
+def provider_token(url: str) -> str:
+    """The token the session has for a non-GitHub repository, from its provider's env var"""
+    repo = parse(url)
+    if repo is None or repo.provider == GITHUB:
+        return ""
+    return (os.getenv(TOKEN_ENV.get(repo.provider, "GIT_TOKEN")) or "").strip()
+
+
+def _split_user_token(token: str) -> Tuple[str, str]:
+    user, sep, secret = token.partition(":")
+    if not sep or not user or not secret:
+        return "", ""
+    return user, secret
+
+
+def url_userinfo(provider: str, token: str) -> str:
+    """The user:password a token is sent as in a clone URL. "user:secret" tokens, as
+    Bitbucket Server access tokens and Bitbucket Cloud app passwords need, keep their user."""
+    user, secret = _split_user_token(token)
+    if user:
+        return f"{quote(user, safe='')}:{quote(secret, safe='')}"
+    if provider in (GITLAB, GITEA):
+        user = "oauth2"
+    elif provider in (BITBUCKET, BITBUCKET_SERVER):
+        user = "x-token-auth"
+    else:
+        user = "x-access-token"
+    return f"{user}:{quote(token, safe='')}"
+
+
+def authorization_header(provider: str, token: str) -> str:
+    user, secret = _split_user_token(token)
+    if user:
+        return "Basic " + base64.b64encode(f"{user}:{secret}".encode("utf-8")).decode("ascii")
+    if provider == GITEA:
+        return f"token {token}"
+    return f"Bearer {token}"
+
+
+def pull_request(upstream: Repo, fork: Repo, head_branch: str, base_branch: str, title: str, body: str) -> Tuple[str, dict]:
+    """The API URL and payload that open a pull request on Gitea or Bitbucket Server"""
+    base = f"{upstream.scheme}://{upstream.host}"
+    if upstream.provider == GITEA:
+        same = (upstream.owner, upstream.name) == (fork.owner, fork.name)
+        return f"{base}/api/v1/repos/{upstream.owner}/{upstream.name}/pulls", {
+            "title": title,
+            "body": body,
+            "head": head_branch if same else f"{fork.owner}:{head_branch}",
+            "base": base_branch,
+        }
+    if upstream.provider == BITBUCKET_SERVER:
+
+        def ref(repo: Repo, branch: str) -> dict:
+            return {
+                "id": f"refs/heads/{branch}",
+                "repository": {"slug": repo.name, "project": {"key": repo.owner}},
+            }
+
+        return f"{base}/rest/api/1.0/projects/{upstream.owner}/repos/{upstream.name}/pull-requests", {
+            "title": title,
+            "description": body,
+            "fromRef": ref(fork, head_branch),
+            "toRef": ref(upstream, base_branch),
+        }
+    raise ValueError(f"pull requests are not supported on {upstream.host}")
+
+
+def pull_request_url(provider: str, response: dict) -> Optional[str]:
+    """The web URL of a pull request from the API's create response"""
+    if provider == BITBUCKET_SERVER:
+        links = (response.get("links") or {}).get("self") or []
+        return (links[0] or {}).get("href") if links else None
+    return response.get("html_url") or None
@@ -0,0 +1,51 @@
+"""
+Short-lived GitHub App tokens for the runner.
+
+The backend keeps a repository-scoped installation token in the session's
+ambient-github-token-<session> Secret and refreshes it before it expires; the operator
+mounts it at GITHUB_TOKEN_FILE. The kubelet updates the file in place, so it is read on
+every use instead of once at start, and git gets it through a credential helper rather
+than a token embedded in the remote URL, which would stop working after an hour.
+"""
+
+import os
+import shlex
+from typing import Optional
+from urllib.parse import urlparse
+
+# Hosts the mounted token is valid for; the backend only mints tokens for github.com installations
+TOKEN_FILE_HOSTS = ("github.com",)
+
+
+def token_file_path() -> Optional[str]:
+    path = (os.getenv("GITHUB_TOKEN_FILE") or "").strip()
+    return path or None
+
+
+def read_token_file() -> str:
+    """The current App token, or "" when none is mounted (yet)"""
+    path = token_file_path()
+    if not path:
+        return ""
+    try:
+        with open(path, "r", encoding="utf-8") as f:
+            return f.read().strip()
+    except OSError:
+        return ""
+
+
+def uses_token_file(url: str) -> bool:
+    """Whether git should authenticate to url with the mounted token through the helper"""
+    if not read_token_file():
+        return False
+    try:
+        host = (urlparse(url).hostname or "").lower()
+    except ValueError:
+        return False
+    return host in TOKEN_FILE_HOSTS
+
+
+def credential_helper(path: str) -> str:
+    """A git credential helper that answers "get" with the token currently in path"""
+    quoted = shlex.quote(path)
+    return f'!f() {{ test "$1" = get || exit 0; echo username=x-access-token; echo "password=$(cat {quoted})"; }}; f'
@@ -0,0 +1,113 @@
+"""
+Step progress reported to the operator through the ambient-code.io/progress annotation.
+
+The operator maps the reports to status.steps and an overall status.progress percentage.
+"""
+
+import json
+import re
+
+PROGRESS_ANNOTATION = "ambient-code.io/progress"
+
+# Steps of a run, in order; must match SessionStepNames in the shared API package
+STEPS = ("clone", "analyze", "edit", "test", "publish")
+
+PENDING = "Pending"
+RUNNING = "Running"
+COMPLETED = "Completed"
+FAILED = "Failed"
+SKIPPED = "Skipped"
+
+# Tools that change workspace files
+EDIT_TOOLS = {"Edit", "MultiEdit", "Write", "NotebookEdit"}
+
+# Shell commands that run a test suite
+TEST_COMMAND = re.compile(
+    r"(^|[\s;&|(])("
+    r"pytest|tox|nox|go test|cargo test|mvn (\S+ )*test|gradle(w)? test|\./gradlew test|"
+    r"(npm|yarn|pnpm|bun)( run)? test|jest|vitest|make (\S+ )*(test|check)|ctest|rspec|phpunit"
+    r")\b"
+)
+
+
+def step_for_tool_use(tool_name: str, tool_input) -> str | None:
+    """The step a tool use moves the run to, or None if it does not indicate one."""
+    if tool_name in EDIT_TOOLS:
+        return "edit"
+    if tool_name == "Bash" and isinstance(tool_input, dict):
+        command = str(tool_input.get("command") or "")
+        if TEST_COMMAND.search(command):
+            return "test"
+    return None
+
+
+class ProgressTracker:
+    """Tracks the state of each step. Steps only move forward: starting a step completes the
+    running steps before it and skips the ones that never started.
+    """
+
+    def __init__(self):
+        self._steps = {name: {"state": PENDING, "message": ""} for name in STEPS}
+
+    def start(self, step: str, message: str = "") -> bool:
+        """Mark step Running. Returns whether anything changed."""
+        if step not in self._steps or self._steps[step]["state"] != PENDING:
+            return False
+        for name in STEPS[:STEPS.index(step)]:
+            self._finish_step(name, COMPLETED, SKIPPED)
+        self._steps[step] = {"state": RUNNING, "message": message}
+        return True
+
+    def complete(self, step: str, message: str = "") -> bool:
+        """Mark step Completed, starting it first if needed. Returns whether anything changed."""
+        changed = self.start(step)
+        current = self._steps.get(step)
+        if current is None or current["state"] != RUNNING:
+            return changed
+        self._steps[step] = {"state": COMPLETED, "message": message or current["message"]}
+        return True
+
+    def skip(self, step: str, message: str = "") -> bool:
+        """Mark a step that never started Skipped. Returns whether anything changed."""
+        current = self._steps.get(step)
+        if current is None or current["state"] != PENDING:
+            return False
+        self._steps[step] = {"state": SKIPPED, "message": message}
+        return True
+
+    def finish(self, success: bool, message: str = "") -> bool:
+        """End the run: running steps complete (or fail) and pending steps are skipped."""
+        changed = False
+        for name in STEPS:
+            if self._steps[name]["state"] == RUNNING and not success:
+                self._steps[name] = {"state": FAILED, "message": message}
+                changed = True
+            else:
+                changed = self._finish_step(name, COMPLETED, SKIPPED) or changed
+        return changed
+
+    def _finish_step(self, name: str, running_to: str, pending_to: str) -> bool:
+        state = self._steps[name]["state"]
+        if state == RUNNING:
+            self._steps[name]["state"] = running_to
+            return True
+        if state == PENDING:
+            self._steps[name]["state"] = pending_to
+            return True
+        return False
+
+    def state(self, step: str) -> str:
+        return self._steps[step]["state"]
+
+    def reports(self) -> list[dict]:
+        """The step reports, as the operator reads them from the annotation."""
+        out = []
+        for name in STEPS:
+            report = {"name": name, "state": self._steps[name]["state"]}
+            if self._steps[name]["message"]:
+                report["message"] = self._steps[name]["message"]
+            out.append(report)
+        return out
+
+    def annotation_value(self) -> str:
+        return json.dumps(self.reports(), separators=(",", ":"))
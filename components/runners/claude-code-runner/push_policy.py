@@ -0,0 +1,88 @@
+"""
+Enforces ProjectSettings spec.branchProtection.forbidForcePush for every git push in the runner.
+
+The operator sets GIT_FORBID_FORCE_PUSH. When it is true, the runner points git at its own hooks
+directory (core.hooksPath, through GIT_CONFIG_COUNT so the agent's shell inherits it). The
+pre-push hook compares each ref update git is about to send and refuses any that is not a
+fast-forward, however it was asked for: --force, -f, --force-with-lease, a +refspec or --mirror,
+and any deletion.
+Every hook, pre-push included, then runs the repository's own hook of the same name, so repos
+keep their pre-commit and similar hooks.
+
+Hooks are skipped with --no-verify, so that flag is denied to the agent's shell as well. This
+guards against the agent's mistakes; the Git server's branch protection is the real guarantee.
+"""
+
+import os
+import stat
+from pathlib import Path
+from typing import MutableMapping
+
+# The client-side hooks git runs, all of which the hooks directory must pass on to the repo's
+CLIENT_HOOKS = [
+    "applypatch-msg", "pre-applypatch", "post-applypatch", "pre-commit", "pre-merge-commit",
+    "prepare-commit-msg", "commit-msg", "post-commit", "pre-rebase", "post-checkout",
+    "post-merge", "pre-push", "post-rewrite", "pre-auto-gc", "reference-transaction",
+    "sendemail-validate", "post-index-change",
+]
+
+# Bash prefixes denied to the agent: the force flags, and --no-verify which would skip the hook
+DISALLOWED_BASH = [
+    "Bash(git push --force:*)", "Bash(git push -f:*)", "Bash(git push --force-with-lease:*)",
+    "Bash(git push --no-verify:*)",
+]
+
+HOOK = """#!/bin/sh
+# Installed by the Ambient runner as core.hooksPath (see push_policy.py)
+hook=$(basename "$0")
+own=$(git config --local core.hooksPath 2>/dev/null) || own="$(git rev-parse --git-common-dir)/hooks"
+
+if [ "$hook" != pre-push ]; then
+    [ -x "$own/$hook" ] && exec "$own/$hook" "$@"
+    exit 0
+fi
+
+updates=$(cat)
+refused=0
+while read -r local_ref local_sha remote_ref remote_sha; do
+    [ -n "$remote_ref" ] || continue
+    # New refs replace no history; deleting a ref and pushing it again would
+    case "$remote_sha" in *[!0]*) ;; *) continue ;; esac
+    # A remote commit unknown here cannot be an ancestor: git only sends that when forced
+    if ! git merge-base --is-ancestor "$remote_sha" "$local_sha" 2>/dev/null; then
+        echo "Force push to $remote_ref refused: the project forbids force pushes (spec.branchProtection.forbidForcePush)" >&2
+        refused=1
+    fi
+done <<EOF
+$updates
+EOF
+[ "$refused" = 0 ] || exit 1
+
+if [ -x "$own/$hook" ]; then
+    printf '%s\\n' "$updates" | "$own/$hook" "$@"
+    exit $?
+fi
+exit 0
+"""
+
+
+def forbid_force_push_from_env() -> bool:
+    return os.getenv("GIT_FORBID_FORCE_PUSH", "false").strip().lower() == "true"
+
+
+def install(hooks_dir: Path, env: MutableMapping[str, str] = os.environ) -> None:
+    """Writes the hooks to hooks_dir and makes git in env (and its children) use them"""
+    hooks_dir.mkdir(parents=True, exist_ok=True)
+    for name in CLIENT_HOOKS:
+        path = hooks_dir / name
+        path.write_text(HOOK)
+        path.chmod(path.stat().st_mode | stat.S_IXUSR | stat.S_IXGRP | stat.S_IXOTH)
+
+    count = int(env.get("GIT_CONFIG_COUNT") or "0")
+    for i in range(count):
+        if env.get(f"GIT_CONFIG_KEY_{i}") == "core.hooksPath":
+            env[f"GIT_CONFIG_VALUE_{i}"] = str(hooks_dir)
+            return
+    env[f"GIT_CONFIG_KEY_{count}"] = "core.hooksPath"
+    env[f"GIT_CONFIG_VALUE_{count}"] = str(hooks_dir)
+    env["GIT_CONFIG_COUNT"] = str(count + 1)
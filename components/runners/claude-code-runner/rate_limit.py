@@ -0,0 +1,122 @@
+"""
+Tracks the model provider's rate limits and reports them in status.rateLimit.
+
+The operator holds new sessions on the same provider credential while a session reports
+limited=true (see components/operator/internal/handlers/ratelimit.go), so a burst of
+sessions waits for the limit to reset instead of every session retrying into 429s.
+
+Headroom comes from the Anthropic rate limit headers of the API calls the runner makes
+itself; the SDK does not expose the agent's own responses, so its 429s are recognised from
+the error text the CLI reports.
+"""
+
+import re
+from datetime import datetime, timedelta, timezone
+from typing import Dict, Optional
+
+# Error text of a rate limited request, as the CLI surfaces it
+_RATE_LIMIT_ERROR = re.compile(r"rate_limit_error|^\s*API Error: 429")
+
+
+def _iso(dt: datetime) -> str:
+    return dt.astimezone(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ")
+
+
+def _parse_time(value: str) -> Optional[datetime]:
+    try:
+        return datetime.fromisoformat(value.strip().replace("Z", "+00:00"))
+    except (AttributeError, ValueError):
+        return None
+
+
+def _parse_int(value) -> Optional[int]:
+    try:
+        return max(0, int(str(value).strip()))
+    except (TypeError, ValueError):
+        return None
+
+
+def is_rate_limit_error(text) -> bool:
+    return bool(text) and bool(_RATE_LIMIT_ERROR.search(str(text)))
+
+
+class RateLimitTracker:
+    """Keeps the last rate limit state; each observe_* call returns True when the state the
+    operator schedules on (limited or not) changed and should be reported."""
+
+    def __init__(self, now=lambda: datetime.now(timezone.utc)):
+        self._now = now
+        self.limited = False
+        self.throttled = 0
+        self.retry_after: Optional[datetime] = None
+        self.reset_at: Optional[datetime] = None
+        self.requests_remaining: Optional[int] = None
+        self.tokens_remaining: Optional[int] = None
+        self._observed = False
+
+    def observe_headers(self, headers: Dict[str, str]) -> bool:
+        """Record anthropic-ratelimit-* and retry-after response headers (lower-case keys)"""
+        headers = {k.lower(): v for k, v in (headers or {}).items()}
+        found = False
+        for field, name in (("requests_remaining", "anthropic-ratelimit-requests-remaining"),
+                            ("tokens_remaining", "anthropic-ratelimit-tokens-remaining")):
+            value = _parse_int(headers.get(name))
+            if value is not None:
+                setattr(self, field, value)
+                found = True
+        resets = [t for t in (_parse_time(headers.get("anthropic-ratelimit-requests-reset", "")),
+                              _parse_time(headers.get("anthropic-ratelimit-tokens-reset", ""))) if t]
+        if resets:
+            self.reset_at = max(resets)
+            found = True
+        retry = _parse_int(headers.get("retry-after"))
+        if retry is not None:
+            return self.observe_limited(retry)
+        if found:
+            self._observed = True
+        return False
+
+    def observe_limited(self, retry_after_seconds: Optional[int] = None) -> bool:
+        """Record a rate limited request"""
+        changed = not self.limited
+        self.limited = True
+        self.throttled += 1
+        self._observed = True
+        if retry_after_seconds is not None:
+            self.retry_after = self._now() + timedelta(seconds=retry_after_seconds)
+        elif self.reset_at and self.reset_at > self._now():
+            self.retry_after = self.reset_at
+        else:
+            self.retry_after = None
+        return changed
+
+    def observe_error(self, text) -> bool:
+        """Record an error message; only rate limit errors count"""
+        if not is_rate_limit_error(text):
+            return False
+        return self.observe_limited()
+
+    def recovered(self) -> bool:
+        """Record a successful model response after being limited"""
+        if not self.limited:
+            return False
+        self.limited = False
+        self.retry_after = None
+        return True
+
+    def status_fields(self) -> dict:
+        """The status.rateLimit field for a CR status update, empty before any observation"""
+        if not self._observed:
+            return {}
+        report = {"limited": self.limited, "observedAt": _iso(self._now())}
+        if self.throttled:
+            report["throttled"] = self.throttled
+        if self.limited and self.retry_after:
+            report["retryAfter"] = _iso(self.retry_after)
+        if self.reset_at:
+            report["resetAt"] = _iso(self.reset_at)
+        if self.requests_remaining is not None:
+            report["requestsRemaining"] = self.requests_remaining
+        if self.tokens_remaining is not None:
+            report["tokensRemaining"] = self.tokens_remaining
+        return {"rateLimit": report}
@@ -0,0 +1,51 @@
+"""
+Loads the agent personas the session names in AGENT_PERSONAS.
+
+The project's Agent resources (see components/backend/handlers/agents.go) are resolved by
+the backend into the runner config document:
+
+    GET $RUNNER_CONFIG_URL -> {"agents": [{"name", "description", "prompt", "tools", "model", ...}]}
+
+Each becomes a subagent the main agent can hand work to. Personas without an Agent resource
+are not in the document; they may still come from the workflow's .claude/agents.
+"""
+
+import json
+import logging
+from typing import Dict
+
+from artifact_upload import Transport, urllib_transport
+
+
+class SessionAgentError(Exception):
+    """The runner config could not be fetched"""
+
+
+def load_session_agents(
+    config_url: str,
+    token: str = "",
+    transport: Transport = urllib_transport,
+) -> Dict[str, dict]:
+    """Return subagent definitions by name: description, prompt and, when set, tools and model"""
+    headers = {"Authorization": f"Bearer {token}"} if token else {}
+    status, _, body = transport("GET", config_url, headers, None)
+    if status != 200:
+        raise SessionAgentError(f"fetching runner config failed: HTTP {status}: {body[:200]!r}")
+    agents = json.loads(body.decode("utf-8") or "{}").get("agents") or []
+
+    definitions: Dict[str, dict] = {}
+    for agent in agents:
+        name = str(agent.get("name") or "")
+        description = agent.get("description") or ""
+        prompt = agent.get("prompt") or ""
+        if not name or not description or not prompt:
+            logging.warning(f"Ignoring incomplete agent {name or '(unnamed)'!r} in runner config")
+            continue
+        definition = {"description": description, "prompt": prompt}
+        if agent.get("tools"):
+            definition["tools"] = list(agent["tools"])
+        # "inherit" is the SDK default as well
+        if agent.get("model") and agent["model"] != "inherit":
+            definition["model"] = agent["model"]
+        definitions[name] = definition
+    return definitions
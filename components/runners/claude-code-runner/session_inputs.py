@@ -0,0 +1,75 @@
+"""
+Downloads the files a user attached to the session into workspace/inputs.
+
+The backend keeps attached files in its input store (see
+components/backend/handlers/session_inputs.go) and serves them to the runner:
+
+    GET <base>/internal/inputs/<session>         -> {"inputs": [{"name", "size", "sha256"}]}
+    GET <base>/internal/inputs/<session>/<name>  -> file contents
+
+Files already present with the same checksum (a restarted runner, or a file the backend
+copied into the running workspace) are not downloaded again.
+"""
+
+import hashlib
+import json
+import logging
+import os
+from pathlib import Path
+from typing import List, Optional
+from urllib.parse import quote
+
+from artifact_upload import Transport, file_sha256, urllib_transport
+
+INPUTS_DIR = "inputs"
+
+
+class SessionInputError(Exception):
+    """The list of session inputs could not be fetched"""
+
+
+def inputs_url_from_env(session_name: str) -> Optional[str]:
+    """Derive the inputs endpoint from INTERNAL_API_URL, else BACKEND_API_URL (".../api")"""
+    base = (os.getenv("INTERNAL_API_URL") or os.getenv("BACKEND_API_URL") or "").strip().rstrip("/")
+    if not base or not session_name:
+        return None
+    if base.endswith("/api"):
+        base = base[: -len("/api")]
+    return f"{base}/internal/inputs/{session_name}"
+
+
+def download_inputs(
+    inputs_url: str,
+    dest: Path,
+    token: str = "",
+    transport: Transport = urllib_transport,
+) -> List[str]:
+    """Download every session input into dest; returns the names of the files written"""
+    headers = {"Authorization": f"Bearer {token}"} if token else {}
+    status, _, body = transport("GET", inputs_url.rstrip("/"), headers, None)
+    if status != 200:
+        raise SessionInputError(f"listing inputs failed: HTTP {status}: {body[:200]!r}")
+    inputs = json.loads(body.decode("utf-8") or "{}").get("inputs") or []
+
+    written = []
+    for item in inputs:
+        name = Path(str(item.get("name", ""))).name
+        if not name or name.startswith("."):
+            continue
+        target = dest / name
+        expected = str(item.get("sha256") or "")
+        if target.is_file() and expected and file_sha256(target) == expected:
+            continue
+        status, _, data = transport("GET", f"{inputs_url.rstrip('/')}/{quote(name)}", headers, None)
+        if status != 200:
+            logging.warning(f"Downloading input {name} failed: HTTP {status}")
+            continue
+        if expected and hashlib.sha256(data).hexdigest() != expected:
+            logging.warning(f"Input {name} does not match its checksum; skipped")
+            continue
+        dest.mkdir(parents=True, exist_ok=True)
+        tmp = target.with_name(f".{name}.part")
+        tmp.write_bytes(data)
+        tmp.replace(target)
+        written.append(name)
+    return written
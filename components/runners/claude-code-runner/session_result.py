@@ -0,0 +1,126 @@
+"""
+Typed session result written to AgenticSession status.result.
+
+Mirrors SessionResult in components/pkg/apis/vteam/v1alpha1/result.go; keep the two in sync.
+"""
+
+import json
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+
+OUTCOME_SUCCEEDED = "Succeeded"
+OUTCOME_PARTIAL = "Partial"
+OUTCOME_NO_CHANGES = "NoChanges"
+OUTCOME_FAILED = "Failed"
+OUTCOME_INTERRUPTED = "Interrupted"
+
+OUTCOMES = (OUTCOME_SUCCEEDED, OUTCOME_PARTIAL, OUTCOME_NO_CHANGES, OUTCOME_FAILED, OUTCOME_INTERRUPTED)
+
+MAX_SUMMARY_LENGTH = 10000
+
+# Written by the agent when its change builds a container image reviewers can run as a Preview
+PREVIEW_ARTIFACT = "artifacts/preview.json"
+
+# GitHub pull/N, GitLab -/merge_requests/N, Gitea pulls/N, Bitbucket pull-requests/N (on
+# Bitbucket Server under projects/KEY/repos/slug or users/name/repos/slug)
+_PR_URL_RE = re.compile(
+    r"https://[\w.-]+/(?:(?:projects|users)/[\w.~-]+/repos/[\w.-]+|[\w.-]+/[\w.-]+)"
+    r"/(?:pulls?/\d+|pull-requests/\d+|-/merge_requests/\d+)"
+)
+
+
+@dataclass
+class SessionResult:
+    outcome: str
+    summary: str = ""
+    pr_urls: list[str] = field(default_factory=list)
+    files_changed: int = 0
+    tests_run: int = 0
+    follow_ups: list[str] = field(default_factory=list)
+    preview: dict | None = None
+
+    def to_dict(self) -> dict:
+        """Render the JSON shape accepted by the backend (empty fields omitted)."""
+        if self.outcome not in OUTCOMES:
+            raise ValueError(f"invalid result outcome {self.outcome!r}")
+        out: dict = {"outcome": self.outcome}
+        if self.summary:
+            out["summary"] = self.summary[:MAX_SUMMARY_LENGTH]
+        if self.pr_urls:
+            out["prURLs"] = list(self.pr_urls)
+        if self.files_changed > 0:
+            out["filesChanged"] = self.files_changed
+        if self.tests_run > 0:
+            out["testsRun"] = self.tests_run
+        if self.follow_ups:
+            out["followUps"] = list(self.follow_ups)
+        if self.preview:
+            out["preview"] = dict(self.preview)
+        return out
+
+
+def extract_pr_urls(text: str) -> list[str]:
+    """Return the distinct pull/merge request URLs mentioned in text, in order."""
+    seen: list[str] = []
+    for url in _PR_URL_RE.findall(text or ""):
+        if url not in seen:
+            seen.append(url)
+    return seen
+
+
+def read_preview_artifact(workspace: Path) -> dict | None:
+    """Return the {"image", "port"} preview artifact the agent wrote, None when absent or invalid."""
+    path = workspace / PREVIEW_ARTIFACT
+    if not path.is_file():
+        return None
+    try:
+        data = json.loads(path.read_text())
+    except (OSError, ValueError) as e:
+        logging.warning(f"Ignoring unreadable {PREVIEW_ARTIFACT}: {e}")
+        return None
+    image = data.get("image") if isinstance(data, dict) else None
+    if not isinstance(image, str) or not image.strip():
+        logging.warning(f"Ignoring {PREVIEW_ARTIFACT} without an image")
+        return None
+    preview: dict = {"image": image.strip()}
+    port = data.get("port")
+    if isinstance(port, int) and not isinstance(port, bool) and 0 < port <= 65535:
+        preview["port"] = port
+    return preview
+
+
+def build_session_result(
+    sdk_result: dict | None,
+    *,
+    files_changed: int = 0,
+    pr_urls: list[str] | None = None,
+    cost_limit_reached: bool = False,
+    preview: dict | None = None,
+) -> SessionResult:
+    """Derive the typed result from the SDK ResultMessage payload and workspace facts.
+
+    A run that errored is Failed; one stopped by the turn or cost limit is Partial; a
+    successful run that neither changed files nor opened a PR is NoChanges.
+    """
+    payload = sdk_result or {}
+    text = payload.get("result") or ""
+    urls = list(pr_urls or [])
+    for url in extract_pr_urls(text):
+        if url not in urls:
+            urls.append(url)
+
+    subtype = (payload.get("subtype") or "").lower()
+    if subtype == "error_max_turns" or cost_limit_reached:
+        outcome = OUTCOME_PARTIAL
+    elif payload.get("is_error") or subtype.startswith("error"):
+        outcome = OUTCOME_FAILED
+    elif files_changed == 0 and not urls:
+        outcome = OUTCOME_NO_CHANGES
+    else:
+        outcome = OUTCOME_SUCCEEDED
+
+    return SessionResult(
+        outcome=outcome, summary=text, pr_urls=urls, files_changed=files_changed, preview=preview
+    )
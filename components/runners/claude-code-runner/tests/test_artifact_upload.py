@@ -0,0 +1,151 @@
+"""
+Test cases for resumable artifact uploads against an in-memory backend.
+"""
+
+from pathlib import Path
+import base64
+import hashlib
+import json
+import sys
+
+# Add parent directory to path for importing artifact_upload module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+import pytest
+
+from artifact_upload import (  # type: ignore[import]
+    ArtifactUploader,
+    ArtifactUploadError,
+    internal_ssl_context,
+    uploads_url_from_env,
+)
+
+URL = "http://backend/internal/artifacts/sess/uploads"
+
+
+class FakeBackend:
+    """Implements the backend side of the protocol; fail_patches drops the next N PATCHes"""
+
+    def __init__(self, fail_patches=0, accept_then_fail=False):
+        self.uploads = {}
+        self.stored = {}
+        self.fail_patches = fail_patches
+        self.accept_then_fail = accept_then_fail
+        self.calls = []
+
+    def __call__(self, method, url, headers, body):
+        self.calls.append(method)
+        if method == "POST":
+            req = json.loads(body)
+            up_id = hashlib.sha256(f"{req['path']}{req['size']}{req['sha256']}".encode()).hexdigest()[:32]
+            up = self.uploads.setdefault(up_id, {"meta": req, "data": b""})
+            if len(up["data"]) >= req["size"]:
+                return self._finish(up_id)
+            return 201, {}, json.dumps({"id": up_id, "offset": len(up["data"])}).encode()
+        up_id = url.rsplit("/", 1)[1]
+        up = self.uploads.get(up_id)
+        if up is None:
+            return 404, {}, b"not found"
+        if method == "HEAD":
+            return 200, {"upload-offset": str(len(up["data"]))}, b""
+        if int(headers["Upload-Offset"]) != len(up["data"]):
+            return 409, {"upload-offset": str(len(up["data"]))}, b""
+        algo, value = headers["Upload-Checksum"].split(" ", 1)
+        assert algo == "sha256" and base64.b64decode(value) == hashlib.sha256(body).digest()
+        if self.fail_patches:
+            self.fail_patches -= 1
+            if self.accept_then_fail:
+                # The bytes arrive but the response is lost
+                up["data"] += body
+            raise ConnectionResetError("connection reset by peer")
+        up["data"] += body
+        if len(up["data"]) == up["meta"]["size"]:
+            return self._finish(up_id)
+        return 204, {"upload-offset": str(len(up["data"]))}, b""
+
+    def _finish(self, up_id):
+        up = self.uploads.pop(up_id)
+        if hashlib.sha256(up["data"]).hexdigest() != up["meta"]["sha256"]:
+            return 422, {}, b"checksum mismatch"
+        self.stored[up["meta"]["path"]] = up["data"]
+        return 200, {}, json.dumps({"id": up_id}).encode()
+
+
+def _uploader(backend, **kwargs):
+    return ArtifactUploader(URL, token="tok", transport=backend, chunk_size=4, sleep=lambda _: None, **kwargs)
+
+
+class TestArtifactUploader:
+    """Test suite for ArtifactUploader"""
+
+    def test_uploads_in_chunks(self, tmp_path):
+        """A file is sent in chunk_size pieces and stored intact"""
+        f = tmp_path / "report.txt"
+        f.write_bytes(b"0123456789")
+        backend = FakeBackend()
+        result = _uploader(backend).upload_file(f, "report.txt")
+        assert backend.stored == {"report.txt": b"0123456789"}
+        assert result.size == 10
+        assert backend.calls.count("PATCH") == 3
+
+    def test_resumes_after_network_error(self, tmp_path):
+        """A dropped connection resumes from the backend's offset instead of restarting"""
+        f = tmp_path / "a.bin"
+        f.write_bytes(b"abcdefghij")
+        backend = FakeBackend(fail_patches=1, accept_then_fail=True)
+        _uploader(backend).upload_file(f, "a.bin")
+        assert backend.stored == {"a.bin": b"abcdefghij"}
+        # 4 bytes were accepted before the failure, so only two more chunks are needed
+        assert backend.calls.count("PATCH") == 3
+
+    def test_empty_file(self, tmp_path):
+        """Empty artifacts complete on create"""
+        f = tmp_path / "empty"
+        f.write_bytes(b"")
+        backend = FakeBackend()
+        _uploader(backend).upload_file(f, "empty")
+        assert backend.stored == {"empty": b""}
+        assert "PATCH" not in backend.calls
+
+    def test_gives_up_after_max_attempts(self, tmp_path):
+        """Persistent failures raise ArtifactUploadError"""
+        f = tmp_path / "a.bin"
+        f.write_bytes(b"abcdefgh")
+        backend = FakeBackend(fail_patches=100)
+        with pytest.raises(ArtifactUploadError):
+            _uploader(backend, max_attempts=3).upload_file(f, "a.bin")
+
+    def test_upload_dir_uses_relative_paths(self, tmp_path):
+        """Nested files keep their path relative to the artifacts directory"""
+        (tmp_path / "sub").mkdir()
+        (tmp_path / "sub" / "x.txt").write_bytes(b"x")
+        (tmp_path / "y.txt").write_bytes(b"yy")
+        backend = FakeBackend()
+        results = _uploader(backend).upload_dir(tmp_path)
+        assert [r.path for r in results] == ["sub/x.txt", "y.txt"]
+        assert backend.stored == {"sub/x.txt": b"x", "y.txt": b"yy"}
+
+
+def test_uploads_url_from_env(monkeypatch):
+    """The internal endpoint sits next to /api on the backend"""
+    monkeypatch.setenv("BACKEND_API_URL", "http://backend-service.ns.svc.cluster.local:8080/api/")
+    assert uploads_url_from_env("sess") == "http://backend-service.ns.svc.cluster.local:8080/internal/artifacts/sess/uploads"
+    monkeypatch.delenv("BACKEND_API_URL")
+    assert uploads_url_from_env("sess") is None
+
+
+def test_uploads_url_prefers_internal_listener(monkeypatch):
+    """With mTLS the operator points INTERNAL_API_URL at the backend's TLS listener"""
+    monkeypatch.setenv("BACKEND_API_URL", "http://backend-service.ns.svc.cluster.local:8080/api")
+    monkeypatch.setenv("INTERNAL_API_URL", "https://backend-service.ns.svc.cluster.local:8443")
+    assert uploads_url_from_env("sess") == "https://backend-service.ns.svc.cluster.local:8443/internal/artifacts/sess/uploads"
+
+
+def test_internal_ssl_context_requires_mounted_certificate(monkeypatch, tmp_path):
+    """No client certificate is presented unless the operator mounted one"""
+    monkeypatch.delenv("RUNNER_TLS_DIR", raising=False)
+    assert internal_ssl_context() is None
+    monkeypatch.setenv("RUNNER_TLS_DIR", str(tmp_path))
+    assert internal_ssl_context() is None
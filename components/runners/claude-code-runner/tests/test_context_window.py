@@ -0,0 +1,63 @@
+"""
+Test cases for context window management (spec.llmSettings.contextStrategy).
+"""
+
+from pathlib import Path
+import sys
+
+# Add parent directory to path for importing context_window module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from context_window import ContextWindow, RETRIEVAL_NOTES  # type: ignore[import]
+
+
+def message_start(input_tokens, cache_read=0):
+    return {"type": "message_start", "message": {"id": "msg_1", "usage": {
+        "input_tokens": input_tokens, "cache_read_input_tokens": cache_read, "output_tokens": 1,
+    }}}
+
+
+class TestContextWindow:
+    """Test suite for ContextWindow"""
+
+    def test_defaults_to_summarize_without_limit(self):
+        """Without maxContextTokens the runner leaves compaction to Claude Code"""
+        window = ContextWindow.from_env(lambda key: None)
+        window.on_event(message_start(500000))
+        assert window.strategy == "summarize"
+        assert window.command() is None
+
+    def test_summarize_compacts_at_the_limit(self):
+        """The prompt size of the last model call, cache reads included, is compared"""
+        env = {"LLM_CONTEXT_STRATEGY": "summarize", "LLM_MAX_CONTEXT_TOKENS": "50000"}
+        window = ContextWindow.from_env(env.get)
+        window.on_event(message_start(1000, cache_read=40000))
+        assert window.command() is None
+        window.on_event(message_start(2000, cache_read=48000))
+        assert window.context_tokens == 50000
+        assert window.command() == "/compact"
+        assert window.command() is None
+
+    def test_truncate_clears(self):
+        window = ContextWindow("truncate", 20000)
+        window.on_event(message_start(25000))
+        assert window.command() == "/clear"
+        assert window.prepare_prompt("next", "artifacts") == "next"
+
+    def test_retrieval_points_to_notes_after_clearing(self):
+        """Retrieval instructs the agent to keep notes and re-read them once cleared"""
+        window = ContextWindow("retrieval", 20000)
+        assert RETRIEVAL_NOTES in window.system_prompt("artifacts")
+        window.on_event(message_start(25000))
+        assert window.command() == "/clear"
+        prompt = window.prepare_prompt("next", "artifacts")
+        assert prompt.endswith("next") and f"artifacts/{RETRIEVAL_NOTES}" in prompt
+        assert window.prepare_prompt("again", "artifacts") == "again"
+
+    def test_malformed_settings_are_ignored(self):
+        env = {"LLM_CONTEXT_STRATEGY": "sliding", "LLM_MAX_CONTEXT_TOKENS": "lots"}
+        window = ContextWindow.from_env(env.get)
+        assert window.strategy == "summarize" and window.max_tokens == 0
+        assert window.system_prompt("artifacts") == ""
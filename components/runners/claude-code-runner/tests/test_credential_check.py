@@ -0,0 +1,118 @@
+"""
+Test cases for the credential pre-flight checks against fake API responses.
+"""
+
+from pathlib import Path
+import json
+import sys
+
+# Add parent directory to path for importing credential_check module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+import pytest
+
+from credential_check import (  # type: ignore[import]
+    REASON_ANTHROPIC_INVALID,
+    REASON_GIT_SCOPES,
+    REASON_GIT_TOKEN_INVALID,
+    CredentialCheckError,
+    GitTarget,
+    check_anthropic,
+    check_github_token,
+    enabled,
+    git_targets,
+)
+
+
+class FakeAPI:
+    """Answers every request with one response, or raises error; records the requests"""
+
+    def __init__(self, status=200, headers=None, body=b"{}", error=None):
+        self.status = status
+        self.headers = headers or {}
+        self.body = body
+        self.error = error
+        self.requests = []
+
+    def __call__(self, method, url, headers, body):
+        self.requests.append((method, url, headers))
+        if self.error:
+            raise self.error
+        return self.status, self.headers, self.body
+
+
+def test_anthropic_accepts_valid_key():
+    api = FakeAPI()
+    check_anthropic("sk-test", transport=api)
+    method, url, headers = api.requests[0]
+    assert method == "GET"
+    assert url == "https://api.anthropic.com/v1/models?limit=1"
+    assert headers["x-api-key"] == "sk-test"
+
+
+def test_anthropic_uses_base_url():
+    api = FakeAPI()
+    check_anthropic("sk-test", "https://gateway.internal/", transport=api)
+    assert api.requests[0][1] == "https://gateway.internal/v1/models?limit=1"
+
+
+def test_anthropic_rejected_key_fails():
+    body = json.dumps({"type": "error", "error": {"type": "authentication_error", "message": "invalid x-api-key"}}).encode()
+    with pytest.raises(CredentialCheckError) as exc:
+        check_anthropic("sk-bad", transport=FakeAPI(status=401, body=body))
+    assert exc.value.reason == REASON_ANTHROPIC_INVALID
+    assert "invalid x-api-key" in exc.value.message
+
+
+def test_anthropic_outage_is_inconclusive():
+    check_anthropic("sk-test", transport=FakeAPI(status=529))
+    check_anthropic("sk-test", transport=FakeAPI(error=OSError("connection refused")))
+
+
+def test_git_targets():
+    repos = [
+        {"input": {"url": "https://github.com/org/a"}, "output": {"url": "https://github.com/me/a"}},
+        {"input": {"url": "https://gitlab.com/g/b"}, "output": None},
+    ]
+    assert git_targets(repos, auto_push=True) == [GitTarget(host="github.com", push=True)]
+    assert git_targets(repos, auto_push=False) == [GitTarget(host="github.com", push=False)]
+    assert git_targets([{"input": {"url": "https://github.com/org/a"}}], auto_push=True) == [GitTarget(host="github.com", push=False)]
+    assert git_targets([{"input": {"url": "https://gitlab.com/g/b"}}], auto_push=True) == []
+
+
+def test_github_rejected_token_fails():
+    with pytest.raises(CredentialCheckError) as exc:
+        check_github_token("bad", GitTarget("github.com", False), transport=FakeAPI(status=401, body=b'{"message": "Bad credentials"}'))
+    assert exc.value.reason == REASON_GIT_TOKEN_INVALID
+    assert "Bad credentials" in exc.value.message
+
+
+def test_github_push_needs_repo_scope():
+    api = FakeAPI(headers={"x-oauth-scopes": "read:org, gist"})
+    with pytest.raises(CredentialCheckError) as exc:
+        check_github_token("tok", GitTarget("github.com", True), transport=api)
+    assert exc.value.reason == REASON_GIT_SCOPES
+    assert "read:org" in exc.value.message
+    # Read-only sessions do not need push scopes
+    check_github_token("tok", GitTarget("github.com", False), transport=api)
+
+
+def test_github_scopes_accepted():
+    check_github_token("tok", GitTarget("github.com", True), transport=FakeAPI(headers={"x-oauth-scopes": "repo, workflow"}))
+    check_github_token("tok", GitTarget("github.com", True), transport=FakeAPI(headers={"x-oauth-scopes": "public_repo"}))
+    # Fine-grained and App tokens report no scopes
+    check_github_token("tok", GitTarget("github.com", True), transport=FakeAPI())
+
+
+def test_github_outage_is_inconclusive():
+    check_github_token("tok", GitTarget("github.com", True), transport=FakeAPI(status=503))
+    check_github_token("tok", GitTarget("github.com", True), transport=FakeAPI(error=OSError("timed out")))
+
+
+def test_enabled(monkeypatch):
+    monkeypatch.delenv("CREDENTIAL_PREFLIGHT", raising=False)
+    assert enabled()
+    monkeypatch.setenv("CREDENTIAL_PREFLIGHT", "false")
+    assert not enabled()
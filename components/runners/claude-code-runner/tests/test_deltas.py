@@ -0,0 +1,68 @@
+"""
+Test cases for turning SDK stream events into message.delta payloads.
+"""
+
+from pathlib import Path
+import sys
+
+# Add parent directory to path for importing deltas module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from deltas import DeltaTracker  # type: ignore[import]
+
+
+def feed(tracker, events):
+    return [d for d in (tracker.on_event(e) for e in events) if d]
+
+
+class TestDeltaTracker:
+    """Test suite for DeltaTracker"""
+
+    def test_text_block_fragments_are_numbered(self):
+        """Text deltas of a block share an id, count up from 0 and end with done"""
+        tracker = DeltaTracker()
+        deltas = feed(tracker, [
+            {"type": "message_start", "message": {"id": "msg_1"}},
+            {"type": "content_block_start", "index": 0, "content_block": {"type": "text", "text": ""}},
+            {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "Hel"}},
+            {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "lo"}},
+            {"type": "content_block_stop", "index": 0},
+            {"type": "message_stop"},
+        ])
+        assert deltas == [
+            {"id": "msg_1:0", "index": 0, "text": "Hel"},
+            {"id": "msg_1:0", "index": 1, "text": "lo"},
+            {"id": "msg_1:0", "index": 2, "done": True},
+        ]
+
+    def test_only_text_is_forwarded(self):
+        """Thinking and tool input deltas are dropped, as are unknown events"""
+        tracker = DeltaTracker()
+        deltas = feed(tracker, [
+            {"type": "message_start", "message": {"id": "msg_2"}},
+            {"type": "content_block_start", "index": 0, "content_block": {"type": "thinking"}},
+            {"type": "content_block_delta", "index": 0, "delta": {"type": "thinking_delta", "thinking": "hmm"}},
+            {"type": "content_block_stop", "index": 0},
+            {"type": "content_block_start", "index": 1, "content_block": {"type": "tool_use", "name": "Bash"}},
+            {"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": "{"}},
+            {"type": "content_block_stop", "index": 1},
+            {"type": "ping"},
+            None,
+        ])
+        assert deltas == []
+
+    def test_new_message_resets_blocks(self):
+        """Block indexes restart with every message, so ids include the message id"""
+        tracker = DeltaTracker()
+        events = []
+        for message_id in ("msg_a", "msg_b"):
+            events += [
+                {"type": "message_start", "message": {"id": message_id}},
+                {"type": "content_block_start", "index": 0, "content_block": {"type": "text"}},
+                {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "x"}},
+            ]
+        deltas = feed(tracker, events)
+        assert [d["id"] for d in deltas] == ["msg_a:0", "msg_b:0"]
+        assert [d["index"] for d in deltas] == [0, 0]
@@ -0,0 +1,45 @@
+"""
+Test cases for cloning with the project's git mirror cache.
+"""
+
+from pathlib import Path
+import sys
+
+# Add parent directory to path for importing git_mirror module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from git_mirror import git_mirror_key, reference_args  # type: ignore[import]
+
+
+def test_git_mirror_key():
+    """URL forms of the same repo share one mirror (same cases as TestGitMirrorKey in the operator)"""
+    cases = {
+        "https://github.com/Org/Repo": "github.com/org/repo.git",
+        "https://github.com/org/repo.git": "github.com/org/repo.git",
+        "https://x-access-token:t@github.com/o/r/": "github.com/o/r.git",
+        "git@github.com:org/repo.git": "github.com/org/repo.git",
+        "ssh://git@gitlab.example.com:22/a/b/c": "gitlab.example.com:22/a/b/c.git",
+        "https://github.com": "",
+        "https://github.com/org/../etc": "",
+        "": "",
+    }
+    for url, expected in cases.items():
+        assert git_mirror_key(url) == expected, url
+
+
+def test_reference_args_uses_existing_mirror(tmp_path):
+    """Only mirrors that have been cloned are referenced"""
+    url = "https://github.com/org/mono"
+    assert reference_args(url, str(tmp_path)) == []
+
+    mirror = tmp_path / "github.com" / "org" / "mono.git"
+    (mirror / "objects").mkdir(parents=True)
+    assert reference_args(url, str(tmp_path)) == ["--reference-if-able", str(mirror), "--dissociate"]
+
+
+def test_reference_args_without_mirror_dir(monkeypatch):
+    """No mirror volume mounted: plain clone"""
+    monkeypatch.delenv("GIT_MIRROR_DIR", raising=False)
+    assert reference_args("https://github.com/org/mono") == []
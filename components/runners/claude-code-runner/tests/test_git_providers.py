@@ -0,0 +1,86 @@
+"""
+Test cases for Gitea and Bitbucket repository URLs, tokens and pull requests.
+"""
+
+from pathlib import Path
+import sys
+
+# Add parent directory to path for importing git_providers module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from git_providers import (  # type: ignore[import]
+    BITBUCKET_SERVER,
+    GITEA,
+    GITHUB,
+    authorization_header,
+    parse,
+    provider_token,
+    pull_request,
+    pull_request_url,
+    url_userinfo,
+)
+
+
+class TestParse:
+    def test_bitbucket_server_paths(self):
+        for url in (
+            "https://git.example.com/scm/PROJ/repo.git",
+            "https://git.example.com/projects/PROJ/repos/repo/browse",
+        ):
+            repo = parse(url)
+            assert repo is not None
+            assert (repo.provider, repo.owner, repo.name) == (BITBUCKET_SERVER, "PROJ", "repo")
+
+    def test_personal_bitbucket_server_repo(self):
+        repo = parse("https://bitbucket.example.com/users/jdoe/repos/dotfiles")
+        assert repo is not None
+        assert (repo.provider, repo.owner, repo.name) == (BITBUCKET_SERVER, "~jdoe", "dotfiles")
+
+    def test_gitea_and_github(self):
+        repo = parse("https://gitea.example.com:3000/org/repo.git")
+        assert repo is not None
+        assert (repo.provider, repo.host, repo.owner, repo.name) == (GITEA, "gitea.example.com:3000", "org", "repo")
+        assert parse("https://github.com/org/repo").provider == GITHUB
+        assert parse("git@github.com:org/repo.git") is None
+
+
+class TestTokens:
+    def test_provider_token_from_env(self, monkeypatch):
+        monkeypatch.setenv("GITEA_TOKEN", " gt \n")
+        monkeypatch.setenv("GITHUB_TOKEN", "ghp")
+        assert provider_token("https://gitea.example.com/org/repo") == "gt"
+        # GitHub keeps its App token handling
+        assert provider_token("https://github.com/org/repo") == ""
+
+    def test_clone_credentials(self):
+        assert url_userinfo(GITEA, "abc") == "oauth2:abc"
+        assert url_userinfo(BITBUCKET_SERVER, "abc") == "x-token-auth:abc"
+        assert url_userinfo(BITBUCKET_SERVER, "jdoe:p/w") == "jdoe:p%2Fw"
+
+    def test_api_authorization(self):
+        assert authorization_header(GITEA, "abc") == "token abc"
+        assert authorization_header(BITBUCKET_SERVER, "abc") == "Bearer abc"
+        assert authorization_header(BITBUCKET_SERVER, "jdoe:pw") == "Basic amRvZTpwdw=="
+
+
+class TestPullRequest:
+    def test_gitea_cross_fork(self):
+        url, body = pull_request(
+            parse("https://gitea.example.com/org/repo"), parse("https://gitea.example.com/me/repo"),
+            "feature", "main", "Title", "Body",
+        )
+        assert url == "https://gitea.example.com/api/v1/repos/org/repo/pulls"
+        assert body["head"] == "me:feature"
+        assert body["base"] == "main"
+        assert pull_request_url(GITEA, {"html_url": "https://gitea.example.com/org/repo/pulls/5"}).endswith("/pulls/5")
+
+    def test_bitbucket_server(self):
+        repo = parse("https://git.example.com/scm/PROJ/repo.git")
+        url, body = pull_request(repo, repo, "feature", "main", "Title", "Body")
+        assert url == "https://git.example.com/rest/api/1.0/projects/PROJ/repos/repo/pull-requests"
+        assert body["fromRef"]["id"] == "refs/heads/feature"
+        assert body["toRef"]["repository"] == {"slug": "repo", "project": {"key": "PROJ"}}
+        created = {"links": {"self": [{"href": "https://git.example.com/projects/PROJ/repos/repo/pull-requests/12"}]}}
+        assert pull_request_url(BITBUCKET_SERVER, created).endswith("/pull-requests/12")
@@ -0,0 +1,53 @@
+"""
+Test cases for the mounted GitHub App token and its git credential helper.
+"""
+
+from pathlib import Path
+import subprocess
+import sys
+
+# Add parent directory to path for importing github_token module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from github_token import (  # type: ignore[import]
+    credential_helper,
+    read_token_file,
+    uses_token_file,
+)
+
+
+class TestTokenFile:
+    def test_reads_current_token(self, tmp_path, monkeypatch):
+        token = tmp_path / "token"
+        token.write_text("ghs_first\n")
+        monkeypatch.setenv("GITHUB_TOKEN_FILE", str(token))
+        assert read_token_file() == "ghs_first"
+        # The kubelet replaces the file when the backend refreshes the Secret
+        token.write_text("ghs_second")
+        assert read_token_file() == "ghs_second"
+
+    def test_missing_file(self, tmp_path, monkeypatch):
+        monkeypatch.setenv("GITHUB_TOKEN_FILE", str(tmp_path / "absent"))
+        assert read_token_file() == ""
+        assert not uses_token_file("https://github.com/org/repo.git")
+
+    def test_only_github_com_uses_token_file(self, tmp_path, monkeypatch):
+        token = tmp_path / "token"
+        token.write_text("ghs_x")
+        monkeypatch.setenv("GITHUB_TOKEN_FILE", str(token))
+        assert uses_token_file("https://github.com/org/repo.git")
+        assert not uses_token_file("https://gitlab.com/org/repo.git")
+        assert not uses_token_file("git@github.com:org/repo.git")
+
+
+def test_credential_helper_prints_current_token(tmp_path):
+    token = tmp_path / "my token"
+    token.write_text("ghs_live")
+    helper = credential_helper(str(token))
+    assert helper.startswith("!")
+    out = subprocess.run(["sh", "-c", helper[1:] + " get"], capture_output=True, text=True, check=True).stdout
+    assert out.splitlines() == ["username=x-access-token", "password=ghs_live"]
+    out = subprocess.run(["sh", "-c", helper[1:] + " store"], capture_output=True, text=True, check=True).stdout
+    assert out == ""
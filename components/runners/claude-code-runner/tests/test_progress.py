@@ -0,0 +1,74 @@
+"""
+Test cases for the step progress reported to the operator.
+"""
+
+import json
+from pathlib import Path
+import sys
+
+# Add parent directory to path for importing progress module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from progress import ProgressTracker, step_for_tool_use  # type: ignore[import]
+
+
+class TestProgressTracker:
+    """Test suite for ProgressTracker"""
+
+    def test_starting_a_step_finishes_earlier_steps(self):
+        """Running steps before it complete and steps that never started are skipped"""
+        tracker = ProgressTracker()
+        tracker.start("clone")
+        tracker.start("analyze")
+        assert tracker.state("clone") == "Completed"
+        assert tracker.start("test") is True
+        assert tracker.state("analyze") == "Completed"
+        assert tracker.state("edit") == "Skipped"
+        assert tracker.state("test") == "Running"
+
+    def test_steps_only_move_forward(self):
+        """A step that already ran is not restarted"""
+        tracker = ProgressTracker()
+        tracker.start("edit")
+        tracker.start("test")
+        assert tracker.start("edit") is False
+        assert tracker.state("edit") == "Completed"
+
+    def test_finish(self):
+        """Success completes the running step; failure marks it Failed with the message"""
+        ok = ProgressTracker()
+        ok.start("edit")
+        ok.finish(True)
+        assert [r["state"] for r in ok.reports()] == ["Skipped", "Skipped", "Completed", "Skipped", "Skipped"]
+
+        failed = ProgressTracker()
+        failed.start("analyze")
+        failed.finish(False, "SDK crashed")
+        assert failed.reports()[1] == {"name": "analyze", "state": "Failed", "message": "SDK crashed"}
+        assert failed.state("publish") == "Skipped"
+
+    def test_annotation_value_lists_every_step(self):
+        """The annotation holds one report per step, in order"""
+        tracker = ProgressTracker()
+        tracker.skip("publish", "auto-push disabled")
+        reports = json.loads(tracker.annotation_value())
+        assert [r["name"] for r in reports] == ["clone", "analyze", "edit", "test", "publish"]
+        assert reports[4] == {"name": "publish", "state": "Skipped", "message": "auto-push disabled"}
+
+
+class TestStepForToolUse:
+    """Test suite for step_for_tool_use"""
+
+    def test_edit_tools(self):
+        assert step_for_tool_use("Edit", {"file_path": "a.py"}) == "edit"
+        assert step_for_tool_use("Write", {}) == "edit"
+        assert step_for_tool_use("Read", {"file_path": "a.py"}) is None
+
+    def test_test_commands(self):
+        assert step_for_tool_use("Bash", {"command": "cd app && pytest -q"}) == "test"
+        assert step_for_tool_use("Bash", {"command": "go test ./..."}) == "test"
+        assert step_for_tool_use("Bash", {"command": "npm run test"}) == "test"
+        assert step_for_tool_use("Bash", {"command": "git status"}) is None
+        assert step_for_tool_use("Bash", {"command": "cat latest_tests.txt"}) is None
@@ -0,0 +1,77 @@
+"""
+Test cases for the pre-push hook enforcing spec.branchProtection.forbidForcePush.
+"""
+
+import os
+from pathlib import Path
+import subprocess
+import sys
+
+# Add parent directory to path for importing push_policy module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+import push_policy  # type: ignore[import]
+
+
+def _git(env, cwd, *args):
+    return subprocess.run(["git", *args], cwd=cwd, env=env, capture_output=True, text=True)
+
+
+def _repo(tmp_path):
+    env = {k: v for k, v in os.environ.items() if not k.startswith("GIT_")}
+    env.update({"HOME": str(tmp_path), "GIT_AUTHOR_NAME": "t", "GIT_AUTHOR_EMAIL": "t@example.com",
+                "GIT_COMMITTER_NAME": "t", "GIT_COMMITTER_EMAIL": "t@example.com"})
+    remote = tmp_path / "remote.git"
+    work = tmp_path / "work"
+    _git(env, tmp_path, "init", "--bare", "-b", "main", str(remote))
+    _git(env, tmp_path, "clone", str(remote), str(work))
+    _git(env, work, "checkout", "-b", "main")
+    _git(env, work, "commit", "--allow-empty", "-m", "one")
+    assert _git(env, work, "push", "origin", "main").returncode == 0
+    push_policy.install(tmp_path / "hooks", env)
+    return env, work
+
+
+def test_force_pushes_refused_whatever_the_syntax(tmp_path):
+    """--force anywhere, +refspecs and deletions are refused; fast-forwards and new branches pass"""
+    env, work = _repo(tmp_path)
+    _git(env, work, "commit", "--allow-empty", "-m", "two")
+    assert _git(env, work, "push", "origin", "main").returncode == 0
+
+    _git(env, work, "reset", "--hard", "HEAD~1")
+    _git(env, work, "commit", "--allow-empty", "-m", "rewritten")
+    for args in (["origin", "+main"], ["origin", "main", "--force"], ["--force-with-lease", "origin", "main"],
+                 ["origin", "+HEAD:refs/heads/main"], ["origin", ":main"]):
+        result = _git(env, work, "push", *args)
+        assert result.returncode != 0, args
+        assert "forbids force pushes" in result.stderr, args
+
+    assert _git(env, work, "push", "origin", "HEAD:refs/heads/feature").returncode == 0
+
+
+def test_repo_hooks_still_run(tmp_path):
+    """The repository's own hooks keep running behind the runner's"""
+    env, work = _repo(tmp_path)
+    hook = work / ".git" / "hooks" / "pre-commit"
+    hook.write_text("#!/bin/sh\necho no >&2\nexit 1\n")
+    hook.chmod(0o755)
+    assert _git(env, work, "commit", "--allow-empty", "-m", "blocked").returncode != 0
+
+    hook.unlink()
+    push_hook = work / ".git" / "hooks" / "pre-push"
+    push_hook.write_text("#!/bin/sh\ngrep -q refs/heads/main && exit 1\nexit 0\n")
+    push_hook.chmod(0o755)
+    _git(env, work, "commit", "--allow-empty", "-m", "two")
+    assert _git(env, work, "push", "origin", "main").returncode != 0
+
+
+def test_install_reuses_config_slot(tmp_path):
+    """Installing again keeps one core.hooksPath entry beside the existing config"""
+    env = {"GIT_CONFIG_COUNT": "1", "GIT_CONFIG_KEY_0": "user.name", "GIT_CONFIG_VALUE_0": "x"}
+    push_policy.install(tmp_path, env)
+    push_policy.install(tmp_path, env)
+    assert env["GIT_CONFIG_COUNT"] == "2"
+    assert env["GIT_CONFIG_KEY_1"] == "core.hooksPath"
+    assert env["GIT_CONFIG_VALUE_1"] == str(tmp_path)
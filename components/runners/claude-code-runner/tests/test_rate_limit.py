@@ -0,0 +1,73 @@
+"""
+Test cases for tracking the model provider's rate limits.
+"""
+
+from datetime import datetime, timezone
+from pathlib import Path
+import sys
+
+# Add parent directory to path for importing rate_limit module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from rate_limit import RateLimitTracker, is_rate_limit_error  # type: ignore[import]
+
+NOW = datetime(2026, 1, 1, 12, 0, 0, tzinfo=timezone.utc)
+
+
+def tracker():
+    return RateLimitTracker(now=lambda: NOW)
+
+
+def test_nothing_is_reported_before_an_observation():
+    assert tracker().status_fields() == {}
+
+
+def test_headers_record_headroom():
+    t = tracker()
+    changed = t.observe_headers({
+        "Anthropic-RateLimit-Requests-Remaining": "49",
+        "anthropic-ratelimit-tokens-remaining": "79000",
+        "anthropic-ratelimit-requests-reset": "2026-01-01T12:00:20Z",
+        "anthropic-ratelimit-tokens-reset": "2026-01-01T12:00:40Z",
+    })
+    assert not changed
+    report = t.status_fields()["rateLimit"]
+    assert report == {
+        "limited": False,
+        "observedAt": "2026-01-01T12:00:00Z",
+        "resetAt": "2026-01-01T12:00:40Z",
+        "requestsRemaining": 49,
+        "tokensRemaining": 79000,
+    }
+
+
+def test_retry_after_header_marks_limited():
+    t = tracker()
+    assert t.observe_headers({"retry-after": "30"})
+    report = t.status_fields()["rateLimit"]
+    assert report["limited"] is True
+    assert report["retryAfter"] == "2026-01-01T12:00:30Z"
+    assert report["throttled"] == 1
+
+
+def test_errors_hold_until_the_window_resets_and_recover():
+    t = tracker()
+    t.observe_headers({"anthropic-ratelimit-tokens-reset": "2026-01-01T12:01:00Z"})
+    assert t.observe_error('API Error: 429 {"type":"error","error":{"type":"rate_limit_error"}}')
+    assert not t.observe_error("rate_limit_error again"), "already limited is not a change"
+    assert t.status_fields()["rateLimit"]["retryAfter"] == "2026-01-01T12:01:00Z"
+    assert t.throttled == 2
+
+    assert t.recovered()
+    assert not t.recovered()
+    report = t.status_fields()["rateLimit"]
+    assert report["limited"] is False and "retryAfter" not in report
+
+
+def test_only_rate_limit_errors_count():
+    assert not is_rate_limit_error("API Error: 500 internal error")
+    assert not is_rate_limit_error("The API returns 429 when you are rate limited")
+    assert not is_rate_limit_error(None)
+    assert not tracker().observe_error("Tool failed")
@@ -0,0 +1,58 @@
+"""
+Test cases for loading the session's agent personas from the runner config.
+"""
+
+from pathlib import Path
+import json
+import sys
+
+# Add parent directory to path for importing session_agents module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+import pytest
+
+from session_agents import SessionAgentError, load_session_agents  # type: ignore[import]
+
+URL = "http://backend/internal/runner-config/sess"
+
+
+def serve(document, status=200):
+    """Transport answering the runner config request with document"""
+
+    def transport(method, url, headers, body):
+        assert method == "GET" and url == URL
+        assert headers.get("Authorization") == "Bearer tok"
+        return status, {}, json.dumps(document).encode()
+
+    return transport
+
+
+def test_agents_become_subagent_definitions():
+    """Each complete agent maps to description, prompt and optional tools and model"""
+    document = {
+        "version": "v1",
+        "agents": [
+            {"name": "stella", "displayName": "Stella", "description": "Code review", "prompt": "You review code.",
+             "tools": ["Read", "Grep"], "model": "opus"},
+            {"name": "parker", "description": "Product questions", "prompt": "You are a PM.", "model": "inherit"},
+            {"name": "broken", "description": "No prompt"},
+        ],
+    }
+    agents = load_session_agents(URL, "tok", transport=serve(document))
+    assert agents == {
+        "stella": {"description": "Code review", "prompt": "You review code.", "tools": ["Read", "Grep"], "model": "opus"},
+        "parker": {"description": "Product questions", "prompt": "You are a PM."},
+    }
+
+
+def test_no_agents():
+    """A config without agents yields none"""
+    assert load_session_agents(URL, "tok", transport=serve({"version": "v1"})) == {}
+
+
+def test_fetch_failure_raises():
+    """HTTP errors surface as SessionAgentError"""
+    with pytest.raises(SessionAgentError):
+        load_session_agents(URL, "tok", transport=serve({"error": "forbidden"}, status=403))
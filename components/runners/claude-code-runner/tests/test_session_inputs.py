@@ -0,0 +1,85 @@
+"""
+Test cases for downloading session input files from an in-memory backend.
+"""
+
+from pathlib import Path
+import hashlib
+import json
+import sys
+
+# Add parent directory to path for importing session_inputs module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+import pytest
+
+from session_inputs import (  # type: ignore[import]
+    SessionInputError,
+    download_inputs,
+    inputs_url_from_env,
+)
+
+URL = "http://backend/internal/inputs/sess"
+
+
+class FakeBackend:
+    """Serves the inputs list and files; corrupt names a file served with the wrong bytes"""
+
+    def __init__(self, files, corrupt=None, list_status=200):
+        self.files = files
+        self.corrupt = corrupt
+        self.list_status = list_status
+        self.requests = []
+
+    def __call__(self, method, url, headers, body):
+        assert method == "GET"
+        assert headers.get("Authorization") == "Bearer tok"
+        self.requests.append(url)
+        if url == URL:
+            if self.list_status != 200:
+                return self.list_status, {}, b"nope"
+            inputs = [
+                {"name": name, "size": len(data), "sha256": hashlib.sha256(data).hexdigest()}
+                for name, data in self.files.items()
+            ]
+            return 200, {}, json.dumps({"inputs": inputs}).encode()
+        name = url.rsplit("/", 1)[1]
+        if name not in self.files:
+            return 404, {}, b""
+        data = self.files[name]
+        if name == self.corrupt:
+            data = data + b"x"
+        return 200, {}, data
+
+
+class TestDownloadInputs:
+    def test_downloads_every_input(self, tmp_path):
+        backend = FakeBackend({"design.md": b"# Design", "data.csv": b"a,b\n1,2\n"})
+        written = download_inputs(URL, tmp_path / "inputs", token="tok", transport=backend)
+        assert sorted(written) == ["data.csv", "design.md"]
+        assert (tmp_path / "inputs" / "data.csv").read_bytes() == b"a,b\n1,2\n"
+
+    def test_skips_files_already_present(self, tmp_path):
+        backend = FakeBackend({"design.md": b"# Design"})
+        download_inputs(URL, tmp_path, token="tok", transport=backend)
+        backend.requests.clear()
+        assert download_inputs(URL, tmp_path, token="tok", transport=backend) == []
+        assert backend.requests == [URL]
+
+    def test_rejects_checksum_mismatch(self, tmp_path):
+        backend = FakeBackend({"design.md": b"# Design"}, corrupt="design.md")
+        assert download_inputs(URL, tmp_path, token="tok", transport=backend) == []
+        assert not (tmp_path / "design.md").exists()
+
+    def test_list_failure_raises(self, tmp_path):
+        backend = FakeBackend({}, list_status=403)
+        with pytest.raises(SessionInputError):
+            download_inputs(URL, tmp_path, token="tok", transport=backend)
+
+
+def test_inputs_url_from_env(monkeypatch):
+    monkeypatch.delenv("INTERNAL_API_URL", raising=False)
+    monkeypatch.setenv("BACKEND_API_URL", "http://backend-service.ns.svc.cluster.local:8080/api/")
+    assert inputs_url_from_env("sess") == "http://backend-service.ns.svc.cluster.local:8080/internal/inputs/sess"
+    assert inputs_url_from_env("") is None
@@ -0,0 +1,107 @@
+"""
+Test cases for the typed session result written to status.result.
+"""
+
+from pathlib import Path
+import sys
+
+# Add parent directory to path for importing session_result module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+import pytest
+
+from session_result import (  # type: ignore[import]
+    OUTCOME_FAILED,
+    OUTCOME_NO_CHANGES,
+    OUTCOME_PARTIAL,
+    OUTCOME_SUCCEEDED,
+    SessionResult,
+    build_session_result,
+    extract_pr_urls,
+    read_preview_artifact,
+)
+
+
+class TestBuildSessionResult:
+    """Test suite for build_session_result"""
+
+    def test_success_with_changes(self):
+        """A successful run that changed files is Succeeded and keeps the summary"""
+        result = build_session_result({"subtype": "success", "result": "Done"}, files_changed=2)
+        assert result.outcome == OUTCOME_SUCCEEDED
+        assert result.to_dict() == {"outcome": "Succeeded", "summary": "Done", "filesChanged": 2}
+
+    def test_success_without_changes(self):
+        """A successful run with no changes and no PRs is NoChanges"""
+        result = build_session_result({"subtype": "success", "result": "Nothing to do"})
+        assert result.outcome == OUTCOME_NO_CHANGES
+
+    def test_max_turns_is_partial(self):
+        """Hitting the turn limit is reported as Partial"""
+        result = build_session_result({"subtype": "error_max_turns", "is_error": True}, files_changed=1)
+        assert result.outcome == OUTCOME_PARTIAL
+
+    def test_cost_limit_is_partial(self):
+        """A run wound down by the cost limit is Partial even if it ended cleanly"""
+        result = build_session_result({"subtype": "success", "result": "Summary"}, files_changed=2, cost_limit_reached=True)
+        assert result.outcome == OUTCOME_PARTIAL
+        assert result.summary == "Summary"
+
+    def test_error_is_failed(self):
+        """An errored run is Failed"""
+        result = build_session_result({"subtype": "error_during_execution", "is_error": True})
+        assert result.outcome == OUTCOME_FAILED
+
+    def test_pr_urls_merged_from_text(self):
+        """PR URLs from the result text are merged with the ones the runner opened"""
+        opened = ["https://github.com/org/repo/pull/1"]
+        text = "Opened https://github.com/org/repo/pull/1 and https://gitlab.com/g/p/-/merge_requests/4."
+        result = build_session_result({"subtype": "success", "result": text}, pr_urls=opened)
+        assert result.outcome == OUTCOME_SUCCEEDED
+        assert result.pr_urls == ["https://github.com/org/repo/pull/1", "https://gitlab.com/g/p/-/merge_requests/4"]
+
+
+def test_extract_pr_urls_gitea_and_bitbucket():
+    """Gitea and Bitbucket pull request URLs are recognised"""
+    text = (
+        "https://gitea.example.com/org/repo/pulls/5 "
+        "https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/12/overview "
+        "https://bitbucket.org/ws/repo/pull-requests/3"
+    )
+    assert extract_pr_urls(text) == [
+        "https://gitea.example.com/org/repo/pulls/5",
+        "https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/12",
+        "https://bitbucket.org/ws/repo/pull-requests/3",
+    ]
+
+
+def test_extract_pr_urls_ignores_other_links():
+    """Issue and repository links are not PR URLs"""
+    assert extract_pr_urls("See https://github.com/org/repo/issues/3 and https://github.com/org/repo") == []
+
+
+def test_to_dict_rejects_unknown_outcome():
+    """Outcomes outside the CRD enum are rejected before reaching the backend"""
+    with pytest.raises(ValueError):
+        SessionResult(outcome="Done").to_dict()
+
+
+def test_preview_artifact_reaches_result(tmp_path):
+    """artifacts/preview.json becomes result.preview; an image is required and bad ports are dropped"""
+    assert read_preview_artifact(tmp_path) is None
+    (tmp_path / "artifacts").mkdir()
+    artifact = tmp_path / "artifacts" / "preview.json"
+    artifact.write_text('{"image": "quay.io/team/app:abc", "port": 3000}')
+    preview = read_preview_artifact(tmp_path)
+    assert preview == {"image": "quay.io/team/app:abc", "port": 3000}
+    result = build_session_result({"subtype": "success", "result": "done"}, files_changed=2, preview=preview)
+    assert result.to_dict()["preview"] == preview
+
+    artifact.write_text('{"image": "app:1", "port": 70000}')
+    assert read_preview_artifact(tmp_path) == {"image": "app:1"}
+    artifact.write_text('{"port": 3000}')
+    assert read_preview_artifact(tmp_path) is None
+    artifact.write_text("not json")
+    assert read_preview_artifact(tmp_path) is None
@@ -0,0 +1,51 @@
+"""
+Test cases for session-wide usage accounting.
+"""
+
+from pathlib import Path
+import sys
+
+# Add parent directory to path for importing usage module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from usage import UsageTracker  # type: ignore[import]
+
+
+class TestUsageTracker:
+    """Test suite for UsageTracker"""
+
+    def test_tokens_are_summed_across_results(self):
+        """Per-query token usage adds up, including cache tokens"""
+        tracker = UsageTracker()
+        tracker.record(0.10, {"input_tokens": 100, "output_tokens": 20, "cache_read_input_tokens": 500})
+        tracker.record(0.25, {"input_tokens": 50, "output_tokens": 10, "service_tier": "standard"})
+        assert tracker.total_tokens == 680
+        assert tracker.status_fields()["usage"]["input_tokens"] == 150
+
+    def test_cost_is_a_running_total_per_client(self):
+        """total_cost_usd is cumulative within one client and kept across restarts"""
+        tracker = UsageTracker()
+        tracker.start_client()
+        tracker.record(0.10, None)
+        tracker.record(0.25, None)
+        assert tracker.total_cost_usd == 0.25
+        tracker.start_client()
+        tracker.record(0.05, None)
+        assert abs(tracker.total_cost_usd - 0.30) < 1e-9
+
+    def test_ignores_malformed_values(self):
+        """None and non-numeric values do not break accounting"""
+        tracker = UsageTracker()
+        tracker.record(None, {"input_tokens": "lots"})
+        tracker.record("0.5", "usage")
+        assert tracker.status_fields() == {
+            "total_cost_usd": 0.0,
+            "usage": {
+                "input_tokens": 0,
+                "output_tokens": 0,
+                "cache_creation_input_tokens": 0,
+                "cache_read_input_tokens": 0,
+            },
+        }
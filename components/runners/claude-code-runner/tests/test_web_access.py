@@ -0,0 +1,61 @@
+"""
+Test cases for limiting the agent's web tools to the project's allowed domains.
+"""
+
+from pathlib import Path
+import asyncio
+import sys
+
+# Add parent directory to path for importing web_access module
+wrapper_dir = Path(__file__).parent.parent
+if str(wrapper_dir) not in sys.path:
+    sys.path.insert(0, str(wrapper_dir))
+
+from web_access import (  # type: ignore[import]
+    allowed_domains_from_env,
+    domain_allowed,
+    url_allowed,
+    web_fetch_hook,
+)
+
+DOMAINS = ["docs.python.org", "*.readthedocs.io"]
+
+
+def test_allowed_domains_from_env(monkeypatch):
+    monkeypatch.delenv("WEB_ALLOWED_DOMAINS", raising=False)
+    assert allowed_domains_from_env() == []
+    monkeypatch.setenv("WEB_ALLOWED_DOMAINS", "docs.python.org, *.ReadTheDocs.io,")
+    assert allowed_domains_from_env() == DOMAINS
+
+
+def test_domain_allowed():
+    assert domain_allowed("docs.python.org", DOMAINS)
+    assert domain_allowed("requests.readthedocs.io", DOMAINS)
+    assert not domain_allowed("readthedocs.io", DOMAINS)
+    assert not domain_allowed("notreadthedocs.io", DOMAINS)
+    assert not domain_allowed("python.org", DOMAINS)
+    assert domain_allowed("anything.example.com", [])
+
+
+def test_url_allowed():
+    assert url_allowed("https://docs.python.org/3/library/json.html", DOMAINS)
+    assert url_allowed("http://Docs.Python.org./3/", DOMAINS)
+    assert not url_allowed("https://docs.python.org.evil.com/", DOMAINS)
+    assert not url_allowed("https://user@evil.com/?docs.python.org", DOMAINS)
+    assert not url_allowed("file:///etc/passwd", DOMAINS)
+    assert not url_allowed("not a url", DOMAINS)
+    assert url_allowed("https://example.com", [])
+
+
+def test_web_fetch_hook():
+    hook = web_fetch_hook(DOMAINS)
+
+    def run(tool_name, url):
+        return asyncio.run(hook({"tool_name": tool_name, "tool_input": {"url": url}}, "tool-1", None))
+
+    assert run("WebFetch", "https://docs.python.org/3/") == {}
+    denied = run("WebFetch", "https://pastebin.com/raw/x")
+    assert denied["hookSpecificOutput"]["permissionDecision"] == "deny"
+    assert "docs.python.org" in denied["hookSpecificOutput"]["permissionDecisionReason"]
+    # Other tools are not this hook's business
+    assert run("Read", "https://pastebin.com") == {}
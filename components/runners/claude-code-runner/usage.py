@@ -0,0 +1,54 @@
+"""
+Session-wide usage accounting, reported to status so the operator can enforce spec.costLimit.
+"""
+
+# Token counters summed across results; the operator adds all four for a token limit
+TOKEN_FIELDS = (
+    "input_tokens",
+    "output_tokens",
+    "cache_creation_input_tokens",
+    "cache_read_input_tokens",
+)
+
+
+class UsageTracker:
+    """Accumulates cost and token usage over every SDK client the session starts.
+
+    Each ResultMessage carries the token usage of its own query, but total_cost_usd is the
+    running total of the CLI process, which restarts on workflow or repo changes. Call
+    start_client() before each new client so earlier spend is kept.
+    """
+
+    def __init__(self):
+        self._cost_before_client = 0.0
+        self._client_cost = 0.0
+        self._tokens = {field: 0 for field in TOKEN_FIELDS}
+
+    def start_client(self):
+        self._cost_before_client += self._client_cost
+        self._client_cost = 0.0
+
+    def record(self, total_cost_usd, usage):
+        """Add one ResultMessage's usage. Missing or malformed values are ignored."""
+        if isinstance(total_cost_usd, (int, float)):
+            self._client_cost = max(self._client_cost, float(total_cost_usd))
+        if isinstance(usage, dict):
+            for field in TOKEN_FIELDS:
+                value = usage.get(field)
+                if isinstance(value, (int, float)):
+                    self._tokens[field] += int(value)
+
+    @property
+    def total_cost_usd(self) -> float:
+        return self._cost_before_client + self._client_cost
+
+    @property
+    def total_tokens(self) -> int:
+        return sum(self._tokens.values())
+
+    def status_fields(self) -> dict:
+        """The status.total_cost_usd and status.usage fields for a CR status update."""
+        return {
+            "total_cost_usd": round(self.total_cost_usd, 6),
+            "usage": dict(self._tokens),
+        }
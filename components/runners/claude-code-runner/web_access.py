@@ -0,0 +1,70 @@
+"""
+Limits the agent's web tools to the project's allowed domains.
+
+The operator sets WEB_ALLOWED_DOMAINS from ProjectSettings spec.networkPolicy.allowedDomains
+(comma-separated; "*.example.com" allows the subdomains of example.com). When it is set,
+WebFetch calls to other hosts are denied by a PreToolUse hook and WebSearch is disabled,
+since its results come from arbitrary sites. Matching mirrors AgentNetworkPolicy.DomainAllowed
+in components/pkg/apis/vteam/v1alpha1/networkpolicy.go.
+"""
+
+import os
+from typing import List
+from urllib.parse import urlparse
+
+WEB_FETCH_TOOL = "WebFetch"
+WEB_SEARCH_TOOL = "WebSearch"
+
+
+def allowed_domains_from_env() -> List[str]:
+    """The allowed domains, or [] when web access is unrestricted"""
+    raw = os.getenv("WEB_ALLOWED_DOMAINS") or ""
+    return [d.strip().lower() for d in raw.split(",") if d.strip()]
+
+
+def domain_allowed(host: str, domains: List[str]) -> bool:
+    if not domains:
+        return True
+    host = (host or "").lower().rstrip(".")
+    for d in domains:
+        if d.startswith("*."):
+            suffix = d[1:]
+            if host.endswith(suffix) and len(host) > len(suffix):
+                return True
+        elif host == d:
+            return True
+    return False
+
+
+def url_allowed(url: str, domains: List[str]) -> bool:
+    """Whether the agent may fetch url; only http(s) URLs to an allowed host pass"""
+    if not domains:
+        return True
+    try:
+        parsed = urlparse((url or "").strip())
+        host = parsed.hostname or ""
+    except ValueError:
+        return False
+    return parsed.scheme in ("http", "https") and domain_allowed(host, domains)
+
+
+def web_fetch_hook(domains: List[str]):
+    """A PreToolUse hook denying WebFetch calls outside the allowed domains"""
+
+    async def hook(input_data, tool_use_id, context):
+        if input_data.get("tool_name") != WEB_FETCH_TOOL:
+            return {}
+        url = str((input_data.get("tool_input") or {}).get("url") or "")
+        if url_allowed(url, domains):
+            return {}
+        return {
+            "hookSpecificOutput": {
+                "hookEventName": "PreToolUse",
+                "permissionDecision": "deny",
+                "permissionDecisionReason": (
+                    f"{url} is outside this project's allowed domains: {', '.join(domains)}"
+                ),
+            }
+        }
+
+    return hook
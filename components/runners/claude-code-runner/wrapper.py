@@ -11,6 +11,7 @@ import logging
 import json as _json
 import re
 import shutil
+import tempfile
 from pathlib import Path
 from urllib.parse import urlparse, urlunparse
 from urllib import request as _urllib_request, error as _urllib_error
@@ -22,6 +23,29 @@ from runner_shell.core.shell import RunnerShell
 from runner_shell.core.protocol import MessageType, SessionStatus, PartialInfo
 from runner_shell.core.context import RunnerContext
 
+from session_result import OUTCOME_FAILED, SessionResult, build_session_result, read_preview_artifact
+from artifact_upload import ArtifactUploader, ArtifactUploadError, uploads_url_from_env
+import github_token
+import git_providers
+import credential_check
+import web_access
+import push_policy
+from session_inputs import INPUTS_DIR, SessionInputError, download_inputs, inputs_url_from_env
+from session_agents import SessionAgentError, load_session_agents
+from git_mirror import reference_args
+from usage import UsageTracker
+from rate_limit import RateLimitTracker, is_rate_limit_error
+from progress import PROGRESS_ANNOTATION, ProgressTracker, step_for_tool_use
+from deltas import DeltaTracker
+from context_window import ContextWindow
+
+# Sent once the session's cost limit is reached, in place of further work
+COST_LIMIT_SUMMARY_PROMPT = (
+    "The cost limit for this session has been reached. Stop working now and do not call any more tools. "
+    "Reply with a brief summary of what you accomplished, what remains to be done, and any changes "
+    "that are not yet committed or pushed."
+)
+
 
 class ClaudeCodeAdapter:
     """Adapter that wraps the existing Claude Code CLI for runner-shell."""
@@ -33,13 +57,27 @@ class ClaudeCodeAdapter:
         self._incoming_queue: "asyncio.Queue[dict]" = asyncio.Queue()
         self._restart_requested = False
         self._first_run = True  # Track if this is the first SDK run or a mid-session restart
+        self._pr_urls: list[str] = []  # Pull requests opened by this run, reported in status.result
+        self._usage = UsageTracker()  # Cost and tokens reported to status for spec.costLimit
+        self._rate_limit = RateLimitTracker()  # Provider rate limits the operator schedules on
+        self._cost_limit_message: str | None = None  # Set once the operator reports the limit reached
+        self._active_client = None  # SDK client of the current run, for interrupts from handle_message
+        self._progress = ProgressTracker()  # Steps reported to the operator for status.steps
+        self._github_helper_configured = False  # git reads the mounted App token via a credential helper
 
     async def initialize(self, context: RunnerContext):
         """Initialize the adapter with context."""
         self.context = context
         logging.info(f"Initialized Claude Code adapter for session {context.session_id}")
         # Prepare workspace from input repo if provided
+        self._progress.start("clone")
+        await self._report_progress()
+        # Fail fast on rejected credentials instead of minutes into the run
+        await self._verify_credentials()
+        await self._configure_github_credential_helper()
         await self._prepare_workspace()
+        # Place files the user attached to the session in workspace/inputs
+        await self._download_session_inputs()
         # Initialize workflow if ACTIVE_WORKFLOW env vars are set
         await self._initialize_workflow_if_set()
         # Validate prerequisite files exist for phase-based commands
@@ -69,7 +107,8 @@ class ClaudeCodeAdapter:
                 })
             except Exception as _:
                 logging.debug("CR status update (Running) skipped")
-
+            self._progress.start("analyze")
+            await self._report_progress()
 
             # Append token to websocket URL if available (to pass SA token to backend)
             try:
@@ -106,35 +145,48 @@ class ClaudeCodeAdapter:
                 auto_push = str(self.context.get_env('AUTO_PUSH_ON_COMPLETE', 'false')).strip().lower() in ('1','true','yes')
             except Exception:
                 auto_push = False
+            # Count changes before auto-push commits them
+            files_changed = await self._count_changed_files()
             if auto_push:
+                self._progress.start("publish")
+                await self._report_progress()
                 await self._push_results_if_any()
+            else:
+                self._progress.skip("publish", "auto-push disabled")
+            await self._upload_artifacts()
 
             # CR status update based on result - MUST complete before pod exits
             try:
                 if isinstance(result, dict) and result.get("success"):
                     logging.info(f"Updating CR status to Completed (result.success={result.get('success')})")
-                    result_summary = ""
-                    if isinstance(result.get("result"), dict):
-                        # Prefer subtype and output if present
-                        subtype = result["result"].get("subtype")
-                        if subtype:
-                            result_summary = f"Completed with subtype: {subtype}"
-                    stdout_text = result.get("stdout") or ""
+                    sdk_result = result.get("result") if isinstance(result.get("result"), dict) else None
+                    session_result = build_session_result(
+                        sdk_result,
+                        files_changed=files_changed,
+                        pr_urls=self._pr_urls,
+                        cost_limit_reached=self._cost_limit_message is not None,
+                        preview=read_preview_artifact(Path(self.context.workspace_path)),
+                    )
+                    self._progress.finish(True)
+                    await self._report_progress()
                     # Use BLOCKING call to ensure completion before container exits
                     await self._update_cr_status({
                         "phase": "Completed",
                         "completionTime": self._utc_iso(),
                         "message": "Runner completed",
-                        "subtype": (result.get("result") or {}).get("subtype", "success"),
+                        "subtype": (sdk_result or {}).get("subtype") or "success",
                         "is_error": False,
                         "num_turns": getattr(self, "_turn_count", 0),
                         "session_id": self.context.session_id,
-                        "result": stdout_text[:10000],
+                        "result": session_result.to_dict(),
+                        **self._usage.status_fields(),
                     }, blocking=True)
                     logging.info("CR status update to Completed completed")
                 elif isinstance(result, dict) and not result.get("success"):
                     # Handle failure case (e.g., SDK crashed without ResultMessage)
                     error_msg = result.get("error", "Unknown error")
+                    self._progress.finish(False, error_msg)
+                    await self._report_progress()
                     # Use BLOCKING call to ensure completion before container exits
                     await self._update_cr_status({
                         "phase": "Failed",
@@ -143,6 +195,7 @@ class ClaudeCodeAdapter:
                         "is_error": True,
                         "num_turns": getattr(self, "_turn_count", 0),
                         "session_id": self.context.session_id,
+                        "result": SessionResult(outcome=OUTCOME_FAILED, summary=error_msg).to_dict(),
                     }, blocking=True)
             except Exception as e:
                 logging.error(f"CR status update exception: {e}")
@@ -153,12 +206,15 @@ class ClaudeCodeAdapter:
             logging.error(f"Claude Code adapter failed: {e}")
             # Best-effort CR failure update
             try:
+                self._progress.finish(False, f"Runner failed: {e}")
+                await self._report_progress()
                 await self._update_cr_status({
                     "phase": "Failed",
                     "completionTime": self._utc_iso(),
                     "message": f"Runner failed: {e}",
                     "is_error": True,
                     "session_id": self.context.session_id,
+                    "result": SessionResult(outcome=OUTCOME_FAILED, summary=f"Runner failed: {e}").to_dict(),
                 })
             except Exception:
                 logging.debug("CR status update (Failed) skipped")
@@ -177,10 +233,14 @@ class ClaudeCodeAdapter:
             use_vertex = (
                 self.context.get_env('CLAUDE_CODE_USE_VERTEX', '').strip() == '1'
                 )
+            # Bedrock is selected per project; the operator sets CLAUDE_CODE_USE_BEDROCK=1
+            use_bedrock = (
+                self.context.get_env('CLAUDE_CODE_USE_BEDROCK', '').strip() == '1'
+                )
 
             # Determine which authentication method to use
-            if not api_key and not use_vertex:
-                raise RuntimeError("Either ANTHROPIC_API_KEY or CLAUDE_CODE_USE_VERTEX=1 must be set")
+            if not api_key and not use_vertex and not use_bedrock:
+                raise RuntimeError("Either ANTHROPIC_API_KEY, CLAUDE_CODE_USE_VERTEX=1 or CLAUDE_CODE_USE_BEDROCK=1 must be set")
 
             # Set environment variables BEFORE importing SDK
             # The Anthropic SDK checks these during initialization
@@ -211,6 +271,20 @@ class ClaudeCodeAdapter:
                 logging.info(f"  ANTHROPIC_VERTEX_PROJECT_ID: {os.environ.get('ANTHROPIC_VERTEX_PROJECT_ID')}")
                 logging.info(f"  CLOUD_ML_REGION: {os.environ.get('CLOUD_ML_REGION')}")
 
+            # Configure AWS Bedrock if requested
+            if use_bedrock:
+                region = self.context.get_env('AWS_REGION', '').strip()
+                if not region:
+                    raise RuntimeError("AWS_REGION must be set when CLAUDE_CODE_USE_BEDROCK=1")
+                if 'ANTHROPIC_API_KEY' in os.environ:
+                    logging.info("Clearing ANTHROPIC_API_KEY to force Bedrock mode")
+                    del os.environ['ANTHROPIC_API_KEY']
+                os.environ['CLAUDE_CODE_USE_BEDROCK'] = '1'
+                os.environ['AWS_REGION'] = region
+                logging.info("Bedrock environment configured:")
+                logging.info(f"  AWS_REGION: {region}")
+                logging.info(f"  AWS_ROLE_ARN: {os.environ.get('AWS_ROLE_ARN', '(static credentials)')}")
+
             # NOW we can safely import the SDK with the correct environment set
             from claude_agent_sdk import ClaudeSDKClient, ClaudeAgentOptions
 
@@ -320,6 +394,11 @@ class ClaudeCodeAdapter:
                     cwd_path = self.context.workspace_path
                     logging.info(f"Falling back to workspace root: {cwd_path}")
 
+            # Attached input files are readable from any working directory
+            inputs_path = str(Path(self.context.workspace_path) / INPUTS_DIR)
+            if Path(inputs_path).is_dir() and inputs_path != cwd_path and inputs_path not in add_dirs:
+                add_dirs.append(inputs_path)
+
             # Log working directory and additional directories for debugging
             logging.info(f"Claude SDK CWD: {cwd_path}")
             logging.info(f"Claude SDK additional directories: {add_dirs}")
@@ -334,6 +413,22 @@ class ClaudeCodeAdapter:
                     allowed_tools.append(f"mcp__{server_name}")
                 logging.info(f"MCP tool permissions granted for servers: {list(mcp_servers.keys())}")
 
+            # Project branch protection: a pre-push hook refuses force pushes from any git command
+            disallowed_tools = []
+            if push_policy.forbid_force_push_from_env():
+                push_policy.install(Path(tempfile.gettempdir()) / "ambient-git-hooks")
+                disallowed_tools = list(push_policy.DISALLOWED_BASH)
+
+            # Project network policy: WebFetch only to the allowed domains, no WebSearch
+            web_domains = web_access.allowed_domains_from_env()
+            hooks = None
+            if web_domains:
+                from claude_agent_sdk import HookMatcher
+                allowed_tools = [t for t in allowed_tools if t != web_access.WEB_SEARCH_TOOL]
+                disallowed_tools.append(web_access.WEB_SEARCH_TOOL)
+                hooks = {"PreToolUse": [HookMatcher(matcher=web_access.WEB_FETCH_TOOL, hooks=[web_access.web_fetch_hook(web_domains)])]}
+                logging.info(f"Web access limited to: {', '.join(web_domains)}")
+
             # Build comprehensive workspace context system prompt
             workspace_prompt = self._build_workspace_context_prompt(
                 repos_cfg=repos_cfg,
@@ -341,6 +436,9 @@ class ClaudeCodeAdapter:
                 artifacts_path="artifacts",
                 ambient_config=ambient_config
             )
+            # spec.llmSettings.contextStrategy and maxContextTokens
+            context_window = ContextWindow.from_env(self.context.get_env)
+            workspace_prompt += context_window.system_prompt("artifacts")
             system_prompt_config = {
                 "type": "text",
                 "text": workspace_prompt
@@ -352,10 +450,20 @@ class ClaudeCodeAdapter:
                 cwd=cwd_path,
                 permission_mode="acceptEdits",
                 allowed_tools= allowed_tools,
+                disallowed_tools=disallowed_tools,
                 mcp_servers=mcp_servers,
                 setting_sources=["project"],
                 system_prompt=system_prompt_config
                 )
+            if hooks:
+                options.hooks = hooks  # type: ignore[attr-defined]
+            # Project Agents named in AGENT_PERSONAS, e.g. the RFE council
+            agents = await self._load_session_agents()
+            if agents:
+                from claude_agent_sdk import AgentDefinition
+                options.agents = {name: AgentDefinition(**d) for name, d in agents.items()}  # type: ignore[attr-defined]
+            # Stream assistant text as it is generated; sent to the UI as message.delta frames
+            options.include_partial_messages = True  # type: ignore[attr-defined]
 
             # Use SDK's built-in session resumption if continuing
             # The CLI stores session state in /app/.claude which is now persisted in PVC
@@ -390,6 +498,9 @@ class ClaudeCodeAdapter:
                     if use_vertex:
                         model = self._map_to_vertex_model(model)
                         logging.info(f"Mapped to Vertex AI model: {model}")
+                    elif use_bedrock:
+                        model = self._map_to_bedrock_model(model)
+                        logging.info(f"Mapped to Bedrock model: {model}")
                     options.model = model  # type: ignore[attr-defined]
                 except Exception:
                     pass
@@ -425,6 +536,10 @@ class ClaudeCodeAdapter:
                 ToolUseBlock,
                 ToolResultBlock,
             )
+            try:
+                from claude_agent_sdk import StreamEvent
+            except ImportError:  # SDK without partial message support
+                StreamEvent = None
             # Determine interactive mode once for this run
             interactive = str(self.context.get_env('INTERACTIVE', 'false')).strip().lower() in ('1', 'true', 'yes')
 
@@ -432,7 +547,14 @@ class ClaudeCodeAdapter:
 
             async def process_response_stream(client_obj):
                 nonlocal result_payload, sdk_session_id
+                deltas = DeltaTracker()
                 async for message in client_obj.receive_response():
+                    if StreamEvent is not None and isinstance(message, StreamEvent):
+                        context_window.on_event(getattr(message, 'event', None))
+                        delta = deltas.on_event(getattr(message, 'event', None))
+                        if delta:
+                            await self.shell._send_message(MessageType.MESSAGE_DELTA, delta)
+                        continue
                     logging.info(f"[ClaudeSDKClient]: {message}")
 
                     # Capture SDK session ID from init message
@@ -446,6 +568,8 @@ class ClaudeCodeAdapter:
                             except Exception as e:
                                 logging.warning(f"Failed to store SDK session ID in CR annotations: {e}")
 
+                    if isinstance(message, AssistantMessage):
+                        await self._observe_rate_limit(message)
                     if isinstance(message, (AssistantMessage, UserMessage)):
                         for block in getattr(message, 'content', []) or []:
                             if isinstance(block, TextBlock):
@@ -464,6 +588,9 @@ class ClaudeCodeAdapter:
                                     {"tool": tool_name, "input": tool_input, "id": tool_id},
                                 )
                                 self._turn_count += 1
+                                step = step_for_tool_use(tool_name, tool_input)
+                                if step and self._progress.start(step):
+                                    await self._report_progress()
                             elif isinstance(block, ToolResultBlock):
                                 tool_use_id = getattr(block, 'tool_use_id', None)
                                 content = getattr(block, 'content', None)
@@ -502,6 +629,12 @@ class ClaudeCodeAdapter:
                             "usage": getattr(message, 'usage', None),
                             "result": getattr(message, 'result', None),
                         }
+                        # Report spend after every turn so the operator can enforce spec.costLimit
+                        self._usage.record(result_payload["total_cost_usd"], result_payload["usage"])
+                        # The assistant message of a 429 usually reported it already
+                        if result_payload["is_error"] and not self._rate_limit.limited:
+                            self._rate_limit.observe_error(result_payload["result"])
+                        await self._update_cr_status({**self._usage.status_fields(), **self._rate_limit.status_fields()})
                         if not interactive:
                             await self.shell._send_message(
                                 MessageType.AGENT_MESSAGE,
@@ -509,15 +642,27 @@ class ClaudeCodeAdapter:
                             )
 
             # Use async with - SDK will automatically resume if options.resume is set
+            self._usage.start_client()
             async with ClaudeSDKClient(options=options) as client:
+                self._active_client = client
                 if is_continuation and parent_session_id:
                     await self._send_log("✅ SDK resuming session with full context")
                     logging.info(f"SDK is handling session resumption for {parent_session_id}")
 
                 async def process_one_prompt(text: str):
                     await self.shell._send_message(MessageType.AGENT_RUNNING, {})
-                    await client.query(text)
+                    await client.query(context_window.prepare_prompt(text, "artifacts"))
                     await process_response_stream(client)
+                    # Shrink the conversation between turns once it reached maxContextTokens
+                    command = context_window.command() if interactive else None
+                    if command:
+                        await self._send_log({"level": "system", "message": f"🧹 Context reached {context_window.max_tokens} tokens; applying {context_window.strategy} strategy"})
+                        await client.query(command)
+                        await process_response_stream(client)
+
+                async def wind_down_for_cost_limit():
+                    await self._send_log({"level": "system", "message": f"💰 {self._cost_limit_message}; summarizing and ending the session"})
+                    await process_one_prompt(COST_LIMIT_SUMMARY_PROMPT)
 
                 # Handle startup prompts
                 # Only send startupPrompt from workflow on restart (not first run)
@@ -540,7 +685,9 @@ class ClaudeCodeAdapter:
                 # Mark that first run is complete
                 self._first_run = False
 
-                if interactive:
+                if not interactive and self._cost_limit_message:
+                    await wind_down_for_cost_limit()
+                elif interactive:
                     await self._send_log({"level": "system", "message": "Chat ready"})
                     # Consume incoming user messages until end_session
                     while True:
@@ -556,6 +703,10 @@ class ClaudeCodeAdapter:
                         elif mtype in ('end_session', 'terminate', 'stop'):
                             await self._send_log({"level": "system", "message": "interactive.ended"})
                             break
+                        elif mtype == 'cost_limit_reached':
+                            await wind_down_for_cost_limit()
+                            await self._send_log({"level": "system", "message": "interactive.ended"})
+                            break
                         elif mtype == 'workflow_change':
                             # Handle workflow selection during interactive session
                             git_url = str(payload.get('gitUrl') or '').strip()
@@ -586,6 +737,8 @@ class ClaudeCodeAdapter:
                         else:
                             await self._send_log({"level": "debug", "message": f"ignored.message: {mtype_raw}"})
 
+            self._active_client = None
+
             # Note: All output is streamed via WebSocket, not collected here
             await self._check_pr_intent("")
 
@@ -627,6 +780,99 @@ class ClaudeCodeAdapter:
             logging.info(f"Model mapping: {model} → {mapped}")
         return mapped
 
+    def _map_to_bedrock_model(self, model: str) -> str:
+        """Map Anthropic API model names to Bedrock cross-region inference profile IDs.
+
+        Args:
+            model: Anthropic API model name (e.g., 'claude-sonnet-4-5')
+
+        Returns:
+            Bedrock model ID (e.g., 'us.anthropic.claude-sonnet-4-5-20250929-v1:0')
+        """
+        # Reference: https://docs.aws.amazon.com/bedrock/latest/userguide/inference-profiles-support.html
+        model_map = {
+            'claude-opus-4-1': 'anthropic.claude-opus-4-1-20250805-v1:0',
+            'claude-sonnet-4-5': 'anthropic.claude-sonnet-4-5-20250929-v1:0',
+            'claude-haiku-4-5': 'anthropic.claude-haiku-4-5-20251001-v1:0',
+        }
+        base = model_map.get(model)
+        if not base:
+            return model
+
+        region = os.environ.get('AWS_REGION', '')
+        if region.startswith('eu-'):
+            prefix = 'eu'
+        elif region.startswith('ap-'):
+            prefix = 'apac'
+        else:
+            prefix = 'us'
+        mapped = f"{prefix}.{base}"
+        logging.info(f"Model mapping: {model} → {mapped}")
+        return mapped
+
+    async def _verify_credentials(self):
+        """Check the model and Git credentials with one cheap call each before the agent starts.
+
+        A rejected credential marks the session Failed with the CredentialsVerified condition's
+        reason and raises, so the runner exits before cloning anything.
+        """
+        if not credential_check.enabled():
+            logging.info("Credential pre-flight disabled by CREDENTIAL_PREFLIGHT")
+            return
+        loop = asyncio.get_event_loop()
+        try:
+            if self.context.get_env('CLAUDE_CODE_USE_VERTEX', '').strip() == '1':
+                path = self.context.get_env('GOOGLE_APPLICATION_CREDENTIALS', '').strip()
+                if path:
+                    await loop.run_in_executor(None, credential_check.check_vertex, path)
+            elif self.context.get_env('CLAUDE_CODE_USE_BEDROCK', '').strip() != '1':
+                api_key = self.context.get_env('ANTHROPIC_API_KEY', '').strip()
+                if api_key:
+                    base_url = self.context.get_env('ANTHROPIC_BASE_URL', '').strip()
+                    headers = await loop.run_in_executor(None, credential_check.check_anthropic, api_key, base_url)
+                    self._rate_limit.observe_headers(headers)
+
+            auto_push = str(self.context.get_env('AUTO_PUSH_ON_COMPLETE', 'false')).strip().lower() in ('1', 'true', 'yes')
+            repos = self._get_repos_config()
+            if not repos and os.getenv("INPUT_REPO_URL", "").strip():
+                repos = [{
+                    'input': {'url': os.getenv("INPUT_REPO_URL", "").strip()},
+                    'output': {'url': os.getenv("OUTPUT_REPO_URL", "").strip()},
+                }]
+            targets = credential_check.git_targets(repos, auto_push)
+            token = await self._fetch_github_token() if targets else ""
+            for target in targets if token else []:
+                await loop.run_in_executor(None, credential_check.check_github_token, token, target)
+        except credential_check.CredentialCheckError as e:
+            logging.error(f"Credential pre-flight failed ({e.reason}): {e.message}")
+            await self._send_log(f"❌ {e.message}")
+            self._progress.finish(False, e.message)
+            await self._report_progress()
+            await self._update_cr_status({
+                "phase": "Failed",
+                "completionTime": self._utc_iso(),
+                "message": e.message,
+                "is_error": True,
+                "session_id": self.context.session_id,
+                "result": SessionResult(outcome=OUTCOME_FAILED, summary=e.message).to_dict(),
+                "credentialCheck": {"passed": False, "reason": e.reason, "message": e.message},
+            }, blocking=True)
+            raise RuntimeError(f"Credential pre-flight failed: {e.message}") from e
+        await self._update_cr_status({"credentialCheck": {"passed": True}, **self._rate_limit.status_fields()})
+
+    async def _observe_rate_limit(self, message):
+        """Report as soon as the agent starts or stops being rate limited, so the operator
+        holds or releases new sessions on the same provider credential."""
+        texts = [getattr(b, 'text', '') for b in getattr(message, 'content', []) or []]
+        if getattr(message, 'error', None) == 'rate_limit' or any(is_rate_limit_error(t) for t in texts):
+            changed = self._rate_limit.observe_limited()
+        else:
+            changed = self._rate_limit.recovered()
+        if changed:
+            if self._rate_limit.limited:
+                await self._send_log("⏳ Model provider rate limit reached; new sessions on this credential are held")
+            await self._update_cr_status(self._rate_limit.status_fields())
+
     async def _setup_vertex_credentials(self) -> dict:
         """Set up Google Cloud Vertex AI credentials from service account.
 
@@ -665,7 +911,7 @@ class ClaudeCodeAdapter:
 
     async def _prepare_workspace(self):
         """Clone input repo/branch into workspace and configure git remotes."""
-        token = os.getenv("GITHUB_TOKEN") or await self._fetch_github_token()
+        token = await self._fetch_github_token()
         workspace = Path(self.context.workspace_path)
         workspace.mkdir(parents=True, exist_ok=True)
 
@@ -698,8 +944,8 @@ class ClaudeCodeAdapter:
                         # Clone fresh copy
                         await self._send_log(f"📥 Cloning {name}...")
                         logging.info(f"Cloning {name} from {url} (branch: {branch})")
-                        clone_url = self._url_with_token(url, token) if token else url
-                        await self._run_cmd(["git", "clone", "--branch", branch, "--single-branch", clone_url, str(repo_dir)], cwd=str(workspace))
+                        clone_url = self._url_with_token(url, token)
+                        await self._run_cmd(["git", "clone", "--branch", branch, "--single-branch", *reference_args(url), clone_url, str(repo_dir)], cwd=str(workspace))
                         # Update remote URL to persist token (git strips it from clone URL)
                         await self._run_cmd(["git", "remote", "set-url", "origin", clone_url], cwd=str(repo_dir), ignore_errors=True)
                         logging.info(f"Successfully cloned {name}")
@@ -708,13 +954,13 @@ class ClaudeCodeAdapter:
                         await self._send_log(f"✓ Preserving {name} (continuation)")
                         logging.info(f"Repo {name} exists and reusing workspace - preserving all local changes")
                         # Update remote URL in case credentials changed
-                        await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(url, token) if token else url], cwd=str(repo_dir), ignore_errors=True)
+                        await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(url, token)], cwd=str(repo_dir), ignore_errors=True)
                         # Don't fetch, don't reset - keep all changes!
                     else:
                         # Repo exists but NOT reusing - reset to clean state
                         await self._send_log(f"🔄 Resetting {name} to clean state")
                         logging.info(f"Repo {name} exists but not reusing - resetting to clean state")
-                        await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(url, token) if token else url], cwd=str(repo_dir), ignore_errors=True)
+                        await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(url, token)], cwd=str(repo_dir), ignore_errors=True)
                         await self._run_cmd(["git", "fetch", "origin", branch], cwd=str(repo_dir))
                         await self._run_cmd(["git", "checkout", branch], cwd=str(repo_dir))
                         await self._run_cmd(["git", "reset", "--hard", f"origin/{branch}"], cwd=str(repo_dir))
@@ -731,7 +977,7 @@ class ClaudeCodeAdapter:
                     out = r.get('output') or {}
                     out_url_raw = (out.get('url') or '').strip()
                     if out_url_raw:
-                        out_url = self._url_with_token(out_url_raw, token) if token else out_url_raw
+                        out_url = self._url_with_token(out_url_raw, token)
                         await self._run_cmd(["git", "remote", "remove", "output"], cwd=str(repo_dir), ignore_errors=True)
                         await self._run_cmd(["git", "remote", "add", "output", out_url], cwd=str(repo_dir))
             except Exception as e:
@@ -755,8 +1001,8 @@ class ClaudeCodeAdapter:
                 # Clone fresh copy
                 await self._send_log("📥 Cloning input repository...")
                 logging.info(f"Cloning from {input_repo} (branch: {input_branch})")
-                clone_url = self._url_with_token(input_repo, token) if token else input_repo
-                await self._run_cmd(["git", "clone", "--branch", input_branch, "--single-branch", clone_url, str(workspace)], cwd=str(workspace.parent))
+                clone_url = self._url_with_token(input_repo, token)
+                await self._run_cmd(["git", "clone", "--branch", input_branch, "--single-branch", *reference_args(input_repo), clone_url, str(workspace)], cwd=str(workspace.parent))
                 # Update remote URL to persist token (git strips it from clone URL)
                 await self._run_cmd(["git", "remote", "set-url", "origin", clone_url], cwd=str(workspace), ignore_errors=True)
                 logging.info("Successfully cloned repository")
@@ -764,13 +1010,13 @@ class ClaudeCodeAdapter:
                 # Reusing workspace - preserve local changes from previous session
                 await self._send_log("✓ Preserving workspace (continuation)")
                 logging.info("Workspace exists and reusing - preserving all local changes")
-                await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(input_repo, token) if token else input_repo], cwd=str(workspace), ignore_errors=True)
+                await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(input_repo, token)], cwd=str(workspace), ignore_errors=True)
                 # Don't fetch, don't reset - keep all changes!
             else:
                 # Reset to clean state
                 await self._send_log("🔄 Resetting workspace to clean state")
                 logging.info("Workspace exists but not reusing - resetting to clean state")
-                await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(input_repo, token) if token else input_repo], cwd=str(workspace))
+                await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(input_repo, token)], cwd=str(workspace))
                 await self._run_cmd(["git", "fetch", "origin", input_branch], cwd=str(workspace))
                 await self._run_cmd(["git", "checkout", input_branch], cwd=str(workspace))
                 await self._run_cmd(["git", "reset", "--hard", f"origin/{input_branch}"], cwd=str(workspace))
@@ -785,7 +1031,7 @@ class ClaudeCodeAdapter:
 
             if output_repo:
                 await self._send_log("Configuring output remote...")
-                out_url = self._url_with_token(output_repo, token) if token else output_repo
+                out_url = self._url_with_token(output_repo, token)
                 await self._run_cmd(["git", "remote", "remove", "output"], cwd=str(workspace), ignore_errors=True)
                 await self._run_cmd(["git", "remote", "add", "output", out_url], cwd=str(workspace))
 
@@ -895,7 +1141,7 @@ class ClaudeCodeAdapter:
     async def _clone_workflow_repository(self, git_url: str, branch: str, path: str, workflow_name: str):
         """Clone workflow repository without requesting restart (used during initialization)."""
         workspace = Path(self.context.workspace_path)
-        token = os.getenv("GITHUB_TOKEN") or await self._fetch_github_token()
+        token = await self._fetch_github_token()
         
         workflow_dir = workspace / "workflows" / workflow_name
         temp_clone_dir = workspace / "workflows" / f"{workflow_name}-clone-temp"
@@ -909,8 +1155,8 @@ class ClaudeCodeAdapter:
         # Clone to temporary directory first
         await self._send_log(f"📥 Cloning workflow {workflow_name}...")
         logging.info(f"Cloning workflow from {git_url} (branch: {branch})")
-        clone_url = self._url_with_token(git_url, token) if token else git_url
-        await self._run_cmd(["git", "clone", "--branch", branch, "--single-branch", clone_url, str(temp_clone_dir)], cwd=str(workspace))
+        clone_url = self._url_with_token(git_url, token)
+        await self._run_cmd(["git", "clone", "--branch", branch, "--single-branch", *reference_args(git_url), clone_url, str(temp_clone_dir)], cwd=str(workspace))
         logging.info(f"Successfully cloned workflow to temp directory")
         
         # Extract subdirectory if path is specified
@@ -990,11 +1236,11 @@ class ClaudeCodeAdapter:
             await self._send_log(f"Repository {repo_name} already exists")
             return
         
-        token = os.getenv("GITHUB_TOKEN") or await self._fetch_github_token()
-        clone_url = self._url_with_token(repo_url, token) if token else repo_url
+        token = await self._fetch_github_token()
+        clone_url = self._url_with_token(repo_url, token)
         
         await self._send_log(f"📥 Cloning {repo_name}...")
-        await self._run_cmd(["git", "clone", "--branch", repo_branch, "--single-branch", clone_url, str(repo_dir)], cwd=str(workspace))
+        await self._run_cmd(["git", "clone", "--branch", repo_branch, "--single-branch", *reference_args(repo_url), clone_url, str(repo_dir)], cwd=str(workspace))
         
         # Configure git identity
         user_name = os.getenv("GIT_USER_NAME", "").strip() or "Ambient Code Bot"
@@ -1040,10 +1286,37 @@ class ClaudeCodeAdapter:
         # Request restart to update additional directories
         self._restart_requested = True
 
+    def _check_branch_policy(self, branch: str):
+        """Raise if pushing to branch violates the project's branch protection policy."""
+        import fnmatch
+        normalized = (branch or '').strip()
+        require_pr = os.getenv('GIT_REQUIRE_PR', 'false').strip().lower() == 'true'
+        if require_pr and normalized.lower() in ('main', 'master', 'develop'):
+            raise RuntimeError(f"project policy requires a pull request; direct pushes to '{normalized}' are not allowed")
+        allowed = [p.strip() for p in os.getenv('GIT_ALLOWED_TARGET_BRANCHES', '').split(',') if p.strip()]
+        if allowed and not any(fnmatch.fnmatchcase(normalized, p) for p in allowed):
+            raise RuntimeError(f"branch '{normalized}' is not in the project's allowed target branches")
+
+    async def _count_changed_files(self) -> int:
+        """Count uncommitted file changes across the session's repos (best-effort)."""
+        repos_cfg = self._get_repos_config()
+        if repos_cfg:
+            dirs = [Path(self.context.workspace_path) / (r.get('name') or '').strip() for r in repos_cfg if (r.get('name') or '').strip()]
+        else:
+            dirs = [Path(self.context.workspace_path)]
+        total = 0
+        for d in dirs:
+            try:
+                status = await self._run_cmd(["git", "status", "--porcelain"], cwd=str(d), capture_stdout=True, ignore_errors=True)
+                total += len([line for line in (status or "").splitlines() if line.strip()])
+            except Exception as e:
+                logging.debug(f"Could not count changes in {d}: {e}")
+        return total
+
     async def _push_results_if_any(self):
         """Commit and push changes to output repo/branch if configured."""
         # Get GitHub token once for all repos
-        token = os.getenv("GITHUB_TOKEN") or await self._fetch_github_token()
+        token = await self._fetch_github_token()
         if token:
             logging.info("GitHub token obtained for push operations")
         else:
@@ -1070,11 +1343,12 @@ class ClaudeCodeAdapter:
                         continue
 
                     # Add token to output URL
-                    out_url = self._url_with_token(out_url_raw, token) if token else out_url_raw
+                    out_url = self._url_with_token(out_url_raw, token)
 
                     in_ = r.get('input') or {}
                     in_branch = (in_.get('branch') or '').strip()
                     out_branch = (out.get('branch') or '').strip() or f"sessions/{self.context.session_id}"
+                    self._check_branch_policy(out_branch)
 
                     await self._send_log(f"Pushing changes for {name}...")
                     logging.info(f"Configuring output remote with authentication for {name}")
@@ -1122,6 +1396,7 @@ class ClaudeCodeAdapter:
                         try:
                             pr_url = await self._create_pull_request(upstream_repo=upstream_url, fork_repo=out_url, head_branch=out_branch, base_branch=target_branch)
                             if pr_url:
+                                self._pr_urls.append(pr_url)
                                 await self._send_log({"level": "info", "message": f"Pull request created for {name}: {pr_url}"})
                         except Exception as e:
                             await self._send_log({"level": "error", "message": f"PR creation failed for {name}: {e}"})
@@ -1137,13 +1412,14 @@ class ClaudeCodeAdapter:
             return
 
         # Add token to output URL
-        output_repo = self._url_with_token(output_repo_raw, token) if token else output_repo_raw
+        output_repo = self._url_with_token(output_repo_raw, token)
 
         output_branch = os.getenv("OUTPUT_BRANCH", "").strip() or f"sessions/{self.context.session_id}"
         input_repo = os.getenv("INPUT_REPO_URL", "").strip()
         input_branch = os.getenv("INPUT_BRANCH", "").strip()
         workspace = Path(self.context.workspace_path)
         try:
+            self._check_branch_policy(output_branch)
             status = await self._run_cmd(["git", "status", "--porcelain"], cwd=str(workspace), capture_stdout=True)
             if not status.strip():
                 await self._send_log({"level": "system", "message": "No changes to push."})
@@ -1195,6 +1471,7 @@ class ClaudeCodeAdapter:
                 try:
                     pr_url = await self._create_pull_request(upstream_repo=input_repo or output_repo, fork_repo=output_repo, head_branch=output_branch, base_branch=target_branch)
                     if pr_url:
+                        self._pr_urls.append(pr_url)
                         await self._send_log({"level": "info", "message": f"Pull request created: {pr_url}"})
                 except Exception as e:
                     await self._send_log({"level": "error", "message": f"PR creation failed: {e}"})
@@ -1208,7 +1485,11 @@ class ClaudeCodeAdapter:
         Returns the PR HTML URL on success, or None.
         """
 
-        token = (os.getenv("GITHUB_TOKEN") or await self._fetch_github_token() or "").strip()
+        upstream = git_providers.parse(upstream_repo)
+        if upstream and upstream.provider in (git_providers.GITEA, git_providers.BITBUCKET_SERVER):
+            return await self._create_provider_pull_request(upstream_repo, fork_repo, head_branch, base_branch)
+
+        token = (await self._fetch_github_token() or "").strip()
         if not token:
             raise RuntimeError("Missing token for PR creation")
 
@@ -1261,6 +1542,45 @@ class ClaudeCodeAdapter:
         except Exception:
             return None
 
+    async def _create_provider_pull_request(self, upstream_repo: str, fork_repo: str, head_branch: str, base_branch: str) -> str | None:
+        """Create a Gitea or Bitbucket Server pull request with the provider's token."""
+        upstream, fork = git_providers.parse(upstream_repo), git_providers.parse(fork_repo)
+        if upstream is None or fork is None:
+            raise RuntimeError("Invalid repository URLs for PR creation")
+        token = git_providers.provider_token(upstream_repo)
+        if not token:
+            raise RuntimeError(f"Missing {git_providers.TOKEN_ENV[upstream.provider]} for PR creation")
+
+        url, body = git_providers.pull_request(
+            upstream, fork, head_branch, base_branch,
+            title=f"Changes from session {self.context.session_id[:8]}",
+            body=f"Automated changes from runner session {self.context.session_id}",
+        )
+        req = _urllib_request.Request(url, data=_json.dumps(body).encode("utf-8"), headers={
+            "Accept": "application/json",
+            "Authorization": git_providers.authorization_header(upstream.provider, token),
+            "Content-Type": "application/json",
+            "User-Agent": "vTeam-Runner",
+        }, method="POST")
+
+        loop = asyncio.get_event_loop()
+
+        def _do_req():
+            try:
+                with _urllib_request.urlopen(req, timeout=15) as resp:
+                    return resp.read().decode("utf-8", errors="replace")
+            except _urllib_error.HTTPError as he:
+                err_body = he.read().decode("utf-8", errors="replace")
+                raise RuntimeError(f"{upstream.host} PR create failed: HTTP {he.code}: {err_body}")
+            except Exception as e:
+                raise RuntimeError(str(e))
+
+        resp_text = await loop.run_in_executor(None, _do_req)
+        try:
+            return git_providers.pull_request_url(upstream.provider, _json.loads(resp_text))
+        except Exception:
+            return None
+
     def _parse_owner_repo(self, url: str) -> tuple[str, str, str]:
         """Return (owner, name, host) from various URL formats."""
         s = (url or "").strip()
@@ -1382,6 +1702,60 @@ class ClaudeCodeAdapter:
         except Exception as e:
             logging.error(f"Failed to update annotation: {e}")
 
+    async def _report_progress(self):
+        """Publish the step reports for the operator's status.steps (best-effort)."""
+        try:
+            await self._update_cr_annotation(PROGRESS_ANNOTATION, self._progress.annotation_value())
+        except Exception as e:
+            logging.warning(f"Failed to report progress: {e}")
+
+    async def _download_session_inputs(self):
+        """Download the session's attached input files into workspace/inputs (best-effort)."""
+        url = inputs_url_from_env(self.context.session_id)
+        if not url:
+            return
+        dest = Path(self.context.workspace_path) / INPUTS_DIR
+        try:
+            names = await asyncio.to_thread(
+                download_inputs, url, dest, (os.getenv('BOT_TOKEN') or '').strip()
+            )
+            if names:
+                await self._send_log(f"📎 Attached {len(names)} input file(s) in {INPUTS_DIR}/")
+        except (SessionInputError, OSError, ValueError) as e:
+            logging.warning(f"Session input download failed: {e}")
+
+    async def _load_session_agents(self) -> dict:
+        """Fetch the project Agents the session names in AGENT_PERSONAS (best-effort)."""
+        personas = (os.getenv('AGENT_PERSONAS') or os.getenv('AGENT_PERSONA') or '').strip()
+        url = (os.getenv('RUNNER_CONFIG_URL') or '').strip()
+        if not personas or not url:
+            return {}
+        try:
+            agents = await asyncio.to_thread(load_session_agents, url, (os.getenv('BOT_TOKEN') or '').strip())
+        except (SessionAgentError, OSError, ValueError) as e:
+            logging.warning(f"Loading session agents failed: {e}")
+            return {}
+        if agents:
+            await self._send_log(f"👥 Agents available: {', '.join(sorted(agents))}")
+        return agents
+
+    async def _upload_artifacts(self):
+        """Upload files under workspace/artifacts to the backend with resumable uploads."""
+        artifacts_dir = Path(self.context.workspace_path) / "artifacts"
+        if not artifacts_dir.is_dir() or not any(p.is_file() for p in artifacts_dir.rglob("*")):
+            return
+        url = uploads_url_from_env(self.context.session_id)
+        if not url:
+            logging.warning("BACKEND_API_URL not set; skipping artifact upload")
+            return
+        uploader = ArtifactUploader(url, token=(os.getenv('BOT_TOKEN') or '').strip())
+        try:
+            uploaded = await asyncio.to_thread(uploader.upload_dir, artifacts_dir)
+            await self._send_log(f"Uploaded {len(uploaded)} artifact(s)")
+        except (ArtifactUploadError, OSError) as e:
+            logging.error(f"Artifact upload failed: {e}")
+            await self._send_log(f"⚠️ Artifact upload failed: {e}")
+
     async def _update_cr_status(self, fields: dict, blocking: bool = False):
         """Update CR status. Set blocking=True for critical final updates before container exit."""
         url = self._compute_status_url()
@@ -1504,14 +1878,23 @@ class ClaudeCodeAdapter:
         )
 
     def _url_with_token(self, url: str, token: str) -> str:
+        """url with credentials: token (the GitHub token) for GitHub and unknown hosts, the
+        provider's own token from the integration secret for GitLab, Gitea and Bitbucket"""
+        repo = git_providers.parse(url)
+        provider = repo.provider if repo else ""
+        if provider and provider != git_providers.GITHUB:
+            token = git_providers.provider_token(url)
         if not token or not url.lower().startswith("http"):
             return url
+        # The credential helper supplies the refreshed App token; an embedded one would expire
+        if self._github_helper_configured and github_token.uses_token_file(url):
+            return url
         try:
             parsed = urlparse(url)
             netloc = parsed.netloc
             if "@" in netloc:
                 netloc = netloc.split("@", 1)[1]
-            auth = f"x-access-token:{token}@"
+            auth = git_providers.url_userinfo(provider, token) + "@"
             new_netloc = auth + netloc
             return urlunparse((parsed.scheme, new_netloc, parsed.path, parsed.params, parsed.query, parsed.fragment))
         except Exception:
@@ -1525,6 +1908,8 @@ class ClaudeCodeAdapter:
         text = re.sub(r'gh[pousr]_[a-zA-Z0-9]{36,255}', 'gh*_***REDACTED***', text)
         # Redact x-access-token: patterns in URLs
         text = re.sub(r'x-access-token:[^@\s]+@', 'x-access-token:***REDACTED***@', text)
+        # Redact Bitbucket access tokens in URLs
+        text = re.sub(r'x-token-auth:[^@\s]+@', 'x-token-auth:***REDACTED***@', text)
         # Redact oauth tokens in URLs
         text = re.sub(r'oauth2:[^@\s]+@', 'oauth2:***REDACTED***@', text)
         # Redact basic auth credentials
@@ -1601,8 +1986,26 @@ class ClaudeCodeAdapter:
             logging.error(f"Failed to parse SDK session ID: {e}")
             return ""
 
+    async def _configure_github_credential_helper(self):
+        """Let git read the mounted, refreshed GitHub App token (see github_token.py)."""
+        path = github_token.token_file_path()
+        if not path or not github_token.read_token_file():
+            return
+        for host in github_token.TOKEN_FILE_HOSTS:
+            await self._run_cmd(
+                ["git", "config", "--global", f"credential.https://{host}.helper", github_token.credential_helper(path)],
+                ignore_errors=True,
+            )
+        self._github_helper_configured = True
+        logging.info("Using the short-lived GitHub App token mounted by the operator")
+
     async def _fetch_github_token(self) -> str:
-        # Try cached value from env first (GITHUB_TOKEN from ambient-non-vertex-integrations)
+        # Short-lived App token mounted by the operator and refreshed by the backend
+        mounted = github_token.read_token_file()
+        if mounted:
+            return mounted
+
+        # Try cached value from env next (GITHUB_TOKEN from ambient-non-vertex-integrations)
         cached = os.getenv("GITHUB_TOKEN", "").strip()
         if cached:
             logging.info("Using GITHUB_TOKEN from environment")
@@ -1699,6 +2102,10 @@ class ClaudeCodeAdapter:
         """Handle incoming messages from backend."""
         msg_type = message.get('type', '')
 
+        if msg_type == 'cost_limit_reached':
+            await self._handle_cost_limit_reached(message)
+            return
+
         # Queue interactive messages for processing loop
         if msg_type in ('user_message', 'interrupt', 'end_session', 'terminate', 'stop', 'workflow_change', 'repo_added', 'repo_removed'):
             await self._incoming_queue.put(message)
@@ -1707,6 +2114,21 @@ class ClaudeCodeAdapter:
 
         logging.debug(f"Claude Code adapter received message: {msg_type}")
 
+    async def _handle_cost_limit_reached(self, message: dict):
+        """Stop the current turn once spec.costLimit is reached; the run loop then asks for a summary."""
+        if self._cost_limit_message:
+            return
+        payload = message.get('payload') or {}
+        self._cost_limit_message = str(payload.get('message') or 'Session cost limit reached')
+        logging.info(f"Cost limit reached: {self._cost_limit_message}")
+        # The interactive loop only reads the queue between turns, so interrupt here
+        if self._active_client is not None:
+            try:
+                await self._active_client.interrupt()  # type: ignore[attr-defined]
+            except Exception as e:
+                logging.warning(f"Failed to interrupt for cost limit: {e}")
+        await self._incoming_queue.put(message)
+
     def _build_workspace_context_prompt(self, repos_cfg, workflow_name, artifacts_path, ambient_config):
         """Generate comprehensive system prompt describing workspace layout."""
         
@@ -1724,6 +2146,13 @@ class ClaudeCodeAdapter:
         prompt += "Purpose: Create all output artifacts (documents, specs, reports) here.\n"
         prompt += "This directory persists across workflows and has its own git remote.\n\n"
         
+        # Files the user attached to the session
+        inputs_dir = Path(self.context.workspace_path) / INPUTS_DIR
+        if inputs_dir.is_dir() and any(p.is_file() for p in inputs_dir.iterdir()):
+            prompt += "## Input Files\n"
+            prompt += f"Location: {INPUTS_DIR}/ (in the workspace root)\n"
+            prompt += "The user attached these files as context for this session. Read them before starting; do not modify them.\n\n"
+
         # Available repos
         if repos_cfg:
             prompt += "## Available Code Repositories\n"
@@ -1733,6 +2162,25 @@ class ClaudeCodeAdapter:
             prompt += "\nThese repositories contain source code you can read or modify.\n"
             prompt += "Each has its own git configuration and remote.\n\n"
         
+        # Session group: other agents edit the same checkout concurrently
+        group = (os.getenv('SESSION_GROUP') or '').strip()
+        if group:
+            session = os.getenv('AGENTIC_SESSION_NAME', '')
+            locks_url = f"$BACKEND_API_URL/projects/$AGENTIC_SESSION_NAMESPACE/session-groups/{group}/locks"
+            prompt += "## Shared Workspace\n"
+            prompt += f"This workspace is shared with the other sessions of session group '{group}', which may be editing it right now.\n"
+            prompt += "Before changing files, take the advisory lock on the file or directory (paths are relative to the workspace root, '.' is everything):\n"
+            prompt += f"  curl -s -X POST {locks_url} -H \"Authorization: Bearer $BOT_TOKEN\" -H 'Content-Type: application/json' -d '{{\"session\": \"{session}\", \"path\": \"<path>\"}}'\n"
+            prompt += "A 409 response means another session holds it; work on something else and retry later. Locks expire after 5 minutes unless taken again.\n"
+            prompt += f"Release the lock when done: curl -s -X DELETE \"{locks_url}?session={session}&path=<path>\" -H \"Authorization: Bearer $BOT_TOKEN\"\n"
+            prompt += "Pull or rebase before committing, since other sessions may have committed in the same repositories.\n\n"
+
+        web_domains = web_access.allowed_domains_from_env()
+        if web_domains:
+            prompt += "## Web Access\n"
+            prompt += f"WebFetch may only reach these domains: {', '.join(web_domains)}. WebSearch is disabled.\n"
+            prompt += "Look for documentation on these sites; requests to other hosts are denied.\n\n"
+
         # Workflow-specific instructions
         if ambient_config.get("systemPrompt"):
             prompt += f"## Workflow Instructions\n{ambient_config['systemPrompt']}\n\n"
@@ -14,6 +14,7 @@ class MessageType(str, Enum):
     AGENT_MESSAGE = "agent.message"
     USER_MESSAGE = "user.message"
     MESSAGE_PARTIAL = "message.partial"
+    MESSAGE_DELTA = "message.delta"
     AGENT_RUNNING = "agent.running"
     WAITING_FOR_INPUT = "agent.waiting"
 
@@ -294,13 +294,13 @@ build_and_deploy() {
   
   # Start builds
   log "Building backend image..."
-  oc start-build vteam-backend --from-dir="$BACKEND_DIR" --wait -n "$PROJECT_NAME"
+  oc start-build vteam-backend --from-dir="${REPO_ROOT}/components" --wait -n "$PROJECT_NAME"
   
   log "Building frontend image..."  
   oc start-build vteam-frontend --from-dir="$FRONTEND_DIR" --wait -n "$PROJECT_NAME"
   
   log "Building operator image..."
-  oc start-build vteam-operator --from-dir="$OPERATOR_DIR" --wait -n "$PROJECT_NAME"
+  oc start-build vteam-operator --from-dir="${REPO_ROOT}/components" --wait -n "$PROJECT_NAME"
   
   # Deploy services
   log "Creating backend PVC..."